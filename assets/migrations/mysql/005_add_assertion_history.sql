@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE assertion_history (
+    store CHAR(26) NOT NULL,
+    authorization_model_id CHAR(26) NOT NULL,
+    version INT NOT NULL,
+    assertions BLOB,
+    inserted_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (store, authorization_model_id, version)
+);
+
+INSERT INTO assertion_history (store, authorization_model_id, version, assertions, inserted_at)
+SELECT store, authorization_model_id, 1, assertions, NOW() FROM assertion;
+
+-- +goose Down
+DROP TABLE assertion_history;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE authorization_model_label (
+    store CHAR(26) NOT NULL,
+    authorization_model_id CHAR(26) NOT NULL,
+    label_key VARCHAR(63) NOT NULL,
+    label_value VARCHAR(255) NOT NULL,
+    PRIMARY KEY (store, authorization_model_id, label_key)
+);
+
+CREATE INDEX idx_authorization_model_label ON authorization_model_label (store, label_key, label_value, authorization_model_id);
+
+-- +goose Down
+DROP TABLE authorization_model_label;
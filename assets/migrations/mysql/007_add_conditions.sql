@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE tuple ADD COLUMN condition_name VARCHAR(256), ADD COLUMN condition_context LONGBLOB;
+
+CREATE TABLE authorization_model_conditions (
+    store CHAR(26) NOT NULL,
+    authorization_model_id CHAR(26) NOT NULL,
+    conditions LONGBLOB NOT NULL,
+    PRIMARY KEY (store, authorization_model_id)
+);
+
+-- +goose Down
+DROP TABLE authorization_model_conditions;
+
+ALTER TABLE tuple DROP COLUMN condition_name, DROP COLUMN condition_context;
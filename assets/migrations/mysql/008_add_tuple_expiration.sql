@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE tuple ADD COLUMN expires_at DATETIME(6);
+
+CREATE INDEX idx_tuple_expires_at ON tuple (expires_at);
+
+-- +goose Down
+DROP INDEX idx_tuple_expires_at ON tuple;
+
+ALTER TABLE tuple DROP COLUMN expires_at;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE assertion_history (
+	store TEXT NOT NULL,
+	authorization_model_id TEXT NOT NULL,
+	version INTEGER NOT NULL,
+	assertions BYTEA,
+	inserted_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (store, authorization_model_id, version)
+);
+
+INSERT INTO assertion_history (store, authorization_model_id, version, assertions, inserted_at)
+SELECT store, authorization_model_id, 1, assertions, NOW() FROM assertion;
+
+-- +goose Down
+DROP TABLE assertion_history;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE authorization_model_label (
+	store TEXT NOT NULL,
+	authorization_model_id TEXT NOT NULL,
+	label_key TEXT NOT NULL,
+	label_value TEXT NOT NULL,
+	PRIMARY KEY (store, authorization_model_id, label_key)
+);
+
+CREATE INDEX idx_authorization_model_label ON authorization_model_label (store, label_key, label_value, authorization_model_id);
+
+-- +goose Down
+DROP TABLE authorization_model_label;
@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE tuple ADD COLUMN condition_name TEXT, ADD COLUMN condition_context BYTEA;
+
+CREATE TABLE authorization_model_conditions (
+	store TEXT NOT NULL,
+	authorization_model_id TEXT NOT NULL,
+	conditions BYTEA NOT NULL,
+	PRIMARY KEY (store, authorization_model_id)
+);
+
+-- +goose Down
+DROP TABLE authorization_model_conditions;
+
+ALTER TABLE tuple DROP COLUMN condition_name, DROP COLUMN condition_context;
@@ -0,0 +1,81 @@
+-- +goose Up
+CREATE TABLE tuple (
+	store TEXT NOT NULL,
+	object_type TEXT NOT NULL,
+	object_id TEXT NOT NULL,
+	relation TEXT NOT NULL,
+	_user TEXT NOT NULL,
+	user_type TEXT NOT NULL,
+	ulid TEXT NOT NULL,
+	inserted_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (store, object_type, object_id, relation, _user)
+);
+
+CREATE UNIQUE INDEX idx_tuple_ulid ON tuple (ulid);
+CREATE INDEX idx_reverse_lookup_user ON tuple (store, object_type, relation, _user);
+CREATE INDEX idx_tuple_user_type ON tuple (store, object_type, object_id, relation, user_type);
+
+CREATE TABLE authorization_model (
+	store TEXT NOT NULL,
+	authorization_model_id TEXT NOT NULL,
+	schema_version TEXT NOT NULL DEFAULT '1.1',
+	type TEXT NOT NULL,
+	type_definition BLOB,
+	serialized_protobuf BLOB,
+	PRIMARY KEY (store, authorization_model_id, type)
+);
+
+CREATE TABLE store (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP,
+	deleted_at TIMESTAMP
+);
+
+CREATE TABLE assertion (
+	store TEXT NOT NULL,
+	authorization_model_id TEXT NOT NULL,
+	assertions BLOB,
+	PRIMARY KEY (store, authorization_model_id)
+);
+
+CREATE TABLE assertion_history (
+	store TEXT NOT NULL,
+	authorization_model_id TEXT NOT NULL,
+	version INTEGER NOT NULL,
+	assertions BLOB,
+	inserted_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (store, authorization_model_id, version)
+);
+
+CREATE TABLE authorization_model_label (
+	store TEXT NOT NULL,
+	authorization_model_id TEXT NOT NULL,
+	label_key TEXT NOT NULL,
+	label_value TEXT NOT NULL,
+	PRIMARY KEY (store, authorization_model_id, label_key)
+);
+
+CREATE INDEX idx_authorization_model_label ON authorization_model_label (store, label_key, label_value, authorization_model_id);
+
+CREATE TABLE changelog (
+	store TEXT NOT NULL,
+	object_type TEXT NOT NULL,
+	object_id TEXT NOT NULL,
+	relation TEXT NOT NULL,
+	_user TEXT NOT NULL,
+	operation INTEGER NOT NULL,
+	ulid TEXT NOT NULL,
+	inserted_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (store, ulid, object_type)
+);
+
+-- +goose Down
+DROP TABLE tuple;
+DROP TABLE authorization_model;
+DROP TABLE store;
+DROP TABLE assertion;
+DROP TABLE assertion_history;
+DROP TABLE authorization_model_label;
+DROP TABLE changelog;
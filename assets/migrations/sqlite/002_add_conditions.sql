@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE tuple ADD COLUMN condition_name TEXT;
+ALTER TABLE tuple ADD COLUMN condition_context BLOB;
+
+CREATE TABLE authorization_model_conditions (
+	store TEXT NOT NULL,
+	authorization_model_id TEXT NOT NULL,
+	conditions BLOB NOT NULL,
+	PRIMARY KEY (store, authorization_model_id)
+);
+
+-- +goose Down
+DROP TABLE authorization_model_conditions;
+
+ALTER TABLE tuple DROP COLUMN condition_name;
+ALTER TABLE tuple DROP COLUMN condition_context;
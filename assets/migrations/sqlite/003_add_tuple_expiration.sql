@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE tuple ADD COLUMN expires_at TIMESTAMP;
+
+CREATE INDEX idx_tuple_expires_at ON tuple (expires_at) WHERE expires_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX idx_tuple_expires_at;
+
+ALTER TABLE tuple DROP COLUMN expires_at;
@@ -0,0 +1,120 @@
+// Package assertionscoverage contains the command to report the assertions coverage of authorization models.
+package assertionscoverage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/server/commands"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/mysql"
+	"github.com/openfga/openfga/pkg/storage/postgres"
+	"github.com/openfga/openfga/pkg/storage/sqlcommon"
+	"github.com/openfga/openfga/pkg/storage/sqlite"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	datastoreEngineFlag = "datastore-engine"
+	datastoreURIFlag    = "datastore-uri"
+	storeIDFlag         = "store-id"
+	modelIDFlag         = "model-id"
+	minCoverageFlag     = "min-coverage"
+)
+
+// ErrInsufficientCoverage is returned when the assertions coverage of a model is below the minimum.
+var ErrInsufficientCoverage = errors.New("assertions coverage is below the minimum")
+
+func NewAssertionsCoverageCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "assertions-coverage",
+		Short: "Report which relations of an authorization model are not covered by assertions.",
+		Long:  "Map the assertions of an authorization model onto the model and report the relations that have no assertions.\nThe command fails if the ratio of covered relations is below --min-coverage, so it can be used in CI.",
+		RunE:  runAssertionsCoverage,
+		Args:  cobra.NoArgs,
+	}
+
+	flags := cmd.Flags()
+	flags.String(datastoreEngineFlag, "", "the datastore engine")
+	flags.String(datastoreURIFlag, "", "the connection uri to the datastore")
+	flags.String(storeIDFlag, "", "the id of the store")
+	flags.String(modelIDFlag, "", "the id of the authorization model (defaults to the latest model of the store)")
+	flags.Float64(minCoverageFlag, 0, "the minimum ratio (between 0 and 1) of relations that must be covered by assertions")
+
+	// NOTE: if you add a new flag here, update the function below, too
+
+	cmd.PreRun = bindRunFlagsFunc(flags)
+
+	return cmd
+}
+
+func runAssertionsCoverage(_ *cobra.Command, _ []string) error {
+	engine := viper.GetString(datastoreEngineFlag)
+	uri := viper.GetString(datastoreURIFlag)
+	storeID := viper.GetString(storeIDFlag)
+	modelID := viper.GetString(modelIDFlag)
+	minCoverage := viper.GetFloat64(minCoverageFlag)
+
+	if storeID == "" {
+		return fmt.Errorf("missing store id")
+	}
+
+	ctx := context.Background()
+
+	var (
+		db  storage.OpenFGADatastore
+		err error
+	)
+	switch engine {
+	case "mysql":
+		db, err = mysql.New(uri, sqlcommon.NewConfig())
+	case "postgres":
+		db, err = postgres.New(uri, sqlcommon.NewConfig())
+	case "sqlite":
+		db, err = sqlite.New(uri, sqlcommon.NewConfig())
+	case "":
+		return fmt.Errorf("missing datastore engine type")
+	case "memory":
+		fallthrough
+	default:
+		return fmt.Errorf("storage engine '%s' is unsupported", engine)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to open a connection to the datastore: %v", err)
+	}
+	defer db.Close()
+
+	report, err := AssertionsCoverage(ctx, db, storeID, modelID)
+	if err != nil {
+		return err
+	}
+
+	marshalled, err := json.MarshalIndent(report, " ", "    ")
+	if err != nil {
+		return fmt.Errorf("error gathering assertions coverage: %w", err)
+	}
+	fmt.Println(string(marshalled))
+
+	if report.Coverage < minCoverage {
+		return fmt.Errorf("%w: %.2f < %.2f", ErrInsufficientCoverage, report.Coverage, minCoverage)
+	}
+
+	return nil
+}
+
+// AssertionsCoverage reports the assertions coverage of the given authorization model, or of the latest
+// authorization model of the store if modelID is empty.
+func AssertionsCoverage(ctx context.Context, db storage.OpenFGADatastore, storeID, modelID string) (*commands.AssertionsCoverageResponse, error) {
+	typesys, err := typesystem.MemoizedTypesystemResolverFunc(db)(ctx, storeID, modelID)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the authorization model: %w", err)
+	}
+
+	return commands.NewAssertionsCoverageQuery(db, logger.NewNoopLogger()).Execute(ctx, storeID, typesys)
+}
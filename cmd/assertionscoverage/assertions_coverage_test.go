@@ -0,0 +1,51 @@
+package assertionscoverage
+
+import (
+	"context"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertionsCoverageOfLatestModel(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	defer ds.Close()
+
+	storeID := ulid.Make().String()
+
+	_, err := AssertionsCoverage(ctx, ds, storeID, "")
+	require.ErrorIs(t, err, typesystem.ErrModelNotFound)
+
+	modelID := ulid.Make().String()
+	err = ds.WriteAuthorizationModel(ctx, storeID, &openfgav1.AuthorizationModel{
+		Id:            modelID,
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type document
+		  relations
+			define editor: [user] as self
+			define viewer: [user] as self
+		`),
+	})
+	require.NoError(t, err)
+
+	err = ds.WriteAssertions(ctx, storeID, modelID, []*openfgav1.Assertion{
+		{TupleKey: tuple.NewTupleKey("document:1", "viewer", "user:anne"), Expectation: true},
+	})
+	require.NoError(t, err)
+
+	report, err := AssertionsCoverage(ctx, ds, storeID, "")
+	require.NoError(t, err)
+	require.Equal(t, modelID, report.AuthorizationModelID)
+	require.Equal(t, []string{"document#editor"}, report.UncoveredRelations)
+	require.InDelta(t, 0.5, report.Coverage, 0.0001)
+}
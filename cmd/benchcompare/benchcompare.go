@@ -0,0 +1,106 @@
+// Package benchcompare contains the command to compare the results of the benchmarks of two branches, e.g. to
+// detect the regressions of the latency of Check and ListObjects.
+package benchcompare
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/openfga/openfga/internal/benchcompare"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	baseFlag      = "base"
+	headFlag      = "head"
+	thresholdFlag = "threshold"
+)
+
+// ErrRegressions is returned when benchmarks of the head regressed against the base.
+var ErrRegressions = errors.New("the benchmarks regressed")
+
+func NewBenchCompareCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "benchcompare",
+		Short: "Compare the results of the benchmarks of two branches.",
+		Long:  "Parse the output of 'go test -bench' of a base and a head branch, e.g. of 'make bench-fixtures', and report the benchmarks of the head slower than the base by more than --threshold, as JSON. The command fails if any benchmark regressed.\nWith --head only, the results of the head are reported as JSON.",
+		RunE:  runBenchCompare,
+		Args:  cobra.NoArgs,
+	}
+
+	flags := cmd.Flags()
+	flags.String(baseFlag, "", "the file of the output of the benchmarks of the base")
+	flags.String(headFlag, "", "the file of the output of the benchmarks of the head")
+	flags.Float64(thresholdFlag, 0.1, "the relative change of the latency above which a benchmark regressed, e.g. 0.1 for 10%")
+
+	// NOTE: if you add a new flag here, update the function below, too
+
+	cmd.PreRun = bindRunFlagsFunc(flags)
+
+	return cmd
+}
+
+func runBenchCompare(_ *cobra.Command, _ []string) error {
+	return benchCompare(os.Stdout, viper.GetString(baseFlag), viper.GetString(headFlag), viper.GetFloat64(thresholdFlag))
+}
+
+func benchCompare(w io.Writer, basePath, headPath string, threshold float64) error {
+	if headPath == "" {
+		return fmt.Errorf("missing results of the head")
+	}
+
+	if threshold < 0 {
+		return fmt.Errorf("the threshold must not be negative")
+	}
+
+	head, err := parseFile(headPath)
+	if err != nil {
+		return err
+	}
+
+	var (
+		output interface{} = head
+		report *benchcompare.Report
+	)
+	if basePath != "" {
+		base, err := parseFile(basePath)
+		if err != nil {
+			return err
+		}
+
+		report = benchcompare.Compare(base, head, threshold)
+		output = report
+	}
+
+	marshalled, err := json.MarshalIndent(output, " ", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal the results: %w", err)
+	}
+
+	fmt.Fprintln(w, string(marshalled))
+
+	if report != nil && report.Regressions > 0 {
+		return fmt.Errorf("%w: %d of %d benchmarks", ErrRegressions, report.Regressions, len(report.Comparisons))
+	}
+
+	return nil
+}
+
+func parseFile(path string) ([]benchcompare.Result, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the results: %w", err)
+	}
+	defer file.Close()
+
+	results, err := benchcompare.Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the results of '%s': %w", path, err)
+	}
+
+	return results, nil
+}
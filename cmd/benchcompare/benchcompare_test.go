@@ -0,0 +1,63 @@
+package benchcompare
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openfga/openfga/internal/benchcompare"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBenchCompare(t *testing.T) {
+	dir := t.TempDir()
+
+	writeResults := func(name, output string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(output), 0o600))
+		return path
+	}
+
+	base := writeResults("base.txt", "BenchmarkCheck/flat-8 1000 1000 ns/op\nBenchmarkCheck/intersection-8 1000 1000 ns/op\n")
+	faster := writeResults("faster.txt", "BenchmarkCheck/flat-8 1000 900 ns/op\nBenchmarkCheck/intersection-8 1000 1050 ns/op\n")
+	slower := writeResults("slower.txt", "BenchmarkCheck/flat-8 1000 900 ns/op\nBenchmarkCheck/intersection-8 1000 2000 ns/op\n")
+
+	t.Run("head_only", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, benchCompare(&out, "", base, 0.1))
+
+		var results []benchcompare.Result
+		require.NoError(t, json.Unmarshal(out.Bytes(), &results))
+		require.Len(t, results, 2)
+	})
+
+	t.Run("no_regression", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, benchCompare(&out, base, faster, 0.1))
+
+		var report benchcompare.Report
+		require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+		require.Len(t, report.Comparisons, 2)
+		require.Zero(t, report.Regressions)
+	})
+
+	t.Run("regression", func(t *testing.T) {
+		var out bytes.Buffer
+		err := benchCompare(&out, base, slower, 0.1)
+		require.ErrorIs(t, err, ErrRegressions)
+
+		var report benchcompare.Report
+		require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+		require.Equal(t, 1, report.Regressions)
+		require.True(t, report.Comparisons[1].Regression)
+	})
+
+	t.Run("invalid_input", func(t *testing.T) {
+		var out bytes.Buffer
+		require.Error(t, benchCompare(&out, "", "", 0.1))
+		require.Error(t, benchCompare(&out, base, filepath.Join(dir, "missing.txt"), 0.1))
+		require.Error(t, benchCompare(&out, base, faster, -1))
+	})
+}
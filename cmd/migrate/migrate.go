@@ -15,6 +15,7 @@ import (
 	"github.com/pressly/goose/v3"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	_ "modernc.org/sqlite"
 )
 
 const (
@@ -72,6 +73,10 @@ func runMigration(_ *cobra.Command, _ []string) error {
 		driver = "pgx"
 		dialect = "postgres"
 		migrationsPath = assets.PostgresMigrationDir
+	case "sqlite":
+		driver = "sqlite"
+		dialect = "sqlite3"
+		migrationsPath = assets.SQLiteMigrationDir
 	case "":
 		return fmt.Errorf("missing datastore engine type")
 	default:
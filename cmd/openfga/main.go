@@ -5,8 +5,14 @@ import (
 	"os"
 
 	"github.com/openfga/openfga/cmd"
+	"github.com/openfga/openfga/cmd/assertionscoverage"
+	"github.com/openfga/openfga/cmd/benchcompare"
 	"github.com/openfga/openfga/cmd/migrate"
+	"github.com/openfga/openfga/cmd/replay"
 	"github.com/openfga/openfga/cmd/run"
+	"github.com/openfga/openfga/cmd/soak"
+	"github.com/openfga/openfga/cmd/storefile"
+	"github.com/openfga/openfga/cmd/unusedtuples"
 	"github.com/openfga/openfga/cmd/validatemodels"
 )
 
@@ -22,6 +28,24 @@ func main() {
 	validateModelsCmd := validatemodels.NewValidateCommand()
 	rootCmd.AddCommand(validateModelsCmd)
 
+	assertionsCoverageCmd := assertionscoverage.NewAssertionsCoverageCommand()
+	rootCmd.AddCommand(assertionsCoverageCmd)
+
+	replayCmd := replay.NewReplayCommand()
+	rootCmd.AddCommand(replayCmd)
+
+	storeCmd := storefile.NewStoreCommand()
+	rootCmd.AddCommand(storeCmd)
+
+	unusedTuplesCmd := unusedtuples.NewUnusedTuplesCommand()
+	rootCmd.AddCommand(unusedTuplesCmd)
+
+	benchCompareCmd := benchcompare.NewBenchCompareCommand()
+	rootCmd.AddCommand(benchCompareCmd)
+
+	soakCmd := soak.NewSoakCommand()
+	rootCmd.AddCommand(soakCmd)
+
 	versionCmd := cmd.NewVersionCommand()
 	rootCmd.AddCommand(versionCmd)
 
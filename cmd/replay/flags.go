@@ -0,0 +1,17 @@
+package replay
+
+import (
+	"github.com/openfga/openfga/cmd/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// bindRunFlags binds the cobra cmd flags to the equivalent config value being managed
+// by viper. This bridges the config between cobra flags and viper flags.
+func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		util.MustBindPFlag(capturesFlag, flags.Lookup(capturesFlag))
+		util.MustBindPFlag(snapshotFlag, flags.Lookup(snapshotFlag))
+		util.MustBindPFlag(redactionKeyFlag, flags.Lookup(redactionKeyFlag))
+	}
+}
@@ -0,0 +1,120 @@
+// Package replay contains the command to replay the captured requests against a snapshot of the stores.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/openfga/openfga/pkg/capture"
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	capturesFlag     = "captures"
+	snapshotFlag     = "snapshot"
+	redactionKeyFlag = "redaction-key"
+)
+
+// ErrMismatches is returned when the result of some of the replayed requests differs from the recorded one.
+var ErrMismatches = errors.New("the results of some of the replayed requests differ from the recorded ones")
+
+func NewReplayCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay",
+		Short: "Replay the captured requests against a snapshot of the stores.",
+		Long:  "Re-execute the Check and ListObjects requests captured by a server (see --capture-enabled) against a snapshot of the stores of the memory datastore, and report the requests whose result differs from the recorded one along with the recorded and replayed durations.\nThe command fails if any result differs, so it can be used to validate a change of the resolution.",
+		RunE:  runReplay,
+		Args:  cobra.NoArgs,
+	}
+
+	flags := cmd.Flags()
+	flags.String(capturesFlag, "", "the path of the file of the captured requests")
+	flags.String(snapshotFlag, "", "the path of the JSON snapshot of the stores to replay the requests against")
+	flags.String(redactionKeyFlag, "", "the key the captured requests were redacted with, to redact the snapshot with it too")
+
+	// NOTE: if you add a new flag here, update the function below, too
+
+	cmd.PreRun = bindRunFlagsFunc(flags)
+
+	return cmd
+}
+
+func runReplay(_ *cobra.Command, _ []string) error {
+	capturesPath := viper.GetString(capturesFlag)
+	snapshotPath := viper.GetString(snapshotFlag)
+	redactionKey := viper.GetString(redactionKeyFlag)
+
+	if capturesPath == "" {
+		return fmt.Errorf("missing captures file")
+	}
+
+	if snapshotPath == "" {
+		return fmt.Errorf("missing snapshot file")
+	}
+
+	capturesFile, err := os.Open(capturesPath)
+	if err != nil {
+		return fmt.Errorf("failed to open the captures file: %w", err)
+	}
+	defer capturesFile.Close()
+
+	records, err := capture.ReadRecords(capturesFile)
+	if err != nil {
+		return fmt.Errorf("failed to read the captures file: %w", err)
+	}
+
+	snapshotFile, err := os.ReadFile(snapshotPath)
+	if err != nil {
+		return fmt.Errorf("failed to read the snapshot file: %w", err)
+	}
+
+	snapshot := &memory.Snapshot{}
+	if err := json.Unmarshal(snapshotFile, snapshot); err != nil {
+		return fmt.Errorf("failed to decode the snapshot file: %w", err)
+	}
+
+	report, err := Replay(context.Background(), snapshot, redactionKey, records)
+	if err != nil {
+		return err
+	}
+
+	marshalled, err := json.MarshalIndent(report, " ", "    ")
+	if err != nil {
+		return fmt.Errorf("error gathering the replay report: %w", err)
+	}
+	fmt.Println(string(marshalled))
+
+	if len(report.Mismatches) > 0 {
+		return fmt.Errorf("%w: %d of %d", ErrMismatches, len(report.Mismatches), report.Total)
+	}
+
+	return nil
+}
+
+// Replay replays the records against a server serving the snapshot, redacted with the redaction key if it
+// isn't empty.
+func Replay(ctx context.Context, snapshot *memory.Snapshot, redactionKey string, records []*capture.Record) (*capture.Report, error) {
+	if redactionKey != "" {
+		snapshot = capture.RedactSnapshot(snapshot, capture.NewHMACRedactor(redactionKey))
+	}
+
+	ds, err := memory.NewFromSnapshot(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the snapshot: %w", err)
+	}
+	defer ds.Close()
+
+	s, err := server.NewServerWithOpts(server.WithDatastore(ds))
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	return capture.Replay(ctx, s, records)
+}
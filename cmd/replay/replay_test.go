@@ -0,0 +1,64 @@
+package replay
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/capture"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayRedactsTheSnapshot(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	defer ds.Close()
+
+	storeID := ulid.Make().String()
+	modelID := ulid.Make().String()
+	err := ds.WriteAuthorizationModel(ctx, storeID, &openfgav1.AuthorizationModel{
+		Id:            modelID,
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: []*openfgav1.TypeDefinition{
+			{Type: "user"},
+			{
+				Type: "document",
+				Relations: map[string]*openfgav1.Userset{
+					"viewer": typesystem.This(),
+				},
+				Metadata: &openfgav1.Metadata{
+					Relations: map[string]*openfgav1.RelationMetadata{
+						"viewer": {DirectlyRelatedUserTypes: []*openfgav1.RelationReference{typesystem.DirectRelationReference("user", "")}},
+					},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	err = ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("document:roadmap", "viewer", "user:anne")})
+	require.NoError(t, err)
+
+	snapshot, err := ds.(*memory.MemoryBackend).Snapshot()
+	require.NoError(t, err)
+
+	redactor := capture.NewHMACRedactor("key")
+	records := []*capture.Record{{
+		Method:   capture.MethodCheck,
+		Request:  []byte(`{"store_id":"` + storeID + `","authorization_model_id":"` + modelID + `","tuple_key":{"object":"document:` + redactor("roadmap") + `","relation":"viewer","user":"user:` + redactor("anne") + `"}}`),
+		Response: []byte(`{"allowed":true}`),
+		Code:     "OK",
+	}}
+
+	report, err := Replay(ctx, snapshot, "key", records)
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Matched)
+
+	report, err = Replay(ctx, snapshot, "", records)
+	require.NoError(t, err)
+	require.Len(t, report.Mismatches, 1)
+}
@@ -27,6 +27,42 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 
 		command.MarkFlagsRequiredTogether("grpc-tls-enabled", "grpc-tls-cert", "grpc-tls-key")
 
+		util.MustBindPFlag("grpc.streamCompressor", flags.Lookup("grpc-stream-compressor"))
+		util.MustBindEnv("grpc.streamCompressor", "OPENFGA_GRPC_STREAM_COMPRESSOR")
+
+		util.MustBindPFlag("grpc.gzipLevel", flags.Lookup("grpc-gzip-level"))
+		util.MustBindEnv("grpc.gzipLevel", "OPENFGA_GRPC_GZIP_LEVEL")
+
+		util.MustBindPFlag("grpc.keepalive.maxConnectionIdle", flags.Lookup("grpc-keepalive-max-connection-idle"))
+		util.MustBindEnv("grpc.keepalive.maxConnectionIdle", "OPENFGA_GRPC_KEEPALIVE_MAX_CONNECTION_IDLE")
+
+		util.MustBindPFlag("grpc.keepalive.maxConnectionAge", flags.Lookup("grpc-keepalive-max-connection-age"))
+		util.MustBindEnv("grpc.keepalive.maxConnectionAge", "OPENFGA_GRPC_KEEPALIVE_MAX_CONNECTION_AGE")
+
+		util.MustBindPFlag("grpc.keepalive.maxConnectionAgeGrace", flags.Lookup("grpc-keepalive-max-connection-age-grace"))
+		util.MustBindEnv("grpc.keepalive.maxConnectionAgeGrace", "OPENFGA_GRPC_KEEPALIVE_MAX_CONNECTION_AGE_GRACE")
+
+		util.MustBindPFlag("grpc.keepalive.time", flags.Lookup("grpc-keepalive-time"))
+		util.MustBindEnv("grpc.keepalive.time", "OPENFGA_GRPC_KEEPALIVE_TIME")
+
+		util.MustBindPFlag("grpc.keepalive.timeout", flags.Lookup("grpc-keepalive-timeout"))
+		util.MustBindEnv("grpc.keepalive.timeout", "OPENFGA_GRPC_KEEPALIVE_TIMEOUT")
+
+		util.MustBindPFlag("grpc.keepalive.enforcementMinTime", flags.Lookup("grpc-keepalive-enforcement-min-time"))
+		util.MustBindEnv("grpc.keepalive.enforcementMinTime", "OPENFGA_GRPC_KEEPALIVE_ENFORCEMENT_MIN_TIME")
+
+		util.MustBindPFlag("grpc.keepalive.enforcementPermitWithoutStream", flags.Lookup("grpc-keepalive-enforcement-permit-without-stream"))
+		util.MustBindEnv("grpc.keepalive.enforcementPermitWithoutStream", "OPENFGA_GRPC_KEEPALIVE_ENFORCEMENT_PERMIT_WITHOUT_STREAM")
+
+		util.MustBindPFlag("grpc.listener.maxConnections", flags.Lookup("grpc-listener-max-connections"))
+		util.MustBindEnv("grpc.listener.maxConnections", "OPENFGA_GRPC_LISTENER_MAX_CONNECTIONS")
+
+		util.MustBindPFlag("grpc.listener.acceptRate", flags.Lookup("grpc-listener-accept-rate"))
+		util.MustBindEnv("grpc.listener.acceptRate", "OPENFGA_GRPC_LISTENER_ACCEPT_RATE")
+
+		util.MustBindPFlag("grpc.listener.acceptBurst", flags.Lookup("grpc-listener-accept-burst"))
+		util.MustBindEnv("grpc.listener.acceptBurst", "OPENFGA_GRPC_LISTENER_ACCEPT_BURST")
+
 		util.MustBindPFlag("http.enabled", flags.Lookup("http-enabled"))
 		util.MustBindEnv("http.enabled", "OPENFGA_HTTP_ENABLED")
 
@@ -53,9 +89,30 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("http.corsAllowedHeaders", flags.Lookup("http-cors-allowed-headers"))
 		util.MustBindEnv("http.corsAllowedHeaders", "OPENFGA_HTTP_CORS_ALLOWED_HEADERS", "OPENFGA_HTTP_CORSALLOWEDHEADERS")
 
+		util.MustBindPFlag("http.forwardedHeaders", flags.Lookup("http-forwarded-headers"))
+		util.MustBindEnv("http.forwardedHeaders", "OPENFGA_HTTP_FORWARDED_HEADERS", "OPENFGA_HTTP_FORWARDEDHEADERS")
+
+		util.MustBindPFlag("http.h2cEnabled", flags.Lookup("http-h2c-enabled"))
+		util.MustBindEnv("http.h2cEnabled", "OPENFGA_HTTP_H2C_ENABLED")
+
+		util.MustBindPFlag("http.maxConcurrentStreams", flags.Lookup("http-max-concurrent-streams"))
+		util.MustBindEnv("http.maxConcurrentStreams", "OPENFGA_HTTP_MAX_CONCURRENT_STREAMS")
+
+		util.MustBindPFlag("http.listener.maxConnections", flags.Lookup("http-listener-max-connections"))
+		util.MustBindEnv("http.listener.maxConnections", "OPENFGA_HTTP_LISTENER_MAX_CONNECTIONS")
+
+		util.MustBindPFlag("http.listener.acceptRate", flags.Lookup("http-listener-accept-rate"))
+		util.MustBindEnv("http.listener.acceptRate", "OPENFGA_HTTP_LISTENER_ACCEPT_RATE")
+
+		util.MustBindPFlag("http.listener.acceptBurst", flags.Lookup("http-listener-accept-burst"))
+		util.MustBindEnv("http.listener.acceptBurst", "OPENFGA_HTTP_LISTENER_ACCEPT_BURST")
+
 		util.MustBindPFlag("authn.method", flags.Lookup("authn-method"))
 		util.MustBindEnv("authn.method", "OPENFGA_AUTHN_METHOD")
 
+		util.MustBindPFlag("authn.unauthenticatedMethods", flags.Lookup("authn-unauthenticated-methods"))
+		util.MustBindEnv("authn.unauthenticatedMethods", "OPENFGA_AUTHN_UNAUTHENTICATED_METHODS", "OPENFGA_AUTHN_UNAUTHENTICATEDMETHODS")
+
 		util.MustBindPFlag("authn.preshared.keys", flags.Lookup("authn-preshared-keys"))
 		util.MustBindEnv("authn.preshared.keys", "OPENFGA_AUTHN_PRESHARED_KEYS")
 
@@ -65,6 +122,42 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("authn.oidc.issuer", flags.Lookup("authn-oidc-issuer"))
 		util.MustBindEnv("authn.oidc.issuer", "OPENFGA_AUTHN_OIDC_ISSUER")
 
+		util.MustBindPFlag("authn.signed.signingKeys", flags.Lookup("authn-signed-signing-keys"))
+		util.MustBindEnv("authn.signed.signingKeys", "OPENFGA_AUTHN_SIGNED_SIGNING_KEYS", "OPENFGA_AUTHN_SIGNED_SIGNINGKEYS")
+
+		util.MustBindPFlag("authn.signed.maxClockSkew", flags.Lookup("authn-signed-max-clock-skew"))
+		util.MustBindEnv("authn.signed.maxClockSkew", "OPENFGA_AUTHN_SIGNED_MAX_CLOCK_SKEW", "OPENFGA_AUTHN_SIGNED_MAXCLOCKSKEW")
+
+		util.MustBindPFlag("authn.signed.maxNonces", flags.Lookup("authn-signed-max-nonces"))
+		util.MustBindEnv("authn.signed.maxNonces", "OPENFGA_AUTHN_SIGNED_MAX_NONCES", "OPENFGA_AUTHN_SIGNED_MAXNONCES")
+
+		util.MustBindPFlag("requestEnrichment.contextualTuples", flags.Lookup("request-enrichment-contextual-tuples"))
+		util.MustBindEnv("requestEnrichment.contextualTuples", "OPENFGA_REQUEST_ENRICHMENT_CONTEXTUAL_TUPLES", "OPENFGA_REQUESTENRICHMENT_CONTEXTUALTUPLES")
+
+		util.MustBindPFlag("authorizationModelValidation.rules", flags.Lookup("authorization-model-validation-rules"))
+		util.MustBindEnv("authorizationModelValidation.rules", "OPENFGA_AUTHORIZATION_MODEL_VALIDATION_RULES", "OPENFGA_AUTHORIZATIONMODELVALIDATION_RULES")
+
+		util.MustBindPFlag("authorizationModelValidation.plugins", flags.Lookup("authorization-model-validation-plugins"))
+		util.MustBindEnv("authorizationModelValidation.plugins", "OPENFGA_AUTHORIZATION_MODEL_VALIDATION_PLUGINS", "OPENFGA_AUTHORIZATIONMODELVALIDATION_PLUGINS")
+
+		util.MustBindPFlag("authorizationModelValidation.reservedTypeNames", flags.Lookup("authorization-model-validation-reserved-type-names"))
+		util.MustBindEnv("authorizationModelValidation.reservedTypeNames", "OPENFGA_AUTHORIZATION_MODEL_VALIDATION_RESERVED_TYPE_NAMES", "OPENFGA_AUTHORIZATIONMODELVALIDATION_RESERVEDTYPENAMES")
+
+		util.MustBindPFlag("authorizationModelValidation.reservedRelationNames", flags.Lookup("authorization-model-validation-reserved-relation-names"))
+		util.MustBindEnv("authorizationModelValidation.reservedRelationNames", "OPENFGA_AUTHORIZATION_MODEL_VALIDATION_RESERVED_RELATION_NAMES", "OPENFGA_AUTHORIZATIONMODELVALIDATION_RESERVEDRELATIONNAMES")
+
+		util.MustBindPFlag("authorizationModelValidation.maxTypeNameLength", flags.Lookup("authorization-model-validation-max-type-name-length"))
+		util.MustBindEnv("authorizationModelValidation.maxTypeNameLength", "OPENFGA_AUTHORIZATION_MODEL_VALIDATION_MAX_TYPE_NAME_LENGTH", "OPENFGA_AUTHORIZATIONMODELVALIDATION_MAXTYPENAMELENGTH")
+
+		util.MustBindPFlag("authorizationModelValidation.maxRelationNameLength", flags.Lookup("authorization-model-validation-max-relation-name-length"))
+		util.MustBindEnv("authorizationModelValidation.maxRelationNameLength", "OPENFGA_AUTHORIZATION_MODEL_VALIDATION_MAX_RELATION_NAME_LENGTH", "OPENFGA_AUTHORIZATIONMODELVALIDATION_MAXRELATIONNAMELENGTH")
+
+		util.MustBindPFlag("authorizationModelValidation.disallowWildcards", flags.Lookup("authorization-model-validation-disallow-wildcards"))
+		util.MustBindEnv("authorizationModelValidation.disallowWildcards", "OPENFGA_AUTHORIZATION_MODEL_VALIDATION_DISALLOW_WILDCARDS", "OPENFGA_AUTHORIZATIONMODELVALIDATION_DISALLOWWILDCARDS")
+
+		util.MustBindPFlag("authorizationModelValidation.disallowIntersectionAndExclusion", flags.Lookup("authorization-model-validation-disallow-intersection-and-exclusion"))
+		util.MustBindEnv("authorizationModelValidation.disallowIntersectionAndExclusion", "OPENFGA_AUTHORIZATION_MODEL_VALIDATION_DISALLOW_INTERSECTION_AND_EXCLUSION", "OPENFGA_AUTHORIZATIONMODELVALIDATION_DISALLOWINTERSECTIONANDEXCLUSION")
+
 		util.MustBindPFlag("datastore.engine", flags.Lookup("datastore-engine"))
 		util.MustBindEnv("datastore.engine", "OPENFGA_DATASTORE_ENGINE")
 
@@ -80,6 +173,12 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("datastore.maxCacheSize", flags.Lookup("datastore-max-cache-size"))
 		util.MustBindEnv("datastore.maxCacheSize", "OPENFGA_DATASTORE_MAX_CACHE_SIZE", "OPENFGA_DATASTORE_MAXCACHESIZE")
 
+		util.MustBindPFlag("datastore.latestModelIDCacheTTL", flags.Lookup("datastore-latest-model-id-cache-ttl"))
+		util.MustBindEnv("datastore.latestModelIDCacheTTL", "OPENFGA_DATASTORE_LATEST_MODEL_ID_CACHE_TTL")
+
+		util.MustBindPFlag("datastore.expiredTuplesReapInterval", flags.Lookup("datastore-expired-tuples-reap-interval"))
+		util.MustBindEnv("datastore.expiredTuplesReapInterval", "OPENFGA_DATASTORE_EXPIRED_TUPLES_REAP_INTERVAL")
+
 		util.MustBindPFlag("datastore.maxOpenConns", flags.Lookup("datastore-max-open-conns"))
 		util.MustBindEnv("datastore.maxOpenConns", "OPENFGA_DATASTORE_MAX_OPEN_CONNS", "OPENFGA_DATASTORE_MAXOPENCONNS")
 
@@ -95,6 +194,72 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("datastore.metrics.enabled", flags.Lookup("datastore-metrics-enabled"))
 		util.MustBindEnv("datastore.metrics.enabled", "OPENFGA_DATASTORE_METRICS_ENABLED")
 
+		util.MustBindPFlag("datastore.autoMigrate", flags.Lookup("auto-migrate"))
+		util.MustBindEnv("datastore.autoMigrate", "OPENFGA_DATASTORE_AUTO_MIGRATE")
+
+		util.MustBindPFlag("datastore.shadow.engine", flags.Lookup("datastore-shadow-engine"))
+		util.MustBindEnv("datastore.shadow.engine", "OPENFGA_DATASTORE_SHADOW_ENGINE")
+
+		util.MustBindPFlag("datastore.shadow.uri", flags.Lookup("datastore-shadow-uri"))
+		util.MustBindEnv("datastore.shadow.uri", "OPENFGA_DATASTORE_SHADOW_URI")
+
+		util.MustBindPFlag("datastore.shadow.username", flags.Lookup("datastore-shadow-username"))
+		util.MustBindEnv("datastore.shadow.username", "OPENFGA_DATASTORE_SHADOW_USERNAME")
+
+		util.MustBindPFlag("datastore.shadow.password", flags.Lookup("datastore-shadow-password"))
+		util.MustBindEnv("datastore.shadow.password", "OPENFGA_DATASTORE_SHADOW_PASSWORD")
+
+		util.MustBindPFlag("datastore.shadow.timeout", flags.Lookup("datastore-shadow-timeout"))
+		util.MustBindEnv("datastore.shadow.timeout", "OPENFGA_DATASTORE_SHADOW_TIMEOUT")
+
+		util.MustBindPFlag("datastore.shadow.maxConcurrency", flags.Lookup("datastore-shadow-max-concurrency"))
+		util.MustBindEnv("datastore.shadow.maxConcurrency", "OPENFGA_DATASTORE_SHADOW_MAX_CONCURRENCY")
+
+		util.MustBindPFlag("datastore.maintenance.schedule", flags.Lookup("datastore-maintenance-schedule"))
+		util.MustBindEnv("datastore.maintenance.schedule", "OPENFGA_DATASTORE_MAINTENANCE_SCHEDULE")
+
+		util.MustBindPFlag("datastore.maintenance.jitter", flags.Lookup("datastore-maintenance-jitter"))
+		util.MustBindEnv("datastore.maintenance.jitter", "OPENFGA_DATASTORE_MAINTENANCE_JITTER")
+
+		util.MustBindPFlag("datastore.maintenance.tasks", flags.Lookup("datastore-maintenance-tasks"))
+		util.MustBindEnv("datastore.maintenance.tasks", "OPENFGA_DATASTORE_MAINTENANCE_TASKS")
+
+		util.MustBindPFlag("datastore.replicas.uris", flags.Lookup("datastore-replica-uris"))
+		util.MustBindEnv("datastore.replicas.uris", "OPENFGA_DATASTORE_REPLICAS_URIS")
+
+		util.MustBindPFlag("datastore.replicas.hedgeAfter", flags.Lookup("datastore-replica-hedge-after"))
+		util.MustBindEnv("datastore.replicas.hedgeAfter", "OPENFGA_DATASTORE_REPLICAS_HEDGE_AFTER")
+
+		util.MustBindPFlag("datastore.replicas.hedgingBudget", flags.Lookup("datastore-replica-hedging-budget"))
+		util.MustBindEnv("datastore.replicas.hedgingBudget", "OPENFGA_DATASTORE_REPLICAS_HEDGING_BUDGET")
+
+		util.MustBindPFlag("datastore.routing.stores", flags.Lookup("datastore-routing-stores"))
+		util.MustBindEnv("datastore.routing.stores", "OPENFGA_DATASTORE_ROUTING_STORES")
+
+		util.MustBindPFlag("datastore.routing.prefixes", flags.Lookup("datastore-routing-prefixes"))
+		util.MustBindEnv("datastore.routing.prefixes", "OPENFGA_DATASTORE_ROUTING_PREFIXES")
+
+		util.MustBindPFlag("datastore.residency.region", flags.Lookup("datastore-residency-region"))
+		util.MustBindEnv("datastore.residency.region", "OPENFGA_DATASTORE_RESIDENCY_REGION")
+
+		util.MustBindPFlag("datastore.residency.regions", flags.Lookup("datastore-residency-regions"))
+		util.MustBindEnv("datastore.residency.regions", "OPENFGA_DATASTORE_RESIDENCY_REGIONS")
+
+		util.MustBindPFlag("datastore.circuitBreaker.enabled", flags.Lookup("datastore-circuit-breaker-enabled"))
+		util.MustBindEnv("datastore.circuitBreaker.enabled", "OPENFGA_DATASTORE_CIRCUIT_BREAKER_ENABLED")
+
+		util.MustBindPFlag("datastore.circuitBreaker.errorRateThreshold", flags.Lookup("datastore-circuit-breaker-error-rate-threshold"))
+		util.MustBindEnv("datastore.circuitBreaker.errorRateThreshold", "OPENFGA_DATASTORE_CIRCUIT_BREAKER_ERROR_RATE_THRESHOLD")
+
+		util.MustBindPFlag("datastore.circuitBreaker.minRequests", flags.Lookup("datastore-circuit-breaker-min-requests"))
+		util.MustBindEnv("datastore.circuitBreaker.minRequests", "OPENFGA_DATASTORE_CIRCUIT_BREAKER_MIN_REQUESTS")
+
+		util.MustBindPFlag("datastore.circuitBreaker.window", flags.Lookup("datastore-circuit-breaker-window"))
+		util.MustBindEnv("datastore.circuitBreaker.window", "OPENFGA_DATASTORE_CIRCUIT_BREAKER_WINDOW")
+
+		util.MustBindPFlag("datastore.circuitBreaker.openDuration", flags.Lookup("datastore-circuit-breaker-open-duration"))
+		util.MustBindEnv("datastore.circuitBreaker.openDuration", "OPENFGA_DATASTORE_CIRCUIT_BREAKER_OPEN_DURATION")
+
 		util.MustBindPFlag("playground.enabled", flags.Lookup("playground-enabled"))
 		util.MustBindEnv("playground.enabled", "OPENFGA_PLAYGROUND_ENABLED")
 
@@ -113,6 +278,15 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("log.level", flags.Lookup("log-level"))
 		util.MustBindEnv("log.level", "OPENFGA_LOG_LEVEL")
 
+		util.MustBindPFlag("log.sampling.initial", flags.Lookup("log-sampling-initial"))
+		util.MustBindEnv("log.sampling.initial", "OPENFGA_LOG_SAMPLING_INITIAL")
+
+		util.MustBindPFlag("log.sampling.thereafter", flags.Lookup("log-sampling-thereafter"))
+		util.MustBindEnv("log.sampling.thereafter", "OPENFGA_LOG_SAMPLING_THEREAFTER")
+
+		util.MustBindPFlag("log.sampling.level", flags.Lookup("log-sampling-level"))
+		util.MustBindEnv("log.sampling.level", "OPENFGA_LOG_SAMPLING_LEVEL")
+
 		util.MustBindPFlag("trace.enabled", flags.Lookup("trace-enabled"))
 		util.MustBindEnv("trace.enabled", "OPENFGA_TRACE_ENABLED")
 
@@ -137,6 +311,15 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("metrics.enableRPCHistograms", flags.Lookup("metrics-enable-rpc-histograms"))
 		util.MustBindEnv("metrics.enableRPCHistograms", "OPENFGA_METRICS_ENABLE_RPC_HISTOGRAMS")
 
+		util.MustBindPFlag("metrics.enableStoreLabels", flags.Lookup("metrics-enable-store-labels"))
+		util.MustBindEnv("metrics.enableStoreLabels", "OPENFGA_METRICS_ENABLE_STORE_LABELS")
+
+		util.MustBindPFlag("metrics.storeLabelsAllowlist", flags.Lookup("metrics-store-labels-allowlist"))
+		util.MustBindEnv("metrics.storeLabelsAllowlist", "OPENFGA_METRICS_STORE_LABELS_ALLOWLIST")
+
+		util.MustBindPFlag("metrics.storeLabelsTopK", flags.Lookup("metrics-store-labels-top-k"))
+		util.MustBindEnv("metrics.storeLabelsTopK", "OPENFGA_METRICS_STORE_LABELS_TOP_K")
+
 		util.MustBindPFlag("maxTuplesPerWrite", flags.Lookup("max-tuples-per-write"))
 		util.MustBindEnv("maxTuplesPerWrite", "OPENFGA_MAX_TUPLES_PER_WRITE", "OPENFGA_MAXTUPLESPERWRITE")
 
@@ -146,12 +329,33 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("maxAuthorizationModelSizeInBytes", flags.Lookup("max-authorization-model-size-in-bytes"))
 		util.MustBindEnv("maxAuthorizationModelSizeInBytes", "OPENFGA_MAX_AUTHORIZATION_MODEL_SIZE_IN_BYTES", "OPENFGA_MAXAUTHORIZATIONMODELSIZEINBYTES")
 
+		util.MustBindPFlag("assertionsCopyForward", flags.Lookup("assertions-copy-forward"))
+		util.MustBindEnv("assertionsCopyForward", "OPENFGA_ASSERTIONS_COPY_FORWARD", "OPENFGA_ASSERTIONSCOPYFORWARD")
+
+		util.MustBindPFlag("skipIdenticalAuthorizationModels", flags.Lookup("skip-identical-authorization-models"))
+		util.MustBindEnv("skipIdenticalAuthorizationModels", "OPENFGA_SKIP_IDENTICAL_AUTHORIZATION_MODELS")
+
+		util.MustBindPFlag("rejectSelfReferentialTuples", flags.Lookup("reject-self-referential-tuples"))
+		util.MustBindEnv("rejectSelfReferentialTuples", "OPENFGA_REJECT_SELF_REFERENTIAL_TUPLES", "OPENFGA_REJECTSELFREFERENTIALTUPLES")
+
+		util.MustBindPFlag("allowCacheBypass", flags.Lookup("allow-cache-bypass"))
+		util.MustBindEnv("allowCacheBypass", "OPENFGA_ALLOW_CACHE_BYPASS", "OPENFGA_ALLOWCACHEBYPASS")
+
+		util.MustBindPFlag("statusCodeOverrides", flags.Lookup("status-code-overrides"))
+		util.MustBindEnv("statusCodeOverrides", "OPENFGA_STATUS_CODE_OVERRIDES")
+
+		util.MustBindPFlag("storeTombstoneTTL", flags.Lookup("store-tombstone-ttl"))
+		util.MustBindEnv("storeTombstoneTTL", "OPENFGA_STORE_TOMBSTONE_TTL", "OPENFGA_STORETOMBSTONETTL")
+
 		util.MustBindPFlag("maxConcurrentReadsForListObjects", flags.Lookup("max-concurrent-reads-for-list-objects"))
 		util.MustBindEnv("maxConcurrentReadsForListObjects", "OPENFGA_MAX_CONCURRENT_READS_FOR_LIST_OBJECTS", "OPENFGA_MAXCONCURRENTREADSFORLISTOBJECTS")
 
 		util.MustBindPFlag("maxConcurrentReadsForCheck", flags.Lookup("max-concurrent-reads-for-check"))
 		util.MustBindEnv("maxConcurrentReadsForCheck", "OPENFGA_MAX_CONCURRENT_READS_FOR_CHECK", "OPENFGA_MAXCONCURRENTREADSFORCHECK")
 
+		util.MustBindPFlag("checkUsersetBatchSize", flags.Lookup("check-userset-batch-size"))
+		util.MustBindEnv("checkUsersetBatchSize", "OPENFGA_CHECK_USERSET_BATCH_SIZE", "OPENFGA_CHECKUSERSETBATCHSIZE")
+
 		util.MustBindPFlag("changelogHorizonOffset", flags.Lookup("changelog-horizon-offset"))
 		util.MustBindEnv("changelogHorizonOffset", "OPENFGA_CHANGELOG_HORIZON_OFFSET", "OPENFGA_CHANGELOGHORIZONOFFSET")
 
@@ -176,6 +380,171 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("checkQueryCache.ttl", flags.Lookup("check-query-cache-ttl"))
 		util.MustBindEnv("checkQueryCache.ttl", "OPENFGA_CHECK_QUERY_CACHE_TTL")
 
+		util.MustBindPFlag("checkQueryCache.hotKeyQPSThreshold", flags.Lookup("check-query-cache-hot-key-qps-threshold"))
+		util.MustBindEnv("checkQueryCache.hotKeyQPSThreshold", "OPENFGA_CHECK_QUERY_CACHE_HOT_KEY_QPS_THRESHOLD")
+
+		util.MustBindPFlag("checkQueryCache.hotKeyTTLMultiplier", flags.Lookup("check-query-cache-hot-key-ttl-multiplier"))
+		util.MustBindEnv("checkQueryCache.hotKeyTTLMultiplier", "OPENFGA_CHECK_QUERY_CACHE_HOT_KEY_TTL_MULTIPLIER")
+
+		util.MustBindPFlag("checkQueryCache.negativeLimit", flags.Lookup("check-query-cache-negative-limit"))
+		util.MustBindEnv("checkQueryCache.negativeLimit", "OPENFGA_CHECK_QUERY_CACHE_NEGATIVE_LIMIT")
+
+		util.MustBindPFlag("checkQueryCache.negativeTTL", flags.Lookup("check-query-cache-negative-ttl"))
+		util.MustBindEnv("checkQueryCache.negativeTTL", "OPENFGA_CHECK_QUERY_CACHE_NEGATIVE_TTL")
+
+		util.MustBindPFlag("checkQueryCache.changelogInterval", flags.Lookup("check-query-cache-changelog-interval"))
+		util.MustBindEnv("checkQueryCache.changelogInterval", "OPENFGA_CHECK_QUERY_CACHE_CHANGELOG_INTERVAL")
+
+		util.MustBindPFlag("checkQueryCache.sharedMemcachedServers", flags.Lookup("check-query-cache-shared-memcached-servers"))
+		util.MustBindEnv("checkQueryCache.sharedMemcachedServers", "OPENFGA_CHECK_QUERY_CACHE_SHARED_MEMCACHED_SERVERS")
+
+		util.MustBindPFlag("checkProfiling.enabled", flags.Lookup("check-profiling-enabled"))
+		util.MustBindEnv("checkProfiling.enabled", "OPENFGA_CHECK_PROFILING_ENABLED")
+
+		util.MustBindPFlag("checkProfiling.latencyThreshold", flags.Lookup("check-profiling-latency-threshold"))
+		util.MustBindEnv("checkProfiling.latencyThreshold", "OPENFGA_CHECK_PROFILING_LATENCY_THRESHOLD")
+
+		util.MustBindPFlag("checkProfiling.sampleRate", flags.Lookup("check-profiling-sample-rate"))
+		util.MustBindEnv("checkProfiling.sampleRate", "OPENFGA_CHECK_PROFILING_SAMPLE_RATE")
+
+		util.MustBindPFlag("checkProfiling.filePath", flags.Lookup("check-profiling-file-path"))
+		util.MustBindEnv("checkProfiling.filePath", "OPENFGA_CHECK_PROFILING_FILE_PATH")
+
+		util.MustBindPFlag("capture.enabled", flags.Lookup("capture-enabled"))
+		util.MustBindEnv("capture.enabled", "OPENFGA_CAPTURE_ENABLED")
+
+		util.MustBindPFlag("capture.sampleRate", flags.Lookup("capture-sample-rate"))
+		util.MustBindEnv("capture.sampleRate", "OPENFGA_CAPTURE_SAMPLE_RATE")
+
+		util.MustBindPFlag("capture.filePath", flags.Lookup("capture-file-path"))
+		util.MustBindEnv("capture.filePath", "OPENFGA_CAPTURE_FILE_PATH")
+
+		util.MustBindPFlag("capture.redactionKey", flags.Lookup("capture-redaction-key"))
+		util.MustBindEnv("capture.redactionKey", "OPENFGA_CAPTURE_REDACTION_KEY")
+
+		util.MustBindPFlag("inlineModel.enabled", flags.Lookup("inline-model-enabled"))
+		util.MustBindEnv("inlineModel.enabled", "OPENFGA_INLINE_MODEL_ENABLED")
+
+		util.MustBindPFlag("inlineModel.maxSizeInBytes", flags.Lookup("inline-model-max-size-in-bytes"))
+		util.MustBindEnv("inlineModel.maxSizeInBytes", "OPENFGA_INLINE_MODEL_MAX_SIZE_IN_BYTES")
+
+		util.MustBindPFlag("batchCheck.maxChecks", flags.Lookup("batch-check-max-checks"))
+		util.MustBindEnv("batchCheck.maxChecks", "OPENFGA_BATCH_CHECK_MAX_CHECKS")
+
+		util.MustBindPFlag("batchCheck.maxConcurrentChecks", flags.Lookup("batch-check-max-concurrent-checks"))
+		util.MustBindEnv("batchCheck.maxConcurrentChecks", "OPENFGA_BATCH_CHECK_MAX_CONCURRENT_CHECKS")
+
+		util.MustBindPFlag("batchCheck.maxDatastoreQueries", flags.Lookup("batch-check-max-datastore-queries"))
+		util.MustBindEnv("batchCheck.maxDatastoreQueries", "OPENFGA_BATCH_CHECK_MAX_DATASTORE_QUERIES")
+
+		util.MustBindPFlag("relationUsage.enabled", flags.Lookup("relation-usage-enabled"))
+		util.MustBindEnv("relationUsage.enabled", "OPENFGA_RELATION_USAGE_ENABLED")
+
+		util.MustBindPFlag("relationUsage.window", flags.Lookup("relation-usage-window"))
+		util.MustBindEnv("relationUsage.window", "OPENFGA_RELATION_USAGE_WINDOW")
+
+		util.MustBindPFlag("relationUsage.maxRelations", flags.Lookup("relation-usage-max-relations"))
+		util.MustBindEnv("relationUsage.maxRelations", "OPENFGA_RELATION_USAGE_MAX_RELATIONS")
+
+		util.MustBindPFlag("slo.enabled", flags.Lookup("slo-enabled"))
+		util.MustBindEnv("slo.enabled", "OPENFGA_SLO_ENABLED")
+
+		util.MustBindPFlag("slo.availabilityObjective", flags.Lookup("slo-availability-objective"))
+		util.MustBindEnv("slo.availabilityObjective", "OPENFGA_SLO_AVAILABILITY_OBJECTIVE")
+
+		util.MustBindPFlag("slo.latencyObjective", flags.Lookup("slo-latency-objective"))
+		util.MustBindEnv("slo.latencyObjective", "OPENFGA_SLO_LATENCY_OBJECTIVE")
+
+		util.MustBindPFlag("slo.latencyThreshold", flags.Lookup("slo-latency-threshold"))
+		util.MustBindEnv("slo.latencyThreshold", "OPENFGA_SLO_LATENCY_THRESHOLD")
+
+		util.MustBindPFlag("slo.methodLatencyThresholds", flags.Lookup("slo-method-latency-thresholds"))
+		util.MustBindEnv("slo.methodLatencyThresholds", "OPENFGA_SLO_METHOD_LATENCY_THRESHOLDS")
+
+		util.MustBindPFlag("slo.window", flags.Lookup("slo-window"))
+		util.MustBindEnv("slo.window", "OPENFGA_SLO_WINDOW")
+
+		util.MustBindPFlag("admin.enabled", flags.Lookup("admin-enabled"))
+		util.MustBindEnv("admin.enabled", "OPENFGA_ADMIN_ENABLED")
+
+		util.MustBindPFlag("admin.addr", flags.Lookup("admin-addr"))
+		util.MustBindEnv("admin.addr", "OPENFGA_ADMIN_ADDR")
+
+		util.MustBindPFlag("admin.graphqlEnabled", flags.Lookup("admin-graphql-enabled"))
+		util.MustBindEnv("admin.graphqlEnabled", "OPENFGA_ADMIN_GRAPHQL_ENABLED")
+
+		util.MustBindPFlag("admin.keys", flags.Lookup("admin-keys"))
+		util.MustBindEnv("admin.keys", "OPENFGA_ADMIN_KEYS")
+
+		util.MustBindPFlag("admin.requiredClaim", flags.Lookup("admin-required-claim"))
+		util.MustBindEnv("admin.requiredClaim", "OPENFGA_ADMIN_REQUIRED_CLAIM")
+
+		util.MustBindPFlag("shadowCheck.enabled", flags.Lookup("shadow-check-enabled"))
+		util.MustBindEnv("shadowCheck.enabled", "OPENFGA_SHADOW_CHECK_ENABLED")
+
+		util.MustBindPFlag("shadowCheck.candidates", flags.Lookup("shadow-check-candidates"))
+		util.MustBindEnv("shadowCheck.candidates", "OPENFGA_SHADOW_CHECK_CANDIDATES")
+
+		util.MustBindPFlag("shadowCheck.sampleRate", flags.Lookup("shadow-check-sample-rate"))
+		util.MustBindEnv("shadowCheck.sampleRate", "OPENFGA_SHADOW_CHECK_SAMPLE_RATE")
+
+		util.MustBindPFlag("shadowCheck.timeout", flags.Lookup("shadow-check-timeout"))
+		util.MustBindEnv("shadowCheck.timeout", "OPENFGA_SHADOW_CHECK_TIMEOUT")
+
+		util.MustBindPFlag("shadowCheck.maxConcurrency", flags.Lookup("shadow-check-max-concurrency"))
+		util.MustBindEnv("shadowCheck.maxConcurrency", "OPENFGA_SHADOW_CHECK_MAX_CONCURRENCY")
+
+		util.MustBindPFlag("maintenance.enabled", flags.Lookup("maintenance-enabled"))
+		util.MustBindEnv("maintenance.enabled", "OPENFGA_MAINTENANCE_ENABLED")
+
+		util.MustBindPFlag("maintenance.message", flags.Lookup("maintenance-message"))
+		util.MustBindEnv("maintenance.message", "OPENFGA_MAINTENANCE_MESSAGE")
+
+		util.MustBindPFlag("maintenance.retryAfter", flags.Lookup("maintenance-retry-after"))
+		util.MustBindEnv("maintenance.retryAfter", "OPENFGA_MAINTENANCE_RETRY_AFTER")
+
+		util.MustBindPFlag("loadShedding.maxConcurrentRequests", flags.Lookup("load-shedding-max-concurrent-requests"))
+		util.MustBindEnv("loadShedding.maxConcurrentRequests", "OPENFGA_LOAD_SHEDDING_MAX_CONCURRENT_REQUESTS")
+
+		util.MustBindPFlag("loadShedding.retryAfter", flags.Lookup("load-shedding-retry-after"))
+		util.MustBindEnv("loadShedding.retryAfter", "OPENFGA_LOAD_SHEDDING_RETRY_AFTER")
+
+		util.MustBindPFlag("readGuardrails.rejectUnfiltered", flags.Lookup("read-guardrails-reject-unfiltered"))
+		util.MustBindEnv("readGuardrails.rejectUnfiltered", "OPENFGA_READ_GUARDRAILS_REJECT_UNFILTERED")
+
+		util.MustBindPFlag("readGuardrails.minFilterDimensions", flags.Lookup("read-guardrails-min-filter-dimensions"))
+		util.MustBindEnv("readGuardrails.minFilterDimensions", "OPENFGA_READ_GUARDRAILS_MIN_FILTER_DIMENSIONS")
+
+		util.MustBindPFlag("readGuardrails.largeStoreTupleCount", flags.Lookup("read-guardrails-large-store-tuple-count"))
+		util.MustBindEnv("readGuardrails.largeStoreTupleCount", "OPENFGA_READ_GUARDRAILS_LARGE_STORE_TUPLE_COUNT")
+
+		util.MustBindPFlag("continuationToken.encryptionKey", flags.Lookup("continuation-token-encryption-key"))
+		util.MustBindEnv("continuationToken.encryptionKey", "OPENFGA_CONTINUATION_TOKEN_ENCRYPTION_KEY")
+
+		util.MustBindPFlag("continuationToken.previousEncryptionKeys", flags.Lookup("continuation-token-previous-encryption-keys"))
+		util.MustBindEnv("continuationToken.previousEncryptionKeys", "OPENFGA_CONTINUATION_TOKEN_PREVIOUS_ENCRYPTION_KEYS")
+
+		util.MustBindPFlag("continuationToken.ttl", flags.Lookup("continuation-token-ttl"))
+		util.MustBindEnv("continuationToken.ttl", "OPENFGA_CONTINUATION_TOKEN_TTL")
+
+		util.MustBindPFlag("cluster.enabled", flags.Lookup("cluster-enabled"))
+		util.MustBindEnv("cluster.enabled", "OPENFGA_CLUSTER_ENABLED")
+
+		util.MustBindPFlag("cluster.advertiseAddress", flags.Lookup("cluster-advertise-address"))
+		util.MustBindEnv("cluster.advertiseAddress", "OPENFGA_CLUSTER_ADVERTISE_ADDRESS")
+
+		util.MustBindPFlag("cluster.peers", flags.Lookup("cluster-peers"))
+		util.MustBindEnv("cluster.peers", "OPENFGA_CLUSTER_PEERS")
+
+		util.MustBindPFlag("cluster.probeInterval", flags.Lookup("cluster-probe-interval"))
+		util.MustBindEnv("cluster.probeInterval", "OPENFGA_CLUSTER_PROBE_INTERVAL")
+
+		util.MustBindPFlag("cluster.dispatchRetries", flags.Lookup("cluster-dispatch-retries"))
+		util.MustBindEnv("cluster.dispatchRetries", "OPENFGA_CLUSTER_DISPATCH_RETRIES")
+
+		util.MustBindPFlag("auditLog.enabled", flags.Lookup("audit-log-enabled"))
+		util.MustBindEnv("auditLog.enabled", "OPENFGA_AUDIT_LOG_ENABLED")
+
 		util.MustBindPFlag("requestDurationDatastoreQueryCountBuckets", flags.Lookup("request-duration-datastore-query-count-buckets"))
 		util.MustBindEnv("requestDurationDatastoreQueryCountBuckets", "OPENFGA_REQUEST_DURATION_DATASTORE_QUERY_COUNT_BUCKETS")
 	}
@@ -12,6 +12,7 @@ import (
 	"os"
 	"os/signal"
 	goruntime "runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
@@ -19,7 +20,7 @@ import (
 
 	"github.com/cenkalti/backoff/v4"
 	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
-	grpcauth "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/selector"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	grpc_prometheus "github.com/jon-whit/go-grpc-prometheus"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
@@ -27,17 +28,39 @@ import (
 	"github.com/openfga/openfga/internal/authn"
 	"github.com/openfga/openfga/internal/authn/oidc"
 	"github.com/openfga/openfga/internal/authn/presharedkey"
+	"github.com/openfga/openfga/internal/authn/signedrequest"
 	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/internal/cluster"
 	"github.com/openfga/openfga/internal/gateway"
+	"github.com/openfga/openfga/internal/graph"
 	authnmw "github.com/openfga/openfga/internal/middleware/authn"
+	"github.com/openfga/openfga/internal/middleware/enrichment"
 	serverconfig "github.com/openfga/openfga/internal/server/config"
+	"github.com/openfga/openfga/internal/sharedcache"
+	"github.com/openfga/openfga/pkg/admin"
+	"github.com/openfga/openfga/pkg/capture"
+	"github.com/openfga/openfga/pkg/encoder"
+	"github.com/openfga/openfga/pkg/events"
+	"github.com/openfga/openfga/pkg/graphql"
 	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/middleware/cachebypass"
+	"github.com/openfga/openfga/pkg/middleware/compression"
+	"github.com/openfga/openfga/pkg/middleware/consistency"
 	httpmiddleware "github.com/openfga/openfga/pkg/middleware/http"
+	"github.com/openfga/openfga/pkg/middleware/loadshed"
 	"github.com/openfga/openfga/pkg/middleware/logging"
+	"github.com/openfga/openfga/pkg/middleware/maintenance"
 	"github.com/openfga/openfga/pkg/middleware/requestid"
+	"github.com/openfga/openfga/pkg/middleware/slo"
+	"github.com/openfga/openfga/pkg/middleware/statuscodes"
 	"github.com/openfga/openfga/pkg/middleware/storeid"
+	"github.com/openfga/openfga/pkg/middleware/storemetrics"
+	"github.com/openfga/openfga/pkg/middleware/tombstone"
+	"github.com/openfga/openfga/pkg/middleware/tracecontext"
 	"github.com/openfga/openfga/pkg/middleware/validator"
+	"github.com/openfga/openfga/pkg/modelvalidation"
 	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/server/commands"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/server/health"
 	"github.com/openfga/openfga/pkg/storage"
@@ -45,8 +68,10 @@ import (
 	"github.com/openfga/openfga/pkg/storage/mysql"
 	"github.com/openfga/openfga/pkg/storage/postgres"
 	"github.com/openfga/openfga/pkg/storage/sqlcommon"
+	"github.com/openfga/openfga/pkg/storage/sqlite"
 	"github.com/openfga/openfga/pkg/storage/storagewrappers"
 	"github.com/openfga/openfga/pkg/telemetry"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/cors"
 	"github.com/spf13/cobra"
@@ -56,10 +81,14 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
 	"go.opentelemetry.io/otel/trace/noop"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	healthv1pb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
 )
@@ -93,6 +122,30 @@ func NewRunCommand() *cobra.Command {
 
 	cmd.MarkFlagsRequiredTogether("grpc-tls-enabled", "grpc-tls-cert", "grpc-tls-key")
 
+	flags.String("grpc-stream-compressor", defaultConfig.GRPC.StreamCompressor, "the compressor of the responses of the streaming methods (e.g. StreamedListObjects), used if the clients support it. Besides 'gzip', it can be any compressor registered in the binary, e.g. by a zstd encoding plugin. If empty, the responses are compressed like the requests")
+
+	flags.Int("grpc-gzip-level", defaultConfig.GRPC.GzipLevel, "the level of the gzip compression, between -2 (Huffman only) and 9 (best compression). -1 is the default level of gzip")
+
+	flags.Duration("grpc-keepalive-max-connection-idle", defaultConfig.GRPC.Keepalive.MaxConnectionIdle, "the duration after which an idle connection (i.e. without any RPC) to the grpc server is closed. If 0, the idle connections are never closed")
+
+	flags.Duration("grpc-keepalive-max-connection-age", defaultConfig.GRPC.Keepalive.MaxConnectionAge, "the maximum duration (with a jitter of ±10%) of a connection to the grpc server, after which the clients are asked to reconnect, so that the long-lived connections rebalance across the servers. If 0, the connections are never closed because of their age")
+
+	flags.Duration("grpc-keepalive-max-connection-age-grace", defaultConfig.GRPC.Keepalive.MaxConnectionAgeGrace, "the duration for which the RPCs in progress on a connection that reached its maximum age can complete, before the connection is forcibly closed. If 0, they can always complete")
+
+	flags.Duration("grpc-keepalive-time", defaultConfig.GRPC.Keepalive.Time, "the duration after which the grpc server pings an idle connection to check that it's still alive")
+
+	flags.Duration("grpc-keepalive-timeout", defaultConfig.GRPC.Keepalive.Timeout, "the duration the grpc server waits for the response to a ping before closing the connection")
+
+	flags.Duration("grpc-keepalive-enforcement-min-time", defaultConfig.GRPC.Keepalive.EnforcementMinTime, "the minimum duration between the keepalive pings of the clients. The connections of the clients pinging more often are closed")
+
+	flags.Int("grpc-listener-max-connections", defaultConfig.GRPC.Listener.MaxConnections, "the maximum number of open connections to the grpc server. The connections beyond it are closed as soon as they're accepted. If 0, it's unbounded")
+
+	flags.Float64("grpc-listener-accept-rate", defaultConfig.GRPC.Listener.AcceptRate, "the maximum rate (per second) of the connections accepted by the grpc server. The connections beyond it are closed as soon as they're accepted. If 0, it's unbounded")
+
+	flags.Int("grpc-listener-accept-burst", defaultConfig.GRPC.Listener.AcceptBurst, "the number of connections by which the grpc server can exceed its accept rate in a burst")
+
+	flags.Bool("grpc-keepalive-enforcement-permit-without-stream", defaultConfig.GRPC.Keepalive.EnforcementPermitWithoutStream, "allow the clients to send keepalive pings when there's no RPC in progress on their connection. If false, the connections of the clients doing so are closed")
+
 	flags.Bool("http-enabled", defaultConfig.HTTP.Enabled, "enable/disable the OpenFGA HTTP server")
 
 	flags.String("http-addr", defaultConfig.HTTP.Addr, "the host:port address to serve the HTTP server on")
@@ -111,14 +164,52 @@ func NewRunCommand() *cobra.Command {
 
 	flags.StringSlice("http-cors-allowed-headers", defaultConfig.HTTP.CORSAllowedHeaders, "specifies the CORS allowed headers")
 
+	flags.StringSlice("http-forwarded-headers", defaultConfig.HTTP.ForwardedHeaders, "the headers of the HTTP requests forwarded as is to the grpc endpoint as metadata (e.g. 'X-Tenant-ID'), beyond the default ones")
+
+	flags.Bool("http-h2c-enabled", defaultConfig.HTTP.H2CEnabled, "serve HTTP/2 over cleartext (h2c) on the HTTP server, besides HTTP/1.1, so that the HTTP clients behind L4 load balancers multiplex their requests over one connection. HTTP/2 is always served over TLS")
+
+	flags.Int("http-listener-max-connections", defaultConfig.HTTP.Listener.MaxConnections, "the maximum number of open connections to the HTTP server. The connections beyond it are closed as soon as they're accepted. If 0, it's unbounded")
+
+	flags.Float64("http-listener-accept-rate", defaultConfig.HTTP.Listener.AcceptRate, "the maximum rate (per second) of the connections accepted by the HTTP server. The connections beyond it are closed as soon as they're accepted. If 0, it's unbounded")
+
+	flags.Int("http-listener-accept-burst", defaultConfig.HTTP.Listener.AcceptBurst, "the number of connections by which the HTTP server can exceed its accept rate in a burst")
+
+	flags.Uint32("http-max-concurrent-streams", defaultConfig.HTTP.MaxConcurrentStreams, "the maximum number of concurrent streams (i.e. requests) of each HTTP/2 connection to the HTTP server")
+
 	flags.String("authn-method", defaultConfig.Authn.Method, "the authentication method to use")
 
+	flags.StringSlice("authn-unauthenticated-methods", defaultConfig.Authn.UnauthenticatedMethods, "one or more full gRPC method names (e.g. '/grpc.health.v1.Health/Check') that don't require authentication. A method ending in '*' matches every method with that prefix")
+
 	flags.StringSlice("authn-preshared-keys", defaultConfig.Authn.Keys, "one or more preshared keys to use for authentication")
 
 	flags.String("authn-oidc-audience", defaultConfig.Authn.Audience, "the OIDC audience of the tokens being signed by the authorization server")
 
 	flags.String("authn-oidc-issuer", defaultConfig.Authn.Issuer, "the OIDC issuer (authorization server) signing the tokens")
 
+	flags.StringSlice("authn-signed-signing-keys", defaultConfig.Authn.SigningKeys, "one or more keys, of the form '<key id>:<secret>', to verify request signatures against")
+
+	flags.Duration("authn-signed-max-clock-skew", defaultConfig.Authn.MaxClockSkew, "the maximum allowed difference between the time at which a request was signed and the time at which it is received")
+
+	flags.Int("authn-signed-max-nonces", defaultConfig.Authn.MaxNonces, "the maximum number of nonces remembered, i.e. the maximum number of requests accepted within twice the maximum clock skew. The requests beyond it are rejected")
+
+	flags.StringSlice("request-enrichment-contextual-tuples", defaultConfig.RequestEnrichment.ContextualTuples, "one or more CEL expressions that derive contextual tuples for Check and ListObjects requests from the auth claims of the caller")
+
+	flags.StringSlice("authorization-model-validation-rules", defaultConfig.AuthorizationModelValidation.Rules, "one or more CEL expressions that every authorization model written must satisfy (e.g. naming conventions or mandatory relations)")
+
+	flags.StringSlice("authorization-model-validation-plugins", defaultConfig.AuthorizationModelValidation.Plugins, "the paths of one or more Go plugins that export a 'Validator' of the authorization models written")
+
+	flags.StringSlice("authorization-model-validation-reserved-type-names", defaultConfig.AuthorizationModelValidation.ReservedTypeNames, "one or more regular expressions that the type names of the authorization models written must not match")
+
+	flags.StringSlice("authorization-model-validation-reserved-relation-names", defaultConfig.AuthorizationModelValidation.ReservedRelationNames, "one or more regular expressions that the relation names of the authorization models written must not match")
+
+	flags.Int("authorization-model-validation-max-type-name-length", defaultConfig.AuthorizationModelValidation.MaxTypeNameLength, "the maximum length of the type names of the authorization models written. 0 means no limit")
+
+	flags.Int("authorization-model-validation-max-relation-name-length", defaultConfig.AuthorizationModelValidation.MaxRelationNameLength, "the maximum length of the relation names of the authorization models written. 0 means no limit")
+
+	flags.Bool("authorization-model-validation-disallow-wildcards", defaultConfig.AuthorizationModelValidation.DisallowWildcards, "reject the authorization models whose relations can be directly related to a public wildcard (e.g. '[user:*]')")
+
+	flags.Bool("authorization-model-validation-disallow-intersection-and-exclusion", defaultConfig.AuthorizationModelValidation.DisallowIntersectionAndExclusion, "reject the authorization models whose relations are defined with an intersection ('and') or an exclusion ('but not')")
+
 	flags.String("datastore-engine", defaultConfig.Datastore.Engine, "the datastore engine that will be used for persistence")
 
 	flags.String("datastore-uri", defaultConfig.Datastore.URI, "the connection uri to use to connect to the datastore (for any engine other than 'memory')")
@@ -129,6 +220,10 @@ func NewRunCommand() *cobra.Command {
 
 	flags.Int("datastore-max-cache-size", defaultConfig.Datastore.MaxCacheSize, "the maximum number of cache keys that the storage cache can store before evicting old keys")
 
+	flags.Duration("datastore-latest-model-id-cache-ttl", defaultConfig.Datastore.LatestModelIDCacheTTL, "the duration the ID of the latest authorization model of the stores is cached for. The models written through another server are only seen once it expires. 0 disables the cache")
+
+	flags.Duration("datastore-expired-tuples-reap-interval", defaultConfig.Datastore.ExpiredTuplesReapInterval, "the interval at which the expired tuples are deleted from the datastore. The reads treat them as deleted regardless. 0 disables their deletion")
+
 	flags.Int("datastore-max-open-conns", defaultConfig.Datastore.MaxOpenConns, "the maximum number of open connections to the datastore")
 
 	flags.Int("datastore-max-idle-conns", defaultConfig.Datastore.MaxIdleConns, "the maximum number of connections to the datastore in the idle connection pool")
@@ -139,6 +234,50 @@ func NewRunCommand() *cobra.Command {
 
 	flags.Bool("datastore-metrics-enabled", defaultConfig.Datastore.Metrics.Enabled, "enable/disable sql metrics")
 
+	flags.Bool("auto-migrate", defaultConfig.Datastore.AutoMigrate, "apply the pending datastore schema migrations at startup, before serving. Otherwise the server refuses to start against a datastore whose schema isn't at the version it expects")
+
+	flags.String("datastore-shadow-engine", defaultConfig.Datastore.Shadow.Engine, "the datastore engine of a shadow datastore to which every write is mirrored and against which the reads are compared in the background, e.g. to validate a migration to a new datastore. An empty engine disables the shadow datastore")
+
+	flags.String("datastore-shadow-uri", defaultConfig.Datastore.Shadow.URI, "the connection uri to use to connect to the shadow datastore (for any engine other than 'memory')")
+
+	flags.String("datastore-shadow-username", "", "the connection username to use to connect to the shadow datastore (overwrites any username provided in the connection uri)")
+
+	flags.String("datastore-shadow-password", "", "the connection password to use to connect to the shadow datastore (overwrites any password provided in the connection uri)")
+
+	flags.Duration("datastore-shadow-timeout", defaultConfig.Datastore.Shadow.Timeout, "the maximum duration of a read compared against the shadow datastore")
+
+	flags.Uint32("datastore-shadow-max-concurrency", defaultConfig.Datastore.Shadow.MaxConcurrency, "the maximum number of reads compared against the shadow datastore at any time. The reads that would exceed it aren't compared")
+
+	flags.String("datastore-maintenance-schedule", defaultConfig.Datastore.Maintenance.Schedule, "the cron-like schedule (e.g. '0 3 * * *' or '@every 6h') on which the maintenance tasks of the datastore (e.g. 'vacuum' and 'analyze' for Postgres) run in the background. An empty schedule disables them")
+
+	flags.Duration("datastore-maintenance-jitter", defaultConfig.Datastore.Maintenance.Jitter, "the maximum random delay added to each run of the maintenance tasks of the datastore, so that the servers sharing a datastore don't all run them at the same time")
+
+	flags.StringSlice("datastore-maintenance-tasks", defaultConfig.Datastore.Maintenance.Tasks, "the names of the maintenance tasks of the datastore to run. If empty, all the tasks of the datastore run")
+
+	flags.StringSlice("datastore-replica-uris", defaultConfig.Datastore.Replicas.URIs, "the connection uris of the read replicas of the datastore, which serve the reads of tuples that tolerate stale data. The replicas have the engine and the credentials of the datastore")
+
+	flags.Duration("datastore-replica-hedge-after", defaultConfig.Datastore.Replicas.HedgeAfter, "the latency after which the reads of a replica on the paths of Check are hedged with a read of another replica (or of the datastore, if there's a single replica), the first answer winning. 0 disables hedging")
+
+	flags.Float64("datastore-replica-hedging-budget", defaultConfig.Datastore.Replicas.HedgingBudget, "the maximum fraction of the reads of the replicas that can be hedged")
+
+	flags.StringSlice("datastore-routing-stores", defaultConfig.Datastore.Routing.Stores, "the datastores of single stores, in the form '<store ID>=<uri>', which have the engine and the credentials of the datastore. They take precedence over the prefixes, and the stores that match no route are served by the datastore")
+
+	flags.StringSlice("datastore-routing-prefixes", defaultConfig.Datastore.Routing.Prefixes, "the datastores of the stores whose ID starts with a prefix, in the form '<prefix>=<uri>', which have the engine and the credentials of the datastore. The longest matching prefix wins, and the stores that match no route are served by the datastore")
+
+	flags.String("datastore-residency-region", defaultConfig.Datastore.Residency.Region, "the region the server runs in. If set, the data of every store is pinned to the datastore of the region it resides in, and the server refuses to read the data of the stores of the other regions. The datastore is the one of this region")
+
+	flags.StringSlice("datastore-residency-regions", defaultConfig.Datastore.Residency.Regions, "the datastores of the other regions, in the form '<region>=<uri>', which have the engine and the credentials of the datastore. The stores are created in the region named by the 'openfga-store-residency' header of CreateStore")
+
+	flags.Bool("datastore-circuit-breaker-enabled", defaultConfig.Datastore.CircuitBreaker.Enabled, "enable the circuit breakers of the stores, which fail fast with UNAVAILABLE the requests to the stores whose datastore operations fail too often, while the other stores keep being served")
+
+	flags.Float64("datastore-circuit-breaker-error-rate-threshold", defaultConfig.Datastore.CircuitBreaker.ErrorRateThreshold, "the rate (between 0 and 1) of the datastore operations of a store that fail over a window above which the circuit breaker of the store opens")
+
+	flags.Int("datastore-circuit-breaker-min-requests", defaultConfig.Datastore.CircuitBreaker.MinRequests, "the minimum number of datastore operations of a store over a window before its error rate can open its circuit breaker")
+
+	flags.Duration("datastore-circuit-breaker-window", defaultConfig.Datastore.CircuitBreaker.Window, "the duration of the windows the error rates of the datastore operations of the stores are measured over")
+
+	flags.Duration("datastore-circuit-breaker-open-duration", defaultConfig.Datastore.CircuitBreaker.OpenDuration, "the duration the requests to a store fail fast for once its circuit breaker opens, before the datastore is probed")
+
 	flags.Bool("playground-enabled", defaultConfig.Playground.Enabled, "enable/disable the OpenFGA Playground")
 
 	flags.Int("playground-port", defaultConfig.Playground.Port, "the port to serve the local OpenFGA Playground on")
@@ -151,6 +290,12 @@ func NewRunCommand() *cobra.Command {
 
 	flags.String("log-level", defaultConfig.Log.Level, "the log level to use")
 
+	flags.Uint32("log-sampling-initial", defaultConfig.Log.Sampling.Initial, "every second, the number of log messages with the same level and text that are logged before they're sampled")
+
+	flags.Uint32("log-sampling-thereafter", defaultConfig.Log.Sampling.Thereafter, "once the log messages with the same level and text are sampled, one in this number is logged for the rest of the second. If 0, the log messages aren't sampled")
+
+	flags.String("log-sampling-level", defaultConfig.Log.Sampling.Level, "the highest level whose log messages are sampled (e.g. 'info' samples the 'debug' and 'info' messages)")
+
 	flags.Bool("trace-enabled", defaultConfig.Trace.Enabled, "enable tracing")
 
 	flags.String("trace-otlp-endpoint", defaultConfig.Trace.OTLP.Endpoint, "the endpoint of the trace collector")
@@ -167,16 +312,36 @@ func NewRunCommand() *cobra.Command {
 
 	flags.Bool("metrics-enable-rpc-histograms", defaultConfig.Metrics.EnableRPCHistograms, "enables prometheus histogram metrics for RPC latency distributions")
 
+	flags.Bool("metrics-enable-store-labels", defaultConfig.Metrics.EnableStoreLabels, "enables prometheus metrics labeled by store. Only the allowlisted stores and the busiest stores are labeled with their ID, every other store is labeled 'other'")
+
+	flags.StringSlice("metrics-store-labels-allowlist", defaultConfig.Metrics.StoreLabelsAllowlist, "the IDs of the stores that are always labeled with their ID in the metrics labeled by store")
+
+	flags.Int("metrics-store-labels-top-k", defaultConfig.Metrics.StoreLabelsTopK, "the number of busiest stores, besides the allowlisted ones, that are labeled with their ID in the metrics labeled by store")
+
 	flags.Int("max-tuples-per-write", defaultConfig.MaxTuplesPerWrite, "the maximum allowed number of tuples per Write transaction")
 
 	flags.Int("max-types-per-authorization-model", defaultConfig.MaxTypesPerAuthorizationModel, "the maximum allowed number of type definitions per authorization model")
 
 	flags.Int("max-authorization-model-size-in-bytes", defaultConfig.MaxAuthorizationModelSizeInBytes, "the maximum size in bytes allowed for persisting an Authorization Model.")
 
+	flags.Bool("assertions-copy-forward", defaultConfig.AssertionsCopyForward, "copy the assertions of the latest authorization model of a store to every new authorization model, dropping the assertions that are not valid against the new model")
+
+	flags.Bool("skip-identical-authorization-models", defaultConfig.SkipIdenticalAuthorizationModels, "return the ID of the latest authorization model of a store, instead of writing a new model, when the model written is identical to it (i.e. has the same canonical form). The responses then have the 'openfga-authorization-model-unchanged' header")
+
+	flags.Bool("reject-self-referential-tuples", defaultConfig.RejectSelfReferentialTuples, "reject the writes of the tuples that relate a userset of an object to itself (e.g. 'group:eng#member' as a member of 'group:eng'), or to a relation it's computed from")
+
+	flags.Bool("allow-cache-bypass", defaultConfig.AllowCacheBypass, "let the requests with the 'openfga-no-cache: true' header bypass every cache of the server, and report the caches that would have served them in the 'openfga-bypassed-caches' response header. Meant for debugging stale results")
+
+	flags.StringSlice("status-code-overrides", defaultConfig.StatusCodeOverrides, "overrides of the gRPC and HTTP status codes of classes of errors, of the form '<class>=<gRPC code>:<HTTP status>' (e.g. 'resource_exhausted=UNAVAILABLE:503'), where the class is the name of an error code of the API")
+
+	flags.Duration("store-tombstone-ttl", defaultConfig.StoreTombstoneTTL, "for how long the requests to a store deleted through the server are rejected with a 'store_deleted' error, rather than served as if the store had no data. 0 disables it")
+
 	flags.Uint32("max-concurrent-reads-for-list-objects", defaultConfig.MaxConcurrentReadsForListObjects, "the maximum allowed number of concurrent datastore reads in a single ListObjects query. A high number means that you want ListObjects latency to be low, at the expense of other queries performance")
 
 	flags.Uint32("max-concurrent-reads-for-check", defaultConfig.MaxConcurrentReadsForCheck, "the maximum allowed number of concurrent datastore reads in a single Check query. A high number means that you want Check latency to be low, at the expense of other queries performance")
 
+	flags.Uint32("check-userset-batch-size", defaultConfig.CheckUsersetBatchSize, "the maximum number of usersets of a relation (e.g. 'team:1#member', 'team:2#member') whose membership is looked up in a single datastore query in Check queries. 0 disables batching")
+
 	flags.Int("changelog-horizon-offset", defaultConfig.ChangelogHorizonOffset, "the offset (in minutes) from the current time. Changes that occur after this offset will not be included in the response of ReadChanges")
 
 	flags.Uint32("resolve-node-limit", defaultConfig.ResolveNodeLimit, "maximum resolution depth to attempt before throwing an error (defines how deeply nested an authorization model can be before a query errors out).")
@@ -193,6 +358,116 @@ func NewRunCommand() *cobra.Command {
 
 	flags.Duration("check-query-cache-ttl", defaultConfig.CheckQueryCache.TTL, "if caching of Check and ListObjects is enabled, this is the TTL of each value")
 
+	flags.Float64("check-query-cache-hot-key-qps-threshold", defaultConfig.CheckQueryCache.HotKeyQPSThreshold, "if caching of Check and ListObjects is enabled, this is the Check QPS above which a relation is considered hot. Hot relations are cached for longer and their concurrent evaluations are collapsed. If 0, hot relations are not detected")
+
+	flags.Uint32("check-query-cache-hot-key-ttl-multiplier", defaultConfig.CheckQueryCache.HotKeyTTLMultiplier, "if caching of Check and ListObjects is enabled, this is the factor by which the TTL of hot relations is multiplied")
+
+	flags.Uint32("check-query-cache-negative-limit", defaultConfig.CheckQueryCache.NegativeLimit, "if caching of Check and ListObjects is enabled, this is the size limit of a separate cache for the negative (denied) results, which writes invalidate far more often than the positive ones. If 0, they share the cache of the positive results")
+
+	flags.Duration("check-query-cache-negative-ttl", defaultConfig.CheckQueryCache.NegativeTTL, "if caching of Check and ListObjects is enabled, this is the TTL of the negative (denied) results. If 0, it's the TTL of the positive results")
+
+	flags.Duration("check-query-cache-changelog-interval", defaultConfig.CheckQueryCache.ChangelogInterval, "if caching of Check and ListObjects is enabled, this is the interval at which the changelogs of the stores are read to invalidate the results that the writes of the other servers sharing the datastore could have changed, which bounds how stale these results can be. If 0, only the writes of the server itself invalidate its results")
+
+	flags.StringSlice("check-query-cache-shared-memcached-servers", defaultConfig.CheckQueryCache.SharedMemcachedServers, "if caching of Check and ListObjects is enabled, these are the Memcached servers (e.g. 'memcached:11211') of a cache of the results shared by the servers of a fleet, which is looked up after a miss of the local cache. The writes invalidate every shared result of their store")
+
+	flags.Bool("check-profiling-enabled", defaultConfig.CheckProfiling.Enabled, "enables the profiling of expensive Checks. The resolution trees of a fraction of the Checks that exceed a latency threshold are written out along with their timings")
+
+	flags.Duration("check-profiling-latency-threshold", defaultConfig.CheckProfiling.LatencyThreshold, "if profiling of Checks is enabled, this is the latency above which the resolution tree of a sampled Check is written out")
+
+	flags.Float64("check-profiling-sample-rate", defaultConfig.CheckProfiling.SampleRate, "if profiling of Checks is enabled, this is the fraction (between 0 and 1) of the Checks that are sampled")
+
+	flags.String("check-profiling-file-path", defaultConfig.CheckProfiling.FilePath, "if profiling of Checks is enabled, this is the file to which the resolution trees are appended, one JSON document per line. If empty, the resolution trees are logged")
+
+	flags.Bool("capture-enabled", defaultConfig.Capture.Enabled, "enables the capture of a fraction of the Check and ListObjects requests, along with the authorization model they were resolved with and their results, to replay them against a snapshot of the stores with the replay command")
+
+	flags.Float64("capture-sample-rate", defaultConfig.Capture.SampleRate, "if the capture is enabled, this is the fraction (between 0 and 1) of the Check and ListObjects requests that are captured")
+
+	flags.String("capture-file-path", defaultConfig.Capture.FilePath, "if the capture is enabled, this is the file to which the captured requests are appended, one JSON document per line")
+
+	flags.String("capture-redaction-key", defaultConfig.Capture.RedactionKey, "if the capture is enabled, this is the key the IDs of the objects and of the users of the captured requests are redacted with. The snapshot the captures are replayed against must be redacted with the same key. If empty, nothing is redacted")
+
+	flags.Bool("inline-model-enabled", defaultConfig.InlineModel.Enabled, "enables the Check and ListObjects requests that carry an authorization model inline, in the 'openfga-inline-authorization-model' header, to be evaluated against it, without persisting it, instead of against a model of the store")
+
+	flags.Int("inline-model-max-size-in-bytes", defaultConfig.InlineModel.MaxSizeInBytes, "if the inline models are enabled, this is the maximum size in bytes of the JSON encoding of the inline models")
+
+	flags.Int("batch-check-max-checks", defaultConfig.BatchCheck.MaxChecks, "the maximum number of tuple keys of a BatchCheck call")
+
+	flags.Int("batch-check-max-concurrent-checks", defaultConfig.BatchCheck.MaxConcurrentChecks, "the maximum number of tuple keys of a BatchCheck call that are checked concurrently")
+
+	flags.Uint32("batch-check-max-datastore-queries", defaultConfig.BatchCheck.MaxDatastoreQueries, "the budget of datastore queries shared by the Checks of a BatchCheck call. Once it's spent, the Checks left fail. 0 disables the budget")
+
+	flags.Bool("relation-usage-enabled", defaultConfig.RelationUsage.Enabled, "enables the counting of the Checks of every relation of every store, and of the tuples written with it, over a rolling window, which the admin API reports to find the unused relations")
+
+	flags.Duration("relation-usage-window", defaultConfig.RelationUsage.Window, "if the relation usage is enabled, this is the duration of the rolling window the relations are counted over")
+
+	flags.Int("relation-usage-max-relations", defaultConfig.RelationUsage.MaxRelations, "if the relation usage is enabled, this is the maximum number of relations, across all the stores, that are counted")
+
+	flags.Bool("slo-enabled", defaultConfig.SLO.Enabled, "enables the prometheus metrics measuring the availability and the latency of every RPC against service level objectives")
+
+	flags.Float64("slo-availability-objective", defaultConfig.SLO.AvailabilityObjective, "the ratio (between 0 and 1) of RPCs that must not fail because of a server error")
+
+	flags.Float64("slo-latency-objective", defaultConfig.SLO.LatencyObjective, "the ratio (between 0 and 1) of RPCs that must complete within the latency threshold of their method")
+
+	flags.Duration("slo-latency-threshold", defaultConfig.SLO.LatencyThreshold, "the latency threshold of the methods without a specific one")
+
+	flags.StringSlice("slo-method-latency-thresholds", defaultConfig.SLO.MethodLatencyThresholds, "the latency thresholds of specific methods, in the form '<method>:<threshold>' (e.g. 'Check:100ms')")
+
+	flags.Duration("slo-window", defaultConfig.SLO.Window, "the duration of the sliding window over which the service level indicators are computed")
+
+	flags.Bool("admin-enabled", defaultConfig.Admin.Enabled, "enable/disable the admin HTTP server, which exposes the operational controls of the server (e.g. the maintenance mode, or the statistics and the flush of the caches). When the API is authenticated, its requests must be made with one of 'admin-keys' or by a caller with 'admin-required-claim'")
+
+	flags.StringSlice("admin-keys", defaultConfig.Admin.Keys, "the preshared keys of the operators of the server, the requests to the admin API are accepted with ('Authorization: Bearer <key>'). They must differ from the keys of the API")
+
+	flags.String("admin-required-claim", defaultConfig.Admin.RequiredClaim, "the claim, in the form '<name>=<value>' (e.g. 'role=admin'), the callers of the API authenticated with the 'oidc' method must have to be served by the admin API")
+
+	flags.String("admin-addr", defaultConfig.Admin.Addr, "the host:port address to serve the admin HTTP server on. It should only be reachable by the operators of the server")
+
+	flags.Bool("admin-graphql-enabled", defaultConfig.Admin.GraphQLEnabled, "serve the read APIs (stores, authorization models, tuples, Check and ListObjects) as a GraphQL endpoint on the admin HTTP server, at '/admin/graphql'")
+
+	flags.Bool("shadow-check-enabled", defaultConfig.ShadowCheck.Enabled, "enable the shadow evaluation of Checks against candidate authorization models. The differences with the served results are logged and counted")
+
+	flags.StringSlice("shadow-check-candidates", defaultConfig.ShadowCheck.Candidates, "the candidate authorization models, in the form '<store ID>:<model ID>'. The candidates can also be changed while serving through the admin API")
+
+	flags.Float64("shadow-check-sample-rate", defaultConfig.ShadowCheck.SampleRate, "the fraction (between 0 and 1) of the Checks of the stores with a candidate model that are shadow evaluated")
+
+	flags.Duration("shadow-check-timeout", defaultConfig.ShadowCheck.Timeout, "the timeout of the shadow evaluation of a Check")
+
+	flags.Uint32("shadow-check-max-concurrency", defaultConfig.ShadowCheck.MaxConcurrency, "the maximum number of shadow evaluations in flight. Checks that would exceed it are not shadow evaluated")
+
+	flags.Bool("maintenance-enabled", defaultConfig.Maintenance.Enabled, "start the server in maintenance, rejecting the requests to every store with an UNAVAILABLE error")
+
+	flags.String("maintenance-message", defaultConfig.Maintenance.Message, "the message of the errors returned while undergoing maintenance, unless another one is provided when maintenance is enabled")
+
+	flags.Duration("maintenance-retry-after", defaultConfig.Maintenance.RetryAfter, "the delay after which the clients may retry the requests rejected while undergoing maintenance, unless another one is provided when maintenance is enabled")
+
+	flags.Uint32("load-shedding-max-concurrent-requests", defaultConfig.LoadShedding.MaxConcurrentRequests, "the maximum number of requests served concurrently. The other ones are rejected with a RESOURCE_EXHAUSTED error (429 over HTTP) and a hint of when to retry them. 0 means no limit")
+
+	flags.Duration("load-shedding-retry-after", defaultConfig.LoadShedding.RetryAfter, "the delay after which the clients may retry the requests rejected to shed load")
+
+	flags.Bool("read-guardrails-reject-unfiltered", defaultConfig.ReadGuardrails.RejectUnfiltered, "reject the Read requests without a tuple key, which read every tuple of the store")
+
+	flags.Int("read-guardrails-min-filter-dimensions", defaultConfig.ReadGuardrails.MinFilterDimensions, "the minimum number of dimensions (the object type, the object ID, the relation and the user) that the Read requests to the large stores must filter on. 0 disables the guardrail")
+
+	flags.Int64("read-guardrails-large-store-tuple-count", defaultConfig.ReadGuardrails.LargeStoreTupleCount, "the number of tuples above which a store is large, for the Read guardrails. 0 makes every store large, and so do the datastores which don't maintain the statistics of the stores")
+
+	flags.String("continuation-token-encryption-key", defaultConfig.ContinuationToken.EncryptionKey, "if set, the continuation tokens are encrypted with AES-GCM and bound to the store they're issued for, instead of being encoded in base64. The tokens used with another store or once expired are rejected")
+
+	flags.StringSlice("continuation-token-previous-encryption-keys", defaultConfig.ContinuationToken.PreviousEncryptionKeys, "the keys that encrypted the continuation tokens before the current encryption key, whose tokens are still accepted. A key can be removed once the tokens it encrypted have expired")
+
+	flags.Duration("continuation-token-ttl", defaultConfig.ContinuationToken.TTL, "how long the encrypted continuation tokens are valid for. 0 means they never expire")
+
+	flags.Bool("cluster-enabled", defaultConfig.Cluster.Enabled, "enable the clustering mode, in which the Check sub-problems are dispatched to the member of the cluster that owns the hash of their store and object, to concentrate the results of each sub-problem in the cache of a single member. It requires the 'none' or 'preshared' authn method")
+
+	flags.String("cluster-advertise-address", defaultConfig.Cluster.AdvertiseAddress, "the gRPC address at which the other members of the cluster reach this one, e.g. 'openfga-0:8081'")
+
+	flags.StringSlice("cluster-peers", defaultConfig.Cluster.Peers, "the gRPC addresses of the members of the cluster. They may include the advertise address")
+
+	flags.Duration("cluster-probe-interval", defaultConfig.Cluster.ProbeInterval, "the interval at which the health of the peers of the cluster is probed. The unhealthy peers don't own any sub-problem until they're healthy again")
+
+	flags.Uint32("cluster-dispatch-retries", defaultConfig.Cluster.DispatchRetries, "the number of times a dispatch to an unavailable peer is retried before the sub-problem is resolved locally")
+
+	flags.Bool("audit-log-enabled", defaultConfig.AuditLog.Enabled, "log the changes to the stores (the stores created and deleted, the authorization models and the tuples written)")
+
 	// Unfortunately UintSlice/IntSlice does not work well when used as environment variable, we need to stick with string slice and convert back to integer
 	flags.StringSlice("request-duration-datastore-query-count-buckets", defaultConfig.RequestDurationDatastoreQueryCountBuckets, "datastore query count buckets used in labelling request duration by query count histogram")
 
@@ -228,9 +503,12 @@ func MustDefaultConfigWithRandomPorts() *serverconfig.Config {
 	defer httpPortReleaser()
 	grpcPort, grpcPortReleaser := TCPRandomPort()
 	defer grpcPortReleaser()
+	adminPort, adminPortReleaser := TCPRandomPort()
+	defer adminPortReleaser()
 
 	config.GRPC.Addr = fmt.Sprintf("0.0.0.0:%d", grpcPort)
 	config.HTTP.Addr = fmt.Sprintf("0.0.0.0:%d", httpPort)
+	config.Admin.Addr = fmt.Sprintf("127.0.0.1:%d", adminPort)
 
 	return config
 }
@@ -266,7 +544,14 @@ func run(_ *cobra.Command, _ []string) {
 		panic(err)
 	}
 
-	logger := logger.MustNewLogger(config.Log.Format, config.Log.Level)
+	samplingLevel, err := zapcore.ParseLevel(config.Log.Sampling.Level)
+	if err != nil {
+		panic(err)
+	}
+
+	logger := logger.MustNewLogger(config.Log.Format, config.Log.Level,
+		logger.WithSampling(int(config.Log.Sampling.Initial), int(config.Log.Sampling.Thereafter), samplingLevel),
+	)
 
 	serverCtx := &ServerContext{Logger: logger}
 	if err := serverCtx.Run(context.Background(), config); err != nil {
@@ -290,8 +575,155 @@ func convertStringArrayToUintArray(stringArray []string) []uint {
 	return uintArray
 }
 
+// newDatastore constructs the datastore of the provided engine and checks that its schema is at the version
+// expected by the server, migrating it first if auto-migration is enabled.
+func (s *ServerContext) newDatastore(ctx context.Context, config *serverconfig.Config, engine, uri string, options ...sqlcommon.DatastoreOption) (storage.OpenFGADatastore, error) {
+	dsCfg := sqlcommon.NewConfig(options...)
+
+	var datastore storage.OpenFGADatastore
+	var err error
+	switch engine {
+	case "memory":
+		opts := []memory.StorageOption{
+			memory.WithMaxTypesPerAuthorizationModel(config.MaxTypesPerAuthorizationModel),
+			memory.WithMaxTuplesPerWrite(config.MaxTuplesPerWrite),
+			memory.WithExpiredTuplesReapInterval(dsCfg.ExpiredTuplesReapInterval),
+		}
+		datastore = memory.New(opts...)
+	case "mysql":
+		datastore, err = mysql.New(uri, dsCfg)
+		if err != nil {
+			return nil, fmt.Errorf("initialize mysql datastore: %w", err)
+		}
+	case "postgres":
+		datastore, err = postgres.New(uri, dsCfg)
+		if err != nil {
+			return nil, fmt.Errorf("initialize postgres datastore: %w", err)
+		}
+	case "sqlite":
+		datastore, err = sqlite.New(uri, dsCfg)
+		if err != nil {
+			return nil, fmt.Errorf("initialize sqlite datastore: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("storage engine '%s' is unsupported", engine)
+	}
+
+	if migrator, ok := datastore.(storage.SchemaMigrator); ok {
+		if config.Datastore.AutoMigrate {
+			s.Logger.Info(fmt.Sprintf("applying the pending '%v' datastore schema migrations", engine))
+			if err := migrator.Migrate(ctx); err != nil {
+				datastore.Close()
+				return nil, fmt.Errorf("migrate the datastore schema: %w", err)
+			}
+		}
+
+		if err := storage.CheckSchemaVersion(ctx, migrator); err != nil {
+			datastore.Close()
+			return nil, err
+		}
+	}
+
+	return datastore, nil
+}
+
+// newRoutingDatastore returns a datastore that routes the stores of the routes of the config to their
+// datastores, and the others to the fallback. The datastores of the routes are the primary datastores of their
+// stores, and are created with the options. The routes to the same uri share a datastore, and the fallback is
+// closed if they can't be created.
+func (s *ServerContext) newRoutingDatastore(ctx context.Context, config *serverconfig.Config, fallback storage.OpenFGADatastore, options ...sqlcommon.DatastoreOption) (storage.OpenFGADatastore, error) {
+	storeURIs, err := config.Datastore.Routing.ParseStores()
+	if err != nil {
+		fallback.Close()
+		return nil, err
+	}
+
+	prefixURIs, err := config.Datastore.Routing.ParsePrefixes()
+	if err != nil {
+		fallback.Close()
+		return nil, err
+	}
+
+	// [uri] => datastore
+	routeDatastores := map[string]storage.OpenFGADatastore{}
+	routeDatastore := func(uri string) (storage.OpenFGADatastore, error) {
+		if ds, ok := routeDatastores[uri]; ok {
+			return ds, nil
+		}
+
+		ds, err := s.newDatastore(ctx, config, config.Datastore.Engine, uri, options...)
+		if err != nil {
+			return nil, err
+		}
+		routeDatastores[uri] = ds
+
+		return ds, nil
+	}
+	closeAll := func() {
+		for _, ds := range routeDatastores {
+			ds.Close()
+		}
+		fallback.Close()
+	}
+
+	routingOptions := make([]storagewrappers.RoutingDatastoreOption, 0, len(storeURIs)+len(prefixURIs))
+	for storeID, uri := range storeURIs {
+		ds, err := routeDatastore(uri)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("initialize the datastore of the store '%s': %w", storeID, err)
+		}
+		routingOptions = append(routingOptions, storagewrappers.WithStoreRoute(storeID, ds))
+	}
+	for prefix, uri := range prefixURIs {
+		ds, err := routeDatastore(uri)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("initialize the datastore of the stores prefixed with '%s': %w", prefix, err)
+		}
+		routingOptions = append(routingOptions, storagewrappers.WithStorePrefixRoute(prefix, ds))
+	}
+
+	s.Logger.Info(fmt.Sprintf("routing %d stores and %d store prefixes to %d other datastores", len(storeURIs), len(prefixURIs), len(routeDatastores)))
+	return storagewrappers.NewRoutingDatastore(fallback, routingOptions...), nil
+}
+
+// listen listens on the TCP address, shedding the connections beyond the limits of the config, if any. The
+// name labels the metrics of the shed connections.
+func listen(addr, name string, cfg serverconfig.ListenerConfig) (net.Listener, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.MaxConnections == 0 && cfg.AcceptRate == 0 {
+		return lis, nil
+	}
+
+	return loadshed.NewListener(lis, name,
+		loadshed.WithMaxConnections(cfg.MaxConnections),
+		loadshed.WithAcceptRate(cfg.AcceptRate, cfg.AcceptBurst),
+	), nil
+}
+
+// configureHTTP2 serves HTTP/2 on the server, with the maximum number of concurrent streams of the config: over
+// TLS, where it's negotiated, and over cleartext (h2c) if it's enabled.
+func configureHTTP2(srv *http.Server, cfg serverconfig.HTTPConfig) error {
+	h2s := &http2.Server{MaxConcurrentStreams: cfg.MaxConcurrentStreams}
+	if err := http2.ConfigureServer(srv, h2s); err != nil {
+		return fmt.Errorf("failed to configure HTTP/2: %w", err)
+	}
+
+	if cfg.H2CEnabled {
+		srv.Handler = h2c.NewHandler(srv.Handler, h2s)
+	}
+
+	return nil
+}
+
 func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) error {
 	otel.SetTracerProvider(noop.NewTracerProvider())
+	telemetry.SetTextMapPropagator()
 
 	var tracerProviderCloser func()
 
@@ -337,37 +769,129 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		sqlcommon.WithMaxIdleConns(config.Datastore.MaxIdleConns),
 		sqlcommon.WithConnMaxIdleTime(config.Datastore.ConnMaxIdleTime),
 		sqlcommon.WithConnMaxLifetime(config.Datastore.ConnMaxLifetime),
+		// the expired tuples are only deleted from the primary datastore
+		sqlcommon.WithExpiredTuplesReapInterval(0),
 	}
 
 	if config.Datastore.Metrics.Enabled {
 		datastoreOptions = append(datastoreOptions, sqlcommon.WithMetrics())
 	}
 
-	dsCfg := sqlcommon.NewConfig(datastoreOptions...)
+	primaryDatastoreOptions := append(slices.Clone(datastoreOptions), sqlcommon.WithExpiredTuplesReapInterval(config.Datastore.ExpiredTuplesReapInterval))
+	datastore, err := s.newDatastore(ctx, config, config.Datastore.Engine, config.Datastore.URI, primaryDatastoreOptions...)
+	if err != nil {
+		return err
+	}
 
-	var datastore storage.OpenFGADatastore
-	var err error
-	switch config.Datastore.Engine {
-	case "memory":
-		opts := []memory.StorageOption{
-			memory.WithMaxTypesPerAuthorizationModel(config.MaxTypesPerAuthorizationModel),
-			memory.WithMaxTuplesPerWrite(config.MaxTuplesPerWrite),
+	if config.Datastore.Shadow.Engine != "" {
+		shadowDatastoreOptions := append(datastoreOptions,
+			sqlcommon.WithUsername(config.Datastore.Shadow.Username),
+			sqlcommon.WithPassword(config.Datastore.Shadow.Password),
+		)
+
+		shadowDatastore, err := s.newDatastore(ctx, config, config.Datastore.Shadow.Engine, config.Datastore.Shadow.URI, shadowDatastoreOptions...)
+		if err != nil {
+			datastore.Close()
+			return fmt.Errorf("initialize shadow datastore: %w", err)
 		}
-		datastore = memory.New(opts...)
-	case "mysql":
-		datastore, err = mysql.New(config.Datastore.URI, dsCfg)
+
+		s.Logger.Info(fmt.Sprintf("mirroring the datastore operations to a shadow '%v' datastore", config.Datastore.Shadow.Engine))
+		datastore = storagewrappers.NewShadowDatastore(datastore, shadowDatastore,
+			storagewrappers.WithShadowLogger(s.Logger),
+			storagewrappers.WithShadowTimeout(config.Datastore.Shadow.Timeout),
+			storagewrappers.WithShadowMaxConcurrency(config.Datastore.Shadow.MaxConcurrency),
+		)
+	}
+
+	if len(config.Datastore.Replicas.URIs) > 0 {
+		replicas := make([]storage.OpenFGADatastore, 0, len(config.Datastore.Replicas.URIs))
+		for _, uri := range config.Datastore.Replicas.URIs {
+			replica, err := s.newDatastore(ctx, config, config.Datastore.Engine, uri, datastoreOptions...)
+			if err != nil {
+				for _, replica := range replicas {
+					replica.Close()
+				}
+				datastore.Close()
+				return fmt.Errorf("initialize datastore replica: %w", err)
+			}
+			replicas = append(replicas, replica)
+		}
+
+		s.Logger.Info(fmt.Sprintf("reading from %d datastore replicas", len(replicas)))
+		datastore = storagewrappers.NewReplicaRoutingDatastore(datastore, replicas,
+			storagewrappers.WithHedging(config.Datastore.Replicas.HedgeAfter, config.Datastore.Replicas.HedgingBudget),
+		)
+	}
+
+	if len(config.Datastore.Routing.Stores)+len(config.Datastore.Routing.Prefixes) > 0 {
+		datastore, err = s.newRoutingDatastore(ctx, config, datastore, primaryDatastoreOptions...)
 		if err != nil {
-			return fmt.Errorf("initialize mysql datastore: %w", err)
+			return err
 		}
-	case "postgres":
-		datastore, err = postgres.New(config.Datastore.URI, dsCfg)
+	}
+
+	if config.Datastore.Residency.Region != "" {
+		regionURIs, err := config.Datastore.Residency.ParseRegions()
 		if err != nil {
-			return fmt.Errorf("initialize postgres datastore: %w", err)
+			datastore.Close()
+			return err
 		}
-	default:
-		return fmt.Errorf("storage engine '%s' is unsupported", config.Datastore.Engine)
+
+		regionDatastores := make([]storage.OpenFGADatastore, 0, len(regionURIs))
+		residencyOptions := make([]storagewrappers.ResidencyDatastoreOption, 0, len(regionURIs))
+		for region, uri := range regionURIs {
+			regionDatastore, err := s.newDatastore(ctx, config, config.Datastore.Engine, uri, datastoreOptions...)
+			if err != nil {
+				for _, regionDatastore := range regionDatastores {
+					regionDatastore.Close()
+				}
+				datastore.Close()
+				return fmt.Errorf("initialize the datastore of the region '%s': %w", region, err)
+			}
+			regionDatastores = append(regionDatastores, regionDatastore)
+			residencyOptions = append(residencyOptions, storagewrappers.WithRegion(region, regionDatastore))
+		}
+
+		s.Logger.Info(fmt.Sprintf("pinning the data of the stores to their region, serving the region '%s' out of %d regions", config.Datastore.Residency.Region, len(regionURIs)+1))
+		datastore = storagewrappers.NewResidencyDatastore(config.Datastore.Residency.Region, datastore, residencyOptions...)
 	}
-	datastore = storagewrappers.NewCachedOpenFGADatastore(storagewrappers.NewContextWrapper(datastore), config.Datastore.MaxCacheSize)
+
+	if config.Datastore.CircuitBreaker.Enabled {
+		s.Logger.Info("datastore circuit breakers enabled")
+		datastore = storagewrappers.NewCircuitBreakerDatastore(datastore,
+			storagewrappers.WithCircuitBreakerErrorRateThreshold(config.Datastore.CircuitBreaker.ErrorRateThreshold),
+			storagewrappers.WithCircuitBreakerMinRequests(config.Datastore.CircuitBreaker.MinRequests),
+			storagewrappers.WithCircuitBreakerWindow(config.Datastore.CircuitBreaker.Window),
+			storagewrappers.WithCircuitBreakerOpenDuration(config.Datastore.CircuitBreaker.OpenDuration),
+			storagewrappers.WithCircuitBreakerLogger(s.Logger),
+		)
+	}
+
+	eventBus := events.NewBus(events.WithLogger(s.Logger))
+	defer eventBus.Close()
+
+	if config.AuditLog.Enabled {
+		s.Logger.Info("audit log enabled")
+		eventBus.Subscribe(events.LogHandler(s.Logger),
+			events.StoreCreated,
+			events.StoreDeleted,
+			events.AuthorizationModelWritten,
+			events.TuplesWritten,
+		)
+	}
+
+	cachedDatastore := storagewrappers.NewCachedOpenFGADatastore(storagewrappers.NewContextWrapper(datastore), config.Datastore.MaxCacheSize,
+		storagewrappers.WithCacheEvents(eventBus),
+		storagewrappers.WithLatestModelIDCacheTTL(config.Datastore.LatestModelIDCacheTTL),
+	)
+	datastore = cachedDatastore
+
+	// the optional backends of the datastore are served through all its wrappers
+	statsProvider, _ := storage.As[storage.StatsProvider](datastore)
+	conditionsBackend, _ := storage.As[storage.ConditionsBackend](datastore)
+	expirationBackend, _ := storage.As[storage.TupleExpirationBackend](datastore)
+	preconditionsBackend, _ := storage.As[storage.PreconditionsBackend](datastore)
+	maintainer, _ := storage.As[storage.Maintainer](datastore)
 
 	s.Logger.Info(fmt.Sprintf("using '%v' storage engine", config.Datastore.Engine))
 
@@ -382,6 +906,13 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 	case "oidc":
 		s.Logger.Info("using 'oidc' authentication")
 		authenticator, err = oidc.NewRemoteOidcAuthenticator(config.Authn.Issuer, config.Authn.Audience)
+	case "signed":
+		s.Logger.Info("using 'signed' authentication")
+		authenticator, err = signedrequest.NewSignedRequestAuthenticator(
+			config.Authn.SigningKeys,
+			signedrequest.WithMaxClockSkew(config.Authn.MaxClockSkew),
+			signedrequest.WithMaxNonces(config.Authn.MaxNonces),
+		)
 	default:
 		return fmt.Errorf("unsupported authentication method '%v'", config.Authn.Method)
 	}
@@ -389,11 +920,54 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		return fmt.Errorf("failed to initialize authenticator: %w", err)
 	}
 
+	maintenanceMode := maintenance.NewMode(
+		maintenance.WithMessage(config.Maintenance.Message),
+		maintenance.WithRetryAfter(config.Maintenance.RetryAfter),
+	)
+	if config.Maintenance.Enabled {
+		maintenanceMode.Enable("", "", 0)
+	}
+
+	var tombstones *tombstone.Tombstones
+	if config.StoreTombstoneTTL > 0 {
+		tombstones = tombstone.NewTombstones(tombstone.WithTTL(config.StoreTombstoneTTL))
+		eventBus.Subscribe(tombstones.HandleStoreDeleted, events.StoreDeleted)
+	}
+
 	var serverOpts []grpc.ServerOption
+	var storeLabeler *storemetrics.StoreLabeler
+
+	serverOpts = append(serverOpts,
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle:     config.GRPC.Keepalive.MaxConnectionIdle,
+			MaxConnectionAge:      config.GRPC.Keepalive.MaxConnectionAge,
+			MaxConnectionAgeGrace: config.GRPC.Keepalive.MaxConnectionAgeGrace,
+			Time:                  config.GRPC.Keepalive.Time,
+			Timeout:               config.GRPC.Keepalive.Timeout,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             config.GRPC.Keepalive.EnforcementMinTime,
+			PermitWithoutStream: config.GRPC.Keepalive.EnforcementPermitWithoutStream,
+		}),
+	)
+
+	statusCodeOverrides, err := serverErrors.ParseStatusCodeOverrides(config.StatusCodeOverrides)
+	if err != nil {
+		return err
+	}
+	serverErrors.SetStatusCodeOverrides(statusCodeOverrides)
+
+	if len(statusCodeOverrides) > 0 {
+		// the overrides come first, so that they apply to the errors of the other interceptors
+		serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(statuscodes.NewUnaryInterceptor()))
+		serverOpts = append(serverOpts, grpc.ChainStreamInterceptor(statuscodes.NewStreamingInterceptor()))
+	}
 
 	serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(
 		[]grpc.UnaryServerInterceptor{
 			requestid.NewUnaryInterceptor(),
+			tracecontext.NewUnaryInterceptor(),
+			maintenance.NewUnaryInterceptor(maintenanceMode),
 			validator.UnaryServerInterceptor(),
 			grpc_ctxtags.UnaryServerInterceptor(),
 		}...,
@@ -402,11 +976,39 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 	serverOpts = append(serverOpts, grpc.ChainStreamInterceptor(
 		[]grpc.StreamServerInterceptor{
 			requestid.NewStreamingInterceptor(),
+			tracecontext.NewStreamingInterceptor(),
+			maintenance.NewStreamingInterceptor(maintenanceMode),
 			validator.StreamServerInterceptor(),
 			grpc_ctxtags.StreamServerInterceptor(),
 		}...,
 	))
 
+	if tombstones != nil {
+		serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(tombstone.NewUnaryInterceptor(tombstones)))
+		serverOpts = append(serverOpts, grpc.ChainStreamInterceptor(tombstone.NewStreamingInterceptor(tombstones)))
+	}
+
+	serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(consistency.NewUnaryInterceptor()))
+	serverOpts = append(serverOpts, grpc.ChainStreamInterceptor(consistency.NewStreamingInterceptor()))
+
+	if config.AllowCacheBypass {
+		s.Logger.Warn(fmt.Sprintf("the requests with the '%s' header bypass every cache, at the expense of their latency and of the datastore load", cachebypass.NoCacheHeader))
+
+		serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(cachebypass.NewUnaryInterceptor()))
+		serverOpts = append(serverOpts, grpc.ChainStreamInterceptor(cachebypass.NewStreamingInterceptor()))
+	}
+
+	// the limiter is shared with the GraphQL endpoint, which calls the server directly
+	var concurrencyLimiter *loadshed.ConcurrencyLimiter
+	if config.LoadShedding.MaxConcurrentRequests > 0 {
+		concurrencyLimiter = loadshed.NewConcurrencyLimiter(
+			config.LoadShedding.MaxConcurrentRequests,
+			loadshed.WithRetryAfter(config.LoadShedding.RetryAfter),
+		)
+		serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(loadshed.NewUnaryInterceptor(concurrencyLimiter)))
+		serverOpts = append(serverOpts, grpc.ChainStreamInterceptor(loadshed.NewStreamingInterceptor(concurrencyLimiter)))
+	}
+
 	if config.Metrics.Enabled {
 		serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(grpc_prometheus.UnaryServerInterceptor))
 		serverOpts = append(serverOpts, grpc.ChainStreamInterceptor(grpc_prometheus.StreamServerInterceptor))
@@ -414,6 +1016,47 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		if config.Metrics.EnableRPCHistograms {
 			grpc_prometheus.EnableHandlingTimeHistogram()
 		}
+
+		if config.SLO.Enabled {
+			methodLatencyThresholds, err := config.SLO.ParseMethodLatencyThresholds()
+			if err != nil {
+				return err
+			}
+
+			sloOpts := []slo.TrackerOpt{
+				slo.WithAvailabilityObjective(config.SLO.AvailabilityObjective),
+				slo.WithLatencyObjective(config.SLO.LatencyObjective),
+				slo.WithLatencyThreshold(config.SLO.LatencyThreshold),
+				slo.WithWindow(config.SLO.Window),
+			}
+			for method, threshold := range methodLatencyThresholds {
+				sloOpts = append(sloOpts, slo.WithMethodLatencyThreshold(method, threshold))
+			}
+
+			sloTracker := slo.NewTracker(sloOpts...)
+			if err := prometheus.Register(sloTracker); err != nil {
+				alreadyRegistered := prometheus.AlreadyRegisteredError{}
+				if !errors.As(err, &alreadyRegistered) {
+					return fmt.Errorf("failed to register the slo metrics: %w", err)
+				}
+
+				// keep reporting to the tracker that is already registered, e.g. when the server is restarted in-process
+				sloTracker = alreadyRegistered.ExistingCollector.(*slo.Tracker)
+			}
+
+			serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(slo.NewUnaryInterceptor(sloTracker)))
+			serverOpts = append(serverOpts, grpc.ChainStreamInterceptor(slo.NewStreamingInterceptor(sloTracker)))
+		}
+
+		if config.Metrics.EnableStoreLabels {
+			storeLabeler = storemetrics.NewStoreLabeler(
+				storemetrics.WithAllowlist(config.Metrics.StoreLabelsAllowlist),
+				storemetrics.WithTopK(config.Metrics.StoreLabelsTopK),
+			)
+
+			serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(storemetrics.NewUnaryInterceptor(storeLabeler)))
+			serverOpts = append(serverOpts, grpc.ChainStreamInterceptor(storemetrics.NewStreamingInterceptor(storeLabeler)))
+		}
 	}
 
 	if config.Trace.Enabled {
@@ -424,13 +1067,13 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		[]grpc.UnaryServerInterceptor{
 			storeid.NewUnaryInterceptor(),
 			logging.NewLoggingInterceptor(s.Logger),
-			grpcauth.UnaryServerInterceptor(authnmw.AuthFunc(authenticator)),
+			authnmw.NewUnaryInterceptor(authenticator, config.Authn.UnauthenticatedMethods),
 		}...,
 	))
 
 	serverOpts = append(serverOpts, grpc.ChainStreamInterceptor(
 		[]grpc.StreamServerInterceptor{
-			grpcauth.StreamServerInterceptor(authnmw.AuthFunc(authenticator)),
+			authnmw.NewStreamingInterceptor(authenticator, config.Authn.UnauthenticatedMethods),
 			// The following interceptors wrap the server stream with our own
 			// wrapper and must come last.
 			storeid.NewStreamingInterceptor(),
@@ -438,6 +1081,57 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		}...,
 	))
 
+	if config.Authn.Method == "signed" {
+		// The content digest interceptors must run after the authentication interceptors.
+		requiresAuthentication := authnmw.RequiresAuthentication(config.Authn.UnauthenticatedMethods)
+		serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(selector.UnaryServerInterceptor(signedrequest.NewUnaryInterceptor(), requiresAuthentication)))
+		serverOpts = append(serverOpts, grpc.ChainStreamInterceptor(selector.StreamServerInterceptor(signedrequest.NewStreamingInterceptor(), requiresAuthentication)))
+	}
+
+	if len(config.RequestEnrichment.ContextualTuples) > 0 {
+		enricher, err := enrichment.NewEnricher(config.RequestEnrichment.ContextualTuples)
+		if err != nil {
+			return fmt.Errorf("failed to initialize request enrichment: %w", err)
+		}
+
+		s.Logger.Info(fmt.Sprintf("enriching requests with %d contextual tuple expression(s)", len(config.RequestEnrichment.ContextualTuples)))
+
+		// The enrichment interceptors must run after the authentication interceptors.
+		serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(enrichment.NewUnaryInterceptor(enricher)))
+		serverOpts = append(serverOpts, grpc.ChainStreamInterceptor(enrichment.NewStreamingInterceptor(enricher)))
+	}
+
+	// the level applies to every gzip compressed response, including those of the clients that compress their
+	// requests with gzip
+	if err := compression.SetGzipLevel(config.GRPC.GzipLevel); err != nil {
+		return fmt.Errorf("failed to set the gzip level: %w", err)
+	}
+
+	if config.GRPC.StreamCompressor != "" {
+		serverOpts = append(serverOpts, grpc.ChainStreamInterceptor(compression.NewStreamingInterceptor(config.GRPC.StreamCompressor)))
+	}
+
+	var captureSink *capture.FileSink
+	if config.Capture.Enabled {
+		captureSink, err = capture.NewFileSink(config.Capture.FilePath)
+		if err != nil {
+			return err
+		}
+
+		captureOpts := []capture.CapturerOpt{
+			capture.WithSampleRate(config.Capture.SampleRate),
+			capture.WithLogger(s.Logger),
+		}
+		if config.Capture.RedactionKey != "" {
+			captureOpts = append(captureOpts, capture.WithRedactor(capture.NewHMACRedactor(config.Capture.RedactionKey)))
+		} else {
+			s.Logger.Warn("the captured requests aren't redacted, so the capture file holds the IDs of the objects and of the users")
+		}
+
+		// The capture interceptor must run after the interceptors that change the requests.
+		serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(capture.NewUnaryInterceptor(capture.NewCapturer(captureSink, captureOpts...))))
+	}
+
 	if config.GRPC.TLS.Enabled {
 		if config.GRPC.TLS.CertPath == "" || config.GRPC.TLS.KeyPath == "" {
 			return errors.New("'grpc.tls.cert' and 'grpc.tls.key' configs must be set")
@@ -487,10 +1181,112 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		}()
 	}
 
+	var shadowCheckCandidates *server.ShadowCheckCandidates
+	if config.ShadowCheck.Enabled {
+		candidates, err := config.ShadowCheck.ParseCandidates()
+		if err != nil {
+			return err
+		}
+
+		s.Logger.Info(fmt.Sprintf("🪞 shadow evaluation of Checks enabled for %d stores, sampling %v of the Checks", len(candidates), config.ShadowCheck.SampleRate))
+		shadowCheckCandidates = server.NewShadowCheckCandidates(candidates)
+	}
+
+	var checkProfileSink graph.ProfileSink
+	var checkProfileFileSink *graph.FileProfileSink
+	if config.CheckProfiling.Enabled {
+		checkProfileSink = graph.NewLoggerProfileSink(s.Logger)
+
+		if config.CheckProfiling.FilePath != "" {
+			checkProfileFileSink, err = graph.NewFileProfileSink(config.CheckProfiling.FilePath)
+			if err != nil {
+				return err
+			}
+			checkProfileSink = checkProfileFileSink
+		}
+	}
+
+	var clusterDispatcher *cluster.Dispatcher
+	if config.Cluster.Enabled {
+		var dialOpts []grpc.DialOption
+		if config.GRPC.TLS.Enabled {
+			creds, err := credentials.NewClientTLSFromFile(config.GRPC.TLS.CertPath, "")
+			if err != nil {
+				return err
+			}
+			dialOpts = append(dialOpts, grpc.WithTransportCredentials(creds))
+		}
+
+		clusterPool := cluster.NewConnPool(dialOpts...)
+		defer clusterPool.Close()
+
+		membership := cluster.NewMembership(config.Cluster.AdvertiseAddress, config.Cluster.Peers, clusterPool,
+			cluster.WithProbeInterval(config.Cluster.ProbeInterval),
+			cluster.WithLogger(s.Logger),
+		)
+		membership.Start()
+		defer membership.Stop()
+
+		dispatcherOpts := []cluster.DispatcherOption{
+			cluster.WithDispatchRetries(int(config.Cluster.DispatchRetries)),
+			cluster.WithDispatcherLogger(s.Logger),
+		}
+		if config.Authn.Method == "preshared" {
+			dispatcherOpts = append(dispatcherOpts, cluster.WithPresharedKey(config.Authn.AuthnPresharedKeyConfig.Keys[0]))
+		}
+
+		s.Logger.Info(fmt.Sprintf("clustering mode enabled, advertising '%s' to %d peers", config.Cluster.AdvertiseAddress, len(config.Cluster.Peers)))
+		clusterDispatcher = cluster.NewDispatcher(membership, clusterPool, dispatcherOpts...)
+	}
+
+	var checkSharedCache graph.SharedCheckCache
+	if len(config.CheckQueryCache.SharedMemcachedServers) > 0 {
+		s.Logger.Info(fmt.Sprintf("sharing the check query cache through %d Memcached servers", len(config.CheckQueryCache.SharedMemcachedServers)))
+		checkSharedCache = sharedcache.NewMemcachedCheckCache(config.CheckQueryCache.SharedMemcachedServers)
+	}
+
+	var modelValidator modelvalidation.Validator
+	if len(config.AuthorizationModelValidation.Rules) > 0 || len(config.AuthorizationModelValidation.Plugins) > 0 {
+		modelValidator, err = modelvalidation.New(config.AuthorizationModelValidation.Rules, config.AuthorizationModelValidation.Plugins)
+		if err != nil {
+			return fmt.Errorf("failed to initialize authorization model validation: %w", err)
+		}
+
+		s.Logger.Info(fmt.Sprintf("validating the authorization models with %d rule(s) and %d plugin(s)", len(config.AuthorizationModelValidation.Rules), len(config.AuthorizationModelValidation.Plugins)))
+	}
+
+	namingPolicy, err := config.AuthorizationModelValidation.NamingPolicy()
+	if err != nil {
+		return err
+	}
+
+	var tokenEncoder encoder.Encoder = encoder.NewBase64Encoder()
+	if config.ContinuationToken.EncryptionKey != "" {
+		aesGCMEncoder, err := encoder.NewAESGCMEncoder(config.ContinuationToken.EncryptionKey, config.ContinuationToken.TTL,
+			encoder.WithPreviousKeys(config.ContinuationToken.PreviousEncryptionKeys...),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to initialize the continuation token encryption: %w", err)
+		}
+
+		s.Logger.Info(fmt.Sprintf("encrypting the continuation tokens with the key '%s', valid for %s, and accepting the ones encrypted with %d previous key(s)",
+			aesGCMEncoder.KeyID(), config.ContinuationToken.TTL, len(config.ContinuationToken.PreviousEncryptionKeys)))
+		tokenEncoder = aesGCMEncoder
+	}
+
+	var relationUsageTracker *server.RelationUsageTracker
+	if config.RelationUsage.Enabled {
+		relationUsageTracker = server.NewRelationUsageTracker(
+			server.WithRelationUsageWindow(config.RelationUsage.Window),
+			server.WithRelationUsageMaxRelations(config.RelationUsage.MaxRelations),
+		)
+	}
+
 	svr := server.MustNewServerWithOpts(
 		server.WithDatastore(datastore),
 		server.WithLogger(s.Logger),
 		server.WithTransport(gateway.NewRPCTransport(s.Logger)),
+		server.WithTokenEncoder(tokenEncoder),
 		server.WithResolveNodeLimit(config.ResolveNodeLimit),
 		server.WithResolveNodeBreadthLimit(config.ResolveNodeBreadthLimit),
 		server.WithChangelogHorizonOffset(config.ChangelogHorizonOffset),
@@ -498,14 +1294,110 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		server.WithListObjectsMaxResults(config.ListObjectsMaxResults),
 		server.WithMaxConcurrentReadsForListObjects(config.MaxConcurrentReadsForListObjects),
 		server.WithMaxConcurrentReadsForCheck(config.MaxConcurrentReadsForCheck),
+		server.WithCheckUsersetBatchSize(config.CheckUsersetBatchSize),
+		server.WithStatsProvider(statsProvider),
+		server.WithConditionsBackend(conditionsBackend),
+		server.WithTupleExpirationBackend(expirationBackend),
+		server.WithPreconditionsBackend(preconditionsBackend),
+		server.WithReadGuardrails(commands.ReadGuardrails{
+			RejectUnfiltered:     config.ReadGuardrails.RejectUnfiltered,
+			MinFilterDimensions:  config.ReadGuardrails.MinFilterDimensions,
+			LargeStoreTupleCount: config.ReadGuardrails.LargeStoreTupleCount,
+		}),
 		server.WithCheckQueryCacheEnabled(config.CheckQueryCache.Enabled),
 		server.WithCheckQueryCacheLimit(config.CheckQueryCache.Limit),
 		server.WithCheckQueryCacheTTL(config.CheckQueryCache.TTL),
+		server.WithCheckQueryCacheHotKeyQPSThreshold(config.CheckQueryCache.HotKeyQPSThreshold),
+		server.WithCheckQueryCacheHotKeyTTLMultiplier(config.CheckQueryCache.HotKeyTTLMultiplier),
+		server.WithCheckQueryCacheNegativeLimit(config.CheckQueryCache.NegativeLimit),
+		server.WithCheckQueryCacheNegativeTTL(config.CheckQueryCache.NegativeTTL),
+		server.WithCheckQueryCacheChangelogInterval(config.CheckQueryCache.ChangelogInterval),
+		server.WithCheckQueryCacheShared(checkSharedCache),
 		server.WithRequestDurationByQueryHistogramBuckets(convertStringArrayToUintArray(config.RequestDurationDatastoreQueryCountBuckets)),
 		server.WithMaxAuthorizationModelSizeInBytes(config.MaxAuthorizationModelSizeInBytes),
+		server.WithAssertionsCopyForward(config.AssertionsCopyForward),
+		server.WithInlineModelsEnabled(config.InlineModel.Enabled),
+		server.WithInlineModelMaxSizeInBytes(config.InlineModel.MaxSizeInBytes),
+		server.WithRelationUsageTracker(relationUsageTracker),
+		server.WithMaxChecksPerBatchCheck(config.BatchCheck.MaxChecks),
+		server.WithMaxConcurrentChecksPerBatchCheck(config.BatchCheck.MaxConcurrentChecks),
+		server.WithBatchCheckMaxDatastoreQueries(config.BatchCheck.MaxDatastoreQueries),
+		server.WithIdenticalAuthorizationModelsSkipped(config.SkipIdenticalAuthorizationModels),
+		server.WithSelfReferentialTuplesRejected(config.RejectSelfReferentialTuples),
+		server.WithAuthorizationModelValidator(modelValidator),
+		server.WithAuthorizationModelNamingPolicy(namingPolicy),
+		server.WithWildcardsDisallowed(config.AuthorizationModelValidation.DisallowWildcards),
+		server.WithIntersectionAndExclusionDisallowed(config.AuthorizationModelValidation.DisallowIntersectionAndExclusion),
+		server.WithStoreLabeler(storeLabeler),
+		server.WithCheckProfileSink(checkProfileSink),
+		server.WithCheckProfileLatencyThreshold(config.CheckProfiling.LatencyThreshold),
+		server.WithCheckProfileSampleRate(config.CheckProfiling.SampleRate),
+		server.WithShadowCheckCandidates(shadowCheckCandidates),
+		server.WithShadowCheckSampleRate(config.ShadowCheck.SampleRate),
+		server.WithShadowCheckTimeout(config.ShadowCheck.Timeout),
+		server.WithShadowCheckMaxConcurrency(config.ShadowCheck.MaxConcurrency),
+		server.WithEventPublisher(eventBus),
+		server.WithDatastoreMaintainer(maintainer),
+		server.WithDatastoreMaintenanceSchedule(config.Datastore.Maintenance.Schedule),
+		server.WithDatastoreMaintenanceJitter(config.Datastore.Maintenance.Jitter),
+		server.WithDatastoreMaintenanceTasks(config.Datastore.Maintenance.Tasks...),
+		server.WithClusterDispatcher(clusterDispatcher),
 		server.WithExperimentals(experimentals...),
 	)
 
+	var adminServer *http.Server
+	if config.Admin.Enabled {
+		adminOpts := []admin.HandlerOpt{
+			admin.WithLogger(s.Logger),
+			admin.WithAuthenticator(authenticator),
+			admin.WithCaches(append(cachedDatastore.Caches(), svr.CheckCaches()...)...),
+			admin.WithMaintenanceMode(maintenanceMode),
+			admin.WithStoreFiles(svr),
+			admin.WithAuthorizationModelLabels(svr),
+			admin.WithModelImpact(svr),
+			admin.WithModelValidation(svr),
+			admin.WithModelDiff(svr),
+			admin.WithRelationRenames(svr),
+			admin.WithTupleSamples(svr),
+		}
+		if len(config.Admin.Keys) > 0 {
+			adminOpts = append(adminOpts, admin.WithKeys(config.Admin.Keys...))
+		}
+		if config.Admin.RequiredClaim != "" {
+			name, value, _ := strings.Cut(config.Admin.RequiredClaim, "=")
+			adminOpts = append(adminOpts, admin.WithRequiredClaim(name, value))
+		}
+		if shadowCheckCandidates != nil {
+			adminOpts = append(adminOpts, admin.WithShadowCheckCandidates(shadowCheckCandidates))
+		}
+		if relationUsageTracker != nil {
+			adminOpts = append(adminOpts, admin.WithRelationUsage(svr))
+		}
+		if config.Admin.GraphQLEnabled {
+			s.Logger.Info(fmt.Sprintf("serving the GraphQL endpoint on http://%s/admin/graphql", config.Admin.Addr))
+			var graphQLOpts []graphql.HandlerOpt
+			if concurrencyLimiter != nil {
+				graphQLOpts = append(graphQLOpts, graphql.WithConcurrencyLimiter(concurrencyLimiter))
+			}
+			adminOpts = append(adminOpts, admin.WithGraphQL(svr, graphQLOpts...))
+		}
+
+		adminServer = &http.Server{
+			Addr:    config.Admin.Addr,
+			Handler: admin.NewHandler(adminOpts...),
+		}
+
+		go func() {
+			s.Logger.Info(fmt.Sprintf("🛠️ starting admin server on '%s'", config.Admin.Addr))
+
+			if err := adminServer.ListenAndServe(); err != nil {
+				if err != http.ErrServerClosed {
+					s.Logger.Fatal("failed to start the admin server", zap.Error(err))
+				}
+			}
+		}()
+	}
+
 	s.Logger.Info(
 		"🚀 starting openfga service...",
 		zap.String("version", build.Version),
@@ -521,7 +1413,7 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 	healthv1pb.RegisterHealthServer(grpcServer, healthServer)
 	reflection.Register(grpcServer)
 
-	lis, err := net.Listen("tcp", config.GRPC.Addr)
+	lis, err := listen(config.GRPC.Addr, "grpc", config.GRPC.Listener)
 	if err != nil {
 		return fmt.Errorf("failed to listen: %w", err)
 	}
@@ -564,6 +1456,20 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		}
 		defer conn.Close()
 
+		forwardedHeaders := append([]string{consistency.ConsistencyHeader, server.ContextualTuplesHeader, server.AuthorizationModelLabelsHeader, server.AuthorizationModelLabelHeader, server.AuthorizationModelConditionsHeader, server.TupleConditionsHeader, server.ConditionContextHeader, server.TupleExpirationsHeader, server.WritePreconditionsHeader}, config.HTTP.ForwardedHeaders...)
+		if config.AllowCacheBypass {
+			forwardedHeaders = append([]string{cachebypass.NoCacheHeader}, forwardedHeaders...)
+		}
+		if config.Datastore.Residency.Region != "" {
+			forwardedHeaders = append([]string{server.StoreResidencyHeader}, forwardedHeaders...)
+		}
+		if config.InlineModel.Enabled {
+			forwardedHeaders = append([]string{server.InlineAuthorizationModelHeader}, forwardedHeaders...)
+		}
+		if config.Authn.Method == "signed" {
+			forwardedHeaders = append([]string{signedrequest.SignatureHeader, signedrequest.TimestampHeader, signedrequest.NonceHeader, signedrequest.ContentDigestHeader}, forwardedHeaders...)
+		}
+
 		muxOpts := []runtime.ServeMuxOption{
 			runtime.WithForwardResponseOption(httpmiddleware.HTTPResponseModifier),
 			runtime.WithErrorHandler(func(c context.Context, sr *runtime.ServeMux, mm runtime.Marshaler, w http.ResponseWriter, r *http.Request, e error) {
@@ -576,6 +1482,7 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 				return status.Convert(encodedErr)
 			}),
 			runtime.WithHealthzEndpoint(healthv1pb.NewHealthClient(conn)),
+			runtime.WithIncomingHeaderMatcher(httpmiddleware.NewIncomingHeaderMatcher(forwardedHeaders...)),
 			runtime.WithOutgoingHeaderMatcher(func(s string) (string, bool) { return s, true }),
 		}
 		mux := runtime.NewServeMux(muxOpts...)
@@ -591,7 +1498,15 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 				AllowedHeaders:   config.HTTP.CORSAllowedHeaders,
 				AllowedMethods: []string{http.MethodGet, http.MethodPost,
 					http.MethodHead, http.MethodPatch, http.MethodDelete, http.MethodPut},
-			}).Handler(mux),
+			}).Handler(httpmiddleware.WithRouteMiddlewares(mux, config.HTTP.Middlewares...)),
+		}
+		if err := configureHTTP2(httpServer, config.HTTP); err != nil {
+			return err
+		}
+
+		httpListener, err := listen(config.HTTP.Addr, "http", config.HTTP.Listener)
+		if err != nil {
+			return fmt.Errorf("failed to listen: %w", err)
 		}
 
 		go func() {
@@ -600,9 +1515,9 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 				if config.HTTP.TLS.CertPath == "" || config.HTTP.TLS.KeyPath == "" {
 					s.Logger.Fatal("'http.tls.cert' and 'http.tls.key' configs must be set")
 				}
-				err = httpServer.ListenAndServeTLS(config.HTTP.TLS.CertPath, config.HTTP.TLS.KeyPath)
+				err = httpServer.ServeTLS(httpListener, config.HTTP.TLS.CertPath, config.HTTP.TLS.KeyPath)
 			} else {
-				err = httpServer.ListenAndServe()
+				err = httpServer.Serve(httpListener)
 			}
 			if err != http.ErrServerClosed {
 				s.Logger.Fatal("HTTP server closed with unexpected error", zap.Error(err))
@@ -689,6 +1604,8 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		}()
 	}
 
+	eventBus.Publish(ctx, events.Event{Type: events.ServerStarted})
+
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 
@@ -698,6 +1615,8 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 	}
 	s.Logger.Info("attempting to shutdown gracefully")
 
+	eventBus.Publish(ctx, events.Event{Type: events.ServerStopping})
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -713,12 +1632,32 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		}
 	}
 
+	if adminServer != nil {
+		if err := adminServer.Shutdown(ctx); err != nil {
+			s.Logger.Info("failed to shutdown the admin server", zap.Error(err))
+		}
+	}
+
 	grpcServer.GracefulStop()
 
 	authenticator.Close()
 
+	svr.Close()
+
 	datastore.Close()
 
+	if captureSink != nil {
+		if err := captureSink.Close(); err != nil {
+			s.Logger.Info("failed to close the capture file", zap.Error(err))
+		}
+	}
+
+	if checkProfileFileSink != nil {
+		if err := checkProfileFileSink.Close(); err != nil {
+			s.Logger.Info("failed to close the check profile file", zap.Error(err))
+		}
+	}
+
 	if tracerProviderCloser != nil {
 		tracerProviderCloser()
 	}
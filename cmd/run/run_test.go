@@ -14,11 +14,13 @@ import (
 	"io"
 	"log"
 	"math/big"
+	"net"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -29,10 +31,15 @@ import (
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"github.com/openfga/openfga/cmd"
 	"github.com/openfga/openfga/cmd/util"
+	"github.com/openfga/openfga/internal/authn/signedrequest"
 	"github.com/openfga/openfga/internal/mocks"
 	serverconfig "github.com/openfga/openfga/internal/server/config"
 	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/server"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/sqlcommon"
+	"github.com/openfga/openfga/pkg/storage/sqlite"
 	storagefixtures "github.com/openfga/openfga/pkg/testfixtures/storage"
 	"github.com/openfga/openfga/pkg/tuple"
 	"github.com/openfga/openfga/pkg/typesystem"
@@ -41,12 +48,18 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/tidwall/gjson"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"golang.org/x/net/http2"
 	"google.golang.org/grpc"
 	grpcbackoff "google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	healthv1pb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestMain(m *testing.M) {
@@ -324,6 +337,332 @@ func TestBuildServiceWithPresharedKeyAuthentication(t *testing.T) {
 	}
 }
 
+func TestBuildServiceWithUnauthenticatedMethods(t *testing.T) {
+	cfg := MustDefaultConfigWithRandomPorts()
+	cfg.Authn.Method = "preshared"
+	cfg.Authn.AuthnPresharedKeyConfig = &serverconfig.AuthnPresharedKeyConfig{
+		Keys: []string{"KEYONE"},
+	}
+	cfg.Authn.UnauthenticatedMethods = []string{
+		"/grpc.health.v1.Health/*",
+		"/openfga.v1.OpenFGAService/ListStores",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		if err := runServer(ctx, cfg); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	ensureServiceUp(t, cfg.GRPC.Addr, cfg.HTTP.Addr, nil, true)
+
+	retryClient := retryablehttp.NewClient()
+
+	t.Run("unauthenticated_method_succeeds_without_header", func(t *testing.T) {
+		tryGetStores(t, authTest{
+			authHeader:         "",
+			expectedStatusCode: 200,
+		}, cfg.HTTP.Addr, retryClient)
+	})
+
+	t.Run("other_methods_still_require_authentication", func(t *testing.T) {
+		tryStreamingListObjects(t, authTest{
+			authHeader: "",
+			expectedErrorResponse: &serverErrors.ErrorResponse{
+				Code:    "bearer_token_missing",
+				Message: "missing bearer token",
+			},
+			expectedStatusCode: 401,
+		}, cfg.HTTP.Addr, retryClient, cfg.Authn.AuthnPresharedKeyConfig.Keys[0])
+	})
+}
+
+func TestBuildServiceWithShadowDatastore(t *testing.T) {
+	t.Run("serves_with_a_shadow_datastore", func(t *testing.T) {
+		cfg := MustDefaultConfigWithRandomPorts()
+		cfg.Datastore.Shadow.Engine = "memory"
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go func() {
+			if err := runServer(ctx, cfg); err != nil {
+				log.Fatal(err)
+			}
+		}()
+
+		ensureServiceUp(t, cfg.GRPC.Addr, cfg.HTTP.Addr, nil, true)
+
+		res, err := http.Post(fmt.Sprintf("http://%s/stores", cfg.HTTP.Addr), "application/json", strings.NewReader(`{"name":"shadowed"}`))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+	})
+
+	t.Run("unsupported_shadow_engine", func(t *testing.T) {
+		cfg := MustDefaultConfigWithRandomPorts()
+		cfg.Datastore.Shadow.Engine = "unknown"
+
+		err := runServer(context.Background(), cfg)
+		require.EqualError(t, err, "initialize shadow datastore: storage engine 'unknown' is unsupported")
+	})
+}
+
+func TestBuildServiceWithWrappedDatastore(t *testing.T) {
+	uri := filepath.Join(t.TempDir(), "openfga.db")
+
+	cfg := MustDefaultConfigWithRandomPorts()
+	cfg.Datastore.Engine = "sqlite"
+	cfg.Datastore.URI = uri
+	cfg.Datastore.AutoMigrate = true
+	cfg.Datastore.Shadow.Engine = "memory"
+	cfg.Datastore.Replicas.URIs = []string{uri}
+	cfg.Datastore.CircuitBreaker.Enabled = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		if err := runServer(ctx, cfg); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	ensureServiceUp(t, cfg.GRPC.Addr, cfg.HTTP.Addr, nil, true)
+
+	conn, err := grpc.Dial(cfg.GRPC.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := openfgav1.NewOpenFGAServiceClient(conn)
+
+	createStoreResp, err := client.CreateStore(context.Background(), &openfgav1.CreateStoreRequest{Name: "wrapped"})
+	require.NoError(t, err)
+	store := createStoreResp.GetId()
+
+	_, err = client.WriteAuthorizationModel(metadata.AppendToOutgoingContext(context.Background(), server.AuthorizationModelConditionsHeader,
+		`{"conditions": [{"name": "ip_allowed", "expression": "ip in allowed_ips", "parameters": {"ip": "string", "allowed_ips": "list"}}], `+
+			`"type_restrictions": [{"type": "document", "relation": "viewer", "user_type": "user", "condition": "ip_allowed"}]}`), &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:       store,
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+		type document
+		  relations
+		    define viewer: [user] as self
+		`),
+	})
+	require.NoError(t, err)
+
+	t.Run("conditions", func(t *testing.T) {
+		_, err := client.Write(metadata.AppendToOutgoingContext(context.Background(), server.TupleConditionsHeader,
+			`[{"tuple_key": {"object": "document:roadmap", "relation": "viewer", "user": "user:anne"}, "condition": {"name": "ip_allowed", "context": {"allowed_ips": ["10.0.0.1"]}}}]`), &openfgav1.WriteRequest{
+			StoreId: store,
+			Writes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+			}},
+		})
+		require.NoError(t, err)
+
+		check := func(ip string) bool {
+			resp, err := client.Check(metadata.AppendToOutgoingContext(context.Background(), server.ConditionContextHeader, `{"ip": "`+ip+`"}`), &openfgav1.CheckRequest{
+				StoreId:  store,
+				TupleKey: tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+			})
+			require.NoError(t, err)
+			return resp.GetAllowed()
+		}
+
+		require.True(t, check("10.0.0.1"))
+		require.False(t, check("10.0.0.2"))
+	})
+
+	t.Run("expirations", func(t *testing.T) {
+		tk := tuple.NewTupleKey("document:roadmap", "viewer", "user:bob")
+		expiresAt := time.Now().Add(time.Second).UTC().Format(time.RFC3339Nano)
+
+		_, err := client.Write(metadata.AppendToOutgoingContext(context.Background(), server.TupleExpirationsHeader,
+			`[{"tuple_key": {"object": "document:roadmap", "relation": "viewer", "user": "user:bob"}, "expires_at": "`+expiresAt+`"}]`), &openfgav1.WriteRequest{
+			StoreId: store,
+			Writes:  &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{tk}},
+		})
+		require.NoError(t, err)
+
+		check := func() bool {
+			resp, err := client.Check(context.Background(), &openfgav1.CheckRequest{StoreId: store, TupleKey: tk})
+			require.NoError(t, err)
+			return resp.GetAllowed()
+		}
+
+		require.True(t, check())
+		require.Eventually(t, func() bool { return !check() }, 5*time.Second, 100*time.Millisecond)
+	})
+
+	t.Run("preconditions", func(t *testing.T) {
+		write := func(user string) error {
+			_, err := client.Write(metadata.AppendToOutgoingContext(context.Background(), server.WritePreconditionsHeader,
+				`[{"tuple_key": {"object": "document:roadmap", "relation": "viewer", "user": "user:anne"}, "exists": true}]`), &openfgav1.WriteRequest{
+				StoreId: store,
+				Writes:  &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:roadmap", "viewer", user)}},
+			})
+			return err
+		}
+
+		require.NoError(t, write("user:carl"))
+
+		_, err := client.Write(context.Background(), &openfgav1.WriteRequest{
+			StoreId: store,
+			Deletes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:roadmap", "viewer", "user:anne")}},
+		})
+		require.NoError(t, err)
+
+		require.Error(t, write("user:dave"))
+	})
+}
+
+func TestBuildServiceWithRoutedDatastore(t *testing.T) {
+	uri := filepath.Join(t.TempDir(), "openfga.db")
+	routedURI := filepath.Join(t.TempDir(), "tenants.db")
+
+	// every store, whose ID is a ULID, is routed to the datastore of the tenants
+	cfg := MustDefaultConfigWithRandomPorts()
+	cfg.Datastore.Engine = "sqlite"
+	cfg.Datastore.URI = uri
+	cfg.Datastore.AutoMigrate = true
+	cfg.Datastore.Routing.Prefixes = []string{"0=" + routedURI}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		if err := runServer(ctx, cfg); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	ensureServiceUp(t, cfg.GRPC.Addr, cfg.HTTP.Addr, nil, true)
+
+	conn, err := grpc.Dial(cfg.GRPC.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := openfgav1.NewOpenFGAServiceClient(conn)
+
+	createStoreResp, err := client.CreateStore(context.Background(), &openfgav1.CreateStoreRequest{Name: "routed"})
+	require.NoError(t, err)
+	store := createStoreResp.GetId()
+
+	_, err = client.WriteAuthorizationModel(metadata.AppendToOutgoingContext(context.Background(), server.AuthorizationModelConditionsHeader,
+		`{"conditions": [{"name": "ip_allowed", "expression": "ip in allowed_ips", "parameters": {"ip": "string", "allowed_ips": "list"}}], `+
+			`"type_restrictions": [{"type": "document", "relation": "viewer", "user_type": "user", "condition": "ip_allowed"}]}`), &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:       store,
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+		type document
+		  relations
+		    define viewer: [user] as self
+		`),
+	})
+	require.NoError(t, err)
+
+	// the conditions of the tuples are written to the datastore of the store too
+	_, err = client.Write(metadata.AppendToOutgoingContext(context.Background(), server.TupleConditionsHeader,
+		`[{"tuple_key": {"object": "document:roadmap", "relation": "viewer", "user": "user:anne"}, "condition": {"name": "ip_allowed", "context": {"allowed_ips": ["10.0.0.1"]}}}]`), &openfgav1.WriteRequest{
+		StoreId: store,
+		Writes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+		}},
+	})
+	require.NoError(t, err)
+
+	check := func(ip string) bool {
+		resp, err := client.Check(metadata.AppendToOutgoingContext(context.Background(), server.ConditionContextHeader, `{"ip": "`+ip+`"}`), &openfgav1.CheckRequest{
+			StoreId:  store,
+			TupleKey: tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+		return resp.GetAllowed()
+	}
+
+	require.True(t, check("10.0.0.1"))
+	require.False(t, check("10.0.0.2"))
+
+	for datastoreURI, expected := range map[string]error{routedURI: nil, uri: storage.ErrNotFound} {
+		ds, err := sqlite.New(datastoreURI, sqlcommon.NewConfig())
+		require.NoError(t, err)
+
+		_, err = ds.GetStore(context.Background(), store)
+		require.ErrorIs(t, err, expected, datastoreURI)
+		ds.Close()
+	}
+}
+
+func TestBuildServiceWithMaintenanceMode(t *testing.T) {
+	cfg := MustDefaultConfigWithRandomPorts()
+	cfg.Admin.Enabled = true
+	cfg.Maintenance.Enabled = true
+	cfg.Maintenance.Message = "upgrading the datastore"
+	cfg.Maintenance.RetryAfter = time.Minute
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		if err := runServer(ctx, cfg); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	// the health checks are served while undergoing maintenance
+	ensureServiceUp(t, cfg.GRPC.Addr, cfg.HTTP.Addr, nil, true)
+
+	getStores := func(t *testing.T) *http.Response {
+		res, err := http.Get(fmt.Sprintf("http://%s/stores", cfg.HTTP.Addr))
+		require.NoError(t, err)
+		t.Cleanup(func() { res.Body.Close() })
+		return res
+	}
+
+	adminRequest := func(t *testing.T, method, path string) {
+		req, err := http.NewRequest(method, fmt.Sprintf("http://%s%s", cfg.Admin.Addr, path), nil)
+		require.NoError(t, err)
+
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	}
+
+	t.Run("requests_are_rejected_while_undergoing_maintenance", func(t *testing.T) {
+		res := getStores(t)
+		require.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		require.Equal(t, "60", res.Header.Get("Retry-After"))
+		require.Equal(t, "maintenance", res.Header.Get("Openfga-Load-Hint"))
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Contains(t, string(body), "upgrading the datastore")
+	})
+
+	t.Run("requests_are_served_once_maintenance_is_disabled", func(t *testing.T) {
+		adminRequest(t, http.MethodDelete, "/admin/maintenance")
+
+		res := getStores(t)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("maintenance_can_be_enabled_again", func(t *testing.T) {
+		adminRequest(t, http.MethodPut, "/admin/maintenance")
+
+		res := getStores(t)
+		require.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	})
+}
+
 func TestBuildServiceWithTracingEnabled(t *testing.T) {
 	// create mock OTLP server
 	otlpServerPort, otlpServerPortReleaser := TCPRandomPort()
@@ -619,6 +958,130 @@ func TestBuildServerWithOIDCAuthentication(t *testing.T) {
 	}
 }
 
+func TestBuildServerWithSignedRequestAuthentication(t *testing.T) {
+	cfg := MustDefaultConfigWithRandomPorts()
+	cfg.Authn.Method = "signed"
+	cfg.Authn.AuthnSignedRequestConfig = &serverconfig.AuthnSignedRequestConfig{
+		SigningKeys:  []string{"machine:secret"},
+		MaxClockSkew: time.Minute,
+		MaxNonces:    serverconfig.DefaultAuthnSignedRequestMaxNonces,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		if err := runServer(ctx, cfg); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	ensureServiceUp(t, cfg.GRPC.Addr, cfg.HTTP.Addr, nil, true)
+
+	conn, err := grpc.Dial(cfg.GRPC.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := openfgav1.NewOpenFGAServiceClient(conn)
+
+	sign := func(t *testing.T, secret, nonce string, req *openfgav1.CreateStoreRequest) context.Context {
+		digest, err := signedrequest.ContentDigest(req)
+		require.NoError(t, err)
+
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+		return metadata.AppendToOutgoingContext(context.Background(),
+			signedrequest.TimestampHeader, timestamp,
+			signedrequest.NonceHeader, nonce,
+			signedrequest.ContentDigestHeader, digest,
+			signedrequest.SignatureHeader, signedrequest.Sign("machine", secret, openfgav1.OpenFGAService_CreateStore_FullMethodName, timestamp, nonce, digest),
+		)
+	}
+
+	req := &openfgav1.CreateStoreRequest{Name: "store"}
+
+	_, err = client.CreateStore(context.Background(), req)
+	require.Equal(t, codes.Code(openfgav1.AuthErrorCode_unauthenticated), status.Code(err))
+
+	_, err = client.CreateStore(sign(t, "wrong", "nonce-1", req), req)
+	require.Equal(t, codes.Code(openfgav1.AuthErrorCode_unauthenticated), status.Code(err))
+
+	signedCtx := sign(t, "secret", "nonce-2", req)
+	_, err = client.CreateStore(signedCtx, req)
+	require.NoError(t, err)
+
+	// replaying the same request fails
+	_, err = client.CreateStore(signedCtx, req)
+	require.Equal(t, codes.Code(openfgav1.AuthErrorCode_unauthenticated), status.Code(err))
+
+	// a request whose content doesn't match the signed digest fails
+	_, err = client.CreateStore(sign(t, "secret", "nonce-3", req), &openfgav1.CreateStoreRequest{Name: "other"})
+	require.Equal(t, codes.Code(openfgav1.AuthErrorCode_unauthenticated), status.Code(err))
+
+	t.Run("http", func(t *testing.T) {
+		// the HTTP requests are signed like the gRPC requests their route maps to
+		do := func(t *testing.T, method, path, body, secret, nonce, fullMethod string, msg proto.Message) *http.Response {
+			var reader io.Reader
+			if body != "" {
+				reader = strings.NewReader(body)
+			}
+			httpReq, err := http.NewRequest(method, fmt.Sprintf("http://%s%s", cfg.HTTP.Addr, path), reader)
+			require.NoError(t, err)
+			httpReq.Header.Set("content-type", "application/json")
+
+			if msg != nil {
+				digest, err := signedrequest.ContentDigest(msg)
+				require.NoError(t, err)
+
+				timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+				httpReq.Header.Set(signedrequest.TimestampHeader, timestamp)
+				httpReq.Header.Set(signedrequest.NonceHeader, nonce)
+				httpReq.Header.Set(signedrequest.ContentDigestHeader, digest)
+				httpReq.Header.Set(signedrequest.SignatureHeader, signedrequest.Sign("machine", secret, fullMethod, timestamp, nonce, digest))
+			}
+
+			res, err := http.DefaultClient.Do(httpReq)
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				_ = res.Body.Close()
+			})
+
+			return res
+		}
+
+		createStoreReq := &openfgav1.CreateStoreRequest{Name: "http-store"}
+
+		res := do(t, http.MethodPost, "/stores", `{"name": "http-store"}`, "", "", "", nil)
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		res = do(t, http.MethodPost, "/stores", `{"name": "http-store"}`, "wrong", "http-nonce-1", openfgav1.OpenFGAService_CreateStore_FullMethodName, createStoreReq)
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		res = do(t, http.MethodPost, "/stores", `{"name": "http-store"}`, "secret", "http-nonce-2", openfgav1.OpenFGAService_CreateStore_FullMethodName, createStoreReq)
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		var createStoreResponse openfgav1.CreateStoreResponse
+		require.NoError(t, protojson.Unmarshal(body, &createStoreResponse))
+
+		// the digest covers the message the gateway builds from the path too
+		path := "/stores/" + createStoreResponse.GetId()
+		getStoreReq := &openfgav1.GetStoreRequest{StoreId: createStoreResponse.GetId()}
+
+		res = do(t, http.MethodGet, path, "", "secret", "http-nonce-3", openfgav1.OpenFGAService_GetStore_FullMethodName, getStoreReq)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		// replaying the same nonce fails
+		res = do(t, http.MethodGet, path, "", "secret", "http-nonce-3", openfgav1.OpenFGAService_GetStore_FullMethodName, getStoreReq)
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		// a request whose content doesn't match the signed digest fails
+		res = do(t, http.MethodPost, "/stores", `{"name": "other"}`, "secret", "http-nonce-4", openfgav1.OpenFGAService_CreateStore_FullMethodName, createStoreReq)
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+}
+
 func TestHTTPServingTLS(t *testing.T) {
 	t.Run("enable_HTTP_TLS_is_false,_even_with_keys_set,_will_serve_plaintext", func(t *testing.T) {
 		certsAndKeys := createCertsAndKeys(t)
@@ -751,6 +1214,8 @@ func testServerMetricsReporting(t *testing.T, engine string) {
 	cfg.Datastore.Metrics.Enabled = true
 	cfg.Metrics.Enabled = true
 	cfg.Metrics.EnableRPCHistograms = true
+	cfg.Metrics.EnableStoreLabels = true
+	cfg.SLO.Enabled = true
 	metricsPort, metricsPortReleaser := TCPRandomPort()
 	metricsPortReleaser()
 
@@ -844,6 +1309,11 @@ func testServerMetricsReporting(t *testing.T, engine string) {
 	require.Contains(t, stringBody, "list_objects_further_eval_required_count")
 	require.Contains(t, stringBody, "list_objects_no_further_eval_required_count")
 	require.Contains(t, stringBody, "go_sql_idle_connections")
+	require.Contains(t, stringBody, fmt.Sprintf(`store_requests_total{grpc_code="OK",grpc_method="Check",grpc_service="openfga.v1.OpenFGAService",store_id="%s"}`, storeID))
+	require.Contains(t, stringBody, "store_request_duration_ms")
+	require.Contains(t, stringBody, "store_datastore_query_count")
+	require.Contains(t, stringBody, "slo_availability_error_budget_remaining_ratio")
+	require.Contains(t, stringBody, "slo_latency_error_budget_remaining_ratio")
 }
 
 func TestHTTPServerDisabled(t *testing.T) {
@@ -879,23 +1349,105 @@ func TestHTTPServerEnabled(t *testing.T) {
 	ensureServiceUp(t, cfg.GRPC.Addr, cfg.HTTP.Addr, nil, true)
 }
 
-func TestDefaultConfig(t *testing.T) {
-	cfg, err := ReadConfig()
-	require.NoError(t, err)
+func TestHTTPServerWithH2C(t *testing.T) {
+	cfg := MustDefaultConfigWithRandomPorts()
+	cfg.HTTP.H2CEnabled = true
 
-	_, basepath, _, _ := runtime.Caller(0)
-	jsonSchema, err := os.ReadFile(path.Join(filepath.Dir(basepath), "..", "..", ".config-schema.json"))
-	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	res := gjson.ParseBytes(jsonSchema)
+	go func() {
+		if err := runServer(ctx, cfg); err != nil {
+			log.Fatal(err)
+		}
+	}()
 
-	val := res.Get("properties.datastore.properties.engine.default")
-	require.True(t, val.Exists())
-	require.Equal(t, val.String(), cfg.Datastore.Engine)
+	ensureServiceUp(t, cfg.GRPC.Addr, cfg.HTTP.Addr, nil, true)
 
-	val = res.Get("properties.datastore.properties.maxCacheSize.default")
-	require.True(t, val.Exists())
-	require.EqualValues(t, val.Int(), cfg.Datastore.MaxCacheSize)
+	// HTTP/2 with prior knowledge, over cleartext
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}}
+
+	resp, err := client.Get(fmt.Sprintf("http://%s/healthz", cfg.HTTP.Addr))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 2, resp.ProtoMajor)
+
+	// HTTP/1.1 is still served
+	resp, err = http.Get(fmt.Sprintf("http://%s/healthz", cfg.HTTP.Addr))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 1, resp.ProtoMajor)
+}
+
+func TestGRPCServerMaxConnectionAge(t *testing.T) {
+	cfg := MustDefaultConfigWithRandomPorts()
+	cfg.GRPC.Keepalive.MaxConnectionAge = 200 * time.Millisecond
+	cfg.GRPC.Keepalive.MaxConnectionAgeGrace = 100 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		if err := runServer(ctx, cfg); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	ensureServiceUp(t, cfg.GRPC.Addr, cfg.HTTP.Addr, nil, false)
+
+	conn, err := grpc.Dial(cfg.GRPC.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = healthv1pb.NewHealthClient(conn).Check(ctx, &healthv1pb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, connectivity.Ready, conn.GetState())
+
+	// the server asks the client to reconnect once the connection reaches its maximum age
+	timeoutCtx, timeoutCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer timeoutCancel()
+	require.True(t, conn.WaitForStateChange(timeoutCtx, connectivity.Ready))
+}
+
+func TestDefaultConfig(t *testing.T) {
+	cfg, err := ReadConfig()
+	require.NoError(t, err)
+
+	_, basepath, _, _ := runtime.Caller(0)
+	jsonSchema, err := os.ReadFile(path.Join(filepath.Dir(basepath), "..", "..", ".config-schema.json"))
+	require.NoError(t, err)
+
+	res := gjson.ParseBytes(jsonSchema)
+
+	val := res.Get("properties.datastore.properties.engine.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.Datastore.Engine)
+
+	val = res.Get("properties.datastore.properties.maxCacheSize.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.Datastore.MaxCacheSize)
+
+	val = res.Get("properties.datastore.properties.latestModelIDCacheTTL.default")
+	require.True(t, val.Exists())
+	latestModelIDCacheTTL, err := time.ParseDuration(val.String())
+	require.NoError(t, err)
+	require.Equal(t, latestModelIDCacheTTL, cfg.Datastore.LatestModelIDCacheTTL)
+
+	val = res.Get("properties.datastore.properties.expiredTuplesReapInterval.default")
+	require.True(t, val.Exists())
+	expiredTuplesReapInterval, err := time.ParseDuration(val.String())
+	require.NoError(t, err)
+	require.Equal(t, expiredTuplesReapInterval, cfg.Datastore.ExpiredTuplesReapInterval)
 
 	val = res.Get("properties.datastore.properties.maxIdleConns.default")
 	require.True(t, val.Exists())
@@ -915,10 +1467,140 @@ func TestDefaultConfig(t *testing.T) {
 	require.True(t, val.Exists())
 	require.False(t, val.Bool())
 
+	val = res.Get("properties.datastore.properties.autoMigrate.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.Datastore.AutoMigrate)
+
+	val = res.Get("properties.datastore.properties.shadow.properties.engine.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.Datastore.Shadow.Engine)
+
+	val = res.Get("properties.datastore.properties.shadow.properties.timeout.default")
+	require.True(t, val.Exists())
+	shadowDatastoreTimeout, err := time.ParseDuration(val.String())
+	require.NoError(t, err)
+	require.Equal(t, shadowDatastoreTimeout, cfg.Datastore.Shadow.Timeout)
+
+	val = res.Get("properties.datastore.properties.shadow.properties.maxConcurrency.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.Datastore.Shadow.MaxConcurrency)
+
+	val = res.Get("properties.datastore.properties.maintenance.properties.schedule.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.Datastore.Maintenance.Schedule)
+
+	val = res.Get("properties.datastore.properties.maintenance.properties.jitter.default")
+	require.True(t, val.Exists())
+	maintenanceJitter, err := time.ParseDuration(val.String())
+	require.NoError(t, err)
+	require.Equal(t, maintenanceJitter, cfg.Datastore.Maintenance.Jitter)
+
+	val = res.Get("properties.datastore.properties.replicas.properties.hedgeAfter.default")
+	require.True(t, val.Exists())
+	hedgeAfter, err := time.ParseDuration(val.String())
+	require.NoError(t, err)
+	require.Equal(t, hedgeAfter, cfg.Datastore.Replicas.HedgeAfter)
+
+	val = res.Get("properties.datastore.properties.replicas.properties.hedgingBudget.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Float(), cfg.Datastore.Replicas.HedgingBudget)
+
+	val = res.Get("properties.datastore.properties.routing.properties.stores.default")
+	require.True(t, val.Exists())
+	require.Len(t, val.Array(), len(cfg.Datastore.Routing.Stores))
+
+	val = res.Get("properties.datastore.properties.routing.properties.prefixes.default")
+	require.True(t, val.Exists())
+	require.Len(t, val.Array(), len(cfg.Datastore.Routing.Prefixes))
+
+	val = res.Get("properties.datastore.properties.residency.properties.regions.default")
+	require.True(t, val.Exists())
+	require.Len(t, val.Array(), len(cfg.Datastore.Residency.Regions))
+
+	val = res.Get("properties.datastore.properties.circuitBreaker.properties.enabled.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.Datastore.CircuitBreaker.Enabled)
+
+	val = res.Get("properties.datastore.properties.circuitBreaker.properties.errorRateThreshold.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Float(), cfg.Datastore.CircuitBreaker.ErrorRateThreshold)
+
+	val = res.Get("properties.datastore.properties.circuitBreaker.properties.minRequests.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.Datastore.CircuitBreaker.MinRequests)
+
+	val = res.Get("properties.datastore.properties.circuitBreaker.properties.window.default")
+	require.True(t, val.Exists())
+	circuitBreakerWindow, err := time.ParseDuration(val.String())
+	require.NoError(t, err)
+	require.Equal(t, circuitBreakerWindow, cfg.Datastore.CircuitBreaker.Window)
+
+	val = res.Get("properties.datastore.properties.circuitBreaker.properties.openDuration.default")
+	require.True(t, val.Exists())
+	circuitBreakerOpenDuration, err := time.ParseDuration(val.String())
+	require.NoError(t, err)
+	require.Equal(t, circuitBreakerOpenDuration, cfg.Datastore.CircuitBreaker.OpenDuration)
+
+	val = res.Get("properties.admin.properties.graphqlEnabled.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.Admin.GraphQLEnabled)
+
+	val = res.Get("properties.auditLog.properties.enabled.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.AuditLog.Enabled)
+
 	val = res.Get("properties.grpc.properties.addr.default")
 	require.True(t, val.Exists())
 	require.Equal(t, val.String(), cfg.GRPC.Addr)
 
+	val = res.Get("properties.grpc.properties.streamCompressor.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.GRPC.StreamCompressor)
+
+	val = res.Get("properties.grpc.properties.gzipLevel.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.GRPC.GzipLevel)
+
+	val = res.Get("properties.grpc.properties.keepalive.properties.maxConnectionIdle.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.GRPC.Keepalive.MaxConnectionIdle.String())
+
+	val = res.Get("properties.grpc.properties.keepalive.properties.maxConnectionAge.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.GRPC.Keepalive.MaxConnectionAge.String())
+
+	val = res.Get("properties.grpc.properties.keepalive.properties.maxConnectionAgeGrace.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.GRPC.Keepalive.MaxConnectionAgeGrace.String())
+
+	val = res.Get("properties.grpc.properties.keepalive.properties.time.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.GRPC.Keepalive.Time.String())
+
+	val = res.Get("properties.grpc.properties.keepalive.properties.timeout.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.GRPC.Keepalive.Timeout.String())
+
+	val = res.Get("properties.grpc.properties.keepalive.properties.enforcementMinTime.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.GRPC.Keepalive.EnforcementMinTime.String())
+
+	val = res.Get("properties.grpc.properties.keepalive.properties.enforcementPermitWithoutStream.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.GRPC.Keepalive.EnforcementPermitWithoutStream)
+
+	val = res.Get("properties.grpc.properties.listener.properties.maxConnections.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.GRPC.Listener.MaxConnections)
+
+	val = res.Get("properties.grpc.properties.listener.properties.acceptRate.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Float(), cfg.GRPC.Listener.AcceptRate)
+
+	val = res.Get("properties.grpc.properties.listener.properties.acceptBurst.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.GRPC.Listener.AcceptBurst)
+
 	val = res.Get("properties.http.properties.enabled.default")
 	require.True(t, val.Exists())
 	require.Equal(t, val.Bool(), cfg.HTTP.Enabled)
@@ -947,6 +1629,23 @@ func TestDefaultConfig(t *testing.T) {
 	require.True(t, val.Exists())
 	require.Equal(t, val.String(), cfg.Authn.Method)
 
+	val = res.Get("properties.authn.properties.unauthenticatedMethods.default")
+	require.True(t, val.Exists())
+	require.Len(t, val.Array(), len(cfg.Authn.UnauthenticatedMethods))
+	for i, method := range val.Array() {
+		require.Equal(t, method.String(), cfg.Authn.UnauthenticatedMethods[i])
+	}
+
+	val = res.Get("definitions.signed.properties.maxClockSkew.default")
+	require.True(t, val.Exists())
+	maxClockSkew, err := time.ParseDuration(val.String())
+	require.NoError(t, err)
+	require.Equal(t, maxClockSkew, cfg.Authn.MaxClockSkew)
+
+	val = res.Get("definitions.signed.properties.maxNonces.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.Authn.MaxNonces)
+
 	val = res.Get("properties.log.properties.format.default")
 	require.True(t, val.Exists())
 	require.Equal(t, val.String(), cfg.Log.Format)
@@ -967,6 +1666,34 @@ func TestDefaultConfig(t *testing.T) {
 	require.True(t, val.Exists())
 	require.EqualValues(t, val.Int(), cfg.MaxConcurrentReadsForCheck)
 
+	val = res.Get("properties.checkUsersetBatchSize.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.CheckUsersetBatchSize)
+
+	val = res.Get("properties.assertionsCopyForward.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.AssertionsCopyForward)
+
+	val = res.Get("properties.skipIdenticalAuthorizationModels.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.SkipIdenticalAuthorizationModels)
+
+	val = res.Get("properties.rejectSelfReferentialTuples.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.RejectSelfReferentialTuples)
+
+	val = res.Get("properties.allowCacheBypass.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.AllowCacheBypass)
+
+	val = res.Get("properties.statusCodeOverrides.default")
+	require.True(t, val.Exists())
+	require.Equal(t, len(val.Array()), len(cfg.StatusCodeOverrides))
+
+	val = res.Get("properties.storeTombstoneTTL.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.StoreTombstoneTTL.String())
+
 	val = res.Get("properties.changelogHorizonOffset.default")
 	require.True(t, val.Exists())
 	require.EqualValues(t, val.Int(), cfg.ChangelogHorizonOffset)
@@ -999,6 +1726,42 @@ func TestDefaultConfig(t *testing.T) {
 	require.True(t, val.Exists())
 	require.Equal(t, len(val.Array()), len(cfg.Experimentals))
 
+	val = res.Get("properties.authorizationModelValidation.properties.rules.default")
+	require.True(t, val.Exists())
+	require.Len(t, val.Array(), len(cfg.AuthorizationModelValidation.Rules))
+
+	val = res.Get("properties.authorizationModelValidation.properties.plugins.default")
+	require.True(t, val.Exists())
+	require.Len(t, val.Array(), len(cfg.AuthorizationModelValidation.Plugins))
+
+	val = res.Get("properties.authorizationModelValidation.properties.reservedTypeNames.default")
+	require.True(t, val.Exists())
+	require.Len(t, val.Array(), len(cfg.AuthorizationModelValidation.ReservedTypeNames))
+
+	val = res.Get("properties.authorizationModelValidation.properties.reservedRelationNames.default")
+	require.True(t, val.Exists())
+	require.Len(t, val.Array(), len(cfg.AuthorizationModelValidation.ReservedRelationNames))
+
+	val = res.Get("properties.authorizationModelValidation.properties.maxTypeNameLength.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.AuthorizationModelValidation.MaxTypeNameLength)
+
+	val = res.Get("properties.authorizationModelValidation.properties.maxRelationNameLength.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.AuthorizationModelValidation.MaxRelationNameLength)
+
+	val = res.Get("properties.authorizationModelValidation.properties.disallowWildcards.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.AuthorizationModelValidation.DisallowWildcards)
+
+	val = res.Get("properties.authorizationModelValidation.properties.disallowIntersectionAndExclusion.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.AuthorizationModelValidation.DisallowIntersectionAndExclusion)
+
+	val = res.Get("properties.requestEnrichment.properties.contextualTuples.default")
+	require.True(t, val.Exists())
+	require.Equal(t, len(val.Array()), len(cfg.RequestEnrichment.ContextualTuples))
+
 	val = res.Get("properties.metrics.properties.enabled.default")
 	require.True(t, val.Exists())
 	require.Equal(t, val.Bool(), cfg.Metrics.Enabled)
@@ -1011,6 +1774,190 @@ func TestDefaultConfig(t *testing.T) {
 	require.True(t, val.Exists())
 	require.Equal(t, val.Bool(), cfg.Metrics.EnableRPCHistograms)
 
+	val = res.Get("properties.metrics.properties.enableStoreLabels.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.Metrics.EnableStoreLabels)
+
+	val = res.Get("properties.metrics.properties.storeLabelsAllowlist.default")
+	require.True(t, val.Exists())
+	require.Equal(t, len(val.Array()), len(cfg.Metrics.StoreLabelsAllowlist))
+
+	val = res.Get("properties.metrics.properties.storeLabelsTopK.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.Metrics.StoreLabelsTopK)
+
+	val = res.Get("properties.checkProfiling.properties.enabled.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.CheckProfiling.Enabled)
+
+	val = res.Get("properties.checkProfiling.properties.latencyThreshold.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.CheckProfiling.LatencyThreshold.String())
+
+	val = res.Get("properties.checkProfiling.properties.sampleRate.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Float(), cfg.CheckProfiling.SampleRate)
+
+	val = res.Get("properties.checkProfiling.properties.filePath.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.CheckProfiling.FilePath)
+
+	val = res.Get("properties.capture.properties.enabled.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.Capture.Enabled)
+
+	val = res.Get("properties.capture.properties.sampleRate.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Float(), cfg.Capture.SampleRate)
+
+	val = res.Get("properties.capture.properties.filePath.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.Capture.FilePath)
+
+	val = res.Get("properties.capture.properties.redactionKey.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.Capture.RedactionKey)
+
+	val = res.Get("properties.inlineModel.properties.enabled.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.InlineModel.Enabled)
+
+	val = res.Get("properties.inlineModel.properties.maxSizeInBytes.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.InlineModel.MaxSizeInBytes)
+
+	val = res.Get("properties.batchCheck.properties.maxChecks.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.BatchCheck.MaxChecks)
+
+	val = res.Get("properties.batchCheck.properties.maxConcurrentChecks.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.BatchCheck.MaxConcurrentChecks)
+
+	val = res.Get("properties.batchCheck.properties.maxDatastoreQueries.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.BatchCheck.MaxDatastoreQueries)
+
+	val = res.Get("properties.relationUsage.properties.enabled.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.RelationUsage.Enabled)
+
+	val = res.Get("properties.relationUsage.properties.window.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.RelationUsage.Window.String())
+
+	val = res.Get("properties.relationUsage.properties.maxRelations.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.RelationUsage.MaxRelations)
+
+	val = res.Get("properties.slo.properties.enabled.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.SLO.Enabled)
+
+	val = res.Get("properties.slo.properties.availabilityObjective.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Float(), cfg.SLO.AvailabilityObjective)
+
+	val = res.Get("properties.slo.properties.latencyObjective.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Float(), cfg.SLO.LatencyObjective)
+
+	val = res.Get("properties.slo.properties.latencyThreshold.default")
+	require.True(t, val.Exists())
+	latencyThreshold, err := time.ParseDuration(val.String())
+	require.NoError(t, err)
+	require.Equal(t, latencyThreshold, cfg.SLO.LatencyThreshold)
+
+	val = res.Get("properties.slo.properties.methodLatencyThresholds.default")
+	require.True(t, val.Exists())
+	require.Equal(t, len(val.Array()), len(cfg.SLO.MethodLatencyThresholds))
+
+	val = res.Get("properties.slo.properties.window.default")
+	require.True(t, val.Exists())
+	window, err := time.ParseDuration(val.String())
+	require.NoError(t, err)
+	require.Equal(t, window, cfg.SLO.Window)
+
+	val = res.Get("properties.admin.properties.enabled.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.Admin.Enabled)
+
+	val = res.Get("properties.admin.properties.addr.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.Admin.Addr)
+
+	val = res.Get("properties.admin.properties.requiredClaim.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.Admin.RequiredClaim)
+
+	val = res.Get("properties.shadowCheck.properties.enabled.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.ShadowCheck.Enabled)
+
+	val = res.Get("properties.shadowCheck.properties.candidates.default")
+	require.True(t, val.Exists())
+	require.Equal(t, len(val.Array()), len(cfg.ShadowCheck.Candidates))
+
+	val = res.Get("properties.shadowCheck.properties.sampleRate.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Float(), cfg.ShadowCheck.SampleRate)
+
+	val = res.Get("properties.shadowCheck.properties.timeout.default")
+	require.True(t, val.Exists())
+	shadowCheckTimeout, err := time.ParseDuration(val.String())
+	require.NoError(t, err)
+	require.Equal(t, shadowCheckTimeout, cfg.ShadowCheck.Timeout)
+
+	val = res.Get("properties.shadowCheck.properties.maxConcurrency.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.ShadowCheck.MaxConcurrency)
+
+	val = res.Get("properties.maintenance.properties.enabled.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.Maintenance.Enabled)
+
+	val = res.Get("properties.maintenance.properties.message.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.Maintenance.Message)
+
+	val = res.Get("properties.maintenance.properties.retryAfter.default")
+	require.True(t, val.Exists())
+	retryAfter, err := time.ParseDuration(val.String())
+	require.NoError(t, err)
+	require.Equal(t, retryAfter, cfg.Maintenance.RetryAfter)
+
+	val = res.Get("properties.loadShedding.properties.maxConcurrentRequests.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.LoadShedding.MaxConcurrentRequests)
+
+	val = res.Get("properties.loadShedding.properties.retryAfter.default")
+	require.True(t, val.Exists())
+	loadSheddingRetryAfter, err := time.ParseDuration(val.String())
+	require.NoError(t, err)
+	require.Equal(t, loadSheddingRetryAfter, cfg.LoadShedding.RetryAfter)
+
+	val = res.Get("properties.readGuardrails.properties.rejectUnfiltered.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.ReadGuardrails.RejectUnfiltered)
+
+	val = res.Get("properties.readGuardrails.properties.minFilterDimensions.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.ReadGuardrails.MinFilterDimensions)
+
+	val = res.Get("properties.readGuardrails.properties.largeStoreTupleCount.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.ReadGuardrails.LargeStoreTupleCount)
+
+	val = res.Get("properties.continuationToken.properties.previousEncryptionKeys.default")
+	require.True(t, val.Exists())
+	require.Len(t, val.Array(), len(cfg.ContinuationToken.PreviousEncryptionKeys))
+
+	val = res.Get("properties.continuationToken.properties.ttl.default")
+	require.True(t, val.Exists())
+	continuationTokenTTL, err := time.ParseDuration(val.String())
+	require.NoError(t, err)
+	require.Equal(t, continuationTokenTTL, cfg.ContinuationToken.TTL)
+
 	val = res.Get("properties.trace.properties.serviceName.default")
 	require.True(t, val.Exists())
 	require.Equal(t, val.String(), cfg.Trace.ServiceName)
@@ -1035,6 +1982,78 @@ func TestDefaultConfig(t *testing.T) {
 	require.True(t, val.Exists())
 	require.Equal(t, val.String(), cfg.CheckQueryCache.TTL.String())
 
+	val = res.Get("properties.checkQueryCache.properties.hotKeyQPSThreshold.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Float(), cfg.CheckQueryCache.HotKeyQPSThreshold)
+
+	val = res.Get("properties.checkQueryCache.properties.hotKeyTTLMultiplier.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.CheckQueryCache.HotKeyTTLMultiplier)
+
+	val = res.Get("properties.checkQueryCache.properties.negativeLimit.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.CheckQueryCache.NegativeLimit)
+
+	val = res.Get("properties.checkQueryCache.properties.negativeTTL.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.CheckQueryCache.NegativeTTL.String())
+
+	val = res.Get("properties.cluster.properties.enabled.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.Cluster.Enabled)
+
+	val = res.Get("properties.cluster.properties.probeInterval.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.Cluster.ProbeInterval.String())
+
+	val = res.Get("properties.cluster.properties.dispatchRetries.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.Cluster.DispatchRetries)
+
+	val = res.Get("properties.checkQueryCache.properties.changelogInterval.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.CheckQueryCache.ChangelogInterval.String())
+
+	val = res.Get("properties.log.properties.sampling.properties.initial.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.Log.Sampling.Initial)
+
+	val = res.Get("properties.log.properties.sampling.properties.thereafter.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.Log.Sampling.Thereafter)
+
+	val = res.Get("properties.log.properties.sampling.properties.level.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.Log.Sampling.Level)
+
+	val = res.Get("properties.http.properties.forwardedHeaders.default")
+	require.True(t, val.Exists())
+	require.Len(t, val.Array(), len(cfg.HTTP.ForwardedHeaders))
+
+	val = res.Get("properties.http.properties.h2cEnabled.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.HTTP.H2CEnabled)
+
+	val = res.Get("properties.http.properties.maxConcurrentStreams.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Uint(), cfg.HTTP.MaxConcurrentStreams)
+
+	val = res.Get("properties.http.properties.listener.properties.maxConnections.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.HTTP.Listener.MaxConnections)
+
+	val = res.Get("properties.http.properties.listener.properties.acceptRate.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Float(), cfg.HTTP.Listener.AcceptRate)
+
+	val = res.Get("properties.http.properties.listener.properties.acceptBurst.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.HTTP.Listener.AcceptBurst)
+
+	val = res.Get("properties.checkQueryCache.properties.sharedMemcachedServers.default")
+	require.True(t, val.Exists())
+	require.Len(t, val.Array(), len(cfg.CheckQueryCache.SharedMemcachedServers))
+
 	val = res.Get("properties.requestDurationDatastoreQueryCountBuckets.default")
 	require.True(t, val.Exists())
 	require.Equal(t, len(val.Array()), len(cfg.RequestDurationDatastoreQueryCountBuckets))
@@ -0,0 +1,27 @@
+package soak
+
+import (
+	"github.com/openfga/openfga/cmd/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// bindRunFlagsFunc binds the cobra cmd flags to the equivalent config value being managed
+// by viper. This bridges the config between cobra flags and viper flags.
+func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		util.MustBindPFlag(grpcAddrFlag, flags.Lookup(grpcAddrFlag))
+		util.MustBindPFlag(apiTokenFlag, flags.Lookup(apiTokenFlag))
+		util.MustBindPFlag(metricsURLFlag, flags.Lookup(metricsURLFlag))
+		util.MustBindPFlag(durationFlag, flags.Lookup(durationFlag))
+		util.MustBindPFlag(concurrencyFlag, flags.Lookup(concurrencyFlag))
+		util.MustBindPFlag(documentsFlag, flags.Lookup(documentsFlag))
+		util.MustBindPFlag(writeRatioFlag, flags.Lookup(writeRatioFlag))
+		util.MustBindPFlag(stalenessBoundFlag, flags.Lookup(stalenessBoundFlag))
+		util.MustBindPFlag(metricsIntervalFlag, flags.Lookup(metricsIntervalFlag))
+		util.MustBindPFlag(warmupFlag, flags.Lookup(warmupFlag))
+		util.MustBindPFlag(maxGoroutineGrowthFlag, flags.Lookup(maxGoroutineGrowthFlag))
+		util.MustBindPFlag(maxMemoryGrowthFlag, flags.Lookup(maxMemoryGrowthFlag))
+		util.MustBindPFlag(maxErrorRateFlag, flags.Lookup(maxErrorRateFlag))
+	}
+}
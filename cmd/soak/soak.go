@@ -0,0 +1,135 @@
+// Package soak contains the command to soak test a deployment with a long-running mixed traffic.
+package soak
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/signal"
+	"syscall"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/internal/soak"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	grpcAddrFlag           = "grpc-addr"
+	apiTokenFlag           = "api-token"
+	metricsURLFlag         = "metrics-url"
+	durationFlag           = "duration"
+	concurrencyFlag        = "concurrency"
+	documentsFlag          = "documents-per-worker"
+	writeRatioFlag         = "write-ratio"
+	stalenessBoundFlag     = "staleness-bound"
+	metricsIntervalFlag    = "metrics-interval"
+	warmupFlag             = "warmup"
+	maxGoroutineGrowthFlag = "max-goroutine-growth"
+	maxMemoryGrowthFlag    = "max-memory-growth"
+	maxErrorRateFlag       = "max-error-rate"
+)
+
+// ErrInvariantsViolated is returned when the soak test violated some invariants.
+var ErrInvariantsViolated = errors.New("the soak test violated some invariants")
+
+func NewSoakCommand() *cobra.Command {
+	defaults := soak.DefaultConfig()
+
+	cmd := &cobra.Command{
+		Use:   "soak",
+		Short: "Soak test a deployment with a long-running mixed read and write traffic.",
+		Long:  "Drive Check, Read, ListObjects and Write requests against a store created in a deployment for --duration, and assert that the reads don't stay stale beyond --staleness-bound and, if --metrics-url is set, that the goroutines and the heap of the server don't grow beyond their baseline after --warmup. The report is printed as JSON, and the store is deleted at the end.\nThe command fails if any invariant is violated, so it can be used to qualify a release. It stops early on SIGINT or SIGTERM.",
+		RunE:  runSoak,
+		Args:  cobra.NoArgs,
+	}
+
+	flags := cmd.Flags()
+	flags.String(grpcAddrFlag, "localhost:8081", "the gRPC address of the deployment")
+	flags.String(apiTokenFlag, "", "the preshared key the requests are authenticated with, if any")
+	flags.String(metricsURLFlag, "", "the URL of the Prometheus metrics of the server, e.g. 'http://localhost:2112/metrics'. The goroutines and the memory aren't asserted if it's empty")
+	flags.Duration(durationFlag, defaults.Duration, "how long the traffic is driven for")
+	flags.Int(concurrencyFlag, defaults.Concurrency, "the number of concurrent workers")
+	flags.Int(documentsFlag, defaults.DocumentsPerWorker, "the number of documents each worker writes and reads")
+	flags.Float64(writeRatioFlag, defaults.WriteRatio, "the fraction (between 0 and 1) of the requests that are writes")
+	flags.Duration(stalenessBoundFlag, defaults.StalenessBound, "how long after a write is acknowledged the reads may still not reflect it, e.g. the TTL of the caches")
+	flags.Duration(metricsIntervalFlag, defaults.MetricsInterval, "the interval the metrics of the server are sampled at")
+	flags.Duration(warmupFlag, defaults.Warmup, "how long after the start the baseline of the metrics is sampled")
+	flags.Float64(maxGoroutineGrowthFlag, defaults.MaxGoroutineGrowth, "the growth of the goroutines allowed over the baseline, e.g. 0.5 for 50%")
+	flags.Float64(maxMemoryGrowthFlag, defaults.MaxMemoryGrowth, "the growth of the heap in use allowed over the baseline, e.g. 1 for 100%")
+	flags.Float64(maxErrorRateFlag, defaults.MaxErrorRate, "the fraction (between 0 and 1) of the requests allowed to fail")
+
+	// NOTE: if you add a new flag here, update the function below, too
+
+	cmd.PreRun = bindRunFlagsFunc(flags)
+
+	return cmd
+}
+
+func runSoak(_ *cobra.Command, _ []string) error {
+	cfg := soak.Config{
+		Duration:           viper.GetDuration(durationFlag),
+		Concurrency:        viper.GetInt(concurrencyFlag),
+		DocumentsPerWorker: viper.GetInt(documentsFlag),
+		WriteRatio:         viper.GetFloat64(writeRatioFlag),
+		StalenessBound:     viper.GetDuration(stalenessBoundFlag),
+		MetricsURL:         viper.GetString(metricsURLFlag),
+		MetricsInterval:    viper.GetDuration(metricsIntervalFlag),
+		Warmup:             viper.GetDuration(warmupFlag),
+		MaxGoroutineGrowth: viper.GetFloat64(maxGoroutineGrowthFlag),
+		MaxMemoryGrowth:    viper.GetFloat64(maxMemoryGrowthFlag),
+		MaxErrorRate:       viper.GetFloat64(maxErrorRateFlag),
+	}
+
+	opts := []grpc.DialOption{
+		grpc.WithBlock(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+	if token := viper.GetString(apiTokenFlag); token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(tokenCredentials(token)))
+	}
+
+	dialCtx, cancelDial := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelDial()
+
+	conn, err := grpc.DialContext(dialCtx, viper.GetString(grpcAddrFlag), opts...)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the deployment: %w", err)
+	}
+	defer conn.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	report, err := soak.Run(ctx, openfgav1.NewOpenFGAServiceClient(conn), cfg)
+	if err != nil {
+		return err
+	}
+
+	marshalled, err := json.MarshalIndent(report, " ", "    ")
+	if err != nil {
+		return fmt.Errorf("error gathering the soak test report: %w", err)
+	}
+	fmt.Println(string(marshalled))
+
+	if len(report.Violations) > 0 {
+		return fmt.Errorf("%w: %d", ErrInvariantsViolated, len(report.Violations))
+	}
+
+	return nil
+}
+
+// tokenCredentials authenticates the gRPC requests with a preshared key.
+type tokenCredentials string
+
+func (c tokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(c)}, nil
+}
+
+func (c tokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
@@ -0,0 +1,55 @@
+package storefile
+
+import (
+	"github.com/openfga/openfga/cmd/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// bindImportFlagsFunc binds the cobra cmd flags to the equivalent config value being managed
+// by viper. This bridges the config between cobra flags and viper flags.
+func bindImportFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		util.MustBindPFlag(datastoreEngineFlag, flags.Lookup(datastoreEngineFlag))
+		util.MustBindPFlag(datastoreURIFlag, flags.Lookup(datastoreURIFlag))
+		util.MustBindPFlag(fileFlag, flags.Lookup(fileFlag))
+		util.MustBindPFlag(storeIDFlag, flags.Lookup(storeIDFlag))
+	}
+}
+
+// bindExportFlagsFunc binds the cobra cmd flags to the equivalent config value being managed
+// by viper. This bridges the config between cobra flags and viper flags.
+func bindExportFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		util.MustBindPFlag(datastoreEngineFlag, flags.Lookup(datastoreEngineFlag))
+		util.MustBindPFlag(datastoreURIFlag, flags.Lookup(datastoreURIFlag))
+		util.MustBindPFlag(storeIDFlag, flags.Lookup(storeIDFlag))
+		util.MustBindPFlag(modelIDFlag, flags.Lookup(modelIDFlag))
+		util.MustBindPFlag(outputFlag, flags.Lookup(outputFlag))
+	}
+}
+
+// bindImportTuplesFlagsFunc binds the cobra cmd flags to the equivalent config value being managed
+// by viper. This bridges the config between cobra flags and viper flags.
+func bindImportTuplesFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		util.MustBindPFlag(datastoreEngineFlag, flags.Lookup(datastoreEngineFlag))
+		util.MustBindPFlag(datastoreURIFlag, flags.Lookup(datastoreURIFlag))
+		util.MustBindPFlag(fileFlag, flags.Lookup(fileFlag))
+		util.MustBindPFlag(formatFlag, flags.Lookup(formatFlag))
+		util.MustBindPFlag(storeIDFlag, flags.Lookup(storeIDFlag))
+		util.MustBindPFlag(modelIDFlag, flags.Lookup(modelIDFlag))
+	}
+}
+
+// bindExportTuplesFlagsFunc binds the cobra cmd flags to the equivalent config value being managed
+// by viper. This bridges the config between cobra flags and viper flags.
+func bindExportTuplesFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		util.MustBindPFlag(datastoreEngineFlag, flags.Lookup(datastoreEngineFlag))
+		util.MustBindPFlag(datastoreURIFlag, flags.Lookup(datastoreURIFlag))
+		util.MustBindPFlag(storeIDFlag, flags.Lookup(storeIDFlag))
+		util.MustBindPFlag(formatFlag, flags.Lookup(formatFlag))
+		util.MustBindPFlag(outputFlag, flags.Lookup(outputFlag))
+	}
+}
@@ -0,0 +1,350 @@
+// Package storefile contains the commands to import and export stores in the OpenFGA store file format
+// ('.fga.yaml'), and to import and export the tuples of a store as YAML, CSV or NDJSON tuple files.
+package storefile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	serverconfig "github.com/openfga/openfga/internal/server/config"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/server/commands"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/mysql"
+	"github.com/openfga/openfga/pkg/storage/postgres"
+	"github.com/openfga/openfga/pkg/storage/sqlcommon"
+	"github.com/openfga/openfga/pkg/storage/sqlite"
+	"github.com/openfga/openfga/pkg/storefile"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	datastoreEngineFlag = "datastore-engine"
+	datastoreURIFlag    = "datastore-uri"
+	fileFlag            = "file"
+	storeIDFlag         = "store-id"
+	modelIDFlag         = "model-id"
+	outputFlag          = "output"
+	formatFlag          = "format"
+)
+
+func NewStoreCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "store",
+		Short: "Import and export stores in the OpenFGA store file format ('.fga.yaml').",
+	}
+
+	cmd.AddCommand(newImportCommand(), newExportCommand(), newImportTuplesCommand(), newExportTuplesCommand())
+
+	return cmd
+}
+
+func newImportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import a store file into a new store, or into an existing one.",
+		Long:  "Write the model, the tuples and the Check tests (as assertions) of a store file ('.fga.yaml') to a new store, or to the store of --store-id.\nThe Check tests that have tuples of their own aren't imported.",
+		RunE:  runImport,
+		Args:  cobra.NoArgs,
+	}
+
+	flags := cmd.Flags()
+	flags.String(datastoreEngineFlag, "", "the datastore engine")
+	flags.String(datastoreURIFlag, "", "the connection uri to the datastore")
+	flags.String(fileFlag, "", "the path of the store file")
+	flags.String(storeIDFlag, "", "the id of the store to import the file into (defaults to a new store named after the file)")
+
+	// NOTE: if you add a new flag here, update the function below, too
+
+	cmd.PreRun = bindImportFlagsFunc(flags)
+
+	return cmd
+}
+
+func newExportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export a store as a store file.",
+		Long:  "Write the model, the tuples and the assertions (as Check tests) of a store to a store file ('.fga.yaml').",
+		RunE:  runExport,
+		Args:  cobra.NoArgs,
+	}
+
+	flags := cmd.Flags()
+	flags.String(datastoreEngineFlag, "", "the datastore engine")
+	flags.String(datastoreURIFlag, "", "the connection uri to the datastore")
+	flags.String(storeIDFlag, "", "the id of the store")
+	flags.String(modelIDFlag, "", "the id of the authorization model (defaults to the latest model of the store)")
+	flags.String(outputFlag, "", "the path of the store file to write (defaults to the standard output)")
+
+	// NOTE: if you add a new flag here, update the function below, too
+
+	cmd.PreRun = bindExportFlagsFunc(flags)
+
+	return cmd
+}
+
+func newImportTuplesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import-tuples",
+		Short: "Import a tuple file into an existing store.",
+		Long:  "Write the tuples of a YAML, CSV or NDJSON tuple file to a store, after validating them against the model of --model-id.\nThe header of a CSV file names its columns: either 'user', 'relation' and 'object', or 'user_type', 'user_id', 'user_relation' (optional), 'relation', 'object_type' and 'object_id'.",
+		RunE:  runImportTuples,
+		Args:  cobra.NoArgs,
+	}
+
+	flags := cmd.Flags()
+	flags.String(datastoreEngineFlag, "", "the datastore engine")
+	flags.String(datastoreURIFlag, "", "the connection uri to the datastore")
+	flags.String(fileFlag, "", "the path of the tuple file")
+	flags.String(formatFlag, "", "the format of the tuple file: 'yaml', 'csv' or 'ndjson' (defaults to the format of its extension)")
+	flags.String(storeIDFlag, "", "the id of the store")
+	flags.String(modelIDFlag, "", "the id of the authorization model to validate the tuples against (defaults to the latest model of the store)")
+
+	// NOTE: if you add a new flag here, update the function below, too
+
+	cmd.PreRun = bindImportTuplesFlagsFunc(flags)
+
+	return cmd
+}
+
+func newExportTuplesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export-tuples",
+		Short: "Export the tuples of a store as a tuple file.",
+		Long:  "Write the tuples of a store to a YAML, CSV or NDJSON tuple file.",
+		RunE:  runExportTuples,
+		Args:  cobra.NoArgs,
+	}
+
+	flags := cmd.Flags()
+	flags.String(datastoreEngineFlag, "", "the datastore engine")
+	flags.String(datastoreURIFlag, "", "the connection uri to the datastore")
+	flags.String(storeIDFlag, "", "the id of the store")
+	flags.String(formatFlag, "", "the format of the tuple file: 'yaml', 'csv' or 'ndjson' (defaults to the format of the extension of --output, or to 'yaml')")
+	flags.String(outputFlag, "", "the path of the tuple file to write (defaults to the standard output)")
+
+	// NOTE: if you add a new flag here, update the function below, too
+
+	cmd.PreRun = bindExportTuplesFlagsFunc(flags)
+
+	return cmd
+}
+
+func openDatastore() (storage.OpenFGADatastore, error) {
+	engine := viper.GetString(datastoreEngineFlag)
+	uri := viper.GetString(datastoreURIFlag)
+
+	var (
+		db  storage.OpenFGADatastore
+		err error
+	)
+	switch engine {
+	case "mysql":
+		db, err = mysql.New(uri, sqlcommon.NewConfig())
+	case "postgres":
+		db, err = postgres.New(uri, sqlcommon.NewConfig())
+	case "sqlite":
+		db, err = sqlite.New(uri, sqlcommon.NewConfig())
+	case "":
+		return nil, fmt.Errorf("missing datastore engine type")
+	case "memory":
+		fallthrough
+	default:
+		return nil, fmt.Errorf("storage engine '%s' is unsupported", engine)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a connection to the datastore: %v", err)
+	}
+
+	return db, nil
+}
+
+func runImport(_ *cobra.Command, _ []string) error {
+	path := viper.GetString(fileFlag)
+	storeID := viper.GetString(storeIDFlag)
+
+	if path == "" {
+		return fmt.Errorf("missing store file")
+	}
+
+	file, err := storefile.Load(path)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDatastore()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	resp, err := Import(context.Background(), db, file, storeID)
+	if err != nil {
+		return err
+	}
+
+	marshalled, err := json.MarshalIndent(resp, " ", "    ")
+	if err != nil {
+		return fmt.Errorf("error importing the store file: %w", err)
+	}
+	fmt.Println(string(marshalled))
+
+	return nil
+}
+
+func runExport(_ *cobra.Command, _ []string) error {
+	storeID := viper.GetString(storeIDFlag)
+	modelID := viper.GetString(modelIDFlag)
+	output := viper.GetString(outputFlag)
+
+	if storeID == "" {
+		return fmt.Errorf("missing store id")
+	}
+
+	db, err := openDatastore()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	file, err := Export(context.Background(), db, storeID, modelID)
+	if err != nil {
+		return err
+	}
+
+	data, err := file.Marshal()
+	if err != nil {
+		return fmt.Errorf("error exporting the store: %w", err)
+	}
+
+	if output == "" {
+		fmt.Print(string(data))
+		return nil
+	}
+
+	return os.WriteFile(output, data, 0o644)
+}
+
+func runImportTuples(_ *cobra.Command, _ []string) error {
+	path := viper.GetString(fileFlag)
+	storeID := viper.GetString(storeIDFlag)
+	modelID := viper.GetString(modelIDFlag)
+
+	if path == "" {
+		return fmt.Errorf("missing tuple file")
+	}
+
+	if storeID == "" {
+		return fmt.Errorf("missing store id")
+	}
+
+	format, err := tupleFormat(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read the tuple file: %w", err)
+	}
+
+	tuples, err := storefile.ParseTuples(data, format)
+	if err != nil {
+		return fmt.Errorf("failed to parse the tuple file '%s': %w", path, err)
+	}
+
+	db, err := openDatastore()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	resp, err := ImportTuples(context.Background(), db, storeID, modelID, tuples)
+	if err != nil {
+		return err
+	}
+
+	marshalled, err := json.MarshalIndent(resp, " ", "    ")
+	if err != nil {
+		return fmt.Errorf("error importing the tuple file: %w", err)
+	}
+	fmt.Println(string(marshalled))
+
+	return nil
+}
+
+func runExportTuples(_ *cobra.Command, _ []string) error {
+	storeID := viper.GetString(storeIDFlag)
+	output := viper.GetString(outputFlag)
+
+	if storeID == "" {
+		return fmt.Errorf("missing store id")
+	}
+
+	format, err := tupleFormat(output)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDatastore()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	file, err := Export(context.Background(), db, storeID, "")
+	if err != nil {
+		return err
+	}
+
+	data, err := storefile.MarshalTuples(file.Tuples, format)
+	if err != nil {
+		return fmt.Errorf("error exporting the tuples: %w", err)
+	}
+
+	if output == "" {
+		fmt.Print(string(data))
+		return nil
+	}
+
+	return os.WriteFile(output, data, 0o644)
+}
+
+// tupleFormat returns the format of the --format flag, or the format of the extension of the path.
+func tupleFormat(path string) (storefile.TupleFormat, error) {
+	if name := viper.GetString(formatFlag); name != "" {
+		return storefile.ParseTupleFormat(name)
+	}
+
+	return storefile.TupleFormatOf(path), nil
+}
+
+// Import writes the provided store file to a new store, or to the store of storeID if it isn't empty.
+func Import(ctx context.Context, db storage.OpenFGADatastore, file *storefile.StoreFile, storeID string) (*commands.ImportStoreResponse, error) {
+	c := commands.NewImportStoreCommand(db, logger.NewNoopLogger(), serverconfig.DefaultMaxAuthorizationModelSizeInBytes)
+	return c.Execute(ctx, &commands.ImportStoreRequest{StoreID: storeID, File: file})
+}
+
+// Export reads the provided store as a store file, with the given authorization model, or with the latest
+// authorization model of the store if modelID is empty.
+func Export(ctx context.Context, db storage.OpenFGADatastore, storeID, modelID string) (*storefile.StoreFile, error) {
+	return commands.NewExportStoreQuery(db, logger.NewNoopLogger()).Execute(ctx, &commands.ExportStoreRequest{
+		StoreID:              storeID,
+		AuthorizationModelID: modelID,
+	})
+}
+
+// ImportTuples writes the provided tuples to a store, after validating them against the given authorization
+// model, or against the latest authorization model of the store if modelID is empty.
+func ImportTuples(ctx context.Context, db storage.OpenFGADatastore, storeID, modelID string, tuples []*storefile.TupleKey) (*commands.ImportTuplesResponse, error) {
+	return commands.NewImportTuplesCommand(db, logger.NewNoopLogger()).Execute(ctx, &commands.ImportTuplesRequest{
+		StoreID:              storeID,
+		AuthorizationModelID: modelID,
+		Tuples:               tuples,
+	})
+}
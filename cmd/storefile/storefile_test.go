@@ -0,0 +1,130 @@
+package storefile
+
+import (
+	"context"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/storefile"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const storeFile = `
+name: documents
+model: |
+  model
+    schema 1.1
+
+  type user
+
+  type document
+    relations
+      define editor: [user]
+      define viewer: [user] or editor
+tuples:
+  - user: user:anne
+    relation: editor
+    object: document:1
+  - user: user:bob
+    relation: viewer
+    object: document:1
+tests:
+  - name: viewers
+    check:
+      - user: user:anne
+        object: document:1
+        assertions:
+          editor: true
+          viewer: true
+  - name: with tuples of its own
+    tuples:
+      - user: user:charlie
+        relation: viewer
+        object: document:2
+    check:
+      - user: user:charlie
+        object: document:2
+        assertions:
+          viewer: true
+`
+
+func TestImportExport(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	defer ds.Close()
+
+	file, err := storefile.Parse([]byte(storeFile))
+	require.NoError(t, err)
+
+	resp, err := Import(ctx, ds, file, "")
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.StoreID)
+	require.Equal(t, 2, resp.TuplesWritten)
+	require.Equal(t, 2, resp.AssertionsWritten)
+
+	exported, err := Export(ctx, ds, resp.StoreID, "")
+	require.NoError(t, err)
+	require.Equal(t, "documents", exported.Name)
+	require.Equal(t, file.Tuples, exported.Tuples)
+	require.Equal(t, []*storefile.Test{{
+		Name:  "assertions",
+		Check: []*storefile.CheckTest{file.Tests[0].Check[0]},
+	}}, exported.Tests)
+
+	exportedModel, err := exported.AuthorizationModel()
+	require.NoError(t, err)
+	model, err := file.AuthorizationModel()
+	require.NoError(t, err)
+	require.True(t, proto.Equal(model, exportedModel))
+
+	t.Run("import_into_an_existing_store", func(t *testing.T) {
+		file.Tuples = nil
+
+		reimported, err := Import(ctx, ds, file, resp.StoreID)
+		require.NoError(t, err)
+		require.Equal(t, resp.StoreID, reimported.StoreID)
+		require.NotEqual(t, resp.AuthorizationModelID, reimported.AuthorizationModelID)
+	})
+
+	t.Run("invalid_tuples_are_rejected_before_writing", func(t *testing.T) {
+		invalid, err := storefile.Parse([]byte(storeFile))
+		require.NoError(t, err)
+		invalid.Tuples = append(invalid.Tuples, &storefile.TupleKey{User: "user:anne", Relation: "owner", Object: "document:1"})
+
+		_, err = Import(ctx, ds, invalid, "")
+		require.Error(t, err)
+
+		stores, _, err := ds.ListStores(ctx, storage.NewPaginationOptions(0, ""))
+		require.NoError(t, err)
+		require.Len(t, stores, 1)
+	})
+
+	t.Run("import_tuples", func(t *testing.T) {
+		tuples, err := storefile.ParseTuples([]byte("user,relation,object\nuser:dan,viewer,document:3\n"), storefile.TupleFormatCSV)
+		require.NoError(t, err)
+
+		imported, err := ImportTuples(ctx, ds, resp.StoreID, resp.AuthorizationModelID, tuples)
+		require.NoError(t, err)
+		require.Equal(t, 1, imported.TuplesWritten)
+
+		exported, err := Export(ctx, ds, resp.StoreID, "")
+		require.NoError(t, err)
+		require.Contains(t, exported.Tuples, &storefile.TupleKey{User: "user:dan", Relation: "viewer", Object: "document:3"})
+
+		_, err = ImportTuples(ctx, ds, resp.StoreID, "", []*storefile.TupleKey{{User: "user:dan", Relation: "owner", Object: "document:3"}})
+		require.Error(t, err)
+
+		_, err = ImportTuples(ctx, ds, "unknown", "", tuples)
+		require.Equal(t, codes.Code(openfgav1.NotFoundErrorCode_store_id_not_found), status.Code(err))
+	})
+
+	t.Run("unknown_store", func(t *testing.T) {
+		_, err := Export(ctx, ds, "unknown", "")
+		require.Equal(t, codes.Code(openfgav1.NotFoundErrorCode_store_id_not_found), status.Code(err))
+	})
+}
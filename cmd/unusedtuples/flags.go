@@ -0,0 +1,20 @@
+package unusedtuples
+
+import (
+	"github.com/openfga/openfga/cmd/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// bindRunFlags binds the cobra cmd flags to the equivalent config value being managed
+// by viper. This bridges the config between cobra flags and viper flags.
+func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		util.MustBindPFlag(datastoreEngineFlag, flags.Lookup(datastoreEngineFlag))
+		util.MustBindPFlag(datastoreURIFlag, flags.Lookup(datastoreURIFlag))
+		util.MustBindPFlag(storeIDFlag, flags.Lookup(storeIDFlag))
+		util.MustBindPFlag(modelIDFlag, flags.Lookup(modelIDFlag))
+		util.MustBindPFlag(cleanupFlag, flags.Lookup(cleanupFlag))
+		util.MustBindPFlag(batchSizeFlag, flags.Lookup(batchSizeFlag))
+	}
+}
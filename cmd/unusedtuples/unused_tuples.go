@@ -0,0 +1,124 @@
+// Package unusedtuples contains the command to report, and optionally delete, the tuples that don't fit an
+// authorization model anymore.
+package unusedtuples
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/server/commands"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/mysql"
+	"github.com/openfga/openfga/pkg/storage/postgres"
+	"github.com/openfga/openfga/pkg/storage/sqlcommon"
+	"github.com/openfga/openfga/pkg/storage/sqlite"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	datastoreEngineFlag = "datastore-engine"
+	datastoreURIFlag    = "datastore-uri"
+	storeIDFlag         = "store-id"
+	modelIDFlag         = "model-id"
+	cleanupFlag         = "cleanup"
+	batchSizeFlag       = "batch-size"
+)
+
+func NewUnusedTuplesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "unused-tuples",
+		Short: "Report the tuples of a store that don't fit its authorization model anymore.",
+		Long:  "Read every tuple of a store and report the ones whose type, relation or user type isn't in the authorization model anymore, e.g. after a model change that removed a relation.\nWith --cleanup, the tuples reported are deleted, in batches of --batch-size tuples.",
+		RunE:  runUnusedTuples,
+		Args:  cobra.NoArgs,
+	}
+
+	flags := cmd.Flags()
+	flags.String(datastoreEngineFlag, "", "the datastore engine")
+	flags.String(datastoreURIFlag, "", "the connection uri to the datastore")
+	flags.String(storeIDFlag, "", "the id of the store")
+	flags.String(modelIDFlag, "", "the id of the authorization model (defaults to the latest model of the store)")
+	flags.Bool(cleanupFlag, false, "delete the tuples reported")
+	flags.Int(batchSizeFlag, storage.DefaultMaxTuplesPerWrite, "the number of tuples deleted per write, capped to the maximum number of tuples per write of the datastore")
+
+	// NOTE: if you add a new flag here, update the function below, too
+
+	cmd.PreRun = bindRunFlagsFunc(flags)
+
+	return cmd
+}
+
+func runUnusedTuples(_ *cobra.Command, _ []string) error {
+	engine := viper.GetString(datastoreEngineFlag)
+	uri := viper.GetString(datastoreURIFlag)
+	storeID := viper.GetString(storeIDFlag)
+	modelID := viper.GetString(modelIDFlag)
+
+	if storeID == "" {
+		return fmt.Errorf("missing store id")
+	}
+
+	ctx := context.Background()
+
+	var (
+		db  storage.OpenFGADatastore
+		err error
+	)
+	switch engine {
+	case "mysql":
+		db, err = mysql.New(uri, sqlcommon.NewConfig())
+	case "postgres":
+		db, err = postgres.New(uri, sqlcommon.NewConfig())
+	case "sqlite":
+		db, err = sqlite.New(uri, sqlcommon.NewConfig())
+	case "":
+		return fmt.Errorf("missing datastore engine type")
+	case "memory":
+		fallthrough
+	default:
+		return fmt.Errorf("storage engine '%s' is unsupported", engine)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to open a connection to the datastore: %v", err)
+	}
+	defer db.Close()
+
+	var opts []commands.UnusedTuplesQueryOption
+	if viper.GetBool(cleanupFlag) {
+		opts = append(opts, commands.WithUnusedTuplesCleanup(viper.GetInt(batchSizeFlag)))
+	}
+
+	report, err := UnusedTuples(ctx, db, storeID, modelID, opts...)
+	if err != nil {
+		return err
+	}
+
+	marshalled, err := json.MarshalIndent(report, " ", "    ")
+	if err != nil {
+		return fmt.Errorf("error gathering unused tuples: %w", err)
+	}
+	fmt.Println(string(marshalled))
+
+	return nil
+}
+
+// UnusedTuples reports the tuples of the store that don't fit the given authorization model, or the latest
+// authorization model of the store if modelID is empty.
+func UnusedTuples(ctx context.Context, db storage.OpenFGADatastore, storeID, modelID string, opts ...commands.UnusedTuplesQueryOption) (*commands.UnusedTuplesResponse, error) {
+	typesys, err := typesystem.MemoizedTypesystemResolverFunc(db)(ctx, storeID, modelID)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the authorization model: %w", err)
+	}
+
+	resp, err := commands.NewUnusedTuplesQuery(db, logger.NewNoopLogger(), opts...).Execute(ctx, storeID, typesys)
+	if err != nil {
+		return nil, fmt.Errorf("error reading or deleting the tuples: %w", err)
+	}
+
+	return resp, nil
+}
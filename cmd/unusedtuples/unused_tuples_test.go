@@ -0,0 +1,76 @@
+package unusedtuples
+
+import (
+	"context"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/server/commands"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnusedTuples(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	defer ds.Close()
+
+	storeID := ulid.Make().String()
+
+	// the tuples are written against a model that the latest model changed
+	err := ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:1", "viewer", "group:eng#member"),
+		tuple.NewTupleKey("document:1", "commenter", "user:anne"),
+		tuple.NewTupleKey("folder:1", "viewer", "user:anne"),
+	})
+	require.NoError(t, err)
+
+	err = ds.WriteAuthorizationModel(ctx, storeID, &openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type group
+		  relations
+		    define member: [user] as self
+
+		type document
+		  relations
+		    define viewer: [user] as self
+		`),
+	})
+	require.NoError(t, err)
+
+	report, err := UnusedTuples(ctx, ds, storeID, "")
+	require.NoError(t, err)
+	require.Equal(t, 4, report.TuplesScanned)
+	require.Len(t, report.UnusedTuples, 3)
+	require.Zero(t, report.Deleted)
+
+	unused := make([]string, 0, len(report.UnusedTuples))
+	for _, u := range report.UnusedTuples {
+		require.NotEmpty(t, u.Reason)
+		unused = append(unused, tuple.TupleKeyToString(u.TupleKey))
+	}
+	require.ElementsMatch(t, []string{
+		"document:1#viewer@group:eng#member",
+		"document:1#commenter@user:anne",
+		"folder:1#viewer@user:anne",
+	}, unused)
+
+	report, err = UnusedTuples(ctx, ds, storeID, "", commands.WithUnusedTuplesCleanup(2))
+	require.NoError(t, err)
+	require.Equal(t, 3, report.Deleted)
+
+	tuples, _, err := ds.ReadPage(ctx, storeID, &openfgav1.TupleKey{}, storage.NewPaginationOptions(10, ""), storage.ReadOptions{})
+	require.NoError(t, err)
+	require.Len(t, tuples, 1)
+	require.Equal(t, "document:1#viewer@user:anne", tuple.TupleKeyToString(tuples[0].GetKey()))
+}
@@ -44,6 +44,9 @@ func (n NoopAuthenticator) Close() {}
 type AuthClaims struct {
 	Subject string
 	Scopes  map[string]bool
+
+	// Claims holds all the claims of the token of the caller, if the authentication method has any.
+	Claims map[string]interface{}
 }
 
 // ContextWithAuthClaims injects the provided AuthClaims into the parent context.
@@ -20,6 +20,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/openfga/openfga/internal/authn"
+	"github.com/openfga/openfga/pkg/telemetry"
 )
 
 type RemoteOidcAuthenticator struct {
@@ -48,6 +49,7 @@ var _ authn.OIDCAuthenticator = (*RemoteOidcAuthenticator)(nil)
 func NewRemoteOidcAuthenticator(issuerURL, audience string) (*RemoteOidcAuthenticator, error) {
 	client := retryablehttp.NewClient()
 	client.Logger = nil
+	client.HTTPClient.Transport = telemetry.NewPropagatingTransport(client.HTTPClient.Transport)
 	oidc := &RemoteOidcAuthenticator{
 		IssuerURL:  issuerURL,
 		Audience:   audience,
@@ -103,6 +105,7 @@ func (oidc *RemoteOidcAuthenticator) Authenticate(requestContext context.Context
 	principal := &authn.AuthClaims{
 		Subject: subject,
 		Scopes:  make(map[string]bool),
+		Claims:  claims,
 	}
 
 	// optional scopes
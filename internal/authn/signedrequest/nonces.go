@@ -0,0 +1,56 @@
+package signedrequest
+
+import (
+	"sync"
+	"time"
+)
+
+// nonceCache remembers the nonces of the requests until they expire. It never evicts a nonce before it
+// expires: when the cache is full, the new nonces are rejected instead, since evicting a nonce would let
+// its request be replayed.
+type nonceCache struct {
+	mu      sync.Mutex
+	nonces  map[string]struct{}
+	expiry  []expiringNonce // in the order the nonces were added, so in the order they expire
+	maxSize int
+}
+
+type expiringNonce struct {
+	nonce     string
+	expiresAt time.Time
+}
+
+func newNonceCache(maxSize int) *nonceCache {
+	return &nonceCache{
+		nonces:  map[string]struct{}{},
+		maxSize: maxSize,
+	}
+}
+
+// add remembers the nonce for the ttl, and returns errReplayedRequest if it's already remembered, or
+// errTooManyNonces if the cache is full. The check and the add are atomic, so that concurrent requests with
+// the same nonce are only accepted once.
+func (c *nonceCache) add(nonce string, now time.Time, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// every nonce is remembered for the same ttl, so the nonces expire in the order they were added
+	for len(c.expiry) > 0 && !c.expiry[0].expiresAt.After(now) {
+		delete(c.nonces, c.expiry[0].nonce)
+		c.expiry[0] = expiringNonce{}
+		c.expiry = c.expiry[1:]
+	}
+
+	if _, ok := c.nonces[nonce]; ok {
+		return errReplayedRequest
+	}
+
+	if len(c.nonces) >= c.maxSize {
+		return errTooManyNonces
+	}
+
+	c.nonces[nonce] = struct{}{}
+	c.expiry = append(c.expiry, expiringNonce{nonce: nonce, expiresAt: now.Add(ttl)})
+
+	return nil
+}
@@ -0,0 +1,242 @@
+package signedrequest
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const checkMethod = "/openfga.v1.OpenFGAService/Check"
+
+// methodStream is the server transport stream of a call to a method.
+type methodStream struct {
+	grpc.ServerTransportStream
+	method string
+}
+
+func (m *methodStream) Method() string {
+	return m.method
+}
+
+// methodContext returns the context of a call to the full gRPC method.
+func methodContext(ctx context.Context, method string) context.Context {
+	return grpc.NewContextWithServerTransportStream(ctx, &methodStream{method: method})
+}
+
+func signedContext(t *testing.T, keyID, secret string, timestamp time.Time, nonce string, req *openfgav1.CheckRequest) context.Context {
+	digest, err := ContentDigest(req)
+	require.NoError(t, err)
+
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+
+	return methodContext(metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		TimestampHeader, ts,
+		NonceHeader, nonce,
+		ContentDigestHeader, digest,
+		SignatureHeader, Sign(keyID, secret, checkMethod, ts, nonce, digest),
+	)), checkMethod)
+}
+
+func TestNewSignedRequestAuthenticatorValidatesKeys(t *testing.T) {
+	_, err := NewSignedRequestAuthenticator(nil)
+	require.ErrorContains(t, err, "please specify at least one signing key")
+
+	_, err = NewSignedRequestAuthenticator([]string{"missing-secret"})
+	require.ErrorContains(t, err, "signing keys must be of the form")
+
+	_, err = NewSignedRequestAuthenticator([]string{"key:"})
+	require.ErrorContains(t, err, "signing keys must be of the form")
+}
+
+func TestSignedRequestAuthenticator(t *testing.T) {
+	now := time.Now()
+	authenticator, err := NewSignedRequestAuthenticator([]string{"one:secret1", "two:secret2"}, WithMaxClockSkew(time.Minute))
+	require.NoError(t, err)
+	defer authenticator.Close()
+	authenticator.now = func() time.Time { return now }
+
+	req := &openfgav1.CheckRequest{
+		StoreId:  "store",
+		TupleKey: tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+	}
+
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		expectedError error
+		expectedKeyID string
+	}{
+		{
+			name:          "missing_headers",
+			ctx:           context.Background(),
+			expectedError: errMissingSignature,
+		},
+		{
+			name:          "valid_signature_with_first_key",
+			ctx:           signedContext(t, "one", "secret1", now, "nonce-1", req),
+			expectedKeyID: "one",
+		},
+		{
+			name:          "valid_signature_with_second_key",
+			ctx:           signedContext(t, "two", "secret2", now.Add(-30*time.Second), "nonce-2", req),
+			expectedKeyID: "two",
+		},
+		{
+			name:          "unknown_key",
+			ctx:           signedContext(t, "three", "secret1", now, "nonce-3", req),
+			expectedError: errInvalidSignature,
+		},
+		{
+			name:          "wrong_secret",
+			ctx:           signedContext(t, "one", "secret2", now, "nonce-4", req),
+			expectedError: errInvalidSignature,
+		},
+		{
+			name:          "expired_timestamp",
+			ctx:           signedContext(t, "one", "secret1", now.Add(-2*time.Minute), "nonce-5", req),
+			expectedError: errExpiredSignature,
+		},
+		{
+			name:          "timestamp_in_the_future",
+			ctx:           signedContext(t, "one", "secret1", now.Add(2*time.Minute), "nonce-6", req),
+			expectedError: errExpiredSignature,
+		},
+		{
+			name:          "replayed_nonce",
+			ctx:           signedContext(t, "one", "secret1", now, "nonce-1", req),
+			expectedError: errReplayedRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			claims, err := authenticator.Authenticate(test.ctx)
+			if test.expectedError != nil {
+				require.ErrorIs(t, err, test.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expectedKeyID, claims.Subject)
+		})
+	}
+
+	t.Run("tampered_header", func(t *testing.T) {
+		ctx := signedContext(t, "one", "secret1", now, "nonce-7", req)
+		md, _ := metadata.FromIncomingContext(ctx)
+		md.Set(NonceHeader, "nonce-8")
+
+		_, err := authenticator.Authenticate(methodContext(metadata.NewIncomingContext(context.Background(), md), checkMethod))
+		require.ErrorIs(t, err, errInvalidSignature)
+	})
+
+	t.Run("other_method", func(t *testing.T) {
+		// the request is signed for Check
+		ctx := signedContext(t, "one", "secret1", now, "nonce-9", req)
+
+		_, err := authenticator.Authenticate(methodContext(ctx, "/openfga.v1.OpenFGAService/Expand"))
+		require.ErrorIs(t, err, errInvalidSignature)
+	})
+}
+
+func TestSignedRequestAuthenticatorNonces(t *testing.T) {
+	req := &openfgav1.CheckRequest{
+		StoreId:  "store",
+		TupleKey: tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+	}
+
+	t.Run("concurrent_replays", func(t *testing.T) {
+		authenticator, err := NewSignedRequestAuthenticator([]string{"one:secret1"})
+		require.NoError(t, err)
+		defer authenticator.Close()
+
+		ctx := signedContext(t, "one", "secret1", time.Now(), "nonce", req)
+
+		var accepted, replayed atomic.Int32
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := authenticator.Authenticate(ctx)
+				if err == nil {
+					accepted.Add(1)
+				}
+				if errors.Is(err, errReplayedRequest) {
+					replayed.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		require.EqualValues(t, 1, accepted.Load())
+		require.EqualValues(t, 99, replayed.Load())
+	})
+
+	t.Run("full", func(t *testing.T) {
+		now := time.Now()
+		authenticator, err := NewSignedRequestAuthenticator([]string{"one:secret1"}, WithMaxClockSkew(time.Minute), WithMaxNonces(2))
+		require.NoError(t, err)
+		defer authenticator.Close()
+		authenticator.now = func() time.Time { return now }
+
+		for _, nonce := range []string{"nonce-1", "nonce-2"} {
+			_, err := authenticator.Authenticate(signedContext(t, "one", "secret1", now, nonce, req))
+			require.NoError(t, err)
+		}
+
+		// the nonces aren't forgotten within twice the clock skew, the new requests are rejected instead
+		_, err = authenticator.Authenticate(signedContext(t, "one", "secret1", now, "nonce-3", req))
+		require.ErrorIs(t, err, errTooManyNonces)
+
+		_, err = authenticator.Authenticate(signedContext(t, "one", "secret1", now, "nonce-1", req))
+		require.ErrorIs(t, err, errReplayedRequest)
+
+		// the nonces expire after twice the clock skew
+		now = now.Add(2 * time.Minute)
+
+		_, err = authenticator.Authenticate(signedContext(t, "one", "secret1", now, "nonce-3", req))
+		require.NoError(t, err)
+
+		_, err = authenticator.Authenticate(signedContext(t, "one", "secret1", now, "nonce-1", req))
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid_max_nonces", func(t *testing.T) {
+		_, err := NewSignedRequestAuthenticator([]string{"one:secret1"}, WithMaxNonces(0))
+		require.ErrorContains(t, err, "the maximum number of nonces must be positive")
+	})
+}
+
+func TestUnaryInterceptorVerifiesContentDigest(t *testing.T) {
+	req := &openfgav1.CheckRequest{
+		StoreId:  "store",
+		TupleKey: tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+	}
+	ctx := signedContext(t, "one", "secret1", time.Now(), "nonce", req)
+
+	interceptor := NewUnaryInterceptor()
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	}
+
+	_, err := interceptor(ctx, req, &grpc.UnaryServerInfo{}, handler)
+	require.NoError(t, err)
+
+	tampered := &openfgav1.CheckRequest{
+		StoreId:  "store",
+		TupleKey: tuple.NewTupleKey("document:1", "editor", "user:anne"),
+	}
+	_, err = interceptor(ctx, tampered, &grpc.UnaryServerInfo{}, handler)
+	require.ErrorIs(t, err, errInvalidDigest)
+}
@@ -0,0 +1,182 @@
+// Package benchcompare parses the results of the Go benchmarks, and compares the results of two runs, e.g. of
+// the Check and ListObjects benchmarks of a branch against the ones of the main branch, to detect the
+// regressions of their latency.
+package benchcompare
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNoResults is returned when the output parsed has no benchmark results.
+var ErrNoResults = errors.New("no benchmark results")
+
+// procsSuffix is the suffix of the names of the benchmarks with the GOMAXPROCS they ran with, e.g. '-8'.
+var procsSuffix = regexp.MustCompile(`-\d+$`)
+
+// Result is the result of a benchmark, over all its samples.
+type Result struct {
+	// Name is the name of the benchmark, without the GOMAXPROCS suffix.
+	Name string `json:"name"`
+
+	// Samples is the number of runs of the benchmark, e.g. with 'go test -count'.
+	Samples int `json:"samples"`
+
+	// NsPerOp, BytesPerOp and AllocsPerOp are the medians of the samples. The memory is only reported with
+	// 'go test -benchmem'.
+	NsPerOp     float64 `json:"ns_per_op"`
+	BytesPerOp  float64 `json:"bytes_per_op,omitempty"`
+	AllocsPerOp float64 `json:"allocs_per_op,omitempty"`
+}
+
+// Parse parses the results of the output of 'go test -bench', in the order of the benchmarks. The samples of
+// the benchmarks that ran several times, e.g. with 'go test -count', are summarized by their median.
+func Parse(r io.Reader) ([]Result, error) {
+	var names []string
+	samples := map[string]map[string][]float64{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+
+		// e.g. 'BenchmarkCheck/flat-8   1000   12345 ns/op   7108 B/op   123 allocs/op'
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") || len(fields)%2 != 0 {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+
+		name := procsSuffix.ReplaceAllString(fields[0], "")
+		if _, ok := samples[name]; !ok {
+			names = append(names, name)
+			samples[name] = map[string][]float64{}
+		}
+
+		for i := 2; i < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value '%s' of the benchmark '%s'", fields[i], name)
+			}
+
+			samples[name][fields[i+1]] = append(samples[name][fields[i+1]], value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(names) == 0 {
+		return nil, ErrNoResults
+	}
+
+	results := make([]Result, 0, len(names))
+	for _, name := range names {
+		results = append(results, Result{
+			Name:        name,
+			Samples:     len(samples[name]["ns/op"]),
+			NsPerOp:     median(samples[name]["ns/op"]),
+			BytesPerOp:  median(samples[name]["B/op"]),
+			AllocsPerOp: median(samples[name]["allocs/op"]),
+		})
+	}
+
+	return results, nil
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+
+	return sorted[middle]
+}
+
+// Comparison is the comparison of the latency of a benchmark of the head against the base.
+type Comparison struct {
+	Name            string  `json:"name"`
+	BaseNsPerOp     float64 `json:"base_ns_per_op"`
+	HeadNsPerOp     float64 `json:"head_ns_per_op"`
+	BaseAllocsPerOp float64 `json:"base_allocs_per_op,omitempty"`
+	HeadAllocsPerOp float64 `json:"head_allocs_per_op,omitempty"`
+
+	// Delta is the relative change of the latency, e.g. 0.25 if the head is 25% slower than the base.
+	Delta float64 `json:"delta"`
+
+	// Regression is whether the head is slower than the base by more than the threshold.
+	Regression bool `json:"regression"`
+}
+
+// Report is the comparison of the benchmarks of the head against the ones of the base.
+type Report struct {
+	// Threshold is the relative change of the latency above which a benchmark regressed, e.g. 0.1 for 10%.
+	Threshold float64 `json:"threshold"`
+
+	Comparisons []Comparison `json:"comparisons"`
+
+	// Regressions is the number of benchmarks that regressed.
+	Regressions int `json:"regressions"`
+
+	// Missing are the benchmarks that only ran on the base or on the head, which can't be compared.
+	Missing []string `json:"missing,omitempty"`
+}
+
+// Compare compares the latency of the benchmarks of the head against the ones of the base, in the order of the
+// base. A benchmark regressed if the head is slower by more than the threshold, e.g. 0.1 for 10%.
+func Compare(base, head []Result, threshold float64) *Report {
+	report := &Report{Threshold: threshold, Comparisons: []Comparison{}}
+
+	headByName := make(map[string]Result, len(head))
+	for _, result := range head {
+		headByName[result.Name] = result
+	}
+
+	compared := make(map[string]struct{}, len(base))
+	for _, baseResult := range base {
+		headResult, ok := headByName[baseResult.Name]
+		if !ok {
+			report.Missing = append(report.Missing, baseResult.Name)
+			continue
+		}
+		compared[baseResult.Name] = struct{}{}
+
+		comparison := Comparison{
+			Name:            baseResult.Name,
+			BaseNsPerOp:     baseResult.NsPerOp,
+			HeadNsPerOp:     headResult.NsPerOp,
+			BaseAllocsPerOp: baseResult.AllocsPerOp,
+			HeadAllocsPerOp: headResult.AllocsPerOp,
+		}
+		if baseResult.NsPerOp > 0 {
+			comparison.Delta = (headResult.NsPerOp - baseResult.NsPerOp) / baseResult.NsPerOp
+		}
+		if comparison.Delta > threshold {
+			comparison.Regression = true
+			report.Regressions++
+		}
+
+		report.Comparisons = append(report.Comparisons, comparison)
+	}
+
+	for _, result := range head {
+		if _, ok := compared[result.Name]; !ok {
+			report.Missing = append(report.Missing, result.Name)
+		}
+	}
+
+	return report
+}
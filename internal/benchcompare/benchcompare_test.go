@@ -0,0 +1,60 @@
+package benchcompare
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const output = `goos: linux
+goarch: amd64
+pkg: github.com/openfga/openfga/pkg/server
+BenchmarkCheck/flat-8         	    1000	      1000 ns/op	     512 B/op	      10 allocs/op
+BenchmarkCheck/flat-8         	    1000	      3000 ns/op	     512 B/op	      10 allocs/op
+BenchmarkCheck/flat-8         	    1000	      2000 ns/op	     512 B/op	      10 allocs/op
+BenchmarkListObjects/flat-8   	     100	     50000 ns/op
+PASS
+ok  	github.com/openfga/openfga/pkg/server	1.234s
+`
+
+func TestParse(t *testing.T) {
+	results, err := Parse(strings.NewReader(output))
+	require.NoError(t, err)
+	require.Equal(t, []Result{
+		{Name: "BenchmarkCheck/flat", Samples: 3, NsPerOp: 2000, BytesPerOp: 512, AllocsPerOp: 10},
+		{Name: "BenchmarkListObjects/flat", Samples: 1, NsPerOp: 50000},
+	}, results)
+
+	_, err = Parse(strings.NewReader("PASS\n"))
+	require.ErrorIs(t, err, ErrNoResults)
+
+	_, err = Parse(strings.NewReader("BenchmarkCheck-8 1000 fast ns/op\n"))
+	require.Error(t, err)
+}
+
+func TestCompare(t *testing.T) {
+	base := []Result{
+		{Name: "BenchmarkCheck/flat", NsPerOp: 1000},
+		{Name: "BenchmarkCheck/deep_hierarchy", NsPerOp: 1000},
+		{Name: "BenchmarkCheck/removed", NsPerOp: 1000},
+	}
+	head := []Result{
+		{Name: "BenchmarkCheck/flat", NsPerOp: 1050},
+		{Name: "BenchmarkCheck/deep_hierarchy", NsPerOp: 1500},
+		{Name: "BenchmarkCheck/added", NsPerOp: 1000},
+	}
+
+	report := Compare(base, head, 0.1)
+	require.Equal(t, 0.1, report.Threshold)
+	require.Equal(t, 1, report.Regressions)
+	require.Equal(t, []string{"BenchmarkCheck/removed", "BenchmarkCheck/added"}, report.Missing)
+
+	require.Len(t, report.Comparisons, 2)
+	require.Equal(t, "BenchmarkCheck/flat", report.Comparisons[0].Name)
+	require.InDelta(t, 0.05, report.Comparisons[0].Delta, 1e-9)
+	require.False(t, report.Comparisons[0].Regression)
+	require.Equal(t, "BenchmarkCheck/deep_hierarchy", report.Comparisons[1].Name)
+	require.InDelta(t, 0.5, report.Comparisons[1].Delta, 1e-9)
+	require.True(t, report.Comparisons[1].Regression)
+}
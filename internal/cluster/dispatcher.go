@@ -0,0 +1,235 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/internal/graph"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/telemetry"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DispatchedHeader is the request header set on the Check requests dispatched to the other members. The
+	// sub-problems of a dispatched request are resolved locally, so that a request isn't dispatched back and
+	// forth.
+	DispatchedHeader = "openfga-dispatched"
+
+	defaultDispatchRetries = 2
+	defaultDispatchBackoff = 10 * time.Millisecond
+
+	// maxDispatchedContextualTuples is the maximum number of contextual tuples of a Check request. The
+	// sub-problems with more are resolved locally.
+	maxDispatchedContextualTuples = 20
+
+	dispatchResultLocal    = "local"
+	dispatchResultRemote   = "remote"
+	dispatchResultFallback = "fallback"
+)
+
+var dispatchCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "cluster_dispatch_count",
+	Help: "The total number of Check sub-problems resolved in clustering mode, labeled by result: 'local' if the member owns them, 'remote' if they were dispatched to their owner, and 'fallback' if they were resolved locally because the dispatch failed.",
+}, []string{"result"})
+
+// Dispatcher dispatches the Check sub-problems to the member of the cluster that owns the hash of their store
+// and object, with retries, and falls back to resolving them locally if the owner can't be reached.
+type Dispatcher struct {
+	membership   *Membership
+	pool         *ConnPool
+	retries      int
+	backoff      time.Duration
+	presharedKey string
+	logger       logger.Logger
+}
+
+// DispatcherOption defines an option that can be used to change the behavior of a Dispatcher.
+type DispatcherOption func(d *Dispatcher)
+
+// WithDispatchRetries sets the number of times a dispatch that failed because the owner is unavailable is
+// retried before falling back to local resolution. It defaults to 2.
+func WithDispatchRetries(retries int) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.retries = retries
+	}
+}
+
+// WithPresharedKey sets the preshared key with which the dispatched requests are authenticated, if the
+// members use the preshared key authentication.
+func WithPresharedKey(key string) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.presharedKey = key
+	}
+}
+
+// WithDispatcherLogger sets the logger to which the failed dispatches are logged.
+func WithDispatcherLogger(l logger.Logger) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.logger = l
+	}
+}
+
+// NewDispatcher returns a Dispatcher of the members of the membership, whose connections come from the pool.
+func NewDispatcher(membership *Membership, pool *ConnPool, opts ...DispatcherOption) *Dispatcher {
+	d := &Dispatcher{
+		membership: membership,
+		pool:       pool,
+		retries:    defaultDispatchRetries,
+		backoff:    defaultDispatchBackoff,
+		logger:     logger.NewNoopLogger(),
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// Wrap returns a CheckResolver that dispatches the sub-problems owned by other members to them, and resolves
+// the other ones with the local resolver (see graph.WithDispatcher).
+func (d *Dispatcher) Wrap(local graph.CheckResolver) graph.CheckResolver {
+	return &dispatchingCheckResolver{
+		dispatcher: d,
+		local:      local,
+	}
+}
+
+type dispatchingCheckResolver struct {
+	dispatcher *Dispatcher
+	local      graph.CheckResolver
+}
+
+var _ graph.CheckResolver = (*dispatchingCheckResolver)(nil)
+
+func (r *dispatchingCheckResolver) ResolveCheck(ctx context.Context, req *graph.ResolveCheckRequest) (*graph.ResolveCheckResponse, error) {
+	// the Check requests can't carry the context the conditions of the tuples are evaluated with
+	if IsDispatched(ctx) || len(req.GetContextualTuples()) > maxDispatchedContextualTuples || len(req.GetContext()) > 0 {
+		dispatchCounter.WithLabelValues(dispatchResultLocal).Inc()
+		return r.local.ResolveCheck(ctx, req)
+	}
+
+	d := r.dispatcher
+	owner := d.membership.Owner(req.GetStoreID() + "/" + req.GetTupleKey().GetObject())
+	if owner == "" || owner == d.membership.Self() {
+		dispatchCounter.WithLabelValues(dispatchResultLocal).Inc()
+		return r.local.ResolveCheck(ctx, req)
+	}
+
+	resp, err := d.dispatch(ctx, owner, req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, graph.ErrResolutionDepthExceeded) {
+			return nil, err
+		}
+
+		d.logger.WarnWithContext(ctx, "failed to dispatch a check sub-problem, resolving it locally",
+			zap.String("peer", owner),
+			zap.Error(err),
+		)
+		dispatchCounter.WithLabelValues(dispatchResultFallback).Inc()
+		return r.local.ResolveCheck(ctx, req)
+	}
+
+	dispatchCounter.WithLabelValues(dispatchResultRemote).Inc()
+	return resp, nil
+}
+
+func (r *dispatchingCheckResolver) Close() {}
+
+// dispatch resolves the sub-problem with a Check request to its owner, retrying it while the owner is
+// unavailable. The request carries the trace context and the remaining resolution depth of the sub-problem,
+// and the datastore queries of its owner are counted in the response.
+func (d *Dispatcher) dispatch(ctx context.Context, owner string, req *graph.ResolveCheckRequest) (*graph.ResolveCheckResponse, error) {
+	ctx, span := tracer.Start(ctx, "dispatch", trace.WithAttributes(
+		attribute.String("peer", owner),
+		attribute.String("object", req.GetTupleKey().GetObject()),
+		attribute.String("relation", req.GetTupleKey().GetRelation()),
+	))
+	defer span.End()
+
+	conn, err := d.pool.Get(owner)
+	if err != nil {
+		telemetry.TraceError(span, err)
+		return nil, err
+	}
+
+	var depth uint32
+	if metadata := req.GetResolutionMetadata(); metadata != nil {
+		depth = metadata.Depth
+	}
+
+	ctx = outgoingContext(ctx, depth)
+	if d.presharedKey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+d.presharedKey)
+	}
+
+	checkReq := &openfgav1.CheckRequest{
+		StoreId:              req.GetStoreID(),
+		AuthorizationModelId: req.GetAuthorizationModelID(),
+		TupleKey:             req.GetTupleKey(),
+	}
+	if len(req.GetContextualTuples()) > 0 {
+		checkReq.ContextualTuples = &openfgav1.ContextualTupleKeys{TupleKeys: req.GetContextualTuples()}
+	}
+
+	client := openfgav1.NewOpenFGAServiceClient(conn)
+
+	backoff := d.backoff
+	for attempt := 0; ; attempt++ {
+		var trailer metadata.MD
+		res, err := client.Check(ctx, checkReq, grpc.Trailer(&trailer))
+		if err == nil {
+			span.SetAttributes(attribute.Int("attempts", attempt+1), attribute.Bool("allowed", res.GetAllowed()))
+
+			return &graph.ResolveCheckResponse{
+				Allowed: res.GetAllowed(),
+				ResolutionMetadata: &graph.ResolutionMetadata{
+					Depth:               depth,
+					DatastoreQueryCount: datastoreQueryCount(trailer),
+				},
+			}, nil
+		}
+
+		if status.Code(err) == codes.Code(openfgav1.ErrorCode_authorization_model_resolution_too_complex) {
+			// the owner ran out of the remaining depth, so would this member
+			telemetry.TraceError(span, graph.ErrResolutionDepthExceeded)
+			return nil, graph.ErrResolutionDepthExceeded
+		}
+
+		if attempt >= d.retries || !retryable(err) {
+			telemetry.TraceError(span, err)
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// retryable reports whether a failed dispatch may succeed if it's retried.
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
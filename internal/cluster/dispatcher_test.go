@@ -0,0 +1,188 @@
+package cluster
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/internal/graph"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthv1pb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+// peer is a member of the cluster which allows every Check dispatched to it with a remaining depth, in 3
+// datastore queries.
+type peer struct {
+	openfgav1.UnimplementedOpenFGAServiceServer
+	checks     atomic.Int32
+	dispatched atomic.Int32
+	depth      atomic.Uint32
+	traceID    atomic.Value
+}
+
+func (p *peer) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openfgav1.CheckResponse, error) {
+	p.checks.Add(1)
+	if IsDispatched(ctx) {
+		p.dispatched.Add(1)
+	}
+
+	depth, _ := DispatchedDepth(ctx)
+	p.depth.Store(depth)
+	if depth == 0 {
+		return nil, serverErrors.AuthorizationModelResolutionTooComplex
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	spanCtx := trace.SpanContextFromContext(otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md)))
+	p.traceID.Store(spanCtx.TraceID().String())
+
+	SetDatastoreQueryCount(ctx, 3)
+	return &openfgav1.CheckResponse{Allowed: true}, nil
+}
+
+func startPeer(t *testing.T) (*peer, string, func()) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	p := &peer{}
+	s := grpc.NewServer()
+	openfgav1.RegisterOpenFGAServiceServer(s, p)
+	healthv1pb.RegisterHealthServer(s, health.NewServer())
+	go func() {
+		_ = s.Serve(lis)
+	}()
+
+	return p, lis.Addr().String(), s.Stop
+}
+
+// localResolver denies every Check it resolves.
+type localResolver struct {
+	checks atomic.Int32
+}
+
+func (r *localResolver) ResolveCheck(context.Context, *graph.ResolveCheckRequest) (*graph.ResolveCheckResponse, error) {
+	r.checks.Add(1)
+	return &graph.ResolveCheckResponse{Allowed: false, ResolutionMetadata: &graph.ResolutionMetadata{}}, nil
+}
+
+func (r *localResolver) Close() {}
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+
+	remote, remoteAddr, stopRemote := startPeer(t)
+	t.Cleanup(stopRemote)
+
+	pool := NewConnPool()
+	t.Cleanup(pool.Close)
+
+	self := "127.0.0.1:1"
+	membership := NewMembership(self, []string{self, remoteAddr}, pool, WithProbeInterval(10*time.Millisecond))
+	membership.Start()
+	t.Cleanup(membership.Stop)
+
+	require.Eventually(t, func() bool {
+		return len(membership.Members()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// find an object owned by each member
+	var localObject, remoteObject string
+	for i := 0; localObject == "" || remoteObject == ""; i++ {
+		object := "document:" + string(rune('a'+i%26)) + string(rune('a'+i/26))
+		if membership.Owner("store/"+object) == self {
+			localObject = object
+		} else {
+			remoteObject = object
+		}
+	}
+
+	request := func(object string) *graph.ResolveCheckRequest {
+		return &graph.ResolveCheckRequest{
+			StoreID:            "store",
+			TupleKey:           tuple.NewTupleKey(object, "viewer", "user:jon"),
+			ResolutionMetadata: &graph.ResolutionMetadata{Depth: 25},
+		}
+	}
+
+	t.Run("owned_sub_problems_are_resolved_locally", func(t *testing.T) {
+		local := &localResolver{}
+		resp, err := NewDispatcher(membership, pool).Wrap(local).ResolveCheck(ctx, request(localObject))
+		require.NoError(t, err)
+		require.False(t, resp.GetAllowed())
+		require.EqualValues(t, 1, local.checks.Load())
+	})
+
+	t.Run("other_sub_problems_are_dispatched", func(t *testing.T) {
+		local := &localResolver{}
+		before := remote.dispatched.Load()
+
+		resp, err := NewDispatcher(membership, pool).Wrap(local).ResolveCheck(ctx, request(remoteObject))
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+		require.EqualValues(t, 25, resp.GetResolutionMetadata().Depth)
+		require.EqualValues(t, 3, resp.GetResolutionMetadata().DatastoreQueryCount)
+		require.EqualValues(t, 0, local.checks.Load())
+		require.Equal(t, before+1, remote.dispatched.Load())
+		require.EqualValues(t, 25, remote.depth.Load())
+	})
+
+	t.Run("dispatches_carry_the_trace_context", func(t *testing.T) {
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+		t.Cleanup(func() {
+			otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+		})
+
+		ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(ctx, "Check")
+		defer span.End()
+
+		_, err := NewDispatcher(membership, pool).Wrap(&localResolver{}).ResolveCheck(ctx, request(remoteObject))
+		require.NoError(t, err)
+		require.Equal(t, span.SpanContext().TraceID().String(), remote.traceID.Load())
+	})
+
+	t.Run("dispatches_are_bounded_by_the_remaining_depth", func(t *testing.T) {
+		local := &localResolver{}
+		req := request(remoteObject)
+		req.ResolutionMetadata.Depth = 0
+
+		_, err := NewDispatcher(membership, pool).Wrap(local).ResolveCheck(ctx, req)
+		require.ErrorIs(t, err, graph.ErrResolutionDepthExceeded)
+		require.EqualValues(t, 0, local.checks.Load())
+	})
+
+	t.Run("dispatched_sub_problems_are_resolved_locally", func(t *testing.T) {
+		local := &localResolver{}
+		ctx := metadata.NewIncomingContext(ctx, metadata.Pairs(DispatchedHeader, "true"))
+
+		resp, err := NewDispatcher(membership, pool).Wrap(local).ResolveCheck(ctx, request(remoteObject))
+		require.NoError(t, err)
+		require.False(t, resp.GetAllowed())
+		require.EqualValues(t, 1, local.checks.Load())
+	})
+
+	t.Run("falls_back_to_local_resolution", func(t *testing.T) {
+		// the peer is still healthy for the membership, but unreachable for the dispatcher
+		brokenPool := &ConnPool{dialOptions: pool.dialOptions, conns: map[string]*grpc.ClientConn{}}
+		conn, err := brokenPool.Get(remoteAddr)
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+
+		local := &localResolver{}
+		resp, err := NewDispatcher(membership, brokenPool, WithDispatchRetries(1)).Wrap(local).ResolveCheck(ctx, request(remoteObject))
+		require.NoError(t, err)
+		require.False(t, resp.GetAllowed())
+		require.EqualValues(t, 1, local.checks.Load())
+	})
+}
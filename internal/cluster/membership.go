@@ -0,0 +1,194 @@
+package cluster
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/openfga/openfga/pkg/logger"
+	"go.uber.org/zap"
+	healthv1pb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	defaultProbeInterval = 5 * time.Second
+	defaultProbeTimeout  = time.Second
+)
+
+// Membership tracks the healthy members of the cluster, and the ring they form. The member itself is always
+// healthy. The peers are probed at an interval with the gRPC health checks, and are only part of the ring
+// while they're serving.
+type Membership struct {
+	self          string
+	peers         []string
+	pool          *ConnPool
+	probeInterval time.Duration
+	probeTimeout  time.Duration
+	logger        logger.Logger
+
+	mu      sync.RWMutex
+	healthy map[string]bool
+	ring    *Ring
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// MembershipOption defines an option that can be used to change the behavior of a Membership.
+type MembershipOption func(m *Membership)
+
+// WithProbeInterval sets the interval at which the health of the peers is probed. It defaults to 5 seconds.
+func WithProbeInterval(interval time.Duration) MembershipOption {
+	return func(m *Membership) {
+		m.probeInterval = interval
+	}
+}
+
+// WithLogger sets the logger to which the changes of the membership are logged.
+func WithLogger(l logger.Logger) MembershipOption {
+	return func(m *Membership) {
+		m.logger = l
+	}
+}
+
+// NewMembership returns the membership of the member self (its advertised gRPC address) in a cluster with the
+// provided peers. The peers may include self. Until the peers have been probed, the ring only has self.
+func NewMembership(self string, peers []string, pool *ConnPool, opts ...MembershipOption) *Membership {
+	m := &Membership{
+		self:          self,
+		pool:          pool,
+		probeInterval: defaultProbeInterval,
+		probeTimeout:  defaultProbeTimeout,
+		logger:        logger.NewNoopLogger(),
+		healthy:       map[string]bool{},
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+
+	for _, peer := range peers {
+		if peer != self {
+			m.peers = append(m.peers, peer)
+		}
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	m.ring = NewRing([]string{self}, defaultVirtualNodes)
+
+	return m
+}
+
+// Self returns the address of the member itself.
+func (m *Membership) Self() string {
+	return m.self
+}
+
+// Owner returns the healthy member that owns the key.
+func (m *Membership) Owner(key string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.ring.Owner(key)
+}
+
+// Members returns the healthy members, sorted.
+func (m *Membership) Members() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	members := []string{m.self}
+	for peer, healthy := range m.healthy {
+		if healthy {
+			members = append(members, peer)
+		}
+	}
+	sort.Strings(members)
+
+	return members
+}
+
+// Start starts probing the peers in the background.
+func (m *Membership) Start() {
+	go m.run()
+}
+
+// Stop stops probing the peers and waits for the current probes, if any, to end.
+func (m *Membership) Stop() {
+	close(m.stop)
+	<-m.done
+}
+
+func (m *Membership) run() {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.probeInterval)
+	defer ticker.Stop()
+
+	for {
+		m.probe(context.Background())
+
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probe probes every peer concurrently, and rebuilds the ring if their health changed.
+func (m *Membership) probe(ctx context.Context) {
+	healthy := make([]bool, len(m.peers))
+
+	var wg sync.WaitGroup
+	for i, peer := range m.peers {
+		wg.Add(1)
+		go func(i int, peer string) {
+			defer wg.Done()
+			healthy[i] = m.probePeer(ctx, peer)
+		}(i, peer)
+	}
+	wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	changed := false
+	for i, peer := range m.peers {
+		if m.healthy[peer] != healthy[i] {
+			changed = true
+			m.healthy[peer] = healthy[i]
+
+			if healthy[i] {
+				m.logger.Info("cluster peer joined", zap.String("peer", peer))
+			} else {
+				m.logger.Warn("cluster peer left", zap.String("peer", peer))
+			}
+		}
+	}
+
+	if changed {
+		members := []string{m.self}
+		for peer, healthy := range m.healthy {
+			if healthy {
+				members = append(members, peer)
+			}
+		}
+		m.ring = NewRing(members, defaultVirtualNodes)
+	}
+}
+
+func (m *Membership) probePeer(ctx context.Context, peer string) bool {
+	conn, err := m.pool.Get(peer)
+	if err != nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.probeTimeout)
+	defer cancel()
+
+	res, err := healthv1pb.NewHealthClient(conn).Check(ctx, &healthv1pb.HealthCheckRequest{})
+	return err == nil && res.GetStatus() == healthv1pb.HealthCheckResponse_SERVING
+}
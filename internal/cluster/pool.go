@@ -0,0 +1,60 @@
+package cluster
+
+import (
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// ConnPool is a pool of gRPC client connections to the members of the cluster, one per member, created on
+// first use and shared by the dispatches and the health probes.
+type ConnPool struct {
+	dialOptions []grpc.DialOption
+
+	mu    sync.Mutex
+	conns map[string]*grpc.ClientConn
+}
+
+// NewConnPool returns a pool whose connections are dialed with the provided options. Without options, the
+// connections use plaintext.
+func NewConnPool(dialOptions ...grpc.DialOption) *ConnPool {
+	if len(dialOptions) == 0 {
+		dialOptions = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+
+	return &ConnPool{
+		dialOptions: dialOptions,
+		conns:       map[string]*grpc.ClientConn{},
+	}
+}
+
+// Get returns the connection to the member. The connection is established in the background, so Get doesn't
+// block.
+func (p *ConnPool) Get(addr string) (*grpc.ClientConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if conn, ok := p.conns[addr]; ok {
+		return conn, nil
+	}
+
+	conn, err := grpc.Dial(addr, p.dialOptions...)
+	if err != nil {
+		return nil, err
+	}
+	p.conns[addr] = conn
+
+	return conn, nil
+}
+
+// Close closes every connection of the pool.
+func (p *ConnPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for addr, conn := range p.conns {
+		_ = conn.Close()
+		delete(p.conns, addr)
+	}
+}
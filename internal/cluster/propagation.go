@@ -0,0 +1,112 @@
+package cluster
+
+import (
+	"context"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// DispatchDepthHeader is the request header that holds the remaining resolution depth of a dispatched
+	// sub-problem, so that a Check is bounded by the same depth whichever members resolve it.
+	DispatchDepthHeader = "openfga-dispatch-depth"
+
+	// DatastoreQueryCountTrailer is the response trailer of a dispatched Check that holds the number of
+	// datastore queries its owner made, so that they're counted in the Check that dispatched it.
+	DatastoreQueryCountTrailer = "openfga-datastore-query-count"
+)
+
+var tracer = otel.Tracer("openfga/internal/cluster")
+
+// IsDispatched reports whether the request being served was dispatched by another member.
+func IsDispatched(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	return ok && len(md.Get(DispatchedHeader)) > 0
+}
+
+// DispatchedDepth returns the remaining resolution depth of the request being served, if it was dispatched by
+// another member.
+func DispatchedDepth(ctx context.Context) (uint32, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md.Get(DispatchedHeader)) == 0 {
+		return 0, false
+	}
+
+	values := md.Get(DispatchDepthHeader)
+	if len(values) == 0 {
+		return 0, false
+	}
+
+	depth, err := strconv.ParseUint(values[0], 10, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	return uint32(depth), true
+}
+
+// SetDatastoreQueryCount reports the number of datastore queries of the request being served to the member
+// that dispatched it, if any.
+func SetDatastoreQueryCount(ctx context.Context, count uint32) {
+	if !IsDispatched(ctx) {
+		return
+	}
+
+	_ = grpc.SetTrailer(ctx, metadata.Pairs(DatastoreQueryCountTrailer, strconv.FormatUint(uint64(count), 10)))
+}
+
+// outgoingContext returns the context of a dispatched request, with the remaining depth of the sub-problem and
+// the trace context of the caller.
+func outgoingContext(ctx context.Context, depth uint32) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md.Set(DispatchedHeader, "true")
+	md.Set(DispatchDepthHeader, strconv.FormatUint(uint64(depth), 10))
+
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// datastoreQueryCount returns the number of datastore queries reported in the trailer of a dispatched Check.
+func datastoreQueryCount(trailer metadata.MD) uint32 {
+	values := trailer.Get(DatastoreQueryCountTrailer)
+	if len(values) == 0 {
+		return 0
+	}
+
+	count, err := strconv.ParseUint(values[0], 10, 32)
+	if err != nil {
+		return 0
+	}
+
+	return uint32(count)
+}
+
+// metadataCarrier adapts metadata.MD to propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
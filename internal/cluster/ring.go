@@ -0,0 +1,75 @@
+// Package cluster contains the clustering mode of the server, in which the Check sub-problems are dispatched
+// to the member of the cluster that owns them on a consistent hash ring, so that the results of a sub-problem
+// are concentrated in the cache of a single member.
+//
+// The members are the addresses of the gRPC servers of the servers of the cluster. Each member probes the
+// health of the other ones and removes the unhealthy ones from its ring, so that their sub-problems are
+// resolved by the remaining members until they're healthy again.
+package cluster
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// defaultVirtualNodes is the number of points of each member on the ring, which spreads the keys evenly.
+const defaultVirtualNodes = 100
+
+// Ring is a consistent hash ring of the members of a cluster: adding or removing a member only moves the keys
+// that it owns or will own.
+type Ring struct {
+	hashes []uint64
+	owners map[uint64]string
+}
+
+// NewRing returns a ring of the members, each with the provided number of virtual nodes.
+func NewRing(members []string, virtualNodes int) *Ring {
+	r := &Ring{
+		owners: make(map[uint64]string, len(members)*virtualNodes),
+	}
+
+	for _, member := range members {
+		for i := 0; i < virtualNodes; i++ {
+			h := hash(member + "#" + strconv.Itoa(i))
+			if _, ok := r.owners[h]; ok {
+				continue
+			}
+
+			r.owners[h] = member
+			r.hashes = append(r.hashes, h)
+		}
+	}
+
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+
+	return r
+}
+
+// Owner returns the member that owns the key, i.e. the member of the first point of the ring after the hash of
+// the key. It returns an empty string if the ring has no members.
+func (r *Ring) Owner(key string) string {
+	if len(r.hashes) == 0 {
+		return ""
+	}
+
+	h := hash(key)
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if i == len(r.hashes) {
+		i = 0
+	}
+
+	return r.owners[r.hashes[i]]
+}
+
+// hash returns the FNV-1a hash of the string, mixed with the finalizer of SplitMix64: FNV-1a alone clusters
+// the hashes of strings which only differ by their last characters, like the virtual nodes of a member.
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+
+	x := h.Sum64()
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
@@ -0,0 +1,41 @@
+package cluster
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRing(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		require.Empty(t, NewRing(nil, defaultVirtualNodes).Owner("store/document:1"))
+	})
+
+	t.Run("keys_are_spread_across_the_members", func(t *testing.T) {
+		members := []string{"openfga-0:8081", "openfga-1:8081", "openfga-2:8081"}
+		ring := NewRing(members, defaultVirtualNodes)
+
+		owned := map[string]int{}
+		for i := 0; i < 3000; i++ {
+			owned[ring.Owner("store/document:"+strconv.Itoa(i))]++
+		}
+
+		require.Len(t, owned, len(members))
+		for _, member := range members {
+			require.Greater(t, owned[member], 500, member)
+		}
+	})
+
+	t.Run("removing_a_member_only_moves_its_keys", func(t *testing.T) {
+		ring := NewRing([]string{"openfga-0:8081", "openfga-1:8081", "openfga-2:8081"}, defaultVirtualNodes)
+		smaller := NewRing([]string{"openfga-0:8081", "openfga-1:8081"}, defaultVirtualNodes)
+
+		for i := 0; i < 1000; i++ {
+			key := "store/document:" + strconv.Itoa(i)
+			if owner := ring.Owner(key); owner != "openfga-2:8081" {
+				require.Equal(t, owner, smaller.Owner(key))
+			}
+		}
+	})
+}
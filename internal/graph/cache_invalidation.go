@@ -0,0 +1,162 @@
+package graph
+
+import (
+	"sync"
+
+	"github.com/karlseguin/ccache/v3"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var checkCacheInvalidatedCounter = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "check_cache_invalidated_count",
+	Help: "The total number of cached Check results invalidated because of the tuples written to their store.",
+})
+
+// maxTrackedModels is the maximum number of models of a store whose cached results the CheckCacheInvalidator
+// tracks. Beyond it, the writes invalidate every cached result of the store.
+const maxTrackedModels = 8
+
+// CheckCacheInvalidator invalidates the cached Check results that written tuples could have changed. Rather
+// than flushing every result of the store, it only invalidates the results of the relations affected by the
+// relations of the tuples (see RelationshipGraph.GetAffectedRelations), so the other results stay cached
+// under steady writes.
+//
+// The cache keys don't tell the model the results were resolved with, so the relations affected are those of
+// every model of the store whose results are cached (see Track).
+type CheckCacheInvalidator struct {
+	caches []*ccache.Cache[*CachedResolveCheckResponse]
+
+	mu sync.RWMutex
+	// models are the models of each store whose results are cached, keyed by their ID, or nil if there are
+	// more than maxTrackedModels of them
+	models map[string]map[string]*typesystem.TypeSystem
+}
+
+// NewCheckCacheInvalidator returns a CheckCacheInvalidator of the provided caches, e.g. the caches of the
+// positive and of the negative results shared by the CachedCheckResolvers of the server.
+func NewCheckCacheInvalidator(caches ...*ccache.Cache[*CachedResolveCheckResponse]) *CheckCacheInvalidator {
+	i := &CheckCacheInvalidator{models: map[string]map[string]*typesystem.TypeSystem{}}
+	for _, cache := range caches {
+		if cache != nil {
+			i.caches = append(i.caches, cache)
+		}
+	}
+
+	return i
+}
+
+// Track records that results of the store resolved with the model of the typesystem are cached, so that the
+// writes invalidate the relations their tuples affect in that model too.
+func (i *CheckCacheInvalidator) Track(typesys *typesystem.TypeSystem, storeID string) {
+	modelID := typesys.GetAuthorizationModelID()
+
+	i.mu.RLock()
+	models, ok := i.models[storeID]
+	_, tracked := models[modelID]
+	i.mu.RUnlock()
+
+	if ok && (models == nil || tracked) {
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	models, ok = i.models[storeID]
+	switch {
+	case !ok:
+		i.models[storeID] = map[string]*typesystem.TypeSystem{modelID: typesys}
+	case models == nil:
+	case len(models) >= maxTrackedModels:
+		i.models[storeID] = nil
+	default:
+		models[modelID] = typesys
+	}
+}
+
+// Invalidate invalidates the cached results of the store that the tuples, written (or deleted) with the
+// model of the typesystem, could have changed, and returns how many were invalidated. The relations affected
+// are those of the provided model and of every model of the store whose results are cached (see Track), and
+// every result of the store is invalidated if there are too many of them.
+func (i *CheckCacheInvalidator) Invalidate(typesys *typesystem.TypeSystem, storeID string, tupleKeys []*openfgav1.TupleKey) (int, error) {
+	if len(i.caches) == 0 {
+		return 0, nil
+	}
+
+	i.mu.RLock()
+	models, ok := i.models[storeID]
+	typesystems := make([]*typesystem.TypeSystem, 0, len(models)+1)
+	typesystems = append(typesystems, typesys)
+	for modelID, tracked := range models {
+		if modelID != typesys.GetAuthorizationModelID() {
+			typesystems = append(typesystems, tracked)
+		}
+	}
+	i.mu.RUnlock()
+
+	if ok && models == nil {
+		return i.InvalidateStore(storeID), nil
+	}
+
+	invalidated := map[string]struct{}{}
+	count := 0
+
+	for _, typesys := range typesystems {
+		g := New(typesys)
+
+		written := map[string]struct{}{}
+		for _, tk := range tupleKeys {
+			objectType := tuple.GetType(tk.GetObject())
+			relation := tk.GetRelation()
+
+			key := tuple.ToObjectRelationString(objectType, relation)
+			if _, ok := written[key]; ok {
+				continue
+			}
+			written[key] = struct{}{}
+
+			affected, err := g.GetAffectedRelations(typesystem.DirectRelationReference(objectType, relation))
+			if err != nil {
+				checkCacheInvalidatedCounter.Add(float64(count))
+				return count, err
+			}
+
+			for _, rr := range affected {
+				prefix := checkCacheKeyPrefix(storeID, rr.GetType(), rr.GetRelation())
+				if _, ok := invalidated[prefix]; ok {
+					continue
+				}
+				invalidated[prefix] = struct{}{}
+
+				for _, cache := range i.caches {
+					count += cache.DeletePrefix(prefix)
+				}
+			}
+		}
+	}
+
+	checkCacheInvalidatedCounter.Add(float64(count))
+
+	return count, nil
+}
+
+// InvalidateStore invalidates every cached result of the store, e.g. when it's deleted, and returns how many
+// were invalidated.
+func (i *CheckCacheInvalidator) InvalidateStore(storeID string) int {
+	i.mu.Lock()
+	delete(i.models, storeID)
+	i.mu.Unlock()
+
+	count := 0
+	for _, cache := range i.caches {
+		count += cache.DeletePrefix(CheckCacheStoreKeyPrefix(storeID))
+	}
+
+	checkCacheInvalidatedCounter.Add(float64(count))
+
+	return count
+}
@@ -0,0 +1,197 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/karlseguin/ccache/v3"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCacheInvalidator(t *testing.T) {
+	ctx := context.Background()
+
+	typesys := typesystem.New(&openfgav1.AuthorizationModel{
+		Id:            "01HB8JQTVGJ8DD1SSDQ2BBWYTE",
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type document
+		  relations
+		    define editor: [user] as self
+		    define viewer as editor
+		    define unrelated: [user] as self
+		`),
+	})
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockResolver := NewMockCheckResolver(ctrl)
+	mockResolver.EXPECT().ResolveCheck(gomock.Any(), gomock.Any()).AnyTimes().Return(&ResolveCheckResponse{Allowed: true}, nil)
+
+	cache := ccache.New(ccache.Configure[*CachedResolveCheckResponse]())
+	defer cache.Stop()
+	negativeCache := ccache.New(ccache.Configure[*CachedResolveCheckResponse]())
+	defer negativeCache.Stop()
+
+	dut := NewCachedCheckResolver(mockResolver, WithExistingCache(cache), WithExistingNegativeCache(negativeCache))
+	defer dut.Close()
+
+	keys := map[string]string{}
+	for _, req := range []*ResolveCheckRequest{
+		{StoreID: "store", TupleKey: tuple.NewTupleKey("document:1", "viewer", "user:jon")},
+		{StoreID: "store", TupleKey: tuple.NewTupleKey("document:1", "unrelated", "user:jon")},
+		{StoreID: "other", TupleKey: tuple.NewTupleKey("document:1", "viewer", "user:jon")},
+	} {
+		req.AuthorizationModelID = typesys.GetAuthorizationModelID()
+		_, err := dut.ResolveCheck(ctx, req)
+		require.NoError(t, err)
+
+		key, err := checkRequestCacheKey(req)
+		require.NoError(t, err)
+		keys[req.GetStoreID()+"/"+req.GetTupleKey().GetRelation()] = key
+	}
+	require.Equal(t, 3, cache.ItemCount())
+
+	invalidated, err := NewCheckCacheInvalidator(cache, negativeCache).Invalidate(typesys, "store", []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:2", "editor", "user:anne"),
+		tuple.NewTupleKey("document:3", "editor", "user:anne"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, invalidated)
+
+	require.Nil(t, cache.Get(keys["store/viewer"]))
+	require.NotNil(t, cache.Get(keys["store/unrelated"]))
+	require.NotNil(t, cache.Get(keys["other/viewer"]))
+}
+
+func TestCheckCacheInvalidatorInvalidateStore(t *testing.T) {
+	cache := ccache.New(ccache.Configure[*CachedResolveCheckResponse]())
+	defer cache.Stop()
+	negativeCache := ccache.New(ccache.Configure[*CachedResolveCheckResponse]())
+	defer negativeCache.Stop()
+
+	storeKey := checkCacheKeyPrefix("store", "document", "viewer") + "key"
+	otherKey := checkCacheKeyPrefix("other", "document", "viewer") + "key"
+
+	cache.Set(storeKey, &CachedResolveCheckResponse{Allowed: true}, time.Minute)
+	cache.Set(otherKey, &CachedResolveCheckResponse{Allowed: true}, time.Minute)
+	negativeCache.Set(storeKey, &CachedResolveCheckResponse{}, time.Minute)
+
+	require.Equal(t, 2, NewCheckCacheInvalidator(cache, negativeCache).InvalidateStore("store"))
+
+	require.Nil(t, cache.Get(storeKey))
+	require.Nil(t, negativeCache.Get(storeKey))
+	require.NotNil(t, cache.Get(otherKey))
+}
+
+func TestCheckCacheInvalidatorWithSeveralModels(t *testing.T) {
+	// the viewers are the editors in the newer model only
+	older := typesystem.New(&openfgav1.AuthorizationModel{
+		Id:            "01HB8JQTVGJ8DD1SSDQ2BBWYTE",
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type document
+		  relations
+		    define editor: [user] as self
+		    define viewer: [user] as self
+		    define unrelated: [user] as self
+		`),
+	})
+	newer := typesystem.New(&openfgav1.AuthorizationModel{
+		Id:            "01HB8JR4Y7WJN3SZF5G0QK2S6X",
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type document
+		  relations
+		    define editor: [user] as self
+		    define viewer: [user] as self or editor
+		    define unrelated: [user] as self
+		`),
+	})
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockResolver := NewMockCheckResolver(ctrl)
+	mockResolver.EXPECT().ResolveCheck(gomock.Any(), gomock.Any()).AnyTimes().Return(&ResolveCheckResponse{Allowed: true}, nil)
+
+	cache := ccache.New(ccache.Configure[*CachedResolveCheckResponse]())
+	defer cache.Stop()
+
+	invalidator := NewCheckCacheInvalidator(cache)
+
+	dut := NewCachedCheckResolver(mockResolver, WithExistingCache(cache), WithCacheInvalidator(invalidator))
+	defer dut.Close()
+
+	resolve := func(t *testing.T, typesys *typesystem.TypeSystem, relation string) string {
+		req := &ResolveCheckRequest{
+			StoreID:              "store",
+			AuthorizationModelID: typesys.GetAuthorizationModelID(),
+			TupleKey:             tuple.NewTupleKey("document:1", relation, "user:jon"),
+		}
+		_, err := dut.ResolveCheck(typesystem.ContextWithTypesystem(context.Background(), typesys), req)
+		require.NoError(t, err)
+
+		key, err := checkRequestCacheKey(req)
+		require.NoError(t, err)
+		return key
+	}
+
+	t.Run("affected_relations_of_every_model", func(t *testing.T) {
+		viewer := resolve(t, newer, "viewer")
+		unrelated := resolve(t, newer, "unrelated")
+
+		// written with the older model, in which the editors aren't viewers
+		invalidated, err := invalidator.Invalidate(older, "store", []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:2", "editor", "user:anne"),
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, invalidated)
+
+		require.Nil(t, cache.Get(viewer))
+		require.NotNil(t, cache.Get(unrelated))
+	})
+
+	t.Run("too_many_models", func(t *testing.T) {
+		unrelated := resolve(t, newer, "unrelated")
+
+		for i := 0; i < maxTrackedModels; i++ {
+			invalidator.Track(typesystem.New(&openfgav1.AuthorizationModel{
+				Id:              fmt.Sprintf("model-%d", i),
+				SchemaVersion:   typesystem.SchemaVersion1_1,
+				TypeDefinitions: older.GetAllTypeDefinitions(),
+			}), "store")
+		}
+
+		// every result of the store is invalidated
+		invalidated, err := invalidator.Invalidate(older, "store", []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:2", "editor", "user:anne"),
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, invalidated)
+		require.Nil(t, cache.Get(unrelated))
+
+		// the models are tracked again from scratch
+		unrelated = resolve(t, newer, "unrelated")
+		invalidated, err = invalidator.Invalidate(older, "store", []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:2", "editor", "user:anne"),
+		})
+		require.NoError(t, err)
+		require.Zero(t, invalidated)
+		require.NotNil(t, cache.Get(unrelated))
+	})
+}
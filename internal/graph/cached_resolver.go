@@ -5,11 +5,19 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/gob"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/karlseguin/ccache/v3"
+	"github.com/openfga/openfga/pkg/cachestats"
 	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/requestcontext"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"go.uber.org/zap"
@@ -31,6 +39,23 @@ var (
 		Name: "check_cache_hit_count",
 		Help: "The total number of cache hits for ResolveCheck.",
 	})
+
+	checkCacheOutcomeCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "check_cache_outcome_count",
+		Help: "The total number of calls to ResolveCheck, labeled by outcome ('allowed' or 'denied') and by whether the result was served from the cache ('hit' or 'miss').",
+	}, []string{"outcome", "result"})
+)
+
+const (
+	checkCacheOutcomeAllowed = "allowed"
+	checkCacheOutcomeDenied  = "denied"
+
+	checkCacheResultHit  = "hit"
+	checkCacheResultMiss = "miss"
+
+	// the names of the caches reported to the requests that bypass them
+	checkCacheName       = "check"
+	sharedCheckCacheName = "check_shared"
 )
 
 // CachedResolveCheckResponse is very similar to ResolveCheckResponse except we
@@ -38,6 +63,9 @@ var (
 // will be incorrect as data is served from cache instead of actual database read.
 type CachedResolveCheckResponse struct {
 	Allowed bool
+
+	// expiresAt is the earliest expiration of the tuples the result was resolved from, if any.
+	expiresAt time.Time
 }
 
 func (c *CachedResolveCheckResponse) convertToResolveCheckResponse() *ResolveCheckResponse {
@@ -56,17 +84,53 @@ func newCachedResolveCheckResponse(r *ResolveCheckResponse) *CachedResolveCheckR
 	}
 }
 
+func checkCacheOutcome(allowed bool) string {
+	if allowed {
+		return checkCacheOutcomeAllowed
+	}
+
+	return checkCacheOutcomeDenied
+}
+
 // CachedCheckResolver attempts to resolve check sub-problems via prior computations before
 // delegating the request to some underlying CheckResolver.
+//
+// The negative (denied) results can be cached with their own TTL and in their own cache, since the writes
+// invalidate them far more often than the positive ones. By default, they share the cache and the TTL of
+// the positive results.
 type CachedCheckResolver struct {
 	delegate     CheckResolver
 	cache        *ccache.Cache[*CachedResolveCheckResponse]
 	maxCacheSize int64
 	cacheTTL     time.Duration
 	logger       logger.Logger
+	hotKeys      *HotKeyTracker
 	// allocatedCache is used to denote whether the cache is allocated by this struct.
 	// If so, CachedCheckResolver is responsible for cleaning up.
 	allocatedCache bool
+
+	// negativeCache holds the negative results. It's the cache of the positive results unless a separate one
+	// has been provided or sized.
+	negativeCache          *ccache.Cache[*CachedResolveCheckResponse]
+	maxNegativeCacheSize   int64
+	negativeCacheTTL       time.Duration
+	allocatedNegativeCache bool
+
+	// counters and negativeCounters count the lookups of the caches of the positive and of the negative
+	// results, if their statistics are reported
+	counters         *cachestats.Counters
+	negativeCounters *cachestats.Counters
+
+	// sharedCache is the cache shared with the other servers, if any, which is looked up after a miss of the
+	// local caches.
+	sharedCache SharedCheckCache
+
+	// invalidator tracks the models of the results cached, if any.
+	invalidator *CheckCacheInvalidator
+
+	// mu guards the cache against Close, since the sub-problems of a Check that short-circuited may still
+	// be resolving after the resolver is closed.
+	mu sync.RWMutex
 }
 
 var _ CheckResolver = (*CachedCheckResolver)(nil)
@@ -90,6 +154,31 @@ func WithCacheTTL(ttl time.Duration) CachedCheckResolverOpt {
 	}
 }
 
+// WithNegativeCacheTTL sets the TTL of the negative (denied) results. It defaults to the TTL of the positive
+// results.
+func WithNegativeCacheTTL(ttl time.Duration) CachedCheckResolverOpt {
+	return func(ccr *CachedCheckResolver) {
+		ccr.negativeCacheTTL = ttl
+	}
+}
+
+// WithMaxNegativeCacheSize caches the negative (denied) results in a separate cache of the provided maximum
+// size, so that they don't evict the positive results. By default, they share the cache of the positive
+// results.
+func WithMaxNegativeCacheSize(size int64) CachedCheckResolverOpt {
+	return func(ccr *CachedCheckResolver) {
+		ccr.maxNegativeCacheSize = size
+	}
+}
+
+// WithExistingNegativeCache sets the cache of the negative (denied) results to the specified cache.
+// As with WithExistingCache, the cache will not be stopped when the resolver is closed.
+func WithExistingNegativeCache(cache *ccache.Cache[*CachedResolveCheckResponse]) CachedCheckResolverOpt {
+	return func(ccr *CachedCheckResolver) {
+		ccr.negativeCache = cache
+	}
+}
+
 // WithExistingCache sets the cache to the specified cache.
 // Note that the original cache will not be stopped as it may still be used by others. It is up to the caller
 // to check whether the original cache should be stopped.
@@ -99,6 +188,16 @@ func WithExistingCache(cache *ccache.Cache[*CachedResolveCheckResponse]) CachedC
 	}
 }
 
+// WithCacheCounters counts the lookups of the caches of the positive and of the negative results, e.g. to
+// report their statistics through cachestats. The negative counters only count the lookups of a separate
+// cache of the negative results.
+func WithCacheCounters(counters, negativeCounters *cachestats.Counters) CachedCheckResolverOpt {
+	return func(ccr *CachedCheckResolver) {
+		ccr.counters = counters
+		ccr.negativeCounters = negativeCounters
+	}
+}
+
 // WithLogger sets the logger for the cached check resolver
 func WithLogger(logger logger.Logger) CachedCheckResolverOpt {
 	return func(ccr *CachedCheckResolver) {
@@ -106,6 +205,23 @@ func WithLogger(logger logger.Logger) CachedCheckResolverOpt {
 	}
 }
 
+// WithHotKeyTracker sets the tracker used to detect hot relations. Check sub-problems of hot relations
+// are cached with a longer TTL, and concurrent evaluations of the same hot sub-problem are collapsed into one.
+// The tracker is expected to be shared across requests.
+func WithHotKeyTracker(tracker *HotKeyTracker) CachedCheckResolverOpt {
+	return func(ccr *CachedCheckResolver) {
+		ccr.hotKeys = tracker
+	}
+}
+
+// WithCacheInvalidator tracks the models of the results cached with the invalidator of the caches (see
+// CheckCacheInvalidator.Track), so that the writes invalidate the relations their tuples affect in each of them.
+func WithCacheInvalidator(invalidator *CheckCacheInvalidator) CachedCheckResolverOpt {
+	return func(ccr *CachedCheckResolver) {
+		ccr.invalidator = invalidator
+	}
+}
+
 // NewCachedCheckResolver constructs a CheckResolver that delegates Check resolution to the provided delegate,
 // but before delegating the query to the delegate a cache-key lookup is made to see if the Check sub-problem
 // has already recently been computed. If the Check sub-problem is in the cache, then the response is returned
@@ -130,16 +246,141 @@ func NewCachedCheckResolver(delegate CheckResolver, opts ...CachedCheckResolverO
 		)
 	}
 
+	if checker.negativeCache == nil {
+		if checker.maxNegativeCacheSize > 0 {
+			checker.allocatedNegativeCache = true
+			checker.negativeCache = ccache.New(
+				ccache.Configure[*CachedResolveCheckResponse]().MaxSize(checker.maxNegativeCacheSize),
+			)
+		} else {
+			checker.negativeCache = checker.cache
+		}
+	}
+
+	if checker.negativeCacheTTL == 0 {
+		checker.negativeCacheTTL = checker.cacheTTL
+	}
+
 	return checker
 }
 
 // Close will deallocate resource allocated by the CachedCheckResolver
-// It will not deallocate cache if it has been passed in from WithExistingCache
+// It will not deallocate cache if it has been passed in from WithExistingCache or WithExistingNegativeCache
 func (c *CachedCheckResolver) Close() {
-	if c.allocatedCache {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.allocatedCache && c.cache != nil {
 		c.cache.Stop()
 		c.cache = nil
 	}
+
+	if c.allocatedNegativeCache && c.negativeCache != nil {
+		c.negativeCache.Stop()
+		c.negativeCache = nil
+	}
+}
+
+// get returns the cached response of the key, if any and not expired. Nothing is cached once the resolver
+// is closed.
+func (c *CachedCheckResolver) get(cacheKey string, record bool) *CachedResolveCheckResponse {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.cache == nil {
+		return nil
+	}
+
+	item := c.cache.Get(cacheKey)
+	hit := item != nil && !item.Expired()
+	if record {
+		c.counters.Record(hit)
+	}
+	if hit {
+		return item.Value()
+	}
+
+	// a key is only in both caches when its result changed, and the other entry has expired then
+	if c.negativeCache != nil && c.negativeCache != c.cache {
+		item := c.negativeCache.Get(cacheKey)
+		hit := item != nil && !item.Expired()
+		if record {
+			c.negativeCounters.Record(hit)
+		}
+		if hit {
+			return item.Value()
+		}
+	}
+
+	return nil
+}
+
+// track records the model of the request, from the context, to the invalidator before its result is cached,
+// so that a write can't miss the result.
+func (c *CachedCheckResolver) track(ctx context.Context, req *ResolveCheckRequest) {
+	if c.invalidator == nil {
+		return
+	}
+
+	if typesys, ok := typesystem.TypesystemFromContext(ctx); ok {
+		c.invalidator.Track(typesys, req.GetStoreID())
+	}
+}
+
+// set caches the response of the key for the TTL of its outcome multiplied by ttlMultiplier, capped by
+// expiresAt, the earliest expiration of the tuples it was resolved from (if they expire), unless the resolver is
+// closed.
+func (c *CachedCheckResolver) set(cacheKey string, resp *ResolveCheckResponse, ttlMultiplier uint32, expiresAt time.Time) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.cache == nil {
+		return
+	}
+
+	cache, ttl := c.cache, c.cacheTTL
+	if !resp.GetAllowed() {
+		cache, ttl = c.negativeCache, c.negativeCacheTTL
+	}
+	if cache == nil {
+		return
+	}
+
+	if ttl = capTTL(ttl*time.Duration(ttlMultiplier), expiresAt); ttl <= 0 {
+		return
+	}
+
+	cachedResp := newCachedResolveCheckResponse(resp)
+	cachedResp.expiresAt = expiresAt
+
+	cache.Set(cacheKey, cachedResp, ttl)
+}
+
+// capTTL returns the TTL, shortened so that an entry cached for it now doesn't outlive expiresAt, unless it's the
+// zero time. It returns 0 or less if expiresAt is already past.
+func capTTL(ttl time.Duration, expiresAt time.Time) time.Duration {
+	if expiresAt.IsZero() {
+		return ttl
+	}
+
+	return min(ttl, time.Until(expiresAt))
+}
+
+// resolveTracked delegates the resolution of the sub-problem, tracking the expirations of the tuples read for
+// it so that its result isn't cached past the earliest one, which it returns (or the zero time if none expires).
+func (c *CachedCheckResolver) resolveTracked(
+	ctx context.Context,
+	req *ResolveCheckRequest,
+) (*ResolveCheckResponse, time.Time, error) {
+	trackedCtx, tracker := storage.ContextWithExpirationTracker(ctx)
+
+	resp, err := c.delegate.ResolveCheck(trackedCtx, req)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	expiresAt, _ := tracker.Earliest()
+	return resp, expiresAt, nil
 }
 
 func (c *CachedCheckResolver) ResolveCheck(
@@ -154,26 +395,153 @@ func (c *CachedCheckResolver) ResolveCheck(
 		return nil, err
 	}
 
-	cachedResp := c.cache.Get(cacheKey)
-	if cachedResp != nil && !cachedResp.Expired() {
-		checkCacheHitCounter.Inc()
-		return cachedResp.Value().convertToResolveCheckResponse(), nil
+	if requestcontext.CacheBypassed(ctx) {
+		return c.resolveBypassingCache(ctx, req, cacheKey)
+	}
+
+	// the requests which prefer a higher consistency don't read the cached results, which may be stale, but
+	// they cache the results they resolve
+	if storage.ConsistencyFromContext(ctx) != storage.ConsistencyPreferenceHigherConsistency {
+		cachedResp := c.get(cacheKey, true)
+		if cachedResp == nil {
+			cachedResp = c.getShared(ctx, req, cacheKey)
+		}
+		if cachedResp != nil {
+			// the results resolved from this one mustn't be cached past the expiration of its tuples either
+			storage.RecordTupleExpiration(ctx, cachedResp.expiresAt)
+
+			checkCacheHitCounter.Inc()
+			checkCacheOutcomeCounter.WithLabelValues(checkCacheOutcome(cachedResp.Allowed), checkCacheResultHit).Inc()
+			return cachedResp.convertToResolveCheckResponse(), nil
+		}
+	}
+
+	if c.hotKeys != nil && c.hotKeys.Observe(HotKey{
+		StoreID:    req.GetStoreID(),
+		ObjectType: tuple.GetType(req.GetTupleKey().GetObject()),
+		Relation:   req.GetTupleKey().GetRelation(),
+	}) {
+		return c.resolveHotCheck(ctx, req, cacheKey)
 	}
 
-	resp, err := c.delegate.ResolveCheck(ctx, req)
+	resp, expiresAt, err := c.resolveTracked(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
-	c.cache.Set(cacheKey, newCachedResolveCheckResponse(resp), c.cacheTTL)
+	checkCacheOutcomeCounter.WithLabelValues(checkCacheOutcome(resp.GetAllowed()), checkCacheResultMiss).Inc()
+	c.track(ctx, req)
+	c.set(cacheKey, resp, 1, expiresAt)
+	c.setShared(ctx, req, cacheKey, resp, expiresAt)
 	return resp, nil
 }
 
+// resolveBypassingCache resolves a Check sub-problem without reading nor writing the caches, and records
+// in the request context the cache that would have served it, if any.
+func (c *CachedCheckResolver) resolveBypassingCache(
+	ctx context.Context,
+	req *ResolveCheckRequest,
+	cacheKey string,
+) (*ResolveCheckResponse, error) {
+	if c.get(cacheKey, false) != nil {
+		requestcontext.AddBypassedCache(ctx, checkCacheName)
+	} else if c.sharedCache != nil {
+		if _, _, ok, err := c.sharedCache.Get(ctx, req.GetStoreID(), cacheKey); err == nil && ok {
+			requestcontext.AddBypassedCache(ctx, sharedCheckCacheName)
+		}
+	}
+
+	return c.delegate.ResolveCheck(ctx, req)
+}
+
+// sharedCheckCanceledError is the error of a shared evaluation of a hot sub-problem that ended because the
+// request of the caller which evaluated it ended, e.g. when a union it's part of short-circuited.
+type sharedCheckCanceledError struct {
+	err error
+}
+
+func (e *sharedCheckCanceledError) Error() string {
+	return e.err.Error()
+}
+
+func (e *sharedCheckCanceledError) Unwrap() error {
+	return e.err
+}
+
+// hotCheckResult is the result of a shared evaluation of a hot sub-problem, with the earliest expiration of
+// the tuples it was resolved from, if any.
+type hotCheckResult struct {
+	resp      *ResolveCheckResponse
+	expiresAt time.Time
+}
+
+// resolveHotCheck resolves a Check sub-problem of a hot relation. Concurrent evaluations of the same
+// sub-problem share a single delegated evaluation, and the result is cached for longer than usual. If the
+// shared evaluation ends because the request of the caller that made it ended, the other callers evaluate the
+// sub-problem again.
+func (c *CachedCheckResolver) resolveHotCheck(
+	ctx context.Context,
+	req *ResolveCheckRequest,
+	cacheKey string,
+) (*ResolveCheckResponse, error) {
+	var v interface{}
+	for {
+		var err error
+		v, err, _ = c.hotKeys.lookupGroup.Do(cacheKey, func() (interface{}, error) {
+			resp, expiresAt, err := c.resolveTracked(ctx, req)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, &sharedCheckCanceledError{err: err}
+				}
+				return nil, err
+			}
+
+			c.track(ctx, req)
+			c.set(cacheKey, resp, c.hotKeys.ttlMultiplier, expiresAt)
+			c.setShared(ctx, req, cacheKey, resp, expiresAt)
+
+			return &hotCheckResult{resp: resp, expiresAt: expiresAt}, nil
+		})
+		if err == nil {
+			break
+		}
+
+		var canceledErr *sharedCheckCanceledError
+		if errors.As(err, &canceledErr) {
+			if ctx.Err() == nil {
+				continue
+			}
+			return nil, canceledErr.err
+		}
+
+		return nil, err
+	}
+
+	result := v.(*hotCheckResult)
+
+	// as for the cached results, the results resolved from this one mustn't be cached past the expiration of
+	// its tuples, whichever caller evaluated it
+	storage.RecordTupleExpiration(ctx, result.expiresAt)
+
+	// the response may be shared by several callers, so each one gets its own copy
+	resp := result.resp
+	checkCacheOutcomeCounter.WithLabelValues(checkCacheOutcome(resp.GetAllowed()), checkCacheResultMiss).Inc()
+	respCopy := &ResolveCheckResponse{Allowed: resp.GetAllowed()}
+	if metadata := resp.GetResolutionMetadata(); metadata != nil {
+		metadataCopy := *metadata
+		respCopy.ResolutionMetadata = &metadataCopy
+	}
+
+	return respCopy, nil
+}
+
 // checkRequestCacheKey converts the ResolveCheckRequest into a canonical cache key that can be
 // used for Check resolution cache key lookups.
 // The same tuple provided with the same contextual tuples should produce the same
 // cache key. If the contextual tuples are different order, it is possible that a different
 // cache key will be produced. This will result in duplicate entries.
+// The key starts with the checkCacheKeyPrefix of the store and the relation, so that the results of a
+// relation can be invalidated together.
 func checkRequestCacheKey(req *ResolveCheckRequest) (string, error) {
 	var contextualTuplesCacheKey string
 
@@ -190,12 +558,37 @@ func checkRequestCacheKey(req *ResolveCheckRequest) (string, error) {
 		contextualTuplesCacheKey = "/" + c.String()
 	}
 
-	key := fmt.Sprintf("%s/%s/%s%s",
+	var contextCacheKey string
+	if len(req.GetContext()) > 0 {
+		// the keys of the maps are sorted by json, unlike gob
+		c, err := json.Marshal(req.GetContext())
+		if err != nil {
+			return "", err
+		}
+
+		contextCacheKey = "/" + string(c)
+	}
+
+	key := fmt.Sprintf("%s/%s/%s%s%s",
 		req.GetStoreID(),
 		req.GetAuthorizationModelID(),
 		req.GetTupleKey(),
 		contextualTuplesCacheKey, // note that there is a prefix "/" if contextualTuplesCacheKey is not empty
+		contextCacheKey,
 	)
 
-	return base64.StdEncoding.EncodeToString([]byte(key)), nil
+	tk := req.GetTupleKey()
+	prefix := checkCacheKeyPrefix(req.GetStoreID(), tuple.GetType(tk.GetObject()), tk.GetRelation())
+
+	return prefix + base64.StdEncoding.EncodeToString([]byte(key)), nil
+}
+
+// checkCacheKeyPrefix returns the prefix of the cache keys of the Check results of a relation of a store.
+func checkCacheKeyPrefix(storeID, objectType, relation string) string {
+	return fmt.Sprintf("%s%s#%s/", CheckCacheStoreKeyPrefix(storeID), objectType, relation)
+}
+
+// CheckCacheStoreKeyPrefix returns the prefix of the cache keys of the Check results of a store.
+func CheckCacheStoreKeyPrefix(storeID string) string {
+	return storeID + "/"
 }
@@ -3,6 +3,8 @@ package graph
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,7 +13,10 @@ import (
 	"github.com/karlseguin/ccache/v3"
 	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/cachestats"
 	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/requestcontext"
+	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/storage/memory"
 	"github.com/openfga/openfga/pkg/storage/storagewrappers"
 	"github.com/openfga/openfga/pkg/tuple"
@@ -45,10 +50,10 @@ func TestResolveCheckFromCache(t *testing.T) {
 				TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:XYZ"),
 			},
 			setInitialResult: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(result, nil)
 			},
 			setTestExpectations: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(0).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(0).Return(result, nil)
 			},
 		},
 		{
@@ -59,10 +64,10 @@ func TestResolveCheckFromCache(t *testing.T) {
 				TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:XYZ"),
 			},
 			setInitialResult: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(result, nil)
 			},
 			setTestExpectations: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(result, nil)
 			},
 		},
 		{
@@ -73,10 +78,10 @@ func TestResolveCheckFromCache(t *testing.T) {
 				TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:XYZ"),
 			},
 			setInitialResult: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(result, nil)
 			},
 			setTestExpectations: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(result, nil)
 			},
 		},
 		{
@@ -87,10 +92,10 @@ func TestResolveCheckFromCache(t *testing.T) {
 				TupleKey:             tuple.NewTupleKey("document:abcd", "reader", "user:XYZ"),
 			},
 			setInitialResult: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(result, nil)
 			},
 			setTestExpectations: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(result, nil)
 			},
 		},
 		{
@@ -101,10 +106,10 @@ func TestResolveCheckFromCache(t *testing.T) {
 				TupleKey:             tuple.NewTupleKey("document:abc", "owner", "user:XYZ"),
 			},
 			setInitialResult: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(result, nil)
 			},
 			setTestExpectations: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(result, nil)
 			},
 		},
 		{
@@ -115,10 +120,10 @@ func TestResolveCheckFromCache(t *testing.T) {
 				TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:AAA"),
 			},
 			setInitialResult: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(result, nil)
 			},
 			setTestExpectations: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(result, nil)
 			},
 		},
 		{
@@ -136,10 +141,10 @@ func TestResolveCheckFromCache(t *testing.T) {
 				},
 			},
 			setInitialResult: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(result, nil)
 			},
 			setTestExpectations: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(result, nil)
 			},
 		},
 		{
@@ -150,10 +155,10 @@ func TestResolveCheckFromCache(t *testing.T) {
 				TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:XYZ"),
 			},
 			setInitialResult: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(nil, fmt.Errorf("Mock error"))
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(nil, fmt.Errorf("Mock error"))
 			},
 			setTestExpectations: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(result, nil)
 			},
 		},
 		{
@@ -193,10 +198,10 @@ func TestResolveCheckFromCache(t *testing.T) {
 				},
 			},
 			setInitialResult: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(result, nil)
 			},
 			setTestExpectations: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(0).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(0).Return(result, nil)
 			},
 		},
 		{
@@ -239,10 +244,10 @@ func TestResolveCheckFromCache(t *testing.T) {
 				},
 			},
 			setInitialResult: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(result, nil)
 			},
 			setTestExpectations: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(result, nil)
 			},
 		},
 		{
@@ -287,10 +292,10 @@ func TestResolveCheckFromCache(t *testing.T) {
 				},
 			},
 			setInitialResult: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(result, nil)
 			},
 			setTestExpectations: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(result, nil)
 			},
 		},
 		{
@@ -319,10 +324,10 @@ func TestResolveCheckFromCache(t *testing.T) {
 				ContextualTuples:     []*openfgav1.TupleKey{},
 			},
 			setInitialResult: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(result, nil)
 			},
 			setTestExpectations: func(mock *MockCheckResolver, request *ResolveCheckRequest) {
-				mock.EXPECT().ResolveCheck(ctx, request).Times(1).Return(result, nil)
+				mock.EXPECT().ResolveCheck(gomock.Any(), request).Times(1).Return(result, nil)
 			},
 		},
 	}
@@ -378,7 +383,7 @@ func TestResolveCheckExpired(t *testing.T) {
 
 	result := &ResolveCheckResponse{Allowed: true}
 	initialMockResolver := NewMockCheckResolver(ctrl)
-	initialMockResolver.EXPECT().ResolveCheck(ctx, req).Times(2).Return(result, nil)
+	initialMockResolver.EXPECT().ResolveCheck(gomock.Any(), req).Times(2).Return(result, nil)
 
 	// expect first call to result in actual resolve call
 	dut := NewCachedCheckResolver(initialMockResolver, WithCacheTTL(1*time.Microsecond))
@@ -396,6 +401,358 @@ func TestResolveCheckExpired(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestResolveCheckExpiringTuples(t *testing.T) {
+	ctx := context.Background()
+
+	req := &ResolveCheckRequest{
+		StoreID:              "12",
+		AuthorizationModelID: "33",
+		TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:XYZ"),
+	}
+
+	// resolveFromTupleExpiringIn resolves the request as if from a tuple which expires after the duration
+	resolveFromTupleExpiringIn := func(d time.Duration) func(context.Context, *ResolveCheckRequest) (*ResolveCheckResponse, error) {
+		return func(ctx context.Context, _ *ResolveCheckRequest) (*ResolveCheckResponse, error) {
+			storage.RecordTupleExpiration(ctx, time.Now().Add(d))
+			return &ResolveCheckResponse{Allowed: true}, nil
+		}
+	}
+
+	t.Run("results_are_not_cached_past_the_expiration_of_their_tuples", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockResolver := NewMockCheckResolver(ctrl)
+		mockResolver.EXPECT().ResolveCheck(gomock.Any(), req).Times(2).DoAndReturn(resolveFromTupleExpiringIn(time.Millisecond))
+
+		dut := NewCachedCheckResolver(mockResolver, WithCacheTTL(time.Hour))
+		defer dut.Close()
+
+		_, err := dut.ResolveCheck(ctx, req)
+		require.NoError(t, err)
+
+		time.Sleep(5 * time.Millisecond)
+
+		_, err = dut.ResolveCheck(ctx, req)
+		require.NoError(t, err)
+	})
+
+	t.Run("cached_results_report_the_expiration_of_their_tuples", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockResolver := NewMockCheckResolver(ctrl)
+		mockResolver.EXPECT().ResolveCheck(gomock.Any(), req).Times(1).DoAndReturn(resolveFromTupleExpiringIn(time.Minute))
+
+		dut := NewCachedCheckResolver(mockResolver, WithCacheTTL(time.Hour))
+		defer dut.Close()
+
+		_, err := dut.ResolveCheck(ctx, req)
+		require.NoError(t, err)
+
+		// e.g. to the sub-problem the result is resolved for
+		trackedCtx, tracker := storage.ContextWithExpirationTracker(ctx)
+		_, err = dut.ResolveCheck(trackedCtx, req)
+		require.NoError(t, err)
+
+		expiresAt, ok := tracker.Earliest()
+		require.True(t, ok)
+		require.WithinDuration(t, time.Now().Add(time.Minute), expiresAt, time.Second)
+	})
+
+	t.Run("shared_results_are_not_cached_past_the_expiration_of_their_tuples", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		shared := &inMemorySharedCheckCache{results: map[string]sharedCheckResult{}}
+
+		first := NewMockCheckResolver(ctrl)
+		first.EXPECT().ResolveCheck(gomock.Any(), req).Times(1).DoAndReturn(resolveFromTupleExpiringIn(50 * time.Millisecond))
+		firstCache := NewCachedCheckResolver(first, WithCacheTTL(time.Hour), WithSharedCache(shared))
+		defer firstCache.Close()
+
+		_, err := firstCache.ResolveCheck(ctx, req)
+		require.NoError(t, err)
+
+		// the second server reads the result from the shared cache, and reports the expiration of its tuples
+		second := NewMockCheckResolver(ctrl)
+		second.EXPECT().ResolveCheck(gomock.Any(), req).Times(1).Return(&ResolveCheckResponse{Allowed: false}, nil)
+		secondCache := NewCachedCheckResolver(second, WithCacheTTL(time.Hour), WithSharedCache(shared))
+		defer secondCache.Close()
+
+		trackedCtx, tracker := storage.ContextWithExpirationTracker(ctx)
+		resp, err := secondCache.ResolveCheck(trackedCtx, req)
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+
+		_, ok := tracker.Earliest()
+		require.True(t, ok)
+
+		// neither its local copy nor the shared one outlive the tuple
+		time.Sleep(60 * time.Millisecond)
+
+		resp, err = secondCache.ResolveCheck(ctx, req)
+		require.NoError(t, err)
+		require.False(t, resp.GetAllowed())
+	})
+}
+
+func TestResolveCheckNegativeCachePolicy(t *testing.T) {
+	ctx := context.Background()
+
+	allowedReq := &ResolveCheckRequest{
+		StoreID:              "12",
+		AuthorizationModelID: "33",
+		TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:XYZ"),
+	}
+	deniedReq := &ResolveCheckRequest{
+		StoreID:              "12",
+		AuthorizationModelID: "33",
+		TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:ABC"),
+	}
+
+	t.Run("negative_results_expire_after_their_own_ttl", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockResolver := NewMockCheckResolver(ctrl)
+		mockResolver.EXPECT().ResolveCheck(gomock.Any(), allowedReq).Times(1).Return(&ResolveCheckResponse{Allowed: true}, nil)
+		mockResolver.EXPECT().ResolveCheck(gomock.Any(), deniedReq).Times(2).Return(&ResolveCheckResponse{Allowed: false}, nil)
+
+		dut := NewCachedCheckResolver(mockResolver,
+			WithCacheTTL(time.Minute),
+			WithNegativeCacheTTL(1*time.Microsecond))
+		defer dut.Close()
+
+		for i := 0; i < 2; i++ {
+			resp, err := dut.ResolveCheck(ctx, allowedReq)
+			require.NoError(t, err)
+			require.True(t, resp.GetAllowed())
+
+			resp, err = dut.ResolveCheck(ctx, deniedReq)
+			require.NoError(t, err)
+			require.False(t, resp.GetAllowed())
+
+			time.Sleep(5 * time.Microsecond)
+		}
+	})
+
+	t.Run("negative_results_are_cached_separately", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockResolver := NewMockCheckResolver(ctrl)
+		mockResolver.EXPECT().ResolveCheck(gomock.Any(), allowedReq).Times(1).Return(&ResolveCheckResponse{Allowed: true}, nil)
+		mockResolver.EXPECT().ResolveCheck(gomock.Any(), deniedReq).Times(1).Return(&ResolveCheckResponse{Allowed: false}, nil)
+
+		counters, negativeCounters := &cachestats.Counters{}, &cachestats.Counters{}
+		dut := NewCachedCheckResolver(mockResolver, WithMaxNegativeCacheSize(10), WithCacheCounters(counters, negativeCounters))
+		defer dut.Close()
+		require.NotSame(t, dut.cache, dut.negativeCache)
+
+		for i := 0; i < 2; i++ {
+			resp, err := dut.ResolveCheck(ctx, allowedReq)
+			require.NoError(t, err)
+			require.True(t, resp.GetAllowed())
+
+			resp, err = dut.ResolveCheck(ctx, deniedReq)
+			require.NoError(t, err)
+			require.False(t, resp.GetAllowed())
+		}
+
+		require.Equal(t, 1, dut.cache.ItemCount())
+		require.Equal(t, 1, dut.negativeCache.ItemCount())
+
+		hits, misses := counters.Load()
+		require.Equal(t, uint64(1), hits)
+		require.Equal(t, uint64(3), misses)
+
+		hits, misses = negativeCounters.Load()
+		require.Equal(t, uint64(1), hits)
+		require.Equal(t, uint64(2), misses)
+	})
+
+	t.Run("negative_results_share_the_cache_by_default", func(t *testing.T) {
+		dut := NewCachedCheckResolver(NewMockCheckResolver(gomock.NewController(t)), WithCacheTTL(time.Minute))
+		defer dut.Close()
+
+		require.Same(t, dut.cache, dut.negativeCache)
+		require.Equal(t, time.Minute, dut.negativeCacheTTL)
+	})
+}
+
+// inMemorySharedCheckCache is a SharedCheckCache in memory, which isn't versioned and doesn't expire.
+type inMemorySharedCheckCache struct {
+	mu      sync.Mutex
+	results map[string]sharedCheckResult
+}
+
+type sharedCheckResult struct {
+	allowed   bool
+	expiresAt time.Time
+}
+
+func (c *inMemorySharedCheckCache) Get(_ context.Context, storeID, key string) (bool, time.Time, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result, ok := c.results[storeID+"/"+key]
+	return result.allowed, result.expiresAt, ok, nil
+}
+
+func (c *inMemorySharedCheckCache) Set(_ context.Context, storeID, key string, allowed bool, expiresAt time.Time, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.results[storeID+"/"+key] = sharedCheckResult{allowed: allowed, expiresAt: expiresAt}
+	return nil
+}
+
+func (c *inMemorySharedCheckCache) Invalidate(_ context.Context, storeID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.results {
+		if strings.HasPrefix(key, storeID+"/") {
+			delete(c.results, key)
+		}
+	}
+	return nil
+}
+
+func TestResolveCheckSharedCache(t *testing.T) {
+	ctx := context.Background()
+
+	req := &ResolveCheckRequest{
+		StoreID:              "12",
+		AuthorizationModelID: "33",
+		TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:XYZ"),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	shared := &inMemorySharedCheckCache{results: map[string]sharedCheckResult{}}
+
+	// the first server resolves the sub-problem, and the second one reads its result from the shared cache
+	first := NewMockCheckResolver(ctrl)
+	first.EXPECT().ResolveCheck(gomock.Any(), req).Times(1).Return(&ResolveCheckResponse{Allowed: true}, nil)
+	firstCache := NewCachedCheckResolver(first, WithSharedCache(shared))
+	defer firstCache.Close()
+
+	resp, err := firstCache.ResolveCheck(ctx, req)
+	require.NoError(t, err)
+	require.True(t, resp.GetAllowed())
+
+	second := NewMockCheckResolver(ctrl)
+	second.EXPECT().ResolveCheck(gomock.Any(), req).Times(1).Return(&ResolveCheckResponse{Allowed: false}, nil)
+	secondCache := NewCachedCheckResolver(second, WithSharedCache(shared))
+	defer secondCache.Close()
+
+	for i := 0; i < 2; i++ {
+		resp, err = secondCache.ResolveCheck(ctx, req)
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+	}
+	require.Equal(t, 1, secondCache.cache.ItemCount())
+
+	// once the store is invalidated, the sub-problem is resolved again
+	require.NoError(t, shared.Invalidate(ctx, req.GetStoreID()))
+	secondCache.cache.Clear()
+
+	resp, err = secondCache.ResolveCheck(ctx, req)
+	require.NoError(t, err)
+	require.False(t, resp.GetAllowed())
+}
+
+func TestResolveCheckBypassingCache(t *testing.T) {
+	ctx := context.Background()
+
+	req := &ResolveCheckRequest{
+		StoreID:              "12",
+		AuthorizationModelID: "33",
+		TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:XYZ"),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockResolver := NewMockCheckResolver(ctrl)
+	mockResolver.EXPECT().ResolveCheck(gomock.Any(), req).Times(4).Return(&ResolveCheckResponse{Allowed: true}, nil)
+
+	shared := &inMemorySharedCheckCache{results: map[string]sharedCheckResult{}}
+	dut := NewCachedCheckResolver(mockResolver, WithSharedCache(shared))
+	defer dut.Close()
+
+	// nothing is cached yet, and the result of a bypassing request isn't cached
+	bypassingCtx := requestcontext.NewContext(ctx)
+	requestcontext.SetCacheBypassed(bypassingCtx)
+
+	_, err := dut.ResolveCheck(bypassingCtx, req)
+	require.NoError(t, err)
+	require.Empty(t, requestcontext.BypassedCaches(bypassingCtx))
+	require.Equal(t, 0, dut.cache.ItemCount())
+
+	_, err = dut.ResolveCheck(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, 1, dut.cache.ItemCount())
+
+	bypassingCtx = requestcontext.NewContext(ctx)
+	requestcontext.SetCacheBypassed(bypassingCtx)
+
+	_, err = dut.ResolveCheck(bypassingCtx, req)
+	require.NoError(t, err)
+	require.Equal(t, []string{checkCacheName}, requestcontext.BypassedCaches(bypassingCtx))
+
+	// the shared cache is reported when it would have served the request instead of the local one
+	dut.cache.Clear()
+
+	bypassingCtx = requestcontext.NewContext(ctx)
+	requestcontext.SetCacheBypassed(bypassingCtx)
+
+	_, err = dut.ResolveCheck(bypassingCtx, req)
+	require.NoError(t, err)
+	require.Equal(t, []string{sharedCheckCacheName}, requestcontext.BypassedCaches(bypassingCtx))
+}
+
+func TestResolveCheckHigherConsistency(t *testing.T) {
+	ctx := context.Background()
+
+	req := &ResolveCheckRequest{
+		StoreID:              "12",
+		AuthorizationModelID: "33",
+		TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:XYZ"),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockResolver := NewMockCheckResolver(ctrl)
+	gomock.InOrder(
+		mockResolver.EXPECT().ResolveCheck(gomock.Any(), req).Return(&ResolveCheckResponse{Allowed: true}, nil),
+		mockResolver.EXPECT().ResolveCheck(gomock.Any(), req).Return(&ResolveCheckResponse{Allowed: false}, nil),
+	)
+
+	dut := NewCachedCheckResolver(mockResolver)
+	defer dut.Close()
+
+	resp, err := dut.ResolveCheck(ctx, req)
+	require.NoError(t, err)
+	require.True(t, resp.GetAllowed())
+
+	// the cached result isn't read, but it's replaced with the fresh one
+	consistentCtx := requestcontext.NewContext(ctx)
+	requestcontext.SetConsistency(consistentCtx, storage.ConsistencyPreferenceHigherConsistency.String())
+
+	resp, err = dut.ResolveCheck(consistentCtx, req)
+	require.NoError(t, err)
+	require.False(t, resp.GetAllowed())
+
+	resp, err = dut.ResolveCheck(ctx, req)
+	require.NoError(t, err)
+	require.False(t, resp.GetAllowed())
+}
+
 func TestCachedCheckDatastoreQueryCount(t *testing.T) {
 	t.Parallel()
 
@@ -32,6 +32,10 @@ type ResolveCheckRequest struct {
 	ContextualTuples     []*openfgav1.TupleKey
 	ResolutionMetadata   *ResolutionMetadata
 	VisitedPaths         map[string]struct{}
+
+	// Context is the context the conditions of the tuples are evaluated with (see the condition package). The
+	// tuple reader of the resolver evaluates them, and the context only tells the results apart.
+	Context map[string]interface{}
 }
 
 type ResolveCheckResponse struct {
@@ -87,6 +91,14 @@ func (r *ResolveCheckRequest) GetContextualTuples() []*openfgav1.TupleKey {
 	return nil
 }
 
+func (r *ResolveCheckRequest) GetContext() map[string]interface{} {
+	if r != nil {
+		return r.Context
+	}
+
+	return nil
+}
+
 func (r *ResolveCheckRequest) GetResolutionMetadata() *ResolutionMetadata {
 	if r != nil {
 		return r.ResolutionMetadata
@@ -113,6 +125,9 @@ type LocalChecker struct {
 	delegate           CheckResolver
 	concurrencyLimit   uint32
 	maxConcurrentReads uint32
+	usersetBatchSize   uint32
+	statsProvider      storage.StatsProvider
+	dispatcher         func(local CheckResolver) CheckResolver
 }
 
 type LocalCheckerOption func(d *LocalChecker)
@@ -131,12 +146,35 @@ func WithMaxConcurrentReads(limit uint32) LocalCheckerOption {
 	}
 }
 
+// WithUsersetBatchSize see server.WithCheckUsersetBatchSize
+func WithUsersetBatchSize(size uint32) LocalCheckerOption {
+	return func(d *LocalChecker) {
+		d.usersetBatchSize = size
+	}
+}
+
+// WithStatsProvider see server.WithStatsProvider
+func WithStatsProvider(stats storage.StatsProvider) LocalCheckerOption {
+	return func(d *LocalChecker) {
+		d.statsProvider = stats
+	}
+}
+
 func WithDelegate(delegate CheckResolver) LocalCheckerOption {
 	return func(d *LocalChecker) {
 		d.delegate = delegate
 	}
 }
 
+// WithDispatcher wraps the resolution of the sub-problems, e.g. to dispatch them to other servers. The wrapper
+// is given the LocalChecker, to resolve the sub-problems locally, and is placed behind the cached resolver, if
+// any, so that the dispatched results are cached too.
+func WithDispatcher(dispatcher func(local CheckResolver) CheckResolver) LocalCheckerOption {
+	return func(d *LocalChecker) {
+		d.dispatcher = dispatcher
+	}
+}
+
 func WithCachedResolver(opts ...CachedCheckResolverOpt) LocalCheckerOption {
 	return func(d *LocalChecker) {
 		cachedCheckResolver := NewCachedCheckResolver(
@@ -154,6 +192,7 @@ func NewLocalChecker(ds storage.RelationshipTupleReader, opts ...LocalCheckerOpt
 		ds:                 ds,
 		concurrencyLimit:   serverconfig.DefaultResolveNodeBreadthLimit,
 		maxConcurrentReads: serverconfig.DefaultMaxConcurrentReadsForCheck,
+		usersetBatchSize:   serverconfig.DefaultCheckUsersetBatchSize,
 	}
 	checker.delegate = checker // by default, a LocalChecker delegates/dispatchs subproblems to itself (e.g. local dispatch) unless otherwise configured.
 
@@ -161,6 +200,15 @@ func NewLocalChecker(ds storage.RelationshipTupleReader, opts ...LocalCheckerOpt
 		opt(checker)
 	}
 
+	if checker.dispatcher != nil {
+		dispatcher := checker.dispatcher(checker)
+		if cached, ok := checker.delegate.(*CachedCheckResolver); ok {
+			cached.delegate = dispatcher
+		} else {
+			checker.delegate = dispatcher
+		}
+	}
+
 	checker.ds = storagewrappers.NewBoundedConcurrencyTupleReader(checker.ds, checker.maxConcurrentReads)
 
 	// Depending on whether cached check resolver is used,
@@ -272,37 +320,29 @@ func union(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHandle
 }
 
 // intersection implements a CheckFuncReducer that requires all of the provided CheckHandlerFunc to resolve
-// to an allowed outcome. The first falsey or erroneous outcome causes premature termination of the reducer.
-func intersection(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHandlerFunc) (*ResolveCheckResponse, error) {
-	ctx, cancel := context.WithCancel(ctx)
-	resultChan := make(chan checkOutcome, len(handlers))
-
-	drain := resolver(ctx, concurrencyLimit, resultChan, handlers...)
-
-	defer func() {
-		cancel()
-		drain()
-		close(resultChan)
-	}()
-
+// to an allowed outcome. The handlers are evaluated lazily, one at a time and in the order they're provided,
+// so that the first falsey outcome causes premature termination of the reducer without evaluating the
+// remaining handlers. An erroneous outcome doesn't terminate the reducer, since a later falsey outcome still
+// determines the result.
+func intersection(ctx context.Context, _ uint32, handlers ...CheckHandlerFunc) (*ResolveCheckResponse, error) {
 	var dbReads uint32
 	var err error
-	for i := 0; i < len(handlers); i++ {
-		select {
-		case result := <-resultChan:
-			if result.err != nil {
-				err = result.err
-				continue
-			}
-
-			dbReads += result.resp.GetResolutionMetadata().DatastoreQueryCount
-			if !result.resp.GetAllowed() {
-				result.resp.GetResolutionMetadata().DatastoreQueryCount = dbReads
-				return result.resp, nil
-			}
-		case <-ctx.Done():
+	for _, handler := range handlers {
+		if ctx.Err() != nil {
 			return nil, ctx.Err()
 		}
+
+		resp, handlerErr := handler(ctx)
+		if handlerErr != nil {
+			err = handlerErr
+			continue
+		}
+
+		dbReads += resp.GetResolutionMetadata().DatastoreQueryCount
+		if !resp.GetAllowed() {
+			resp.GetResolutionMetadata().DatastoreQueryCount = dbReads
+			return resp, nil
+		}
 	}
 
 	if err != nil {
@@ -324,7 +364,9 @@ func intersection(ctx context.Context, concurrencyLimit uint32, handlers ...Chec
 
 // exclusion implements a CheckFuncReducer that requires a 'base' CheckHandlerFunc to resolve to an allowed
 // outcome and a 'sub' CheckHandlerFunc to resolve to a falsey outcome. The base and sub computations are
-// handled concurrently relative to one another.
+// handled concurrently relative to one another, and whichever determines the outcome first cancels the
+// other: a falsey base or an allowed sub. An erroneous outcome doesn't terminate the reducer, since the other
+// computation may still determine the outcome.
 func exclusion(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHandlerFunc) (*ResolveCheckResponse, error) {
 	if len(handlers) != 2 {
 		panic(fmt.Sprintf("expected two rewrite operands for exclusion operator, but got '%d'", len(handlers)))
@@ -345,26 +387,24 @@ func exclusion(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHa
 		close(subChan)
 	}()
 
-	baseHandler := handlers[0]
-	subHandler := handlers[1]
+	evaluate := func(handler CheckHandlerFunc, outcomeChan chan<- checkOutcome) {
+		defer wg.Done()
 
-	limiter <- struct{}{}
-	wg.Add(1)
-	go func() {
-		resp, err := baseHandler(ctx)
-		baseChan <- checkOutcome{resp, err}
-		<-limiter
-		wg.Done()
-	}()
+		select {
+		case limiter <- struct{}{}:
+		case <-ctx.Done():
+			// the outcome was determined by the other computation before this one could start
+			return
+		}
 
-	limiter <- struct{}{}
-	wg.Add(1)
-	go func() {
-		resp, err := subHandler(ctx)
-		subChan <- checkOutcome{resp, err}
+		resp, err := handler(ctx)
 		<-limiter
-		wg.Done()
-	}()
+		outcomeChan <- checkOutcome{resp, err}
+	}
+
+	wg.Add(2)
+	go evaluate(handlers[0], baseChan)
+	go evaluate(handlers[1], subChan)
 
 	response := &ResolveCheckResponse{
 		Allowed: false,
@@ -373,11 +413,13 @@ func exclusion(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHa
 		},
 	}
 	var dbReads uint32
+	var err error
 	for i := 0; i < len(handlers); i++ {
 		select {
 		case baseResult := <-baseChan:
 			if baseResult.err != nil {
-				return response, baseResult.err
+				err = baseResult.err
+				continue
 			}
 
 			dbReads += baseResult.resp.GetResolutionMetadata().DatastoreQueryCount
@@ -389,7 +431,8 @@ func exclusion(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHa
 
 		case subResult := <-subChan:
 			if subResult.err != nil {
-				return response, subResult.err
+				err = subResult.err
+				continue
 			}
 
 			dbReads += subResult.resp.GetResolutionMetadata().DatastoreQueryCount
@@ -403,6 +446,11 @@ func exclusion(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHa
 		}
 	}
 
+	if err != nil {
+		response.GetResolutionMetadata().DatastoreQueryCount = dbReads
+		return response, err
+	}
+
 	return &ResolveCheckResponse{
 		Allowed: true,
 		ResolutionMetadata: &ResolutionMetadata{
@@ -433,10 +481,8 @@ func (c *LocalChecker) ResolveCheck(
 	ctx context.Context,
 	req *ResolveCheckRequest,
 ) (*ResolveCheckResponse, error) {
-	ctx, span := tracer.Start(ctx, "ResolveCheck")
-	defer span.End()
-
-	span.SetAttributes(attribute.String("tuple_key", req.GetTupleKey().String()))
+	ctx, span := startResolutionSpan(ctx, "ResolveCheck", attribute.String("tuple_key", req.GetTupleKey().String()))
+	defer span.end()
 
 	if req.GetResolutionMetadata().Depth == 0 {
 		return nil, ErrResolutionDepthExceeded
@@ -476,40 +522,99 @@ func (c *LocalChecker) ResolveCheck(
 	return resp, nil
 }
 
+// resolutionSpan is the span of a step of the resolution of a Check, which is also recorded in the
+// resolution tree of the Check if it's being profiled (see ProfilingCheckResolver).
+type resolutionSpan struct {
+	span    trace.Span
+	profile *profileContext
+}
+
+func startResolutionSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, *resolutionSpan) {
+	ctx, span := tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	ctx, profile := startProfileNode(ctx, name, attrs)
+
+	return ctx, &resolutionSpan{span: span, profile: profile}
+}
+
+func (s *resolutionSpan) end(attrs ...attribute.KeyValue) {
+	s.span.SetAttributes(attrs...)
+	s.profile.end(attrs)
+	s.span.End()
+}
+
+// startEdgeSpan starts the span of the evaluation of a resolution edge, so that a trace shows which
+// branches of the model the time of a Check was spent on.
+func startEdgeSpan(
+	ctx context.Context,
+	name string,
+	edgeType RelationshipEdgeType,
+	tk *openfgav1.TupleKey,
+	attrs ...attribute.KeyValue,
+) (context.Context, *resolutionSpan) {
+	attrs = append([]attribute.KeyValue{
+		attribute.String("edge_type", edgeType.String()),
+		attribute.String("object_relation", tuple.ToObjectRelationString(tk.GetObject(), tk.GetRelation())),
+	}, attrs...)
+
+	return startResolutionSpan(ctx, name, attrs...)
+}
+
+// endEdgeSpan records the outcome of the evaluation of a resolution edge and the number of tuples it
+// read on its span, and ends it.
+func endEdgeSpan(span *resolutionSpan, resp *ResolveCheckResponse, tuplesRead int, err error) {
+	attrs := []attribute.KeyValue{
+		attribute.Bool("allowed", resp.GetAllowed()),
+		attribute.Int("tuples_read", tuplesRead),
+	}
+
+	if metadata := resp.GetResolutionMetadata(); metadata != nil {
+		attrs = append(attrs, attribute.Int("datastore_query_count", int(metadata.DatastoreQueryCount)))
+	}
+
+	if err != nil {
+		span.span.RecordError(err)
+		attrs = append(attrs, attribute.String("error", err.Error()))
+	}
+
+	span.end(attrs...)
+}
+
 // checkDirect composes two CheckHandlerFunc which evaluate direct relationships with the provided
 // 'object#relation'. The first handler looks up direct matches on the provided 'object#relation@user',
 // while the second handler looks up relationships between the target 'object#relation' and any usersets
 // related to it.
 func (c *LocalChecker) checkDirect(parentctx context.Context, req *ResolveCheckRequest) CheckHandlerFunc {
-	return func(ctx context.Context) (*ResolveCheckResponse, error) {
+	return func(ctx context.Context) (resp *ResolveCheckResponse, err error) {
 		typesys, ok := typesystem.TypesystemFromContext(parentctx) // note: use of 'parentctx' not 'ctx' - this is important
 		if !ok {
 			return nil, fmt.Errorf("typesystem missing in context")
 		}
 
-		ctx, span := tracer.Start(ctx, "checkDirect")
-		defer span.End()
-
 		storeID := req.GetStoreID()
 		tk := req.GetTupleKey()
+
+		ctx, span := startEdgeSpan(ctx, "checkDirect", DirectEdge, tk)
+		defer func() { endEdgeSpan(span, resp, 0, err) }()
+
 		objectType := tuple.GetType(tk.GetObject())
 		relation := tk.GetRelation()
 
 		// directlyRelatedUsersetTypes could be "user:*" or "group#member"
 		directlyRelatedUsersetTypes, _ := typesys.DirectlyRelatedUsersets(objectType, relation)
 
-		fn1 := func(ctx context.Context) (*ResolveCheckResponse, error) {
-			ctx, span := tracer.Start(ctx, "checkDirectUserTuple", trace.WithAttributes(attribute.String("tuple_key", tk.String())))
-			defer span.End()
+		fn1 := func(ctx context.Context) (response *ResolveCheckResponse, err error) {
+			ctx, span := startEdgeSpan(ctx, "checkDirectUserTuple", DirectEdge, tk, attribute.String("tuple_key", tk.String()))
+			var tuplesRead int
+			defer func() { endEdgeSpan(span, response, tuplesRead, err) }()
 
-			response := &ResolveCheckResponse{
+			response = &ResolveCheckResponse{
 				Allowed: false,
 				ResolutionMetadata: &ResolutionMetadata{
 					DatastoreQueryCount: req.GetResolutionMetadata().DatastoreQueryCount + 1,
 				},
 			}
 
-			t, err := c.ds.ReadUserTuple(ctx, storeID, tk)
+			t, err := c.ds.ReadUserTuple(ctx, storeID, tk, storage.ReadOptionsFromContext(ctx))
 			if err != nil {
 				if errors.Is(err, storage.ErrNotFound) {
 					return response, nil
@@ -518,22 +623,26 @@ func (c *LocalChecker) checkDirect(parentctx context.Context, req *ResolveCheckR
 				return response, err
 			}
 
+			if t != nil {
+				tuplesRead++
+			}
+
 			// filter out invalid tuples yielded by the database query
 			err = validation.ValidateTuple(typesys, tk)
 
 			if t != nil && err == nil {
-				span.SetAttributes(attribute.Bool("allowed", true))
 				response.Allowed = true
 				return response, nil
 			}
 			return response, nil
 		}
 
-		fn2 := func(ctx context.Context) (*ResolveCheckResponse, error) {
-			ctx, span := tracer.Start(ctx, "checkDirectUsersetTuples", trace.WithAttributes(attribute.String("userset", tuple.ToObjectRelationString(tk.Object, tk.Relation))))
-			defer span.End()
+		fn2 := func(ctx context.Context) (response *ResolveCheckResponse, err error) {
+			ctx, span := startEdgeSpan(ctx, "checkDirectUsersetTuples", DirectEdge, tk, attribute.String("userset", tuple.ToObjectRelationString(tk.Object, tk.Relation)))
+			var tuplesRead int
+			defer func() { endEdgeSpan(span, response, tuplesRead, err) }()
 
-			response := &ResolveCheckResponse{
+			response = &ResolveCheckResponse{
 				Allowed: false,
 				ResolutionMetadata: &ResolutionMetadata{
 					DatastoreQueryCount: req.GetResolutionMetadata().DatastoreQueryCount + 1,
@@ -544,7 +653,7 @@ func (c *LocalChecker) checkDirect(parentctx context.Context, req *ResolveCheckR
 				Object:                      tk.Object,
 				Relation:                    tk.Relation,
 				AllowedUserTypeRestrictions: directlyRelatedUsersetTypes,
-			})
+			}, storage.ReadOptionsFromContext(ctx))
 			if err != nil {
 				return response, err
 			}
@@ -558,6 +667,11 @@ func (c *LocalChecker) checkDirect(parentctx context.Context, req *ResolveCheckR
 			defer filteredIter.Stop()
 
 			var handlers []CheckHandlerFunc
+
+			// the objects of the usersets that are checked in batches, keyed by 'type#relation'
+			var batchedUsersets []string
+			batchedObjectIDs := map[string][]string{}
+
 			for {
 				t, err := filteredIter.Next()
 				if err != nil {
@@ -568,11 +682,12 @@ func (c *LocalChecker) checkDirect(parentctx context.Context, req *ResolveCheckR
 					return response, err
 				}
 
+				tuplesRead++
+
 				usersetObject, usersetRelation := tuple.SplitObjectRelation(t.GetUser())
 
 				// for 1.0 models, if the user is '*' then we're done searching
 				if usersetObject == tuple.Wildcard && typesys.GetSchemaVersion() == typesystem.SchemaVersion1_0 {
-					span.SetAttributes(attribute.Bool("allowed", true))
 					response.Allowed = true
 					return response, nil
 				}
@@ -583,7 +698,6 @@ func (c *LocalChecker) checkDirect(parentctx context.Context, req *ResolveCheckR
 					wildcardType := tuple.GetType(usersetObject)
 
 					if tuple.GetType(tk.GetUser()) == wildcardType {
-						span.SetAttributes(attribute.Bool("allowed", true))
 						response.Allowed = true
 						return response, nil
 					}
@@ -598,6 +712,16 @@ func (c *LocalChecker) checkDirect(parentctx context.Context, req *ResolveCheckR
 						return nil, ErrCycleDetected
 					}
 
+					usersetType, usersetObjectID := tuple.SplitObject(usersetObject)
+					if c.usersetBatchSize > 0 && isBatchableUserset(typesys, usersetType, usersetRelation) {
+						userset := tuple.ToObjectRelationString(usersetType, usersetRelation)
+						if _, ok := batchedObjectIDs[userset]; !ok {
+							batchedUsersets = append(batchedUsersets, userset)
+						}
+						batchedObjectIDs[userset] = append(batchedObjectIDs[userset], usersetObjectID)
+						continue
+					}
+
 					handlers = append(handlers, c.dispatch(
 						ctx,
 						&ResolveCheckRequest{
@@ -605,6 +729,7 @@ func (c *LocalChecker) checkDirect(parentctx context.Context, req *ResolveCheckR
 							AuthorizationModelID: req.GetAuthorizationModelID(),
 							TupleKey:             tupleKey,
 							ContextualTuples:     req.GetContextualTuples(),
+							Context:              req.GetContext(),
 							ResolutionMetadata: &ResolutionMetadata{
 								Depth:               req.GetResolutionMetadata().Depth - 1,
 								DatastoreQueryCount: response.GetResolutionMetadata().DatastoreQueryCount,
@@ -614,6 +739,16 @@ func (c *LocalChecker) checkDirect(parentctx context.Context, req *ResolveCheckR
 				}
 			}
 
+			for _, userset := range batchedUsersets {
+				usersetType, usersetRelation := tuple.SplitObjectRelation(userset)
+				objectIDs := batchedObjectIDs[userset]
+
+				for start := 0; start < len(objectIDs); start += int(c.usersetBatchSize) {
+					end := min(start+int(c.usersetBatchSize), len(objectIDs))
+					handlers = append(handlers, c.checkUsersetsBatch(typesys, req, usersetType, usersetRelation, objectIDs[start:end], response.GetResolutionMetadata().DatastoreQueryCount))
+				}
+			}
+
 			if len(handlers) == 0 {
 				return response, nil
 			}
@@ -640,15 +775,124 @@ func (c *LocalChecker) checkDirect(parentctx context.Context, req *ResolveCheckR
 	}
 }
 
+// isBatchableUserset returns whether the relation of the object type can only be satisfied by a direct
+// relationship with a user, or with a typed wildcard, so that whether a user is in many usersets of it
+// (e.g. 'team:1#member', 'team:2#member') can be found with a single datastore query.
+func isBatchableUserset(typesys *typesystem.TypeSystem, objectType, relation string) bool {
+	if typesys.GetSchemaVersion() != typesystem.SchemaVersion1_1 {
+		return false
+	}
+
+	r, err := typesys.GetRelation(objectType, relation)
+	if err != nil {
+		return false
+	}
+
+	if _, ok := r.GetRewrite().GetUserset().(*openfgav1.Userset_This); !ok {
+		return false
+	}
+
+	directlyRelatedTypes, err := typesys.GetDirectlyRelatedUserTypes(objectType, relation)
+	if err != nil {
+		return false
+	}
+
+	for _, directlyRelatedType := range directlyRelatedTypes {
+		if directlyRelatedType.GetRelation() != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkUsersetsBatch evaluates whether the user of the request is in any of the usersets of the relation of
+// the objects of the object type with the provided IDs, where the relation is batchable (see
+// isBatchableUserset). The usersets are looked up with a single datastore query rather than with a Check
+// for each one of them.
+func (c *LocalChecker) checkUsersetsBatch(
+	typesys *typesystem.TypeSystem,
+	req *ResolveCheckRequest,
+	objectType, relation string,
+	objectIDs []string,
+	datastoreQueryCount uint32,
+) CheckHandlerFunc {
+	return func(ctx context.Context) (response *ResolveCheckResponse, err error) {
+		tk := req.GetTupleKey()
+
+		ctx, span := startEdgeSpan(ctx, "checkUsersetsBatch", DirectEdge, tk,
+			attribute.String("userset", tuple.ToObjectRelationString(objectType, relation)),
+			attribute.Int("batch_size", len(objectIDs)))
+		var tuplesRead int
+		defer func() { endEdgeSpan(span, response, tuplesRead, err) }()
+
+		response = &ResolveCheckResponse{
+			Allowed: false,
+			ResolutionMetadata: &ResolutionMetadata{
+				DatastoreQueryCount: datastoreQueryCount + 1,
+			},
+		}
+
+		userObject, userRelation := tuple.SplitObjectRelation(tk.GetUser())
+		userFilter := []*openfgav1.ObjectRelation{{Object: userObject, Relation: userRelation}}
+
+		if userRelation == "" && !tuple.IsTypedWildcard(userObject) {
+			userType := tuple.GetType(userObject)
+			if publiclyAssignable, _ := typesys.IsPubliclyAssignable(typesystem.DirectRelationReference(objectType, relation), userType); publiclyAssignable {
+				userFilter = append(userFilter, &openfgav1.ObjectRelation{Object: tuple.BuildObject(userType, tuple.Wildcard)})
+			}
+		}
+
+		iter, err := c.ds.ReadStartingWithUser(ctx, req.GetStoreID(), storage.ReadStartingWithUserFilter{
+			ObjectType: objectType,
+			Relation:   relation,
+			UserFilter: userFilter,
+			ObjectIDs:  objectIDs,
+		}, storage.ReadOptionsFromContext(ctx))
+		if err != nil {
+			return response, err
+		}
+
+		// filter out invalid tuples yielded by the database iterator
+		filteredIter := storage.NewFilteredTupleKeyIterator(
+			storage.NewTupleKeyIteratorFromTupleIterator(iter),
+			validation.FilterInvalidTuples(typesys),
+		)
+		defer filteredIter.Stop()
+
+		_, err = filteredIter.Next()
+		if err != nil {
+			if errors.Is(err, storage.ErrIteratorDone) {
+				return response, nil
+			}
+
+			return response, err
+		}
+
+		tuplesRead++
+		response.Allowed = true
+		return response, nil
+	}
+}
+
 // checkComputedUserset evaluates the Check request with the rewritten relation (e.g. the computed userset relation).
-func (c *LocalChecker) checkComputedUserset(_ context.Context, req *ResolveCheckRequest, rewrite *openfgav1.Userset_ComputedUserset) CheckHandlerFunc {
-	return func(ctx context.Context) (*ResolveCheckResponse, error) {
-		ctx, span := tracer.Start(ctx, "checkComputedUserset")
-		defer span.End()
+//
+// If the typesystem was constructed with a computed relation closure, then a chain of purely computed
+// usersets is resolved in a single hop to the last relation in the chain.
+func (c *LocalChecker) checkComputedUserset(parentctx context.Context, req *ResolveCheckRequest, rewrite *openfgav1.Userset_ComputedUserset) CheckHandlerFunc {
+	return func(ctx context.Context) (resp *ResolveCheckResponse, err error) {
+		ctx, span := startEdgeSpan(ctx, "checkComputedUserset", ComputedUsersetEdge, req.GetTupleKey(),
+			attribute.String("computed_relation", rewrite.ComputedUserset.GetRelation()))
+		defer func() { endEdgeSpan(span, resp, 0, err) }()
+
+		relation := rewrite.ComputedUserset.GetRelation()
+		if typesys, ok := typesystem.TypesystemFromContext(parentctx); ok {
+			relation = typesys.ResolveComputedRelation(tuple.GetType(req.TupleKey.GetObject()), relation)
+		}
 
 		rewrittenTupleKey := tuple.NewTupleKey(
 			req.TupleKey.GetObject(),
-			rewrite.ComputedUserset.GetRelation(),
+			relation,
 			req.TupleKey.GetUser(),
 		)
 
@@ -663,6 +907,7 @@ func (c *LocalChecker) checkComputedUserset(_ context.Context, req *ResolveCheck
 				AuthorizationModelID: req.GetAuthorizationModelID(),
 				TupleKey:             rewrittenTupleKey,
 				ContextualTuples:     req.GetContextualTuples(),
+				Context:              req.GetContext(),
 				ResolutionMetadata: &ResolutionMetadata{
 					Depth:               req.GetResolutionMetadata().Depth - 1,
 					DatastoreQueryCount: req.GetResolutionMetadata().DatastoreQueryCount,
@@ -675,27 +920,28 @@ func (c *LocalChecker) checkComputedUserset(_ context.Context, req *ResolveCheck
 // checkTTU looks up all tuples of the target tupleset relation on the provided object and for each one
 // of them evaluates the computed userset of the TTU rewrite rule for them.
 func (c *LocalChecker) checkTTU(parentctx context.Context, req *ResolveCheckRequest, rewrite *openfgav1.Userset) CheckHandlerFunc {
-	return func(ctx context.Context) (*ResolveCheckResponse, error) {
+	return func(ctx context.Context) (response *ResolveCheckResponse, err error) {
 		typesys, ok := typesystem.TypesystemFromContext(parentctx) // note: use of 'parentctx' not 'ctx' - this is important
 		if !ok {
 			return nil, fmt.Errorf("typesystem missing in context")
 		}
 
-		ctx, span := tracer.Start(ctx, "checkTTU")
-		defer span.End()
-
-		ctx = typesystem.ContextWithTypesystem(ctx, typesys)
-
 		tuplesetRelation := rewrite.GetTupleToUserset().GetTupleset().GetRelation()
 		computedRelation := rewrite.GetTupleToUserset().GetComputedUserset().GetRelation()
 
 		tk := req.GetTupleKey()
 		object := tk.GetObject()
 
-		span.SetAttributes(attribute.String("tupleset_relation", fmt.Sprintf("%s#%s", tuple.GetType(object), tuplesetRelation)))
-		span.SetAttributes(attribute.String("computed_relation", computedRelation))
+		ctx, span := startEdgeSpan(ctx, "checkTTU", TupleToUsersetEdge, tk,
+			attribute.String("tupleset_relation", fmt.Sprintf("%s#%s", tuple.GetType(object), tuplesetRelation)),
+			attribute.String("computed_relation", computedRelation),
+		)
+		var tuplesRead int
+		defer func() { endEdgeSpan(span, response, tuplesRead, err) }()
+
+		ctx = typesystem.ContextWithTypesystem(ctx, typesys)
 
-		response := &ResolveCheckResponse{
+		response = &ResolveCheckResponse{
 			Allowed: false,
 			ResolutionMetadata: &ResolutionMetadata{
 				DatastoreQueryCount: req.GetResolutionMetadata().DatastoreQueryCount + 1,
@@ -705,6 +951,7 @@ func (c *LocalChecker) checkTTU(parentctx context.Context, req *ResolveCheckRequ
 			ctx,
 			req.GetStoreID(),
 			tuple.NewTupleKey(object, tuplesetRelation, ""),
+			storage.ReadOptionsFromContext(ctx),
 		)
 		if err != nil {
 			return response, err
@@ -729,6 +976,8 @@ func (c *LocalChecker) checkTTU(parentctx context.Context, req *ResolveCheckRequ
 				return response, err
 			}
 
+			tuplesRead++
+
 			userObj, _ := tuple.SplitObjectRelation(t.GetUser())
 
 			tupleKey := &openfgav1.TupleKey{
@@ -754,6 +1003,7 @@ func (c *LocalChecker) checkTTU(parentctx context.Context, req *ResolveCheckRequ
 					AuthorizationModelID: req.GetAuthorizationModelID(),
 					TupleKey:             tupleKey,
 					ContextualTuples:     req.GetContextualTuples(),
+					Context:              req.GetContext(),
 					ResolutionMetadata: &ResolutionMetadata{
 						Depth:               req.GetResolutionMetadata().Depth - 1,
 						DatastoreQueryCount: req.GetResolutionMetadata().DatastoreQueryCount, // add TTU read below
@@ -797,6 +1047,13 @@ func (c *LocalChecker) checkSetOperation(
 
 		if setOpType == intersectionSetOperator {
 			reducerKey = "intersection"
+
+			// the operands are evaluated one at a time, so the cheapest ones go first
+			if typesys, ok := typesystem.TypesystemFromContext(ctx); ok {
+				tk := req.GetTupleKey()
+				fanOut := storeFanOut(ctx, c.statsProvider, req.GetStoreID())
+				children = orderByCost(typesys, fanOut, tuple.GetType(tk.GetObject()), tk.GetRelation(), children)
+			}
 		}
 
 		if setOpType == exclusionSetOperator {
@@ -811,8 +1068,8 @@ func (c *LocalChecker) checkSetOperation(
 	}
 
 	return func(ctx context.Context) (*ResolveCheckResponse, error) {
-		ctx, span := tracer.Start(ctx, reducerKey)
-		defer span.End()
+		ctx, span := startResolutionSpan(ctx, reducerKey)
+		defer span.end()
 
 		return reducer(ctx, c.concurrencyLimit, handlers...)
 	}
@@ -3,16 +3,22 @@ package graph
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
 
 	parser "github.com/craigpastro/openfga-dsl-parser/v2"
 	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/storage/memory"
 	"github.com/openfga/openfga/pkg/storage/storagewrappers"
 	"github.com/openfga/openfga/pkg/tuple"
 	"github.com/openfga/openfga/pkg/typesystem"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestResolveCheckDeterministic(t *testing.T) {
@@ -418,3 +424,354 @@ func TestCheckWithUnexpectedCycle(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckEdgeSpans(t *testing.T) {
+	spanRecorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+
+	ds := memory.New()
+	defer ds.Close()
+
+	storeID := ulid.Make().String()
+
+	err := ds.Write(context.Background(), storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "parent", "folder:x"),
+		tuple.NewTupleKey("document:1", "parent", "folder:y"),
+		tuple.NewTupleKey("folder:y", "viewer", "user:jon"),
+	})
+	require.NoError(t, err)
+
+	checker := NewLocalChecker(ds)
+
+	typedefs := parser.MustParse(`
+	type user
+
+	type folder
+	  relations
+	    define viewer: [user] as self
+
+	type document
+	  relations
+	    define parent: [folder] as self
+	    define viewer as can_view
+	    define can_view as viewer from parent
+	`)
+
+	ctx := typesystem.ContextWithTypesystem(context.Background(), typesystem.New(
+		&openfgav1.AuthorizationModel{
+			Id:              ulid.Make().String(),
+			TypeDefinitions: typedefs,
+			SchemaVersion:   typesystem.SchemaVersion1_1,
+		},
+	))
+
+	resp, err := checker.ResolveCheck(ctx, &ResolveCheckRequest{
+		StoreID:            storeID,
+		TupleKey:           tuple.NewTupleKey("document:1", "viewer", "user:jon"),
+		ResolutionMetadata: &ResolutionMetadata{Depth: 25},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Allowed)
+
+	edgeSpans := map[string]map[attribute.Key]attribute.Value{}
+	for _, span := range spanRecorder.Ended() {
+		attrs := map[attribute.Key]attribute.Value{}
+		for _, attr := range span.Attributes() {
+			attrs[attr.Key] = attr.Value
+		}
+
+		if _, ok := attrs["edge_type"]; ok {
+			edgeSpans[span.Name()+" "+attrs["object_relation"].AsString()] = attrs
+		}
+	}
+
+	computed, ok := edgeSpans["checkComputedUserset document:1#viewer"]
+	require.True(t, ok)
+	require.Equal(t, ComputedUsersetEdge.String(), computed["edge_type"].AsString())
+	require.True(t, computed["allowed"].AsBool())
+
+	ttu, ok := edgeSpans["checkTTU document:1#can_view"]
+	require.True(t, ok)
+	require.Equal(t, TupleToUsersetEdge.String(), ttu["edge_type"].AsString())
+	require.Equal(t, int64(2), ttu["tuples_read"].AsInt64())
+	require.True(t, ttu["allowed"].AsBool())
+
+	direct, ok := edgeSpans["checkDirectUserTuple folder:y#viewer"]
+	require.True(t, ok)
+	require.Equal(t, DirectEdge.String(), direct["edge_type"].AsString())
+	require.Equal(t, int64(1), direct["tuples_read"].AsInt64())
+	require.True(t, direct["allowed"].AsBool())
+}
+
+func BenchmarkCheckIntersection(b *testing.B) {
+	ds := memory.New()
+	b.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+
+	var tuples []*openfgav1.TupleKey
+	for i := 0; i < 100; i++ {
+		tuples = append(tuples,
+			tuple.NewTupleKey("group:eng", "member", fmt.Sprintf("user:%d", i)),
+			tuple.NewTupleKey("folder:1", "viewer", fmt.Sprintf("user:%d", i)),
+			tuple.NewTupleKey("document:1", "viewer", fmt.Sprintf("user:%d", i)),
+		)
+	}
+	tuples = append(tuples,
+		tuple.NewTupleKey("document:1", "member", "group:eng#member"),
+		tuple.NewTupleKey("document:1", "parent", "folder:1"),
+		tuple.NewTupleKey("document:1", "allowed", "user:0"),
+	)
+	require.NoError(b, ds.Write(context.Background(), storeID, nil, tuples))
+
+	typesys := typesystem.New(&openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type group
+		  relations
+		    define member: [user] as self
+
+		type folder
+		  relations
+		    define viewer: [user] as self
+
+		type document
+		  relations
+		    define allowed: [user] as self
+		    define parent: [folder] as self
+		    define member: [group#member] as self
+		    define viewer: [user] as self and allowed
+		    define group_viewer as member and allowed
+		    define inherited_viewer as viewer from parent and allowed
+		`),
+	})
+
+	ctx := typesystem.ContextWithTypesystem(context.Background(), typesys)
+	checker := NewLocalChecker(ds)
+
+	for _, relation := range []string{"viewer", "group_viewer", "inherited_viewer"} {
+		for _, user := range []string{"user:0", "user:1"} {
+			b.Run(fmt.Sprintf("%s_%s", relation, user), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					_, err := checker.ResolveCheck(ctx, &ResolveCheckRequest{
+						StoreID:              storeID,
+						AuthorizationModelID: typesys.GetAuthorizationModelID(),
+						TupleKey:             tuple.NewTupleKey("document:1", relation, user),
+						ResolutionMetadata:   &ResolutionMetadata{Depth: 25},
+					})
+					require.NoError(b, err)
+				}
+			})
+		}
+	}
+}
+
+func TestExclusionShortCircuits(t *testing.T) {
+	resolved := func(allowed bool, err error) CheckHandlerFunc {
+		return func(ctx context.Context) (*ResolveCheckResponse, error) {
+			if err != nil {
+				return nil, err
+			}
+			return &ResolveCheckResponse{
+				Allowed:            allowed,
+				ResolutionMetadata: &ResolutionMetadata{DatastoreQueryCount: 1},
+			}, nil
+		}
+	}
+
+	// blocked only resolves once it's cancelled, so the reducer can only return if it cancels it (or if it
+	// doesn't start it at all)
+	blocked := func(ctx context.Context) (*ResolveCheckResponse, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	t.Run("falsey_base_cancels_sub", func(t *testing.T) {
+		resp, err := exclusion(context.Background(), 2, resolved(false, nil), blocked)
+		require.NoError(t, err)
+		require.False(t, resp.GetAllowed())
+		require.Equal(t, uint32(1), resp.GetResolutionMetadata().DatastoreQueryCount)
+	})
+
+	t.Run("allowed_sub_cancels_base", func(t *testing.T) {
+		resp, err := exclusion(context.Background(), 2, blocked, resolved(true, nil))
+		require.NoError(t, err)
+		require.False(t, resp.GetAllowed())
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		resp, err := exclusion(context.Background(), 1, resolved(true, nil), resolved(false, nil))
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+		require.Equal(t, uint32(2), resp.GetResolutionMetadata().DatastoreQueryCount)
+	})
+
+	t.Run("base_error_superseded_by_allowed_sub", func(t *testing.T) {
+		resp, err := exclusion(context.Background(), 2, resolved(false, fmt.Errorf("boom")), resolved(true, nil))
+		require.NoError(t, err)
+		require.False(t, resp.GetAllowed())
+	})
+
+	t.Run("sub_error_superseded_by_falsey_base", func(t *testing.T) {
+		resp, err := exclusion(context.Background(), 2, resolved(false, nil), resolved(false, fmt.Errorf("boom")))
+		require.NoError(t, err)
+		require.False(t, resp.GetAllowed())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := exclusion(context.Background(), 2, resolved(true, nil), resolved(false, fmt.Errorf("boom")))
+		require.EqualError(t, err, "boom")
+	})
+}
+
+// countingTupleReader counts the queries made to the wrapped RelationshipTupleReader.
+type countingTupleReader struct {
+	storage.RelationshipTupleReader
+
+	mu      sync.Mutex
+	queries map[string]int
+}
+
+func (c *countingTupleReader) count(query string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queries[query]++
+}
+
+func (c *countingTupleReader) ReadUserTuple(ctx context.Context, store string, tk *openfgav1.TupleKey, options storage.ReadOptions) (*openfgav1.Tuple, error) {
+	c.count("ReadUserTuple")
+	return c.RelationshipTupleReader.ReadUserTuple(ctx, store, tk, options)
+}
+
+func (c *countingTupleReader) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter, options storage.ReadOptions) (storage.TupleIterator, error) {
+	c.count("ReadUsersetTuples")
+	return c.RelationshipTupleReader.ReadUsersetTuples(ctx, store, filter, options)
+}
+
+func (c *countingTupleReader) ReadStartingWithUser(ctx context.Context, store string, filter storage.ReadStartingWithUserFilter, options storage.ReadOptions) (storage.TupleIterator, error) {
+	c.count("ReadStartingWithUser")
+	return c.RelationshipTupleReader.ReadStartingWithUser(ctx, store, filter, options)
+}
+
+func TestCheckUsersetsBatch(t *testing.T) {
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+
+	var tuples []*openfgav1.TupleKey
+	for i := 0; i < 50; i++ {
+		tuples = append(tuples,
+			tuple.NewTupleKey("document:1", "viewer", fmt.Sprintf("team:%d#member", i)),
+			tuple.NewTupleKey("document:1", "editor", fmt.Sprintf("group:%d#member", i)),
+		)
+	}
+	tuples = append(tuples,
+		tuple.NewTupleKey("team:37", "member", "user:anne"),
+		tuple.NewTupleKey("group:37", "member", "user:anne"),
+		tuple.NewTupleKey("document:2", "viewer", "team:99#member"),
+		tuple.NewTupleKey("team:99", "member", "user:*"),
+	)
+	require.NoError(t, ds.Write(context.Background(), storeID, nil, tuples))
+
+	typesys := typesystem.New(&openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type team
+		  relations
+		    define member: [user, user:*] as self
+
+		type group
+		  relations
+		    define member: [user, group#member] as self
+
+		type document
+		  relations
+		    define viewer: [team#member] as self
+		    define editor: [group#member] as self
+		`),
+	})
+	ctx := typesystem.ContextWithTypesystem(context.Background(), typesys)
+
+	tests := []struct {
+		name            string
+		tupleKey        *openfgav1.TupleKey
+		batchSize       uint32
+		allowed         bool
+		expectedQueries map[string]int
+	}{
+		{
+			name:      "batched",
+			tupleKey:  tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+			batchSize: 100,
+			allowed:   true,
+			expectedQueries: map[string]int{
+				"ReadUsersetTuples":    1,
+				"ReadStartingWithUser": 1,
+			},
+		},
+		{
+			name:      "batched_through_wildcard",
+			tupleKey:  tuple.NewTupleKey("document:2", "viewer", "user:bob"),
+			batchSize: 100,
+			allowed:   true,
+			expectedQueries: map[string]int{
+				"ReadUsersetTuples":    1,
+				"ReadStartingWithUser": 1,
+			},
+		},
+		{
+			name:      "batched_in_chunks",
+			tupleKey:  tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+			batchSize: 10,
+			allowed:   false,
+			expectedQueries: map[string]int{
+				"ReadUsersetTuples":    1,
+				"ReadStartingWithUser": 5,
+			},
+		},
+		{
+			name:      "batching_disabled",
+			tupleKey:  tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+			batchSize: 0,
+			allowed:   false,
+			expectedQueries: map[string]int{
+				"ReadUsersetTuples": 51,
+				"ReadUserTuple":     50,
+			},
+		},
+		{
+			name:      "nested_usersets_are_not_batched",
+			tupleKey:  tuple.NewTupleKey("document:1", "editor", "user:bob"),
+			batchSize: 100,
+			allowed:   false,
+			expectedQueries: map[string]int{
+				"ReadUsersetTuples": 51,
+				"ReadUserTuple":     50,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reader := &countingTupleReader{RelationshipTupleReader: ds, queries: map[string]int{}}
+			checker := NewLocalChecker(reader, WithUsersetBatchSize(test.batchSize))
+
+			resp, err := checker.ResolveCheck(ctx, &ResolveCheckRequest{
+				StoreID:              storeID,
+				AuthorizationModelID: typesys.GetAuthorizationModelID(),
+				TupleKey:             test.tupleKey,
+				ResolutionMetadata:   &ResolutionMetadata{Depth: 25},
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.allowed, resp.GetAllowed())
+			require.Equal(t, test.expectedQueries, reader.queries)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package graph
+
+import (
+	"context"
+	"sort"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// The relative costs of resolving the edges of the relationship graph. They're used to evaluate the operands
+// of intersections from the cheapest to the most expensive.
+const (
+	// directEdgeCost is the cost of a single tuple lookup.
+	directEdgeCost = 1
+
+	// usersetEdgeCost is the cost of a tuple lookup followed by the evaluation of the usersets related to
+	// the object, e.g. 'define viewer: [group#member]'.
+	usersetEdgeCost = 3
+
+	// tupleToUsersetEdgeCost is the cost of reading the tupleset of the object, before evaluating the computed
+	// relation on every related object, e.g. 'define viewer: viewer from parent'.
+	tupleToUsersetEdgeCost = 4
+
+	// unknownRewriteCost is the cost assumed for the relations more than maxRewriteCostDepth relations away.
+	unknownRewriteCost = 10
+
+	maxRewriteCostDepth = 3
+
+	// maxFanOut bounds the fan-out estimated from the statistics of a store.
+	maxFanOut = 100
+)
+
+// fanOutFunc estimates the number of tuples of the relation of each object of the type, e.g. the number of
+// usersets to evaluate for 'define viewer: [group#member]' or the number of parents of a tupleset.
+type fanOutFunc func(objectType, relation string) int
+
+// noFanOut is the fan-out assumed when the statistics of the store aren't available.
+func noFanOut(string, string) int {
+	return 1
+}
+
+// storeFanOut returns the fan-out of the relations of the store estimated from the statistics of its tuples,
+// which are read at most once per relation.
+func storeFanOut(ctx context.Context, stats storage.StatsProvider, store string) fanOutFunc {
+	if stats == nil {
+		return noFanOut
+	}
+
+	estimates := map[string]int{}
+	return func(objectType, relation string) int {
+		key := tuple.ToObjectRelationString(objectType, relation)
+		if fanOut, ok := estimates[key]; ok {
+			return fanOut
+		}
+
+		fanOut := 1
+		if relationStats, err := stats.RelationStats(ctx, store, objectType, relation); err == nil && relationStats.ObjectCount > 0 {
+			// the average, rounded up
+			fanOut = int(min((relationStats.TupleCount+relationStats.ObjectCount-1)/relationStats.ObjectCount, maxFanOut))
+		}
+
+		estimates[key] = fanOut
+		return fanOut
+	}
+}
+
+// orderByCost sorts the operands of a rewrite of the relation of the object type from the cheapest to the
+// most expensive to evaluate. Operands that cost the same keep the order they're defined in.
+func orderByCost(typesys *typesystem.TypeSystem, fanOut fanOutFunc, objectType, relation string, operands []*openfgav1.Userset) []*openfgav1.Userset {
+	costs := make(map[*openfgav1.Userset]int, len(operands))
+	for _, operand := range operands {
+		costs[operand] = rewriteCost(typesys, fanOut, objectType, relation, operand, 0)
+	}
+
+	ordered := append([]*openfgav1.Userset(nil), operands...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return costs[ordered[i]] < costs[ordered[j]]
+	})
+
+	return ordered
+}
+
+// rewriteCost estimates the cost of evaluating the rewrite of the relation of the object type, following
+// the relations it references at most maxRewriteCostDepth relations away. The costs of the edges that
+// evaluate several usersets or related objects are multiplied by their fan-out.
+func rewriteCost(typesys *typesystem.TypeSystem, fanOut fanOutFunc, objectType, relation string, rewrite *openfgav1.Userset, depth int) int {
+	if depth > maxRewriteCostDepth {
+		return unknownRewriteCost
+	}
+
+	switch rw := rewrite.GetUserset().(type) {
+	case *openfgav1.Userset_This:
+		directlyRelatedTypes, err := typesys.GetDirectlyRelatedUserTypes(objectType, relation)
+		if err != nil {
+			return unknownRewriteCost
+		}
+
+		for _, directlyRelatedType := range directlyRelatedTypes {
+			if directlyRelatedType.GetRelation() != "" {
+				return usersetEdgeCost * fanOut(objectType, relation)
+			}
+		}
+
+		return directEdgeCost
+	case *openfgav1.Userset_ComputedUserset:
+		computedRelation := rw.ComputedUserset.GetRelation()
+
+		r, err := typesys.GetRelation(objectType, computedRelation)
+		if err != nil {
+			return unknownRewriteCost
+		}
+
+		return relationCost(typesys, fanOut, objectType, r, depth+1)
+	case *openfgav1.Userset_TupleToUserset:
+		computedRelation := rw.TupleToUserset.GetComputedUserset().GetRelation()
+		tuplesetRelation := rw.TupleToUserset.GetTupleset().GetRelation()
+
+		tuplesetTypes, err := typesys.GetDirectlyRelatedUserTypes(objectType, tuplesetRelation)
+		if err != nil {
+			return unknownRewriteCost
+		}
+
+		// the computed relation is evaluated on objects of any of the types of the tupleset, so the most
+		// expensive one is assumed
+		var computedCost int
+		for _, tuplesetType := range tuplesetTypes {
+			r, err := typesys.GetRelation(tuplesetType.GetType(), computedRelation)
+			if err != nil {
+				continue
+			}
+
+			computedCost = max(computedCost, relationCost(typesys, fanOut, tuplesetType.GetType(), r, depth+1))
+		}
+
+		return tupleToUsersetEdgeCost + fanOut(objectType, tuplesetRelation)*computedCost
+	case *openfgav1.Userset_Union:
+		return operandsCost(typesys, fanOut, objectType, relation, rw.Union.GetChild(), depth)
+	case *openfgav1.Userset_Intersection:
+		return operandsCost(typesys, fanOut, objectType, relation, rw.Intersection.GetChild(), depth)
+	case *openfgav1.Userset_Difference:
+		return operandsCost(typesys, fanOut, objectType, relation, []*openfgav1.Userset{rw.Difference.GetBase(), rw.Difference.GetSubtract()}, depth)
+	default:
+		return unknownRewriteCost
+	}
+}
+
+func relationCost(typesys *typesystem.TypeSystem, fanOut fanOutFunc, objectType string, relation *openfgav1.Relation, depth int) int {
+	return rewriteCost(typesys, fanOut, objectType, relation.GetName(), relation.GetRewrite(), depth)
+}
+
+func operandsCost(typesys *typesystem.TypeSystem, fanOut fanOutFunc, objectType, relation string, operands []*openfgav1.Userset, depth int) int {
+	var cost int
+	for _, operand := range operands {
+		cost += rewriteCost(typesys, fanOut, objectType, relation, operand, depth)
+	}
+
+	return cost
+}
@@ -0,0 +1,173 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderByCost(t *testing.T) {
+	typesys := typesystem.New(&openfgav1.AuthorizationModel{
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type group
+		  relations
+		    define member: [user, group#member] as self
+
+		type folder
+		  relations
+		    define viewer: [user, group#member] as self
+
+		type document
+		  relations
+		    define parent: [folder] as self
+		    define allowed: [user] as self
+		    define member: [group#member] as self
+		    define inherited as viewer from parent
+		    define viewer as inherited and allowed
+		    define editor as member and allowed
+		    define reader as allowed and member
+		    define mixed as member and inherited
+		`),
+	})
+
+	rewrite := func(objectType, relation string) []*openfgav1.Userset {
+		r, err := typesys.GetRelation(objectType, relation)
+		require.NoError(t, err)
+
+		return r.GetRewrite().GetIntersection().GetChild()
+	}
+
+	relations := func(operands []*openfgav1.Userset) []string {
+		var names []string
+		for _, operand := range operands {
+			names = append(names, operand.GetComputedUserset().GetRelation())
+		}
+		return names
+	}
+
+	t.Run("cheapest_first", func(t *testing.T) {
+		ordered := orderByCost(typesys, noFanOut, "document", "viewer", rewrite("document", "viewer"))
+		require.Equal(t, []string{"allowed", "inherited"}, relations(ordered))
+
+		ordered = orderByCost(typesys, noFanOut, "document", "editor", rewrite("document", "editor"))
+		require.Equal(t, []string{"allowed", "member"}, relations(ordered))
+	})
+
+	t.Run("already_ordered", func(t *testing.T) {
+		ordered := orderByCost(typesys, noFanOut, "document", "reader", rewrite("document", "reader"))
+		require.Equal(t, []string{"allowed", "member"}, relations(ordered))
+	})
+
+	t.Run("fan_out", func(t *testing.T) {
+		ordered := orderByCost(typesys, noFanOut, "document", "mixed", rewrite("document", "mixed"))
+		require.Equal(t, []string{"member", "inherited"}, relations(ordered))
+
+		// every document has many members, so evaluating them costs more than following its parent
+		fanOut := func(objectType, relation string) int {
+			if objectType == "document" && relation == "member" {
+				return 5
+			}
+			return 1
+		}
+
+		ordered = orderByCost(typesys, fanOut, "document", "mixed", rewrite("document", "mixed"))
+		require.Equal(t, []string{"inherited", "member"}, relations(ordered))
+	})
+
+	t.Run("costs", func(t *testing.T) {
+		cost := func(relation string) int {
+			r, err := typesys.GetRelation("document", relation)
+			require.NoError(t, err)
+			return relationCost(typesys, noFanOut, "document", r, 0)
+		}
+
+		require.Equal(t, directEdgeCost, cost("allowed"))
+		require.Equal(t, usersetEdgeCost, cost("member"))
+		require.Equal(t, tupleToUsersetEdgeCost+usersetEdgeCost, cost("inherited"))
+	})
+}
+
+func TestStoreFanOut(t *testing.T) {
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "member", "group:a#member"),
+		tuple.NewTupleKey("document:1", "member", "group:b#member"),
+		tuple.NewTupleKey("document:1", "member", "group:c#member"),
+		tuple.NewTupleKey("document:2", "member", "group:a#member"),
+		tuple.NewTupleKey("document:1", "parent", "folder:x"),
+	}))
+
+	fanOut := storeFanOut(ctx, ds.(storage.StatsProvider), storeID)
+	require.Equal(t, 2, fanOut("document", "member"))
+	require.Equal(t, 1, fanOut("document", "parent"))
+	require.Equal(t, 1, fanOut("document", "viewer"))
+
+	require.Equal(t, 1, storeFanOut(ctx, nil, storeID)("document", "member"))
+}
+
+func TestIntersectionShortCircuits(t *testing.T) {
+	var evaluated []int
+	handler := func(i int, allowed bool, err error) CheckHandlerFunc {
+		return func(ctx context.Context) (*ResolveCheckResponse, error) {
+			evaluated = append(evaluated, i)
+			if err != nil {
+				return nil, err
+			}
+			return &ResolveCheckResponse{
+				Allowed:            allowed,
+				ResolutionMetadata: &ResolutionMetadata{DatastoreQueryCount: 1},
+			}, nil
+		}
+	}
+
+	t.Run("stops_at_first_unsatisfied_operand", func(t *testing.T) {
+		evaluated = nil
+
+		resp, err := intersection(context.Background(), 1, handler(0, true, nil), handler(1, false, nil), handler(2, true, nil))
+		require.NoError(t, err)
+		require.False(t, resp.GetAllowed())
+		require.Equal(t, uint32(2), resp.GetResolutionMetadata().DatastoreQueryCount)
+		require.Equal(t, []int{0, 1}, evaluated)
+	})
+
+	t.Run("all_operands_satisfied", func(t *testing.T) {
+		evaluated = nil
+
+		resp, err := intersection(context.Background(), 1, handler(0, true, nil), handler(1, true, nil))
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+		require.Equal(t, []int{0, 1}, evaluated)
+	})
+
+	t.Run("error_is_superseded_by_an_unsatisfied_operand", func(t *testing.T) {
+		evaluated = nil
+
+		resp, err := intersection(context.Background(), 1, handler(0, false, errors.New("boom")), handler(1, false, nil))
+		require.NoError(t, err)
+		require.False(t, resp.GetAllowed())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		evaluated = nil
+
+		_, err := intersection(context.Background(), 1, handler(0, true, nil), handler(1, false, errors.New("boom")))
+		require.EqualError(t, err, "boom")
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
@@ -110,6 +111,11 @@ type RelationshipEdge struct {
 	// TODO this is leaking implementation details of ReverseExpand. This can be a boolean saying
 	// if `TargetReference` is intersection or exclusion.
 	Condition EdgeCondition
+
+	// PrunedBranches are the operands of the intersections and exclusions that were not followed to find this
+	// edge. They're only set by GetPrunedRelationshipEdges, and the results found through the edge must be
+	// checked against them.
+	PrunedBranches []*PrunedBranch
 }
 
 func (r RelationshipEdge) String() string {
@@ -123,6 +129,38 @@ func (r RelationshipEdge) String() string {
 	return strings.ReplaceAll(val, "  ", " ")
 }
 
+type PrunedBranchType int
+
+const (
+	// IntersectionBranch is an operand of an intersection ('and') other than the first one.
+	IntersectionBranch PrunedBranchType = iota
+	// ExclusionBranch is the subtracted operand of an exclusion ('but not').
+	ExclusionBranch
+)
+
+func (p PrunedBranchType) String() string {
+	switch p {
+	case IntersectionBranch:
+		return "intersection"
+	case ExclusionBranch:
+		return "exclusion"
+	default:
+		return "undefined"
+	}
+}
+
+// PrunedBranch is a sibling branch of a rewrite that GetPrunedRelationshipEdges doesn't follow because it
+// requires further evaluation, e.g. 'allowed' in 'define viewer: [user] and allowed'.
+type PrunedBranch struct {
+	Type PrunedBranchType
+
+	// The relation whose rewrite the branch belongs to, e.g. document#viewer
+	Relation *openfgav1.RelationReference
+
+	// The pruned rewrite, written in the DSL, e.g. 'allowed' or '(editor or viewer from parent)'
+	Rewrite string
+}
+
 // RelationshipGraph represents a graph of relationships and the connectivity between
 // object and relation references within the graph through direct or indirect relationships.
 type RelationshipGraph struct {
@@ -162,6 +200,132 @@ func (g *RelationshipGraph) GetPrunedRelationshipEdges(target *openfgav1.Relatio
 	return g.getRelationshipEdges(target, source, map[string]struct{}{}, resolveAnyEdge)
 }
 
+// GetReachableTypes returns every user type (e.g. 'user'), typed wildcard (e.g. 'user:*') and userset
+// (e.g. 'group#member') from which the target relation can be reached, i.e. every kind of user that can
+// possibly satisfy it, as found by GetRelationshipEdges. The references are sorted by type, with the type
+// first, then its wildcard and then its usersets sorted by relation.
+func (g *RelationshipGraph) GetReachableTypes(target *openfgav1.RelationReference) ([]*openfgav1.RelationReference, error) {
+	if _, err := g.typesystem.GetRelation(target.GetType(), target.GetRelation()); err != nil {
+		return nil, err
+	}
+
+	var reachable []*openfgav1.RelationReference
+	for _, typeDefinition := range g.typesystem.GetAllTypeDefinitions() {
+		objectType := typeDefinition.GetType()
+
+		relations := make([]string, 0, len(typeDefinition.GetRelations()))
+		for relation := range typeDefinition.GetRelations() {
+			relations = append(relations, relation)
+		}
+		sort.Strings(relations)
+
+		sources := []*openfgav1.RelationReference{
+			typesystem.DirectRelationReference(objectType, ""),
+			typesystem.WildcardRelationReference(objectType),
+		}
+		for _, relation := range relations {
+			sources = append(sources, typesystem.DirectRelationReference(objectType, relation))
+		}
+
+		for _, source := range sources {
+			edges, err := g.GetRelationshipEdges(target, source)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(edges) > 0 {
+				reachable = append(reachable, source)
+			}
+		}
+	}
+
+	return reachable, nil
+}
+
+// GetAffectedRelations returns every relation whose Check results a tuple of the written relation (e.g.
+// 'document#parent') can change: the written relation itself, the relations of the same type that use it as
+// the tupleset of a tuple to userset rewrite (e.g. 'define viewer: viewer from parent'), and every relation
+// from which one of these can be reached, as found by GetRelationshipEdges. The references are sorted by type
+// and relation.
+func (g *RelationshipGraph) GetAffectedRelations(written *openfgav1.RelationReference) ([]*openfgav1.RelationReference, error) {
+	seeds := []*openfgav1.RelationReference{
+		typesystem.DirectRelationReference(written.GetType(), written.GetRelation()),
+	}
+
+	if relations, err := g.typesystem.GetRelations(written.GetType()); err == nil {
+		for name, relation := range relations {
+			if name != written.GetRelation() && rewriteUsesTupleset(relation.GetRewrite(), written.GetRelation()) {
+				seeds = append(seeds, typesystem.DirectRelationReference(written.GetType(), name))
+			}
+		}
+	}
+
+	// the written relation is affected even if it isn't a relation of the model, e.g. a relation of an older one
+	affected := []*openfgav1.RelationReference{seeds[0]}
+	for _, typeDefinition := range g.typesystem.GetAllTypeDefinitions() {
+		objectType := typeDefinition.GetType()
+
+		for relation := range typeDefinition.GetRelations() {
+			if objectType == written.GetType() && relation == written.GetRelation() {
+				continue
+			}
+
+			target := typesystem.DirectRelationReference(objectType, relation)
+
+			for _, seed := range seeds {
+				if seed.GetType() == objectType && seed.GetRelation() == relation {
+					affected = append(affected, target)
+					break
+				}
+
+				edges, err := g.GetRelationshipEdges(target, seed)
+				if err != nil {
+					return nil, err
+				}
+
+				if len(edges) > 0 {
+					affected = append(affected, target)
+					break
+				}
+			}
+		}
+	}
+
+	sort.Slice(affected, func(i, j int) bool {
+		if affected[i].GetType() != affected[j].GetType() {
+			return affected[i].GetType() < affected[j].GetType()
+		}
+		return affected[i].GetRelation() < affected[j].GetRelation()
+	})
+
+	return affected, nil
+}
+
+// rewriteUsesTupleset reports whether the rewrite has a tuple to userset rewrite on the tupleset relation.
+func rewriteUsesTupleset(rewrite *openfgav1.Userset, tupleset string) bool {
+	switch rw := rewrite.GetUserset().(type) {
+	case *openfgav1.Userset_TupleToUserset:
+		return rw.TupleToUserset.GetTupleset().GetRelation() == tupleset
+	case *openfgav1.Userset_Union:
+		for _, child := range rw.Union.GetChild() {
+			if rewriteUsesTupleset(child, tupleset) {
+				return true
+			}
+		}
+	case *openfgav1.Userset_Intersection:
+		for _, child := range rw.Intersection.GetChild() {
+			if rewriteUsesTupleset(child, tupleset) {
+				return true
+			}
+		}
+	case *openfgav1.Userset_Difference:
+		return rewriteUsesTupleset(rw.Difference.GetBase(), tupleset) ||
+			rewriteUsesTupleset(rw.Difference.GetSubtract(), tupleset)
+	}
+
+	return false
+}
+
 func (g *RelationshipGraph) getRelationshipEdges(
 	target *openfgav1.RelationReference,
 	source *openfgav1.RelationReference,
@@ -335,8 +499,18 @@ func (g *RelationshipGraph) getRelationshipEdgesWithTargetRewrite(
 				return nil, err
 			}
 
+			var pruned []*PrunedBranch
+			for _, sibling := range t.Intersection.GetChild()[1:] {
+				pruned = append(pruned, &PrunedBranch{
+					Type:     IntersectionBranch,
+					Relation: typesystem.DirectRelationReference(target.GetType(), target.GetRelation()),
+					Rewrite:  rewriteString(sibling),
+				})
+			}
+
 			for _, childresult := range childresults {
 				childresult.Condition = RequiresFurtherEvalCondition
+				childresult.PrunedBranches = append(childresult.PrunedBranches, pruned...)
 			}
 
 			return childresults, nil
@@ -372,8 +546,15 @@ func (g *RelationshipGraph) getRelationshipEdgesWithTargetRewrite(
 				return nil, err
 			}
 
+			pruned := &PrunedBranch{
+				Type:     ExclusionBranch,
+				Relation: typesystem.DirectRelationReference(target.GetType(), target.GetRelation()),
+				Rewrite:  rewriteString(t.Difference.GetSubtract()),
+			}
+
 			for _, childresult := range childresults {
 				childresult.Condition = RequiresFurtherEvalCondition
+				childresult.PrunedBranches = append(childresult.PrunedBranches, pruned)
 			}
 
 			return childresults, nil
@@ -407,3 +588,33 @@ func (g *RelationshipGraph) getRelationshipEdgesWithTargetRewrite(
 		panic("unexpected userset rewrite encountered")
 	}
 }
+
+// rewriteString writes a rewrite in the DSL. Nested operations are grouped in parentheses.
+func rewriteString(rewrite *openfgav1.Userset) string {
+	var operator string
+	var children []*openfgav1.Userset
+
+	switch rw := rewrite.GetUserset().(type) {
+	case *openfgav1.Userset_This:
+		return "self"
+	case *openfgav1.Userset_ComputedUserset:
+		return rw.ComputedUserset.GetRelation()
+	case *openfgav1.Userset_TupleToUserset:
+		return fmt.Sprintf("%s from %s", rw.TupleToUserset.GetComputedUserset().GetRelation(), rw.TupleToUserset.GetTupleset().GetRelation())
+	case *openfgav1.Userset_Union:
+		operator, children = "or", rw.Union.GetChild()
+	case *openfgav1.Userset_Intersection:
+		operator, children = "and", rw.Intersection.GetChild()
+	case *openfgav1.Userset_Difference:
+		operator, children = "but not", []*openfgav1.Userset{rw.Difference.GetBase(), rw.Difference.GetSubtract()}
+	default:
+		return ""
+	}
+
+	operands := make([]string, 0, len(children))
+	for _, child := range children {
+		operands = append(operands, rewriteString(child))
+	}
+
+	return fmt.Sprintf("(%s)", strings.Join(operands, fmt.Sprintf(" %s ", operator)))
+}
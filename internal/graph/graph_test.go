@@ -9,6 +9,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/tuple"
 	"github.com/openfga/openfga/pkg/typesystem"
 	"github.com/stretchr/testify/require"
 )
@@ -86,6 +87,12 @@ func TestRelationshipEdgeType_String(t *testing.T) {
 	require.Equal(t, "undefined", RelationshipEdgeType(4).String())
 }
 
+func TestPrunedBranchType_String(t *testing.T) {
+	require.Equal(t, "intersection", IntersectionBranch.String())
+	require.Equal(t, "exclusion", ExclusionBranch.String())
+	require.Equal(t, "undefined", PrunedBranchType(2).String())
+}
+
 func TestPrunedRelationshipEdges(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -111,6 +118,48 @@ func TestPrunedRelationshipEdges(t *testing.T) {
 					Type:            DirectEdge,
 					TargetReference: typesystem.DirectRelationReference("document", "viewer"),
 					Condition:       RequiresFurtherEvalCondition,
+					PrunedBranches: []*PrunedBranch{
+						{
+							Type:     IntersectionBranch,
+							Relation: typesystem.DirectRelationReference("document", "viewer"),
+							Rewrite:  "allowed",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "nested_intersection_and_exclusion",
+			model: `
+			type user
+
+			type document
+			  relations
+			    define allowed: [user] as self
+			    define blocked: [user] as self
+			    define editor: [user] as self
+			    define restricted: [user] as self and allowed
+			    define viewer as restricted but not (blocked or editor)
+			`,
+			target: typesystem.DirectRelationReference("document", "viewer"),
+			source: typesystem.DirectRelationReference("user", ""),
+			expected: []*RelationshipEdge{
+				{
+					Type:            DirectEdge,
+					TargetReference: typesystem.DirectRelationReference("document", "restricted"),
+					Condition:       RequiresFurtherEvalCondition,
+					PrunedBranches: []*PrunedBranch{
+						{
+							Type:     IntersectionBranch,
+							Relation: typesystem.DirectRelationReference("document", "restricted"),
+							Rewrite:  "allowed",
+						},
+						{
+							Type:     ExclusionBranch,
+							Relation: typesystem.DirectRelationReference("document", "viewer"),
+							Rewrite:  "(blocked or editor)",
+						},
+					},
 				},
 			},
 		},
@@ -136,6 +185,13 @@ func TestPrunedRelationshipEdges(t *testing.T) {
 					Type:            DirectEdge,
 					TargetReference: typesystem.DirectRelationReference("folder", "viewer"),
 					Condition:       RequiresFurtherEvalCondition,
+					PrunedBranches: []*PrunedBranch{
+						{
+							Type:     IntersectionBranch,
+							Relation: typesystem.DirectRelationReference("folder", "viewer"),
+							Rewrite:  "allowed",
+						},
+					},
 				},
 			},
 		},
@@ -193,6 +249,13 @@ func TestPrunedRelationshipEdges(t *testing.T) {
 					Type:            DirectEdge,
 					TargetReference: typesystem.DirectRelationReference("folder", "writer"),
 					Condition:       RequiresFurtherEvalCondition,
+					PrunedBranches: []*PrunedBranch{
+						{
+							Type:     ExclusionBranch,
+							Relation: typesystem.DirectRelationReference("folder", "viewer"),
+							Rewrite:  "editor",
+						},
+					},
 				},
 			},
 		},
@@ -1459,3 +1522,146 @@ func TestResolutionDepthContext(t *testing.T) {
 	require.False(t, ok)
 	require.Equal(t, uint32(0), depth)
 }
+
+func TestGetReachableTypes(t *testing.T) {
+	model := &openfgav1.AuthorizationModel{
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type employee
+
+		type group
+		  relations
+		    define member: [user, group#member] as self
+
+		type folder
+		  relations
+		    define viewer: [user:*, employee] as self
+
+		type document
+		  relations
+		    define parent: [folder] as self
+		    define allowed: [employee] as self
+		    define editor: [user, group#member] as self
+		    define viewer as editor or viewer from parent
+		    define restricted: [user] as self and allowed
+		    define unrelated: [employee] as self
+		`),
+	}
+
+	g := New(typesystem.New(model))
+
+	reachableTypes := func(t *testing.T, objectType, relation string) []string {
+		references, err := g.GetReachableTypes(typesystem.DirectRelationReference(objectType, relation))
+		require.NoError(t, err)
+
+		var types []string
+		for _, reference := range references {
+			switch {
+			case reference.GetWildcard() != nil:
+				types = append(types, reference.GetType()+":*")
+			case reference.GetRelation() != "":
+				types = append(types, reference.GetType()+"#"+reference.GetRelation())
+			default:
+				types = append(types, reference.GetType())
+			}
+		}
+
+		return types
+	}
+
+	require.Equal(t, []string{"group#member", "user"}, reachableTypes(t, "group", "member"))
+	require.Equal(t, []string{"document#editor", "employee", "folder#viewer", "group#member", "user", "user:*"}, reachableTypes(t, "document", "viewer"))
+	require.Equal(t, []string{"document#allowed", "employee", "user"}, reachableTypes(t, "document", "restricted"))
+	require.Equal(t, []string{"employee"}, reachableTypes(t, "document", "unrelated"))
+
+	_, err := g.GetReachableTypes(typesystem.DirectRelationReference("document", "undefined"))
+	require.ErrorIs(t, err, typesystem.ErrRelationUndefined)
+}
+
+func TestGetAffectedRelations(t *testing.T) {
+	model := &openfgav1.AuthorizationModel{
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type group
+		  relations
+		    define member: [user, group#member] as self
+
+		type folder
+		  relations
+		    define viewer: [user, group#member] as self
+
+		type document
+		  relations
+		    define parent: [folder] as self
+		    define allowed: [user] as self
+		    define editor: [user] as self
+		    define viewer as editor or viewer from parent
+		    define restricted: [user] as self and allowed
+		    define unrelated: [user] as self
+		`),
+	}
+
+	g := New(typesystem.New(model))
+
+	tests := []struct {
+		name     string
+		written  string
+		expected []string
+	}{
+		{
+			name:     "computed_userset",
+			written:  "document#editor",
+			expected: []string{"document#editor", "document#viewer"},
+		},
+		{
+			name:     "tupleset",
+			written:  "document#parent",
+			expected: []string{"document#parent", "document#viewer"},
+		},
+		{
+			name:     "tuple_to_userset",
+			written:  "folder#viewer",
+			expected: []string{"document#viewer", "folder#viewer"},
+		},
+		{
+			name:     "userset",
+			written:  "group#member",
+			expected: []string{"document#viewer", "folder#viewer", "group#member"},
+		},
+		{
+			name:     "intersection",
+			written:  "document#allowed",
+			expected: []string{"document#allowed", "document#restricted"},
+		},
+		{
+			name:     "unrelated",
+			written:  "document#unrelated",
+			expected: []string{"document#unrelated"},
+		},
+		{
+			name:     "undefined_relation",
+			written:  "document#undefined",
+			expected: []string{"document#undefined"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			objectType, relation := tuple.SplitObjectRelation(test.written)
+
+			references, err := g.GetAffectedRelations(typesystem.DirectRelationReference(objectType, relation))
+			require.NoError(t, err)
+
+			var affected []string
+			for _, reference := range references {
+				affected = append(affected, tuple.ToObjectRelationString(reference.GetType(), reference.GetRelation()))
+			}
+			require.Equal(t, test.expected, affected)
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package graph
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultHotKeyWindow        = 10 * time.Second
+	defaultHotKeyTopN          = 10
+	defaultHotKeyTTLMultiplier = 3
+)
+
+var (
+	hotRelationQPSDesc = prometheus.NewDesc(
+		"check_hot_relation_qps",
+		"The estimated Check QPS of the hottest relations, per store.",
+		[]string{"store_id", "object_type", "relation"},
+		nil,
+	)
+
+	hotStoreQPSDesc = prometheus.NewDesc(
+		"check_hot_store_qps",
+		"The estimated Check QPS of the hottest stores.",
+		[]string{"store_id"},
+		nil,
+	)
+)
+
+// HotKey identifies a relation of an object type within a store. A HotKey with an empty ObjectType
+// and Relation identifies the store as a whole.
+type HotKey struct {
+	StoreID    string
+	ObjectType string
+	Relation   string
+}
+
+func (k HotKey) String() string {
+	return k.StoreID + "/" + k.ObjectType + "#" + k.Relation
+}
+
+func (k HotKey) isStore() bool {
+	return k.ObjectType == "" && k.Relation == ""
+}
+
+// HotKeyQPS is a HotKey along with its estimated QPS.
+type HotKeyQPS struct {
+	HotKey
+	QPS float64
+}
+
+// HotKeyTracker tracks the Check QPS of every store and of every relation within a store. Relations whose
+// QPS exceeds a threshold are considered hot, and the CachedCheckResolver caches their results for longer and
+// collapses concurrent evaluations of the same sub-problem into one.
+//
+// The QPS is estimated over a sliding window made of two consecutive fixed windows, weighting the previous
+// window by how much of it still overlaps with the sliding window.
+//
+// HotKeyTracker implements prometheus.Collector and exposes the QPS of the top N stores and relations.
+type HotKeyTracker struct {
+	mu          sync.Mutex
+	windowStart time.Time
+	current     map[HotKey]uint64
+	previous    map[HotKey]uint64
+
+	window        time.Duration
+	qpsThreshold  float64
+	ttlMultiplier uint32
+	topN          int
+	now           func() time.Time
+
+	// lookupGroup collapses concurrent evaluations of hot sub-problems. It lives in the tracker (and not in
+	// the CachedCheckResolver) because the tracker is shared across requests.
+	lookupGroup singleflight.Group
+}
+
+var _ prometheus.Collector = (*HotKeyTracker)(nil)
+
+// HotKeyTrackerOpt defines an option that can be used to change the behavior of a HotKeyTracker.
+type HotKeyTrackerOpt func(*HotKeyTracker)
+
+// WithHotKeyQPSThreshold sets the QPS above which a relation is considered hot. A threshold of 0
+// (the default) means that no relation is ever considered hot, but the QPS is still tracked.
+func WithHotKeyQPSThreshold(qps float64) HotKeyTrackerOpt {
+	return func(t *HotKeyTracker) {
+		t.qpsThreshold = qps
+	}
+}
+
+// WithHotKeyTTLMultiplier sets the factor by which the cache TTL is multiplied for hot relations.
+func WithHotKeyTTLMultiplier(multiplier uint32) HotKeyTrackerOpt {
+	return func(t *HotKeyTracker) {
+		t.ttlMultiplier = multiplier
+	}
+}
+
+// WithHotKeyTopN sets the number of hottest stores and relations exposed as metrics.
+func WithHotKeyTopN(n int) HotKeyTrackerOpt {
+	return func(t *HotKeyTracker) {
+		t.topN = n
+	}
+}
+
+// WithHotKeyWindow sets the duration of the window over which the QPS is estimated.
+func WithHotKeyWindow(window time.Duration) HotKeyTrackerOpt {
+	return func(t *HotKeyTracker) {
+		t.window = window
+	}
+}
+
+// NewHotKeyTracker constructs a HotKeyTracker.
+func NewHotKeyTracker(opts ...HotKeyTrackerOpt) *HotKeyTracker {
+	t := &HotKeyTracker{
+		current:       map[HotKey]uint64{},
+		previous:      map[HotKey]uint64{},
+		window:        defaultHotKeyWindow,
+		ttlMultiplier: defaultHotKeyTTLMultiplier,
+		topN:          defaultHotKeyTopN,
+		now:           time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	t.windowStart = t.now()
+
+	return t
+}
+
+// Observe records one Check of the given relation (and, implicitly, of its store) and reports
+// whether the relation is hot.
+func (t *HotKeyTracker) Observe(key HotKey) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.rotate(now)
+
+	t.current[key]++
+	t.current[HotKey{StoreID: key.StoreID}]++
+
+	return t.qpsThreshold > 0 && t.qps(key, now) >= t.qpsThreshold
+}
+
+// QPS returns the estimated QPS of the given key.
+func (t *HotKeyTracker) QPS(key HotKey) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.rotate(now)
+
+	return t.qps(key, now)
+}
+
+// TopN returns the hottest relations and the hottest stores (at most N of each), sorted by descending QPS.
+func (t *HotKeyTracker) TopN() (relations []HotKeyQPS, stores []HotKeyQPS) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.rotate(now)
+
+	keys := make(map[HotKey]struct{}, len(t.current)+len(t.previous))
+	for key := range t.previous {
+		keys[key] = struct{}{}
+	}
+	for key := range t.current {
+		keys[key] = struct{}{}
+	}
+
+	for key := range keys {
+		entry := HotKeyQPS{HotKey: key, QPS: t.qps(key, now)}
+		if key.isStore() {
+			stores = append(stores, entry)
+		} else {
+			relations = append(relations, entry)
+		}
+	}
+
+	return t.top(relations), t.top(stores)
+}
+
+// Describe implements prometheus.Collector.
+func (t *HotKeyTracker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- hotRelationQPSDesc
+	ch <- hotStoreQPSDesc
+}
+
+// Collect implements prometheus.Collector.
+func (t *HotKeyTracker) Collect(ch chan<- prometheus.Metric) {
+	relations, stores := t.TopN()
+
+	for _, r := range relations {
+		ch <- prometheus.MustNewConstMetric(hotRelationQPSDesc, prometheus.GaugeValue, r.QPS, r.StoreID, r.ObjectType, r.Relation)
+	}
+
+	for _, s := range stores {
+		ch <- prometheus.MustNewConstMetric(hotStoreQPSDesc, prometheus.GaugeValue, s.QPS, s.StoreID)
+	}
+}
+
+func (t *HotKeyTracker) top(entries []HotKeyQPS) []HotKeyQPS {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].QPS != entries[j].QPS {
+			return entries[i].QPS > entries[j].QPS
+		}
+
+		return entries[i].HotKey.String() < entries[j].HotKey.String()
+	})
+
+	if len(entries) > t.topN {
+		entries = entries[:t.topN]
+	}
+
+	return entries
+}
+
+// rotate moves the fixed windows forward so that the current window contains now. The caller must hold the lock.
+func (t *HotKeyTracker) rotate(now time.Time) {
+	elapsed := now.Sub(t.windowStart)
+	if elapsed < t.window {
+		return
+	}
+
+	if elapsed < 2*t.window {
+		t.previous = t.current
+	} else {
+		t.previous = map[HotKey]uint64{}
+	}
+
+	t.current = map[HotKey]uint64{}
+	t.windowStart = now.Add(-(elapsed % t.window))
+}
+
+// qps estimates the QPS of the key over the sliding window ending at now. The caller must hold the lock.
+func (t *HotKeyTracker) qps(key HotKey, now time.Time) float64 {
+	overlap := 1 - float64(now.Sub(t.windowStart))/float64(t.window)
+	count := float64(t.current[key]) + float64(t.previous[key])*overlap
+
+	return count / t.window.Seconds()
+}
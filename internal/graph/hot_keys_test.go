@@ -0,0 +1,226 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHotKeyTrackerQPS(t *testing.T) {
+	now := time.Now()
+	tracker := NewHotKeyTracker(WithHotKeyWindow(10 * time.Second))
+	tracker.now = func() time.Time { return now }
+	tracker.windowStart = now
+
+	key := HotKey{StoreID: "01", ObjectType: "document", Relation: "viewer"}
+	for i := 0; i < 100; i++ {
+		tracker.Observe(key)
+	}
+
+	require.InDelta(t, 10, tracker.QPS(key), 0.001)
+	require.InDelta(t, 10, tracker.QPS(HotKey{StoreID: "01"}), 0.001)
+
+	// halfway through the next window, half of the previous window is still counted
+	now = now.Add(15 * time.Second)
+	require.InDelta(t, 5, tracker.QPS(key), 0.001)
+
+	// once two full windows have gone by, nothing is counted
+	now = now.Add(20 * time.Second)
+	require.Zero(t, tracker.QPS(key))
+}
+
+func TestHotKeyTrackerThreshold(t *testing.T) {
+	tracker := NewHotKeyTracker(WithHotKeyWindow(time.Second), WithHotKeyQPSThreshold(3))
+	now := time.Now()
+	tracker.now = func() time.Time { return now }
+	tracker.windowStart = now
+
+	key := HotKey{StoreID: "01", ObjectType: "document", Relation: "viewer"}
+	require.False(t, tracker.Observe(key))
+	require.False(t, tracker.Observe(key))
+	require.True(t, tracker.Observe(key))
+
+	// a threshold of 0 disables hot key detection
+	disabled := NewHotKeyTracker()
+	for i := 0; i < 1000; i++ {
+		require.False(t, disabled.Observe(key))
+	}
+}
+
+func TestHotKeyTrackerTopN(t *testing.T) {
+	tracker := NewHotKeyTracker(WithHotKeyTopN(2))
+
+	for i, relation := range []string{"viewer", "editor", "owner"} {
+		for j := 0; j <= i; j++ {
+			tracker.Observe(HotKey{StoreID: "01", ObjectType: "document", Relation: relation})
+		}
+	}
+	tracker.Observe(HotKey{StoreID: "02", ObjectType: "document", Relation: "viewer"})
+	tracker.Observe(HotKey{StoreID: "03", ObjectType: "document", Relation: "viewer"})
+
+	relations, stores := tracker.TopN()
+	require.Len(t, relations, 2)
+	require.Equal(t, "owner", relations[0].Relation)
+	require.Equal(t, "editor", relations[1].Relation)
+
+	require.Len(t, stores, 2)
+	require.Equal(t, "01", stores[0].StoreID)
+	require.Equal(t, "02", stores[1].StoreID)
+
+	require.Equal(t, 4, testutil.CollectAndCount(tracker))
+}
+
+func TestResolveHotCheckExtendsTTL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	req := &ResolveCheckRequest{
+		StoreID:              "12",
+		AuthorizationModelID: "33",
+		TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:XYZ"),
+	}
+
+	mockResolver := NewMockCheckResolver(ctrl)
+	mockResolver.EXPECT().ResolveCheck(gomock.Any(), req).Times(1).Return(&ResolveCheckResponse{
+		Allowed:            true,
+		ResolutionMetadata: &ResolutionMetadata{Depth: 1, DatastoreQueryCount: 1},
+	}, nil)
+
+	tracker := NewHotKeyTracker(WithHotKeyQPSThreshold(0.01), WithHotKeyTTLMultiplier(100))
+	dut := NewCachedCheckResolver(mockResolver, WithCacheTTL(time.Second), WithHotKeyTracker(tracker))
+	defer dut.Close()
+
+	resp, err := dut.ResolveCheck(ctx, req)
+	require.NoError(t, err)
+	require.True(t, resp.GetAllowed())
+
+	cacheKey, err := checkRequestCacheKey(req)
+	require.NoError(t, err)
+
+	item := dut.cache.Get(cacheKey)
+	require.NotNil(t, item)
+	require.Greater(t, item.TTL(), time.Second)
+
+	// the second call is served from the cache
+	resp, err = dut.ResolveCheck(ctx, req)
+	require.NoError(t, err)
+	require.True(t, resp.GetAllowed())
+}
+
+func TestResolveHotCheckLeaderCanceled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	req := &ResolveCheckRequest{
+		StoreID:              "12",
+		AuthorizationModelID: "33",
+		TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:XYZ"),
+	}
+
+	started := make(chan struct{})
+	mockResolver := NewMockCheckResolver(ctrl)
+	gomock.InOrder(
+		// the first caller's evaluation lasts until its request is canceled
+		mockResolver.EXPECT().ResolveCheck(gomock.Any(), req).Times(1).DoAndReturn(
+			func(ctx context.Context, _ *ResolveCheckRequest) (*ResolveCheckResponse, error) {
+				close(started)
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}),
+		mockResolver.EXPECT().ResolveCheck(gomock.Any(), req).Times(1).Return(&ResolveCheckResponse{Allowed: true}, nil),
+	)
+
+	tracker := NewHotKeyTracker(WithHotKeyQPSThreshold(0.01))
+	dut := NewCachedCheckResolver(mockResolver, WithHotKeyTracker(tracker))
+	defer dut.Close()
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := dut.ResolveCheck(leaderCtx, req)
+		leaderErr <- err
+	}()
+	<-started
+
+	type result struct {
+		resp *ResolveCheckResponse
+		err  error
+	}
+	waiter := make(chan result, 1)
+	go func() {
+		resp, err := dut.ResolveCheck(context.Background(), req)
+		waiter <- result{resp, err}
+	}()
+
+	// let the second caller wait for the evaluation of the first one
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	require.ErrorIs(t, <-leaderErr, context.Canceled)
+
+	res := <-waiter
+	require.NoError(t, res.err)
+	require.True(t, res.resp.GetAllowed())
+}
+
+func TestResolveHotCheckRecordsExpirations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	req := &ResolveCheckRequest{
+		StoreID:              "12",
+		AuthorizationModelID: "33",
+		TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:XYZ"),
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mockResolver := NewMockCheckResolver(ctrl)
+	mockResolver.EXPECT().ResolveCheck(gomock.Any(), req).Times(1).DoAndReturn(
+		func(ctx context.Context, _ *ResolveCheckRequest) (*ResolveCheckResponse, error) {
+			close(started)
+			<-release
+			storage.RecordTupleExpiration(ctx, time.Now().Add(time.Minute))
+			return &ResolveCheckResponse{Allowed: true}, nil
+		})
+
+	tracker := NewHotKeyTracker(WithHotKeyQPSThreshold(0.01))
+	dut := NewCachedCheckResolver(mockResolver, WithHotKeyTracker(tracker))
+	defer dut.Close()
+
+	var wg sync.WaitGroup
+	trackers := make([]*storage.ExpirationTracker, 2)
+	for i := range trackers {
+		var ctx context.Context
+		ctx, trackers[i] = storage.ContextWithExpirationTracker(context.Background())
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := dut.ResolveCheck(ctx, req)
+			require.NoError(t, err)
+		}()
+
+		if i == 0 {
+			<-started
+		}
+	}
+
+	// let the second caller wait for the evaluation of the first one
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for _, tracker := range trackers {
+		_, ok := tracker.Earliest()
+		require.True(t, ok)
+	}
+}
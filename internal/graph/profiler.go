@@ -0,0 +1,275 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/tuple"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultProfileLatencyThreshold = time.Second
+	defaultProfileSampleRate       = 0.01
+)
+
+type profileCtxKey struct{}
+
+// ProfileNode is a node of the resolution tree of a profiled Check, e.g. the evaluation of a relation or
+// of one of its resolution edges.
+type ProfileNode struct {
+	Name       string            `json:"name"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Start      time.Time         `json:"start"`
+	Duration   time.Duration     `json:"duration_ns"`
+	Children   []*ProfileNode    `json:"children,omitempty"`
+}
+
+// CheckProfile is the resolution tree of a Check along with its timings.
+type CheckProfile struct {
+	StoreID              string        `json:"store_id"`
+	AuthorizationModelID string        `json:"authorization_model_id"`
+	TupleKey             string        `json:"tuple_key"`
+	Allowed              bool          `json:"allowed"`
+	Error                string        `json:"error,omitempty"`
+	Start                time.Time     `json:"start"`
+	Duration             time.Duration `json:"duration_ns"`
+	Root                 *ProfileNode  `json:"root"`
+}
+
+// profileRecorder guards the resolution tree of a profiled Check. Concurrent branches of the resolution may
+// still be running after the Check has been resolved, so once the recorder is done the tree is frozen.
+type profileRecorder struct {
+	mu   sync.Mutex
+	done bool
+}
+
+type profileContext struct {
+	recorder *profileRecorder
+	node     *ProfileNode
+}
+
+// startProfileNode adds a node to the resolution tree of the profiled Check in ctx, if any, as a child of
+// the current node.
+func startProfileNode(ctx context.Context, name string, attrs []attribute.KeyValue) (context.Context, *profileContext) {
+	parent, ok := ctx.Value(profileCtxKey{}).(*profileContext)
+	if !ok {
+		return ctx, nil
+	}
+
+	node := &ProfileNode{
+		Name:       name,
+		Attributes: make(map[string]string, len(attrs)),
+		Start:      time.Now(),
+	}
+	for _, attr := range attrs {
+		node.Attributes[string(attr.Key)] = attr.Value.Emit()
+	}
+
+	parent.recorder.mu.Lock()
+	defer parent.recorder.mu.Unlock()
+
+	if parent.recorder.done {
+		return ctx, nil
+	}
+
+	parent.node.Children = append(parent.node.Children, node)
+
+	pc := &profileContext{recorder: parent.recorder, node: node}
+	return context.WithValue(ctx, profileCtxKey{}, pc), pc
+}
+
+// end records the duration of the node and the provided attributes.
+func (pc *profileContext) end(attrs []attribute.KeyValue) {
+	if pc == nil {
+		return
+	}
+
+	pc.recorder.mu.Lock()
+	defer pc.recorder.mu.Unlock()
+
+	if pc.recorder.done {
+		return
+	}
+
+	pc.node.Duration = time.Since(pc.node.Start)
+	for _, attr := range attrs {
+		pc.node.Attributes[string(attr.Key)] = attr.Value.Emit()
+	}
+}
+
+// ProfileSink receives the profiles of the Checks sampled by a ProfilingCheckResolver.
+type ProfileSink interface {
+	Write(ctx context.Context, profile *CheckProfile) error
+}
+
+// LoggerProfileSink logs every profile it receives.
+type LoggerProfileSink struct {
+	logger logger.Logger
+}
+
+var _ ProfileSink = (*LoggerProfileSink)(nil)
+
+// NewLoggerProfileSink constructs a ProfileSink that logs every profile with the provided logger.
+func NewLoggerProfileSink(logger logger.Logger) *LoggerProfileSink {
+	return &LoggerProfileSink{logger: logger}
+}
+
+func (s *LoggerProfileSink) Write(ctx context.Context, profile *CheckProfile) error {
+	s.logger.WarnWithContext(ctx, "slow check profile", zap.Any("profile", profile))
+	return nil
+}
+
+// FileProfileSink appends every profile it receives to a file, one JSON document per line.
+type FileProfileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+var _ ProfileSink = (*FileProfileSink)(nil)
+
+// NewFileProfileSink constructs a ProfileSink that appends every profile to the file at the provided path,
+// creating it if needed.
+func NewFileProfileSink(path string) (*FileProfileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open check profile file: %w", err)
+	}
+
+	return &FileProfileSink{file: file}, nil
+}
+
+func (s *FileProfileSink) Write(_ context.Context, profile *CheckProfile) error {
+	b, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.file.Write(append(b, '\n'))
+	return err
+}
+
+// Close closes the underlying file.
+func (s *FileProfileSink) Close() error {
+	return s.file.Close()
+}
+
+// ProfilingCheckResolver samples a fraction of the Checks it resolves and, for those that take longer than a
+// latency threshold, writes their full resolution tree along with its timings to a ProfileSink. This allows
+// for the analysis of tail latency without always-on tracing.
+//
+// Since the latency of a Check isn't known until it's resolved, the resolution tree of every sampled Check is
+// recorded, and it's discarded if the Check resolves under the threshold.
+type ProfilingCheckResolver struct {
+	delegate   CheckResolver
+	sink       ProfileSink
+	logger     logger.Logger
+	threshold  time.Duration
+	sampleRate float64
+	random     func() float64
+}
+
+var _ CheckResolver = (*ProfilingCheckResolver)(nil)
+
+// ProfilingCheckResolverOpt defines an option that can be used to change the behavior of a
+// ProfilingCheckResolver.
+type ProfilingCheckResolverOpt func(*ProfilingCheckResolver)
+
+// WithProfileLatencyThreshold sets the latency above which the profile of a sampled Check is written to
+// the sink.
+func WithProfileLatencyThreshold(threshold time.Duration) ProfilingCheckResolverOpt {
+	return func(r *ProfilingCheckResolver) {
+		r.threshold = threshold
+	}
+}
+
+// WithProfileSampleRate sets the fraction (between 0 and 1) of the Checks that are sampled.
+func WithProfileSampleRate(rate float64) ProfilingCheckResolverOpt {
+	return func(r *ProfilingCheckResolver) {
+		r.sampleRate = rate
+	}
+}
+
+// WithProfileLogger sets the logger used to report the failures to write to the sink.
+func WithProfileLogger(logger logger.Logger) ProfilingCheckResolverOpt {
+	return func(r *ProfilingCheckResolver) {
+		r.logger = logger
+	}
+}
+
+// NewProfilingCheckResolver constructs a ProfilingCheckResolver that delegates the resolution of Checks to
+// the provided delegate and writes the profiles of the sampled Checks to the provided sink.
+func NewProfilingCheckResolver(delegate CheckResolver, sink ProfileSink, opts ...ProfilingCheckResolverOpt) *ProfilingCheckResolver {
+	r := &ProfilingCheckResolver{
+		delegate:   delegate,
+		sink:       sink,
+		logger:     logger.NewNoopLogger(),
+		threshold:  defaultProfileLatencyThreshold,
+		sampleRate: defaultProfileSampleRate,
+		random:     rand.Float64,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+func (r *ProfilingCheckResolver) ResolveCheck(ctx context.Context, req *ResolveCheckRequest) (*ResolveCheckResponse, error) {
+	if r.random() >= r.sampleRate {
+		return r.delegate.ResolveCheck(ctx, req)
+	}
+
+	recorder := &profileRecorder{}
+	root := &ProfileNode{
+		Name:  "Check",
+		Start: time.Now(),
+	}
+	ctx = context.WithValue(ctx, profileCtxKey{}, &profileContext{recorder: recorder, node: root})
+
+	resp, err := r.delegate.ResolveCheck(ctx, req)
+	duration := time.Since(root.Start)
+
+	recorder.mu.Lock()
+	recorder.done = true
+	recorder.mu.Unlock()
+
+	if duration < r.threshold {
+		return resp, err
+	}
+
+	root.Duration = duration
+	profile := &CheckProfile{
+		StoreID:              req.GetStoreID(),
+		AuthorizationModelID: req.GetAuthorizationModelID(),
+		TupleKey:             tuple.TupleKeyToString(req.GetTupleKey()),
+		Allowed:              resp.GetAllowed(),
+		Start:                root.Start,
+		Duration:             duration,
+		Root:                 root,
+	}
+	if err != nil {
+		profile.Error = err.Error()
+	}
+
+	if err := r.sink.Write(ctx, profile); err != nil {
+		r.logger.WarnWithContext(ctx, "failed to write check profile", zap.Error(err))
+	}
+
+	return resp, err
+}
+
+func (r *ProfilingCheckResolver) Close() {
+	r.delegate.Close()
+}
@@ -0,0 +1,175 @@
+package graph
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryProfileSink struct {
+	mu       sync.Mutex
+	profiles []*CheckProfile
+}
+
+func (s *memoryProfileSink) Write(_ context.Context, profile *CheckProfile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.profiles = append(s.profiles, profile)
+	return nil
+}
+
+func findProfileNode(node *ProfileNode, name string) *ProfileNode {
+	if node.Name == name {
+		return node
+	}
+
+	for _, child := range node.Children {
+		if found := findProfileNode(child, name); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+func TestProfilingCheckResolver(t *testing.T) {
+	ds := memory.New()
+	defer ds.Close()
+
+	storeID := ulid.Make().String()
+
+	err := ds.Write(context.Background(), storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "parent", "folder:x"),
+		tuple.NewTupleKey("folder:x", "viewer", "user:jon"),
+	})
+	require.NoError(t, err)
+
+	typedefs := parser.MustParse(`
+	type user
+
+	type folder
+	  relations
+	    define viewer: [user] as self
+
+	type document
+	  relations
+	    define parent: [folder] as self
+	    define viewer as viewer from parent
+	`)
+
+	ctx := typesystem.ContextWithTypesystem(context.Background(), typesystem.New(
+		&openfgav1.AuthorizationModel{
+			Id:              ulid.Make().String(),
+			TypeDefinitions: typedefs,
+			SchemaVersion:   typesystem.SchemaVersion1_1,
+		},
+	))
+
+	req := func() *ResolveCheckRequest {
+		return &ResolveCheckRequest{
+			StoreID:            storeID,
+			TupleKey:           tuple.NewTupleKey("document:1", "viewer", "user:jon"),
+			ResolutionMetadata: &ResolutionMetadata{Depth: 25},
+		}
+	}
+
+	t.Run("sampled_checks_above_the_threshold_are_written_to_the_sink", func(t *testing.T) {
+		sink := &memoryProfileSink{}
+		resolver := NewProfilingCheckResolver(NewLocalChecker(ds), sink,
+			WithProfileSampleRate(1),
+			WithProfileLatencyThreshold(0),
+		)
+		defer resolver.Close()
+
+		resp, err := resolver.ResolveCheck(ctx, req())
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+
+		require.Len(t, sink.profiles, 1)
+		profile := sink.profiles[0]
+		require.Equal(t, storeID, profile.StoreID)
+		require.Equal(t, "document:1#viewer@user:jon", profile.TupleKey)
+		require.True(t, profile.Allowed)
+
+		ttu := findProfileNode(profile.Root, "checkTTU")
+		require.NotNil(t, ttu)
+		require.Equal(t, TupleToUsersetEdge.String(), ttu.Attributes["edge_type"])
+		require.Equal(t, "1", ttu.Attributes["tuples_read"])
+		require.Equal(t, "true", ttu.Attributes["allowed"])
+
+		direct := findProfileNode(ttu, "checkDirectUserTuple")
+		require.NotNil(t, direct)
+		require.Equal(t, "folder:x#viewer", direct.Attributes["object_relation"])
+	})
+
+	t.Run("sampled_checks_below_the_threshold_are_discarded", func(t *testing.T) {
+		sink := &memoryProfileSink{}
+		resolver := NewProfilingCheckResolver(NewLocalChecker(ds), sink,
+			WithProfileSampleRate(1),
+			WithProfileLatencyThreshold(time.Hour),
+		)
+		defer resolver.Close()
+
+		_, err := resolver.ResolveCheck(ctx, req())
+		require.NoError(t, err)
+		require.Empty(t, sink.profiles)
+	})
+
+	t.Run("checks_that_are_not_sampled_are_discarded", func(t *testing.T) {
+		sink := &memoryProfileSink{}
+		resolver := NewProfilingCheckResolver(NewLocalChecker(ds), sink,
+			WithProfileSampleRate(0),
+			WithProfileLatencyThreshold(0),
+		)
+		defer resolver.Close()
+
+		_, err := resolver.ResolveCheck(ctx, req())
+		require.NoError(t, err)
+		require.Empty(t, sink.profiles)
+	})
+}
+
+func TestFileProfileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.ndjson")
+
+	sink, err := NewFileProfileSink(path)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		err = sink.Write(context.Background(), &CheckProfile{
+			StoreID:  "store",
+			TupleKey: "document:1#viewer@user:jon",
+			Root:     &ProfileNode{Name: "Check"},
+		})
+		require.NoError(t, err)
+	}
+	require.NoError(t, sink.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var lines int
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var profile CheckProfile
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &profile))
+		require.Equal(t, "store", profile.StoreID)
+		lines++
+	}
+	require.Equal(t, 2, lines)
+}
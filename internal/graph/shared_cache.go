@@ -0,0 +1,93 @@
+package graph
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+const (
+	sharedCacheResultHit   = "hit"
+	sharedCacheResultMiss  = "miss"
+	sharedCacheResultError = "error"
+)
+
+var sharedCheckCacheCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "check_shared_cache_count",
+	Help: "The total number of lookups of the shared Check cache after a miss of the local one, labeled by result ('hit', 'miss' or 'error').",
+}, []string{"result"})
+
+// SharedCheckCache is a cache of the resolved Check sub-problems that is shared by the servers of a fleet
+// (e.g. in Memcached), so that they benefit from each other's work.
+//
+// The entries of a store are versioned by the position of its changelog: invalidating a store moves it
+// forward, so that the entries cached before are no longer read by any server.
+type SharedCheckCache interface {
+	// Get returns the cached result of the key of a sub-problem of the store, with the earliest expiration of the
+	// tuples it was resolved from (or the zero time if none expires), and whether there was one.
+	Get(ctx context.Context, storeID, key string) (allowed bool, expiresAt time.Time, ok bool, err error)
+
+	// Set caches the result of the key of a sub-problem of the store, with the earliest expiration of the tuples
+	// it was resolved from (or the zero time if none expires), for the TTL. The result mustn't be cached for
+	// longer than the TTL, which is already capped by the expiration.
+	Set(ctx context.Context, storeID, key string, allowed bool, expiresAt time.Time, ttl time.Duration) error
+
+	// Invalidate invalidates every cached result of the store, e.g. after a write.
+	Invalidate(ctx context.Context, storeID string) error
+}
+
+// WithSharedCache looks the sub-problems that aren't in the cache up in a cache shared with the other
+// servers, before resolving them, and caches the results resolved in both caches.
+func WithSharedCache(cache SharedCheckCache) CachedCheckResolverOpt {
+	return func(ccr *CachedCheckResolver) {
+		ccr.sharedCache = cache
+	}
+}
+
+// getShared returns the result of the sub-problem cached in the shared cache, if any, and caches it locally, no
+// longer than the expiration of the tuples it was resolved from.
+func (c *CachedCheckResolver) getShared(ctx context.Context, req *ResolveCheckRequest, cacheKey string) *CachedResolveCheckResponse {
+	if c.sharedCache == nil {
+		return nil
+	}
+
+	allowed, expiresAt, ok, err := c.sharedCache.Get(ctx, req.GetStoreID(), cacheKey)
+	if err != nil {
+		sharedCheckCacheCounter.WithLabelValues(sharedCacheResultError).Inc()
+		c.logger.Debug("shared check cache lookup failed", zap.Error(err))
+		return nil
+	}
+	if !ok || (!expiresAt.IsZero() && !time.Now().Before(expiresAt)) {
+		sharedCheckCacheCounter.WithLabelValues(sharedCacheResultMiss).Inc()
+		return nil
+	}
+
+	sharedCheckCacheCounter.WithLabelValues(sharedCacheResultHit).Inc()
+
+	resp := &CachedResolveCheckResponse{Allowed: allowed, expiresAt: expiresAt}
+	c.set(cacheKey, resp.convertToResolveCheckResponse(), 1, expiresAt)
+	return resp
+}
+
+// setShared caches the result of the sub-problem in the shared cache, for the TTL of its outcome capped by the
+// earliest expiration of the tuples it was resolved from.
+func (c *CachedCheckResolver) setShared(ctx context.Context, req *ResolveCheckRequest, cacheKey string, resp *ResolveCheckResponse, expiresAt time.Time) {
+	if c.sharedCache == nil {
+		return
+	}
+
+	ttl := c.cacheTTL
+	if !resp.GetAllowed() {
+		ttl = c.negativeCacheTTL
+	}
+	if ttl = capTTL(ttl, expiresAt); ttl <= 0 {
+		return
+	}
+
+	if err := c.sharedCache.Set(ctx, req.GetStoreID(), cacheKey, resp.GetAllowed(), expiresAt, ttl); err != nil {
+		c.logger.Debug("shared check cache update failed", zap.Error(err))
+	}
+}
@@ -2,9 +2,14 @@ package authn
 
 import (
 	"context"
+	"strings"
 
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
 	grpcauth "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/selector"
 	"github.com/openfga/openfga/internal/authn"
+	"github.com/openfga/openfga/pkg/requestcontext"
+	"google.golang.org/grpc"
 )
 
 func AuthFunc(authenticator authn.Authenticator) grpcauth.AuthFunc {
@@ -14,6 +19,51 @@ func AuthFunc(authenticator authn.Authenticator) grpcauth.AuthFunc {
 			return nil, err
 		}
 
+		requestcontext.SetCaller(ctx, claims.Subject)
+
 		return authn.ContextWithAuthClaims(ctx, claims), nil
 	}
 }
+
+// RequiresAuthentication returns a selector.Matcher that matches the RPCs that must be authenticated, i.e.
+// those whose full method name (e.g. '/grpc.health.v1.Health/Check') doesn't match any of the provided
+// unauthenticated methods. An unauthenticated method ending in '*' matches every method with that prefix
+// (e.g. '/grpc.health.v1.Health/*').
+func RequiresAuthentication(unauthenticatedMethods []string) selector.Matcher {
+	return selector.MatchFunc(func(_ context.Context, callMeta interceptors.CallMeta) bool {
+		fullMethod := callMeta.FullMethod()
+		for _, method := range unauthenticatedMethods {
+			if prefix, ok := strings.CutSuffix(method, "*"); ok {
+				if strings.HasPrefix(fullMethod, prefix) {
+					return false
+				}
+
+				continue
+			}
+
+			if fullMethod == method {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+// NewUnaryInterceptor creates a grpc.UnaryServerInterceptor that authenticates every RPC with the provided
+// authenticator, except for the unauthenticated methods (see RequiresAuthentication).
+func NewUnaryInterceptor(authenticator authn.Authenticator, unauthenticatedMethods []string) grpc.UnaryServerInterceptor {
+	return selector.UnaryServerInterceptor(
+		grpcauth.UnaryServerInterceptor(AuthFunc(authenticator)),
+		RequiresAuthentication(unauthenticatedMethods),
+	)
+}
+
+// NewStreamingInterceptor creates a grpc.StreamServerInterceptor that authenticates every RPC with the provided
+// authenticator, except for the unauthenticated methods (see RequiresAuthentication).
+func NewStreamingInterceptor(authenticator authn.Authenticator, unauthenticatedMethods []string) grpc.StreamServerInterceptor {
+	return selector.StreamServerInterceptor(
+		grpcauth.StreamServerInterceptor(AuthFunc(authenticator)),
+		RequiresAuthentication(unauthenticatedMethods),
+	)
+}
@@ -0,0 +1,74 @@
+package authn
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openfga/openfga/internal/authn"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestUnaryInterceptorUnauthenticatedMethods(t *testing.T) {
+	unauthenticatedMethods := []string{
+		"/grpc.health.v1.Health/*",
+		"/openfga.v1.OpenFGAService/ListStores",
+	}
+
+	tests := []struct {
+		name           string
+		fullMethod     string
+		expectAuthCall bool
+	}{
+		{
+			name:           "exact_match_bypasses_authentication",
+			fullMethod:     "/openfga.v1.OpenFGAService/ListStores",
+			expectAuthCall: false,
+		},
+		{
+			name:           "prefix_match_bypasses_authentication",
+			fullMethod:     "/grpc.health.v1.Health/Check",
+			expectAuthCall: false,
+		},
+		{
+			name:           "other_methods_are_authenticated",
+			fullMethod:     "/openfga.v1.OpenFGAService/Check",
+			expectAuthCall: true,
+		},
+		{
+			name:           "exact_match_is_not_a_prefix_match",
+			fullMethod:     "/openfga.v1.OpenFGAService/ListStoresAndMore",
+			expectAuthCall: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			authenticator := &countingAuthenticator{}
+			interceptor := NewUnaryInterceptor(authenticator, unauthenticatedMethods)
+
+			_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: test.fullMethod},
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					return nil, nil
+				})
+			require.NoError(t, err)
+
+			if test.expectAuthCall {
+				require.Equal(t, 1, authenticator.calls)
+			} else {
+				require.Zero(t, authenticator.calls)
+			}
+		})
+	}
+}
+
+type countingAuthenticator struct {
+	calls int
+}
+
+func (a *countingAuthenticator) Authenticate(context.Context) (*authn.AuthClaims, error) {
+	a.calls++
+	return &authn.AuthClaims{}, nil
+}
+
+func (a *countingAuthenticator) Close() {}
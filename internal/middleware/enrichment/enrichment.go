@@ -0,0 +1,203 @@
+// Package enrichment contains middleware that enriches requests with data derived from the auth claims
+// of the caller.
+package enrichment
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/google/cel-go/cel"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/internal/authn"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	claimsVariable  = "claims"
+	subjectVariable = "subject"
+	requestVariable = "request"
+)
+
+var tupleKeysType = reflect.TypeOf([]map[string]string{})
+
+// Enricher derives contextual tuples from the auth claims of the caller by evaluating CEL expressions.
+//
+// Each expression must evaluate to a list of maps with the 'object', 'relation' and 'user' keys, each of which
+// becomes a contextual tuple. The expressions can use the following variables:
+//
+//   - claims: the claims of the token of the caller (e.g. claims.groups), or an empty map if there are none
+//   - subject: the subject of the caller
+//   - request: the 'store_id', 'authorization_model_id', 'object', 'relation', 'user' and 'type' fields of the request
+//
+// For example, the following expression injects the group claims of the caller as contextual tuples:
+//
+//	claims.groups.map(g, {"object": "group:" + g, "relation": "member", "user": "user:" + subject})
+type Enricher struct {
+	programs []cel.Program
+}
+
+// NewEnricher compiles the provided CEL expressions into an Enricher.
+func NewEnricher(expressions []string) (*Enricher, error) {
+	env, err := cel.NewEnv(
+		cel.Variable(claimsVariable, cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable(subjectVariable, cel.StringType),
+		cel.Variable(requestVariable, cel.MapType(cel.StringType, cel.StringType)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Enricher{}
+	for _, expression := range expressions {
+		ast, issues := env.Compile(expression)
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("invalid request enrichment expression '%s': %w", expression, issues.Err())
+		}
+
+		program, err := env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request enrichment expression '%s': %w", expression, err)
+		}
+
+		e.programs = append(e.programs, program)
+	}
+
+	return e, nil
+}
+
+// ContextualTuples evaluates the expressions of the Enricher against the auth claims found in ctx and
+// the provided request fields, and returns the resulting contextual tuples.
+func (e *Enricher) ContextualTuples(ctx context.Context, request map[string]string) ([]*openfgav1.TupleKey, error) {
+	claims := map[string]interface{}{}
+	var subject string
+	if authClaims, ok := authn.AuthClaimsFromContext(ctx); ok && authClaims != nil {
+		subject = authClaims.Subject
+		if authClaims.Claims != nil {
+			claims = authClaims.Claims
+		}
+	}
+
+	vars := map[string]interface{}{
+		claimsVariable:  claims,
+		subjectVariable: subject,
+		requestVariable: request,
+	}
+
+	var tupleKeys []*openfgav1.TupleKey
+	for _, program := range e.programs {
+		out, _, err := program.ContextEval(ctx, vars)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate request enrichment expression: %w", err)
+		}
+
+		native, err := out.ConvertToNative(tupleKeysType)
+		if err != nil {
+			return nil, fmt.Errorf("request enrichment expression must evaluate to a list of tuple keys: %w", err)
+		}
+
+		for _, tk := range native.([]map[string]string) {
+			tupleKeys = append(tupleKeys, &openfgav1.TupleKey{
+				Object:   tk["object"],
+				Relation: tk["relation"],
+				User:     tk["user"],
+			})
+		}
+	}
+
+	return tupleKeys, nil
+}
+
+// Enrich appends the contextual tuples derived by the Enricher to the supported requests (Check,
+// ListObjects and StreamedListObjects). Other requests are left untouched.
+func (e *Enricher) Enrich(ctx context.Context, req interface{}) error {
+	var contextualTuples **openfgav1.ContextualTupleKeys
+	var request map[string]string
+
+	switch r := req.(type) {
+	case *openfgav1.CheckRequest:
+		contextualTuples = &r.ContextualTuples
+		request = map[string]string{
+			"store_id":               r.GetStoreId(),
+			"authorization_model_id": r.GetAuthorizationModelId(),
+			"object":                 r.GetTupleKey().GetObject(),
+			"relation":               r.GetTupleKey().GetRelation(),
+			"user":                   r.GetTupleKey().GetUser(),
+		}
+	case *openfgav1.ListObjectsRequest:
+		contextualTuples = &r.ContextualTuples
+		request = map[string]string{
+			"store_id":               r.GetStoreId(),
+			"authorization_model_id": r.GetAuthorizationModelId(),
+			"type":                   r.GetType(),
+			"relation":               r.GetRelation(),
+			"user":                   r.GetUser(),
+		}
+	case *openfgav1.StreamedListObjectsRequest:
+		contextualTuples = &r.ContextualTuples
+		request = map[string]string{
+			"store_id":               r.GetStoreId(),
+			"authorization_model_id": r.GetAuthorizationModelId(),
+			"type":                   r.GetType(),
+			"relation":               r.GetRelation(),
+			"user":                   r.GetUser(),
+		}
+	default:
+		return nil
+	}
+
+	tupleKeys, err := e.ContextualTuples(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	if len(tupleKeys) == 0 {
+		return nil
+	}
+
+	if *contextualTuples == nil {
+		*contextualTuples = &openfgav1.ContextualTupleKeys{}
+	}
+	(*contextualTuples).TupleKeys = append((*contextualTuples).TupleKeys, tupleKeys...)
+
+	return nil
+}
+
+// NewUnaryInterceptor creates a grpc.UnaryServerInterceptor that enriches the requests with the
+// contextual tuples derived by the Enricher. It must run after the authentication interceptor.
+func NewUnaryInterceptor(e *Enricher) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := e.Enrich(ctx, req); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// NewStreamingInterceptor creates a grpc.StreamServerInterceptor that enriches the requests with the
+// contextual tuples derived by the Enricher. It must run after the authentication interceptor.
+func NewStreamingInterceptor(e *Enricher) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &enrichingServerStream{ServerStream: stream, enricher: e})
+	}
+}
+
+type enrichingServerStream struct {
+	grpc.ServerStream
+	enricher *Enricher
+}
+
+func (s *enrichingServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	if err := s.enricher.Enrich(s.Context(), m); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package enrichment
+
+import (
+	"context"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/internal/authn"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewEnricherRejectsInvalidExpressions(t *testing.T) {
+	_, err := NewEnricher([]string{`claims.groups.map(g,`})
+	require.ErrorContains(t, err, "invalid request enrichment expression")
+
+	_, err = NewEnricher([]string{`unknown_variable`})
+	require.ErrorContains(t, err, "invalid request enrichment expression")
+}
+
+func TestEnrich(t *testing.T) {
+	enricher, err := NewEnricher([]string{
+		`claims.groups.map(g, {"object": "group:" + g, "relation": "member", "user": "user:" + subject})`,
+		`request.store_id == "store" ? [{"object": "store:" + request.store_id, "relation": "member", "user": "user:" + subject}] : []`,
+	})
+	require.NoError(t, err)
+
+	ctx := authn.ContextWithAuthClaims(context.Background(), &authn.AuthClaims{
+		Subject: "anne",
+		Claims: map[string]interface{}{
+			"groups": []interface{}{"eng", "ops"},
+		},
+	})
+
+	t.Run("check", func(t *testing.T) {
+		req := &openfgav1.CheckRequest{
+			StoreId:  "store",
+			TupleKey: tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+			ContextualTuples: &openfgav1.ContextualTupleKeys{
+				TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "parent", "folder:1")},
+			},
+		}
+
+		require.NoError(t, enricher.Enrich(ctx, req))
+		require.Equal(t, []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:1", "parent", "folder:1"),
+			tuple.NewTupleKey("group:eng", "member", "user:anne"),
+			tuple.NewTupleKey("group:ops", "member", "user:anne"),
+			tuple.NewTupleKey("store:store", "member", "user:anne"),
+		}, req.GetContextualTuples().GetTupleKeys())
+	})
+
+	t.Run("list_objects", func(t *testing.T) {
+		req := &openfgav1.ListObjectsRequest{
+			StoreId:  "other",
+			Type:     "document",
+			Relation: "viewer",
+			User:     "user:anne",
+		}
+
+		require.NoError(t, enricher.Enrich(ctx, req))
+		require.Equal(t, []*openfgav1.TupleKey{
+			tuple.NewTupleKey("group:eng", "member", "user:anne"),
+			tuple.NewTupleKey("group:ops", "member", "user:anne"),
+		}, req.GetContextualTuples().GetTupleKeys())
+	})
+
+	t.Run("unsupported_requests_are_left_untouched", func(t *testing.T) {
+		req := &openfgav1.ReadRequest{StoreId: "store"}
+		require.NoError(t, enricher.Enrich(ctx, req))
+	})
+
+	t.Run("missing_claims_fail_the_evaluation", func(t *testing.T) {
+		req := &openfgav1.CheckRequest{
+			StoreId:  "store",
+			TupleKey: tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		}
+
+		err := enricher.Enrich(context.Background(), req)
+		require.ErrorContains(t, err, "failed to evaluate request enrichment expression")
+	})
+
+	t.Run("expressions_must_evaluate_to_tuple_keys", func(t *testing.T) {
+		enricher, err := NewEnricher([]string{`subject`})
+		require.NoError(t, err)
+
+		err = enricher.Enrich(ctx, &openfgav1.CheckRequest{})
+		require.ErrorContains(t, err, "must evaluate to a list of tuple keys")
+	})
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	enricher, err := NewEnricher([]string{`[{"object": "group:eng", "relation": "member", "user": "user:" + subject}]`})
+	require.NoError(t, err)
+
+	interceptor := NewUnaryInterceptor(enricher)
+	ctx := authn.ContextWithAuthClaims(context.Background(), &authn.AuthClaims{Subject: "anne"})
+
+	_, err = interceptor(ctx, &openfgav1.CheckRequest{}, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		require.Len(t, req.(*openfgav1.CheckRequest).GetContextualTuples().GetTupleKeys(), 1)
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	failing, err := NewEnricher([]string{`subject`})
+	require.NoError(t, err)
+
+	_, err = NewUnaryInterceptor(failing)(ctx, &openfgav1.CheckRequest{}, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		require.FailNow(t, "the handler must not be called")
+		return nil, nil
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
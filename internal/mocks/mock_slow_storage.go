@@ -25,31 +25,32 @@ func NewMockSlowDataStorage(ds storage.OpenFGADatastore, readTuplesDelay time.Du
 
 func (m *slowDataStorage) Close() {}
 
-func (m *slowDataStorage) Read(ctx context.Context, store string, key *openfgav1.TupleKey) (storage.TupleIterator, error) {
+func (m *slowDataStorage) Read(ctx context.Context, store string, key *openfgav1.TupleKey, options storage.ReadOptions) (storage.TupleIterator, error) {
 	time.Sleep(m.readTuplesDelay)
-	return m.OpenFGADatastore.Read(ctx, store, key)
+	return m.OpenFGADatastore.Read(ctx, store, key, options)
 }
 
-func (m *slowDataStorage) ReadPage(ctx context.Context, store string, key *openfgav1.TupleKey, paginationOptions storage.PaginationOptions) ([]*openfgav1.Tuple, []byte, error) {
+func (m *slowDataStorage) ReadPage(ctx context.Context, store string, key *openfgav1.TupleKey, paginationOptions storage.PaginationOptions, options storage.ReadOptions) ([]*openfgav1.Tuple, []byte, error) {
 	time.Sleep(m.readTuplesDelay)
-	return m.OpenFGADatastore.ReadPage(ctx, store, key, paginationOptions)
+	return m.OpenFGADatastore.ReadPage(ctx, store, key, paginationOptions, options)
 }
 
-func (m *slowDataStorage) ReadUserTuple(ctx context.Context, store string, key *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+func (m *slowDataStorage) ReadUserTuple(ctx context.Context, store string, key *openfgav1.TupleKey, options storage.ReadOptions) (*openfgav1.Tuple, error) {
 	time.Sleep(m.readTuplesDelay)
-	return m.OpenFGADatastore.ReadUserTuple(ctx, store, key)
+	return m.OpenFGADatastore.ReadUserTuple(ctx, store, key, options)
 }
 
-func (m *slowDataStorage) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter) (storage.TupleIterator, error) {
+func (m *slowDataStorage) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter, options storage.ReadOptions) (storage.TupleIterator, error) {
 	time.Sleep(m.readTuplesDelay)
-	return m.OpenFGADatastore.ReadUsersetTuples(ctx, store, filter)
+	return m.OpenFGADatastore.ReadUsersetTuples(ctx, store, filter, options)
 }
 
 func (m *slowDataStorage) ReadStartingWithUser(
 	ctx context.Context,
 	store string,
 	filter storage.ReadStartingWithUserFilter,
+	options storage.ReadOptions,
 ) (storage.TupleIterator, error) {
 	time.Sleep(m.readTuplesDelay)
-	return m.OpenFGADatastore.ReadStartingWithUser(ctx, store, filter)
+	return m.OpenFGADatastore.ReadStartingWithUser(ctx, store, filter, options)
 }
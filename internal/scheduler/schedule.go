@@ -0,0 +1,203 @@
+// Package scheduler runs background tasks on a cron-like schedule, e.g. the maintenance tasks of the
+// datastore.
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxScheduleHorizon bounds the search for the next time a schedule is due, so that schedules that are never
+// due (e.g. '0 0 30 2 *') don't search forever.
+const maxScheduleHorizon = 5 * 366 * 24 * time.Hour
+
+// A Schedule decides when a task is due.
+type Schedule interface {
+	// Next returns the first time the task is due strictly after t, or the zero time if it's never due.
+	Next(t time.Time) time.Time
+}
+
+// ParseSchedule parses a cron-like schedule. It's either:
+//
+//   - '@every <duration>', e.g. '@every 6h', which is due every duration.
+//   - '@hourly', '@daily', '@weekly' or '@monthly'.
+//   - five space separated fields: minute (0-59), hour (0-23), day of the month (1-31), month (1-12) and day
+//     of the week (0-6, Sunday being 0). Each field is '*' or a comma separated list of values or ranges
+//     (e.g. '1-5'), each optionally followed by a step (e.g. '*/15'). For example, '30 3 * * 1-5' is due
+//     at 03:30 on weekdays. Like in cron, if both the day of the month and the day of the week are
+//     restricted, the schedule is due on the days that match either of them.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if interval, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(interval))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule '%s': %w", spec, err)
+		}
+
+		if d < time.Second {
+			return nil, fmt.Errorf("invalid schedule '%s': the interval must be at least one second", spec)
+		}
+
+		return everySchedule(d), nil
+	}
+
+	switch spec {
+	case "@hourly":
+		spec = "0 * * * *"
+	case "@daily":
+		spec = "0 0 * * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	case "@monthly":
+		spec = "0 0 1 * *"
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule '%s': expected 5 fields, got %d", spec, len(fields))
+	}
+
+	bounds := []struct {
+		name      string
+		low, high int
+	}{
+		{"minute", 0, 59},
+		{"hour", 0, 23},
+		{"day of the month", 1, 31},
+		{"month", 1, 12},
+		{"day of the week", 0, 6},
+	}
+
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseField(field, bounds[i].low, bounds[i].high)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule '%s': invalid %s '%s': %w", spec, bounds[i].name, field, err)
+		}
+		sets[i] = set
+	}
+
+	return &cronSchedule{
+		minutes:       sets[0],
+		hours:         sets[1],
+		daysOfMonth:   sets[2],
+		months:        sets[3],
+		daysOfWeek:    sets[4],
+		anyDayOfMonth: fields[2] == "*",
+		anyDayOfWeek:  fields[4] == "*",
+	}, nil
+}
+
+// parseField parses a field of a cron schedule into the set of the values it matches, as a bit set.
+func parseField(field string, low, high int) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(field, ",") {
+		rng, stepSpec, hasStep := strings.Cut(item, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepSpec)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step '%s'", stepSpec)
+			}
+		}
+
+		var from, to int
+		switch {
+		case rng == "*":
+			from, to = low, high
+		case strings.Contains(rng, "-"):
+			first, last, _ := strings.Cut(rng, "-")
+
+			var err error
+			if from, err = strconv.Atoi(first); err != nil {
+				return 0, fmt.Errorf("invalid value '%s'", first)
+			}
+			if to, err = strconv.Atoi(last); err != nil {
+				return 0, fmt.Errorf("invalid value '%s'", last)
+			}
+		default:
+			value, err := strconv.Atoi(rng)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value '%s'", rng)
+			}
+
+			from, to = value, value
+			if hasStep {
+				to = high
+			}
+		}
+
+		if from < low || to > high || from > to {
+			return 0, fmt.Errorf("'%s' is out of the range %d-%d", rng, low, high)
+		}
+
+		for v := from; v <= to; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+
+	return set, nil
+}
+
+// everySchedule is due every interval.
+type everySchedule time.Duration
+
+func (e everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// cronSchedule is due on the minutes that match all of its fields.
+type cronSchedule struct {
+	minutes, hours, daysOfMonth, months, daysOfWeek uint64
+
+	anyDayOfMonth, anyDayOfWeek bool
+}
+
+func (c *cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxScheduleHorizon)
+
+	for t.Before(limit) {
+		if c.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if c.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+
+		if c.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (c *cronSchedule) matchesDay(t time.Time) bool {
+	dayOfMonth := c.daysOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeek := c.daysOfWeek&(1<<uint(t.Weekday())) != 0
+
+	if !c.anyDayOfMonth && !c.anyDayOfWeek {
+		return dayOfMonth || dayOfWeek
+	}
+
+	return dayOfMonth && dayOfWeek
+}
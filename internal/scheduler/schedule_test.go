@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule(t *testing.T) {
+	// 2024-01-15 is a Monday
+	from := time.Date(2024, time.January, 15, 10, 20, 30, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		spec     string
+		expected time.Time
+	}{
+		{
+			name:     "every",
+			spec:     "@every 90m",
+			expected: from.Add(90 * time.Minute),
+		},
+		{
+			name:     "hourly",
+			spec:     "@hourly",
+			expected: time.Date(2024, time.January, 15, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "daily",
+			spec:     "@daily",
+			expected: time.Date(2024, time.January, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "weekly",
+			spec:     "@weekly",
+			expected: time.Date(2024, time.January, 21, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "monthly",
+			spec:     "@monthly",
+			expected: time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "every_minute",
+			spec:     "* * * * *",
+			expected: time.Date(2024, time.January, 15, 10, 21, 0, 0, time.UTC),
+		},
+		{
+			name:     "later_today",
+			spec:     "30 22 * * *",
+			expected: time.Date(2024, time.January, 15, 22, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "tomorrow",
+			spec:     "0 3 * * *",
+			expected: time.Date(2024, time.January, 16, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "step",
+			spec:     "*/15 * * * *",
+			expected: time.Date(2024, time.January, 15, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "list_and_range",
+			spec:     "0 1,4-6 * * *",
+			expected: time.Date(2024, time.January, 16, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "day_of_the_week",
+			spec:     "0 0 * * 6",
+			expected: time.Date(2024, time.January, 20, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "day_of_the_month_or_day_of_the_week",
+			spec:     "0 0 17 * 6",
+			expected: time.Date(2024, time.January, 17, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "month",
+			spec:     "0 0 29 2 *",
+			expected: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "never_due",
+			spec:     "0 0 30 2 *",
+			expected: time.Time{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(test.spec)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, schedule.Next(from))
+		})
+	}
+}
+
+func TestParseInvalidSchedule(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        string
+		expectedErr string
+	}{
+		{
+			name:        "empty",
+			spec:        "",
+			expectedErr: "invalid schedule '': expected 5 fields, got 0",
+		},
+		{
+			name:        "too_many_fields",
+			spec:        "0 0 * * * *",
+			expectedErr: "invalid schedule '0 0 * * * *': expected 5 fields, got 6",
+		},
+		{
+			name:        "out_of_range",
+			spec:        "60 * * * *",
+			expectedErr: "invalid schedule '60 * * * *': invalid minute '60': '60' is out of the range 0-59",
+		},
+		{
+			name:        "inverted_range",
+			spec:        "* 5-1 * * *",
+			expectedErr: "invalid schedule '* 5-1 * * *': invalid hour '5-1': '5-1' is out of the range 0-23",
+		},
+		{
+			name:        "invalid_value",
+			spec:        "* * x * *",
+			expectedErr: "invalid schedule '* * x * *': invalid day of the month 'x': invalid value 'x'",
+		},
+		{
+			name:        "invalid_step",
+			spec:        "*/0 * * * *",
+			expectedErr: "invalid schedule '*/0 * * * *': invalid minute '*/0': invalid step '0'",
+		},
+		{
+			name:        "interval_too_short",
+			spec:        "@every 10ms",
+			expectedErr: "invalid schedule '@every 10ms': the interval must be at least one second",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseSchedule(test.spec)
+			require.EqualError(t, err, test.expectedErr)
+		})
+	}
+}
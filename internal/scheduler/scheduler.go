@@ -0,0 +1,161 @@
+package scheduler
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var (
+	taskRunsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduled_task_runs_total",
+		Help: "The total number of runs of the scheduled background tasks (e.g. the maintenance tasks of the datastore), labeled by task and by result ('success' or 'error').",
+	}, []string{"task", "result"})
+
+	taskDurationHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:                            "scheduled_task_duration_ms",
+		Help:                            "The duration (in ms) of the runs of the scheduled background tasks, labeled by task.",
+		Buckets:                         []float64{10, 100, 1000, 10000, 60000, 300000, 1800000},
+		NativeHistogramBucketFactor:     1.1,
+		NativeHistogramMaxBucketNumber:  100,
+		NativeHistogramMinResetDuration: time.Hour,
+	}, []string{"task"})
+)
+
+// A Task is a background task run by a Scheduler.
+type Task struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// A Scheduler runs a set of tasks, one after the other, every time its schedule is due. A random delay of up
+// to the jitter is added to each run, so that the servers sharing a datastore don't all run the same tasks
+// at the same time.
+type Scheduler struct {
+	schedule Schedule
+	tasks    []Task
+	jitter   time.Duration
+	logger   logger.Logger
+
+	now    func() time.Time
+	random func() float64
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type SchedulerOption func(s *Scheduler)
+
+// WithJitter sets the maximum random delay added to each run.
+func WithJitter(jitter time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.jitter = jitter
+	}
+}
+
+func WithLogger(logger logger.Logger) SchedulerOption {
+	return func(s *Scheduler) {
+		s.logger = logger
+	}
+}
+
+func New(schedule Schedule, tasks []Task, opts ...SchedulerOption) *Scheduler {
+	s := &Scheduler{
+		schedule: schedule,
+		tasks:    tasks,
+		logger:   logger.NewNoopLogger(),
+		now:      time.Now,
+		random:   rand.Float64,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Start runs the tasks in the background until Stop is called.
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.loop(ctx)
+	}()
+}
+
+// Stop stops the scheduler, cancelling the tasks that are running and waiting for them to return.
+func (s *Scheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context) {
+	for {
+		now := s.now()
+
+		next := s.schedule.Next(now)
+		if next.IsZero() {
+			s.logger.Warn("the schedule of the background tasks is never due")
+			return
+		}
+
+		delay := next.Sub(now) + s.jitterDelay()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.RunTasks(ctx)
+	}
+}
+
+func (s *Scheduler) jitterDelay() time.Duration {
+	if s.jitter <= 0 {
+		return 0
+	}
+
+	return time.Duration(s.random() * float64(s.jitter))
+}
+
+// RunTasks runs every task once, one after the other. A task that fails is logged, and doesn't prevent the
+// next ones from running.
+func (s *Scheduler) RunTasks(ctx context.Context) {
+	for _, task := range s.tasks {
+		if ctx.Err() != nil {
+			return
+		}
+
+		start := time.Now()
+		err := task.Run(ctx)
+		duration := time.Since(start)
+
+		taskDurationHistogram.WithLabelValues(task.Name).Observe(float64(duration.Milliseconds()))
+
+		if err != nil {
+			taskRunsCounter.WithLabelValues(task.Name, "error").Inc()
+			s.logger.Error("background task failed", zap.String("task", task.Name), zap.Duration("duration", duration), zap.Error(err))
+			continue
+		}
+
+		taskRunsCounter.WithLabelValues(task.Name, "success").Inc()
+		s.logger.Info("background task completed", zap.String("task", task.Name), zap.Duration("duration", duration))
+	}
+}
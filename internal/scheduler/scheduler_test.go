@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunTasks(t *testing.T) {
+	var ran []string
+	s := New(everySchedule(time.Hour), []Task{
+		{
+			Name: "failing",
+			Run: func(ctx context.Context) error {
+				ran = append(ran, "failing")
+				return errors.New("failed")
+			},
+		},
+		{
+			Name: "succeeding",
+			Run: func(ctx context.Context) error {
+				ran = append(ran, "succeeding")
+				return nil
+			},
+		},
+	})
+
+	s.RunTasks(context.Background())
+	require.Equal(t, []string{"failing", "succeeding"}, ran)
+}
+
+func TestStartStop(t *testing.T) {
+	var runs atomic.Int32
+	s := New(everySchedule(time.Second), []Task{
+		{
+			Name: "counting",
+			Run: func(ctx context.Context) error {
+				runs.Add(1)
+				return nil
+			},
+		},
+	})
+
+	s.Start()
+	require.Eventually(t, func() bool { return runs.Load() >= 1 }, 5*time.Second, 10*time.Millisecond)
+
+	s.Stop()
+	stopped := runs.Load()
+
+	time.Sleep(1500 * time.Millisecond)
+	require.Equal(t, stopped, runs.Load())
+}
+
+func TestJitter(t *testing.T) {
+	s := New(everySchedule(time.Hour), nil, WithJitter(10*time.Minute))
+	s.random = func() float64 { return 0.5 }
+
+	require.Equal(t, 5*time.Minute, s.jitterDelay())
+
+	s = New(everySchedule(time.Hour), nil)
+	require.Zero(t, s.jitterDelay())
+}
@@ -3,11 +3,20 @@
 package config
 
 import (
+	"compress/gzip"
 	"errors"
 	"fmt"
 	"math"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/openfga/openfga/internal/scheduler"
+	"github.com/openfga/openfga/pkg/middleware/compression"
+	httpmiddleware "github.com/openfga/openfga/pkg/middleware/http"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/typesystem"
 )
 
 const (
@@ -21,12 +30,235 @@ const (
 	DefaultListObjectsMaxResults            = 1000
 	DefaultMaxConcurrentReadsForCheck       = math.MaxUint32
 	DefaultMaxConcurrentReadsForListObjects = math.MaxUint32
+	DefaultCheckUsersetBatchSize            = 100
 
 	DefaultCheckQueryCacheLimit  = 10000
 	DefaultCheckQueryCacheTTL    = 10 * time.Second
 	DefaultCheckQueryCacheEnable = false
+
+	DefaultCheckQueryCacheHotKeyQPSThreshold  = 0
+	DefaultCheckQueryCacheHotKeyTTLMultiplier = 3
+
+	DefaultCheckQueryCacheNegativeLimit = 0
+	DefaultCheckQueryCacheNegativeTTL   = 0
+
+	DefaultCheckQueryCacheChangelogInterval = 0
+
+	DefaultLogSamplingInitial    = 100
+	DefaultLogSamplingThereafter = 100
+	DefaultLogSamplingLevel      = "info"
+
+	DefaultAuthnSignedRequestMaxClockSkew = 5 * time.Minute
+	DefaultAuthnSignedRequestMaxNonces    = 1000000
+
+	DefaultHTTPMaxConcurrentStreams = 250
+
+	DefaultListenerAcceptBurst = 100
+
+	DefaultGRPCKeepaliveMaxConnectionAge      = 30 * time.Minute
+	DefaultGRPCKeepaliveMaxConnectionAgeGrace = 30 * time.Second
+	DefaultGRPCKeepaliveTime                  = 2 * time.Hour
+	DefaultGRPCKeepaliveTimeout               = 20 * time.Second
+	DefaultGRPCKeepaliveEnforcementMinTime    = 5 * time.Minute
+
+	DefaultMetricsStoreLabelsTopK = 10
+
+	DefaultCheckProfilingLatencyThreshold = time.Second
+	DefaultCheckProfilingSampleRate       = 0.01
+
+	DefaultCaptureSampleRate = 0.01
+
+	DefaultInlineModelMaxSizeInBytes = 64 * 1_024 // 64 KB
+
+	DefaultRelationUsageWindow       = 24 * time.Hour
+	DefaultRelationUsageMaxRelations = 10_000
+
+	DefaultBatchCheckMaxChecks           = 50
+	DefaultBatchCheckMaxConcurrentChecks = 10
+	DefaultBatchCheckMaxDatastoreQueries = 5_000
+
+	DefaultSLOAvailabilityObjective = 0.999
+	DefaultSLOLatencyObjective      = 0.99
+	DefaultSLOLatencyThreshold      = 500 * time.Millisecond
+	DefaultSLOWindow                = time.Hour
+
+	DefaultMaintenanceMessage    = "the server is undergoing maintenance"
+	DefaultMaintenanceRetryAfter = 30 * time.Second
+
+	DefaultLoadSheddingRetryAfter = time.Second
+
+	DefaultReadGuardrailsLargeStoreTupleCount = 1_000_000
+
+	DefaultContinuationTokenTTL = 24 * time.Hour
+
+	DefaultStoreTombstoneTTL = time.Hour
+
+	DefaultClusterProbeInterval   = 5 * time.Second
+	DefaultClusterDispatchRetries = 2
+
+	DefaultShadowCheckSampleRate     = 0.1
+	DefaultShadowCheckTimeout        = 3 * time.Second
+	DefaultShadowCheckMaxConcurrency = 100
+
+	DefaultShadowDatastoreTimeout        = 5 * time.Second
+	DefaultShadowDatastoreMaxConcurrency = 100
+
+	DefaultDatastoreReplicasHedgingBudget = 0.1
+
+	DefaultDatastoreLatestModelIDCacheTTL = 5 * time.Second
+
+	DefaultDatastoreExpiredTuplesReapInterval = time.Minute
+
+	DefaultDatastoreCircuitBreakerErrorRateThreshold = 0.5
+	DefaultDatastoreCircuitBreakerMinRequests        = 20
+	DefaultDatastoreCircuitBreakerWindow             = 10 * time.Second
+	DefaultDatastoreCircuitBreakerOpenDuration       = 30 * time.Second
+
+	DefaultDatastoreMaintenanceJitter = 5 * time.Minute
 )
 
+// ShadowDatastoreConfig defines the configuration of a shadow datastore, to which every write is mirrored
+// and against which the reads are compared with the primary datastore, e.g. to validate a migration to a
+// new datastore before switching to it.
+type ShadowDatastoreConfig struct {
+	// Engine is the datastore engine of the shadow datastore. An empty engine disables the shadow datastore.
+	Engine   string
+	URI      string
+	Username string
+	Password string
+
+	// Timeout is the maximum duration of a read compared against the shadow datastore.
+	Timeout time.Duration
+
+	// MaxConcurrency is the maximum number of reads compared at any time. The reads that would exceed it
+	// aren't compared.
+	MaxConcurrency uint32
+}
+
+// DatastoreReplicasConfig defines the configuration of the read replicas of the datastore, which serve the
+// reads of tuples that tolerate stale data.
+type DatastoreReplicasConfig struct {
+	// URIs are the connection uris of the replicas, which have the engine and the credentials of the
+	// datastore. No URIs disables the replicas.
+	URIs []string
+
+	// HedgeAfter is the latency after which the reads of the replicas on the paths of Check are hedged with a
+	// read of another replica (or of the datastore, if there's a single replica). 0 disables hedging.
+	HedgeAfter time.Duration
+
+	// HedgingBudget is the maximum fraction of the reads of the replicas that can be hedged.
+	HedgingBudget float64
+}
+
+// DatastoreResidencyConfig defines the configuration of the data residency of the stores, which pins the data
+// of every store to the datastore of the region it resides in. The datastore is the one of the local region.
+type DatastoreResidencyConfig struct {
+	// Region is the region the server runs in. The server refuses to read the data of the stores of the other
+	// regions. An empty region disables the data residency.
+	Region string
+
+	// Regions are the datastores of the other regions, in the form '<region>=<uri>'. They have the engine and
+	// the credentials of the datastore.
+	Regions []string
+}
+
+// ParseRegions parses the connection uris of the datastores of the other regions, keyed by region.
+func (cfg DatastoreResidencyConfig) ParseRegions() (map[string]string, error) {
+	regions := make(map[string]string, len(cfg.Regions))
+	for _, region := range cfg.Regions {
+		name, uri, ok := strings.Cut(region, "=")
+		if !ok || name == "" || uri == "" {
+			return nil, fmt.Errorf("invalid 'datastore.residency.regions' item '%s': must be in the form '<region>=<uri>'", region)
+		}
+
+		if _, ok := regions[name]; ok || name == cfg.Region {
+			return nil, fmt.Errorf("invalid 'datastore.residency.regions' item '%s': the region '%s' is configured more than once", region, name)
+		}
+
+		regions[name] = uri
+	}
+
+	return regions, nil
+}
+
+// DatastoreRoutingConfig defines the configuration of the routing of the stores to other datastores than the
+// datastore, e.g. to partition the stores across several databases. The stores that match no route are served
+// by the datastore.
+type DatastoreRoutingConfig struct {
+	// Stores are the datastores of single stores, in the form '<store ID>=<uri>'. They take precedence over the
+	// prefixes.
+	Stores []string
+
+	// Prefixes are the datastores of the stores whose ID starts with a prefix, in the form '<prefix>=<uri>'. The
+	// longest matching prefix wins.
+	Prefixes []string
+}
+
+// ParseStores parses the connection uris of the datastores of single stores, keyed by store ID.
+func (cfg DatastoreRoutingConfig) ParseStores() (map[string]string, error) {
+	return parseRoutes("datastore.routing.stores", "<store ID>=<uri>", cfg.Stores)
+}
+
+// ParsePrefixes parses the connection uris of the datastores of the stores whose ID starts with a prefix, keyed
+// by prefix.
+func (cfg DatastoreRoutingConfig) ParsePrefixes() (map[string]string, error) {
+	return parseRoutes("datastore.routing.prefixes", "<prefix>=<uri>", cfg.Prefixes)
+}
+
+func parseRoutes(name, form string, routes []string) (map[string]string, error) {
+	parsed := make(map[string]string, len(routes))
+	for _, route := range routes {
+		key, uri, ok := strings.Cut(route, "=")
+		if !ok || key == "" || uri == "" {
+			return nil, fmt.Errorf("invalid '%s' item '%s': must be in the form '%s'", name, route, form)
+		}
+
+		if _, ok := parsed[key]; ok {
+			return nil, fmt.Errorf("invalid '%s' item '%s': '%s' is routed more than once", name, route, key)
+		}
+
+		parsed[key] = uri
+	}
+
+	return parsed, nil
+}
+
+// DatastoreCircuitBreakerConfig defines the configuration of the circuit breakers of the stores, which fail
+// fast the datastore operations of the stores whose datastore operations fail too often, so that one store
+// with a broken shard doesn't tie up the server while the other stores keep being served.
+type DatastoreCircuitBreakerConfig struct {
+	Enabled bool
+
+	// ErrorRateThreshold is the rate (between 0 and 1) of the datastore operations of a store that fail over a
+	// window above which the circuit breaker of the store opens.
+	ErrorRateThreshold float64
+
+	// MinRequests is the minimum number of datastore operations of a store over a window before its error
+	// rate can open its circuit breaker.
+	MinRequests int
+
+	// Window is the duration of the windows the error rates are measured over.
+	Window time.Duration
+
+	// OpenDuration is the duration the operations of a store fail fast for before the datastore is probed.
+	OpenDuration time.Duration
+}
+
+// DatastoreMaintenanceConfig defines the configuration of the maintenance tasks of the datastore (e.g.
+// 'vacuum' and 'analyze' for Postgres), which the server runs in the background on a schedule.
+type DatastoreMaintenanceConfig struct {
+	// Schedule is the cron-like schedule of the maintenance tasks, e.g. '0 3 * * *' or '@every 6h'. An empty
+	// schedule disables them.
+	Schedule string
+
+	// Jitter is the maximum random delay added to each run, so that the servers sharing a datastore don't
+	// all run the maintenance tasks at the same time.
+	Jitter time.Duration
+
+	// Tasks are the names of the maintenance tasks to run. If empty, all the tasks of the datastore run.
+	Tasks []string
+}
+
 type DatastoreMetricsConfig struct {
 	// Enabled enables export of the Datastore metrics.
 	Enabled bool
@@ -34,7 +266,7 @@ type DatastoreMetricsConfig struct {
 
 // DatastoreConfig defines OpenFGA server configurations for datastore specific settings.
 type DatastoreConfig struct {
-	// Engine is the datastore engine to use (e.g. 'memory', 'postgres', 'mysql')
+	// Engine is the datastore engine to use (e.g. 'memory', 'postgres', 'mysql', 'sqlite')
 	Engine   string
 	URI      string
 	Username string
@@ -46,6 +278,14 @@ type DatastoreConfig struct {
 	// such as type definitions.
 	MaxCacheSize int
 
+	// LatestModelIDCacheTTL is the duration the ID of the latest authorization model of the stores is cached
+	// for. The models written through another server are only seen once it expires. 0 disables the cache.
+	LatestModelIDCacheTTL time.Duration
+
+	// ExpiredTuplesReapInterval is the interval at which the expired tuples are deleted from the datastore. The
+	// reads treat them as deleted regardless. 0 disables their deletion.
+	ExpiredTuplesReapInterval time.Duration
+
 	// MaxOpenConns is the maximum number of open connections to the database.
 	MaxOpenConns int
 
@@ -61,12 +301,81 @@ type DatastoreConfig struct {
 
 	// Metrics is configuration for the Datastore metrics.
 	Metrics DatastoreMetricsConfig
+
+	// AutoMigrate applies the pending schema migrations at startup, before serving. Otherwise the server
+	// refuses to start against a datastore whose schema isn't at the version it expects.
+	AutoMigrate bool
+
+	// Shadow is the configuration of the shadow datastore.
+	Shadow ShadowDatastoreConfig
+
+	// Maintenance is the configuration of the maintenance tasks of the datastore.
+	Maintenance DatastoreMaintenanceConfig
+
+	// Replicas is the configuration of the read replicas of the datastore.
+	Replicas DatastoreReplicasConfig
+
+	// Routing is the configuration of the routing of the stores to other datastores.
+	Routing DatastoreRoutingConfig
+
+	// Residency is the configuration of the data residency of the stores.
+	Residency DatastoreResidencyConfig
+
+	// CircuitBreaker is the configuration of the circuit breakers of the stores.
+	CircuitBreaker DatastoreCircuitBreakerConfig
 }
 
 // GRPCConfig defines OpenFGA server configurations for grpc server specific settings.
 type GRPCConfig struct {
 	Addr string
 	TLS  *TLSConfig
+
+	// StreamCompressor is the compressor of the responses of the streaming methods, e.g. 'gzip', used if the
+	// clients support it. If empty, the responses are compressed like the requests.
+	StreamCompressor string
+
+	// GzipLevel is the level of the gzip compression (see compress/gzip).
+	GzipLevel int
+
+	Keepalive GRPCKeepaliveConfig
+	Listener  ListenerConfig
+}
+
+// ListenerConfig defines the connections a listener sheds to protect the server against connection floods.
+// The shed connections are closed as soon as they're accepted.
+type ListenerConfig struct {
+	// MaxConnections is the maximum number of open connections. If 0, it's unbounded.
+	MaxConnections int
+
+	// AcceptRate is the maximum rate (per second) of the accepted connections, which can be exceeded by bursts
+	// of up to AcceptBurst connections. If 0, it's unbounded.
+	AcceptRate  float64
+	AcceptBurst int
+}
+
+// GRPCKeepaliveConfig defines the keepalive and the connection management policies of the grpc server.
+type GRPCKeepaliveConfig struct {
+	// MaxConnectionIdle is the duration after which an idle connection is closed. If 0, it's never closed.
+	MaxConnectionIdle time.Duration
+
+	// MaxConnectionAge is the maximum duration of a connection, after which the clients are asked to reconnect
+	// so that the long-lived connections rebalance across the servers. If 0, it's unbounded.
+	MaxConnectionAge time.Duration
+
+	// MaxConnectionAgeGrace is the duration for which the RPCs in progress on a connection that reached its
+	// maximum age can complete, before the connection is forcibly closed. If 0, it's unbounded.
+	MaxConnectionAgeGrace time.Duration
+
+	// Time is the duration after which the server pings an idle connection, and Timeout the duration it waits
+	// for the response before closing it.
+	Time    time.Duration
+	Timeout time.Duration
+
+	// EnforcementMinTime is the minimum duration between the keepalive pings of the clients, and
+	// EnforcementPermitWithoutStream allows them to ping without any RPC in progress. The connections of the
+	// clients that don't comply are closed.
+	EnforcementMinTime             time.Duration
+	EnforcementPermitWithoutStream bool
 }
 
 // HTTPConfig defines OpenFGA server configurations for HTTP server specific settings.
@@ -81,6 +390,24 @@ type HTTPConfig struct {
 
 	CORSAllowedOrigins []string
 	CORSAllowedHeaders []string
+
+	Listener ListenerConfig
+
+	// H2CEnabled serves HTTP/2 over cleartext (h2c), besides HTTP/1.1. HTTP/2 is always served over TLS.
+	H2CEnabled bool
+
+	// MaxConcurrentStreams is the maximum number of concurrent streams of each HTTP/2 connection.
+	MaxConcurrentStreams uint32
+
+	// ForwardedHeaders are the headers of the HTTP requests forwarded as is to the grpc endpoint as metadata,
+	// beyond the default ones, e.g. to propagate tenant IDs. The metadata that the endpoint responds with is
+	// returned as HTTP headers.
+	ForwardedHeaders []string
+
+	// Middlewares are the HTTP middlewares applied to the routes of the HTTP gateway they match, before the
+	// requests are proxied to the grpc endpoint. They can only be set programmatically, by the users that run
+	// the server with their own Config.
+	Middlewares []httpmiddleware.RouteMiddleware `mapstructure:"-" json:"-"`
 }
 
 // TLSConfig defines configuration specific to Transport Layer Security (TLS) settings.
@@ -94,10 +421,17 @@ type TLSConfig struct {
 type AuthnConfig struct {
 
 	// Method is the authentication method that should be enforced (e.g. 'none', 'preshared',
-	// 'oidc')
-	Method                   string
-	*AuthnOIDCConfig         `mapstructure:"oidc"`
-	*AuthnPresharedKeyConfig `mapstructure:"preshared"`
+	// 'oidc', 'signed')
+	Method string
+
+	// UnauthenticatedMethods is a list of full gRPC method names (e.g. '/grpc.health.v1.Health/Check')
+	// that don't require authentication. A method ending in '*' matches every method with that prefix.
+	// HTTP routes are served by the gRPC methods they map to.
+	UnauthenticatedMethods []string
+
+	*AuthnOIDCConfig          `mapstructure:"oidc"`
+	*AuthnPresharedKeyConfig  `mapstructure:"preshared"`
+	*AuthnSignedRequestConfig `mapstructure:"signed"`
 }
 
 // AuthnOIDCConfig defines configurations for the 'oidc' method of authentication.
@@ -112,6 +446,90 @@ type AuthnPresharedKeyConfig struct {
 	Keys []string
 }
 
+// AuthnSignedRequestConfig defines configurations for the 'signed' method of authentication.
+type AuthnSignedRequestConfig struct {
+	// SigningKeys define the keys, of the form '<key id>:<secret>', to verify request signatures against.
+	SigningKeys []string
+
+	// MaxClockSkew is the maximum allowed difference between the time at which a request was signed
+	// and the time at which it is received. Nonces are remembered for twice this duration.
+	MaxClockSkew time.Duration
+
+	// MaxNonces is the maximum number of nonces remembered, i.e. the maximum number of requests accepted
+	// within twice MaxClockSkew. The requests beyond it are rejected rather than forgetting nonces.
+	MaxNonces int
+}
+
+// RequestEnrichmentConfig defines OpenFGA server configurations for enriching requests with data derived
+// from the auth claims of the caller.
+type RequestEnrichmentConfig struct {
+	// ContextualTuples is a list of CEL expressions that derive contextual tuples from the auth claims of
+	// the caller. They are evaluated for every Check and ListObjects request.
+	ContextualTuples []string
+}
+
+// AuthorizationModelValidationConfig defines OpenFGA server configurations for validating the
+// authorization models written to the stores beyond their validity.
+type AuthorizationModelValidationConfig struct {
+	// Rules is a list of CEL expressions that every authorization model written must satisfy, e.g. naming
+	// conventions or mandatory relations.
+	Rules []string
+
+	// Plugins is a list of paths of Go plugins that export a 'Validator' of the authorization models.
+	Plugins []string
+
+	// ReservedTypeNames is a list of regular expressions that the type names must not match.
+	ReservedTypeNames []string
+
+	// ReservedRelationNames is a list of regular expressions that the relation names must not match.
+	ReservedRelationNames []string
+
+	// MaxTypeNameLength is the maximum length of the type names. 0 means no limit.
+	MaxTypeNameLength int
+
+	// MaxRelationNameLength is the maximum length of the relation names. 0 means no limit.
+	MaxRelationNameLength int
+
+	// DisallowWildcards rejects the models whose relations can be directly related to a public wildcard
+	// (e.g. '[user:*]').
+	DisallowWildcards bool
+
+	// DisallowIntersectionAndExclusion rejects the models whose relations are defined with an intersection
+	// ('and') or an exclusion ('but not').
+	DisallowIntersectionAndExclusion bool
+}
+
+// NamingPolicy returns the naming policy of the authorization models, or nil if there's none.
+func (cfg AuthorizationModelValidationConfig) NamingPolicy() (*typesystem.NamingPolicy, error) {
+	if len(cfg.ReservedTypeNames) == 0 && len(cfg.ReservedRelationNames) == 0 &&
+		cfg.MaxTypeNameLength == 0 && cfg.MaxRelationNameLength == 0 {
+		return nil, nil
+	}
+
+	policy := &typesystem.NamingPolicy{
+		MaxTypeNameLength:     cfg.MaxTypeNameLength,
+		MaxRelationNameLength: cfg.MaxRelationNameLength,
+	}
+
+	for _, pattern := range cfg.ReservedTypeNames {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("config 'authorizationModelValidation.reservedTypeNames' has an invalid regular expression '%s': %w", pattern, err)
+		}
+		policy.ReservedTypeNames = append(policy.ReservedTypeNames, re)
+	}
+
+	for _, pattern := range cfg.ReservedRelationNames {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("config 'authorizationModelValidation.reservedRelationNames' has an invalid regular expression '%s': %w", pattern, err)
+		}
+		policy.ReservedRelationNames = append(policy.ReservedRelationNames, re)
+	}
+
+	return policy, nil
+}
+
 // LogConfig defines OpenFGA server configurations for log specific settings. For production we
 // recommend using the 'json' log format.
 type LogConfig struct {
@@ -120,6 +538,17 @@ type LogConfig struct {
 
 	// Level is the log level to use in the log output (e.g. 'none', 'debug', or 'info')
 	Level string
+
+	Sampling LogSamplingConfig
+}
+
+// LogSamplingConfig defines the sampling of the high-volume log messages: every second, the first Initial
+// messages with the same level and text are logged, then one in Thereafter. Only the messages of the levels
+// up to Level (e.g. 'debug' and 'info' for 'info') are sampled. A Thereafter of 0 disables sampling.
+type LogSamplingConfig struct {
+	Initial    uint32
+	Thereafter uint32
+	Level      string
 }
 
 type TraceConfig struct {
@@ -150,11 +579,260 @@ type ProfilerConfig struct {
 	Addr    string
 }
 
+// AdminConfig defines the configuration of the admin HTTP server, which exposes the operational controls
+// of the server (e.g. the maintenance mode).
+type AdminConfig struct {
+	Enabled bool
+	Addr    string
+
+	// GraphQLEnabled serves the read APIs as a GraphQL endpoint on the admin server, at '/admin/graphql'.
+	GraphQLEnabled bool
+
+	// Keys are the preshared keys of the operators of the server ('Authorization: Bearer <key>'), which
+	// must differ from the ones of the API. The other callers of the API are forbidden from the admin API,
+	// unless they have the RequiredClaim.
+	Keys []string
+
+	// RequiredClaim, of the form '<name>=<value>' (e.g. 'role=admin'), is the claim the callers of the API
+	// authenticated with the 'oidc' method must have to be served by the admin API.
+	RequiredClaim string
+}
+
+// AuditLogConfig defines the configuration of the audit log, which logs the changes to the stores (e.g. the
+// stores created and deleted, the models and the tuples written) as they're published on the event bus.
+type AuditLogConfig struct {
+	Enabled bool
+}
+
+// MaintenanceConfig defines the configuration of the maintenance mode.
+type MaintenanceConfig struct {
+	// Enabled starts the server in maintenance, i.e. rejecting the requests to every store.
+	Enabled bool
+
+	// Message is the message of the errors returned while undergoing maintenance, unless another one is
+	// provided when maintenance is enabled.
+	Message string
+
+	// RetryAfter is the delay after which the clients may retry the rejected requests, unless another one
+	// is provided when maintenance is enabled.
+	RetryAfter time.Duration
+}
+
+// LoadSheddingConfig defines the configuration of the limiters that shed load. The rejected requests carry a
+// hint of when to retry them (see package loadshed).
+type LoadSheddingConfig struct {
+	// MaxConcurrentRequests is the maximum number of requests served concurrently. The other ones are
+	// rejected with a RESOURCE_EXHAUSTED error. 0 means no limit.
+	MaxConcurrentRequests uint32
+
+	// RetryAfter is the delay after which the clients may retry the rejected requests.
+	RetryAfter time.Duration
+}
+
+// ReadGuardrailsConfig defines the guardrails that reject the overly broad Read requests, to protect the
+// datastore from the accidental scans of every tuple of a store.
+type ReadGuardrailsConfig struct {
+	// RejectUnfiltered rejects the Read requests without a tuple key, which read every tuple of the store.
+	RejectUnfiltered bool
+
+	// MinFilterDimensions is the minimum number of dimensions (the object type, the object ID, the relation
+	// and the user) that the Read requests to the large stores must filter on. 0 disables the guardrail.
+	MinFilterDimensions int
+
+	// LargeStoreTupleCount is the number of tuples above which a store is large. 0 makes every store large,
+	// and so do the datastores which don't maintain the statistics of the stores.
+	LargeStoreTupleCount int64
+}
+
+// ContinuationTokenConfig defines the configuration of the continuation tokens of the paginated APIs.
+type ContinuationTokenConfig struct {
+	// EncryptionKey, if set, encrypts the continuation tokens with AES-GCM and binds them to the store they're
+	// issued for, instead of encoding them in base64.
+	EncryptionKey string
+
+	// PreviousEncryptionKeys are the keys that encrypted the continuation tokens before EncryptionKey, whose
+	// tokens are still accepted. A key can be removed once the tokens it encrypted have expired.
+	PreviousEncryptionKeys []string
+
+	// TTL is how long the encrypted continuation tokens are valid for. 0 means they never expire.
+	TTL time.Duration
+}
+
+// ClusterConfig defines the configuration of the clustering mode, in which the Check sub-problems are
+// dispatched to the member of the cluster that owns the hash of their store and object, to concentrate the
+// results of each sub-problem in the cache of a single member.
+type ClusterConfig struct {
+	Enabled bool
+
+	// AdvertiseAddress is the gRPC address at which the other members reach this one, e.g. 'openfga-0:8081'.
+	AdvertiseAddress string
+
+	// Peers are the gRPC addresses of the members of the cluster. They may include the AdvertiseAddress.
+	Peers []string
+
+	// ProbeInterval is the interval at which the health of the peers is probed. The unhealthy peers don't
+	// own any sub-problem until they're healthy again.
+	ProbeInterval time.Duration
+
+	// DispatchRetries is the number of times a dispatch to an unavailable peer is retried before the
+	// sub-problem is resolved locally.
+	DispatchRetries uint32
+}
+
+// ShadowCheckConfig defines the configuration of the shadow evaluation of Checks against candidate
+// authorization models.
+type ShadowCheckConfig struct {
+	Enabled bool
+
+	// Candidates are the candidate authorization models, in the form '<store ID>:<model ID>'. The candidates
+	// can also be changed while serving through the admin API.
+	Candidates []string
+
+	// SampleRate is the fraction (between 0 and 1) of the Checks of the stores with a candidate model that
+	// are shadow evaluated.
+	SampleRate float64
+
+	// Timeout is the timeout of the shadow evaluation of a Check.
+	Timeout time.Duration
+
+	// MaxConcurrency is the maximum number of shadow evaluations in flight.
+	MaxConcurrency uint32
+}
+
+// ParseCandidates parses the candidate authorization models, keyed by store ID.
+func (cfg ShadowCheckConfig) ParseCandidates() (map[string]string, error) {
+	candidates := make(map[string]string, len(cfg.Candidates))
+	for _, candidate := range cfg.Candidates {
+		storeID, modelID, ok := strings.Cut(candidate, ":")
+		if !ok || storeID == "" || modelID == "" {
+			return nil, fmt.Errorf("invalid 'shadowCheck.candidates' item '%s': must be in the form '<store ID>:<model ID>'", candidate)
+		}
+
+		candidates[storeID] = modelID
+	}
+
+	return candidates, nil
+}
+
+// CheckProfilingConfig defines the configuration of the sampling profiler of expensive Checks.
+type CheckProfilingConfig struct {
+	Enabled bool
+
+	// LatencyThreshold is the latency above which the resolution tree of a sampled Check is written out.
+	LatencyThreshold time.Duration
+
+	// SampleRate is the fraction (between 0 and 1) of the Checks that are sampled.
+	SampleRate float64
+
+	// FilePath is the file to which the resolution trees are appended, one JSON document per line. If empty,
+	// the resolution trees are logged.
+	FilePath string
+}
+
+// CaptureConfig defines the configuration of the capture of a sample of the Check and ListObjects requests,
+// to replay them against a snapshot of the stores (see the replay command).
+type CaptureConfig struct {
+	Enabled bool
+
+	// SampleRate is the fraction (between 0 and 1) of the requests that are captured.
+	SampleRate float64
+
+	// FilePath is the file to which the captured requests are appended, one JSON document per line.
+	FilePath string
+
+	// RedactionKey, if set, is the key the IDs of the objects and of the users of the captured requests are
+	// redacted with. The snapshot the captures are replayed against must be redacted with the same key.
+	RedactionKey string
+}
+
+// InlineModelConfig defines the configuration of the Check and ListObjects requests evaluated against an
+// authorization model supplied inline, which isn't persisted, e.g. by the tools that experiment with changes to
+// a model against the tuples of a store.
+type InlineModelConfig struct {
+	Enabled bool
+
+	// MaxSizeInBytes is the maximum size of the JSON encoding of the inline models.
+	MaxSizeInBytes int
+}
+
+// BatchCheckConfig defines the configuration of the BatchCheck calls, which check several tuple keys at once.
+type BatchCheckConfig struct {
+	// MaxChecks is the maximum number of tuple keys of a call.
+	MaxChecks int
+
+	// MaxConcurrentChecks is the maximum number of tuple keys of a call that are checked concurrently.
+	MaxConcurrentChecks int
+
+	// MaxDatastoreQueries is the budget of datastore queries shared by the Checks of a call. Once it's spent,
+	// the Checks left fail. 0 disables the budget.
+	MaxDatastoreQueries uint32
+}
+
+// RelationUsageConfig defines the configuration of the counting of the Checks of every relation, and of the
+// tuples written with it, which finds the relations that are unused before they're removed from a model.
+type RelationUsageConfig struct {
+	Enabled bool
+
+	// Window is the duration of the rolling window the relations are counted over.
+	Window time.Duration
+
+	// MaxRelations is the maximum number of relations, across all the stores, that are counted.
+	MaxRelations int
+}
+
+// SLOConfig defines the service level objectives that every RPC is measured against.
+type SLOConfig struct {
+	Enabled bool
+
+	// AvailabilityObjective is the ratio (between 0 and 1) of RPCs that must not fail because of a server error.
+	AvailabilityObjective float64
+
+	// LatencyObjective is the ratio (between 0 and 1) of RPCs that must complete within the latency threshold.
+	LatencyObjective float64
+
+	// LatencyThreshold is the latency threshold of the methods without a specific one.
+	LatencyThreshold time.Duration
+
+	// MethodLatencyThresholds are the latency thresholds of specific methods, in the form '<method>:<threshold>'
+	// (e.g. 'Check:100ms').
+	MethodLatencyThresholds []string
+
+	// Window is the duration of the sliding window over which the service level indicators are computed.
+	Window time.Duration
+}
+
+// ParseMethodLatencyThresholds parses the MethodLatencyThresholds into a map of method names to thresholds.
+func (cfg SLOConfig) ParseMethodLatencyThresholds() (map[string]time.Duration, error) {
+	thresholds := make(map[string]time.Duration, len(cfg.MethodLatencyThresholds))
+	for _, methodThreshold := range cfg.MethodLatencyThresholds {
+		method, threshold, found := strings.Cut(methodThreshold, ":")
+		if !found || method == "" {
+			return nil, fmt.Errorf("'slo.methodLatencyThresholds' items must be of the form '<method>:<threshold>', got '%s'", methodThreshold)
+		}
+
+		d, err := time.ParseDuration(threshold)
+		if err != nil {
+			return nil, fmt.Errorf("'slo.methodLatencyThresholds' item '%s' has an invalid threshold: %w", methodThreshold, err)
+		}
+
+		thresholds[method] = d
+	}
+
+	return thresholds, nil
+}
+
 // MetricConfig defines configurations for serving custom metrics from OpenFGA.
 type MetricConfig struct {
 	Enabled             bool
 	Addr                string
 	EnableRPCHistograms bool
+
+	// EnableStoreLabels enables metrics labeled by store. To bound their cardinality, only the stores in
+	// StoreLabelsAllowlist and the StoreLabelsTopK busiest stores are labeled with their ID, and every
+	// other store is labeled 'other'.
+	EnableStoreLabels    bool
+	StoreLabelsAllowlist []string
+	StoreLabelsTopK      int
 }
 
 // CheckQueryCache defines configuration for caching when resolving check
@@ -162,6 +840,30 @@ type CheckQueryCache struct {
 	Enabled bool
 	Limit   uint32 // (in items)
 	TTL     time.Duration
+
+	// HotKeyQPSThreshold is the Check QPS above which a relation is considered hot. The results of hot
+	// relations are cached HotKeyTTLMultiplier times longer and concurrent evaluations are collapsed.
+	// A threshold of 0 disables hot key detection.
+	HotKeyQPSThreshold  float64
+	HotKeyTTLMultiplier uint32
+
+	// NegativeLimit is the size limit (in items) of a separate cache for the negative (denied) results, which
+	// the writes invalidate far more often than the positive ones. If 0, they share the cache of the
+	// positive results.
+	NegativeLimit uint32
+
+	// NegativeTTL is the TTL of the negative (denied) results. If 0, it's the TTL of the positive results.
+	NegativeTTL time.Duration
+
+	// ChangelogInterval is the interval at which the changelogs of the stores are read to invalidate the
+	// results that the writes of the other servers sharing the datastore could have changed, which bounds
+	// how stale these results can be. If 0, only the writes of the server itself invalidate its results.
+	ChangelogInterval time.Duration
+
+	// SharedMemcachedServers are the Memcached servers (e.g. 'memcached:11211') of a cache of the results
+	// shared by the servers of a fleet, which is looked up after a miss of the local cache. If empty, the
+	// results aren't shared.
+	SharedMemcachedServers []string
 }
 
 type Config struct {
@@ -189,6 +891,31 @@ type Config struct {
 	// persisting an Authorization Model.
 	MaxAuthorizationModelSizeInBytes int
 
+	// AssertionsCopyForward enables copying the assertions of the latest authorization model of a store
+	// to every new authorization model written to it.
+	AssertionsCopyForward bool
+
+	// SkipIdenticalAuthorizationModels returns the ID of the latest authorization model of a store, instead
+	// of writing a new model, when the model written is identical to it.
+	SkipIdenticalAuthorizationModels bool
+
+	// RejectSelfReferentialTuples rejects the writes of the tuples that relate a userset of an object to
+	// itself (e.g. 'group:eng#member' as a member of 'group:eng').
+	RejectSelfReferentialTuples bool
+
+	// AllowCacheBypass lets the requests with the cachebypass.NoCacheHeader header bypass every cache of
+	// the server, e.g. to verify that a result isn't stale.
+	AllowCacheBypass bool
+
+	// StatusCodeOverrides overrides the gRPC and HTTP status codes of classes of errors, e.g.
+	// 'resource_exhausted=UNAVAILABLE:503' to report the requests shed as unavailable rather than as
+	// throttled (see errors.ParseStatusCodeOverrides).
+	StatusCodeOverrides []string
+
+	// StoreTombstoneTTL is for how long the requests to a store deleted through the server are rejected
+	// with a 'store_deleted' error, rather than served as if the store had no data. 0 disables it.
+	StoreTombstoneTTL time.Duration
+
 	// MaxConcurrentReadsForListObjects defines the maximum number of concurrent database reads
 	// allowed in ListObjects queries
 	MaxConcurrentReadsForListObjects uint32
@@ -197,6 +924,10 @@ type Config struct {
 	// Check queries
 	MaxConcurrentReadsForCheck uint32
 
+	// CheckUsersetBatchSize defines the maximum number of usersets of a relation (e.g. 'team:1#member',
+	// 'team:2#member') looked up in a single database query in Check queries. 0 disables batching.
+	CheckUsersetBatchSize uint32
+
 	// ChangelogHorizonOffset is an offset in minutes from the current time. Changes that occur
 	// after this offset will not be included in the response of ReadChanges.
 	ChangelogHorizonOffset int
@@ -212,16 +943,33 @@ type Config struct {
 	// concurrently in a query
 	ResolveNodeBreadthLimit uint32
 
-	Datastore       DatastoreConfig
-	GRPC            GRPCConfig
-	HTTP            HTTPConfig
-	Authn           AuthnConfig
-	Log             LogConfig
-	Trace           TraceConfig
-	Playground      PlaygroundConfig
-	Profiler        ProfilerConfig
-	Metrics         MetricConfig
-	CheckQueryCache CheckQueryCache
+	Datastore         DatastoreConfig
+	GRPC              GRPCConfig
+	HTTP              HTTPConfig
+	Authn             AuthnConfig
+	RequestEnrichment RequestEnrichmentConfig
+	Log               LogConfig
+	Trace             TraceConfig
+	Playground        PlaygroundConfig
+	Profiler          ProfilerConfig
+	Metrics           MetricConfig
+	CheckQueryCache   CheckQueryCache
+	CheckProfiling    CheckProfilingConfig
+	Capture           CaptureConfig
+	InlineModel       InlineModelConfig
+	BatchCheck        BatchCheckConfig
+	RelationUsage     RelationUsageConfig
+	SLO               SLOConfig
+	Admin             AdminConfig
+	Maintenance       MaintenanceConfig
+	LoadShedding      LoadSheddingConfig
+	ReadGuardrails    ReadGuardrailsConfig
+	ContinuationToken ContinuationTokenConfig
+	Cluster           ClusterConfig
+	ShadowCheck       ShadowCheckConfig
+	AuditLog          AuditLogConfig
+
+	AuthorizationModelValidation AuthorizationModelValidationConfig
 
 	RequestDurationDatastoreQueryCountBuckets []string
 }
@@ -251,6 +999,12 @@ func (cfg *Config) Verify() error {
 		)
 	}
 
+	switch cfg.Log.Sampling.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("config 'log.sampling.level' must be one of ['debug', 'info', 'warn', 'error']")
+	}
+
 	if cfg.Playground.Enabled {
 		if !cfg.HTTP.Enabled {
 			return errors.New("the HTTP server must be enabled to run the openfga playground")
@@ -267,12 +1021,266 @@ func (cfg *Config) Verify() error {
 		}
 	}
 
+	for _, header := range cfg.HTTP.ForwardedHeaders {
+		if strings.TrimSpace(header) == "" {
+			return errors.New("config 'http.forwardedHeaders' cannot contain an empty header")
+		}
+	}
+
+	for _, listener := range []struct {
+		name   string
+		config ListenerConfig
+	}{{"grpc", cfg.GRPC.Listener}, {"http", cfg.HTTP.Listener}} {
+		if listener.config.MaxConnections < 0 || listener.config.AcceptRate < 0 || listener.config.AcceptBurst < 0 {
+			return fmt.Errorf("the '%s.listener' configs cannot be negative", listener.name)
+		}
+	}
+
+	if cfg.HTTP.MaxConcurrentStreams == 0 {
+		return errors.New("config 'http.maxConcurrentStreams' must be positive")
+	}
+
+	if cfg.AuthorizationModelValidation.MaxTypeNameLength < 0 || cfg.AuthorizationModelValidation.MaxRelationNameLength < 0 {
+		return errors.New("configs 'authorizationModelValidation.maxTypeNameLength' and 'authorizationModelValidation.maxRelationNameLength' cannot be negative")
+	}
+
+	if _, err := cfg.AuthorizationModelValidation.NamingPolicy(); err != nil {
+		return err
+	}
+
+	for _, route := range cfg.HTTP.Middlewares {
+		if route.Pattern == "" || route.Middleware == nil {
+			return errors.New("every HTTP middleware must have a 'Pattern' and a 'Middleware'")
+		}
+	}
+
 	if cfg.GRPC.TLS.Enabled {
 		if cfg.GRPC.TLS.CertPath == "" || cfg.GRPC.TLS.KeyPath == "" {
 			return errors.New("'grpc.tls.cert' and 'grpc.tls.key' configs must be set")
 		}
 	}
 
+	if cfg.GRPC.StreamCompressor != "" {
+		if err := compression.Validate(cfg.GRPC.StreamCompressor); err != nil {
+			return fmt.Errorf("invalid 'grpc.streamCompressor': %w", err)
+		}
+	}
+
+	if cfg.GRPC.GzipLevel < gzip.HuffmanOnly || cfg.GRPC.GzipLevel > gzip.BestCompression {
+		return fmt.Errorf("'grpc.gzipLevel' must be between %d and %d", gzip.HuffmanOnly, gzip.BestCompression)
+	}
+
+	keepalive := cfg.GRPC.Keepalive
+	for _, d := range []time.Duration{keepalive.MaxConnectionIdle, keepalive.MaxConnectionAge, keepalive.MaxConnectionAgeGrace, keepalive.EnforcementMinTime} {
+		if d < 0 {
+			return errors.New("the 'grpc.keepalive' durations must be non-negative")
+		}
+	}
+
+	if keepalive.Time <= 0 || keepalive.Timeout <= 0 {
+		return errors.New("'grpc.keepalive.time' and 'grpc.keepalive.timeout' must be positive durations")
+	}
+
+	if cfg.CheckProfiling.SampleRate < 0 || cfg.CheckProfiling.SampleRate > 1 {
+		return errors.New("'checkProfiling.sampleRate' must be between 0 and 1")
+	}
+
+	if cfg.Capture.SampleRate < 0 || cfg.Capture.SampleRate > 1 {
+		return errors.New("'capture.sampleRate' must be between 0 and 1")
+	}
+
+	if cfg.Capture.Enabled && cfg.Capture.FilePath == "" {
+		return errors.New("'capture.filePath' must be set when the capture is enabled")
+	}
+
+	if cfg.InlineModel.MaxSizeInBytes <= 0 {
+		return errors.New("'inlineModel.maxSizeInBytes' must be positive")
+	}
+
+	if cfg.BatchCheck.MaxChecks <= 0 || cfg.BatchCheck.MaxConcurrentChecks <= 0 {
+		return errors.New("'batchCheck.maxChecks' and 'batchCheck.maxConcurrentChecks' must be positive")
+	}
+
+	if cfg.RelationUsage.Enabled && (cfg.RelationUsage.Window <= 0 || cfg.RelationUsage.MaxRelations <= 0) {
+		return errors.New("'relationUsage.window' and 'relationUsage.maxRelations' must be positive when the relation usage is enabled")
+	}
+
+	if cfg.SLO.AvailabilityObjective < 0 || cfg.SLO.AvailabilityObjective > 1 {
+		return errors.New("'slo.availabilityObjective' must be between 0 and 1")
+	}
+
+	if cfg.SLO.LatencyObjective < 0 || cfg.SLO.LatencyObjective > 1 {
+		return errors.New("'slo.latencyObjective' must be between 0 and 1")
+	}
+
+	if _, err := cfg.SLO.ParseMethodLatencyThresholds(); err != nil {
+		return err
+	}
+
+	if cfg.ShadowCheck.SampleRate < 0 || cfg.ShadowCheck.SampleRate > 1 {
+		return errors.New("'shadowCheck.sampleRate' must be between 0 and 1")
+	}
+
+	if _, err := cfg.ShadowCheck.ParseCandidates(); err != nil {
+		return err
+	}
+
+	if cfg.Datastore.Shadow.Engine != "" && cfg.Datastore.Shadow.Timeout <= 0 {
+		return errors.New("'datastore.shadow.timeout' must be a positive duration")
+	}
+
+	if cfg.CheckQueryCache.NegativeTTL < 0 {
+		return errors.New("'checkQueryCache.negativeTTL' must be a non-negative duration")
+	}
+
+	if cfg.CheckQueryCache.ChangelogInterval < 0 {
+		return errors.New("'checkQueryCache.changelogInterval' must be a non-negative duration")
+	}
+
+	if len(cfg.CheckQueryCache.SharedMemcachedServers) > 0 && !cfg.CheckQueryCache.Enabled {
+		return errors.New("'checkQueryCache.sharedMemcachedServers' requires 'checkQueryCache.enabled'")
+	}
+
+	if cfg.Datastore.LatestModelIDCacheTTL < 0 {
+		return errors.New("'datastore.latestModelIDCacheTTL' must be a non-negative duration")
+	}
+
+	if cfg.Datastore.ExpiredTuplesReapInterval < 0 {
+		return errors.New("'datastore.expiredTuplesReapInterval' must be a non-negative duration")
+	}
+
+	if len(cfg.Datastore.Replicas.URIs) > 0 && cfg.Datastore.Engine == "memory" {
+		return errors.New("'datastore.replicas.uris' can't be used with the 'memory' engine")
+	}
+
+	if cfg.Datastore.Replicas.HedgeAfter < 0 {
+		return errors.New("'datastore.replicas.hedgeAfter' must be a non-negative duration")
+	}
+
+	if cfg.Datastore.Replicas.HedgingBudget < 0 || cfg.Datastore.Replicas.HedgingBudget > 1 {
+		return errors.New("'datastore.replicas.hedgingBudget' must be between 0 and 1")
+	}
+
+	if len(cfg.Datastore.Routing.Stores)+len(cfg.Datastore.Routing.Prefixes) > 0 && cfg.Datastore.Engine == "memory" {
+		return errors.New("'datastore.routing' can't be used with the 'memory' engine")
+	}
+
+	if _, err := cfg.Datastore.Routing.ParseStores(); err != nil {
+		return err
+	}
+
+	if _, err := cfg.Datastore.Routing.ParsePrefixes(); err != nil {
+		return err
+	}
+
+	if len(cfg.Datastore.Residency.Regions) > 0 && cfg.Datastore.Residency.Region == "" {
+		return errors.New("'datastore.residency.regions' requires 'datastore.residency.region'")
+	}
+
+	if len(cfg.Datastore.Residency.Regions) > 0 && cfg.Datastore.Engine == "memory" {
+		return errors.New("'datastore.residency.regions' can't be used with the 'memory' engine")
+	}
+
+	if _, err := cfg.Datastore.Residency.ParseRegions(); err != nil {
+		return err
+	}
+
+	if cfg.Datastore.CircuitBreaker.Enabled {
+		if cfg.Datastore.CircuitBreaker.ErrorRateThreshold <= 0 || cfg.Datastore.CircuitBreaker.ErrorRateThreshold > 1 {
+			return errors.New("'datastore.circuitBreaker.errorRateThreshold' must be greater than 0 and at most 1")
+		}
+
+		if cfg.Datastore.CircuitBreaker.MinRequests <= 0 || cfg.Datastore.CircuitBreaker.Window <= 0 || cfg.Datastore.CircuitBreaker.OpenDuration <= 0 {
+			return errors.New("'datastore.circuitBreaker.minRequests', 'datastore.circuitBreaker.window' and 'datastore.circuitBreaker.openDuration' must be positive")
+		}
+	}
+
+	if cfg.Datastore.Maintenance.Schedule != "" {
+		if _, err := scheduler.ParseSchedule(cfg.Datastore.Maintenance.Schedule); err != nil {
+			return fmt.Errorf("invalid 'datastore.maintenance.schedule': %w", err)
+		}
+	}
+
+	if cfg.Datastore.Maintenance.Jitter < 0 {
+		return errors.New("'datastore.maintenance.jitter' must be a non-negative duration")
+	}
+
+	if cfg.Admin.GraphQLEnabled && !cfg.Admin.Enabled {
+		return errors.New("'admin.graphqlEnabled' requires 'admin.enabled'")
+	}
+
+	if cfg.Admin.Enabled && cfg.Authn.Method != "none" && len(cfg.Admin.Keys) == 0 && cfg.Admin.RequiredClaim == "" {
+		return errors.New("'admin.enabled' requires 'admin.keys' or 'admin.requiredClaim' when the API is authenticated, not to serve the admin API to every caller of the API")
+	}
+
+	if cfg.Authn.Method == "preshared" {
+		for _, key := range cfg.Admin.Keys {
+			for _, apiKey := range cfg.Authn.Keys {
+				if key == apiKey {
+					return errors.New("'admin.keys' must differ from 'authn.preshared.keys'")
+				}
+			}
+		}
+	}
+
+	if cfg.Admin.RequiredClaim != "" {
+		name, value, found := strings.Cut(cfg.Admin.RequiredClaim, "=")
+		if !found || name == "" || value == "" {
+			return fmt.Errorf("invalid 'admin.requiredClaim' '%s': expected the form '<name>=<value>'", cfg.Admin.RequiredClaim)
+		}
+
+		if cfg.Authn.Method != "oidc" {
+			return errors.New("'admin.requiredClaim' requires the 'oidc' authentication method")
+		}
+	}
+
+	if cfg.Maintenance.RetryAfter < 0 {
+		return errors.New("'maintenance.retryAfter' must be a non-negative duration")
+	}
+
+	if cfg.LoadShedding.RetryAfter < 0 {
+		return errors.New("'loadShedding.retryAfter' must be a non-negative duration")
+	}
+
+	if cfg.ReadGuardrails.MinFilterDimensions < 0 || cfg.ReadGuardrails.MinFilterDimensions > 4 {
+		return errors.New("'readGuardrails.minFilterDimensions' must be between 0 and 4")
+	}
+
+	if cfg.ReadGuardrails.LargeStoreTupleCount < 0 {
+		return errors.New("'readGuardrails.largeStoreTupleCount' must be non-negative")
+	}
+
+	if cfg.ContinuationToken.TTL < 0 {
+		return errors.New("'continuationToken.ttl' must be a non-negative duration")
+	}
+
+	if len(cfg.ContinuationToken.PreviousEncryptionKeys) > 0 && cfg.ContinuationToken.EncryptionKey == "" {
+		return errors.New("'continuationToken.previousEncryptionKeys' requires 'continuationToken.encryptionKey'")
+	}
+
+	if cfg.Cluster.Enabled {
+		if cfg.Cluster.AdvertiseAddress == "" {
+			return errors.New("'cluster.advertiseAddress' is required when the clustering mode is enabled")
+		}
+
+		if cfg.Cluster.ProbeInterval <= 0 {
+			return errors.New("'cluster.probeInterval' must be a positive duration")
+		}
+
+		// the members authenticate the sub-problems they dispatch to each other with a preshared key
+		if !(cfg.Authn.Method == "none" || cfg.Authn.Method == "preshared") {
+			return errors.New("the clustering mode only supports authn methods 'none' and 'preshared'")
+		}
+	}
+
+	if _, err := serverErrors.ParseStatusCodeOverrides(cfg.StatusCodeOverrides); err != nil {
+		return err
+	}
+
+	if cfg.StoreTombstoneTTL < 0 {
+		return errors.New("'storeTombstoneTTL' must be a non-negative duration")
+	}
+
 	if len(cfg.RequestDurationDatastoreQueryCountBuckets) == 0 {
 		return errors.New("request duration datastore query count buckets must not be empty")
 	}
@@ -295,23 +1303,69 @@ func DefaultConfig() *Config {
 		MaxTypesPerAuthorizationModel:             DefaultMaxTypesPerAuthorizationModel,
 		MaxAuthorizationModelSizeInBytes:          DefaultMaxAuthorizationModelSizeInBytes,
 		MaxConcurrentReadsForCheck:                DefaultMaxConcurrentReadsForCheck,
+		CheckUsersetBatchSize:                     DefaultCheckUsersetBatchSize,
 		MaxConcurrentReadsForListObjects:          DefaultMaxConcurrentReadsForListObjects,
 		ChangelogHorizonOffset:                    DefaultChangelogHorizonOffset,
 		ResolveNodeLimit:                          DefaultResolveNodeLimit,
 		ResolveNodeBreadthLimit:                   DefaultResolveNodeBreadthLimit,
 		Experimentals:                             []string{},
+		StatusCodeOverrides:                       []string{},
+		StoreTombstoneTTL:                         DefaultStoreTombstoneTTL,
 		ListObjectsDeadline:                       DefaultListObjectsDeadline,
 		ListObjectsMaxResults:                     DefaultListObjectsMaxResults,
 		RequestDurationDatastoreQueryCountBuckets: []string{"50", "200"},
 		Datastore: DatastoreConfig{
-			Engine:       "memory",
-			MaxCacheSize: 100000,
-			MaxIdleConns: 10,
-			MaxOpenConns: 30,
+			Engine:                    "memory",
+			MaxCacheSize:              100000,
+			LatestModelIDCacheTTL:     DefaultDatastoreLatestModelIDCacheTTL,
+			ExpiredTuplesReapInterval: DefaultDatastoreExpiredTuplesReapInterval,
+			MaxIdleConns:              10,
+			MaxOpenConns:              30,
+			Shadow: ShadowDatastoreConfig{
+				Timeout:        DefaultShadowDatastoreTimeout,
+				MaxConcurrency: DefaultShadowDatastoreMaxConcurrency,
+			},
+			Maintenance: DatastoreMaintenanceConfig{
+				Jitter: DefaultDatastoreMaintenanceJitter,
+				Tasks:  []string{},
+			},
+			Replicas: DatastoreReplicasConfig{
+				URIs:          []string{},
+				HedgingBudget: DefaultDatastoreReplicasHedgingBudget,
+			},
+			Routing: DatastoreRoutingConfig{
+				Stores:   []string{},
+				Prefixes: []string{},
+			},
+			Residency: DatastoreResidencyConfig{
+				Regions: []string{},
+			},
+			CircuitBreaker: DatastoreCircuitBreakerConfig{
+				ErrorRateThreshold: DefaultDatastoreCircuitBreakerErrorRateThreshold,
+				MinRequests:        DefaultDatastoreCircuitBreakerMinRequests,
+				Window:             DefaultDatastoreCircuitBreakerWindow,
+				OpenDuration:       DefaultDatastoreCircuitBreakerOpenDuration,
+			},
 		},
 		GRPC: GRPCConfig{
-			Addr: "0.0.0.0:8081",
-			TLS:  &TLSConfig{Enabled: false},
+			Addr:             "0.0.0.0:8081",
+			TLS:              &TLSConfig{Enabled: false},
+			StreamCompressor: "",
+			GzipLevel:        gzip.DefaultCompression,
+			Keepalive: GRPCKeepaliveConfig{
+				MaxConnectionIdle:              0,
+				MaxConnectionAge:               DefaultGRPCKeepaliveMaxConnectionAge,
+				MaxConnectionAgeGrace:          DefaultGRPCKeepaliveMaxConnectionAgeGrace,
+				Time:                           DefaultGRPCKeepaliveTime,
+				Timeout:                        DefaultGRPCKeepaliveTimeout,
+				EnforcementMinTime:             DefaultGRPCKeepaliveEnforcementMinTime,
+				EnforcementPermitWithoutStream: false,
+			},
+			Listener: ListenerConfig{
+				MaxConnections: 0,
+				AcceptRate:     0,
+				AcceptBurst:    DefaultListenerAcceptBurst,
+			},
 		},
 		HTTP: HTTPConfig{
 			Enabled:            true,
@@ -320,15 +1374,34 @@ func DefaultConfig() *Config {
 			UpstreamTimeout:    5 * time.Second,
 			CORSAllowedOrigins: []string{"*"},
 			CORSAllowedHeaders: []string{"*"},
+			ForwardedHeaders:   []string{},
+
+			H2CEnabled:           false,
+			MaxConcurrentStreams: DefaultHTTPMaxConcurrentStreams,
+			Listener: ListenerConfig{
+				MaxConnections: 0,
+				AcceptRate:     0,
+				AcceptBurst:    DefaultListenerAcceptBurst,
+			},
 		},
 		Authn: AuthnConfig{
 			Method:                  "none",
+			UnauthenticatedMethods:  []string{"/grpc.health.v1.Health/*"},
 			AuthnPresharedKeyConfig: &AuthnPresharedKeyConfig{},
 			AuthnOIDCConfig:         &AuthnOIDCConfig{},
+			AuthnSignedRequestConfig: &AuthnSignedRequestConfig{
+				MaxClockSkew: DefaultAuthnSignedRequestMaxClockSkew,
+				MaxNonces:    DefaultAuthnSignedRequestMaxNonces,
+			},
 		},
 		Log: LogConfig{
 			Format: "text",
 			Level:  "info",
+			Sampling: LogSamplingConfig{
+				Initial:    DefaultLogSamplingInitial,
+				Thereafter: DefaultLogSamplingThereafter,
+				Level:      DefaultLogSamplingLevel,
+			},
 		},
 		Trace: TraceConfig{
 			Enabled: false,
@@ -353,11 +1426,107 @@ func DefaultConfig() *Config {
 			Enabled:             true,
 			Addr:                "0.0.0.0:2112",
 			EnableRPCHistograms: false,
+
+			EnableStoreLabels:    false,
+			StoreLabelsAllowlist: []string{},
+			StoreLabelsTopK:      DefaultMetricsStoreLabelsTopK,
+		},
+		RequestEnrichment: RequestEnrichmentConfig{
+			ContextualTuples: []string{},
+		},
+		AuthorizationModelValidation: AuthorizationModelValidationConfig{
+			Rules:                 []string{},
+			Plugins:               []string{},
+			ReservedTypeNames:     []string{},
+			ReservedRelationNames: []string{},
 		},
 		CheckQueryCache: CheckQueryCache{
 			Enabled: DefaultCheckQueryCacheEnable,
 			Limit:   DefaultCheckQueryCacheLimit,
 			TTL:     DefaultCheckQueryCacheTTL,
+
+			HotKeyQPSThreshold:  DefaultCheckQueryCacheHotKeyQPSThreshold,
+			HotKeyTTLMultiplier: DefaultCheckQueryCacheHotKeyTTLMultiplier,
+
+			NegativeLimit: DefaultCheckQueryCacheNegativeLimit,
+			NegativeTTL:   DefaultCheckQueryCacheNegativeTTL,
+
+			ChangelogInterval: DefaultCheckQueryCacheChangelogInterval,
+
+			SharedMemcachedServers: []string{},
+		},
+		CheckProfiling: CheckProfilingConfig{
+			Enabled:          false,
+			LatencyThreshold: DefaultCheckProfilingLatencyThreshold,
+			SampleRate:       DefaultCheckProfilingSampleRate,
+			FilePath:         "",
+		},
+		Capture: CaptureConfig{
+			Enabled:      false,
+			SampleRate:   DefaultCaptureSampleRate,
+			FilePath:     "",
+			RedactionKey: "",
+		},
+		InlineModel: InlineModelConfig{
+			Enabled:        false,
+			MaxSizeInBytes: DefaultInlineModelMaxSizeInBytes,
+		},
+		BatchCheck: BatchCheckConfig{
+			MaxChecks:           DefaultBatchCheckMaxChecks,
+			MaxConcurrentChecks: DefaultBatchCheckMaxConcurrentChecks,
+			MaxDatastoreQueries: DefaultBatchCheckMaxDatastoreQueries,
+		},
+		RelationUsage: RelationUsageConfig{
+			Enabled:      false,
+			Window:       DefaultRelationUsageWindow,
+			MaxRelations: DefaultRelationUsageMaxRelations,
+		},
+		SLO: SLOConfig{
+			Enabled:                 false,
+			AvailabilityObjective:   DefaultSLOAvailabilityObjective,
+			LatencyObjective:        DefaultSLOLatencyObjective,
+			LatencyThreshold:        DefaultSLOLatencyThreshold,
+			MethodLatencyThresholds: []string{},
+			Window:                  DefaultSLOWindow,
+		},
+		Admin: AdminConfig{
+			Enabled:        false,
+			Addr:           "127.0.0.1:8082",
+			GraphQLEnabled: false,
+		},
+		Maintenance: MaintenanceConfig{
+			Enabled:    false,
+			Message:    DefaultMaintenanceMessage,
+			RetryAfter: DefaultMaintenanceRetryAfter,
+		},
+		LoadShedding: LoadSheddingConfig{
+			MaxConcurrentRequests: 0,
+			RetryAfter:            DefaultLoadSheddingRetryAfter,
+		},
+		ReadGuardrails: ReadGuardrailsConfig{
+			RejectUnfiltered:     false,
+			MinFilterDimensions:  0,
+			LargeStoreTupleCount: DefaultReadGuardrailsLargeStoreTupleCount,
+		},
+		ContinuationToken: ContinuationTokenConfig{
+			PreviousEncryptionKeys: []string{},
+			TTL:                    DefaultContinuationTokenTTL,
+		},
+		Cluster: ClusterConfig{
+			Enabled:         false,
+			Peers:           []string{},
+			ProbeInterval:   DefaultClusterProbeInterval,
+			DispatchRetries: DefaultClusterDispatchRetries,
+		},
+		ShadowCheck: ShadowCheckConfig{
+			Enabled:        false,
+			Candidates:     []string{},
+			SampleRate:     DefaultShadowCheckSampleRate,
+			Timeout:        DefaultShadowCheckTimeout,
+			MaxConcurrency: DefaultShadowCheckMaxConcurrency,
+		},
+		AuditLog: AuditLogConfig{
+			Enabled: false,
 		},
 	}
 }
@@ -1,9 +1,11 @@
 package config
 
 import (
+	"net/http"
 	"testing"
 	"time"
 
+	httpmiddleware "github.com/openfga/openfga/pkg/middleware/http"
 	"github.com/stretchr/testify/require"
 )
 
@@ -28,6 +30,56 @@ func TestVerifyConfig(t *testing.T) {
 		require.EqualError(t, err, "'http.tls.cert' and 'http.tls.key' configs must be set")
 	})
 
+	t.Run("empty_http_forwarded_header", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.HTTP.ForwardedHeaders = []string{"X-Tenant-ID", " "}
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "config 'http.forwardedHeaders' cannot contain an empty header")
+	})
+
+	t.Run("negative_listener_accept_rate", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.HTTP.Listener.AcceptRate = -1
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "the 'http.listener' configs cannot be negative")
+	})
+
+	t.Run("no_http_max_concurrent_streams", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.HTTP.MaxConcurrentStreams = 0
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "config 'http.maxConcurrentStreams' must be positive")
+	})
+
+	t.Run("invalid_reserved_type_name", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.AuthorizationModelValidation.ReservedTypeNames = []string{"^internal_("}
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "config 'authorizationModelValidation.reservedTypeNames' has an invalid regular expression '^internal_(': error parsing regexp: missing closing ): `^internal_(`")
+	})
+
+	t.Run("negative_max_relation_name_length", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.AuthorizationModelValidation.MaxRelationNameLength = -1
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "configs 'authorizationModelValidation.maxTypeNameLength' and 'authorizationModelValidation.maxRelationNameLength' cannot be negative")
+	})
+
+	t.Run("http_middleware_without_a_pattern", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.HTTP.Middlewares = []httpmiddleware.RouteMiddleware{{
+			Middleware: func(next http.Handler) http.Handler { return next },
+		}}
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "every HTTP middleware must have a 'Pattern' and a 'Middleware'")
+	})
+
 	t.Run("failing_to_set_grpc_cert_path_will_not_allow_server_to_start", func(t *testing.T) {
 		cfg := DefaultConfig()
 		cfg.GRPC.TLS = &TLSConfig{
@@ -100,4 +152,401 @@ func TestVerifyConfig(t *testing.T) {
 		err := cfg.Verify()
 		require.Error(t, err)
 	})
+
+	t.Run("slo_objective_out_of_range", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.SLO.AvailabilityObjective = 1.5
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'slo.availabilityObjective' must be between 0 and 1")
+	})
+
+	t.Run("unknown_stream_compressor", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.GRPC.StreamCompressor = "zstd"
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "invalid 'grpc.streamCompressor': unknown compressor 'zstd'")
+	})
+
+	t.Run("gzip_level_out_of_range", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.GRPC.GzipLevel = 10
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'grpc.gzipLevel' must be between -2 and 9")
+	})
+
+	t.Run("negative_grpc_keepalive_max_connection_age", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.GRPC.Keepalive.MaxConnectionAge = -time.Minute
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "the 'grpc.keepalive' durations must be non-negative")
+	})
+
+	t.Run("no_grpc_keepalive_timeout", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.GRPC.Keepalive.Timeout = 0
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'grpc.keepalive.time' and 'grpc.keepalive.timeout' must be positive durations")
+	})
+
+	t.Run("capture_without_file_path", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Capture.Enabled = true
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'capture.filePath' must be set when the capture is enabled")
+	})
+
+	t.Run("non_positive_inline_model_max_size", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.InlineModel.MaxSizeInBytes = 0
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'inlineModel.maxSizeInBytes' must be positive")
+	})
+
+	t.Run("non_positive_batch_check_max_checks", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.BatchCheck.MaxChecks = 0
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'batchCheck.maxChecks' and 'batchCheck.maxConcurrentChecks' must be positive")
+	})
+
+	t.Run("non_positive_relation_usage_window", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.RelationUsage.Enabled = true
+		cfg.RelationUsage.Window = 0
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'relationUsage.window' and 'relationUsage.maxRelations' must be positive when the relation usage is enabled")
+	})
+
+	t.Run("invalid_status_code_override", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.StatusCodeOverrides = []string{"throttled=UNAVAILABLE:503"}
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "invalid status code override 'throttled=UNAVAILABLE:503': unknown error class 'throttled'")
+	})
+
+	t.Run("negative_maintenance_retry_after", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Maintenance.RetryAfter = -time.Second
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'maintenance.retryAfter' must be a non-negative duration")
+	})
+
+	t.Run("negative_load_shedding_retry_after", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.LoadShedding.RetryAfter = -time.Second
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'loadShedding.retryAfter' must be a non-negative duration")
+	})
+
+	t.Run("invalid_read_guardrails_min_filter_dimensions", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.ReadGuardrails.MinFilterDimensions = 5
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'readGuardrails.minFilterDimensions' must be between 0 and 4")
+	})
+
+	t.Run("negative_read_guardrails_large_store_tuple_count", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.ReadGuardrails.LargeStoreTupleCount = -1
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'readGuardrails.largeStoreTupleCount' must be non-negative")
+	})
+
+	t.Run("negative_store_tombstone_ttl", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.StoreTombstoneTTL = -time.Second
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'storeTombstoneTTL' must be a non-negative duration")
+	})
+
+	t.Run("negative_continuation_token_ttl", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.ContinuationToken.TTL = -time.Second
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'continuationToken.ttl' must be a non-negative duration")
+	})
+
+	t.Run("continuation_token_previous_keys_without_key", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.ContinuationToken.PreviousEncryptionKeys = []string{"previous key"}
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'continuationToken.previousEncryptionKeys' requires 'continuationToken.encryptionKey'")
+	})
+
+	t.Run("shadow_check_sample_rate_out_of_range", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.ShadowCheck.SampleRate = -0.1
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'shadowCheck.sampleRate' must be between 0 and 1")
+	})
+
+	t.Run("invalid_shadow_check_candidates", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.ShadowCheck.Candidates = []string{"store1"}
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "invalid 'shadowCheck.candidates' item 'store1': must be in the form '<store ID>:<model ID>'")
+	})
+
+	t.Run("non_positive_shadow_datastore_timeout", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Datastore.Shadow.Engine = "memory"
+		cfg.Datastore.Shadow.Timeout = 0
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'datastore.shadow.timeout' must be a positive duration")
+	})
+
+	t.Run("negative_check_query_cache_ttl", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.CheckQueryCache.NegativeTTL = -time.Second
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'checkQueryCache.negativeTTL' must be a non-negative duration")
+	})
+
+	t.Run("negative_check_query_cache_changelog_interval", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.CheckQueryCache.ChangelogInterval = -time.Second
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'checkQueryCache.changelogInterval' must be a non-negative duration")
+	})
+
+	t.Run("invalid_log_sampling_level", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Log.Sampling.Level = "fatal"
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "config 'log.sampling.level' must be one of ['debug', 'info', 'warn', 'error']")
+	})
+
+	t.Run("shared_check_query_cache_without_check_query_cache", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.CheckQueryCache.SharedMemcachedServers = []string{"memcached:11211"}
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'checkQueryCache.sharedMemcachedServers' requires 'checkQueryCache.enabled'")
+	})
+
+	t.Run("cluster_without_advertise_address", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Cluster.Enabled = true
+		cfg.Cluster.Peers = []string{"openfga-0:8081", "openfga-1:8081"}
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'cluster.advertiseAddress' is required when the clustering mode is enabled")
+	})
+
+	t.Run("cluster_with_oidc_authn", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Cluster.Enabled = true
+		cfg.Cluster.AdvertiseAddress = "openfga-0:8081"
+		cfg.Authn.Method = "oidc"
+		cfg.Authn.AuthnOIDCConfig = &AuthnOIDCConfig{Issuer: "https://issuer.example.com", Audience: "openfga"}
+		cfg.Playground.Enabled = false
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "the clustering mode only supports authn methods 'none' and 'preshared'")
+	})
+
+	t.Run("replicas_of_the_memory_engine", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Datastore.Replicas.URIs = []string{"replica"}
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'datastore.replicas.uris' can't be used with the 'memory' engine")
+	})
+
+	t.Run("negative_latest_model_id_cache_ttl", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Datastore.LatestModelIDCacheTTL = -time.Second
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'datastore.latestModelIDCacheTTL' must be a non-negative duration")
+	})
+
+	t.Run("hedging_budget_out_of_range", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Datastore.Replicas.HedgingBudget = 1.5
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'datastore.replicas.hedgingBudget' must be between 0 and 1")
+	})
+
+	t.Run("routing_with_memory_engine", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Datastore.Routing.Prefixes = []string{"01=postgres://tenants"}
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'datastore.routing' can't be used with the 'memory' engine")
+	})
+
+	t.Run("invalid_routing_store", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Datastore.Engine = "postgres"
+		cfg.Datastore.Routing.Stores = []string{"postgres://tenant"}
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "invalid 'datastore.routing.stores' item 'postgres://tenant': must be in the form '<store ID>=<uri>'")
+	})
+
+	t.Run("routing_prefix_repeated", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Datastore.Engine = "postgres"
+		cfg.Datastore.Routing.Prefixes = []string{"01=postgres://a", "01=postgres://b"}
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "invalid 'datastore.routing.prefixes' item '01=postgres://b': '01' is routed more than once")
+	})
+
+	t.Run("residency_regions_without_region", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Datastore.Engine = "postgres"
+		cfg.Datastore.Residency.Regions = []string{"us=postgres://us"}
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'datastore.residency.regions' requires 'datastore.residency.region'")
+	})
+
+	t.Run("invalid_residency_region", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Datastore.Engine = "postgres"
+		cfg.Datastore.Residency.Region = "eu"
+		cfg.Datastore.Residency.Regions = []string{"postgres://us"}
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "invalid 'datastore.residency.regions' item 'postgres://us': must be in the form '<region>=<uri>'")
+	})
+
+	t.Run("local_residency_region_repeated", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Datastore.Engine = "postgres"
+		cfg.Datastore.Residency.Region = "eu"
+		cfg.Datastore.Residency.Regions = []string{"eu=postgres://eu"}
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "invalid 'datastore.residency.regions' item 'eu=postgres://eu': the region 'eu' is configured more than once")
+	})
+
+	t.Run("circuit_breaker_error_rate_threshold_out_of_range", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Datastore.CircuitBreaker.Enabled = true
+		cfg.Datastore.CircuitBreaker.ErrorRateThreshold = 0
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'datastore.circuitBreaker.errorRateThreshold' must be greater than 0 and at most 1")
+	})
+
+	t.Run("graphql_without_admin", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Admin.GraphQLEnabled = true
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'admin.graphqlEnabled' requires 'admin.enabled'")
+	})
+
+	t.Run("admin_without_credentials_with_authenticated_api", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Admin.Enabled = true
+		cfg.Authn.Method = "preshared"
+		cfg.Authn.Keys = []string{"key"}
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'admin.enabled' requires 'admin.keys' or 'admin.requiredClaim' when the API is authenticated, not to serve the admin API to every caller of the API")
+	})
+
+	t.Run("admin_keys_of_the_api", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Admin.Enabled = true
+		cfg.Admin.Keys = []string{"admin-key", "key"}
+		cfg.Authn.Method = "preshared"
+		cfg.Authn.Keys = []string{"key"}
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'admin.keys' must differ from 'authn.preshared.keys'")
+	})
+
+	t.Run("invalid_admin_required_claim", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Admin.Enabled = true
+		cfg.Admin.RequiredClaim = "role"
+		cfg.Authn.Method = "oidc"
+		cfg.Playground.Enabled = false
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "invalid 'admin.requiredClaim' 'role': expected the form '<name>=<value>'")
+	})
+
+	t.Run("admin_required_claim_without_oidc", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Admin.Enabled = true
+		cfg.Admin.RequiredClaim = "role=admin"
+		cfg.Authn.Method = "preshared"
+		cfg.Authn.Keys = []string{"key"}
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "'admin.requiredClaim' requires the 'oidc' authentication method")
+	})
+
+	t.Run("invalid_datastore_maintenance_schedule", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Datastore.Maintenance.Schedule = "0 3 * *"
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "invalid 'datastore.maintenance.schedule': invalid schedule '0 3 * *': expected 5 fields, got 4")
+	})
+
+	t.Run("invalid_slo_method_latency_thresholds", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.SLO.MethodLatencyThresholds = []string{"Check:100ms", "ListObjects"}
+
+		err := cfg.Verify()
+		require.Error(t, err)
+
+		cfg.SLO.MethodLatencyThresholds = []string{"Check:fast"}
+
+		err = cfg.Verify()
+		require.Error(t, err)
+	})
+}
+
+func TestParseMethodLatencyThresholds(t *testing.T) {
+	cfg := SLOConfig{MethodLatencyThresholds: []string{"Check:100ms", "ListObjects:2s"}}
+
+	thresholds, err := cfg.ParseMethodLatencyThresholds()
+	require.NoError(t, err)
+	require.Equal(t, map[string]time.Duration{
+		"Check":       100 * time.Millisecond,
+		"ListObjects": 2 * time.Second,
+	}, thresholds)
+}
+
+func TestParseShadowCheckCandidates(t *testing.T) {
+	cfg := ShadowCheckConfig{Candidates: []string{"store1:model1", "store2:model2"}}
+
+	candidates, err := cfg.ParseCandidates()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"store1": "model1",
+		"store2": "model2",
+	}, candidates)
 }
@@ -0,0 +1,228 @@
+// Package sharedcache contains the caches shared by the servers of a fleet, e.g. to share the results of the
+// Check sub-problems they resolve.
+package sharedcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/openfga/openfga/internal/graph"
+)
+
+const (
+	defaultKeyPrefix       = "openfga/"
+	defaultVersionLifetime = time.Second
+)
+
+var _ graph.SharedCheckCache = (*MemcachedCheckCache)(nil)
+
+// MemcachedCheckCache is a graph.SharedCheckCache in Memcached.
+//
+// The version of a store is a counter in Memcached, which every invalidation increments, and which is part of
+// the keys of the results of the store. It's initialized from the clock, so that it moves forward even if it
+// was evicted. The servers read it at most once per version lifetime, which bounds how long they may read the
+// results of a store invalidated by another server.
+type MemcachedCheckCache struct {
+	client          *memcache.Client
+	keyPrefix       string
+	versionLifetime time.Duration
+
+	mu       sync.Mutex
+	versions map[string]storeVersion
+}
+
+type storeVersion struct {
+	version   string
+	fetchedAt time.Time
+}
+
+// MemcachedCheckCacheOption defines an option that can be used to change the behavior of a
+// MemcachedCheckCache.
+type MemcachedCheckCacheOption func(c *MemcachedCheckCache)
+
+// WithKeyPrefix sets the prefix of the keys, e.g. to share the Memcached servers between several fleets. It
+// defaults to 'openfga/'.
+func WithKeyPrefix(prefix string) MemcachedCheckCacheOption {
+	return func(c *MemcachedCheckCache) {
+		c.keyPrefix = prefix
+	}
+}
+
+// WithVersionLifetime sets how long the version of a store is used before it's read again. It defaults to a
+// second.
+func WithVersionLifetime(lifetime time.Duration) MemcachedCheckCacheOption {
+	return func(c *MemcachedCheckCache) {
+		c.versionLifetime = lifetime
+	}
+}
+
+// NewMemcachedCheckCache returns a MemcachedCheckCache in the Memcached servers (e.g. 'memcached:11211').
+func NewMemcachedCheckCache(servers []string, opts ...MemcachedCheckCacheOption) *MemcachedCheckCache {
+	c := &MemcachedCheckCache{
+		client:          memcache.New(servers...),
+		keyPrefix:       defaultKeyPrefix,
+		versionLifetime: defaultVersionLifetime,
+		versions:        map[string]storeVersion{},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Get implements graph.SharedCheckCache.
+func (c *MemcachedCheckCache) Get(ctx context.Context, storeID, key string) (bool, time.Time, bool, error) {
+	version, err := c.version(storeID)
+	if err != nil {
+		return false, time.Time{}, false, err
+	}
+
+	item, err := c.client.Get(c.entryKey(storeID, version, key))
+	if err != nil {
+		if errors.Is(err, memcache.ErrCacheMiss) {
+			return false, time.Time{}, false, nil
+		}
+		return false, time.Time{}, false, err
+	}
+
+	// the value is the result, '0' or '1', followed by the expiration in Unix nanoseconds if there's one
+	allowed, expiresAtNanos, found := strings.Cut(string(item.Value), "/")
+
+	var expiresAt time.Time
+	if found {
+		nanos, err := strconv.ParseInt(expiresAtNanos, 10, 64)
+		if err != nil {
+			return false, time.Time{}, false, fmt.Errorf("invalid shared check cache entry '%s': %w", item.Value, err)
+		}
+		expiresAt = time.Unix(0, nanos)
+	}
+
+	return allowed == "1", expiresAt, true, nil
+}
+
+// Set implements graph.SharedCheckCache. The results whose TTL is shorter than a second, the resolution of the
+// expirations of Memcached, aren't cached.
+func (c *MemcachedCheckCache) Set(ctx context.Context, storeID, key string, allowed bool, expiresAt time.Time, ttl time.Duration) error {
+	exp := expiration(ttl)
+	if exp == 0 {
+		return nil
+	}
+
+	version, err := c.version(storeID)
+	if err != nil {
+		return err
+	}
+
+	value := "0"
+	if allowed {
+		value = "1"
+	}
+	if !expiresAt.IsZero() {
+		value += "/" + strconv.FormatInt(expiresAt.UnixNano(), 10)
+	}
+
+	return c.client.Set(&memcache.Item{
+		Key:        c.entryKey(storeID, version, key),
+		Value:      []byte(value),
+		Expiration: exp,
+	})
+}
+
+// Invalidate implements graph.SharedCheckCache.
+func (c *MemcachedCheckCache) Invalidate(ctx context.Context, storeID string) error {
+	versionKey := c.versionKey(storeID)
+
+	next, err := c.client.Increment(versionKey, 1)
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return c.initVersion(storeID)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.storeVersion(storeID, strconv.FormatUint(next, 10))
+	return nil
+}
+
+// version returns the version of the store, reading it again once its lifetime is over.
+func (c *MemcachedCheckCache) version(storeID string) (string, error) {
+	c.mu.Lock()
+	v, ok := c.versions[storeID]
+	c.mu.Unlock()
+
+	if ok && time.Since(v.fetchedAt) < c.versionLifetime {
+		return v.version, nil
+	}
+
+	item, err := c.client.Get(c.versionKey(storeID))
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		if err := c.initVersion(storeID); err != nil {
+			return "", err
+		}
+		return c.version(storeID)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	c.storeVersion(storeID, string(item.Value))
+	return string(item.Value), nil
+}
+
+// initVersion initializes the missing version of the store from the clock, unless another server just did.
+func (c *MemcachedCheckCache) initVersion(storeID string) error {
+	version := strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	err := c.client.Add(&memcache.Item{Key: c.versionKey(storeID), Value: []byte(version)})
+	if errors.Is(err, memcache.ErrNotStored) {
+		c.forgetVersion(storeID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	c.storeVersion(storeID, version)
+	return nil
+}
+
+func (c *MemcachedCheckCache) storeVersion(storeID, version string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.versions[storeID] = storeVersion{version: version, fetchedAt: time.Now()}
+}
+
+func (c *MemcachedCheckCache) forgetVersion(storeID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.versions, storeID)
+}
+
+func (c *MemcachedCheckCache) versionKey(storeID string) string {
+	return c.keyPrefix + "version/" + storeID
+}
+
+// entryKey returns the key of a result, which is hashed since the keys of Memcached are limited to 250
+// characters without spaces.
+func (c *MemcachedCheckCache) entryKey(storeID, version, key string) string {
+	sum := sha256.Sum256([]byte(storeID + "/" + version + "/" + key))
+	return c.keyPrefix + "check/" + hex.EncodeToString(sum[:])
+}
+
+// expiration returns the expiration of Memcached of a TTL, in seconds rounded down so that the entry doesn't
+// outlive it, or 0 if the TTL is shorter than a second (which Memcached would never expire).
+func expiration(ttl time.Duration) int32 {
+	return int32(max(ttl/time.Second, 0))
+}
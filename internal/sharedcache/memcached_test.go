@@ -0,0 +1,196 @@
+package sharedcache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMemcached serves the commands of the text protocol of Memcached that the cache uses, without
+// expiration.
+type fakeMemcached struct {
+	mu    sync.Mutex
+	items map[string][]byte
+}
+
+func startFakeMemcached(t *testing.T) (*fakeMemcached, string) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = lis.Close()
+	})
+
+	m := &fakeMemcached{items: map[string][]byte{}}
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go m.serve(conn)
+		}
+	}()
+
+	return m, lis.Addr().String()
+}
+
+func (m *fakeMemcached) serve(conn net.Conn) {
+	defer conn.Close()
+
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			return
+		}
+
+		m.mu.Lock()
+		switch fields[0] {
+		case "get", "gets":
+			for _, key := range fields[1:] {
+				if value, ok := m.items[key]; ok {
+					fmt.Fprintf(rw, "VALUE %s 0 %d 0\r\n%s\r\n", key, len(value), value)
+				}
+			}
+			fmt.Fprint(rw, "END\r\n")
+		case "set", "add":
+			size, _ := strconv.Atoi(fields[4])
+			value := make([]byte, size+2)
+			if _, err := io.ReadFull(rw, value); err != nil {
+				m.mu.Unlock()
+				return
+			}
+
+			if _, ok := m.items[fields[1]]; ok && fields[0] == "add" {
+				fmt.Fprint(rw, "NOT_STORED\r\n")
+			} else {
+				m.items[fields[1]] = value[:size]
+				fmt.Fprint(rw, "STORED\r\n")
+			}
+		case "incr":
+			value, ok := m.items[fields[1]]
+			if !ok {
+				fmt.Fprint(rw, "NOT_FOUND\r\n")
+				break
+			}
+			n, _ := strconv.ParseUint(string(value), 10, 64)
+			delta, _ := strconv.ParseUint(fields[2], 10, 64)
+			m.items[fields[1]] = []byte(strconv.FormatUint(n+delta, 10))
+			fmt.Fprintf(rw, "%d\r\n", n+delta)
+		default:
+			fmt.Fprint(rw, "ERROR\r\n")
+		}
+		m.mu.Unlock()
+
+		if err := rw.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (m *fakeMemcached) evict(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.items, key)
+}
+
+func TestMemcachedCheckCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("results_are_shared", func(t *testing.T) {
+		_, addr := startFakeMemcached(t)
+		first := NewMemcachedCheckCache([]string{addr})
+		second := NewMemcachedCheckCache([]string{addr})
+
+		_, _, ok, err := second.Get(ctx, "store", "document:1#viewer@user:jon")
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		require.NoError(t, first.Set(ctx, "store", "document:1#viewer@user:jon", true, time.Time{}, time.Minute))
+		require.NoError(t, first.Set(ctx, "store", "document:1#viewer@user:bob", false, time.Time{}, time.Minute))
+
+		allowed, _, ok, err := second.Get(ctx, "store", "document:1#viewer@user:jon")
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.True(t, allowed)
+
+		allowed, _, ok, err = second.Get(ctx, "store", "document:1#viewer@user:bob")
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.False(t, allowed)
+
+		_, _, ok, err = second.Get(ctx, "other-store", "document:1#viewer@user:jon")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("results_carry_the_expiration_of_their_tuples", func(t *testing.T) {
+		_, addr := startFakeMemcached(t)
+		cache := NewMemcachedCheckCache([]string{addr})
+
+		expiresAt := time.Now().Add(time.Minute)
+		require.NoError(t, cache.Set(ctx, "store", "document:1#viewer@user:jon", true, expiresAt, time.Minute))
+
+		allowed, cachedExpiresAt, ok, err := cache.Get(ctx, "store", "document:1#viewer@user:jon")
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.True(t, allowed)
+		require.True(t, expiresAt.Equal(cachedExpiresAt))
+
+		// Memcached can't expire the results within less than a second
+		require.NoError(t, cache.Set(ctx, "store", "document:1#viewer@user:bob", true, time.Now().Add(500*time.Millisecond), 500*time.Millisecond))
+
+		_, _, ok, err = cache.Get(ctx, "store", "document:1#viewer@user:bob")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("invalidation_moves_the_version_of_the_store_forward", func(t *testing.T) {
+		_, addr := startFakeMemcached(t)
+		first := NewMemcachedCheckCache([]string{addr})
+		second := NewMemcachedCheckCache([]string{addr}, WithVersionLifetime(0))
+
+		require.NoError(t, first.Set(ctx, "store", "document:1#viewer@user:jon", true, time.Time{}, time.Minute))
+		require.NoError(t, first.Set(ctx, "other-store", "document:1#viewer@user:jon", true, time.Time{}, time.Minute))
+		require.NoError(t, first.Invalidate(ctx, "store"))
+
+		_, _, ok, err := first.Get(ctx, "store", "document:1#viewer@user:jon")
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		_, _, ok, err = second.Get(ctx, "store", "document:1#viewer@user:jon")
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		_, _, ok, err = second.Get(ctx, "other-store", "document:1#viewer@user:jon")
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("an_evicted_version_isn't_reused", func(t *testing.T) {
+		memcached, addr := startFakeMemcached(t)
+		cache := NewMemcachedCheckCache([]string{addr}, WithVersionLifetime(0))
+
+		require.NoError(t, cache.Set(ctx, "store", "document:1#viewer@user:jon", true, time.Time{}, time.Minute))
+		memcached.evict(cache.versionKey("store"))
+
+		_, _, ok, err := cache.Get(ctx, "store", "document:1#viewer@user:jon")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+}
@@ -0,0 +1,523 @@
+// Package soak drives a long-running mixed read and write traffic against a deployment, and asserts the
+// invariants that a short test can't: the reads don't stay stale beyond a bound, e.g. because of the caches,
+// and the goroutines and the memory of the server don't grow over time. It's meant for the pre-release
+// qualification of the caching layers.
+package soak
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/prometheus/common/expfmt"
+)
+
+const (
+	// InvariantStaleRead is violated by a Check, a Read or a ListObjects whose result doesn't reflect a write
+	// acknowledged more than the staleness bound before.
+	InvariantStaleRead = "stale_read"
+
+	// InvariantGoroutineGrowth is violated when the goroutines of the server grow beyond the baseline sampled
+	// after the warmup by more than the allowed growth.
+	InvariantGoroutineGrowth = "goroutine_growth"
+
+	// InvariantMemoryGrowth is violated when the heap in use of the server grows beyond the baseline sampled
+	// after the warmup by more than the allowed growth.
+	InvariantMemoryGrowth = "memory_growth"
+
+	// InvariantErrorRate is violated when the fraction of the requests that failed exceeds the allowed rate.
+	InvariantErrorRate = "error_rate"
+
+	goroutinesMetric = "go_goroutines"
+	heapInuseMetric  = "go_memstats_heap_inuse_bytes"
+)
+
+const model = `
+type user
+
+type document
+  relations
+    define viewer: [user] as self
+`
+
+// Config configures a soak test.
+type Config struct {
+	// Duration is how long the traffic is driven for.
+	Duration time.Duration
+
+	// Concurrency is the number of concurrent workers, which each own a distinct user and its documents.
+	Concurrency int
+
+	// DocumentsPerWorker is the number of documents the user of each worker may be a viewer of.
+	DocumentsPerWorker int
+
+	// WriteRatio is the fraction (between 0 and 1) of the requests that are writes.
+	WriteRatio float64
+
+	// StalenessBound is how long after a write is acknowledged the reads may still not reflect it.
+	StalenessBound time.Duration
+
+	// MetricsURL is the URL of the Prometheus metrics of the server, e.g. 'http://localhost:2112/metrics'. The
+	// goroutines and the memory aren't asserted if it's empty.
+	MetricsURL string
+
+	// MetricsInterval is the interval the metrics are sampled at.
+	MetricsInterval time.Duration
+
+	// Warmup is how long after the start the baseline of the metrics is sampled, once the caches are filled.
+	Warmup time.Duration
+
+	// MaxGoroutineGrowth and MaxMemoryGrowth are the growths allowed over the baseline, e.g. 0.5 for 50%.
+	MaxGoroutineGrowth float64
+	MaxMemoryGrowth    float64
+
+	// MaxErrorRate is the fraction (between 0 and 1) of the requests allowed to fail.
+	MaxErrorRate float64
+}
+
+// DefaultConfig returns the default configuration of a soak test.
+func DefaultConfig() Config {
+	return Config{
+		Duration:           time.Hour,
+		Concurrency:        8,
+		DocumentsPerWorker: 100,
+		WriteRatio:         0.2,
+		StalenessBound:     30 * time.Second,
+		MetricsInterval:    15 * time.Second,
+		Warmup:             time.Minute,
+		MaxGoroutineGrowth: 0.5,
+		MaxMemoryGrowth:    1,
+		MaxErrorRate:       0.001,
+	}
+}
+
+func (c Config) validate() error {
+	if c.Duration <= 0 {
+		return fmt.Errorf("the duration must be positive")
+	}
+
+	if c.Concurrency < 1 || c.DocumentsPerWorker < 1 {
+		return fmt.Errorf("the concurrency and the documents per worker must be at least 1")
+	}
+
+	if c.WriteRatio < 0 || c.WriteRatio > 1 || c.MaxErrorRate < 0 || c.MaxErrorRate > 1 {
+		return fmt.Errorf("the write ratio and the maximum error rate must be between 0 and 1")
+	}
+
+	if c.MetricsURL != "" && c.MetricsInterval <= 0 {
+		return fmt.Errorf("the metrics interval must be positive")
+	}
+
+	return nil
+}
+
+// Sample is a sample of the runtime metrics of the server.
+type Sample struct {
+	Time           time.Time `json:"time"`
+	Goroutines     float64   `json:"goroutines"`
+	HeapInuseBytes float64   `json:"heap_inuse_bytes"`
+}
+
+// Violation is a violated invariant, with the number of times and the first time it was.
+type Violation struct {
+	Invariant string    `json:"invariant"`
+	Count     int       `json:"count"`
+	First     time.Time `json:"first"`
+	Message   string    `json:"message"`
+}
+
+// Report is the result of a soak test.
+type Report struct {
+	StoreID  string `json:"store_id"`
+	Duration string `json:"duration"`
+
+	// Requests and Errors are the numbers of requests and of failed requests, by method.
+	Requests map[string]int64 `json:"requests"`
+	Errors   map[string]int64 `json:"errors"`
+
+	// LaggingReads is the number of reads that didn't reflect a write yet, within the staleness bound.
+	LaggingReads int64 `json:"lagging_reads"`
+
+	// MetricsErrors is the number of times the metrics of the server failed to be sampled.
+	MetricsErrors int64 `json:"metrics_errors"`
+
+	Baseline   *Sample      `json:"baseline,omitempty"`
+	Samples    []*Sample    `json:"samples,omitempty"`
+	Violations []*Violation `json:"violations"`
+}
+
+// recorder records the results of the workers and of the sampler.
+type recorder struct {
+	mu         sync.Mutex
+	report     *Report
+	violations map[string]*Violation
+}
+
+func (r *recorder) request(method string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.report.Requests[method]++
+	if err != nil {
+		r.report.Errors[method]++
+	}
+}
+
+func (r *recorder) lagging() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.report.LaggingReads++
+}
+
+func (r *recorder) violation(invariant, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if v, ok := r.violations[invariant]; ok {
+		v.Count++
+		return
+	}
+
+	v := &Violation{Invariant: invariant, Count: 1, First: time.Now(), Message: message}
+	r.violations[invariant] = v
+	r.report.Violations = append(r.report.Violations, v)
+}
+
+func (r *recorder) sample(s *Sample) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.report.Samples = append(r.report.Samples, s)
+}
+
+func (r *recorder) baseline(s *Sample) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.report.Baseline = s
+}
+
+func (r *recorder) metricsError() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.report.MetricsErrors++
+}
+
+// Run creates a store and drives the traffic of the configuration against it through the client, until the
+// duration elapses or the context is done. The store is deleted at the end. The invariants violated are
+// reported, not returned as an error.
+func Run(ctx context.Context, client openfgav1.OpenFGAServiceClient, cfg Config) (*Report, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	store, err := client.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "soak"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the store: %w", err)
+	}
+	defer func() {
+		_, _ = client.DeleteStore(context.Background(), &openfgav1.DeleteStoreRequest{StoreId: store.GetId()})
+	}()
+
+	writtenModel, err := client.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         store.GetId(),
+		SchemaVersion:   typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(model),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write the authorization model: %w", err)
+	}
+
+	rec := &recorder{
+		report: &Report{
+			StoreID:    store.GetId(),
+			Requests:   map[string]int64{},
+			Errors:     map[string]int64{},
+			Violations: []*Violation{},
+		},
+		violations: map[string]*Violation{},
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Concurrency; i++ {
+		w := &worker{
+			client:  client,
+			cfg:     cfg,
+			rec:     rec,
+			store:   store.GetId(),
+			modelID: writtenModel.GetAuthorizationModelId(),
+			user:    fmt.Sprintf("user:soak-%d", i),
+			prefix:  fmt.Sprintf("document:soak-%d-", i),
+			docs:    make([]documentState, cfg.DocumentsPerWorker),
+			rand:    rand.New(rand.NewSource(start.UnixNano() + int64(i))),
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.run(ctx)
+		}()
+	}
+
+	if cfg.MetricsURL != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sampleMetrics(ctx, cfg, rec, start)
+		}()
+	}
+
+	wg.Wait()
+
+	report := rec.report
+	report.Duration = time.Since(start).Round(time.Second).String()
+
+	var requests, failures int64
+	for method, count := range report.Requests {
+		requests += count
+		failures += report.Errors[method]
+	}
+	if requests > 0 && float64(failures)/float64(requests) > cfg.MaxErrorRate {
+		rec.violation(InvariantErrorRate, fmt.Sprintf("%d of %d requests failed, above the maximum error rate of %g", failures, requests, cfg.MaxErrorRate))
+	}
+
+	return report, nil
+}
+
+// documentState is whether the user of a worker is a viewer of a document, as of the last write.
+type documentState struct {
+	viewer    bool
+	changedAt time.Time
+
+	// unknown is set when a write failed, since it may have been applied. The document isn't used anymore.
+	unknown bool
+}
+
+// worker drives the traffic of a user, whose documents no other worker writes, so that it knows what the
+// reads must return.
+type worker struct {
+	client  openfgav1.OpenFGAServiceClient
+	cfg     Config
+	rec     *recorder
+	store   string
+	modelID string
+	user    string
+	prefix  string
+	docs    []documentState
+	rand    *rand.Rand
+}
+
+func (w *worker) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		i := w.rand.Intn(len(w.docs))
+		if w.docs[i].unknown {
+			continue
+		}
+
+		switch op := w.rand.Float64(); {
+		case op < w.cfg.WriteRatio:
+			w.write(ctx, i)
+		case op < w.cfg.WriteRatio+(1-w.cfg.WriteRatio)/2:
+			w.check(ctx, i)
+		case op < w.cfg.WriteRatio+3*(1-w.cfg.WriteRatio)/4:
+			w.read(ctx, i)
+		default:
+			w.listObjects(ctx)
+		}
+	}
+}
+
+// record records a request of the method, unless it was interrupted by the end of the test.
+func (w *worker) record(ctx context.Context, method string, err error) {
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+
+	w.rec.request(method, err)
+}
+
+func (w *worker) tupleKey(i int) *openfgav1.TupleKey {
+	return &openfgav1.TupleKey{Object: fmt.Sprintf("%s%d", w.prefix, i), Relation: "viewer", User: w.user}
+}
+
+// write toggles whether the user is a viewer of the document.
+func (w *worker) write(ctx context.Context, i int) {
+	req := &openfgav1.WriteRequest{StoreId: w.store, AuthorizationModelId: w.modelID}
+	if w.docs[i].viewer {
+		req.Deletes = &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{w.tupleKey(i)}}
+	} else {
+		req.Writes = &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{w.tupleKey(i)}}
+	}
+
+	_, err := w.client.Write(ctx, req)
+	w.record(ctx, "Write", err)
+	if err != nil {
+		w.docs[i].unknown = true
+		return
+	}
+
+	w.docs[i] = documentState{viewer: !w.docs[i].viewer, changedAt: time.Now()}
+}
+
+func (w *worker) check(ctx context.Context, i int) {
+	resp, err := w.client.Check(ctx, &openfgav1.CheckRequest{
+		StoreId:              w.store,
+		AuthorizationModelId: w.modelID,
+		TupleKey:             w.tupleKey(i),
+	})
+	w.record(ctx, "Check", err)
+	if err != nil {
+		return
+	}
+
+	w.assertFresh("Check", i, resp.GetAllowed())
+}
+
+func (w *worker) read(ctx context.Context, i int) {
+	resp, err := w.client.Read(ctx, &openfgav1.ReadRequest{
+		StoreId:  w.store,
+		TupleKey: w.tupleKey(i),
+	})
+	w.record(ctx, "Read", err)
+	if err != nil {
+		return
+	}
+
+	w.assertFresh("Read", i, len(resp.GetTuples()) > 0)
+}
+
+func (w *worker) listObjects(ctx context.Context) {
+	resp, err := w.client.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+		StoreId:              w.store,
+		AuthorizationModelId: w.modelID,
+		Type:                 "document",
+		Relation:             "viewer",
+		User:                 w.user,
+	})
+	w.record(ctx, "ListObjects", err)
+	if err != nil {
+		return
+	}
+
+	objects := make(map[string]struct{}, len(resp.GetObjects()))
+	for _, object := range resp.GetObjects() {
+		objects[object] = struct{}{}
+	}
+
+	for i := range w.docs {
+		if w.docs[i].unknown {
+			continue
+		}
+
+		_, viewer := objects[w.tupleKey(i).GetObject()]
+		w.assertFresh("ListObjects", i, viewer)
+	}
+}
+
+// assertFresh records a stale read if whether the user is a viewer of the document, as read by the method,
+// doesn't reflect a write acknowledged more than the staleness bound before.
+func (w *worker) assertFresh(method string, i int, viewer bool) {
+	doc := w.docs[i]
+	if doc.unknown || viewer == doc.viewer {
+		return
+	}
+
+	if lag := time.Since(doc.changedAt); lag > w.cfg.StalenessBound {
+		w.rec.violation(InvariantStaleRead, fmt.Sprintf("%s of '%s' didn't reflect a write acknowledged %s before, beyond the staleness bound of %s", method, w.tupleKey(i).GetObject(), lag.Round(time.Millisecond), w.cfg.StalenessBound))
+		return
+	}
+
+	w.rec.lagging()
+}
+
+// sampleMetrics samples the runtime metrics of the server at the interval of the configuration, and records
+// their growth over the baseline sampled after the warmup.
+func sampleMetrics(ctx context.Context, cfg Config, rec *recorder, start time.Time) {
+	ticker := time.NewTicker(cfg.MetricsInterval)
+	defer ticker.Stop()
+
+	var baseline *Sample
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		sample, err := scrape(ctx, cfg.MetricsURL)
+		if err != nil {
+			if ctx.Err() == nil {
+				rec.metricsError()
+			}
+			continue
+		}
+		rec.sample(sample)
+
+		if sample.Time.Sub(start) < cfg.Warmup {
+			continue
+		}
+
+		if baseline == nil {
+			baseline = sample
+			rec.baseline(sample)
+			continue
+		}
+
+		if limit := baseline.Goroutines * (1 + cfg.MaxGoroutineGrowth); sample.Goroutines > limit {
+			rec.violation(InvariantGoroutineGrowth, fmt.Sprintf("%g goroutines, above %g for a baseline of %g", sample.Goroutines, limit, baseline.Goroutines))
+		}
+
+		if limit := baseline.HeapInuseBytes * (1 + cfg.MaxMemoryGrowth); sample.HeapInuseBytes > limit {
+			rec.violation(InvariantMemoryGrowth, fmt.Sprintf("%g bytes of heap in use, above %g for a baseline of %g", sample.HeapInuseBytes, limit, baseline.HeapInuseBytes))
+		}
+	}
+}
+
+// scrape reads the runtime metrics of the server from its Prometheus metrics.
+func scrape(ctx context.Context, url string) (*Sample, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d of the metrics", resp.StatusCode)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	sample := &Sample{Time: time.Now()}
+	for name, value := range map[string]*float64{goroutinesMetric: &sample.Goroutines, heapInuseMetric: &sample.HeapInuseBytes} {
+		family, ok := families[name]
+		if !ok || len(family.GetMetric()) == 0 {
+			return nil, fmt.Errorf("the metrics don't have '%s'", name)
+		}
+
+		*value = family.GetMetric()[0].GetGauge().GetValue()
+	}
+
+	return sample, nil
+}
@@ -0,0 +1,121 @@
+package soak
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/cmd/run"
+	"github.com/openfga/openfga/tests"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// staleClient acknowledges the writes without applying them, so that every read is stale.
+type staleClient struct {
+	openfgav1.OpenFGAServiceClient
+}
+
+func (c *staleClient) CreateStore(context.Context, *openfgav1.CreateStoreRequest, ...grpc.CallOption) (*openfgav1.CreateStoreResponse, error) {
+	return &openfgav1.CreateStoreResponse{Id: ulid.Make().String()}, nil
+}
+
+func (c *staleClient) DeleteStore(context.Context, *openfgav1.DeleteStoreRequest, ...grpc.CallOption) (*openfgav1.DeleteStoreResponse, error) {
+	return &openfgav1.DeleteStoreResponse{}, nil
+}
+
+func (c *staleClient) WriteAuthorizationModel(context.Context, *openfgav1.WriteAuthorizationModelRequest, ...grpc.CallOption) (*openfgav1.WriteAuthorizationModelResponse, error) {
+	return &openfgav1.WriteAuthorizationModelResponse{AuthorizationModelId: ulid.Make().String()}, nil
+}
+
+func (c *staleClient) Write(context.Context, *openfgav1.WriteRequest, ...grpc.CallOption) (*openfgav1.WriteResponse, error) {
+	return &openfgav1.WriteResponse{}, nil
+}
+
+func (c *staleClient) Check(context.Context, *openfgav1.CheckRequest, ...grpc.CallOption) (*openfgav1.CheckResponse, error) {
+	return &openfgav1.CheckResponse{}, nil
+}
+
+func (c *staleClient) Read(context.Context, *openfgav1.ReadRequest, ...grpc.CallOption) (*openfgav1.ReadResponse, error) {
+	return &openfgav1.ReadResponse{}, nil
+}
+
+func (c *staleClient) ListObjects(context.Context, *openfgav1.ListObjectsRequest, ...grpc.CallOption) (*openfgav1.ListObjectsResponse, error) {
+	return &openfgav1.ListObjectsResponse{}, nil
+}
+
+func TestRunDetectsStaleReads(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Duration = 500 * time.Millisecond
+	cfg.Concurrency = 2
+	cfg.DocumentsPerWorker = 1
+	cfg.WriteRatio = 0.01
+	cfg.StalenessBound = 0
+
+	report, err := Run(context.Background(), &staleClient{}, cfg)
+	require.NoError(t, err)
+	require.Positive(t, report.Requests["Write"])
+	require.Len(t, report.Violations, 1)
+	require.Equal(t, InvariantStaleRead, report.Violations[0].Invariant)
+	require.Positive(t, report.Violations[0].Count)
+}
+
+func TestRun(t *testing.T) {
+	cfg := run.MustDefaultConfigWithRandomPorts()
+	cfg.Log.Level = "none"
+	cfg.Datastore.Engine = "memory"
+
+	cancel := tests.StartServer(t, cfg)
+	t.Cleanup(cancel)
+
+	ctx, cancelDial := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelDial()
+
+	conn, err := grpc.DialContext(ctx, cfg.GRPC.Addr, grpc.WithBlock(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// the server runs in the process of the test, so the metrics of the process are the ones of the server
+	metrics := httptest.NewServer(promhttp.Handler())
+	defer metrics.Close()
+
+	soakCfg := DefaultConfig()
+	soakCfg.Duration = 2 * time.Second
+	soakCfg.Concurrency = 4
+	soakCfg.DocumentsPerWorker = 10
+	soakCfg.StalenessBound = time.Second
+	soakCfg.MetricsURL = metrics.URL
+	soakCfg.MetricsInterval = 100 * time.Millisecond
+	soakCfg.Warmup = 500 * time.Millisecond
+	soakCfg.MaxGoroutineGrowth = 10
+	soakCfg.MaxMemoryGrowth = 10
+
+	report, err := Run(context.Background(), openfgav1.NewOpenFGAServiceClient(conn), soakCfg)
+	require.NoError(t, err)
+	require.Empty(t, report.Violations)
+	require.Zero(t, report.MetricsErrors)
+	require.NotNil(t, report.Baseline)
+	require.NotEmpty(t, report.Samples)
+
+	for _, method := range []string{"Write", "Check", "Read", "ListObjects"} {
+		require.Positive(t, report.Requests[method], method)
+		require.Zero(t, report.Errors[method], method)
+	}
+
+	// the store is deleted at the end
+	_, err = openfgav1.NewOpenFGAServiceClient(conn).GetStore(context.Background(), &openfgav1.GetStoreRequest{StoreId: report.StoreID})
+	require.Error(t, err)
+}
+
+func TestConfigValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.WriteRatio = 2
+
+	_, err := Run(context.Background(), &staleClient{}, cfg)
+	require.Error(t, err)
+}
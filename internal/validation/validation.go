@@ -11,6 +11,57 @@ import (
 	"github.com/openfga/openfga/pkg/typesystem"
 )
 
+// ErrSelfReferentialTuple is the cause of the errors of the tuples that relate a userset of an object to
+// itself.
+var ErrSelfReferentialTuple = errors.New("the tuple relates a userset of the object to itself")
+
+// ValidateNotSelfReferential returns an error if the user of the tuple is a userset of the object of the
+// tuple, and its relation is either the relation of the tuple (e.g. 'group:eng#member' as a member of
+// 'group:eng') or defined from it through computed relations, since such a tuple only creates a cycle that
+// wastes resolution work. The tuple must be valid.
+func ValidateNotSelfReferential(typesys *typesystem.TypeSystem, tk *openfgav1.TupleKey) error {
+	user, err := tuple.ParseUser(tk.GetUser())
+	if err != nil || user.Kind != tuple.UserKindUserset || user.Object().String() != tk.GetObject() {
+		return nil
+	}
+
+	if user.Relation == tk.GetRelation() ||
+		isComputedFrom(typesys, user.Type, user.Relation, tk.GetRelation(), map[string]struct{}{}) {
+		return &tuple.InvalidTupleError{Cause: ErrSelfReferentialTuple, TupleKey: tk}
+	}
+
+	return nil
+}
+
+// isComputedFrom reports whether the rewrite of the relation of the object type references the target
+// relation of the same object, directly or through other computed relations.
+func isComputedFrom(typesys *typesystem.TypeSystem, objectType, relation, target string, visited map[string]struct{}) bool {
+	if _, ok := visited[relation]; ok {
+		return false
+	}
+	visited[relation] = struct{}{}
+
+	rel, err := typesys.GetRelation(objectType, relation)
+	if err != nil {
+		return false
+	}
+
+	found, err := typesystem.WalkUsersetRewrite(rel.GetRewrite(), func(r *openfgav1.Userset) interface{} {
+		computed := r.GetComputedUserset().GetRelation()
+		if computed == "" {
+			return nil
+		}
+
+		if computed == target || isComputedFrom(typesys, objectType, computed, target, visited) {
+			return true
+		}
+
+		return nil
+	})
+
+	return err == nil && found != nil
+}
+
 // ValidateUserObjectRelation returns nil if the tuple is well-formed and valid according to the provided model.
 //
 // Do NOT use this when reading or writing tuples to storage. Use ValidateTuple instead, because it's stricter.
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"github.com/openfga/openfga/pkg/tuple"
 	"github.com/openfga/openfga/pkg/typesystem"
@@ -694,3 +695,65 @@ func TestValidateTuple(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateNotSelfReferential(t *testing.T) {
+	typesys := typesystem.New(&openfgav1.AuthorizationModel{
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type group
+		  relations
+		    define member: [user, group#member, group#admin, group#viewer] as self
+		    define admin: [user, group#member] as self
+		    define owner: [user] as self
+		    define viewer: [user, group#owner] as self or member
+		`),
+	})
+
+	tests := []struct {
+		name          string
+		tuple         *openfgav1.TupleKey
+		selfReferring bool
+	}{
+		{
+			name:  "user",
+			tuple: tuple.NewTupleKey("group:eng", "member", "user:anne"),
+		},
+		{
+			name:  "userset_of_another_object",
+			tuple: tuple.NewTupleKey("group:eng", "member", "group:fga#member"),
+		},
+		{
+			name:          "same_relation",
+			tuple:         tuple.NewTupleKey("group:eng", "member", "group:eng#member"),
+			selfReferring: true,
+		},
+		{
+			name:  "unrelated_relation",
+			tuple: tuple.NewTupleKey("group:eng", "member", "group:eng#admin"),
+		},
+		{
+			name:          "computed_from_the_relation",
+			tuple:         tuple.NewTupleKey("group:eng", "member", "group:eng#viewer"),
+			selfReferring: true,
+		},
+		{
+			name:  "relation_computed_from_the_user_relation",
+			tuple: tuple.NewTupleKey("group:eng", "viewer", "group:eng#owner"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateNotSelfReferential(typesys, test.tuple)
+			if test.selfReferring {
+				var invalidTupleErr *tuple.InvalidTupleError
+				require.ErrorAs(t, err, &invalidTupleErr)
+				require.ErrorIs(t, invalidTupleErr.Cause, ErrSelfReferentialTuple)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,1145 @@
+// Package admin contains the HTTP handler of the admin API, which exposes the operational controls of the
+// server (e.g. the maintenance mode). It is meant to be served on an address that is only reachable by
+// the operators of the server.
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	grpcauth "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/internal/authn"
+	"github.com/openfga/openfga/pkg/cachestats"
+	"github.com/openfga/openfga/pkg/graphql"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/middleware/maintenance"
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/server/commands"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storefile"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	maintenancePath         = "/admin/maintenance"
+	storeMaintenancePath    = "/admin/maintenance/stores/"
+	shadowCheckPath         = "/admin/shadow-check"
+	storeShadowCheckPath    = "/admin/shadow-check/stores/"
+	storesPath              = "/admin/stores/"
+	authorizationModelsPath = "/admin/authorization-models/stores/"
+	relationUsagePath       = "/admin/relation-usage/stores/"
+	modelImpactPath         = "/admin/model-impact/stores/"
+	modelValidationPath     = "/admin/validate-authorization-model/stores/"
+	modelDiffPath           = "/admin/model-diff/stores/"
+	relationRenamesPath     = "/admin/relation-renames/stores/"
+	tupleSamplesPath        = "/admin/tuple-samples/stores/"
+	cachesPath              = "/admin/caches"
+	storeCachesPath         = "/admin/caches/stores/"
+	graphQLPath             = "/admin/graphql"
+	contentTypeHeader       = "Content-Type"
+	contentTypeJSONHeader   = "application/json"
+	contentTypeYAMLHeader   = "application/yaml"
+	contentTypeCSV          = "text/csv"
+	contentTypeNDJSON       = "application/x-ndjson"
+
+	// maxStoreFileSize is the maximum size of the store files that can be imported.
+	maxStoreFileSize = 64 << 20
+)
+
+// MaintenanceStatus is the maintenance of a store or of the whole server.
+type MaintenanceStatus struct {
+	Message    string    `json:"message"`
+	RetryAfter string    `json:"retry_after"`
+	Since      time.Time `json:"since"`
+}
+
+// MaintenanceResponse lists the maintenance of the whole server, if any, and the one of every store.
+type MaintenanceResponse struct {
+	Global *MaintenanceStatus            `json:"global"`
+	Stores map[string]*MaintenanceStatus `json:"stores"`
+}
+
+// EnableMaintenanceRequest is the optional body of the requests that enable maintenance.
+type EnableMaintenanceRequest struct {
+	Message    string `json:"message"`
+	RetryAfter string `json:"retry_after"`
+}
+
+// ShadowCheckResponse lists the candidate authorization models that Checks are shadow evaluated against,
+// keyed by store ID.
+type ShadowCheckResponse struct {
+	Candidates map[string]string `json:"candidates"`
+}
+
+// SetShadowCheckCandidateRequest is the body of the requests that set the candidate model of a store.
+type SetShadowCheckCandidateRequest struct {
+	AuthorizationModelID string `json:"authorization_model_id"`
+}
+
+// StoreFileService imports and exports store files. It's implemented by server.Server.
+type StoreFileService interface {
+	ImportStore(ctx context.Context, req *commands.ImportStoreRequest) (*commands.ImportStoreResponse, error)
+	ExportStore(ctx context.Context, req *commands.ExportStoreRequest) (*storefile.StoreFile, error)
+	ImportTuples(ctx context.Context, req *commands.ImportTuplesRequest) (*commands.ImportTuplesResponse, error)
+}
+
+// AuthorizationModelLabelService finds the authorization models by label. It's implemented by server.Server.
+type AuthorizationModelLabelService interface {
+	GetAuthorizationModelByLabel(ctx context.Context, req *commands.GetAuthorizationModelByLabelRequest) (*commands.GetAuthorizationModelByLabelResponse, error)
+}
+
+// RelationUsageService reports the usage of the relations of the authorization models. It's implemented by
+// server.Server.
+type RelationUsageService interface {
+	GetRelationUsage(ctx context.Context, req *server.GetRelationUsageRequest) (*server.GetRelationUsageResponse, error)
+}
+
+// ModelImpactService analyzes the impact of the candidate authorization models on the tuples. It's
+// implemented by server.Server.
+type ModelImpactService interface {
+	AnalyzeModelImpact(ctx context.Context, req *commands.ModelImpactRequest) (*commands.ModelImpactResponse, error)
+}
+
+// ModelValidationService validates the authorization models without writing them. It's implemented by
+// server.Server.
+type ModelValidationService interface {
+	ValidateAuthorizationModel(ctx context.Context, req *openfgav1.WriteAuthorizationModelRequest) (*commands.ValidateAuthorizationModelResponse, error)
+}
+
+// ModelDiffService reports the changes between the authorization models. It's implemented by server.Server.
+type ModelDiffService interface {
+	DiffAuthorizationModels(ctx context.Context, req *commands.ModelDiffRequest) (*commands.ModelDiffResponse, error)
+}
+
+// RelationRenameService rewrites the tuples of the relations being renamed. It's implemented by
+// server.Server.
+type RelationRenameService interface {
+	RenameRelations(ctx context.Context, req *commands.RenameRelationsRequest) (*commands.RenameRelationsResponse, error)
+}
+
+// TupleSampleService samples the tuples of the stores. It's implemented by server.Server.
+type TupleSampleService interface {
+	SampleTuples(ctx context.Context, req *commands.SampleTuplesRequest) (*commands.SampleTuplesResponse, error)
+}
+
+// CacheStats are the statistics of a cache of the server.
+type CacheStats struct {
+	Name    string  `json:"name"`
+	Size    int     `json:"size"`
+	Hits    uint64  `json:"hits"`
+	Misses  uint64  `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+
+	// OldestEntry is when the oldest entry of the cache was cached, which is null if the cache is empty.
+	OldestEntry *time.Time `json:"oldest_entry"`
+}
+
+// CachesResponse lists the statistics of the caches of the server.
+type CachesResponse struct {
+	Caches []CacheStats `json:"caches"`
+}
+
+// FlushCachesResponse reports the number of entries flushed from every cache.
+type FlushCachesResponse struct {
+	Flushed map[string]int `json:"flushed"`
+}
+
+// AuthorizationModelResponse is an authorization model, encoded as in the HTTP API, along with its labels.
+type AuthorizationModelResponse struct {
+	AuthorizationModel json.RawMessage   `json:"authorization_model"`
+	Labels             map[string]string `json:"labels"`
+}
+
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+// Handler serves the admin API.
+type Handler struct {
+	mux           *http.ServeMux
+	logger        logger.Logger
+	authenticator authn.Authenticator
+	keys          map[string]struct{}
+	requiredClaim *requiredClaim
+	caches        []cachestats.Cache
+	maintenance   *maintenance.Mode
+	shadowCheck   *server.ShadowCheckCandidates
+	storeFiles    StoreFileService
+	modelLabels   AuthorizationModelLabelService
+	usage         RelationUsageService
+	modelImpact   ModelImpactService
+	validation    ModelValidationService
+	modelDiff     ModelDiffService
+	renames       RelationRenameService
+	tupleSamples  TupleSampleService
+	graphQL       graphql.Service
+	graphQLOpts   []graphql.HandlerOpt
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+// HandlerOpt defines an option that can be used to change the behavior of a Handler.
+type HandlerOpt func(*Handler)
+
+// WithLogger sets the logger of the Handler.
+func WithLogger(logger logger.Logger) HandlerOpt {
+	return func(h *Handler) {
+		h.logger = logger
+	}
+}
+
+// WithAuthenticator authenticates every request to the admin API with the authenticator, e.g. the one of the
+// API of the server, from the headers of the request (e.g. 'Authorization: Bearer <key>'). The requests
+// that fail to authenticate are rejected with the HTTP status the API would have responded with.
+func WithAuthenticator(authenticator authn.Authenticator) HandlerOpt {
+	return func(h *Handler) {
+		h.authenticator = authenticator
+	}
+}
+
+// WithKeys accepts the requests to the admin API made with one of the keys ('Authorization: Bearer <key>'),
+// which are the credentials of the operators of the server and must differ from the ones of the API. The
+// other callers the authenticator authenticates are rejected with 403 Forbidden, unless they have the
+// claim required by WithRequiredClaim.
+func WithKeys(keys ...string) HandlerOpt {
+	return func(h *Handler) {
+		h.keys = make(map[string]struct{}, len(keys))
+		for _, key := range keys {
+			h.keys[key] = struct{}{}
+		}
+	}
+}
+
+// WithRequiredClaim only accepts the requests to the admin API of the callers the authenticator
+// authenticates with the claim of that name and value (e.g. 'role' and 'admin'), which either is the value
+// of the claim or is one of its values, if it's a list or a space separated string (as 'scope'). The other
+// callers are rejected with 403 Forbidden, unless they're using one of the keys of WithKeys.
+func WithRequiredClaim(name, value string) HandlerOpt {
+	return func(h *Handler) {
+		h.requiredClaim = &requiredClaim{name: name, value: value}
+	}
+}
+
+// WithCaches exposes the statistics of the provided caches, and their flush:
+//
+//	GET    /admin/caches               lists the statistics of every cache
+//	DELETE /admin/caches               flushes every cache
+//	DELETE /admin/caches/stores/{id}   flushes the entries of a store from every cache
+//
+// The 'cache' query parameter of the DELETE requests only flushes the cache of that name.
+func WithCaches(caches ...cachestats.Cache) HandlerOpt {
+	return func(h *Handler) {
+		h.caches = append(h.caches, caches...)
+	}
+}
+
+// WithMaintenanceMode exposes the provided maintenance mode:
+//
+//	GET    /admin/maintenance               lists the maintenance of the server and of every store
+//	PUT    /admin/maintenance               puts the whole server in maintenance
+//	DELETE /admin/maintenance               takes the whole server out of maintenance
+//	PUT    /admin/maintenance/stores/{id}   puts a store in maintenance
+//	DELETE /admin/maintenance/stores/{id}   takes a store out of maintenance
+//
+// The PUT requests accept an optional EnableMaintenanceRequest body.
+func WithMaintenanceMode(mode *maintenance.Mode) HandlerOpt {
+	return func(h *Handler) {
+		h.maintenance = mode
+	}
+}
+
+// WithShadowCheckCandidates exposes the provided candidate authorization models of the shadow evaluation of
+// Checks:
+//
+//	GET    /admin/shadow-check               lists the candidate model of every store
+//	PUT    /admin/shadow-check/stores/{id}   sets the candidate model of a store
+//	DELETE /admin/shadow-check/stores/{id}   stops the shadow evaluation of the Checks of a store
+//
+// The PUT requests require a SetShadowCheckCandidateRequest body.
+func WithShadowCheckCandidates(candidates *server.ShadowCheckCandidates) HandlerOpt {
+	return func(h *Handler) {
+		h.shadowCheck = candidates
+	}
+}
+
+// WithStoreFiles exposes the import and the export of store files ('.fga.yaml'):
+//
+//	POST /admin/stores/import        imports the store file in the body into a new store, or into the
+//	                                 store of the 'store_id' query parameter
+//	GET  /admin/stores/{id}/export   exports a store, with the model of the 'authorization_model_id'
+//	                                 query parameter or with its latest model
+//	POST /admin/stores/{id}/tuples   imports the tuple file in the body, validated against the model of the
+//	                                 'authorization_model_id' query parameter or the latest model
+//	GET  /admin/stores/{id}/tuples   exports the tuples of a store as a tuple file
+//
+// The format of the tuple files is the 'format' query parameter ('yaml', 'csv' or 'ndjson'). Otherwise, the
+// imported files are in the format of their content type ('text/csv', 'application/x-ndjson' or YAML), and
+// the exported files are in YAML.
+func WithStoreFiles(service StoreFileService) HandlerOpt {
+	return func(h *Handler) {
+		h.storeFiles = service
+	}
+}
+
+// WithAuthorizationModelLabels exposes the lookup of the authorization models by label:
+//
+//	GET /admin/authorization-models/stores/{id}?label=release=42   returns the latest model of the store
+//	                                                             with the label, along with its labels
+func WithAuthorizationModelLabels(service AuthorizationModelLabelService) HandlerOpt {
+	return func(h *Handler) {
+		h.modelLabels = service
+	}
+}
+
+// WithRelationUsage exposes the usage of the relations of the authorization models:
+//
+//	GET /admin/relation-usage/stores/{id}   returns the number of Checks of every relation of the model of
+//	                                        the 'authorization_model_id' query parameter, or of the latest
+//	                                        model, and the number of tuples written with it
+func WithRelationUsage(service RelationUsageService) HandlerOpt {
+	return func(h *Handler) {
+		h.usage = service
+	}
+}
+
+// WithModelImpact exposes the analysis of the impact of a candidate authorization model on the tuples:
+//
+//	POST /admin/model-impact/stores/{id}   reports the tuples of the store that don't fit the model in the
+//	                                       body, encoded as in the HTTP API, which isn't written
+//
+// The 'sample_rate' query parameter (between 0 and 1) only scans a fraction of the tuples, and the
+// 'max_tuples' query parameter stops the scan after some tuples.
+func WithModelImpact(service ModelImpactService) HandlerOpt {
+	return func(h *Handler) {
+		h.modelImpact = service
+	}
+}
+
+// WithModelValidation exposes the validation of an authorization model that isn't written, e.g. to lint the
+// models in CI pipelines before they are deployed:
+//
+//	POST /admin/validate-authorization-model/stores/{id}   validates the model in the body, encoded as in the
+//	                                                       HTTP API, and reports its errors and warnings
+//
+// The model is validated with the conditions of the 'openfga-authorization-model-conditions' header, if any,
+// and the response is 200 whether the model is valid or not.
+func WithModelValidation(service ModelValidationService) HandlerOpt {
+	return func(h *Handler) {
+		h.validation = service
+	}
+}
+
+// WithModelDiff exposes the changes between the authorization models of a store:
+//
+//	GET /admin/model-diff/stores/{id}   reports the types, the relations and the type restrictions added and
+//	                                    removed from the model of the 'from' query parameter to the one of the
+//	                                    'to' query parameter, or to the latest model, and flags the changes
+//	                                    that invalidate tuples of the store
+//
+// The 'max_tuples' query parameter stops the scan of the tuples after some tuples.
+func WithModelDiff(service ModelDiffService) HandlerOpt {
+	return func(h *Handler) {
+		h.modelDiff = service
+	}
+}
+
+// WithRelationRenames exposes the migration of the tuples of the relations being renamed:
+//
+//	POST /admin/relation-renames/stores/{id}   rewrites the tuples of the renames of the
+//	                                           RenameRelationsRequest body with their new relations
+//
+// The 'dry_run' field of the body only reports the tuples that would be rewritten.
+func WithRelationRenames(service RelationRenameService) HandlerOpt {
+	return func(h *Handler) {
+		h.renames = service
+	}
+}
+
+// WithTupleSamples exposes the sampling of the tuples of the stores, e.g. for support engineers to see what
+// the tuples of a huge store look like:
+//
+//	GET /admin/tuple-samples/stores/{id}   returns a random sample of the tuples of the store
+//
+// The 'object_type' and 'relation' query parameters only sample the tuples of an object type and relation,
+// and the 'size' query parameter sets the number of tuples sampled.
+func WithTupleSamples(service TupleSampleService) HandlerOpt {
+	return func(h *Handler) {
+		h.tupleSamples = service
+	}
+}
+
+// WithGraphQL exposes the read APIs of the service as a GraphQL endpoint (see package graphql):
+//
+//	GET, POST /admin/graphql   executes a GraphQL query
+//
+// The options configure the GraphQL handler, e.g. to share the concurrency limiter of the server with it.
+func WithGraphQL(service graphql.Service, opts ...graphql.HandlerOpt) HandlerOpt {
+	return func(h *Handler) {
+		h.graphQL = service
+		h.graphQLOpts = opts
+	}
+}
+
+// NewHandler constructs a Handler of the admin API.
+func NewHandler(opts ...HandlerOpt) *Handler {
+	h := &Handler{
+		mux:    http.NewServeMux(),
+		logger: logger.NewNoopLogger(),
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	if h.maintenance != nil {
+		h.mux.HandleFunc(maintenancePath, h.handleMaintenance)
+		h.mux.HandleFunc(storeMaintenancePath, h.handleMaintenance)
+	}
+
+	if h.shadowCheck != nil {
+		h.mux.HandleFunc(shadowCheckPath, h.handleShadowCheck)
+		h.mux.HandleFunc(storeShadowCheckPath, h.handleShadowCheck)
+	}
+
+	if h.storeFiles != nil {
+		h.mux.HandleFunc(storesPath, h.handleStoreFiles)
+	}
+
+	if h.modelLabels != nil {
+		h.mux.HandleFunc(authorizationModelsPath, h.handleAuthorizationModels)
+	}
+
+	if h.usage != nil {
+		h.mux.HandleFunc(relationUsagePath, h.handleRelationUsage)
+	}
+
+	if h.modelImpact != nil {
+		h.mux.HandleFunc(modelImpactPath, h.handleModelImpact)
+	}
+
+	if h.validation != nil {
+		h.mux.HandleFunc(modelValidationPath, h.handleModelValidation)
+	}
+
+	if h.modelDiff != nil {
+		h.mux.HandleFunc(modelDiffPath, h.handleModelDiff)
+	}
+
+	if h.renames != nil {
+		h.mux.HandleFunc(relationRenamesPath, h.handleRelationRenames)
+	}
+
+	if h.tupleSamples != nil {
+		h.mux.HandleFunc(tupleSamplesPath, h.handleTupleSamples)
+	}
+
+	if h.graphQL != nil {
+		h.mux.Handle(graphQLPath, graphql.NewHandler(h.graphQL, append([]graphql.HandlerOpt{graphql.WithLogger(h.logger)}, h.graphQLOpts...)...))
+	}
+
+	if len(h.caches) > 0 {
+		h.mux.HandleFunc(cachesPath, h.handleCaches)
+		h.mux.HandleFunc(storeCachesPath, h.handleCaches)
+	}
+
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := incomingContext(r)
+	if h.hasKey(ctx) {
+		h.mux.ServeHTTP(w, r)
+		return
+	}
+
+	var claims *authn.AuthClaims
+	if h.authenticator != nil {
+		var err error
+		if claims, err = h.authenticator.Authenticate(ctx); err != nil {
+			writeStatusError(w, err)
+			return
+		}
+	}
+
+	// the callers of the API are only operators if they have the required claim, when the operators have
+	// their own credentials
+	if (h.keys != nil || h.requiredClaim != nil) && !h.requiredClaim.heldBy(claims) {
+		writeError(w, http.StatusForbidden, "the admin API is restricted to the operators of the server")
+		return
+	}
+
+	h.mux.ServeHTTP(w, r)
+}
+
+// hasKey returns true if the request is made with one of the keys of the operators.
+func (h *Handler) hasKey(ctx context.Context) bool {
+	if h.keys == nil {
+		return false
+	}
+
+	key, err := grpcauth.AuthFromMD(ctx, "Bearer")
+	if err != nil {
+		return false
+	}
+
+	_, ok := h.keys[key]
+	return ok
+}
+
+// requiredClaim is the claim the callers of the API must have to be served by the admin API.
+type requiredClaim struct {
+	name  string
+	value string
+}
+
+// heldBy returns true if the claims have the required claim, as its value or as one of its values.
+func (c *requiredClaim) heldBy(claims *authn.AuthClaims) bool {
+	if c == nil || claims == nil {
+		return false
+	}
+
+	switch value := claims.Claims[c.name].(type) {
+	case string:
+		for _, v := range strings.Fields(value) {
+			if v == c.value {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, v := range value {
+			if v == c.value {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// incomingContext returns the context of the request with its headers as the incoming gRPC metadata, which
+// the authenticators read.
+func incomingContext(r *http.Request) context.Context {
+	md := metadata.MD{}
+	for name, values := range r.Header {
+		md.Append(strings.ToLower(name), values...)
+	}
+
+	return metadata.NewIncomingContext(r.Context(), md)
+}
+
+// storeIDFromPath returns the store ID at the end of the path of the request, if it starts with the
+// provided prefix. It returns false if the store ID is empty or malformed.
+func storeIDFromPath(r *http.Request, prefix string) (string, bool) {
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		return "", true
+	}
+
+	storeID := strings.TrimPrefix(r.URL.Path, prefix)
+	if storeID == "" || strings.Contains(storeID, "/") {
+		return "", false
+	}
+
+	return storeID, true
+}
+
+func (h *Handler) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	storeID, ok := storeIDFromPath(r, storeMaintenancePath)
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if storeID != "" {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+	case http.MethodPut:
+		req := EnableMaintenanceRequest{}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+				return
+			}
+		}
+
+		var retryAfter time.Duration
+		if req.RetryAfter != "" {
+			var err error
+			if retryAfter, err = time.ParseDuration(req.RetryAfter); err != nil || retryAfter < 0 {
+				writeError(w, http.StatusBadRequest, "'retry_after' must be a non-negative duration")
+				return
+			}
+		}
+
+		h.maintenance.Enable(storeID, req.Message, retryAfter)
+		h.logger.Info("maintenance enabled", zap.String("store_id", storeID))
+	case http.MethodDelete:
+		h.maintenance.Disable(storeID)
+		h.logger.Info("maintenance disabled", zap.String("store_id", storeID))
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	global, stores := h.maintenance.Snapshot()
+	resp := MaintenanceResponse{
+		Global: toMaintenanceStatus(global),
+		Stores: make(map[string]*MaintenanceStatus, len(stores)),
+	}
+	for id, s := range stores {
+		s := s
+		resp.Stores[id] = toMaintenanceStatus(&s)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) handleCaches(w http.ResponseWriter, r *http.Request) {
+	storeID, ok := storeIDFromPath(r, storeCachesPath)
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && storeID == "":
+		resp := CachesResponse{Caches: make([]CacheStats, 0, len(h.caches))}
+		for _, cache := range h.caches {
+			resp.Caches = append(resp.Caches, toCacheStats(cache))
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	case r.Method == http.MethodDelete:
+		name := r.URL.Query().Get("cache")
+
+		resp := FlushCachesResponse{Flushed: map[string]int{}}
+		for _, cache := range h.caches {
+			if name == "" || cache.Name() == name {
+				resp.Flushed[cache.Name()] = cache.Flush(storeID)
+			}
+		}
+
+		if len(resp.Flushed) == 0 {
+			writeError(w, http.StatusNotFound, "unknown cache '"+name+"'")
+			return
+		}
+
+		h.logger.Info("caches flushed",
+			zap.String("store_id", storeID),
+			zap.Any("flushed", resp.Flushed))
+
+		writeJSON(w, http.StatusOK, resp)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func toCacheStats(cache cachestats.Cache) CacheStats {
+	stats := cache.Stats()
+
+	resp := CacheStats{
+		Name:    cache.Name(),
+		Size:    stats.Size,
+		Hits:    stats.Hits,
+		Misses:  stats.Misses,
+		HitRate: stats.HitRate(),
+	}
+	if !stats.OldestEntry.IsZero() {
+		resp.OldestEntry = &stats.OldestEntry
+	}
+
+	return resp
+}
+
+func (h *Handler) handleShadowCheck(w http.ResponseWriter, r *http.Request) {
+	storeID, ok := storeIDFromPath(r, storeShadowCheckPath)
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && storeID == "":
+	case r.Method == http.MethodPut && storeID != "":
+		req := SetShadowCheckCandidateRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+
+		if _, err := ulid.Parse(req.AuthorizationModelID); err != nil {
+			writeError(w, http.StatusBadRequest, "'authorization_model_id' must be a valid ULID")
+			return
+		}
+
+		h.shadowCheck.Set(storeID, req.AuthorizationModelID)
+		h.logger.Info("shadow check candidate set",
+			zap.String("store_id", storeID),
+			zap.String("authorization_model_id", req.AuthorizationModelID))
+	case r.Method == http.MethodDelete && storeID != "":
+		h.shadowCheck.Delete(storeID)
+		h.logger.Info("shadow check candidate deleted", zap.String("store_id", storeID))
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ShadowCheckResponse{Candidates: h.shadowCheck.List()})
+}
+
+func (h *Handler) handleStoreFiles(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, storesPath)
+
+	if path == "import" {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		data, err := io.ReadAll(io.LimitReader(r.Body, maxStoreFileSize))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+
+		file, err := storefile.Parse(data)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if file.ModelFile != "" || file.TupleFile != "" {
+			writeError(w, http.StatusBadRequest, "the model and the tuples of the store file must be inlined")
+			return
+		}
+
+		resp, err := h.storeFiles.ImportStore(r.Context(), &commands.ImportStoreRequest{
+			StoreID: r.URL.Query().Get("store_id"),
+			File:    file,
+		})
+		if err != nil {
+			writeStatusError(w, err)
+			return
+		}
+
+		h.logger.Info("store imported",
+			zap.String("store_id", resp.StoreID),
+			zap.String("authorization_model_id", resp.AuthorizationModelID))
+		writeJSON(w, http.StatusCreated, resp)
+		return
+	}
+
+	storeID, action, found := strings.Cut(path, "/")
+	if !found || storeID == "" || (action != "export" && action != "tuples") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if action == "tuples" {
+		h.handleTuples(w, r, storeID)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	file, err := h.storeFiles.ExportStore(r.Context(), &commands.ExportStoreRequest{
+		StoreID:              storeID,
+		AuthorizationModelID: r.URL.Query().Get("authorization_model_id"),
+	})
+	if err != nil {
+		writeStatusError(w, err)
+		return
+	}
+
+	data, err := file.Marshal()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set(contentTypeHeader, contentTypeYAMLHeader)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+func (h *Handler) handleAuthorizationModels(w http.ResponseWriter, r *http.Request) {
+	storeID, ok := storeIDFromPath(r, authorizationModelsPath)
+	if !ok || storeID == "" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	label := r.URL.Query().Get("label")
+	if label == "" {
+		writeError(w, http.StatusBadRequest, "the 'label' query parameter is required")
+		return
+	}
+
+	resp, err := h.modelLabels.GetAuthorizationModelByLabel(r.Context(), &commands.GetAuthorizationModelByLabelRequest{
+		StoreID: storeID,
+		Label:   label,
+	})
+	if err != nil {
+		writeStatusError(w, err)
+		return
+	}
+
+	model, err := protojson.Marshal(resp.AuthorizationModel)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, AuthorizationModelResponse{AuthorizationModel: model, Labels: resp.Labels})
+}
+
+func (h *Handler) handleRelationUsage(w http.ResponseWriter, r *http.Request) {
+	storeID, ok := storeIDFromPath(r, relationUsagePath)
+	if !ok || storeID == "" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	resp, err := h.usage.GetRelationUsage(r.Context(), &server.GetRelationUsageRequest{
+		StoreID:              storeID,
+		AuthorizationModelID: r.URL.Query().Get("authorization_model_id"),
+	})
+	if err != nil {
+		writeStatusError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) handleModelImpact(w http.ResponseWriter, r *http.Request) {
+	storeID, ok := storeIDFromPath(r, modelImpactPath)
+	if !ok || storeID == "" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	req := &commands.ModelImpactRequest{StoreID: storeID}
+
+	var err error
+	if value := r.URL.Query().Get("sample_rate"); value != "" {
+		if req.SampleRate, err = strconv.ParseFloat(value, 64); err != nil {
+			writeError(w, http.StatusBadRequest, "'sample_rate' must be a number")
+			return
+		}
+	}
+	if value := r.URL.Query().Get("max_tuples"); value != "" {
+		if req.MaxTuples, err = strconv.Atoi(value); err != nil {
+			writeError(w, http.StatusBadRequest, "'max_tuples' must be an integer")
+			return
+		}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxStoreFileSize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	req.AuthorizationModel = &openfgav1.AuthorizationModel{}
+	if err := protojson.Unmarshal(body, req.AuthorizationModel); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid authorization model: "+err.Error())
+		return
+	}
+
+	resp, err := h.modelImpact.AnalyzeModelImpact(r.Context(), req)
+	if err != nil {
+		writeStatusError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) handleModelValidation(w http.ResponseWriter, r *http.Request) {
+	storeID, ok := storeIDFromPath(r, modelValidationPath)
+	if !ok || storeID == "" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxStoreFileSize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	req := &openfgav1.WriteAuthorizationModelRequest{}
+	if err := protojson.Unmarshal(body, req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid authorization model: "+err.Error())
+		return
+	}
+	req.StoreId = storeID
+
+	resp, err := h.validation.ValidateAuthorizationModel(incomingContext(r), req)
+	if err != nil {
+		writeStatusError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) handleModelDiff(w http.ResponseWriter, r *http.Request) {
+	storeID, ok := storeIDFromPath(r, modelDiffPath)
+	if !ok || storeID == "" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	req := &commands.ModelDiffRequest{
+		StoreID:                  storeID,
+		FromAuthorizationModelID: r.URL.Query().Get("from"),
+		ToAuthorizationModelID:   r.URL.Query().Get("to"),
+	}
+	if req.FromAuthorizationModelID == "" {
+		writeError(w, http.StatusBadRequest, "'from' is required")
+		return
+	}
+
+	if value := r.URL.Query().Get("max_tuples"); value != "" {
+		var err error
+		if req.MaxTuples, err = strconv.Atoi(value); err != nil {
+			writeError(w, http.StatusBadRequest, "'max_tuples' must be an integer")
+			return
+		}
+	}
+
+	resp, err := h.modelDiff.DiffAuthorizationModels(r.Context(), req)
+	if err != nil {
+		writeStatusError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) handleRelationRenames(w http.ResponseWriter, r *http.Request) {
+	storeID, ok := storeIDFromPath(r, relationRenamesPath)
+	if !ok || storeID == "" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	req := &commands.RenameRelationsRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	req.StoreID = storeID
+
+	resp, err := h.renames.RenameRelations(r.Context(), req)
+	if err != nil {
+		writeStatusError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) handleTupleSamples(w http.ResponseWriter, r *http.Request) {
+	storeID, ok := storeIDFromPath(r, tupleSamplesPath)
+	if !ok || storeID == "" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	req := &commands.SampleTuplesRequest{
+		StoreID:    storeID,
+		ObjectType: query.Get("object_type"),
+		Relation:   query.Get("relation"),
+	}
+
+	if value := query.Get("size"); value != "" {
+		var err error
+		if req.Size, err = strconv.Atoi(value); err != nil {
+			writeError(w, http.StatusBadRequest, "'size' must be an integer")
+			return
+		}
+	}
+
+	resp, err := h.tupleSamples.SampleTuples(r.Context(), req)
+	if err != nil {
+		writeStatusError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) handleTuples(w http.ResponseWriter, r *http.Request, storeID string) {
+	query := r.URL.Query()
+
+	format := storefile.TupleFormatYAML
+	if r.Method == http.MethodPost {
+		format = tupleFormatOfContentType(r.Header.Get(contentTypeHeader))
+	}
+	if name := query.Get("format"); name != "" {
+		var err error
+		if format, err = storefile.ParseTupleFormat(name); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		data, err := io.ReadAll(io.LimitReader(r.Body, maxStoreFileSize))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+
+		tuples, err := storefile.ParseTuples(data, format)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid tuple file: "+err.Error())
+			return
+		}
+
+		resp, err := h.storeFiles.ImportTuples(r.Context(), &commands.ImportTuplesRequest{
+			StoreID:              storeID,
+			AuthorizationModelID: query.Get("authorization_model_id"),
+			Tuples:               tuples,
+		})
+		if err != nil {
+			writeStatusError(w, err)
+			return
+		}
+
+		h.logger.Info("tuples imported",
+			zap.String("store_id", resp.StoreID),
+			zap.Int("tuples_written", resp.TuplesWritten))
+		writeJSON(w, http.StatusCreated, resp)
+	case http.MethodGet:
+		file, err := h.storeFiles.ExportStore(r.Context(), &commands.ExportStoreRequest{
+			StoreID:              storeID,
+			AuthorizationModelID: query.Get("authorization_model_id"),
+		})
+		if err != nil {
+			writeStatusError(w, err)
+			return
+		}
+
+		data, err := storefile.MarshalTuples(file.Tuples, format)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		w.Header().Set(contentTypeHeader, contentTypeOfTupleFormat(format))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(data)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// tupleFormatOfContentType returns the format of the tuple files of a content type, which defaults to YAML.
+func tupleFormatOfContentType(contentType string) storefile.TupleFormat {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.TrimSpace(strings.ToLower(mediaType)) {
+	case contentTypeCSV:
+		return storefile.TupleFormatCSV
+	case contentTypeNDJSON, "application/jsonl":
+		return storefile.TupleFormatNDJSON
+	default:
+		return storefile.TupleFormatYAML
+	}
+}
+
+func contentTypeOfTupleFormat(format storefile.TupleFormat) string {
+	switch format {
+	case storefile.TupleFormatCSV:
+		return contentTypeCSV
+	case storefile.TupleFormatNDJSON:
+		return contentTypeNDJSON
+	default:
+		return contentTypeYAMLHeader
+	}
+}
+
+func toMaintenanceStatus(s *maintenance.Status) *MaintenanceStatus {
+	if s == nil {
+		return nil
+	}
+
+	return &MaintenanceStatus{
+		Message:    s.Message,
+		RetryAfter: s.RetryAfter.String(),
+		Since:      s.Since,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set(contentTypeHeader, contentTypeJSONHeader)
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, errorResponse{Message: message})
+}
+
+// writeStatusError writes an error returned by the server with the HTTP status code the HTTP API would
+// have responded with.
+func writeStatusError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	encoded := serverErrors.NewEncodedError(serverErrors.ConvertToEncodedErrorCode(st), st.Message())
+	writeError(w, encoded.HTTPStatus(), st.Message())
+}
@@ -0,0 +1,833 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/karlseguin/ccache/v3"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/internal/authn"
+	"github.com/openfga/openfga/internal/authn/presharedkey"
+	"github.com/openfga/openfga/pkg/cachestats"
+	"github.com/openfga/openfga/pkg/middleware/maintenance"
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/server/commands"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/storefile"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestMaintenanceHandler(t *testing.T) {
+	mode := maintenance.NewMode()
+	handler := NewHandler(WithMaintenanceMode(mode))
+
+	do := func(t *testing.T, method, path, body string) (int, MaintenanceResponse) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		var resp MaintenanceResponse
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		}
+		return w.Code, resp
+	}
+
+	code, resp := do(t, http.MethodGet, "/admin/maintenance", "")
+	require.Equal(t, http.StatusOK, code)
+	require.Nil(t, resp.Global)
+	require.Empty(t, resp.Stores)
+
+	code, resp = do(t, http.MethodPut, "/admin/maintenance/stores/store1", `{"message":"migrating","retry_after":"2m"}`)
+	require.Equal(t, http.StatusOK, code)
+	require.Nil(t, resp.Global)
+	require.Equal(t, "migrating", resp.Stores["store1"].Message)
+	require.Equal(t, "2m0s", resp.Stores["store1"].RetryAfter)
+
+	s, ok := mode.Get("store1")
+	require.True(t, ok)
+	require.Equal(t, 2*time.Minute, s.RetryAfter)
+
+	code, resp = do(t, http.MethodPut, "/admin/maintenance", "")
+	require.Equal(t, http.StatusOK, code)
+	require.NotNil(t, resp.Global)
+
+	code, resp = do(t, http.MethodDelete, "/admin/maintenance", "")
+	require.Equal(t, http.StatusOK, code)
+	require.Nil(t, resp.Global)
+	require.Len(t, resp.Stores, 1)
+
+	code, resp = do(t, http.MethodDelete, "/admin/maintenance/stores/store1", "")
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, resp.Stores)
+
+	t.Run("invalid_requests", func(t *testing.T) {
+		code, _ := do(t, http.MethodPut, "/admin/maintenance", `{"retry_after":"soon"}`)
+		require.Equal(t, http.StatusBadRequest, code)
+
+		code, _ = do(t, http.MethodPut, "/admin/maintenance", `{`)
+		require.Equal(t, http.StatusBadRequest, code)
+
+		code, _ = do(t, http.MethodPost, "/admin/maintenance", "")
+		require.Equal(t, http.StatusMethodNotAllowed, code)
+
+		code, _ = do(t, http.MethodPut, "/admin/maintenance/stores/", "")
+		require.Equal(t, http.StatusNotFound, code)
+
+		_, ok := mode.Get("")
+		require.False(t, ok)
+	})
+}
+
+func TestShadowCheckHandler(t *testing.T) {
+	candidates := server.NewShadowCheckCandidates(nil)
+	handler := NewHandler(WithShadowCheckCandidates(candidates))
+
+	do := func(t *testing.T, method, path, body string) (int, ShadowCheckResponse) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		var resp ShadowCheckResponse
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		}
+		return w.Code, resp
+	}
+
+	modelID := ulid.Make().String()
+
+	code, resp := do(t, http.MethodPut, "/admin/shadow-check/stores/store1", `{"authorization_model_id":"`+modelID+`"}`)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, map[string]string{"store1": modelID}, resp.Candidates)
+
+	code, resp = do(t, http.MethodGet, "/admin/shadow-check", "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, map[string]string{"store1": modelID}, resp.Candidates)
+
+	code, resp = do(t, http.MethodDelete, "/admin/shadow-check/stores/store1", "")
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, resp.Candidates)
+
+	t.Run("invalid_requests", func(t *testing.T) {
+		code, _ := do(t, http.MethodPut, "/admin/shadow-check/stores/store1", `{"authorization_model_id":"invalid"}`)
+		require.Equal(t, http.StatusBadRequest, code)
+
+		code, _ = do(t, http.MethodPut, "/admin/shadow-check", `{"authorization_model_id":"`+modelID+`"}`)
+		require.Equal(t, http.StatusMethodNotAllowed, code)
+
+		code, _ = do(t, http.MethodGet, "/admin/maintenance", "")
+		require.Equal(t, http.StatusNotFound, code)
+
+		require.Empty(t, candidates.List())
+	})
+}
+
+func TestStoreFilesHandler(t *testing.T) {
+	ds := memory.New()
+	defer ds.Close()
+
+	s := server.MustNewServerWithOpts(server.WithDatastore(ds))
+	defer s.Close()
+
+	handler := NewHandler(WithStoreFiles(s))
+
+	do := func(t *testing.T, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(t, http.MethodPost, "/admin/stores/import", `
+name: documents
+model: |
+  model
+    schema 1.1
+  type user
+  type document
+    relations
+      define viewer: [user]
+tuples:
+  - user: user:anne
+    relation: viewer
+    object: document:1
+`)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var resp commands.ImportStoreResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, 1, resp.TuplesWritten)
+
+	w = do(t, http.MethodGet, "/admin/stores/"+resp.StoreID+"/export", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/yaml", w.Header().Get("Content-Type"))
+
+	file, err := storefile.Parse(w.Body.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, "documents", file.Name)
+	require.Equal(t, []*storefile.TupleKey{{User: "user:anne", Relation: "viewer", Object: "document:1"}}, file.Tuples)
+
+	t.Run("tuples", func(t *testing.T) {
+		w := do(t, http.MethodPost, "/admin/stores/"+resp.StoreID+"/tuples?format=csv", "user_type,user_id,relation,object_type,object_id\nuser,bob,viewer,document,2\n")
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var importResp commands.ImportTuplesResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &importResp))
+		require.Equal(t, 1, importResp.TuplesWritten)
+		require.Equal(t, resp.AuthorizationModelID, importResp.AuthorizationModelID)
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/stores/"+resp.StoreID+"/tuples", strings.NewReader(`{"user":"user:charlie","relation":"viewer","object":"document:3"}`))
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		w = do(t, http.MethodGet, "/admin/stores/"+resp.StoreID+"/tuples?format=ndjson", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+
+		tuples, err := storefile.ParseTuples(w.Body.Bytes(), storefile.TupleFormatNDJSON)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []*storefile.TupleKey{
+			{User: "user:anne", Relation: "viewer", Object: "document:1"},
+			{User: "user:bob", Relation: "viewer", Object: "document:2"},
+			{User: "user:charlie", Relation: "viewer", Object: "document:3"},
+		}, tuples)
+
+		w = do(t, http.MethodPost, "/admin/stores/"+resp.StoreID+"/tuples?format=csv", "user,relation,object\nuser:dan,viewer,document:4\nuser:erin,viewer\n")
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Contains(t, w.Body.String(), "line 3: expected 3 fields but got 2")
+
+		w = do(t, http.MethodPost, "/admin/stores/"+resp.StoreID+"/tuples?format=csv", "user,relation,object\nuser:dan,editor,document:4\n")
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = do(t, http.MethodGet, "/admin/stores/"+resp.StoreID+"/tuples?format=xml", "")
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid_requests", func(t *testing.T) {
+		w := do(t, http.MethodPost, "/admin/stores/import", "name: invalid\nmodel: 'type document relations define'\n")
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = do(t, http.MethodPost, "/admin/stores/import", "name: invalid\nmodel_file: ./model.fga\n")
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = do(t, http.MethodGet, "/admin/stores/"+ulid.Make().String()+"/export", "")
+		require.Equal(t, http.StatusNotFound, w.Code)
+
+		w = do(t, http.MethodGet, "/admin/stores/import", "")
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+		w = do(t, http.MethodGet, "/admin/stores/"+resp.StoreID, "")
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestAuthorizationModelsHandler(t *testing.T) {
+	ds := memory.New()
+	defer ds.Close()
+
+	s := server.MustNewServerWithOpts(server.WithDatastore(ds))
+	defer s.Close()
+
+	handler := NewHandler(WithAuthorizationModelLabels(s))
+
+	ctx := context.Background()
+	store := ulid.Make().String()
+
+	model, err := s.WriteAuthorizationModel(metadata.NewIncomingContext(ctx, metadata.Pairs(server.AuthorizationModelLabelsHeader, "release=42")), &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         store,
+		SchemaVersion:   typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`type user`),
+	})
+	require.NoError(t, err)
+
+	do := func(t *testing.T, method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	w := do(t, http.MethodGet, "/admin/authorization-models/stores/"+store+"?label=release=42")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp AuthorizationModelResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, map[string]string{"release": "42"}, resp.Labels)
+	require.Contains(t, string(resp.AuthorizationModel), model.GetAuthorizationModelId())
+
+	// the models that aren't found are bad requests, as in the HTTP API
+	w = do(t, http.MethodGet, "/admin/authorization-models/stores/"+store+"?label=release=43")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), "No authorization model labeled 'release=43' found")
+
+	w = do(t, http.MethodGet, "/admin/authorization-models/stores/"+store)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(t, http.MethodDelete, "/admin/authorization-models/stores/"+store+"?label=release=42")
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestRelationUsageHandler(t *testing.T) {
+	ds := memory.New()
+	defer ds.Close()
+
+	s := server.MustNewServerWithOpts(server.WithDatastore(ds), server.WithRelationUsageTracker(server.NewRelationUsageTracker()))
+	defer s.Close()
+
+	handler := NewHandler(WithRelationUsage(s))
+
+	ctx := context.Background()
+	store := ulid.Make().String()
+
+	_, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:       store,
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+		type document
+		  relations
+		    define viewer: [user] as self
+		`),
+	})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/relation-usage/stores/"+store, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp server.GetRelationUsageResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Relations, 1)
+	require.Equal(t, "viewer", resp.Relations[0].Relation)
+	require.Zero(t, resp.Relations[0].Checks)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/relation-usage/stores/"+store, nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestModelImpactHandler(t *testing.T) {
+	ds := memory.New()
+	defer ds.Close()
+
+	s := server.MustNewServerWithOpts(server.WithDatastore(ds))
+	defer s.Close()
+
+	handler := NewHandler(WithModelImpact(s))
+
+	ctx := context.Background()
+	store := ulid.Make().String()
+
+	err := ds.Write(ctx, store, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "editor", "user:anne"),
+	})
+	require.NoError(t, err)
+
+	do := func(t *testing.T, method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	model := `{"schema_version":"1.1","type_definitions":[{"type":"user"},{"type":"document","relations":{"viewer":{"this":{}}},"metadata":{"relations":{"viewer":{"directly_related_user_types":[{"type":"user"}]}}}}]}`
+
+	w := do(t, http.MethodPost, "/admin/model-impact/stores/"+store+"?max_tuples=10", model)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp commands.ModelImpactResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, 1, resp.TuplesScanned)
+	require.Len(t, resp.InvalidTuples, 1)
+	require.Equal(t, "editor", resp.InvalidTuples[0].TupleKey.GetRelation())
+
+	w = do(t, http.MethodPost, "/admin/model-impact/stores/"+store+"?sample_rate=often", model)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(t, http.MethodPost, "/admin/model-impact/stores/"+store, "{")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(t, http.MethodGet, "/admin/model-impact/stores/"+store, "")
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestModelValidationHandler(t *testing.T) {
+	ds := memory.New()
+	defer ds.Close()
+
+	s := server.MustNewServerWithOpts(server.WithDatastore(ds), server.WithWildcardsDisallowed(true))
+	defer s.Close()
+
+	handler := NewHandler(WithModelValidation(s))
+
+	ctx := context.Background()
+	store := ulid.Make().String()
+
+	do := func(t *testing.T, method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	model := `{"schema_version":"1.1","type_definitions":[{"type":"user"},{"type":"service"},{"type":"document","relations":{"viewer":{"this":{}}},"metadata":{"relations":{"viewer":{"directly_related_user_types":[{"type":"user"}]}}}}]}`
+
+	w := do(t, http.MethodPost, "/admin/validate-authorization-model/stores/"+store, model)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp commands.ValidateAuthorizationModelResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.True(t, resp.Valid)
+	require.Empty(t, resp.Errors)
+	require.Len(t, resp.Warnings, 1)
+	require.Equal(t, "service", resp.Warnings[0].ObjectType)
+
+	// the model isn't written
+	_, err := ds.FindLatestAuthorizationModelID(ctx, store)
+	require.Error(t, err)
+
+	// the restrictions of the server apply
+	wildcardModel := strings.Replace(model, `[{"type":"user"}]`, `[{"type":"user","wildcard":{}}]`, 1)
+	w = do(t, http.MethodPost, "/admin/validate-authorization-model/stores/"+store, wildcardModel)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	resp = commands.ValidateAuthorizationModelResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.False(t, resp.Valid)
+	require.Len(t, resp.Errors, 1)
+	require.Equal(t, "invalid_authorization_model", resp.Errors[0].Code)
+	require.Equal(t, "viewer", resp.Errors[0].Relation)
+
+	w = do(t, http.MethodPost, "/admin/validate-authorization-model/stores/"+store, `{"type_definitions":[]}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(t, http.MethodPost, "/admin/validate-authorization-model/stores/"+store, "{")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(t, http.MethodGet, "/admin/validate-authorization-model/stores/"+store, "")
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestModelDiffHandler(t *testing.T) {
+	ds := memory.New()
+	defer ds.Close()
+
+	s := server.MustNewServerWithOpts(server.WithDatastore(ds))
+	defer s.Close()
+
+	handler := NewHandler(WithModelDiff(s))
+
+	ctx := context.Background()
+	store := ulid.Make().String()
+
+	writeModel := func(t *testing.T, model string) string {
+		resp, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         store,
+			SchemaVersion:   typesystem.SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(model),
+		})
+		require.NoError(t, err)
+		return resp.GetAuthorizationModelId()
+	}
+
+	from := writeModel(t, `
+	type user
+	type document
+	  relations
+	    define editor: [user] as self
+	    define viewer: [user] as self
+	`)
+	writeModel(t, `
+	type user
+	type document
+	  relations
+	    define viewer: [user] as self
+	`)
+
+	err := ds.Write(ctx, store, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "editor", "user:anne"),
+	})
+	require.NoError(t, err)
+
+	do := func(t *testing.T, method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	w := do(t, http.MethodGet, "/admin/model-diff/stores/"+store+"?from="+from)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp commands.ModelDiffResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, from, resp.FromAuthorizationModelID)
+	require.True(t, resp.Breaking)
+	require.Len(t, resp.Changes, 1)
+	require.Equal(t, typesystem.RelationRemoved, resp.Changes[0].Kind)
+	require.Equal(t, "editor", resp.Changes[0].Relation)
+	require.Equal(t, 1, resp.Changes[0].InvalidatedTuples)
+
+	w = do(t, http.MethodGet, "/admin/model-diff/stores/"+store)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(t, http.MethodGet, "/admin/model-diff/stores/"+store+"?from="+from+"&max_tuples=many")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(t, http.MethodGet, "/admin/model-diff/stores/"+store+"?from="+ulid.Make().String())
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(t, http.MethodPost, "/admin/model-diff/stores/"+store+"?from="+from)
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestGraphQLHandler(t *testing.T) {
+	ds := memory.New()
+	defer ds.Close()
+
+	s := server.MustNewServerWithOpts(server.WithDatastore(ds))
+	defer s.Close()
+
+	t.Run("disabled", func(t *testing.T) {
+		handler := NewHandler()
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/graphql", strings.NewReader(`{"query": "{ stores { continuationToken } }"}`)))
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		handler := NewHandler(WithGraphQL(s))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/graphql", strings.NewReader(`{"query": "{ stores { stores { id } } }"}`)))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"data": {"stores": {"stores": []}}}`, w.Body.String())
+	})
+}
+
+func TestRelationRenamesHandler(t *testing.T) {
+	ds := memory.New()
+	defer ds.Close()
+
+	s := server.MustNewServerWithOpts(server.WithDatastore(ds))
+	defer s.Close()
+
+	handler := NewHandler(WithRelationRenames(s))
+
+	ctx := context.Background()
+	store := ulid.Make().String()
+
+	err := ds.WriteAuthorizationModel(ctx, store, &openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type document
+		  relations
+			define viewer: [user] as self
+			define reader: [user] as self or viewer
+		`),
+	})
+	require.NoError(t, err)
+
+	err = ds.Write(ctx, store, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+	})
+	require.NoError(t, err)
+
+	do := func(t *testing.T, method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	w := do(t, http.MethodPost, "/admin/relation-renames/stores/"+store, `{"renames":[{"object_type":"document","from":"viewer","to":"reader"}]}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp commands.RenameRelationsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Renames, 1)
+	require.Equal(t, 1, resp.Renames[0].Rewritten)
+
+	_, err = ds.ReadUserTuple(ctx, store, tuple.NewTupleKey("document:1", "reader", "user:anne"), storage.ReadOptions{})
+	require.NoError(t, err)
+
+	w = do(t, http.MethodPost, "/admin/relation-renames/stores/"+store, `{"renames":[{"object_type":"document","from":"viewer","to":"owner"}]}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(t, http.MethodPost, "/admin/relation-renames/stores/"+store, "{")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(t, http.MethodGet, "/admin/relation-renames/stores/"+store, "")
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestTupleSamplesHandler(t *testing.T) {
+	ds := memory.New()
+	defer ds.Close()
+
+	s := server.MustNewServerWithOpts(server.WithDatastore(ds))
+	defer s.Close()
+
+	handler := NewHandler(WithTupleSamples(s))
+
+	ctx := context.Background()
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "store"})
+	require.NoError(t, err)
+
+	err = ds.Write(ctx, store.GetId(), nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:2", "viewer", "user:bob"),
+		tuple.NewTupleKey("folder:1", "viewer", "user:anne"),
+	})
+	require.NoError(t, err)
+
+	do := func(t *testing.T, method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	w := do(t, http.MethodGet, "/admin/tuple-samples/stores/"+store.GetId()+"?object_type=document&relation=viewer&size=1")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp commands.SampleTuplesResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Zero(t, resp.TuplesScanned) // sampled by the datastore
+	require.Len(t, resp.Tuples, 1)
+	require.Equal(t, "document", tuple.GetType(resp.Tuples[0].GetObject()))
+
+	w = do(t, http.MethodGet, "/admin/tuple-samples/stores/"+store.GetId()+"?size=some")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(t, http.MethodGet, "/admin/tuple-samples/stores/"+store.GetId()+"?relation=viewer")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(t, http.MethodGet, "/admin/tuple-samples/stores/"+ulid.Make().String())
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	w = do(t, http.MethodPost, "/admin/tuple-samples/stores/"+store.GetId())
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	t.Run("restricted_to_the_operators", func(t *testing.T) {
+		authenticator, err := presharedkey.NewPresharedKeyAuthenticator([]string{"key"})
+		require.NoError(t, err)
+
+		handler := NewHandler(WithAuthenticator(authenticator), WithKeys("admin-key"), WithTupleSamples(s))
+
+		for key, expectedCode := range map[string]int{"key": http.StatusForbidden, "admin-key": http.StatusOK} {
+			req := httptest.NewRequest(http.MethodGet, "/admin/tuple-samples/stores/"+store.GetId(), nil)
+			req.Header.Set("Authorization", "Bearer "+key)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			require.Equal(t, expectedCode, w.Code)
+		}
+	})
+}
+
+func TestCachesHandler(t *testing.T) {
+	const ttl = time.Hour
+
+	cache := ccache.New(ccache.Configure[string]())
+	t.Cleanup(cache.Stop)
+	otherCache := ccache.New(ccache.Configure[string]())
+	t.Cleanup(otherCache.Stop)
+
+	counters := &cachestats.Counters{}
+	counters.Record(true)
+	counters.Record(true)
+	counters.Record(true)
+	counters.Record(false)
+
+	storePrefix := func(storeID string) string { return storeID + ":" }
+	handler := NewHandler(WithCaches(
+		cachestats.NewCCache("models", cache, counters, ttl, storePrefix),
+		cachestats.NewCCache("other", otherCache, nil, ttl, storePrefix),
+	))
+
+	cache.Set("store1:a", "a", ttl)
+	cache.Set("store1:b", "b", ttl)
+	cache.Set("store2:a", "a", ttl)
+	otherCache.Set("store1:a", "a", ttl)
+
+	do := func(t *testing.T, method, path string, resp interface{}) int {
+		req := httptest.NewRequest(method, path, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+		}
+		return w.Code
+	}
+
+	var stats CachesResponse
+	require.Equal(t, http.StatusOK, do(t, http.MethodGet, "/admin/caches", &stats))
+	require.Len(t, stats.Caches, 2)
+	require.Equal(t, "models", stats.Caches[0].Name)
+	require.Equal(t, 3, stats.Caches[0].Size)
+	require.Equal(t, uint64(3), stats.Caches[0].Hits)
+	require.Equal(t, uint64(1), stats.Caches[0].Misses)
+	require.InDelta(t, 0.75, stats.Caches[0].HitRate, 0.001)
+	require.NotNil(t, stats.Caches[0].OldestEntry)
+	require.WithinDuration(t, time.Now(), *stats.Caches[0].OldestEntry, time.Minute)
+	require.Zero(t, stats.Caches[1].HitRate)
+
+	var flushed FlushCachesResponse
+	require.Equal(t, http.StatusOK, do(t, http.MethodDelete, "/admin/caches/stores/store1?cache=models", &flushed))
+	require.Equal(t, map[string]int{"models": 2}, flushed.Flushed)
+	require.Equal(t, 1, cache.ItemCount())
+	require.Equal(t, 1, otherCache.ItemCount())
+
+	require.Equal(t, http.StatusOK, do(t, http.MethodDelete, "/admin/caches", &flushed))
+	require.Equal(t, map[string]int{"models": 1, "other": 1}, flushed.Flushed)
+
+	require.Equal(t, http.StatusOK, do(t, http.MethodGet, "/admin/caches", &stats))
+	require.Zero(t, stats.Caches[0].Size)
+	require.Nil(t, stats.Caches[0].OldestEntry)
+
+	require.Equal(t, http.StatusNotFound, do(t, http.MethodDelete, "/admin/caches?cache=unknown", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, do(t, http.MethodGet, "/admin/caches/stores/store1", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, do(t, http.MethodPost, "/admin/caches", nil))
+}
+
+func TestHandlerAuthentication(t *testing.T) {
+	authenticator, err := presharedkey.NewPresharedKeyAuthenticator([]string{"key"})
+	require.NoError(t, err)
+
+	handler := NewHandler(WithAuthenticator(authenticator), WithMaintenanceMode(maintenance.NewMode()))
+
+	for name, test := range map[string]struct {
+		authorization string
+		expectedCode  int
+	}{
+		"missing_key": {expectedCode: http.StatusUnauthorized},
+		"invalid_key": {authorization: "Bearer other", expectedCode: http.StatusUnauthorized},
+		"valid_key":   {authorization: "Bearer key", expectedCode: http.StatusOK},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/maintenance", nil)
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			require.Equal(t, test.expectedCode, w.Code)
+		})
+	}
+}
+
+// claimsAuthenticator authenticates the callers with the claims of the token they're using.
+type claimsAuthenticator map[string]map[string]interface{}
+
+func (c claimsAuthenticator) Authenticate(ctx context.Context) (*authn.AuthClaims, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, authorization := range md.Get("authorization") {
+		if claims, ok := c[strings.TrimPrefix(authorization, "Bearer ")]; ok {
+			return &authn.AuthClaims{Claims: claims}, nil
+		}
+	}
+
+	return nil, authn.ErrUnauthenticated
+}
+
+func (c claimsAuthenticator) Close() {}
+
+func TestHandlerAuthorization(t *testing.T) {
+	authenticator, err := presharedkey.NewPresharedKeyAuthenticator([]string{"key"})
+	require.NoError(t, err)
+
+	claims := claimsAuthenticator{
+		"user":     {"role": "reader"},
+		"operator": {"role": "admin"},
+		"scoped":   {"scope": "read admin"},
+		"listed":   {"roles": []interface{}{"reader", "admin"}},
+	}
+
+	for name, test := range map[string]struct {
+		opts          []HandlerOpt
+		authorization string
+		expectedCode  int
+	}{
+		"missing_key": {
+			opts:         []HandlerOpt{WithAuthenticator(authenticator), WithKeys("admin-key")},
+			expectedCode: http.StatusUnauthorized,
+		},
+		"invalid_key": {
+			opts:          []HandlerOpt{WithAuthenticator(authenticator), WithKeys("admin-key")},
+			authorization: "Bearer other",
+			expectedCode:  http.StatusUnauthorized,
+		},
+		"api_key": {
+			opts:          []HandlerOpt{WithAuthenticator(authenticator), WithKeys("admin-key")},
+			authorization: "Bearer key",
+			expectedCode:  http.StatusForbidden,
+		},
+		"admin_key": {
+			opts:          []HandlerOpt{WithAuthenticator(authenticator), WithKeys("admin-key")},
+			authorization: "Bearer admin-key",
+			expectedCode:  http.StatusOK,
+		},
+		"admin_key_without_api_authentication": {
+			opts:          []HandlerOpt{WithAuthenticator(authn.NoopAuthenticator{}), WithKeys("admin-key")},
+			authorization: "Bearer admin-key",
+			expectedCode:  http.StatusOK,
+		},
+		"unauthenticated_without_admin_key": {
+			opts:         []HandlerOpt{WithAuthenticator(authn.NoopAuthenticator{}), WithKeys("admin-key")},
+			expectedCode: http.StatusForbidden,
+		},
+		"missing_claim": {
+			opts:          []HandlerOpt{WithAuthenticator(claims), WithRequiredClaim("role", "admin")},
+			authorization: "Bearer user",
+			expectedCode:  http.StatusForbidden,
+		},
+		"required_claim": {
+			opts:          []HandlerOpt{WithAuthenticator(claims), WithRequiredClaim("role", "admin")},
+			authorization: "Bearer operator",
+			expectedCode:  http.StatusOK,
+		},
+		"required_scope": {
+			opts:          []HandlerOpt{WithAuthenticator(claims), WithRequiredClaim("scope", "admin")},
+			authorization: "Bearer scoped",
+			expectedCode:  http.StatusOK,
+		},
+		"required_claim_in_list": {
+			opts:          []HandlerOpt{WithAuthenticator(claims), WithRequiredClaim("roles", "admin")},
+			authorization: "Bearer listed",
+			expectedCode:  http.StatusOK,
+		},
+		"unauthenticated_with_required_claim": {
+			opts:          []HandlerOpt{WithAuthenticator(claims), WithRequiredClaim("role", "admin")},
+			authorization: "Bearer other",
+			expectedCode:  http.StatusUnauthorized,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			handler := NewHandler(append(test.opts, WithMaintenanceMode(maintenance.NewMode()))...)
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/maintenance", nil)
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			require.Equal(t, test.expectedCode, w.Code)
+		})
+	}
+}
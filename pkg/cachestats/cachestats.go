@@ -0,0 +1,131 @@
+// Package cachestats reports the statistics of the in-process caches of the server, and flushes them, e.g.
+// to rule caching in or out as the cause of stale results.
+package cachestats
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/karlseguin/ccache/v3"
+)
+
+// Stats are the statistics of a cache.
+type Stats struct {
+	// Size is the number of entries of the cache, including the expired ones not evicted yet.
+	Size int
+
+	// Hits and Misses count the lookups of the cache since the server started.
+	Hits   uint64
+	Misses uint64
+
+	// OldestEntry is when the oldest entry of the cache was cached, which is zero if the cache is empty.
+	OldestEntry time.Time
+}
+
+// HitRate returns the fraction of the lookups of the cache that hit it, or 0 if it wasn't looked up.
+func (s Stats) HitRate() float64 {
+	lookups := s.Hits + s.Misses
+	if lookups == 0 {
+		return 0
+	}
+
+	return float64(s.Hits) / float64(lookups)
+}
+
+// Cache is a cache whose statistics are reported, and which can be flushed.
+type Cache interface {
+	// Name identifies the cache, e.g. 'authorization_models'.
+	Name() string
+
+	// Stats returns the statistics of the cache.
+	Stats() Stats
+
+	// Flush deletes the entries of the store from the cache, or every entry if storeID is empty, and
+	// returns how many were deleted.
+	Flush(storeID string) int
+}
+
+// Counters count the hits and the misses of the lookups of a cache. The methods of a nil *Counters are
+// no-ops, so that the caches whose statistics aren't reported needn't count their lookups.
+type Counters struct {
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// Record counts a lookup of the cache, which hit it or missed it.
+func (c *Counters) Record(hit bool) {
+	if c == nil {
+		return
+	}
+
+	if hit {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+}
+
+// Load returns the number of hits and misses counted.
+func (c *Counters) Load() (hits, misses uint64) {
+	if c == nil {
+		return 0, 0
+	}
+
+	return c.hits.Load(), c.misses.Load()
+}
+
+// CCache reports the statistics of a ccache.Cache, and flushes it.
+type CCache[T any] struct {
+	name     string
+	cache    *ccache.Cache[T]
+	counters *Counters
+	ttl      time.Duration
+
+	// storeKeyPrefix returns the prefix of the keys of the entries of the store
+	storeKeyPrefix func(storeID string) string
+}
+
+var _ Cache = (*CCache[any])(nil)
+
+// NewCCache returns the Cache of the provided ccache.Cache, whose lookups are counted by the counters. The
+// keys of the entries of a store start with storeKeyPrefix(storeID). Since the cache doesn't hold when its
+// entries were cached, it's derived from when they expire and from ttl, the TTL of the entries.
+func NewCCache[T any](name string, cache *ccache.Cache[T], counters *Counters, ttl time.Duration, storeKeyPrefix func(storeID string) string) *CCache[T] {
+	return &CCache[T]{
+		name:           name,
+		cache:          cache,
+		counters:       counters,
+		ttl:            ttl,
+		storeKeyPrefix: storeKeyPrefix,
+	}
+}
+
+func (c *CCache[T]) Name() string {
+	return c.name
+}
+
+func (c *CCache[T]) Stats() Stats {
+	stats := Stats{Size: c.cache.ItemCount()}
+	stats.Hits, stats.Misses = c.counters.Load()
+
+	c.cache.ForEachFunc(func(_ string, item *ccache.Item[T]) bool {
+		cachedAt := item.Expires().Add(-c.ttl)
+		if stats.OldestEntry.IsZero() || cachedAt.Before(stats.OldestEntry) {
+			stats.OldestEntry = cachedAt
+		}
+		return true
+	})
+
+	return stats
+}
+
+func (c *CCache[T]) Flush(storeID string) int {
+	if storeID != "" {
+		return c.cache.DeletePrefix(c.storeKeyPrefix(storeID))
+	}
+
+	flushed := c.cache.ItemCount()
+	c.cache.Clear()
+
+	return flushed
+}
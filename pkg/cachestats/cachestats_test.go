@@ -0,0 +1,53 @@
+package cachestats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/karlseguin/ccache/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounters(t *testing.T) {
+	var nilCounters *Counters
+	nilCounters.Record(true)
+	hits, misses := nilCounters.Load()
+	require.Zero(t, hits)
+	require.Zero(t, misses)
+
+	counters := &Counters{}
+	counters.Record(true)
+	counters.Record(false)
+	counters.Record(false)
+	counters.Record(false)
+
+	hits, misses = counters.Load()
+	require.Equal(t, uint64(1), hits)
+	require.Equal(t, uint64(3), misses)
+	require.InDelta(t, 0.25, Stats{Hits: hits, Misses: misses}.HitRate(), 0.001)
+	require.Zero(t, Stats{}.HitRate())
+}
+
+func TestCCache(t *testing.T) {
+	const ttl = time.Hour
+
+	cache := ccache.New(ccache.Configure[int]())
+	t.Cleanup(cache.Stop)
+
+	c := NewCCache("numbers", cache, nil, ttl, func(storeID string) string { return storeID + "/" })
+	require.Equal(t, "numbers", c.Name())
+	require.True(t, c.Stats().OldestEntry.IsZero())
+
+	before := time.Now()
+	cache.Set("store1/1", 1, ttl)
+	cache.Set("store1/2", 2, 2*ttl)
+	cache.Set("store2/1", 1, ttl)
+
+	stats := c.Stats()
+	require.Equal(t, 3, stats.Size)
+	require.WithinDuration(t, before, stats.OldestEntry, time.Second)
+
+	require.Equal(t, 2, c.Flush("store1"))
+	require.Equal(t, 1, c.Flush(""))
+	require.Zero(t, cache.ItemCount())
+}
@@ -0,0 +1,269 @@
+// Package capture contains the capture and the replay of the production traffic: an interceptor records a
+// sample of the Check and ListObjects requests, along with the authorization model they were resolved with
+// and their results, and Replay re-executes the records against a server, e.g. one serving a snapshot of the
+// stores (see memory.NewFromSnapshot), to validate that a change of the resolution preserves the results and
+// the performance.
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/requestcontext"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	MethodCheck       = "Check"
+	MethodListObjects = "ListObjects"
+)
+
+// Record is a captured request.
+type Record struct {
+	Time     time.Time     `json:"time"`
+	Method   string        `json:"method"`
+	Duration time.Duration `json:"duration"`
+
+	// AuthorizationModelID is the ID of the authorization model the request was resolved with. It's set on
+	// the request too, so that the replay resolves it with the same model.
+	StoreID              string `json:"store_id"`
+	AuthorizationModelID string `json:"authorization_model_id"`
+
+	// Request and Response are the protojson encodings of the request and of its response, if it succeeded.
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response,omitempty"`
+
+	// Code is the gRPC code of the result of the request, e.g. 'OK'.
+	Code string `json:"code"`
+}
+
+// Sink receives the records of the captured requests.
+type Sink interface {
+	Write(ctx context.Context, record *Record) error
+}
+
+// FileSink appends every record it receives to a file, one JSON document per line.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+var _ Sink = (*FileSink)(nil)
+
+// NewFileSink constructs a Sink that appends every record to the file at the provided path, creating it if
+// needed.
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open capture file: %w", err)
+	}
+
+	return &FileSink{file: file}, nil
+}
+
+func (s *FileSink) Write(_ context.Context, record *Record) error {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.file.Write(append(b, '\n'))
+	return err
+}
+
+// Close closes the underlying file.
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// ReadRecords reads the records written by a FileSink.
+func ReadRecords(r io.Reader) ([]*Record, error) {
+	var records []*Record
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		record := &Record{}
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			return nil, fmt.Errorf("invalid record on line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+
+	return records, scanner.Err()
+}
+
+// Capturer samples the Check and ListObjects requests, and writes their records to a Sink.
+type Capturer struct {
+	sink       Sink
+	sampleRate float64
+	redactor   Redactor
+	logger     logger.Logger
+	random     func() float64
+	now        func() time.Time
+}
+
+// CapturerOpt defines an option that can be used to change the behavior of a Capturer.
+type CapturerOpt func(*Capturer)
+
+// WithSampleRate sets the fraction (between 0 and 1) of the requests that are captured.
+func WithSampleRate(rate float64) CapturerOpt {
+	return func(c *Capturer) {
+		c.sampleRate = rate
+	}
+}
+
+// WithRedactor redacts the IDs of the objects and of the users of the captured requests with the Redactor.
+func WithRedactor(redactor Redactor) CapturerOpt {
+	return func(c *Capturer) {
+		c.redactor = redactor
+	}
+}
+
+// WithLogger sets the logger of the failures to write the records.
+func WithLogger(logger logger.Logger) CapturerOpt {
+	return func(c *Capturer) {
+		c.logger = logger
+	}
+}
+
+// NewCapturer constructs a Capturer that writes the records to the Sink. By default, every request is
+// captured and nothing is redacted.
+func NewCapturer(sink Sink, opts ...CapturerOpt) *Capturer {
+	c := &Capturer{
+		sink:       sink,
+		sampleRate: 1,
+		logger:     logger.NewNoopLogger(),
+		random:     rand.Float64,
+		now:        time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// NewUnaryInterceptor creates a grpc.UnaryServerInterceptor that captures a sample of the Check and
+// ListObjects requests. It must come after the requestid interceptor, since it reads the authorization model
+// the request was resolved with from the requestcontext metadata, and after the interceptors that change the
+// requests (e.g. the enrichment ones), so that the replay executes them as they were served.
+func NewUnaryInterceptor(c *Capturer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		method := ""
+		switch req.(type) {
+		case *openfgav1.CheckRequest:
+			method = MethodCheck
+		case *openfgav1.ListObjectsRequest:
+			method = MethodListObjects
+		}
+
+		if method == "" || c.sampleRate <= 0 || c.random() >= c.sampleRate {
+			return handler(ctx, req)
+		}
+
+		// the handlers may change the request, so it's cloned before they run
+		captured := proto.Clone(req.(proto.Message))
+
+		start := c.now()
+		resp, err := handler(ctx, req)
+		duration := c.now().Sub(start)
+
+		record, recordErr := c.record(ctx, method, captured, resp, err)
+		if recordErr == nil {
+			record.Time = start
+			record.Duration = duration
+			recordErr = c.sink.Write(ctx, record)
+		}
+		if recordErr != nil {
+			c.logger.WarnWithContext(ctx, "failed to capture the request", zap.Error(recordErr))
+		}
+
+		return resp, err
+	}
+}
+
+func (c *Capturer) record(ctx context.Context, method string, req proto.Message, resp interface{}, err error) (*Record, error) {
+	record := &Record{
+		Method: method,
+		Code:   status.Code(err).String(),
+	}
+
+	modelID, _ := requestcontext.AuthorizationModelID(ctx)
+
+	switch req := req.(type) {
+	case *openfgav1.CheckRequest:
+		record.StoreID = req.GetStoreId()
+		if req.GetAuthorizationModelId() == "" {
+			req.AuthorizationModelId = modelID
+		}
+		record.AuthorizationModelID = req.GetAuthorizationModelId()
+		c.redactor.redactCheckRequest(req)
+	case *openfgav1.ListObjectsRequest:
+		record.StoreID = req.GetStoreId()
+		if req.GetAuthorizationModelId() == "" {
+			req.AuthorizationModelId = modelID
+		}
+		record.AuthorizationModelID = req.GetAuthorizationModelId()
+		c.redactor.redactListObjectsRequest(req)
+	}
+
+	var marshalErr error
+	record.Request, marshalErr = marshal(req)
+	if marshalErr != nil {
+		return nil, marshalErr
+	}
+
+	if err != nil {
+		return record, nil
+	}
+
+	switch resp := resp.(type) {
+	case *openfgav1.CheckResponse:
+		record.Response, marshalErr = marshal(resp)
+	case *openfgav1.ListObjectsResponse:
+		resp = proto.Clone(resp).(*openfgav1.ListObjectsResponse)
+		c.redactor.redactListObjectsResponse(resp)
+		record.Response, marshalErr = marshal(resp)
+	}
+
+	return record, marshalErr
+}
+
+// marshal returns the compact protojson encoding of the message.
+func marshal(m proto.Message) (json.RawMessage, error) {
+	encoded, err := protojson.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	// protojson randomizes its whitespace, which compacting removes
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, encoded); err != nil {
+		return nil, err
+	}
+
+	return compacted.Bytes(), nil
+}
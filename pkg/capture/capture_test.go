@@ -0,0 +1,118 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/requestcontext"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryInterceptor(t *testing.T) {
+	req := &openfgav1.CheckRequest{
+		StoreId:  "01HCSBNPGRMSRYJRJRZ0KCZP1C",
+		TupleKey: tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+	}
+
+	t.Run("samples", func(t *testing.T) {
+		sink := &recordingSink{}
+		c := NewCapturer(sink, WithSampleRate(0.5))
+
+		for _, random := range []float64{0.2, 0.7} {
+			c.random = func() float64 { return random }
+			_, err := NewUnaryInterceptor(c)(context.Background(), req, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return &openfgav1.CheckResponse{Allowed: true}, nil
+			})
+			require.NoError(t, err)
+		}
+
+		require.Len(t, sink.records, 1)
+		require.JSONEq(t, `{"allowed":true}`, string(sink.records[0].Response))
+	})
+
+	t.Run("records_the_resolved_model_and_the_errors", func(t *testing.T) {
+		sink := &recordingSink{}
+		ctx := requestcontext.NewContext(context.Background())
+
+		_, err := NewUnaryInterceptor(NewCapturer(sink))(ctx, req, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			requestcontext.SetAuthorizationModelID(ctx, "01HCSBNWV4YGBQW0Y8HP1Q4NKJ")
+			return nil, status.Error(codes.InvalidArgument, "invalid")
+		})
+		require.Error(t, err)
+
+		require.Len(t, sink.records, 1)
+		record := sink.records[0]
+		require.Equal(t, MethodCheck, record.Method)
+		require.Equal(t, "01HCSBNWV4YGBQW0Y8HP1Q4NKJ", record.AuthorizationModelID)
+		require.Contains(t, string(record.Request), "01HCSBNWV4YGBQW0Y8HP1Q4NKJ")
+		require.Contains(t, string(record.Request), "user:anne")
+		require.Equal(t, codes.InvalidArgument.String(), record.Code)
+		require.Empty(t, record.Response)
+
+		// the request served isn't changed
+		require.Empty(t, req.GetAuthorizationModelId())
+	})
+
+	t.Run("sink_failures_are_ignored", func(t *testing.T) {
+		_, err := NewUnaryInterceptor(NewCapturer(failingSink{}))(context.Background(), req, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return &openfgav1.CheckResponse{}, nil
+		})
+		require.NoError(t, err)
+	})
+}
+
+type failingSink struct{}
+
+func (failingSink) Write(context.Context, *Record) error {
+	return errors.New("disk full")
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "captures.jsonl")
+
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Write(context.Background(), &Record{Method: MethodCheck, Request: []byte(`{}`), Code: "OK"}))
+	require.NoError(t, sink.Write(context.Background(), &Record{Method: MethodListObjects, Request: []byte(`{}`), Code: "OK"}))
+	require.NoError(t, sink.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	records, err := ReadRecords(file)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, MethodListObjects, records[1].Method)
+}
+
+func TestHMACRedactor(t *testing.T) {
+	r := NewHMACRedactor("key")
+	require.Equal(t, r("anne"), NewHMACRedactor("key")("anne"))
+	require.NotEqual(t, r("anne"), NewHMACRedactor("other key")("anne"))
+
+	for _, tc := range []struct {
+		user     string
+		expected string
+	}{
+		{user: "user:anne", expected: "user:" + r("anne")},
+		{user: "group:eng#member", expected: "group:" + r("eng") + "#member"},
+		{user: "user:*", expected: "user:*"},
+		{user: "*", expected: "*"},
+		{user: "anne", expected: r("anne")},
+	} {
+		require.Equal(t, tc.expected, r.redactUser(tc.user), tc.user)
+	}
+
+	var none Redactor
+	require.Equal(t, "user:anne", none.redactUser("user:anne"))
+}
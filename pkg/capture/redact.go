@@ -0,0 +1,148 @@
+package capture
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// redactedIDLength is the length, in bytes, of the hashes the IDs are redacted into.
+const redactedIDLength = 12
+
+// Redactor redacts an ID of an object or of a user, e.g. 'anne' in 'user:anne'. The types, the relations and
+// the wildcards are never redacted, so that the redacted requests can still be resolved. A Redactor must be
+// deterministic, so that the redacted requests can be replayed against a snapshot redacted with the same
+// Redactor (see RedactSnapshot).
+type Redactor func(id string) string
+
+// NewHMACRedactor returns a Redactor that replaces the IDs with their HMAC-SHA256 keyed with the provided key,
+// truncated and hex encoded. The key prevents the IDs from being recovered from their hash.
+func NewHMACRedactor(key string) Redactor {
+	return func(id string) string {
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(id))
+		return hex.EncodeToString(mac.Sum(nil)[:redactedIDLength])
+	}
+}
+
+// redactObject redacts the ID of the object, e.g. 'document:roadmap'.
+func (r Redactor) redactObject(object string) string {
+	if r == nil || object == "" {
+		return object
+	}
+
+	objectType, objectID := tuple.SplitObject(object)
+	if objectID == "" || objectID == tuple.Wildcard {
+		return object
+	}
+
+	if objectType == "" {
+		return r(objectID)
+	}
+
+	return tuple.BuildObject(objectType, r(objectID))
+}
+
+// redactUser redacts the ID of the user, e.g. 'user:anne' or 'group:eng#member'.
+func (r Redactor) redactUser(user string) string {
+	if r == nil || user == "" {
+		return user
+	}
+
+	object, relation := tuple.SplitObjectRelation(user)
+	if relation == "" {
+		return r.redactObject(object)
+	}
+
+	return tuple.ToObjectRelationString(r.redactObject(object), relation)
+}
+
+func (r Redactor) redactTupleKey(tk *openfgav1.TupleKey) {
+	if tk == nil {
+		return
+	}
+
+	tk.Object = r.redactObject(tk.GetObject())
+	tk.User = r.redactUser(tk.GetUser())
+}
+
+func (r Redactor) redactCheckRequest(req *openfgav1.CheckRequest) {
+	if r == nil {
+		return
+	}
+
+	r.redactTupleKey(req.GetTupleKey())
+	for _, tk := range req.GetContextualTuples().GetTupleKeys() {
+		r.redactTupleKey(tk)
+	}
+}
+
+func (r Redactor) redactListObjectsRequest(req *openfgav1.ListObjectsRequest) {
+	if r == nil {
+		return
+	}
+
+	req.User = r.redactUser(req.GetUser())
+	for _, tk := range req.GetContextualTuples().GetTupleKeys() {
+		r.redactTupleKey(tk)
+	}
+}
+
+func (r Redactor) redactListObjectsResponse(resp *openfgav1.ListObjectsResponse) {
+	if r == nil {
+		return
+	}
+
+	for i, object := range resp.GetObjects() {
+		resp.Objects[i] = r.redactObject(object)
+	}
+}
+
+// RedactSnapshot returns a copy of the snapshot whose tuples, changes and assertions are redacted with the
+// Redactor, to replay the requests captured with the same Redactor against it.
+func RedactSnapshot(snapshot *memory.Snapshot, r Redactor) *memory.Snapshot {
+	redacted := &memory.Snapshot{Stores: make([]memory.StoreSnapshot, 0, len(snapshot.Stores))}
+	for _, store := range snapshot.Stores {
+		tuples := make([]memory.TupleSnapshot, 0, len(store.Tuples))
+		for _, t := range store.Tuples {
+			t.Object = r.redactObject(t.Object)
+			t.User = r.redactUser(t.User)
+			tuples = append(tuples, t)
+		}
+		store.Tuples = tuples
+
+		changes := make([]memory.ChangeSnapshot, 0, len(store.Changes))
+		for _, c := range store.Changes {
+			c.Object = r.redactObject(c.Object)
+			c.User = r.redactUser(c.User)
+			changes = append(changes, c)
+		}
+		store.Changes = changes
+
+		assertions := make([]memory.AssertionsSnapshot, 0, len(store.Assertions))
+		for _, a := range store.Assertions {
+			versions := make([]memory.AssertionsVersionSnapshot, 0, len(a.Versions))
+			for _, version := range a.Versions {
+				redactedAssertions := make([]memory.AssertionSnapshot, 0, len(version.Assertions))
+				for _, assertion := range version.Assertions {
+					assertion.Object = r.redactObject(assertion.Object)
+					assertion.User = r.redactUser(assertion.User)
+					redactedAssertions = append(redactedAssertions, assertion)
+				}
+				version.Assertions = redactedAssertions
+				versions = append(versions, version)
+			}
+			a.Versions = versions
+			assertions = append(assertions, a)
+		}
+		store.Assertions = assertions
+
+		redacted.Stores = append(redacted.Stores, store)
+	}
+
+	return redacted
+}
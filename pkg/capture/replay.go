@@ -0,0 +1,166 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Mismatch is a replayed request whose result differs from the recorded one.
+type Mismatch struct {
+	Record *Record `json:"record"`
+
+	// Code and Response are the result of the replay.
+	Code     string `json:"code"`
+	Response string `json:"response,omitempty"`
+}
+
+// Report is the report of a replay.
+type Report struct {
+	Total   int `json:"total"`
+	Matched int `json:"matched"`
+
+	Mismatches []*Mismatch `json:"mismatches,omitempty"`
+
+	// RecordedDuration and ReplayedDuration are the total durations of the requests when they were recorded
+	// and when they were replayed.
+	RecordedDuration time.Duration `json:"recorded_duration"`
+	ReplayedDuration time.Duration `json:"replayed_duration"`
+}
+
+// Replay re-executes the requests of the records against the server, one after the other, and reports the
+// requests whose result differs from the recorded one, i.e. with another gRPC code, another Check result or
+// another set of ListObjects objects.
+func Replay(ctx context.Context, srv openfgav1.OpenFGAServiceServer, records []*Record) (*Report, error) {
+	report := &Report{}
+
+	for i, record := range records {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		start := time.Now()
+		resp, replayErr := replay(ctx, srv, record)
+		duration := time.Since(start)
+		if resp == nil && replayErr == nil {
+			return nil, fmt.Errorf("invalid record %d: unknown method '%s'", i, record.Method)
+		}
+
+		if _, ok := status.FromError(replayErr); !ok {
+			return nil, fmt.Errorf("invalid record %d: %w", i, replayErr)
+		}
+
+		report.Total++
+		report.RecordedDuration += record.Duration
+		report.ReplayedDuration += duration
+
+		matched, err := matches(record, resp, replayErr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid record %d: %w", i, err)
+		}
+
+		if matched {
+			report.Matched++
+			continue
+		}
+
+		mismatch := &Mismatch{Record: record, Code: status.Code(replayErr).String()}
+		if resp != nil {
+			encoded, err := marshal(resp)
+			if err != nil {
+				return nil, err
+			}
+			mismatch.Response = string(encoded)
+		}
+		report.Mismatches = append(report.Mismatches, mismatch)
+	}
+
+	return report, nil
+}
+
+// replay executes the request of the record. It returns a nil response and a nil error if the method of the
+// record is unknown, and an error which isn't a gRPC status if the request can't be decoded.
+func replay(ctx context.Context, srv openfgav1.OpenFGAServiceServer, record *Record) (proto.Message, error) {
+	switch record.Method {
+	case MethodCheck:
+		req := &openfgav1.CheckRequest{}
+		if err := protojson.Unmarshal(record.Request, req); err != nil {
+			return nil, err
+		}
+
+		resp, err := srv.Check(ctx, req)
+		if err != nil {
+			return nil, status.Convert(err).Err()
+		}
+		return resp, nil
+	case MethodListObjects:
+		req := &openfgav1.ListObjectsRequest{}
+		if err := protojson.Unmarshal(record.Request, req); err != nil {
+			return nil, err
+		}
+
+		resp, err := srv.ListObjects(ctx, req)
+		if err != nil {
+			return nil, status.Convert(err).Err()
+		}
+		return resp, nil
+	default:
+		return nil, nil
+	}
+}
+
+// matches reports whether the result of the replay matches the recorded one.
+func matches(record *Record, resp proto.Message, err error) (bool, error) {
+	if status.Code(err).String() != record.Code {
+		return false, nil
+	}
+
+	if err != nil {
+		return true, nil
+	}
+
+	switch resp := resp.(type) {
+	case *openfgav1.CheckResponse:
+		recorded := &openfgav1.CheckResponse{}
+		if err := protojson.Unmarshal(record.Response, recorded); err != nil {
+			return false, err
+		}
+
+		return recorded.GetAllowed() == resp.GetAllowed(), nil
+	case *openfgav1.ListObjectsResponse:
+		recorded := &openfgav1.ListObjectsResponse{}
+		if err := protojson.Unmarshal(record.Response, recorded); err != nil {
+			return false, err
+		}
+
+		return sameObjects(recorded.GetObjects(), resp.GetObjects()), nil
+	default:
+		return false, nil
+	}
+}
+
+// sameObjects reports whether the two lists of objects hold the same objects, in any order.
+func sameObjects(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,159 @@
+package capture
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/requestcontext"
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+type recordingSink struct {
+	mu      sync.Mutex
+	records []*Record
+}
+
+func (s *recordingSink) Write(_ context.Context, record *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = append(s.records, record)
+	return nil
+}
+
+func TestCaptureAndReplay(t *testing.T) {
+	ctx := context.Background()
+
+	ds := memory.New()
+	defer ds.Close()
+
+	s := server.MustNewServerWithOpts(server.WithDatastore(ds))
+	defer s.Close()
+
+	storeID := ulid.Make().String()
+	_, err := ds.CreateStore(ctx, &openfgav1.Store{Id: storeID, Name: "docs"})
+	require.NoError(t, err)
+
+	model, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:       storeID,
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type document
+		  relations
+		    define viewer: [user] as self
+		`),
+	})
+	require.NoError(t, err)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+		}},
+	})
+	require.NoError(t, err)
+
+	redactor := NewHMACRedactor("key")
+	sink := &recordingSink{}
+	interceptor := NewUnaryInterceptor(NewCapturer(sink, WithRedactor(redactor)))
+
+	serve := func(req interface{}) {
+		ctx := requestcontext.NewContext(context.Background())
+		_, err := interceptor(ctx, req, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			switch req := req.(type) {
+			case *openfgav1.CheckRequest:
+				return s.Check(ctx, req)
+			case *openfgav1.ListObjectsRequest:
+				return s.ListObjects(ctx, req)
+			default:
+				return s.ReadAuthorizationModels(ctx, req.(*openfgav1.ReadAuthorizationModelsRequest))
+			}
+		})
+		require.NoError(t, err)
+	}
+
+	serve(&openfgav1.CheckRequest{StoreId: storeID, TupleKey: tuple.NewTupleKey("document:roadmap", "viewer", "user:anne")})
+	serve(&openfgav1.CheckRequest{StoreId: storeID, TupleKey: tuple.NewTupleKey("document:roadmap", "viewer", "user:bob")})
+	serve(&openfgav1.ListObjectsRequest{StoreId: storeID, Type: "document", Relation: "viewer", User: "user:anne"})
+	serve(&openfgav1.ReadAuthorizationModelsRequest{StoreId: storeID})
+
+	// the other methods aren't captured
+	require.Len(t, sink.records, 3)
+
+	for _, record := range sink.records {
+		require.Equal(t, storeID, record.StoreID)
+		require.Equal(t, model.GetAuthorizationModelId(), record.AuthorizationModelID)
+		require.Contains(t, string(record.Request), model.GetAuthorizationModelId())
+		require.Equal(t, "OK", record.Code)
+
+		// the IDs are redacted, but not the types and the relations
+		require.NotContains(t, string(record.Request)+string(record.Response), "anne")
+		require.NotContains(t, string(record.Request)+string(record.Response), "roadmap")
+		require.Contains(t, string(record.Request), "document")
+	}
+	require.Contains(t, string(sink.records[2].Response), "document:"+redactor("roadmap"))
+
+	snapshot, err := ds.(*memory.MemoryBackend).Snapshot()
+	require.NoError(t, err)
+
+	replayDatastore, err := memory.NewFromSnapshot(RedactSnapshot(snapshot, redactor))
+	require.NoError(t, err)
+
+	replayServer := server.MustNewServerWithOpts(server.WithDatastore(replayDatastore))
+	defer replayServer.Close()
+
+	t.Run("parity", func(t *testing.T) {
+		report, err := Replay(ctx, replayServer, sink.records)
+		require.NoError(t, err)
+		require.Equal(t, 3, report.Total)
+		require.Equal(t, 3, report.Matched)
+		require.Empty(t, report.Mismatches)
+		require.Positive(t, report.ReplayedDuration)
+	})
+
+	t.Run("mismatches", func(t *testing.T) {
+		err := replayDatastore.Write(ctx, storeID, []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:"+redactor("roadmap"), "viewer", "user:"+redactor("anne")),
+		}, nil)
+		require.NoError(t, err)
+
+		report, err := Replay(ctx, replayServer, sink.records)
+		require.NoError(t, err)
+		require.Equal(t, 3, report.Total)
+		require.Equal(t, 1, report.Matched)
+		require.Len(t, report.Mismatches, 2)
+		require.Equal(t, MethodCheck, report.Mismatches[0].Record.Method)
+		require.Equal(t, MethodListObjects, report.Mismatches[1].Record.Method)
+	})
+
+	t.Run("invalid_record", func(t *testing.T) {
+		_, err := Replay(ctx, replayServer, []*Record{{Method: "Expand"}})
+		require.ErrorContains(t, err, "unknown method 'Expand'")
+	})
+}
+
+func TestReadRecords(t *testing.T) {
+	records, err := ReadRecords(strings.NewReader(`{"method":"Check","store_id":"store","request":{"store_id":"store"},"code":"OK"}
+
+{"method":"ListObjects","store_id":"store","request":{"store_id":"store"},"code":"NotFound"}
+`))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, MethodListObjects, records[1].Method)
+	require.Equal(t, "NotFound", records[1].Code)
+
+	_, err = ReadRecords(strings.NewReader("{\n"))
+	require.ErrorContains(t, err, "line 1")
+}
@@ -0,0 +1,273 @@
+// Package condition contains the conditions of the authorization models: named CEL expressions that the
+// tuples of the relations with a conditioned type restriction can carry, and that Check evaluates against the
+// context of the tuples and of the requests.
+//
+// For example, the following condition grants the tuples that carry it only during business hours, given the
+// 'current_time' of the request:
+//
+//	{
+//	  "name": "business_hours",
+//	  "expression": "current_time.getHours('Europe/Paris') >= 9 && current_time.getHours('Europe/Paris') < 18",
+//	  "parameters": {"current_time": "timestamp"}
+//	}
+package condition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/cel-go/cel"
+)
+
+// ParameterType is the type of a parameter of a condition.
+type ParameterType string
+
+const (
+	ParameterTypeString    ParameterType = "string"
+	ParameterTypeInt       ParameterType = "int"
+	ParameterTypeUint      ParameterType = "uint"
+	ParameterTypeDouble    ParameterType = "double"
+	ParameterTypeBool      ParameterType = "bool"
+	ParameterTypeTimestamp ParameterType = "timestamp"
+	ParameterTypeDuration  ParameterType = "duration"
+	ParameterTypeList      ParameterType = "list"
+	ParameterTypeMap       ParameterType = "map"
+)
+
+var celTypes = map[ParameterType]*cel.Type{
+	ParameterTypeString:    cel.StringType,
+	ParameterTypeInt:       cel.IntType,
+	ParameterTypeUint:      cel.UintType,
+	ParameterTypeDouble:    cel.DoubleType,
+	ParameterTypeBool:      cel.BoolType,
+	ParameterTypeTimestamp: cel.TimestampType,
+	ParameterTypeDuration:  cel.DurationType,
+	ParameterTypeList:      cel.ListType(cel.DynType),
+	ParameterTypeMap:       cel.MapType(cel.StringType, cel.DynType),
+}
+
+// ErrMissingParameters is returned by the evaluation of a condition when some of its parameters aren't bound
+// by any context.
+var ErrMissingParameters = errors.New("missing condition parameters")
+
+// Condition is a named CEL expression of an authorization model, which evaluates to a bool given the values of
+// its parameters.
+type Condition struct {
+	Name       string                   `json:"name"`
+	Expression string                   `json:"expression"`
+	Parameters map[string]ParameterType `json:"parameters,omitempty"`
+}
+
+// TypeRestriction allows the tuples of the Relation of the Type whose user has the UserType to carry the
+// Condition. The UserType is one of the directly related user types of the relation, e.g. 'user', 'user:*' or
+// 'group#member', and the tuples with this user type can still be written without a condition.
+type TypeRestriction struct {
+	Type      string `json:"type"`
+	Relation  string `json:"relation"`
+	UserType  string `json:"user_type"`
+	Condition string `json:"condition"`
+}
+
+// ModelConditions holds the conditions of an authorization model, and the type restrictions that use them.
+type ModelConditions struct {
+	Conditions       []*Condition       `json:"conditions"`
+	TypeRestrictions []*TypeRestriction `json:"type_restrictions"`
+}
+
+// IsEmpty reports whether the model has neither conditions nor conditioned type restrictions.
+func (m *ModelConditions) IsEmpty() bool {
+	return m == nil || (len(m.Conditions) == 0 && len(m.TypeRestrictions) == 0)
+}
+
+// TupleCondition is the condition a tuple carries: the name of a condition of the model, and the values of some
+// of its parameters, which take precedence over the context of the requests.
+type TupleCondition struct {
+	Name    string                 `json:"name"`
+	Context map[string]interface{} `json:"context,omitempty"`
+}
+
+// EvaluationError is returned when a condition can't be evaluated, e.g. because some of its parameters are
+// missing (see ErrMissingParameters).
+type EvaluationError struct {
+	Condition string
+	Err       error
+}
+
+func (e *EvaluationError) Error() string {
+	return fmt.Sprintf("failed to evaluate the condition '%s': %v", e.Condition, e.Err)
+}
+
+func (e *EvaluationError) Unwrap() error {
+	return e.Err
+}
+
+// EvaluableCondition is a Condition compiled into a CEL program. It's compiled the first time it's needed.
+type EvaluableCondition struct {
+	*Condition
+
+	once    sync.Once
+	program cel.Program
+	err     error
+}
+
+// NewEvaluableCondition returns the EvaluableCondition of the condition.
+func NewEvaluableCondition(condition *Condition) *EvaluableCondition {
+	return &EvaluableCondition{Condition: condition}
+}
+
+// Compile compiles the condition, and returns an error if its parameters or its expression are invalid, or if
+// its expression doesn't evaluate to a bool.
+func (e *EvaluableCondition) Compile() error {
+	e.once.Do(func() {
+		e.program, e.err = e.compile()
+	})
+
+	return e.err
+}
+
+func (e *EvaluableCondition) compile() (cel.Program, error) {
+	if e.Name == "" {
+		return nil, errors.New("the name of a condition must be provided")
+	}
+
+	var opts []cel.EnvOption
+	for name, paramType := range e.Parameters {
+		celType, ok := celTypes[paramType]
+		if !ok {
+			return nil, fmt.Errorf("invalid condition '%s': the parameter '%s' has the unknown type '%s'", e.Name, name, paramType)
+		}
+
+		opts = append(opts, cel.Variable(name, celType))
+	}
+
+	env, err := cel.NewEnv(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition '%s': %w", e.Name, err)
+	}
+
+	ast, issues := env.Compile(e.Expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("invalid condition '%s': %w", e.Name, issues.Err())
+	}
+
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return nil, fmt.Errorf("invalid condition '%s': it must evaluate to a bool", e.Name)
+	}
+
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition '%s': %w", e.Name, err)
+	}
+
+	return program, nil
+}
+
+// Evaluate evaluates the condition with the values of its parameters in the contexts, which take precedence over
+// the ones of the contexts that follow them. The values decoded from JSON are converted to the types of the
+// parameters, e.g. the timestamps and the durations are parsed from strings. It returns an EvaluationError if
+// the condition can't be evaluated, e.g. if some of its parameters aren't bound by any context.
+func (e *EvaluableCondition) Evaluate(ctx context.Context, contexts ...map[string]interface{}) (bool, error) {
+	if err := e.Compile(); err != nil {
+		return false, &EvaluationError{Condition: e.Name, Err: err}
+	}
+
+	vars := make(map[string]interface{}, len(e.Parameters))
+	var missing []string
+	for name, paramType := range e.Parameters {
+		value, ok := lookup(name, contexts)
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+
+		converted, err := convert(value, paramType)
+		if err != nil {
+			return false, &EvaluationError{Condition: e.Name, Err: fmt.Errorf("the parameter '%s': %w", name, err)}
+		}
+		vars[name] = converted
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return false, &EvaluationError{Condition: e.Name, Err: fmt.Errorf("%w: %v", ErrMissingParameters, missing)}
+	}
+
+	out, _, err := e.program.ContextEval(ctx, vars)
+	if err != nil {
+		return false, &EvaluationError{Condition: e.Name, Err: err}
+	}
+
+	met, ok := out.Value().(bool)
+	if !ok {
+		return false, &EvaluationError{Condition: e.Name, Err: errors.New("it must evaluate to a bool")}
+	}
+
+	return met, nil
+}
+
+// lookup returns the value of the parameter in the first context that binds it.
+func lookup(name string, contexts []map[string]interface{}) (interface{}, bool) {
+	for _, c := range contexts {
+		if value, ok := c[name]; ok {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
+
+// convert converts a value, possibly decoded from JSON, to the Go type CEL expects for the parameter type.
+func convert(value interface{}, paramType ParameterType) (interface{}, error) {
+	switch paramType {
+	case ParameterTypeInt:
+		switch v := value.(type) {
+		case float64:
+			if v != math.Trunc(v) {
+				return nil, fmt.Errorf("%v isn't an int", v)
+			}
+			return int64(v), nil
+		case int:
+			return int64(v), nil
+		}
+	case ParameterTypeUint:
+		switch v := value.(type) {
+		case float64:
+			if v < 0 || v != math.Trunc(v) {
+				return nil, fmt.Errorf("%v isn't a uint", v)
+			}
+			return uint64(v), nil
+		case int:
+			if v < 0 {
+				return nil, fmt.Errorf("%v isn't a uint", v)
+			}
+			return uint64(v), nil
+		}
+	case ParameterTypeDouble:
+		if v, ok := value.(int); ok {
+			return float64(v), nil
+		}
+	case ParameterTypeTimestamp:
+		if v, ok := value.(string); ok {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, fmt.Errorf("'%s' isn't an RFC 3339 timestamp", v)
+			}
+			return t, nil
+		}
+	case ParameterTypeDuration:
+		if v, ok := value.(string); ok {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, fmt.Errorf("'%s' isn't a duration", v)
+			}
+			return d, nil
+		}
+	}
+
+	return value, nil
+}
@@ -0,0 +1,126 @@
+package condition
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition *Condition
+		valid     bool
+	}{
+		{
+			name: "valid",
+			condition: &Condition{
+				Name:       "ip_allowed",
+				Expression: "ip in allowed_ips",
+				Parameters: map[string]ParameterType{"ip": ParameterTypeString, "allowed_ips": ParameterTypeList},
+			},
+			valid: true,
+		},
+		{
+			name:      "missing_name",
+			condition: &Condition{Expression: "true"},
+		},
+		{
+			name: "unknown_parameter_type",
+			condition: &Condition{
+				Name:       "unknown",
+				Expression: "x",
+				Parameters: map[string]ParameterType{"x": "ipaddress"},
+			},
+		},
+		{
+			name: "undeclared_parameter",
+			condition: &Condition{
+				Name:       "undeclared",
+				Expression: "x > 1",
+			},
+		},
+		{
+			name: "not_a_bool",
+			condition: &Condition{
+				Name:       "not_a_bool",
+				Expression: "x + 1",
+				Parameters: map[string]ParameterType{"x": ParameterTypeInt},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := NewEvaluableCondition(test.condition).Compile()
+			if test.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	ctx := context.Background()
+
+	businessHours := NewEvaluableCondition(&Condition{
+		Name:       "business_hours",
+		Expression: "current_time.getHours('UTC') >= start && current_time.getHours('UTC') < start + duration(length).getHours()",
+		Parameters: map[string]ParameterType{
+			"current_time": ParameterTypeTimestamp,
+			"start":        ParameterTypeInt,
+			"length":       ParameterTypeString,
+		},
+	})
+
+	t.Run("met", func(t *testing.T) {
+		met, err := businessHours.Evaluate(ctx,
+			map[string]interface{}{"start": float64(9), "length": "9h"},
+			map[string]interface{}{"current_time": "2023-10-02T10:00:00Z"},
+		)
+		require.NoError(t, err)
+		require.True(t, met)
+	})
+
+	t.Run("not_met", func(t *testing.T) {
+		met, err := businessHours.Evaluate(ctx,
+			map[string]interface{}{"start": float64(9), "length": "9h"},
+			map[string]interface{}{"current_time": "2023-10-02T20:00:00Z"},
+		)
+		require.NoError(t, err)
+		require.False(t, met)
+	})
+
+	t.Run("first_context_takes_precedence", func(t *testing.T) {
+		met, err := businessHours.Evaluate(ctx,
+			map[string]interface{}{"start": float64(9), "length": "9h"},
+			map[string]interface{}{"start": float64(0), "length": "24h", "current_time": "2023-10-02T20:00:00Z"},
+		)
+		require.NoError(t, err)
+		require.False(t, met)
+	})
+
+	t.Run("missing_parameters", func(t *testing.T) {
+		_, err := businessHours.Evaluate(ctx, map[string]interface{}{"start": float64(9)})
+		require.ErrorIs(t, err, ErrMissingParameters)
+
+		var evaluationErr *EvaluationError
+		require.ErrorAs(t, err, &evaluationErr)
+		require.Equal(t, "business_hours", evaluationErr.Condition)
+		require.ErrorContains(t, err, "[current_time length]")
+	})
+
+	t.Run("invalid_parameter", func(t *testing.T) {
+		_, err := businessHours.Evaluate(ctx, map[string]interface{}{
+			"start":        float64(9.5),
+			"length":       "9h",
+			"current_time": "2023-10-02T10:00:00Z",
+		})
+
+		var evaluationErr *EvaluationError
+		require.ErrorAs(t, err, &evaluationErr)
+	})
+}
@@ -0,0 +1,268 @@
+package encoder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openfga/openfga/pkg/encrypter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	aesGCMTokenVersion byte = 1
+
+	// aesGCMKeyIDLength is the length of the IDs of the keys, which prefix the encrypted tokens.
+	aesGCMKeyIDLength = 4
+)
+
+var previousKeyDecodeCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "continuation_token_previous_key_decode_count",
+	Help: "The total number of continuation tokens decoded with a previous encryption key, labeled by the ID of the key. Once it stops increasing, the key can be removed.",
+}, []string{"key_id"})
+
+var (
+	ErrExpiredToken       = errors.New("the continuation token has expired")
+	ErrTokenStoreMismatch = errors.New("the continuation token was issued for another store")
+	ErrMalformedToken     = errors.New("the continuation token is malformed")
+)
+
+// StoreEncoder is an Encoder that can bind the continuation tokens to the store they're issued for, so that
+// they're rejected when they're used with another store.
+type StoreEncoder interface {
+	Encoder
+
+	// EncodeForStore encodes the data into a token bound to the store.
+	EncodeForStore(storeID string, data []byte) (string, error)
+
+	// DecodeForStore decodes a token, which must be bound to the store.
+	DecodeForStore(storeID string, s string) ([]byte, error)
+}
+
+// EncodeForStore encodes the data with the encoder, into a token bound to the store if the encoder is a
+// StoreEncoder.
+func EncodeForStore(e Encoder, storeID string, data []byte) (string, error) {
+	if se, ok := e.(StoreEncoder); ok {
+		return se.EncodeForStore(storeID, data)
+	}
+
+	return e.Encode(data)
+}
+
+// DecodeForStore decodes the token with the encoder, which must be bound to the store if the encoder is a
+// StoreEncoder.
+func DecodeForStore(e Encoder, storeID string, s string) ([]byte, error) {
+	if se, ok := e.(StoreEncoder); ok {
+		return se.DecodeForStore(storeID, s)
+	}
+
+	return e.Decode(s)
+}
+
+// AESGCMEncoder is a StoreEncoder that encrypts the continuation tokens with AES-GCM, and embeds the ID of
+// the store they're issued for and their expiry in them. So the tokens are opaque to the clients, can't be
+// forged, and are rejected with ErrTokenStoreMismatch or ErrExpiredToken when they're used with another
+// store or once they've expired.
+//
+// The tokens issued without a store (e.g. by Encode, for ListStores) can only be decoded without a store.
+//
+// The tokens are prefixed by the ID of the key that encrypted them, so that the keys can be rotated: the
+// tokens are encrypted with the current key, but the ones encrypted with the previous keys are still
+// decoded (see WithPreviousKeys). A previous key can be removed once the tokens it encrypted have expired.
+type AESGCMEncoder struct {
+	// keys is the key ring, the first key being the current one
+	keys    []*aesGCMKey
+	encoder *Base64Encoder
+	ttl     time.Duration
+	now     func() time.Time
+
+	previousKeys []string
+}
+
+var _ StoreEncoder = (*AESGCMEncoder)(nil)
+
+type aesGCMKey struct {
+	id        []byte
+	encrypter *encrypter.GCMEncrypter
+}
+
+func newAESGCMKey(key string) (*aesGCMKey, error) {
+	if key == "" {
+		return nil, errors.New("the continuation token encryption keys can't be empty")
+	}
+
+	gcm, err := encrypter.NewGCMEncrypter(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// the ID is a hash of the hash the encryption key is derived from, so it reveals nothing about it
+	derived := sha256.Sum256([]byte(key))
+	id := sha256.Sum256(derived[:])
+
+	return &aesGCMKey{id: id[:aesGCMKeyIDLength], encrypter: gcm}, nil
+}
+
+// AESGCMEncoderOpt defines an option that can be used to change the behavior of an AESGCMEncoder.
+type AESGCMEncoderOpt func(*AESGCMEncoder)
+
+// WithPreviousKeys makes the AESGCMEncoder decode the tokens encrypted with the provided keys, which are
+// the keys used before the current one, e.g. for the time it takes the tokens issued before a rotation to
+// expire.
+func WithPreviousKeys(keys ...string) AESGCMEncoderOpt {
+	return func(e *AESGCMEncoder) {
+		e.previousKeys = keys
+	}
+}
+
+// NewAESGCMEncoder constructs an AESGCMEncoder that encrypts the tokens with a key derived from the provided
+// one, and that issues tokens valid for the provided TTL. A TTL of 0 issues tokens that never expire.
+func NewAESGCMEncoder(key string, ttl time.Duration, opts ...AESGCMEncoderOpt) (*AESGCMEncoder, error) {
+	if ttl < 0 {
+		return nil, fmt.Errorf("the continuation token TTL can't be negative, got %s", ttl)
+	}
+
+	e := &AESGCMEncoder{
+		encoder: NewBase64Encoder(),
+		ttl:     ttl,
+		now:     time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	for _, k := range append([]string{key}, e.previousKeys...) {
+		aesKey, err := newAESGCMKey(k)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, other := range e.keys {
+			if bytes.Equal(other.id, aesKey.id) {
+				return nil, errors.New("the continuation token encryption keys must be distinct")
+			}
+		}
+
+		e.keys = append(e.keys, aesKey)
+	}
+
+	return e, nil
+}
+
+// KeyID returns the ID of the current key, which prefixes the tokens it encrypts, in hexadecimal.
+func (e *AESGCMEncoder) KeyID() string {
+	return hex.EncodeToString(e.keys[0].id)
+}
+
+// Decode decodes a token issued without a store.
+func (e *AESGCMEncoder) Decode(s string) ([]byte, error) {
+	return e.DecodeForStore("", s)
+}
+
+// Encode encodes the data into a token issued without a store.
+func (e *AESGCMEncoder) Encode(data []byte) (string, error) {
+	return e.EncodeForStore("", data)
+}
+
+// EncodeForStore encrypts the data, along with the store ID and the expiry, into a token. Empty data is
+// encoded into an empty token, which is the absence of a continuation token.
+func (e *AESGCMEncoder) EncodeForStore(storeID string, data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", nil
+	}
+
+	var expiry int64
+	if e.ttl > 0 {
+		expiry = e.now().Add(e.ttl).Unix()
+	}
+
+	// version (1 byte) | expiry in Unix seconds, 0 if none (8 bytes) | store ID length (uvarint) | store ID | data
+	payload := make([]byte, 0, 1+8+binary.MaxVarintLen64+len(storeID)+len(data))
+	payload = append(payload, aesGCMTokenVersion)
+	payload = binary.BigEndian.AppendUint64(payload, uint64(expiry))
+	payload = binary.AppendUvarint(payload, uint64(len(storeID)))
+	payload = append(payload, storeID...)
+	payload = append(payload, data...)
+
+	key := e.keys[0]
+	encrypted, err := key.encrypter.Encrypt(payload)
+	if err != nil {
+		return "", err
+	}
+
+	// key ID | nonce | encrypted payload
+	return e.encoder.Encode(append(append(make([]byte, 0, len(key.id)+len(encrypted)), key.id...), encrypted...))
+}
+
+// decrypt returns the payload of a token, decrypted with the key whose ID prefixes it.
+func (e *AESGCMEncoder) decrypt(s string) ([]byte, error) {
+	token, err := e.encoder.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedToken, err)
+	}
+
+	if len(token) < aesGCMKeyIDLength {
+		return nil, ErrMalformedToken
+	}
+
+	keyID, encrypted := token[:aesGCMKeyIDLength], token[aesGCMKeyIDLength:]
+	for i, key := range e.keys {
+		if !bytes.Equal(key.id, keyID) {
+			continue
+		}
+
+		payload, err := key.encrypter.Decrypt(encrypted)
+		if err != nil || len(payload) == 0 {
+			return nil, fmt.Errorf("%w: %v", ErrMalformedToken, err)
+		}
+
+		if i > 0 {
+			previousKeyDecodeCounter.WithLabelValues(hex.EncodeToString(key.id)).Inc()
+		}
+
+		return payload, nil
+	}
+
+	return nil, fmt.Errorf("%w: it was encrypted with an unknown key", ErrMalformedToken)
+}
+
+// DecodeForStore decrypts a token, and returns its data if it's bound to the store and hasn't expired. An
+// empty token is decoded into empty data.
+func (e *AESGCMEncoder) DecodeForStore(storeID string, s string) ([]byte, error) {
+	if s == "" {
+		return []byte{}, nil
+	}
+
+	payload, err := e.decrypt(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) < 1+8 || payload[0] != aesGCMTokenVersion {
+		return nil, ErrMalformedToken
+	}
+
+	expiry := int64(binary.BigEndian.Uint64(payload[1:9]))
+
+	storeIDLength, n := binary.Uvarint(payload[9:])
+	if n <= 0 || uint64(len(payload)-9-n) < storeIDLength {
+		return nil, ErrMalformedToken
+	}
+
+	tokenStoreID := string(payload[9+n : 9+n+int(storeIDLength)])
+	if tokenStoreID != storeID {
+		return nil, ErrTokenStoreMismatch
+	}
+
+	if expiry != 0 && e.now().Unix() >= expiry {
+		return nil, ErrExpiredToken
+	}
+
+	return payload[9+n+int(storeIDLength):], nil
+}
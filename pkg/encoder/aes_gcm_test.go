@@ -0,0 +1,180 @@
+package encoder
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAESGCMEncoder(t *testing.T) {
+	const (
+		storeID      = "01HCSBNPGRMSRYJRJRZ0KCZP1C"
+		otherStoreID = "01HCSBNWV4YGBQW0Y8HP1Q4NKJ"
+	)
+
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	e, err := NewAESGCMEncoder("key", time.Hour)
+	require.NoError(t, err)
+	e.now = func() time.Time { return now }
+
+	data := []byte(`{"ulid":"01HCSBNPGRMSRYJRJRZ0KCZP1C","ObjectType":"document"}`)
+
+	token, err := e.EncodeForStore(storeID, data)
+	require.NoError(t, err)
+	require.NotContains(t, token, "document")
+
+	t.Run("decodes_for_the_store", func(t *testing.T) {
+		decoded, err := e.DecodeForStore(storeID, token)
+		require.NoError(t, err)
+		require.Equal(t, data, decoded)
+
+		decoded, err = DecodeForStore(e, storeID, token)
+		require.NoError(t, err)
+		require.Equal(t, data, decoded)
+	})
+
+	t.Run("rejects_another_store", func(t *testing.T) {
+		_, err := e.DecodeForStore(otherStoreID, token)
+		require.ErrorIs(t, err, ErrTokenStoreMismatch)
+
+		_, err = e.Decode(token)
+		require.ErrorIs(t, err, ErrTokenStoreMismatch)
+	})
+
+	t.Run("rejects_expired", func(t *testing.T) {
+		e.now = func() time.Time { return now.Add(time.Hour) }
+		defer func() { e.now = func() time.Time { return now } }()
+
+		_, err := e.DecodeForStore(storeID, token)
+		require.ErrorIs(t, err, ErrExpiredToken)
+	})
+
+	t.Run("rejects_tampered", func(t *testing.T) {
+		raw, err := base64.URLEncoding.DecodeString(token)
+		require.NoError(t, err)
+		raw[len(raw)-1] ^= 1
+
+		_, err = e.DecodeForStore(storeID, base64.URLEncoding.EncodeToString(raw))
+		require.ErrorIs(t, err, ErrMalformedToken)
+
+		_, err = e.DecodeForStore(storeID, "not a token")
+		require.ErrorIs(t, err, ErrMalformedToken)
+	})
+
+	t.Run("rejects_another_key", func(t *testing.T) {
+		other, err := NewAESGCMEncoder("other key", time.Hour)
+		require.NoError(t, err)
+
+		_, err = other.DecodeForStore(storeID, token)
+		require.ErrorIs(t, err, ErrMalformedToken)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		token, err := e.EncodeForStore(storeID, nil)
+		require.NoError(t, err)
+		require.Empty(t, token)
+
+		decoded, err := e.DecodeForStore(storeID, "")
+		require.NoError(t, err)
+		require.Empty(t, decoded)
+	})
+
+	t.Run("without_store", func(t *testing.T) {
+		token, err := e.Encode(data)
+		require.NoError(t, err)
+
+		decoded, err := e.Decode(token)
+		require.NoError(t, err)
+		require.Equal(t, data, decoded)
+
+		_, err = e.DecodeForStore(storeID, token)
+		require.ErrorIs(t, err, ErrTokenStoreMismatch)
+	})
+}
+
+func TestAESGCMEncoderWithoutExpiry(t *testing.T) {
+	e, err := NewAESGCMEncoder("key", 0)
+	require.NoError(t, err)
+
+	token, err := e.EncodeForStore("01HCSBNPGRMSRYJRJRZ0KCZP1C", []byte("data"))
+	require.NoError(t, err)
+
+	e.now = func() time.Time { return time.Now().Add(100 * 365 * 24 * time.Hour) }
+
+	decoded, err := e.DecodeForStore("01HCSBNPGRMSRYJRJRZ0KCZP1C", token)
+	require.NoError(t, err)
+	require.Equal(t, []byte("data"), decoded)
+}
+
+func TestNewAESGCMEncoderErrors(t *testing.T) {
+	_, err := NewAESGCMEncoder("", time.Hour)
+	require.Error(t, err)
+
+	_, err = NewAESGCMEncoder("key", -time.Hour)
+	require.Error(t, err)
+
+	_, err = NewAESGCMEncoder("key", time.Hour, WithPreviousKeys(""))
+	require.Error(t, err)
+
+	_, err = NewAESGCMEncoder("key", time.Hour, WithPreviousKeys("previous key", "key"))
+	require.Error(t, err)
+}
+
+func TestAESGCMEncoderKeyRotation(t *testing.T) {
+	const storeID = "01HCSBNPGRMSRYJRJRZ0KCZP1C"
+
+	before, err := NewAESGCMEncoder("first key", time.Hour)
+	require.NoError(t, err)
+
+	token, err := before.EncodeForStore(storeID, []byte("data"))
+	require.NoError(t, err)
+
+	after, err := NewAESGCMEncoder("second key", time.Hour, WithPreviousKeys("first key"))
+	require.NoError(t, err)
+	require.NotEqual(t, before.KeyID(), after.KeyID())
+
+	// the tokens issued before the rotation are still decoded, and counted
+	decodedWithPreviousKey := testutil.ToFloat64(previousKeyDecodeCounter.WithLabelValues(before.KeyID()))
+
+	decoded, err := after.DecodeForStore(storeID, token)
+	require.NoError(t, err)
+	require.Equal(t, []byte("data"), decoded)
+	require.Equal(t, decodedWithPreviousKey+1, testutil.ToFloat64(previousKeyDecodeCounter.WithLabelValues(before.KeyID())))
+
+	// the tokens issued after the rotation are encrypted with the new key only
+	token, err = after.EncodeForStore(storeID, []byte("data"))
+	require.NoError(t, err)
+
+	decoded, err = after.DecodeForStore(storeID, token)
+	require.NoError(t, err)
+	require.Equal(t, []byte("data"), decoded)
+
+	_, err = before.DecodeForStore(storeID, token)
+	require.ErrorIs(t, err, ErrMalformedToken)
+
+	// once the previous key is removed, the tokens it encrypted are rejected
+	token, err = before.EncodeForStore(storeID, []byte("data"))
+	require.NoError(t, err)
+
+	removed, err := NewAESGCMEncoder("second key", time.Hour)
+	require.NoError(t, err)
+
+	_, err = removed.DecodeForStore(storeID, token)
+	require.ErrorIs(t, err, ErrMalformedToken)
+}
+
+func TestStoreEncoderFallback(t *testing.T) {
+	e := NewBase64Encoder()
+
+	token, err := EncodeForStore(e, "01HCSBNPGRMSRYJRJRZ0KCZP1C", []byte("data"))
+	require.NoError(t, err)
+	require.Equal(t, "ZGF0YQ==", token)
+
+	decoded, err := DecodeForStore(e, "01HCSBNWV4YGBQW0Y8HP1Q4NKJ", token)
+	require.NoError(t, err)
+	require.Equal(t, []byte("data"), decoded)
+}
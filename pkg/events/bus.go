@@ -0,0 +1,186 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+const DefaultSubscriberBufferSize = 1000
+
+var (
+	eventsPublishedCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "events_published_total",
+		Help: "The total number of events published on the event bus, labeled by type.",
+	}, []string{"type"})
+
+	eventsDroppedCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "events_dropped_total",
+		Help: "The total number of events not delivered to a subscriber of the event bus because the subscriber was too slow, labeled by type.",
+	}, []string{"type"})
+)
+
+var _ Publisher = (*Bus)(nil)
+
+// A Bus delivers the published events to the subscribers of their type. Each subscriber handles its events
+// in the background: publishing never blocks, and the events that would overflow the buffer of a slow
+// subscriber are dropped for it (and counted).
+type Bus struct {
+	bufferSize int
+	logger     logger.Logger
+
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+	closed      bool
+}
+
+type subscriber struct {
+	handler Handler
+	types   map[Type]struct{}
+	queue   chan queuedEvent
+	done    chan struct{}
+}
+
+type queuedEvent struct {
+	ctx   context.Context
+	event Event
+}
+
+type BusOption func(b *Bus)
+
+// WithSubscriberBufferSize sets the number of events that are buffered for each subscriber, beyond which the
+// events are dropped for the subscriber.
+func WithSubscriberBufferSize(size int) BusOption {
+	return func(b *Bus) {
+		b.bufferSize = size
+	}
+}
+
+func WithLogger(logger logger.Logger) BusOption {
+	return func(b *Bus) {
+		b.logger = logger
+	}
+}
+
+func NewBus(opts ...BusOption) *Bus {
+	b := &Bus{
+		bufferSize:  DefaultSubscriberBufferSize,
+		logger:      logger.NewNoopLogger(),
+		subscribers: map[*subscriber]struct{}{},
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// Subscribe delivers the events of the given types (or all the events, if no type is given) to the handler,
+// until the returned function is called to unsubscribe it. Unsubscribing waits for the events queued for the
+// handler to be handled.
+func (b *Bus) Subscribe(handler Handler, types ...Type) (unsubscribe func()) {
+	s := &subscriber{
+		handler: handler,
+		types:   make(map[Type]struct{}, len(types)),
+		queue:   make(chan queuedEvent, b.bufferSize),
+		done:    make(chan struct{}),
+	}
+	for _, t := range types {
+		s.types[t] = struct{}{}
+	}
+
+	go func() {
+		defer close(s.done)
+
+		for queued := range s.queue {
+			b.handle(s, queued)
+		}
+	}()
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		close(s.queue)
+		return func() {}
+	}
+	b.subscribers[s] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			_, subscribed := b.subscribers[s]
+			delete(b.subscribers, s)
+			b.mu.Unlock()
+
+			if subscribed {
+				close(s.queue)
+			}
+			<-s.done
+		})
+	}
+}
+
+func (b *Bus) handle(s *subscriber, queued queuedEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.logger.Error("panic while handling an event",
+				zap.String("event_type", string(queued.event.Type)),
+				zap.Any("panic", r))
+		}
+	}()
+
+	s.handler(queued.ctx, queued.event)
+}
+
+// Publish delivers the event to the subscribers of its type, without waiting for them to handle it. The
+// subscribers receive a context that carries the values of ctx, but isn't cancelled with it.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	eventsPublishedCounter.WithLabelValues(string(event.Type)).Inc()
+
+	queued := queuedEvent{ctx: context.WithoutCancel(ctx), event: event}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for s := range b.subscribers {
+		if len(s.types) > 0 {
+			if _, ok := s.types[event.Type]; !ok {
+				continue
+			}
+		}
+
+		select {
+		case s.queue <- queued:
+		default:
+			eventsDroppedCounter.WithLabelValues(string(event.Type)).Inc()
+			b.logger.Warn("dropped an event for a slow subscriber of the event bus", zap.String("event_type", string(event.Type)))
+		}
+	}
+}
+
+// Close unsubscribes every subscriber, waiting for the events queued for them to be handled. The events
+// published afterwards are discarded.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	subscribers := b.subscribers
+	b.subscribers = map[*subscriber]struct{}{}
+	b.closed = true
+	b.mu.Unlock()
+
+	for s := range subscribers {
+		close(s.queue)
+		<-s.done
+	}
+}
@@ -0,0 +1,139 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recorder records the events it handles.
+type recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recorder) handle(_ context.Context, event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) types() []Type {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var types []Type
+	for _, event := range r.events {
+		types = append(types, event.Type)
+	}
+
+	return types
+}
+
+func TestBus(t *testing.T) {
+	t.Run("delivers_the_events_of_the_subscribed_types_in_order", func(t *testing.T) {
+		bus := NewBus()
+		defer bus.Close()
+
+		var all, stores recorder
+		unsubscribeAll := bus.Subscribe(all.handle)
+		unsubscribeStores := bus.Subscribe(stores.handle, StoreCreated, StoreDeleted)
+
+		bus.Publish(context.Background(), Event{Type: StoreCreated, StoreID: "1"})
+		bus.Publish(context.Background(), Event{Type: TuplesWritten, StoreID: "1"})
+		bus.Publish(context.Background(), Event{Type: StoreDeleted, StoreID: "1"})
+
+		unsubscribeAll()
+		unsubscribeStores()
+
+		require.Equal(t, []Type{StoreCreated, TuplesWritten, StoreDeleted}, all.types())
+		require.Equal(t, []Type{StoreCreated, StoreDeleted}, stores.types())
+		require.False(t, all.events[0].Time.IsZero())
+	})
+
+	t.Run("the_handlers_context_is_not_cancelled_with_the_publishers", func(t *testing.T) {
+		bus := NewBus()
+		defer bus.Close()
+
+		var errs []error
+		unsubscribe := bus.Subscribe(func(ctx context.Context, event Event) {
+			errs = append(errs, ctx.Err())
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		bus.Publish(ctx, Event{Type: StoreCreated})
+		cancel()
+
+		unsubscribe()
+		require.Equal(t, []error{nil}, errs)
+	})
+
+	t.Run("drops_the_events_of_slow_subscribers", func(t *testing.T) {
+		bus := NewBus(WithSubscriberBufferSize(1))
+		defer bus.Close()
+
+		release := make(chan struct{})
+		var handled recorder
+		unsubscribe := bus.Subscribe(func(ctx context.Context, event Event) {
+			<-release
+			handled.handle(ctx, event)
+		})
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 10; i++ {
+				bus.Publish(context.Background(), Event{Type: TuplesWritten})
+			}
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "publishing blocked on a slow subscriber")
+		}
+
+		close(release)
+		unsubscribe()
+		require.Less(t, len(handled.types()), 10)
+	})
+
+	t.Run("recovers_from_panicking_handlers", func(t *testing.T) {
+		bus := NewBus()
+		defer bus.Close()
+
+		var handled recorder
+		unsubscribe := bus.Subscribe(func(ctx context.Context, event Event) {
+			if event.Type == StoreCreated {
+				panic("boom")
+			}
+			handled.handle(ctx, event)
+		})
+
+		bus.Publish(context.Background(), Event{Type: StoreCreated})
+		bus.Publish(context.Background(), Event{Type: StoreDeleted})
+
+		unsubscribe()
+		require.Equal(t, []Type{StoreDeleted}, handled.types())
+	})
+
+	t.Run("discards_the_events_published_after_close", func(t *testing.T) {
+		bus := NewBus()
+
+		var handled recorder
+		bus.Subscribe(handled.handle)
+
+		bus.Publish(context.Background(), Event{Type: StoreCreated})
+		bus.Close()
+		bus.Publish(context.Background(), Event{Type: StoreDeleted})
+
+		require.Equal(t, []Type{StoreCreated}, handled.types())
+
+		unsubscribe := bus.Subscribe(handled.handle)
+		unsubscribe()
+	})
+}
@@ -0,0 +1,99 @@
+// Package events contains a bus on which the server publishes its lifecycle and domain events (e.g. a store
+// was created or an authorization model was written), so that the subsystems interested in them (e.g. the
+// caches) can react to them without the code that produces them knowing about these subsystems.
+package events
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/openfga/openfga/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Type is the type of an event.
+type Type string
+
+const (
+	// ServerStarted is published once the server is serving.
+	ServerStarted Type = "server_started"
+
+	// ServerStopping is published when the server starts shutting down.
+	ServerStopping Type = "server_stopping"
+
+	// StoreCreated is published when a store is created.
+	StoreCreated Type = "store_created"
+
+	// StoreDeleted is published when a store is deleted.
+	StoreDeleted Type = "store_deleted"
+
+	// AuthorizationModelWritten is published when an authorization model is written to a store.
+	AuthorizationModelWritten Type = "authorization_model_written"
+
+	// TuplesWritten is published when tuples are written to or deleted from a store.
+	TuplesWritten Type = "tuples_written"
+
+	// CacheInvalidated is published when entries of a cache are invalidated, e.g. the cached authorization
+	// models of a deleted store. The 'cache' attribute names the cache.
+	CacheInvalidated Type = "cache_invalidated"
+)
+
+// An Event is something that happened in the server.
+type Event struct {
+	Type Type
+
+	// Time is when the event happened. Publish sets it if it's zero.
+	Time time.Time
+
+	// StoreID and AuthorizationModelID are the store and the authorization model the event is about, if any.
+	StoreID              string
+	AuthorizationModelID string
+
+	// Attributes are the details of the event, specific to its type (e.g. the number of tuples written).
+	Attributes map[string]string
+}
+
+// A Handler handles the events a subscriber subscribed to. The events are handled in the background, one at a
+// time and in the order they were published, so a handler doesn't slow down the code that publishes them.
+type Handler func(ctx context.Context, event Event)
+
+// A Publisher publishes events.
+type Publisher interface {
+	Publish(ctx context.Context, event Event)
+}
+
+// NoopPublisher discards the events.
+type NoopPublisher struct{}
+
+var _ Publisher = NoopPublisher{}
+
+func (NoopPublisher) Publish(context.Context, Event) {}
+
+// LogHandler logs the events it handles, e.g. as an audit log of the changes to the stores.
+func LogHandler(l logger.Logger) Handler {
+	return func(ctx context.Context, event Event) {
+		fields := []zap.Field{
+			zap.String("event_type", string(event.Type)),
+			zap.Time("event_time", event.Time),
+		}
+		if event.StoreID != "" {
+			fields = append(fields, zap.String("store_id", event.StoreID))
+		}
+		if event.AuthorizationModelID != "" {
+			fields = append(fields, zap.String("authorization_model_id", event.AuthorizationModelID))
+		}
+
+		keys := make([]string, 0, len(event.Attributes))
+		for key := range event.Attributes {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fields = append(fields, zap.String(key, event.Attributes[key]))
+		}
+
+		l.InfoWithContext(ctx, "event", fields...)
+	}
+}
@@ -0,0 +1,92 @@
+package graphql
+
+import (
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+const (
+	// MaxCostPerQuery is the maximum cost of a query. Every call of the service a field makes costs 1, except
+	// ListObjects, which costs listObjectsCost, and the fields of the items of a page cost as much as the page
+	// may have items.
+	MaxCostPerQuery = 200
+
+	// listObjectsCost is the cost of a listObjects field, since a ListObjects may take as long as its deadline.
+	listObjectsCost = 10
+)
+
+// fieldCost is the cost of a field of a type.
+type fieldCost struct {
+	// cost is the cost of resolving the field once
+	cost int
+
+	// typ is the type of the value of the field, if its fields may call the service
+	typ *objectType
+
+	// paged tells whether the value of the field is a page, whose number of items is the pageSize argument of
+	// the field, or the default page size
+	paged bool
+
+	// items tells whether the value of the field is the items of the page it's a field of, whose fields are
+	// resolved for each item
+	items bool
+}
+
+// fieldCosts are the costs of the fields that call the service, or whose fields may, by type and name. The other
+// fields are free.
+var fieldCosts map[*objectType]map[string]fieldCost
+
+// The costs are set in init, since the types are.
+func init() {
+	fieldCosts = map[*objectType]map[string]fieldCost{
+		queryType: {
+			"stores":      {cost: 1, typ: storesPageType, paged: true},
+			"store":       {cost: 1, typ: storeType},
+			"check":       {cost: 1},
+			"listObjects": {cost: listObjectsCost},
+		},
+		storesPageType: {
+			"stores": {typ: storeType, items: true},
+		},
+		storeType: {
+			"authorizationModels": {cost: 1},
+			"authorizationModel":  {cost: 1},
+			"tuples":              {cost: 1},
+		},
+	}
+}
+
+// cost returns the cost of the selections of a value of the type, which is an item of a page of pageSize items
+// if it's a page. The fields whose arguments are invalid are counted with the default page size, and fail when
+// they're resolved.
+func (e *executor) cost(typ *objectType, selections []*field, pageSize int) int {
+	var total int
+	for _, f := range selections {
+		fc, ok := fieldCosts[typ][f.name]
+		if !ok {
+			continue
+		}
+
+		total += fc.cost
+		if fc.typ == nil {
+			continue
+		}
+
+		itemsPageSize := pageSize
+		if fc.paged {
+			itemsPageSize = storage.DefaultPageSize
+			if args, err := e.arguments(f); err == nil {
+				if size, err := args.int32("pageSize"); err == nil && size > 0 {
+					itemsPageSize = int(size)
+				}
+			}
+		}
+
+		cost := e.cost(fc.typ, f.selections, itemsPageSize)
+		if fc.items {
+			cost *= pageSize
+		}
+		total += cost
+	}
+
+	return total
+}
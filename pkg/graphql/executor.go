@@ -0,0 +1,340 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// An objectType is a type of the schema with fields.
+type objectType struct {
+	name   string
+	fields map[string]fieldResolver
+}
+
+// A fieldResolver resolves a field of an object, given the source of the object and the arguments of the
+// field. It returns a scalar, nil, an *object, or a []any of these.
+type fieldResolver func(ctx context.Context, e *executor, source any, args arguments) (any, error)
+
+// An object is a value of an object type.
+type object struct {
+	typ    *objectType
+	source any
+}
+
+// Error is an error of the response. Its path locates the field whose resolution failed, if any.
+type Error struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// orderedMap is a JSON object whose keys are marshalled in order, since the fields of a response are in the
+// order of the query.
+type orderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: map[string]any{}}
+}
+
+func (m *orderedMap) set(key string, v any) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = v
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// executor executes an operation of a query.
+type executor struct {
+	service   Service
+	variables map[string]any
+	checks    *checkLoader
+	errors    []*Error
+}
+
+// executeSelections resolves the selected fields of an object.
+func (e *executor) executeSelections(ctx context.Context, obj *object, selections []*field, path []any) *orderedMap {
+	result := newOrderedMap()
+
+	for _, f := range selections {
+		key := f.responseKey()
+		fieldPath := append(append([]any{}, path...), key)
+
+		if f.name == "__typename" {
+			result.set(key, obj.typ.name)
+			continue
+		}
+
+		resolve, ok := obj.typ.fields[f.name]
+		if !ok {
+			e.addError(fieldPath, fmt.Errorf("the type '%s' has no field '%s'", obj.typ.name, f.name))
+			result.set(key, nil)
+			continue
+		}
+
+		args, err := e.arguments(f)
+		if err != nil {
+			e.addError(fieldPath, err)
+			result.set(key, nil)
+			continue
+		}
+
+		v, err := resolve(ctx, e, obj.source, args)
+		if err != nil {
+			e.addError(fieldPath, err)
+			result.set(key, nil)
+			continue
+		}
+
+		result.set(key, e.complete(ctx, v, f, fieldPath))
+	}
+
+	return result
+}
+
+// complete completes the value of a field: the selections of objects are resolved, and lists are completed
+// item by item.
+func (e *executor) complete(ctx context.Context, v any, f *field, path []any) any {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case *object:
+		if len(f.selections) == 0 {
+			e.addError(path, fmt.Errorf("the field '%s' of type '%s' must have a selection of subfields", f.name, v.typ.name))
+			return nil
+		}
+
+		return e.executeSelections(ctx, v, f.selections, path)
+	case []any:
+		items := make([]any, 0, len(v))
+		for i, item := range v {
+			items = append(items, e.complete(ctx, item, f, append(append([]any{}, path...), i)))
+		}
+
+		return items
+	default:
+		if len(f.selections) > 0 {
+			e.addError(path, fmt.Errorf("the field '%s' is a scalar and can't have a selection of subfields", f.name))
+			return nil
+		}
+
+		return v
+	}
+}
+
+func (e *executor) addError(path []any, err error) {
+	gqlErr := &Error{Message: err.Error(), Path: path}
+
+	if st, ok := status.FromError(err); ok && st.Code() != codes.OK {
+		gqlErr.Message = st.Message()
+		gqlErr.Extensions = map[string]any{"code": errorCodeName(st.Code())}
+	}
+
+	e.errors = append(e.errors, gqlErr)
+}
+
+// errorCodeName returns the name of the code of an error of the service, which is either an OpenFGA error
+// code (e.g. 'store_id_not_found') or a gRPC code.
+func errorCodeName(code codes.Code) string {
+	for _, names := range []map[int32]string{
+		openfgav1.ErrorCode_name,
+		openfgav1.NotFoundErrorCode_name,
+		openfgav1.InternalErrorCode_name,
+		openfgav1.AuthErrorCode_name,
+	} {
+		if name, ok := names[int32(code)]; ok && code > codes.Unauthenticated {
+			return name
+		}
+	}
+
+	return code.String()
+}
+
+// arguments resolves the arguments of a field, substituting the variables.
+func (e *executor) arguments(f *field) (arguments, error) {
+	args := make(arguments, len(f.arguments))
+	for _, arg := range f.arguments {
+		v, err := e.resolveValue(arg.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid argument '%s': %w", arg.name, err)
+		}
+		args[arg.name] = v
+	}
+
+	return args, nil
+}
+
+// resolveValue substitutes the variables of a value.
+func (e *executor) resolveValue(v value) (any, error) {
+	switch v := v.(type) {
+	case variable:
+		resolved, ok := e.variables[string(v)]
+		if !ok {
+			return nil, fmt.Errorf("the variable '$%s' isn't defined", string(v))
+		}
+		return resolved, nil
+	case enumValue:
+		return string(v), nil
+	case []value:
+		list := make([]any, 0, len(v))
+		for _, item := range v {
+			resolved, err := e.resolveValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, resolved)
+		}
+		return list, nil
+	case map[string]value:
+		obj := make(map[string]any, len(v))
+		for key, item := range v {
+			resolved, err := e.resolveValue(item)
+			if err != nil {
+				return nil, err
+			}
+			obj[key] = resolved
+		}
+		return obj, nil
+	default:
+		return v, nil
+	}
+}
+
+// arguments are the resolved arguments of a field.
+type arguments map[string]any
+
+// string returns the string argument, or "" if it's missing or null.
+func (a arguments) string(name string) (string, error) {
+	switch v := a[name].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		return "", fmt.Errorf("the argument '%s' must be a string", name)
+	}
+}
+
+// requiredString returns the string argument, which must be non-empty.
+func (a arguments) requiredString(name string) (string, error) {
+	s, err := a.string(name)
+	if err != nil {
+		return "", err
+	}
+
+	if s == "" {
+		return "", fmt.Errorf("the argument '%s' is required", name)
+	}
+
+	return s, nil
+}
+
+// int32 returns the integer argument, or 0 if it's missing or null. The integers of the variables are
+// decoded from JSON as floats.
+func (a arguments) int32(name string) (int32, error) {
+	var i int64
+	switch v := a[name].(type) {
+	case nil:
+		return 0, nil
+	case int64:
+		i = v
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("the argument '%s' must be an integer", name)
+		}
+		i = int64(v)
+	case json.Number:
+		var err error
+		if i, err = v.Int64(); err != nil {
+			return 0, fmt.Errorf("the argument '%s' must be an integer", name)
+		}
+	default:
+		return 0, fmt.Errorf("the argument '%s' must be an integer", name)
+	}
+
+	if i < math.MinInt32 || i > math.MaxInt32 {
+		return 0, fmt.Errorf("the argument '%s' is out of range", name)
+	}
+
+	return int32(i), nil
+}
+
+// coerceVariables applies the default values of the variables of the operation and checks that the
+// non-null ones are provided.
+func coerceVariables(op *operation, provided map[string]any) (map[string]any, error) {
+	variables := make(map[string]any, len(op.variables))
+	for _, definition := range op.variables {
+		v, ok := provided[definition.name]
+		if !ok && definition.defaultValue != nil {
+			resolved, err := (&executor{}).resolveValue(definition.defaultValue)
+			if err != nil {
+				return nil, err
+			}
+			v = resolved
+		}
+
+		if v == nil && definition.nonNull {
+			return nil, fmt.Errorf("the variable '$%s' is required", definition.name)
+		}
+
+		variables[definition.name] = v
+	}
+
+	return variables, nil
+}
+
+// selectOperation returns the operation to execute: the one with the given name, or the only one of the
+// document.
+func selectOperation(doc *document, name string) (*operation, error) {
+	if name == "" {
+		if len(doc.operations) > 1 {
+			return nil, errors.New("the document has several operations: the operation name is required")
+		}
+
+		return doc.operations[0], nil
+	}
+
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+
+	return nil, fmt.Errorf("the document has no operation '%s'", name)
+}
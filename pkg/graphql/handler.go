@@ -0,0 +1,225 @@
+// Package graphql contains an HTTP handler that serves the read APIs of the server (the stores, their
+// authorization models and tuples, Check and ListObjects) as a GraphQL endpoint, for the admin tools that
+// prefer GraphQL over the HTTP and gRPC APIs. The schema is Schema.
+//
+// The Checks of a query are batched: the Checks of the same user with the same object are resolved with a
+// single CheckRelations, and the batches are resolved concurrently.
+//
+// The work of a query is bounded: the queries whose cost exceeds MaxCostPerQuery are rejected before they're
+// executed, and a query holds a slot of the concurrency limiter of the server, if any, while it's executed.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/middleware/loadshed"
+	"github.com/openfga/openfga/pkg/server/commands"
+	"go.uber.org/zap"
+)
+
+const (
+	// MaxChecksPerQuery is the maximum number of Checks in a query.
+	MaxChecksPerQuery = 100
+
+	// maxRequestSize is the maximum size of the body of a request.
+	maxRequestSize = 1 << 20
+)
+
+// Service serves the read APIs. It's implemented by server.Server.
+type Service interface {
+	ListStores(ctx context.Context, req *openfgav1.ListStoresRequest) (*openfgav1.ListStoresResponse, error)
+	GetStore(ctx context.Context, req *openfgav1.GetStoreRequest) (*openfgav1.GetStoreResponse, error)
+	ReadAuthorizationModels(ctx context.Context, req *openfgav1.ReadAuthorizationModelsRequest) (*openfgav1.ReadAuthorizationModelsResponse, error)
+	ReadAuthorizationModel(ctx context.Context, req *openfgav1.ReadAuthorizationModelRequest) (*openfgav1.ReadAuthorizationModelResponse, error)
+	Read(ctx context.Context, req *openfgav1.ReadRequest) (*openfgav1.ReadResponse, error)
+	Check(ctx context.Context, req *openfgav1.CheckRequest) (*openfgav1.CheckResponse, error)
+	CheckRelations(ctx context.Context, req *commands.CheckRelationsRequest) (*commands.CheckRelationsResponse, error)
+	ListObjects(ctx context.Context, req *openfgav1.ListObjectsRequest) (*openfgav1.ListObjectsResponse, error)
+}
+
+// Request is a GraphQL request.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Response is a GraphQL response. Data is nil if the request couldn't be executed (e.g. a syntax error);
+// otherwise the fields that couldn't be resolved are null, and the reasons are in Errors.
+type Response struct {
+	Data   any      `json:"data"`
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+// Handler serves the GraphQL endpoint.
+type Handler struct {
+	service Service
+	logger  logger.Logger
+	limiter *loadshed.ConcurrencyLimiter
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+// HandlerOpt defines an option that can be used to change the behavior of a Handler.
+type HandlerOpt func(*Handler)
+
+// WithLogger sets the logger of the Handler.
+func WithLogger(logger logger.Logger) HandlerOpt {
+	return func(h *Handler) {
+		h.logger = logger
+	}
+}
+
+// WithConcurrencyLimiter makes the queries hold a slot of the limiter while they're executed, e.g. the one of
+// the gRPC server, since the Handler calls the service directly. The queries beyond its limit are rejected with
+// the status 429 Too Many Requests and the load shedding hints (see package loadshed).
+func WithConcurrencyLimiter(limiter *loadshed.ConcurrencyLimiter) HandlerOpt {
+	return func(h *Handler) {
+		h.limiter = limiter
+	}
+}
+
+// NewHandler returns a Handler that serves the read APIs of the service.
+func NewHandler(service Service, opts ...HandlerOpt) *Handler {
+	h := &Handler{
+		service: service,
+		logger:  logger.NewNoopLogger(),
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// ServeHTTP serves the GraphQL requests, either as the JSON body of a POST or as the 'query', 'operationName'
+// and 'variables' parameters of a GET.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req Request
+
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				h.writeResponse(w, http.StatusBadRequest, &Response{Errors: []*Error{{Message: fmt.Sprintf("invalid variables: %v", err)}}})
+				return
+			}
+		}
+	case http.MethodPost:
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize+1))
+		if err != nil {
+			h.writeResponse(w, http.StatusBadRequest, &Response{Errors: []*Error{{Message: "failed to read the request"}}})
+			return
+		}
+
+		if len(body) > maxRequestSize {
+			h.writeResponse(w, http.StatusRequestEntityTooLarge, &Response{Errors: []*Error{{Message: "the request is too large"}}})
+			return
+		}
+
+		if err := json.Unmarshal(body, &req); err != nil {
+			h.writeResponse(w, http.StatusBadRequest, &Response{Errors: []*Error{{Message: fmt.Sprintf("invalid request: %v", err)}}})
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		h.writeResponse(w, http.StatusMethodNotAllowed, &Response{Errors: []*Error{{Message: "method not allowed"}}})
+		return
+	}
+
+	if h.limiter != nil {
+		release, hint, ok := h.limiter.TryAcquire()
+		if !ok {
+			loadshed.RejectHTTP(w.Header(), hint)
+			h.writeResponse(w, http.StatusTooManyRequests, &Response{Errors: []*Error{{Message: "the server is serving too many requests concurrently"}}})
+			return
+		}
+		defer release()
+	}
+
+	resp := h.Execute(r.Context(), &req)
+
+	statusCode := http.StatusOK
+	if resp.Data == nil {
+		statusCode = http.StatusBadRequest
+	}
+
+	h.writeResponse(w, statusCode, resp)
+}
+
+func (h *Handler) writeResponse(w http.ResponseWriter, statusCode int, resp *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("failed to write the GraphQL response", zap.Error(err))
+	}
+}
+
+// Execute executes a GraphQL request.
+func (h *Handler) Execute(ctx context.Context, req *Request) *Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	op, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	variables, err := coerceVariables(op, req.Variables)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	e := &executor{
+		service:   h.service,
+		variables: variables,
+		checks:    newCheckLoader(h.service),
+	}
+
+	if cost := e.cost(queryType, op.selections, 0); cost > MaxCostPerQuery {
+		return &Response{Errors: []*Error{{Message: fmt.Sprintf("the cost of the query is %d, more than the maximum of %d", cost, MaxCostPerQuery)}}}
+	}
+
+	// the Checks are only fields of the query, so they're all known before it's executed
+	for _, f := range op.selections {
+		if f.name != "check" {
+			continue
+		}
+
+		args, err := e.arguments(f)
+		if err != nil {
+			continue // reported when the field is resolved
+		}
+
+		key, err := checkKeyFromArguments(args)
+		if err != nil {
+			continue // reported when the field is resolved
+		}
+
+		e.checks.add(key)
+	}
+
+	if len(e.checks.keys) > MaxChecksPerQuery {
+		return &Response{Errors: []*Error{{Message: fmt.Sprintf("the query has more than %d Checks", MaxChecksPerQuery)}}}
+	}
+
+	e.checks.resolve(ctx)
+
+	data := e.executeSelections(ctx, &object{typ: queryType}, op.selections, nil)
+
+	return &Response{Data: data, Errors: e.errors}
+}
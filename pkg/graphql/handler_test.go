@@ -0,0 +1,273 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dslparser "github.com/craigpastro/openfga-dsl-parser/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/middleware/loadshed"
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/server/commands"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+// countingService counts the Checks and the CheckRelations of the service.
+type countingService struct {
+	*server.Server
+	checks         atomic.Int32
+	checkRelations atomic.Int32
+}
+
+func (s *countingService) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openfgav1.CheckResponse, error) {
+	s.checks.Add(1)
+	return s.Server.Check(ctx, req)
+}
+
+func (s *countingService) CheckRelations(ctx context.Context, req *commands.CheckRelationsRequest) (*commands.CheckRelationsResponse, error) {
+	s.checkRelations.Add(1)
+	return s.Server.CheckRelations(ctx, req)
+}
+
+func setupHandler(t *testing.T) (*Handler, *countingService, string, string) {
+	ctx := context.Background()
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := server.MustNewServerWithOpts(server.WithDatastore(ds))
+	t.Cleanup(s.Close)
+
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "graphql"})
+	require.NoError(t, err)
+
+	model, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:       store.GetId(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: dslparser.MustParse(`
+		type user
+
+		type document
+		  relations
+		    define editor: [user] as self
+		    define viewer: [user] as self or editor
+		`),
+	})
+	require.NoError(t, err)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: store.GetId(),
+		Writes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:1", "editor", "user:anne"),
+			tuple.NewTupleKey("document:2", "viewer", "user:anne"),
+		}},
+	})
+	require.NoError(t, err)
+
+	service := &countingService{Server: s}
+	return NewHandler(service), service, store.GetId(), model.GetAuthorizationModelId()
+}
+
+func executeJSON(t *testing.T, h *Handler, req *Request) map[string]any {
+	resp := h.Execute(context.Background(), req)
+
+	body, err := json.Marshal(resp)
+	require.NoError(t, err)
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(body, &decoded))
+
+	return decoded
+}
+
+func TestExecute(t *testing.T) {
+	h, service, storeID, modelID := setupHandler(t)
+
+	t.Run("store_with_models_and_tuples", func(t *testing.T) {
+		resp := executeJSON(t, h, &Request{
+			Query: `query Store($id: ID!) {
+			  store(id: $id) {
+			    __typename
+			    name
+			    authorizationModels { authorizationModels { id types } }
+			    tuples(object: "document:1") { tuples { object relation user } continuationToken }
+			  }
+			}`,
+			Variables: map[string]any{"id": storeID},
+		})
+
+		require.Equal(t, map[string]any{
+			"data": map[string]any{
+				"store": map[string]any{
+					"__typename": "Store",
+					"name":       "graphql",
+					"authorizationModels": map[string]any{
+						"authorizationModels": []any{
+							map[string]any{"id": modelID, "types": []any{"user", "document"}},
+						},
+					},
+					"tuples": map[string]any{
+						"tuples": []any{
+							map[string]any{"object": "document:1", "relation": "editor", "user": "user:anne"},
+						},
+						"continuationToken": "",
+					},
+				},
+			},
+		}, resp)
+	})
+
+	t.Run("checks_are_batched", func(t *testing.T) {
+		service.checks.Store(0)
+		service.checkRelations.Store(0)
+
+		resp := executeJSON(t, h, &Request{
+			Query: `query Checks($store: ID!) {
+			  canEdit: check(storeId: $store, object: "document:1", relation: "editor", user: "user:anne")
+			  canView: check(storeId: $store, object: "document:1", relation: "viewer", user: "user:anne")
+			  canViewOther: check(storeId: $store, object: "document:2", relation: "viewer", user: "user:anne")
+			  undefined: check(storeId: $store, object: "document:2", relation: "owner", user: "user:anne")
+			  documents: listObjects(storeId: $store, type: "document", relation: "viewer", user: "user:anne")
+			}`,
+			Variables: map[string]any{"store": storeID},
+		})
+
+		data := resp["data"].(map[string]any)
+		require.Equal(t, true, data["canEdit"])
+		require.Equal(t, true, data["canView"])
+		require.Equal(t, true, data["canViewOther"])
+		require.Nil(t, data["undefined"])
+		require.ElementsMatch(t, []any{"document:1", "document:2"}, data["documents"])
+
+		errs := resp["errors"].([]any)
+		require.Len(t, errs, 1)
+		require.Equal(t, []any{"undefined"}, errs[0].(map[string]any)["path"])
+
+		// one CheckRelations for document:1, and a CheckRelations for document:2 that fails because of the
+		// undefined relation, followed by a Check of each of its relations
+		require.EqualValues(t, 2, service.checkRelations.Load())
+		require.EqualValues(t, 2, service.checks.Load())
+	})
+
+	t.Run("field_errors", func(t *testing.T) {
+		resp := executeJSON(t, h, &Request{
+			Query: `{ store(id: "01HVMMBCMGZNT3SED4Z17ECXCA") { id } stores { unknown } }`,
+		})
+
+		require.Equal(t, map[string]any{"store": nil, "stores": map[string]any{"unknown": nil}}, resp["data"])
+
+		errs := resp["errors"].([]any)
+		require.Len(t, errs, 2)
+		require.Equal(t, map[string]any{"code": "store_id_not_found"}, errs[0].(map[string]any)["extensions"])
+		require.Equal(t, "the type 'StoresPage' has no field 'unknown'", errs[1].(map[string]any)["message"])
+	})
+
+	t.Run("request_errors", func(t *testing.T) {
+		resp := h.Execute(context.Background(), &Request{Query: `query ($id: ID!) { store(id: $id) { id } }`})
+		require.Nil(t, resp.Data)
+		require.Equal(t, []*Error{{Message: "the variable '$id' is required"}}, resp.Errors)
+
+		resp = h.Execute(context.Background(), &Request{Query: `query A { stores { continuationToken } } query B { stores { continuationToken } }`})
+		require.Nil(t, resp.Data)
+		require.Equal(t, []*Error{{Message: "the document has several operations: the operation name is required"}}, resp.Errors)
+	})
+
+	t.Run("over_budget_queries_are_rejected", func(t *testing.T) {
+		var listObjects strings.Builder
+		for i := 0; i < MaxCostPerQuery/listObjectsCost+1; i++ {
+			fmt.Fprintf(&listObjects, `l%d: listObjects(storeId: $store, type: "document", relation: "viewer", user: "user:anne") `, i)
+		}
+
+		resp := h.Execute(context.Background(), &Request{
+			Query:     `query ($store: ID!) { ` + listObjects.String() + `}`,
+			Variables: map[string]any{"store": storeID},
+		})
+		require.Nil(t, resp.Data)
+		require.Equal(t, []*Error{{Message: "the cost of the query is 210, more than the maximum of 200"}}, resp.Errors)
+
+		// the fields of the stores are resolved for each store of the page
+		resp = h.Execute(context.Background(), &Request{
+			Query: `{ stores(pageSize: 100) { stores { tuples { continuationToken } authorizationModels { continuationToken } } } }`,
+		})
+		require.Nil(t, resp.Data)
+		require.Equal(t, []*Error{{Message: "the cost of the query is 201, more than the maximum of 200"}}, resp.Errors)
+
+		resp = h.Execute(context.Background(), &Request{
+			Query: `{ stores(pageSize: 99) { stores { tuples { continuationToken } authorizationModels { continuationToken } } } }`,
+		})
+		require.Empty(t, resp.Errors)
+	})
+}
+
+func TestServeHTTP(t *testing.T) {
+	h, _, storeID, _ := setupHandler(t)
+
+	t.Run("post", func(t *testing.T) {
+		body := `{"query": "query ($id: ID!) { store(id: $id) { id name } }", "variables": {"id": "` + storeID + `"}}`
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/graphql", strings.NewReader(body)))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{"data": {"store": {"id": "`+storeID+`", "name": "graphql"}}}`, rec.Body.String())
+	})
+
+	t.Run("get", func(t *testing.T) {
+		query := url.Values{"query": {`{ store(id: "` + storeID + `") { name } }`}}
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/graphql?"+query.Encode(), nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{"data": {"store": {"name": "graphql"}}}`, rec.Body.String())
+	})
+
+	t.Run("syntax_error", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/graphql", strings.NewReader(`{"query": "{ stores "}`)))
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		require.JSONEq(t, `{"data": null, "errors": [{"message": "syntax error at position 9: expected a name, got <EOF>"}]}`, rec.Body.String())
+	})
+
+	t.Run("concurrency_limit", func(t *testing.T) {
+		limiter := loadshed.NewConcurrencyLimiter(1, loadshed.WithRetryAfter(time.Second))
+		h := NewHandler(h.service, WithConcurrencyLimiter(limiter))
+
+		release, _, ok := limiter.TryAcquire()
+		require.True(t, ok)
+
+		query := url.Values{"query": {`{ store(id: "` + storeID + `") { name } }`}}
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/graphql?"+query.Encode(), nil))
+
+		require.Equal(t, http.StatusTooManyRequests, rec.Code)
+		require.Equal(t, "1", rec.Header().Get(loadshed.RetryAfterHeader))
+		require.Equal(t, string(loadshed.ReasonConcurrencyLimit), rec.Header().Get(loadshed.LoadHintHeader))
+
+		release()
+
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/graphql?"+query.Encode(), nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("method_not_allowed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/admin/graphql", nil))
+
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
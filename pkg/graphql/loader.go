@@ -0,0 +1,162 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/server/commands"
+	"github.com/openfga/openfga/pkg/tuple"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxConcurrentCheckBatches is the maximum number of batches of Checks of a query resolved concurrently.
+const maxConcurrentCheckBatches = 10
+
+// checkKey identifies a Check of a query.
+type checkKey struct {
+	storeID              string
+	authorizationModelID string
+	object               string
+	relation             string
+	user                 string
+}
+
+// batchKey identifies the Checks that can be resolved together, with a single CheckRelations.
+type batchKey struct {
+	storeID              string
+	authorizationModelID string
+	object               string
+	user                 string
+}
+
+type checkResult struct {
+	allowed bool
+	err     error
+}
+
+// checkLoader resolves the Checks of a query in batches: the Checks of the same user with the same object
+// are resolved with a single CheckRelations, and the batches are resolved concurrently. The Checks are
+// collected from the query before it's executed, so that the resolvers of the Check fields only read the
+// results.
+type checkLoader struct {
+	service Service
+	keys    []checkKey
+	results map[checkKey]checkResult
+}
+
+func newCheckLoader(service Service) *checkLoader {
+	return &checkLoader{
+		service: service,
+		results: map[checkKey]checkResult{},
+	}
+}
+
+func (l *checkLoader) add(key checkKey) {
+	l.keys = append(l.keys, key)
+}
+
+// resolve resolves every Check added to the loader.
+func (l *checkLoader) resolve(ctx context.Context) {
+	batches := map[batchKey][]string{}
+	var order []batchKey
+	seen := map[checkKey]struct{}{}
+
+	for _, key := range l.keys {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		batch := batchKey{
+			storeID:              key.storeID,
+			authorizationModelID: key.authorizationModelID,
+			object:               key.object,
+			user:                 key.user,
+		}
+		if _, ok := batches[batch]; !ok {
+			order = append(order, batch)
+		}
+		batches[batch] = append(batches[batch], key.relation)
+	}
+
+	var mu sync.Mutex
+	pool := errgroup.Group{}
+	pool.SetLimit(maxConcurrentCheckBatches)
+
+	for _, batch := range order {
+		relations := batches[batch]
+		for start := 0; start < len(relations); start += commands.MaxRelationsPerCheckRelations {
+			end := min(start+commands.MaxRelationsPerCheckRelations, len(relations))
+
+			batch, chunk := batch, relations[start:end]
+			pool.Go(func() error {
+				results := l.resolveBatch(ctx, batch, chunk)
+
+				mu.Lock()
+				defer mu.Unlock()
+				for key, result := range results {
+					l.results[key] = result
+				}
+
+				return nil
+			})
+		}
+	}
+
+	_ = pool.Wait()
+}
+
+// resolveBatch resolves the Checks of a batch. If the batch fails, its Checks are resolved one by one, so that
+// the error of a Check (e.g. an undefined relation) doesn't fail the other ones.
+func (l *checkLoader) resolveBatch(ctx context.Context, batch batchKey, relations []string) map[checkKey]checkResult {
+	results := make(map[checkKey]checkResult, len(relations))
+
+	keyOf := func(relation string) checkKey {
+		return checkKey{
+			storeID:              batch.storeID,
+			authorizationModelID: batch.authorizationModelID,
+			object:               batch.object,
+			relation:             relation,
+			user:                 batch.user,
+		}
+	}
+
+	if len(relations) > 1 {
+		resp, err := l.service.CheckRelations(ctx, &commands.CheckRelationsRequest{
+			StoreID:              batch.storeID,
+			AuthorizationModelID: batch.authorizationModelID,
+			Object:               batch.object,
+			User:                 batch.user,
+			Relations:            relations,
+		})
+		if err == nil {
+			for _, relation := range relations {
+				results[keyOf(relation)] = checkResult{allowed: resp.Relations[relation]}
+			}
+
+			return results
+		}
+	}
+
+	for _, relation := range relations {
+		resp, err := l.service.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:              batch.storeID,
+			AuthorizationModelId: batch.authorizationModelID,
+			TupleKey:             tuple.NewTupleKey(batch.object, relation, batch.user),
+		})
+		results[keyOf(relation)] = checkResult{allowed: resp.GetAllowed(), err: err}
+	}
+
+	return results
+}
+
+func (l *checkLoader) load(key checkKey) (bool, error) {
+	result, ok := l.results[key]
+	if !ok {
+		// the Checks are collected before the query is executed, so this can't happen
+		return false, errCheckNotLoaded
+	}
+
+	return result.allowed, result.err
+}
@@ -0,0 +1,592 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The parser supports the subset of the GraphQL query language that the read APIs need: query operations
+// (named or anonymous, with variables), fields with aliases, arguments and selection sets. Fragments,
+// directives, mutations and subscriptions aren't supported.
+
+type document struct {
+	operations []*operation
+}
+
+type operation struct {
+	name       string
+	variables  []*variableDefinition
+	selections []*field
+}
+
+type variableDefinition struct {
+	name         string
+	nonNull      bool
+	defaultValue value
+}
+
+type field struct {
+	alias      string
+	name       string
+	arguments  []*argument
+	selections []*field
+}
+
+// responseKey is the key of the field in the response: its alias, if any, or its name.
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+
+	return f.name
+}
+
+type argument struct {
+	name  string
+	value value
+}
+
+// A value is a literal value of the query. It's one of: nil, bool, int64, float64, string, enumValue,
+// variable, []value or map[string]value.
+type value any
+
+type enumValue string
+
+type variable string
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "<EOF>"
+	}
+
+	return fmt.Sprintf("'%s'", t.value)
+}
+
+type parser struct {
+	src string
+	pos int
+	tok token
+}
+
+func parse(src string) (*document, error) {
+	p := &parser{src: src}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	doc := &document{}
+	for p.tok.kind != tokenEOF {
+		op, err := p.parseOperation()
+		if err != nil {
+			return nil, err
+		}
+		doc.operations = append(doc.operations, op)
+	}
+
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("the document has no operation")
+	}
+
+	return doc, nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("syntax error at position %d: %s", p.tok.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) parseOperation() (*operation, error) {
+	op := &operation{}
+
+	if p.tok.kind == tokenName {
+		switch p.tok.value {
+		case "query":
+		case "mutation", "subscription":
+			return nil, p.errorf("%s operations aren't supported", p.tok.value)
+		case "fragment":
+			return nil, p.errorf("fragments aren't supported")
+		default:
+			return nil, p.errorf("unexpected %s", p.tok)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		if p.tok.kind == tokenName {
+			op.name = p.tok.value
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		}
+
+		if p.isPunctuator("(") {
+			variables, err := p.parseVariableDefinitions()
+			if err != nil {
+				return nil, err
+			}
+			op.variables = variables
+		}
+	}
+
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = selections
+
+	return op, nil
+}
+
+func (p *parser) parseVariableDefinitions() ([]*variableDefinition, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	var definitions []*variableDefinition
+	for !p.isPunctuator(")") {
+		if err := p.expect("$"); err != nil {
+			return nil, err
+		}
+
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		nonNull, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+
+		definition := &variableDefinition{name: name, nonNull: nonNull}
+
+		if p.isPunctuator("=") {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+
+			definition.defaultValue, err = p.parseValue(true)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		definitions = append(definitions, definition)
+	}
+
+	return definitions, p.expect(")")
+}
+
+// parseType parses the type of a variable, returning whether it's non-null. The types of the variables
+// aren't checked: the arguments are coerced when they're resolved.
+func (p *parser) parseType() (bool, error) {
+	if p.isPunctuator("[") {
+		if err := p.next(); err != nil {
+			return false, err
+		}
+		if _, err := p.parseType(); err != nil {
+			return false, err
+		}
+		if err := p.expect("]"); err != nil {
+			return false, err
+		}
+	} else if _, err := p.parseName(); err != nil {
+		return false, err
+	}
+
+	if p.isPunctuator("!") {
+		return true, p.next()
+	}
+
+	return false, nil
+}
+
+func (p *parser) parseSelectionSet() ([]*field, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	var selections []*field
+	for !p.isPunctuator("}") {
+		if p.isPunctuator("...") {
+			return nil, p.errorf("fragments aren't supported")
+		}
+
+		f, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, f)
+	}
+
+	if len(selections) == 0 {
+		return nil, p.errorf("empty selection set")
+	}
+
+	return selections, p.expect("}")
+}
+
+func (p *parser) parseField() (*field, error) {
+	name, err := p.parseName()
+	if err != nil {
+		return nil, err
+	}
+
+	f := &field{name: name}
+
+	if p.isPunctuator(":") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		f.alias = name
+		if f.name, err = p.parseName(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.isPunctuator("(") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		for !p.isPunctuator(")") {
+			name, err := p.parseName()
+			if err != nil {
+				return nil, err
+			}
+
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+
+			v, err := p.parseValue(false)
+			if err != nil {
+				return nil, err
+			}
+
+			f.arguments = append(f.arguments, &argument{name: name, value: v})
+		}
+
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.isPunctuator("@") {
+		return nil, p.errorf("directives aren't supported")
+	}
+
+	if p.isPunctuator("{") {
+		if f.selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// parseValue parses a value. Constant values (e.g. the default values of the variables) can't contain
+// variables.
+func (p *parser) parseValue(constant bool) (value, error) {
+	tok := p.tok
+
+	switch tok.kind {
+	case tokenPunctuator:
+		switch tok.value {
+		case "$":
+			if constant {
+				return nil, p.errorf("unexpected variable")
+			}
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+
+			name, err := p.parseName()
+			if err != nil {
+				return nil, err
+			}
+
+			return variable(name), nil
+		case "[":
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+
+			list := []value{}
+			for !p.isPunctuator("]") {
+				v, err := p.parseValue(constant)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+
+			return list, p.next()
+		case "{":
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+
+			object := map[string]value{}
+			for !p.isPunctuator("}") {
+				name, err := p.parseName()
+				if err != nil {
+					return nil, err
+				}
+
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+
+				if object[name], err = p.parseValue(constant); err != nil {
+					return nil, err
+				}
+			}
+
+			return object, p.next()
+		}
+	case tokenInt:
+		i, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return nil, p.errorf("invalid integer %s", tok)
+		}
+		return i, p.next()
+	case tokenFloat:
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, p.errorf("invalid float %s", tok)
+		}
+		return f, p.next()
+	case tokenString:
+		return tok.value, p.next()
+	case tokenName:
+		var v value
+		switch tok.value {
+		case "true":
+			v = true
+		case "false":
+			v = false
+		case "null":
+			v = nil
+		default:
+			v = enumValue(tok.value)
+		}
+		return v, p.next()
+	}
+
+	return nil, p.errorf("unexpected %s", tok)
+}
+
+func (p *parser) parseName() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", p.errorf("expected a name, got %s", p.tok)
+	}
+
+	name := p.tok.value
+	return name, p.next()
+}
+
+func (p *parser) isPunctuator(value string) bool {
+	return p.tok.kind == tokenPunctuator && p.tok.value == value
+}
+
+func (p *parser) expect(punctuator string) error {
+	if !p.isPunctuator(punctuator) {
+		return p.errorf("expected '%s', got %s", punctuator, p.tok)
+	}
+
+	return p.next()
+}
+
+// next reads the next token, skipping the whitespace, the commas and the comments.
+func (p *parser) next() error {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			p.pos++
+			continue
+		}
+
+		if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' && p.src[p.pos] != '\r' {
+				p.pos++
+			}
+			continue
+		}
+
+		break
+	}
+
+	start := p.pos
+	if p.pos >= len(p.src) {
+		p.tok = token{kind: tokenEOF, pos: start}
+		return nil
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+		p.tok = token{kind: tokenPunctuator, value: "...", pos: start}
+	case strings.IndexByte("!$()/:=@[]{|}&", c) >= 0:
+		p.pos++
+		p.tok = token{kind: tokenPunctuator, value: string(c), pos: start}
+	case c == '_' || isLetter(c):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || isLetter(p.src[p.pos]) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		p.tok = token{kind: tokenName, value: p.src[start:p.pos], pos: start}
+	case c == '-' || isDigit(c):
+		return p.readNumber()
+	case c == '"':
+		return p.readString()
+	default:
+		r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+		p.tok = token{pos: start}
+		return p.errorf("unexpected character %q", r)
+	}
+
+	return nil
+}
+
+func (p *parser) readNumber() error {
+	start := p.pos
+	kind := tokenInt
+
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+
+	digits := func() {
+		for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+			p.pos++
+		}
+	}
+
+	digits()
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		kind = tokenFloat
+		p.pos++
+		digits()
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		kind = tokenFloat
+		p.pos++
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		digits()
+	}
+
+	p.tok = token{kind: kind, value: p.src[start:p.pos], pos: start}
+	return nil
+}
+
+func (p *parser) readString() error {
+	start := p.pos
+
+	if strings.HasPrefix(p.src[p.pos:], `"""`) {
+		end := strings.Index(p.src[p.pos+3:], `"""`)
+		if end < 0 {
+			p.tok = token{pos: start}
+			return p.errorf("unterminated string")
+		}
+
+		p.tok = token{kind: tokenString, value: p.src[p.pos+3 : p.pos+3+end], pos: start}
+		p.pos += end + 6
+		return nil
+	}
+
+	var sb strings.Builder
+	p.pos++
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch c {
+		case '\\':
+			if p.pos+1 >= len(p.src) {
+				p.pos++
+				continue
+			}
+
+			escaped := p.src[p.pos+1]
+			switch escaped {
+			case '"', '\\', '/':
+				sb.WriteByte(escaped)
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'u':
+				if p.pos+6 > len(p.src) {
+					p.tok = token{pos: p.pos}
+					return p.errorf("invalid escape sequence")
+				}
+
+				r, err := strconv.ParseUint(p.src[p.pos+2:p.pos+6], 16, 32)
+				if err != nil {
+					p.tok = token{pos: p.pos}
+					return p.errorf("invalid escape sequence")
+				}
+
+				sb.WriteRune(rune(r))
+				p.pos += 4
+			default:
+				p.tok = token{pos: p.pos}
+				return p.errorf("invalid escape sequence")
+			}
+
+			p.pos += 2
+			continue
+		case '\n', '\r':
+			p.tok = token{pos: start}
+			return p.errorf("unterminated string")
+		case '"':
+			p.pos++
+			p.tok = token{kind: tokenString, value: sb.String(), pos: start}
+			return nil
+		}
+
+		sb.WriteByte(c)
+		p.pos++
+	}
+
+	p.tok = token{pos: start}
+	return p.errorf("unterminated string")
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,109 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	doc, err := parse(`
+	# the stores and a Check
+	query Stores($pageSize: Int = 10, $user: String!) {
+	  page: stores(pageSize: $pageSize) {
+	    stores { id name }
+	  }
+	  check(storeId: "01H", object: "document:1", relation: "viewer", user: $user, list: [1, 2.5, true, null, VIEWER], obj: {a: "\"b\"é"})
+	}
+
+	{ __typename }
+	`)
+	require.NoError(t, err)
+	require.Len(t, doc.operations, 2)
+
+	op := doc.operations[0]
+	require.Equal(t, "Stores", op.name)
+	require.Equal(t, []*variableDefinition{
+		{name: "pageSize", defaultValue: int64(10)},
+		{name: "user", nonNull: true},
+	}, op.variables)
+
+	require.Len(t, op.selections, 2)
+
+	page := op.selections[0]
+	require.Equal(t, "page", page.alias)
+	require.Equal(t, "stores", page.name)
+	require.Equal(t, []*argument{{name: "pageSize", value: variable("pageSize")}}, page.arguments)
+	require.Len(t, page.selections, 1)
+	require.Len(t, page.selections[0].selections, 2)
+
+	check := op.selections[1]
+	require.Equal(t, "check", check.responseKey())
+	require.Equal(t, []*argument{
+		{name: "storeId", value: "01H"},
+		{name: "object", value: "document:1"},
+		{name: "relation", value: "viewer"},
+		{name: "user", value: variable("user")},
+		{name: "list", value: []value{int64(1), 2.5, true, nil, enumValue("VIEWER")}},
+		{name: "obj", value: map[string]value{"a": `"b"é`}},
+	}, check.arguments)
+
+	require.Empty(t, doc.operations[1].name)
+	require.Equal(t, "__typename", doc.operations[1].selections[0].name)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		expectedErr string
+	}{
+		{
+			name:        "empty",
+			query:       " ",
+			expectedErr: "the document has no operation",
+		},
+		{
+			name:        "mutation",
+			query:       "mutation { createStore }",
+			expectedErr: "syntax error at position 0: mutation operations aren't supported",
+		},
+		{
+			name:        "fragment_spread",
+			query:       "{ stores { ...page } }",
+			expectedErr: "syntax error at position 11: fragments aren't supported",
+		},
+		{
+			name:        "directive",
+			query:       "{ stores @skip(if: true) { continuationToken } }",
+			expectedErr: "syntax error at position 9: directives aren't supported",
+		},
+		{
+			name:        "unterminated_selection_set",
+			query:       "{ stores { continuationToken }",
+			expectedErr: "syntax error at position 30: expected a name, got <EOF>",
+		},
+		{
+			name:        "unterminated_string",
+			query:       `{ store(id: "01H) { id } }`,
+			expectedErr: "syntax error at position 12: unterminated string",
+		},
+		{
+			name:        "variable_in_default_value",
+			query:       "query ($a: String = $b) { store(id: $a) { id } }",
+			expectedErr: "syntax error at position 20: unexpected variable",
+		},
+		{
+			name:        "unexpected_character",
+			query:       "{ store(id: ?) { id } }",
+			expectedErr: "syntax error at position 12: unexpected character '?'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parse(test.query)
+			require.EqualError(t, err, test.expectedErr)
+		})
+	}
+}
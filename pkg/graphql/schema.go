@@ -0,0 +1,402 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Schema is the GraphQL schema of the read APIs served by the Handler.
+const Schema = `type Query {
+  stores(pageSize: Int, continuationToken: String): StoresPage
+  store(id: ID!): Store
+  check(storeId: ID!, authorizationModelId: ID, object: String!, relation: String!, user: String!): Boolean
+  listObjects(storeId: ID!, authorizationModelId: ID, type: String!, relation: String!, user: String!): [String]
+}
+
+type StoresPage {
+  stores: [Store]
+  continuationToken: String
+}
+
+type Store {
+  id: ID
+  name: String
+  createdAt: String
+  updatedAt: String
+  authorizationModels(pageSize: Int, continuationToken: String): AuthorizationModelsPage
+  authorizationModel(id: ID!): AuthorizationModel
+  tuples(object: String, relation: String, user: String, pageSize: Int, continuationToken: String): TuplesPage
+}
+
+type AuthorizationModelsPage {
+  authorizationModels: [AuthorizationModel]
+  continuationToken: String
+}
+
+type AuthorizationModel {
+  id: ID
+  schemaVersion: String
+  types: [String]
+  "The authorization model in its JSON representation."
+  definition: String
+}
+
+type TuplesPage {
+  tuples: [Tuple]
+  continuationToken: String
+}
+
+type Tuple {
+  object: String
+  relation: String
+  user: String
+  timestamp: String
+}
+`
+
+var errCheckNotLoaded = errors.New("the Check wasn't loaded")
+
+var (
+	queryType                   = &objectType{name: "Query"}
+	storesPageType              = &objectType{name: "StoresPage"}
+	storeType                   = &objectType{name: "Store"}
+	authorizationModelsPageType = &objectType{name: "AuthorizationModelsPage"}
+	authorizationModelType      = &objectType{name: "AuthorizationModel"}
+	tuplesPageType              = &objectType{name: "TuplesPage"}
+	tupleType                   = &objectType{name: "Tuple"}
+)
+
+// The fields are set in init, since the resolvers refer to the types.
+func init() {
+	queryType.fields = map[string]fieldResolver{
+		"stores":      resolveStores,
+		"store":       resolveStore,
+		"check":       resolveCheck,
+		"listObjects": resolveListObjects,
+	}
+
+	storesPageType.fields = map[string]fieldResolver{
+		"stores": func(_ context.Context, _ *executor, source any, _ arguments) (any, error) {
+			stores := source.(*openfgav1.ListStoresResponse).GetStores()
+
+			items := make([]any, 0, len(stores))
+			for _, store := range stores {
+				items = append(items, &object{typ: storeType, source: store})
+			}
+
+			return items, nil
+		},
+		"continuationToken": func(_ context.Context, _ *executor, source any, _ arguments) (any, error) {
+			return source.(*openfgav1.ListStoresResponse).GetContinuationToken(), nil
+		},
+	}
+
+	storeType.fields = map[string]fieldResolver{
+		"id": func(_ context.Context, _ *executor, source any, _ arguments) (any, error) {
+			return source.(*openfgav1.Store).GetId(), nil
+		},
+		"name": func(_ context.Context, _ *executor, source any, _ arguments) (any, error) {
+			return source.(*openfgav1.Store).GetName(), nil
+		},
+		"createdAt": func(_ context.Context, _ *executor, source any, _ arguments) (any, error) {
+			return formatTimestamp(source.(*openfgav1.Store).GetCreatedAt()), nil
+		},
+		"updatedAt": func(_ context.Context, _ *executor, source any, _ arguments) (any, error) {
+			return formatTimestamp(source.(*openfgav1.Store).GetUpdatedAt()), nil
+		},
+		"authorizationModels": resolveAuthorizationModels,
+		"authorizationModel":  resolveAuthorizationModel,
+		"tuples":              resolveTuples,
+	}
+
+	authorizationModelsPageType.fields = map[string]fieldResolver{
+		"authorizationModels": func(_ context.Context, _ *executor, source any, _ arguments) (any, error) {
+			models := source.(*openfgav1.ReadAuthorizationModelsResponse).GetAuthorizationModels()
+
+			items := make([]any, 0, len(models))
+			for _, model := range models {
+				items = append(items, &object{typ: authorizationModelType, source: model})
+			}
+
+			return items, nil
+		},
+		"continuationToken": func(_ context.Context, _ *executor, source any, _ arguments) (any, error) {
+			return source.(*openfgav1.ReadAuthorizationModelsResponse).GetContinuationToken(), nil
+		},
+	}
+
+	authorizationModelType.fields = map[string]fieldResolver{
+		"id": func(_ context.Context, _ *executor, source any, _ arguments) (any, error) {
+			return source.(*openfgav1.AuthorizationModel).GetId(), nil
+		},
+		"schemaVersion": func(_ context.Context, _ *executor, source any, _ arguments) (any, error) {
+			return source.(*openfgav1.AuthorizationModel).GetSchemaVersion(), nil
+		},
+		"types": func(_ context.Context, _ *executor, source any, _ arguments) (any, error) {
+			typeDefinitions := source.(*openfgav1.AuthorizationModel).GetTypeDefinitions()
+
+			types := make([]any, 0, len(typeDefinitions))
+			for _, typeDefinition := range typeDefinitions {
+				types = append(types, typeDefinition.GetType())
+			}
+
+			return types, nil
+		},
+		"definition": func(_ context.Context, _ *executor, source any, _ arguments) (any, error) {
+			definition, err := protojson.Marshal(source.(*openfgav1.AuthorizationModel))
+			if err != nil {
+				return nil, err
+			}
+
+			return string(definition), nil
+		},
+	}
+
+	tuplesPageType.fields = map[string]fieldResolver{
+		"tuples": func(_ context.Context, _ *executor, source any, _ arguments) (any, error) {
+			tuples := source.(*openfgav1.ReadResponse).GetTuples()
+
+			items := make([]any, 0, len(tuples))
+			for _, t := range tuples {
+				items = append(items, &object{typ: tupleType, source: t})
+			}
+
+			return items, nil
+		},
+		"continuationToken": func(_ context.Context, _ *executor, source any, _ arguments) (any, error) {
+			return source.(*openfgav1.ReadResponse).GetContinuationToken(), nil
+		},
+	}
+
+	tupleType.fields = map[string]fieldResolver{
+		"object": func(_ context.Context, _ *executor, source any, _ arguments) (any, error) {
+			return source.(*openfgav1.Tuple).GetKey().GetObject(), nil
+		},
+		"relation": func(_ context.Context, _ *executor, source any, _ arguments) (any, error) {
+			return source.(*openfgav1.Tuple).GetKey().GetRelation(), nil
+		},
+		"user": func(_ context.Context, _ *executor, source any, _ arguments) (any, error) {
+			return source.(*openfgav1.Tuple).GetKey().GetUser(), nil
+		},
+		"timestamp": func(_ context.Context, _ *executor, source any, _ arguments) (any, error) {
+			return formatTimestamp(source.(*openfgav1.Tuple).GetTimestamp()), nil
+		},
+	}
+}
+
+func resolveStores(ctx context.Context, e *executor, _ any, args arguments) (any, error) {
+	pageSize, err := args.int32("pageSize")
+	if err != nil {
+		return nil, err
+	}
+
+	continuationToken, err := args.string("continuationToken")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := e.service.ListStores(ctx, &openfgav1.ListStoresRequest{
+		PageSize:          pageSizeValue(pageSize),
+		ContinuationToken: continuationToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &object{typ: storesPageType, source: resp}, nil
+}
+
+func resolveStore(ctx context.Context, e *executor, _ any, args arguments) (any, error) {
+	id, err := args.requiredString("id")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := e.service.GetStore(ctx, &openfgav1.GetStoreRequest{StoreId: id})
+	if err != nil {
+		return nil, err
+	}
+
+	return &object{typ: storeType, source: &openfgav1.Store{
+		Id:        resp.GetId(),
+		Name:      resp.GetName(),
+		CreatedAt: resp.GetCreatedAt(),
+		UpdatedAt: resp.GetUpdatedAt(),
+	}}, nil
+}
+
+func resolveCheck(_ context.Context, e *executor, _ any, args arguments) (any, error) {
+	key, err := checkKeyFromArguments(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.checks.load(key)
+}
+
+// checkKeyFromArguments returns the Check requested by the arguments of a check field.
+func checkKeyFromArguments(args arguments) (checkKey, error) {
+	var key checkKey
+	var err error
+
+	if key.storeID, err = args.requiredString("storeId"); err != nil {
+		return checkKey{}, err
+	}
+	if key.authorizationModelID, err = args.string("authorizationModelId"); err != nil {
+		return checkKey{}, err
+	}
+	if key.object, err = args.requiredString("object"); err != nil {
+		return checkKey{}, err
+	}
+	if key.relation, err = args.requiredString("relation"); err != nil {
+		return checkKey{}, err
+	}
+	if key.user, err = args.requiredString("user"); err != nil {
+		return checkKey{}, err
+	}
+
+	return key, nil
+}
+
+func resolveListObjects(ctx context.Context, e *executor, _ any, args arguments) (any, error) {
+	var req openfgav1.ListObjectsRequest
+	var err error
+
+	if req.StoreId, err = args.requiredString("storeId"); err != nil {
+		return nil, err
+	}
+	if req.AuthorizationModelId, err = args.string("authorizationModelId"); err != nil {
+		return nil, err
+	}
+	if req.Type, err = args.requiredString("type"); err != nil {
+		return nil, err
+	}
+	if req.Relation, err = args.requiredString("relation"); err != nil {
+		return nil, err
+	}
+	if req.User, err = args.requiredString("user"); err != nil {
+		return nil, err
+	}
+
+	resp, err := e.service.ListObjects(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]any, 0, len(resp.GetObjects()))
+	for _, obj := range resp.GetObjects() {
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+func resolveAuthorizationModels(ctx context.Context, e *executor, source any, args arguments) (any, error) {
+	pageSize, err := args.int32("pageSize")
+	if err != nil {
+		return nil, err
+	}
+
+	continuationToken, err := args.string("continuationToken")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := e.service.ReadAuthorizationModels(ctx, &openfgav1.ReadAuthorizationModelsRequest{
+		StoreId:           source.(*openfgav1.Store).GetId(),
+		PageSize:          pageSizeValue(pageSize),
+		ContinuationToken: continuationToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &object{typ: authorizationModelsPageType, source: resp}, nil
+}
+
+func resolveAuthorizationModel(ctx context.Context, e *executor, source any, args arguments) (any, error) {
+	id, err := args.requiredString("id")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := e.service.ReadAuthorizationModel(ctx, &openfgav1.ReadAuthorizationModelRequest{
+		StoreId: source.(*openfgav1.Store).GetId(),
+		Id:      id,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &object{typ: authorizationModelType, source: resp.GetAuthorizationModel()}, nil
+}
+
+func resolveTuples(ctx context.Context, e *executor, source any, args arguments) (any, error) {
+	objectFilter, err := args.string("object")
+	if err != nil {
+		return nil, err
+	}
+
+	relation, err := args.string("relation")
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := args.string("user")
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize, err := args.int32("pageSize")
+	if err != nil {
+		return nil, err
+	}
+
+	continuationToken, err := args.string("continuationToken")
+	if err != nil {
+		return nil, err
+	}
+
+	req := &openfgav1.ReadRequest{
+		StoreId:           source.(*openfgav1.Store).GetId(),
+		PageSize:          pageSizeValue(pageSize),
+		ContinuationToken: continuationToken,
+	}
+	if objectFilter != "" || relation != "" || user != "" {
+		req.TupleKey = &openfgav1.TupleKey{
+			Object:   objectFilter,
+			Relation: relation,
+			User:     user,
+		}
+	}
+
+	resp, err := e.service.Read(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &object{typ: tuplesPageType, source: resp}, nil
+}
+
+// pageSizeValue returns the page size of a request, which is the default one if unset.
+func pageSizeValue(pageSize int32) *wrapperspb.Int32Value {
+	if pageSize == 0 {
+		return nil
+	}
+
+	return wrapperspb.Int32(pageSize)
+}
+
+func formatTimestamp(ts *timestamppb.Timestamp) any {
+	if ts == nil {
+		return nil
+	}
+
+	return ts.AsTime().Format(time.RFC3339Nano)
+}
@@ -5,10 +5,18 @@ import (
 	"fmt"
 
 	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/requestcontext"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+// The fields of the requests (see RequestFields).
+const (
+	requestIDKey            = "request_id"
+	storeIDKey              = "store_id"
+	authorizationModelIDKey = "authorization_model_id"
+)
+
 type Logger interface {
 	// These are ops that call directly to the actual zap implementation
 	Debug(string, ...zap.Field)
@@ -25,6 +33,14 @@ type Logger interface {
 	ErrorWithContext(context.Context, string, ...zap.Field)
 	PanicWithContext(context.Context, string, ...zap.Field)
 	FatalWithContext(context.Context, string, ...zap.Field)
+
+	// Child returns a logger which adds the provided fields to every message, e.g. the RequestFields of a
+	// request, without changing this one.
+	Child(...zap.Field) Logger
+
+	// Enabled reports whether the messages of the level are logged, so that the fields of the messages that
+	// aren't can be skipped.
+	Enabled(zapcore.Level) bool
 }
 
 // ZapLogger is an implementation of Logger that uses the uber/zap logger underneath.
@@ -37,6 +53,14 @@ func (l *ZapLogger) With(fields ...zap.Field) {
 	l.Logger = l.Logger.With(fields...)
 }
 
+func (l *ZapLogger) Child(fields ...zap.Field) Logger {
+	return &ZapLogger{l.Logger.With(fields...)}
+}
+
+func (l *ZapLogger) Enabled(level zapcore.Level) bool {
+	return l.Logger.Core().Enabled(level)
+}
+
 func (l *ZapLogger) Debug(msg string, fields ...zap.Field) {
 	l.Logger.Debug(msg, fields...)
 }
@@ -61,28 +85,60 @@ func (l *ZapLogger) Fatal(msg string, fields ...zap.Field) {
 	l.Logger.Fatal(msg, fields...)
 }
 
+// RequestFields returns the fields of the request of the context (see requestcontext): its ID, its store and
+// its authorization model, if they're set.
+func RequestFields(ctx context.Context) []zap.Field {
+	var fields []zap.Field
+	if requestID, ok := requestcontext.RequestID(ctx); ok {
+		fields = append(fields, zap.String(requestIDKey, requestID))
+	}
+	if storeID, ok := requestcontext.StoreID(ctx); ok {
+		fields = append(fields, zap.String(storeIDKey, storeID))
+	}
+	if modelID, ok := requestcontext.AuthorizationModelID(ctx); ok {
+		fields = append(fields, zap.String(authorizationModelIDKey, modelID))
+	}
+
+	return fields
+}
+
+// logWithContext logs the message with the ID of the request of the context, if any. Nothing is allocated
+// if the level is disabled, or if the message is dropped by sampling.
+func (l *ZapLogger) logWithContext(ctx context.Context, level zapcore.Level, msg string, fields []zap.Field) {
+	ce := l.Logger.Check(level, msg)
+	if ce == nil {
+		return
+	}
+
+	if requestID, ok := requestcontext.RequestID(ctx); ok {
+		fields = append(fields, zap.String(requestIDKey, requestID))
+	}
+
+	ce.Write(fields...)
+}
+
 func (l *ZapLogger) DebugWithContext(ctx context.Context, msg string, fields ...zap.Field) {
-	l.Logger.Debug(msg, fields...)
+	l.logWithContext(ctx, zapcore.DebugLevel, msg, fields)
 }
 
 func (l *ZapLogger) InfoWithContext(ctx context.Context, msg string, fields ...zap.Field) {
-	l.Logger.Info(msg, fields...)
+	l.logWithContext(ctx, zapcore.InfoLevel, msg, fields)
 }
 
 func (l *ZapLogger) WarnWithContext(ctx context.Context, msg string, fields ...zap.Field) {
-	l.Logger.Warn(msg, fields...)
+	l.logWithContext(ctx, zapcore.WarnLevel, msg, fields)
 }
 
 func (l *ZapLogger) ErrorWithContext(ctx context.Context, msg string, fields ...zap.Field) {
-	l.Logger.Error(msg, fields...)
+	l.logWithContext(ctx, zapcore.ErrorLevel, msg, fields)
 }
 
 func (l *ZapLogger) PanicWithContext(ctx context.Context, msg string, fields ...zap.Field) {
-	l.Logger.Panic(msg, fields...)
+	l.logWithContext(ctx, zapcore.PanicLevel, msg, fields)
 }
 
 func (l *ZapLogger) FatalWithContext(ctx context.Context, msg string, fields ...zap.Field) {
-	l.Logger.Fatal(msg, fields...)
+	l.logWithContext(ctx, zapcore.FatalLevel, msg, fields)
 }
 
 // NewNoopLogger provides noop logger that satisfies the logger interface.
@@ -92,11 +148,16 @@ func NewNoopLogger() *ZapLogger {
 	}
 }
 
-func NewLogger(logFormat, logLevel string) (*ZapLogger, error) {
+func NewLogger(logFormat, logLevel string, opts ...Option) (*ZapLogger, error) {
 	if logLevel == "none" {
 		return NewNoopLogger(), nil
 	}
 
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	var level zapcore.Level
 	switch logLevel {
 	case "debug":
@@ -120,6 +181,7 @@ func NewLogger(logFormat, logLevel string) (*ZapLogger, error) {
 	cfg.EncoderConfig.TimeKey = "timestamp"
 	cfg.EncoderConfig.CallerKey = "" // remove the "caller" field
 	cfg.DisableStacktrace = true
+	cfg.Sampling = nil // see WithSampling
 
 	if logFormat == "text" {
 		cfg.Encoding = "console"
@@ -128,7 +190,9 @@ func NewLogger(logFormat, logLevel string) (*ZapLogger, error) {
 		cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 	}
 
-	log, err := cfg.Build()
+	log, err := cfg.Build(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return newLevelSampledCore(core, o)
+	}))
 	if err != nil {
 		return nil, err
 	}
@@ -140,8 +204,8 @@ func NewLogger(logFormat, logLevel string) (*ZapLogger, error) {
 	return &ZapLogger{log}, nil
 }
 
-func MustNewLogger(logFormat, logLevel string) *ZapLogger {
-	logger, err := NewLogger(logFormat, logLevel)
+func MustNewLogger(logFormat, logLevel string, opts ...Option) *ZapLogger {
+	logger, err := NewLogger(logFormat, logLevel, opts...)
 	if err != nil {
 		panic(err)
 	}
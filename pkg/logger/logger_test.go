@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/openfga/openfga/pkg/requestcontext"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -108,6 +109,23 @@ func TestWithContext(t *testing.T) {
 	}
 }
 
+func TestWithRequestContext(t *testing.T) {
+	observerLogger, logs := observer.New(zap.DebugLevel)
+	dut := ZapLogger{zap.New(observerLogger)}
+
+	ctx := requestcontext.NewContext(context.Background())
+	requestcontext.SetRequestID(ctx, "3e2d0c7e-9c43-4b2f-9d2e-1f3d7b1b5f0a")
+
+	dut.InfoWithContext(ctx, "ABC", zap.String("store_id", "01HCSBNPGRMSRYJRJRZ0KCZP1C"))
+	require.Equal(t, 1, logs.Len())
+
+	expectedZapFields := map[string]interface{}{
+		"store_id":   "01HCSBNPGRMSRYJRJRZ0KCZP1C",
+		"request_id": "3e2d0c7e-9c43-4b2f-9d2e-1f3d7b1b5f0a",
+	}
+	require.Equal(t, expectedZapFields, logs.All()[0].ContextMap())
+}
+
 func TestWithFields(t *testing.T) {
 	observerLogger, logs := observer.New(zap.DebugLevel)
 	logger := ZapLogger{zap.New(observerLogger)}
@@ -124,3 +142,77 @@ func TestWithFields(t *testing.T) {
 	}
 	require.Equal(t, expectedZapFields, actualMessage.ContextMap())
 }
+
+func TestChild(t *testing.T) {
+	observerLogger, logs := observer.New(zap.DebugLevel)
+	parent := &ZapLogger{zap.New(observerLogger)}
+
+	ctx := requestcontext.NewContext(context.Background())
+	requestcontext.SetRequestID(ctx, "3e2d0c7e-9c43-4b2f-9d2e-1f3d7b1b5f0a")
+	requestcontext.SetStoreID(ctx, "01HCSBNPGRMSRYJRJRZ0KCZP1C")
+
+	child := parent.Child(RequestFields(ctx)...)
+	child.Info("ABC")
+	parent.Info("DEF")
+
+	require.Equal(t, 2, logs.Len())
+	require.Equal(t, map[string]interface{}{
+		"request_id": "3e2d0c7e-9c43-4b2f-9d2e-1f3d7b1b5f0a",
+		"store_id":   "01HCSBNPGRMSRYJRJRZ0KCZP1C",
+	}, logs.All()[0].ContextMap())
+	require.Empty(t, logs.All()[1].ContextMap())
+}
+
+func TestSampling(t *testing.T) {
+	observerCore, logs := observer.New(zap.DebugLevel)
+	dut := &ZapLogger{zap.New(newLevelSampledCore(observerCore, &options{
+		samplingInitial:    2,
+		samplingThereafter: 5,
+		samplingMaxLevel:   zap.InfoLevel,
+	}))}
+
+	for i := 0; i < 10; i++ {
+		dut.DebugWithContext(context.Background(), "high volume")
+		dut.Child(zap.Int("i", i)).Warn("low volume")
+	}
+
+	require.Equal(t, 3, logs.FilterMessage("high volume").Len())
+	require.Equal(t, 10, logs.FilterMessage("low volume").Len())
+}
+
+func TestEnabled(t *testing.T) {
+	observerLogger, _ := observer.New(zap.InfoLevel)
+	dut := &ZapLogger{zap.New(observerLogger)}
+
+	require.False(t, dut.Enabled(zap.DebugLevel))
+	require.True(t, dut.Enabled(zap.InfoLevel))
+	require.True(t, dut.Child().Enabled(zap.ErrorLevel))
+}
+
+func TestDisabledLevelDoesNotAllocate(t *testing.T) {
+	observerLogger, _ := observer.New(zap.InfoLevel)
+	dut := &ZapLogger{zap.New(observerLogger)}
+
+	ctx := requestcontext.NewContext(context.Background())
+	requestcontext.SetRequestID(ctx, "3e2d0c7e-9c43-4b2f-9d2e-1f3d7b1b5f0a")
+
+	allocs := testing.AllocsPerRun(100, func() {
+		dut.DebugWithContext(ctx, "ABC")
+	})
+	require.Zero(t, allocs)
+}
+
+func BenchmarkDisabledDebugWithContext(b *testing.B) {
+	observerLogger, _ := observer.New(zap.InfoLevel)
+	dut := &ZapLogger{zap.New(observerLogger)}
+
+	ctx := requestcontext.NewContext(context.Background())
+	requestcontext.SetRequestID(ctx, "3e2d0c7e-9c43-4b2f-9d2e-1f3d7b1b5f0a")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		dut.DebugWithContext(ctx, "ABC")
+	}
+}
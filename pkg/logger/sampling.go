@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// samplingTick is the interval over which the messages are sampled.
+const samplingTick = time.Second
+
+// Option defines an option that can be used to change the behavior of the logger built by NewLogger.
+type Option func(o *options)
+
+type options struct {
+	samplingInitial    int
+	samplingThereafter int
+	samplingMaxLevel   zapcore.Level
+}
+
+// WithSampling samples the high-volume messages of the levels up to maxLevel (e.g. debug and info): every
+// second, the first initial messages with the same level and text are logged, then one in thereafter. The
+// messages of the higher levels are always logged. A thereafter of 0 disables sampling.
+func WithSampling(initial, thereafter int, maxLevel zapcore.Level) Option {
+	return func(o *options) {
+		o.samplingInitial = initial
+		o.samplingThereafter = thereafter
+		o.samplingMaxLevel = maxLevel
+	}
+}
+
+// levelSampledCore samples the messages of the levels up to a maximum level, and logs the other ones as is.
+type levelSampledCore struct {
+	zapcore.Core
+	sampled  zapcore.Core
+	maxLevel zapcore.Level
+}
+
+func newLevelSampledCore(core zapcore.Core, o *options) zapcore.Core {
+	if o.samplingThereafter <= 0 {
+		return core
+	}
+
+	return &levelSampledCore{
+		Core:     core,
+		sampled:  zapcore.NewSamplerWithOptions(core, samplingTick, o.samplingInitial, o.samplingThereafter),
+		maxLevel: o.samplingMaxLevel,
+	}
+}
+
+func (c *levelSampledCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelSampledCore{
+		Core:     c.Core.With(fields),
+		sampled:  c.sampled.With(fields),
+		maxLevel: c.maxLevel,
+	}
+}
+
+func (c *levelSampledCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if entry.Level <= c.maxLevel {
+		return c.sampled.Check(entry, ce)
+	}
+
+	return c.Core.Check(entry, ce)
+}
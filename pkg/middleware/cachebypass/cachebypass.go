@@ -0,0 +1,76 @@
+// Package cachebypass contains middleware that lets the requests bypass every cache of the server (the Check
+// caches, the TypeSystem cache and the authorization model cache), e.g. for support engineers to verify
+// that a result isn't stale.
+package cachebypass
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/openfga/openfga/pkg/requestcontext"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// NoCacheHeader is the request header that makes the request bypass the caches when it's 'true' (or any
+	// value strconv.ParseBool accepts as true).
+	NoCacheHeader = "openfga-no-cache"
+
+	// BypassedCachesHeader is the response header (or trailer, for the streaming methods) of the requests
+	// that bypassed the caches. It holds the comma-separated names of the caches that would have served
+	// the request, e.g. 'check,typesystem'. It's empty if none would have.
+	BypassedCachesHeader = "openfga-bypassed-caches"
+)
+
+// NewUnaryInterceptor creates a grpc.UnaryServerInterceptor which must come after the requestid
+// interceptor, since it records the bypass in the requestcontext metadata.
+func NewUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !requested(ctx) {
+			return handler(ctx, req)
+		}
+
+		requestcontext.SetCacheBypassed(ctx)
+
+		resp, err := handler(ctx, req)
+		_ = grpc.SetHeader(ctx, report(ctx))
+
+		return resp, err
+	}
+}
+
+// NewStreamingInterceptor creates a grpc.StreamServerInterceptor which must come after the requestid
+// interceptor, since it records the bypass in the requestcontext metadata.
+func NewStreamingInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := stream.Context()
+		if !requested(ctx) {
+			return handler(srv, stream)
+		}
+
+		requestcontext.SetCacheBypassed(ctx)
+
+		err := handler(srv, stream)
+		stream.SetTrailer(report(ctx))
+
+		return err
+	}
+}
+
+// requested reports whether the incoming metadata of the request asks to bypass the caches.
+func requested(ctx context.Context) bool {
+	values := metadata.ValueFromIncomingContext(ctx, NoCacheHeader)
+	if len(values) == 0 {
+		return false
+	}
+
+	noCache, err := strconv.ParseBool(values[0])
+	return err == nil && noCache
+}
+
+// report returns the metadata that reports the caches bypassed by the request.
+func report(ctx context.Context) metadata.MD {
+	return metadata.Pairs(BypassedCachesHeader, strings.Join(requestcontext.BypassedCaches(ctx), ","))
+}
@@ -0,0 +1,102 @@
+package cachebypass
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openfga/openfga/pkg/requestcontext"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type headerCapturingStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (s *headerCapturingStream) Method() string {
+	return "/openfga.v1.OpenFGAService/Check"
+}
+
+func (s *headerCapturingStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+type trailerCapturingServerStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	trailer metadata.MD
+}
+
+func (s *trailerCapturingServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *trailerCapturingServerStream) SetTrailer(md metadata.MD) {
+	s.trailer = metadata.Join(s.trailer, md)
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	tests := []struct {
+		name           string
+		header         string
+		bypassedCaches []string
+		expectedHeader []string
+	}{
+		{
+			name:           "bypassed_caches",
+			header:         "true",
+			bypassedCaches: []string{"typesystem", "check"},
+			expectedHeader: []string{"check,typesystem"},
+		},
+		{
+			name:           "no_bypassed_cache",
+			header:         "1",
+			expectedHeader: []string{""},
+		},
+		{
+			name:   "not_requested",
+			header: "false",
+		},
+		{
+			name:   "invalid_header",
+			header: "yes please",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stream := &headerCapturingStream{}
+			ctx := requestcontext.NewContext(context.Background())
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(NoCacheHeader, test.header))
+			ctx = grpc.NewContextWithServerTransportStream(ctx, stream)
+
+			expectedBypass := test.expectedHeader != nil
+			_, err := NewUnaryInterceptor()(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				require.Equal(t, expectedBypass, requestcontext.CacheBypassed(ctx))
+				for _, cache := range test.bypassedCaches {
+					requestcontext.AddBypassedCache(ctx, cache)
+				}
+				return nil, nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.expectedHeader, stream.header.Get(BypassedCachesHeader))
+		})
+	}
+}
+
+func TestStreamingInterceptor(t *testing.T) {
+	ctx := requestcontext.NewContext(context.Background())
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(NoCacheHeader, "true"))
+	stream := &trailerCapturingServerStream{ctx: ctx}
+
+	err := NewStreamingInterceptor()(nil, stream, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+		require.True(t, requestcontext.CacheBypassed(stream.Context()))
+		requestcontext.AddBypassedCache(stream.Context(), "authorization_models")
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"authorization_models"}, stream.trailer.Get(BypassedCachesHeader))
+}
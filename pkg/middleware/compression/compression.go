@@ -0,0 +1,68 @@
+// Package compression contains middleware that compresses the responses of the streaming methods (e.g.
+// StreamedListObjects), which move large volumes of repetitive tuple data, whatever the compression of the
+// requests.
+package compression
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+// Gzip is the name of the gzip compressor, which is always registered.
+const Gzip = gzip.Name
+
+// ErrUnknownCompressor is returned when a compressor isn't registered.
+var ErrUnknownCompressor = errors.New("unknown compressor")
+
+// Validate returns an error if no compressor is registered with the name. Besides gzip, any compressor
+// registered with encoding.RegisterCompressor can be used, e.g. by importing a zstd encoding plugin in
+// the binary that runs the server.
+func Validate(compressor string) error {
+	if encoding.GetCompressor(compressor) == nil {
+		return fmt.Errorf("%w '%s'", ErrUnknownCompressor, compressor)
+	}
+
+	return nil
+}
+
+// SetGzipLevel sets the level of the gzip compression, between gzip.HuffmanOnly and gzip.BestCompression
+// (see compress/gzip). It must be called before the server starts.
+func SetGzipLevel(level int) error {
+	return gzip.SetLevel(level)
+}
+
+// NewStreamingInterceptor creates a grpc.StreamServerInterceptor that compresses the responses of the
+// streaming methods with the compressor, if the client supports it (i.e. advertises it in its
+// 'grpc-accept-encoding' header). The responses to the other clients are sent as they would be otherwise.
+func NewStreamingInterceptor(compressor string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if supported(stream.Context(), compressor) {
+			// it must be set before the first response is sent, and it only fails if the stream isn't a
+			// grpc one, in which case the responses aren't compressed
+			_ = grpc.SetSendCompressor(stream.Context(), compressor)
+		}
+
+		return handler(srv, stream)
+	}
+}
+
+// supported reports whether the client of the stream advertises the compressor.
+func supported(ctx context.Context, compressor string) bool {
+	compressors, err := grpc.ClientSupportedCompressors(ctx)
+	if err != nil {
+		return false
+	}
+
+	for _, c := range compressors {
+		if c == compressor {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,79 @@
+package compression
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/health"
+	healthv1pb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// compressionRecorder records the compression of the responses the client receives.
+type compressionRecorder struct {
+	compression string
+}
+
+func (r *compressionRecorder) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (r *compressionRecorder) HandleRPC(_ context.Context, s stats.RPCStats) {
+	if header, ok := s.(*stats.InHeader); ok {
+		r.compression = header.Compression
+	}
+}
+
+func (r *compressionRecorder) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (r *compressionRecorder) HandleConn(context.Context, stats.ConnStats) {}
+
+func TestStreamingInterceptor(t *testing.T) {
+	listener := bufconn.Listen(1024 * 1024)
+
+	srv := grpc.NewServer(grpc.ChainStreamInterceptor(NewStreamingInterceptor(Gzip)))
+	healthv1pb.RegisterHealthServer(srv, health.NewServer())
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+	t.Cleanup(srv.Stop)
+
+	recorder := &compressionRecorder{}
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(recorder),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	// the client advertises every compressor registered in its process, so gzip here
+	stream, err := healthv1pb.NewHealthClient(conn).Watch(context.Background(), &healthv1pb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, healthv1pb.HealthCheckResponse_SERVING, resp.GetStatus())
+	require.Equal(t, gzip.Name, recorder.compression)
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(Gzip))
+	require.ErrorIs(t, Validate("zstd"), ErrUnknownCompressor)
+}
+
+func TestSetGzipLevel(t *testing.T) {
+	require.Error(t, SetGzipLevel(10))
+}
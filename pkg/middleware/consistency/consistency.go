@@ -0,0 +1,66 @@
+// Package consistency contains middleware that lets the requests choose the consistency of their reads:
+// MINIMIZE_LATENCY (the default) lets the server serve them from its caches and from the read replicas of the
+// datastore, which may be stale, while HIGHER_CONSISTENCY makes it read from the primary datastore, e.g. for
+// the security critical Checks which mustn't miss a recent write. It's honored by Check, Read, Expand and
+// ListObjects.
+package consistency
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openfga/openfga/pkg/requestcontext"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// ConsistencyHeader is the request header that holds the consistency the reads of the request require,
+// either 'MINIMIZE_LATENCY' or 'HIGHER_CONSISTENCY' (case insensitive).
+const ConsistencyHeader = "openfga-consistency"
+
+// NewUnaryInterceptor creates a grpc.UnaryServerInterceptor which must come after the requestid
+// interceptor, since it records the consistency in the requestcontext metadata. The requests with an unknown
+// consistency are rejected.
+func NewUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := record(ctx); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// NewStreamingInterceptor creates a grpc.StreamServerInterceptor which must come after the requestid
+// interceptor, since it records the consistency in the requestcontext metadata. The requests with an unknown
+// consistency are rejected.
+func NewStreamingInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := record(stream.Context()); err != nil {
+			return err
+		}
+
+		return handler(srv, stream)
+	}
+}
+
+// record records the consistency of the incoming metadata of the request, if any, in the requestcontext
+// metadata.
+func record(ctx context.Context) error {
+	values := metadata.ValueFromIncomingContext(ctx, ConsistencyHeader)
+	if len(values) == 0 {
+		return nil
+	}
+
+	c, err := storage.ParseConsistencyPreference(values[0])
+	if err != nil || c == storage.ConsistencyPreferenceUnspecified {
+		return serverErrors.ValidationError(fmt.Errorf("invalid '%s' header '%s': it must be either '%s' or '%s'",
+			ConsistencyHeader, values[0], storage.ConsistencyPreferenceMinimizeLatency, storage.ConsistencyPreferenceHigherConsistency))
+	}
+
+	requestcontext.SetConsistency(ctx, c.String())
+
+	return nil
+}
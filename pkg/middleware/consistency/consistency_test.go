@@ -0,0 +1,99 @@
+package consistency
+
+import (
+	"context"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/requestcontext"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	tests := map[string]struct {
+		header   []string
+		expected storage.ConsistencyPreference
+		invalid  bool
+	}{
+		"not_requested": {
+			expected: storage.ConsistencyPreferenceUnspecified,
+		},
+		"minimize_latency": {
+			header:   []string{"MINIMIZE_LATENCY"},
+			expected: storage.ConsistencyPreferenceMinimizeLatency,
+		},
+		"higher_consistency": {
+			header:   []string{"higher_consistency"},
+			expected: storage.ConsistencyPreferenceHigherConsistency,
+		},
+		"unspecified": {
+			header:  []string{"UNSPECIFIED"},
+			invalid: true,
+		},
+		"unknown": {
+			header:  []string{"strong"},
+			invalid: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := requestcontext.NewContext(context.Background())
+			if test.header != nil {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(ConsistencyHeader, test.header[0]))
+			}
+
+			var called bool
+			_, err := NewUnaryInterceptor()(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				require.Equal(t, test.expected, storage.ConsistencyFromContext(ctx))
+				require.Equal(t, storage.ReadOptions{Consistency: test.expected}, storage.ReadOptionsFromContext(ctx))
+				return nil, nil
+			})
+
+			if test.invalid {
+				require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+				require.False(t, called)
+				return
+			}
+
+			require.NoError(t, err)
+			require.True(t, called)
+		})
+	}
+}
+
+func TestStreamingInterceptor(t *testing.T) {
+	ctx := requestcontext.NewContext(context.Background())
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(ConsistencyHeader, "HIGHER_CONSISTENCY"))
+
+	var called bool
+	err := NewStreamingInterceptor()(nil, &contextServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+		called = true
+		require.Equal(t, storage.ConsistencyPreferenceHigherConsistency, storage.ConsistencyFromContext(stream.Context()))
+		return nil
+	})
+	require.NoError(t, err)
+	require.True(t, called)
+
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(ConsistencyHeader, "eventual"))
+	err = NewStreamingInterceptor()(nil, &contextServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+		t.Fatal("the handler mustn't be called")
+		return nil
+	})
+	require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+}
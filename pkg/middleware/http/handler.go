@@ -42,6 +42,48 @@ func HTTPResponseModifier(ctx context.Context, w http.ResponseWriter, p proto.Me
 	return nil
 }
 
+// traceContextHeaders are the W3C Trace Context and Baggage headers.
+var traceContextHeaders = map[string]struct{}{
+	"traceparent": {},
+	"tracestate":  {},
+	"baggage":     {},
+}
+
+// IncomingHeaderMatcher forwards the W3C Trace Context and Baggage headers of the HTTP requests as is to the
+// gRPC server, so that the requests continue the trace of their callers. The other headers are forwarded by
+// runtime.DefaultHeaderMatcher.
+func IncomingHeaderMatcher(key string) (string, bool) {
+	lowerKey := strings.ToLower(key)
+	if _, ok := traceContextHeaders[lowerKey]; ok {
+		return lowerKey, true
+	}
+
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// NewIncomingHeaderMatcher returns an IncomingHeaderMatcher that also forwards the provided headers of the
+// HTTP requests as is (e.g. 'X-Tenant-ID' as the 'x-tenant-id' metadata) to the gRPC server, so that the
+// interceptors and the plugins can read them. The headers are matched case-insensitively.
+func NewIncomingHeaderMatcher(forwardedHeaders ...string) runtime.HeaderMatcherFunc {
+	if len(forwardedHeaders) == 0 {
+		return IncomingHeaderMatcher
+	}
+
+	forwarded := make(map[string]struct{}, len(forwardedHeaders))
+	for _, header := range forwardedHeaders {
+		forwarded[strings.ToLower(header)] = struct{}{}
+	}
+
+	return func(key string) (string, bool) {
+		lowerKey := strings.ToLower(key)
+		if _, ok := forwarded[lowerKey]; ok {
+			return lowerKey, true
+		}
+
+		return IncomingHeaderMatcher(key)
+	}
+}
+
 func requestAcceptsTrailers(req *http.Request) bool {
 	te := req.Header.Get("TE")
 	return strings.Contains(strings.ToLower(te), "trailers")
@@ -72,3 +72,50 @@ func TestCustomHTTPErrorHandlerSpecialEncoding(t *testing.T) {
 	expectedData := "{\"code\":\"assertions_too_many_items\",\"message\":\"invalid character '<' looking for beginning of value,\"}"
 	require.Equal(t, expectedData, strings.TrimSpace(string(data)))
 }
+
+func TestIncomingHeaderMatcher(t *testing.T) {
+	tests := []struct {
+		header      string
+		expectedKey string
+		expectedOk  bool
+	}{
+		{header: "Traceparent", expectedKey: "traceparent", expectedOk: true},
+		{header: "Tracestate", expectedKey: "tracestate", expectedOk: true},
+		{header: "Baggage", expectedKey: "baggage", expectedOk: true},
+		{header: "Authorization", expectedKey: "grpcgateway-Authorization", expectedOk: true},
+		{header: "User-Agent", expectedKey: "grpcgateway-User-Agent", expectedOk: true},
+		{header: "X-Custom", expectedKey: "", expectedOk: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.header, func(t *testing.T) {
+			key, ok := IncomingHeaderMatcher(test.header)
+			require.Equal(t, test.expectedOk, ok)
+			require.Equal(t, test.expectedKey, key)
+		})
+	}
+}
+
+func TestNewIncomingHeaderMatcher(t *testing.T) {
+	matcher := NewIncomingHeaderMatcher("X-Tenant-ID", "x-consistency-token")
+
+	tests := []struct {
+		header      string
+		expectedKey string
+		expectedOk  bool
+	}{
+		{header: "X-Tenant-Id", expectedKey: "x-tenant-id", expectedOk: true},
+		{header: "X-Consistency-Token", expectedKey: "x-consistency-token", expectedOk: true},
+		{header: "Traceparent", expectedKey: "traceparent", expectedOk: true},
+		{header: "Authorization", expectedKey: "grpcgateway-Authorization", expectedOk: true},
+		{header: "X-Custom", expectedKey: "", expectedOk: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.header, func(t *testing.T) {
+			key, ok := matcher(test.header)
+			require.Equal(t, test.expectedOk, ok)
+			require.Equal(t, test.expectedKey, key)
+		})
+	}
+}
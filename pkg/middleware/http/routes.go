@@ -0,0 +1,76 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+)
+
+// RouteMiddleware is an HTTP middleware that wraps the requests to the routes of the HTTP gateway matching
+// a method and a path pattern, before they reach the gateway, e.g. to require extra authentication to
+// create stores, or to set caching headers on the reads.
+type RouteMiddleware struct {
+	// Method is the HTTP method of the matched requests (e.g. 'POST'). An empty method matches every method.
+	Method string
+
+	// Pattern is the path pattern of the matched requests, as the routes of the API are documented, e.g.
+	// '/stores' or '/stores/{store_id}/check'. A '{param}' or '*' segment matches any single segment, and a
+	// trailing '**' segment matches any number of remaining segments.
+	Pattern string
+
+	// Middleware wraps the handler of the matched requests.
+	Middleware func(http.Handler) http.Handler
+}
+
+// matches reports whether the route matches the request.
+func (r RouteMiddleware) matches(req *http.Request) bool {
+	if r.Method != "" && !strings.EqualFold(r.Method, req.Method) {
+		return false
+	}
+
+	return matchPath(r.Pattern, req.URL.Path)
+}
+
+// matchPath reports whether the path matches the pattern, segment by segment.
+func matchPath(pattern, path string) bool {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, segment := range patternSegments {
+		if segment == "**" && i == len(patternSegments)-1 {
+			return true
+		}
+		if i >= len(pathSegments) {
+			return false
+		}
+		if segment == "*" || (strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")) {
+			if pathSegments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != pathSegments[i] {
+			return false
+		}
+	}
+
+	return len(patternSegments) == len(pathSegments)
+}
+
+// WithRouteMiddlewares returns a handler that passes every request through the middlewares of the routes it
+// matches, in the order they are provided (the first one is the outermost), and then to the handler. The
+// middlewares are applied once, so they can keep state across the requests.
+func WithRouteMiddlewares(handler http.Handler, routes ...RouteMiddleware) http.Handler {
+	for i := len(routes) - 1; i >= 0; i-- {
+		route, next := routes[i], handler
+		wrapped := route.Middleware(next)
+		handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if route.matches(req) {
+				wrapped.ServeHTTP(w, req)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+
+	return handler
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{pattern: "/stores", path: "/stores", match: true},
+		{pattern: "/stores", path: "/stores/", match: true},
+		{pattern: "/stores", path: "/stores/01H", match: false},
+		{pattern: "/stores/{store_id}/check", path: "/stores/01H/check", match: true},
+		{pattern: "/stores/*/check", path: "/stores/01H/check", match: true},
+		{pattern: "/stores/{store_id}/check", path: "/stores//check", match: false},
+		{pattern: "/stores/{store_id}/check", path: "/stores/01H/expand", match: false},
+		{pattern: "/stores/{store_id}/**", path: "/stores/01H/authorization-models/01J", match: true},
+		{pattern: "/stores/{store_id}/**", path: "/stores/01H", match: true},
+		{pattern: "/stores/{store_id}/**", path: "/stores", match: false},
+		{pattern: "/**", path: "/healthz", match: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern+" "+test.path, func(t *testing.T) {
+			require.Equal(t, test.match, matchPath(test.pattern, test.path))
+		})
+	}
+}
+
+func TestWithRouteMiddlewares(t *testing.T) {
+	header := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Middleware", name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	denied := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		})
+	}
+
+	handler := WithRouteMiddlewares(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+		RouteMiddleware{Method: http.MethodPost, Pattern: "/stores", Middleware: denied},
+		RouteMiddleware{Method: http.MethodGet, Pattern: "/stores/{store_id}/**", Middleware: header("read")},
+		RouteMiddleware{Pattern: "/stores/**", Middleware: header("any")},
+	)
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		status      int
+		middlewares []string
+	}{
+		{
+			name:   "create_store_denied",
+			method: http.MethodPost,
+			path:   "/stores",
+			status: http.StatusForbidden,
+		},
+		{
+			name:        "list_stores",
+			method:      http.MethodGet,
+			path:        "/stores",
+			status:      http.StatusOK,
+			middlewares: []string{"any"},
+		},
+		{
+			name:        "read_in_order",
+			method:      http.MethodGet,
+			path:        "/stores/01H/authorization-models",
+			status:      http.StatusOK,
+			middlewares: []string{"read", "any"},
+		},
+		{
+			name:        "write",
+			method:      http.MethodPost,
+			path:        "/stores/01H/write",
+			status:      http.StatusOK,
+			middlewares: []string{"any"},
+		},
+		{
+			name:   "unmatched",
+			method: http.MethodGet,
+			path:   "/healthz",
+			status: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+
+			require.Equal(t, test.status, w.Code)
+			require.Equal(t, test.middlewares, w.Header().Values("X-Middleware"))
+		})
+	}
+}
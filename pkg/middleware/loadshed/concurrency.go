@@ -0,0 +1,114 @@
+package loadshed
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	defaultConcurrencyLimitRetryAfter = time.Second
+
+	concurrencyLimitMessage = "the server is serving too many requests concurrently"
+
+	healthServicePrefix = "/grpc.health.v1.Health/"
+)
+
+// ConcurrencyLimiter limits the number of requests served concurrently, and rejects the other ones with a
+// RESOURCE_EXHAUSTED error and a hint to retry them later.
+type ConcurrencyLimiter struct {
+	slots      chan struct{}
+	retryAfter time.Duration
+}
+
+// ConcurrencyLimiterOpt defines an option that can be used to change the behavior of a ConcurrencyLimiter.
+type ConcurrencyLimiterOpt func(*ConcurrencyLimiter)
+
+// WithRetryAfter sets the retry-after returned with the rejected requests. It defaults to a second.
+func WithRetryAfter(retryAfter time.Duration) ConcurrencyLimiterOpt {
+	return func(l *ConcurrencyLimiter) {
+		l.retryAfter = retryAfter
+	}
+}
+
+// NewConcurrencyLimiter returns a ConcurrencyLimiter that serves up to limit requests concurrently.
+func NewConcurrencyLimiter(limit uint32, opts ...ConcurrencyLimiterOpt) *ConcurrencyLimiter {
+	l := &ConcurrencyLimiter{
+		slots:      make(chan struct{}, limit),
+		retryAfter: defaultConcurrencyLimitRetryAfter,
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// TryAcquire reserves a slot for a request served outside of the gRPC server (e.g. by an HTTP handler), if one
+// is free, and otherwise returns the hint to reject the request with (see RejectHTTP). The slot must be released
+// once the request has been served.
+func (l *ConcurrencyLimiter) TryAcquire() (release func(), hint Hint, ok bool) {
+	select {
+	case l.slots <- struct{}{}:
+		return func() { <-l.slots }, Hint{}, true
+	default:
+		return nil, Hint{Reason: ReasonConcurrencyLimit, RetryAfter: l.retryAfter}, false
+	}
+}
+
+// acquire reserves a slot for a request, if one is free. The slot must be released once the request has
+// been served.
+func (l *ConcurrencyLimiter) acquire(ctx context.Context) (func(), error) {
+	release, hint, ok := l.TryAcquire()
+	if !ok {
+		return nil, Reject(ctx, codes.ResourceExhausted, concurrencyLimitMessage, hint)
+	}
+
+	return release, nil
+}
+
+// exempt reports whether the method is always served, e.g. the health checks.
+func exempt(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, healthServicePrefix)
+}
+
+// NewUnaryInterceptor creates a grpc.UnaryServerInterceptor which rejects the requests beyond the limit of
+// the limiter. The health checks are always served.
+func NewUnaryInterceptor(limiter *ConcurrencyLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if exempt(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		release, err := limiter.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+
+		return handler(ctx, req)
+	}
+}
+
+// NewStreamingInterceptor creates a grpc.StreamServerInterceptor which rejects the requests beyond the limit
+// of the limiter. A streaming request holds its slot until the stream ends. The health checks are always
+// served.
+func NewStreamingInterceptor(limiter *ConcurrencyLimiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if exempt(info.FullMethod) {
+			return handler(srv, stream)
+		}
+
+		release, err := limiter.acquire(stream.Context())
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		return handler(srv, stream)
+	}
+}
@@ -0,0 +1,59 @@
+package loadshed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	limiter := NewConcurrencyLimiter(1, WithRetryAfter(5*time.Second))
+	interceptor := NewUnaryInterceptor(limiter)
+	info := &grpc.UnaryServerInfo{FullMethod: "/openfga.v1.OpenFGAService/Check"}
+
+	started := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		_, _ = interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			close(started)
+			<-done
+			return nil, nil
+		})
+	}()
+	<-started
+
+	stream := &metadataCapturingStream{}
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return req, nil
+	}
+
+	_, err := interceptor(ctx, nil, info, handler)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Equal(t, []string{"5"}, stream.header.Get(RetryAfterHeader))
+	require.Equal(t, []string{"concurrency_limit"}, stream.header.Get(LoadHintHeader))
+
+	t.Run("requests_outside_of_the_server_share_the_limit", func(t *testing.T) {
+		_, hint, ok := limiter.TryAcquire()
+		require.False(t, ok)
+		require.Equal(t, Hint{Reason: ReasonConcurrencyLimit, RetryAfter: 5 * time.Second}, hint)
+	})
+
+	t.Run("health_checks_are_always_served", func(t *testing.T) {
+		_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+		require.NoError(t, err)
+	})
+
+	close(done)
+
+	require.Eventually(t, func() bool {
+		_, err := interceptor(context.Background(), nil, info, handler)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,177 @@
+package loadshed
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	// ReasonConnectionLimit means that a listener has as many open connections as it's allowed to.
+	ReasonConnectionLimit Reason = "connection_limit"
+
+	// ReasonAcceptRateLimit means that the rate of the connections accepted by a listener exceeds a limit.
+	ReasonAcceptRateLimit Reason = "accept_rate_limit"
+)
+
+var (
+	shedConnectionsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "shed_connections_total",
+		Help: "The total number of connections closed as soon as they were accepted to shed load, labeled by listener (e.g. 'grpc') and by reason (e.g. 'connection_limit').",
+	}, []string{"listener", "reason"})
+
+	openConnectionsGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "open_connections",
+		Help: "The number of open connections of the listeners that shed connections, labeled by listener (e.g. 'grpc').",
+	}, []string{"listener"})
+)
+
+// Listener is a net.Listener that closes the connections it accepts beyond a number of open connections or
+// beyond an accept rate as soon as they're accepted, before any TLS handshake or read, so that a flood of
+// connections doesn't exhaust the server. Closing them rather than leaving them in the backlog (which is the
+// one of the system, e.g. net.core.somaxconn) also keeps the backlog from filling up.
+type Listener struct {
+	net.Listener
+
+	name           string
+	maxConnections int
+	limiter        *acceptRateLimiter
+
+	mu          sync.Mutex
+	connections int
+}
+
+// ListenerOpt defines an option that can be used to change the behavior of a Listener.
+type ListenerOpt func(*Listener)
+
+// WithMaxConnections sets the maximum number of open connections. If 0, it's unbounded.
+func WithMaxConnections(maxConnections int) ListenerOpt {
+	return func(l *Listener) {
+		l.maxConnections = maxConnections
+	}
+}
+
+// WithAcceptRate sets the maximum rate (per second) of the accepted connections, which can be exceeded by
+// bursts of up to burst connections. If the rate is 0, it's unbounded.
+func WithAcceptRate(rate float64, burst int) ListenerOpt {
+	return func(l *Listener) {
+		l.limiter = nil
+		if rate > 0 {
+			l.limiter = newAcceptRateLimiter(rate, burst, time.Now)
+		}
+	}
+}
+
+// NewListener wraps the listener with a Listener, whose name labels its metrics (e.g. 'grpc'). By default,
+// it doesn't shed any connection.
+func NewListener(inner net.Listener, name string, opts ...ListenerOpt) *Listener {
+	l := &Listener{
+		Listener: inner,
+		name:     name,
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// Accept waits for and returns the next connection that isn't shed.
+func (l *Listener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		if reason, ok := l.admit(); !ok {
+			shedConnectionsCounter.WithLabelValues(l.name, string(reason)).Inc()
+			_ = conn.Close()
+			continue
+		}
+
+		openConnectionsGauge.WithLabelValues(l.name).Inc()
+		return &listenerConn{Conn: conn, listener: l}, nil
+	}
+}
+
+// admit reports whether a new connection can be served, and the reason it can't otherwise.
+func (l *Listener) admit() (Reason, bool) {
+	if l.limiter != nil && !l.limiter.allow() {
+		return ReasonAcceptRateLimit, false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxConnections > 0 && l.connections >= l.maxConnections {
+		return ReasonConnectionLimit, false
+	}
+
+	l.connections++
+	return "", true
+}
+
+func (l *Listener) release() {
+	l.mu.Lock()
+	l.connections--
+	l.mu.Unlock()
+
+	openConnectionsGauge.WithLabelValues(l.name).Dec()
+}
+
+// listenerConn releases its slot of the Listener once it's closed.
+type listenerConn struct {
+	net.Conn
+
+	listener *Listener
+	once     sync.Once
+}
+
+func (c *listenerConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.listener.release)
+	return err
+}
+
+// acceptRateLimiter is a token bucket, which holds up to burst tokens and is refilled at rate tokens per
+// second.
+type acceptRateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newAcceptRateLimiter(rate float64, burst int, now func() time.Time) *acceptRateLimiter {
+	return &acceptRateLimiter{
+		rate:   rate,
+		burst:  float64(max(burst, 1)),
+		tokens: float64(max(burst, 1)),
+		last:   now(),
+		now:    now,
+	}
+}
+
+// allow takes a token from the bucket, if there's one.
+func (l *acceptRateLimiter) allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+
+	l.tokens--
+	return true
+}
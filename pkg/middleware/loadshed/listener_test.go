@@ -0,0 +1,119 @@
+package loadshed
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// acceptAll accepts the connections of the listener until it's closed, and sends them on the channel.
+func acceptAll(l net.Listener) <-chan net.Conn {
+	conns := make(chan net.Conn)
+	go func() {
+		defer close(conns)
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+		}
+	}()
+
+	return conns
+}
+
+// requireShed requires the connection to be closed by the server.
+func requireShed(t *testing.T, conn net.Conn) {
+	t.Helper()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, err := conn.Read(make([]byte, 1))
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestListenerMaxConnections(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	shed := shedConnectionsCounter.WithLabelValues("max_connections_test", string(ReasonConnectionLimit))
+	shedBefore := testutil.ToFloat64(shed)
+
+	l := NewListener(inner, "max_connections_test", WithMaxConnections(1))
+	defer l.Close()
+	conns := acceptAll(l)
+
+	first, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer first.Close()
+	served := <-conns
+
+	second, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer second.Close()
+	requireShed(t, second)
+	require.InDelta(t, shedBefore+1, testutil.ToFloat64(shed), 0)
+	require.InDelta(t, 1, testutil.ToFloat64(openConnectionsGauge.WithLabelValues("max_connections_test")), 0)
+
+	// closing the served connection frees its slot, even when it's closed twice
+	require.NoError(t, served.Close())
+	_ = served.Close()
+	require.InDelta(t, 0, testutil.ToFloat64(openConnectionsGauge.WithLabelValues("max_connections_test")), 0)
+
+	third, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer third.Close()
+	served = <-conns
+	defer served.Close()
+}
+
+func TestAcceptRateLimiter(t *testing.T) {
+	now := time.Now()
+	limiter := newAcceptRateLimiter(2, 3, func() time.Time { return now })
+
+	// the burst
+	for i := 0; i < 3; i++ {
+		require.True(t, limiter.allow())
+	}
+	require.False(t, limiter.allow())
+
+	// refilled at 2 tokens per second
+	now = now.Add(500 * time.Millisecond)
+	require.True(t, limiter.allow())
+	require.False(t, limiter.allow())
+
+	// up to the burst
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		require.True(t, limiter.allow())
+	}
+	require.False(t, limiter.allow())
+}
+
+func TestListenerAcceptRate(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	shed := shedConnectionsCounter.WithLabelValues("accept_rate_test", string(ReasonAcceptRateLimit))
+	shedBefore := testutil.ToFloat64(shed)
+
+	l := NewListener(inner, "accept_rate_test", WithAcceptRate(0.001, 1))
+	defer l.Close()
+	conns := acceptAll(l)
+
+	first, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer first.Close()
+	served := <-conns
+	defer served.Close()
+
+	second, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer second.Close()
+	requireShed(t, second)
+	require.InDelta(t, shedBefore+1, testutil.ToFloat64(shed), 0)
+}
@@ -0,0 +1,111 @@
+// Package loadshed contains the hints returned with the requests that the server rejects to shed load (e.g.
+// because of a limiter or of maintenance), so that the clients can back off adaptively, and the limiters
+// that shed load. Its Listener sheds the connections themselves, before any request is read.
+//
+// Every rejected request carries, both as response headers and as trailers:
+//
+//	retry-after         the number of seconds after which the request may be retried
+//	openfga-load-hint   the reason the request was rejected (e.g. 'concurrency_limit')
+//
+// The gRPC status of the rejection also carries the delay as a google.rpc.RetryInfo detail.
+package loadshed
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	// RetryAfterHeader is the response header (and trailer) that holds the number of seconds after which the
+	// rejected requests may be retried.
+	RetryAfterHeader = "retry-after"
+
+	// LoadHintHeader is the response header (and trailer) that holds the Reason the request was rejected.
+	LoadHintHeader = "openfga-load-hint"
+)
+
+// Reason is the reason a request was rejected to shed load.
+type Reason string
+
+const (
+	// ReasonMaintenance means that the store, or the whole server, is undergoing maintenance.
+	ReasonMaintenance Reason = "maintenance"
+
+	// ReasonRateLimit means that the rate of the requests exceeds a limit.
+	ReasonRateLimit Reason = "rate_limit"
+
+	// ReasonConcurrencyLimit means that the server is serving as many requests as it's allowed to.
+	ReasonConcurrencyLimit Reason = "concurrency_limit"
+
+	// ReasonDispatchThrottle means that the request required more dispatches than the server could afford.
+	ReasonDispatchThrottle Reason = "dispatch_throttle"
+
+	// ReasonStoreUnavailable means that the datastore operations of the store are failed fast because they
+	// failed too often.
+	ReasonStoreUnavailable Reason = "store_unavailable"
+)
+
+var shedRequestsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "shed_requests_total",
+	Help: "The total number of requests rejected to shed load, labeled by reason (e.g. 'concurrency_limit').",
+}, []string{"reason"})
+
+// Hint tells the clients why their request was rejected and when to retry it.
+type Hint struct {
+	Reason     Reason
+	RetryAfter time.Duration
+}
+
+// retryAfterSeconds returns the retry-after of the hint in seconds, rounded up, and at least 1.
+func (h Hint) retryAfterSeconds() int64 {
+	seconds := int64((h.RetryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	return seconds
+}
+
+// Reject returns the error of a request rejected to shed load, with the provided code (usually
+// ResourceExhausted, or Unavailable if the server can't serve the request at all), and sets the headers and
+// the trailers of the hint on the response.
+func Reject(ctx context.Context, code codes.Code, message string, hint Hint) error {
+	shedRequestsCounter.WithLabelValues(string(hint.Reason)).Inc()
+
+	seconds := hint.retryAfterSeconds()
+	md := metadata.Pairs(
+		RetryAfterHeader, strconv.FormatInt(seconds, 10),
+		LoadHintHeader, string(hint.Reason),
+	)
+	_ = grpc.SetHeader(ctx, md)
+	_ = grpc.SetTrailer(ctx, md)
+
+	st := status.New(code, message)
+	if withDetails, err := st.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(time.Duration(seconds) * time.Second),
+	}); err == nil {
+		st = withDetails
+	}
+
+	return st.Err()
+}
+
+// RejectHTTP records an HTTP request rejected to shed load, and sets the headers of the hint on its response,
+// which the caller then writes (usually with the status 429 Too Many Requests, or 503 Service Unavailable).
+func RejectHTTP(header http.Header, hint Hint) {
+	shedRequestsCounter.WithLabelValues(string(hint.Reason)).Inc()
+
+	header.Set(RetryAfterHeader, strconv.FormatInt(hint.retryAfterSeconds(), 10))
+	header.Set(LoadHintHeader, string(hint.Reason))
+}
@@ -0,0 +1,74 @@
+package loadshed
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type metadataCapturingStream struct {
+	grpc.ServerTransportStream
+	header  metadata.MD
+	trailer metadata.MD
+}
+
+func (s *metadataCapturingStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *metadataCapturingStream) SetTrailer(md metadata.MD) error {
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
+
+func TestReject(t *testing.T) {
+	tests := []struct {
+		name               string
+		retryAfter         time.Duration
+		expectedRetryAfter string
+	}{
+		{name: "whole_seconds", retryAfter: 30 * time.Second, expectedRetryAfter: "30"},
+		{name: "rounded_up", retryAfter: 1500 * time.Millisecond, expectedRetryAfter: "2"},
+		{name: "at_least_a_second", retryAfter: 0, expectedRetryAfter: "1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stream := &metadataCapturingStream{}
+			ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+
+			err := Reject(ctx, codes.ResourceExhausted, "slow down", Hint{Reason: ReasonRateLimit, RetryAfter: test.retryAfter})
+
+			st := status.Convert(err)
+			require.Equal(t, codes.ResourceExhausted, st.Code())
+			require.Equal(t, "slow down", st.Message())
+
+			for _, md := range []metadata.MD{stream.header, stream.trailer} {
+				require.Equal(t, []string{test.expectedRetryAfter}, md.Get(RetryAfterHeader))
+				require.Equal(t, []string{"rate_limit"}, md.Get(LoadHintHeader))
+			}
+
+			require.Len(t, st.Details(), 1)
+			retryInfo, ok := st.Details()[0].(*errdetails.RetryInfo)
+			require.True(t, ok)
+			require.Equal(t, test.expectedRetryAfter+"s", retryInfo.GetRetryDelay().AsDuration().String())
+		})
+	}
+}
+
+func TestRejectHTTP(t *testing.T) {
+	header := http.Header{}
+	RejectHTTP(header, Hint{Reason: ReasonConcurrencyLimit, RetryAfter: 1500 * time.Millisecond})
+
+	require.Equal(t, "2", header.Get(RetryAfterHeader))
+	require.Equal(t, "concurrency_limit", header.Get(LoadHintHeader))
+}
@@ -9,7 +9,7 @@ import (
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
 	"github.com/openfga/openfga/pkg/logger"
-	"github.com/openfga/openfga/pkg/middleware/requestid"
+	"github.com/openfga/openfga/pkg/requestcontext"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
@@ -21,17 +21,19 @@ import (
 )
 
 const (
-	grpcServiceKey     = "grpc_service"
-	grpcMethodKey      = "grpc_method"
-	grpcTypeKey        = "grpc_type"
-	grpcCodeKey        = "grpc_code"
-	requestIDKey       = "request_id"
-	traceIDKey         = "trace_id"
-	rawRequestKey      = "raw_request"
-	rawResponseKey     = "raw_response"
-	internalErrorKey   = "internal_error"
-	grpcReqCompleteKey = "grpc_req_complete"
-	userAgentKey       = "user_agent"
+	grpcServiceKey          = "grpc_service"
+	grpcMethodKey           = "grpc_method"
+	grpcTypeKey             = "grpc_type"
+	grpcCodeKey             = "grpc_code"
+	requestIDKey            = "request_id"
+	storeIDKey              = "store_id"
+	authorizationModelIDKey = "authorization_model_id"
+	traceIDKey              = "trace_id"
+	rawRequestKey           = "raw_request"
+	rawResponseKey          = "raw_response"
+	internalErrorKey        = "internal_error"
+	grpcReqCompleteKey      = "grpc_req_complete"
+	userAgentKey            = "user_agent"
 
 	gatewayUserAgentHeader string = "grpcgateway-user-agent"
 	userAgentHeader        string = "user-agent"
@@ -54,6 +56,12 @@ type reporter struct {
 
 func (r *reporter) PostCall(err error, _ time.Duration) {
 	r.fields = append(r.fields, ctxzap.TagsToFields(r.ctx)...)
+	if storeID, ok := requestcontext.StoreID(r.ctx); ok {
+		r.fields = append(r.fields, zap.String(storeIDKey, storeID))
+	}
+	if modelID, ok := requestcontext.AuthorizationModelID(r.ctx); ok {
+		r.fields = append(r.fields, zap.String(authorizationModelIDKey, modelID))
+	}
 
 	code := serverErrors.ConvertToEncodedErrorCode(status.Convert(err))
 	r.fields = append(r.fields, zap.Int32(grpcCodeKey, code))
@@ -121,7 +129,7 @@ func reportable(l logger.Logger) interceptors.CommonReportableFunc {
 			fields = append(fields, zap.String(traceIDKey, spanCtx.TraceID().String()))
 		}
 
-		if requestID, ok := requestid.FromContext(ctx); ok {
+		if requestID, ok := requestcontext.RequestID(ctx); ok {
 			fields = append(fields, zap.String(requestIDKey, requestID))
 		}
 
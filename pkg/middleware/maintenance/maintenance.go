@@ -0,0 +1,221 @@
+// Package maintenance contains middleware that rejects the requests to the stores (or to the whole server)
+// that are undergoing maintenance.
+package maintenance
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openfga/openfga/pkg/middleware/loadshed"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	defaultMessage    = "the server is undergoing maintenance"
+	defaultRetryAfter = 30 * time.Second
+
+	// RetryAfterHeader is the response header that holds the number of seconds after which the rejected
+	// requests may be retried.
+	RetryAfterHeader = loadshed.RetryAfterHeader
+
+	healthServicePrefix = "/grpc.health.v1.Health/"
+)
+
+// Status describes the maintenance of a store or of the whole server.
+type Status struct {
+	Message    string
+	RetryAfter time.Duration
+	Since      time.Time
+}
+
+// Mode holds the stores that are undergoing maintenance, and whether the whole server is.
+type Mode struct {
+	mu     sync.RWMutex
+	global *Status
+	stores map[string]Status
+
+	message    string
+	retryAfter time.Duration
+	now        func() time.Time
+}
+
+// ModeOpt defines an option that can be used to change the behavior of a Mode.
+type ModeOpt func(*Mode)
+
+// WithMessage sets the message returned when maintenance is enabled without one.
+func WithMessage(message string) ModeOpt {
+	return func(m *Mode) {
+		m.message = message
+	}
+}
+
+// WithRetryAfter sets the retry-after returned when maintenance is enabled without one.
+func WithRetryAfter(retryAfter time.Duration) ModeOpt {
+	return func(m *Mode) {
+		m.retryAfter = retryAfter
+	}
+}
+
+// NewMode constructs a Mode under which neither the server nor any store is undergoing maintenance.
+func NewMode(opts ...ModeOpt) *Mode {
+	m := &Mode{
+		stores:     map[string]Status{},
+		message:    defaultMessage,
+		retryAfter: defaultRetryAfter,
+		now:        time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Enable puts the provided store, or the whole server if storeID is empty, in maintenance. An empty message
+// and a zero retryAfter are replaced by the defaults of the Mode.
+func (m *Mode) Enable(storeID, message string, retryAfter time.Duration) Status {
+	if message == "" {
+		message = m.message
+	}
+	if retryAfter <= 0 {
+		retryAfter = m.retryAfter
+	}
+
+	s := Status{Message: message, RetryAfter: retryAfter, Since: m.now()}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if storeID == "" {
+		m.global = &s
+	} else {
+		m.stores[storeID] = s
+	}
+
+	return s
+}
+
+// Disable takes the provided store, or the whole server if storeID is empty, out of maintenance.
+func (m *Mode) Disable(storeID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if storeID == "" {
+		m.global = nil
+	} else {
+		delete(m.stores, storeID)
+	}
+}
+
+// Get returns the maintenance that applies to the requests to the provided store, if any. The maintenance
+// of the whole server takes precedence over the one of the store.
+func (m *Mode) Get(storeID string) (Status, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.global != nil {
+		return *m.global, true
+	}
+
+	s, ok := m.stores[storeID]
+	return s, ok
+}
+
+// Snapshot returns the maintenance of the whole server, if any, and the one of every store.
+func (m *Mode) Snapshot() (*Status, map[string]Status) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var global *Status
+	if m.global != nil {
+		s := *m.global
+		global = &s
+	}
+
+	stores := make(map[string]Status, len(m.stores))
+	for storeID, s := range m.stores {
+		stores[storeID] = s
+	}
+
+	return global, stores
+}
+
+// check returns an UNAVAILABLE error if the requests to the provided store are rejected, along with the
+// load shedding hints (see package loadshed).
+func (m *Mode) check(ctx context.Context, storeID string) error {
+	s, ok := m.Get(storeID)
+	if !ok {
+		return nil
+	}
+
+	return loadshed.Reject(ctx, codes.Unavailable, s.Message, loadshed.Hint{
+		Reason:     loadshed.ReasonMaintenance,
+		RetryAfter: s.RetryAfter,
+	})
+}
+
+type hasGetStoreID interface {
+	GetStoreId() string
+}
+
+func storeIDFrom(msg interface{}) string {
+	if m, ok := msg.(hasGetStoreID); ok {
+		return m.GetStoreId()
+	}
+
+	return ""
+}
+
+// exempt reports whether the method is always served, e.g. the health checks.
+func exempt(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, healthServicePrefix)
+}
+
+// NewUnaryInterceptor creates a grpc.UnaryServerInterceptor which rejects the requests to the stores
+// undergoing maintenance with an UNAVAILABLE error. The health checks are always served.
+func NewUnaryInterceptor(mode *Mode) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if exempt(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		if err := mode.check(ctx, storeIDFrom(req)); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// NewStreamingInterceptor creates a grpc.StreamServerInterceptor which rejects the requests to the stores
+// undergoing maintenance with an UNAVAILABLE error. The health checks are always served.
+func NewStreamingInterceptor(mode *Mode) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if exempt(info.FullMethod) {
+			return handler(srv, stream)
+		}
+
+		if err := mode.check(stream.Context(), ""); err != nil {
+			return err
+		}
+
+		return handler(srv, &wrappedServerStream{ServerStream: stream, mode: mode})
+	}
+}
+
+type wrappedServerStream struct {
+	grpc.ServerStream
+	mode *Mode
+}
+
+func (s *wrappedServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	return s.mode.check(s.Context(), storeIDFrom(m))
+}
@@ -0,0 +1,136 @@
+package maintenance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/middleware/loadshed"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type headerCapturingStream struct {
+	grpc.ServerTransportStream
+	header  metadata.MD
+	trailer metadata.MD
+}
+
+func (s *headerCapturingStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *headerCapturingStream) SetTrailer(md metadata.MD) error {
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
+
+func TestMode(t *testing.T) {
+	mode := NewMode(WithMessage("default message"), WithRetryAfter(time.Minute))
+
+	_, ok := mode.Get("store1")
+	require.False(t, ok)
+
+	mode.Enable("store1", "", 0)
+	s, ok := mode.Get("store1")
+	require.True(t, ok)
+	require.Equal(t, "default message", s.Message)
+	require.Equal(t, time.Minute, s.RetryAfter)
+
+	_, ok = mode.Get("store2")
+	require.False(t, ok)
+
+	mode.Enable("", "global message", 5*time.Second)
+	s, ok = mode.Get("store2")
+	require.True(t, ok)
+	require.Equal(t, "global message", s.Message)
+	require.Equal(t, 5*time.Second, s.RetryAfter)
+
+	global, stores := mode.Snapshot()
+	require.NotNil(t, global)
+	require.Len(t, stores, 1)
+	require.Contains(t, stores, "store1")
+
+	mode.Disable("")
+	_, ok = mode.Get("store2")
+	require.False(t, ok)
+	_, ok = mode.Get("store1")
+	require.True(t, ok)
+
+	mode.Disable("store1")
+	global, stores = mode.Snapshot()
+	require.Nil(t, global)
+	require.Empty(t, stores)
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	mode := NewMode()
+	mode.Enable("store1", "migrating", 90*time.Second)
+
+	tests := []struct {
+		name               string
+		method             string
+		req                interface{}
+		expectedCode       codes.Code
+		expectedRetryAfter []string
+	}{
+		{
+			name:               "store_undergoing_maintenance",
+			method:             "/openfga.v1.OpenFGAService/Check",
+			req:                &openfgav1.CheckRequest{StoreId: "store1"},
+			expectedCode:       codes.Unavailable,
+			expectedRetryAfter: []string{"90"},
+		},
+		{
+			name:         "other_store",
+			method:       "/openfga.v1.OpenFGAService/Check",
+			req:          &openfgav1.CheckRequest{StoreId: "store2"},
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "request_without_store",
+			method:       "/openfga.v1.OpenFGAService/ListStores",
+			req:          &openfgav1.ListStoresRequest{},
+			expectedCode: codes.OK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stream := &headerCapturingStream{}
+			ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return req, nil
+			}
+
+			_, err := NewUnaryInterceptor(mode)(ctx, test.req, &grpc.UnaryServerInfo{FullMethod: test.method}, handler)
+			require.Equal(t, test.expectedCode, status.Code(err))
+			require.Equal(t, test.expectedRetryAfter, stream.header.Get(RetryAfterHeader))
+			if err != nil {
+				require.Equal(t, "migrating", status.Convert(err).Message())
+				require.Equal(t, []string{"maintenance"}, stream.trailer.Get(loadshed.LoadHintHeader))
+			}
+		})
+	}
+
+	t.Run("health_checks_are_served_during_global_maintenance", func(t *testing.T) {
+		mode := NewMode()
+		mode.Enable("", "", 0)
+
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return req, nil
+		}
+
+		_, err := NewUnaryInterceptor(mode)(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+		require.NoError(t, err)
+
+		_, err = NewUnaryInterceptor(mode)(context.Background(), &openfgav1.ListStoresRequest{}, &grpc.UnaryServerInfo{FullMethod: "/openfga.v1.OpenFGAService/ListStores"}, handler)
+		require.Equal(t, codes.Unavailable, status.Code(err))
+	})
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
+	"github.com/openfga/openfga/pkg/requestcontext"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
@@ -13,30 +14,23 @@ import (
 )
 
 const (
-	requestIDCtxKey   = "request-id-context-key"
 	requestIDTraceKey = "request_id"
 	requestIDHeader   = "x-request-id"
 )
 
-// FromContext extracts the requestid from the context, if it exists.
+// FromContext extracts the requestid from the context, if it exists. It's requestcontext.RequestID.
 func FromContext(ctx context.Context) (string, bool) {
-	if md, ok := metadata.FromOutgoingContext(ctx); ok {
-		if vals := md.Get(requestIDCtxKey); len(vals) > 0 {
-			return vals[0], true
-		}
-	}
-
-	return "", false
+	return requestcontext.RequestID(ctx)
 }
 
-// NewUnaryInterceptor creates a grpc.UnaryServerInterceptor which must
-// come after the trace interceptor and before the logging interceptor.
+// NewUnaryInterceptor creates a grpc.UnaryServerInterceptor which must come first, since it attaches the
+// requestcontext metadata of the request that the other interceptors complete.
 func NewUnaryInterceptor() grpc.UnaryServerInterceptor {
 	return interceptors.UnaryServerInterceptor(reportable())
 }
 
-// NewStreamingInterceptor creates a grpc.StreamServerInterceptor which must
-// come after the trace interceptor and before the logging interceptor.
+// NewStreamingInterceptor creates a grpc.StreamServerInterceptor which must come first, since it attaches
+// the requestcontext metadata of the request that the other interceptors complete.
 func NewStreamingInterceptor() grpc.StreamServerInterceptor {
 	return interceptors.StreamServerInterceptor(reportable())
 }
@@ -47,7 +41,8 @@ func reportable() interceptors.CommonReportableFunc {
 		requestID := id.String()
 
 		// Add the requestID to the context
-		ctx = metadata.AppendToOutgoingContext(ctx, requestIDCtxKey, requestID)
+		ctx = requestcontext.NewContext(ctx)
+		requestcontext.SetRequestID(ctx, requestID)
 
 		// Add the requestID to the span
 		trace.SpanFromContext(ctx).SetAttributes(attribute.String(requestIDTraceKey, requestID))
@@ -0,0 +1,344 @@
+// Package slo contains middleware that measures the availability and the latency of every RPC against
+// configurable service level objectives, and exposes the resulting service level indicators and error
+// budgets as Prometheus metrics.
+package slo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultAvailabilityObjective = 0.999
+	defaultLatencyObjective      = 0.99
+	defaultLatencyThreshold      = 500 * time.Millisecond
+	defaultWindow                = time.Hour
+
+	// windowBuckets is the number of buckets the window is divided into. The window slides one bucket at a time.
+	windowBuckets = 60
+)
+
+var (
+	labels = []string{"grpc_service", "grpc_method"}
+
+	requestsDesc = prometheus.NewDesc(
+		"slo_requests_total",
+		"The total number of RPCs measured against the service level objectives.",
+		labels, nil,
+	)
+
+	availableRequestsDesc = prometheus.NewDesc(
+		"slo_available_requests_total",
+		"The total number of RPCs that didn't fail because of a server error.",
+		labels, nil,
+	)
+
+	fastRequestsDesc = prometheus.NewDesc(
+		"slo_fast_requests_total",
+		"The total number of RPCs that completed within the latency threshold of their method.",
+		labels, nil,
+	)
+
+	availabilityDesc = prometheus.NewDesc(
+		"slo_availability_ratio",
+		"The ratio of RPCs that didn't fail because of a server error over the SLO window.",
+		labels, nil,
+	)
+
+	latencyDesc = prometheus.NewDesc(
+		"slo_latency_ratio",
+		"The ratio of RPCs that completed within the latency threshold of their method over the SLO window.",
+		labels, nil,
+	)
+
+	availabilityObjectiveDesc = prometheus.NewDesc(
+		"slo_availability_objective_ratio",
+		"The availability objective of the method.",
+		labels, nil,
+	)
+
+	latencyObjectiveDesc = prometheus.NewDesc(
+		"slo_latency_objective_ratio",
+		"The latency objective of the method, i.e. the ratio of RPCs that must complete within the latency threshold.",
+		labels, nil,
+	)
+
+	latencyThresholdDesc = prometheus.NewDesc(
+		"slo_latency_threshold_seconds",
+		"The latency threshold of the method.",
+		labels, nil,
+	)
+
+	availabilityErrorBudgetDesc = prometheus.NewDesc(
+		"slo_availability_error_budget_remaining_ratio",
+		"The ratio of the availability error budget that remains over the SLO window. It is negative if the budget is exhausted.",
+		labels, nil,
+	)
+
+	latencyErrorBudgetDesc = prometheus.NewDesc(
+		"slo_latency_error_budget_remaining_ratio",
+		"The ratio of the latency error budget that remains over the SLO window. It is negative if the budget is exhausted.",
+		labels, nil,
+	)
+)
+
+// unavailableCodes are the codes of the errors that count against the availability objective. Other errors
+// (e.g. invalid requests) are attributed to the caller.
+var unavailableCodes = map[codes.Code]struct{}{
+	codes.Unknown:          {},
+	codes.DeadlineExceeded: {},
+	codes.Internal:         {},
+	codes.Unavailable:      {},
+	codes.DataLoss:         {},
+}
+
+type method struct {
+	service string
+	name    string
+}
+
+type counts struct {
+	requests  uint64
+	available uint64
+	fast      uint64
+}
+
+type bucket struct {
+	index int64
+	counts
+}
+
+type methodStats struct {
+	total   counts
+	buckets [windowBuckets]bucket
+}
+
+// Tracker measures the availability and the latency of RPCs against service level objectives.
+//
+// The service level indicators are computed over a sliding window. The error budget remaining is
+// 1 - (1 - indicator) / (1 - objective), i.e. 1 when no RPC failed the objective and 0 when as many RPCs
+// failed it as the objective allows.
+//
+// Tracker implements prometheus.Collector.
+type Tracker struct {
+	mu      sync.Mutex
+	methods map[method]*methodStats
+
+	availabilityObjective float64
+	latencyObjective      float64
+	latencyThreshold      time.Duration
+	latencyThresholds     map[string]time.Duration
+	window                time.Duration
+	now                   func() time.Time
+}
+
+var _ prometheus.Collector = (*Tracker)(nil)
+
+// TrackerOpt defines an option that can be used to change the behavior of a Tracker.
+type TrackerOpt func(*Tracker)
+
+// WithAvailabilityObjective sets the ratio (between 0 and 1) of RPCs that must not fail because of a
+// server error.
+func WithAvailabilityObjective(objective float64) TrackerOpt {
+	return func(t *Tracker) {
+		t.availabilityObjective = objective
+	}
+}
+
+// WithLatencyObjective sets the ratio (between 0 and 1) of RPCs that must complete within the latency
+// threshold of their method.
+func WithLatencyObjective(objective float64) TrackerOpt {
+	return func(t *Tracker) {
+		t.latencyObjective = objective
+	}
+}
+
+// WithLatencyThreshold sets the latency threshold of the methods without a specific one.
+func WithLatencyThreshold(threshold time.Duration) TrackerOpt {
+	return func(t *Tracker) {
+		t.latencyThreshold = threshold
+	}
+}
+
+// WithMethodLatencyThreshold sets the latency threshold of a method, identified by its name (e.g. 'Check').
+func WithMethodLatencyThreshold(method string, threshold time.Duration) TrackerOpt {
+	return func(t *Tracker) {
+		t.latencyThresholds[method] = threshold
+	}
+}
+
+// WithWindow sets the duration of the sliding window over which the service level indicators are computed.
+func WithWindow(window time.Duration) TrackerOpt {
+	return func(t *Tracker) {
+		t.window = window
+	}
+}
+
+// NewTracker constructs a Tracker.
+func NewTracker(opts ...TrackerOpt) *Tracker {
+	t := &Tracker{
+		methods:               map[method]*methodStats{},
+		availabilityObjective: defaultAvailabilityObjective,
+		latencyObjective:      defaultLatencyObjective,
+		latencyThreshold:      defaultLatencyThreshold,
+		latencyThresholds:     map[string]time.Duration{},
+		window:                defaultWindow,
+		now:                   time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+func (t *Tracker) thresholdFor(name string) time.Duration {
+	if threshold, ok := t.latencyThresholds[name]; ok {
+		return threshold
+	}
+
+	return t.latencyThreshold
+}
+
+// bucketIndex returns the index of the window bucket the provided time falls in.
+func (t *Tracker) bucketIndex(now time.Time) int64 {
+	width := int64(t.window / windowBuckets)
+	if width <= 0 {
+		width = 1
+	}
+
+	return now.UnixNano() / width
+}
+
+// Observe records the outcome of an RPC.
+func (t *Tracker) Observe(service, name string, err error, duration time.Duration) {
+	_, unavailable := unavailableCodes[status.Code(err)]
+	fast := duration <= t.thresholdFor(name)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := method{service: service, name: name}
+	stats, ok := t.methods[key]
+	if !ok {
+		stats = &methodStats{}
+		t.methods[key] = stats
+	}
+
+	index := t.bucketIndex(t.now())
+	b := &stats.buckets[index%windowBuckets]
+	if b.index != index {
+		*b = bucket{index: index}
+	}
+
+	for _, c := range []*counts{&stats.total, &b.counts} {
+		c.requests++
+		if !unavailable {
+			c.available++
+		}
+		if fast {
+			c.fast++
+		}
+	}
+}
+
+func (t *Tracker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- requestsDesc
+	ch <- availableRequestsDesc
+	ch <- fastRequestsDesc
+	ch <- availabilityDesc
+	ch <- latencyDesc
+	ch <- availabilityObjectiveDesc
+	ch <- latencyObjectiveDesc
+	ch <- latencyThresholdDesc
+	ch <- availabilityErrorBudgetDesc
+	ch <- latencyErrorBudgetDesc
+}
+
+func (t *Tracker) Collect(ch chan<- prometheus.Metric) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current := t.bucketIndex(t.now())
+
+	for m, stats := range t.methods {
+		var windowed counts
+		for _, b := range stats.buckets {
+			if b.index > current-windowBuckets && b.index <= current {
+				windowed.requests += b.requests
+				windowed.available += b.available
+				windowed.fast += b.fast
+			}
+		}
+
+		ch <- prometheus.MustNewConstMetric(requestsDesc, prometheus.CounterValue, float64(stats.total.requests), m.service, m.name)
+		ch <- prometheus.MustNewConstMetric(availableRequestsDesc, prometheus.CounterValue, float64(stats.total.available), m.service, m.name)
+		ch <- prometheus.MustNewConstMetric(fastRequestsDesc, prometheus.CounterValue, float64(stats.total.fast), m.service, m.name)
+
+		ch <- prometheus.MustNewConstMetric(availabilityObjectiveDesc, prometheus.GaugeValue, t.availabilityObjective, m.service, m.name)
+		ch <- prometheus.MustNewConstMetric(latencyObjectiveDesc, prometheus.GaugeValue, t.latencyObjective, m.service, m.name)
+		ch <- prometheus.MustNewConstMetric(latencyThresholdDesc, prometheus.GaugeValue, t.thresholdFor(m.name).Seconds(), m.service, m.name)
+
+		// without requests in the window, the indicators are undefined and no budget has been spent
+		availability, latency := 1.0, 1.0
+		if windowed.requests > 0 {
+			availability = float64(windowed.available) / float64(windowed.requests)
+			latency = float64(windowed.fast) / float64(windowed.requests)
+		}
+
+		ch <- prometheus.MustNewConstMetric(availabilityDesc, prometheus.GaugeValue, availability, m.service, m.name)
+		ch <- prometheus.MustNewConstMetric(latencyDesc, prometheus.GaugeValue, latency, m.service, m.name)
+		ch <- prometheus.MustNewConstMetric(availabilityErrorBudgetDesc, prometheus.GaugeValue, errorBudgetRemaining(availability, t.availabilityObjective), m.service, m.name)
+		ch <- prometheus.MustNewConstMetric(latencyErrorBudgetDesc, prometheus.GaugeValue, errorBudgetRemaining(latency, t.latencyObjective), m.service, m.name)
+	}
+}
+
+func errorBudgetRemaining(indicator, objective float64) float64 {
+	budget := 1 - objective
+	if budget <= 0 {
+		// an objective of 100% leaves no budget: it's either untouched or exhausted
+		if indicator >= 1 {
+			return 1
+		}
+		return 0
+	}
+
+	return 1 - (1-indicator)/budget
+}
+
+type reporter struct {
+	tracker  *Tracker
+	callMeta interceptors.CallMeta
+}
+
+func (r *reporter) PostCall(err error, duration time.Duration) {
+	r.tracker.Observe(r.callMeta.Service, r.callMeta.Method, err, duration)
+}
+
+func (r *reporter) PostMsgSend(interface{}, error, time.Duration) {}
+
+func (r *reporter) PostMsgReceive(interface{}, error, time.Duration) {}
+
+func reportable(tracker *Tracker) interceptors.CommonReportableFunc {
+	return func(ctx context.Context, c interceptors.CallMeta) (interceptors.Reporter, context.Context) {
+		return &reporter{tracker: tracker, callMeta: c}, ctx
+	}
+}
+
+// NewUnaryInterceptor creates a grpc.UnaryServerInterceptor which measures every RPC with the provided Tracker.
+func NewUnaryInterceptor(tracker *Tracker) grpc.UnaryServerInterceptor {
+	return interceptors.UnaryServerInterceptor(reportable(tracker))
+}
+
+// NewStreamingInterceptor creates a grpc.StreamServerInterceptor which measures every RPC with the provided Tracker.
+func NewStreamingInterceptor(tracker *Tracker) grpc.StreamServerInterceptor {
+	return interceptors.StreamServerInterceptor(reportable(tracker))
+}
@@ -0,0 +1,105 @@
+package slo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const service = "openfga.v1.OpenFGAService"
+
+// gather returns the value of every metric of the Tracker for the provided method, keyed by metric name.
+func gather(t *testing.T, tracker *Tracker, method string) map[string]float64 {
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(tracker))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	values := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+
+			if labels["grpc_method"] != method {
+				continue
+			}
+
+			if metric.GetCounter() != nil {
+				values[family.GetName()] = metric.GetCounter().GetValue()
+			} else {
+				values[family.GetName()] = metric.GetGauge().GetValue()
+			}
+		}
+	}
+
+	return values
+}
+
+func TestTracker(t *testing.T) {
+	now := time.Now()
+	tracker := NewTracker(
+		WithAvailabilityObjective(0.9),
+		WithLatencyObjective(0.5),
+		WithLatencyThreshold(100*time.Millisecond),
+		WithMethodLatencyThreshold("Check", 10*time.Millisecond),
+		WithWindow(time.Minute),
+	)
+	tracker.now = func() time.Time { return now }
+
+	// 10 Checks: one internal error, one invalid request (attributed to the caller) and 3 slow ones
+	for i := 0; i < 10; i++ {
+		var err error
+		switch i {
+		case 0:
+			err = status.Error(codes.Internal, "internal error")
+		case 1:
+			err = status.Error(codes.InvalidArgument, "invalid request")
+		}
+
+		duration := time.Millisecond
+		if i >= 7 {
+			duration = 50 * time.Millisecond
+		}
+
+		tracker.Observe(service, "Check", err, duration)
+	}
+
+	// 50ms is within the default threshold
+	tracker.Observe(service, "Read", nil, 50*time.Millisecond)
+
+	check := gather(t, tracker, "Check")
+	require.Equal(t, float64(10), check["slo_requests_total"])
+	require.Equal(t, float64(9), check["slo_available_requests_total"])
+	require.Equal(t, float64(7), check["slo_fast_requests_total"])
+	require.InDelta(t, 0.9, check["slo_availability_ratio"], 1e-9)
+	require.InDelta(t, 0.7, check["slo_latency_ratio"], 1e-9)
+	require.InDelta(t, 0.01, check["slo_latency_threshold_seconds"], 1e-9)
+
+	// 10% of the Checks failed for an objective of 90%: the availability budget is spent
+	require.InDelta(t, 0, check["slo_availability_error_budget_remaining_ratio"], 1e-9)
+	// 30% of the Checks were slow for an objective of 50%: 40% of the latency budget remains
+	require.InDelta(t, 0.4, check["slo_latency_error_budget_remaining_ratio"], 1e-9)
+
+	read := gather(t, tracker, "Read")
+	require.Equal(t, float64(1), read["slo_fast_requests_total"])
+	require.InDelta(t, 1, read["slo_latency_error_budget_remaining_ratio"], 1e-9)
+
+	t.Run("requests_outside_of_the_window_are_not_counted_in_the_indicators", func(t *testing.T) {
+		now = now.Add(2 * time.Minute)
+		tracker.Observe(service, "Check", status.Error(codes.Unavailable, "unavailable"), time.Millisecond)
+
+		check := gather(t, tracker, "Check")
+		require.Equal(t, float64(11), check["slo_requests_total"])
+		require.InDelta(t, 0, check["slo_availability_ratio"], 1e-9)
+		require.InDelta(t, 1, check["slo_latency_ratio"], 1e-9)
+		require.InDelta(t, -9, check["slo_availability_error_budget_remaining_ratio"], 1e-9)
+	})
+}
@@ -0,0 +1,28 @@
+// Package statuscodes contains middleware that overrides the gRPC codes of the errors of the classes whose
+// status codes are configured (see errors.SetStatusCodeOverrides), e.g. because the intermediaries between
+// the clients and the server key their retries off them.
+package statuscodes
+
+import (
+	"context"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"google.golang.org/grpc"
+)
+
+// NewUnaryInterceptor creates a grpc.UnaryServerInterceptor which must come first, so that it overrides the
+// codes of the errors of the other interceptors, e.g. of the requests shed or rejected during maintenance.
+func NewUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, serverErrors.WithStatusCodeOverride(err)
+	}
+}
+
+// NewStreamingInterceptor creates a grpc.StreamServerInterceptor which must come first, so that it overrides
+// the codes of the errors of the other interceptors.
+func NewStreamingInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return serverErrors.WithStatusCodeOverride(handler(srv, stream))
+	}
+}
@@ -5,47 +5,30 @@ import (
 	"context"
 	"time"
 
-	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
+	"github.com/openfga/openfga/pkg/requestcontext"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 )
 
-type ctxKey string
-
 const (
-	storeIDCtxKey ctxKey = "store-id-context-key"
 	storeIDKey    string = "store_id"
 	storeIDHeader string = "openfga-store-id"
 )
 
-type storeidHandle struct {
-	storeid string
-}
-
+// StoreIDFromContext returns the store ID of the request (see requestcontext.StoreID), which is empty if
+// the request has no store, and whether the context has the metadata of a request.
 func StoreIDFromContext(ctx context.Context) (string, bool) {
-	if c := ctx.Value(storeIDCtxKey); c != nil {
-		handle := c.(*storeidHandle)
-		return handle.storeid, true
-	}
-
-	return "", false
-}
-
-func contextWithHandle(ctx context.Context) context.Context {
-	return context.WithValue(ctx, storeIDCtxKey, &storeidHandle{})
+	storeID, _ := requestcontext.StoreID(ctx)
+	return storeID, requestcontext.HasMetadata(ctx)
 }
 
+// SetStoreIDInContext sets the store ID of the request from the request message, if it has one.
 func SetStoreIDInContext(ctx context.Context, req interface{}) {
-	handle := ctx.Value(storeIDCtxKey)
-	if handle == nil {
-		return
-	}
-
 	if r, ok := req.(hasGetStoreID); ok {
-		handle.(*storeidHandle).storeid = r.GetStoreId()
+		requestcontext.SetStoreID(ctx, r.GetStoreId())
 	}
 }
 
@@ -82,15 +65,13 @@ func (r *reporter) PostMsgReceive(msg interface{}, err error, _ time.Duration) {
 		SetStoreIDInContext(r.ctx, msg)
 		trace.SpanFromContext(r.ctx).SetAttributes(attribute.String(storeIDKey, storeID))
 
-		grpc_ctxtags.Extract(r.ctx).Set(storeIDKey, storeID)
-
 		_ = grpc.SetHeader(r.ctx, metadata.Pairs(storeIDHeader, storeID))
 	}
 }
 
 func reportable() interceptors.CommonReportableFunc {
 	return func(ctx context.Context, c interceptors.CallMeta) (interceptors.Reporter, context.Context) {
-		ctx = contextWithHandle(ctx)
+		ctx = requestcontext.NewContext(ctx)
 
 		r := reporter{ctx}
 		return &r, r.ctx
@@ -0,0 +1,241 @@
+// Package storemetrics contains middleware to report RPC metrics labeled by store ID, and a StoreLabeler
+// to keep the cardinality of those labels under control.
+package storemetrics
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// OtherStoresLabel is the store_id label value of the stores that are neither allowlisted nor among the
+	// top K stores.
+	OtherStoresLabel = "other"
+
+	defaultTopK   = 10
+	defaultWindow = time.Minute
+)
+
+var (
+	storeRequestsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "store_requests_total",
+		Help: "The total number of RPCs, labeled by store. Stores that are neither allowlisted nor among the busiest are labeled 'other'.",
+	}, []string{"grpc_service", "grpc_method", "grpc_code", "store_id"})
+
+	storeRequestDurationHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:                            "store_request_duration_ms",
+		Help:                            "The RPC duration (in ms), labeled by store. Stores that are neither allowlisted nor among the busiest are labeled 'other'.",
+		Buckets:                         []float64{1, 5, 10, 25, 50, 80, 100, 150, 200, 300, 1000, 2000, 5000},
+		NativeHistogramBucketFactor:     1.1,
+		NativeHistogramMaxBucketNumber:  100,
+		NativeHistogramMinResetDuration: time.Hour,
+	}, []string{"grpc_service", "grpc_method", "store_id"})
+)
+
+// StoreLabeler maps store IDs to store_id label values, so that the number of distinct label values stays
+// bounded no matter how many stores there are.
+//
+// Allowlisted stores are always labeled with their ID. Otherwise, only the K stores with the most requests
+// in the previous window are labeled with their ID, and every other store is labeled OtherStoresLabel.
+// Until K stores have been seen in a window, new stores are labeled with their ID as they come.
+type StoreLabeler struct {
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[string]uint64
+	top         map[string]struct{}
+
+	allowlist map[string]struct{}
+	topK      int
+	window    time.Duration
+	now       func() time.Time
+}
+
+// StoreLabelerOpt defines an option that can be used to change the behavior of a StoreLabeler.
+type StoreLabelerOpt func(*StoreLabeler)
+
+// WithAllowlist sets the stores that are always labeled with their ID.
+func WithAllowlist(storeIDs []string) StoreLabelerOpt {
+	return func(l *StoreLabeler) {
+		for _, storeID := range storeIDs {
+			l.allowlist[storeID] = struct{}{}
+		}
+	}
+}
+
+// WithTopK sets the number of busiest stores, besides the allowlisted ones, that are labeled with their ID.
+// A value of 0 means that only the allowlisted stores are labeled with their ID.
+func WithTopK(k int) StoreLabelerOpt {
+	return func(l *StoreLabeler) {
+		l.topK = k
+	}
+}
+
+// WithWindow sets the duration of the window over which the busiest stores are computed.
+func WithWindow(window time.Duration) StoreLabelerOpt {
+	return func(l *StoreLabeler) {
+		l.window = window
+	}
+}
+
+// NewStoreLabeler constructs a StoreLabeler.
+func NewStoreLabeler(opts ...StoreLabelerOpt) *StoreLabeler {
+	l := &StoreLabeler{
+		counts:    map[string]uint64{},
+		top:       map[string]struct{}{},
+		allowlist: map[string]struct{}{},
+		topK:      defaultTopK,
+		window:    defaultWindow,
+		now:       time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	l.windowStart = l.now()
+
+	return l
+}
+
+// Record records a request for the provided store and returns the store_id label value to report it with.
+func (l *StoreLabeler) Record(storeID string) string {
+	return l.label(storeID, true)
+}
+
+// Label returns the store_id label value of the provided store without recording a request for it. It
+// must be called after Record for the same request, so that new stores can be labeled with their ID.
+func (l *StoreLabeler) Label(storeID string) string {
+	return l.label(storeID, false)
+}
+
+func (l *StoreLabeler) label(storeID string, record bool) string {
+	if storeID == "" {
+		return ""
+	}
+
+	if _, ok := l.allowlist[storeID]; ok {
+		return storeID
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !record {
+		if _, ok := l.top[storeID]; ok {
+			return storeID
+		}
+
+		return OtherStoresLabel
+	}
+
+	now := l.now()
+	if now.Sub(l.windowStart) >= l.window {
+		l.top = topStores(l.counts, l.topK)
+		l.counts = map[string]uint64{}
+		l.windowStart = now
+	}
+
+	l.counts[storeID]++
+
+	if _, ok := l.top[storeID]; ok {
+		return storeID
+	}
+
+	if len(l.top) < l.topK {
+		l.top[storeID] = struct{}{}
+		return storeID
+	}
+
+	return OtherStoresLabel
+}
+
+func topStores(counts map[string]uint64, k int) map[string]struct{} {
+	storeIDs := make([]string, 0, len(counts))
+	for storeID := range counts {
+		storeIDs = append(storeIDs, storeID)
+	}
+
+	sort.Slice(storeIDs, func(i, j int) bool {
+		if counts[storeIDs[i]] != counts[storeIDs[j]] {
+			return counts[storeIDs[i]] > counts[storeIDs[j]]
+		}
+		return storeIDs[i] < storeIDs[j]
+	})
+
+	if len(storeIDs) > k {
+		storeIDs = storeIDs[:k]
+	}
+
+	top := make(map[string]struct{}, len(storeIDs))
+	for _, storeID := range storeIDs {
+		top[storeID] = struct{}{}
+	}
+
+	return top
+}
+
+type hasGetStoreID interface {
+	GetStoreId() string
+}
+
+type reporter struct {
+	labeler  *StoreLabeler
+	callMeta interceptors.CallMeta
+	start    time.Time
+	label    string
+}
+
+func (r *reporter) PostCall(err error, _ time.Duration) {
+	// RPCs that aren't scoped to a store (e.g. ListStores) aren't reported
+	if r.label == "" {
+		return
+	}
+
+	storeRequestsCounter.WithLabelValues(
+		r.callMeta.Service,
+		r.callMeta.Method,
+		status.Code(err).String(),
+		r.label,
+	).Inc()
+
+	storeRequestDurationHistogram.WithLabelValues(
+		r.callMeta.Service,
+		r.callMeta.Method,
+		r.label,
+	).Observe(float64(time.Since(r.start).Milliseconds()))
+}
+
+func (r *reporter) PostMsgSend(interface{}, error, time.Duration) {}
+
+func (r *reporter) PostMsgReceive(msg interface{}, _ error, _ time.Duration) {
+	// the store of streaming RPCs is recorded once, when the first message is received
+	if m, ok := msg.(hasGetStoreID); ok && r.label == "" {
+		r.label = r.labeler.Record(m.GetStoreId())
+	}
+}
+
+func reportable(labeler *StoreLabeler) interceptors.CommonReportableFunc {
+	return func(ctx context.Context, c interceptors.CallMeta) (interceptors.Reporter, context.Context) {
+		return &reporter{labeler: labeler, callMeta: c, start: time.Now()}, ctx
+	}
+}
+
+// NewUnaryInterceptor creates a grpc.UnaryServerInterceptor which reports the number and the duration of
+// the RPCs scoped to a store, labeled by the store_id label value returned by the provided StoreLabeler.
+func NewUnaryInterceptor(labeler *StoreLabeler) grpc.UnaryServerInterceptor {
+	return interceptors.UnaryServerInterceptor(reportable(labeler))
+}
+
+// NewStreamingInterceptor creates a grpc.StreamServerInterceptor which reports the number and the duration
+// of the RPCs scoped to a store, labeled by the store_id label value returned by the provided StoreLabeler.
+func NewStreamingInterceptor(labeler *StoreLabeler) grpc.StreamServerInterceptor {
+	return interceptors.StreamServerInterceptor(reportable(labeler))
+}
@@ -0,0 +1,68 @@
+package storemetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreLabeler(t *testing.T) {
+	now := time.Now()
+	labeler := NewStoreLabeler(
+		WithAllowlist([]string{"allowed"}),
+		WithTopK(2),
+		WithWindow(time.Minute),
+	)
+	labeler.now = func() time.Time { return now }
+	labeler.windowStart = now
+
+	t.Run("empty_store_id_is_not_labeled", func(t *testing.T) {
+		require.Empty(t, labeler.Record(""))
+	})
+
+	t.Run("allowlisted_stores_are_always_labeled_with_their_id", func(t *testing.T) {
+		require.Equal(t, "allowed", labeler.Label("allowed"))
+		require.Equal(t, "allowed", labeler.Record("allowed"))
+	})
+
+	t.Run("new_stores_are_labeled_with_their_id_until_k_stores_are_seen", func(t *testing.T) {
+		require.Equal(t, "a", labeler.Record("a"))
+		require.Equal(t, "b", labeler.Record("b"))
+		require.Equal(t, OtherStoresLabel, labeler.Record("c"))
+		require.Equal(t, "a", labeler.Label("a"))
+		require.Equal(t, OtherStoresLabel, labeler.Label("c"))
+	})
+
+	t.Run("busiest_stores_of_the_previous_window_are_labeled_with_their_id", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			labeler.Record("c")
+		}
+		labeler.Record("b")
+
+		now = now.Add(time.Minute)
+
+		require.Equal(t, "c", labeler.Record("c"))
+		require.Equal(t, "b", labeler.Record("b"))
+		require.Equal(t, OtherStoresLabel, labeler.Record("a"))
+	})
+
+	t.Run("label_does_not_record_requests", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			labeler.Label("a")
+		}
+		labeler.Record("b")
+		labeler.Record("c")
+
+		now = now.Add(time.Minute)
+
+		require.Equal(t, OtherStoresLabel, labeler.Record("a"))
+	})
+}
+
+func TestStoreLabelerWithZeroTopK(t *testing.T) {
+	labeler := NewStoreLabeler(WithAllowlist([]string{"allowed"}), WithTopK(0))
+
+	require.Equal(t, "allowed", labeler.Record("allowed"))
+	require.Equal(t, OtherStoresLabel, labeler.Record("a"))
+}
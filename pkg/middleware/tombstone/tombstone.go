@@ -0,0 +1,148 @@
+// Package tombstone contains middleware that rejects the requests to the stores that were deleted, rather
+// than serving them the empty results of a store without data.
+package tombstone
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/events"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"google.golang.org/grpc"
+)
+
+const defaultTTL = time.Hour
+
+// Tombstones holds the stores deleted recently, until their tombstone expires.
+type Tombstones struct {
+	mu     sync.RWMutex
+	stores map[string]time.Time
+
+	ttl time.Duration
+	now func() time.Time
+}
+
+// TombstonesOpt defines an option that can be used to change the behavior of Tombstones.
+type TombstonesOpt func(*Tombstones)
+
+// WithTTL sets for how long the deleted stores are remembered. It defaults to an hour.
+func WithTTL(ttl time.Duration) TombstonesOpt {
+	return func(t *Tombstones) {
+		t.ttl = ttl
+	}
+}
+
+// NewTombstones constructs Tombstones without any deleted store.
+func NewTombstones(opts ...TombstonesOpt) *Tombstones {
+	t := &Tombstones{
+		stores: map[string]time.Time{},
+		ttl:    defaultTTL,
+		now:    time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+// Add records that the store was deleted, and drops the expired tombstones.
+func (t *Tombstones) Add(storeID string) {
+	now := t.now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for id, expiresAt := range t.stores {
+		if !now.Before(expiresAt) {
+			delete(t.stores, id)
+		}
+	}
+
+	t.stores[storeID] = now.Add(t.ttl)
+}
+
+// Deleted reports whether the store was deleted and its tombstone hasn't expired yet.
+func (t *Tombstones) Deleted(storeID string) bool {
+	if storeID == "" {
+		return false
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	expiresAt, ok := t.stores[storeID]
+	return ok && t.now().Before(expiresAt)
+}
+
+// HandleStoreDeleted records the store of an events.StoreDeleted event as deleted, e.g. to subscribe the
+// Tombstones to an events.Bus.
+func (t *Tombstones) HandleStoreDeleted(_ context.Context, event events.Event) {
+	if event.Type == events.StoreDeleted {
+		t.Add(event.StoreID)
+	}
+}
+
+func (t *Tombstones) check(storeID string) error {
+	if t.Deleted(storeID) {
+		return serverErrors.StoreDeleted(storeID)
+	}
+
+	return nil
+}
+
+type hasGetStoreID interface {
+	GetStoreId() string
+}
+
+func storeIDFrom(msg interface{}) string {
+	if m, ok := msg.(hasGetStoreID); ok {
+		return m.GetStoreId()
+	}
+
+	return ""
+}
+
+// NewUnaryInterceptor creates a grpc.UnaryServerInterceptor which rejects the requests to the deleted stores
+// with a NOT_FOUND error whose reason is serverErrors.ReasonStoreDeleted. The stores deleted by the
+// DeleteStore requests it serves are recorded before the requests complete, so that no later request is
+// served.
+func NewUnaryInterceptor(tombstones *Tombstones) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		storeID := storeIDFrom(req)
+		if err := tombstones.check(storeID); err != nil {
+			return nil, err
+		}
+
+		resp, err := handler(ctx, req)
+		if err == nil && info.FullMethod == openfgav1.OpenFGAService_DeleteStore_FullMethodName {
+			tombstones.Add(storeID)
+		}
+
+		return resp, err
+	}
+}
+
+// NewStreamingInterceptor creates a grpc.StreamServerInterceptor which rejects the requests to the deleted
+// stores with a NOT_FOUND error whose reason is serverErrors.ReasonStoreDeleted.
+func NewStreamingInterceptor(tombstones *Tombstones) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &wrappedServerStream{ServerStream: stream, tombstones: tombstones})
+	}
+}
+
+type wrappedServerStream struct {
+	grpc.ServerStream
+	tombstones *Tombstones
+}
+
+func (s *wrappedServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	return s.tombstones.check(storeIDFrom(m))
+}
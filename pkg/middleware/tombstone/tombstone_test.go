@@ -0,0 +1,126 @@
+package tombstone
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/events"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func requireStoreDeleted(t *testing.T, err error) {
+	t.Helper()
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, int32(openfgav1.NotFoundErrorCode_store_id_not_found), int32(st.Code()))
+
+	require.Len(t, st.Details(), 1)
+	errorInfo, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	require.Equal(t, serverErrors.ReasonStoreDeleted, errorInfo.GetReason())
+}
+
+func TestTombstones(t *testing.T) {
+	now := time.Now()
+	tombstones := NewTombstones(WithTTL(time.Minute))
+	tombstones.now = func() time.Time { return now }
+
+	require.False(t, tombstones.Deleted("store1"))
+
+	tombstones.Add("store1")
+	require.True(t, tombstones.Deleted("store1"))
+	require.False(t, tombstones.Deleted("store2"))
+
+	tombstones.HandleStoreDeleted(context.Background(), events.Event{Type: events.StoreDeleted, StoreID: "store2"})
+	require.True(t, tombstones.Deleted("store2"))
+
+	now = now.Add(time.Minute)
+	require.False(t, tombstones.Deleted("store1"))
+
+	// the expired tombstones are dropped by the next deletion
+	tombstones.Add("store3")
+	require.Len(t, tombstones.stores, 1)
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	ctx := context.Background()
+	tombstones := NewTombstones()
+	interceptor := NewUnaryInterceptor(tombstones)
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	failingHandler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errors.New("failed")
+	}
+
+	deleteInfo := &grpc.UnaryServerInfo{FullMethod: openfgav1.OpenFGAService_DeleteStore_FullMethodName}
+	checkInfo := &grpc.UnaryServerInfo{FullMethod: openfgav1.OpenFGAService_Check_FullMethodName}
+
+	// a failed deletion doesn't leave a tombstone
+	_, err := interceptor(ctx, &openfgav1.DeleteStoreRequest{StoreId: "store1"}, deleteInfo, failingHandler)
+	require.Error(t, err)
+	require.False(t, tombstones.Deleted("store1"))
+
+	resp, err := interceptor(ctx, &openfgav1.DeleteStoreRequest{StoreId: "store1"}, deleteInfo, handler)
+	require.NoError(t, err)
+	require.Equal(t, "ok", resp)
+
+	_, err = interceptor(ctx, &openfgav1.CheckRequest{StoreId: "store1"}, checkInfo, handler)
+	requireStoreDeleted(t, err)
+
+	_, err = interceptor(ctx, &openfgav1.DeleteStoreRequest{StoreId: "store1"}, deleteInfo, handler)
+	requireStoreDeleted(t, err)
+
+	resp, err = interceptor(ctx, &openfgav1.CheckRequest{StoreId: "store2"}, checkInfo, handler)
+	require.NoError(t, err)
+	require.Equal(t, "ok", resp)
+
+	resp, err = interceptor(ctx, &openfgav1.CreateStoreRequest{Name: "store"}, &grpc.UnaryServerInfo{FullMethod: openfgav1.OpenFGAService_CreateStore_FullMethodName}, handler)
+	require.NoError(t, err)
+	require.Equal(t, "ok", resp)
+}
+
+type recvStream struct {
+	grpc.ServerStream
+	msg *openfgav1.StreamedListObjectsRequest
+}
+
+func (s *recvStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *recvStream) RecvMsg(m interface{}) error {
+	proto.Merge(m.(proto.Message), s.msg)
+	return nil
+}
+
+func TestStreamingInterceptor(t *testing.T) {
+	tombstones := NewTombstones()
+	tombstones.Add("store1")
+
+	interceptor := NewStreamingInterceptor(tombstones)
+	info := &grpc.StreamServerInfo{FullMethod: openfgav1.OpenFGAService_StreamedListObjects_FullMethodName}
+
+	for storeID, deleted := range map[string]bool{"store1": true, "store2": false} {
+		stream := &recvStream{msg: &openfgav1.StreamedListObjectsRequest{StoreId: storeID}}
+
+		err := interceptor(nil, stream, info, func(srv interface{}, stream grpc.ServerStream) error {
+			return stream.RecvMsg(&openfgav1.StreamedListObjectsRequest{})
+		})
+		if deleted {
+			requireStoreDeleted(t, err)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Package tracecontext contains middleware that correlates requests with the distributed trace they belong to.
+package tracecontext
+
+import (
+	"context"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// TraceIDHeader is the response header that holds the ID of the trace the request belongs to.
+const TraceIDHeader = "x-trace-id"
+
+// NewUnaryInterceptor creates a grpc.UnaryServerInterceptor which must
+// come after the trace interceptor and before the logging interceptor.
+func NewUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return interceptors.UnaryServerInterceptor(reportable())
+}
+
+// NewStreamingInterceptor creates a grpc.StreamServerInterceptor which must
+// come after the trace interceptor and before the logging interceptor.
+func NewStreamingInterceptor() grpc.StreamServerInterceptor {
+	return interceptors.StreamServerInterceptor(reportable())
+}
+
+func reportable() interceptors.CommonReportableFunc {
+	return func(ctx context.Context, c interceptors.CallMeta) (interceptors.Reporter, context.Context) {
+		spanCtx := trace.SpanContextFromContext(ctx)
+		if !spanCtx.IsValid() {
+			// without a trace interceptor (e.g. tracing is disabled), continue the trace of the caller so that
+			// the logs and the outgoing calls are still correlated with it
+			ctx = extract(ctx)
+			spanCtx = trace.SpanContextFromContext(ctx)
+		}
+
+		if spanCtx.HasTraceID() {
+			_ = grpc.SetHeader(ctx, metadata.Pairs(TraceIDHeader, spanCtx.TraceID().String()))
+		}
+
+		return interceptors.NoopReporter{}, ctx
+	}
+}
+
+// extract returns a context with the remote span context propagated in the incoming metadata of ctx, if any.
+func extract(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	return otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+}
+
+// metadataCarrier adapts metadata.MD to propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if vals := metadata.MD(c).Get(key); len(vals) > 0 {
+		return vals[0]
+	}
+
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
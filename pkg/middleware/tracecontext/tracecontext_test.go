@@ -0,0 +1,100 @@
+package tracecontext
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type headerCapturingStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (s *headerCapturingStream) Method() string {
+	return "/openfga.v1.OpenFGAService/Check"
+}
+
+func (s *headerCapturingStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	tests := []struct {
+		name            string
+		ctx             func() context.Context
+		expectedTraceID string
+	}{
+		{
+			name: "continues_the_trace_of_the_caller",
+			ctx: func() context.Context {
+				return metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+					"traceparent", "00-"+traceID+"-00f067aa0ba902b7-01",
+				))
+			},
+			expectedTraceID: traceID,
+		},
+		{
+			name: "uses_the_span_in_context",
+			ctx: func() context.Context {
+				tid, err := trace.TraceIDFromHex(traceID)
+				require.NoError(t, err)
+				sid, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+				require.NoError(t, err)
+
+				return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+					TraceID: tid,
+					SpanID:  sid,
+				}))
+			},
+			expectedTraceID: traceID,
+		},
+		{
+			name: "invalid_traceparent",
+			ctx: func() context.Context {
+				return metadata.NewIncomingContext(context.Background(), metadata.Pairs("traceparent", "invalid"))
+			},
+		},
+		{
+			name:            "no_trace",
+			ctx:             context.Background,
+			expectedTraceID: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stream := &headerCapturingStream{}
+			ctx := grpc.NewContextWithServerTransportStream(test.ctx(), stream)
+
+			var handlerTraceID string
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.HasTraceID() {
+					handlerTraceID = spanCtx.TraceID().String()
+				}
+				return nil, nil
+			}
+
+			_, err := NewUnaryInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: stream.Method()}, handler)
+			require.NoError(t, err)
+
+			require.Equal(t, test.expectedTraceID, handlerTraceID)
+			if test.expectedTraceID == "" {
+				require.Empty(t, stream.header.Get(TraceIDHeader))
+			} else {
+				require.Equal(t, []string{test.expectedTraceID}, stream.header.Get(TraceIDHeader))
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+// Package modelvalidation contains the hooks that validate the authorization models written to the stores
+// beyond their validity, e.g. to enforce naming conventions, mandatory relations, or to forbid wildcards.
+//
+// The hooks are either Go plugins or CEL rules. A rejected model fails WriteAuthorizationModel with a
+// validation error.
+package modelvalidation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"plugin"
+	"sort"
+
+	"github.com/google/cel-go/cel"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+)
+
+const (
+	modelVariable   = "model"
+	storeIDVariable = "store_id"
+
+	// PluginSymbol is the symbol that the Go plugins must export: either a Validator, or a function with the
+	// signature of ValidatorFunc.
+	PluginSymbol = "Validator"
+)
+
+// ErrNoValidators is returned by New when neither a rule nor a plugin is provided.
+var ErrNoValidators = errors.New("no authorization model validation rule or plugin")
+
+// Validator validates the authorization models written to a store. It returns an error that describes why
+// the model is rejected, or nil if the model is accepted.
+type Validator interface {
+	Validate(ctx context.Context, storeID string, model *openfgav1.AuthorizationModel) error
+}
+
+// ValidatorFunc is a function that implements Validator.
+type ValidatorFunc func(ctx context.Context, storeID string, model *openfgav1.AuthorizationModel) error
+
+func (f ValidatorFunc) Validate(ctx context.Context, storeID string, model *openfgav1.AuthorizationModel) error {
+	return f(ctx, storeID, model)
+}
+
+// Validators is a Validator that rejects the models that any of its validators rejects.
+type Validators []Validator
+
+func (v Validators) Validate(ctx context.Context, storeID string, model *openfgav1.AuthorizationModel) error {
+	for _, validator := range v {
+		if err := validator.Validate(ctx, storeID, model); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadPlugin loads a Validator from the Go plugin at the provided path, which must export PluginSymbol.
+func LoadPlugin(path string) (Validator, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the authorization model validation plugin '%s': %w", path, err)
+	}
+
+	symbol, err := p.Lookup(PluginSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("the authorization model validation plugin '%s' doesn't export '%s': %w", path, PluginSymbol, err)
+	}
+
+	switch v := symbol.(type) {
+	case *Validator:
+		return *v, nil
+	case Validator:
+		return v, nil
+	case func(context.Context, string, *openfgav1.AuthorizationModel) error:
+		return ValidatorFunc(v), nil
+	default:
+		return nil, fmt.Errorf("the '%s' symbol of the authorization model validation plugin '%s' must be a Validator or a ValidatorFunc, got %T", PluginSymbol, path, symbol)
+	}
+}
+
+// CELValidator rejects the models for which any of its CEL rules evaluates to false. The rules can use the
+// following variables:
+//
+//   - store_id: the ID of the store the model is written to
+//   - model: the model, as a map with the 'schema_version' and the 'types' keys. Every type is a map with the
+//     'name' and the 'relations' keys, and every relation a map with the 'name' and the
+//     'directly_related_user_types' keys, the latter being a list of user types (e.g. 'user', 'user:*' or
+//     'group#member')
+//
+// For example, the following rules require an 'owner' relation on every type that has relations, and forbid
+// the wildcards:
+//
+//	model.types.all(t, size(t.relations) == 0 || t.relations.exists(r, r.name == "owner"))
+//	model.types.all(t, t.relations.all(r, r.directly_related_user_types.all(u, !u.endsWith(":*"))))
+type CELValidator struct {
+	rules    []string
+	programs []cel.Program
+}
+
+// NewCELValidator compiles the provided CEL rules into a CELValidator.
+func NewCELValidator(rules []string) (*CELValidator, error) {
+	env, err := cel.NewEnv(
+		cel.Variable(modelVariable, cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable(storeIDVariable, cel.StringType),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &CELValidator{}
+	for _, rule := range rules {
+		ast, issues := env.Compile(rule)
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("invalid authorization model validation rule '%s': %w", rule, issues.Err())
+		}
+
+		if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+			return nil, fmt.Errorf("invalid authorization model validation rule '%s': it must evaluate to a bool", rule)
+		}
+
+		program, err := env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("invalid authorization model validation rule '%s': %w", rule, err)
+		}
+
+		v.rules = append(v.rules, rule)
+		v.programs = append(v.programs, program)
+	}
+
+	return v, nil
+}
+
+// Validate evaluates the rules against the model, and returns an error naming the first rule it violates.
+func (v *CELValidator) Validate(ctx context.Context, storeID string, model *openfgav1.AuthorizationModel) error {
+	vars := map[string]interface{}{
+		modelVariable:   modelToMap(model),
+		storeIDVariable: storeID,
+	}
+
+	for i, program := range v.programs {
+		out, _, err := program.ContextEval(ctx, vars)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate the authorization model validation rule '%s': %w", v.rules[i], err)
+		}
+
+		valid, ok := out.Value().(bool)
+		if !ok {
+			return fmt.Errorf("the authorization model validation rule '%s' must evaluate to a bool", v.rules[i])
+		}
+		if !valid {
+			return fmt.Errorf("the authorization model violates the rule '%s'", v.rules[i])
+		}
+	}
+
+	return nil
+}
+
+// modelToMap converts a model to the map that the CEL rules evaluate. The relations are sorted by name.
+func modelToMap(model *openfgav1.AuthorizationModel) map[string]interface{} {
+	types := make([]interface{}, 0, len(model.GetTypeDefinitions()))
+	for _, typeDef := range model.GetTypeDefinitions() {
+		names := make([]string, 0, len(typeDef.GetRelations()))
+		for name := range typeDef.GetRelations() {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		relations := make([]interface{}, 0, len(names))
+		for _, name := range names {
+			directlyRelated := []string{}
+			for _, ref := range typeDef.GetMetadata().GetRelations()[name].GetDirectlyRelatedUserTypes() {
+				directlyRelated = append(directlyRelated, userTypeString(ref))
+			}
+
+			relations = append(relations, map[string]interface{}{
+				"name":                        name,
+				"directly_related_user_types": directlyRelated,
+			})
+		}
+
+		types = append(types, map[string]interface{}{
+			"name":      typeDef.GetType(),
+			"relations": relations,
+		})
+	}
+
+	return map[string]interface{}{
+		"schema_version": model.GetSchemaVersion(),
+		"types":          types,
+	}
+}
+
+// userTypeString returns the user type of a relation reference as it's written in the DSL.
+func userTypeString(ref *openfgav1.RelationReference) string {
+	switch {
+	case ref.GetWildcard() != nil:
+		return ref.GetType() + ":*"
+	case ref.GetRelation() != "":
+		return ref.GetType() + "#" + ref.GetRelation()
+	default:
+		return ref.GetType()
+	}
+}
+
+// New returns a Validator that rejects the models that violate any of the CEL rules, or that any of the Go
+// plugins at the provided paths rejects.
+func New(rules []string, pluginPaths []string) (Validator, error) {
+	if len(rules) == 0 && len(pluginPaths) == 0 {
+		return nil, ErrNoValidators
+	}
+
+	var validators Validators
+	if len(rules) > 0 {
+		celValidator, err := NewCELValidator(rules)
+		if err != nil {
+			return nil, err
+		}
+		validators = append(validators, celValidator)
+	}
+
+	for _, path := range pluginPaths {
+		validator, err := LoadPlugin(path)
+		if err != nil {
+			return nil, err
+		}
+		validators = append(validators, validator)
+	}
+
+	return validators, nil
+}
@@ -0,0 +1,125 @@
+package modelvalidation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCELValidatorRejectsInvalidRules(t *testing.T) {
+	_, err := NewCELValidator([]string{`model.types.all(t,`})
+	require.ErrorContains(t, err, "invalid authorization model validation rule")
+
+	_, err = NewCELValidator([]string{`unknown_variable`})
+	require.ErrorContains(t, err, "invalid authorization model validation rule")
+
+	_, err = NewCELValidator([]string{`store_id`})
+	require.ErrorContains(t, err, "it must evaluate to a bool")
+}
+
+func TestCELValidator(t *testing.T) {
+	validator, err := NewCELValidator([]string{
+		`model.types.all(t, t.name.matches("^[a-z_]+$"))`,
+		`model.types.all(t, size(t.relations) == 0 || t.relations.exists(r, r.name == "owner"))`,
+		`model.types.all(t, t.relations.all(r, r.directly_related_user_types.all(u, !u.endsWith(":*"))))`,
+		`store_id != "frozen"`,
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		storeID       string
+		model         string
+		expectedError string
+	}{
+		{
+			name:    "valid",
+			storeID: "store",
+			model: `type user
+type document
+  relations
+    define owner: [user] as self
+    define viewer: [user, document#owner] as self or owner`,
+		},
+		{
+			name:    "naming_convention",
+			storeID: "store",
+			model: `type user
+type Document`,
+			expectedError: `the authorization model violates the rule 'model.types.all(t, t.name.matches("^[a-z_]+$"))'`,
+		},
+		{
+			name:    "mandatory_relation",
+			storeID: "store",
+			model: `type user
+type document
+  relations
+    define viewer: [user] as self`,
+			expectedError: `the authorization model violates the rule 'model.types.all(t, size(t.relations) == 0 || t.relations.exists(r, r.name == "owner"))'`,
+		},
+		{
+			name:    "wildcard",
+			storeID: "store",
+			model: `type user
+type document
+  relations
+    define owner: [user, user:*] as self`,
+			expectedError: `the authorization model violates the rule 'model.types.all(t, t.relations.all(r, r.directly_related_user_types.all(u, !u.endsWith(":*"))))'`,
+		},
+		{
+			name:          "store",
+			storeID:       "frozen",
+			model:         `type user`,
+			expectedError: `the authorization model violates the rule 'store_id != "frozen"'`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			model := &openfgav1.AuthorizationModel{
+				SchemaVersion:   typesystem.SchemaVersion1_1,
+				TypeDefinitions: parser.MustParse(test.model),
+			}
+
+			err := validator.Validate(context.Background(), test.storeID, model)
+			if test.expectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, test.expectedError)
+			}
+		})
+	}
+}
+
+func TestValidators(t *testing.T) {
+	var calls int
+	accept := ValidatorFunc(func(context.Context, string, *openfgav1.AuthorizationModel) error {
+		calls++
+		return nil
+	})
+	reject := ValidatorFunc(func(context.Context, string, *openfgav1.AuthorizationModel) error {
+		calls++
+		return errors.New("rejected")
+	})
+
+	err := Validators{accept, reject, accept}.Validate(context.Background(), "store", &openfgav1.AuthorizationModel{})
+	require.EqualError(t, err, "rejected")
+	require.Equal(t, 2, calls)
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(nil, nil)
+	require.ErrorIs(t, err, ErrNoValidators)
+
+	_, err = New(nil, []string{"/does/not/exist.so"})
+	require.ErrorContains(t, err, "failed to open the authorization model validation plugin '/does/not/exist.so'")
+
+	validator, err := New([]string{`true`}, nil)
+	require.NoError(t, err)
+	require.NoError(t, validator.Validate(context.Background(), "store", &openfgav1.AuthorizationModel{}))
+}
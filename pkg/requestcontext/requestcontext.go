@@ -0,0 +1,205 @@
+// Package requestcontext holds the metadata of the request being served in its context: its ID, the API
+// method, the caller, the store and the authorization model. The middleware, the server, the logger and the
+// storage decorators all read it from here.
+//
+// The metadata is attached to the context once, by the first middleware (see NewContext), and is completed
+// as the request is served, e.g. the store once the request message is received and the authorization model
+// once it's resolved. So the setters update the metadata of the context in place, and are no-ops if the
+// context has none.
+package requestcontext
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+type ctxKey struct{}
+
+// requestMetadata is the metadata of a request, which is shared by every context derived from the context it
+// was attached to.
+type requestMetadata struct {
+	mu sync.RWMutex
+
+	requestID            string
+	method               string
+	caller               string
+	storeID              string
+	authorizationModelID string
+	storeResidency       string
+	consistency          string
+
+	cacheBypassed  bool
+	bypassedCaches map[string]struct{}
+}
+
+// NewContext returns a context with empty metadata, unless the parent context already has some.
+func NewContext(parent context.Context) context.Context {
+	if _, ok := parent.Value(ctxKey{}).(*requestMetadata); ok {
+		return parent
+	}
+
+	return context.WithValue(parent, ctxKey{}, &requestMetadata{})
+}
+
+// HasMetadata reports whether the context has request metadata.
+func HasMetadata(ctx context.Context) bool {
+	_, ok := ctx.Value(ctxKey{}).(*requestMetadata)
+	return ok
+}
+
+// CopyTo returns the dst context with the metadata of the src context, if any, e.g. for the operations which
+// mustn't be canceled with the request.
+func CopyTo(dst, src context.Context) context.Context {
+	md, ok := src.Value(ctxKey{}).(*requestMetadata)
+	if !ok {
+		return dst
+	}
+
+	return context.WithValue(dst, ctxKey{}, md)
+}
+
+func set(ctx context.Context, update func(md *requestMetadata)) {
+	md, ok := ctx.Value(ctxKey{}).(*requestMetadata)
+	if !ok {
+		return
+	}
+
+	md.mu.Lock()
+	defer md.mu.Unlock()
+
+	update(md)
+}
+
+func get(ctx context.Context, field func(md *requestMetadata) string) (string, bool) {
+	md, ok := ctx.Value(ctxKey{}).(*requestMetadata)
+	if !ok {
+		return "", false
+	}
+
+	md.mu.RLock()
+	defer md.mu.RUnlock()
+
+	value := field(md)
+	return value, value != ""
+}
+
+// SetRequestID sets the ID of the request.
+func SetRequestID(ctx context.Context, requestID string) {
+	set(ctx, func(md *requestMetadata) { md.requestID = requestID })
+}
+
+// RequestID returns the ID of the request, if it's set.
+func RequestID(ctx context.Context) (string, bool) {
+	return get(ctx, func(md *requestMetadata) string { return md.requestID })
+}
+
+// SetMethod sets the name of the API method being served (e.g. 'Check').
+func SetMethod(ctx context.Context, method string) {
+	set(ctx, func(md *requestMetadata) { md.method = method })
+}
+
+// Method returns the name of the API method being served, if it's set.
+func Method(ctx context.Context) (string, bool) {
+	return get(ctx, func(md *requestMetadata) string { return md.method })
+}
+
+// SetCaller sets the subject of the authenticated client that issued the request.
+func SetCaller(ctx context.Context, caller string) {
+	set(ctx, func(md *requestMetadata) { md.caller = caller })
+}
+
+// Caller returns the subject of the authenticated client that issued the request, if it's set.
+func Caller(ctx context.Context) (string, bool) {
+	return get(ctx, func(md *requestMetadata) string { return md.caller })
+}
+
+// SetStoreID sets the ID of the store of the request.
+func SetStoreID(ctx context.Context, storeID string) {
+	set(ctx, func(md *requestMetadata) { md.storeID = storeID })
+}
+
+// StoreID returns the ID of the store of the request, if it's set.
+func StoreID(ctx context.Context) (string, bool) {
+	return get(ctx, func(md *requestMetadata) string { return md.storeID })
+}
+
+// SetAuthorizationModelID sets the ID of the authorization model resolved for the request.
+func SetAuthorizationModelID(ctx context.Context, modelID string) {
+	set(ctx, func(md *requestMetadata) { md.authorizationModelID = modelID })
+}
+
+// AuthorizationModelID returns the ID of the authorization model resolved for the request, if it's set.
+func AuthorizationModelID(ctx context.Context) (string, bool) {
+	return get(ctx, func(md *requestMetadata) string { return md.authorizationModelID })
+}
+
+// SetStoreResidency sets the region the data of the store of the request resides in, either the one requested
+// for a new store or the one the store was found in.
+func SetStoreResidency(ctx context.Context, region string) {
+	set(ctx, func(md *requestMetadata) { md.storeResidency = region })
+}
+
+// StoreResidency returns the region the data of the store of the request resides in, if it's set.
+func StoreResidency(ctx context.Context) (string, bool) {
+	return get(ctx, func(md *requestMetadata) string { return md.storeResidency })
+}
+
+// SetConsistency sets the name of the consistency that the reads of the request require, e.g.
+// 'HIGHER_CONSISTENCY' (see storage.ParseConsistencyPreference).
+func SetConsistency(ctx context.Context, consistency string) {
+	set(ctx, func(md *requestMetadata) { md.consistency = consistency })
+}
+
+// Consistency returns the name of the consistency that the reads of the request require, if it's set.
+func Consistency(ctx context.Context) (string, bool) {
+	return get(ctx, func(md *requestMetadata) string { return md.consistency })
+}
+
+// SetCacheBypassed makes the caches serve nothing to the request, e.g. to verify that a result isn't stale.
+func SetCacheBypassed(ctx context.Context) {
+	set(ctx, func(md *requestMetadata) { md.cacheBypassed = true })
+}
+
+// CacheBypassed reports whether the caches must serve nothing to the request.
+func CacheBypassed(ctx context.Context) bool {
+	md, ok := ctx.Value(ctxKey{}).(*requestMetadata)
+	if !ok {
+		return false
+	}
+
+	md.mu.RLock()
+	defer md.mu.RUnlock()
+
+	return md.cacheBypassed
+}
+
+// AddBypassedCache records that the named cache would have served the request if it weren't bypassed.
+func AddBypassedCache(ctx context.Context, cache string) {
+	set(ctx, func(md *requestMetadata) {
+		if md.bypassedCaches == nil {
+			md.bypassedCaches = map[string]struct{}{}
+		}
+		md.bypassedCaches[cache] = struct{}{}
+	})
+}
+
+// BypassedCaches returns the sorted names of the caches that would have served the request if they weren't
+// bypassed.
+func BypassedCaches(ctx context.Context) []string {
+	md, ok := ctx.Value(ctxKey{}).(*requestMetadata)
+	if !ok {
+		return nil
+	}
+
+	md.mu.RLock()
+	defer md.mu.RUnlock()
+
+	caches := make([]string, 0, len(md.bypassedCaches))
+	for cache := range md.bypassedCaches {
+		caches = append(caches, cache)
+	}
+	sort.Strings(caches)
+
+	return caches
+}
@@ -0,0 +1,90 @@
+package requestcontext
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestContext(t *testing.T) {
+	t.Run("without_metadata", func(t *testing.T) {
+		ctx := context.Background()
+		SetRequestID(ctx, "3e2d0c7e-9c43-4b2f-9d2e-1f3d7b1b5f0a")
+
+		_, ok := RequestID(ctx)
+		require.False(t, ok)
+		require.False(t, HasMetadata(ctx))
+	})
+
+	t.Run("metadata_is_shared_by_the_derived_contexts", func(t *testing.T) {
+		ctx := NewContext(context.Background())
+		require.True(t, HasMetadata(ctx))
+		require.Equal(t, ctx, NewContext(ctx))
+
+		_, ok := StoreID(ctx)
+		require.False(t, ok)
+
+		child, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		SetRequestID(ctx, "3e2d0c7e-9c43-4b2f-9d2e-1f3d7b1b5f0a")
+		SetMethod(ctx, "Check")
+		SetCaller(child, "client-1")
+		SetStoreID(child, "01HCSBNPGRMSRYJRJRZ0KCZP1C")
+		SetAuthorizationModelID(child, "01HCSBNWV4YGBQW0Y8HP1Q4NKJ")
+		SetStoreResidency(child, "eu")
+
+		tests := []struct {
+			get      func(context.Context) (string, bool)
+			expected string
+		}{
+			{RequestID, "3e2d0c7e-9c43-4b2f-9d2e-1f3d7b1b5f0a"},
+			{Method, "Check"},
+			{Caller, "client-1"},
+			{StoreID, "01HCSBNPGRMSRYJRJRZ0KCZP1C"},
+			{AuthorizationModelID, "01HCSBNWV4YGBQW0Y8HP1Q4NKJ"},
+			{StoreResidency, "eu"},
+		}
+
+		for _, test := range tests {
+			value, ok := test.get(ctx)
+			require.True(t, ok)
+			require.Equal(t, test.expected, value)
+		}
+	})
+
+	t.Run("copy_to", func(t *testing.T) {
+		ctx := NewContext(context.Background())
+		SetStoreID(ctx, "01HCSBNPGRMSRYJRJRZ0KCZP1C")
+
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		copied := CopyTo(context.Background(), ctx)
+		require.NoError(t, copied.Err())
+
+		storeID, ok := StoreID(copied)
+		require.True(t, ok)
+		require.Equal(t, "01HCSBNPGRMSRYJRJRZ0KCZP1C", storeID)
+
+		_, ok = StoreID(CopyTo(context.Background(), context.Background()))
+		require.False(t, ok)
+	})
+	t.Run("cache_bypass", func(t *testing.T) {
+		require.False(t, CacheBypassed(context.Background()))
+		AddBypassedCache(context.Background(), "check")
+		require.Empty(t, BypassedCaches(context.Background()))
+
+		ctx := NewContext(context.Background())
+		require.False(t, CacheBypassed(ctx))
+
+		SetCacheBypassed(ctx)
+		require.True(t, CacheBypassed(ctx))
+
+		AddBypassedCache(ctx, "typesystem")
+		AddBypassedCache(ctx, "check")
+		AddBypassedCache(ctx, "typesystem")
+		require.Equal(t, []string{"check", "typesystem"}, BypassedCaches(ctx))
+	})
+}
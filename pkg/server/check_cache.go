@@ -0,0 +1,282 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/internal/graph"
+	"github.com/openfga/openfga/pkg/cachestats"
+	"github.com/openfga/openfga/pkg/events"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"go.uber.org/zap"
+)
+
+const (
+	// checkCacheChangelogPageSize is the number of changes read at once from the changelog of a store.
+	checkCacheChangelogPageSize = 100
+
+	// the names of the caches of the Check results, as reported in their statistics and their events
+	checkCacheName         = "check"
+	checkNegativeCacheName = "check_negative"
+)
+
+// WithCheckQueryCacheChangelogInterval sets the interval at which the changelogs of the stores are read to
+// invalidate the cached Check results that the writes of the other servers sharing the datastore could have
+// changed, which bounds how stale these results can be. 0 disables it, e.g. for a single server.
+func WithCheckQueryCacheChangelogInterval(interval time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkQueryCacheChangelogInterval = interval
+	}
+}
+
+// WithCheckQueryCacheShared shares the cached Check results with the other servers through the provided
+// cache (e.g. a sharedcache.MemcachedCheckCache), if the Check query cache is enabled. The writes invalidate
+// every shared result of their store.
+func WithCheckQueryCacheShared(cache graph.SharedCheckCache) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkSharedCache = cache
+	}
+}
+
+// invalidateCheckCache invalidates the cached Check results that the tuples written to (or deleted from) the
+// store could have changed. It's a no-op if the Check query cache is disabled.
+func (s *Server) invalidateCheckCache(ctx context.Context, typesys *typesystem.TypeSystem, storeID string, tupleKeys []*openfgav1.TupleKey) {
+	if s.checkCacheInvalidator == nil || len(tupleKeys) == 0 {
+		return
+	}
+
+	if s.checkSharedCache != nil {
+		if err := s.checkSharedCache.Invalidate(ctx, storeID); err != nil {
+			// the shared results expire after the TTL of the cache
+			s.logger.WarnWithContext(ctx, "failed to invalidate the shared check query cache",
+				zap.String("store_id", storeID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	invalidated, err := s.checkCacheInvalidator.Invalidate(typesys, storeID, tupleKeys)
+	if err != nil {
+		// the results that weren't invalidated expire after the TTL of the cache
+		s.logger.WarnWithContext(ctx, "failed to invalidate the check query cache",
+			zap.String("store_id", storeID),
+			zap.Error(err),
+		)
+	}
+
+	if invalidated > 0 {
+		s.publishEvent(ctx, events.Event{
+			Type:                 events.CacheInvalidated,
+			StoreID:              storeID,
+			AuthorizationModelID: typesys.GetAuthorizationModelID(),
+			Attributes: map[string]string{
+				"cache":       checkCacheName,
+				"invalidated": strconv.Itoa(invalidated),
+			},
+		})
+	}
+}
+
+// purgeCheckCache invalidates every cached Check result of the store, e.g. when it's deleted. It's a no-op if
+// the Check query cache is disabled.
+func (s *Server) purgeCheckCache(ctx context.Context, storeID string) {
+	if s.checkCacheInvalidator == nil {
+		return
+	}
+
+	if s.checkSharedCache != nil {
+		if err := s.checkSharedCache.Invalidate(ctx, storeID); err != nil {
+			// the shared results expire after the TTL of the cache
+			s.logger.WarnWithContext(ctx, "failed to invalidate the shared check query cache",
+				zap.String("store_id", storeID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	if invalidated := s.checkCacheInvalidator.InvalidateStore(storeID); invalidated > 0 {
+		s.publishEvent(ctx, events.Event{
+			Type:    events.CacheInvalidated,
+			StoreID: storeID,
+			Attributes: map[string]string{
+				"cache":       checkCacheName,
+				"invalidated": strconv.Itoa(invalidated),
+			},
+		})
+	}
+}
+
+// CheckCaches returns the caches of the Check results, to report their statistics and to flush them. It's
+// empty if the Check query cache is disabled.
+func (s *Server) CheckCaches() []cachestats.Cache {
+	if s.checkCache == nil {
+		return nil
+	}
+
+	caches := []cachestats.Cache{
+		cachestats.NewCCache(checkCacheName, s.checkCache, &s.checkCacheCounters, s.checkQueryCacheTTL, graph.CheckCacheStoreKeyPrefix),
+	}
+
+	if s.checkNegativeCache != nil {
+		negativeTTL := s.checkQueryCacheNegativeTTL
+		if negativeTTL == 0 {
+			negativeTTL = s.checkQueryCacheTTL
+		}
+
+		caches = append(caches, cachestats.NewCCache(checkNegativeCacheName, s.checkNegativeCache, &s.checkNegativeCacheCounters, negativeTTL, graph.CheckCacheStoreKeyPrefix))
+	}
+
+	return caches
+}
+
+// checkCacheChangelogTailer reads the changelogs of the stores at an interval, and invalidates the cached
+// Check results that the changes could have changed.
+type checkCacheChangelogTailer struct {
+	server   *Server
+	interval time.Duration
+
+	// tokens are the continuation tokens of the changelogs of the stores read so far.
+	tokens map[string]string
+
+	// startedAt is when the tailer started. The earlier changes are skipped, since nothing was cached then.
+	startedAt time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// startCheckCacheChangelogTailer starts reading the changelogs of the stores, if the Check query cache and
+// the changelog interval are both enabled.
+func (s *Server) startCheckCacheChangelogTailer() {
+	if s.checkCacheInvalidator == nil || s.checkQueryCacheChangelogInterval <= 0 {
+		return
+	}
+
+	s.logger.Info("invalidating the check query cache from the changelogs of the stores",
+		zap.Duration("interval", s.checkQueryCacheChangelogInterval))
+
+	s.checkCacheChangelogTailer = &checkCacheChangelogTailer{
+		server:    s,
+		interval:  s.checkQueryCacheChangelogInterval,
+		tokens:    map[string]string{},
+		startedAt: time.Now(),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go s.checkCacheChangelogTailer.run()
+}
+
+// Stop stops reading the changelogs and waits for the current read, if any, to end.
+func (t *checkCacheChangelogTailer) Stop() {
+	close(t.stop)
+	<-t.done
+}
+
+func (t *checkCacheChangelogTailer) run() {
+	defer close(t.done)
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		t.poll(context.Background())
+
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll reads the new changes of the changelog of every store.
+func (t *checkCacheChangelogTailer) poll(ctx context.Context) {
+	storeIDs, err := t.listStores(ctx)
+	if err != nil {
+		t.server.logger.Warn("failed to list the stores to read their changelogs", zap.Error(err))
+		return
+	}
+
+	// the stores that don't exist anymore are forgotten
+	tokens := make(map[string]string, len(storeIDs))
+	for _, storeID := range storeIDs {
+		token, err := t.tailStore(ctx, storeID, t.tokens[storeID])
+		if err != nil {
+			t.server.logger.Warn("failed to read the changelog of a store to invalidate the check query cache",
+				zap.String("store_id", storeID),
+				zap.Error(err),
+			)
+		}
+		tokens[storeID] = token
+	}
+
+	t.tokens = tokens
+}
+
+func (t *checkCacheChangelogTailer) listStores(ctx context.Context) ([]string, error) {
+	var storeIDs []string
+
+	var token string
+	for {
+		stores, next, err := t.server.datastore.ListStores(ctx, storage.NewPaginationOptions(0, token))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, store := range stores {
+			storeIDs = append(storeIDs, store.GetId())
+		}
+
+		token = string(next)
+		if token == "" {
+			return storeIDs, nil
+		}
+	}
+}
+
+// tailStore reads the changes of the store after the continuation token, invalidates the cached results they
+// could have changed, and returns the continuation token of the last change read.
+func (t *checkCacheChangelogTailer) tailStore(ctx context.Context, storeID, token string) (string, error) {
+	horizonOffset := time.Duration(t.server.changelogHorizonOffset) * time.Minute
+
+	for {
+		changes, next, err := t.server.datastore.ReadChanges(ctx, storeID, "",
+			storage.NewPaginationOptions(checkCacheChangelogPageSize, token),
+			horizonOffset,
+			storage.ReadOptions{},
+		)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return token, nil
+			}
+			return token, err
+		}
+
+		tupleKeys := make([]*openfgav1.TupleKey, 0, len(changes))
+		for _, change := range changes {
+			if !change.GetTimestamp().AsTime().Before(t.startedAt) {
+				tupleKeys = append(tupleKeys, change.GetTupleKey())
+			}
+		}
+
+		if len(tupleKeys) > 0 {
+			// the changes don't record the model they were written with, so the latest one is assumed
+			typesys, err := t.server.typesystemResolver(ctx, storeID, "")
+			if err != nil && !errors.Is(err, typesystem.ErrModelNotFound) {
+				return token, err
+			}
+			if typesys != nil {
+				t.server.invalidateCheckCache(ctx, typesys, storeID, tupleKeys)
+			}
+		}
+
+		token = string(next)
+		if len(changes) < checkCacheChangelogPageSize {
+			return token, nil
+		}
+	}
+}
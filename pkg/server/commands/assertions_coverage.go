@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/internal/validation"
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// AssertionsCoverageRequest requests the assertions coverage report of an authorization model. If the
+// AuthorizationModelID is empty, the latest authorization model of the store is used.
+type AssertionsCoverageRequest struct {
+	StoreID              string
+	AuthorizationModelID string
+}
+
+// RelationCoverage is the number of assertions that target a relation of an object type.
+type RelationCoverage struct {
+	ObjectType string `json:"object_type"`
+	Relation   string `json:"relation"`
+	Assertions uint32 `json:"assertions"`
+}
+
+// AssertionsCoverageResponse reports which relations of an authorization model are exercised by
+// its assertions.
+type AssertionsCoverageResponse struct {
+	AuthorizationModelID string `json:"authorization_model_id"`
+
+	// Relations holds every relation of the model, sorted by object type and relation.
+	Relations []*RelationCoverage `json:"relations"`
+
+	// UncoveredRelations holds the 'type#relation' pairs that no assertion targets.
+	UncoveredRelations []string `json:"uncovered_relations"`
+
+	// InvalidAssertions holds the assertions that can't be mapped onto the model, for example because they
+	// target a relation that has since been removed.
+	InvalidAssertions []*openfgav1.Assertion `json:"invalid_assertions"`
+
+	// Coverage is the ratio of relations that are targeted by at least one assertion. A model without
+	// relations is fully covered.
+	Coverage float64 `json:"coverage"`
+}
+
+// AssertionsCoverageQuery maps the assertions of an authorization model onto the model and reports
+// which relations have no assertions.
+type AssertionsCoverageQuery struct {
+	backend storage.AssertionsBackend
+	logger  logger.Logger
+}
+
+func NewAssertionsCoverageQuery(backend storage.AssertionsBackend, logger logger.Logger) *AssertionsCoverageQuery {
+	return &AssertionsCoverageQuery{
+		backend: backend,
+		logger:  logger,
+	}
+}
+
+// Execute computes the assertions coverage of the model of the provided typesystem.
+func (q *AssertionsCoverageQuery) Execute(ctx context.Context, store string, typesys *typesystem.TypeSystem) (*AssertionsCoverageResponse, error) {
+	assertions, err := q.backend.ReadAssertions(ctx, store, typesys.GetAuthorizationModelID())
+	if err != nil {
+		return nil, serverErrors.HandleError(ctx, "", err)
+	}
+
+	return ComputeAssertionsCoverage(typesys, assertions), nil
+}
+
+// ComputeAssertionsCoverage maps the assertions onto the model of the provided typesystem.
+func ComputeAssertionsCoverage(typesys *typesystem.TypeSystem, assertions []*openfgav1.Assertion) *AssertionsCoverageResponse {
+	resp := &AssertionsCoverageResponse{
+		AuthorizationModelID: typesys.GetAuthorizationModelID(),
+		Relations:            []*RelationCoverage{},
+		UncoveredRelations:   []string{},
+		InvalidAssertions:    []*openfgav1.Assertion{},
+		Coverage:             1,
+	}
+
+	// [type#relation] => coverage
+	coverage := map[string]*RelationCoverage{}
+	for _, typedef := range typesys.GetAllTypeDefinitions() {
+		for relation := range typedef.GetRelations() {
+			rc := &RelationCoverage{ObjectType: typedef.GetType(), Relation: relation}
+			coverage[fmt.Sprintf("%s#%s", typedef.GetType(), relation)] = rc
+			resp.Relations = append(resp.Relations, rc)
+		}
+	}
+
+	sort.Slice(resp.Relations, func(i, j int) bool {
+		if resp.Relations[i].ObjectType != resp.Relations[j].ObjectType {
+			return resp.Relations[i].ObjectType < resp.Relations[j].ObjectType
+		}
+
+		return resp.Relations[i].Relation < resp.Relations[j].Relation
+	})
+
+	for _, assertion := range assertions {
+		tk := assertion.GetTupleKey()
+		if err := validation.ValidateUserObjectRelation(typesys, tk); err != nil {
+			resp.InvalidAssertions = append(resp.InvalidAssertions, assertion)
+			continue
+		}
+
+		objectType, _ := tuple.SplitObject(tk.GetObject())
+		coverage[fmt.Sprintf("%s#%s", objectType, tk.GetRelation())].Assertions++
+	}
+
+	if len(resp.Relations) == 0 {
+		return resp
+	}
+
+	for _, rc := range resp.Relations {
+		if rc.Assertions == 0 {
+			resp.UncoveredRelations = append(resp.UncoveredRelations, fmt.Sprintf("%s#%s", rc.ObjectType, rc.Relation))
+		}
+	}
+
+	resp.Coverage = float64(len(resp.Relations)-len(resp.UncoveredRelations)) / float64(len(resp.Relations))
+
+	return resp
+}
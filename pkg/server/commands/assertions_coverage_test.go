@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	mockstorage "github.com/openfga/openfga/internal/mocks"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeAssertionsCoverage(t *testing.T) {
+	typesys := typesystem.New(&openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type folder
+		  relations
+			define viewer: [user] as self
+
+		type document
+		  relations
+			define parent: [folder] as self
+			define editor: [user] as self
+			define viewer: [user] as self or editor or viewer from parent
+		`),
+	})
+
+	tests := []struct {
+		name               string
+		assertions         []*openfgav1.Assertion
+		expectedUncovered  []string
+		expectedInvalid    int
+		expectedCoverage   float64
+		expectedAssertions map[string]uint32
+	}{
+		{
+			name:              "no_assertions",
+			expectedUncovered: []string{"document#editor", "document#parent", "document#viewer", "folder#viewer"},
+			expectedCoverage:  0,
+		},
+		{
+			name: "some_relations_covered",
+			assertions: []*openfgav1.Assertion{
+				{TupleKey: tuple.NewTupleKey("document:1", "viewer", "user:anne"), Expectation: true},
+				{TupleKey: tuple.NewTupleKey("document:1", "viewer", "user:bob"), Expectation: false},
+				{TupleKey: tuple.NewTupleKey("folder:1", "viewer", "user:anne"), Expectation: true},
+			},
+			expectedUncovered:  []string{"document#editor", "document#parent"},
+			expectedCoverage:   0.5,
+			expectedAssertions: map[string]uint32{"document#viewer": 2, "folder#viewer": 1},
+		},
+		{
+			name: "assertions_that_do_not_match_the_model_are_reported",
+			assertions: []*openfgav1.Assertion{
+				{TupleKey: tuple.NewTupleKey("document:1", "owner", "user:anne"), Expectation: true},
+				{TupleKey: tuple.NewTupleKey("repo:1", "viewer", "user:anne"), Expectation: true},
+				{TupleKey: tuple.NewTupleKey("document:1", "editor", "user:anne"), Expectation: true},
+			},
+			expectedUncovered:  []string{"document#parent", "document#viewer", "folder#viewer"},
+			expectedInvalid:    2,
+			expectedCoverage:   0.25,
+			expectedAssertions: map[string]uint32{"document#editor": 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := ComputeAssertionsCoverage(typesys, test.assertions)
+
+			require.Equal(t, typesys.GetAuthorizationModelID(), resp.AuthorizationModelID)
+			require.Len(t, resp.Relations, 4)
+			require.Equal(t, test.expectedUncovered, resp.UncoveredRelations)
+			require.Len(t, resp.InvalidAssertions, test.expectedInvalid)
+			require.InDelta(t, test.expectedCoverage, resp.Coverage, 0.0001)
+
+			for _, rc := range resp.Relations {
+				require.Equal(t, test.expectedAssertions[rc.ObjectType+"#"+rc.Relation], rc.Assertions)
+			}
+		})
+	}
+
+	t.Run("model_without_relations_is_fully_covered", func(t *testing.T) {
+		resp := ComputeAssertionsCoverage(typesystem.New(&openfgav1.AuthorizationModel{
+			SchemaVersion:   typesystem.SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(`type user`),
+		}), nil)
+
+		require.Empty(t, resp.Relations)
+		require.Empty(t, resp.UncoveredRelations)
+		require.InDelta(t, 1, resp.Coverage, 0.0001)
+	})
+}
+
+func TestAssertionsCoverageQuery(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+	typesys := typesystem.New(&openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type document
+		  relations
+			define viewer: [user] as self
+		`),
+	})
+
+	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+	mockDatastore.EXPECT().ReadAssertions(gomock.Any(), storeID, typesys.GetAuthorizationModelID()).Return([]*openfgav1.Assertion{
+		{TupleKey: tuple.NewTupleKey("document:1", "viewer", "user:anne"), Expectation: true},
+	}, nil)
+
+	resp, err := NewAssertionsCoverageQuery(mockDatastore, logger.NewNoopLogger()).Execute(ctx, storeID, typesys)
+	require.NoError(t, err)
+	require.Empty(t, resp.UncoveredRelations)
+	require.InDelta(t, 1, resp.Coverage, 0.0001)
+}
@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+const (
+	// MaxAuthorizationModelLabels is the maximum number of labels written with an authorization model.
+	MaxAuthorizationModelLabels = 16
+
+	maxAuthorizationModelLabelValueLength = 255
+)
+
+// authorizationModelLabelKeyRegex matches the keys of the labels, e.g. 'release' or 'git_sha'.
+var authorizationModelLabelKeyRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,62}$`)
+
+// ParseAuthorizationModelLabel parses a label of an authorization model, written as 'key=value' (e.g.
+// 'release=42'). The key is made of at most 63 lowercase letters, digits, '_', '.' and '-', and the value is
+// a non-empty string of at most 255 characters without a comma.
+func ParseAuthorizationModelLabel(label string) (string, string, error) {
+	key, value, found := strings.Cut(strings.TrimSpace(label), "=")
+	if !found {
+		return "", "", fmt.Errorf("invalid label '%s': the labels are written as 'key=value'", label)
+	}
+
+	if !authorizationModelLabelKeyRegex.MatchString(key) {
+		return "", "", fmt.Errorf("invalid label '%s': the key must match %s", label, authorizationModelLabelKeyRegex)
+	}
+
+	if value == "" || len(value) > maxAuthorizationModelLabelValueLength || strings.Contains(value, ",") {
+		return "", "", fmt.Errorf("invalid label '%s': the value must be a non-empty string of at most %d characters without a comma", label, maxAuthorizationModelLabelValueLength)
+	}
+
+	return key, value, nil
+}
+
+// ParseAuthorizationModelLabels parses the comma-separated lists of labels of an authorization model (e.g.
+// 'release=42,git_sha=3f2a9c1'). A key can't be repeated.
+func ParseAuthorizationModelLabels(lists ...string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, list := range lists {
+		if strings.TrimSpace(list) == "" {
+			continue
+		}
+
+		for _, label := range strings.Split(list, ",") {
+			key, value, err := ParseAuthorizationModelLabel(label)
+			if err != nil {
+				return nil, err
+			}
+
+			if _, ok := labels[key]; ok {
+				return nil, fmt.Errorf("the label '%s' is set more than once", key)
+			}
+			labels[key] = value
+		}
+	}
+
+	if len(labels) > MaxAuthorizationModelLabels {
+		return nil, fmt.Errorf("an authorization model can't be written with more than %d labels", MaxAuthorizationModelLabels)
+	}
+
+	return labels, nil
+}
+
+// GetAuthorizationModelByLabelRequest requests the latest authorization model of a store that has a label.
+type GetAuthorizationModelByLabelRequest struct {
+	StoreID string
+
+	// Label is written as 'key=value', e.g. 'release=42' (see ParseAuthorizationModelLabel).
+	Label string
+}
+
+// GetAuthorizationModelByLabelResponse is the authorization model found, along with all its labels.
+type GetAuthorizationModelByLabelResponse struct {
+	AuthorizationModel *openfgav1.AuthorizationModel
+	Labels             map[string]string
+}
+
+// GetAuthorizationModelByLabelQuery finds the latest authorization model of a store that has a label, so that
+// deployments can refer to 'the model of the release 42' rather than to its ID.
+type GetAuthorizationModelByLabelQuery struct {
+	backend storage.OpenFGADatastore
+	logger  logger.Logger
+}
+
+func NewGetAuthorizationModelByLabelQuery(backend storage.OpenFGADatastore, logger logger.Logger) *GetAuthorizationModelByLabelQuery {
+	return &GetAuthorizationModelByLabelQuery{backend: backend, logger: logger}
+}
+
+func (q *GetAuthorizationModelByLabelQuery) Execute(ctx context.Context, req *GetAuthorizationModelByLabelRequest) (*GetAuthorizationModelByLabelResponse, error) {
+	key, value, err := ParseAuthorizationModelLabel(req.Label)
+	if err != nil {
+		return nil, serverErrors.ValidationError(err)
+	}
+
+	modelID, err := q.backend.FindAuthorizationModelIDByLabel(ctx, req.StoreID, key, value)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, serverErrors.AuthorizationModelLabelNotFound(req.Label)
+		}
+		return nil, serverErrors.HandleError(ctx, "", err)
+	}
+
+	model, err := q.backend.ReadAuthorizationModel(ctx, req.StoreID, modelID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, serverErrors.AuthorizationModelNotFound(modelID)
+		}
+		return nil, serverErrors.HandleError(ctx, "", err)
+	}
+
+	labels, err := q.backend.ReadAuthorizationModelLabels(ctx, req.StoreID, modelID)
+	if err != nil {
+		return nil, serverErrors.HandleError(ctx, "", err)
+	}
+
+	return &GetAuthorizationModelByLabelResponse{
+		AuthorizationModel: model,
+		Labels:             labels,
+	}, nil
+}
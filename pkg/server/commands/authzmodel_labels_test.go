@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAuthorizationModelLabels(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		lists    []string
+		expected map[string]string
+		err      string
+	}{
+		{
+			name:     "lists",
+			lists:    []string{"release=42, git_sha=3f2a9c1", "env=prod", ""},
+			expected: map[string]string{"release": "42", "git_sha": "3f2a9c1", "env": "prod"},
+		},
+		{
+			name:     "value_with_equal_signs",
+			lists:    []string{"query=a=b"},
+			expected: map[string]string{"query": "a=b"},
+		},
+		{
+			name:  "missing_value",
+			lists: []string{"release"},
+			err:   "invalid label 'release': the labels are written as 'key=value'",
+		},
+		{
+			name:  "empty_value",
+			lists: []string{"release="},
+			err:   "invalid label 'release=': the value must be a non-empty string of at most 255 characters without a comma",
+		},
+		{
+			name:  "invalid_key",
+			lists: []string{"Release=42"},
+			err:   "invalid label 'Release=42': the key must match ^[a-z0-9][a-z0-9_.-]{0,62}$",
+		},
+		{
+			name:  "repeated_key",
+			lists: []string{"release=41", "release=42"},
+			err:   "the label 'release' is set more than once",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			labels, err := ParseAuthorizationModelLabels(tc.lists...)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, labels)
+		})
+	}
+
+	t.Run("too_many_labels", func(t *testing.T) {
+		labels := make([]string, 0, MaxAuthorizationModelLabels+1)
+		for i := 0; i <= MaxAuthorizationModelLabels; i++ {
+			labels = append(labels, string(rune('a'+i))+"=1")
+		}
+
+		_, err := ParseAuthorizationModelLabels(strings.Join(labels, ","))
+		require.EqualError(t, err, "an authorization model can't be written with more than 16 labels")
+	})
+}
@@ -0,0 +1,31 @@
+package commands
+
+import (
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+)
+
+// BatchCheckRequest requests the Checks of several tuple keys of a store at once, e.g. the ones a page needs
+// to be rendered. If the AuthorizationModelID is empty, the latest authorization model of the store is used.
+// The contextual tuples apply to every Check.
+type BatchCheckRequest struct {
+	StoreID              string
+	AuthorizationModelID string
+	TupleKeys            []*openfgav1.TupleKey
+	ContextualTuples     []*openfgav1.TupleKey
+	// Context is the context the conditions of the tuples are evaluated with (see the condition package)
+	Context map[string]interface{}
+}
+
+// BatchCheckResult is the result of the Check of a tuple key of a BatchCheckRequest. If the Check failed,
+// Error is the error Check would have returned, and Allowed is false.
+type BatchCheckResult struct {
+	TupleKey *openfgav1.TupleKey
+	Allowed  bool
+	Error    error
+}
+
+// BatchCheckResponse holds the result of the Check of every tuple key of a BatchCheckRequest, in the order of
+// the request.
+type BatchCheckResponse struct {
+	Results []*BatchCheckResult
+}
@@ -0,0 +1,28 @@
+package commands
+
+import (
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+)
+
+// MaxRelationsPerCheckRelations is the maximum number of relations that can be checked in a single
+// CheckRelations call.
+const MaxRelationsPerCheckRelations = 50
+
+// CheckRelationsRequest requests whether a user has each of a set of relations with an object, e.g. to
+// gate the actions of a UI. If the AuthorizationModelID is empty, the latest authorization model of the
+// store is used.
+type CheckRelationsRequest struct {
+	StoreID              string
+	AuthorizationModelID string
+	Object               string
+	User                 string
+	Relations            []string
+	ContextualTuples     []*openfgav1.TupleKey
+	// Context is the context the conditions of the tuples are evaluated with (see the condition package)
+	Context map[string]interface{}
+}
+
+// CheckRelationsResponse maps each relation of the request to whether the user has it with the object.
+type CheckRelationsResponse struct {
+	Relations map[string]bool `json:"relations"`
+}
@@ -31,7 +31,7 @@ func (s *CreateStoreCommand) Execute(ctx context.Context, req *openfgav1.CreateS
 		Name: req.Name,
 	})
 	if err != nil {
-		return nil, serverErrors.HandleError("", err)
+		return nil, serverErrors.HandleError(ctx, "", err)
 	}
 
 	return &openfgav1.CreateStoreResponse{
@@ -32,11 +32,11 @@ func (s *DeleteStoreCommand) Execute(ctx context.Context, req *openfgav1.DeleteS
 			return &openfgav1.DeleteStoreResponse{}, nil
 		}
 
-		return nil, serverErrors.HandleError("", err)
+		return nil, serverErrors.HandleError(ctx, "", err)
 	}
 
 	if err := s.storesBackend.DeleteStore(ctx, store.Id); err != nil {
-		return nil, serverErrors.HandleError("Error deleting store", err)
+		return nil, serverErrors.HandleError(ctx, "Error deleting store", err)
 	}
 	return &openfgav1.DeleteStoreResponse{}, nil
 }
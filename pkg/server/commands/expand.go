@@ -9,6 +9,7 @@ import (
 	"github.com/openfga/openfga/pkg/logger"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/storagewrappers"
 	tupleUtils "github.com/openfga/openfga/pkg/tuple"
 	"github.com/openfga/openfga/pkg/typesystem"
 	"golang.org/x/sync/errgroup"
@@ -18,25 +19,53 @@ import (
 type ExpandQuery struct {
 	logger    logger.Logger
 	datastore storage.OpenFGADatastore
+
+	// tupleReader reads the tuples of the datastore, along with the contextual tuples, if any
+	tupleReader      storage.RelationshipTupleReader
+	contextualTuples []*openfgav1.TupleKey
+
+	// typesys is the resolved TypeSystem of the model of the requests, if any
+	typesys *typesystem.TypeSystem
 }
 
-// NewExpandQuery creates a new ExpandQuery using the supplied backends for retrieving data.
-func NewExpandQuery(datastore storage.OpenFGADatastore, logger logger.Logger) *ExpandQuery {
-	return &ExpandQuery{logger: logger, datastore: datastore}
+type ExpandQueryOption func(*ExpandQuery)
+
+// WithExpandTypesystem expands against the TypeSystem of the model of the requests, which the caller
+// resolved, rather than reading the model again.
+func WithExpandTypesystem(typesys *typesystem.TypeSystem) ExpandQueryOption {
+	return func(q *ExpandQuery) {
+		q.typesys = typesys
+	}
 }
 
-func (q *ExpandQuery) Execute(ctx context.Context, req *openfgav1.ExpandRequest) (*openfgav1.ExpandResponse, error) {
-	store := req.GetStoreId()
-	modelID := req.GetAuthorizationModelId()
-	tupleKey := req.GetTupleKey()
-	object := tupleKey.GetObject()
-	relation := tupleKey.GetRelation()
+// WithExpandContextualTuples expands the relations as if the contextual tuples were written, e.g. to preview
+// the tree that hypothetical tuples would give before writing them. The caller validates the tuples.
+func WithExpandContextualTuples(contextualTuples []*openfgav1.TupleKey) ExpandQueryOption {
+	return func(q *ExpandQuery) {
+		q.contextualTuples = contextualTuples
+	}
+}
 
-	if object == "" || relation == "" {
-		return nil, serverErrors.InvalidExpandInput
+// NewExpandQuery creates a new ExpandQuery using the supplied backends for retrieving data.
+func NewExpandQuery(datastore storage.OpenFGADatastore, logger logger.Logger, opts ...ExpandQueryOption) *ExpandQuery {
+	q := &ExpandQuery{logger: logger, datastore: datastore, tupleReader: datastore}
+
+	for _, opt := range opts {
+		opt(q)
 	}
 
-	tk := tupleUtils.NewTupleKey(object, relation, "")
+	if len(q.contextualTuples) > 0 {
+		q.tupleReader = storagewrappers.NewCombinedTupleReader(datastore, q.contextualTuples)
+	}
+
+	return q
+}
+
+// resolveTypesystem returns the TypeSystem of the model, reading the model unless the caller resolved it.
+func (q *ExpandQuery) resolveTypesystem(ctx context.Context, store, modelID string) (*typesystem.TypeSystem, error) {
+	if q.typesys != nil && q.typesys.GetAuthorizationModelID() == modelID {
+		return q.typesys, nil
+	}
 
 	model, err := q.datastore.ReadAuthorizationModel(ctx, store, modelID)
 	if err != nil {
@@ -44,7 +73,7 @@ func (q *ExpandQuery) Execute(ctx context.Context, req *openfgav1.ExpandRequest)
 			return nil, serverErrors.AuthorizationModelNotFound(modelID)
 		}
 
-		return nil, serverErrors.HandleError("", err)
+		return nil, serverErrors.HandleError(ctx, "", err)
 	}
 
 	if !typesystem.IsSchemaVersionSupported(model.GetSchemaVersion()) {
@@ -56,6 +85,27 @@ func (q *ExpandQuery) Execute(ctx context.Context, req *openfgav1.ExpandRequest)
 		return nil, serverErrors.ValidationError(typesystem.ErrInvalidModel)
 	}
 
+	return typesys, nil
+}
+
+func (q *ExpandQuery) Execute(ctx context.Context, req *openfgav1.ExpandRequest) (*openfgav1.ExpandResponse, error) {
+	store := req.GetStoreId()
+	modelID := req.GetAuthorizationModelId()
+	tupleKey := req.GetTupleKey()
+	object := tupleKey.GetObject()
+	relation := tupleKey.GetRelation()
+
+	if object == "" || relation == "" {
+		return nil, serverErrors.InvalidExpandInput
+	}
+
+	tk := tupleUtils.NewTupleKey(object, relation, "")
+
+	typesys, err := q.resolveTypesystem(ctx, store, modelID)
+	if err != nil {
+		return nil, err
+	}
+
 	if err = validation.ValidateObject(typesys, tk); err != nil {
 		return nil, serverErrors.ValidationError(err)
 	}
@@ -76,7 +126,7 @@ func (q *ExpandQuery) Execute(ctx context.Context, req *openfgav1.ExpandRequest)
 			return nil, serverErrors.RelationNotFound(relation, objectType, tk)
 		}
 
-		return nil, serverErrors.HandleError("", err)
+		return nil, serverErrors.HandleError(ctx, "", err)
 	}
 
 	userset := rel.GetRewrite()
@@ -126,9 +176,9 @@ func (q *ExpandQuery) resolveThis(ctx context.Context, store string, tk *openfga
 	ctx, span := tracer.Start(ctx, "resolveThis")
 	defer span.End()
 
-	tupleIter, err := q.datastore.Read(ctx, store, tk)
+	tupleIter, err := q.tupleReader.Read(ctx, store, tk, storage.ReadOptionsFromContext(ctx))
 	if err != nil {
-		return nil, serverErrors.HandleError("", err)
+		return nil, serverErrors.HandleError(ctx, "", err)
 	}
 
 	filteredIter := storage.NewFilteredTupleKeyIterator(
@@ -144,7 +194,7 @@ func (q *ExpandQuery) resolveThis(ctx context.Context, store string, tk *openfga
 			if err == storage.ErrIteratorDone {
 				break
 			}
-			return nil, serverErrors.HandleError("", err)
+			return nil, serverErrors.HandleError(ctx, "", err)
 		}
 		distinctUsers[tk.GetUser()] = true
 	}
@@ -236,9 +286,9 @@ func (q *ExpandQuery) resolveTupleToUserset(
 		tsKey.Relation = tk.GetRelation()
 	}
 
-	tupleIter, err := q.datastore.Read(ctx, store, tsKey)
+	tupleIter, err := q.tupleReader.Read(ctx, store, tsKey, storage.ReadOptionsFromContext(ctx))
 	if err != nil {
-		return nil, serverErrors.HandleError("", err)
+		return nil, serverErrors.HandleError(ctx, "", err)
 	}
 
 	filteredIter := storage.NewFilteredTupleKeyIterator(
@@ -255,7 +305,7 @@ func (q *ExpandQuery) resolveTupleToUserset(
 			if err == storage.ErrIteratorDone {
 				break
 			}
-			return nil, serverErrors.HandleError("", err)
+			return nil, serverErrors.HandleError(ctx, "", err)
 		}
 		user := tk.GetUser()
 
@@ -0,0 +1,188 @@
+package commands
+
+import (
+	"context"
+	"sort"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/internal/validation"
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	tupleUtils "github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"golang.org/x/sync/errgroup"
+)
+
+// ExpandObjectRequest requests the expansion of every relation of an object. If the AuthorizationModelID
+// is empty, the latest authorization model of the store is used.
+type ExpandObjectRequest struct {
+	StoreID              string
+	AuthorizationModelID string
+	Object               string
+}
+
+// RelationAccessSummary summarizes the expansion of a relation of an object, i.e. who has the relation
+// with the object one level deep. Every list is sorted.
+type RelationAccessSummary struct {
+	Relation string `json:"relation"`
+
+	// Users are the users related with the object, e.g. 'user:anne'.
+	Users []string `json:"users"`
+
+	// Usersets are the usersets related with the object, either through tuples (e.g. 'group:eng#member') or
+	// through the rewrite of the relation (e.g. 'document:1#editor' or 'folder:1#viewer').
+	Usersets []string `json:"usersets"`
+
+	// Wildcards are the typed wildcards related with the object, e.g. 'user:*', i.e. the types of users
+	// that all have the relation with the object.
+	Wildcards []string `json:"wildcards"`
+
+	// Conditional is set if the relation involves an intersection or an exclusion, in which case not
+	// every user, userset and wildcard of the summary has the relation.
+	Conditional bool `json:"conditional"`
+}
+
+// ExpandObjectResponse holds the summary of every relation of the object, sorted by relation.
+type ExpandObjectResponse struct {
+	Object    string                   `json:"object"`
+	Relations []*RelationAccessSummary `json:"relations"`
+}
+
+// ExpandObjectQuery expands every relation of an object and summarizes each one of them. It answers who has
+// access to an object without an Expand call per relation, e.g. for the admin screens of a resource.
+type ExpandObjectQuery struct {
+	expand *ExpandQuery
+}
+
+func NewExpandObjectQuery(datastore storage.OpenFGADatastore, logger logger.Logger) *ExpandObjectQuery {
+	return &ExpandObjectQuery{
+		expand: NewExpandQuery(datastore, logger),
+	}
+}
+
+func (q *ExpandObjectQuery) Execute(ctx context.Context, typesys *typesystem.TypeSystem, req *ExpandObjectRequest) (*ExpandObjectResponse, error) {
+	if req.Object == "" {
+		return nil, serverErrors.InvalidExpandInput
+	}
+
+	if err := validation.ValidateObject(typesys, tupleUtils.NewTupleKey(req.Object, "", "")); err != nil {
+		return nil, serverErrors.ValidationError(err)
+	}
+
+	objectType := tupleUtils.GetType(req.Object)
+
+	relations, err := typesys.GetRelations(objectType)
+	if err != nil {
+		return nil, serverErrors.TypeNotFound(objectType)
+	}
+
+	names := make([]string, 0, len(relations))
+	for name := range relations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	summaries := make([]*RelationAccessSummary, len(names))
+	grp, ctx := errgroup.WithContext(ctx)
+	for i, name := range names {
+		i, name := i, name
+		grp.Go(func() error {
+			tk := tupleUtils.NewTupleKey(req.Object, name, "")
+
+			root, err := q.expand.resolveUserset(ctx, req.StoreID, relations[name].GetRewrite(), tk, typesys)
+			if err != nil {
+				return err
+			}
+
+			summary, err := summarizeExpansion(ctx, typesys, objectType, name, root)
+			if err != nil {
+				return err
+			}
+
+			summaries[i] = summary
+			return nil
+		})
+	}
+	if err := grp.Wait(); err != nil {
+		return nil, err
+	}
+
+	return &ExpandObjectResponse{
+		Object:    req.Object,
+		Relations: summaries,
+	}, nil
+}
+
+// summarizeExpansion flattens the leaves of the expansion of a relation into a RelationAccessSummary.
+func summarizeExpansion(ctx context.Context, typesys *typesystem.TypeSystem, objectType, relation string, root *openfgav1.UsersetTree_Node) (*RelationAccessSummary, error) {
+	involvesIntersection, err := typesys.RelationInvolvesIntersection(objectType, relation)
+	if err != nil {
+		return nil, serverErrors.HandleError(ctx, "", err)
+	}
+
+	involvesExclusion, err := typesys.RelationInvolvesExclusion(objectType, relation)
+	if err != nil {
+		return nil, serverErrors.HandleError(ctx, "", err)
+	}
+
+	users := map[string]struct{}{}
+	usersets := map[string]struct{}{}
+	wildcards := map[string]struct{}{}
+
+	var walk func(node *openfgav1.UsersetTree_Node)
+	walk = func(node *openfgav1.UsersetTree_Node) {
+		switch value := node.GetValue().(type) {
+		case *openfgav1.UsersetTree_Node_Leaf:
+			leaf := value.Leaf
+			for _, user := range leaf.GetUsers().GetUsers() {
+				switch {
+				case tupleUtils.IsWildcard(user):
+					wildcards[user] = struct{}{}
+				case tupleUtils.IsObjectRelation(user):
+					usersets[user] = struct{}{}
+				default:
+					users[user] = struct{}{}
+				}
+			}
+
+			if computed := leaf.GetComputed(); computed != nil {
+				usersets[computed.GetUserset()] = struct{}{}
+			}
+
+			for _, computed := range leaf.GetTupleToUserset().GetComputed() {
+				usersets[computed.GetUserset()] = struct{}{}
+			}
+		case *openfgav1.UsersetTree_Node_Union:
+			for _, child := range value.Union.GetNodes() {
+				walk(child)
+			}
+		case *openfgav1.UsersetTree_Node_Intersection:
+			for _, child := range value.Intersection.GetNodes() {
+				walk(child)
+			}
+		case *openfgav1.UsersetTree_Node_Difference:
+			walk(value.Difference.GetBase())
+			walk(value.Difference.GetSubtract())
+		}
+	}
+	walk(root)
+
+	return &RelationAccessSummary{
+		Relation:    relation,
+		Users:       sortedKeys(users),
+		Usersets:    sortedKeys(usersets),
+		Wildcards:   sortedKeys(wildcards),
+		Conditional: involvesIntersection || involvesExclusion,
+	}, nil
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
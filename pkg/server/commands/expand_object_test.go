@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandObjectQuery(t *testing.T) {
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+
+	ds := memory.New()
+	defer ds.Close()
+
+	typesys := typesystem.New(&openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type group
+		  relations
+			define member: [user] as self
+
+		type folder
+		  relations
+			define viewer: [user] as self
+
+		type document
+		  relations
+			define blocked: [user] as self
+			define parent: [folder] as self
+			define editor: [user, group#member] as self
+			define viewer: [user, user:*] as self or editor or viewer from parent
+			define commenter: [user] as self but not blocked
+		`),
+	})
+
+	err := ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "parent", "folder:x"),
+		tuple.NewTupleKey("document:1", "editor", "user:anne"),
+		tuple.NewTupleKey("document:1", "editor", "group:eng#member"),
+		tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+		tuple.NewTupleKey("document:1", "viewer", "user:*"),
+		tuple.NewTupleKey("document:1", "commenter", "user:carl"),
+		tuple.NewTupleKey("document:1", "blocked", "user:dave"),
+		tuple.NewTupleKey("document:2", "viewer", "user:erin"),
+	})
+	require.NoError(t, err)
+
+	q := NewExpandObjectQuery(ds, logger.NewNoopLogger())
+
+	t.Run("summarizes_every_relation", func(t *testing.T) {
+		resp, err := q.Execute(ctx, typesys, &ExpandObjectRequest{
+			StoreID: storeID,
+			Object:  "document:1",
+		})
+		require.NoError(t, err)
+		require.Equal(t, &ExpandObjectResponse{
+			Object: "document:1",
+			Relations: []*RelationAccessSummary{
+				{
+					Relation:    "blocked",
+					Users:       []string{"user:dave"},
+					Usersets:    []string{},
+					Wildcards:   []string{},
+					Conditional: false,
+				},
+				{
+					Relation:    "commenter",
+					Users:       []string{"user:carl"},
+					Usersets:    []string{"document:1#blocked"},
+					Wildcards:   []string{},
+					Conditional: true,
+				},
+				{
+					Relation:  "editor",
+					Users:     []string{"user:anne"},
+					Usersets:  []string{"group:eng#member"},
+					Wildcards: []string{},
+				},
+				{
+					Relation:  "parent",
+					Users:     []string{"folder:x"},
+					Usersets:  []string{},
+					Wildcards: []string{},
+				},
+				{
+					Relation:  "viewer",
+					Users:     []string{"user:bob"},
+					Usersets:  []string{"document:1#editor", "folder:x#viewer"},
+					Wildcards: []string{"user:*"},
+				},
+			},
+		}, resp)
+	})
+
+	t.Run("object_without_tuples", func(t *testing.T) {
+		resp, err := q.Execute(ctx, typesys, &ExpandObjectRequest{
+			StoreID: storeID,
+			Object:  "folder:y",
+		})
+		require.NoError(t, err)
+		require.Equal(t, []*RelationAccessSummary{
+			{
+				Relation:  "viewer",
+				Users:     []string{},
+				Usersets:  []string{},
+				Wildcards: []string{},
+			},
+		}, resp.Relations)
+	})
+
+	t.Run("missing_object", func(t *testing.T) {
+		_, err := q.Execute(ctx, typesys, &ExpandObjectRequest{StoreID: storeID})
+		require.ErrorIs(t, err, serverErrors.InvalidExpandInput)
+	})
+
+	t.Run("undefined_type", func(t *testing.T) {
+		_, err := q.Execute(ctx, typesys, &ExpandObjectRequest{
+			StoreID: storeID,
+			Object:  "repo:1",
+		})
+		require.Error(t, err)
+	})
+}
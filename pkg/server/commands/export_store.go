@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"context"
+	"errors"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storefile"
+)
+
+// exportTuplesPageSize is the number of tuples read from the datastore at a time.
+const exportTuplesPageSize = 100
+
+// ExportStoreRequest requests the export of a store. If the AuthorizationModelID is empty, the latest
+// authorization model of the store is exported.
+type ExportStoreRequest struct {
+	StoreID              string
+	AuthorizationModelID string
+}
+
+// ExportStoreQuery exports a store as a store file: the model is written in the DSL, and the assertions of
+// the model are written as a Check test.
+type ExportStoreQuery struct {
+	datastore storage.OpenFGADatastore
+	logger    logger.Logger
+}
+
+func NewExportStoreQuery(datastore storage.OpenFGADatastore, logger logger.Logger) *ExportStoreQuery {
+	return &ExportStoreQuery{
+		datastore: datastore,
+		logger:    logger,
+	}
+}
+
+func (q *ExportStoreQuery) Execute(ctx context.Context, req *ExportStoreRequest) (*storefile.StoreFile, error) {
+	store, err := q.datastore.GetStore(ctx, req.StoreID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, serverErrors.StoreIDNotFound
+		}
+		return nil, serverErrors.HandleError(ctx, "", err)
+	}
+
+	modelID := req.AuthorizationModelID
+	if modelID == "" {
+		modelID, err = q.datastore.FindLatestAuthorizationModelID(ctx, req.StoreID)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil, serverErrors.LatestAuthorizationModelNotFound(req.StoreID)
+			}
+			return nil, serverErrors.HandleError(ctx, "", err)
+		}
+	}
+
+	model, err := q.datastore.ReadAuthorizationModel(ctx, req.StoreID, modelID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, serverErrors.AuthorizationModelNotFound(modelID)
+		}
+		return nil, serverErrors.HandleError(ctx, "", err)
+	}
+
+	file := &storefile.StoreFile{
+		Name:  store.GetName(),
+		Model: storefile.TransformModelToDSL(model),
+	}
+
+	var from string
+	for {
+		tuples, token, err := q.datastore.ReadPage(ctx, req.StoreID, &openfgav1.TupleKey{}, storage.NewPaginationOptions(exportTuplesPageSize, from), storage.ReadOptions{})
+		if err != nil {
+			return nil, serverErrors.HandleError(ctx, "", err)
+		}
+
+		for _, t := range tuples {
+			file.Tuples = append(file.Tuples, &storefile.TupleKey{
+				User:     t.GetKey().GetUser(),
+				Relation: t.GetKey().GetRelation(),
+				Object:   t.GetKey().GetObject(),
+			})
+		}
+
+		if len(token) == 0 {
+			break
+		}
+		from = string(token)
+	}
+
+	assertions, err := q.datastore.ReadAssertions(ctx, req.StoreID, modelID)
+	if err != nil {
+		return nil, serverErrors.HandleError(ctx, "", err)
+	}
+
+	if len(assertions) > 0 {
+		file.Tests = []*storefile.Test{{
+			Name:  "assertions",
+			Check: storeFileChecks(assertions),
+		}}
+	}
+
+	return file, nil
+}
+
+// storeFileChecks groups the assertions by user and object, in the order of their first assertion.
+func storeFileChecks(assertions []*openfgav1.Assertion) []*storefile.CheckTest {
+	type userObject struct {
+		user   string
+		object string
+	}
+
+	var checks []*storefile.CheckTest
+	index := map[userObject]*storefile.CheckTest{}
+
+	for _, assertion := range assertions {
+		tk := assertion.GetTupleKey()
+		key := userObject{user: tk.GetUser(), object: tk.GetObject()}
+
+		check, ok := index[key]
+		if !ok {
+			check = &storefile.CheckTest{
+				User:       tk.GetUser(),
+				Object:     tk.GetObject(),
+				Assertions: map[string]bool{},
+			}
+			index[key] = check
+			checks = append(checks, check)
+		}
+
+		check.Assertions[tk.GetRelation()] = assertion.GetExpectation()
+	}
+
+	return checks
+}
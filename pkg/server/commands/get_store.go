@@ -29,7 +29,7 @@ func (q *GetStoreQuery) Execute(ctx context.Context, req *openfgav1.GetStoreRequ
 		if errors.Is(err, storage.ErrNotFound) {
 			return nil, serverErrors.StoreIDNotFound
 		}
-		return nil, serverErrors.HandleError("", err)
+		return nil, serverErrors.HandleError(ctx, "", err)
 	}
 	return &openfgav1.GetStoreResponse{
 		Id:        store.Id,
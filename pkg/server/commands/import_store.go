@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"context"
+	"sort"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/internal/validation"
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storefile"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// ImportStoreRequest requests the import of a store file. If the StoreID is empty, a store named after the
+// store file is created.
+type ImportStoreRequest struct {
+	StoreID string
+	File    *storefile.StoreFile
+}
+
+// ImportStoreResponse describes what has been imported.
+type ImportStoreResponse struct {
+	StoreID              string `json:"store_id"`
+	AuthorizationModelID string `json:"authorization_model_id"`
+	TuplesWritten        int    `json:"tuples_written"`
+	AssertionsWritten    int    `json:"assertions_written"`
+}
+
+// ImportStoreCommand imports a store file: it writes the model and the tuples of the file, and writes the
+// Check tests of the file as the assertions of the model.
+//
+// Only the Check tests without tuples of their own can be written as assertions; the other tests are
+// skipped.
+type ImportStoreCommand struct {
+	datastore                        storage.OpenFGADatastore
+	logger                           logger.Logger
+	maxAuthorizationModelSizeInBytes int
+}
+
+func NewImportStoreCommand(datastore storage.OpenFGADatastore, logger logger.Logger, maxAuthorizationModelSizeInBytes int) *ImportStoreCommand {
+	return &ImportStoreCommand{
+		datastore:                        datastore,
+		logger:                           logger,
+		maxAuthorizationModelSizeInBytes: maxAuthorizationModelSizeInBytes,
+	}
+}
+
+// Execute validates the whole store file before writing anything, so that an invalid file doesn't leave
+// a partially imported store behind.
+func (c *ImportStoreCommand) Execute(ctx context.Context, req *ImportStoreRequest) (*ImportStoreResponse, error) {
+	model, err := req.File.AuthorizationModel()
+	if err != nil {
+		return nil, serverErrors.InvalidAuthorizationModelInput(err)
+	}
+
+	typesys, err := typesystem.NewAndValidate(ctx, model)
+	if err != nil {
+		return nil, serverErrors.InvalidAuthorizationModelInput(err)
+	}
+
+	tupleKeys := req.File.TupleKeys()
+	for _, tk := range tupleKeys {
+		if err := validation.ValidateTuple(typesys, tk); err != nil {
+			return nil, serverErrors.HandleTupleValidateError(ctx, err)
+		}
+	}
+
+	assertions := storeFileAssertions(req.File)
+	for _, assertion := range assertions {
+		if err := validation.ValidateUserObjectRelation(typesys, assertion.GetTupleKey()); err != nil {
+			return nil, serverErrors.ValidationError(err)
+		}
+	}
+
+	storeID := req.StoreID
+	if storeID == "" {
+		store, err := NewCreateStoreCommand(c.datastore, c.logger).Execute(ctx, &openfgav1.CreateStoreRequest{
+			Name: req.File.Name,
+		})
+		if err != nil {
+			return nil, err
+		}
+		storeID = store.GetId()
+	}
+
+	writeModelResponse, err := NewWriteAuthorizationModelCommand(c.datastore, c.logger, c.maxAuthorizationModelSizeInBytes).Execute(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		TypeDefinitions: model.GetTypeDefinitions(),
+		SchemaVersion:   model.GetSchemaVersion(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	modelID := writeModelResponse.GetAuthorizationModelId()
+
+	maxTuplesPerWrite := c.datastore.MaxTuplesPerWrite()
+	for start := 0; start < len(tupleKeys); start += maxTuplesPerWrite {
+		end := min(start+maxTuplesPerWrite, len(tupleKeys))
+		if err := c.datastore.Write(ctx, storeID, nil, tupleKeys[start:end]); err != nil {
+			return nil, serverErrors.HandleError(ctx, "", err)
+		}
+	}
+
+	if len(assertions) > 0 {
+		if err := c.datastore.WriteAssertions(ctx, storeID, modelID, assertions); err != nil {
+			return nil, serverErrors.HandleError(ctx, "", err)
+		}
+	}
+
+	return &ImportStoreResponse{
+		StoreID:              storeID,
+		AuthorizationModelID: modelID,
+		TuplesWritten:        len(tupleKeys),
+		AssertionsWritten:    len(assertions),
+	}, nil
+}
+
+// storeFileAssertions returns the assertions of the Check tests of the store file that don't have tuples of
+// their own.
+func storeFileAssertions(file *storefile.StoreFile) []*openfgav1.Assertion {
+	var assertions []*openfgav1.Assertion
+	for _, test := range file.Tests {
+		if len(test.Tuples) > 0 || test.TupleFile != "" {
+			continue
+		}
+
+		for _, check := range test.Check {
+			relations := make([]string, 0, len(check.Assertions))
+			for relation := range check.Assertions {
+				relations = append(relations, relation)
+			}
+			sort.Strings(relations)
+
+			for _, relation := range relations {
+				assertions = append(assertions, &openfgav1.Assertion{
+					TupleKey:    tuple.NewTupleKey(check.Object, relation, check.User),
+					Expectation: check.Assertions[relation],
+				})
+			}
+		}
+	}
+
+	return assertions
+}
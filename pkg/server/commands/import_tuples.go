@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/openfga/openfga/internal/validation"
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storefile"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// ImportTuplesRequest requests the import of tuples into a store. The tuples are validated against the
+// authorization model of the AuthorizationModelID, or against the latest model of the store if it's empty.
+type ImportTuplesRequest struct {
+	StoreID              string
+	AuthorizationModelID string
+	Tuples               []*storefile.TupleKey
+}
+
+// ImportTuplesResponse describes what has been imported.
+type ImportTuplesResponse struct {
+	StoreID              string `json:"store_id"`
+	AuthorizationModelID string `json:"authorization_model_id"`
+	TuplesWritten        int    `json:"tuples_written"`
+}
+
+// ImportTuplesCommand writes tuples in bulk, e.g. the tuples of a CSV or NDJSON tuple file, in as many
+// writes as the datastore requires.
+type ImportTuplesCommand struct {
+	datastore storage.OpenFGADatastore
+	logger    logger.Logger
+	hook      TuplesWrittenHook
+}
+
+// ImportTuplesCommandOption defines an option that can be used to change the behavior of an
+// ImportTuplesCommand.
+type ImportTuplesCommandOption func(*ImportTuplesCommand)
+
+// WithImportTuplesHook calls the hook with the tuples of every successful write of an import.
+func WithImportTuplesHook(hook TuplesWrittenHook) ImportTuplesCommandOption {
+	return func(c *ImportTuplesCommand) {
+		c.hook = hook
+	}
+}
+
+func NewImportTuplesCommand(datastore storage.OpenFGADatastore, logger logger.Logger, opts ...ImportTuplesCommandOption) *ImportTuplesCommand {
+	c := &ImportTuplesCommand{
+		datastore: datastore,
+		logger:    logger,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Execute validates every tuple before writing anything, so that an invalid tuple doesn't leave a partial
+// import behind. The writes themselves aren't atomic: if one of them fails, the previous ones are kept.
+func (c *ImportTuplesCommand) Execute(ctx context.Context, req *ImportTuplesRequest) (*ImportTuplesResponse, error) {
+	if _, err := c.datastore.GetStore(ctx, req.StoreID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, serverErrors.StoreIDNotFound
+		}
+		return nil, serverErrors.HandleError(ctx, "", err)
+	}
+
+	modelID := req.AuthorizationModelID
+	if modelID == "" {
+		var err error
+		modelID, err = c.datastore.FindLatestAuthorizationModelID(ctx, req.StoreID)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil, serverErrors.LatestAuthorizationModelNotFound(req.StoreID)
+			}
+			return nil, serverErrors.HandleError(ctx, "", err)
+		}
+	}
+
+	model, err := c.datastore.ReadAuthorizationModel(ctx, req.StoreID, modelID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, serverErrors.AuthorizationModelNotFound(modelID)
+		}
+		return nil, serverErrors.HandleError(ctx, "", err)
+	}
+
+	typesys, err := typesystem.NewAndValidate(ctx, model)
+	if err != nil {
+		return nil, serverErrors.ValidationError(fmt.Errorf("%w: %v", typesystem.ErrInvalidModel, err))
+	}
+
+	tupleKeys := (&storefile.StoreFile{Tuples: req.Tuples}).TupleKeys()
+	for _, tk := range tupleKeys {
+		if err := validation.ValidateTuple(typesys, tk); err != nil {
+			return nil, serverErrors.HandleTupleValidateError(ctx, err)
+		}
+	}
+
+	maxTuplesPerWrite := c.datastore.MaxTuplesPerWrite()
+	for start := 0; start < len(tupleKeys); start += maxTuplesPerWrite {
+		end := min(start+maxTuplesPerWrite, len(tupleKeys))
+		if err := c.datastore.Write(ctx, req.StoreID, nil, tupleKeys[start:end]); err != nil {
+			return nil, serverErrors.HandleError(ctx, "", err)
+		}
+
+		if c.hook != nil {
+			c.hook(ctx, typesys, req.StoreID, tupleKeys[start:end])
+		}
+	}
+
+	return &ImportTuplesResponse{
+		StoreID:              req.StoreID,
+		AuthorizationModelID: modelID,
+		TuplesWritten:        len(tupleKeys),
+	}, nil
+}
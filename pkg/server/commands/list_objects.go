@@ -46,6 +46,7 @@ type ListObjectsQuery struct {
 	resolveNodeLimit        uint32
 	resolveNodeBreadthLimit uint32
 	maxConcurrentReads      uint32
+	conditionContext        map[string]interface{}
 
 	checkOptions []graph.LocalCheckerOption
 }
@@ -95,6 +96,14 @@ func WithCheckOptions(checkOptions []graph.LocalCheckerOption) ListObjectsQueryO
 	}
 }
 
+// WithConditionContext sets the context the conditions of the tuples are evaluated with, along with the context
+// of the tuples, by the checks of the query (see the condition package).
+func WithConditionContext(conditionContext map[string]interface{}) ListObjectsQueryOption {
+	return func(d *ListObjectsQuery) {
+		d.conditionContext = conditionContext
+	}
+}
+
 // WithMaxConcurrentReads see server.WithMaxConcurrentReadsForListObjects
 func WithMaxConcurrentReads(limit uint32) ListObjectsQueryOption {
 	return func(d *ListObjectsQuery) {
@@ -161,7 +170,7 @@ func (q *ListObjectsQuery) evaluate(
 
 	for _, ctxTuple := range req.GetContextualTuples().GetTupleKeys() {
 		if err := validation.ValidateTuple(typesys, ctxTuple); err != nil {
-			return serverErrors.HandleTupleValidateError(err)
+			return serverErrors.HandleTupleValidateError(ctx, err)
 		}
 	}
 
@@ -278,6 +287,7 @@ func (q *ListObjectsQuery) evaluate(
 					AuthorizationModelID: req.GetAuthorizationModelId(),
 					TupleKey:             tuple.NewTupleKey(res.Object, req.GetRelation(), req.GetUser()),
 					ContextualTuples:     req.GetContextualTuples().GetTupleKeys(),
+					Context:              q.conditionContext,
 					ResolutionMetadata: &graph.ResolutionMetadata{
 						Depth: q.resolveNodeLimit,
 					},
@@ -364,7 +374,7 @@ func (q *ListObjectsQuery) Execute(
 				}
 
 				if !(errors.Is(result.Err, context.Canceled) || errors.Is(result.Err, context.DeadlineExceeded)) {
-					return nil, serverErrors.HandleError("", result.Err)
+					return nil, serverErrors.HandleError(ctx, "", result.Err)
 				}
 
 				continue
@@ -422,7 +432,7 @@ func (q *ListObjectsQuery) ExecuteStreamed(ctx context.Context, req *openfgav1.S
 					return nil, result.Err
 				}
 
-				return nil, serverErrors.HandleError("", result.Err)
+				return nil, serverErrors.HandleError(ctx, "", result.Err)
 			}
 
 			if err := srv.Send(&openfgav1.StreamedListObjectsResponse{
@@ -25,21 +25,21 @@ func NewListStoresQuery(storesBackend storage.StoresBackend, logger logger.Logge
 }
 
 func (q *ListStoresQuery) Execute(ctx context.Context, req *openfgav1.ListStoresRequest) (*openfgav1.ListStoresResponse, error) {
-	decodedContToken, err := q.encoder.Decode(req.GetContinuationToken())
+	decodedContToken, err := encoder.DecodeForStore(q.encoder, "", req.GetContinuationToken())
 	if err != nil {
-		return nil, serverErrors.InvalidContinuationToken
+		return nil, serverErrors.ContinuationTokenError(err)
 	}
 
 	paginationOptions := storage.NewPaginationOptions(req.GetPageSize().GetValue(), string(decodedContToken))
 
 	stores, continuationToken, err := q.storesBackend.ListStores(ctx, paginationOptions)
 	if err != nil {
-		return nil, serverErrors.HandleError("", err)
+		return nil, serverErrors.HandleError(ctx, "", err)
 	}
 
-	encodedToken, err := q.encoder.Encode(continuationToken)
+	encodedToken, err := encoder.EncodeForStore(q.encoder, "", continuationToken)
 	if err != nil {
-		return nil, serverErrors.HandleError("", err)
+		return nil, serverErrors.HandleError(ctx, "", err)
 	}
 
 	resp := &openfgav1.ListStoresResponse{
@@ -0,0 +1,522 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/internal/graph"
+	serverconfig "github.com/openfga/openfga/internal/server/config"
+	"github.com/openfga/openfga/internal/validation"
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/storagewrappers"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// ListUsersRequest requests the users that have a relation with an object. If the AuthorizationModelID is
+// empty, the latest authorization model of the store is used.
+type ListUsersRequest struct {
+	StoreID              string
+	AuthorizationModelID string
+	Object               string
+	Relation             string
+
+	// UserFilters are the kinds of users listed: object types (e.g. 'user'), whose objects and typed
+	// wildcards are listed, and usersets (e.g. 'group#member'), whose usersets are listed. At least one is
+	// required.
+	UserFilters []string
+
+	ContextualTuples []*openfgav1.TupleKey
+}
+
+// ListUsersResponse holds the users that have the relation with the object. Every list is sorted.
+type ListUsersResponse struct {
+	// Users are the users of the filters that have the relation, e.g. 'user:anne', 'user:*' or
+	// 'group:eng#member'.
+	Users []string `json:"users"`
+
+	// ExcludedUsers are the users of a typed wildcard of Users (e.g. 'user:bob' for 'user:*') that don't
+	// have the relation, because of an exclusion or of an intersection.
+	ExcludedUsers []string `json:"excluded_users"`
+}
+
+// ListUsersQuery lists the users that have a relation with an object, e.g. for the share dialogs that show
+// who has access to a resource. It expands the relation recursively, only following the rewrites from which
+// the users of the filters can be reached, as found by the edges of the RelationshipGraph.
+type ListUsersQuery struct {
+	datastore        storage.RelationshipTupleReader
+	logger           logger.Logger
+	resolveNodeLimit uint32
+}
+
+// ListUsersQueryOption defines an option that can be used to change the behavior of a ListUsersQuery.
+type ListUsersQueryOption func(*ListUsersQuery)
+
+// WithListUsersResolveNodeLimit sets the maximum depth of the expansion of the relations.
+func WithListUsersResolveNodeLimit(limit uint32) ListUsersQueryOption {
+	return func(q *ListUsersQuery) {
+		q.resolveNodeLimit = limit
+	}
+}
+
+func NewListUsersQuery(datastore storage.RelationshipTupleReader, logger logger.Logger, opts ...ListUsersQueryOption) *ListUsersQuery {
+	q := &ListUsersQuery{
+		datastore:        datastore,
+		logger:           logger,
+		resolveNodeLimit: serverconfig.DefaultResolveNodeLimit,
+	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	return q
+}
+
+func (q *ListUsersQuery) Execute(ctx context.Context, typesys *typesystem.TypeSystem, req *ListUsersRequest) (*ListUsersResponse, error) {
+	tk := tuple.NewTupleKey(req.Object, req.Relation, "")
+	if err := validation.ValidateObject(typesys, tk); err != nil {
+		return nil, serverErrors.ValidationError(err)
+	}
+
+	if err := validation.ValidateRelation(typesys, tk); err != nil {
+		return nil, serverErrors.ValidationError(err)
+	}
+
+	if len(req.UserFilters) == 0 {
+		return nil, serverErrors.ValidationError(errors.New("at least one user filter is required"))
+	}
+
+	filters := make([]*openfgav1.RelationReference, 0, len(req.UserFilters))
+	for _, filter := range req.UserFilters {
+		objectType, relation, _ := strings.Cut(filter, "#")
+		if _, err := typesys.GetRelations(objectType); err != nil {
+			return nil, serverErrors.ValidationError(fmt.Errorf("invalid user filter '%s': %w", filter, err))
+		}
+
+		if relation != "" {
+			if _, err := typesys.GetRelation(objectType, relation); err != nil {
+				return nil, serverErrors.ValidationError(fmt.Errorf("invalid user filter '%s': %w", filter, err))
+			}
+		}
+
+		filters = append(filters, typesystem.DirectRelationReference(objectType, relation))
+	}
+
+	for _, ctxTuple := range req.ContextualTuples {
+		if err := validation.ValidateTuple(typesys, ctxTuple); err != nil {
+			return nil, serverErrors.HandleTupleValidateError(ctx, err)
+		}
+	}
+
+	e := &usersExpansion{
+		datastore: storagewrappers.NewCombinedTupleReader(q.datastore, req.ContextualTuples),
+		storeID:   req.StoreID,
+		typesys:   typesys,
+		graph:     graph.New(typesys),
+		filters:   filters,
+		reachable: map[string]bool{},
+		visited:   map[string]struct{}{},
+	}
+
+	users, err := e.expand(ctx, req.Object, req.Relation, q.resolveNodeLimit)
+	if err != nil {
+		if errors.Is(err, graph.ErrResolutionDepthExceeded) {
+			return nil, serverErrors.AuthorizationModelResolutionTooComplex
+		}
+
+		return nil, serverErrors.HandleError(ctx, "", err)
+	}
+
+	return e.response(users), nil
+}
+
+// userSet is a set of users: the members (objects and usersets), and the typed wildcards, minus the users
+// excluded from the wildcards.
+type userSet struct {
+	members   map[string]struct{}
+	wildcards map[string]struct{}
+	excluded  map[string]struct{}
+}
+
+func newUserSet() *userSet {
+	return &userSet{
+		members:   map[string]struct{}{},
+		wildcards: map[string]struct{}{},
+		excluded:  map[string]struct{}{},
+	}
+}
+
+// wildcardType returns the type of the wildcard that covers the user, or an empty string for the usersets.
+func wildcardType(user string) string {
+	if tuple.IsObjectRelation(user) {
+		return ""
+	}
+
+	return tuple.GetType(user)
+}
+
+func (s *userSet) has(user string) bool {
+	if _, ok := s.members[user]; ok {
+		return true
+	}
+
+	if _, ok := s.wildcards[wildcardType(user)]; ok {
+		_, excluded := s.excluded[user]
+		return !excluded
+	}
+
+	return false
+}
+
+func (s *userSet) add(user string) {
+	if tuple.IsTypedWildcard(user) {
+		s.wildcards[tuple.GetType(user)] = struct{}{}
+		return
+	}
+
+	s.members[user] = struct{}{}
+}
+
+// union adds the users of the other set to the set.
+func (s *userSet) union(other *userSet) {
+	excluded := map[string]struct{}{}
+	for _, users := range []map[string]struct{}{s.excluded, other.excluded} {
+		for user := range users {
+			if !s.has(user) && !other.has(user) {
+				excluded[user] = struct{}{}
+			}
+		}
+	}
+
+	for member := range other.members {
+		s.members[member] = struct{}{}
+	}
+	for objectType := range other.wildcards {
+		s.wildcards[objectType] = struct{}{}
+	}
+	s.excluded = excluded
+}
+
+func intersection(a, b *userSet) *userSet {
+	result := newUserSet()
+	for _, members := range []map[string]struct{}{a.members, b.members} {
+		for member := range members {
+			if a.has(member) && b.has(member) {
+				result.members[member] = struct{}{}
+			}
+		}
+	}
+
+	for objectType := range a.wildcards {
+		if _, ok := b.wildcards[objectType]; ok {
+			result.wildcards[objectType] = struct{}{}
+		}
+	}
+
+	for _, excluded := range []map[string]struct{}{a.excluded, b.excluded} {
+		for user := range excluded {
+			if _, ok := result.wildcards[wildcardType(user)]; ok {
+				result.excluded[user] = struct{}{}
+			}
+		}
+	}
+
+	return result
+}
+
+func difference(base, subtract *userSet) *userSet {
+	result := newUserSet()
+	for member := range base.members {
+		if !subtract.has(member) {
+			result.members[member] = struct{}{}
+		}
+	}
+
+	for objectType := range base.wildcards {
+		if _, ok := subtract.wildcards[objectType]; !ok {
+			result.wildcards[objectType] = struct{}{}
+		}
+	}
+
+	for user := range base.excluded {
+		if _, ok := result.wildcards[wildcardType(user)]; ok {
+			result.excluded[user] = struct{}{}
+		}
+	}
+
+	for member := range subtract.members {
+		if _, ok := result.wildcards[wildcardType(member)]; ok {
+			result.excluded[member] = struct{}{}
+		}
+	}
+
+	// the users excluded from a wildcard of both sets have the relation if the base has them
+	for user := range subtract.excluded {
+		if _, ok := base.wildcards[wildcardType(user)]; ok && base.has(user) {
+			result.members[user] = struct{}{}
+		}
+	}
+
+	return result
+}
+
+// usersExpansion holds the state of the expansion of a ListUsersQuery.
+type usersExpansion struct {
+	datastore storage.RelationshipTupleReader
+	storeID   string
+	typesys   *typesystem.TypeSystem
+	graph     *graph.RelationshipGraph
+	filters   []*openfgav1.RelationReference
+
+	// reachable memoizes whether a user of the filters can have a relation of a type, keyed by 'type#relation'
+	reachable map[string]bool
+
+	// visited holds the 'object#relation' being expanded, which are cycles if they're expanded again
+	visited map[string]struct{}
+}
+
+// isReachable reports whether a user of the filters can have the relation of the object type.
+func (e *usersExpansion) isReachable(objectType, relation string) (bool, error) {
+	key := tuple.ToObjectRelationString(objectType, relation)
+	if reachable, ok := e.reachable[key]; ok {
+		return reachable, nil
+	}
+
+	target := typesystem.DirectRelationReference(objectType, relation)
+
+	reachable := false
+	for _, filter := range e.filters {
+		sources := []*openfgav1.RelationReference{filter}
+		if filter.GetRelation() == "" {
+			sources = append(sources, typesystem.WildcardRelationReference(filter.GetType()))
+		}
+
+		for _, source := range sources {
+			edges, err := e.graph.GetRelationshipEdges(target, source)
+			if err != nil {
+				return false, err
+			}
+
+			if len(edges) > 0 {
+				reachable = true
+			}
+		}
+	}
+
+	e.reachable[key] = reachable
+	return reachable, nil
+}
+
+// expand returns the users that have the relation with the object.
+func (e *usersExpansion) expand(ctx context.Context, object, relation string, depth uint32) (*userSet, error) {
+	if depth == 0 {
+		return nil, graph.ErrResolutionDepthExceeded
+	}
+
+	objectType := tuple.GetType(object)
+	rel, err := e.typesys.GetRelation(objectType, relation)
+	if err != nil {
+		// e.g. the computed relation of a tuple to userset that the type of the tupleset object doesn't have
+		return newUserSet(), nil
+	}
+
+	reachable, err := e.isReachable(objectType, relation)
+	if err != nil {
+		return nil, err
+	}
+
+	key := tuple.ToObjectRelationString(object, relation)
+	if _, ok := e.visited[key]; ok || !reachable {
+		return newUserSet(), nil
+	}
+
+	e.visited[key] = struct{}{}
+	defer delete(e.visited, key)
+
+	return e.expandRewrite(ctx, object, relation, rel.GetRewrite(), depth-1)
+}
+
+func (e *usersExpansion) expandRewrite(ctx context.Context, object, relation string, rewrite *openfgav1.Userset, depth uint32) (*userSet, error) {
+	switch rw := rewrite.GetUserset().(type) {
+	case *openfgav1.Userset_This:
+		return e.expandThis(ctx, object, relation, depth)
+	case *openfgav1.Userset_ComputedUserset:
+		return e.expand(ctx, object, rw.ComputedUserset.GetRelation(), depth)
+	case *openfgav1.Userset_TupleToUserset:
+		return e.expandTupleToUserset(ctx, object, rw.TupleToUserset, depth)
+	case *openfgav1.Userset_Union:
+		result := newUserSet()
+		for _, child := range rw.Union.GetChild() {
+			users, err := e.expandRewrite(ctx, object, relation, child, depth)
+			if err != nil {
+				return nil, err
+			}
+			result.union(users)
+		}
+		return result, nil
+	case *openfgav1.Userset_Intersection:
+		var result *userSet
+		for _, child := range rw.Intersection.GetChild() {
+			users, err := e.expandRewrite(ctx, object, relation, child, depth)
+			if err != nil {
+				return nil, err
+			}
+
+			if result == nil {
+				result = users
+				continue
+			}
+			result = intersection(result, users)
+		}
+		if result == nil {
+			result = newUserSet()
+		}
+		return result, nil
+	case *openfgav1.Userset_Difference:
+		base, err := e.expandRewrite(ctx, object, relation, rw.Difference.GetBase(), depth)
+		if err != nil {
+			return nil, err
+		}
+
+		subtract, err := e.expandRewrite(ctx, object, relation, rw.Difference.GetSubtract(), depth)
+		if err != nil {
+			return nil, err
+		}
+
+		return difference(base, subtract), nil
+	default:
+		return nil, serverErrors.UnsupportedUserSet
+	}
+}
+
+// expandThis returns the users directly related with the object, and the users of the usersets directly
+// related with it.
+func (e *usersExpansion) expandThis(ctx context.Context, object, relation string, depth uint32) (*userSet, error) {
+	users, err := e.readUsers(ctx, object, relation)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newUserSet()
+	for _, user := range users {
+		result.add(user)
+
+		if tuple.IsObjectRelation(user) {
+			usersetObject, usersetRelation := tuple.SplitObjectRelation(user)
+			members, err := e.expand(ctx, usersetObject, usersetRelation, depth)
+			if err != nil {
+				return nil, err
+			}
+			result.union(members)
+		}
+	}
+
+	return result, nil
+}
+
+// expandTupleToUserset returns the users that have the computed relation with the objects related with the
+// object through the tupleset.
+func (e *usersExpansion) expandTupleToUserset(ctx context.Context, object string, ttu *openfgav1.TupleToUserset, depth uint32) (*userSet, error) {
+	users, err := e.readUsers(ctx, object, ttu.GetTupleset().GetRelation())
+	if err != nil {
+		return nil, err
+	}
+
+	result := newUserSet()
+	for _, user := range users {
+		if tuple.IsObjectRelation(user) || tuple.IsTypedWildcard(user) {
+			continue
+		}
+
+		members, err := e.expand(ctx, user, ttu.GetComputedUserset().GetRelation(), depth)
+		if err != nil {
+			return nil, err
+		}
+		result.union(members)
+	}
+
+	return result, nil
+}
+
+// readUsers returns the users of the valid tuples of the relation of the object.
+func (e *usersExpansion) readUsers(ctx context.Context, object, relation string) ([]string, error) {
+	iter, err := e.datastore.Read(ctx, e.storeID, tuple.NewTupleKey(object, relation, ""), storage.ReadOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	filteredIter := storage.NewFilteredTupleKeyIterator(
+		storage.NewTupleKeyIteratorFromTupleIterator(iter),
+		validation.FilterInvalidTuples(e.typesys),
+	)
+	defer filteredIter.Stop()
+
+	var users []string
+	for {
+		tk, err := filteredIter.Next()
+		if err != nil {
+			if errors.Is(err, storage.ErrIteratorDone) {
+				return users, nil
+			}
+			return nil, err
+		}
+
+		users = append(users, tk.GetUser())
+	}
+}
+
+// response returns the users of the set that match the filters.
+func (e *usersExpansion) response(users *userSet) *ListUsersResponse {
+	types := map[string]struct{}{}
+	usersets := map[string]struct{}{}
+	for _, filter := range e.filters {
+		if filter.GetRelation() == "" {
+			types[filter.GetType()] = struct{}{}
+		} else {
+			usersets[tuple.ToObjectRelationString(filter.GetType(), filter.GetRelation())] = struct{}{}
+		}
+	}
+
+	resp := &ListUsersResponse{Users: []string{}, ExcludedUsers: []string{}}
+	for member := range users.members {
+		if tuple.IsObjectRelation(member) {
+			usersetObject, usersetRelation := tuple.SplitObjectRelation(member)
+			if _, ok := usersets[tuple.ToObjectRelationString(tuple.GetType(usersetObject), usersetRelation)]; !ok {
+				continue
+			}
+		} else if _, ok := types[tuple.GetType(member)]; !ok {
+			continue
+		}
+
+		// the members of a wildcard of the response are implied
+		if _, ok := users.wildcards[wildcardType(member)]; ok {
+			continue
+		}
+
+		resp.Users = append(resp.Users, member)
+	}
+
+	for objectType := range users.wildcards {
+		if _, ok := types[objectType]; ok {
+			resp.Users = append(resp.Users, fmt.Sprintf("%s:%s", objectType, tuple.Wildcard))
+		}
+	}
+
+	for user := range users.excluded {
+		if _, ok := types[wildcardType(user)]; ok {
+			resp.ExcludedUsers = append(resp.ExcludedUsers, user)
+		}
+	}
+
+	sort.Strings(resp.Users)
+	sort.Strings(resp.ExcludedUsers)
+
+	return resp
+}
@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListUsersQuery(t *testing.T) {
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+
+	ds := memory.New()
+	defer ds.Close()
+
+	typesys := typesystem.New(&openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type group
+		  relations
+			define member: [user, group#member] as self
+
+		type folder
+		  relations
+			define viewer: [user, group#member] as self
+
+		type document
+		  relations
+			define blocked: [user] as self
+			define parent: [folder] as self
+			define editor: [user, group#member] as self
+			define viewer: [user] as self or editor or viewer from parent
+			define public: [user:*] as self but not blocked
+		`),
+	})
+
+	err := ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "parent", "folder:x"),
+		tuple.NewTupleKey("document:1", "editor", "user:anne"),
+		tuple.NewTupleKey("document:1", "editor", "group:eng#member"),
+		tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+		tuple.NewTupleKey("group:eng", "member", "user:carl"),
+		tuple.NewTupleKey("group:eng", "member", "group:backend#member"),
+		tuple.NewTupleKey("group:backend", "member", "user:dave"),
+		tuple.NewTupleKey("folder:x", "viewer", "user:erin"),
+		tuple.NewTupleKey("document:1", "public", "user:*"),
+		tuple.NewTupleKey("document:1", "blocked", "user:frank"),
+	})
+	require.NoError(t, err)
+
+	q := NewListUsersQuery(ds, logger.NewNoopLogger())
+
+	t.Run("expands_the_usersets_and_the_tuple_to_usersets", func(t *testing.T) {
+		resp, err := q.Execute(ctx, typesys, &ListUsersRequest{
+			StoreID:     storeID,
+			Object:      "document:1",
+			Relation:    "viewer",
+			UserFilters: []string{"user"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, &ListUsersResponse{
+			Users:         []string{"user:anne", "user:bob", "user:carl", "user:dave", "user:erin"},
+			ExcludedUsers: []string{},
+		}, resp)
+	})
+
+	t.Run("usersets_filter", func(t *testing.T) {
+		resp, err := q.Execute(ctx, typesys, &ListUsersRequest{
+			StoreID:     storeID,
+			Object:      "document:1",
+			Relation:    "viewer",
+			UserFilters: []string{"group#member"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"group:backend#member", "group:eng#member"}, resp.Users)
+	})
+
+	t.Run("wildcard_with_exclusions", func(t *testing.T) {
+		resp, err := q.Execute(ctx, typesys, &ListUsersRequest{
+			StoreID:     storeID,
+			Object:      "document:1",
+			Relation:    "public",
+			UserFilters: []string{"user"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, &ListUsersResponse{
+			Users:         []string{"user:*"},
+			ExcludedUsers: []string{"user:frank"},
+		}, resp)
+	})
+
+	t.Run("contextual_tuples", func(t *testing.T) {
+		resp, err := q.Execute(ctx, typesys, &ListUsersRequest{
+			StoreID:     storeID,
+			Object:      "folder:y",
+			Relation:    "viewer",
+			UserFilters: []string{"user"},
+			ContextualTuples: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("folder:y", "viewer", "user:gina"),
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"user:gina"}, resp.Users)
+	})
+
+	t.Run("unreachable_filter", func(t *testing.T) {
+		resp, err := q.Execute(ctx, typesys, &ListUsersRequest{
+			StoreID:     storeID,
+			Object:      "document:1",
+			Relation:    "blocked",
+			UserFilters: []string{"folder"},
+		})
+		require.NoError(t, err)
+		require.Empty(t, resp.Users)
+	})
+
+	t.Run("invalid_filter", func(t *testing.T) {
+		_, err := q.Execute(ctx, typesys, &ListUsersRequest{
+			StoreID:     storeID,
+			Object:      "document:1",
+			Relation:    "viewer",
+			UserFilters: []string{"group#owner"},
+		})
+		require.ErrorContains(t, err, "invalid user filter 'group#owner'")
+	})
+
+	t.Run("missing_filters", func(t *testing.T) {
+		_, err := q.Execute(ctx, typesys, &ListUsersRequest{
+			StoreID:  storeID,
+			Object:   "document:1",
+			Relation: "viewer",
+		})
+		require.ErrorContains(t, err, "at least one user filter is required")
+	})
+
+	t.Run("undefined_relation", func(t *testing.T) {
+		_, err := q.Execute(ctx, typesys, &ListUsersRequest{
+			StoreID:     storeID,
+			Object:      "document:1",
+			Relation:    "owner",
+			UserFilters: []string{"user"},
+		})
+		require.Error(t, err)
+	})
+}
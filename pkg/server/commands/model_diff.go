@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// ModelDiffRequest requests the changes between two authorization models of a store.
+type ModelDiffRequest struct {
+	StoreID string
+
+	// FromAuthorizationModelID is the ID of the old model.
+	FromAuthorizationModelID string
+
+	// ToAuthorizationModelID is the ID of the new model. It defaults to the latest model of the store.
+	ToAuthorizationModelID string
+
+	// MaxTuples is the maximum number of tuples scanned for the tuples invalidated by the changes. A limit of 0
+	// scans the whole store.
+	MaxTuples int
+}
+
+// ModelChange is a change between two models, with the number of tuples of the store it invalidates.
+type ModelChange struct {
+	*typesystem.Change
+
+	// InvalidatedTuples is the number of tuples scanned that the change invalidates.
+	InvalidatedTuples int `json:"invalidated_tuples"`
+
+	// Breaking is whether the change invalidates some of the tuples scanned, e.g. it removes a relation that
+	// tuples are still written for.
+	Breaking bool `json:"breaking"`
+}
+
+// ModelDiffResponse reports the changes between two models of a store.
+type ModelDiffResponse struct {
+	FromAuthorizationModelID string         `json:"from_authorization_model_id"`
+	ToAuthorizationModelID   string         `json:"to_authorization_model_id"`
+	Changes                  []*ModelChange `json:"changes"`
+
+	// Breaking is whether any change is breaking.
+	Breaking bool `json:"breaking"`
+
+	TuplesScanned int `json:"tuples_scanned"`
+
+	// Sampled tells whether only some of the tuples of the store were scanned, because of the scan limit, in
+	// which case changes may be breaking without being reported as such.
+	Sampled bool `json:"sampled"`
+}
+
+// ModelDiffQuery reports the changes between two models of a store (see typesystem.Diff), and flags the ones
+// that would break the tuples of the store, e.g. the removal of a relation still referenced by tuples.
+type ModelDiffQuery struct {
+	datastore storage.OpenFGADatastore
+	logger    logger.Logger
+}
+
+func NewModelDiffQuery(datastore storage.OpenFGADatastore, logger logger.Logger) *ModelDiffQuery {
+	return &ModelDiffQuery{
+		datastore: datastore,
+		logger:    logger,
+	}
+}
+
+// Execute reports the changes from the old model to the new one, and scans the tuples of the store for the
+// ones the changes invalidate, if any change may.
+func (q *ModelDiffQuery) Execute(ctx context.Context, from, to *typesystem.TypeSystem, req *ModelDiffRequest) (*ModelDiffResponse, error) {
+	if req.MaxTuples < 0 {
+		return nil, serverErrors.ValidationError(fmt.Errorf("the maximum number of tuples must not be negative"))
+	}
+
+	changes := typesystem.Diff(
+		&openfgav1.AuthorizationModel{TypeDefinitions: from.GetAllTypeDefinitions()},
+		&openfgav1.AuthorizationModel{TypeDefinitions: to.GetAllTypeDefinitions()},
+	)
+
+	resp := &ModelDiffResponse{
+		FromAuthorizationModelID: from.GetAuthorizationModelID(),
+		ToAuthorizationModelID:   to.GetAuthorizationModelID(),
+		Changes:                  make([]*ModelChange, 0, len(changes)),
+	}
+
+	var breakingChanges []*ModelChange
+	for _, change := range changes {
+		modelChange := &ModelChange{Change: change}
+		resp.Changes = append(resp.Changes, modelChange)
+
+		if change.Breaking() {
+			breakingChanges = append(breakingChanges, modelChange)
+		}
+	}
+
+	if len(breakingChanges) == 0 {
+		return resp, nil
+	}
+
+	unused, err := NewUnusedTuplesQuery(q.datastore, q.logger, WithUnusedTuplesScanLimit(req.MaxTuples)).Execute(ctx, req.StoreID, to)
+	if err != nil {
+		return nil, serverErrors.HandleError(ctx, "", err)
+	}
+
+	resp.TuplesScanned = unused.TuplesScanned
+	resp.Sampled = unused.Sampled
+
+	for _, unusedTuple := range unused.UnusedTuples {
+		for _, change := range breakingChanges {
+			if change.Invalidates(unusedTuple.TupleKey) {
+				change.InvalidatedTuples++
+				change.Breaking = true
+				resp.Breaking = true
+			}
+		}
+	}
+
+	return resp, nil
+}
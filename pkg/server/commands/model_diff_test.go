@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelDiffQuery(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	defer ds.Close()
+
+	storeID := ulid.Make().String()
+
+	from := typesystem.New(&openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+		type document
+		  relations
+		    define editor: [user] as self
+		    define commenter: [user] as self
+		    define viewer: [user, user:*] as self
+		`),
+	})
+	to := typesystem.New(&openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+		type folder
+		type document
+		  relations
+		    define commenter: [user] as self
+		    define viewer: [user] as self
+		`),
+	})
+
+	err := ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:1", "viewer", "user:*"),
+		tuple.NewTupleKey("document:2", "viewer", "user:*"),
+		tuple.NewTupleKey("document:1", "commenter", "user:anne"),
+	})
+	require.NoError(t, err)
+
+	q := NewModelDiffQuery(ds, logger.NewNoopLogger())
+
+	t.Run("breaking_changes", func(t *testing.T) {
+		resp, err := q.Execute(ctx, from, to, &ModelDiffRequest{StoreID: storeID})
+		require.NoError(t, err)
+		require.Equal(t, from.GetAuthorizationModelID(), resp.FromAuthorizationModelID)
+		require.Equal(t, to.GetAuthorizationModelID(), resp.ToAuthorizationModelID)
+		require.Equal(t, 4, resp.TuplesScanned)
+		require.True(t, resp.Breaking)
+
+		require.Len(t, resp.Changes, 3)
+
+		// no tuple is written for the editors
+		require.Equal(t, typesystem.RelationRemoved, resp.Changes[0].Kind)
+		require.Equal(t, "editor", resp.Changes[0].Relation)
+		require.False(t, resp.Changes[0].Breaking)
+
+		require.Equal(t, typesystem.TypeRestrictionRemoved, resp.Changes[1].Kind)
+		require.Equal(t, "user:*", resp.Changes[1].TypeRestriction)
+		require.True(t, resp.Changes[1].Breaking)
+		require.Equal(t, 2, resp.Changes[1].InvalidatedTuples)
+
+		require.Equal(t, typesystem.TypeAdded, resp.Changes[2].Kind)
+		require.Equal(t, "folder", resp.Changes[2].ObjectType)
+	})
+
+	t.Run("no_tuple_invalidated", func(t *testing.T) {
+		// the folder type is removed, but no tuple is written for it
+		resp, err := q.Execute(ctx, to, from, &ModelDiffRequest{StoreID: storeID})
+		require.NoError(t, err)
+		require.False(t, resp.Breaking)
+		require.Equal(t, 4, resp.TuplesScanned)
+
+		for _, change := range resp.Changes {
+			require.False(t, change.Breaking)
+			require.Zero(t, change.InvalidatedTuples)
+		}
+	})
+
+	t.Run("no_breaking_change", func(t *testing.T) {
+		resp, err := q.Execute(ctx, from, from, &ModelDiffRequest{StoreID: storeID})
+		require.NoError(t, err)
+		require.Empty(t, resp.Changes)
+		require.False(t, resp.Breaking)
+		require.Zero(t, resp.TuplesScanned)
+	})
+
+	t.Run("scan_limit", func(t *testing.T) {
+		resp, err := q.Execute(ctx, from, to, &ModelDiffRequest{StoreID: storeID, MaxTuples: 1})
+		require.NoError(t, err)
+		require.Equal(t, 1, resp.TuplesScanned)
+		require.True(t, resp.Sampled)
+
+		_, err = q.Execute(ctx, from, to, &ModelDiffRequest{StoreID: storeID, MaxTuples: -1})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ModelImpactRequest requests the analysis of the impact of a candidate authorization model on the tuples of
+// a store.
+type ModelImpactRequest struct {
+	StoreID string
+
+	// AuthorizationModel is the candidate model, which isn't written. Its ID is ignored.
+	AuthorizationModel *openfgav1.AuthorizationModel
+
+	// SampleRate is the fraction (between 0 and 1) of the tuples that are scanned. A rate of 0 scans every
+	// tuple.
+	SampleRate float64
+
+	// MaxTuples is the maximum number of tuples scanned. A limit of 0 scans the whole store.
+	MaxTuples int
+}
+
+// ModelImpactResponse reports the tuples of a store that would become invalid under a candidate model.
+type ModelImpactResponse struct {
+	TuplesScanned int  `json:"tuples_scanned"`
+	Sampled       bool `json:"sampled"`
+
+	// InvalidTuples holds the tuples scanned that don't fit the candidate model, with the reason why.
+	InvalidTuples []*UnusedTuple `json:"invalid_tuples"`
+}
+
+// ModelImpactQuery reports which tuples of a store would become invalid, e.g. because of new type
+// restrictions or of removed relations, if a candidate model was promoted, so that the migration of the
+// tuples can be assessed before the model is written.
+type ModelImpactQuery struct {
+	datastore                        storage.OpenFGADatastore
+	logger                           logger.Logger
+	maxAuthorizationModelSizeInBytes int
+	typesystemOpts                   []typesystem.TypeSystemOption
+}
+
+func NewModelImpactQuery(datastore storage.OpenFGADatastore, logger logger.Logger, maxAuthorizationModelSizeInBytes int, typesystemOpts ...typesystem.TypeSystemOption) *ModelImpactQuery {
+	return &ModelImpactQuery{
+		datastore:                        datastore,
+		logger:                           logger,
+		maxAuthorizationModelSizeInBytes: maxAuthorizationModelSizeInBytes,
+		typesystemOpts:                   typesystemOpts,
+	}
+}
+
+// Execute validates the candidate model, as if it was written, and scans the tuples of the store against it.
+func (q *ModelImpactQuery) Execute(ctx context.Context, req *ModelImpactRequest) (*ModelImpactResponse, error) {
+	if req.AuthorizationModel == nil {
+		return nil, serverErrors.ValidationError(fmt.Errorf("the candidate authorization model is required"))
+	}
+
+	if req.SampleRate < 0 || req.SampleRate > 1 {
+		return nil, serverErrors.ValidationError(fmt.Errorf("the sample rate must be between 0 and 1"))
+	}
+
+	if req.MaxTuples < 0 {
+		return nil, serverErrors.ValidationError(fmt.Errorf("the maximum number of tuples must not be negative"))
+	}
+
+	model := proto.Clone(req.AuthorizationModel).(*openfgav1.AuthorizationModel)
+	model.Id = ulid.Make().String()
+
+	modelSize := proto.Size(model)
+	if modelSize > q.maxAuthorizationModelSizeInBytes {
+		return nil, status.Error(
+			codes.Code(openfgav1.ErrorCode_exceeded_entity_limit),
+			fmt.Sprintf("model exceeds size limit: %d bytes vs %d bytes", modelSize, q.maxAuthorizationModelSizeInBytes),
+		)
+	}
+
+	typesys, err := typesystem.NewAndValidate(ctx, model, q.typesystemOpts...)
+	if err != nil {
+		return nil, serverErrors.InvalidAuthorizationModelInput(err)
+	}
+
+	opts := []UnusedTuplesQueryOption{WithUnusedTuplesScanLimit(req.MaxTuples)}
+	if req.SampleRate > 0 {
+		opts = append(opts, WithUnusedTuplesSampleRate(req.SampleRate))
+	}
+
+	resp, err := NewUnusedTuplesQuery(q.datastore, q.logger, opts...).Execute(ctx, req.StoreID, typesys)
+	if err != nil {
+		return nil, serverErrors.HandleError(ctx, "", err)
+	}
+
+	return &ModelImpactResponse{
+		TuplesScanned: resp.TuplesScanned,
+		Sampled:       resp.Sampled,
+		InvalidTuples: resp.UnusedTuples,
+	}, nil
+}
@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestModelImpactQuery(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	defer ds.Close()
+
+	storeID := ulid.Make().String()
+
+	err := ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:1", "viewer", "user:*"),
+		tuple.NewTupleKey("document:2", "viewer", "user:*"),
+		tuple.NewTupleKey("document:1", "editor", "user:anne"),
+	})
+	require.NoError(t, err)
+
+	// the candidate model disallows the public viewers, and removes the editors
+	candidate := &openfgav1.AuthorizationModel{
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type document
+		  relations
+		    define viewer: [user] as self
+		`),
+	}
+
+	q := NewModelImpactQuery(ds, logger.NewNoopLogger(), 256*1_024)
+
+	t.Run("full_scan", func(t *testing.T) {
+		resp, err := q.Execute(ctx, &ModelImpactRequest{StoreID: storeID, AuthorizationModel: candidate})
+		require.NoError(t, err)
+		require.Equal(t, 4, resp.TuplesScanned)
+		require.False(t, resp.Sampled)
+		require.Len(t, resp.InvalidTuples, 3)
+
+		// the candidate model isn't written
+		_, err = ds.FindLatestAuthorizationModelID(ctx, storeID)
+		require.Error(t, err)
+	})
+
+	t.Run("scan_limit", func(t *testing.T) {
+		resp, err := q.Execute(ctx, &ModelImpactRequest{StoreID: storeID, AuthorizationModel: candidate, MaxTuples: 2})
+		require.NoError(t, err)
+		require.Equal(t, 2, resp.TuplesScanned)
+		require.True(t, resp.Sampled)
+
+		resp, err = q.Execute(ctx, &ModelImpactRequest{StoreID: storeID, AuthorizationModel: candidate, MaxTuples: 4})
+		require.NoError(t, err)
+		require.False(t, resp.Sampled)
+	})
+
+	t.Run("invalid_requests", func(t *testing.T) {
+		_, err := q.Execute(ctx, &ModelImpactRequest{StoreID: storeID, AuthorizationModel: candidate, SampleRate: 2})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+
+		_, err = q.Execute(ctx, &ModelImpactRequest{StoreID: storeID})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+
+		_, err = q.Execute(ctx, &ModelImpactRequest{StoreID: storeID, AuthorizationModel: &openfgav1.AuthorizationModel{
+			SchemaVersion:   typesystem.SchemaVersion1_1,
+			TypeDefinitions: []*openfgav1.TypeDefinition{{Type: "user"}, {Type: "user"}},
+		}})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_invalid_authorization_model), status.Code(err))
+
+		_, err = NewModelImpactQuery(ds, logger.NewNoopLogger(), 16).Execute(ctx, &ModelImpactRequest{StoreID: storeID, AuthorizationModel: candidate})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_exceeded_entity_limit), status.Code(err))
+	})
+}
+
+func TestUnusedTuplesQuerySampling(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	defer ds.Close()
+
+	storeID := ulid.Make().String()
+	err := ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:2", "viewer", "user:anne"),
+	})
+	require.NoError(t, err)
+
+	typesys := typesystem.New(&openfgav1.AuthorizationModel{
+		Id:              ulid.Make().String(),
+		SchemaVersion:   typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`type user`),
+	})
+
+	q := NewUnusedTuplesQuery(ds, logger.NewNoopLogger(), WithUnusedTuplesSampleRate(0.5))
+	randoms := []float64{0.2, 0.7}
+	q.random = func() float64 {
+		random := randoms[0]
+		randoms = randoms[1:]
+		return random
+	}
+
+	resp, err := q.Execute(ctx, storeID, typesys)
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.TuplesScanned)
+	require.True(t, resp.Sampled)
+	require.Len(t, resp.UnusedTuples, 1)
+}
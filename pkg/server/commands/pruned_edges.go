@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/internal/graph"
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// GetPrunedRelationshipEdgesRequest requests the pruned relationship edges from a kind of user, e.g. 'user',
+// 'user:*' or 'group#member', to a relation of an object type. If the AuthorizationModelID is empty, the
+// latest authorization model of the store is used.
+type GetPrunedRelationshipEdgesRequest struct {
+	StoreID              string
+	AuthorizationModelID string
+	ObjectType           string
+	Relation             string
+	UserType             string
+}
+
+// PrunedBranch is a sibling branch of an intersection or an exclusion that isn't followed when resolving an
+// edge.
+type PrunedBranch struct {
+	// Type is either 'intersection' or 'exclusion'.
+	Type string `json:"type"`
+
+	// Relation is the 'type#relation' whose rewrite the branch belongs to.
+	Relation string `json:"relation"`
+
+	// Rewrite is the pruned branch written in the DSL.
+	Rewrite string `json:"rewrite"`
+}
+
+// PrunedRelationshipEdge is an edge of the relationship graph that is followed to find the objects a user
+// has a relation with.
+type PrunedRelationshipEdge struct {
+	// Type is either 'direct', 'computed_userset' or 'ttu'.
+	Type string `json:"type"`
+
+	// Target is the 'type#relation' the edge is directed towards.
+	Target string `json:"target"`
+
+	// TuplesetRelation is the 'type#relation' of the tupleset of a 'ttu' edge.
+	TuplesetRelation string `json:"tupleset_relation,omitempty"`
+
+	// RequiresFurtherEval is set when the results found through the edge must be checked against its
+	// pruned branches.
+	RequiresFurtherEval bool `json:"requires_further_eval"`
+
+	PrunedBranches []*PrunedBranch `json:"pruned_branches,omitempty"`
+}
+
+type GetPrunedRelationshipEdgesResponse struct {
+	Edges []*PrunedRelationshipEdge `json:"edges"`
+}
+
+// GetPrunedRelationshipEdgesQuery returns the relationship edges that ListObjects follows from a kind of
+// user to a relation, along with the branches it prunes. It's a debugging aid that makes the pruning the
+// resolver relies on observable.
+type GetPrunedRelationshipEdgesQuery struct {
+	logger logger.Logger
+}
+
+func NewGetPrunedRelationshipEdgesQuery(logger logger.Logger) *GetPrunedRelationshipEdgesQuery {
+	return &GetPrunedRelationshipEdgesQuery{
+		logger: logger,
+	}
+}
+
+func (q *GetPrunedRelationshipEdgesQuery) Execute(ctx context.Context, typesys *typesystem.TypeSystem, req *GetPrunedRelationshipEdgesRequest) (*GetPrunedRelationshipEdgesResponse, error) {
+	source, err := userTypeReference(typesys, req.UserType)
+	if err != nil {
+		return nil, err
+	}
+
+	edges, err := graph.New(typesys).GetPrunedRelationshipEdges(typesystem.DirectRelationReference(req.ObjectType, req.Relation), source)
+	if err != nil {
+		if errors.Is(err, typesystem.ErrObjectTypeUndefined) {
+			return nil, serverErrors.TypeNotFound(req.ObjectType)
+		}
+		if errors.Is(err, typesystem.ErrRelationUndefined) {
+			return nil, serverErrors.RelationNotFound(req.Relation, req.ObjectType, nil)
+		}
+		return nil, serverErrors.HandleError(ctx, "", err)
+	}
+
+	resp := &GetPrunedRelationshipEdgesResponse{
+		Edges: make([]*PrunedRelationshipEdge, 0, len(edges)),
+	}
+
+	for _, edge := range edges {
+		e := &PrunedRelationshipEdge{
+			Type:                edge.Type.String(),
+			Target:              relationReferenceString(edge.TargetReference),
+			RequiresFurtherEval: edge.Condition == graph.RequiresFurtherEvalCondition,
+		}
+
+		if edge.TuplesetRelation != nil {
+			e.TuplesetRelation = relationReferenceString(edge.TuplesetRelation)
+		}
+
+		for _, branch := range edge.PrunedBranches {
+			e.PrunedBranches = append(e.PrunedBranches, &PrunedBranch{
+				Type:     branch.Type.String(),
+				Relation: relationReferenceString(branch.Relation),
+				Rewrite:  branch.Rewrite,
+			})
+		}
+
+		resp.Edges = append(resp.Edges, e)
+	}
+
+	return resp, nil
+}
+
+// userTypeReference parses a kind of user, i.e. 'user', 'user:*' or 'group#member', and validates it
+// against the model.
+func userTypeReference(typesys *typesystem.TypeSystem, userType string) (*openfgav1.RelationReference, error) {
+	var reference *openfgav1.RelationReference
+	switch {
+	case strings.HasSuffix(userType, ":*"):
+		reference = typesystem.WildcardRelationReference(strings.TrimSuffix(userType, ":*"))
+	default:
+		objectType, relation := tuple.SplitObjectRelation(userType)
+		reference = typesystem.DirectRelationReference(objectType, relation)
+	}
+
+	if _, ok := typesys.GetTypeDefinition(reference.GetType()); !ok {
+		return nil, serverErrors.TypeNotFound(reference.GetType())
+	}
+
+	if reference.GetRelation() != "" {
+		if _, err := typesys.GetRelation(reference.GetType(), reference.GetRelation()); err != nil {
+			return nil, serverErrors.RelationNotFound(reference.GetRelation(), reference.GetType(), nil)
+		}
+	}
+
+	return reference, nil
+}
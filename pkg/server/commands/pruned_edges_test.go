@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPrunedRelationshipEdgesQuery(t *testing.T) {
+	typesys := typesystem.New(&openfgav1.AuthorizationModel{
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type folder
+		  relations
+			define blocked: [user] as self
+			define viewer: [user] as self but not blocked
+
+		type document
+		  relations
+			define allowed: [user] as self
+			define parent: [folder] as self
+			define viewer: [user:*] as self and allowed
+			define reader as viewer from parent
+		`),
+	})
+
+	tests := []struct {
+		name          string
+		objectType    string
+		relation      string
+		userType      string
+		expectedEdges []*PrunedRelationshipEdge
+		expectedError error
+	}{
+		{
+			name:       "intersection",
+			objectType: "document",
+			relation:   "viewer",
+			userType:   "user:*",
+			expectedEdges: []*PrunedRelationshipEdge{
+				{
+					Type:                "direct",
+					Target:              "document#viewer",
+					RequiresFurtherEval: true,
+					PrunedBranches: []*PrunedBranch{
+						{Type: "intersection", Relation: "document#viewer", Rewrite: "allowed"},
+					},
+				},
+			},
+		},
+		{
+			name:       "exclusion_through_ttu",
+			objectType: "document",
+			relation:   "reader",
+			userType:   "folder#viewer",
+			expectedEdges: []*PrunedRelationshipEdge{
+				{
+					Type:                "ttu",
+					Target:              "document#reader",
+					TuplesetRelation:    "document#parent",
+					RequiresFurtherEval: true,
+				},
+			},
+		},
+		{
+			name:          "no_edges",
+			objectType:    "document",
+			relation:      "viewer",
+			userType:      "folder",
+			expectedEdges: []*PrunedRelationshipEdge{},
+		},
+		{
+			name:          "undefined_user_type",
+			objectType:    "document",
+			relation:      "viewer",
+			userType:      "group#member",
+			expectedError: serverErrors.TypeNotFound("group"),
+		},
+		{
+			name:          "undefined_user_relation",
+			objectType:    "document",
+			relation:      "viewer",
+			userType:      "folder#editor",
+			expectedError: serverErrors.RelationNotFound("editor", "folder", nil),
+		},
+		{
+			name:          "undefined_relation",
+			objectType:    "document",
+			relation:      "editor",
+			userType:      "user",
+			expectedError: serverErrors.RelationNotFound("editor", "document", nil),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := NewGetPrunedRelationshipEdgesQuery(logger.NewNoopLogger()).Execute(context.Background(), typesys, &GetPrunedRelationshipEdgesRequest{
+				ObjectType: test.objectType,
+				Relation:   test.relation,
+				UserType:   test.userType,
+			})
+			if test.expectedError != nil {
+				require.ErrorIs(t, err, test.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expectedEdges, resp.Edges)
+		})
+	}
+}
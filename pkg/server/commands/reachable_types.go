@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/internal/graph"
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// GetReachableTypesRequest requests the kinds of users that can possibly have a relation with the objects of
+// a type. If the AuthorizationModelID is empty, the latest authorization model of the store is used.
+type GetReachableTypesRequest struct {
+	StoreID              string
+	AuthorizationModelID string
+	ObjectType           string
+	Relation             string
+}
+
+// GetReachableTypesResponse lists the user types (e.g. 'user'), typed wildcards (e.g. 'user:*') and usersets
+// (e.g. 'group#member') that can possibly satisfy the relation.
+type GetReachableTypesResponse struct {
+	UserTypes []string `json:"user_types"`
+}
+
+// GetReachableTypesQuery finds, in the relationship graph of an authorization model, the kinds of users from
+// which a relation can be reached. Clients can use it to only offer the subjects that make sense for a
+// relation, e.g. in the subject pickers of admin UIs.
+type GetReachableTypesQuery struct {
+	logger logger.Logger
+}
+
+func NewGetReachableTypesQuery(logger logger.Logger) *GetReachableTypesQuery {
+	return &GetReachableTypesQuery{
+		logger: logger,
+	}
+}
+
+func (q *GetReachableTypesQuery) Execute(ctx context.Context, typesys *typesystem.TypeSystem, req *GetReachableTypesRequest) (*GetReachableTypesResponse, error) {
+	references, err := graph.New(typesys).GetReachableTypes(typesystem.DirectRelationReference(req.ObjectType, req.Relation))
+	if err != nil {
+		if errors.Is(err, typesystem.ErrObjectTypeUndefined) {
+			return nil, serverErrors.TypeNotFound(req.ObjectType)
+		}
+		if errors.Is(err, typesystem.ErrRelationUndefined) {
+			return nil, serverErrors.RelationNotFound(req.Relation, req.ObjectType, nil)
+		}
+		return nil, serverErrors.HandleError(ctx, "", err)
+	}
+
+	userTypes := make([]string, 0, len(references))
+	for _, reference := range references {
+		userTypes = append(userTypes, relationReferenceString(reference))
+	}
+
+	return &GetReachableTypesResponse{UserTypes: userTypes}, nil
+}
+
+// relationReferenceString returns 'user', 'user:*' or 'group#member'.
+func relationReferenceString(reference *openfgav1.RelationReference) string {
+	switch ref := reference.GetRelationOrWildcard().(type) {
+	case *openfgav1.RelationReference_Relation:
+		return fmt.Sprintf("%s#%s", reference.GetType(), ref.Relation)
+	case *openfgav1.RelationReference_Wildcard:
+		return fmt.Sprintf("%s:*", reference.GetType())
+	default:
+		return reference.GetType()
+	}
+}
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetReachableTypesQuery(t *testing.T) {
+	typesys := typesystem.New(&openfgav1.AuthorizationModel{
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type group
+		  relations
+			define member: [user] as self
+
+		type document
+		  relations
+			define viewer: [user:*, group#member] as self
+		`),
+	})
+
+	tests := []struct {
+		name              string
+		objectType        string
+		relation          string
+		expectedUserTypes []string
+		expectedError     error
+	}{
+		{
+			name:              "reachable_types",
+			objectType:        "document",
+			relation:          "viewer",
+			expectedUserTypes: []string{"group#member", "user", "user:*"},
+		},
+		{
+			name:          "undefined_type",
+			objectType:    "folder",
+			relation:      "viewer",
+			expectedError: serverErrors.TypeNotFound("folder"),
+		},
+		{
+			name:          "undefined_relation",
+			objectType:    "document",
+			relation:      "editor",
+			expectedError: serverErrors.RelationNotFound("editor", "document", nil),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := NewGetReachableTypesQuery(logger.NewNoopLogger()).Execute(context.Background(), typesys, &GetReachableTypesRequest{
+				ObjectType: test.objectType,
+				Relation:   test.relation,
+			})
+			if test.expectedError != nil {
+				require.ErrorIs(t, err, test.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expectedUserTypes, resp.UserTypes)
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/storage"
 	tupleUtils "github.com/openfga/openfga/pkg/tuple"
+	"go.uber.org/zap"
 )
 
 // A ReadQuery can be used to read one or many tuplesets
@@ -21,15 +22,63 @@ type ReadQuery struct {
 	datastore storage.OpenFGADatastore
 	logger    logger.Logger
 	encoder   encoder.Encoder
+
+	contextualTuples []*openfgav1.TupleKey
+
+	guardrails ReadGuardrails
+	stats      storage.StatsProvider
+}
+
+// ReadGuardrails reject the overly broad Read requests, to protect the datastore from the accidental scans of
+// every tuple of a store. The filter dimensions of a request are the object type, the object ID, the relation
+// and the user of its tuple key.
+type ReadGuardrails struct {
+	// RejectUnfiltered rejects the requests without a tuple key, which read every tuple of the store.
+	RejectUnfiltered bool
+
+	// MinFilterDimensions is the minimum number of filter dimensions of the requests to the large stores. 0
+	// disables the guardrail.
+	MinFilterDimensions int
+
+	// LargeStoreTupleCount is the number of tuples above which a store is large, according to the statistics
+	// of the datastore. 0 makes every store large, and so do the datastores without statistics.
+	LargeStoreTupleCount int64
+}
+
+type ReadQueryOption func(*ReadQuery)
+
+// WithReadGuardrails rejects the Read requests that the guardrails deem too broad. The statistics of the
+// stores, which may be nil, tell which stores are large.
+func WithReadGuardrails(guardrails ReadGuardrails, stats storage.StatsProvider) ReadQueryOption {
+	return func(q *ReadQuery) {
+		q.guardrails = guardrails
+		q.stats = stats
+	}
+}
+
+// WithReadContextualTuples reads the contextual tuples as if they were written, e.g. to preview the tuples of
+// an object with hypothetical tuples before writing them. The contextual tuples which match the tuple key of
+// a request are returned first, in its first page, which may then hold more tuples than the page size. The
+// caller validates the tuples.
+func WithReadContextualTuples(contextualTuples []*openfgav1.TupleKey) ReadQueryOption {
+	return func(q *ReadQuery) {
+		q.contextualTuples = contextualTuples
+	}
 }
 
 // NewReadQuery creates a ReadQuery using the provided OpenFGA datastore implementation.
-func NewReadQuery(datastore storage.OpenFGADatastore, logger logger.Logger, encoder encoder.Encoder) *ReadQuery {
-	return &ReadQuery{
+func NewReadQuery(datastore storage.OpenFGADatastore, logger logger.Logger, encoder encoder.Encoder, opts ...ReadQueryOption) *ReadQuery {
+	q := &ReadQuery{
 		datastore: datastore,
 		logger:    logger,
 		encoder:   encoder,
 	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	return q
 }
 
 // Execute the ReadQuery, returning paginated `openfga.Tuple`(s) that match the tuple. Return all tuples if the tuple is
@@ -48,21 +97,36 @@ func (q *ReadQuery) Execute(ctx context.Context, req *openfgav1.ReadRequest) (*o
 		}
 	}
 
-	decodedContToken, err := q.encoder.Decode(req.GetContinuationToken())
+	if err := q.checkGuardrails(ctx, store, tk); err != nil {
+		return nil, err
+	}
+
+	decodedContToken, err := encoder.DecodeForStore(q.encoder, store, req.GetContinuationToken())
 	if err != nil {
-		return nil, serverErrors.InvalidContinuationToken
+		return nil, serverErrors.ContinuationTokenError(err)
 	}
 
 	paginationOptions := storage.NewPaginationOptions(req.GetPageSize().GetValue(), string(decodedContToken))
 
-	tuples, contToken, err := q.datastore.ReadPage(ctx, store, tk, paginationOptions)
+	tuples, contToken, err := q.datastore.ReadPage(ctx, store, tk, paginationOptions, storage.ReadOptionsFromContext(ctx))
 	if err != nil {
-		return nil, serverErrors.HandleError("", err)
+		return nil, serverErrors.HandleError(ctx, "", err)
+	}
+
+	if req.GetContinuationToken() == "" {
+		var contextualTuples []*openfgav1.Tuple
+		for _, ctxTuple := range q.contextualTuples {
+			if matchesReadTupleKey(ctxTuple, tk) {
+				contextualTuples = append(contextualTuples, &openfgav1.Tuple{Key: ctxTuple})
+			}
+		}
+
+		tuples = append(contextualTuples, tuples...)
 	}
 
-	encodedContToken, err := q.encoder.Encode(contToken)
+	encodedContToken, err := encoder.EncodeForStore(q.encoder, store, contToken)
 	if err != nil {
-		return nil, serverErrors.HandleError("", err)
+		return nil, serverErrors.HandleError(ctx, "", err)
 	}
 
 	return &openfgav1.ReadResponse{
@@ -70,3 +134,64 @@ func (q *ReadQuery) Execute(ctx context.Context, req *openfgav1.ReadRequest) (*o
 		ContinuationToken: encodedContToken,
 	}, nil
 }
+
+// matchesReadTupleKey reports whether the tuple is one of the tuples read with the tuple key of a Read request,
+// whose object may be just a type, and whose relation and user may be empty.
+func matchesReadTupleKey(t, tk *openfgav1.TupleKey) bool {
+	objectType, objectID := tupleUtils.SplitObject(tk.GetObject())
+	tupleObjectType, tupleObjectID := tupleUtils.SplitObject(t.GetObject())
+
+	switch {
+	case objectType != "" && objectType != tupleObjectType:
+		return false
+	case objectID != "" && objectID != tupleObjectID:
+		return false
+	case tk.GetRelation() != "" && tk.GetRelation() != t.GetRelation():
+		return false
+	case tk.GetUser() != "" && tk.GetUser() != t.GetUser():
+		return false
+	default:
+		return true
+	}
+}
+
+// checkGuardrails rejects the request if the guardrails deem its tuple key too broad.
+func (q *ReadQuery) checkGuardrails(ctx context.Context, store string, tk *openfgav1.TupleKey) error {
+	objectType, objectID := tupleUtils.SplitObject(tk.GetObject())
+
+	dimensions := 0
+	for _, value := range []string{objectType, objectID, tk.GetRelation(), tk.GetUser()} {
+		if value != "" {
+			dimensions++
+		}
+	}
+
+	if dimensions == 0 && q.guardrails.RejectUnfiltered {
+		return serverErrors.ReadTooBroad(fmt.Errorf("reading every tuple of the store isn't allowed: the 'tuple_key' field must be provided"))
+	}
+
+	if dimensions >= q.guardrails.MinFilterDimensions || !q.largeStore(ctx, store) {
+		return nil
+	}
+
+	return serverErrors.ReadTooBroad(fmt.Errorf(
+		"the 'tuple_key' field must filter on at least %d of the object type, the object ID, the relation and the user",
+		q.guardrails.MinFilterDimensions,
+	))
+}
+
+// largeStore reports whether the store has more tuples than the LargeStoreTupleCount of the guardrails. The
+// stores are deemed large if their statistics can't be read.
+func (q *ReadQuery) largeStore(ctx context.Context, store string) bool {
+	if q.guardrails.LargeStoreTupleCount == 0 || q.stats == nil {
+		return true
+	}
+
+	stats, err := q.stats.StoreStats(ctx, store)
+	if err != nil {
+		q.logger.WarnWithContext(ctx, "failed to read the statistics of the store", zap.String("store_id", store), zap.Error(err))
+		return true
+	}
+
+	return stats.TupleCount > q.guardrails.LargeStoreTupleCount
+}
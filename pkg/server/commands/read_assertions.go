@@ -24,7 +24,7 @@ func NewReadAssertionsQuery(backend storage.AssertionsBackend, logger logger.Log
 func (q *ReadAssertionsQuery) Execute(ctx context.Context, store, authorizationModelID string) (*openfgav1.ReadAssertionsResponse, error) {
 	assertions, err := q.backend.ReadAssertions(ctx, store, authorizationModelID)
 	if err != nil {
-		return nil, serverErrors.HandleError("", err)
+		return nil, serverErrors.HandleError(ctx, "", err)
 	}
 	return &openfgav1.ReadAssertionsResponse{
 		AuthorizationModelId: authorizationModelID,
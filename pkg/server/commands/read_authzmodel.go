@@ -27,7 +27,7 @@ func (q *ReadAuthorizationModelQuery) Execute(ctx context.Context, req *openfgav
 		if errors.Is(err, storage.ErrNotFound) {
 			return nil, serverErrors.AuthorizationModelNotFound(modelID)
 		}
-		return nil, serverErrors.HandleError("", err)
+		return nil, serverErrors.HandleError(ctx, "", err)
 	}
 	return &openfgav1.ReadAuthorizationModelResponse{
 		AuthorizationModel: azm,
@@ -0,0 +1,251 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/internal/validation"
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"go.uber.org/zap"
+)
+
+const renameRelationsPageSize = 100
+
+// RelationRename renames a relation of an object type, e.g. 'viewer' to 'reader' on 'document'.
+type RelationRename struct {
+	ObjectType string `json:"object_type"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+}
+
+// RenameRelationsRequest requests the rewrite of the tuples of the relations renamed. The authorization model
+// of the AuthorizationModelID, or the latest model of the store if it's empty, must define both the old and
+// the new relation of every rename.
+type RenameRelationsRequest struct {
+	StoreID              string            `json:"store_id"`
+	AuthorizationModelID string            `json:"authorization_model_id,omitempty"`
+	Renames              []*RelationRename `json:"renames"`
+
+	// DryRun reports the tuples that would be rewritten without rewriting them.
+	DryRun bool `json:"dry_run"`
+}
+
+// RelationRenameResult reports the tuples of a rename.
+type RelationRenameResult struct {
+	RelationRename
+
+	// Rewritten is the number of tuples of the old relation rewritten with the new one, or that would be in a
+	// dry run. The tuples whose rewrite already exists are only deleted, and counted as rewritten.
+	Rewritten int `json:"rewritten"`
+
+	// Skipped holds the tuples of the old relation that the new one doesn't allow, e.g. because of its
+	// directly related user types, with the reason why. They're left as is.
+	Skipped []*UnusedTuple `json:"skipped"`
+}
+
+// RenameRelationsResponse reports the tuples of every rename of a RenameRelationsRequest.
+type RenameRelationsResponse struct {
+	AuthorizationModelID string                  `json:"authorization_model_id"`
+	DryRun               bool                    `json:"dry_run"`
+	Renames              []*RelationRenameResult `json:"renames"`
+}
+
+// RenameRelationsCommand rewrites the tuples of the relations being renamed with their new names. A rename
+// is a migration in three steps: a model that defines both relations, e.g. with the new relation computed
+// from the old one ('define reader: [user] as self or viewer'), is written; the tuples are rewritten by the
+// command; and a model without the old relation is written once no tuple of the old relation is left, which
+// a dry run tells. The rewrites are regular writes, which are recorded in the changelog.
+type RenameRelationsCommand struct {
+	datastore storage.OpenFGADatastore
+	logger    logger.Logger
+	batchSize int
+	hook      TuplesWrittenHook
+}
+
+// RenameRelationsCommandOption defines an option that can be used to change the behavior of a
+// RenameRelationsCommand.
+type RenameRelationsCommandOption func(*RenameRelationsCommand)
+
+// WithRenameRelationsBatchSize sets the number of tuples rewritten per write, which is capped to half the
+// maximum number of tuples per write of the datastore, since every rewrite is a delete and a write.
+func WithRenameRelationsBatchSize(batchSize int) RenameRelationsCommandOption {
+	return func(c *RenameRelationsCommand) {
+		c.batchSize = batchSize
+	}
+}
+
+// WithRenameRelationsHook calls the hook with the tuples deleted and written by every successful rewrite.
+func WithRenameRelationsHook(hook TuplesWrittenHook) RenameRelationsCommandOption {
+	return func(c *RenameRelationsCommand) {
+		c.hook = hook
+	}
+}
+
+func NewRenameRelationsCommand(datastore storage.OpenFGADatastore, logger logger.Logger, opts ...RenameRelationsCommandOption) *RenameRelationsCommand {
+	c := &RenameRelationsCommand{
+		datastore: datastore,
+		logger:    logger,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	maxBatchSize := max(datastore.MaxTuplesPerWrite()/2, 1)
+	if c.batchSize <= 0 || c.batchSize > maxBatchSize {
+		c.batchSize = maxBatchSize
+	}
+
+	return c
+}
+
+// Execute rewrites the tuples of every rename, one rename after the other. The tuples of a rename are all
+// read before they're rewritten, so that the rewrites don't move the pages being read. The tuples of the
+// old relation written in the meantime are left, and rewritten by the next execution.
+func (c *RenameRelationsCommand) Execute(ctx context.Context, typesys *typesystem.TypeSystem, req *RenameRelationsRequest) (*RenameRelationsResponse, error) {
+	if err := validateRelationRenames(typesys, req.Renames); err != nil {
+		return nil, serverErrors.ValidationError(err)
+	}
+
+	resp := &RenameRelationsResponse{
+		AuthorizationModelID: typesys.GetAuthorizationModelID(),
+		DryRun:               req.DryRun,
+		Renames:              make([]*RelationRenameResult, 0, len(req.Renames)),
+	}
+
+	for _, rename := range req.Renames {
+		result, err := c.rename(ctx, typesys, req.StoreID, rename, req.DryRun)
+		if err != nil {
+			return nil, serverErrors.HandleError(ctx, "", err)
+		}
+
+		resp.Renames = append(resp.Renames, result)
+	}
+
+	return resp, nil
+}
+
+func (c *RenameRelationsCommand) rename(ctx context.Context, typesys *typesystem.TypeSystem, storeID string, rename *RelationRename, dryRun bool) (*RelationRenameResult, error) {
+	result := &RelationRenameResult{RelationRename: *rename, Skipped: []*UnusedTuple{}}
+
+	var renamed []*openfgav1.TupleKey
+	filter := tuple.NewTupleKey(fmt.Sprintf("%s:", rename.ObjectType), rename.From, "")
+
+	var from string
+	for {
+		tuples, token, err := c.datastore.ReadPage(ctx, storeID, filter, storage.NewPaginationOptions(renameRelationsPageSize, from), storage.ReadOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range tuples {
+			tk := t.GetKey()
+			if err := validation.ValidateTuple(typesys, tuple.NewTupleKey(tk.GetObject(), rename.To, tk.GetUser())); err != nil {
+				reason := err
+				var invalidTupleErr *tuple.InvalidTupleError
+				if errors.As(err, &invalidTupleErr) {
+					reason = invalidTupleErr.Cause
+				}
+
+				result.Skipped = append(result.Skipped, &UnusedTuple{TupleKey: tk, Reason: reason.Error()})
+				continue
+			}
+
+			renamed = append(renamed, tk)
+		}
+
+		if len(token) == 0 {
+			break
+		}
+		from = string(token)
+	}
+
+	if dryRun {
+		result.Rewritten = len(renamed)
+		return result, nil
+	}
+
+	for start := 0; start < len(renamed); start += c.batchSize {
+		end := min(start+c.batchSize, len(renamed))
+
+		deletes := make([]*openfgav1.TupleKey, 0, end-start)
+		writes := make([]*openfgav1.TupleKey, 0, end-start)
+		for _, tk := range renamed[start:end] {
+			deletes = append(deletes, tuple.NewTupleKey(tk.GetObject(), tk.GetRelation(), tk.GetUser()))
+
+			rewrite := tuple.NewTupleKey(tk.GetObject(), rename.To, tk.GetUser())
+			_, err := c.datastore.ReadUserTuple(ctx, storeID, rewrite, storage.ReadOptions{})
+			if err == nil {
+				// the tuple was already written with the new relation
+				continue
+			}
+			if !errors.Is(err, storage.ErrNotFound) {
+				return nil, err
+			}
+
+			writes = append(writes, rewrite)
+		}
+
+		if err := c.datastore.Write(ctx, storeID, deletes, writes); err != nil {
+			return nil, err
+		}
+
+		if c.hook != nil {
+			c.hook(ctx, typesys, storeID, append(deletes, writes...))
+		}
+
+		result.Rewritten += len(deletes)
+		c.logger.Info("relation tuples rewritten",
+			zap.String("store_id", storeID),
+			zap.String("object_type", rename.ObjectType),
+			zap.String("from", rename.From),
+			zap.String("to", rename.To),
+			zap.Int("rewritten", result.Rewritten))
+	}
+
+	return result, nil
+}
+
+// validateRelationRenames checks that the model defines both relations of every rename, and that no relation
+// is renamed twice, or both renamed and the new name of another relation.
+func validateRelationRenames(typesys *typesystem.TypeSystem, renames []*RelationRename) error {
+	if len(renames) == 0 {
+		return errors.New("at least one rename is required")
+	}
+
+	renamed := map[string]struct{}{}
+	targets := map[string]struct{}{}
+	for _, rename := range renames {
+		if rename.From == rename.To {
+			return fmt.Errorf("the relation '%s' of type '%s' is renamed to itself", rename.From, rename.ObjectType)
+		}
+
+		for _, relation := range []string{rename.From, rename.To} {
+			if _, err := typesys.GetRelation(rename.ObjectType, relation); err != nil {
+				return fmt.Errorf("the model must define both relations of the rename of '%s' to '%s': %w", rename.From, rename.To, err)
+			}
+		}
+
+		from := tuple.ToObjectRelationString(rename.ObjectType, rename.From)
+		to := tuple.ToObjectRelationString(rename.ObjectType, rename.To)
+		if _, ok := renamed[from]; ok {
+			return fmt.Errorf("the relation '%s' is renamed more than once", from)
+		}
+		renamed[from] = struct{}{}
+		targets[to] = struct{}{}
+	}
+
+	for from := range renamed {
+		if _, ok := targets[from]; ok {
+			return fmt.Errorf("the relation '%s' is both renamed and the new name of another relation", from)
+		}
+	}
+
+	return nil
+}
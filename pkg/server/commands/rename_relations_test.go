@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenameRelationsCommand(t *testing.T) {
+	ctx := context.Background()
+
+	typesys := typesystem.New(&openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type group
+		  relations
+			define member: [user] as self
+
+		type document
+		  relations
+			define viewer: [user, group#member] as self
+			define reader: [user] as self or viewer
+		`),
+	})
+
+	setup := func(t *testing.T) (storage.OpenFGADatastore, string) {
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+
+		storeID := ulid.Make().String()
+		err := ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+			tuple.NewTupleKey("document:2", "viewer", "user:bob"),
+			tuple.NewTupleKey("document:2", "reader", "user:bob"),
+			tuple.NewTupleKey("document:3", "viewer", "group:eng#member"),
+			tuple.NewTupleKey("group:eng", "member", "user:carl"),
+		})
+		require.NoError(t, err)
+
+		return ds, storeID
+	}
+
+	renames := []*RelationRename{{ObjectType: "document", From: "viewer", To: "reader"}}
+
+	readAll := func(t *testing.T, ds storage.OpenFGADatastore, storeID string) []string {
+		tuples, _, err := ds.ReadPage(ctx, storeID, &openfgav1.TupleKey{}, storage.NewPaginationOptions(100, ""), storage.ReadOptions{})
+		require.NoError(t, err)
+
+		keys := make([]string, 0, len(tuples))
+		for _, t := range tuples {
+			keys = append(keys, tuple.TupleKeyToString(t.GetKey()))
+		}
+		return keys
+	}
+
+	t.Run("dry_run", func(t *testing.T) {
+		ds, storeID := setup(t)
+
+		resp, err := NewRenameRelationsCommand(ds, logger.NewNoopLogger()).Execute(ctx, typesys, &RenameRelationsRequest{
+			StoreID: storeID,
+			Renames: renames,
+			DryRun:  true,
+		})
+		require.NoError(t, err)
+		require.True(t, resp.DryRun)
+		require.Len(t, resp.Renames, 1)
+		require.Equal(t, 2, resp.Renames[0].Rewritten)
+		require.Len(t, resp.Renames[0].Skipped, 1)
+		require.Equal(t, "document:3", resp.Renames[0].Skipped[0].TupleKey.GetObject())
+
+		require.Len(t, readAll(t, ds, storeID), 5)
+	})
+
+	t.Run("rewrites_in_batches", func(t *testing.T) {
+		ds, storeID := setup(t)
+
+		resp, err := NewRenameRelationsCommand(ds, logger.NewNoopLogger(), WithRenameRelationsBatchSize(1)).Execute(ctx, typesys, &RenameRelationsRequest{
+			StoreID: storeID,
+			Renames: renames,
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, resp.Renames[0].Rewritten)
+
+		require.ElementsMatch(t, []string{
+			"document:1#reader@user:anne",
+			"document:2#reader@user:bob",
+			"document:3#viewer@group:eng#member",
+			"group:eng#member@user:carl",
+		}, readAll(t, ds, storeID))
+
+		changes, _, err := ds.ReadChanges(ctx, storeID, "document", storage.NewPaginationOptions(100, ""), 0, storage.ReadOptions{})
+		require.NoError(t, err)
+		// the 4 tuples written, the 2 deletes and the write of the tuple of anne, since the one of bob existed
+		require.Len(t, changes, 7)
+	})
+
+	t.Run("calls_the_hook", func(t *testing.T) {
+		ds, storeID := setup(t)
+
+		var tupleKeys []string
+		hook := func(ctx context.Context, _ *typesystem.TypeSystem, _ string, tks []*openfgav1.TupleKey) {
+			for _, tk := range tks {
+				tupleKeys = append(tupleKeys, tuple.TupleKeyToString(tk))
+			}
+		}
+
+		_, err := NewRenameRelationsCommand(ds, logger.NewNoopLogger(), WithRenameRelationsHook(hook)).Execute(ctx, typesys, &RenameRelationsRequest{
+			StoreID: storeID,
+			Renames: renames,
+		})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{
+			"document:1#viewer@user:anne",
+			"document:2#viewer@user:bob",
+			"document:1#reader@user:anne",
+		}, tupleKeys)
+	})
+
+	t.Run("invalid_renames", func(t *testing.T) {
+		ds, storeID := setup(t)
+		c := NewRenameRelationsCommand(ds, logger.NewNoopLogger())
+
+		tests := map[string][]*RelationRename{
+			"no_renames":        nil,
+			"undefined_to":      {{ObjectType: "document", From: "viewer", To: "owner"}},
+			"same_relation":     {{ObjectType: "document", From: "viewer", To: "viewer"}},
+			"renamed_twice":     {renames[0], renames[0]},
+			"renamed_and_a_new": {renames[0], {ObjectType: "document", From: "reader", To: "viewer"}},
+		}
+
+		for name, renames := range tests {
+			t.Run(name, func(t *testing.T) {
+				_, err := c.Execute(ctx, typesys, &RenameRelationsRequest{StoreID: storeID, Renames: renames})
+				require.Error(t, err)
+			})
+		}
+	})
+}
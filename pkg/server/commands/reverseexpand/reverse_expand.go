@@ -368,7 +368,7 @@ func (c *ReverseExpandQuery) reverseExpandTupleToUserset(
 		ObjectType: req.edge.TargetReference.GetType(),
 		Relation:   req.edge.TuplesetRelation.GetRelation(),
 		UserFilter: userFilter,
-	})
+	}, storage.ReadOptionsFromContext(ctx))
 	atomic.AddUint32(resolutionMetadata.QueryCount, 1)
 	if err != nil {
 		return err
@@ -471,7 +471,7 @@ func (c *ReverseExpandQuery) reverseExpandDirect(
 		ObjectType: req.edge.TargetReference.GetType(),
 		Relation:   req.edge.TargetReference.GetRelation(),
 		UserFilter: userFilter,
-	})
+	}, storage.ReadOptionsFromContext(ctx))
 	atomic.AddUint32(resolutionMetadata.QueryCount, 1)
 	if err != nil {
 		return err
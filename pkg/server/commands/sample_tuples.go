@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+const (
+	// DefaultSampleTuplesSize is the number of tuples sampled if the request doesn't tell.
+	DefaultSampleTuplesSize = 10
+
+	// MaxSampleTuplesSize is the maximum number of tuples sampled by a request.
+	MaxSampleTuplesSize = 1000
+
+	// DefaultSampleTuplesScanLimit is the maximum number of tuples read to sample them, by default.
+	DefaultSampleTuplesScanLimit = 10000
+
+	sampleTuplesPageSize = 100
+)
+
+// SampleTuplesRequest requests a random sample of the tuples of a store, e.g. for support engineers to see
+// what the tuples of a huge store look like. The ObjectType, and the Relation of the ObjectType, only sample
+// the tuples of the objects of the type, and of the relation.
+type SampleTuplesRequest struct {
+	StoreID    string `json:"store_id"`
+	ObjectType string `json:"object_type,omitempty"`
+	Relation   string `json:"relation,omitempty"`
+
+	// Size is the number of tuples sampled, DefaultSampleTuplesSize if it's 0.
+	Size int `json:"size"`
+}
+
+// SampleTuplesResponse holds the tuples sampled.
+type SampleTuplesResponse struct {
+	Tuples []*openfgav1.TupleKey `json:"tuples"`
+
+	// TuplesScanned is the number of tuples read by the server to sample them, 0 if the datastore sampled
+	// them itself.
+	TuplesScanned int `json:"tuples_scanned"`
+
+	// Truncated tells whether the scan stopped before the last tuple matching the request, because of the
+	// scan limit, in which case the tuples are only sampled from the ones scanned.
+	Truncated bool `json:"truncated"`
+}
+
+// SampleTuplesQuery samples the tuples of a store at random, without reading every page of them: the datastore
+// samples them across all the tuples of the store if it can (see WithTupleSampler), otherwise the tuples are
+// sampled from the first ones read, up to a scan limit, with a reservoir sampling.
+type SampleTuplesQuery struct {
+	datastore storage.OpenFGADatastore
+	sampler   storage.TupleSampler
+	logger    logger.Logger
+	scanLimit int
+	randomInt func(n int) int
+}
+
+// SampleTuplesQueryOption defines an option that can be used to change the behavior of a SampleTuplesQuery.
+type SampleTuplesQueryOption func(*SampleTuplesQuery)
+
+// WithSampleTuplesScanLimit sets the maximum number of tuples read to sample them, DefaultSampleTuplesScanLimit
+// by default.
+func WithSampleTuplesScanLimit(limit int) SampleTuplesQueryOption {
+	return func(q *SampleTuplesQuery) {
+		q.scanLimit = limit
+	}
+}
+
+// WithTupleSampler samples the tuples with the sampler of the datastore, across all the tuples of the store
+// rather than the first ones read.
+func WithTupleSampler(sampler storage.TupleSampler) SampleTuplesQueryOption {
+	return func(q *SampleTuplesQuery) {
+		q.sampler = sampler
+	}
+}
+
+func NewSampleTuplesQuery(datastore storage.OpenFGADatastore, logger logger.Logger, opts ...SampleTuplesQueryOption) *SampleTuplesQuery {
+	q := &SampleTuplesQuery{
+		datastore: datastore,
+		logger:    logger,
+		scanLimit: DefaultSampleTuplesScanLimit,
+		randomInt: rand.Intn,
+	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	if q.scanLimit <= 0 {
+		q.scanLimit = DefaultSampleTuplesScanLimit
+	}
+
+	return q
+}
+
+func (q *SampleTuplesQuery) Execute(ctx context.Context, req *SampleTuplesRequest) (*SampleTuplesResponse, error) {
+	size := req.Size
+	if size == 0 {
+		size = DefaultSampleTuplesSize
+	}
+	if size < 0 || size > MaxSampleTuplesSize {
+		return nil, serverErrors.ValidationError(fmt.Errorf("the size of the sample must be between 1 and %d", MaxSampleTuplesSize))
+	}
+	if req.Relation != "" && req.ObjectType == "" {
+		return nil, serverErrors.ValidationError(fmt.Errorf("the object type of the relation '%s' must be provided", req.Relation))
+	}
+
+	if _, err := q.datastore.GetStore(ctx, req.StoreID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, serverErrors.StoreIDNotFound
+		}
+		return nil, serverErrors.HandleError(ctx, "", err)
+	}
+
+	filter := &openfgav1.TupleKey{}
+	if req.ObjectType != "" {
+		filter = tuple.NewTupleKey(fmt.Sprintf("%s:", req.ObjectType), req.Relation, "")
+	}
+
+	if q.sampler != nil {
+		tuples, err := q.sampler.SampleTuples(ctx, req.StoreID, filter, size)
+		if err != nil {
+			return nil, serverErrors.HandleError(ctx, "", err)
+		}
+
+		return &SampleTuplesResponse{Tuples: append(make([]*openfgav1.TupleKey, 0, len(tuples)), tuples...)}, nil
+	}
+
+	resp := &SampleTuplesResponse{Tuples: make([]*openfgav1.TupleKey, 0, size)}
+
+	var from string
+	for {
+		tuples, token, err := q.datastore.ReadPage(ctx, req.StoreID, filter, storage.NewPaginationOptions(sampleTuplesPageSize, from), storage.ReadOptions{})
+		if err != nil {
+			return nil, serverErrors.HandleError(ctx, "", err)
+		}
+
+		for _, t := range tuples {
+			if resp.TuplesScanned >= q.scanLimit {
+				// there are tuples left
+				resp.Truncated = true
+				token = nil
+				break
+			}
+			resp.TuplesScanned++
+
+			// every tuple scanned ends up in the sample with the same probability
+			if len(resp.Tuples) < size {
+				resp.Tuples = append(resp.Tuples, t.GetKey())
+			} else if i := q.randomInt(resp.TuplesScanned); i < size {
+				resp.Tuples[i] = t.GetKey()
+			}
+		}
+
+		if len(token) == 0 {
+			break
+		}
+		from = string(token)
+	}
+
+	return resp, nil
+}
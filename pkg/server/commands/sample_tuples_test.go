@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSampleTuplesQuery(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	defer ds.Close()
+
+	storeID := ulid.Make().String()
+	_, err := ds.CreateStore(ctx, &openfgav1.Store{Id: storeID, Name: "store"})
+	require.NoError(t, err)
+
+	var tupleKeys []*openfgav1.TupleKey
+	for i := 0; i < 20; i++ {
+		tupleKeys = append(tupleKeys,
+			tuple.NewTupleKey(fmt.Sprintf("document:%d", i), "viewer", "user:anne"),
+			tuple.NewTupleKey(fmt.Sprintf("document:%d", i), "editor", "user:bob"),
+			tuple.NewTupleKey(fmt.Sprintf("folder:%d", i), "viewer", "user:anne"),
+		)
+	}
+	for start := 0; start < len(tupleKeys); start += ds.MaxTuplesPerWrite() {
+		require.NoError(t, ds.Write(ctx, storeID, nil, tupleKeys[start:min(start+ds.MaxTuplesPerWrite(), len(tupleKeys))]))
+	}
+
+	t.Run("default_size", func(t *testing.T) {
+		resp, err := NewSampleTuplesQuery(ds, logger.NewNoopLogger()).Execute(ctx, &SampleTuplesRequest{StoreID: storeID})
+		require.NoError(t, err)
+		require.Len(t, resp.Tuples, DefaultSampleTuplesSize)
+		require.Equal(t, 60, resp.TuplesScanned)
+		require.False(t, resp.Truncated)
+	})
+
+	t.Run("filtered", func(t *testing.T) {
+		resp, err := NewSampleTuplesQuery(ds, logger.NewNoopLogger()).Execute(ctx, &SampleTuplesRequest{
+			StoreID:    storeID,
+			ObjectType: "document",
+			Relation:   "editor",
+			Size:       5,
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Tuples, 5)
+		require.Equal(t, 20, resp.TuplesScanned)
+		for _, tk := range resp.Tuples {
+			require.Equal(t, "document", tuple.GetType(tk.GetObject()))
+			require.Equal(t, "editor", tk.GetRelation())
+		}
+	})
+
+	t.Run("reservoir", func(t *testing.T) {
+		q := NewSampleTuplesQuery(ds, logger.NewNoopLogger(), WithSampleTuplesScanLimit(30))
+		// every tuple scanned after the first ones replaces the first tuple of the sample
+		q.randomInt = func(n int) int { return 0 }
+
+		resp, err := q.Execute(ctx, &SampleTuplesRequest{StoreID: storeID, ObjectType: "folder", Size: 2})
+		require.NoError(t, err)
+		require.Equal(t, 20, resp.TuplesScanned)
+		require.False(t, resp.Truncated)
+		require.Len(t, resp.Tuples, 2)
+
+		all, err := NewSampleTuplesQuery(ds, logger.NewNoopLogger()).Execute(ctx, &SampleTuplesRequest{StoreID: storeID, ObjectType: "folder", Size: 20})
+		require.NoError(t, err)
+		require.Equal(t, []*openfgav1.TupleKey{all.Tuples[19], all.Tuples[1]}, resp.Tuples)
+	})
+
+	t.Run("scan_limit", func(t *testing.T) {
+		resp, err := NewSampleTuplesQuery(ds, logger.NewNoopLogger(), WithSampleTuplesScanLimit(15)).Execute(ctx, &SampleTuplesRequest{StoreID: storeID, Size: 20})
+		require.NoError(t, err)
+		require.Len(t, resp.Tuples, 15)
+		require.Equal(t, 15, resp.TuplesScanned)
+		require.True(t, resp.Truncated)
+	})
+
+	t.Run("sampler", func(t *testing.T) {
+		// the datastore samples the tuples across the whole store, regardless of the scan limit
+		q := NewSampleTuplesQuery(ds, logger.NewNoopLogger(), WithSampleTuplesScanLimit(1), WithTupleSampler(ds.(storage.TupleSampler)))
+
+		resp, err := q.Execute(ctx, &SampleTuplesRequest{StoreID: storeID, ObjectType: "document", Size: 40})
+		require.NoError(t, err)
+		var documents []*openfgav1.TupleKey
+		for _, tk := range tupleKeys {
+			if tuple.GetType(tk.GetObject()) == "document" {
+				documents = append(documents, tk)
+			}
+		}
+		require.ElementsMatch(t, documents, resp.Tuples)
+		require.Zero(t, resp.TuplesScanned)
+		require.False(t, resp.Truncated)
+	})
+
+	t.Run("invalid_requests", func(t *testing.T) {
+		q := NewSampleTuplesQuery(ds, logger.NewNoopLogger())
+
+		for name, req := range map[string]*SampleTuplesRequest{
+			"negative_size":           {StoreID: storeID, Size: -1},
+			"too_large":               {StoreID: storeID, Size: MaxSampleTuplesSize + 1},
+			"relation_without_a_type": {StoreID: storeID, Relation: "viewer"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := q.Execute(ctx, req)
+				require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+			})
+		}
+
+		_, err := q.Execute(ctx, &SampleTuplesRequest{StoreID: ulid.Make().String()})
+		require.ErrorIs(t, err, serverErrors.StoreIDNotFound)
+	})
+}
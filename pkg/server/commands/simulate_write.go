@@ -0,0 +1,53 @@
+package commands
+
+import (
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+)
+
+// MaxQueriesPerSimulateWrite is the maximum number of Check and ListObjects queries, combined, that can be
+// answered in a single SimulateWrite call.
+const MaxQueriesPerSimulateWrite = 50
+
+// SimulateWriteRequest requests the answers to Check and ListObjects queries as if the Writes had been
+// written to the store. If the AuthorizationModelID is empty, the latest authorization model of the store is
+// used.
+type SimulateWriteRequest struct {
+	StoreID              string
+	AuthorizationModelID string
+
+	// Writes are the tuples whose write is simulated. They're never persisted.
+	Writes []*openfgav1.TupleKey
+
+	Checks      []*openfgav1.TupleKey
+	ListObjects []*SimulatedListObjectsQuery
+
+	// Context is the context the conditions of the tuples are evaluated with (see the condition package)
+	Context map[string]interface{}
+}
+
+// SimulatedListObjectsQuery asks for the objects of a type the user has the relation with.
+type SimulatedListObjectsQuery struct {
+	Type     string
+	Relation string
+	User     string
+}
+
+type SimulatedCheckResult struct {
+	Object   string `json:"object"`
+	Relation string `json:"relation"`
+	User     string `json:"user"`
+	Allowed  bool   `json:"allowed"`
+}
+
+type SimulatedListObjectsResult struct {
+	Type     string   `json:"type"`
+	Relation string   `json:"relation"`
+	User     string   `json:"user"`
+	Objects  []string `json:"objects"`
+}
+
+// SimulateWriteResponse holds the answers to the queries of the request, in the order they were asked.
+type SimulateWriteResponse struct {
+	Checks      []*SimulatedCheckResult       `json:"checks"`
+	ListObjects []*SimulatedListObjectsResult `json:"list_objects"`
+}
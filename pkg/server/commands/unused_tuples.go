@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/internal/validation"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"go.uber.org/zap"
+)
+
+const unusedTuplesPageSize = 100
+
+// UnusedTuple is a tuple that doesn't fit the authorization model anymore, e.g. because its relation was
+// removed from its object type, or because its user type isn't allowed by the relation anymore.
+type UnusedTuple struct {
+	TupleKey *openfgav1.TupleKey `json:"tuple_key"`
+	Reason   string              `json:"reason"`
+}
+
+// UnusedTuplesResponse reports the tuples of a store that don't fit an authorization model.
+type UnusedTuplesResponse struct {
+	AuthorizationModelID string `json:"authorization_model_id"`
+	TuplesScanned        int    `json:"tuples_scanned"`
+
+	// Sampled tells whether only some of the tuples of the store were scanned, because of the sample rate or
+	// of the scan limit.
+	Sampled      bool           `json:"sampled"`
+	UnusedTuples []*UnusedTuple `json:"unused_tuples"`
+
+	// Deleted is the number of unused tuples deleted, if the cleanup is enabled.
+	Deleted int `json:"deleted"`
+}
+
+// UnusedTuplesQuery reads every tuple of a store and reports the ones that don't fit an authorization model,
+// which are left behind by the model changes that remove relations or restrict their user types. These tuples
+// can't be written anymore and are ignored by the evaluation of the relations they're for, but they still
+// take space and make the reads slower.
+type UnusedTuplesQuery struct {
+	datastore storage.OpenFGADatastore
+	logger    logger.Logger
+
+	cleanup         bool
+	deleteBatchSize int
+	sampleRate      float64
+	scanLimit       int
+	random          func() float64
+}
+
+// UnusedTuplesQueryOption defines an option that can be used to change the behavior of an UnusedTuplesQuery.
+type UnusedTuplesQueryOption func(*UnusedTuplesQuery)
+
+// WithUnusedTuplesCleanup deletes the unused tuples found, in batches of the provided size, which is capped
+// to the maximum number of tuples per write of the datastore.
+func WithUnusedTuplesCleanup(batchSize int) UnusedTuplesQueryOption {
+	return func(q *UnusedTuplesQuery) {
+		q.cleanup = true
+		q.deleteBatchSize = batchSize
+	}
+}
+
+// WithUnusedTuplesSampleRate only scans the provided fraction (between 0 and 1) of the tuples read, picked at
+// random. A rate of 1 (the default) scans every tuple.
+func WithUnusedTuplesSampleRate(rate float64) UnusedTuplesQueryOption {
+	return func(q *UnusedTuplesQuery) {
+		q.sampleRate = rate
+	}
+}
+
+// WithUnusedTuplesScanLimit stops the scan once the provided number of tuples has been scanned. A limit of 0
+// (the default) scans the whole store.
+func WithUnusedTuplesScanLimit(limit int) UnusedTuplesQueryOption {
+	return func(q *UnusedTuplesQuery) {
+		q.scanLimit = limit
+	}
+}
+
+func NewUnusedTuplesQuery(datastore storage.OpenFGADatastore, logger logger.Logger, opts ...UnusedTuplesQueryOption) *UnusedTuplesQuery {
+	q := &UnusedTuplesQuery{
+		datastore:       datastore,
+		logger:          logger,
+		deleteBatchSize: datastore.MaxTuplesPerWrite(),
+		sampleRate:      1,
+		random:          rand.Float64,
+	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	if q.deleteBatchSize <= 0 || q.deleteBatchSize > datastore.MaxTuplesPerWrite() {
+		q.deleteBatchSize = datastore.MaxTuplesPerWrite()
+	}
+
+	return q
+}
+
+// Execute reports the tuples of the store that don't fit the model of the type system, and deletes them if
+// the cleanup is enabled. The tuples are deleted once they've all been read, so that the deletes don't move
+// the pages being read.
+func (q *UnusedTuplesQuery) Execute(ctx context.Context, storeID string, typesys *typesystem.TypeSystem) (*UnusedTuplesResponse, error) {
+	resp := &UnusedTuplesResponse{
+		AuthorizationModelID: typesys.GetAuthorizationModelID(),
+		UnusedTuples:         []*UnusedTuple{},
+	}
+
+	var from string
+	for {
+		tuples, token, err := q.datastore.ReadPage(ctx, storeID, &openfgav1.TupleKey{}, storage.NewPaginationOptions(unusedTuplesPageSize, from), storage.ReadOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range tuples {
+			if q.scanLimit > 0 && resp.TuplesScanned >= q.scanLimit {
+				// there are tuples left
+				resp.Sampled = true
+				token = nil
+				break
+			}
+
+			if q.sampleRate < 1 && q.random() >= q.sampleRate {
+				resp.Sampled = true
+				continue
+			}
+			resp.TuplesScanned++
+
+			if err := validation.ValidateTuple(typesys, t.GetKey()); err != nil {
+				reason := err
+				var invalidTupleErr *tuple.InvalidTupleError
+				if errors.As(err, &invalidTupleErr) {
+					reason = invalidTupleErr.Cause
+				}
+
+				resp.UnusedTuples = append(resp.UnusedTuples, &UnusedTuple{TupleKey: t.GetKey(), Reason: reason.Error()})
+			}
+		}
+
+		if len(token) == 0 {
+			break
+		}
+		from = string(token)
+	}
+
+	if !q.cleanup {
+		return resp, nil
+	}
+
+	for start := 0; start < len(resp.UnusedTuples); start += q.deleteBatchSize {
+		end := min(start+q.deleteBatchSize, len(resp.UnusedTuples))
+
+		deletes := make([]*openfgav1.TupleKey, 0, end-start)
+		for _, unused := range resp.UnusedTuples[start:end] {
+			deletes = append(deletes, tuple.NewTupleKey(unused.TupleKey.GetObject(), unused.TupleKey.GetRelation(), unused.TupleKey.GetUser()))
+		}
+
+		if err := q.datastore.Write(ctx, storeID, deletes, nil); err != nil {
+			return resp, err
+		}
+
+		resp.Deleted += len(deletes)
+		q.logger.Info("unused tuples deleted", zap.String("store_id", storeID), zap.Int("deleted", resp.Deleted))
+	}
+
+	return resp, nil
+}
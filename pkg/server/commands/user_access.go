@@ -0,0 +1,185 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/internal/graph"
+	"github.com/openfga/openfga/internal/validation"
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// MaxDirectObjectsPerUserAccess is the maximum number of objects listed as directly assigned to the user
+// for each relation in a ListUserAccess call.
+const MaxDirectObjectsPerUserAccess = 1000
+
+// ListUserAccessRequest requests the relations a user, e.g. 'user:anne' or 'group:eng#member', can possibly
+// have. If the AuthorizationModelID is empty, the latest authorization model of the store is used.
+type ListUserAccessRequest struct {
+	StoreID              string
+	AuthorizationModelID string
+	User                 string
+}
+
+// UserAccess is a relation of an object type the user can possibly have with objects of the type.
+type UserAccess struct {
+	ObjectType string `json:"object_type"`
+	Relation   string `json:"relation"`
+
+	// DirectObjects are the objects the user is directly assigned the relation with, i.e. through a tuple
+	// whose user is the user. The user may have the relation with other objects through the rewrite of the
+	// relation, which ListObjects lists.
+	DirectObjects []string `json:"direct_objects"`
+
+	// Truncated is set if the user is directly assigned the relation with more than
+	// MaxDirectObjectsPerUserAccess objects, only the first of which are listed.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// ListUserAccessResponse holds the access of the user, sorted by object type and relation.
+type ListUserAccessResponse struct {
+	Access []*UserAccess `json:"access"`
+}
+
+// ListUserAccessQuery reports where a user can be granted access, for pages that show a user their access
+// or for the review of the access of a user that is being offboarded.
+type ListUserAccessQuery struct {
+	datastore storage.RelationshipTupleReader
+	logger    logger.Logger
+}
+
+func NewListUserAccessQuery(datastore storage.RelationshipTupleReader, logger logger.Logger) *ListUserAccessQuery {
+	return &ListUserAccessQuery{
+		datastore: datastore,
+		logger:    logger,
+	}
+}
+
+func (q *ListUserAccessQuery) Execute(ctx context.Context, typesys *typesystem.TypeSystem, req *ListUserAccessRequest) (*ListUserAccessResponse, error) {
+	if typesys.GetSchemaVersion() != typesystem.SchemaVersion1_1 {
+		return nil, serverErrors.ValidationError(typesystem.ErrInvalidSchemaVersion)
+	}
+
+	if err := validation.ValidateUser(typesys, req.User); err != nil {
+		return nil, serverErrors.ValidationError(fmt.Errorf("invalid 'user' value: %s", err))
+	}
+
+	userObject, userRelation := tuple.SplitObjectRelation(req.User)
+	userType := tuple.GetType(userObject)
+
+	var source *openfgav1.RelationReference
+	switch {
+	case tuple.IsTypedWildcard(userObject):
+		source = typesystem.WildcardRelationReference(userType)
+	default:
+		source = typesystem.DirectRelationReference(userType, userRelation)
+	}
+
+	g := graph.New(typesys)
+
+	resp := &ListUserAccessResponse{
+		Access: []*UserAccess{},
+	}
+
+	for _, typeDefinition := range typesys.GetAllTypeDefinitions() {
+		objectType := typeDefinition.GetType()
+
+		relations := make([]string, 0, len(typeDefinition.GetRelations()))
+		for relation := range typeDefinition.GetRelations() {
+			relations = append(relations, relation)
+		}
+		sort.Strings(relations)
+
+		for _, relation := range relations {
+			target := typesystem.DirectRelationReference(objectType, relation)
+
+			edges, err := g.GetRelationshipEdges(target, source)
+			if err != nil {
+				return nil, serverErrors.HandleError(ctx, "", err)
+			}
+
+			if len(edges) == 0 {
+				continue
+			}
+
+			access := &UserAccess{
+				ObjectType:    objectType,
+				Relation:      relation,
+				DirectObjects: []string{},
+			}
+
+			directlyRelated, err := typesys.IsDirectlyRelated(target, source)
+			if err != nil {
+				return nil, serverErrors.HandleError(ctx, "", err)
+			}
+
+			if directlyRelated {
+				access.DirectObjects, access.Truncated, err = q.readDirectObjects(ctx, typesys, req.StoreID, objectType, relation, userObject, userRelation)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			resp.Access = append(resp.Access, access)
+		}
+	}
+
+	return resp, nil
+}
+
+// readDirectObjects returns the objects of the object type the user is directly assigned the relation with,
+// and whether there are more than MaxDirectObjectsPerUserAccess of them.
+func (q *ListUserAccessQuery) readDirectObjects(
+	ctx context.Context,
+	typesys *typesystem.TypeSystem,
+	storeID, objectType, relation, userObject, userRelation string,
+) ([]string, bool, error) {
+	iter, err := q.datastore.ReadStartingWithUser(ctx, storeID, storage.ReadStartingWithUserFilter{
+		ObjectType: objectType,
+		Relation:   relation,
+		UserFilter: []*openfgav1.ObjectRelation{{
+			Object:   userObject,
+			Relation: userRelation,
+		}},
+	}, storage.ReadOptions{})
+	if err != nil {
+		return nil, false, serverErrors.HandleError(ctx, "", err)
+	}
+
+	// filter out invalid tuples yielded by the database iterator
+	filteredIter := storage.NewFilteredTupleKeyIterator(
+		storage.NewTupleKeyIteratorFromTupleIterator(iter),
+		validation.FilterInvalidTuples(typesys),
+	)
+	defer filteredIter.Stop()
+
+	objects := []string{}
+	for {
+		tk, err := filteredIter.Next()
+		if err != nil {
+			if errors.Is(err, storage.ErrIteratorDone) {
+				break
+			}
+
+			return nil, false, serverErrors.HandleError(ctx, "", err)
+		}
+
+		if len(objects) == MaxDirectObjectsPerUserAccess {
+			sort.Strings(objects)
+			return objects, true, nil
+		}
+
+		objects = append(objects, tk.GetObject())
+	}
+
+	sort.Strings(objects)
+
+	return objects, false, nil
+}
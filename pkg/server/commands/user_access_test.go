@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListUserAccessQuery(t *testing.T) {
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+
+	ds := memory.New()
+	defer ds.Close()
+
+	typesys := typesystem.New(&openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type group
+		  relations
+			define member: [user] as self
+
+		type folder
+		  relations
+			define owner: [group] as self
+			define viewer: [user, group#member] as self
+
+		type document
+		  relations
+			define parent: [folder] as self
+			define viewer: [user:*] as self or viewer from parent
+		`),
+	})
+
+	err := ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("group:eng", "member", "user:anne"),
+		tuple.NewTupleKey("folder:x", "viewer", "user:anne"),
+		tuple.NewTupleKey("folder:y", "viewer", "group:eng#member"),
+		tuple.NewTupleKey("folder:z", "viewer", "user:bob"),
+	})
+	require.NoError(t, err)
+
+	q := NewListUserAccessQuery(ds, logger.NewNoopLogger())
+
+	tests := []struct {
+		name     string
+		user     string
+		expected []*UserAccess
+	}{
+		{
+			name: "user",
+			user: "user:anne",
+			expected: []*UserAccess{
+				{ObjectType: "document", Relation: "viewer", DirectObjects: []string{}},
+				{ObjectType: "folder", Relation: "viewer", DirectObjects: []string{"folder:x"}},
+				{ObjectType: "group", Relation: "member", DirectObjects: []string{"group:eng"}},
+			},
+		},
+		{
+			name: "userset",
+			user: "group:eng#member",
+			expected: []*UserAccess{
+				{ObjectType: "document", Relation: "viewer", DirectObjects: []string{}},
+				{ObjectType: "folder", Relation: "viewer", DirectObjects: []string{"folder:y"}},
+			},
+		},
+		{
+			name: "object",
+			user: "group:eng",
+			expected: []*UserAccess{
+				{ObjectType: "folder", Relation: "owner", DirectObjects: []string{}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := q.Execute(ctx, typesys, &ListUserAccessRequest{
+				StoreID: storeID,
+				User:    test.user,
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.expected, resp.Access)
+		})
+	}
+
+	t.Run("invalid_user", func(t *testing.T) {
+		_, err := q.Execute(ctx, typesys, &ListUserAccessRequest{
+			StoreID: storeID,
+			User:    "repo:1",
+		})
+		require.Error(t, err)
+	})
+}
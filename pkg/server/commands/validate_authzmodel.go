@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"context"
+	"errors"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"google.golang.org/grpc/status"
+)
+
+// ValidateAuthorizationModelResponse is the result of the validation of an authorization model that isn't
+// written.
+type ValidateAuthorizationModelResponse struct {
+	// Valid is whether the model would be written. A valid model may have warnings.
+	Valid bool `json:"valid"`
+
+	Errors   []*ModelValidationError `json:"errors"`
+	Warnings []*typesystem.Warning   `json:"warnings"`
+}
+
+// ModelValidationError is the reason why an authorization model would be rejected.
+type ModelValidationError struct {
+	// Code is the name of the error code the model would be rejected with, e.g. 'invalid_authorization_model'.
+	Code string `json:"code"`
+
+	// ObjectType and Relation are the type and the relation of the model the error is about, if any.
+	ObjectType string `json:"object_type,omitempty"`
+	Relation   string `json:"relation,omitempty"`
+
+	Message string `json:"message"`
+}
+
+// ValidateAuthorizationModelQuery validates an authorization model exactly as WriteAuthorizationModelCommand
+// would, without writing it, and reports its errors and the warnings of typesystem.TypeSystem.Lint, e.g. to
+// lint the models in CI pipelines before they are deployed.
+type ValidateAuthorizationModelQuery struct {
+	command *WriteAuthorizationModelCommand
+}
+
+// NewValidateAuthorizationModelQuery returns a query that validates the models with the options of
+// WriteAuthorizationModelCommand. The options that only apply to the writes, e.g. WithLabels, are ignored.
+func NewValidateAuthorizationModelQuery(
+	backend storage.TypeDefinitionWriteBackend,
+	logger logger.Logger,
+	maxAuthorizationModelSizeInBytes int,
+	opts ...WriteAuthModelOption,
+) *ValidateAuthorizationModelQuery {
+	return &ValidateAuthorizationModelQuery{
+		command: NewWriteAuthorizationModelCommand(backend, logger, maxAuthorizationModelSizeInBytes, opts...),
+	}
+}
+
+// Execute validates the model of the request. A model that would be rejected isn't an error of Execute, but
+// is reported in the response.
+func (q *ValidateAuthorizationModelQuery) Execute(ctx context.Context, req *openfgav1.WriteAuthorizationModelRequest) (*ValidateAuthorizationModelResponse, error) {
+	resp := &ValidateAuthorizationModelResponse{
+		Errors:   []*ModelValidationError{},
+		Warnings: []*typesystem.Warning{},
+	}
+
+	_, typesys, err := q.command.validate(ctx, req)
+	if err != nil {
+		resp.Errors = append(resp.Errors, newModelValidationError(err))
+		return resp, nil
+	}
+
+	resp.Valid = true
+	if warnings := typesys.Lint(); len(warnings) > 0 {
+		resp.Warnings = warnings
+	}
+
+	return resp, nil
+}
+
+// newModelValidationError returns the error of validate as a ModelValidationError, with the type and the
+// relation of the model it's about.
+func newModelValidationError(err error) *ModelValidationError {
+	var invalidModel *invalidModelError
+	if !errors.As(err, &invalidModel) {
+		st := status.Convert(err)
+		return &ModelValidationError{
+			Code:    openfgav1.ErrorCode(st.Code()).String(),
+			Message: st.Message(),
+		}
+	}
+
+	validationErr := &ModelValidationError{
+		Code:    openfgav1.ErrorCode_invalid_authorization_model.String(),
+		Message: invalidModel.cause.Error(),
+	}
+
+	var (
+		invalidRelation   *typesystem.InvalidRelationError
+		invalidType       *typesystem.InvalidTypeError
+		undefinedRelation *typesystem.RelationUndefinedError
+		undefinedType     *typesystem.ObjectTypeUndefinedError
+	)
+	switch {
+	case errors.As(err, &invalidRelation):
+		validationErr.ObjectType = invalidRelation.ObjectType
+		validationErr.Relation = invalidRelation.Relation
+	case errors.As(err, &invalidType):
+		validationErr.ObjectType = invalidType.ObjectType
+	case errors.As(err, &undefinedRelation):
+		validationErr.ObjectType = undefinedRelation.ObjectType
+		validationErr.Relation = undefinedRelation.Relation
+	case errors.As(err, &undefinedType):
+		validationErr.ObjectType = undefinedType.ObjectType
+	}
+
+	return validationErr
+}
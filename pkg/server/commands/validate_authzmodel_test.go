@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/modelvalidation"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAuthorizationModelQuery(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	defer ds.Close()
+
+	storeID := ulid.Make().String()
+
+	t.Run("valid_model_with_warnings", func(t *testing.T) {
+		q := NewValidateAuthorizationModelQuery(ds, logger.NewNoopLogger(), 256*1_024)
+
+		resp, err := q.Execute(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:       storeID,
+			SchemaVersion: typesystem.SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(`
+			type user
+			type document
+			  relations
+			    define viewer: [user, user:*] as self
+			`),
+		})
+		require.NoError(t, err)
+		require.True(t, resp.Valid)
+		require.Empty(t, resp.Errors)
+		require.Len(t, resp.Warnings, 1)
+		require.Equal(t, typesystem.WarningPublicWildcard, resp.Warnings[0].Code)
+
+		// the model isn't written
+		_, err = ds.FindLatestAuthorizationModelID(ctx, storeID)
+		require.Error(t, err)
+	})
+
+	t.Run("invalid_relation", func(t *testing.T) {
+		q := NewValidateAuthorizationModelQuery(ds, logger.NewNoopLogger(), 256*1_024)
+
+		resp, err := q.Execute(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:       storeID,
+			SchemaVersion: typesystem.SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(`
+			type user
+			type document
+			  relations
+			    define viewer: [user] as self or editor
+			`),
+		})
+		require.NoError(t, err)
+		require.False(t, resp.Valid)
+		require.Empty(t, resp.Warnings)
+		require.Len(t, resp.Errors, 1)
+		require.Equal(t, "invalid_authorization_model", resp.Errors[0].Code)
+
+		// the error is about the undefined relation
+		require.Equal(t, "document", resp.Errors[0].ObjectType)
+		require.Equal(t, "editor", resp.Errors[0].Relation)
+	})
+
+	t.Run("type_restrictions", func(t *testing.T) {
+		q := NewValidateAuthorizationModelQuery(ds, logger.NewNoopLogger(), 256*1_024, WithWildcardsDisallowed())
+
+		resp, err := q.Execute(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:       storeID,
+			SchemaVersion: typesystem.SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(`
+			type user
+			type document
+			  relations
+			    define viewer: [user, user:*] as self
+			`),
+		})
+		require.NoError(t, err)
+		require.False(t, resp.Valid)
+		require.Len(t, resp.Errors, 1)
+		require.Contains(t, resp.Errors[0].Message, typesystem.ErrWildcardDisallowed.Error())
+		require.Equal(t, "viewer", resp.Errors[0].Relation)
+	})
+
+	t.Run("validator", func(t *testing.T) {
+		validator := modelvalidation.ValidatorFunc(func(context.Context, string, *openfgav1.AuthorizationModel) error {
+			return errors.New("every model must be reviewed")
+		})
+		q := NewValidateAuthorizationModelQuery(ds, logger.NewNoopLogger(), 256*1_024, WithAuthorizationModelValidator(validator))
+
+		resp, err := q.Execute(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         storeID,
+			SchemaVersion:   typesystem.SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(`type user`),
+		})
+		require.NoError(t, err)
+		require.False(t, resp.Valid)
+		require.Equal(t, []*ModelValidationError{{
+			Code:    "invalid_authorization_model",
+			Message: "every model must be reviewed",
+		}}, resp.Errors)
+	})
+
+	t.Run("size_limit", func(t *testing.T) {
+		q := NewValidateAuthorizationModelQuery(ds, logger.NewNoopLogger(), 10)
+
+		resp, err := q.Execute(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         storeID,
+			SchemaVersion:   typesystem.SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(`type user`),
+		})
+		require.NoError(t, err)
+		require.False(t, resp.Valid)
+		require.Len(t, resp.Errors, 1)
+		require.Equal(t, "exceeded_entity_limit", resp.Errors[0].Code)
+	})
+}
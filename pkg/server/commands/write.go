@@ -4,28 +4,117 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"github.com/openfga/openfga/internal/validation"
+	"github.com/openfga/openfga/pkg/condition"
 	"github.com/openfga/openfga/pkg/logger"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/storage"
 	tupleUtils "github.com/openfga/openfga/pkg/tuple"
 	"github.com/openfga/openfga/pkg/typesystem"
+	"go.uber.org/zap"
 )
 
 // WriteCommand is used to Write and Delete tuples. Instances may be safely shared by multiple goroutines.
 type WriteCommand struct {
 	logger    logger.Logger
 	datastore storage.OpenFGADatastore
+
+	rejectSelfReferentialTuples bool
+
+	// typesys is the resolved TypeSystem of the model of the requests, if any
+	typesys *typesystem.TypeSystem
+
+	hook TuplesWrittenHook
+
+	conditionsBackend storage.ConditionsBackend
+	// [tupleKey] => condition of the tuple written
+	tupleConditions map[string]*condition.TupleCondition
+
+	expirationBackend storage.TupleExpirationBackend
+	// [tupleKey] => time the tuple written expires at
+	tupleExpirations map[string]time.Time
+
+	preconditionsBackend storage.PreconditionsBackend
+	preconditions        []storage.Precondition
+}
+
+// TuplesWrittenHook is called with the tuples that a command wrote to (or deleted from) a store, once they're
+// written, e.g. to invalidate the cached Check results that they could have changed. The typesys is the one
+// of the model the tuples were written with.
+type TuplesWrittenHook func(ctx context.Context, typesys *typesystem.TypeSystem, storeID string, tupleKeys []*openfgav1.TupleKey)
+
+type WriteCommandOption func(*WriteCommand)
+
+// WithSelfReferentialTuplesRejected rejects the writes of the tuples that relate a userset of an object to
+// itself, e.g. 'group:eng#member' as a member of 'group:eng'.
+func WithSelfReferentialTuplesRejected(rejected bool) WriteCommandOption {
+	return func(c *WriteCommand) {
+		c.rejectSelfReferentialTuples = rejected
+	}
+}
+
+// WithWriteTypesystem validates the writes against the TypeSystem of the model of the requests, which the
+// caller resolved, rather than reading the model again.
+func WithWriteTypesystem(typesys *typesystem.TypeSystem) WriteCommandOption {
+	return func(c *WriteCommand) {
+		c.typesys = typesys
+	}
+}
+
+// WithWriteHook calls the hook with the tuples written and deleted by every successful write.
+func WithWriteHook(hook TuplesWrittenHook) WriteCommandOption {
+	return func(c *WriteCommand) {
+		c.hook = hook
+	}
+}
+
+// WithTupleConditions writes the tuples with the conditions, keyed by their tuple.TupleKeyToString, to the
+// conditions backend. Every condition must apply to a tuple written, and be allowed by a type restriction of
+// the model of the request.
+func WithTupleConditions(backend storage.ConditionsBackend, conditions map[string]*condition.TupleCondition) WriteCommandOption {
+	return func(c *WriteCommand) {
+		c.conditionsBackend = backend
+		c.tupleConditions = conditions
+	}
+}
+
+// WithTupleExpirations writes the tuples that expire at the times, keyed by their tuple.TupleKeyToString, to the
+// expiration backend. Every expiration must apply to a tuple written, and be in the future. The tuples can't
+// both carry conditions and expire.
+func WithTupleExpirations(backend storage.TupleExpirationBackend, expirations map[string]time.Time) WriteCommandOption {
+	return func(c *WriteCommand) {
+		c.expirationBackend = backend
+		c.tupleExpirations = expirations
+	}
+}
+
+// WithWritePreconditions writes the tuples to the preconditions backend only if the preconditions hold as the
+// write applies, e.g. only if a tuple still exists, or doesn't exist yet, so that concurrent writers can
+// implement optimistic concurrency. The write fails with a precondition error otherwise. The tuples written
+// under preconditions can't carry conditions or expire.
+func WithWritePreconditions(backend storage.PreconditionsBackend, preconditions []storage.Precondition) WriteCommandOption {
+	return func(c *WriteCommand) {
+		c.preconditionsBackend = backend
+		c.preconditions = preconditions
+	}
 }
 
 // NewWriteCommand creates a WriteCommand with specified storage.TupleBackend to use for storage.
-func NewWriteCommand(datastore storage.OpenFGADatastore, logger logger.Logger) *WriteCommand {
-	return &WriteCommand{
+func NewWriteCommand(datastore storage.OpenFGADatastore, logger logger.Logger, opts ...WriteCommandOption) *WriteCommand {
+	c := &WriteCommand{
 		logger:    logger,
 		datastore: datastore,
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
 // Execute deletes and writes the specified tuples. Deletes are applied first, then writes.
@@ -34,14 +123,140 @@ func (c *WriteCommand) Execute(ctx context.Context, req *openfgav1.WriteRequest)
 		return nil, err
 	}
 
-	err := c.datastore.Write(ctx, req.GetStoreId(), req.GetDeletes().GetTupleKeys(), req.GetWrites().GetTupleKeys())
+	var err error
+	switch {
+	case len(c.tupleConditions) > 0 && len(c.tupleExpirations) > 0:
+		return nil, serverErrors.ValidationError(errors.New("the tuples written can't both carry conditions and expire"))
+	case len(c.preconditions) > 0 && (len(c.tupleConditions) > 0 || len(c.tupleExpirations) > 0):
+		return nil, serverErrors.ValidationError(errors.New("the tuples written under preconditions can't carry conditions or expire"))
+	case len(c.preconditions) > 0:
+		if err := c.validatePreconditions(); err != nil {
+			return nil, err
+		}
+
+		err = c.preconditionsBackend.WriteWithPreconditions(ctx, req.GetStoreId(), req.GetDeletes().GetTupleKeys(), req.GetWrites().GetTupleKeys(), c.preconditions)
+	case len(c.tupleConditions) > 0:
+		if err := c.validateTupleConditions(ctx, req); err != nil {
+			return nil, err
+		}
+
+		err = c.conditionsBackend.WriteWithConditions(ctx, req.GetStoreId(), req.GetDeletes().GetTupleKeys(), req.GetWrites().GetTupleKeys(), c.tupleConditions)
+	case len(c.tupleExpirations) > 0:
+		if err := c.validateTupleExpirations(req); err != nil {
+			return nil, err
+		}
+
+		err = c.expirationBackend.WriteWithExpirations(ctx, req.GetStoreId(), req.GetDeletes().GetTupleKeys(), req.GetWrites().GetTupleKeys(), c.tupleExpirations)
+	default:
+		err = c.datastore.Write(ctx, req.GetStoreId(), req.GetDeletes().GetTupleKeys(), req.GetWrites().GetTupleKeys())
+	}
 	if err != nil {
-		return nil, handleError(err)
+		return nil, handleError(ctx, err)
+	}
+
+	if c.hook != nil {
+		// the model was resolved by the validation unless only deletes were requested
+		typesys, err := c.resolveTypesystem(ctx, req.GetStoreId(), req.GetAuthorizationModelId())
+		if err != nil {
+			c.logger.WarnWithContext(ctx, "failed to resolve the model of the tuples written", zap.Error(err))
+		} else {
+			c.hook(ctx, typesys, req.GetStoreId(), append(
+				slices.Clone(req.GetWrites().GetTupleKeys()),
+				req.GetDeletes().GetTupleKeys()...,
+			))
+		}
 	}
 
 	return &openfgav1.WriteResponse{}, nil
 }
 
+// validateTupleConditions ensures that every condition of the command applies to a tuple written, and that the
+// model of the request allows the tuple to carry it.
+func (c *WriteCommand) validateTupleConditions(ctx context.Context, req *openfgav1.WriteRequest) error {
+	typesys, err := c.resolveTypesystem(ctx, req.GetStoreId(), req.GetAuthorizationModelId())
+	if err != nil {
+		return err
+	}
+
+	writes := make(map[string]*openfgav1.TupleKey, len(req.GetWrites().GetTupleKeys()))
+	for _, tk := range req.GetWrites().GetTupleKeys() {
+		writes[tupleUtils.TupleKeyToString(tk)] = tk
+	}
+
+	for key, tupleCondition := range c.tupleConditions {
+		tk, ok := writes[key]
+		if !ok {
+			return serverErrors.ValidationError(fmt.Errorf("the condition of the tuple '%s' doesn't apply to a tuple written", key))
+		}
+
+		if !typesys.IsConditionAllowed(tk, tupleCondition.Name) {
+			return serverErrors.ValidationError(fmt.Errorf("the tuple '%s' can't carry the condition '%s'", key, tupleCondition.Name))
+		}
+	}
+
+	return nil
+}
+
+// validateTupleExpirations ensures that every expiration of the command applies to a tuple written, and that
+// it's in the future.
+func (c *WriteCommand) validateTupleExpirations(req *openfgav1.WriteRequest) error {
+	writes := make(map[string]struct{}, len(req.GetWrites().GetTupleKeys()))
+	for _, tk := range req.GetWrites().GetTupleKeys() {
+		writes[tupleUtils.TupleKeyToString(tk)] = struct{}{}
+	}
+
+	now := time.Now()
+	for key, expiresAt := range c.tupleExpirations {
+		if _, ok := writes[key]; !ok {
+			return serverErrors.ValidationError(fmt.Errorf("the expiration of the tuple '%s' doesn't apply to a tuple written", key))
+		}
+
+		if !expiresAt.After(now) {
+			return serverErrors.ValidationError(fmt.Errorf("the tuple '%s' can't expire in the past", key))
+		}
+	}
+
+	return nil
+}
+
+// validatePreconditions ensures that the tuples of the preconditions of the command are well formed, and that
+// they're not too many.
+func (c *WriteCommand) validatePreconditions() error {
+	if len(c.preconditions) > c.datastore.MaxTuplesPerWrite() {
+		return serverErrors.ExceededEntityLimit("write preconditions", c.datastore.MaxTuplesPerWrite())
+	}
+
+	for _, precondition := range c.preconditions {
+		tk := precondition.TupleKey
+		if !tupleUtils.IsValidObject(tk.GetObject()) || !tupleUtils.IsValidRelation(tk.GetRelation()) || !tupleUtils.IsValidUser(tk.GetUser()) {
+			return serverErrors.ValidationError(fmt.Errorf("the tuple '%s' of a precondition is malformed", tupleUtils.TupleKeyToString(tk)))
+		}
+	}
+
+	return nil
+}
+
+// resolveTypesystem returns the TypeSystem of the model, reading the model unless the caller resolved it.
+func (c *WriteCommand) resolveTypesystem(ctx context.Context, store, modelID string) (*typesystem.TypeSystem, error) {
+	if c.typesys != nil && c.typesys.GetAuthorizationModelID() == modelID {
+		return c.typesys, nil
+	}
+
+	authModel, err := c.datastore.ReadAuthorizationModel(ctx, store, modelID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, serverErrors.AuthorizationModelNotFound(modelID)
+		}
+		return nil, err
+	}
+
+	if !typesystem.IsSchemaVersionSupported(authModel.GetSchemaVersion()) {
+		return nil, serverErrors.ValidationError(typesystem.ErrInvalidSchemaVersion)
+	}
+
+	return typesystem.New(authModel), nil
+}
+
 func (c *WriteCommand) validateWriteRequest(ctx context.Context, req *openfgav1.WriteRequest) error {
 	ctx, span := tracer.Start(ctx, "validateWriteRequest")
 	defer span.End()
@@ -55,79 +270,89 @@ func (c *WriteCommand) validateWriteRequest(ctx context.Context, req *openfgav1.
 		return serverErrors.InvalidWriteInput
 	}
 
+	// the duplicate and conflicting operations are rejected before reading anything from the datastore
+	if err := c.validateNoDuplicatesAndCorrectSize(deletes, writes); err != nil {
+		return err
+	}
+
+	// every invalid tuple is reported, so that the callers can fix all of them at once
+	var violations []serverErrors.FieldViolation
+
 	if len(writes) > 0 {
-		authModel, err := c.datastore.ReadAuthorizationModel(ctx, store, modelID)
+		typesys, err := c.resolveTypesystem(ctx, store, modelID)
 		if err != nil {
-			if errors.Is(err, storage.ErrNotFound) {
-				return serverErrors.AuthorizationModelNotFound(modelID)
-			}
 			return err
 		}
 
-		if !typesystem.IsSchemaVersionSupported(authModel.GetSchemaVersion()) {
-			return serverErrors.ValidationError(typesystem.ErrInvalidSchemaVersion)
-		}
-
-		typesys := typesystem.New(authModel)
-
-		for _, tk := range writes {
+		for i, tk := range writes {
 			err := validation.ValidateTuple(typesys, tk)
+			if err == nil && c.rejectSelfReferentialTuples {
+				err = validation.ValidateNotSelfReferential(typesys, tk)
+			}
 			if err != nil {
-				return serverErrors.ValidationError(err)
+				violations = append(violations, serverErrors.FieldViolation{Field: "writes.tuple_keys", Index: i, Err: err})
 			}
 		}
 	}
 
-	for _, tk := range deletes {
+	for i, tk := range deletes {
 		if ok := tupleUtils.IsValidUser(tk.GetUser()); !ok {
-			return serverErrors.ValidationError(
-				&tupleUtils.InvalidTupleError{
+			violations = append(violations, serverErrors.FieldViolation{
+				Field: "deletes.tuple_keys",
+				Index: i,
+				Err: &tupleUtils.InvalidTupleError{
 					Cause:    fmt.Errorf("the 'user' field is malformed"),
 					TupleKey: tk,
 				},
-			)
+			})
 		}
 	}
 
-	if err := c.validateNoDuplicatesAndCorrectSize(deletes, writes); err != nil {
-		return err
+	if len(violations) > 0 {
+		return serverErrors.ValidationErrors(violations)
 	}
 
 	return nil
 }
 
-// validateNoDuplicatesAndCorrectSize ensures the deletes and writes contain no duplicates and length fits.
+// validateNoDuplicatesAndCorrectSize ensures the deletes and writes contain no duplicates, that no tuple is
+// both written and deleted, and that the length fits.
 func (c *WriteCommand) validateNoDuplicatesAndCorrectSize(deletes []*openfgav1.TupleKey, writes []*openfgav1.TupleKey) error {
-	tuples := map[string]struct{}{}
-
+	deleted := make(map[string]struct{}, len(deletes))
 	for _, tk := range deletes {
 		key := tupleUtils.TupleKeyToString(tk)
-		if _, ok := tuples[key]; ok {
-			return serverErrors.DuplicateTupleInWrite(tk)
+		if _, ok := deleted[key]; ok {
+			return serverErrors.DuplicateTupleInDelete(tk)
 		}
-		tuples[key] = struct{}{}
+		deleted[key] = struct{}{}
 	}
 
+	written := make(map[string]struct{}, len(writes))
 	for _, tk := range writes {
 		key := tupleUtils.TupleKeyToString(tk)
-		if _, ok := tuples[key]; ok {
+		if _, ok := written[key]; ok {
 			return serverErrors.DuplicateTupleInWrite(tk)
 		}
-		tuples[key] = struct{}{}
+		if _, ok := deleted[key]; ok {
+			return serverErrors.ConflictingTupleInWriteAndDelete(tk)
+		}
+		written[key] = struct{}{}
 	}
 
-	if len(tuples) > c.datastore.MaxTuplesPerWrite() {
+	if len(deleted)+len(written) > c.datastore.MaxTuplesPerWrite() {
 		return serverErrors.ExceededEntityLimit("write operations", c.datastore.MaxTuplesPerWrite())
 	}
 	return nil
 }
 
-func handleError(err error) error {
+func handleError(ctx context.Context, err error) error {
 	if errors.Is(err, storage.ErrTransactionalWriteFailed) {
 		return serverErrors.NewInternalError("concurrent write conflict", err)
 	} else if errors.Is(err, storage.ErrInvalidWriteInput) {
 		return serverErrors.WriteFailedDueToInvalidInput(err)
+	} else if errors.Is(err, storage.ErrPreconditionFailed) {
+		return serverErrors.PreconditionFailed(err)
 	}
 
-	return serverErrors.HandleError("", err)
+	return serverErrors.HandleError(ctx, "", err)
 }
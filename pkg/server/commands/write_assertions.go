@@ -38,7 +38,7 @@ func (w *WriteAssertionsCommand) Execute(ctx context.Context, req *openfgav1.Wri
 			return nil, serverErrors.AuthorizationModelNotFound(req.GetAuthorizationModelId())
 		}
 
-		return nil, serverErrors.HandleError("", err)
+		return nil, serverErrors.HandleError(ctx, "", err)
 	}
 
 	if !typesystem.IsSchemaVersionSupported(model.GetSchemaVersion()) {
@@ -55,7 +55,7 @@ func (w *WriteAssertionsCommand) Execute(ctx context.Context, req *openfgav1.Wri
 
 	err = w.datastore.WriteAssertions(ctx, store, modelID, assertions)
 	if err != nil {
-		return nil, serverErrors.HandleError("", err)
+		return nil, serverErrors.HandleError(ctx, "", err)
 	}
 
 	return &openfgav1.WriteAssertionsResponse{}, nil
@@ -2,14 +2,20 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
 
 	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/internal/validation"
+	"github.com/openfga/openfga/pkg/condition"
 	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/modelvalidation"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/typesystem"
+	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
@@ -20,25 +26,199 @@ type WriteAuthorizationModelCommand struct {
 	backend                          storage.TypeDefinitionWriteBackend
 	logger                           logger.Logger
 	maxAuthorizationModelSizeInBytes int
+	assertionsBackend                AssertionsCopyForwardBackend
+	validator                        modelvalidation.Validator
+	typesystemOpts                   []typesystem.TypeSystemOption
+	latestModelBackend               storage.AuthorizationModelReadBackend
+	labelsBackend                    storage.AuthorizationModelLabelsBackend
+	labels                           map[string]string
+	conditionsBackend                storage.ConditionsBackend
+	conditions                       *condition.ModelConditions
+	skipped                          bool
+}
+
+// AssertionsCopyForwardBackend is the backend used to copy the assertions of the previous authorization
+// model forward to a newly written one.
+type AssertionsCopyForwardBackend interface {
+	storage.AuthorizationModelReadBackend
+	storage.AssertionsBackend
+}
+
+type WriteAuthModelOption func(*WriteAuthorizationModelCommand)
+
+// WithAssertionsCopyForward enables copying the assertions of the latest authorization model of the store
+// to every newly written model. Assertions that are not valid against the new model are dropped.
+func WithAssertionsCopyForward(backend AssertionsCopyForwardBackend) WriteAuthModelOption {
+	return func(w *WriteAuthorizationModelCommand) {
+		w.assertionsBackend = backend
+	}
+}
+
+// WithIdenticalModelsSkipped returns the ID of the latest authorization model of the store, read from the
+// backend, instead of writing a new model when the model is identical to it (see typesystem.ModelHash).
+func WithIdenticalModelsSkipped(backend storage.AuthorizationModelReadBackend) WriteAuthModelOption {
+	return func(w *WriteAuthorizationModelCommand) {
+		w.latestModelBackend = backend
+	}
+}
+
+// WithLabels writes the labels (e.g. 'release=42') with the authorization model to the backend, or adds them
+// to the latest model of the store when an identical model isn't written (see WithIdenticalModelsSkipped).
+func WithLabels(backend storage.AuthorizationModelLabelsBackend, labels map[string]string) WriteAuthModelOption {
+	return func(w *WriteAuthorizationModelCommand) {
+		w.labelsBackend = backend
+		w.labels = labels
+	}
+}
+
+// WithConditions writes the conditions (see the condition package) with the authorization model to the backend,
+// after validating them against the model. An identical model is only skipped (see WithIdenticalModelsSkipped)
+// if the latest model of the store has the same conditions.
+func WithConditions(backend storage.ConditionsBackend, conditions *condition.ModelConditions) WriteAuthModelOption {
+	return func(w *WriteAuthorizationModelCommand) {
+		w.conditionsBackend = backend
+		w.conditions = conditions
+		w.typesystemOpts = append(w.typesystemOpts, typesystem.WithConditions(conditions))
+	}
+}
+
+// WithAuthorizationModelValidator validates every model with the provided validator, after it has been
+// found valid, and rejects it with a validation error if the validator does.
+func WithAuthorizationModelValidator(validator modelvalidation.Validator) WriteAuthModelOption {
+	return func(w *WriteAuthorizationModelCommand) {
+		w.validator = validator
+	}
+}
+
+// WithNamingPolicy rejects the models whose type or relation names violate the provided policy.
+func WithNamingPolicy(policy *typesystem.NamingPolicy) WriteAuthModelOption {
+	return func(w *WriteAuthorizationModelCommand) {
+		w.typesystemOpts = append(w.typesystemOpts, typesystem.WithNamingPolicy(policy))
+	}
+}
+
+// WithWildcardsDisallowed rejects the models whose relations can be directly related to a public wildcard.
+func WithWildcardsDisallowed() WriteAuthModelOption {
+	return func(w *WriteAuthorizationModelCommand) {
+		w.typesystemOpts = append(w.typesystemOpts, typesystem.WithWildcardsDisallowed())
+	}
+}
+
+// WithIntersectionAndExclusionDisallowed rejects the models whose relations are defined with an intersection
+// or an exclusion.
+func WithIntersectionAndExclusionDisallowed() WriteAuthModelOption {
+	return func(w *WriteAuthorizationModelCommand) {
+		w.typesystemOpts = append(w.typesystemOpts, typesystem.WithIntersectionAndExclusionDisallowed())
+	}
 }
 
 func NewWriteAuthorizationModelCommand(
 	backend storage.TypeDefinitionWriteBackend,
 	logger logger.Logger,
 	maxAuthorizationModelSizeInBytes int,
+	opts ...WriteAuthModelOption,
 ) *WriteAuthorizationModelCommand {
-	return &WriteAuthorizationModelCommand{
+	w := &WriteAuthorizationModelCommand{
 		backend:                          backend,
 		logger:                           logger,
 		maxAuthorizationModelSizeInBytes: maxAuthorizationModelSizeInBytes,
 	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
 }
 
 // Execute the command using the supplied request.
 func (w *WriteAuthorizationModelCommand) Execute(ctx context.Context, req *openfgav1.WriteAuthorizationModelRequest) (*openfgav1.WriteAuthorizationModelResponse, error) {
+	model, typesys, err := w.validate(ctx, req)
+	if err != nil {
+		var invalidModel *invalidModelError
+		if errors.As(err, &invalidModel) {
+			return nil, serverErrors.InvalidAuthorizationModelInput(invalidModel.cause)
+		}
+		return nil, err
+	}
+
+	if w.latestModelBackend != nil {
+		latestModelID, err := w.identicalLatestModelID(ctx, req.GetStoreId(), model)
+		if err != nil {
+			return nil, serverErrors.HandleError(ctx, "", err)
+		}
+
+		if latestModelID != "" {
+			if err := w.writeLabels(ctx, req.GetStoreId(), latestModelID); err != nil {
+				return nil, err
+			}
+
+			w.skipped = true
+			return &openfgav1.WriteAuthorizationModelResponse{
+				AuthorizationModelId: latestModelID,
+			}, nil
+		}
+	}
+
+	var previousModelID string
+	if w.assertionsBackend != nil {
+		previousModelID, err = w.assertionsBackend.FindLatestAuthorizationModelID(ctx, req.GetStoreId())
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return nil, serverErrors.HandleError(ctx, "", err)
+		}
+	}
+
+	err = w.backend.WriteAuthorizationModel(ctx, req.GetStoreId(), model)
+	if err != nil {
+		return nil, serverErrors.NewInternalError("Error writing authorization model configuration", err)
+	}
+
+	if w.conditionsBackend != nil && !w.conditions.IsEmpty() {
+		if err := w.conditionsBackend.WriteAuthorizationModelConditions(ctx, req.GetStoreId(), model.GetId(), w.conditions); err != nil {
+			return nil, serverErrors.NewInternalError("Error writing authorization model conditions", err)
+		}
+	}
+
+	if err := w.writeLabels(ctx, req.GetStoreId(), model.GetId()); err != nil {
+		return nil, err
+	}
+
+	if previousModelID != "" {
+		// the model has been written at this point, so failing to copy the assertions must not fail the request
+		if err := w.copyAssertionsForward(ctx, req.GetStoreId(), previousModelID, model.GetId(), typesys); err != nil {
+			w.logger.WarnWithContext(ctx, "failed to copy assertions to the new authorization model",
+				zap.String("store_id", req.GetStoreId()),
+				zap.String("authorization_model_id", model.GetId()),
+				zap.String("previous_authorization_model_id", previousModelID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return &openfgav1.WriteAuthorizationModelResponse{
+		AuthorizationModelId: model.Id,
+	}, nil
+}
+
+// invalidModelError is the error of validate when the model itself is invalid, as opposed to the errors of the
+// limits, which are already encoded.
+type invalidModelError struct {
+	cause error
+}
+
+func (e *invalidModelError) Error() string {
+	return e.cause.Error()
+}
+
+func (e *invalidModelError) Unwrap() error {
+	return e.cause
+}
+
+// validate validates the model of the request as it would be written, and returns it with its TypeSystem.
+func (w *WriteAuthorizationModelCommand) validate(ctx context.Context, req *openfgav1.WriteAuthorizationModelRequest) (*openfgav1.AuthorizationModel, *typesystem.TypeSystem, error) {
 	// Until this is solved: https://github.com/envoyproxy/protoc-gen-validate/issues/74
 	if len(req.GetTypeDefinitions()) > w.backend.MaxTypesPerAuthorizationModel() {
-		return nil, serverErrors.ExceededEntityLimit("type definitions in an authorization model", w.backend.MaxTypesPerAuthorizationModel())
+		return nil, nil, serverErrors.ExceededEntityLimit("type definitions in an authorization model", w.backend.MaxTypesPerAuthorizationModel())
 	}
 
 	// Fill in the schema version for old requests, which don't contain it, while we migrate to the new schema version.
@@ -55,23 +235,107 @@ func (w *WriteAuthorizationModelCommand) Execute(ctx context.Context, req *openf
 	// Validate the size in bytes of the wire-format encoding of the authorization model.
 	modelSize := proto.Size(model)
 	if modelSize > w.maxAuthorizationModelSizeInBytes {
-		return nil, status.Error(
+		return nil, nil, status.Error(
 			codes.Code(openfgav1.ErrorCode_exceeded_entity_limit),
 			fmt.Sprintf("model exceeds size limit: %d bytes vs %d bytes", modelSize, w.maxAuthorizationModelSizeInBytes),
 		)
 	}
 
-	_, err := typesystem.NewAndValidate(ctx, model)
+	typesys, err := typesystem.NewAndValidate(ctx, model, w.typesystemOpts...)
 	if err != nil {
-		return nil, serverErrors.InvalidAuthorizationModelInput(err)
+		return nil, nil, &invalidModelError{cause: err}
 	}
 
-	err = w.backend.WriteAuthorizationModel(ctx, req.GetStoreId(), model)
+	if w.validator != nil {
+		if err := w.validator.Validate(ctx, req.GetStoreId(), model); err != nil {
+			return nil, nil, &invalidModelError{cause: err}
+		}
+	}
+
+	return model, typesys, nil
+}
+
+// Skipped reports whether Execute returned the ID of the latest model of the store instead of writing an
+// identical model (see WithIdenticalModelsSkipped).
+func (w *WriteAuthorizationModelCommand) Skipped() bool {
+	return w.skipped
+}
+
+// writeLabels writes the labels of the command, if any, to the model.
+func (w *WriteAuthorizationModelCommand) writeLabels(ctx context.Context, store, modelID string) error {
+	if w.labelsBackend == nil || len(w.labels) == 0 {
+		return nil
+	}
+
+	if err := w.labelsBackend.WriteAuthorizationModelLabels(ctx, store, modelID, w.labels); err != nil {
+		return serverErrors.NewInternalError("Error writing authorization model labels", err)
+	}
+
+	return nil
+}
+
+// identicalLatestModelID returns the ID of the latest model of the store if it's identical to the model, and
+// an empty ID otherwise.
+func (w *WriteAuthorizationModelCommand) identicalLatestModelID(ctx context.Context, store string, model *openfgav1.AuthorizationModel) (string, error) {
+	latestModelID, err := w.latestModelBackend.FindLatestAuthorizationModelID(ctx, store)
 	if err != nil {
-		return nil, serverErrors.NewInternalError("Error writing authorization model configuration", err)
+		if errors.Is(err, storage.ErrNotFound) {
+			return "", nil
+		}
+		return "", err
 	}
 
-	return &openfgav1.WriteAuthorizationModelResponse{
-		AuthorizationModelId: model.Id,
-	}, nil
+	latestModel, err := w.latestModelBackend.ReadAuthorizationModel(ctx, store, latestModelID)
+	if err != nil {
+		return "", err
+	}
+
+	if typesystem.ModelHash(latestModel) != typesystem.ModelHash(model) {
+		return "", nil
+	}
+
+	if w.conditionsBackend != nil {
+		latestConditions, err := w.conditionsBackend.ReadAuthorizationModelConditions(ctx, store, latestModelID)
+		if err != nil {
+			return "", err
+		}
+
+		if !sameConditions(latestConditions, w.conditions) {
+			return "", nil
+		}
+	}
+
+	return latestModelID, nil
+}
+
+// sameConditions reports whether two models have the same conditions.
+func sameConditions(a, b *condition.ModelConditions) bool {
+	if a.IsEmpty() || b.IsEmpty() {
+		return a.IsEmpty() && b.IsEmpty()
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// copyAssertionsForward copies the assertions of the previous model that are still valid to the new model.
+func (w *WriteAuthorizationModelCommand) copyAssertionsForward(ctx context.Context, store, previousModelID, modelID string, typesys *typesystem.TypeSystem) error {
+	assertions, err := w.assertionsBackend.ReadAssertions(ctx, store, previousModelID)
+	if err != nil {
+		return err
+	}
+
+	validAssertions := make([]*openfgav1.Assertion, 0, len(assertions))
+	for _, assertion := range assertions {
+		if err := validation.ValidateUserObjectRelation(typesys, assertion.GetTupleKey()); err != nil {
+			continue
+		}
+
+		validAssertions = append(validAssertions, assertion)
+	}
+
+	if len(validAssertions) == 0 {
+		return nil
+	}
+
+	return w.assertionsBackend.WriteAssertions(ctx, store, modelID, validAssertions)
 }
@@ -4,19 +4,25 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	parser "github.com/craigpastro/openfga-dsl-parser/v2"
 	"github.com/golang/mock/gomock"
 	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	mockstorage "github.com/openfga/openfga/internal/mocks"
+	"github.com/openfga/openfga/internal/validation"
 	"github.com/openfga/openfga/pkg/logger"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
 	"github.com/openfga/openfga/pkg/testutils"
 	"github.com/openfga/openfga/pkg/tuple"
 	"github.com/openfga/openfga/pkg/typesystem"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestValidateNoDuplicatesAndCorrectSize(t *testing.T) {
@@ -62,7 +68,7 @@ func TestValidateNoDuplicatesAndCorrectSize(t *testing.T) {
 			name:          "duplicate_deletes",
 			deletes:       []*openfgav1.TupleKey{items[0], items[1], items[0]},
 			writes:        []*openfgav1.TupleKey{},
-			expectedError: serverErrors.DuplicateTupleInWrite(items[0]),
+			expectedError: serverErrors.DuplicateTupleInDelete(items[0]),
 		},
 		{
 			name:          "duplicate_writes",
@@ -74,7 +80,7 @@ func TestValidateNoDuplicatesAndCorrectSize(t *testing.T) {
 			name:          "same_item_appeared_in_writes_and_deletes",
 			deletes:       []*openfgav1.TupleKey{items[2], items[1]},
 			writes:        []*openfgav1.TupleKey{items[0], items[1]},
-			expectedError: serverErrors.DuplicateTupleInWrite(items[1]),
+			expectedError: serverErrors.ConflictingTupleInWriteAndDelete(items[1]),
 		},
 		{
 			name:          "too_many_items_writes_and_deletes",
@@ -167,6 +173,50 @@ func TestValidateWriteRequest(t *testing.T) {
 	}
 }
 
+func TestWriteRejectsSelfReferentialTuples(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+	mockDatastore.EXPECT().MaxTuplesPerWrite().AnyTimes().Return(10)
+	mockDatastore.EXPECT().
+		ReadAuthorizationModel(gomock.Any(), gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(&openfgav1.AuthorizationModel{
+			SchemaVersion: typesystem.SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(`
+			type user
+
+			type group
+			  relations
+			    define member: [user, group#member] as self
+			`),
+		}, nil)
+
+	tk := tuple.NewTupleKey("group:eng", "member", "group:eng#member")
+	req := &openfgav1.WriteRequest{
+		StoreId: ulid.Make().String(),
+		Writes:  &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{tk}},
+	}
+
+	t.Run("rejected", func(t *testing.T) {
+		cmd := NewWriteCommand(mockDatastore, logger.NewNoopLogger(), WithSelfReferentialTuplesRejected(true))
+
+		err := cmd.validateWriteRequest(context.Background(), req)
+		require.ErrorIs(t, err, serverErrors.ValidationError(&tuple.InvalidTupleError{
+			Cause:    validation.ErrSelfReferentialTuple,
+			TupleKey: tk,
+		}))
+	})
+
+	t.Run("allowed_by_default", func(t *testing.T) {
+		cmd := NewWriteCommand(mockDatastore, logger.NewNoopLogger())
+
+		err := cmd.validateWriteRequest(context.Background(), req)
+		require.NoError(t, err)
+	})
+}
+
 func TestTransactionalWriteFailedError(t *testing.T) {
 	mockController := gomock.NewController(t)
 	defer mockController.Finish()
@@ -206,3 +256,156 @@ func TestTransactionalWriteFailedError(t *testing.T) {
 	require.ErrorIs(t, err, serverErrors.NewInternalError("concurrent write conflict", storage.ErrTransactionalWriteFailed))
 	require.Nil(t, resp)
 }
+
+func TestWriteWithResolvedTypesystem(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	// the model isn't read, since it was resolved by the caller
+	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+	mockDatastore.EXPECT().MaxTuplesPerWrite().AnyTimes().Return(10)
+	mockDatastore.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+	typesys := typesystem.New(&openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type document
+		  relations
+		    define viewer: [user] as self
+		`),
+	})
+
+	cmd := NewWriteCommand(mockDatastore, logger.NewNoopLogger(), WithWriteTypesystem(typesys))
+
+	_, err := cmd.Execute(context.Background(), &openfgav1.WriteRequest{
+		StoreId:              ulid.Make().String(),
+		AuthorizationModelId: typesys.GetAuthorizationModelID(),
+		Writes: &openfgav1.TupleKeys{
+			TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:1", "viewer", "user:jon"),
+			},
+		},
+	})
+	require.NoError(t, err)
+}
+
+func TestWriteHook(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+	mockDatastore.EXPECT().MaxTuplesPerWrite().AnyTimes().Return(10)
+	mockDatastore.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+	typesys := typesystem.New(&openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type document
+		  relations
+		    define viewer: [user] as self
+		`),
+	})
+
+	storeID := ulid.Make().String()
+	write := tuple.NewTupleKey("document:1", "viewer", "user:jon")
+	deletion := tuple.NewTupleKey("document:2", "viewer", "user:jon")
+
+	var called bool
+	hook := func(ctx context.Context, hookTypesys *typesystem.TypeSystem, hookStoreID string, tupleKeys []*openfgav1.TupleKey) {
+		called = true
+		require.Equal(t, typesys, hookTypesys)
+		require.Equal(t, storeID, hookStoreID)
+		require.Equal(t, []*openfgav1.TupleKey{write, deletion}, tupleKeys)
+	}
+
+	cmd := NewWriteCommand(mockDatastore, logger.NewNoopLogger(), WithWriteTypesystem(typesys), WithWriteHook(hook))
+
+	_, err := cmd.Execute(context.Background(), &openfgav1.WriteRequest{
+		StoreId:              storeID,
+		AuthorizationModelId: typesys.GetAuthorizationModelID(),
+		Writes:               &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{write}},
+		Deletes:              &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{deletion}},
+	})
+	require.NoError(t, err)
+	require.True(t, called)
+}
+
+func TestWritePreconditions(t *testing.T) {
+	ctx := context.Background()
+
+	datastore := memory.New().(*memory.MemoryBackend)
+	t.Cleanup(datastore.Close)
+
+	typesys := typesystem.New(&openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type document
+		  relations
+		    define owner: [user] as self
+		    define viewer: [user] as self
+		`),
+	})
+
+	storeID := ulid.Make().String()
+	owner := tuple.NewTupleKey("document:1", "owner", "user:jon")
+	viewer := tuple.NewTupleKey("document:1", "viewer", "user:maria")
+	require.NoError(t, datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{owner}))
+
+	writeViewer := func(preconditions ...storage.Precondition) error {
+		cmd := NewWriteCommand(datastore, logger.NewNoopLogger(), WithWriteTypesystem(typesys), WithWritePreconditions(datastore, preconditions))
+
+		_, err := cmd.Execute(ctx, &openfgav1.WriteRequest{
+			StoreId:              storeID,
+			AuthorizationModelId: typesys.GetAuthorizationModelID(),
+			Writes:               &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{viewer}},
+		})
+		return err
+	}
+
+	t.Run("precondition_that_does_not_hold", func(t *testing.T) {
+		err := writeViewer(storage.Precondition{TupleKey: owner, Exists: false})
+
+		st, ok := status.FromError(err)
+		require.True(t, ok)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_write_failed_due_to_invalid_input), st.Code())
+		require.Len(t, st.Details(), 1)
+		require.Equal(t, serverErrors.ReasonPreconditionFailed, st.Details()[0].(*errdetails.ErrorInfo).GetReason())
+	})
+
+	t.Run("malformed_precondition", func(t *testing.T) {
+		err := writeViewer(storage.Precondition{TupleKey: tuple.NewTupleKey("document", "owner", "user:jon"), Exists: true})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+	})
+
+	t.Run("precondition_with_expirations", func(t *testing.T) {
+		cmd := NewWriteCommand(datastore, logger.NewNoopLogger(),
+			WithWriteTypesystem(typesys),
+			WithWritePreconditions(datastore, []storage.Precondition{{TupleKey: owner, Exists: true}}),
+			WithTupleExpirations(datastore, map[string]time.Time{tuple.TupleKeyToString(viewer): time.Now().Add(time.Hour)}),
+		)
+
+		_, err := cmd.Execute(ctx, &openfgav1.WriteRequest{
+			StoreId:              storeID,
+			AuthorizationModelId: typesys.GetAuthorizationModelID(),
+			Writes:               &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{viewer}},
+		})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+	})
+
+	t.Run("precondition_that_holds", func(t *testing.T) {
+		err := writeViewer(storage.Precondition{TupleKey: owner, Exists: true})
+		require.NoError(t, err)
+
+		_, err = datastore.ReadUserTuple(ctx, storeID, viewer, storage.ReadOptions{})
+		require.NoError(t, err)
+	})
+}
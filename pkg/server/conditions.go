@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/openfga/openfga/pkg/condition"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/storagewrappers"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// AuthorizationModelConditionsHeader is the WriteAuthorizationModel request header that carries the
+	// conditions of the model written, and the type restrictions that use them, as the JSON encoding of a
+	// condition.ModelConditions.
+	AuthorizationModelConditionsHeader = "openfga-authorization-model-conditions"
+
+	// TupleConditionsHeader is the Write request header that carries the conditions of the tuples written, as a
+	// JSON array of '{"tuple_key": {...}, "condition": {"name": "...", "context": {...}}}' objects.
+	TupleConditionsHeader = "openfga-tuple-conditions"
+
+	// ConditionContextHeader is the Check and ListObjects request header that carries the context the
+	// conditions of the tuples are evaluated with, as a JSON object, e.g. '{"current_time": "2023-10-02T10:00:00Z"}'.
+	ConditionContextHeader = "openfga-condition-context"
+)
+
+// WithConditionsBackend sets the backend of the conditions of the models and of the tuples (see the condition
+// package). It defaults to the datastore if it stores conditions (see storage.As). The conditions headers are
+// rejected without a backend.
+func WithConditionsBackend(backend storage.ConditionsBackend) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.conditionsBackend = backend
+	}
+}
+
+// conditionsModelBackend reads the models of a datastore, along with their conditions.
+type conditionsModelBackend struct {
+	storage.AuthorizationModelReadBackend
+	storage.ConditionsBackend
+}
+
+// tupleConditionEntry is an entry of the TupleConditionsHeader header.
+type tupleConditionEntry struct {
+	TupleKey  *tupleConditionKey        `json:"tuple_key"`
+	Condition *condition.TupleCondition `json:"condition"`
+}
+
+type tupleConditionKey struct {
+	Object   string `json:"object"`
+	Relation string `json:"relation"`
+	User     string `json:"user"`
+}
+
+// requestConditionContext returns the context of the ConditionContextHeader header of a request, if any.
+func requestConditionContext(ctx context.Context) (map[string]interface{}, error) {
+	values := metadata.ValueFromIncomingContext(ctx, ConditionContextHeader)
+	if len(values) == 0 || values[0] == "" {
+		return nil, nil
+	}
+
+	var conditionContext map[string]interface{}
+	if err := json.Unmarshal([]byte(values[0]), &conditionContext); err != nil {
+		return nil, serverErrors.ValidationError(fmt.Errorf("invalid '%s' header: %w", ConditionContextHeader, err))
+	}
+
+	return conditionContext, nil
+}
+
+// requestTupleConditions returns the conditions of the TupleConditionsHeader header of a Write request, if
+// any, keyed by the tuple.TupleKeyToString of their tuple.
+func (s *Server) requestTupleConditions(ctx context.Context) (map[string]*condition.TupleCondition, error) {
+	values := metadata.ValueFromIncomingContext(ctx, TupleConditionsHeader)
+	if len(values) == 0 || values[0] == "" {
+		return nil, nil
+	}
+
+	if s.conditionsBackend == nil {
+		return nil, serverErrors.ValidationError(fmt.Errorf("the '%s' header isn't supported by the datastore", TupleConditionsHeader))
+	}
+
+	var entries []*tupleConditionEntry
+	if err := json.Unmarshal([]byte(values[0]), &entries); err != nil {
+		return nil, serverErrors.ValidationError(fmt.Errorf("invalid '%s' header: %w", TupleConditionsHeader, err))
+	}
+
+	conditions := make(map[string]*condition.TupleCondition, len(entries))
+	for _, entry := range entries {
+		if entry.TupleKey == nil || entry.Condition == nil || entry.Condition.Name == "" {
+			return nil, serverErrors.ValidationError(fmt.Errorf("invalid '%s' header: every entry must have a tuple key and a named condition", TupleConditionsHeader))
+		}
+
+		key := tuple.TupleKeyToString(tuple.NewTupleKey(entry.TupleKey.Object, entry.TupleKey.Relation, entry.TupleKey.User))
+		conditions[key] = entry.Condition
+	}
+
+	return conditions, nil
+}
+
+// requestModelConditions returns the conditions of the AuthorizationModelConditionsHeader header of a
+// WriteAuthorizationModel request, if any.
+func (s *Server) requestModelConditions(ctx context.Context) (*condition.ModelConditions, error) {
+	values := metadata.ValueFromIncomingContext(ctx, AuthorizationModelConditionsHeader)
+	if len(values) == 0 || values[0] == "" {
+		return nil, nil
+	}
+
+	if s.conditionsBackend == nil {
+		return nil, serverErrors.ValidationError(fmt.Errorf("the '%s' header isn't supported by the datastore", AuthorizationModelConditionsHeader))
+	}
+
+	conditions := &condition.ModelConditions{}
+	if err := json.Unmarshal([]byte(values[0]), conditions); err != nil {
+		return nil, serverErrors.ValidationError(fmt.Errorf("invalid '%s' header: %w", AuthorizationModelConditionsHeader, err))
+	}
+
+	return conditions, nil
+}
+
+// conditionsTupleReader returns the tuple reader of the Check and ListObjects requests: the datastore, which
+// hides the tuples whose condition isn't met by the request context if the model of the typesys has
+// conditions.
+func (s *Server) conditionsTupleReader(typesys *typesystem.TypeSystem, requestContext map[string]interface{}) storage.RelationshipTupleReader {
+	if s.conditionsBackend == nil || typesys.GetModelConditions().IsEmpty() {
+		return s.datastore
+	}
+
+	return storagewrappers.NewConditionEvaluatingTupleReader(s.datastore, s.conditionsBackend, typesys, requestContext)
+}
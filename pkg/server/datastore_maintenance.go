@@ -0,0 +1,105 @@
+package server
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/openfga/openfga/internal/scheduler"
+	"github.com/openfga/openfga/pkg/storage"
+	"go.uber.org/zap"
+)
+
+// WithDatastoreMaintainer sets the datastore whose maintenance tasks are scheduled. It defaults to the
+// datastore if it has maintenance tasks (see storage.As).
+func WithDatastoreMaintainer(maintainer storage.Maintainer) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.datastoreMaintainer = maintainer
+	}
+}
+
+// WithDatastoreMaintenanceSchedule sets the cron-like schedule (see scheduler.ParseSchedule) on which the
+// maintenance tasks of the datastore run, e.g. '0 3 * * *' or '@every 6h'. An empty schedule disables them.
+func WithDatastoreMaintenanceSchedule(schedule string) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.datastoreMaintenanceSchedule = schedule
+	}
+}
+
+// WithDatastoreMaintenanceJitter sets the maximum random delay added to each run of the maintenance tasks of
+// the datastore, so that the servers sharing a datastore don't all run them at the same time.
+func WithDatastoreMaintenanceJitter(jitter time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.datastoreMaintenanceJitter = jitter
+	}
+}
+
+// WithDatastoreMaintenanceTasks sets the names of the maintenance tasks of the datastore that run. By
+// default, all of them run.
+func WithDatastoreMaintenanceTasks(tasks ...string) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.datastoreMaintenanceTasks = tasks
+	}
+}
+
+// startDatastoreMaintenance schedules the maintenance tasks of the datastore, if any.
+func (s *Server) startDatastoreMaintenance() error {
+	if s.datastoreMaintenanceSchedule == "" {
+		return nil
+	}
+
+	schedule, err := scheduler.ParseSchedule(s.datastoreMaintenanceSchedule)
+	if err != nil {
+		return err
+	}
+
+	maintainer := s.datastoreMaintainer
+	if maintainer == nil {
+		maintainer, _ = storage.As[storage.Maintainer](s.datastore)
+	}
+
+	var available []storage.MaintenanceTask
+	if maintainer != nil {
+		available = maintainer.MaintenanceTasks()
+	}
+
+	var tasks []scheduler.Task
+	for _, task := range available {
+		if len(s.datastoreMaintenanceTasks) > 0 && !slices.Contains(s.datastoreMaintenanceTasks, task.Name) {
+			continue
+		}
+
+		tasks = append(tasks, scheduler.Task{
+			Name: task.Name,
+			Run:  task.Run,
+		})
+	}
+
+	for _, name := range s.datastoreMaintenanceTasks {
+		if !slices.ContainsFunc(available, func(task storage.MaintenanceTask) bool { return task.Name == name }) {
+			return fmt.Errorf("the datastore has no '%s' maintenance task", name)
+		}
+	}
+
+	if len(tasks) == 0 {
+		s.logger.Warn("the datastore has no maintenance tasks to schedule")
+		return nil
+	}
+
+	taskNames := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		taskNames = append(taskNames, task.Name)
+	}
+
+	s.logger.Info("scheduling the maintenance tasks of the datastore",
+		zap.String("schedule", s.datastoreMaintenanceSchedule),
+		zap.Strings("tasks", taskNames))
+
+	s.datastoreMaintenanceScheduler = scheduler.New(schedule, tasks,
+		scheduler.WithJitter(s.datastoreMaintenanceJitter),
+		scheduler.WithLogger(s.logger),
+	)
+	s.datastoreMaintenanceScheduler.Start()
+
+	return nil
+}
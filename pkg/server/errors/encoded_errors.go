@@ -90,6 +90,16 @@ func NewEncodedError(errorCode int32, message string) *EncodedError {
 		httpStatusCode = http.StatusBadRequest
 		code = openfgav1.ErrorCode(errorCode).String()
 		grpcStatusCode = codes.InvalidArgument
+	} else if errorCode == int32(openfgav1.InternalErrorCode_unavailable) {
+		// the server is temporarily unable to serve the request, e.g. it's undergoing maintenance
+		httpStatusCode = http.StatusServiceUnavailable
+		code = openfgav1.InternalErrorCode(errorCode).String()
+		grpcStatusCode = codes.Unavailable
+	} else if errorCode == int32(openfgav1.InternalErrorCode_resource_exhausted) {
+		// the server is shedding load, e.g. it's serving too many requests concurrently
+		httpStatusCode = http.StatusTooManyRequests
+		code = openfgav1.InternalErrorCode(errorCode).String()
+		grpcStatusCode = codes.ResourceExhausted
 	} else if errorCode >= cFirstInternalErrorCode && errorCode < cFirstUnknownEndpointErrorCode {
 		httpStatusCode = http.StatusInternalServerError
 		code = openfgav1.InternalErrorCode(errorCode).String()
@@ -99,6 +109,11 @@ func NewEncodedError(errorCode int32, message string) *EncodedError {
 		code = openfgav1.NotFoundErrorCode(errorCode).String()
 		grpcStatusCode = codes.NotFound
 	}
+
+	if override, ok := statusCodeOverride(errorCode); ok {
+		httpStatusCode = override.HTTP
+		grpcStatusCode = override.GRPC
+	}
 	return &EncodedError{
 		HTTPStatusCode: httpStatusCode,
 		GRPCStatusCode: grpcStatusCode,
@@ -200,6 +215,11 @@ func getCustomizedErrorCode(field string, reason string) int32 {
 }
 
 func ConvertToEncodedErrorCode(statusError *status.Status) int32 {
+	if class, ok := errorClassOf(statusError); ok {
+		// the gRPC code of the error was overridden (see WithStatusCodeOverride)
+		return class
+	}
+
 	code := int32(statusError.Code())
 	if code >= cFirstAuthenticationErrorCode {
 		return code
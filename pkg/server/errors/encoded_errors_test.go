@@ -111,6 +111,24 @@ func TestEncodedError(t *testing.T) {
 			expectedCodeString:     "internal_error",
 			isValidEncodedError:    true,
 		},
+		{
+			_name:                  "unavailable",
+			errorCode:              int32(openfgav1.InternalErrorCode_unavailable),
+			message:                "error message",
+			expectedHTTPStatusCode: http.StatusServiceUnavailable,
+			expectedCode:           int(openfgav1.InternalErrorCode_unavailable),
+			expectedCodeString:     "unavailable",
+			isValidEncodedError:    true,
+		},
+		{
+			_name:                  "resource_exhausted",
+			errorCode:              int32(openfgav1.InternalErrorCode_resource_exhausted),
+			message:                "error message",
+			expectedHTTPStatusCode: http.StatusTooManyRequests,
+			expectedCode:           int(openfgav1.InternalErrorCode_resource_exhausted),
+			expectedCodeString:     "resource_exhausted",
+			isValidEncodedError:    true,
+		},
 		{
 			_name:                  "undefined_endpoint",
 			errorCode:              int32(openfgav1.NotFoundErrorCode_undefined_endpoint),
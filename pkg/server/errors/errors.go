@@ -2,12 +2,19 @@
 package errors
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/condition"
+	"github.com/openfga/openfga/pkg/encoder"
+	"github.com/openfga/openfga/pkg/middleware/loadshed"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/tuple"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -63,6 +70,45 @@ func ValidationError(cause error) error {
 	return status.Error(codes.Code(openfgav1.ErrorCode_validation_error), cause.Error())
 }
 
+// FieldViolation is the error of an element of a repeated field of a request, e.g. of the tuple at the
+// index 2 of 'writes.tuple_keys'.
+type FieldViolation struct {
+	Field string
+	Index int
+	Err   error
+}
+
+// ValidationErrors returns the validation error of a request with several invalid elements, so that the
+// callers can fix all of them at once. The message lists every violation, and the status carries them as
+// the field violations of a google.rpc.BadRequest detail. A single violation is reported as a
+// ValidationError.
+func ValidationErrors(violations []FieldViolation) error {
+	if len(violations) == 1 {
+		return ValidationError(violations[0].Err)
+	}
+
+	badRequest := &errdetails.BadRequest{}
+	messages := make([]string, 0, len(violations))
+	for _, v := range violations {
+		field := fmt.Sprintf("%s[%d]", v.Field, v.Index)
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: v.Err.Error(),
+		})
+		messages = append(messages, fmt.Sprintf("%s: %s", field, v.Err))
+	}
+
+	st := status.New(
+		codes.Code(openfgav1.ErrorCode_validation_error),
+		fmt.Sprintf("%d validation errors: %s", len(violations), strings.Join(messages, "; ")),
+	)
+	if withDetails, err := st.WithDetails(badRequest); err == nil {
+		st = withDetails
+	}
+
+	return st.Err()
+}
+
 func AssertionsNotForAuthorizationModelFound(modelID string) error {
 	return status.Error(codes.Code(openfgav1.ErrorCode_authorization_model_assertions_not_found), fmt.Sprintf("No assertions found for authorization model '%s'", modelID))
 }
@@ -71,10 +117,22 @@ func AuthorizationModelNotFound(modelID string) error {
 	return status.Error(codes.Code(openfgav1.ErrorCode_authorization_model_not_found), fmt.Sprintf("Authorization Model '%s' not found", modelID))
 }
 
+// InvalidAuthorizationModelID is the error of the requests for an authorization model whose ID isn't a ULID,
+// which therefore can't be the ID of any model.
+func InvalidAuthorizationModelID(modelID string) error {
+	return status.Error(codes.Code(openfgav1.ErrorCode_validation_error), fmt.Sprintf("Authorization Model ID '%s' is not a valid ULID", modelID))
+}
+
 func LatestAuthorizationModelNotFound(store string) error {
 	return status.Error(codes.Code(openfgav1.ErrorCode_latest_authorization_model_not_found), fmt.Sprintf("No authorization models found for store '%s'", store))
 }
 
+// AuthorizationModelLabelNotFound is the error of the lookups of the authorization models by a label (e.g.
+// 'release=42') that no model of the store has.
+func AuthorizationModelLabelNotFound(label string) error {
+	return status.Error(codes.Code(openfgav1.ErrorCode_authorization_model_not_found), fmt.Sprintf("No authorization model labeled '%s' found", label))
+}
+
 func TypeNotFound(objectType string) error {
 	return status.Error(codes.Code(openfgav1.ErrorCode_type_not_found), fmt.Sprintf("type '%s' not found", objectType))
 }
@@ -97,8 +155,73 @@ func InvalidTuple(reason string, tuple *openfgav1.TupleKey) error {
 	return status.Error(codes.Code(openfgav1.ErrorCode_invalid_tuple), fmt.Sprintf("Invalid tuple '%s'. Reason: %s", tuple.String(), reason))
 }
 
+// The reasons of the google.rpc.ErrorInfo details of the errors of a Write with duplicate or conflicting
+// operations, which tell them apart.
+const (
+	ReasonDuplicateWrite            = "duplicate_write"
+	ReasonDuplicateDelete           = "duplicate_delete"
+	ReasonConflictingWriteAndDelete = "conflicting_write_and_delete"
+
+	errorInfoDomain = "openfga.dev"
+)
+
+// duplicateTupleError returns the error of a Write that writes or deletes a tuple twice, or both writes and
+// deletes it, with the reason as a google.rpc.ErrorInfo detail.
+func duplicateTupleError(reason, message string) error {
+	return errorWithReason(codes.Code(openfgav1.ErrorCode_cannot_allow_duplicate_tuples_in_one_request), reason, message)
+}
+
+// errorWithReason returns an error with the code and the message, and the reason as a google.rpc.ErrorInfo
+// detail.
+func errorWithReason(code codes.Code, reason, message string) error {
+	st := status.New(code, message)
+	if withDetails, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: reason,
+		Domain: errorInfoDomain,
+	}); err == nil {
+		st = withDetails
+	}
+
+	return st.Err()
+}
+
 func DuplicateTupleInWrite(tk *openfgav1.TupleKey) error {
-	return status.Error(codes.Code(openfgav1.ErrorCode_cannot_allow_duplicate_tuples_in_one_request), fmt.Sprintf("duplicate tuple in write: user: '%s', relation: '%s', object: '%s'", tk.GetUser(), tk.GetRelation(), tk.GetObject()))
+	return duplicateTupleError(ReasonDuplicateWrite, fmt.Sprintf("duplicate tuple in write: user: '%s', relation: '%s', object: '%s'", tk.GetUser(), tk.GetRelation(), tk.GetObject()))
+}
+
+// DuplicateTupleInDelete is the error of a Write that deletes the same tuple twice.
+func DuplicateTupleInDelete(tk *openfgav1.TupleKey) error {
+	return duplicateTupleError(ReasonDuplicateDelete, fmt.Sprintf("duplicate tuple in delete: user: '%s', relation: '%s', object: '%s'", tk.GetUser(), tk.GetRelation(), tk.GetObject()))
+}
+
+// ConflictingTupleInWriteAndDelete is the error of a Write that both writes and deletes the same tuple.
+func ConflictingTupleInWriteAndDelete(tk *openfgav1.TupleKey) error {
+	return duplicateTupleError(ReasonConflictingWriteAndDelete, fmt.Sprintf("tuple both written and deleted: user: '%s', relation: '%s', object: '%s'", tk.GetUser(), tk.GetRelation(), tk.GetObject()))
+}
+
+// The reasons of the google.rpc.ErrorInfo details of the errors of the requests with a continuation token
+// that is valid but can't be used.
+const (
+	ReasonExpiredContinuationToken       = "expired_continuation_token"
+	ReasonContinuationTokenStoreMismatch = "continuation_token_store_mismatch"
+)
+
+var (
+	ExpiredContinuationToken       = errorWithReason(codes.Code(openfgav1.ErrorCode_invalid_continuation_token), ReasonExpiredContinuationToken, "The continuation token has expired")
+	ContinuationTokenStoreMismatch = errorWithReason(codes.Code(openfgav1.ErrorCode_invalid_continuation_token), ReasonContinuationTokenStoreMismatch, "The continuation token was issued for another store")
+)
+
+// ContinuationTokenError returns the error of a request with a continuation token that failed to decode with
+// the provided error.
+func ContinuationTokenError(err error) error {
+	switch {
+	case errors.Is(err, encoder.ErrExpiredToken):
+		return ExpiredContinuationToken
+	case errors.Is(err, encoder.ErrTokenStoreMismatch):
+		return ContinuationTokenStoreMismatch
+	default:
+		return InvalidContinuationToken
+	}
 }
 
 func WriteFailedDueToInvalidInput(err error) error {
@@ -112,20 +235,95 @@ func InvalidAuthorizationModelInput(err error) error {
 	return status.Error(codes.Code(openfgav1.ErrorCode_invalid_authorization_model), err.Error())
 }
 
+const (
+	ReasonUnknownStoreResidency = "unknown_store_residency"
+	ReasonCrossRegionRead       = "cross_region_read"
+	ReasonStoreUnavailable      = "store_unavailable"
+	ReasonStoreDeleted          = "store_deleted"
+	ReasonReadTooBroad          = "read_too_broad"
+	ReasonPreconditionFailed    = "precondition_failed"
+)
+
+// UnknownStoreResidency returns the error of a request for a store to reside in a region the server doesn't
+// know.
+func UnknownStoreResidency(err error) error {
+	return errorWithReason(codes.Code(openfgav1.ErrorCode_validation_error), ReasonUnknownStoreResidency, err.Error())
+}
+
+// CrossRegionRead returns the error of a request to read the data of a store which resides in another region
+// than the server's.
+func CrossRegionRead(err error) error {
+	return errorWithReason(codes.FailedPrecondition, ReasonCrossRegionRead, err.Error())
+}
+
+// StoreUnavailable returns the error of a request to a store whose datastore operations are failed fast
+// because they failed too often, along with the load shedding hints (see package loadshed) of when the
+// datastore is tried again.
+func StoreUnavailable(ctx context.Context, err error) error {
+	var retryAfter time.Duration
+	var unavailableErr *storage.StoreUnavailableError
+	if errors.As(err, &unavailableErr) {
+		retryAfter = unavailableErr.RetryAfter
+	}
+
+	st := status.Convert(loadshed.Reject(ctx, codes.Unavailable, err.Error(), loadshed.Hint{
+		Reason:     loadshed.ReasonStoreUnavailable,
+		RetryAfter: retryAfter,
+	}))
+	if withDetails, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: ReasonStoreUnavailable,
+		Domain: errorInfoDomain,
+	}); err == nil {
+		st = withDetails
+	}
+
+	return st.Err()
+}
+
+// StoreDeleted returns the error of a request to a store that was deleted.
+func StoreDeleted(storeID string) error {
+	return errorWithReason(codes.Code(openfgav1.NotFoundErrorCode_store_id_not_found), ReasonStoreDeleted, fmt.Sprintf("Store '%s' has been deleted", storeID))
+}
+
+// ReadTooBroad returns the error of a Read request which the guardrails reject because it would scan too many
+// tuples of the store.
+func ReadTooBroad(err error) error {
+	return errorWithReason(codes.Code(openfgav1.ErrorCode_validation_error), ReasonReadTooBroad, err.Error())
+}
+
+// PreconditionFailed returns the error of a Write which isn't applied because one of its preconditions doesn't
+// hold.
+func PreconditionFailed(err error) error {
+	return errorWithReason(codes.Code(openfgav1.ErrorCode_write_failed_due_to_invalid_input), ReasonPreconditionFailed, err.Error())
+}
+
 // HandleError is used to hide internal errors from users. Use `public` to return an error message to the user.
-func HandleError(public string, err error) error {
+// The context is the one of the request, which the hints of the rejected requests are set on.
+func HandleError(ctx context.Context, public string, err error) error {
 	if errors.Is(err, storage.ErrInvalidContinuationToken) {
 		return InvalidContinuationToken
 	} else if errors.Is(err, storage.ErrMismatchObjectType) {
 		return MismatchObjectType
 	} else if errors.Is(err, storage.ErrCancelled) {
 		return RequestCancelled
+	} else if errors.Is(err, storage.ErrUnknownResidency) {
+		return UnknownStoreResidency(err)
+	} else if errors.Is(err, storage.ErrCrossRegionRead) {
+		return CrossRegionRead(err)
+	} else if errors.Is(err, storage.ErrStoreUnavailable) {
+		return StoreUnavailable(ctx, err)
 	}
+
+	var evaluationErr *condition.EvaluationError
+	if errors.As(err, &evaluationErr) {
+		return ValidationError(evaluationErr)
+	}
+
 	return NewInternalError(public, err)
 }
 
 // HandleTupleValidateError provide common routines for handling tuples validation error
-func HandleTupleValidateError(err error) error {
+func HandleTupleValidateError(ctx context.Context, err error) error {
 	switch t := err.(type) {
 	case *tuple.InvalidTupleError:
 		return InvalidTuple(t.Cause.Error(), t.TupleKey)
@@ -135,5 +333,5 @@ func HandleTupleValidateError(err error) error {
 		return RelationNotFound(t.Relation, t.TypeName, t.TupleKey)
 	}
 
-	return HandleError("", err)
+	return HandleError(ctx, "", err)
 }
@@ -1,10 +1,23 @@
 package errors
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/encoder"
+	"github.com/openfga/openfga/pkg/middleware/loadshed"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func TestInternalErrorDontLeakInternals(t *testing.T) {
@@ -13,6 +26,177 @@ func TestInternalErrorDontLeakInternals(t *testing.T) {
 	require.NotContains(t, err.Error(), "internal")
 }
 
+func TestValidationErrors(t *testing.T) {
+	t.Run("single_violation", func(t *testing.T) {
+		err := ValidationErrors([]FieldViolation{
+			{Field: "writes.tuple_keys", Index: 1, Err: errors.New("invalid user")},
+		})
+
+		require.ErrorIs(t, err, ValidationError(errors.New("invalid user")))
+	})
+
+	t.Run("several_violations", func(t *testing.T) {
+		err := ValidationErrors([]FieldViolation{
+			{Field: "writes.tuple_keys", Index: 0, Err: errors.New("invalid user")},
+			{Field: "writes.tuple_keys", Index: 2, Err: errors.New("invalid object")},
+			{Field: "deletes.tuple_keys", Index: 1, Err: errors.New("invalid relation")},
+		})
+
+		st, ok := status.FromError(err)
+		require.True(t, ok)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), st.Code())
+		require.Equal(t, "3 validation errors: writes.tuple_keys[0]: invalid user; writes.tuple_keys[2]: invalid object; deletes.tuple_keys[1]: invalid relation", st.Message())
+
+		require.Len(t, st.Details(), 1)
+		badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+		require.True(t, ok)
+		require.Len(t, badRequest.GetFieldViolations(), 3)
+		require.Equal(t, "writes.tuple_keys[2]", badRequest.GetFieldViolations()[1].GetField())
+		require.Equal(t, "invalid object", badRequest.GetFieldViolations()[1].GetDescription())
+	})
+}
+
+func TestDuplicateTupleErrors(t *testing.T) {
+	tk := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+
+	tests := []struct {
+		err            error
+		expectedReason string
+	}{
+		{err: DuplicateTupleInWrite(tk), expectedReason: ReasonDuplicateWrite},
+		{err: DuplicateTupleInDelete(tk), expectedReason: ReasonDuplicateDelete},
+		{err: ConflictingTupleInWriteAndDelete(tk), expectedReason: ReasonConflictingWriteAndDelete},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expectedReason, func(t *testing.T) {
+			st, ok := status.FromError(test.err)
+			require.True(t, ok)
+			require.Equal(t, codes.Code(openfgav1.ErrorCode_cannot_allow_duplicate_tuples_in_one_request), st.Code())
+
+			require.Len(t, st.Details(), 1)
+			errorInfo, ok := st.Details()[0].(*errdetails.ErrorInfo)
+			require.True(t, ok)
+			require.Equal(t, test.expectedReason, errorInfo.GetReason())
+		})
+	}
+}
+
+func TestContinuationTokenError(t *testing.T) {
+	tests := []struct {
+		err            error
+		expected       error
+		expectedReason string
+	}{
+		{err: encoder.ErrExpiredToken, expected: ExpiredContinuationToken, expectedReason: ReasonExpiredContinuationToken},
+		{err: encoder.ErrTokenStoreMismatch, expected: ContinuationTokenStoreMismatch, expectedReason: ReasonContinuationTokenStoreMismatch},
+		{err: encoder.ErrMalformedToken, expected: InvalidContinuationToken},
+		{err: errors.New("illegal base64 data"), expected: InvalidContinuationToken},
+	}
+
+	for _, test := range tests {
+		t.Run(test.err.Error(), func(t *testing.T) {
+			err := ContinuationTokenError(test.err)
+			require.Equal(t, test.expected, err)
+
+			st, ok := status.FromError(err)
+			require.True(t, ok)
+			require.Equal(t, codes.Code(openfgav1.ErrorCode_invalid_continuation_token), st.Code())
+
+			if test.expectedReason == "" {
+				require.Empty(t, st.Details())
+				return
+			}
+
+			require.Len(t, st.Details(), 1)
+			errorInfo, ok := st.Details()[0].(*errdetails.ErrorInfo)
+			require.True(t, ok)
+			require.Equal(t, test.expectedReason, errorInfo.GetReason())
+		})
+	}
+}
+
+func TestHandleResidencyErrors(t *testing.T) {
+	tests := []struct {
+		err            error
+		expectedCode   codes.Code
+		expectedReason string
+	}{
+		{
+			err:            fmt.Errorf("%w: 'ap'", storage.ErrUnknownResidency),
+			expectedCode:   codes.Code(openfgav1.ErrorCode_validation_error),
+			expectedReason: ReasonUnknownStoreResidency,
+		},
+		{
+			err:            fmt.Errorf("%w: the store resides in the region 'us'", storage.ErrCrossRegionRead),
+			expectedCode:   codes.FailedPrecondition,
+			expectedReason: ReasonCrossRegionRead,
+		},
+		{
+			err:            fmt.Errorf("%w: the datastore operations of the store fail too often", storage.ErrStoreUnavailable),
+			expectedCode:   codes.Unavailable,
+			expectedReason: ReasonStoreUnavailable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expectedReason, func(t *testing.T) {
+			st, ok := status.FromError(HandleError(context.Background(), "", test.err))
+			require.True(t, ok)
+			require.Equal(t, test.expectedCode, st.Code())
+			require.Equal(t, test.err.Error(), st.Message())
+
+			var errorInfo *errdetails.ErrorInfo
+			for _, detail := range st.Details() {
+				if info, ok := detail.(*errdetails.ErrorInfo); ok {
+					errorInfo = info
+				}
+			}
+			require.NotNil(t, errorInfo)
+			require.Equal(t, test.expectedReason, errorInfo.GetReason())
+		})
+	}
+}
+
+type headerCapturingStream struct {
+	grpc.ServerTransportStream
+	header  metadata.MD
+	trailer metadata.MD
+}
+
+func (s *headerCapturingStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *headerCapturingStream) SetTrailer(md metadata.MD) error {
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
+
+func TestStoreUnavailableHints(t *testing.T) {
+	stream := &headerCapturingStream{}
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+
+	err := HandleError(ctx, "", fmt.Errorf("read failed: %w", &storage.StoreUnavailableError{Store: "store", RetryAfter: 2500 * time.Millisecond}))
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.Unavailable, st.Code())
+
+	var retryInfo *errdetails.RetryInfo
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retryInfo = info
+		}
+	}
+	require.NotNil(t, retryInfo)
+	require.Equal(t, 3*time.Second, retryInfo.GetRetryDelay().AsDuration())
+
+	require.Equal(t, []string{"3"}, stream.header.Get(loadshed.RetryAfterHeader))
+	require.Equal(t, []string{string(loadshed.ReasonStoreUnavailable)}, stream.trailer.Get(loadshed.LoadHintHeader))
+}
+
 func TestInternalErrorsWithNoMessageReturnsInternalServiceError(t *testing.T) {
 	err := NewInternalError("", errors.New("internal"))
 
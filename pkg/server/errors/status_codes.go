@@ -0,0 +1,158 @@
+package errors
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorClassDomain is the domain of the google.rpc.ErrorInfo details that name the class of the errors whose
+// gRPC code is overridden. It's not errorInfoDomain, so that the class isn't mistaken for the reason of the
+// error.
+const errorClassDomain = "openfga.dev/error-class"
+
+// StatusCodes are the gRPC and HTTP status codes of the errors of a class.
+type StatusCodes struct {
+	GRPC codes.Code
+	HTTP int
+}
+
+// statusCodeOverrides holds the status codes of the classes of errors whose codes are overridden, keyed by
+// their encoded error code.
+var statusCodeOverrides atomic.Pointer[map[int32]StatusCodes]
+
+// ParseStatusCodeOverrides parses overrides of the status codes of classes of errors, of the form
+// '<class>=<gRPC code>:<HTTP status>', e.g. 'resource_exhausted=UNAVAILABLE:503' to report the requests shed
+// by the server as unavailable rather than as throttled. The class is the name of an encoded error code (e.g.
+// 'resource_exhausted', 'deadline_exceeded' or 'validation_error'), and the gRPC code is the name of a gRPC
+// status code.
+func ParseStatusCodeOverrides(overrides []string) (map[int32]StatusCodes, error) {
+	parsed := make(map[int32]StatusCodes, len(overrides))
+	for _, override := range overrides {
+		class, statusCodes, ok := strings.Cut(override, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid status code override '%s': expected '<class>=<gRPC code>:<HTTP status>'", override)
+		}
+
+		code, ok := encodedErrorCodeOfClass(class)
+		if !ok {
+			return nil, fmt.Errorf("invalid status code override '%s': unknown error class '%s'", override, class)
+		}
+
+		grpcCode, httpStatus, ok := strings.Cut(statusCodes, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid status code override '%s': expected '<class>=<gRPC code>:<HTTP status>'", override)
+		}
+
+		var parsedCodes StatusCodes
+		if err := parsedCodes.GRPC.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(grpcCode)))); err != nil {
+			return nil, fmt.Errorf("invalid status code override '%s': unknown gRPC code '%s'", override, grpcCode)
+		}
+
+		var err error
+		if parsedCodes.HTTP, err = strconv.Atoi(httpStatus); err != nil || http.StatusText(parsedCodes.HTTP) == "" {
+			return nil, fmt.Errorf("invalid status code override '%s': unknown HTTP status '%s'", override, httpStatus)
+		}
+
+		parsed[code] = parsedCodes
+	}
+
+	return parsed, nil
+}
+
+// SetStatusCodeOverrides overrides the status codes of the classes of errors (see ParseStatusCodeOverrides),
+// replacing the overrides set before. The HTTP status codes apply to the errors encoded by NewEncodedError,
+// and the gRPC codes to the ones passed through WithStatusCodeOverride.
+func SetStatusCodeOverrides(overrides map[int32]StatusCodes) {
+	statusCodeOverrides.Store(&overrides)
+}
+
+func statusCodeOverride(code int32) (StatusCodes, bool) {
+	overrides := statusCodeOverrides.Load()
+	if overrides == nil {
+		return StatusCodes{}, false
+	}
+
+	override, ok := (*overrides)[code]
+	return override, ok
+}
+
+// WithStatusCodeOverride returns the error with the gRPC code of its class, if it's overridden. The class is
+// added to the status as a google.rpc.ErrorInfo detail, so that ConvertToEncodedErrorCode, e.g. in the HTTP
+// gateway, still finds the class of the error rather than the one of the overridden code.
+func WithStatusCodeOverride(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	st := status.Convert(err)
+	code := ConvertToEncodedErrorCode(st)
+
+	override, ok := statusCodeOverride(code)
+	if !ok || override.GRPC == st.Code() {
+		return err
+	}
+
+	proto := st.Proto()
+	proto.Code = int32(override.GRPC)
+	overridden := status.FromProto(proto)
+
+	withClass, detailsErr := overridden.WithDetails(&errdetails.ErrorInfo{
+		Reason: encodedErrorCodeName(code),
+		Domain: errorClassDomain,
+	})
+	if detailsErr != nil {
+		return overridden.Err()
+	}
+
+	return withClass.Err()
+}
+
+// errorClassOf returns the encoded error code of the class named by the ErrorInfo detail of the status, if
+// it has one (see WithStatusCodeOverride).
+func errorClassOf(st *status.Status) (int32, bool) {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetDomain() == errorClassDomain {
+			return encodedErrorCodeOfClass(info.GetReason())
+		}
+	}
+
+	return 0, false
+}
+
+// encodedErrorCodeOfClass returns the encoded error code of the name, e.g. 'resource_exhausted'.
+func encodedErrorCodeOfClass(class string) (int32, bool) {
+	for _, values := range []map[string]int32{
+		openfgav1.AuthErrorCode_value,
+		openfgav1.ErrorCode_value,
+		openfgav1.InternalErrorCode_value,
+		openfgav1.NotFoundErrorCode_value,
+	} {
+		if code, ok := values[class]; ok && IsValidEncodedError(code) {
+			return code, true
+		}
+	}
+
+	return 0, false
+}
+
+// encodedErrorCodeName returns the name of the encoded error code, e.g. 'resource_exhausted'.
+func encodedErrorCodeName(code int32) string {
+	switch {
+	case code >= cFirstAuthenticationErrorCode && code < cFirstValidationErrorCode:
+		return openfgav1.AuthErrorCode(code).String()
+	case code >= cFirstValidationErrorCode && code < cFirstInternalErrorCode:
+		return openfgav1.ErrorCode(code).String()
+	case code >= cFirstInternalErrorCode && code < cFirstUnknownEndpointErrorCode:
+		return openfgav1.InternalErrorCode(code).String()
+	default:
+		return openfgav1.NotFoundErrorCode(code).String()
+	}
+}
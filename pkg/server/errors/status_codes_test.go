@@ -0,0 +1,86 @@
+package errors
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseStatusCodeOverrides(t *testing.T) {
+	overrides, err := ParseStatusCodeOverrides([]string{"resource_exhausted=UNAVAILABLE:503", "deadline_exceeded=unavailable:504"})
+	require.NoError(t, err)
+	require.Equal(t, map[int32]StatusCodes{
+		int32(openfgav1.InternalErrorCode_resource_exhausted): {GRPC: codes.Unavailable, HTTP: http.StatusServiceUnavailable},
+		int32(openfgav1.InternalErrorCode_deadline_exceeded):  {GRPC: codes.Unavailable, HTTP: http.StatusGatewayTimeout},
+	}, overrides)
+
+	for _, invalid := range []string{
+		"resource_exhausted",
+		"throttled=UNAVAILABLE:503",
+		"no_error=UNAVAILABLE:503",
+		"resource_exhausted=503",
+		"resource_exhausted=THROTTLED:503",
+		"resource_exhausted=UNAVAILABLE:799",
+	} {
+		_, err := ParseStatusCodeOverrides([]string{invalid})
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestStatusCodeOverrides(t *testing.T) {
+	overrides, err := ParseStatusCodeOverrides([]string{"resource_exhausted=UNAVAILABLE:503"})
+	require.NoError(t, err)
+
+	SetStatusCodeOverrides(overrides)
+	t.Cleanup(func() {
+		SetStatusCodeOverrides(nil)
+	})
+
+	t.Run("encoded_error", func(t *testing.T) {
+		encoded := NewEncodedError(int32(openfgav1.InternalErrorCode_resource_exhausted), "too many requests")
+		require.Equal(t, http.StatusServiceUnavailable, encoded.HTTPStatus())
+		require.Equal(t, codes.Unavailable, encoded.GRPCStatus().Code())
+		require.Equal(t, "resource_exhausted", encoded.Code())
+
+		encoded = NewEncodedError(int32(openfgav1.InternalErrorCode_unavailable), "maintenance")
+		require.Equal(t, http.StatusServiceUnavailable, encoded.HTTPStatus())
+		require.Equal(t, codes.Unavailable, encoded.GRPCStatus().Code())
+	})
+
+	t.Run("grpc_code", func(t *testing.T) {
+		err := WithStatusCodeOverride(status.Error(codes.ResourceExhausted, "too many requests"))
+
+		st := status.Convert(err)
+		require.Equal(t, codes.Unavailable, st.Code())
+		require.Equal(t, "too many requests", st.Message())
+
+		// the class of the error is kept, e.g. for the HTTP gateway
+		require.Equal(t, int32(openfgav1.InternalErrorCode_resource_exhausted), ConvertToEncodedErrorCode(st))
+	})
+
+	t.Run("error_with_reason", func(t *testing.T) {
+		reasonOverrides, err := ParseStatusCodeOverrides([]string{"failed_precondition=UNAVAILABLE:503"})
+		require.NoError(t, err)
+		SetStatusCodeOverrides(reasonOverrides)
+		t.Cleanup(func() {
+			SetStatusCodeOverrides(overrides)
+		})
+
+		st := status.Convert(WithStatusCodeOverride(CrossRegionRead(errors.New("cross region read"))))
+		require.Equal(t, codes.Unavailable, st.Code())
+		require.Equal(t, int32(openfgav1.InternalErrorCode_failed_precondition), ConvertToEncodedErrorCode(st))
+		require.Equal(t, ReasonCrossRegionRead, st.Details()[0].(*errdetails.ErrorInfo).GetReason())
+	})
+
+	t.Run("not_overridden", func(t *testing.T) {
+		original := status.Error(codes.DeadlineExceeded, "deadline exceeded")
+		require.Equal(t, original, WithStatusCodeOverride(original))
+		require.NoError(t, WithStatusCodeOverride(nil))
+	})
+}
@@ -0,0 +1,23 @@
+package server
+
+import (
+	"context"
+
+	"github.com/openfga/openfga/pkg/events"
+)
+
+// WithEventPublisher sets the publisher of the domain events of the server, e.g. an events.Bus to which the
+// caches and the audit log subscribe. By default, the events are discarded.
+func WithEventPublisher(publisher events.Publisher) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.eventPublisher = publisher
+	}
+}
+
+func (s *Server) publishEvent(ctx context.Context, event events.Event) {
+	if s.eventPublisher == nil {
+		return
+	}
+
+	s.eventPublisher.Publish(ctx, event)
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"google.golang.org/grpc/metadata"
+)
+
+// TupleExpirationsHeader is the Write request header that carries the times the tuples written expire at, as a
+// JSON array of '{"tuple_key": {...}, "expires_at": "2023-10-02T10:00:00Z"}' objects. The expired tuples are
+// treated as deleted.
+const TupleExpirationsHeader = "openfga-tuple-expirations"
+
+// WithTupleExpirationBackend sets the backend of the tuples that expire. It defaults to the datastore if its
+// tuples may expire (see storage.As). The TupleExpirationsHeader header is rejected without a backend.
+func WithTupleExpirationBackend(backend storage.TupleExpirationBackend) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.expirationBackend = backend
+	}
+}
+
+// tupleExpirationEntry is an entry of the TupleExpirationsHeader header.
+type tupleExpirationEntry struct {
+	TupleKey  *tupleConditionKey `json:"tuple_key"`
+	ExpiresAt time.Time          `json:"expires_at"`
+}
+
+// requestTupleExpirations returns the expirations of the TupleExpirationsHeader header of a Write request, if
+// any, keyed by the tuple.TupleKeyToString of their tuple.
+func (s *Server) requestTupleExpirations(ctx context.Context) (map[string]time.Time, error) {
+	values := metadata.ValueFromIncomingContext(ctx, TupleExpirationsHeader)
+	if len(values) == 0 || values[0] == "" {
+		return nil, nil
+	}
+
+	if s.expirationBackend == nil {
+		return nil, serverErrors.ValidationError(fmt.Errorf("the '%s' header isn't supported by the datastore", TupleExpirationsHeader))
+	}
+
+	var entries []*tupleExpirationEntry
+	if err := json.Unmarshal([]byte(values[0]), &entries); err != nil {
+		return nil, serverErrors.ValidationError(fmt.Errorf("invalid '%s' header: %w", TupleExpirationsHeader, err))
+	}
+
+	expirations := make(map[string]time.Time, len(entries))
+	for _, entry := range entries {
+		if entry.TupleKey == nil || entry.ExpiresAt.IsZero() {
+			return nil, serverErrors.ValidationError(fmt.Errorf("invalid '%s' header: every entry must have a tuple key and an expiration time", TupleExpirationsHeader))
+		}
+
+		key := tuple.TupleKeyToString(tuple.NewTupleKey(entry.TupleKey.Object, entry.TupleKey.Relation, entry.TupleKey.User))
+		expirations[key] = entry.ExpiresAt
+	}
+
+	return expirations, nil
+}
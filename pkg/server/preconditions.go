@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"google.golang.org/grpc/metadata"
+)
+
+// WritePreconditionsHeader is the Write request header that carries the preconditions the write only applies
+// under, as a JSON array of '{"tuple_key": {...}, "exists": true}' objects: each tuple must exist, or must not
+// exist if 'exists' is false, as the write applies. The writes whose preconditions don't hold fail with the
+// 'precondition_failed' reason, and aren't applied.
+const WritePreconditionsHeader = "openfga-write-preconditions"
+
+// WithPreconditionsBackend sets the backend of the writes under preconditions. It defaults to the datastore if
+// it can write under preconditions (see storage.As). The WritePreconditionsHeader header is rejected without a
+// backend.
+func WithPreconditionsBackend(backend storage.PreconditionsBackend) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.preconditionsBackend = backend
+	}
+}
+
+// writePreconditionEntry is an entry of the WritePreconditionsHeader header.
+type writePreconditionEntry struct {
+	TupleKey *tupleConditionKey `json:"tuple_key"`
+	Exists   bool               `json:"exists"`
+}
+
+// requestWritePreconditions returns the preconditions of the WritePreconditionsHeader header of a Write request,
+// if any.
+func (s *Server) requestWritePreconditions(ctx context.Context) ([]storage.Precondition, error) {
+	values := metadata.ValueFromIncomingContext(ctx, WritePreconditionsHeader)
+	if len(values) == 0 || values[0] == "" {
+		return nil, nil
+	}
+
+	if s.preconditionsBackend == nil {
+		return nil, serverErrors.ValidationError(fmt.Errorf("the '%s' header isn't supported by the datastore", WritePreconditionsHeader))
+	}
+
+	var entries []*writePreconditionEntry
+	if err := json.Unmarshal([]byte(values[0]), &entries); err != nil {
+		return nil, serverErrors.ValidationError(fmt.Errorf("invalid '%s' header: %w", WritePreconditionsHeader, err))
+	}
+
+	preconditions := make([]storage.Precondition, 0, len(entries))
+	for _, entry := range entries {
+		if entry.TupleKey == nil {
+			return nil, serverErrors.ValidationError(fmt.Errorf("invalid '%s' header: every entry must have a tuple key", WritePreconditionsHeader))
+		}
+
+		preconditions = append(preconditions, storage.Precondition{
+			TupleKey: tuple.NewTupleKey(entry.TupleKey.Object, entry.TupleKey.Relation, entry.TupleKey.User),
+			Exists:   entry.Exists,
+		})
+	}
+
+	return preconditions, nil
+}
@@ -0,0 +1,277 @@
+package server
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/telemetry"
+	"github.com/openfga/openfga/pkg/tuple"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultRelationUsageWindow       = 24 * time.Hour
+	defaultRelationUsageMaxRelations = 10_000
+
+	// relationUsageBuckets is the number of buckets the window of a RelationUsageTracker is divided into. The
+	// counts of a bucket are forgotten at once, when the bucket leaves the window.
+	relationUsageBuckets = 24
+)
+
+// RelationUsageCounts are the numbers of Checks of a relation and of tuples written with it.
+type RelationUsageCounts struct {
+	Checks uint64 `json:"checks"`
+	Writes uint64 `json:"writes"`
+}
+
+type relationUsageKey struct {
+	storeID    string
+	objectType string
+	relation   string
+}
+
+// relationUsageBucket holds the counts of the relations over one bucket of the window.
+type relationUsageBucket struct {
+	start  time.Time
+	counts map[relationUsageKey]RelationUsageCounts
+}
+
+// RelationUsageTracker counts the Checks of every relation of every store, and the tuples written with
+// it, over a rolling window, so that the relations that are neither checked nor written can be found before
+// they're removed from a model. It's safe for concurrent use.
+//
+// The window is divided into buckets, and the counts of the oldest bucket are forgotten when it leaves the
+// window, so that the counts cover between the window and the window minus a bucket. The number of relations
+// tracked is bounded: once the limit is reached, the relations that aren't tracked yet are ignored until
+// some tracked relations leave the window.
+type RelationUsageTracker struct {
+	mu      sync.Mutex
+	buckets []*relationUsageBucket
+	tracked map[relationUsageKey]int
+
+	window       time.Duration
+	maxRelations int
+	now          func() time.Time
+}
+
+// RelationUsageTrackerOpt defines an option that can be used to change the behavior of a
+// RelationUsageTracker.
+type RelationUsageTrackerOpt func(*RelationUsageTracker)
+
+// WithRelationUsageWindow sets the duration of the window over which the relations are counted.
+func WithRelationUsageWindow(window time.Duration) RelationUsageTrackerOpt {
+	return func(t *RelationUsageTracker) {
+		t.window = window
+	}
+}
+
+// WithRelationUsageMaxRelations sets the maximum number of relations, across all the stores, that are
+// tracked.
+func WithRelationUsageMaxRelations(max int) RelationUsageTrackerOpt {
+	return func(t *RelationUsageTracker) {
+		t.maxRelations = max
+	}
+}
+
+// NewRelationUsageTracker constructs a RelationUsageTracker.
+func NewRelationUsageTracker(opts ...RelationUsageTrackerOpt) *RelationUsageTracker {
+	t := &RelationUsageTracker{
+		tracked:      map[relationUsageKey]int{},
+		window:       defaultRelationUsageWindow,
+		maxRelations: defaultRelationUsageMaxRelations,
+		now:          time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+// Window returns the duration of the window over which the relations are counted.
+func (t *RelationUsageTracker) Window() time.Duration {
+	return t.window
+}
+
+// RecordCheck records a Check of the relation of the object type.
+func (t *RelationUsageTracker) RecordCheck(storeID, objectType, relation string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.record(relationUsageKey{storeID: storeID, objectType: objectType, relation: relation}, func(counts *RelationUsageCounts) {
+		counts.Checks++
+	})
+}
+
+// RecordWrites records the tuples written to the store.
+func (t *RelationUsageTracker) RecordWrites(storeID string, tupleKeys []*openfgav1.TupleKey) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, tk := range tupleKeys {
+		key := relationUsageKey{storeID: storeID, objectType: tuple.GetType(tk.GetObject()), relation: tk.GetRelation()}
+		t.record(key, func(counts *RelationUsageCounts) {
+			counts.Writes++
+		})
+	}
+}
+
+// Usage returns the counts of the relations of the store over the window, keyed by object type and then by
+// relation. The relations that were neither checked nor written are missing.
+func (t *RelationUsageTracker) Usage(storeID string) map[string]map[string]RelationUsageCounts {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.expire(t.now())
+
+	usage := map[string]map[string]RelationUsageCounts{}
+	for _, bucket := range t.buckets {
+		for key, counts := range bucket.counts {
+			if key.storeID != storeID {
+				continue
+			}
+
+			relations, ok := usage[key.objectType]
+			if !ok {
+				relations = map[string]RelationUsageCounts{}
+				usage[key.objectType] = relations
+			}
+
+			total := relations[key.relation]
+			total.Checks += counts.Checks
+			total.Writes += counts.Writes
+			relations[key.relation] = total
+		}
+	}
+
+	return usage
+}
+
+// record applies the increment to the counts of the key in the current bucket. The caller must hold the lock.
+func (t *RelationUsageTracker) record(key relationUsageKey, increment func(*RelationUsageCounts)) {
+	now := t.now()
+	t.expire(now)
+
+	if len(t.buckets) == 0 || now.Sub(t.buckets[len(t.buckets)-1].start) >= t.bucketDuration() {
+		t.buckets = append(t.buckets, &relationUsageBucket{start: now, counts: map[relationUsageKey]RelationUsageCounts{}})
+	}
+	bucket := t.buckets[len(t.buckets)-1]
+
+	counts, ok := bucket.counts[key]
+	if !ok {
+		if _, tracked := t.tracked[key]; !tracked && len(t.tracked) >= t.maxRelations {
+			return
+		}
+		t.tracked[key]++
+	}
+
+	increment(&counts)
+	bucket.counts[key] = counts
+}
+
+// expire forgets the buckets that left the window. The caller must hold the lock.
+func (t *RelationUsageTracker) expire(now time.Time) {
+	expired := 0
+	for _, bucket := range t.buckets {
+		if now.Sub(bucket.start) < t.window {
+			break
+		}
+
+		for key := range bucket.counts {
+			if t.tracked[key]--; t.tracked[key] == 0 {
+				delete(t.tracked, key)
+			}
+		}
+		expired++
+	}
+
+	t.buckets = t.buckets[expired:]
+}
+
+func (t *RelationUsageTracker) bucketDuration() time.Duration {
+	return t.window / relationUsageBuckets
+}
+
+// GetRelationUsageRequest is the request for the usage of the relations of an authorization model of a store.
+type GetRelationUsageRequest struct {
+	StoreID string `json:"store_id"`
+
+	// AuthorizationModelID is the ID of the model whose relations are reported. If empty, the latest model of
+	// the store is used.
+	AuthorizationModelID string `json:"authorization_model_id,omitempty"`
+}
+
+// RelationUsage is the usage of a relation of an authorization model.
+type RelationUsage struct {
+	ObjectType string `json:"object_type"`
+	Relation   string `json:"relation"`
+	RelationUsageCounts
+}
+
+// GetRelationUsageResponse reports the usage of every relation of an authorization model over the window of
+// the RelationUsageTracker, including the relations that are unused, whose counts are 0.
+type GetRelationUsageResponse struct {
+	AuthorizationModelID string `json:"authorization_model_id"`
+
+	// Window is the duration of the window, e.g. '24h0m0s'.
+	Window    string           `json:"window"`
+	Relations []*RelationUsage `json:"relations"`
+}
+
+// GetRelationUsage returns the number of Checks of every relation of an authorization model of a store,
+// and the number of tuples written with it, over the window of the RelationUsageTracker of the server (see
+// WithRelationUsageTracker). The Checks are counted by the relation they're for, and not by the relations
+// their evaluation went through, so a relation that's only used in the rewrites of other relations isn't
+// counted as checked.
+func (s *Server) GetRelationUsage(ctx context.Context, req *GetRelationUsageRequest) (*GetRelationUsageResponse, error) {
+	ctx, span := tracer.Start(ctx, "GetRelationUsage")
+	defer span.End()
+
+	if s.relationUsageTracker == nil {
+		return nil, status.Error(codes.Unimplemented, "the relation usage isn't tracked")
+	}
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		Method:  "GetRelationUsage",
+	})
+	ctx = s.contextWithRequestMetadata(ctx, "GetRelationUsage", req.StoreID)
+
+	typesys, err := s.resolveTypesystem(ctx, req.StoreID, req.AuthorizationModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := s.relationUsageTracker.Usage(req.StoreID)
+
+	resp := &GetRelationUsageResponse{
+		AuthorizationModelID: typesys.GetAuthorizationModelID(),
+		Window:               s.relationUsageTracker.Window().String(),
+		Relations:            []*RelationUsage{},
+	}
+	for _, typeDefinition := range typesys.GetAllTypeDefinitions() {
+		objectType := typeDefinition.GetType()
+		for relation := range typeDefinition.GetRelations() {
+			resp.Relations = append(resp.Relations, &RelationUsage{
+				ObjectType:          objectType,
+				Relation:            relation,
+				RelationUsageCounts: usage[objectType][relation],
+			})
+		}
+	}
+
+	sort.Slice(resp.Relations, func(i, j int) bool {
+		if resp.Relations[i].ObjectType != resp.Relations[j].ObjectType {
+			return resp.Relations[i].ObjectType < resp.Relations[j].ObjectType
+		}
+
+		return resp.Relations[i].Relation < resp.Relations[j].Relation
+	})
+
+	return resp, nil
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRelationUsageTracker(t *testing.T) {
+	now := time.Now()
+	tracker := NewRelationUsageTracker(WithRelationUsageWindow(24*time.Hour), WithRelationUsageMaxRelations(2))
+	tracker.now = func() time.Time { return now }
+
+	tracker.RecordCheck("01", "document", "viewer")
+	tracker.RecordCheck("01", "document", "viewer")
+	tracker.RecordWrites("01", []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")})
+
+	now = now.Add(12 * time.Hour)
+	tracker.RecordWrites("01", []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "editor", "user:anne")})
+
+	// the relations beyond the limit aren't tracked
+	tracker.RecordCheck("02", "document", "viewer")
+	require.Empty(t, tracker.Usage("02"))
+
+	require.Equal(t, map[string]map[string]RelationUsageCounts{
+		"document": {
+			"viewer": {Checks: 2, Writes: 1},
+			"editor": {Writes: 1},
+		},
+	}, tracker.Usage("01"))
+
+	// the counts leave the window with their bucket
+	now = now.Add(12 * time.Hour)
+	require.Equal(t, map[string]map[string]RelationUsageCounts{
+		"document": {"editor": {Writes: 1}},
+	}, tracker.Usage("01"))
+
+	tracker.RecordCheck("02", "document", "viewer")
+	require.Equal(t, map[string]map[string]RelationUsageCounts{
+		"document": {"viewer": {Checks: 1}},
+	}, tracker.Usage("02"))
+}
+
+func TestGetRelationUsage(t *testing.T) {
+	ds := memory.New()
+	defer ds.Close()
+
+	ctx := context.Background()
+	store := ulid.Make().String()
+
+	untracked := MustNewServerWithOpts(WithDatastore(ds))
+	defer untracked.Close()
+
+	_, err := untracked.GetRelationUsage(ctx, &GetRelationUsageRequest{StoreID: store})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+
+	s := MustNewServerWithOpts(WithDatastore(ds), WithRelationUsageTracker(NewRelationUsageTracker()))
+	defer s.Close()
+
+	model, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:       store,
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+		type document
+		  relations
+		    define editor: [user] as self
+		    define owner: [user] as self
+		    define viewer: [user] as self or editor
+		`),
+	})
+	require.NoError(t, err)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: store,
+		Writes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:1", "editor", "user:anne"),
+			tuple.NewTupleKey("document:2", "editor", "user:anne"),
+		}},
+	})
+	require.NoError(t, err)
+
+	_, err = s.Check(ctx, &openfgav1.CheckRequest{
+		StoreId:  store,
+		TupleKey: tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+	})
+	require.NoError(t, err)
+
+	resp, err := s.GetRelationUsage(ctx, &GetRelationUsageRequest{StoreID: store})
+	require.NoError(t, err)
+	require.Equal(t, model.GetAuthorizationModelId(), resp.AuthorizationModelID)
+	require.Equal(t, "24h0m0s", resp.Window)
+	require.Equal(t, []*RelationUsage{
+		{ObjectType: "document", Relation: "editor", RelationUsageCounts: RelationUsageCounts{Writes: 2}},
+		{ObjectType: "document", Relation: "owner"},
+		{ObjectType: "document", Relation: "viewer", RelationUsageCounts: RelationUsageCounts{Checks: 1}},
+	}, resp.Relations)
+}
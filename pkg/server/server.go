@@ -5,28 +5,43 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand"
 	"net/http"
+	"slices"
 	"sort"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
 	"github.com/karlseguin/ccache/v3"
+	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/internal/authn"
+	"github.com/openfga/openfga/internal/cluster"
 	"github.com/openfga/openfga/internal/gateway"
 	"github.com/openfga/openfga/internal/graph"
+	"github.com/openfga/openfga/internal/scheduler"
 	serverconfig "github.com/openfga/openfga/internal/server/config"
 	"github.com/openfga/openfga/internal/utils"
 	"github.com/openfga/openfga/internal/validation"
+	"github.com/openfga/openfga/pkg/cachestats"
 	"github.com/openfga/openfga/pkg/encoder"
+	"github.com/openfga/openfga/pkg/events"
 	"github.com/openfga/openfga/pkg/logger"
 	httpmiddleware "github.com/openfga/openfga/pkg/middleware/http"
+	"github.com/openfga/openfga/pkg/middleware/storemetrics"
 	"github.com/openfga/openfga/pkg/middleware/validator"
+	"github.com/openfga/openfga/pkg/modelvalidation"
+	"github.com/openfga/openfga/pkg/requestcontext"
 	"github.com/openfga/openfga/pkg/server/commands"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/storage/storagewrappers"
+	"github.com/openfga/openfga/pkg/storefile"
 	"github.com/openfga/openfga/pkg/telemetry"
+	"github.com/openfga/openfga/pkg/tuple"
 	"github.com/openfga/openfga/pkg/typesystem"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -34,17 +49,54 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 type ExperimentalFeatureFlag string
 
+const (
+	// ExperimentalComputedRelationClosure enables the precomputation of purely computed userset chains
+	// in authorization models so that Check can resolve them in a single hop.
+	ExperimentalComputedRelationClosure ExperimentalFeatureFlag = "computed-relation-closure"
+)
+
 const (
 	AuthorizationModelIDHeader = "openfga-authorization-model-id"
 	authorizationModelIDKey    = "authorization_model_id"
+
+	// AuthorizationModelUnchangedHeader is the WriteAuthorizationModel response header that tells, when it's
+	// 'true', that the model written was identical to the latest model of the store, whose ID is returned
+	// instead of the one of a new model.
+	AuthorizationModelUnchangedHeader = "openfga-authorization-model-unchanged"
+
+	// AuthorizationModelLabelsHeader is the WriteAuthorizationModel request header that lists the labels to
+	// attach to the model written, as comma-separated 'key=value' pairs (e.g. 'release=42,git_sha=3f2a9c1').
+	AuthorizationModelLabelsHeader = "openfga-authorization-model-labels"
+
+	// AuthorizationModelLabelHeader is the request header that pins the requests without an authorization
+	// model ID to the latest model of the store with the label (e.g. 'release=42'), instead of the latest
+	// model of the store.
+	AuthorizationModelLabelHeader = "openfga-authorization-model-label"
+
+	// InlineAuthorizationModelHeader is the Check and ListObjects request header that carries an authorization
+	// model, as the JSON encoding of its schema version and type definitions, to evaluate the request against,
+	// without persisting it, instead of against a model of the store (see WithInlineModelsEnabled).
+	InlineAuthorizationModelHeader = "openfga-inline-authorization-model"
+
+	// ContextualTuplesHeader is the Expand and Read request header that carries contextual tuples, as the JSON
+	// encoding of a ContextualTupleKeys message, to expand or read as if they were written, e.g. to preview the
+	// tree of a relation with hypothetical tuples before writing them.
+	ContextualTuplesHeader = "openfga-contextual-tuples"
+
+	// StoreResidencyHeader is the CreateStore request header that names the region the data of the new store
+	// must reside in, when the datastore pins the stores to their region. The CreateStore and GetStore
+	// responses report the region of the store in the same header.
+	StoreResidencyHeader = "openfga-store-residency"
 )
 
 var tracer = otel.Tracer("openfga/pkg/server")
@@ -71,6 +123,15 @@ var (
 		NativeHistogramMaxBucketNumber:  100,
 		NativeHistogramMinResetDuration: time.Hour,
 	}, []string{"grpc_service", "grpc_method", "datastore_query_count"})
+
+	storeDatastoreQueryCountHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:                            "store_datastore_query_count",
+		Help:                            "The number of database queries required to resolve a query (e.g. Check or ListObjects), labeled by store. Stores that are neither allowlisted nor among the busiest are labeled 'other'.",
+		Buckets:                         []float64{1, 5, 20, 50, 100, 150, 225, 400, 500, 750, 1000},
+		NativeHistogramBucketFactor:     1.1,
+		NativeHistogramMaxBucketNumber:  100,
+		NativeHistogramMinResetDuration: time.Hour,
+	}, []string{"grpc_service", "grpc_method", "store_id"})
 )
 
 // A Server implements the OpenFGA service backend as both
@@ -78,27 +139,84 @@ var (
 type Server struct {
 	openfgav1.UnimplementedOpenFGAServiceServer
 
-	logger                           logger.Logger
-	datastore                        storage.OpenFGADatastore
-	encoder                          encoder.Encoder
-	transport                        gateway.Transport
-	resolveNodeLimit                 uint32
-	resolveNodeBreadthLimit          uint32
-	changelogHorizonOffset           int
-	listObjectsDeadline              time.Duration
-	listObjectsMaxResults            uint32
-	maxConcurrentReadsForListObjects uint32
-	maxConcurrentReadsForCheck       uint32
-	maxAuthorizationModelSizeInBytes int
-	experimentals                    []ExperimentalFeatureFlag
+	logger                             logger.Logger
+	datastore                          storage.OpenFGADatastore
+	encoder                            encoder.Encoder
+	transport                          gateway.Transport
+	resolveNodeLimit                   uint32
+	resolveNodeBreadthLimit            uint32
+	changelogHorizonOffset             int
+	listObjectsDeadline                time.Duration
+	listObjectsMaxResults              uint32
+	maxConcurrentReadsForListObjects   uint32
+	maxConcurrentReadsForCheck         uint32
+	checkUsersetBatchSize              uint32
+	statsProvider                      storage.StatsProvider
+	conditionsBackend                  storage.ConditionsBackend
+	expirationBackend                  storage.TupleExpirationBackend
+	preconditionsBackend               storage.PreconditionsBackend
+	readGuardrails                     commands.ReadGuardrails
+	maxAuthorizationModelSizeInBytes   int
+	assertionsCopyForward              bool
+	identicalModelsSkipped             bool
+	rejectSelfReferentialTuples        bool
+	authorizationModelValidator        modelvalidation.Validator
+	authorizationModelNamingPolicy     *typesystem.NamingPolicy
+	wildcardsDisallowed                bool
+	intersectionAndExclusionDisallowed bool
+	experimentals                      []ExperimentalFeatureFlag
+	inlineModelsEnabled                bool
+	inlineModelMaxSizeInBytes          int
+	relationUsageTracker               *RelationUsageTracker
+	maxChecksPerBatchCheck             int
+	maxConcurrentChecksPerBatchCheck   int
+	batchCheckMaxDatastoreQueries      uint32
 
 	typesystemResolver typesystem.TypesystemResolverFunc
-
-	checkOptions           []graph.LocalCheckerOption
-	checkQueryCacheEnabled bool
-	checkQueryCacheLimit   uint32
-	checkQueryCacheTTL     time.Duration
-	checkCache             *ccache.Cache[*graph.CachedResolveCheckResponse] // checkCache has to be shared across requests
+	typesystemOpts     []typesystem.TypeSystemOption
+
+	checkOptions                       []graph.LocalCheckerOption
+	inlineModelCheckOptions            []graph.LocalCheckerOption
+	clusterDispatcher                  *cluster.Dispatcher
+	checkQueryCacheEnabled             bool
+	checkQueryCacheLimit               uint32
+	checkQueryCacheTTL                 time.Duration
+	checkQueryCacheHotKeyQPSThreshold  float64
+	checkQueryCacheHotKeyTTLMultiplier uint32
+	checkQueryCacheNegativeLimit       uint32
+	checkQueryCacheNegativeTTL         time.Duration
+	checkCache                         *ccache.Cache[*graph.CachedResolveCheckResponse] // checkCache has to be shared across requests
+	checkNegativeCache                 *ccache.Cache[*graph.CachedResolveCheckResponse] // checkNegativeCache has to be shared across requests
+	checkCacheOptions                  []graph.CachedCheckResolverOpt
+	checkCacheInvalidator              *graph.CheckCacheInvalidator
+	checkCacheCounters                 cachestats.Counters
+	checkNegativeCacheCounters         cachestats.Counters
+	checkQueryCacheChangelogInterval   time.Duration
+	checkSharedCache                   graph.SharedCheckCache
+	checkCacheChangelogTailer          *checkCacheChangelogTailer
+	hotKeyTracker                      *graph.HotKeyTracker // hotKeyTracker has to be shared across requests
+
+	storeLabeler *storemetrics.StoreLabeler
+
+	checkProfileSink             graph.ProfileSink
+	checkProfileLatencyThreshold time.Duration
+	checkProfileSampleRate       float64
+
+	shadowCheckCandidates     *ShadowCheckCandidates
+	shadowCheckSampleRate     float64
+	shadowCheckTimeout        time.Duration
+	shadowCheckMaxConcurrency uint32
+	shadowCheckLimiter        chan struct{}
+	shadowCheckWG             sync.WaitGroup
+	shadowCheckRandom         func() float64
+
+	eventPublisher events.Publisher
+
+	datastoreMaintainer           storage.Maintainer
+	datastoreMaintenanceSchedule  string
+	datastoreMaintenanceJitter    time.Duration
+	datastoreMaintenanceTasks     []string
+	datastoreMaintenanceScheduler *scheduler.Scheduler
 
 	requestDurationByQueryHistogramBuckets []uint
 }
@@ -195,6 +313,33 @@ func WithMaxConcurrentReadsForCheck(max uint32) OpenFGAServiceV1Option {
 	}
 }
 
+// WithCheckUsersetBatchSize sets the maximum number of usersets of a relation (e.g. 'team:1#member', 'team:2#member')
+// whose membership is looked up in a single datastore query when resolving a Check, rather than with a query for each
+// userset. Only the relations that can only be satisfied by direct relationships with users are batched. A size of 0
+// disables batching.
+func WithCheckUsersetBatchSize(size uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkUsersetBatchSize = size
+	}
+}
+
+// WithStatsProvider sets the statistics of the tuples used to estimate the cost of evaluating the operands of
+// intersections, so that the cheapest ones are evaluated first. It defaults to the datastore if it maintains
+// statistics, which may be hidden by the datastore wrappers.
+func WithStatsProvider(stats storage.StatsProvider) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.statsProvider = stats
+	}
+}
+
+// WithReadGuardrails rejects the overly broad Read requests, e.g. the ones without a tuple key, which would scan
+// every tuple of a store. The statistics of the stores tell which stores are large (see WithStatsProvider).
+func WithReadGuardrails(guardrails commands.ReadGuardrails) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.readGuardrails = guardrails
+	}
+}
+
 func WithExperimentals(experimentals ...ExperimentalFeatureFlag) OpenFGAServiceV1Option {
 	return func(s *Server) {
 		s.experimentals = experimentals
@@ -215,6 +360,46 @@ func WithCheckQueryCacheLimit(limit uint32) OpenFGAServiceV1Option {
 	}
 }
 
+// WithCheckQueryCacheHotKeyQPSThreshold sets the Check QPS above which a relation is considered hot.
+// Check results of hot relations are cached for longer and their concurrent evaluations are collapsed.
+// A threshold of 0 disables hot key detection.
+func WithCheckQueryCacheHotKeyQPSThreshold(qps float64) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkQueryCacheHotKeyQPSThreshold = qps
+	}
+}
+
+// WithCheckQueryCacheHotKeyTTLMultiplier sets the factor by which the TTL of cached checks of hot relations is multiplied
+func WithCheckQueryCacheHotKeyTTLMultiplier(multiplier uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkQueryCacheHotKeyTTLMultiplier = multiplier
+	}
+}
+
+// WithCheckQueryCacheNegativeLimit sets the size limit (in items) of a separate cache for the negative check
+// results. If 0, they share the cache of the positive results
+func WithCheckQueryCacheNegativeLimit(limit uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkQueryCacheNegativeLimit = limit
+	}
+}
+
+// WithCheckQueryCacheNegativeTTL sets the TTL of cached negative check results. If 0, it's the TTL of the
+// positive results
+func WithCheckQueryCacheNegativeTTL(ttl time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkQueryCacheNegativeTTL = ttl
+	}
+}
+
+// WithClusterDispatcher enables the clustering mode, in which the Check sub-problems are dispatched to the
+// member of the cluster that owns them (see package cluster).
+func WithClusterDispatcher(dispatcher *cluster.Dispatcher) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.clusterDispatcher = dispatcher
+	}
+}
+
 // WithCheckQueryCacheTTL sets the TTL of cached checks and list objects partial results
 func WithCheckQueryCacheTTL(ttl time.Duration) OpenFGAServiceV1Option {
 	return func(s *Server) {
@@ -230,12 +415,190 @@ func WithRequestDurationByQueryHistogramBuckets(buckets []uint) OpenFGAServiceV1
 	}
 }
 
+// WithStoreLabeler enables reporting the store_datastore_query_count metric, labeled by the store_id label
+// values returned by the provided StoreLabeler. It should be the same StoreLabeler as the one used by the
+// storemetrics interceptors.
+func WithStoreLabeler(labeler *storemetrics.StoreLabeler) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.storeLabeler = labeler
+	}
+}
+
+// WithCheckProfileSink enables the profiling of expensive Checks. A fraction of the Checks (see
+// WithCheckProfileSampleRate) are sampled, and the resolution trees of those that take longer than a
+// latency threshold (see WithCheckProfileLatencyThreshold) are written to the provided sink.
+func WithCheckProfileSink(sink graph.ProfileSink) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkProfileSink = sink
+	}
+}
+
+// WithCheckProfileLatencyThreshold sets the latency above which the resolution tree of a sampled Check is
+// written to the check profile sink.
+func WithCheckProfileLatencyThreshold(threshold time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkProfileLatencyThreshold = threshold
+	}
+}
+
+// WithCheckProfileSampleRate sets the fraction (between 0 and 1) of the Checks that are sampled for profiling.
+func WithCheckProfileSampleRate(rate float64) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkProfileSampleRate = rate
+	}
+}
+
+// WithShadowCheckCandidates enables the shadow evaluation of Checks: a fraction of the Checks (see
+// WithShadowCheckSampleRate) of the stores with a candidate authorization model are also evaluated,
+// asynchronously, against that model, and the differences with the served results are logged and counted.
+// This allows for the validation of model changes against production traffic before they are promoted.
+func WithShadowCheckCandidates(candidates *ShadowCheckCandidates) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.shadowCheckCandidates = candidates
+	}
+}
+
+// WithShadowCheckSampleRate sets the fraction (between 0 and 1) of the Checks that are shadow evaluated.
+func WithShadowCheckSampleRate(rate float64) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.shadowCheckSampleRate = rate
+	}
+}
+
+// WithShadowCheckTimeout sets the timeout of the shadow evaluation of a Check.
+func WithShadowCheckTimeout(timeout time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.shadowCheckTimeout = timeout
+	}
+}
+
+// WithShadowCheckMaxConcurrency sets the maximum number of shadow evaluations in flight. Checks that would
+// exceed it are not shadow evaluated.
+func WithShadowCheckMaxConcurrency(max uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.shadowCheckMaxConcurrency = max
+	}
+}
+
 func WithMaxAuthorizationModelSizeInBytes(size int) OpenFGAServiceV1Option {
 	return func(s *Server) {
 		s.maxAuthorizationModelSizeInBytes = size
 	}
 }
 
+// WithAssertionsCopyForward enables copying the assertions of the latest authorization model of a store
+// to every new authorization model written to it. Assertions that are not valid against the new model
+// are dropped.
+func WithAssertionsCopyForward(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.assertionsCopyForward = enabled
+	}
+}
+
+// WithInlineModelsEnabled enables the Check and ListObjects requests with the InlineAuthorizationModelHeader
+// header, which are evaluated against the model it carries, without persisting it. The model is validated
+// like a model written, and the requests are resolved without the check cache and without dispatching their
+// sub-problems to the other members of the cluster, which don't know the model.
+func WithInlineModelsEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.inlineModelsEnabled = enabled
+	}
+}
+
+// WithInlineModelMaxSizeInBytes sets the maximum size of the JSON encoding of the inline models.
+func WithInlineModelMaxSizeInBytes(size int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.inlineModelMaxSizeInBytes = size
+	}
+}
+
+// WithMaxChecksPerBatchCheck sets the maximum number of tuple keys of a BatchCheck call.
+func WithMaxChecksPerBatchCheck(limit int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.maxChecksPerBatchCheck = limit
+	}
+}
+
+// WithMaxConcurrentChecksPerBatchCheck sets the maximum number of tuple keys of a BatchCheck call that are
+// checked concurrently.
+func WithMaxConcurrentChecksPerBatchCheck(limit int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.maxConcurrentChecksPerBatchCheck = limit
+	}
+}
+
+// WithBatchCheckMaxDatastoreQueries sets the budget of datastore queries shared by the Checks of a BatchCheck
+// call. Once it's spent, the Checks left fail. A budget of 0 disables it.
+func WithBatchCheckMaxDatastoreQueries(limit uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.batchCheckMaxDatastoreQueries = limit
+	}
+}
+
+// WithRelationUsageTracker counts the Checks of every relation, and the tuples written with it, with the
+// provided tracker (see GetRelationUsage).
+func WithRelationUsageTracker(tracker *RelationUsageTracker) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.relationUsageTracker = tracker
+	}
+}
+
+// WithIdenticalAuthorizationModelsSkipped returns the ID of the latest authorization model of a store, instead
+// of writing a new model, when the model written is identical to it. The responses then have the
+// AuthorizationModelUnchangedHeader header.
+func WithIdenticalAuthorizationModelsSkipped(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.identicalModelsSkipped = enabled
+	}
+}
+
+// WithSelfReferentialTuplesRejected rejects the writes of the tuples that relate a userset of an object to
+// itself (e.g. 'group:eng#member' as a member of 'group:eng'), or to a relation it's computed from, since
+// such tuples only waste resolution work.
+func WithSelfReferentialTuplesRejected(rejected bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.rejectSelfReferentialTuples = rejected
+	}
+}
+
+// WithAuthorizationModelValidator validates every authorization model written to a store with the provided
+// validator (e.g. to enforce naming conventions), and rejects the ones it rejects with a validation error.
+func WithAuthorizationModelValidator(validator modelvalidation.Validator) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.authorizationModelValidator = validator
+	}
+}
+
+// WithAuthorizationModelNamingPolicy rejects the authorization models written to a store whose type or
+// relation names violate the provided policy (e.g. reserved prefixes or maximum lengths).
+func WithAuthorizationModelNamingPolicy(policy *typesystem.NamingPolicy) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.authorizationModelNamingPolicy = policy
+	}
+}
+
+// WithWildcardsDisallowed rejects the authorization models written to a store whose relations can be
+// directly related to a public wildcard (e.g. '[user:*]').
+func WithWildcardsDisallowed(disallowed bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.wildcardsDisallowed = disallowed
+	}
+}
+
+// WithIntersectionAndExclusionDisallowed rejects the authorization models written to a store whose relations
+// are defined with an intersection ('and') or an exclusion ('but not').
+func WithIntersectionAndExclusionDisallowed(disallowed bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.intersectionAndExclusionDisallowed = disallowed
+	}
+}
+
+// IsExperimentallyEnabled returns true if the provided experimental feature flag was enabled
+// with WithExperimentals.
+func (s *Server) IsExperimentallyEnabled(flag ExperimentalFeatureFlag) bool {
+	return slices.Contains(s.experimentals, flag)
+}
+
 func MustNewServerWithOpts(opts ...OpenFGAServiceV1Option) *Server {
 	s, err := NewServerWithOpts(opts...)
 	if err != nil {
@@ -256,8 +619,13 @@ func NewServerWithOpts(opts ...OpenFGAServiceV1Option) (*Server, error) {
 		listObjectsDeadline:              serverconfig.DefaultListObjectsDeadline,
 		listObjectsMaxResults:            serverconfig.DefaultListObjectsMaxResults,
 		maxConcurrentReadsForCheck:       serverconfig.DefaultMaxConcurrentReadsForCheck,
+		checkUsersetBatchSize:            serverconfig.DefaultCheckUsersetBatchSize,
 		maxConcurrentReadsForListObjects: serverconfig.DefaultMaxConcurrentReadsForListObjects,
 		maxAuthorizationModelSizeInBytes: serverconfig.DefaultMaxAuthorizationModelSizeInBytes,
+		inlineModelMaxSizeInBytes:        serverconfig.DefaultInlineModelMaxSizeInBytes,
+		maxChecksPerBatchCheck:           serverconfig.DefaultBatchCheckMaxChecks,
+		maxConcurrentChecksPerBatchCheck: serverconfig.DefaultBatchCheckMaxConcurrentChecks,
+		batchCheckMaxDatastoreQueries:    serverconfig.DefaultBatchCheckMaxDatastoreQueries,
 		experimentals:                    make([]ExperimentalFeatureFlag, 0, 10),
 
 		checkQueryCacheEnabled: serverconfig.DefaultCheckQueryCacheEnable,
@@ -265,6 +633,16 @@ func NewServerWithOpts(opts ...OpenFGAServiceV1Option) (*Server, error) {
 		checkQueryCacheTTL:     serverconfig.DefaultCheckQueryCacheTTL,
 		checkCache:             nil,
 
+		checkQueryCacheHotKeyQPSThreshold:  serverconfig.DefaultCheckQueryCacheHotKeyQPSThreshold,
+		checkQueryCacheHotKeyTTLMultiplier: serverconfig.DefaultCheckQueryCacheHotKeyTTLMultiplier,
+		checkQueryCacheNegativeLimit:       serverconfig.DefaultCheckQueryCacheNegativeLimit,
+		checkQueryCacheNegativeTTL:         serverconfig.DefaultCheckQueryCacheNegativeTTL,
+
+		shadowCheckSampleRate:     serverconfig.DefaultShadowCheckSampleRate,
+		shadowCheckTimeout:        serverconfig.DefaultShadowCheckTimeout,
+		shadowCheckMaxConcurrency: serverconfig.DefaultShadowCheckMaxConcurrency,
+		shadowCheckRandom:         rand.Float64,
+
 		requestDurationByQueryHistogramBuckets: []uint{50, 200},
 	}
 
@@ -272,22 +650,83 @@ func NewServerWithOpts(opts ...OpenFGAServiceV1Option) (*Server, error) {
 		opt(s)
 	}
 
+	s.shadowCheckLimiter = make(chan struct{}, s.shadowCheckMaxConcurrency)
+
+	if s.statsProvider == nil {
+		s.statsProvider, _ = storage.As[storage.StatsProvider](s.datastore)
+	}
+
+	if s.expirationBackend == nil {
+		s.expirationBackend, _ = storage.As[storage.TupleExpirationBackend](s.datastore)
+	}
+
+	if s.preconditionsBackend == nil {
+		s.preconditionsBackend, _ = storage.As[storage.PreconditionsBackend](s.datastore)
+	}
+
+	if s.conditionsBackend == nil {
+		s.conditionsBackend, _ = storage.As[storage.ConditionsBackend](s.datastore)
+	}
+
 	s.checkOptions = []graph.LocalCheckerOption{
 		graph.WithResolveNodeBreadthLimit(s.resolveNodeBreadthLimit),
 		graph.WithMaxConcurrentReads(s.maxConcurrentReadsForCheck),
+		graph.WithUsersetBatchSize(s.checkUsersetBatchSize),
+		graph.WithStatsProvider(s.statsProvider),
+	}
+
+	// the inline models are only known to the request that carries them
+	s.inlineModelCheckOptions = slices.Clone(s.checkOptions)
+
+	if s.clusterDispatcher != nil {
+		s.checkOptions = append(s.checkOptions, graph.WithDispatcher(s.clusterDispatcher.Wrap))
 	}
 
 	if s.checkQueryCacheEnabled {
 		s.logger.Info("Check query cache is enabled and may lead to stale query results up to the configured query cache TTL",
 			zap.Duration("CheckQueryCacheTTL", s.checkQueryCacheTTL),
-			zap.Uint32("CheckQueryCacheLimit", s.checkQueryCacheLimit))
+			zap.Uint32("CheckQueryCacheLimit", s.checkQueryCacheLimit),
+			zap.Duration("CheckQueryCacheNegativeTTL", s.checkQueryCacheNegativeTTL),
+			zap.Uint32("CheckQueryCacheNegativeLimit", s.checkQueryCacheNegativeLimit))
 		s.checkCache = ccache.New(
 			ccache.Configure[*graph.CachedResolveCheckResponse]().MaxSize(int64(s.checkQueryCacheLimit)),
 		)
-		s.checkOptions = append(s.checkOptions, graph.WithCachedResolver(
+		s.checkCacheOptions = []graph.CachedCheckResolverOpt{
 			graph.WithExistingCache(s.checkCache),
 			graph.WithCacheTTL(s.checkQueryCacheTTL),
-		))
+			graph.WithNegativeCacheTTL(s.checkQueryCacheNegativeTTL),
+			graph.WithCacheCounters(&s.checkCacheCounters, &s.checkNegativeCacheCounters),
+		}
+
+		if s.checkQueryCacheNegativeLimit > 0 {
+			s.checkNegativeCache = ccache.New(
+				ccache.Configure[*graph.CachedResolveCheckResponse]().MaxSize(int64(s.checkQueryCacheNegativeLimit)),
+			)
+			s.checkCacheOptions = append(s.checkCacheOptions, graph.WithExistingNegativeCache(s.checkNegativeCache))
+		}
+
+		s.checkCacheInvalidator = graph.NewCheckCacheInvalidator(s.checkCache, s.checkNegativeCache)
+		s.checkCacheOptions = append(s.checkCacheOptions, graph.WithCacheInvalidator(s.checkCacheInvalidator))
+
+		if s.checkSharedCache != nil {
+			s.checkCacheOptions = append(s.checkCacheOptions, graph.WithSharedCache(s.checkSharedCache))
+		}
+
+		if s.checkQueryCacheHotKeyQPSThreshold > 0 {
+			s.hotKeyTracker = graph.NewHotKeyTracker(
+				graph.WithHotKeyQPSThreshold(s.checkQueryCacheHotKeyQPSThreshold),
+				graph.WithHotKeyTTLMultiplier(s.checkQueryCacheHotKeyTTLMultiplier),
+			)
+			if err := prometheus.Register(s.hotKeyTracker); err != nil {
+				if !errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+					return nil, err
+				}
+			}
+
+			s.checkCacheOptions = append(s.checkCacheOptions, graph.WithHotKeyTracker(s.hotKeyTracker))
+		}
+
+		s.checkOptions = append(s.checkOptions, graph.WithCachedResolver(s.checkCacheOptions...))
 	}
 
 	if s.datastore == nil {
@@ -298,7 +737,21 @@ func NewServerWithOpts(opts ...OpenFGAServiceV1Option) (*Server, error) {
 		return nil, fmt.Errorf("request duration datastore count buckets must not be empty")
 	}
 
-	s.typesystemResolver = typesystem.MemoizedTypesystemResolverFunc(s.datastore)
+	if s.IsExperimentallyEnabled(ExperimentalComputedRelationClosure) {
+		s.typesystemOpts = append(s.typesystemOpts, typesystem.WithComputedRelationClosure())
+	}
+
+	var modelBackend storage.AuthorizationModelReadBackend = s.datastore
+	if s.conditionsBackend != nil {
+		modelBackend = &conditionsModelBackend{s.datastore, s.conditionsBackend}
+	}
+	s.typesystemResolver = typesystem.MemoizedTypesystemResolverFunc(modelBackend, s.typesystemOpts...)
+
+	if err := s.startDatastoreMaintenance(); err != nil {
+		return nil, err
+	}
+
+	s.startCheckCacheChangelogTailer()
 
 	return s, nil
 }
@@ -325,33 +778,22 @@ func (s *Server) ListObjects(ctx context.Context, req *openfgav1.ListObjectsRequ
 		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
 		Method:  methodName,
 	})
+	ctx = s.contextWithRequestMetadata(ctx, methodName, req.GetStoreId())
 
 	storeID := req.GetStoreId()
 
-	typesys, err := s.resolveTypesystem(ctx, storeID, req.GetAuthorizationModelId())
+	typesys, inline, err := s.resolveRequestTypesystem(ctx, storeID, req.GetAuthorizationModelId())
 	if err != nil {
 		return nil, err
 	}
 
-	checkOptions := []graph.LocalCheckerOption{
-		graph.WithResolveNodeBreadthLimit(s.resolveNodeBreadthLimit),
-		graph.WithMaxConcurrentReads(s.maxConcurrentReadsForListObjects),
-	}
-	if s.checkCache != nil {
-		checkOptions = append(checkOptions, graph.WithCachedResolver(
-			graph.WithExistingCache(s.checkCache),
-			graph.WithCacheTTL(s.checkQueryCacheTTL),
-		))
+	conditionContext, err := requestConditionContext(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	q := commands.NewListObjectsQuery(s.datastore,
-		commands.WithLogger(s.logger),
-		commands.WithListObjectsDeadline(s.listObjectsDeadline),
-		commands.WithListObjectsMaxResults(s.listObjectsMaxResults),
-		commands.WithResolveNodeLimit(s.resolveNodeLimit),
-		commands.WithResolveNodeBreadthLimit(s.resolveNodeBreadthLimit),
-		commands.WithCheckOptions(checkOptions),
-		commands.WithMaxConcurrentReads(s.maxConcurrentReadsForListObjects),
+	q := s.newListObjectsQuery(s.conditionsTupleReader(typesys, conditionContext), !inline,
+		commands.WithConditionContext(conditionContext),
 	)
 
 	result, err := q.Execute(
@@ -376,12 +818,37 @@ func (s *Server) ListObjects(ctx context.Context, req *openfgav1.ListObjectsRequ
 		openfgav1.OpenFGAService_ServiceDesc.ServiceName,
 		methodName,
 	).Observe(queryCount)
+	s.observeStoreDatastoreQueryCount(methodName, storeID, queryCount)
 
 	return &openfgav1.ListObjectsResponse{
 		Objects: result.Objects,
 	}, nil
 }
 
+// newListObjectsQuery returns a ListObjectsQuery of the tuples of the reader configured with the ListObjects
+// options of the server, and the opts. The checks of the query are only cached if cached is true.
+func (s *Server) newListObjectsQuery(ds storage.RelationshipTupleReader, cached bool, opts ...commands.ListObjectsQueryOption) *commands.ListObjectsQuery {
+	checkOptions := []graph.LocalCheckerOption{
+		graph.WithResolveNodeBreadthLimit(s.resolveNodeBreadthLimit),
+		graph.WithMaxConcurrentReads(s.maxConcurrentReadsForListObjects),
+		graph.WithUsersetBatchSize(s.checkUsersetBatchSize),
+		graph.WithStatsProvider(s.statsProvider),
+	}
+	if cached && s.checkCache != nil {
+		checkOptions = append(checkOptions, graph.WithCachedResolver(s.checkCacheOptions...))
+	}
+
+	return commands.NewListObjectsQuery(ds, append([]commands.ListObjectsQueryOption{
+		commands.WithLogger(s.logger),
+		commands.WithListObjectsDeadline(s.listObjectsDeadline),
+		commands.WithListObjectsMaxResults(s.listObjectsMaxResults),
+		commands.WithResolveNodeLimit(s.resolveNodeLimit),
+		commands.WithResolveNodeBreadthLimit(s.resolveNodeBreadthLimit),
+		commands.WithCheckOptions(checkOptions),
+		commands.WithMaxConcurrentReads(s.maxConcurrentReadsForListObjects),
+	}, opts...)...)
+}
+
 func (s *Server) StreamedListObjects(req *openfgav1.StreamedListObjectsRequest, srv openfgav1.OpenFGAService_StreamedListObjectsServer) error {
 	ctx := srv.Context()
 	ctx, span := tracer.Start(ctx, "StreamedListObjects", trace.WithAttributes(
@@ -403,33 +870,22 @@ func (s *Server) StreamedListObjects(req *openfgav1.StreamedListObjectsRequest,
 		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
 		Method:  methodName,
 	})
+	ctx = s.contextWithRequestMetadata(ctx, methodName, req.GetStoreId())
 
 	storeID := req.GetStoreId()
 
-	typesys, err := s.resolveTypesystem(ctx, storeID, req.GetAuthorizationModelId())
+	typesys, inline, err := s.resolveRequestTypesystem(ctx, storeID, req.GetAuthorizationModelId())
 	if err != nil {
 		return err
 	}
 
-	checkOptions := []graph.LocalCheckerOption{
-		graph.WithResolveNodeBreadthLimit(s.resolveNodeBreadthLimit),
-		graph.WithMaxConcurrentReads(s.maxConcurrentReadsForListObjects),
-	}
-	if s.checkCache != nil {
-		checkOptions = append(checkOptions, graph.WithCachedResolver(
-			graph.WithExistingCache(s.checkCache),
-			graph.WithCacheTTL(s.checkQueryCacheTTL),
-		))
+	conditionContext, err := requestConditionContext(ctx)
+	if err != nil {
+		return err
 	}
 
-	q := commands.NewListObjectsQuery(s.datastore,
-		commands.WithLogger(s.logger),
-		commands.WithListObjectsDeadline(s.listObjectsDeadline),
-		commands.WithListObjectsMaxResults(s.listObjectsMaxResults),
-		commands.WithResolveNodeLimit(s.resolveNodeLimit),
-		commands.WithResolveNodeBreadthLimit(s.resolveNodeBreadthLimit),
-		commands.WithCheckOptions(checkOptions),
-		commands.WithMaxConcurrentReads(s.maxConcurrentReadsForListObjects),
+	q := s.newListObjectsQuery(s.conditionsTupleReader(typesys, conditionContext), !inline,
+		commands.WithConditionContext(conditionContext),
 	)
 
 	req.AuthorizationModelId = typesys.GetAuthorizationModelID() // the resolved model id
@@ -450,6 +906,7 @@ func (s *Server) StreamedListObjects(req *openfgav1.StreamedListObjectsRequest,
 		openfgav1.OpenFGAService_ServiceDesc.ServiceName,
 		methodName,
 	).Observe(queryCount)
+	s.observeStoreDatastoreQueryCount(methodName, storeID, queryCount)
 
 	return nil
 }
@@ -473,8 +930,17 @@ func (s *Server) Read(ctx context.Context, req *openfgav1.ReadRequest) (*openfga
 		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
 		Method:  "Read",
 	})
+	ctx = s.contextWithRequestMetadata(ctx, "Read", req.GetStoreId())
+
+	contextualTuples, err := s.requestContextualTuples(ctx, req.GetStoreId(), nil)
+	if err != nil {
+		return nil, err
+	}
 
-	q := commands.NewReadQuery(s.datastore, s.logger, s.encoder)
+	q := commands.NewReadQuery(s.datastore, s.logger, s.encoder,
+		commands.WithReadContextualTuples(contextualTuples),
+		commands.WithReadGuardrails(s.readGuardrails, s.statsProvider),
+	)
 	return q.Execute(ctx, &openfgav1.ReadRequest{
 		StoreId:           req.GetStoreId(),
 		TupleKey:          tk,
@@ -497,6 +963,7 @@ func (s *Server) Write(ctx context.Context, req *openfgav1.WriteRequest) (*openf
 		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
 		Method:  "Write",
 	})
+	ctx = s.contextWithRequestMetadata(ctx, "Write", req.GetStoreId())
 
 	storeID := req.GetStoreId()
 
@@ -505,13 +972,54 @@ func (s *Server) Write(ctx context.Context, req *openfgav1.WriteRequest) (*openf
 		return nil, err
 	}
 
-	cmd := commands.NewWriteCommand(s.datastore, s.logger)
-	return cmd.Execute(ctx, &openfgav1.WriteRequest{
+	tupleConditions, err := s.requestTupleConditions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tupleExpirations, err := s.requestTupleExpirations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	preconditions, err := s.requestWritePreconditions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := commands.NewWriteCommand(s.datastore, s.logger,
+		commands.WithSelfReferentialTuplesRejected(s.rejectSelfReferentialTuples),
+		commands.WithWriteTypesystem(typesys),
+		commands.WithWriteHook(s.invalidateCheckCache),
+		commands.WithTupleConditions(s.conditionsBackend, tupleConditions),
+		commands.WithTupleExpirations(s.expirationBackend, tupleExpirations),
+		commands.WithWritePreconditions(s.preconditionsBackend, preconditions),
+	)
+	res, err := cmd.Execute(ctx, &openfgav1.WriteRequest{
 		StoreId:              storeID,
 		AuthorizationModelId: typesys.GetAuthorizationModelID(), // the resolved model id
 		Writes:               req.GetWrites(),
 		Deletes:              req.GetDeletes(),
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.relationUsageTracker != nil {
+		s.relationUsageTracker.RecordWrites(storeID, req.GetWrites().GetTupleKeys())
+	}
+
+	s.publishEvent(ctx, events.Event{
+		Type:                 events.TuplesWritten,
+		StoreID:              storeID,
+		AuthorizationModelID: typesys.GetAuthorizationModelID(),
+		Attributes: map[string]string{
+			"writes":  strconv.Itoa(len(req.GetWrites().GetTupleKeys())),
+			"deletes": strconv.Itoa(len(req.GetDeletes().GetTupleKeys())),
+		},
+	})
+
+	return res, nil
 }
 
 func (s *Server) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openfgav1.CheckResponse, error) {
@@ -535,6 +1043,7 @@ func (s *Server) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openf
 		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
 		Method:  "Check",
 	})
+	ctx = s.contextWithRequestMetadata(ctx, "Check", req.GetStoreId())
 
 	if tk.GetUser() == "" || tk.GetRelation() == "" || tk.GetObject() == "" {
 		return nil, serverErrors.InvalidCheckInput
@@ -542,7 +1051,7 @@ func (s *Server) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openf
 
 	storeID := req.GetStoreId()
 
-	typesys, err := s.resolveTypesystem(ctx, storeID, req.GetAuthorizationModelId())
+	typesys, inline, err := s.resolveRequestTypesystem(ctx, storeID, req.GetAuthorizationModelId())
 	if err != nil {
 		return nil, err
 	}
@@ -553,25 +1062,51 @@ func (s *Server) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openf
 
 	for _, ctxTuple := range req.GetContextualTuples().GetTupleKeys() {
 		if err := validation.ValidateTuple(typesys, ctxTuple); err != nil {
-			return nil, serverErrors.HandleTupleValidateError(err)
+			return nil, serverErrors.HandleTupleValidateError(ctx, err)
 		}
 	}
 
+	conditionContext, err := requestConditionContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx = typesystem.ContextWithTypesystem(ctx, typesys)
 
-	checkResolver := graph.NewLocalChecker(
-		storagewrappers.NewCombinedTupleReader(s.datastore, req.ContextualTuples.GetTupleKeys()),
-		s.checkOptions...,
+	checkOptions := s.checkOptions
+	if inline {
+		checkOptions = s.inlineModelCheckOptions
+	} else if s.relationUsageTracker != nil {
+		s.relationUsageTracker.RecordCheck(storeID, tuple.GetType(tk.GetObject()), tk.GetRelation())
+	}
+
+	var checkResolver graph.CheckResolver = graph.NewLocalChecker(
+		storagewrappers.NewCombinedTupleReader(s.conditionsTupleReader(typesys, conditionContext), req.ContextualTuples.GetTupleKeys()),
+		checkOptions...,
 	)
+	if s.checkProfileSink != nil {
+		checkResolver = graph.NewProfilingCheckResolver(checkResolver, s.checkProfileSink,
+			graph.WithProfileLatencyThreshold(s.checkProfileLatencyThreshold),
+			graph.WithProfileSampleRate(s.checkProfileSampleRate),
+			graph.WithProfileLogger(s.logger),
+		)
+	}
 	defer checkResolver.Close()
 
+	depth := s.resolveNodeLimit
+	if remaining, ok := cluster.DispatchedDepth(ctx); ok && remaining < depth {
+		// a sub-problem dispatched by another member is bounded by the depth remaining to its Check
+		depth = remaining
+	}
+
 	resp, err := checkResolver.ResolveCheck(ctx, &graph.ResolveCheckRequest{
 		StoreID:              req.GetStoreId(),
 		AuthorizationModelID: typesys.GetAuthorizationModelID(), // the resolved model id
 		TupleKey:             req.GetTupleKey(),
 		ContextualTuples:     req.ContextualTuples.GetTupleKeys(),
+		Context:              conditionContext,
 		ResolutionMetadata: &graph.ResolutionMetadata{
-			Depth:               s.resolveNodeLimit,
+			Depth:               depth,
 			DatastoreQueryCount: 0,
 		},
 	})
@@ -580,9 +1115,11 @@ func (s *Server) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openf
 			return nil, serverErrors.AuthorizationModelResolutionTooComplex
 		}
 
-		return nil, serverErrors.HandleError("", err)
+		return nil, serverErrors.HandleError(ctx, "", err)
 	}
 
+	cluster.SetDatastoreQueryCount(ctx, resp.GetResolutionMetadata().DatastoreQueryCount)
+
 	queryCount := float64(resp.GetResolutionMetadata().DatastoreQueryCount)
 	const methodName = "check"
 
@@ -592,11 +1129,17 @@ func (s *Server) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openf
 		openfgav1.OpenFGAService_ServiceDesc.ServiceName,
 		methodName,
 	).Observe(queryCount)
+	s.observeStoreDatastoreQueryCount(methodName, storeID, queryCount)
 
 	res := &openfgav1.CheckResponse{
 		Allowed: resp.Allowed,
 	}
 
+	if !inline {
+		// the shadow model isn't comparable to an inline model
+		s.shadowCheck(ctx, req, conditionContext, typesys.GetAuthorizationModelID(), res.GetAllowed())
+	}
+
 	span.SetAttributes(attribute.KeyValue{Key: "allowed", Value: attribute.BoolValue(res.GetAllowed())})
 	requestDurationByQueryHistogram.WithLabelValues(
 		openfgav1.OpenFGAService_ServiceDesc.ServiceName,
@@ -607,12 +1150,395 @@ func (s *Server) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openf
 	return res, nil
 }
 
-func (s *Server) Expand(ctx context.Context, req *openfgav1.ExpandRequest) (*openfgav1.ExpandResponse, error) {
-	tk := req.GetTupleKey()
-	ctx, span := tracer.Start(ctx, "Expand", trace.WithAttributes(
-		attribute.KeyValue{Key: "object", Value: attribute.StringValue(tk.GetObject())},
-		attribute.KeyValue{Key: "relation", Value: attribute.StringValue(tk.GetRelation())},
-		attribute.KeyValue{Key: "user", Value: attribute.StringValue(tk.GetUser())},
+// CheckRelations checks whether a user has each of a set of relations with an object. The relations are
+// checked one after the other with the same check resolver, which caches the results of the sub-problems
+// for the duration of the call, so that the relations that overlap (e.g. 'viewer', 'editor' and 'owner')
+// share the evaluation of their common subtrees.
+func (s *Server) CheckRelations(ctx context.Context, req *commands.CheckRelationsRequest) (*commands.CheckRelationsResponse, error) {
+	ctx, span := tracer.Start(ctx, "CheckRelations", trace.WithAttributes(
+		attribute.KeyValue{Key: "object", Value: attribute.StringValue(req.Object)},
+		attribute.KeyValue{Key: "relations", Value: attribute.StringSliceValue(req.Relations)},
+		attribute.KeyValue{Key: "user", Value: attribute.StringValue(req.User)},
+	))
+	defer span.End()
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		Method:  "CheckRelations",
+	})
+	ctx = s.contextWithRequestMetadata(ctx, "CheckRelations", req.StoreID)
+
+	if req.User == "" || req.Object == "" {
+		return nil, serverErrors.InvalidCheckInput
+	}
+
+	if len(req.Relations) == 0 {
+		return nil, serverErrors.ValidationError(errors.New("at least one relation must be provided"))
+	}
+
+	if len(req.Relations) > commands.MaxRelationsPerCheckRelations {
+		return nil, serverErrors.ValidationError(fmt.Errorf("at most %d relations can be checked at once", commands.MaxRelationsPerCheckRelations))
+	}
+
+	typesys, err := s.resolveTypesystem(ctx, req.StoreID, req.AuthorizationModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, relation := range req.Relations {
+		if err := validation.ValidateUserObjectRelation(typesys, tuple.NewTupleKey(req.Object, relation, req.User)); err != nil {
+			return nil, serverErrors.ValidationError(err)
+		}
+	}
+
+	for _, ctxTuple := range req.ContextualTuples {
+		if err := validation.ValidateTuple(typesys, ctxTuple); err != nil {
+			return nil, serverErrors.HandleTupleValidateError(ctx, err)
+		}
+	}
+
+	ctx = typesystem.ContextWithTypesystem(ctx, typesys)
+
+	checkOptions := s.checkOptions
+	if s.checkCache == nil {
+		// the results are shared between the relations through a cache that only lives as long as the call
+		checkOptions = append(slices.Clone(s.checkOptions), graph.WithCachedResolver())
+	}
+
+	checkResolver := graph.NewLocalChecker(
+		storagewrappers.NewCombinedTupleReader(s.conditionsTupleReader(typesys, req.Context), req.ContextualTuples),
+		checkOptions...,
+	)
+	defer checkResolver.Close()
+
+	resp := &commands.CheckRelationsResponse{
+		Relations: make(map[string]bool, len(req.Relations)),
+	}
+
+	var queryCount float64
+	for _, relation := range req.Relations {
+		if _, ok := resp.Relations[relation]; ok {
+			continue
+		}
+
+		checkResp, err := checkResolver.ResolveCheck(ctx, &graph.ResolveCheckRequest{
+			StoreID:              req.StoreID,
+			AuthorizationModelID: typesys.GetAuthorizationModelID(), // the resolved model id
+			TupleKey:             tuple.NewTupleKey(req.Object, relation, req.User),
+			ContextualTuples:     req.ContextualTuples,
+			Context:              req.Context,
+			ResolutionMetadata: &graph.ResolutionMetadata{
+				Depth:               s.resolveNodeLimit,
+				DatastoreQueryCount: 0,
+			},
+		})
+		if err != nil {
+			if errors.Is(err, graph.ErrResolutionDepthExceeded) || errors.Is(err, graph.ErrCycleDetected) {
+				return nil, serverErrors.AuthorizationModelResolutionTooComplex
+			}
+
+			return nil, serverErrors.HandleError(ctx, "", err)
+		}
+
+		resp.Relations[relation] = checkResp.GetAllowed()
+		queryCount += float64(checkResp.GetResolutionMetadata().DatastoreQueryCount)
+	}
+
+	const methodName = "checkrelations"
+
+	grpc_ctxtags.Extract(ctx).Set(datastoreQueryCountHistogramName, queryCount)
+	span.SetAttributes(attribute.Float64(datastoreQueryCountHistogramName, queryCount))
+	datastoreQueryCountHistogram.WithLabelValues(
+		openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		methodName,
+	).Observe(queryCount)
+	s.observeStoreDatastoreQueryCount(methodName, req.StoreID, queryCount)
+
+	return resp, nil
+}
+
+// errBatchCheckBudgetExhausted is the error of the Checks of a BatchCheck call that weren't evaluated because
+// the Checks before them spent its datastore query budget.
+var errBatchCheckBudgetExhausted = status.Error(codes.ResourceExhausted, "the datastore query budget of the batch check is exhausted")
+
+// BatchCheck checks several tuple keys at once, which saves the round trips of a Check call per tuple key.
+// The tuple keys are checked concurrently with the same check resolver, which caches the results of the
+// sub-problems for the duration of the call, and share a budget of datastore queries (see
+// WithBatchCheckMaxDatastoreQueries). The errors of a tuple key, e.g. an undefined relation or a resolution
+// that's too complex, are reported in its result and don't fail the others.
+func (s *Server) BatchCheck(ctx context.Context, req *commands.BatchCheckRequest) (*commands.BatchCheckResponse, error) {
+	ctx, span := tracer.Start(ctx, "BatchCheck", trace.WithAttributes(
+		attribute.Int("tuple_keys", len(req.TupleKeys)),
+	))
+	defer span.End()
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		Method:  "BatchCheck",
+	})
+	ctx = s.contextWithRequestMetadata(ctx, "BatchCheck", req.StoreID)
+
+	if len(req.TupleKeys) == 0 {
+		return nil, serverErrors.ValidationError(errors.New("at least one tuple key must be provided"))
+	}
+
+	if len(req.TupleKeys) > s.maxChecksPerBatchCheck {
+		return nil, serverErrors.ExceededEntityLimit("tuple keys of a batch check", s.maxChecksPerBatchCheck)
+	}
+
+	typesys, err := s.resolveTypesystem(ctx, req.StoreID, req.AuthorizationModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ctxTuple := range req.ContextualTuples {
+		if err := validation.ValidateTuple(typesys, ctxTuple); err != nil {
+			return nil, serverErrors.HandleTupleValidateError(ctx, err)
+		}
+	}
+
+	ctx = typesystem.ContextWithTypesystem(ctx, typesys)
+
+	checkOptions := s.checkOptions
+	if s.checkCache == nil {
+		// the results are shared between the tuple keys through a cache that only lives as long as the call
+		checkOptions = append(slices.Clone(s.checkOptions), graph.WithCachedResolver())
+	}
+
+	checkResolver := graph.NewLocalChecker(
+		storagewrappers.NewCombinedTupleReader(s.conditionsTupleReader(typesys, req.Context), req.ContextualTuples),
+		checkOptions...,
+	)
+	defer checkResolver.Close()
+
+	resp := &commands.BatchCheckResponse{
+		Results: make([]*commands.BatchCheckResult, len(req.TupleKeys)),
+	}
+
+	var queryCount atomic.Uint32
+	pool := errgroup.Group{}
+	pool.SetLimit(s.maxConcurrentChecksPerBatchCheck)
+
+	for i, tk := range req.TupleKeys {
+		tk := tk
+		result := &commands.BatchCheckResult{TupleKey: tk}
+		resp.Results[i] = result
+
+		pool.Go(func() error {
+			if s.batchCheckMaxDatastoreQueries > 0 && queryCount.Load() >= s.batchCheckMaxDatastoreQueries {
+				result.Error = errBatchCheckBudgetExhausted
+				return nil
+			}
+
+			if tk.GetUser() == "" || tk.GetRelation() == "" || tk.GetObject() == "" {
+				result.Error = serverErrors.InvalidCheckInput
+				return nil
+			}
+
+			if err := validation.ValidateUserObjectRelation(typesys, tk); err != nil {
+				result.Error = serverErrors.ValidationError(err)
+				return nil
+			}
+
+			if s.relationUsageTracker != nil {
+				s.relationUsageTracker.RecordCheck(req.StoreID, tuple.GetType(tk.GetObject()), tk.GetRelation())
+			}
+
+			checkResp, err := checkResolver.ResolveCheck(ctx, &graph.ResolveCheckRequest{
+				StoreID:              req.StoreID,
+				AuthorizationModelID: typesys.GetAuthorizationModelID(), // the resolved model id
+				TupleKey:             tk,
+				ContextualTuples:     req.ContextualTuples,
+				Context:              req.Context,
+				ResolutionMetadata: &graph.ResolutionMetadata{
+					Depth:               s.resolveNodeLimit,
+					DatastoreQueryCount: 0,
+				},
+			})
+			if err != nil {
+				if errors.Is(err, graph.ErrResolutionDepthExceeded) || errors.Is(err, graph.ErrCycleDetected) {
+					result.Error = serverErrors.AuthorizationModelResolutionTooComplex
+					return nil
+				}
+
+				result.Error = serverErrors.HandleError(ctx, "", err)
+				return nil
+			}
+
+			result.Allowed = checkResp.GetAllowed()
+			queryCount.Add(checkResp.GetResolutionMetadata().DatastoreQueryCount)
+			return nil
+		})
+	}
+
+	_ = pool.Wait()
+
+	const methodName = "batchcheck"
+
+	totalQueryCount := float64(queryCount.Load())
+	grpc_ctxtags.Extract(ctx).Set(datastoreQueryCountHistogramName, totalQueryCount)
+	span.SetAttributes(attribute.Float64(datastoreQueryCountHistogramName, totalQueryCount))
+	datastoreQueryCountHistogram.WithLabelValues(
+		openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		methodName,
+	).Observe(totalQueryCount)
+	s.observeStoreDatastoreQueryCount(methodName, req.StoreID, totalQueryCount)
+
+	return resp, nil
+}
+
+// SimulateWrite answers Check and ListObjects queries as if a set of tuples had been written to the store,
+// without writing them. The tuples are layered on top of the store as contextual tuples, which lets apps
+// preview the effect of sharing a resource before committing to it.
+func (s *Server) SimulateWrite(ctx context.Context, req *commands.SimulateWriteRequest) (*commands.SimulateWriteResponse, error) {
+	ctx, span := tracer.Start(ctx, "SimulateWrite", trace.WithAttributes(
+		attribute.Int("writes", len(req.Writes)),
+		attribute.Int("checks", len(req.Checks)),
+		attribute.Int("list_objects", len(req.ListObjects)),
+	))
+	defer span.End()
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		Method:  "SimulateWrite",
+	})
+	ctx = s.contextWithRequestMetadata(ctx, "SimulateWrite", req.StoreID)
+
+	if len(req.Writes) == 0 {
+		return nil, serverErrors.ValidationError(errors.New("at least one write must be provided"))
+	}
+
+	if len(req.Writes) > s.datastore.MaxTuplesPerWrite() {
+		return nil, serverErrors.ExceededEntityLimit("write operations", s.datastore.MaxTuplesPerWrite())
+	}
+
+	queries := len(req.Checks) + len(req.ListObjects)
+	if queries == 0 {
+		return nil, serverErrors.ValidationError(errors.New("at least one query must be provided"))
+	}
+
+	if queries > commands.MaxQueriesPerSimulateWrite {
+		return nil, serverErrors.ValidationError(fmt.Errorf("at most %d queries can be simulated at once", commands.MaxQueriesPerSimulateWrite))
+	}
+
+	typesys, err := s.resolveTypesystem(ctx, req.StoreID, req.AuthorizationModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	writes := make(map[string]struct{}, len(req.Writes))
+	for _, tk := range req.Writes {
+		if err := validation.ValidateTuple(typesys, tk); err != nil {
+			return nil, serverErrors.HandleTupleValidateError(ctx, err)
+		}
+
+		key := tuple.TupleKeyToString(tk)
+		if _, ok := writes[key]; ok {
+			return nil, serverErrors.DuplicateTupleInWrite(tk)
+		}
+		writes[key] = struct{}{}
+	}
+
+	for _, tk := range req.Checks {
+		if tk.GetUser() == "" || tk.GetRelation() == "" || tk.GetObject() == "" {
+			return nil, serverErrors.InvalidCheckInput
+		}
+
+		if err := validation.ValidateUserObjectRelation(typesys, tk); err != nil {
+			return nil, serverErrors.ValidationError(err)
+		}
+	}
+
+	ctx = typesystem.ContextWithTypesystem(ctx, typesys)
+
+	resp := &commands.SimulateWriteResponse{
+		Checks:      make([]*commands.SimulatedCheckResult, 0, len(req.Checks)),
+		ListObjects: make([]*commands.SimulatedListObjectsResult, 0, len(req.ListObjects)),
+	}
+
+	var queryCount float64
+
+	if len(req.Checks) > 0 {
+		checkResolver := graph.NewLocalChecker(
+			storagewrappers.NewCombinedTupleReader(s.conditionsTupleReader(typesys, req.Context), req.Writes),
+			s.checkOptions...,
+		)
+		defer checkResolver.Close()
+
+		for _, tk := range req.Checks {
+			checkResp, err := checkResolver.ResolveCheck(ctx, &graph.ResolveCheckRequest{
+				StoreID:              req.StoreID,
+				AuthorizationModelID: typesys.GetAuthorizationModelID(), // the resolved model id
+				TupleKey:             tuple.NewTupleKey(tk.GetObject(), tk.GetRelation(), tk.GetUser()),
+				ContextualTuples:     req.Writes,
+				Context:              req.Context,
+				ResolutionMetadata: &graph.ResolutionMetadata{
+					Depth:               s.resolveNodeLimit,
+					DatastoreQueryCount: 0,
+				},
+			})
+			if err != nil {
+				if errors.Is(err, graph.ErrResolutionDepthExceeded) || errors.Is(err, graph.ErrCycleDetected) {
+					return nil, serverErrors.AuthorizationModelResolutionTooComplex
+				}
+
+				return nil, serverErrors.HandleError(ctx, "", err)
+			}
+
+			resp.Checks = append(resp.Checks, &commands.SimulatedCheckResult{
+				Object:   tk.GetObject(),
+				Relation: tk.GetRelation(),
+				User:     tk.GetUser(),
+				Allowed:  checkResp.GetAllowed(),
+			})
+			queryCount += float64(checkResp.GetResolutionMetadata().DatastoreQueryCount)
+		}
+	}
+
+	q := s.newListObjectsQuery(s.conditionsTupleReader(typesys, req.Context), true,
+		commands.WithConditionContext(req.Context),
+	)
+	for _, query := range req.ListObjects {
+		result, err := q.Execute(ctx, &openfgav1.ListObjectsRequest{
+			StoreId:              req.StoreID,
+			AuthorizationModelId: typesys.GetAuthorizationModelID(), // the resolved model id
+			Type:                 query.Type,
+			Relation:             query.Relation,
+			User:                 query.User,
+			ContextualTuples:     &openfgav1.ContextualTupleKeys{TupleKeys: req.Writes},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		resp.ListObjects = append(resp.ListObjects, &commands.SimulatedListObjectsResult{
+			Type:     query.Type,
+			Relation: query.Relation,
+			User:     query.User,
+			Objects:  result.Objects,
+		})
+		queryCount += float64(*result.ResolutionMetadata.QueryCount)
+	}
+
+	const methodName = "simulatewrite"
+
+	grpc_ctxtags.Extract(ctx).Set(datastoreQueryCountHistogramName, queryCount)
+	span.SetAttributes(attribute.Float64(datastoreQueryCountHistogramName, queryCount))
+	datastoreQueryCountHistogram.WithLabelValues(
+		openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		methodName,
+	).Observe(queryCount)
+	s.observeStoreDatastoreQueryCount(methodName, req.StoreID, queryCount)
+
+	return resp, nil
+}
+
+func (s *Server) Expand(ctx context.Context, req *openfgav1.ExpandRequest) (*openfgav1.ExpandResponse, error) {
+	tk := req.GetTupleKey()
+	ctx, span := tracer.Start(ctx, "Expand", trace.WithAttributes(
+		attribute.KeyValue{Key: "object", Value: attribute.StringValue(tk.GetObject())},
+		attribute.KeyValue{Key: "relation", Value: attribute.StringValue(tk.GetRelation())},
+		attribute.KeyValue{Key: "user", Value: attribute.StringValue(tk.GetUser())},
 	))
 	defer span.End()
 
@@ -626,6 +1552,7 @@ func (s *Server) Expand(ctx context.Context, req *openfgav1.ExpandRequest) (*ope
 		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
 		Method:  "Expand",
 	})
+	ctx = s.contextWithRequestMetadata(ctx, "Expand", req.GetStoreId())
 
 	storeID := req.GetStoreId()
 
@@ -634,7 +1561,15 @@ func (s *Server) Expand(ctx context.Context, req *openfgav1.ExpandRequest) (*ope
 		return nil, err
 	}
 
-	q := commands.NewExpandQuery(s.datastore, s.logger)
+	contextualTuples, err := s.requestContextualTuples(ctx, storeID, typesys)
+	if err != nil {
+		return nil, err
+	}
+
+	q := commands.NewExpandQuery(s.datastore, s.logger,
+		commands.WithExpandTypesystem(typesys),
+		commands.WithExpandContextualTuples(contextualTuples),
+	)
 	return q.Execute(ctx, &openfgav1.ExpandRequest{
 		StoreId:              storeID,
 		AuthorizationModelId: typesys.GetAuthorizationModelID(), // the resolved model id
@@ -658,6 +1593,7 @@ func (s *Server) ReadAuthorizationModel(ctx context.Context, req *openfgav1.Read
 		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
 		Method:  "ReadAuthorizationModels",
 	})
+	ctx = s.contextWithRequestMetadata(ctx, "ReadAuthorizationModels", req.GetStoreId())
 
 	q := commands.NewReadAuthorizationModelQuery(s.datastore, s.logger)
 	return q.Execute(ctx, req)
@@ -677,18 +1613,220 @@ func (s *Server) WriteAuthorizationModel(ctx context.Context, req *openfgav1.Wri
 		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
 		Method:  "WriteAuthorizationModel",
 	})
+	ctx = s.contextWithRequestMetadata(ctx, "WriteAuthorizationModel", req.GetStoreId())
 
-	c := commands.NewWriteAuthorizationModelCommand(s.datastore, s.logger, s.maxAuthorizationModelSizeInBytes)
+	var opts []commands.WriteAuthModelOption
+	if s.assertionsCopyForward {
+		opts = append(opts, commands.WithAssertionsCopyForward(s.datastore))
+	}
+	if s.identicalModelsSkipped {
+		opts = append(opts, commands.WithIdenticalModelsSkipped(s.datastore))
+	}
+	if values := metadata.ValueFromIncomingContext(ctx, AuthorizationModelLabelsHeader); len(values) > 0 {
+		labels, err := commands.ParseAuthorizationModelLabels(values...)
+		if err != nil {
+			return nil, serverErrors.ValidationError(err)
+		}
+		opts = append(opts, commands.WithLabels(s.datastore, labels))
+	}
+	validationOpts, err := s.authorizationModelValidationOpts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, validationOpts...)
+
+	c := commands.NewWriteAuthorizationModelCommand(s.datastore, s.logger, s.maxAuthorizationModelSizeInBytes, opts...)
 	res, err := c.Execute(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
+	if c.Skipped() {
+		_ = grpc.SetHeader(ctx, metadata.Pairs(AuthorizationModelUnchangedHeader, "true"))
+		s.transport.SetHeader(ctx, httpmiddleware.XHttpCode, strconv.Itoa(http.StatusOK))
+
+		return res, nil
+	}
+
+	s.publishEvent(ctx, events.Event{
+		Type:                 events.AuthorizationModelWritten,
+		StoreID:              req.GetStoreId(),
+		AuthorizationModelID: res.GetAuthorizationModelId(),
+	})
+
 	s.transport.SetHeader(ctx, httpmiddleware.XHttpCode, strconv.Itoa(http.StatusCreated))
 
 	return res, nil
 }
 
+// ValidateAuthorizationModel validates an authorization model exactly as WriteAuthorizationModel would, without
+// writing it, and reports its errors and warnings (see commands.ValidateAuthorizationModelQuery).
+func (s *Server) ValidateAuthorizationModel(ctx context.Context, req *openfgav1.WriteAuthorizationModelRequest) (*commands.ValidateAuthorizationModelResponse, error) {
+	ctx, span := tracer.Start(ctx, "ValidateAuthorizationModel")
+	defer span.End()
+
+	if err := req.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		Method:  "ValidateAuthorizationModel",
+	})
+	ctx = s.contextWithRequestMetadata(ctx, "ValidateAuthorizationModel", req.GetStoreId())
+
+	opts, err := s.authorizationModelValidationOpts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q := commands.NewValidateAuthorizationModelQuery(s.datastore, s.logger, s.maxAuthorizationModelSizeInBytes, opts...)
+	return q.Execute(ctx, req)
+}
+
+// authorizationModelValidationOpts returns the options of the validation of the authorization models, along
+// with the conditions of the AuthorizationModelConditionsHeader header of the request, if any.
+func (s *Server) authorizationModelValidationOpts(ctx context.Context) ([]commands.WriteAuthModelOption, error) {
+	var opts []commands.WriteAuthModelOption
+
+	conditions, err := s.requestModelConditions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s.conditionsBackend != nil {
+		opts = append(opts, commands.WithConditions(s.conditionsBackend, conditions))
+	}
+	if s.authorizationModelValidator != nil {
+		opts = append(opts, commands.WithAuthorizationModelValidator(s.authorizationModelValidator))
+	}
+	if s.authorizationModelNamingPolicy != nil {
+		opts = append(opts, commands.WithNamingPolicy(s.authorizationModelNamingPolicy))
+	}
+	if s.wildcardsDisallowed {
+		opts = append(opts, commands.WithWildcardsDisallowed())
+	}
+	if s.intersectionAndExclusionDisallowed {
+		opts = append(opts, commands.WithIntersectionAndExclusionDisallowed())
+	}
+
+	return opts, nil
+}
+
+// GetAuthorizationModelByLabel returns the latest authorization model of a store with a label (e.g.
+// 'release=42'), along with all its labels.
+func (s *Server) GetAuthorizationModelByLabel(ctx context.Context, req *commands.GetAuthorizationModelByLabelRequest) (*commands.GetAuthorizationModelByLabelResponse, error) {
+	ctx, span := tracer.Start(ctx, "GetAuthorizationModelByLabel")
+	defer span.End()
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		Method:  "GetAuthorizationModelByLabel",
+	})
+	ctx = s.contextWithRequestMetadata(ctx, "GetAuthorizationModelByLabel", req.StoreID)
+
+	q := commands.NewGetAuthorizationModelByLabelQuery(s.datastore, s.logger)
+	return q.Execute(ctx, req)
+}
+
+// AnalyzeModelImpact reports which tuples of a store, sampled or not, would become invalid under a candidate
+// authorization model, which isn't written.
+func (s *Server) AnalyzeModelImpact(ctx context.Context, req *commands.ModelImpactRequest) (*commands.ModelImpactResponse, error) {
+	ctx, span := tracer.Start(ctx, "AnalyzeModelImpact")
+	defer span.End()
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		Method:  "AnalyzeModelImpact",
+	})
+	ctx = s.contextWithRequestMetadata(ctx, "AnalyzeModelImpact", req.StoreID)
+
+	q := commands.NewModelImpactQuery(s.datastore, s.logger, s.maxAuthorizationModelSizeInBytes, s.typesystemOpts...)
+	return q.Execute(ctx, req)
+}
+
+// DiffAuthorizationModels reports the changes from an authorization model of a store to another one, or to the
+// latest model, and flags the ones that invalidate tuples of the store (see commands.ModelDiffQuery).
+func (s *Server) DiffAuthorizationModels(ctx context.Context, req *commands.ModelDiffRequest) (*commands.ModelDiffResponse, error) {
+	ctx, span := tracer.Start(ctx, "DiffAuthorizationModels", trace.WithAttributes(
+		attribute.String("from_authorization_model_id", req.FromAuthorizationModelID),
+		attribute.String("to_authorization_model_id", req.ToAuthorizationModelID),
+	))
+	defer span.End()
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		Method:  "DiffAuthorizationModels",
+	})
+	ctx = s.contextWithRequestMetadata(ctx, "DiffAuthorizationModels", req.StoreID)
+
+	if req.FromAuthorizationModelID == "" {
+		return nil, serverErrors.ValidationError(fmt.Errorf("the ID of the old authorization model is required"))
+	}
+
+	from, err := s.resolveTypesystem(ctx, req.StoreID, req.FromAuthorizationModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := s.resolveTypesystem(ctx, req.StoreID, req.ToAuthorizationModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	q := commands.NewModelDiffQuery(s.datastore, s.logger)
+	return q.Execute(ctx, from, to, req)
+}
+
+// SampleTuples returns a random sample of the tuples of a store, optionally of an object type and relation,
+// sampled by the datastore if it's a storage.TupleSampler (see storage.As), otherwise without reading every
+// tuple of the store (see commands.SampleTuplesQuery). It's meant for debugging.
+func (s *Server) SampleTuples(ctx context.Context, req *commands.SampleTuplesRequest) (*commands.SampleTuplesResponse, error) {
+	ctx, span := tracer.Start(ctx, "SampleTuples", trace.WithAttributes(
+		attribute.String("object_type", req.ObjectType),
+		attribute.String("relation", req.Relation),
+		attribute.Int("size", req.Size),
+	))
+	defer span.End()
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		Method:  "SampleTuples",
+	})
+	ctx = s.contextWithRequestMetadata(ctx, "SampleTuples", req.StoreID)
+
+	var opts []commands.SampleTuplesQueryOption
+	if sampler, ok := storage.As[storage.TupleSampler](s.datastore); ok {
+		opts = append(opts, commands.WithTupleSampler(sampler))
+	}
+
+	q := commands.NewSampleTuplesQuery(s.datastore, s.logger, opts...)
+	return q.Execute(ctx, req)
+}
+
+// RenameRelations rewrites the tuples of relations being renamed with their new names, or reports the tuples
+// that would be rewritten in a dry run. The model of the request, or the latest model of the store, must
+// define both the old and the new relations (see commands.RenameRelationsCommand).
+func (s *Server) RenameRelations(ctx context.Context, req *commands.RenameRelationsRequest) (*commands.RenameRelationsResponse, error) {
+	ctx, span := tracer.Start(ctx, "RenameRelations", trace.WithAttributes(
+		attribute.Bool("dry_run", req.DryRun),
+	))
+	defer span.End()
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		Method:  "RenameRelations",
+	})
+	ctx = s.contextWithRequestMetadata(ctx, "RenameRelations", req.StoreID)
+
+	typesys, err := s.resolveTypesystem(ctx, req.StoreID, req.AuthorizationModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	c := commands.NewRenameRelationsCommand(s.datastore, s.logger, commands.WithRenameRelationsHook(s.invalidateCheckCache))
+	return c.Execute(ctx, typesys, req)
+}
+
 func (s *Server) ReadAuthorizationModels(ctx context.Context, req *openfgav1.ReadAuthorizationModelsRequest) (*openfgav1.ReadAuthorizationModelsResponse, error) {
 	ctx, span := tracer.Start(ctx, "ReadAuthorizationModels")
 	defer span.End()
@@ -703,6 +1841,7 @@ func (s *Server) ReadAuthorizationModels(ctx context.Context, req *openfgav1.Rea
 		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
 		Method:  "ReadAuthorizationModels",
 	})
+	ctx = s.contextWithRequestMetadata(ctx, "ReadAuthorizationModels", req.GetStoreId())
 
 	c := commands.NewReadAuthorizationModelsQuery(s.datastore, s.logger, s.encoder)
 	return c.Execute(ctx, req)
@@ -722,6 +1861,7 @@ func (s *Server) WriteAssertions(ctx context.Context, req *openfgav1.WriteAssert
 		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
 		Method:  "WriteAssertions",
 	})
+	ctx = s.contextWithRequestMetadata(ctx, "WriteAssertions", req.GetStoreId())
 
 	storeID := req.GetStoreId()
 
@@ -759,6 +1899,7 @@ func (s *Server) ReadAssertions(ctx context.Context, req *openfgav1.ReadAssertio
 		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
 		Method:  "ReadAssertions",
 	})
+	ctx = s.contextWithRequestMetadata(ctx, "ReadAssertions", req.GetStoreId())
 
 	typesys, err := s.resolveTypesystem(ctx, req.GetStoreId(), req.GetAuthorizationModelId())
 	if err != nil {
@@ -769,6 +1910,237 @@ func (s *Server) ReadAssertions(ctx context.Context, req *openfgav1.ReadAssertio
 	return q.Execute(ctx, req.GetStoreId(), typesys.GetAuthorizationModelID())
 }
 
+// AssertionsCoverage reports which relations of an authorization model are not targeted by any of
+// its assertions. If no authorization model ID is provided, the latest model of the store is used.
+func (s *Server) AssertionsCoverage(ctx context.Context, req *commands.AssertionsCoverageRequest) (*commands.AssertionsCoverageResponse, error) {
+	ctx, span := tracer.Start(ctx, "AssertionsCoverage")
+	defer span.End()
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		Method:  "AssertionsCoverage",
+	})
+	ctx = s.contextWithRequestMetadata(ctx, "AssertionsCoverage", req.StoreID)
+
+	typesys, err := s.resolveTypesystem(ctx, req.StoreID, req.AuthorizationModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	q := commands.NewAssertionsCoverageQuery(s.datastore, s.logger)
+	return q.Execute(ctx, req.StoreID, typesys)
+}
+
+// GetReachableTypes returns the user types, typed wildcards and usersets that can possibly satisfy a relation
+// of an object type.
+func (s *Server) GetReachableTypes(ctx context.Context, req *commands.GetReachableTypesRequest) (*commands.GetReachableTypesResponse, error) {
+	ctx, span := tracer.Start(ctx, "GetReachableTypes", trace.WithAttributes(
+		attribute.String("object_type", req.ObjectType),
+		attribute.String("relation", req.Relation),
+	))
+	defer span.End()
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		Method:  "GetReachableTypes",
+	})
+	ctx = s.contextWithRequestMetadata(ctx, "GetReachableTypes", req.StoreID)
+
+	typesys, err := s.resolveTypesystem(ctx, req.StoreID, req.AuthorizationModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	q := commands.NewGetReachableTypesQuery(s.logger)
+	return q.Execute(ctx, typesys, req)
+}
+
+// GetPrunedRelationshipEdges returns the relationship edges that ListObjects follows from a kind of user to
+// a relation of an object type, along with the branches of the intersections and exclusions it prunes. It's
+// meant for debugging.
+func (s *Server) GetPrunedRelationshipEdges(ctx context.Context, req *commands.GetPrunedRelationshipEdgesRequest) (*commands.GetPrunedRelationshipEdgesResponse, error) {
+	ctx, span := tracer.Start(ctx, "GetPrunedRelationshipEdges", trace.WithAttributes(
+		attribute.String("object_type", req.ObjectType),
+		attribute.String("relation", req.Relation),
+		attribute.String("user_type", req.UserType),
+	))
+	defer span.End()
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		Method:  "GetPrunedRelationshipEdges",
+	})
+	ctx = s.contextWithRequestMetadata(ctx, "GetPrunedRelationshipEdges", req.StoreID)
+
+	typesys, err := s.resolveTypesystem(ctx, req.StoreID, req.AuthorizationModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	q := commands.NewGetPrunedRelationshipEdgesQuery(s.logger)
+	return q.Execute(ctx, typesys, req)
+}
+
+// ExpandObject expands every relation defined on the type of an object and summarizes the users, usersets
+// and wildcards related with the object through each one of them. It's meant for admin screens that show
+// who has access to a resource, which would otherwise take an Expand call per relation.
+func (s *Server) ExpandObject(ctx context.Context, req *commands.ExpandObjectRequest) (*commands.ExpandObjectResponse, error) {
+	ctx, span := tracer.Start(ctx, "ExpandObject", trace.WithAttributes(
+		attribute.String("object", req.Object),
+	))
+	defer span.End()
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		Method:  "ExpandObject",
+	})
+	ctx = s.contextWithRequestMetadata(ctx, "ExpandObject", req.StoreID)
+
+	typesys, err := s.resolveTypesystem(ctx, req.StoreID, req.AuthorizationModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	q := commands.NewExpandObjectQuery(s.datastore, s.logger)
+	return q.Execute(ctx, typesys, req)
+}
+
+// ListUserAccess returns every relation of every object type a user can possibly have, along with the
+// objects the user is directly assigned each relation with. It backs pages that show a user their access
+// and the reviews of the access of users that are being offboarded.
+func (s *Server) ListUserAccess(ctx context.Context, req *commands.ListUserAccessRequest) (*commands.ListUserAccessResponse, error) {
+	ctx, span := tracer.Start(ctx, "ListUserAccess", trace.WithAttributes(
+		attribute.String("user", req.User),
+	))
+	defer span.End()
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		Method:  "ListUserAccess",
+	})
+	ctx = s.contextWithRequestMetadata(ctx, "ListUserAccess", req.StoreID)
+
+	typesys, err := s.resolveTypesystem(ctx, req.StoreID, req.AuthorizationModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	q := commands.NewListUserAccessQuery(s.datastore, s.logger)
+	return q.Execute(ctx, typesys, req)
+}
+
+// ListUsers returns the users of the requested kinds that have a relation with an object, including the
+// users of the usersets and of the tuple to usersets of the relation, e.g. for the share dialogs that show
+// who has access to a resource.
+func (s *Server) ListUsers(ctx context.Context, req *commands.ListUsersRequest) (*commands.ListUsersResponse, error) {
+	ctx, span := tracer.Start(ctx, "ListUsers", trace.WithAttributes(
+		attribute.String("object", req.Object),
+		attribute.String("relation", req.Relation),
+	))
+	defer span.End()
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		Method:  "ListUsers",
+	})
+	ctx = s.contextWithRequestMetadata(ctx, "ListUsers", req.StoreID)
+
+	typesys, err := s.resolveTypesystem(ctx, req.StoreID, req.AuthorizationModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	q := commands.NewListUsersQuery(s.datastore, s.logger, commands.WithListUsersResolveNodeLimit(s.resolveNodeLimit))
+	return q.Execute(ctx, typesys, req)
+}
+
+// ImportStore imports a store file (see package storefile) into a new store, or into an existing one if
+// the request has a store ID.
+func (s *Server) ImportStore(ctx context.Context, req *commands.ImportStoreRequest) (*commands.ImportStoreResponse, error) {
+	ctx, span := tracer.Start(ctx, "ImportStore")
+	defer span.End()
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		Method:  "ImportStore",
+	})
+	ctx = s.contextWithRequestMetadata(ctx, "ImportStore", req.StoreID)
+
+	c := commands.NewImportStoreCommand(s.datastore, s.logger, s.maxAuthorizationModelSizeInBytes)
+	res, err := c.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.StoreID == "" {
+		s.publishEvent(ctx, events.Event{Type: events.StoreCreated, StoreID: res.StoreID})
+	}
+
+	s.publishEvent(ctx, events.Event{
+		Type:                 events.AuthorizationModelWritten,
+		StoreID:              res.StoreID,
+		AuthorizationModelID: res.AuthorizationModelID,
+	})
+
+	s.publishEvent(ctx, events.Event{
+		Type:                 events.TuplesWritten,
+		StoreID:              res.StoreID,
+		AuthorizationModelID: res.AuthorizationModelID,
+		Attributes: map[string]string{
+			"writes":  strconv.Itoa(res.TuplesWritten),
+			"deletes": "0",
+		},
+	})
+
+	return res, nil
+}
+
+// ImportTuples writes tuples in bulk to a store, e.g. the tuples of a CSV or NDJSON tuple file (see package
+// storefile).
+func (s *Server) ImportTuples(ctx context.Context, req *commands.ImportTuplesRequest) (*commands.ImportTuplesResponse, error) {
+	ctx, span := tracer.Start(ctx, "ImportTuples")
+	defer span.End()
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		Method:  "ImportTuples",
+	})
+	ctx = s.contextWithRequestMetadata(ctx, "ImportTuples", req.StoreID)
+
+	c := commands.NewImportTuplesCommand(s.datastore, s.logger, commands.WithImportTuplesHook(s.invalidateCheckCache))
+	res, err := c.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishEvent(ctx, events.Event{
+		Type:                 events.TuplesWritten,
+		StoreID:              res.StoreID,
+		AuthorizationModelID: res.AuthorizationModelID,
+		Attributes: map[string]string{
+			"writes":  strconv.Itoa(res.TuplesWritten),
+			"deletes": "0",
+		},
+	})
+
+	return res, nil
+}
+
+// ExportStore exports a store as a store file (see package storefile).
+func (s *Server) ExportStore(ctx context.Context, req *commands.ExportStoreRequest) (*storefile.StoreFile, error) {
+	ctx, span := tracer.Start(ctx, "ExportStore")
+	defer span.End()
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		Method:  "ExportStore",
+	})
+	ctx = s.contextWithRequestMetadata(ctx, "ExportStore", req.StoreID)
+
+	q := commands.NewExportStoreQuery(s.datastore, s.logger)
+	return q.Execute(ctx, req)
+}
+
 func (s *Server) ReadChanges(ctx context.Context, req *openfgav1.ReadChangesRequest) (*openfgav1.ReadChangesResponse, error) {
 	ctx, span := tracer.Start(ctx, "ReadChangesQuery", trace.WithAttributes(
 		attribute.KeyValue{Key: "type", Value: attribute.StringValue(req.GetType())},
@@ -785,6 +2157,7 @@ func (s *Server) ReadChanges(ctx context.Context, req *openfgav1.ReadChangesRequ
 		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
 		Method:  "ReadChanges",
 	})
+	ctx = s.contextWithRequestMetadata(ctx, "ReadChanges", req.GetStoreId())
 
 	q := commands.NewReadChangesQuery(s.datastore, s.logger, s.encoder, s.changelogHorizonOffset)
 	return q.Execute(ctx, req)
@@ -804,6 +2177,10 @@ func (s *Server) CreateStore(ctx context.Context, req *openfgav1.CreateStoreRequ
 		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
 		Method:  "CreateStore",
 	})
+	ctx = s.contextWithRequestMetadata(ctx, "CreateStore", "")
+	if values := metadata.ValueFromIncomingContext(ctx, StoreResidencyHeader); len(values) > 0 && values[0] != "" {
+		requestcontext.SetStoreResidency(ctx, values[0])
+	}
 
 	c := commands.NewCreateStoreCommand(s.datastore, s.logger)
 	res, err := c.Execute(ctx, req)
@@ -811,6 +2188,10 @@ func (s *Server) CreateStore(ctx context.Context, req *openfgav1.CreateStoreRequ
 		return nil, err
 	}
 
+	setStoreResidencyHeader(ctx)
+
+	s.publishEvent(ctx, events.Event{Type: events.StoreCreated, StoreID: res.GetId()})
+
 	s.transport.SetHeader(ctx, httpmiddleware.XHttpCode, strconv.Itoa(http.StatusCreated))
 
 	return res, nil
@@ -830,6 +2211,7 @@ func (s *Server) DeleteStore(ctx context.Context, req *openfgav1.DeleteStoreRequ
 		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
 		Method:  "DeleteStore",
 	})
+	ctx = s.contextWithRequestMetadata(ctx, "DeleteStore", req.GetStoreId())
 
 	cmd := commands.NewDeleteStoreCommand(s.datastore, s.logger)
 	res, err := cmd.Execute(ctx, req)
@@ -837,6 +2219,9 @@ func (s *Server) DeleteStore(ctx context.Context, req *openfgav1.DeleteStoreRequ
 		return nil, err
 	}
 
+	s.purgeCheckCache(ctx, req.GetStoreId())
+	s.publishEvent(ctx, events.Event{Type: events.StoreDeleted, StoreID: req.GetStoreId()})
+
 	s.transport.SetHeader(ctx, httpmiddleware.XHttpCode, strconv.Itoa(http.StatusNoContent))
 
 	return res, nil
@@ -856,9 +2241,25 @@ func (s *Server) GetStore(ctx context.Context, req *openfgav1.GetStoreRequest) (
 		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
 		Method:  "GetStore",
 	})
+	ctx = s.contextWithRequestMetadata(ctx, "GetStore", req.GetStoreId())
 
 	q := commands.NewGetStoreQuery(s.datastore, s.logger)
-	return q.Execute(ctx, req)
+	res, err := q.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	setStoreResidencyHeader(ctx)
+
+	return res, nil
+}
+
+// setStoreResidencyHeader reports the region the data of the store of the request resides in, if the
+// datastore recorded it.
+func setStoreResidencyHeader(ctx context.Context) {
+	if region, ok := requestcontext.StoreResidency(ctx); ok {
+		_ = grpc.SetHeader(ctx, metadata.Pairs(StoreResidencyHeader, region))
+	}
 }
 
 func (s *Server) ListStores(ctx context.Context, req *openfgav1.ListStoresRequest) (*openfgav1.ListStoresResponse, error) {
@@ -875,6 +2276,7 @@ func (s *Server) ListStores(ctx context.Context, req *openfgav1.ListStoresReques
 		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
 		Method:  "ListStores",
 	})
+	ctx = s.contextWithRequestMetadata(ctx, "ListStores", "")
 
 	q := commands.NewListStoresQuery(s.datastore, s.logger, s.encoder)
 	return q.Execute(ctx, req)
@@ -889,12 +2291,156 @@ func (s *Server) IsReady(ctx context.Context) (bool, error) {
 	return s.datastore.IsReady(ctx)
 }
 
+func (s *Server) observeStoreDatastoreQueryCount(method, storeID string, queryCount float64) {
+	if s.storeLabeler == nil {
+		return
+	}
+
+	storeDatastoreQueryCountHistogram.WithLabelValues(
+		openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		method,
+		s.storeLabeler.Label(storeID),
+	).Observe(queryCount)
+}
+
+// contextWithRequestMetadata completes the requestcontext metadata of the request being served, which the
+// datastore reads as its storage.RequestMetadata.
+func (s *Server) contextWithRequestMetadata(ctx context.Context, method, storeID string) context.Context {
+	ctx = requestcontext.NewContext(ctx)
+	requestcontext.SetMethod(ctx, method)
+	if storeID != "" {
+		requestcontext.SetStoreID(ctx, storeID)
+	}
+
+	if _, ok := requestcontext.Caller(ctx); !ok {
+		if claims, ok := authn.AuthClaimsFromContext(ctx); ok {
+			requestcontext.SetCaller(ctx, claims.Subject)
+		}
+	}
+
+	return ctx
+}
+
+// resolveRequestTypesystem resolves the TypeSystem of a Check or ListObjects request, which is the one of the
+// inline model of the request, if it has the InlineAuthorizationModelHeader header, and otherwise the one of
+// the model of the store given by resolveTypesystem. It reports whether the model is an inline model.
+func (s *Server) resolveRequestTypesystem(ctx context.Context, storeID, modelID string) (*typesystem.TypeSystem, bool, error) {
+	values := metadata.ValueFromIncomingContext(ctx, InlineAuthorizationModelHeader)
+	if len(values) == 0 || values[0] == "" {
+		typesys, err := s.resolveTypesystem(ctx, storeID, modelID)
+		return typesys, false, err
+	}
+
+	if !s.inlineModelsEnabled {
+		return nil, false, serverErrors.ValidationError(fmt.Errorf("the '%s' header requires the inline models to be enabled", InlineAuthorizationModelHeader))
+	}
+
+	if modelID != "" {
+		return nil, false, serverErrors.ValidationError(fmt.Errorf("the '%s' header and an authorization model ID are mutually exclusive", InlineAuthorizationModelHeader))
+	}
+
+	typesys, err := s.newInlineTypesystem(ctx, values[0])
+	if err != nil {
+		return nil, false, err
+	}
+
+	return typesys, true, nil
+}
+
+// requestContextualTuples returns the contextual tuples of the ContextualTuplesHeader header of an Expand or
+// Read request, if any, validated against the model of the typesys, or against the latest model of the store
+// if typesys is nil.
+func (s *Server) requestContextualTuples(ctx context.Context, storeID string, typesys *typesystem.TypeSystem) ([]*openfgav1.TupleKey, error) {
+	values := metadata.ValueFromIncomingContext(ctx, ContextualTuplesHeader)
+	if len(values) == 0 || values[0] == "" {
+		return nil, nil
+	}
+
+	contextualTuples := &openfgav1.ContextualTupleKeys{}
+	if err := protojson.Unmarshal([]byte(values[0]), contextualTuples); err != nil {
+		return nil, serverErrors.ValidationError(fmt.Errorf("invalid '%s' header: %w", ContextualTuplesHeader, err))
+	}
+	if err := contextualTuples.Validate(); err != nil {
+		return nil, serverErrors.ValidationError(fmt.Errorf("invalid '%s' header: %w", ContextualTuplesHeader, err))
+	}
+
+	if typesys == nil {
+		var err error
+		if typesys, err = s.resolveTypesystem(ctx, storeID, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, ctxTuple := range contextualTuples.GetTupleKeys() {
+		if err := validation.ValidateTuple(typesys, ctxTuple); err != nil {
+			return nil, serverErrors.HandleTupleValidateError(ctx, err)
+		}
+	}
+
+	return contextualTuples.GetTupleKeys(), nil
+}
+
+// newInlineTypesystem validates the inline model, given as the JSON encoding of its schema version and type
+// definitions, and returns its TypeSystem. The model is given a new ID, which isn't the ID of any model of
+// the store.
+func (s *Server) newInlineTypesystem(ctx context.Context, encoded string) (*typesystem.TypeSystem, error) {
+	ctx, span := tracer.Start(ctx, "newInlineTypesystem")
+	defer span.End()
+
+	if len(encoded) > s.inlineModelMaxSizeInBytes {
+		return nil, status.Error(
+			codes.Code(openfgav1.ErrorCode_exceeded_entity_limit),
+			fmt.Sprintf("inline model exceeds size limit: %d bytes vs %d bytes", len(encoded), s.inlineModelMaxSizeInBytes),
+		)
+	}
+
+	model := &openfgav1.AuthorizationModel{}
+	if err := protojson.Unmarshal([]byte(encoded), model); err != nil {
+		return nil, serverErrors.ValidationError(fmt.Errorf("invalid '%s' header: %w", InlineAuthorizationModelHeader, err))
+	}
+	model.Id = ulid.Make().String()
+
+	typesys, err := typesystem.NewAndValidate(ctx, model, s.typesystemOpts...)
+	if err != nil {
+		return nil, serverErrors.InvalidAuthorizationModelInput(err)
+	}
+
+	// the ID isn't reported in the AuthorizationModelIDHeader header, since it can't be used by other requests
+	span.SetAttributes(attribute.KeyValue{Key: authorizationModelIDKey, Value: attribute.StringValue(model.GetId())})
+
+	return typesys, nil
+}
+
 // resolveTypesystem resolves the underlying TypeSystem given the storeID and modelID and
 // it sets some response metadata based on the model resolution.
 func (s *Server) resolveTypesystem(ctx context.Context, storeID, modelID string) (*typesystem.TypeSystem, error) {
 	ctx, span := tracer.Start(ctx, "resolveTypesystem")
 	defer span.End()
 
+	// the malformed IDs are rejected before any lookup, since they can't be the ID of any model
+	if modelID != "" {
+		if _, err := ulid.Parse(modelID); err != nil {
+			return nil, serverErrors.InvalidAuthorizationModelID(modelID)
+		}
+	}
+
+	if modelID == "" {
+		if values := metadata.ValueFromIncomingContext(ctx, AuthorizationModelLabelHeader); len(values) > 0 && values[0] != "" {
+			key, value, err := commands.ParseAuthorizationModelLabel(values[0])
+			if err != nil {
+				return nil, serverErrors.ValidationError(err)
+			}
+
+			modelID, err = s.datastore.FindAuthorizationModelIDByLabel(ctx, storeID, key, value)
+			if err != nil {
+				if errors.Is(err, storage.ErrNotFound) {
+					return nil, serverErrors.AuthorizationModelLabelNotFound(values[0])
+				}
+				return nil, serverErrors.HandleError(ctx, "", err)
+			}
+		}
+	}
+
 	typesys, err := s.typesystemResolver(ctx, storeID, modelID)
 	if err != nil {
 		if errors.Is(err, typesystem.ErrModelNotFound) {
@@ -909,13 +2455,14 @@ func (s *Server) resolveTypesystem(ctx context.Context, storeID, modelID string)
 			return nil, serverErrors.ValidationError(err)
 		}
 
-		return nil, serverErrors.HandleError("", err)
+		return nil, serverErrors.HandleError(ctx, "", err)
 	}
 
 	resolvedModelID := typesys.GetAuthorizationModelID()
 
+	requestcontext.SetAuthorizationModelID(ctx, resolvedModelID)
+
 	span.SetAttributes(attribute.KeyValue{Key: authorizationModelIDKey, Value: attribute.StringValue(resolvedModelID)})
-	grpc_ctxtags.Extract(ctx).Set(authorizationModelIDKey, resolvedModelID)
 	_ = grpc.SetHeader(ctx, metadata.Pairs(AuthorizationModelIDHeader, resolvedModelID))
 
 	return typesys, nil
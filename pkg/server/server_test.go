@@ -8,6 +8,7 @@ import (
 	"os"
 	"path"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,7 +18,10 @@ import (
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	mockstorage "github.com/openfga/openfga/internal/mocks"
 	serverconfig "github.com/openfga/openfga/internal/server/config"
+	"github.com/openfga/openfga/pkg/encoder"
+	"github.com/openfga/openfga/pkg/events"
 	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/modelvalidation"
 	"github.com/openfga/openfga/pkg/server/commands"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/server/test"
@@ -30,10 +34,15 @@ import (
 	storagefixtures "github.com/openfga/openfga/pkg/testfixtures/storage"
 	"github.com/openfga/openfga/pkg/tuple"
 	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 func init() {
@@ -63,6 +72,918 @@ func TestServerPanicIfEmptyRequestDurationDatastoreCountBuckets(t *testing.T) {
 	})
 }
 
+func TestServerWithDatastoreMaintenance(t *testing.T) {
+	t.Run("schedules_the_tasks_of_the_datastore", func(t *testing.T) {
+		ds := memory.New()
+		defer ds.Close()
+
+		s, err := NewServerWithOpts(
+			WithDatastore(ds),
+			WithDatastoreMaintenanceSchedule("@daily"),
+			WithDatastoreMaintenanceTasks("compaction"),
+		)
+		require.NoError(t, err)
+		require.NotNil(t, s.datastoreMaintenanceScheduler)
+
+		s.Close()
+	})
+
+	t.Run("invalid_schedule", func(t *testing.T) {
+		ds := memory.New()
+		defer ds.Close()
+
+		_, err := NewServerWithOpts(
+			WithDatastore(ds),
+			WithDatastoreMaintenanceSchedule("@yearly"),
+		)
+		require.EqualError(t, err, "invalid schedule '@yearly': expected 5 fields, got 1")
+	})
+
+	t.Run("unknown_task", func(t *testing.T) {
+		ds := memory.New()
+		defer ds.Close()
+
+		_, err := NewServerWithOpts(
+			WithDatastore(ds),
+			WithDatastoreMaintenanceSchedule("@daily"),
+			WithDatastoreMaintenanceTasks("vacuum"),
+		)
+		require.EqualError(t, err, "the datastore has no 'vacuum' maintenance task")
+	})
+}
+
+func TestWriteAuthorizationModelWithValidator(t *testing.T) {
+	ds := memory.New()
+	defer ds.Close()
+
+	validator, err := modelvalidation.NewCELValidator([]string{
+		`model.types.all(t, size(t.relations) == 0 || t.relations.exists(r, r.name == "owner"))`,
+	})
+	require.NoError(t, err)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithAuthorizationModelValidator(validator),
+	)
+	defer s.Close()
+
+	ctx := context.Background()
+	store := ulid.Make().String()
+
+	t.Run("accepted", func(t *testing.T) {
+		_, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:       store,
+			SchemaVersion: typesystem.SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(`
+			type user
+			type document
+			  relations
+			    define owner: [user] as self
+			`),
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		_, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:       store,
+			SchemaVersion: typesystem.SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(`
+			type user
+			type document
+			  relations
+			    define viewer: [user] as self
+			`),
+		})
+		require.Error(t, err)
+		e, ok := status.FromError(err)
+		require.True(t, ok)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_invalid_authorization_model), e.Code())
+		require.Contains(t, e.Message(), "the authorization model violates the rule")
+	})
+}
+
+type headerCapturingStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (s *headerCapturingStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestWriteAuthorizationModelWithIdenticalModelsSkipped(t *testing.T) {
+	ds := memory.New()
+	defer ds.Close()
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithIdenticalAuthorizationModelsSkipped(true),
+	)
+	defer s.Close()
+
+	store := ulid.Make().String()
+
+	write := func(t *testing.T, model string) (string, bool) {
+		stream := &headerCapturingStream{}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+
+		resp, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         store,
+			SchemaVersion:   typesystem.SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(model),
+		})
+		require.NoError(t, err)
+
+		return resp.GetAuthorizationModelId(), len(stream.header.Get(AuthorizationModelUnchangedHeader)) > 0
+	}
+
+	firstID, unchanged := write(t, `
+	type user
+	type group
+	  relations
+	    define member: [user] as self
+	type document
+	  relations
+	    define editor: [user, group#member] as self
+	    define viewer: [user] as self or editor
+	`)
+	require.False(t, unchanged)
+
+	t.Run("identical_model", func(t *testing.T) {
+		// the same model, with the types and the operands in another order
+		id, unchanged := write(t, `
+		type document
+		  relations
+		    define editor: [group#member, user] as self
+		    define viewer: [user] as editor or self
+		type user
+		type group
+		  relations
+		    define member: [user] as self
+		`)
+		require.True(t, unchanged)
+		require.Equal(t, firstID, id)
+
+		models, _, err := ds.ReadAuthorizationModels(context.Background(), store, storage.PaginationOptions{PageSize: 10})
+		require.NoError(t, err)
+		require.Len(t, models, 1)
+	})
+
+	t.Run("changed_model", func(t *testing.T) {
+		id, unchanged := write(t, `
+		type user
+		type group
+		  relations
+		    define member: [user] as self
+		type document
+		  relations
+		    define editor: [user, group#member] as self
+		    define viewer: [user] as self
+		`)
+		require.False(t, unchanged)
+		require.NotEqual(t, firstID, id)
+	})
+}
+
+func TestAuthorizationModelLabels(t *testing.T) {
+	ds := memory.New()
+	defer ds.Close()
+
+	s := MustNewServerWithOpts(WithDatastore(ds))
+	defer s.Close()
+
+	ctx := context.Background()
+	store := ulid.Make().String()
+
+	write := func(t *testing.T, labels string) string {
+		ctx := metadata.NewIncomingContext(ctx, metadata.Pairs(AuthorizationModelLabelsHeader, labels))
+		resp, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:       store,
+			SchemaVersion: typesystem.SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(`
+			type user
+			type document
+			  relations
+			    define viewer: [user] as self
+			`),
+		})
+		require.NoError(t, err)
+
+		return resp.GetAuthorizationModelId()
+	}
+
+	releaseModelID := write(t, "release=42,git_sha=3f2a9c1")
+	latestModelID := write(t, "release=43")
+
+	t.Run("get_by_label", func(t *testing.T) {
+		resp, err := s.GetAuthorizationModelByLabel(ctx, &commands.GetAuthorizationModelByLabelRequest{StoreID: store, Label: "release=42"})
+		require.NoError(t, err)
+		require.Equal(t, releaseModelID, resp.AuthorizationModel.GetId())
+		require.Equal(t, map[string]string{"release": "42", "git_sha": "3f2a9c1"}, resp.Labels)
+
+		_, err = s.GetAuthorizationModelByLabel(ctx, &commands.GetAuthorizationModelByLabelRequest{StoreID: store, Label: "release=44"})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_authorization_model_not_found), status.Code(err))
+
+		_, err = s.GetAuthorizationModelByLabel(ctx, &commands.GetAuthorizationModelByLabelRequest{StoreID: store, Label: "release"})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+	})
+
+	t.Run("requests_pinned_by_label", func(t *testing.T) {
+		check := func(t *testing.T, md metadata.MD) string {
+			stream := &headerCapturingStream{}
+			ctx := grpc.NewContextWithServerTransportStream(metadata.NewIncomingContext(ctx, md), stream)
+
+			_, err := s.Check(ctx, &openfgav1.CheckRequest{
+				StoreId:  store,
+				TupleKey: tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+			})
+			require.NoError(t, err)
+
+			return stream.header.Get(AuthorizationModelIDHeader)[0]
+		}
+
+		require.Equal(t, releaseModelID, check(t, metadata.Pairs(AuthorizationModelLabelHeader, "release=42")))
+		require.Equal(t, latestModelID, check(t, metadata.MD{}))
+	})
+
+	t.Run("invalid_labels", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(ctx, metadata.Pairs(AuthorizationModelLabelsHeader, "release"))
+		_, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         store,
+			SchemaVersion:   typesystem.SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(`type user`),
+		})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+	})
+}
+
+func TestChecksAgainstInlineModels(t *testing.T) {
+	ds := memory.New()
+	defer ds.Close()
+
+	ctx := context.Background()
+	store := ulid.Make().String()
+
+	_, err := ds.CreateStore(ctx, &openfgav1.Store{Id: store, Name: "docs"})
+	require.NoError(t, err)
+
+	s := MustNewServerWithOpts(WithDatastore(ds), WithInlineModelsEnabled(true))
+	defer s.Close()
+
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:       store,
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+		type document
+		  relations
+		    define editor: [user] as self
+		    define viewer: [user] as self
+		`),
+	})
+	require.NoError(t, err)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: store,
+		Writes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:roadmap", "editor", "user:anne"),
+		}},
+	})
+	require.NoError(t, err)
+
+	// the editors are viewers in the inline model
+	inlineModel, err := protojson.Marshal(&openfgav1.AuthorizationModel{
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+		type document
+		  relations
+		    define editor: [user] as self
+		    define viewer: [user] as self or editor
+		`),
+	})
+	require.NoError(t, err)
+
+	inlineContext := metadata.NewIncomingContext(ctx, metadata.Pairs(InlineAuthorizationModelHeader, string(inlineModel)))
+	checkRequest := &openfgav1.CheckRequest{
+		StoreId:  store,
+		TupleKey: tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+	}
+
+	t.Run("check", func(t *testing.T) {
+		resp, err := s.Check(inlineContext, checkRequest)
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+
+		resp, err = s.Check(ctx, checkRequest)
+		require.NoError(t, err)
+		require.False(t, resp.GetAllowed())
+	})
+
+	t.Run("list_objects", func(t *testing.T) {
+		resp, err := s.ListObjects(inlineContext, &openfgav1.ListObjectsRequest{
+			StoreId:  store,
+			Type:     "document",
+			Relation: "viewer",
+			User:     "user:anne",
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"document:roadmap"}, resp.GetObjects())
+	})
+
+	t.Run("not_persisted", func(t *testing.T) {
+		resp, err := s.ReadAuthorizationModels(ctx, &openfgav1.ReadAuthorizationModelsRequest{StoreId: store})
+		require.NoError(t, err)
+		require.Len(t, resp.GetAuthorizationModels(), 1)
+	})
+
+	t.Run("invalid_requests", func(t *testing.T) {
+		_, err := s.Check(inlineContext, &openfgav1.CheckRequest{
+			StoreId:              store,
+			AuthorizationModelId: ulid.Make().String(),
+			TupleKey:             checkRequest.GetTupleKey(),
+		})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+
+		invalidContext := metadata.NewIncomingContext(ctx, metadata.Pairs(InlineAuthorizationModelHeader, `{"schema_version":"1.1","type_definitions":[{"type":"user"},{"type":"user"}]}`))
+		_, err = s.Check(invalidContext, checkRequest)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_invalid_authorization_model), status.Code(err))
+
+		limited := MustNewServerWithOpts(WithDatastore(ds), WithInlineModelsEnabled(true), WithInlineModelMaxSizeInBytes(16))
+		defer limited.Close()
+
+		_, err = limited.Check(inlineContext, checkRequest)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_exceeded_entity_limit), status.Code(err))
+
+		disabled := MustNewServerWithOpts(WithDatastore(ds))
+		defer disabled.Close()
+
+		_, err = disabled.Check(inlineContext, checkRequest)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+	})
+}
+
+func TestReadGuardrails(t *testing.T) {
+	ds := memory.New()
+	defer ds.Close()
+
+	ctx := context.Background()
+	store := ulid.Make().String()
+
+	s := MustNewServerWithOpts(WithDatastore(ds), WithReadGuardrails(commands.ReadGuardrails{
+		RejectUnfiltered:     true,
+		MinFilterDimensions:  3,
+		LargeStoreTupleCount: 2,
+	}))
+	defer s.Close()
+
+	_, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:       store,
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+		type document
+		  relations
+		    define viewer: [user] as self
+		`),
+	})
+	require.NoError(t, err)
+
+	write := func(t *testing.T, tk *openfgav1.TupleKey) {
+		_, err := s.Write(ctx, &openfgav1.WriteRequest{
+			StoreId: store,
+			Writes:  &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{tk}},
+		})
+		require.NoError(t, err)
+	}
+
+	requireReadTooBroad := func(t *testing.T, err error) {
+		st, ok := status.FromError(err)
+		require.True(t, ok)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), st.Code())
+		require.Len(t, st.Details(), 1)
+		require.Equal(t, serverErrors.ReasonReadTooBroad, st.Details()[0].(*errdetails.ErrorInfo).GetReason())
+	}
+
+	broadRead := &openfgav1.ReadRequest{StoreId: store, TupleKey: tuple.NewTupleKey("document:roadmap", "", "")}
+
+	write(t, tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"))
+
+	_, err = s.Read(ctx, &openfgav1.ReadRequest{StoreId: store})
+	requireReadTooBroad(t, err)
+
+	// the store isn't large yet
+	_, err = s.Read(ctx, broadRead)
+	require.NoError(t, err)
+
+	write(t, tuple.NewTupleKey("document:roadmap", "viewer", "user:bob"))
+	write(t, tuple.NewTupleKey("document:budget", "viewer", "user:bob"))
+
+	_, err = s.Read(ctx, broadRead)
+	requireReadTooBroad(t, err)
+
+	resp, err := s.Read(ctx, &openfgav1.ReadRequest{StoreId: store, TupleKey: tuple.NewTupleKey("document:roadmap", "viewer", "")})
+	require.NoError(t, err)
+	require.Len(t, resp.GetTuples(), 2)
+}
+func TestExpandAndReadWithContextualTuples(t *testing.T) {
+	ds := memory.New()
+	defer ds.Close()
+
+	ctx := context.Background()
+	store := ulid.Make().String()
+
+	s := MustNewServerWithOpts(WithDatastore(ds))
+	defer s.Close()
+
+	_, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:       store,
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+		type document
+		  relations
+		    define viewer: [user] as self
+		`),
+	})
+	require.NoError(t, err)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: store,
+		Writes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+		}},
+	})
+	require.NoError(t, err)
+
+	contextualTuples, err := protojson.Marshal(&openfgav1.ContextualTupleKeys{TupleKeys: []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:roadmap", "viewer", "user:bob"),
+		tuple.NewTupleKey("document:budget", "viewer", "user:bob"),
+	}})
+	require.NoError(t, err)
+
+	contextualContext := metadata.NewIncomingContext(ctx, metadata.Pairs(ContextualTuplesHeader, string(contextualTuples)))
+
+	t.Run("expand", func(t *testing.T) {
+		expandRequest := &openfgav1.ExpandRequest{
+			StoreId:  store,
+			TupleKey: tuple.NewTupleKey("document:roadmap", "viewer", ""),
+		}
+
+		resp, err := s.Expand(contextualContext, expandRequest)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"user:anne", "user:bob"}, resp.GetTree().GetRoot().GetLeaf().GetUsers().GetUsers())
+
+		resp, err = s.Expand(ctx, expandRequest)
+		require.NoError(t, err)
+		require.Equal(t, []string{"user:anne"}, resp.GetTree().GetRoot().GetLeaf().GetUsers().GetUsers())
+	})
+
+	t.Run("read", func(t *testing.T) {
+		resp, err := s.Read(contextualContext, &openfgav1.ReadRequest{
+			StoreId:  store,
+			TupleKey: tuple.NewTupleKey("document:roadmap", "", ""),
+		})
+		require.NoError(t, err)
+
+		var read []string
+		for _, t := range resp.GetTuples() {
+			read = append(read, tuple.TupleKeyToString(t.GetKey()))
+		}
+		require.ElementsMatch(t, []string{"document:roadmap#viewer@user:anne", "document:roadmap#viewer@user:bob"}, read)
+
+		resp, err = s.Read(contextualContext, &openfgav1.ReadRequest{
+			StoreId:  store,
+			TupleKey: tuple.NewTupleKey("document:", "viewer", "user:bob"),
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.GetTuples(), 2)
+
+		// the contextual tuples aren't written
+		resp, err = s.Read(ctx, &openfgav1.ReadRequest{StoreId: store})
+		require.NoError(t, err)
+		require.Len(t, resp.GetTuples(), 1)
+	})
+
+	t.Run("invalid_contextual_tuples", func(t *testing.T) {
+		tests := map[string]struct {
+			header string
+			code   openfgav1.ErrorCode
+		}{
+			"malformed": {
+				header: `{"tuple_keys":`,
+				code:   openfgav1.ErrorCode_validation_error,
+			},
+			"too_many": {
+				header: `{"tuple_keys":[` + strings.Repeat(`{"object":"document:1","relation":"viewer","user":"user:bob"},`, 10) + `{"object":"document:1","relation":"viewer","user":"user:bob"}]}`,
+				code:   openfgav1.ErrorCode_validation_error,
+			},
+			"invalid_tuple": {
+				header: `{"tuple_keys":[{"object":"document:roadmap","relation":"editor","user":"user:bob"}]}`,
+				code:   openfgav1.ErrorCode_invalid_tuple,
+			},
+		}
+
+		for name, test := range tests {
+			t.Run(name, func(t *testing.T) {
+				invalidContext := metadata.NewIncomingContext(ctx, metadata.Pairs(ContextualTuplesHeader, test.header))
+
+				_, err := s.Read(invalidContext, &openfgav1.ReadRequest{StoreId: store})
+				require.Equal(t, codes.Code(test.code), status.Code(err))
+			})
+		}
+	})
+}
+
+func TestConditions(t *testing.T) {
+	ds := memory.New()
+	defer ds.Close()
+
+	ctx := context.Background()
+	store := ulid.Make().String()
+
+	s := MustNewServerWithOpts(WithDatastore(ds))
+	defer s.Close()
+
+	modelContext := metadata.NewIncomingContext(ctx, metadata.Pairs(AuthorizationModelConditionsHeader, `{
+		"conditions": [{"name": "ip_allowed", "expression": "ip in allowed_ips", "parameters": {"ip": "string", "allowed_ips": "list"}}],
+		"type_restrictions": [{"type": "document", "relation": "viewer", "user_type": "user", "condition": "ip_allowed"}]
+	}`))
+
+	writeModelResp, err := s.WriteAuthorizationModel(modelContext, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:       store,
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+		type document
+		  relations
+		    define viewer: [user] as self
+		`),
+	})
+	require.NoError(t, err)
+
+	writeContext := metadata.NewIncomingContext(ctx, metadata.Pairs(TupleConditionsHeader, `[{
+		"tuple_key": {"object": "document:roadmap", "relation": "viewer", "user": "user:anne"},
+		"condition": {"name": "ip_allowed", "context": {"allowed_ips": ["10.0.0.1"]}}
+	}]`))
+
+	_, err = s.Write(writeContext, &openfgav1.WriteRequest{
+		StoreId: store,
+		Writes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+			tuple.NewTupleKey("document:roadmap", "viewer", "user:bob"),
+		}},
+	})
+	require.NoError(t, err)
+
+	check := func(ctx context.Context, user string) (bool, error) {
+		resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  store,
+			TupleKey: tuple.NewTupleKey("document:roadmap", "viewer", user),
+		})
+		return resp.GetAllowed(), err
+	}
+
+	t.Run("condition_met", func(t *testing.T) {
+		allowed, err := check(metadata.NewIncomingContext(ctx, metadata.Pairs(ConditionContextHeader, `{"ip": "10.0.0.1"}`)), "user:anne")
+		require.NoError(t, err)
+		require.True(t, allowed)
+
+		listObjectsResp, err := s.ListObjects(metadata.NewIncomingContext(ctx, metadata.Pairs(ConditionContextHeader, `{"ip": "10.0.0.1"}`)), &openfgav1.ListObjectsRequest{
+			StoreId:  store,
+			Type:     "document",
+			Relation: "viewer",
+			User:     "user:anne",
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"document:roadmap"}, listObjectsResp.GetObjects())
+	})
+
+	t.Run("condition_not_met", func(t *testing.T) {
+		allowed, err := check(metadata.NewIncomingContext(ctx, metadata.Pairs(ConditionContextHeader, `{"ip": "10.0.0.2"}`)), "user:anne")
+		require.NoError(t, err)
+		require.False(t, allowed)
+
+		listObjectsResp, err := s.ListObjects(metadata.NewIncomingContext(ctx, metadata.Pairs(ConditionContextHeader, `{"ip": "10.0.0.2"}`)), &openfgav1.ListObjectsRequest{
+			StoreId:  store,
+			Type:     "document",
+			Relation: "viewer",
+			User:     "user:anne",
+		})
+		require.NoError(t, err)
+		require.Empty(t, listObjectsResp.GetObjects())
+	})
+
+	t.Run("unconditioned_tuple", func(t *testing.T) {
+		allowed, err := check(ctx, "user:bob")
+		require.NoError(t, err)
+		require.True(t, allowed)
+	})
+
+	t.Run("missing_parameters", func(t *testing.T) {
+		_, err := check(ctx, "user:anne")
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+		require.ErrorContains(t, err, "missing condition parameters")
+	})
+
+	t.Run("condition_not_allowed", func(t *testing.T) {
+		_, err := s.Write(metadata.NewIncomingContext(ctx, metadata.Pairs(TupleConditionsHeader, `[{
+			"tuple_key": {"object": "document:budget", "relation": "viewer", "user": "user:anne"},
+			"condition": {"name": "business_hours"}
+		}]`)), &openfgav1.WriteRequest{
+			StoreId: store,
+			Writes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:budget", "viewer", "user:anne"),
+			}},
+		})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+	})
+
+	t.Run("invalid_model_conditions", func(t *testing.T) {
+		_, err := s.WriteAuthorizationModel(metadata.NewIncomingContext(ctx, metadata.Pairs(AuthorizationModelConditionsHeader, `{
+			"conditions": [{"name": "invalid", "expression": "ip =="}]
+		}`)), &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         store,
+			SchemaVersion:   typesystem.SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(`type user`),
+		})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_invalid_authorization_model), status.Code(err))
+	})
+
+	t.Run("simulate_write", func(t *testing.T) {
+		simulate := func(conditionContext map[string]interface{}) *commands.SimulateWriteResponse {
+			resp, err := s.SimulateWrite(ctx, &commands.SimulateWriteRequest{
+				StoreID: store,
+				Writes: []*openfgav1.TupleKey{
+					tuple.NewTupleKey("document:budget", "viewer", "user:anne"),
+				},
+				Checks: []*openfgav1.TupleKey{
+					tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+				},
+				ListObjects: []*commands.SimulatedListObjectsQuery{
+					{Type: "document", Relation: "viewer", User: "user:anne"},
+				},
+				Context: conditionContext,
+			})
+			require.NoError(t, err)
+			return resp
+		}
+
+		resp := simulate(map[string]interface{}{"ip": "10.0.0.1"})
+		require.True(t, resp.Checks[0].Allowed)
+		require.ElementsMatch(t, []string{"document:roadmap", "document:budget"}, resp.ListObjects[0].Objects)
+
+		resp = simulate(map[string]interface{}{"ip": "10.0.0.2"})
+		require.False(t, resp.Checks[0].Allowed)
+		require.Equal(t, []string{"document:budget"}, resp.ListObjects[0].Objects)
+	})
+
+	t.Run("shadow_check", func(t *testing.T) {
+		// the candidate model has the same conditions, so it agrees with the model that serves the Check
+		candidateResp, err := s.WriteAuthorizationModel(modelContext, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:       store,
+			SchemaVersion: typesystem.SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(`
+			type user
+			type document
+			  relations
+			    define viewer: [user] as self
+			`),
+		})
+		require.NoError(t, err)
+
+		shadowServer := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithShadowCheckCandidates(NewShadowCheckCandidates(map[string]string{store: candidateResp.GetAuthorizationModelId()})),
+			WithShadowCheckSampleRate(1),
+		)
+
+		before := testutil.ToFloat64(shadowCheckCounter.WithLabelValues(shadowCheckResultMatch))
+
+		resp, err := shadowServer.Check(metadata.NewIncomingContext(ctx, metadata.Pairs(ConditionContextHeader, `{"ip": "10.0.0.2"}`)), &openfgav1.CheckRequest{
+			StoreId:              store,
+			AuthorizationModelId: writeModelResp.GetAuthorizationModelId(),
+			TupleKey:             tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+		require.False(t, resp.GetAllowed())
+
+		shadowServer.Close()
+
+		require.Equal(t, before+1, testutil.ToFloat64(shadowCheckCounter.WithLabelValues(shadowCheckResultMatch)))
+	})
+
+	t.Run("model_conditions_read_back", func(t *testing.T) {
+		conditions, err := ds.(storage.ConditionsBackend).ReadAuthorizationModelConditions(ctx, store, writeModelResp.GetAuthorizationModelId())
+		require.NoError(t, err)
+		require.Len(t, conditions.Conditions, 1)
+		require.Equal(t, "ip_allowed", conditions.Conditions[0].Name)
+	})
+}
+
+func TestTupleExpirations(t *testing.T) {
+	ds := memory.New()
+	defer ds.Close()
+
+	ctx := context.Background()
+	store := ulid.Make().String()
+
+	s := MustNewServerWithOpts(WithDatastore(ds))
+	defer s.Close()
+
+	_, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:       store,
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+		type document
+		  relations
+		    define viewer: [user] as self
+		`),
+	})
+	require.NoError(t, err)
+
+	expiresAt := time.Now().Add(200 * time.Millisecond)
+	writeContext := metadata.NewIncomingContext(ctx, metadata.Pairs(TupleExpirationsHeader, fmt.Sprintf(`[{
+		"tuple_key": {"object": "document:roadmap", "relation": "viewer", "user": "user:anne"},
+		"expires_at": %q
+	}]`, expiresAt.Format(time.RFC3339Nano))))
+
+	_, err = s.Write(writeContext, &openfgav1.WriteRequest{
+		StoreId: store,
+		Writes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+			tuple.NewTupleKey("document:roadmap", "viewer", "user:bob"),
+		}},
+	})
+	require.NoError(t, err)
+
+	check := func(user string) bool {
+		resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  store,
+			TupleKey: tuple.NewTupleKey("document:roadmap", "viewer", user),
+		})
+		require.NoError(t, err)
+		return resp.GetAllowed()
+	}
+
+	require.True(t, check("user:anne"))
+	require.True(t, check("user:bob"))
+
+	t.Run("expired_tuples_are_deleted", func(t *testing.T) {
+		time.Sleep(time.Until(expiresAt))
+
+		require.False(t, check("user:anne"))
+		require.True(t, check("user:bob"))
+
+		resp, err := s.Read(ctx, &openfgav1.ReadRequest{
+			StoreId:  store,
+			TupleKey: tuple.NewTupleKey("document:roadmap", "", ""),
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.GetTuples(), 1)
+		require.Equal(t, "user:bob", resp.GetTuples()[0].GetKey().GetUser())
+	})
+
+	t.Run("invalid_expirations", func(t *testing.T) {
+		tests := map[string]string{
+			"past":        `[{"tuple_key": {"object": "document:budget", "relation": "viewer", "user": "user:anne"}, "expires_at": "2020-01-01T00:00:00Z"}]`,
+			"not_written": `[{"tuple_key": {"object": "document:other", "relation": "viewer", "user": "user:anne"}, "expires_at": "2999-01-01T00:00:00Z"}]`,
+			"no_time":     `[{"tuple_key": {"object": "document:budget", "relation": "viewer", "user": "user:anne"}}]`,
+			"malformed":   `{`,
+		}
+		for name, header := range tests {
+			t.Run(name, func(t *testing.T) {
+				_, err := s.Write(metadata.NewIncomingContext(ctx, metadata.Pairs(TupleExpirationsHeader, header)), &openfgav1.WriteRequest{
+					StoreId: store,
+					Writes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{
+						tuple.NewTupleKey("document:budget", "viewer", "user:anne"),
+					}},
+				})
+				require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+			})
+		}
+	})
+
+	t.Run("expirations_and_conditions", func(t *testing.T) {
+		_, err := s.Write(metadata.NewIncomingContext(ctx, metadata.Pairs(
+			TupleExpirationsHeader, `[{"tuple_key": {"object": "document:budget", "relation": "viewer", "user": "user:anne"}, "expires_at": "2999-01-01T00:00:00Z"}]`,
+			TupleConditionsHeader, `[{"tuple_key": {"object": "document:budget", "relation": "viewer", "user": "user:anne"}, "condition": {"name": "ip_allowed"}}]`,
+		)), &openfgav1.WriteRequest{
+			StoreId: store,
+			Writes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:budget", "viewer", "user:anne"),
+			}},
+		})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+		require.ErrorContains(t, err, "can't both carry conditions and expire")
+	})
+}
+
+func TestWritePreconditions(t *testing.T) {
+	ds := memory.New()
+	defer ds.Close()
+
+	ctx := context.Background()
+	store := ulid.Make().String()
+
+	s := MustNewServerWithOpts(WithDatastore(ds))
+	defer s.Close()
+
+	_, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:       store,
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+		type document
+		  relations
+		    define owner: [user] as self
+		`),
+	})
+	require.NoError(t, err)
+
+	// shareOwnership shares the ownership of the document with another user, only if the user sharing it still
+	// owns it
+	shareOwnership := func(from, to string) error {
+		_, err := s.Write(metadata.NewIncomingContext(ctx, metadata.Pairs(WritePreconditionsHeader, fmt.Sprintf(`[
+			{"tuple_key": {"object": "document:roadmap", "relation": "owner", "user": %q}, "exists": true}
+		]`, from))), &openfgav1.WriteRequest{
+			StoreId: store,
+			Writes:  &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:roadmap", "owner", to)}},
+		})
+		return err
+	}
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: store,
+		Writes:  &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:roadmap", "owner", "user:anne")}},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, shareOwnership("user:anne", "user:bob"))
+
+	err = shareOwnership("user:carl", "user:dan")
+	require.Equal(t, codes.Code(openfgav1.ErrorCode_write_failed_due_to_invalid_input), status.Code(err))
+	require.ErrorContains(t, err, "the tuple doesn't exist")
+
+	_, err = s.Write(metadata.NewIncomingContext(ctx, metadata.Pairs(WritePreconditionsHeader, `{`)), &openfgav1.WriteRequest{
+		StoreId: store,
+		Writes:  &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:roadmap", "owner", "user:dan")}},
+	})
+	require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+}
+
+func TestReadAuthorizationModelsWithEncryptedContinuationTokens(t *testing.T) {
+	ds := memory.New()
+	defer ds.Close()
+
+	tokenEncoder, err := encoder.NewAESGCMEncoder("key", time.Hour)
+	require.NoError(t, err)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithTokenEncoder(tokenEncoder),
+	)
+	defer s.Close()
+
+	ctx := context.Background()
+	store := ulid.Make().String()
+
+	for i := 0; i < 2; i++ {
+		_, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         store,
+			SchemaVersion:   typesystem.SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(`type user`),
+		})
+		require.NoError(t, err)
+	}
+
+	resp, err := s.ReadAuthorizationModels(ctx, &openfgav1.ReadAuthorizationModelsRequest{
+		StoreId:  store,
+		PageSize: wrapperspb.Int32(1),
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.GetAuthorizationModels(), 1)
+	require.NotEmpty(t, resp.GetContinuationToken())
+
+	_, err = s.ReadAuthorizationModels(ctx, &openfgav1.ReadAuthorizationModelsRequest{
+		StoreId:           ulid.Make().String(),
+		PageSize:          wrapperspb.Int32(1),
+		ContinuationToken: resp.GetContinuationToken(),
+	})
+	require.ErrorIs(t, err, serverErrors.ContinuationTokenStoreMismatch)
+
+	resp, err = s.ReadAuthorizationModels(ctx, &openfgav1.ReadAuthorizationModelsRequest{
+		StoreId:           store,
+		PageSize:          wrapperspb.Int32(1),
+		ContinuationToken: resp.GetContinuationToken(),
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.GetAuthorizationModels(), 1)
+}
+
 func TestServerWithPostgresDatastore(t *testing.T) {
 	ds := MustBootstrapDatastore(t, "postgres")
 	defer ds.Close()
@@ -177,15 +1098,15 @@ func TestCheckDoesNotThrowBecauseDirectTupleWasFound(t *testing.T) {
 
 	// it could happen that one of the following two mocks won't be necessary because the goroutine will be short-circuited
 	mockDatastore.EXPECT().
-		ReadUserTuple(gomock.Any(), storeID, gomock.Any()).
+		ReadUserTuple(gomock.Any(), storeID, gomock.Any(), gomock.Any()).
 		AnyTimes().
 		Return(tuple, nil)
 
 	mockDatastore.EXPECT().
-		ReadUsersetTuples(gomock.Any(), storeID, gomock.Any()).
+		ReadUsersetTuples(gomock.Any(), storeID, gomock.Any(), gomock.Any()).
 		AnyTimes().
 		DoAndReturn(
-			func(_ context.Context, _ string, _ storage.ReadUsersetTuplesFilter) (storage.TupleIterator, error) {
+			func(_ context.Context, _ string, _ storage.ReadUsersetTuplesFilter, _ storage.ReadOptions) (storage.TupleIterator, error) {
 				time.Sleep(50 * time.Millisecond)
 				return nil, errors.New("some error")
 			})
@@ -387,10 +1308,10 @@ func TestShortestPathToSolutionWins(t *testing.T) {
 
 	// it could happen that one of the following two mocks won't be necessary because the goroutine will be short-circuited
 	mockDatastore.EXPECT().
-		ReadUserTuple(gomock.Any(), storeID, gomock.Any()).
+		ReadUserTuple(gomock.Any(), storeID, gomock.Any(), gomock.Any()).
 		AnyTimes().
 		DoAndReturn(
-			func(ctx context.Context, _ string, _ *openfgav1.TupleKey) (storage.TupleIterator, error) {
+			func(ctx context.Context, _ string, _ *openfgav1.TupleKey, _ storage.ReadOptions) (storage.TupleIterator, error) {
 				select {
 				case <-ctx.Done():
 					return nil, ctx.Err()
@@ -400,10 +1321,10 @@ func TestShortestPathToSolutionWins(t *testing.T) {
 			})
 
 	mockDatastore.EXPECT().
-		ReadUsersetTuples(gomock.Any(), storeID, gomock.Any()).
+		ReadUsersetTuples(gomock.Any(), storeID, gomock.Any(), gomock.Any()).
 		AnyTimes().
 		DoAndReturn(
-			func(_ context.Context, _ string, _ storage.ReadUsersetTuplesFilter) (storage.TupleIterator, error) {
+			func(_ context.Context, _ string, _ storage.ReadUsersetTuplesFilter, _ storage.ReadOptions) (storage.TupleIterator, error) {
 				time.Sleep(100 * time.Millisecond)
 				return storage.NewStaticTupleIterator([]*openfgav1.Tuple{tuple}), nil
 			})
@@ -457,7 +1378,7 @@ func TestCheckWithCachedResolution(t *testing.T) {
 		}, nil)
 
 	mockDatastore.EXPECT().
-		ReadUserTuple(gomock.Any(), storeID, gomock.Any()).
+		ReadUserTuple(gomock.Any(), storeID, gomock.Any(), gomock.Any()).
 		Times(1).
 		Return(tuple, nil)
 
@@ -658,7 +1579,7 @@ func TestResolveAuthorizationModel(t *testing.T) {
 	t.Run("non-valid_modelID_returns_error", func(t *testing.T) {
 		store := ulid.Make().String()
 		modelID := "foo"
-		want := serverErrors.AuthorizationModelNotFound(modelID)
+		want := serverErrors.InvalidAuthorizationModelID(modelID)
 
 		mockController := gomock.NewController(t)
 		defer mockController.Finish()
@@ -714,7 +1635,7 @@ func BenchmarkListObjectsNoRaceCondition(b *testing.B) {
 		SchemaVersion:   typesystem.SchemaVersion1_1,
 		TypeDefinitions: typedefs,
 	}, nil)
-	mockDatastore.EXPECT().ReadStartingWithUser(gomock.Any(), store, gomock.Any()).AnyTimes().Return(nil, errors.New("error reading from storage"))
+	mockDatastore.EXPECT().ReadStartingWithUser(gomock.Any(), store, gomock.Any(), gomock.Any()).AnyTimes().Return(nil, errors.New("error reading from storage"))
 
 	s := MustNewServerWithOpts(
 		WithDatastore(mockDatastore),
@@ -777,7 +1698,7 @@ func TestListObjects_ErrorCases(t *testing.T) {
 			UserFilter: []*openfgav1.ObjectRelation{
 				{Object: "user:*"},
 				{Object: "user:bob"},
-			}}).AnyTimes().Return(nil, errors.New("error reading from storage"))
+			}}, gomock.Any()).AnyTimes().Return(nil, errors.New("error reading from storage"))
 
 		t.Run("error_listing_objects_from_storage_in_non-streaming_version", func(t *testing.T) {
 			res, err := s.ListObjects(ctx, &openfgav1.ListObjectsRequest{
@@ -1034,3 +1955,516 @@ func MustBootstrapDatastore(t testing.TB, engine string) storage.OpenFGADatastor
 
 	return ds
 }
+
+func TestCheckRelations(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+
+	model := &openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type folder
+		  relations
+		    define viewer: [user] as self
+
+		type document
+		  relations
+		    define parent: [folder] as self
+		    define owner: [user] as self
+		    define editor: [user] as self or owner
+		    define viewer: [user] as self or editor or viewer from parent
+		`),
+	}
+	require.NoError(t, ds.WriteAuthorizationModel(ctx, storeID, model))
+
+	require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "parent", "folder:1"),
+		tuple.NewTupleKey("document:1", "editor", "user:anne"),
+		tuple.NewTupleKey("folder:1", "viewer", "user:bob"),
+	}))
+
+	for _, cacheEnabled := range []bool{false, true} {
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithCheckQueryCacheEnabled(cacheEnabled),
+		)
+		t.Cleanup(s.Close)
+
+		t.Run(fmt.Sprintf("cache_enabled_%t", cacheEnabled), func(t *testing.T) {
+			tests := []struct {
+				name             string
+				user             string
+				relations        []string
+				contextualTuples []*openfgav1.TupleKey
+				expected         map[string]bool
+				expectedError    error
+			}{
+				{
+					name:      "editor",
+					user:      "user:anne",
+					relations: []string{"owner", "editor", "viewer"},
+					expected:  map[string]bool{"owner": false, "editor": true, "viewer": true},
+				},
+				{
+					name:      "viewer_through_parent",
+					user:      "user:bob",
+					relations: []string{"owner", "editor", "viewer", "viewer"},
+					expected:  map[string]bool{"owner": false, "editor": false, "viewer": true},
+				},
+				{
+					name:             "contextual_tuples",
+					user:             "user:charlie",
+					relations:        []string{"owner", "editor", "viewer"},
+					contextualTuples: []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "owner", "user:charlie")},
+					expected:         map[string]bool{"owner": true, "editor": true, "viewer": true},
+				},
+				{
+					name:          "no_relations",
+					user:          "user:anne",
+					expectedError: serverErrors.ValidationError(errors.New("at least one relation must be provided")),
+				},
+				{
+					name:          "undefined_relation",
+					user:          "user:anne",
+					relations:     []string{"viewer", "commenter"},
+					expectedError: serverErrors.ValidationError(&tuple.RelationNotFoundError{TypeName: "document", Relation: "commenter"}),
+				},
+			}
+
+			for _, test := range tests {
+				t.Run(test.name, func(t *testing.T) {
+					resp, err := s.CheckRelations(ctx, &commands.CheckRelationsRequest{
+						StoreID:          storeID,
+						Object:           "document:1",
+						User:             test.user,
+						Relations:        test.relations,
+						ContextualTuples: test.contextualTuples,
+					})
+					if test.expectedError != nil {
+						require.ErrorIs(t, err, test.expectedError)
+						return
+					}
+
+					require.NoError(t, err)
+					require.Equal(t, test.expected, resp.Relations)
+				})
+			}
+		})
+	}
+}
+
+func TestBatchCheck(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+
+	model := &openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type folder
+		  relations
+		    define viewer: [user] as self
+
+		type document
+		  relations
+		    define parent: [folder] as self
+		    define editor: [user] as self
+		    define viewer: [user] as self or editor or viewer from parent
+		`),
+	}
+	require.NoError(t, ds.WriteAuthorizationModel(ctx, storeID, model))
+
+	require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "parent", "folder:1"),
+		tuple.NewTupleKey("document:1", "editor", "user:anne"),
+		tuple.NewTupleKey("folder:1", "viewer", "user:bob"),
+	}))
+
+	t.Run("per_key_results", func(t *testing.T) {
+		s := MustNewServerWithOpts(WithDatastore(ds))
+		t.Cleanup(s.Close)
+
+		tupleKeys := []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+			tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+			tuple.NewTupleKey("document:1", "editor", "user:bob"),
+			tuple.NewTupleKey("document:1", "commenter", "user:bob"),
+			tuple.NewTupleKey("document:1", "viewer", ""),
+			tuple.NewTupleKey("document:2", "editor", "user:charlie"),
+		}
+
+		resp, err := s.BatchCheck(ctx, &commands.BatchCheckRequest{
+			StoreID:          storeID,
+			TupleKeys:        tupleKeys,
+			ContextualTuples: []*openfgav1.TupleKey{tuple.NewTupleKey("document:2", "editor", "user:charlie")},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Results, len(tupleKeys))
+
+		for i, result := range resp.Results {
+			require.Equal(t, tupleKeys[i], result.TupleKey)
+		}
+
+		require.True(t, resp.Results[0].Allowed)
+		require.NoError(t, resp.Results[0].Error)
+		require.True(t, resp.Results[1].Allowed)
+		require.False(t, resp.Results[2].Allowed)
+		require.NoError(t, resp.Results[2].Error)
+		require.ErrorIs(t, resp.Results[3].Error, serverErrors.ValidationError(&tuple.RelationNotFoundError{TypeName: "document", Relation: "commenter"}))
+		require.ErrorIs(t, resp.Results[4].Error, serverErrors.InvalidCheckInput)
+		require.True(t, resp.Results[5].Allowed)
+	})
+
+	t.Run("too_many_tuple_keys", func(t *testing.T) {
+		s := MustNewServerWithOpts(WithDatastore(ds), WithMaxChecksPerBatchCheck(1))
+		t.Cleanup(s.Close)
+
+		_, err := s.BatchCheck(ctx, &commands.BatchCheckRequest{
+			StoreID: storeID,
+			TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+				tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+			},
+		})
+		require.ErrorIs(t, err, serverErrors.ExceededEntityLimit("tuple keys of a batch check", 1))
+	})
+
+	t.Run("no_tuple_keys", func(t *testing.T) {
+		s := MustNewServerWithOpts(WithDatastore(ds))
+		t.Cleanup(s.Close)
+
+		_, err := s.BatchCheck(ctx, &commands.BatchCheckRequest{StoreID: storeID})
+		require.ErrorIs(t, err, serverErrors.ValidationError(errors.New("at least one tuple key must be provided")))
+	})
+
+	t.Run("shared_datastore_query_budget", func(t *testing.T) {
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithMaxConcurrentChecksPerBatchCheck(1),
+			WithBatchCheckMaxDatastoreQueries(1),
+		)
+		t.Cleanup(s.Close)
+
+		resp, err := s.BatchCheck(ctx, &commands.BatchCheckRequest{
+			StoreID: storeID,
+			TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+				tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+			},
+		})
+		require.NoError(t, err)
+		require.True(t, resp.Results[0].Allowed)
+		require.ErrorIs(t, resp.Results[1].Error, errBatchCheckBudgetExhausted)
+	})
+}
+
+func TestWriteInvalidatesCheckQueryCache(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+
+	model := &openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type folder
+		  relations
+		    define viewer: [user] as self
+
+		type document
+		  relations
+		    define parent: [folder] as self
+		    define viewer: [user] as self or viewer from parent
+		    define unrelated: [user] as self
+		`),
+	}
+	require.NoError(t, ds.WriteAuthorizationModel(ctx, storeID, model))
+	require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "parent", "folder:1"),
+	}))
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithCheckQueryCacheEnabled(true),
+		WithCheckQueryCacheTTL(time.Hour),
+	)
+	t.Cleanup(s.Close)
+
+	check := func(t *testing.T, relation string) bool {
+		resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewTupleKey("document:1", relation, "user:anne"),
+		})
+		require.NoError(t, err)
+		return resp.GetAllowed()
+	}
+
+	require.False(t, check(t, "viewer"))
+	require.False(t, check(t, "unrelated"))
+
+	_, err := s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{
+			tuple.NewTupleKey("folder:1", "viewer", "user:anne"),
+		}},
+	})
+	require.NoError(t, err)
+
+	// the results of document#viewer depend on folder#viewer, so they're invalidated
+	require.True(t, check(t, "viewer"))
+
+	// the results of document#unrelated don't, so they stay cached even though they're stale now
+	require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "unrelated", "user:anne"),
+	}))
+	require.False(t, check(t, "unrelated"))
+}
+
+func TestCheckQueryCacheChangelogInvalidation(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	store, err := ds.CreateStore(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: "store"})
+	require.NoError(t, err)
+	storeID := store.GetId()
+
+	model := &openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type document
+		  relations
+		    define editor: [user] as self
+		    define viewer as editor
+		`),
+	}
+	require.NoError(t, ds.WriteAuthorizationModel(ctx, storeID, model))
+	require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "editor", "user:bob"),
+	}))
+
+	// the servers share the datastore, as if they were the nodes of a deployment
+	reader := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithCheckQueryCacheEnabled(true),
+		WithCheckQueryCacheTTL(time.Hour),
+		WithCheckQueryCacheChangelogInterval(10*time.Millisecond),
+	)
+	t.Cleanup(reader.Close)
+
+	writer := MustNewServerWithOpts(WithDatastore(ds))
+	t.Cleanup(writer.Close)
+
+	check := func() bool {
+		resp, err := reader.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+		return resp.GetAllowed()
+	}
+
+	require.False(t, check())
+
+	_, err = writer.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:1", "editor", "user:anne"),
+		}},
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, check, time.Second, 10*time.Millisecond)
+}
+
+func TestSimulateWrite(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+
+	model := &openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type folder
+		  relations
+		    define viewer: [user] as self
+
+		type document
+		  relations
+		    define parent: [folder] as self
+		    define viewer: [user] as self or viewer from parent
+		`),
+	}
+	require.NoError(t, ds.WriteAuthorizationModel(ctx, storeID, model))
+
+	require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "parent", "folder:1"),
+		tuple.NewTupleKey("document:2", "viewer", "user:anne"),
+	}))
+
+	s := MustNewServerWithOpts(WithDatastore(ds))
+	t.Cleanup(s.Close)
+
+	t.Run("queries_see_the_writes", func(t *testing.T) {
+		resp, err := s.SimulateWrite(ctx, &commands.SimulateWriteRequest{
+			StoreID: storeID,
+			Writes: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("folder:1", "viewer", "user:anne"),
+			},
+			Checks: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+				tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+			},
+			ListObjects: []*commands.SimulatedListObjectsQuery{
+				{Type: "document", Relation: "viewer", User: "user:anne"},
+			},
+		})
+		require.NoError(t, err)
+
+		require.Equal(t, []*commands.SimulatedCheckResult{
+			{Object: "document:1", Relation: "viewer", User: "user:anne", Allowed: true},
+			{Object: "document:1", Relation: "viewer", User: "user:bob", Allowed: false},
+		}, resp.Checks)
+
+		require.Len(t, resp.ListObjects, 1)
+		require.ElementsMatch(t, []string{"document:1", "document:2"}, resp.ListObjects[0].Objects)
+	})
+
+	t.Run("writes_are_not_persisted", func(t *testing.T) {
+		checkResp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+		require.False(t, checkResp.GetAllowed())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name          string
+			writes        []*openfgav1.TupleKey
+			checks        []*openfgav1.TupleKey
+			expectedError error
+		}{
+			{
+				name:          "no_writes",
+				checks:        []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")},
+				expectedError: serverErrors.ValidationError(errors.New("at least one write must be provided")),
+			},
+			{
+				name:          "no_queries",
+				writes:        []*openfgav1.TupleKey{tuple.NewTupleKey("folder:1", "viewer", "user:anne")},
+				expectedError: serverErrors.ValidationError(errors.New("at least one query must be provided")),
+			},
+			{
+				name: "duplicate_writes",
+				writes: []*openfgav1.TupleKey{
+					tuple.NewTupleKey("folder:1", "viewer", "user:anne"),
+					tuple.NewTupleKey("folder:1", "viewer", "user:anne"),
+				},
+				checks:        []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")},
+				expectedError: serverErrors.DuplicateTupleInWrite(tuple.NewTupleKey("folder:1", "viewer", "user:anne")),
+			},
+			{
+				name:          "invalid_check",
+				writes:        []*openfgav1.TupleKey{tuple.NewTupleKey("folder:1", "viewer", "user:anne")},
+				checks:        []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "editor", "user:anne")},
+				expectedError: serverErrors.ValidationError(&tuple.RelationNotFoundError{TypeName: "document", Relation: "editor"}),
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				_, err := s.SimulateWrite(ctx, &commands.SimulateWriteRequest{
+					StoreID: storeID,
+					Writes:  test.writes,
+					Checks:  test.checks,
+				})
+				require.ErrorIs(t, err, test.expectedError)
+			})
+		}
+	})
+}
+
+// eventRecorder is an events.Publisher that records the events it publishes.
+type eventRecorder struct {
+	events []events.Event
+}
+
+func (r *eventRecorder) Publish(_ context.Context, event events.Event) {
+	r.events = append(r.events, event)
+}
+
+func TestServerPublishesEvents(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	recorder := &eventRecorder{}
+	s := MustNewServerWithOpts(WithDatastore(ds), WithEventPublisher(recorder))
+	t.Cleanup(s.Close)
+
+	createStoreResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "events"})
+	require.NoError(t, err)
+	storeID := createStoreResp.GetId()
+
+	writeModelResp, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:       storeID,
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type document
+		  relations
+		    define viewer: [user] as self
+		`),
+	})
+	require.NoError(t, err)
+	modelID := writeModelResp.GetAuthorizationModelId()
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		}},
+	})
+	require.NoError(t, err)
+
+	_, err = s.DeleteStore(ctx, &openfgav1.DeleteStoreRequest{StoreId: storeID})
+	require.NoError(t, err)
+
+	require.Equal(t, []events.Event{
+		{Type: events.StoreCreated, StoreID: storeID},
+		{Type: events.AuthorizationModelWritten, StoreID: storeID, AuthorizationModelID: modelID},
+		{
+			Type:                 events.TuplesWritten,
+			StoreID:              storeID,
+			AuthorizationModelID: modelID,
+			Attributes:           map[string]string{"writes": "1", "deletes": "0"},
+		},
+		{Type: events.StoreDeleted, StoreID: storeID},
+	}, recorder.events)
+}
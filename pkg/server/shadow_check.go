@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"sync"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/internal/graph"
+	"github.com/openfga/openfga/internal/validation"
+	"github.com/openfga/openfga/pkg/storage/storagewrappers"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	shadowCheckResultMatch    = "match"
+	shadowCheckResultMismatch = "mismatch"
+	shadowCheckResultError    = "error"
+	shadowCheckResultDropped  = "dropped"
+)
+
+var shadowCheckCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "shadow_check_total",
+	Help: "The total number of Checks evaluated against the candidate authorization model of their store, labeled by whether the candidate model agreed with the model that served the Check. Checks that are dropped because too many shadow evaluations are in flight are labeled 'dropped'.",
+}, []string{"result"})
+
+// ShadowCheckCandidates holds the candidate authorization model of the stores whose Checks are also
+// evaluated, asynchronously, against that model. It's safe for concurrent use, so that candidates can be
+// changed while serving.
+type ShadowCheckCandidates struct {
+	mu     sync.RWMutex
+	models map[string]string
+}
+
+// NewShadowCheckCandidates constructs a ShadowCheckCandidates holding the provided candidate models,
+// keyed by store ID.
+func NewShadowCheckCandidates(models map[string]string) *ShadowCheckCandidates {
+	c := &ShadowCheckCandidates{models: make(map[string]string, len(models))}
+	for storeID, modelID := range models {
+		c.models[storeID] = modelID
+	}
+
+	return c
+}
+
+// Set sets the candidate model of a store.
+func (c *ShadowCheckCandidates) Set(storeID, modelID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.models[storeID] = modelID
+}
+
+// Delete stops the shadow evaluation of the Checks of a store.
+func (c *ShadowCheckCandidates) Delete(storeID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.models, storeID)
+}
+
+// Get returns the candidate model of a store, if any.
+func (c *ShadowCheckCandidates) Get(storeID string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	modelID, ok := c.models[storeID]
+	return modelID, ok
+}
+
+// List returns the candidate models, keyed by store ID.
+func (c *ShadowCheckCandidates) List() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	models := make(map[string]string, len(c.models))
+	for storeID, modelID := range c.models {
+		models[storeID] = modelID
+	}
+
+	return models
+}
+
+// shadowCheck evaluates a sample of the Checks of the stores with a candidate model against that model,
+// asynchronously, and reports whether the result matches the one of the model that served the Check. The
+// conditions of the tuples are evaluated with the condition context of the Check.
+func (s *Server) shadowCheck(ctx context.Context, req *openfgav1.CheckRequest, conditionContext map[string]interface{}, modelID string, allowed bool) {
+	if s.shadowCheckCandidates == nil {
+		return
+	}
+
+	candidateModelID, ok := s.shadowCheckCandidates.Get(req.GetStoreId())
+	if !ok || candidateModelID == modelID {
+		return
+	}
+
+	if s.shadowCheckRandom() >= s.shadowCheckSampleRate {
+		return
+	}
+
+	select {
+	case s.shadowCheckLimiter <- struct{}{}:
+	default:
+		shadowCheckCounter.WithLabelValues(shadowCheckResultDropped).Inc()
+		return
+	}
+
+	// the shadow evaluation outlives the request, so it only keeps the trace the request belongs to
+	shadowCtx := trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+
+	s.shadowCheckWG.Add(1)
+	go func() {
+		defer func() {
+			<-s.shadowCheckLimiter
+			s.shadowCheckWG.Done()
+		}()
+
+		ctx, cancel := context.WithTimeout(shadowCtx, s.shadowCheckTimeout)
+		defer cancel()
+
+		ctx, span := tracer.Start(ctx, "ShadowCheck", trace.WithAttributes(
+			attribute.String(authorizationModelIDKey, candidateModelID),
+		))
+		defer span.End()
+
+		fields := []zap.Field{
+			zap.String("store_id", req.GetStoreId()),
+			zap.String(authorizationModelIDKey, modelID),
+			zap.String("candidate_authorization_model_id", candidateModelID),
+			zap.String("tuple_key", tuple.TupleKeyToString(req.GetTupleKey())),
+			zap.Bool("allowed", allowed),
+		}
+
+		candidateAllowed, err := s.evaluateShadowCheck(ctx, req, conditionContext, candidateModelID)
+		if err != nil {
+			shadowCheckCounter.WithLabelValues(shadowCheckResultError).Inc()
+			s.logger.Warn("shadow check failed", append(fields, zap.Error(err))...)
+			return
+		}
+
+		if candidateAllowed != allowed {
+			shadowCheckCounter.WithLabelValues(shadowCheckResultMismatch).Inc()
+			s.logger.Warn("shadow check mismatch", append(fields, zap.Bool("candidate_allowed", candidateAllowed))...)
+			return
+		}
+
+		shadowCheckCounter.WithLabelValues(shadowCheckResultMatch).Inc()
+	}()
+}
+
+func (s *Server) evaluateShadowCheck(ctx context.Context, req *openfgav1.CheckRequest, conditionContext map[string]interface{}, modelID string) (bool, error) {
+	typesys, err := s.typesystemResolver(ctx, req.GetStoreId(), modelID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := validation.ValidateUserObjectRelation(typesys, req.GetTupleKey()); err != nil {
+		return false, err
+	}
+
+	for _, ctxTuple := range req.GetContextualTuples().GetTupleKeys() {
+		if err := validation.ValidateTuple(typesys, ctxTuple); err != nil {
+			return false, err
+		}
+	}
+
+	ctx = typesystem.ContextWithTypesystem(ctx, typesys)
+
+	checkResolver := graph.NewLocalChecker(
+		storagewrappers.NewCombinedTupleReader(s.conditionsTupleReader(typesys, conditionContext), req.GetContextualTuples().GetTupleKeys()),
+		s.checkOptions...,
+	)
+	defer checkResolver.Close()
+
+	resp, err := checkResolver.ResolveCheck(ctx, &graph.ResolveCheckRequest{
+		StoreID:              req.GetStoreId(),
+		AuthorizationModelID: typesys.GetAuthorizationModelID(),
+		TupleKey:             req.GetTupleKey(),
+		ContextualTuples:     req.GetContextualTuples().GetTupleKeys(),
+		Context:              conditionContext,
+		ResolutionMetadata: &graph.ResolutionMetadata{
+			Depth: s.resolveNodeLimit,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return resp.GetAllowed(), nil
+}
+
+// Close stops the scheduled maintenance of the datastore and waits for the asynchronous work of the server
+// (e.g. the shadow evaluations of Checks) to complete.
+// It must be called before closing the datastore.
+func (s *Server) Close() {
+	if s.datastoreMaintenanceScheduler != nil {
+		s.datastoreMaintenanceScheduler.Stop()
+	}
+
+	if s.checkCacheChangelogTailer != nil {
+		s.checkCacheChangelogTailer.Stop()
+	}
+
+	s.shadowCheckWG.Wait()
+}
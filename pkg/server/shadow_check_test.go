@@ -0,0 +1,173 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowCheckCandidates(t *testing.T) {
+	candidates := NewShadowCheckCandidates(map[string]string{"store1": "model1"})
+
+	modelID, ok := candidates.Get("store1")
+	require.True(t, ok)
+	require.Equal(t, "model1", modelID)
+
+	candidates.Set("store2", "model2")
+	require.Equal(t, map[string]string{"store1": "model1", "store2": "model2"}, candidates.List())
+
+	candidates.Delete("store1")
+	_, ok = candidates.Get("store1")
+	require.False(t, ok)
+}
+
+func TestShadowCheck(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	defer ds.Close()
+
+	storeID := ulid.Make().String()
+
+	writeModel := func(t *testing.T, dsl string) string {
+		model := &openfgav1.AuthorizationModel{
+			Id:              ulid.Make().String(),
+			SchemaVersion:   typesystem.SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(dsl),
+		}
+		require.NoError(t, ds.WriteAuthorizationModel(ctx, storeID, model))
+		return model.GetId()
+	}
+
+	primaryModelID := writeModel(t, `
+	type user
+
+	type document
+	  relations
+	    define editor: [user] as self
+	    define viewer: [user] as self or editor
+	`)
+
+	// the candidate model no longer lets the editors view the documents
+	candidateModelID := writeModel(t, `
+	type user
+
+	type document
+	  relations
+	    define editor: [user] as self
+	    define viewer: [user] as self
+	`)
+
+	brokenCandidateModelID := writeModel(t, `
+	type user
+
+	type folder
+	  relations
+	    define viewer: [user] as self
+	`)
+
+	require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:1", "editor", "user:bob"),
+	}))
+
+	tests := []struct {
+		name             string
+		candidateModelID string
+		user             string
+		expectedResult   string
+	}{
+		{
+			name:             "match",
+			candidateModelID: candidateModelID,
+			user:             "user:anne",
+			expectedResult:   shadowCheckResultMatch,
+		},
+		{
+			name:             "mismatch",
+			candidateModelID: candidateModelID,
+			user:             "user:bob",
+			expectedResult:   shadowCheckResultMismatch,
+		},
+		{
+			name:             "invalid_request_for_the_candidate_model",
+			candidateModelID: brokenCandidateModelID,
+			user:             "user:anne",
+			expectedResult:   shadowCheckResultError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := MustNewServerWithOpts(
+				WithDatastore(ds),
+				WithShadowCheckCandidates(NewShadowCheckCandidates(map[string]string{storeID: test.candidateModelID})),
+				WithShadowCheckSampleRate(1),
+			)
+
+			before := testutil.ToFloat64(shadowCheckCounter.WithLabelValues(test.expectedResult))
+
+			resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+				StoreId:              storeID,
+				AuthorizationModelId: primaryModelID,
+				TupleKey:             tuple.NewTupleKey("document:1", "viewer", test.user),
+			})
+			require.NoError(t, err)
+			require.True(t, resp.GetAllowed())
+
+			s.Close()
+
+			require.Equal(t, before+1, testutil.ToFloat64(shadowCheckCounter.WithLabelValues(test.expectedResult)))
+		})
+	}
+
+	t.Run("not_sampled", func(t *testing.T) {
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithShadowCheckCandidates(NewShadowCheckCandidates(map[string]string{storeID: candidateModelID})),
+			WithShadowCheckSampleRate(0),
+		)
+
+		before := testutil.ToFloat64(shadowCheckCounter.WithLabelValues(shadowCheckResultMismatch))
+
+		_, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:              storeID,
+			AuthorizationModelId: primaryModelID,
+			TupleKey:             tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+		})
+		require.NoError(t, err)
+
+		s.Close()
+
+		require.Equal(t, before, testutil.ToFloat64(shadowCheckCounter.WithLabelValues(shadowCheckResultMismatch)))
+	})
+
+	t.Run("dropped_without_capacity", func(t *testing.T) {
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithShadowCheckCandidates(NewShadowCheckCandidates(map[string]string{storeID: candidateModelID})),
+			WithShadowCheckSampleRate(1),
+			WithShadowCheckMaxConcurrency(0),
+		)
+
+		before := testutil.ToFloat64(shadowCheckCounter.WithLabelValues(shadowCheckResultDropped))
+
+		_, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:              storeID,
+			AuthorizationModelId: primaryModelID,
+			TupleKey:             tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+		})
+		require.NoError(t, err)
+
+		s.Close()
+
+		require.Equal(t, before+1, testutil.ToFloat64(shadowCheckCounter.WithLabelValues(shadowCheckResultDropped)))
+	})
+}
@@ -0,0 +1,56 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/openfga/openfga/internal/graph"
+	serverconfig "github.com/openfga/openfga/internal/server/config"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/testfixtures/generator"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+// Used to avoid compiler optimizations (see https://dave.cheney.net/2013/06/30/how-to-write-benchmarks-in-go)
+var checkResponse *graph.ResolveCheckResponse //nolint
+
+// BenchmarkCheckWithFixtures checks the sampled tuple keys of the generated fixture of every shape (see the
+// generator package) one after the other.
+func BenchmarkCheckWithFixtures(b *testing.B, ds storage.OpenFGADatastore) {
+	for _, shape := range generator.Shapes() {
+		b.Run(string(shape), func(b *testing.B) {
+			ctx := context.Background()
+			store := ulid.Make().String()
+
+			fixture := generator.MustGenerate(shape, generator.DefaultConfig())
+			modelID, err := fixture.Write(ctx, ds, store)
+			require.NoError(b, err)
+
+			typesys, err := typesystem.NewAndValidate(ctx, fixture.Model)
+			require.NoError(b, err)
+			ctx = typesystem.ContextWithTypesystem(ctx, typesys)
+
+			checker := graph.NewLocalChecker(ds)
+			defer checker.Close()
+
+			var r *graph.ResolveCheckResponse
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				r, err = checker.ResolveCheck(ctx, &graph.ResolveCheckRequest{
+					StoreID:              store,
+					AuthorizationModelID: modelID,
+					TupleKey:             fixture.Checks[i%len(fixture.Checks)],
+					ResolutionMetadata: &graph.ResolutionMetadata{
+						Depth: serverconfig.DefaultResolveNodeLimit,
+					},
+				})
+				require.NoError(b, err)
+			}
+
+			checkResponse = r
+		})
+	}
+}
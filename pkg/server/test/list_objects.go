@@ -15,6 +15,7 @@ import (
 	"github.com/openfga/openfga/internal/mocks"
 	"github.com/openfga/openfga/pkg/server/commands"
 	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/testfixtures/generator"
 	"github.com/openfga/openfga/pkg/tuple"
 	"github.com/openfga/openfga/pkg/typesystem"
 	"github.com/stretchr/testify/require"
@@ -419,3 +420,40 @@ func BenchmarkListObjects(b *testing.B, ds storage.OpenFGADatastore) {
 
 	require.Greater(b, oneResultIterations, allResultsIterations)
 }
+
+func BenchmarkListObjectsWithFixtures(b *testing.B, ds storage.OpenFGADatastore) {
+	for _, shape := range generator.Shapes() {
+		b.Run(string(shape), func(b *testing.B) {
+			ctx := context.Background()
+			store := ulid.Make().String()
+
+			fixture := generator.MustGenerate(shape, generator.DefaultConfig())
+			modelID, err := fixture.Write(ctx, ds, store)
+			require.NoError(b, err)
+
+			typesys, err := typesystem.NewAndValidate(ctx, fixture.Model)
+			require.NoError(b, err)
+			ctx = typesystem.ContextWithTypesystem(ctx, typesys)
+
+			listObjectsQuery := commands.NewListObjectsQuery(ds)
+
+			var r *commands.ListObjectsResponse
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// the objects of the type and the relation of a tuple key checked, which the user is related to
+				check := fixture.Checks[i%len(fixture.Checks)]
+				r, err = listObjectsQuery.Execute(ctx, &openfgav1.ListObjectsRequest{
+					StoreId:              store,
+					AuthorizationModelId: modelID,
+					Type:                 tuple.GetType(check.GetObject()),
+					Relation:             check.GetRelation(),
+					User:                 check.GetUser(),
+				})
+				require.NoError(b, err)
+			}
+
+			listObjectsResponse = r
+		})
+	}
+}
@@ -55,6 +55,7 @@ func RunQueryTests(t *testing.T, ds storage.OpenFGADatastore) {
 func RunCommandTests(t *testing.T, ds storage.OpenFGADatastore) {
 	t.Run("TestWriteCommand", func(t *testing.T) { TestWriteCommand(t, ds) })
 	t.Run("TestWriteAuthorizationModel", func(t *testing.T) { WriteAuthorizationModelTest(t, ds) })
+	t.Run("TestWriteAuthorizationModelCopiesAssertionsForward", func(t *testing.T) { WriteAuthorizationModelCopiesAssertionsForwardTest(t, ds) })
 	t.Run("TestWriteAndReadAssertions", func(t *testing.T) { TestWriteAndReadAssertions(t, ds) })
 	t.Run("TestWriteAssertionsFailure", func(t *testing.T) { TestWriteAssertionsFailure(t, ds) })
 	t.Run("TestCreateStore", func(t *testing.T) { TestCreateStore(t, ds) })
@@ -63,4 +64,6 @@ func RunCommandTests(t *testing.T, ds storage.OpenFGADatastore) {
 
 func RunAllBenchmarks(b *testing.B, ds storage.OpenFGADatastore) {
 	b.Run("BenchmarkListObjects", func(b *testing.B) { BenchmarkListObjects(b, ds) })
+	b.Run("BenchmarkCheckWithFixtures", func(b *testing.B) { BenchmarkCheckWithFixtures(b, ds) })
+	b.Run("BenchmarkListObjectsWithFixtures", func(b *testing.B) { BenchmarkListObjectsWithFixtures(b, ds) })
 }
@@ -256,7 +256,7 @@ var writeCommandTests = []writeCommandTest{
 			Deletes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{tk, tk}},
 		},
 		// output
-		err: serverErrors.DuplicateTupleInWrite(tk),
+		err: serverErrors.DuplicateTupleInDelete(tk),
 	},
 	{
 		_name: "ExecuteWithSameTupleInWritesAndDeletesReturnsError",
@@ -278,7 +278,7 @@ var writeCommandTests = []writeCommandTest{
 			Deletes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{tk}},
 		},
 		// output
-		err: serverErrors.DuplicateTupleInWrite(tk),
+		err: serverErrors.ConflictingTupleInWriteAndDelete(tk),
 	},
 	{
 		_name: "ExecuteDeleteTupleWhichDoesNotExistReturnsError",
@@ -1223,6 +1223,57 @@ var writeCommandTests = []writeCommandTest{
 			},
 		),
 	},
+	{
+		_name: "ExecuteWithSeveralInvalidTuplesReturnsEveryError",
+		model: &openfgav1.AuthorizationModel{
+			Id:            ulid.Make().String(),
+			SchemaVersion: typesystem.SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(`
+			type user
+
+			type repo
+			  relations
+			    define writer: [user] as self
+				define viewer as writer
+			`),
+		},
+		request: &openfgav1.WriteRequest{
+			Writes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("repo:openfga/openfga", "viewer", "user:anne"),
+				tuple.NewTupleKey("repo:openfga/openfga", "writer", "user:anne"),
+				tuple.NewTupleKey("repo:openfga/openfga", "viewer", "user:bob"),
+			}},
+			Deletes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("repo:openfga/openfga", "writer", "user:anne:bob"),
+			}},
+		},
+		err: serverErrors.ValidationErrors([]serverErrors.FieldViolation{
+			{
+				Field: "writes.tuple_keys",
+				Index: 0,
+				Err: &tuple.InvalidTupleError{
+					Cause:    fmt.Errorf("type 'user' is not an allowed type restriction for 'repo#viewer'"),
+					TupleKey: tuple.NewTupleKey("repo:openfga/openfga", "viewer", "user:anne"),
+				},
+			},
+			{
+				Field: "writes.tuple_keys",
+				Index: 2,
+				Err: &tuple.InvalidTupleError{
+					Cause:    fmt.Errorf("type 'user' is not an allowed type restriction for 'repo#viewer'"),
+					TupleKey: tuple.NewTupleKey("repo:openfga/openfga", "viewer", "user:bob"),
+				},
+			},
+			{
+				Field: "deletes.tuple_keys",
+				Index: 0,
+				Err: &tuple.InvalidTupleError{
+					Cause:    fmt.Errorf("the 'user' field is malformed"),
+					TupleKey: tuple.NewTupleKey("repo:openfga/openfga", "writer", "user:anne:bob"),
+				},
+			},
+		}),
+	},
 }
 
 func TestWriteCommand(t *testing.T, datastore storage.OpenFGADatastore) {
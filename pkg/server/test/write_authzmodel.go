@@ -487,3 +487,66 @@ func WriteAuthorizationModelTest(t *testing.T, datastore storage.OpenFGADatastor
 		})
 	}
 }
+
+func WriteAuthorizationModelCopiesAssertionsForwardTest(t *testing.T, datastore storage.OpenFGADatastore) {
+	ctx := context.Background()
+	logger := logger.NewNoopLogger()
+	storeID := ulid.Make().String()
+
+	cmd := commands.NewWriteAuthorizationModelCommand(
+		datastore, logger, serverconfig.DefaultMaxAuthorizationModelSizeInBytes,
+		commands.WithAssertionsCopyForward(datastore),
+	)
+
+	resp, err := cmd.Execute(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:       storeID,
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type document
+		  relations
+			define editor: [user] as self
+			define viewer: [user] as self
+		`),
+	})
+	require.NoError(t, err)
+	previousModelID := resp.GetAuthorizationModelId()
+
+	err = datastore.WriteAssertions(ctx, storeID, previousModelID, []*openfgav1.Assertion{
+		{
+			TupleKey:    &openfgav1.TupleKey{Object: "document:1", Relation: "viewer", User: "user:anne"},
+			Expectation: true,
+		},
+		{
+			TupleKey:    &openfgav1.TupleKey{Object: "document:1", Relation: "editor", User: "user:anne"},
+			Expectation: false,
+		},
+	})
+	require.NoError(t, err)
+
+	// the new model drops the editor relation, so only the viewer assertion remains valid
+	resp, err = cmd.Execute(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:       storeID,
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type document
+		  relations
+			define viewer: [user] as self
+		`),
+	})
+	require.NoError(t, err)
+
+	assertions, err := datastore.ReadAssertions(ctx, storeID, resp.GetAuthorizationModelId())
+	require.NoError(t, err)
+	require.Len(t, assertions, 1)
+	require.Equal(t, "viewer", assertions[0].GetTupleKey().GetRelation())
+	require.True(t, assertions[0].GetExpectation())
+
+	// the assertions of the previous model are left untouched
+	assertions, err = datastore.ReadAssertions(ctx, storeID, previousModelID)
+	require.NoError(t, err)
+	require.Len(t, assertions, 2)
+}
@@ -0,0 +1,82 @@
+// Package cached contains decorators of the storage backends that cache their reads in process.
+package cached
+
+import (
+	"time"
+)
+
+const (
+	// DefaultMaxSize is the default maximum number of entries of a cache.
+	DefaultMaxSize = 10000
+
+	// DefaultModelTTL is the default TTL of the cached authorization models. The models are immutable, so they
+	// are only evicted when the cache is full or their store is invalidated.
+	DefaultModelTTL = 168 * time.Hour
+
+	// DefaultTupleTTL is the default TTL of the cached tuples, which bounds for how long the tuples written
+	// through other servers aren't seen.
+	DefaultTupleTTL = 10 * time.Second
+
+	// ModelCacheName is the name of the cache of the authorization models, reported to the requests that
+	// bypass it.
+	ModelCacheName = "authorization_models"
+
+	// LatestModelIDCacheName is the name of the cache of the latest model IDs, reported to the requests that
+	// bypass it.
+	LatestModelIDCacheName = "latest_authorization_model_ids"
+
+	// TupleCacheName is the name of the cache of the tuples, reported to the requests that bypass it.
+	TupleCacheName = "tuples"
+)
+
+type options struct {
+	maxSize          int
+	ttl              time.Duration
+	latestModelIDTTL time.Duration
+}
+
+// Option configures a cache.
+type Option func(*options)
+
+// WithMaxSize sets the maximum number of entries of the cache, beyond which the least recently used ones are
+// evicted. It defaults to DefaultMaxSize.
+func WithMaxSize(maxSize int) Option {
+	return func(o *options) {
+		o.maxSize = maxSize
+	}
+}
+
+// WithTTL sets the TTL of the cached entries. It defaults to DefaultModelTTL for the authorization models,
+// and to DefaultTupleTTL for the tuples.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithLatestModelIDTTL caches the ID of the latest authorization model of the stores for the TTL. The ID
+// cached for a store is invalidated when a model is written to the store through the cache, but the models
+// written by other servers are only seen once the TTL expires. 0, the default, disables the cache.
+func WithLatestModelIDTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.latestModelIDTTL = ttl
+	}
+}
+
+func newOptions(defaultTTL time.Duration, opts []Option) options {
+	o := options{
+		maxSize: DefaultMaxSize,
+		ttl:     defaultTTL,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// keyPrefix is the prefix of the keys of the entries of the store, so that they can be invalidated together.
+func keyPrefix(storeID string) string {
+	return storeID + ":"
+}
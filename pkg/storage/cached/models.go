@@ -0,0 +1,181 @@
+package cached
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/karlseguin/ccache/v3"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/cachestats"
+	"github.com/openfga/openfga/pkg/requestcontext"
+	"github.com/openfga/openfga/pkg/storage"
+	"golang.org/x/sync/singleflight"
+)
+
+var _ storage.AuthorizationModelBackend = (*AuthorizationModelBackend)(nil)
+
+// AuthorizationModelBackend decorates a storage.AuthorizationModelBackend with an LRU cache of the authorization
+// models it reads and, optionally, of the ID of the latest model of the stores.
+type AuthorizationModelBackend struct {
+	storage.AuthorizationModelBackend
+
+	models        *ccache.Cache[*openfgav1.AuthorizationModel]
+	modelTTL      time.Duration
+	modelCounters cachestats.Counters
+
+	lookupGroup singleflight.Group
+
+	// latestModelIDs caches the ID of the latest authorization model of the stores for latestModelIDTTL
+	latestModelIDs        *ccache.Cache[string]
+	latestModelIDTTL      time.Duration
+	latestModelIDCounters cachestats.Counters
+
+	// invalidations counts the invalidations of the latest model IDs, so that the ID found by a lookup which
+	// was in flight during an invalidation isn't cached
+	invalidations atomic.Uint64
+}
+
+// NewAuthorizationModelBackend returns a decorator of the backend that caches the authorization models it reads.
+func NewAuthorizationModelBackend(inner storage.AuthorizationModelBackend, opts ...Option) *AuthorizationModelBackend {
+	o := newOptions(DefaultModelTTL, opts)
+
+	b := &AuthorizationModelBackend{
+		AuthorizationModelBackend: inner,
+		models:                    ccache.New(ccache.Configure[*openfgav1.AuthorizationModel]().MaxSize(int64(o.maxSize))),
+		modelTTL:                  o.ttl,
+		latestModelIDTTL:          o.latestModelIDTTL,
+	}
+
+	if o.latestModelIDTTL > 0 {
+		b.latestModelIDs = ccache.New(ccache.Configure[string]().MaxSize(int64(o.maxSize)))
+	}
+
+	return b
+}
+
+func (b *AuthorizationModelBackend) ReadAuthorizationModel(ctx context.Context, storeID, modelID string) (*openfgav1.AuthorizationModel, error) {
+	cacheKey := keyPrefix(storeID) + modelID
+
+	bypassed := requestcontext.CacheBypassed(ctx)
+	if item := b.models.Get(cacheKey); item != nil && !item.Expired() {
+		if !bypassed {
+			b.modelCounters.Record(true)
+			return item.Value(), nil
+		}
+
+		requestcontext.AddBypassedCache(ctx, ModelCacheName)
+	}
+
+	if !bypassed {
+		b.modelCounters.Record(false)
+	}
+
+	model, err := b.AuthorizationModelBackend.ReadAuthorizationModel(ctx, storeID, modelID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bypassed {
+		b.models.Set(cacheKey, model, b.modelTTL)
+	}
+
+	return model, nil
+}
+
+func (b *AuthorizationModelBackend) FindLatestAuthorizationModelID(ctx context.Context, storeID string) (string, error) {
+	if b.latestModelIDs == nil {
+		return b.findLatestAuthorizationModelID(ctx, storeID, 0)
+	}
+
+	// the requests which prefer a higher consistency read the latest model ID, and cache it
+	higherConsistency := storage.ConsistencyFromContext(ctx) == storage.ConsistencyPreferenceHigherConsistency
+
+	bypassed := requestcontext.CacheBypassed(ctx)
+	if item := b.latestModelIDs.Get(storeID); item != nil && !item.Expired() && !higherConsistency {
+		if !bypassed {
+			b.latestModelIDCounters.Record(true)
+			return item.Value(), nil
+		}
+
+		requestcontext.AddBypassedCache(ctx, LatestModelIDCacheName)
+	}
+
+	if !bypassed {
+		b.latestModelIDCounters.Record(false)
+	}
+
+	invalidations := b.invalidations.Load()
+
+	modelID, err := b.findLatestAuthorizationModelID(ctx, storeID, invalidations)
+	if err != nil {
+		return "", err
+	}
+
+	if !bypassed && b.invalidations.Load() == invalidations {
+		b.latestModelIDs.Set(storeID, modelID, b.latestModelIDTTL)
+	}
+
+	return modelID, nil
+}
+
+// findLatestAuthorizationModelID looks up the ID of the latest model of the store, sharing the lookup with the
+// concurrent ones started after the same number of invalidations, so that no lookup started after an
+// invalidation gets the ID found by one started before.
+func (b *AuthorizationModelBackend) findLatestAuthorizationModelID(ctx context.Context, storeID string, invalidations uint64) (string, error) {
+	v, err, _ := b.lookupGroup.Do(fmt.Sprintf("FindLatestAuthorizationModelID:%s:%d", storeID, invalidations), func() (interface{}, error) {
+		return b.AuthorizationModelBackend.FindLatestAuthorizationModelID(ctx, storeID)
+	})
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+func (b *AuthorizationModelBackend) WriteAuthorizationModel(ctx context.Context, storeID string, model *openfgav1.AuthorizationModel) error {
+	defer b.InvalidateLatestModelID(storeID)
+
+	return b.AuthorizationModelBackend.WriteAuthorizationModel(ctx, storeID, model)
+}
+
+// InvalidateLatestModelID invalidates the ID of the latest model cached for the store, e.g. when the store is
+// deleted.
+func (b *AuthorizationModelBackend) InvalidateLatestModelID(storeID string) {
+	if b.latestModelIDs == nil {
+		return
+	}
+
+	b.invalidations.Add(1)
+	b.latestModelIDs.Delete(storeID)
+}
+
+// InvalidateStore invalidates everything cached for the store, returning the number of models invalidated.
+func (b *AuthorizationModelBackend) InvalidateStore(storeID string) int {
+	b.InvalidateLatestModelID(storeID)
+
+	return b.models.DeletePrefix(keyPrefix(storeID))
+}
+
+// Caches returns the caches of the backend, to report their statistics and to flush them.
+func (b *AuthorizationModelBackend) Caches() []cachestats.Cache {
+	caches := []cachestats.Cache{
+		cachestats.NewCCache(ModelCacheName, b.models, &b.modelCounters, b.modelTTL, keyPrefix),
+	}
+
+	if b.latestModelIDs != nil {
+		caches = append(caches, cachestats.NewCCache(LatestModelIDCacheName, b.latestModelIDs, &b.latestModelIDCounters, b.latestModelIDTTL, func(storeID string) string {
+			return storeID
+		}))
+	}
+
+	return caches
+}
+
+// Close stops the caches. It doesn't close the decorated backend.
+func (b *AuthorizationModelBackend) Close() {
+	b.models.Stop()
+	if b.latestModelIDs != nil {
+		b.latestModelIDs.Stop()
+	}
+}
@@ -0,0 +1,113 @@
+package cached
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/requestcontext"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+func newModel() *openfgav1.AuthorizationModel {
+	return &openfgav1.AuthorizationModel{
+		Id:              ulid.Make().String(),
+		SchemaVersion:   typesystem.SchemaVersion1_1,
+		TypeDefinitions: []*openfgav1.TypeDefinition{{Type: "user"}},
+	}
+}
+
+func TestAuthorizationModelBackendCachesModels(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	backend := NewAuthorizationModelBackend(ds, WithMaxSize(5))
+	t.Cleanup(backend.Close)
+
+	storeID := ulid.Make().String()
+	model := newModel()
+	require.NoError(t, ds.WriteAuthorizationModel(ctx, storeID, model))
+
+	got, err := backend.ReadAuthorizationModel(ctx, storeID, model.GetId())
+	require.NoError(t, err)
+	require.Equal(t, model, got)
+
+	item := backend.models.Get(keyPrefix(storeID) + model.GetId())
+	require.NotNil(t, item)
+	require.Equal(t, model, item.Value())
+
+	// the models which don't exist aren't cached
+	_, err = backend.ReadAuthorizationModel(ctx, storeID, ulid.Make().String())
+	require.ErrorIs(t, err, storage.ErrNotFound)
+	require.Equal(t, 1, backend.models.ItemCount())
+
+	require.Equal(t, 1, backend.InvalidateStore(storeID))
+	require.Nil(t, backend.models.Get(keyPrefix(storeID)+model.GetId()))
+}
+
+func TestAuthorizationModelBackendModelTTL(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	backend := NewAuthorizationModelBackend(ds, WithTTL(time.Millisecond))
+	t.Cleanup(backend.Close)
+
+	storeID := ulid.Make().String()
+	model := newModel()
+	require.NoError(t, ds.WriteAuthorizationModel(ctx, storeID, model))
+
+	_, err := backend.ReadAuthorizationModel(ctx, storeID, model.GetId())
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return backend.models.Get(keyPrefix(storeID) + model.GetId()).Expired()
+	}, time.Second, time.Millisecond)
+}
+
+func TestAuthorizationModelBackendInvalidatesLatestModelIDOnWrite(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	backend := NewAuthorizationModelBackend(ds, WithLatestModelIDTTL(time.Hour))
+	t.Cleanup(backend.Close)
+
+	storeID := ulid.Make().String()
+	first := newModel()
+	require.NoError(t, backend.WriteAuthorizationModel(ctx, storeID, first))
+
+	latestID, err := backend.FindLatestAuthorizationModelID(ctx, storeID)
+	require.NoError(t, err)
+	require.Equal(t, first.GetId(), latestID)
+
+	// the models written through another server are only seen once the TTL expires
+	second := newModel()
+	require.NoError(t, ds.WriteAuthorizationModel(ctx, storeID, second))
+
+	latestID, err = backend.FindLatestAuthorizationModelID(ctx, storeID)
+	require.NoError(t, err)
+	require.Equal(t, first.GetId(), latestID)
+
+	// or by the requests which prefer a higher consistency
+	consistentCtx := requestcontext.NewContext(ctx)
+	requestcontext.SetConsistency(consistentCtx, storage.ConsistencyPreferenceHigherConsistency.String())
+
+	latestID, err = backend.FindLatestAuthorizationModelID(consistentCtx, storeID)
+	require.NoError(t, err)
+	require.Equal(t, second.GetId(), latestID)
+
+	// while the models written through the cache invalidate it
+	third := newModel()
+	require.NoError(t, backend.WriteAuthorizationModel(ctx, storeID, third))
+
+	latestID, err = backend.FindLatestAuthorizationModelID(ctx, storeID)
+	require.NoError(t, err)
+	require.Equal(t, third.GetId(), latestID)
+}
@@ -0,0 +1,116 @@
+package cached
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/karlseguin/ccache/v3"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/cachestats"
+	"github.com/openfga/openfga/pkg/requestcontext"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+var _ storage.TupleBackend = (*TupleBackend)(nil)
+
+// TupleBackend decorates a storage.TupleBackend with an LRU+TTL cache of the tuples it reads by their key,
+// including the keys of the tuples that don't exist. The tuples of a store are invalidated when tuples are
+// written to it through the cache, but the ones written by other servers are only seen once the TTL expires.
+// The reads which prefer a higher consistency always skip the cache, and the tuples which expire aren't cached
+// past their expiration.
+type TupleBackend struct {
+	storage.TupleBackend
+
+	// tuples caches the tuples by their key, a nil tuple standing for a tuple which doesn't exist
+	tuples   *ccache.Cache[*cachedTuple]
+	ttl      time.Duration
+	counters cachestats.Counters
+
+	// invalidations counts the writes through the cache, so that the tuple read by a lookup which was in
+	// flight during a write isn't cached
+	invalidations atomic.Uint64
+}
+
+// cachedTuple is a tuple cached by its key, with its expiration if any.
+type cachedTuple struct {
+	tuple     *openfgav1.Tuple
+	expiresAt time.Time
+}
+
+// NewTupleBackend returns a decorator of the backend that caches the tuples it reads by their key.
+func NewTupleBackend(inner storage.TupleBackend, opts ...Option) *TupleBackend {
+	o := newOptions(DefaultTupleTTL, opts)
+
+	return &TupleBackend{
+		TupleBackend: inner,
+		tuples:       ccache.New(ccache.Configure[*cachedTuple]().MaxSize(int64(o.maxSize))),
+		ttl:          o.ttl,
+	}
+}
+
+func (b *TupleBackend) ReadUserTuple(ctx context.Context, storeID string, tk *openfgav1.TupleKey, options storage.ReadOptions) (*openfgav1.Tuple, error) {
+	if options.Consistency == storage.ConsistencyPreferenceHigherConsistency {
+		return b.TupleBackend.ReadUserTuple(ctx, storeID, tk, options)
+	}
+
+	cacheKey := keyPrefix(storeID) + tuple.TupleKeyToString(tk)
+
+	bypassed := requestcontext.CacheBypassed(ctx)
+	if item := b.tuples.Get(cacheKey); item != nil && !item.Expired() {
+		if !bypassed {
+			b.counters.Record(true)
+			storage.RecordTupleExpiration(ctx, item.Value().expiresAt)
+			if item.Value().tuple == nil {
+				return nil, storage.ErrNotFound
+			}
+			return item.Value().tuple, nil
+		}
+
+		requestcontext.AddBypassedCache(ctx, TupleCacheName)
+	}
+
+	if !bypassed {
+		b.counters.Record(false)
+	}
+
+	invalidations := b.invalidations.Load()
+
+	trackedCtx, tracker := storage.ContextWithExpirationTracker(ctx)
+	t, err := b.TupleBackend.ReadUserTuple(trackedCtx, storeID, tk, options)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+
+	if ttl := tracker.CapTTL(b.ttl); !bypassed && ttl > 0 && b.invalidations.Load() == invalidations {
+		expiresAt, _ := tracker.Earliest()
+		b.tuples.Set(cacheKey, &cachedTuple{tuple: t, expiresAt: expiresAt}, ttl)
+	}
+
+	return t, err
+}
+
+func (b *TupleBackend) Write(ctx context.Context, storeID string, deletes storage.Deletes, writes storage.Writes) error {
+	defer b.InvalidateStore(storeID)
+
+	return b.TupleBackend.Write(ctx, storeID, deletes, writes)
+}
+
+// InvalidateStore invalidates the tuples cached for the store, returning how many were invalidated.
+func (b *TupleBackend) InvalidateStore(storeID string) int {
+	b.invalidations.Add(1)
+
+	return b.tuples.DeletePrefix(keyPrefix(storeID))
+}
+
+// Caches returns the cache of the backend, to report its statistics and to flush it.
+func (b *TupleBackend) Caches() []cachestats.Cache {
+	return []cachestats.Cache{cachestats.NewCCache(TupleCacheName, b.tuples, &b.counters, b.ttl, keyPrefix)}
+}
+
+// Close stops the cache. It doesn't close the decorated backend.
+func (b *TupleBackend) Close() {
+	b.tuples.Stop()
+}
@@ -0,0 +1,94 @@
+package cached
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTupleBackend(t *testing.T) {
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+	tk := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+
+	t.Run("caches_the_tuples_which_don't_exist", func(t *testing.T) {
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+
+		backend := NewTupleBackend(ds)
+		t.Cleanup(backend.Close)
+
+		_, err := backend.ReadUserTuple(ctx, storeID, tk, storage.ReadOptions{})
+		require.ErrorIs(t, err, storage.ErrNotFound)
+
+		// the tuples written through another server are only seen once the TTL expires
+		require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tk}))
+
+		_, err = backend.ReadUserTuple(ctx, storeID, tk, storage.ReadOptions{})
+		require.ErrorIs(t, err, storage.ErrNotFound)
+
+		// unless the read prefers a higher consistency
+		got, err := backend.ReadUserTuple(ctx, storeID, tk, storage.ReadOptions{
+			Consistency: storage.ConsistencyPreferenceHigherConsistency,
+		})
+		require.NoError(t, err)
+		require.Equal(t, tk.GetUser(), got.GetKey().GetUser())
+	})
+
+	t.Run("tuples_are_not_cached_past_their_expiration", func(t *testing.T) {
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+
+		backend := NewTupleBackend(ds, WithTTL(time.Hour))
+		t.Cleanup(backend.Close)
+
+		require.NoError(t, ds.(storage.TupleExpirationBackend).WriteWithExpirations(ctx, storeID, nil, []*openfgav1.TupleKey{tk}, map[string]time.Time{
+			tuple.TupleKeyToString(tk): time.Now().Add(50 * time.Millisecond),
+		}))
+
+		// the expiration of the tuples read from the cache is reported too
+		for i := 0; i < 2; i++ {
+			trackedCtx, tracker := storage.ContextWithExpirationTracker(ctx)
+			_, err := backend.ReadUserTuple(trackedCtx, storeID, tk, storage.ReadOptions{})
+			require.NoError(t, err)
+
+			_, ok := tracker.Earliest()
+			require.True(t, ok)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+
+		_, err := backend.ReadUserTuple(ctx, storeID, tk, storage.ReadOptions{})
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("writes_invalidate_the_tuples_of_the_store", func(t *testing.T) {
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+
+		backend := NewTupleBackend(ds)
+		t.Cleanup(backend.Close)
+
+		otherStoreID := ulid.Make().String()
+		require.NoError(t, ds.Write(ctx, otherStoreID, nil, []*openfgav1.TupleKey{tk}))
+
+		for _, id := range []string{storeID, otherStoreID} {
+			_, _ = backend.ReadUserTuple(ctx, id, tk, storage.ReadOptions{})
+		}
+		require.Equal(t, 2, backend.tuples.ItemCount())
+
+		require.NoError(t, backend.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tk}))
+		require.Equal(t, 1, backend.tuples.ItemCount())
+
+		got, err := backend.ReadUserTuple(ctx, storeID, tk, storage.ReadOptions{})
+		require.NoError(t, err)
+		require.Equal(t, tk.GetObject(), got.GetKey().GetObject())
+	})
+}
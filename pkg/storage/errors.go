@@ -3,6 +3,7 @@ package storage
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 )
@@ -18,6 +19,12 @@ var (
 	ErrMismatchObjectType       = errors.New("mismatched types in request and continuation token")
 	ErrExceededWriteBatchLimit  = errors.New("number of operations exceeded write batch limit")
 	ErrCancelled                = errors.New("request has been cancelled")
+	ErrIncompatibleSchema       = errors.New("incompatible datastore schema")
+	ErrUnknownResidency         = errors.New("unknown store residency")
+	ErrCrossRegionRead          = errors.New("the data of the store resides in another region")
+	ErrStoreUnavailable         = errors.New("the datastore of the store is unavailable")
+	ErrPreconditionFailed       = errors.New("write precondition failed")
+	ErrUnsupported              = errors.New("operation not supported by the datastore")
 )
 
 func ExceededMaxTypeDefinitionsLimitError(limit int) error {
@@ -34,3 +41,38 @@ func InvalidWriteInputError(tk *openfgav1.TupleKey, operation openfgav1.TupleOpe
 		return nil
 	}
 }
+
+// PreconditionFailedError returns the error of a write whose precondition doesn't hold.
+func PreconditionFailedError(precondition Precondition) error {
+	tk := precondition.TupleKey
+	if precondition.Exists {
+		return fmt.Errorf("the tuple doesn't exist: user: '%s', relation: '%s', object: '%s': %w", tk.GetUser(), tk.GetRelation(), tk.GetObject(), ErrPreconditionFailed)
+	}
+
+	return fmt.Errorf("the tuple exists: user: '%s', relation: '%s', object: '%s': %w", tk.GetUser(), tk.GetRelation(), tk.GetObject(), ErrPreconditionFailed)
+}
+
+// StoreUnavailableError is the ErrStoreUnavailable of a store whose datastore operations are failed fast,
+// along with the time left until the datastore is tried again.
+type StoreUnavailableError struct {
+	Store      string
+	RetryAfter time.Duration
+}
+
+func (e *StoreUnavailableError) Error() string {
+	return fmt.Sprintf("%s: the datastore operations of the store '%s' fail too often", ErrStoreUnavailable, e.Store)
+}
+
+func (e *StoreUnavailableError) Unwrap() error {
+	return ErrStoreUnavailable
+}
+
+// IncompatibleSchemaError returns an error describing why the schema of a datastore, at the current
+// version, is incompatible with the server, which expects the latest version.
+func IncompatibleSchemaError(current, latest int64) error {
+	if current < latest {
+		return fmt.Errorf("the datastore schema is at version %d but the server requires version %d, run the migrations (e.g. 'openfga migrate' or the 'datastore.autoMigrate' config): %w", current, latest, ErrIncompatibleSchema)
+	}
+
+	return fmt.Errorf("the datastore schema is at version %d, which is newer than the version %d known to the server, upgrade the server: %w", current, latest, ErrIncompatibleSchema)
+}
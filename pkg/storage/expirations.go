@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+const expirationTrackerCtxKey ctxKey = "expiration-tracker-context-key"
+
+// ExpirationTracker records the earliest time at which the tuples read on behalf of an operation expire (see
+// TupleExpirationBackend), so that the results derived from them, e.g. the cached Check results, aren't kept
+// past it. It's safe for concurrent use.
+type ExpirationTracker struct {
+	// parent is the tracker of the operation this one is part of, if any, which the expirations are recorded to
+	// too
+	parent *ExpirationTracker
+
+	// earliest is the earliest expiration recorded, in Unix nanoseconds, or 0 if none was
+	earliest atomic.Int64
+}
+
+// ContextWithExpirationTracker returns a context with a new ExpirationTracker, to which the datastores record
+// the expirations of the tuples they read with the context (see RecordTupleExpiration). The expirations are
+// recorded to the tracker of the parent context too, if any.
+func ContextWithExpirationTracker(parent context.Context) (context.Context, *ExpirationTracker) {
+	t := &ExpirationTracker{}
+	t.parent, _ = parent.Value(expirationTrackerCtxKey).(*ExpirationTracker)
+
+	return context.WithValue(parent, expirationTrackerCtxKey, t), t
+}
+
+// RecordTupleExpiration records to the ExpirationTracker of the context, if any, that a tuple read with the
+// context expires at the provided time. The zero time, of the tuples which don't expire, is ignored.
+func RecordTupleExpiration(ctx context.Context, expiresAt time.Time) {
+	if expiresAt.IsZero() {
+		return
+	}
+
+	if t, ok := ctx.Value(expirationTrackerCtxKey).(*ExpirationTracker); ok {
+		t.Record(expiresAt)
+	}
+}
+
+// Record records that a tuple read expires at the provided time, to the tracker and to its parents.
+func (t *ExpirationTracker) Record(expiresAt time.Time) {
+	nanos := expiresAt.UnixNano()
+
+	for ; t != nil; t = t.parent {
+		for {
+			earliest := t.earliest.Load()
+			if earliest != 0 && earliest <= nanos {
+				break
+			}
+
+			if t.earliest.CompareAndSwap(earliest, nanos) {
+				break
+			}
+		}
+	}
+}
+
+// Earliest returns the earliest expiration recorded, if any.
+func (t *ExpirationTracker) Earliest() (time.Time, bool) {
+	earliest := t.earliest.Load()
+	if earliest == 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, earliest), true
+}
+
+// CapTTL returns the provided TTL, shortened so that an entry cached for it now doesn't outlive the earliest
+// expiration recorded. It returns 0 or less if the earliest expiration is already past.
+func (t *ExpirationTracker) CapTTL(ttl time.Duration) time.Duration {
+	if earliest, ok := t.Earliest(); ok {
+		return min(ttl, time.Until(earliest))
+	}
+
+	return ttl
+}
@@ -4,13 +4,17 @@ package memory
 import (
 	"context"
 	"fmt"
+	"math/rand"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/condition"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/telemetry"
 	tupleUtils "github.com/openfga/openfga/pkg/tuple"
@@ -71,20 +75,129 @@ const (
 	defaultMaxTypesPerAuthorizationModel = 100
 )
 
+// storedTuple is a tuple along with the sequence number of the write that created it. Sequence numbers
+// increase monotonically within a store and are used to return the tuples of a store in insertion order.
+type storedTuple struct {
+	tuple *openfgav1.Tuple
+	seq   uint64
+
+	// condition is the condition the tuple carries, if any
+	condition *condition.TupleCondition
+
+	// expiresAt is the time the tuple expires at, if any
+	expiresAt time.Time
+}
+
+// expired reports whether the tuple expired at or before now.
+func (st *storedTuple) expired(now time.Time) bool {
+	return !st.expiresAt.IsZero() && !st.expiresAt.After(now)
+}
+
+// objectState is an immutable snapshot of the tuples of one object, in insertion order. Every write to
+// the object replaces the snapshot, so the identity of the snapshot doubles as the version of the object.
+type objectState struct {
+	tuples []*storedTuple
+
+	// removed is set on the final state of an object removed from its store by a compaction. Writes to it
+	// must start over with the object that replaces it.
+	removed bool
+}
+
+// objectTuples holds the tuples of one object. Readers load the current state without locking, writers
+// replace it while holding commitMu.
+type objectTuples struct {
+	commitMu sync.Mutex
+	state    atomic.Pointer[objectState]
+}
+
+// tupleStore holds the tuples and the changelog of one store.
+type tupleStore struct {
+	mu      sync.RWMutex
+	objects map[string]*objectTuples /* GUARDED_BY(mu) */
+
+	changesMu sync.Mutex
+	changes   []*openfgav1.TupleChange /* GUARDED_BY(changesMu) */
+	seq       uint64                   /* GUARDED_BY(changesMu) */
+
+	// stats counts the tuples of the store by 'type#relation', and storeStats counts all of them. They're
+	// updated as the writes are committed.
+	stats      map[string]*tupleCounter /* GUARDED_BY(changesMu) */
+	storeStats tupleCounter             /* GUARDED_BY(changesMu) */
+}
+
+// tupleCounter counts a set of tuples, along with their distinct objects and users.
+type tupleCounter struct {
+	tuples  int64
+	objects map[string]int64
+	users   map[string]int64
+}
+
+// add adds delta (either 1 or -1) tuples with the key.
+func (c *tupleCounter) add(tk *openfgav1.TupleKey, delta int64) {
+	if c.objects == nil {
+		c.objects = map[string]int64{}
+		c.users = map[string]int64{}
+	}
+
+	c.tuples += delta
+
+	c.objects[tk.GetObject()] += delta
+	if c.objects[tk.GetObject()] == 0 {
+		delete(c.objects, tk.GetObject())
+	}
+
+	c.users[tk.GetUser()] += delta
+	if c.users[tk.GetUser()] == 0 {
+		delete(c.users, tk.GetUser())
+	}
+}
+
+func (c *tupleCounter) tupleStats() storage.TupleStats {
+	return storage.TupleStats{
+		TupleCount:  c.tuples,
+		ObjectCount: int64(len(c.objects)),
+		UserCount:   int64(len(c.users)),
+	}
+}
+
+// count updates the statistics of the store with delta (either 1 or -1) tuples with the key.
+func (ts *tupleStore) count(tk *openfgav1.TupleKey, delta int64) {
+	if ts.stats == nil {
+		ts.stats = map[string]*tupleCounter{}
+	}
+
+	key := tupleUtils.ToObjectRelationString(tupleUtils.GetType(tk.GetObject()), tk.GetRelation())
+	c, ok := ts.stats[key]
+	if !ok {
+		c = &tupleCounter{}
+		ts.stats[key] = c
+	}
+
+	c.add(tk, delta)
+	if c.tuples == 0 {
+		delete(ts.stats, key)
+	}
+
+	ts.storeStats.add(tk, delta)
+}
+
 // A MemoryBackend provides an ephemeral memory-backed implementation of TupleBackend and AuthorizationModelBackend.
 // MemoryBackend instances may be safely shared by multiple go-routines.
+//
+// Tuples are partitioned by object, and writes use optimistic concurrency: a write validates its operations
+// against a snapshot of the objects it touches without holding any lock, and then commits only if none of
+// those objects changed in the meantime (otherwise it retries). Concurrent writes to unrelated objects
+// therefore don't serialize. Reads never block on writes, and a read spanning several objects may observe
+// a concurrent write that touches several objects only partially applied.
 type MemoryBackend struct {
 	maxTuplesPerWrite             int
 	maxTypesPerAuthorizationModel int
 	mu                            sync.Mutex
 
-	// TupleBackend
-	// map: store => set of tuples
-	tuples map[string][]*openfgav1.Tuple /* GUARDED_BY(mu) */
-
-	// ChangelogBackend
-	// map: store => set of changes
-	changes map[string][]*openfgav1.TupleChange
+	// TupleBackend and ChangelogBackend
+	// map: store => tuples and changes
+	tuplesMu sync.RWMutex
+	tuples   map[string]*tupleStore /* GUARDED_BY(tuplesMu) */
 
 	// AuthorizationModelBackend
 	// map: store = > map: type definition id => type definition
@@ -93,15 +206,26 @@ type MemoryBackend struct {
 	// map: store id => store data
 	stores map[string]*openfgav1.Store
 
-	// map: store id | authz model id => assertions
-	assertions map[string][]*openfgav1.Assertion
+	// map: store id | authz model id => assertions versions, from the oldest to the newest
+	assertions map[string][]*storage.AssertionsVersion
+
+	expiredTuplesReapInterval time.Duration
+	reaper                    *storage.ExpiredTuplesReaper
 }
 
 var _ storage.OpenFGADatastore = (*MemoryBackend)(nil)
+var _ storage.StatsProvider = (*MemoryBackend)(nil)
+var _ storage.Maintainer = (*MemoryBackend)(nil)
+var _ storage.ConditionsBackend = (*MemoryBackend)(nil)
+var _ storage.TupleExpirationBackend = (*MemoryBackend)(nil)
+var _ storage.PreconditionsBackend = (*MemoryBackend)(nil)
+var _ storage.TupleSampler = (*MemoryBackend)(nil)
 
 type AuthorizationModelEntry struct {
-	model  *openfgav1.AuthorizationModel
-	latest bool
+	model      *openfgav1.AuthorizationModel
+	latest     bool
+	labels     map[string]string
+	conditions *condition.ModelConditions
 }
 
 // New creates a new empty MemoryBackend.
@@ -109,17 +233,21 @@ func New(opts ...StorageOption) storage.OpenFGADatastore {
 	ds := &MemoryBackend{
 		maxTuplesPerWrite:             defaultMaxTuplesPerWrite,
 		maxTypesPerAuthorizationModel: defaultMaxTypesPerAuthorizationModel,
-		tuples:                        make(map[string][]*openfgav1.Tuple, 0),
-		changes:                       make(map[string][]*openfgav1.TupleChange, 0),
+		tuples:                        make(map[string]*tupleStore, 0),
 		authorizationModels:           make(map[string]map[string]*AuthorizationModelEntry),
 		stores:                        make(map[string]*openfgav1.Store, 0),
-		assertions:                    make(map[string][]*openfgav1.Assertion, 0),
+		assertions:                    make(map[string][]*storage.AssertionsVersion, 0),
+		expiredTuplesReapInterval:     storage.DefaultExpiredTuplesReapInterval,
 	}
 
 	for _, opt := range opts {
 		opt(ds)
 	}
 
+	if ds.expiredTuplesReapInterval > 0 {
+		ds.reaper = storage.StartExpiredTuplesReaper(ds, ds.expiredTuplesReapInterval, nil)
+	}
+
 	return ds
 }
 
@@ -131,20 +259,123 @@ func WithMaxTypesPerAuthorizationModel(n int) StorageOption {
 	return func(ds *MemoryBackend) { ds.maxTypesPerAuthorizationModel = n }
 }
 
+// WithExpiredTuplesReapInterval sets the interval at which the expired tuples are deleted in the background,
+// which is storage.DefaultExpiredTuplesReapInterval by default. They're never deleted in the background if
+// it's 0.
+func WithExpiredTuplesReapInterval(d time.Duration) StorageOption {
+	return func(ds *MemoryBackend) { ds.expiredTuplesReapInterval = d }
+}
+
+// tupleStore returns the tuples of the given store. If the store has no tuples yet, tupleStore returns nil
+// unless create is true.
+func (s *MemoryBackend) tupleStore(store string, create bool) *tupleStore {
+	s.tuplesMu.RLock()
+	ts, ok := s.tuples[store]
+	s.tuplesMu.RUnlock()
+
+	if ok || !create {
+		return ts
+	}
+
+	s.tuplesMu.Lock()
+	defer s.tuplesMu.Unlock()
+
+	if ts, ok = s.tuples[store]; !ok {
+		ts = &tupleStore{objects: map[string]*objectTuples{}}
+		s.tuples[store] = ts
+	}
+
+	return ts
+}
+
+// object returns the tuples of the given object. If the object has no tuples yet, object returns nil
+// unless create is true.
+func (ts *tupleStore) object(object string, create bool) *objectTuples {
+	ts.mu.RLock()
+	o, ok := ts.objects[object]
+	ts.mu.RUnlock()
+
+	if ok || !create {
+		return o
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if o, ok = ts.objects[object]; !ok {
+		o = &objectTuples{}
+		o.state.Store(&objectState{})
+		ts.objects[object] = o
+	}
+
+	return o
+}
+
+// candidates returns, in insertion order, the tuples that may match the provided object. If the object
+// doesn't have an id (e.g. 'document:'), the tuples of all the objects are returned. The expired tuples are
+// left out.
+func (ts *tupleStore) candidates(object string) []*storedTuple {
+	if ts == nil {
+		return nil
+	}
+
+	var stored []*storedTuple
+	if _, objectID := tupleUtils.SplitObject(object); objectID != "" {
+		if o := ts.object(object, false); o != nil {
+			stored = o.state.Load().tuples
+		}
+	} else {
+		ts.mu.RLock()
+		for _, o := range ts.objects {
+			stored = append(stored, o.state.Load().tuples...)
+		}
+		ts.mu.RUnlock()
+
+		sort.Slice(stored, func(i, j int) bool {
+			return stored[i].seq < stored[j].seq
+		})
+	}
+
+	now := time.Now()
+	tuples := make([]*storedTuple, 0, len(stored))
+	for _, st := range stored {
+		if !st.expired(now) {
+			tuples = append(tuples, st)
+		}
+	}
+
+	return tuples
+}
+
+// readTuples returns the tuples read with the context, recording their expirations to the
+// storage.ExpirationTracker of the context (if any).
+func readTuples(ctx context.Context, stored []*storedTuple) []*openfgav1.Tuple {
+	tuples := make([]*openfgav1.Tuple, 0, len(stored))
+	for _, st := range stored {
+		storage.RecordTupleExpiration(ctx, st.expiresAt)
+		tuples = append(tuples, st.tuple)
+	}
+
+	return tuples
+}
+
 // Close closes any open connections and cleans up residual resources
 // used by this storage adapter instance.
 func (s *MemoryBackend) Close() {
+	if s.reaper != nil {
+		s.reaper.Stop()
+	}
 }
 
 // Read See storage.TupleBackend.Read
-func (s *MemoryBackend) Read(ctx context.Context, store string, key *openfgav1.TupleKey) (storage.TupleIterator, error) {
+func (s *MemoryBackend) Read(ctx context.Context, store string, key *openfgav1.TupleKey, _ storage.ReadOptions) (storage.TupleIterator, error) {
 	ctx, span := tracer.Start(ctx, "memory.Read")
 	defer span.End()
 
 	return s.read(ctx, store, key, storage.PaginationOptions{})
 }
 
-func (s *MemoryBackend) ReadPage(ctx context.Context, store string, key *openfgav1.TupleKey, paginationOptions storage.PaginationOptions) ([]*openfgav1.Tuple, []byte, error) {
+func (s *MemoryBackend) ReadPage(ctx context.Context, store string, key *openfgav1.TupleKey, paginationOptions storage.PaginationOptions, _ storage.ReadOptions) ([]*openfgav1.Tuple, []byte, error) {
 	ctx, span := tracer.Start(ctx, "memory.ReadPage")
 	defer span.End()
 
@@ -156,12 +387,16 @@ func (s *MemoryBackend) ReadPage(ctx context.Context, store string, key *openfga
 	return it.tuples, it.continuationToken, nil
 }
 
-func (s *MemoryBackend) ReadChanges(ctx context.Context, store, objectType string, paginationOptions storage.PaginationOptions, horizonOffset time.Duration) ([]*openfgav1.TupleChange, []byte, error) {
+func (s *MemoryBackend) ReadChanges(ctx context.Context, store, objectType string, paginationOptions storage.PaginationOptions, horizonOffset time.Duration, _ storage.ReadOptions) ([]*openfgav1.TupleChange, []byte, error) {
 	_, span := tracer.Start(ctx, "memory.ReadChanges")
 	defer span.End()
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	var changes []*openfgav1.TupleChange
+	if ts := s.tupleStore(store, false); ts != nil {
+		ts.changesMu.Lock()
+		changes = ts.changes
+		ts.changesMu.Unlock()
+	}
 
 	var err error
 	var from int64
@@ -185,7 +420,7 @@ func (s *MemoryBackend) ReadChanges(ctx context.Context, store, objectType strin
 
 	var allChanges []*openfgav1.TupleChange
 	now := time.Now().UTC()
-	for _, change := range s.changes[store] {
+	for _, change := range changes {
 		if objectType == "" || (objectType != "" && strings.HasPrefix(change.TupleKey.Object, objectType+":")) {
 			if change.Timestamp.AsTime().After(now.Add(-horizonOffset)) {
 				break
@@ -223,17 +458,15 @@ func (s *MemoryBackend) read(ctx context.Context, store string, tk *openfgav1.Tu
 	_, span := tracer.Start(ctx, "memory.read")
 	defer span.End()
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	candidates := s.tupleStore(store, false).candidates(tk.GetObject())
 
-	var matches []*openfgav1.Tuple
+	var matches []*storedTuple
 	if tk.GetObject() == "" && tk.GetRelation() == "" && tk.GetUser() == "" {
-		matches = make([]*openfgav1.Tuple, len(s.tuples[store]))
-		copy(matches, s.tuples[store])
+		matches = candidates
 	} else {
-		for _, t := range s.tuples[store] {
-			if match(tk, t.Key) {
-				matches = append(matches, t)
+		for _, st := range candidates {
+			if match(tk, st.tuple.Key) {
+				matches = append(matches, st)
 			}
 		}
 	}
@@ -254,10 +487,10 @@ func (s *MemoryBackend) read(ctx context.Context, store string, tk *openfgav1.Tu
 
 	to := paginationOptions.PageSize
 	if to != 0 && to < len(matches) {
-		return &staticIterator{tuples: matches[:to], continuationToken: []byte(strconv.Itoa(from + to))}, nil
+		return &staticIterator{tuples: readTuples(ctx, matches[:to]), continuationToken: []byte(strconv.Itoa(from + to))}, nil
 	}
 
-	return &staticIterator{tuples: matches}, nil
+	return &staticIterator{tuples: readTuples(ctx, matches)}, nil
 }
 
 // Write See storage.TupleBackend.Write
@@ -265,21 +498,221 @@ func (s *MemoryBackend) Write(ctx context.Context, store string, deletes storage
 	_, span := tracer.Start(ctx, "memory.Write")
 	defer span.End()
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	return s.write(store, deletes, writes, nil, nil, nil)
+}
+
+// WriteWithConditions See storage.ConditionsBackend.WriteWithConditions
+func (s *MemoryBackend) WriteWithConditions(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, conditions map[string]*condition.TupleCondition) error {
+	_, span := tracer.Start(ctx, "memory.WriteWithConditions")
+	defer span.End()
+
+	return s.write(store, deletes, writes, conditions, nil, nil)
+}
+
+// WriteWithExpirations See storage.TupleExpirationBackend.WriteWithExpirations
+func (s *MemoryBackend) WriteWithExpirations(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, expirations map[string]time.Time) error {
+	_, span := tracer.Start(ctx, "memory.WriteWithExpirations")
+	defer span.End()
+
+	return s.write(store, deletes, writes, nil, expirations, nil)
+}
 
-	now := timestamppb.Now()
+// WriteWithPreconditions See storage.PreconditionsBackend.WriteWithPreconditions
+func (s *MemoryBackend) WriteWithPreconditions(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, preconditions []storage.Precondition) error {
+	_, span := tracer.Start(ctx, "memory.WriteWithPreconditions")
+	defer span.End()
+
+	return s.write(store, deletes, writes, nil, nil, preconditions)
+}
 
-	if err := validateTuples(s.tuples[store], deletes, writes); err != nil {
-		return err
+// write applies the deletes and then the writes to the store if the preconditions hold, and the tuples written
+// carry the conditions and expire at the times keyed by their tuple.TupleKeyToString, if any.
+func (s *MemoryBackend) write(store string, deletes storage.Deletes, writes storage.Writes, conditions map[string]*condition.TupleCondition, expirations map[string]time.Time, preconditions []storage.Precondition) error {
+	ts := s.tupleStore(store, true)
+
+	deletesByObject := map[string][]*openfgav1.TupleKey{}
+	for _, tk := range deletes {
+		deletesByObject[tk.GetObject()] = append(deletesByObject[tk.GetObject()], tk)
+	}
+
+	writesByObject := map[string][]*openfgav1.TupleKey{}
+	for _, tk := range writes {
+		writesByObject[tk.GetObject()] = append(writesByObject[tk.GetObject()], tk)
+	}
+
+	// the objects of the preconditions are committed along with the objects written, so that the write fails
+	// to commit if they change after the preconditions are checked
+	preconditionsByObject := map[string][]storage.Precondition{}
+	for _, precondition := range preconditions {
+		object := precondition.TupleKey.GetObject()
+		preconditionsByObject[object] = append(preconditionsByObject[object], precondition)
+	}
+
+	// the objects are committed in a consistent order so that concurrent writes can't deadlock
+	objects := make(map[string]struct{}, len(deletesByObject)+len(writesByObject)+len(preconditionsByObject))
+	for object := range deletesByObject {
+		objects[object] = struct{}{}
+	}
+	for object := range writesByObject {
+		objects[object] = struct{}{}
+	}
+	for object := range preconditionsByObject {
+		objects[object] = struct{}{}
+	}
+	objectIDs := make([]string, 0, len(objects))
+	for object := range objects {
+		objectIDs = append(objectIDs, object)
+	}
+	sort.Strings(objectIDs)
+
+	ops := make([]objectWrite, 0, len(objectIDs))
+	for _, object := range objectIDs {
+		ops = append(ops, objectWrite{
+			object:        ts.object(object, true),
+			deletes:       deletesByObject[object],
+			writes:        writesByObject[object],
+			preconditions: preconditionsByObject[object],
+		})
+	}
+
+	for {
+		committed, err := ts.tryWrite(ops, deletes, writes, conditions, expirations)
+		if err != nil {
+			return err
+		}
+
+		if committed {
+			return nil
+		}
+
+		// the objects may have been removed by a compaction in the meantime
+		for i, object := range objectIDs {
+			ops[i].object = ts.object(object, true)
+		}
+	}
+}
+
+// objectWrite holds the operations of a write that apply to one object, and the state of the object
+// that results from applying them.
+type objectWrite struct {
+	object        *objectTuples
+	deletes       []*openfgav1.TupleKey
+	writes        []*openfgav1.TupleKey
+	preconditions []storage.Precondition
+
+	snapshot *objectState
+	next     *objectState
+	added    []*storedTuple
+	expired  []*storedTuple
+}
+
+// tryWrite applies the operations to a snapshot of each object without holding any lock, and then commits
+// the results if none of the objects changed since their snapshot was taken. It reports whether the write
+// was committed; if it wasn't, the caller should try again.
+func (ts *tupleStore) tryWrite(ops []objectWrite, deletes storage.Deletes, writes storage.Writes, conditions map[string]*condition.TupleCondition, expirations map[string]time.Time) (bool, error) {
+	now := time.Now()
+	for i := range ops {
+		op := &ops[i]
+		op.snapshot = op.object.state.Load()
+		if op.snapshot.removed {
+			return false, nil
+		}
+
+		if err := checkPreconditions(op.snapshot.tuples, op.preconditions, now); err != nil {
+			return false, err
+		}
+
+		if len(op.deletes) == 0 && len(op.writes) == 0 {
+			// the object of preconditions only is left as is
+			op.next, op.added, op.expired = op.snapshot, nil, nil
+			continue
+		}
+
+		if err := validateTuples(op.snapshot.tuples, op.deletes, op.writes, now); err != nil {
+			return false, err
+		}
+
+		op.next, op.added, op.expired = applyWrite(op.snapshot, op.deletes, op.writes, conditions, expirations, now)
+	}
+
+	for i := range ops {
+		ops[i].object.commitMu.Lock()
+	}
+	defer func() {
+		for i := range ops {
+			ops[i].object.commitMu.Unlock()
+		}
+	}()
+
+	for i := range ops {
+		if ops[i].object.state.Load() != ops[i].snapshot {
+			return false, nil
+		}
+	}
+
+	// the changelog is appended to while holding the commit locks, so that it's ordered like the commits
+	ts.changesMu.Lock()
+	defer ts.changesMu.Unlock()
+
+	timestamp := timestamppb.Now()
+	added := map[*openfgav1.TupleKey]struct{}{}
+	for i := range ops {
+		op := &ops[i]
+
+		for _, st := range op.added {
+			ts.seq++
+			st.seq = ts.seq
+			st.tuple.Timestamp = timestamp
+			added[st.tuple.Key] = struct{}{}
+		}
+
+		op.object.state.Store(op.next)
 	}
 
-	var tuples []*openfgav1.Tuple
+	// the expired tuples of the objects written are deleted first, and then the changes are recorded in the
+	// order of the request, regardless of the objects they apply to
+	for i := range ops {
+		for _, st := range ops[i].expired {
+			ts.changes = append(ts.changes, &openfgav1.TupleChange{TupleKey: st.tuple.Key, Operation: openfgav1.TupleOperation_TUPLE_OPERATION_DELETE, Timestamp: timestamp})
+			ts.count(st.tuple.Key, -1)
+		}
+	}
+	for _, tk := range deletes {
+		ts.changes = append(ts.changes, &openfgav1.TupleChange{TupleKey: tk, Operation: openfgav1.TupleOperation_TUPLE_OPERATION_DELETE, Timestamp: timestamp})
+	}
+	for _, tk := range writes {
+		if _, ok := added[tk]; ok {
+			ts.changes = append(ts.changes, &openfgav1.TupleChange{TupleKey: tk, Operation: openfgav1.TupleOperation_TUPLE_OPERATION_WRITE, Timestamp: timestamp})
+		}
+	}
+
+	for _, tk := range deletes {
+		ts.count(tk, -1)
+	}
+	for tk := range added {
+		ts.count(tk, 1)
+	}
+
+	return true, nil
+}
+
+// applyWrite returns the state that results from applying the deletes and then the writes to the given state,
+// along with the tuples added, which carry their conditions and expirations if any, and the tuples that expired
+// at or before now, which are deleted. The timestamps and sequence numbers of the tuples added are set on commit.
+func applyWrite(state *objectState, deletes, writes []*openfgav1.TupleKey, conditions map[string]*condition.TupleCondition, expirations map[string]time.Time, now time.Time) (*objectState, []*storedTuple, []*storedTuple) {
+	var tuples []*storedTuple
+	var added []*storedTuple
+	var expired []*storedTuple
+
 Delete:
-	for _, t := range s.tuples[store] {
+	for _, t := range state.tuples {
+		if t.expired(now) {
+			expired = append(expired, t)
+			continue
+		}
+
 		for _, k := range deletes {
-			if match(k, t.Key) {
-				s.changes[store] = append(s.changes[store], &openfgav1.TupleChange{TupleKey: t.Key, Operation: openfgav1.TupleOperation_TUPLE_OPERATION_DELETE, Timestamp: now})
+			if match(k, t.tuple.Key) {
 				continue Delete
 			}
 		}
@@ -287,53 +720,332 @@ Delete:
 	}
 
 Write:
-	for _, t := range writes {
+	for _, tk := range writes {
 		for _, et := range tuples {
-			if match(t, et.Key) {
+			if match(tk, et.tuple.Key) {
 				continue Write
 			}
 		}
-		tuples = append(tuples, &openfgav1.Tuple{Key: t, Timestamp: now})
-		s.changes[store] = append(s.changes[store], &openfgav1.TupleChange{TupleKey: t, Operation: openfgav1.TupleOperation_TUPLE_OPERATION_WRITE, Timestamp: now})
+
+		st := &storedTuple{tuple: &openfgav1.Tuple{Key: tk}}
+		if len(conditions) > 0 {
+			st.condition = conditions[tupleUtils.TupleKeyToString(tk)]
+		}
+		if len(expirations) > 0 {
+			st.expiresAt = expirations[tupleUtils.TupleKeyToString(tk)]
+		}
+		tuples = append(tuples, st)
+		added = append(added, st)
 	}
-	s.tuples[store] = tuples
-	return nil
+
+	return &objectState{tuples: tuples}, added, expired
 }
 
-func validateTuples(tuples []*openfgav1.Tuple, deletes, writes []*openfgav1.TupleKey) error {
+// validateTuples ensures that the tuples deleted exist, and that the tuples written don't, as of now.
+func validateTuples(tuples []*storedTuple, deletes, writes []*openfgav1.TupleKey, now time.Time) error {
+	live := make([]*storedTuple, 0, len(tuples))
+	for _, st := range tuples {
+		if !st.expired(now) {
+			live = append(live, st)
+		}
+	}
+
 	for _, tk := range deletes {
-		if !find(tuples, tk) {
+		if !find(live, tk) {
 			return storage.InvalidWriteInputError(tk, openfgav1.TupleOperation_TUPLE_OPERATION_DELETE)
 		}
 	}
 	for _, tk := range writes {
-		if find(tuples, tk) {
+		if find(live, tk) {
 			return storage.InvalidWriteInputError(tk, openfgav1.TupleOperation_TUPLE_OPERATION_WRITE)
 		}
 	}
 	return nil
 }
 
-func find(tuples []*openfgav1.Tuple, tupleKey *openfgav1.TupleKey) bool {
-	for _, tuple := range tuples {
-		if match(tuple.Key, tupleKey) {
+// checkPreconditions ensures that the preconditions hold for the tuples, as of now.
+func checkPreconditions(tuples []*storedTuple, preconditions []storage.Precondition, now time.Time) error {
+	for _, precondition := range preconditions {
+		exists := false
+		for _, st := range tuples {
+			if !st.expired(now) && match(st.tuple.Key, precondition.TupleKey) {
+				exists = true
+				break
+			}
+		}
+
+		if exists != precondition.Exists {
+			return storage.PreconditionFailedError(precondition)
+		}
+	}
+
+	return nil
+}
+
+func find(tuples []*storedTuple, tupleKey *openfgav1.TupleKey) bool {
+	for _, st := range tuples {
+		if match(st.tuple.Key, tupleKey) {
 			return true
 		}
 	}
 	return false
 }
 
+// DeleteExpiredTuples See storage.TupleExpirationBackend.DeleteExpiredTuples
+func (s *MemoryBackend) DeleteExpiredTuples(ctx context.Context, before time.Time) (int, error) {
+	_, span := tracer.Start(ctx, "memory.DeleteExpiredTuples")
+	defer span.End()
+
+	s.tuplesMu.RLock()
+	stores := make([]*tupleStore, 0, len(s.tuples))
+	for _, ts := range s.tuples {
+		stores = append(stores, ts)
+	}
+	s.tuplesMu.RUnlock()
+
+	var deleted int
+	for _, ts := range stores {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+
+		deleted += ts.deleteExpired(before)
+	}
+
+	return deleted, nil
+}
+
+// deleteExpired deletes the tuples of the store that expired at or before the given time, and returns the
+// number of tuples deleted.
+func (ts *tupleStore) deleteExpired(before time.Time) int {
+	ts.mu.RLock()
+	objects := make([]*objectTuples, 0, len(ts.objects))
+	for _, o := range ts.objects {
+		objects = append(objects, o)
+	}
+	ts.mu.RUnlock()
+
+	var deleted int
+	for _, o := range objects {
+		if !slices.ContainsFunc(o.state.Load().tuples, func(st *storedTuple) bool { return st.expired(before) }) {
+			continue
+		}
+
+		deleted += ts.deleteExpiredOfObject(o, before)
+	}
+
+	return deleted
+}
+
+func (ts *tupleStore) deleteExpiredOfObject(o *objectTuples, before time.Time) int {
+	o.commitMu.Lock()
+	defer o.commitMu.Unlock()
+
+	state := o.state.Load()
+	if state.removed {
+		return 0
+	}
+
+	var tuples, expired []*storedTuple
+	for _, st := range state.tuples {
+		if st.expired(before) {
+			expired = append(expired, st)
+		} else {
+			tuples = append(tuples, st)
+		}
+	}
+
+	if len(expired) == 0 {
+		return 0
+	}
+
+	ts.changesMu.Lock()
+	defer ts.changesMu.Unlock()
+
+	o.state.Store(&objectState{tuples: tuples})
+
+	timestamp := timestamppb.Now()
+	for _, st := range expired {
+		ts.changes = append(ts.changes, &openfgav1.TupleChange{TupleKey: st.tuple.Key, Operation: openfgav1.TupleOperation_TUPLE_OPERATION_DELETE, Timestamp: timestamp})
+		ts.count(st.tuple.Key, -1)
+	}
+
+	return len(expired)
+}
+
+// MaintenanceTasks returns the maintenance tasks of the memory backend:
+//
+//   - 'compaction' removes the objects whose tuples were all deleted, which are otherwise kept.
+func (s *MemoryBackend) MaintenanceTasks() []storage.MaintenanceTask {
+	return []storage.MaintenanceTask{
+		{
+			Name: "compaction",
+			Run:  s.compact,
+		},
+	}
+}
+
+func (s *MemoryBackend) compact(ctx context.Context) error {
+	s.tuplesMu.RLock()
+	stores := make([]*tupleStore, 0, len(s.tuples))
+	for _, ts := range s.tuples {
+		stores = append(stores, ts)
+	}
+	s.tuplesMu.RUnlock()
+
+	for _, ts := range stores {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		ts.compact()
+	}
+
+	return nil
+}
+
+// compact removes the objects without tuples from the store. The removed objects are marked so that the
+// writes that were about to commit to them start over.
+func (ts *tupleStore) compact() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	for object, o := range ts.objects {
+		if len(o.state.Load().tuples) > 0 {
+			continue
+		}
+
+		o.commitMu.Lock()
+		if len(o.state.Load().tuples) == 0 {
+			o.state.Store(&objectState{removed: true})
+			delete(ts.objects, object)
+		}
+		o.commitMu.Unlock()
+	}
+}
+
+// ReadTupleCondition See storage.ConditionsBackend.ReadTupleCondition
+func (s *MemoryBackend) ReadTupleCondition(ctx context.Context, store string, key *openfgav1.TupleKey) (*condition.TupleCondition, error) {
+	_, span := tracer.Start(ctx, "memory.ReadTupleCondition")
+	defer span.End()
+
+	if ts := s.tupleStore(store, false); ts != nil {
+		if o := ts.object(key.GetObject(), false); o != nil {
+			now := time.Now()
+			for _, st := range o.state.Load().tuples {
+				if match(key, st.tuple.Key) && !st.expired(now) {
+					return st.condition, nil
+				}
+			}
+		}
+	}
+
+	telemetry.TraceError(span, storage.ErrNotFound)
+	return nil, storage.ErrNotFound
+}
+
+// ReadTupleConditions See storage.ConditionsBackend.ReadTupleConditions
+func (s *MemoryBackend) ReadTupleConditions(ctx context.Context, store string, keys []*openfgav1.TupleKey) (map[string]*condition.TupleCondition, error) {
+	_, span := tracer.Start(ctx, "memory.ReadTupleConditions")
+	defer span.End()
+
+	conditions := map[string]*condition.TupleCondition{}
+
+	ts := s.tupleStore(store, false)
+	if ts == nil {
+		return conditions, nil
+	}
+
+	now := time.Now()
+	for _, key := range keys {
+		o := ts.object(key.GetObject(), false)
+		if o == nil {
+			continue
+		}
+
+		for _, st := range o.state.Load().tuples {
+			if match(key, st.tuple.Key) && !st.expired(now) {
+				conditions[tupleUtils.TupleKeyToString(key)] = st.condition
+				break
+			}
+		}
+	}
+
+	return conditions, nil
+}
+
+// SampleTuples See storage.TupleSampler.SampleTuples
+func (s *MemoryBackend) SampleTuples(ctx context.Context, store string, filter *openfgav1.TupleKey, size int) ([]*openfgav1.TupleKey, error) {
+	ctx, span := tracer.Start(ctx, "memory.SampleTuples")
+	defer span.End()
+
+	it, err := s.read(ctx, store, filter, storage.PaginationOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// a partial Fisher-Yates shuffle of the tuples matching the filter
+	tuples := slices.Clone(it.tuples)
+	size = min(size, len(tuples))
+	for i := 0; i < size; i++ {
+		j := i + rand.Intn(len(tuples)-i)
+		tuples[i], tuples[j] = tuples[j], tuples[i]
+	}
+
+	sample := make([]*openfgav1.TupleKey, 0, size)
+	for _, t := range tuples[:size] {
+		sample = append(sample, t.GetKey())
+	}
+
+	return sample, nil
+}
+
+// RelationStats See storage.StatsProvider.RelationStats
+func (s *MemoryBackend) RelationStats(ctx context.Context, store, objectType, relation string) (storage.TupleStats, error) {
+	_, span := tracer.Start(ctx, "memory.RelationStats")
+	defer span.End()
+
+	ts := s.tupleStore(store, false)
+	if ts == nil {
+		return storage.TupleStats{}, nil
+	}
+
+	ts.changesMu.Lock()
+	defer ts.changesMu.Unlock()
+
+	c, ok := ts.stats[tupleUtils.ToObjectRelationString(objectType, relation)]
+	if !ok {
+		return storage.TupleStats{}, nil
+	}
+
+	return c.tupleStats(), nil
+}
+
+// StoreStats See storage.StatsProvider.StoreStats
+func (s *MemoryBackend) StoreStats(ctx context.Context, store string) (storage.TupleStats, error) {
+	_, span := tracer.Start(ctx, "memory.StoreStats")
+	defer span.End()
+
+	ts := s.tupleStore(store, false)
+	if ts == nil {
+		return storage.TupleStats{}, nil
+	}
+
+	ts.changesMu.Lock()
+	defer ts.changesMu.Unlock()
+
+	return ts.storeStats.tupleStats(), nil
+}
+
 // ReadUserTuple See storage.TupleBackend.ReadUserTuple
-func (s *MemoryBackend) ReadUserTuple(ctx context.Context, store string, key *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+func (s *MemoryBackend) ReadUserTuple(ctx context.Context, store string, key *openfgav1.TupleKey, _ storage.ReadOptions) (*openfgav1.Tuple, error) {
 	_, span := tracer.Start(ctx, "memory.ReadUserTuple")
 	defer span.End()
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	for _, t := range s.tuples[store] {
-		if match(key, t.Key) {
-			return t, nil
+	for _, st := range s.tupleStore(store, false).candidates(key.GetObject()) {
+		if match(key, st.tuple.Key) {
+			storage.RecordTupleExpiration(ctx, st.expiresAt)
+			return st.tuple, nil
 		}
 	}
 
@@ -342,21 +1054,19 @@ func (s *MemoryBackend) ReadUserTuple(ctx context.Context, store string, key *op
 }
 
 // ReadUsersetTuples See storage.TupleBackend.ReadUsersetTuples
-func (s *MemoryBackend) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter) (storage.TupleIterator, error) {
+func (s *MemoryBackend) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter, _ storage.ReadOptions) (storage.TupleIterator, error) {
 	_, span := tracer.Start(ctx, "memory.ReadUsersetTuples")
 	defer span.End()
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	var matches []*openfgav1.Tuple
-	for _, t := range s.tuples[store] {
+	var matches []*storedTuple
+	for _, st := range s.tupleStore(store, false).candidates(filter.Object) {
+		t := st.tuple
 		if match(&openfgav1.TupleKey{
 			Object:   filter.Object,
 			Relation: filter.Relation,
 		}, t.Key) && tupleUtils.GetUserTypeFromUser(t.GetKey().GetUser()) == tupleUtils.UserSet {
 			if len(filter.AllowedUserTypeRestrictions) == 0 { // 1.0 model
-				matches = append(matches, t)
+				matches = append(matches, st)
 				continue
 			}
 
@@ -365,29 +1075,28 @@ func (s *MemoryBackend) ReadUsersetTuples(ctx context.Context, store string, fil
 			_, userRelation := tupleUtils.SplitObjectRelation(t.GetKey().GetUser())
 			for _, allowedType := range filter.AllowedUserTypeRestrictions {
 				if allowedType.Type == userType && allowedType.GetRelation() == userRelation {
-					matches = append(matches, t)
+					matches = append(matches, st)
 					continue
 				}
 			}
 		}
 	}
 
-	return &staticIterator{tuples: matches}, nil
+	return &staticIterator{tuples: readTuples(ctx, matches)}, nil
 }
 
 func (s *MemoryBackend) ReadStartingWithUser(
 	ctx context.Context,
 	store string,
 	filter storage.ReadStartingWithUserFilter,
+	_ storage.ReadOptions,
 ) (storage.TupleIterator, error) {
 	_, span := tracer.Start(ctx, "memory.ReadStartingWithUser")
 	defer span.End()
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	var matches []*openfgav1.Tuple
-	for _, t := range s.tuples[store] {
+	var matches []*storedTuple
+	for _, st := range s.tupleStore(store, false).candidates(filter.ObjectType + ":") {
+		t := st.tuple
 		if tupleUtils.GetType(t.Key.GetObject()) != filter.ObjectType {
 			continue
 		}
@@ -396,6 +1105,12 @@ func (s *MemoryBackend) ReadStartingWithUser(
 			continue
 		}
 
+		if len(filter.ObjectIDs) > 0 {
+			if _, objectID := tupleUtils.SplitObject(t.Key.GetObject()); !slices.Contains(filter.ObjectIDs, objectID) {
+				continue
+			}
+		}
+
 		for _, userFilter := range filter.UserFilter {
 			targetUser := userFilter.GetObject()
 			if userFilter.GetRelation() != "" {
@@ -403,11 +1118,11 @@ func (s *MemoryBackend) ReadStartingWithUser(
 			}
 
 			if targetUser == t.Key.GetUser() {
-				matches = append(matches, t)
+				matches = append(matches, st)
 			}
 		}
 	}
-	return &staticIterator{tuples: matches}, nil
+	return &staticIterator{tuples: readTuples(ctx, matches)}, nil
 }
 
 func findAuthorizationModelByID(id string, configurations map[string]*AuthorizationModelEntry) (*openfgav1.AuthorizationModel, bool) {
@@ -554,6 +1269,105 @@ func (s *MemoryBackend) WriteAuthorizationModel(ctx context.Context, store strin
 	return nil
 }
 
+// WriteAuthorizationModelLabels See storage.AuthorizationModelLabelsBackend.WriteAuthorizationModelLabels
+func (s *MemoryBackend) WriteAuthorizationModelLabels(ctx context.Context, store, modelID string, labels map[string]string) error {
+	_, span := tracer.Start(ctx, "memory.WriteAuthorizationModelLabels")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.authorizationModels[store][modelID]
+	if !ok {
+		telemetry.TraceError(span, storage.ErrNotFound)
+		return storage.ErrNotFound
+	}
+
+	if entry.labels == nil {
+		entry.labels = make(map[string]string, len(labels))
+	}
+	for key, value := range labels {
+		entry.labels[key] = value
+	}
+
+	return nil
+}
+
+// ReadAuthorizationModelLabels See storage.AuthorizationModelLabelsBackend.ReadAuthorizationModelLabels
+func (s *MemoryBackend) ReadAuthorizationModelLabels(ctx context.Context, store, modelID string) (map[string]string, error) {
+	_, span := tracer.Start(ctx, "memory.ReadAuthorizationModelLabels")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	labels := map[string]string{}
+	if entry, ok := s.authorizationModels[store][modelID]; ok {
+		for key, value := range entry.labels {
+			labels[key] = value
+		}
+	}
+
+	return labels, nil
+}
+
+// FindAuthorizationModelIDByLabel See storage.AuthorizationModelLabelsBackend.FindAuthorizationModelIDByLabel
+func (s *MemoryBackend) FindAuthorizationModelIDByLabel(ctx context.Context, store, key, value string) (string, error) {
+	_, span := tracer.Start(ctx, "memory.FindAuthorizationModelIDByLabel")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	modelID := ""
+	for id, entry := range s.authorizationModels[store] {
+		if v, ok := entry.labels[key]; ok && v == value && id > modelID {
+			modelID = id
+		}
+	}
+
+	if modelID == "" {
+		telemetry.TraceError(span, storage.ErrNotFound)
+		return "", storage.ErrNotFound
+	}
+
+	return modelID, nil
+}
+
+// WriteAuthorizationModelConditions See storage.ConditionsBackend.WriteAuthorizationModelConditions
+func (s *MemoryBackend) WriteAuthorizationModelConditions(ctx context.Context, store, modelID string, conditions *condition.ModelConditions) error {
+	_, span := tracer.Start(ctx, "memory.WriteAuthorizationModelConditions")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.authorizationModels[store][modelID]
+	if !ok {
+		telemetry.TraceError(span, storage.ErrNotFound)
+		return storage.ErrNotFound
+	}
+
+	entry.conditions = conditions
+
+	return nil
+}
+
+// ReadAuthorizationModelConditions See storage.ConditionsBackend.ReadAuthorizationModelConditions
+func (s *MemoryBackend) ReadAuthorizationModelConditions(ctx context.Context, store, modelID string) (*condition.ModelConditions, error) {
+	_, span := tracer.Start(ctx, "memory.ReadAuthorizationModelConditions")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.authorizationModels[store][modelID]; ok {
+		return entry.conditions, nil
+	}
+
+	return nil, nil
+}
+
 func (s *MemoryBackend) CreateStore(ctx context.Context, newStore *openfgav1.Store) (*openfgav1.Store, error) {
 	_, span := tracer.Start(ctx, "memory.CreateStore")
 	defer span.End()
@@ -595,7 +1409,12 @@ func (s *MemoryBackend) WriteAssertions(ctx context.Context, store, modelID stri
 	defer s.mu.Unlock()
 
 	assertionsID := fmt.Sprintf("%s|%s", store, modelID)
-	s.assertions[assertionsID] = assertions
+	versions := s.assertions[assertionsID]
+	s.assertions[assertionsID] = append(versions, &storage.AssertionsVersion{
+		Version:    uint32(len(versions) + 1),
+		Assertions: assertions,
+		CreatedAt:  time.Now().UTC(),
+	})
 
 	return nil
 }
@@ -608,11 +1427,39 @@ func (s *MemoryBackend) ReadAssertions(ctx context.Context, store, modelID strin
 	defer s.mu.Unlock()
 
 	assertionsID := fmt.Sprintf("%s|%s", store, modelID)
-	assertions, ok := s.assertions[assertionsID]
+	versions, ok := s.assertions[assertionsID]
 	if !ok {
 		return []*openfgav1.Assertion{}, nil
 	}
-	return assertions, nil
+	return versions[len(versions)-1].Assertions, nil
+}
+
+func (s *MemoryBackend) ReadAssertionsHistory(ctx context.Context, store, modelID string) ([]*storage.AssertionsVersion, error) {
+	_, span := tracer.Start(ctx, "memory.ReadAssertionsHistory")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	assertionsID := fmt.Sprintf("%s|%s", store, modelID)
+	versions := s.assertions[assertionsID]
+
+	history := make([]*storage.AssertionsVersion, 0, len(versions))
+	for i := len(versions) - 1; i >= 0; i-- {
+		history = append(history, versions[i])
+	}
+	return history, nil
+}
+
+func (s *MemoryBackend) DeleteAssertions(ctx context.Context, store, modelID string) error {
+	_, span := tracer.Start(ctx, "memory.DeleteAssertions")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.assertions, fmt.Sprintf("%s|%s", store, modelID))
+	return nil
 }
 
 // MaxTuplesPerWrite returns the maximum number of tuples allowed in one write operation
@@ -1,9 +1,16 @@
 package memory
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/storage/test"
 	"github.com/openfga/openfga/pkg/tuple"
 	"github.com/stretchr/testify/require"
@@ -37,3 +44,271 @@ func TestStaticTupleIteratorNoRace(t *testing.T) {
 		require.NoError(t, err)
 	}()
 }
+
+func TestConcurrentWritesToSameObject(t *testing.T) {
+	ctx := context.Background()
+	ds := New()
+	store := "store"
+
+	const writers = 50
+
+	var wg sync.WaitGroup
+	var succeeded atomic.Int32
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			// every writer writes the same tuple, so exactly one of them must succeed
+			err := ds.Write(ctx, store, nil, []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:1", "viewer", "user:jon"),
+				tuple.NewTupleKey(fmt.Sprintf("document:%d", i+2), "viewer", "user:jon"),
+			})
+			if err == nil {
+				succeeded.Add(1)
+				return
+			}
+
+			require.ErrorIs(t, err, storage.ErrInvalidWriteInput)
+		}(i)
+	}
+	wg.Wait()
+
+	require.EqualValues(t, 1, succeeded.Load())
+
+	tuples, _, err := ds.ReadPage(ctx, store, &openfgav1.TupleKey{}, storage.PaginationOptions{}, storage.ReadOptions{})
+	require.NoError(t, err)
+	require.Len(t, tuples, 2)
+
+	changes, _, err := ds.ReadChanges(ctx, store, "", storage.PaginationOptions{}, 0, storage.ReadOptions{})
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+}
+
+func TestReadsReturnTuplesInInsertionOrder(t *testing.T) {
+	ctx := context.Background()
+	ds := New()
+	store := "store"
+
+	var expected []*openfgav1.TupleKey
+	for i := 0; i < 10; i++ {
+		// alternate between objects so that the order can't be reconstructed per object
+		tk := tuple.NewTupleKey(fmt.Sprintf("document:%d", i%3), "viewer", fmt.Sprintf("user:%d", i))
+		require.NoError(t, ds.Write(ctx, store, nil, []*openfgav1.TupleKey{tk}))
+		expected = append(expected, tk)
+	}
+
+	tuples, _, err := ds.ReadPage(ctx, store, &openfgav1.TupleKey{}, storage.PaginationOptions{}, storage.ReadOptions{})
+	require.NoError(t, err)
+	require.Len(t, tuples, len(expected))
+	for i, tk := range expected {
+		require.Equal(t, tk, tuples[i].GetKey())
+	}
+}
+
+func TestStats(t *testing.T) {
+	ctx := context.Background()
+	ds := New().(*MemoryBackend)
+	store := "store"
+
+	stats, err := ds.StoreStats(ctx, store)
+	require.NoError(t, err)
+	require.Equal(t, storage.TupleStats{}, stats)
+
+	require.NoError(t, ds.Write(ctx, store, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+		tuple.NewTupleKey("document:2", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:1", "editor", "user:anne"),
+		tuple.NewTupleKey("folder:1", "viewer", "user:carl"),
+	}))
+
+	stats, err = ds.RelationStats(ctx, store, "document", "viewer")
+	require.NoError(t, err)
+	require.Equal(t, storage.TupleStats{TupleCount: 3, ObjectCount: 2, UserCount: 2}, stats)
+
+	stats, err = ds.StoreStats(ctx, store)
+	require.NoError(t, err)
+	require.Equal(t, storage.TupleStats{TupleCount: 5, ObjectCount: 3, UserCount: 3}, stats)
+
+	// the statistics are maintained as tuples are deleted
+	require.NoError(t, ds.Write(ctx, store, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:2", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:1", "editor", "user:anne"),
+	}, nil))
+
+	stats, err = ds.RelationStats(ctx, store, "document", "viewer")
+	require.NoError(t, err)
+	require.Equal(t, storage.TupleStats{TupleCount: 2, ObjectCount: 1, UserCount: 2}, stats)
+
+	stats, err = ds.RelationStats(ctx, store, "document", "editor")
+	require.NoError(t, err)
+	require.Equal(t, storage.TupleStats{}, stats)
+
+	stats, err = ds.StoreStats(ctx, store)
+	require.NoError(t, err)
+	require.Equal(t, storage.TupleStats{TupleCount: 3, ObjectCount: 2, UserCount: 3}, stats)
+
+	// a failed write doesn't change them
+	require.Error(t, ds.Write(ctx, store, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("folder:2", "viewer", "user:dave"),
+		tuple.NewTupleKey("folder:1", "viewer", "user:carl"),
+	}))
+
+	stats, err = ds.StoreStats(ctx, store)
+	require.NoError(t, err)
+	require.Equal(t, storage.TupleStats{TupleCount: 3, ObjectCount: 2, UserCount: 3}, stats)
+}
+
+func TestCompaction(t *testing.T) {
+	ctx := context.Background()
+	ds := New().(*MemoryBackend)
+	store := "store"
+
+	require.NoError(t, ds.Write(ctx, store, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:2", "viewer", "user:anne"),
+	}))
+	require.NoError(t, ds.Write(ctx, store, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:2", "viewer", "user:anne"),
+	}, nil))
+
+	tasks := ds.MaintenanceTasks()
+	require.Len(t, tasks, 1)
+	require.Equal(t, "compaction", tasks[0].Name)
+	require.NoError(t, tasks[0].Run(ctx))
+
+	ts := ds.tupleStore(store, false)
+	require.Len(t, ts.objects, 1)
+	require.Contains(t, ts.objects, "document:1")
+
+	// the removed objects can be written to again
+	require.NoError(t, ds.Write(ctx, store, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:2", "viewer", "user:bob"),
+	}))
+
+	tk, err := ds.ReadUserTuple(ctx, store, tuple.NewTupleKey("document:2", "viewer", "user:bob"), storage.ReadOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, tk)
+}
+
+func TestCompactionDoesNotLoseConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	ds := New().(*MemoryBackend)
+	store := "store"
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				if err := ds.compact(ctx); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}
+	}()
+
+	// every object is written to, then emptied, then written to again, while it's being compacted
+	for i := 0; i < 200; i++ {
+		tk := tuple.NewTupleKey(fmt.Sprintf("document:%d", i%5), "viewer", fmt.Sprintf("user:%d", i))
+		require.NoError(t, ds.Write(ctx, store, nil, []*openfgav1.TupleKey{tk}))
+		require.NoError(t, ds.Write(ctx, store, []*openfgav1.TupleKey{tk}, nil))
+		require.NoError(t, ds.Write(ctx, store, nil, []*openfgav1.TupleKey{tk}))
+	}
+
+	close(stop)
+	wg.Wait()
+
+	tuples, _, err := ds.ReadPage(ctx, store, &openfgav1.TupleKey{}, storage.PaginationOptions{}, storage.ReadOptions{})
+	require.NoError(t, err)
+	require.Len(t, tuples, 200)
+}
+
+// coarseLockDatastore serializes all the writes, like the memory backend did before it used per-object
+// optimistic concurrency. It is used as the baseline of BenchmarkConcurrentWrites.
+type coarseLockDatastore struct {
+	storage.OpenFGADatastore
+	mu sync.Mutex
+}
+
+func (c *coarseLockDatastore) Write(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.OpenFGADatastore.Write(ctx, store, deletes, writes)
+}
+
+// BenchmarkConcurrentWrites measures the throughput of concurrent writes to unrelated objects. Run it with
+// e.g. -cpu 1,4,8 to compare how the per-object write path and the coarse lock baseline scale.
+func BenchmarkConcurrentWrites(b *testing.B) {
+	datastores := map[string]func() storage.OpenFGADatastore{
+		"coarse_lock": func() storage.OpenFGADatastore {
+			return &coarseLockDatastore{OpenFGADatastore: New()}
+		},
+		"per_object": func() storage.OpenFGADatastore {
+			return New()
+		},
+	}
+
+	for _, name := range []string{"coarse_lock", "per_object"} {
+		b.Run(name, func(b *testing.B) {
+			ctx := context.Background()
+			ds := datastores[name]()
+			store := "store"
+
+			var writer atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				id := writer.Add(1)
+
+				var i int
+				for pb.Next() {
+					i++
+					err := ds.Write(ctx, store, nil, []*openfgav1.TupleKey{
+						tuple.NewTupleKey(fmt.Sprintf("document:%d-%d", id, i), "viewer", "user:jon"),
+					})
+					if err != nil && !errors.Is(err, storage.ErrInvalidWriteInput) {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestExpiredTuplesReaper(t *testing.T) {
+	ctx := context.Background()
+	ds := New(WithExpiredTuplesReapInterval(10 * time.Millisecond)).(*MemoryBackend)
+	defer ds.Close()
+	store := "store"
+
+	expiring := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+	require.NoError(t, ds.WriteWithExpirations(ctx, store, nil, []*openfgav1.TupleKey{
+		expiring,
+		tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+	}, map[string]time.Time{
+		tuple.TupleKeyToString(expiring): time.Now().Add(50 * time.Millisecond),
+	}))
+
+	_, err := ds.ReadUserTuple(ctx, store, expiring, storage.ReadOptions{})
+	require.NoError(t, err)
+
+	// the statistics are maintained as the expired tuples are deleted in the background
+	require.Eventually(t, func() bool {
+		stats, err := ds.StoreStats(ctx, store)
+		require.NoError(t, err)
+
+		return stats.TupleCount == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	_, err = ds.ReadUserTuple(ctx, store, expiring, storage.ReadOptions{})
+	require.ErrorIs(t, err, storage.ErrNotFound)
+}
@@ -0,0 +1,552 @@
+package memory
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	ChangeOperationWrite  = "write"
+	ChangeOperationDelete = "delete"
+)
+
+// Snapshot is the full state of a MemoryBackend: its stores, along with their authorization models, tuples,
+// changelog and assertions. The snapshot of a state is deterministic, so that the integration tests and the
+// bug reports can capture the exact state of the stores that reproduces an issue, either as JSON (see
+// encoding/json) or as a Go fixture (see WriteGoFixture), and restore it with NewFromSnapshot.
+type Snapshot struct {
+	// Stores are sorted by ID.
+	Stores []StoreSnapshot `json:"stores"`
+}
+
+// StoreSnapshot is the state of a store. The MemoryBackend keeps the data of the deleted stores, so they're
+// part of the snapshot too.
+type StoreSnapshot struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name,omitempty"`
+	Deleted   bool      `json:"deleted,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// AuthorizationModels are the protojson encodings of the authorization models, sorted by ID.
+	AuthorizationModels        []json.RawMessage `json:"authorization_models,omitempty"`
+	LatestAuthorizationModelID string            `json:"latest_authorization_model_id,omitempty"`
+
+	// AuthorizationModelLabels are the labels of the authorization models that have some, keyed by model ID.
+	AuthorizationModelLabels map[string]map[string]string `json:"authorization_model_labels,omitempty"`
+
+	// Tuples are in insertion order.
+	Tuples []TupleSnapshot `json:"tuples,omitempty"`
+
+	// Changes are in the order of the changelog.
+	Changes []ChangeSnapshot `json:"changes,omitempty"`
+
+	// Assertions are sorted by authorization model ID.
+	Assertions []AssertionsSnapshot `json:"assertions,omitempty"`
+}
+
+// TupleSnapshot is a tuple, along with the time it was written at.
+type TupleSnapshot struct {
+	Object    string    `json:"object"`
+	Relation  string    `json:"relation"`
+	User      string    `json:"user"`
+	Timestamp time.Time `json:"timestamp"`
+
+	// ExpiresAt is the time the tuple expires at, if any
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// ChangeSnapshot is a change of the changelog. The Operation is either ChangeOperationWrite or
+// ChangeOperationDelete.
+type ChangeSnapshot struct {
+	Operation string    `json:"operation"`
+	Object    string    `json:"object"`
+	Relation  string    `json:"relation"`
+	User      string    `json:"user"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// AssertionsSnapshot is the history of the assertions of an authorization model.
+type AssertionsSnapshot struct {
+	AuthorizationModelID string `json:"authorization_model_id"`
+
+	// Versions are from the oldest to the newest.
+	Versions []AssertionsVersionSnapshot `json:"versions"`
+}
+
+// AssertionsVersionSnapshot is a version of the assertions of an authorization model.
+type AssertionsVersionSnapshot struct {
+	Assertions []AssertionSnapshot `json:"assertions"`
+	CreatedAt  time.Time           `json:"created_at"`
+}
+
+// AssertionSnapshot is an assertion of an authorization model.
+type AssertionSnapshot struct {
+	Object      string `json:"object"`
+	Relation    string `json:"relation"`
+	User        string `json:"user"`
+	Expectation bool   `json:"expectation"`
+}
+
+// Snapshot returns the snapshot of the current state of the backend. The concurrent writes may or may not be
+// part of it.
+func (s *MemoryBackend) Snapshot() (*Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tuplesMu.RLock()
+	defer s.tuplesMu.RUnlock()
+
+	storeIDs := map[string]struct{}{}
+	for id := range s.stores {
+		storeIDs[id] = struct{}{}
+	}
+	for id := range s.authorizationModels {
+		storeIDs[id] = struct{}{}
+	}
+	for id := range s.tuples {
+		storeIDs[id] = struct{}{}
+	}
+
+	assertions := map[string][]AssertionsSnapshot{}
+	for assertionsID, versions := range s.assertions {
+		storeID, modelID, _ := strings.Cut(assertionsID, "|")
+		storeIDs[storeID] = struct{}{}
+		assertions[storeID] = append(assertions[storeID], AssertionsSnapshot{
+			AuthorizationModelID: modelID,
+			Versions:             snapshotAssertionsVersions(versions),
+		})
+	}
+
+	snapshot := &Snapshot{Stores: make([]StoreSnapshot, 0, len(storeIDs))}
+	for id := range storeIDs {
+		store := StoreSnapshot{ID: id, Deleted: true}
+		if st, ok := s.stores[id]; ok {
+			store.Name = st.GetName()
+			store.Deleted = false
+			store.CreatedAt = st.GetCreatedAt().AsTime()
+			store.UpdatedAt = st.GetUpdatedAt().AsTime()
+		}
+
+		models, latest, err := snapshotAuthorizationModels(s.authorizationModels[id])
+		if err != nil {
+			return nil, fmt.Errorf("snapshot the authorization models of the store '%s': %w", id, err)
+		}
+		store.AuthorizationModels = models
+		store.LatestAuthorizationModelID = latest
+		store.AuthorizationModelLabels = snapshotAuthorizationModelLabels(s.authorizationModels[id])
+
+		if ts, ok := s.tuples[id]; ok {
+			store.Tuples, store.Changes = ts.snapshot()
+		}
+
+		store.Assertions = assertions[id]
+		sort.Slice(store.Assertions, func(i, j int) bool {
+			return store.Assertions[i].AuthorizationModelID < store.Assertions[j].AuthorizationModelID
+		})
+
+		snapshot.Stores = append(snapshot.Stores, store)
+	}
+
+	sort.Slice(snapshot.Stores, func(i, j int) bool {
+		return snapshot.Stores[i].ID < snapshot.Stores[j].ID
+	})
+
+	return snapshot, nil
+}
+
+func snapshotAuthorizationModels(entries map[string]*AuthorizationModelEntry) ([]json.RawMessage, string, error) {
+	ids := make([]string, 0, len(entries))
+	latest := ""
+	for id, entry := range entries {
+		ids = append(ids, id)
+		if entry.latest {
+			latest = id
+		}
+	}
+	sort.Strings(ids)
+
+	models := make([]json.RawMessage, 0, len(ids))
+	for _, id := range ids {
+		encoded, err := protojson.Marshal(entries[id].model)
+		if err != nil {
+			return nil, "", err
+		}
+
+		// protojson randomizes its whitespace, which compacting removes
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, encoded); err != nil {
+			return nil, "", err
+		}
+
+		models = append(models, compacted.Bytes())
+	}
+
+	return models, latest, nil
+}
+
+func snapshotAuthorizationModelLabels(entries map[string]*AuthorizationModelEntry) map[string]map[string]string {
+	var labels map[string]map[string]string
+	for id, entry := range entries {
+		if len(entry.labels) == 0 {
+			continue
+		}
+
+		if labels == nil {
+			labels = map[string]map[string]string{}
+		}
+		labels[id] = make(map[string]string, len(entry.labels))
+		for key, value := range entry.labels {
+			labels[id][key] = value
+		}
+	}
+
+	return labels
+}
+
+func snapshotAssertionsVersions(versions []*storage.AssertionsVersion) []AssertionsVersionSnapshot {
+	snapshots := make([]AssertionsVersionSnapshot, 0, len(versions))
+	for _, version := range versions {
+		assertions := make([]AssertionSnapshot, 0, len(version.Assertions))
+		for _, assertion := range version.Assertions {
+			assertions = append(assertions, AssertionSnapshot{
+				Object:      assertion.GetTupleKey().GetObject(),
+				Relation:    assertion.GetTupleKey().GetRelation(),
+				User:        assertion.GetTupleKey().GetUser(),
+				Expectation: assertion.GetExpectation(),
+			})
+		}
+
+		snapshots = append(snapshots, AssertionsVersionSnapshot{Assertions: assertions, CreatedAt: version.CreatedAt})
+	}
+
+	return snapshots
+}
+
+// snapshot returns the tuples of the store in insertion order, and its changelog.
+func (ts *tupleStore) snapshot() ([]TupleSnapshot, []ChangeSnapshot) {
+	ts.mu.RLock()
+	objects := make([]*objectTuples, 0, len(ts.objects))
+	for _, o := range ts.objects {
+		objects = append(objects, o)
+	}
+	ts.mu.RUnlock()
+
+	// the commits hold changesMu, so the tuples and the changelog are consistent with each other, except for
+	// the objects written for the first time since they were listed
+	ts.changesMu.Lock()
+	defer ts.changesMu.Unlock()
+
+	var stored []*storedTuple
+	for _, o := range objects {
+		stored = append(stored, o.state.Load().tuples...)
+	}
+
+	sort.Slice(stored, func(i, j int) bool {
+		return stored[i].seq < stored[j].seq
+	})
+
+	tuples := make([]TupleSnapshot, 0, len(stored))
+	for _, st := range stored {
+		t := TupleSnapshot{
+			Object:    st.tuple.GetKey().GetObject(),
+			Relation:  st.tuple.GetKey().GetRelation(),
+			User:      st.tuple.GetKey().GetUser(),
+			Timestamp: st.tuple.GetTimestamp().AsTime(),
+		}
+		if !st.expiresAt.IsZero() {
+			expiresAt := st.expiresAt.UTC()
+			t.ExpiresAt = &expiresAt
+		}
+		tuples = append(tuples, t)
+	}
+
+	changes := make([]ChangeSnapshot, 0, len(ts.changes))
+	for _, change := range ts.changes {
+		operation := ChangeOperationWrite
+		if change.GetOperation() == openfgav1.TupleOperation_TUPLE_OPERATION_DELETE {
+			operation = ChangeOperationDelete
+		}
+
+		changes = append(changes, ChangeSnapshot{
+			Operation: operation,
+			Object:    change.GetTupleKey().GetObject(),
+			Relation:  change.GetTupleKey().GetRelation(),
+			User:      change.GetTupleKey().GetUser(),
+			Timestamp: change.GetTimestamp().AsTime(),
+		})
+	}
+
+	return tuples, changes
+}
+
+// NewFromSnapshot creates a new MemoryBackend with the state of the snapshot.
+func NewFromSnapshot(snapshot *Snapshot, opts ...StorageOption) (storage.OpenFGADatastore, error) {
+	ds := New(opts...).(*MemoryBackend)
+
+	for _, store := range snapshot.Stores {
+		if err := ds.restore(store); err != nil {
+			return nil, fmt.Errorf("restore the store '%s': %w", store.ID, err)
+		}
+	}
+
+	return ds, nil
+}
+
+func (s *MemoryBackend) restore(store StoreSnapshot) error {
+	if _, ok := s.stores[store.ID]; ok {
+		return fmt.Errorf("the store is in the snapshot more than once")
+	}
+
+	if !store.Deleted {
+		s.stores[store.ID] = &openfgav1.Store{
+			Id:        store.ID,
+			Name:      store.Name,
+			CreatedAt: timestamppb.New(store.CreatedAt),
+			UpdatedAt: timestamppb.New(store.UpdatedAt),
+		}
+	}
+
+	if len(store.AuthorizationModels) > 0 {
+		models := make(map[string]*AuthorizationModelEntry, len(store.AuthorizationModels))
+		for _, encoded := range store.AuthorizationModels {
+			model := &openfgav1.AuthorizationModel{}
+			if err := protojson.Unmarshal(encoded, model); err != nil {
+				return fmt.Errorf("invalid authorization model: %w", err)
+			}
+
+			models[model.GetId()] = &AuthorizationModelEntry{model: model, latest: model.GetId() == store.LatestAuthorizationModelID}
+		}
+
+		if _, ok := models[store.LatestAuthorizationModelID]; !ok {
+			return fmt.Errorf("the latest authorization model '%s' isn't in the snapshot", store.LatestAuthorizationModelID)
+		}
+
+		for id, labels := range store.AuthorizationModelLabels {
+			entry, ok := models[id]
+			if !ok {
+				return fmt.Errorf("the labeled authorization model '%s' isn't in the snapshot", id)
+			}
+
+			entry.labels = make(map[string]string, len(labels))
+			for key, value := range labels {
+				entry.labels[key] = value
+			}
+		}
+
+		s.authorizationModels[store.ID] = models
+	}
+
+	if len(store.Tuples) > 0 || len(store.Changes) > 0 {
+		ts, err := restoreTupleStore(store.Tuples, store.Changes)
+		if err != nil {
+			return err
+		}
+
+		// the expired tuples may be deleted in the background in the meantime
+		s.tuplesMu.Lock()
+		s.tuples[store.ID] = ts
+		s.tuplesMu.Unlock()
+	}
+
+	for _, assertions := range store.Assertions {
+		versions := make([]*storage.AssertionsVersion, 0, len(assertions.Versions))
+		for i, version := range assertions.Versions {
+			restored := &storage.AssertionsVersion{
+				Version:    uint32(i + 1),
+				Assertions: make([]*openfgav1.Assertion, 0, len(version.Assertions)),
+				CreatedAt:  version.CreatedAt,
+			}
+			for _, assertion := range version.Assertions {
+				restored.Assertions = append(restored.Assertions, &openfgav1.Assertion{
+					TupleKey:    &openfgav1.TupleKey{Object: assertion.Object, Relation: assertion.Relation, User: assertion.User},
+					Expectation: assertion.Expectation,
+				})
+			}
+			versions = append(versions, restored)
+		}
+
+		s.assertions[fmt.Sprintf("%s|%s", store.ID, assertions.AuthorizationModelID)] = versions
+	}
+
+	return nil
+}
+
+func restoreTupleStore(tuples []TupleSnapshot, changes []ChangeSnapshot) (*tupleStore, error) {
+	ts := &tupleStore{objects: map[string]*objectTuples{}}
+
+	stored := map[string][]*storedTuple{}
+	for _, t := range tuples {
+		tk := &openfgav1.TupleKey{Object: t.Object, Relation: t.Relation, User: t.User}
+		if find(stored[t.Object], tk) {
+			return nil, fmt.Errorf("the tuple '%s#%s@%s' is in the snapshot more than once", t.Object, t.Relation, t.User)
+		}
+
+		ts.seq++
+		st := &storedTuple{
+			tuple: &openfgav1.Tuple{Key: tk, Timestamp: timestamppb.New(t.Timestamp)},
+			seq:   ts.seq,
+		}
+		if t.ExpiresAt != nil {
+			st.expiresAt = *t.ExpiresAt
+		}
+		stored[t.Object] = append(stored[t.Object], st)
+		ts.count(tk, 1)
+	}
+
+	for object, objectTuples := range stored {
+		o := ts.object(object, true)
+		o.state.Store(&objectState{tuples: objectTuples})
+	}
+
+	for _, change := range changes {
+		operation := openfgav1.TupleOperation_TUPLE_OPERATION_WRITE
+		switch change.Operation {
+		case ChangeOperationWrite:
+		case ChangeOperationDelete:
+			operation = openfgav1.TupleOperation_TUPLE_OPERATION_DELETE
+		default:
+			return nil, fmt.Errorf("invalid change operation '%s'", change.Operation)
+		}
+
+		ts.changes = append(ts.changes, &openfgav1.TupleChange{
+			TupleKey:  &openfgav1.TupleKey{Object: change.Object, Relation: change.Relation, User: change.User},
+			Operation: operation,
+			Timestamp: timestamppb.New(change.Timestamp),
+		})
+	}
+
+	return ts, nil
+}
+
+// WriteGoFixture writes the snapshot as a Go source file of the named package, which declares a variable of
+// the provided name holding the snapshot.
+func (snapshot *Snapshot) WriteGoFixture(w io.Writer, packageName, varName string) error {
+	var src bytes.Buffer
+	p := func(format string, args ...interface{}) {
+		fmt.Fprintf(&src, format, args...)
+	}
+
+	hasModels := false
+	for _, store := range snapshot.Stores {
+		hasModels = hasModels || len(store.AuthorizationModels) > 0
+	}
+
+	p("// Code generated by memory.Snapshot.WriteGoFixture. DO NOT EDIT.\n\n")
+	p("package %s\n\n", packageName)
+	p("import (\n")
+	if hasModels {
+		p("%q\n", "encoding/json")
+	}
+	p("%q\n\n%q\n)\n\n", "time", "github.com/openfga/openfga/pkg/storage/memory")
+
+	p("var %s = &memory.Snapshot{\nStores: []memory.StoreSnapshot{\n", varName)
+	for _, store := range snapshot.Stores {
+		p("{\nID: %q,\n", store.ID)
+		if store.Name != "" {
+			p("Name: %q,\n", store.Name)
+		}
+		if store.Deleted {
+			p("Deleted: true,\n")
+		}
+		p("CreatedAt: %s,\nUpdatedAt: %s,\n", goTime(store.CreatedAt), goTime(store.UpdatedAt))
+
+		if len(store.AuthorizationModels) > 0 {
+			p("AuthorizationModels: []json.RawMessage{\n")
+			for _, model := range store.AuthorizationModels {
+				p("json.RawMessage(%q),\n", model)
+			}
+			p("},\nLatestAuthorizationModelID: %q,\n", store.LatestAuthorizationModelID)
+		}
+
+		if len(store.AuthorizationModelLabels) > 0 {
+			p("AuthorizationModelLabels: map[string]map[string]string{\n")
+			for _, id := range sortedKeys(store.AuthorizationModelLabels) {
+				p("%q: {\n", id)
+				for _, key := range sortedKeys(store.AuthorizationModelLabels[id]) {
+					p("%q: %q,\n", key, store.AuthorizationModelLabels[id][key])
+				}
+				p("},\n")
+			}
+			p("},\n")
+		}
+
+		if len(store.Tuples) > 0 {
+			p("Tuples: []memory.TupleSnapshot{\n")
+			for _, t := range store.Tuples {
+				if t.ExpiresAt != nil {
+					p("{Object: %q, Relation: %q, User: %q, Timestamp: %s, ExpiresAt: func() *time.Time { t := %s; return &t }()},\n", t.Object, t.Relation, t.User, goTime(t.Timestamp), goTime(*t.ExpiresAt))
+				} else {
+					p("{Object: %q, Relation: %q, User: %q, Timestamp: %s},\n", t.Object, t.Relation, t.User, goTime(t.Timestamp))
+				}
+			}
+			p("},\n")
+		}
+
+		if len(store.Changes) > 0 {
+			p("Changes: []memory.ChangeSnapshot{\n")
+			for _, c := range store.Changes {
+				p("{Operation: %q, Object: %q, Relation: %q, User: %q, Timestamp: %s},\n", c.Operation, c.Object, c.Relation, c.User, goTime(c.Timestamp))
+			}
+			p("},\n")
+		}
+
+		if len(store.Assertions) > 0 {
+			p("Assertions: []memory.AssertionsSnapshot{\n")
+			for _, assertions := range store.Assertions {
+				p("{\nAuthorizationModelID: %q,\nVersions: []memory.AssertionsVersionSnapshot{\n", assertions.AuthorizationModelID)
+				for _, version := range assertions.Versions {
+					p("{\nAssertions: []memory.AssertionSnapshot{\n")
+					for _, a := range version.Assertions {
+						p("{Object: %q, Relation: %q, User: %q, Expectation: %t},\n", a.Object, a.Relation, a.User, a.Expectation)
+					}
+					p("},\nCreatedAt: %s,\n},\n", goTime(version.CreatedAt))
+				}
+				p("},\n},\n")
+			}
+			p("},\n")
+		}
+
+		p("},\n")
+	}
+	p("},\n}\n")
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return fmt.Errorf("format the Go fixture: %w", err)
+	}
+
+	_, err = w.Write(formatted)
+	return err
+}
+
+// goTime returns the Go expression of the time.
+func goTime(t time.Time) string {
+	if t.IsZero() {
+		return "time.Time{}"
+	}
+
+	return fmt.Sprintf("time.Unix(%d, %d).UTC()", t.Unix(), t.Nanosecond())
+}
+
+// sortedKeys returns the keys of the map, sorted.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
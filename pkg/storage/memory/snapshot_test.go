@@ -0,0 +1,219 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	ds := New().(*MemoryBackend)
+
+	_, err := ds.CreateStore(ctx, &openfgav1.Store{Id: "01HCSBNPGRMSRYJRJRZ0KCZP1C", Name: "docs"})
+	require.NoError(t, err)
+
+	model := &openfgav1.AuthorizationModel{
+		Id:            "01HCSBNWV4YGBQW0Y8HP1Q4NKJ",
+		SchemaVersion: "1.1",
+		TypeDefinitions: []*openfgav1.TypeDefinition{
+			{Type: "user"},
+			{
+				Type: "document",
+				Relations: map[string]*openfgav1.Userset{
+					"viewer": {Userset: &openfgav1.Userset_This{}},
+				},
+				Metadata: &openfgav1.Metadata{
+					Relations: map[string]*openfgav1.RelationMetadata{
+						"viewer": {DirectlyRelatedUserTypes: []*openfgav1.RelationReference{{Type: "user"}}},
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, ds.WriteAuthorizationModel(ctx, "01HCSBNPGRMSRYJRJRZ0KCZP1C", model))
+	require.NoError(t, ds.WriteAuthorizationModelLabels(ctx, "01HCSBNPGRMSRYJRJRZ0KCZP1C", model.Id, map[string]string{"release": "42"}))
+
+	require.NoError(t, ds.Write(ctx, "01HCSBNPGRMSRYJRJRZ0KCZP1C", nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:2", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+	}))
+	require.NoError(t, ds.Write(ctx, "01HCSBNPGRMSRYJRJRZ0KCZP1C", []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+	}, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:charlie"),
+	}))
+
+	require.NoError(t, ds.WriteAssertions(ctx, "01HCSBNPGRMSRYJRJRZ0KCZP1C", model.Id, []*openfgav1.Assertion{
+		{TupleKey: tuple.NewTupleKey("document:2", "viewer", "user:anne"), Expectation: true},
+	}))
+
+	// the data of a deleted store is kept, and part of the snapshot
+	_, err = ds.CreateStore(ctx, &openfgav1.Store{Id: "01HCSBP2X1J7ZB4P4R6BZG1G9W", Name: "deleted"})
+	require.NoError(t, err)
+	require.NoError(t, ds.Write(ctx, "01HCSBP2X1J7ZB4P4R6BZG1G9W", nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+	}))
+	require.NoError(t, ds.DeleteStore(ctx, "01HCSBP2X1J7ZB4P4R6BZG1G9W"))
+
+	snapshot, err := ds.Snapshot()
+	require.NoError(t, err)
+	require.Len(t, snapshot.Stores, 2)
+
+	store := snapshot.Stores[0]
+	require.Equal(t, "docs", store.Name)
+	require.Equal(t, model.Id, store.LatestAuthorizationModelID)
+	require.Len(t, store.AuthorizationModels, 1)
+	require.Equal(t, map[string]map[string]string{model.Id: {"release": "42"}}, store.AuthorizationModelLabels)
+	require.Equal(t, []string{"document:2#viewer@user:anne", "document:1#viewer@user:charlie"}, tupleStrings(store.Tuples))
+	require.Len(t, store.Changes, 4)
+	require.Equal(t, ChangeOperationDelete, store.Changes[2].Operation)
+	require.Len(t, store.Assertions, 1)
+	require.True(t, snapshot.Stores[1].Deleted)
+
+	encoded, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+
+	t.Run("deterministic", func(t *testing.T) {
+		again, err := ds.Snapshot()
+		require.NoError(t, err)
+
+		encodedAgain, err := json.Marshal(again)
+		require.NoError(t, err)
+		require.Equal(t, string(encoded), string(encodedAgain))
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		var decoded Snapshot
+		require.NoError(t, json.Unmarshal(encoded, &decoded))
+
+		restored, err := NewFromSnapshot(&decoded)
+		require.NoError(t, err)
+
+		restoredSnapshot, err := restored.(*MemoryBackend).Snapshot()
+		require.NoError(t, err)
+
+		encodedRestored, err := json.Marshal(restoredSnapshot)
+		require.NoError(t, err)
+		require.Equal(t, string(encoded), string(encodedRestored))
+
+		tuples, _, err := restored.ReadPage(ctx, "01HCSBNPGRMSRYJRJRZ0KCZP1C", &openfgav1.TupleKey{}, storage.PaginationOptions{}, storage.ReadOptions{})
+		require.NoError(t, err)
+		require.Len(t, tuples, 2)
+
+		latest, err := restored.FindLatestAuthorizationModelID(ctx, "01HCSBNPGRMSRYJRJRZ0KCZP1C")
+		require.NoError(t, err)
+		require.Equal(t, model.Id, latest)
+
+		labeled, err := restored.FindAuthorizationModelIDByLabel(ctx, "01HCSBNPGRMSRYJRJRZ0KCZP1C", "release", "42")
+		require.NoError(t, err)
+		require.Equal(t, model.Id, labeled)
+
+		_, err = restored.GetStore(ctx, "01HCSBP2X1J7ZB4P4R6BZG1G9W")
+		require.ErrorIs(t, err, storage.ErrNotFound)
+
+		stats, err := restored.(*MemoryBackend).StoreStats(ctx, "01HCSBNPGRMSRYJRJRZ0KCZP1C")
+		require.NoError(t, err)
+		require.EqualValues(t, 2, stats.TupleCount)
+
+		// the restored backend accepts writes like the original one
+		err = restored.Write(ctx, "01HCSBNPGRMSRYJRJRZ0KCZP1C", nil, []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:charlie")})
+		require.ErrorIs(t, err, storage.ErrInvalidWriteInput)
+	})
+
+	t.Run("go_fixture", func(t *testing.T) {
+		var fixture bytes.Buffer
+		require.NoError(t, snapshot.WriteGoFixture(&fixture, "fixtures", "docsSnapshot"))
+
+		_, err := parser.ParseFile(token.NewFileSet(), "fixture.go", fixture.Bytes(), 0)
+		require.NoError(t, err)
+
+		require.Contains(t, fixture.String(), "var docsSnapshot = &memory.Snapshot{")
+		require.Contains(t, fixture.String(), `{Object: "document:1", Relation: "viewer", User: "user:charlie", Timestamp: time.Unix(`)
+		require.Contains(t, fixture.String(), `LatestAuthorizationModelID: "01HCSBNWV4YGBQW0Y8HP1Q4NKJ",`)
+		require.Contains(t, fixture.String(), `"01HCSBNWV4YGBQW0Y8HP1Q4NKJ": {`)
+		require.Contains(t, fixture.String(), "Deleted:   true,")
+	})
+}
+
+func TestSnapshotExpirations(t *testing.T) {
+	ctx := context.Background()
+	ds := New().(*MemoryBackend)
+
+	_, err := ds.CreateStore(ctx, &openfgav1.Store{Id: "01HCSBNPGRMSRYJRJRZ0KCZP1C", Name: "docs"})
+	require.NoError(t, err)
+
+	expiring := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+	require.NoError(t, ds.WriteWithExpirations(ctx, "01HCSBNPGRMSRYJRJRZ0KCZP1C", nil, []*openfgav1.TupleKey{expiring}, map[string]time.Time{
+		tuple.TupleKeyToString(expiring): time.Now().Add(time.Hour),
+	}))
+
+	snapshot, err := ds.Snapshot()
+	require.NoError(t, err)
+	require.NotNil(t, snapshot.Stores[0].Tuples[0].ExpiresAt)
+
+	encoded, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+
+	var decoded Snapshot
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+
+	restored, err := NewFromSnapshot(&decoded)
+	require.NoError(t, err)
+
+	// the restored tuple still expires
+	deleted, err := restored.(*MemoryBackend).DeleteExpiredTuples(ctx, time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 1, deleted)
+
+	var fixture bytes.Buffer
+	require.NoError(t, snapshot.WriteGoFixture(&fixture, "fixtures", "docsSnapshot"))
+
+	_, err = parser.ParseFile(token.NewFileSet(), "fixture.go", fixture.Bytes(), 0)
+	require.NoError(t, err)
+	require.Contains(t, fixture.String(), "ExpiresAt: func() *time.Time {")
+}
+
+func TestNewFromSnapshotErrors(t *testing.T) {
+	tests := map[string]*Snapshot{
+		"duplicate_store": {Stores: []StoreSnapshot{{ID: "store"}, {ID: "store"}}},
+		"duplicate_tuple": {Stores: []StoreSnapshot{{ID: "store", Tuples: []TupleSnapshot{
+			{Object: "document:1", Relation: "viewer", User: "user:anne"},
+			{Object: "document:1", Relation: "viewer", User: "user:anne"},
+		}}}},
+		"invalid_change_operation": {Stores: []StoreSnapshot{{ID: "store", Changes: []ChangeSnapshot{
+			{Operation: "update", Object: "document:1", Relation: "viewer", User: "user:anne"},
+		}}}},
+		"missing_latest_model": {Stores: []StoreSnapshot{{ID: "store", AuthorizationModels: []json.RawMessage{
+			json.RawMessage(`{"id":"01HCSBNWV4YGBQW0Y8HP1Q4NKJ","schema_version":"1.1"}`),
+		}}}},
+		"invalid_model": {Stores: []StoreSnapshot{{ID: "store", AuthorizationModels: []json.RawMessage{
+			json.RawMessage(`{"id":1}`),
+		}}}},
+	}
+
+	for name, snapshot := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewFromSnapshot(snapshot)
+			require.Error(t, err)
+		})
+	}
+}
+
+func tupleStrings(tuples []TupleSnapshot) []string {
+	strings := make([]string, 0, len(tuples))
+	for _, t := range tuples {
+		strings = append(strings, tuple.TupleKeyToString(tuple.NewTupleKey(t.Object, t.Relation, t.User)))
+	}
+
+	return strings
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"strings"
 	"time"
 
@@ -14,10 +15,13 @@ import (
 	"github.com/cenkalti/backoff/v4"
 	"github.com/go-sql-driver/mysql"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/assets"
+	"github.com/openfga/openfga/pkg/condition"
 	"github.com/openfga/openfga/pkg/logger"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/storage/sqlcommon"
 	tupleUtils "github.com/openfga/openfga/pkg/tuple"
+	"github.com/pressly/goose/v3"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"go.opentelemetry.io/otel"
@@ -33,11 +37,19 @@ type MySQL struct {
 	db                     *sql.DB
 	logger                 logger.Logger
 	dbStatsCollector       prometheus.Collector
+	migrator               *sqlcommon.Migrator
 	maxTuplesPerWriteField int
 	maxTypesPerModelField  int
+	reaper                 *storage.ExpiredTuplesReaper
 }
 
 var _ storage.OpenFGADatastore = (*MySQL)(nil)
+var _ storage.SchemaMigrator = (*MySQL)(nil)
+var _ storage.Maintainer = (*MySQL)(nil)
+var _ storage.ConditionsBackend = (*MySQL)(nil)
+var _ storage.TupleExpirationBackend = (*MySQL)(nil)
+var _ storage.PreconditionsBackend = (*MySQL)(nil)
+var _ storage.TupleSampler = (*MySQL)(nil)
 
 func New(uri string, cfg *sqlcommon.Config) (*MySQL, error) {
 	if cfg.Username != "" || cfg.Password != "" {
@@ -93,6 +105,16 @@ func New(uri string, cfg *sqlcommon.Config) (*MySQL, error) {
 		return nil, fmt.Errorf("ping db: %w", err)
 	}
 
+	migrations, err := fs.Sub(assets.EmbedMigrations, assets.MySQLMigrationDir)
+	if err != nil {
+		return nil, fmt.Errorf("initialize migrations: %w", err)
+	}
+
+	migrator, err := sqlcommon.NewMigrator(db, goose.DialectMySQL, migrations)
+	if err != nil {
+		return nil, err
+	}
+
 	var collector prometheus.Collector
 	if cfg.ExportMetrics {
 		collector = collectors.NewDBStatsCollector(db, "openfga")
@@ -101,32 +123,44 @@ func New(uri string, cfg *sqlcommon.Config) (*MySQL, error) {
 		}
 	}
 
-	return &MySQL{
+	ds := &MySQL{
 		stbl:                   sq.StatementBuilder.RunWith(db),
 		db:                     db,
 		logger:                 cfg.Logger,
 		dbStatsCollector:       collector,
+		migrator:               migrator,
 		maxTuplesPerWriteField: cfg.MaxTuplesPerWriteField,
 		maxTypesPerModelField:  cfg.MaxTypesPerModelField,
-	}, nil
+	}
+
+	if cfg.ExpiredTuplesReapInterval > 0 {
+		ds.reaper = storage.StartExpiredTuplesReaper(ds, cfg.ExpiredTuplesReapInterval, func(err error) {
+			cfg.Logger.Warn("failed to delete the expired tuples", zap.Error(err))
+		})
+	}
+
+	return ds, nil
 }
 
 // Close closes the datastore and cleans up any residual resources.
 func (m *MySQL) Close() {
+	if m.reaper != nil {
+		m.reaper.Stop()
+	}
 	if m.dbStatsCollector != nil {
 		prometheus.Unregister(m.dbStatsCollector)
 	}
 	m.db.Close()
 }
 
-func (m *MySQL) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (storage.TupleIterator, error) {
+func (m *MySQL) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, _ storage.ReadOptions) (storage.TupleIterator, error) {
 	ctx, span := tracer.Start(ctx, "mysql.Read")
 	defer span.End()
 
 	return m.read(ctx, store, tupleKey, nil)
 }
 
-func (m *MySQL) ReadPage(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, opts storage.PaginationOptions) ([]*openfgav1.Tuple, []byte, error) {
+func (m *MySQL) ReadPage(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, opts storage.PaginationOptions, _ storage.ReadOptions) ([]*openfgav1.Tuple, []byte, error) {
 	ctx, span := tracer.Start(ctx, "mysql.ReadPage")
 	defer span.End()
 
@@ -144,8 +178,9 @@ func (m *MySQL) read(ctx context.Context, store string, tupleKey *openfgav1.Tupl
 	defer span.End()
 
 	sb := m.stbl.
-		Select("store", "object_type", "object_id", "relation", "_user", "ulid", "inserted_at").
+		Select("store", "object_type", "object_id", "relation", "_user", "ulid", "inserted_at", "expires_at").
 		From("tuple").
+		Where(sqlcommon.NotExpired(time.Now().UTC())).
 		Where(sq.Eq{"store": store})
 	if opts != nil {
 		sb = sb.OrderBy("ulid")
@@ -179,7 +214,7 @@ func (m *MySQL) read(ctx context.Context, store string, tupleKey *openfgav1.Tupl
 		return nil, sqlcommon.HandleSQLError(err)
 	}
 
-	return sqlcommon.NewSQLTupleIterator(rows), nil
+	return sqlcommon.NewSQLTupleIterator(ctx, rows), nil
 }
 
 func (m *MySQL) Write(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes) error {
@@ -195,7 +230,76 @@ func (m *MySQL) Write(ctx context.Context, store string, deletes storage.Deletes
 	return sqlcommon.Write(ctx, sqlcommon.NewDBInfo(m.db, m.stbl, sq.Expr("NOW()")), store, deletes, writes, now)
 }
 
-func (m *MySQL) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+func (m *MySQL) WriteWithConditions(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, conditions map[string]*condition.TupleCondition) error {
+	ctx, span := tracer.Start(ctx, "mysql.WriteWithConditions")
+	defer span.End()
+
+	if len(deletes)+len(writes) > m.MaxTuplesPerWrite() {
+		return storage.ErrExceededWriteBatchLimit
+	}
+
+	now := time.Now().UTC()
+
+	return sqlcommon.WriteWithConditions(ctx, sqlcommon.NewDBInfo(m.db, m.stbl, sq.Expr("NOW()")), store, deletes, writes, conditions, now)
+}
+
+func (m *MySQL) WriteWithExpirations(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, expirations map[string]time.Time) error {
+	ctx, span := tracer.Start(ctx, "mysql.WriteWithExpirations")
+	defer span.End()
+
+	if len(deletes)+len(writes) > m.MaxTuplesPerWrite() {
+		return storage.ErrExceededWriteBatchLimit
+	}
+
+	now := time.Now().UTC()
+
+	return sqlcommon.WriteWithExpirations(ctx, sqlcommon.NewDBInfo(m.db, m.stbl, sq.Expr("NOW()")), store, deletes, writes, expirations, now)
+}
+
+func (m *MySQL) WriteWithPreconditions(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, preconditions []storage.Precondition) error {
+	ctx, span := tracer.Start(ctx, "mysql.WriteWithPreconditions")
+	defer span.End()
+
+	if len(deletes)+len(writes) > m.MaxTuplesPerWrite() {
+		return storage.ErrExceededWriteBatchLimit
+	}
+
+	now := time.Now().UTC()
+
+	return sqlcommon.WriteWithPreconditions(ctx, sqlcommon.NewDBInfo(m.db, m.stbl, sq.Expr("NOW()")), store, deletes, writes, preconditions, now)
+}
+
+// DeleteExpiredTuples deletes the tuples of all the stores that expired at or before the given time, in
+// batches, recording their deletion in the changelog.
+func (m *MySQL) DeleteExpiredTuples(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := tracer.Start(ctx, "mysql.DeleteExpiredTuples")
+	defer span.End()
+
+	return sqlcommon.DeleteExpiredTuples(ctx, sqlcommon.NewDBInfo(m.db, m.stbl, sq.Expr("NOW()")), before.UTC())
+}
+
+func (m *MySQL) ReadTupleCondition(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (*condition.TupleCondition, error) {
+	ctx, span := tracer.Start(ctx, "mysql.ReadTupleCondition")
+	defer span.End()
+
+	return sqlcommon.ReadTupleCondition(ctx, sqlcommon.NewDBInfo(m.db, m.stbl, "NOW()"), store, tupleKey)
+}
+
+func (m *MySQL) ReadTupleConditions(ctx context.Context, store string, tupleKeys []*openfgav1.TupleKey) (map[string]*condition.TupleCondition, error) {
+	ctx, span := tracer.Start(ctx, "mysql.ReadTupleConditions")
+	defer span.End()
+
+	return sqlcommon.ReadTupleConditions(ctx, sqlcommon.NewDBInfo(m.db, m.stbl, "NOW()"), store, tupleKeys)
+}
+
+func (m *MySQL) SampleTuples(ctx context.Context, store string, filter *openfgav1.TupleKey, size int) ([]*openfgav1.TupleKey, error) {
+	ctx, span := tracer.Start(ctx, "mysql.SampleTuples")
+	defer span.End()
+
+	return sqlcommon.SampleTuples(ctx, sqlcommon.NewDBInfo(m.db, m.stbl, "NOW()"), store, filter, size, "RAND()")
+}
+
+func (m *MySQL) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, _ storage.ReadOptions) (*openfgav1.Tuple, error) {
 	ctx, span := tracer.Start(ctx, "mysql.ReadUserTuple")
 	defer span.End()
 
@@ -204,8 +308,9 @@ func (m *MySQL) ReadUserTuple(ctx context.Context, store string, tupleKey *openf
 
 	var record sqlcommon.TupleRecord
 	err := m.stbl.
-		Select("object_type", "object_id", "relation", "_user").
+		Select("object_type", "object_id", "relation", "_user", "expires_at").
 		From("tuple").
+		Where(sqlcommon.NotExpired(time.Now().UTC())).
 		Where(sq.Eq{
 			"store":       store,
 			"object_type": objectType,
@@ -215,20 +320,23 @@ func (m *MySQL) ReadUserTuple(ctx context.Context, store string, tupleKey *openf
 			"user_type":   userType,
 		}).
 		QueryRowContext(ctx).
-		Scan(&record.ObjectType, &record.ObjectID, &record.Relation, &record.User)
+		Scan(&record.ObjectType, &record.ObjectID, &record.Relation, &record.User, &record.ExpiresAt)
 	if err != nil {
 		return nil, sqlcommon.HandleSQLError(err)
 	}
 
+	storage.RecordTupleExpiration(ctx, record.ExpiresAt.Time)
+
 	return record.AsTuple(), nil
 }
 
-func (m *MySQL) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter) (storage.TupleIterator, error) {
+func (m *MySQL) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter, _ storage.ReadOptions) (storage.TupleIterator, error) {
 	ctx, span := tracer.Start(ctx, "mysql.ReadUsersetTuples")
 	defer span.End()
 
-	sb := m.stbl.Select("store", "object_type", "object_id", "relation", "_user", "ulid", "inserted_at").
+	sb := m.stbl.Select("store", "object_type", "object_id", "relation", "_user", "ulid", "inserted_at", "expires_at").
 		From("tuple").
+		Where(sqlcommon.NotExpired(time.Now().UTC())).
 		Where(sq.Eq{"store": store}).
 		Where(sq.Eq{"user_type": tupleUtils.UserSet})
 
@@ -259,10 +367,10 @@ func (m *MySQL) ReadUsersetTuples(ctx context.Context, store string, filter stor
 		return nil, sqlcommon.HandleSQLError(err)
 	}
 
-	return sqlcommon.NewSQLTupleIterator(rows), nil
+	return sqlcommon.NewSQLTupleIterator(ctx, rows), nil
 }
 
-func (m *MySQL) ReadStartingWithUser(ctx context.Context, store string, opts storage.ReadStartingWithUserFilter) (storage.TupleIterator, error) {
+func (m *MySQL) ReadStartingWithUser(ctx context.Context, store string, opts storage.ReadStartingWithUserFilter, _ storage.ReadOptions) (storage.TupleIterator, error) {
 	ctx, span := tracer.Start(ctx, "mysql.ReadStartingWithUser")
 	defer span.End()
 
@@ -275,20 +383,26 @@ func (m *MySQL) ReadStartingWithUser(ctx context.Context, store string, opts sto
 		targetUsersArg = append(targetUsersArg, targetUser)
 	}
 
-	rows, err := m.stbl.
-		Select("store", "object_type", "object_id", "relation", "_user", "ulid", "inserted_at").
+	sb := m.stbl.
+		Select("store", "object_type", "object_id", "relation", "_user", "ulid", "inserted_at", "expires_at").
 		From("tuple").
+		Where(sqlcommon.NotExpired(time.Now().UTC())).
 		Where(sq.Eq{
 			"store":       store,
 			"object_type": opts.ObjectType,
 			"relation":    opts.Relation,
 			"_user":       targetUsersArg,
-		}).QueryContext(ctx)
+		})
+	if len(opts.ObjectIDs) > 0 {
+		sb = sb.Where(sq.Eq{"object_id": opts.ObjectIDs})
+	}
+
+	rows, err := sb.QueryContext(ctx)
 	if err != nil {
 		return nil, sqlcommon.HandleSQLError(err)
 	}
 
-	return sqlcommon.NewSQLTupleIterator(rows), nil
+	return sqlcommon.NewSQLTupleIterator(ctx, rows), nil
 }
 
 func (m *MySQL) MaxTuplesPerWrite() int {
@@ -572,16 +686,34 @@ func (m *MySQL) WriteAssertions(ctx context.Context, store, modelID string, asse
 		return err
 	}
 
+	txn, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return sqlcommon.HandleSQLError(err)
+	}
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
 	_, err = m.stbl.
 		Insert("assertion").
 		Columns("store", "authorization_model_id", "assertions").
 		Values(store, modelID, marshalledAssertions).
 		Suffix("ON DUPLICATE KEY UPDATE assertions = ?", marshalledAssertions).
+		RunWith(txn). // Part of a txn
 		ExecContext(ctx)
 	if err != nil {
 		return sqlcommon.HandleSQLError(err)
 	}
 
+	err = sqlcommon.WriteAssertionsHistory(ctx, sqlcommon.NewDBInfo(m.db, m.stbl, sq.Expr("NOW()")), txn, store, modelID, marshalledAssertions)
+	if err != nil {
+		return err
+	}
+
+	if err := txn.Commit(); err != nil {
+		return sqlcommon.HandleSQLError(err)
+	}
+
 	return nil
 }
 
@@ -615,11 +747,61 @@ func (m *MySQL) ReadAssertions(ctx context.Context, store, modelID string) ([]*o
 	return assertions.Assertions, nil
 }
 
+func (m *MySQL) ReadAssertionsHistory(ctx context.Context, store, modelID string) ([]*storage.AssertionsVersion, error) {
+	ctx, span := tracer.Start(ctx, "mysql.ReadAssertionsHistory")
+	defer span.End()
+
+	return sqlcommon.ReadAssertionsHistory(ctx, sqlcommon.NewDBInfo(m.db, m.stbl, sq.Expr("NOW()")), store, modelID)
+}
+
+func (m *MySQL) DeleteAssertions(ctx context.Context, store, modelID string) error {
+	ctx, span := tracer.Start(ctx, "mysql.DeleteAssertions")
+	defer span.End()
+
+	return sqlcommon.DeleteAssertions(ctx, sqlcommon.NewDBInfo(m.db, m.stbl, sq.Expr("NOW()")), store, modelID)
+}
+
+func (m *MySQL) WriteAuthorizationModelLabels(ctx context.Context, store, modelID string, labels map[string]string) error {
+	ctx, span := tracer.Start(ctx, "mysql.WriteAuthorizationModelLabels")
+	defer span.End()
+
+	return sqlcommon.WriteAuthorizationModelLabels(ctx, sqlcommon.NewDBInfo(m.db, m.stbl, "NOW()"), store, modelID, labels)
+}
+
+func (m *MySQL) ReadAuthorizationModelLabels(ctx context.Context, store, modelID string) (map[string]string, error) {
+	ctx, span := tracer.Start(ctx, "mysql.ReadAuthorizationModelLabels")
+	defer span.End()
+
+	return sqlcommon.ReadAuthorizationModelLabels(ctx, sqlcommon.NewDBInfo(m.db, m.stbl, "NOW()"), store, modelID)
+}
+
+func (m *MySQL) FindAuthorizationModelIDByLabel(ctx context.Context, store, key, value string) (string, error) {
+	ctx, span := tracer.Start(ctx, "mysql.FindAuthorizationModelIDByLabel")
+	defer span.End()
+
+	return sqlcommon.FindAuthorizationModelIDByLabel(ctx, sqlcommon.NewDBInfo(m.db, m.stbl, "NOW()"), store, key, value)
+}
+
+func (m *MySQL) WriteAuthorizationModelConditions(ctx context.Context, store, modelID string, conditions *condition.ModelConditions) error {
+	ctx, span := tracer.Start(ctx, "mysql.WriteAuthorizationModelConditions")
+	defer span.End()
+
+	return sqlcommon.WriteAuthorizationModelConditions(ctx, sqlcommon.NewDBInfo(m.db, m.stbl, "NOW()"), store, modelID, conditions)
+}
+
+func (m *MySQL) ReadAuthorizationModelConditions(ctx context.Context, store, modelID string) (*condition.ModelConditions, error) {
+	ctx, span := tracer.Start(ctx, "mysql.ReadAuthorizationModelConditions")
+	defer span.End()
+
+	return sqlcommon.ReadAuthorizationModelConditions(ctx, sqlcommon.NewDBInfo(m.db, m.stbl, "NOW()"), store, modelID)
+}
+
 func (m *MySQL) ReadChanges(
 	ctx context.Context,
 	store, objectTypeFilter string,
 	opts storage.PaginationOptions,
 	horizonOffset time.Duration,
+	_ storage.ReadOptions,
 ) ([]*openfgav1.TupleChange, []byte, error) {
 	ctx, span := tracer.Start(ctx, "mysql.ReadChanges")
 	defer span.End()
@@ -694,3 +876,38 @@ func (m *MySQL) ReadChanges(
 func (m *MySQL) IsReady(ctx context.Context) (bool, error) {
 	return sqlcommon.IsReady(ctx, m.db)
 }
+
+// SchemaVersion returns the version of the schema of this MySQL datastore and the latest version
+// known to the server.
+func (m *MySQL) SchemaVersion(ctx context.Context) (int64, int64, error) {
+	return m.migrator.SchemaVersion(ctx)
+}
+
+// Migrate applies the pending migrations to the schema of this MySQL datastore.
+func (m *MySQL) Migrate(ctx context.Context) error {
+	return m.migrator.Migrate(ctx)
+}
+
+// MaintenanceTasks returns the maintenance tasks of this MySQL datastore:
+//
+//   - 'optimize' rebuilds the tables and their indexes, reclaiming the storage of the deleted tuples and
+//     changes.
+//   - 'analyze' refreshes the statistics of the indexes the query optimizer relies on.
+func (m *MySQL) MaintenanceTasks() []storage.MaintenanceTask {
+	return []storage.MaintenanceTask{
+		{
+			Name: "optimize",
+			Run: func(ctx context.Context) error {
+				_, err := m.db.ExecContext(ctx, "OPTIMIZE TABLE tuple, changelog")
+				return err
+			},
+		},
+		{
+			Name: "analyze",
+			Run: func(ctx context.Context) error {
+				_, err := m.db.ExecContext(ctx, "ANALYZE TABLE tuple, changelog")
+				return err
+			},
+		},
+	}
+}
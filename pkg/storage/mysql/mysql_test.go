@@ -27,6 +27,28 @@ func TestMySQLDatastore(t *testing.T) {
 	test.RunAllTests(t, ds)
 }
 
+func TestMySQLSchemaVersion(t *testing.T) {
+	testDatastore := storagefixtures.RunDatastoreTestContainer(t, "mysql")
+
+	uri := testDatastore.GetConnectionURI(true)
+	ds, err := New(uri, sqlcommon.NewConfig())
+	require.NoError(t, err)
+	defer ds.Close()
+
+	ctx := context.Background()
+
+	current, latest, err := ds.SchemaVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, latest, current)
+	require.NoError(t, storage.CheckSchemaVersion(ctx, ds))
+
+	// migrating an up-to-date schema is a no-op
+	require.NoError(t, ds.Migrate(ctx))
+	current, _, err = ds.SchemaVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, latest, current)
+}
+
 func TestMySQLDatastoreAfterCloseIsNotReady(t *testing.T) {
 	testDatastore := storagefixtures.RunDatastoreTestContainer(t, "mysql")
 
@@ -72,7 +94,7 @@ func TestReadEnsureNoOrder(t *testing.T) {
 
 	iter, err := ds.Read(ctx,
 		store,
-		tuple.NewTupleKey("doc:", "relation", ""))
+		tuple.NewTupleKey("doc:", "relation", ""), storage.ReadOptions{})
 	defer iter.Stop()
 	require.NoError(t, err)
 
@@ -121,7 +143,7 @@ func TestReadPageEnsureOrder(t *testing.T) {
 	tuples, _, err := ds.ReadPage(ctx,
 		store,
 		tuple.NewTupleKey("doc:", "relation", ""),
-		storage.NewPaginationOptions(0, ""))
+		storage.NewPaginationOptions(0, ""), storage.ReadOptions{})
 	require.NoError(t, err)
 
 	require.Len(t, tuples, 2)
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"net/url"
 	"strings"
 	"time"
@@ -15,10 +16,14 @@ import (
 	"github.com/cenkalti/backoff/v4"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/assets"
+	"github.com/openfga/openfga/pkg/condition"
 	"github.com/openfga/openfga/pkg/logger"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/storage/sqlcommon"
 	tupleUtils "github.com/openfga/openfga/pkg/tuple"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"go.opentelemetry.io/otel"
@@ -34,11 +39,19 @@ type Postgres struct {
 	db                     *sql.DB
 	logger                 logger.Logger
 	dbStatsCollector       prometheus.Collector
+	migrator               *sqlcommon.Migrator
 	maxTuplesPerWriteField int
 	maxTypesPerModelField  int
+	reaper                 *storage.ExpiredTuplesReaper
 }
 
 var _ storage.OpenFGADatastore = (*Postgres)(nil)
+var _ storage.SchemaMigrator = (*Postgres)(nil)
+var _ storage.Maintainer = (*Postgres)(nil)
+var _ storage.ConditionsBackend = (*Postgres)(nil)
+var _ storage.TupleExpirationBackend = (*Postgres)(nil)
+var _ storage.PreconditionsBackend = (*Postgres)(nil)
+var _ storage.TupleSampler = (*Postgres)(nil)
 
 func New(uri string, cfg *sqlcommon.Config) (*Postgres, error) {
 	if cfg.Username != "" || cfg.Password != "" {
@@ -106,6 +119,22 @@ func New(uri string, cfg *sqlcommon.Config) (*Postgres, error) {
 		return nil, fmt.Errorf("ping db: %w", err)
 	}
 
+	// the session lock prevents concurrent servers from applying the migrations at the same time
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, fmt.Errorf("initialize migrations lock: %w", err)
+	}
+
+	migrations, err := fs.Sub(assets.EmbedMigrations, assets.PostgresMigrationDir)
+	if err != nil {
+		return nil, fmt.Errorf("initialize migrations: %w", err)
+	}
+
+	migrator, err := sqlcommon.NewMigrator(db, goose.DialectPostgres, migrations, goose.WithSessionLocker(locker))
+	if err != nil {
+		return nil, err
+	}
+
 	var collector prometheus.Collector
 	if cfg.ExportMetrics {
 		collector = collectors.NewDBStatsCollector(db, "openfga")
@@ -114,33 +143,45 @@ func New(uri string, cfg *sqlcommon.Config) (*Postgres, error) {
 		}
 	}
 
-	return &Postgres{
+	ds := &Postgres{
 		stbl:                   sq.StatementBuilder.PlaceholderFormat(sq.Dollar).RunWith(db),
 		db:                     db,
 		logger:                 cfg.Logger,
 		dbStatsCollector:       collector,
+		migrator:               migrator,
 		maxTuplesPerWriteField: cfg.MaxTuplesPerWriteField,
 		maxTypesPerModelField:  cfg.MaxTypesPerModelField,
-	}, nil
+	}
+
+	if cfg.ExpiredTuplesReapInterval > 0 {
+		ds.reaper = storage.StartExpiredTuplesReaper(ds, cfg.ExpiredTuplesReapInterval, func(err error) {
+			cfg.Logger.Warn("failed to delete the expired tuples", zap.Error(err))
+		})
+	}
+
+	return ds, nil
 }
 
 // Close closes any open connections and cleans up residual resources
 // used by this storage adapter instance.
 func (p *Postgres) Close() {
+	if p.reaper != nil {
+		p.reaper.Stop()
+	}
 	if p.dbStatsCollector != nil {
 		prometheus.Unregister(p.dbStatsCollector)
 	}
 	p.db.Close()
 }
 
-func (p *Postgres) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (storage.TupleIterator, error) {
+func (p *Postgres) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, _ storage.ReadOptions) (storage.TupleIterator, error) {
 	ctx, span := tracer.Start(ctx, "postgres.Read")
 	defer span.End()
 
 	return p.read(ctx, store, tupleKey, nil)
 }
 
-func (p *Postgres) ReadPage(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, opts storage.PaginationOptions) ([]*openfgav1.Tuple, []byte, error) {
+func (p *Postgres) ReadPage(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, opts storage.PaginationOptions, _ storage.ReadOptions) ([]*openfgav1.Tuple, []byte, error) {
 	ctx, span := tracer.Start(ctx, "postgres.ReadPage")
 	defer span.End()
 
@@ -158,8 +199,9 @@ func (p *Postgres) read(ctx context.Context, store string, tupleKey *openfgav1.T
 	defer span.End()
 
 	sb := p.stbl.
-		Select("store", "object_type", "object_id", "relation", "_user", "ulid", "inserted_at").
+		Select("store", "object_type", "object_id", "relation", "_user", "ulid", "inserted_at", "expires_at").
 		From("tuple").
+		Where(sqlcommon.NotExpired(time.Now().UTC())).
 		Where(sq.Eq{"store": store})
 	if opts != nil {
 		sb = sb.OrderBy("ulid")
@@ -194,7 +236,7 @@ func (p *Postgres) read(ctx context.Context, store string, tupleKey *openfgav1.T
 		return nil, sqlcommon.HandleSQLError(err)
 	}
 
-	return sqlcommon.NewSQLTupleIterator(rows), nil
+	return sqlcommon.NewSQLTupleIterator(ctx, rows), nil
 }
 
 func (p *Postgres) Write(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes) error {
@@ -209,7 +251,76 @@ func (p *Postgres) Write(ctx context.Context, store string, deletes storage.Dele
 	return sqlcommon.Write(ctx, sqlcommon.NewDBInfo(p.db, p.stbl, "NOW()"), store, deletes, writes, now)
 }
 
-func (p *Postgres) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+func (p *Postgres) WriteWithConditions(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, conditions map[string]*condition.TupleCondition) error {
+	ctx, span := tracer.Start(ctx, "postgres.WriteWithConditions")
+	defer span.End()
+
+	if len(deletes)+len(writes) > p.MaxTuplesPerWrite() {
+		return storage.ErrExceededWriteBatchLimit
+	}
+
+	now := time.Now().UTC()
+
+	return sqlcommon.WriteWithConditions(ctx, sqlcommon.NewDBInfo(p.db, p.stbl, "NOW()"), store, deletes, writes, conditions, now)
+}
+
+func (p *Postgres) WriteWithExpirations(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, expirations map[string]time.Time) error {
+	ctx, span := tracer.Start(ctx, "postgres.WriteWithExpirations")
+	defer span.End()
+
+	if len(deletes)+len(writes) > p.MaxTuplesPerWrite() {
+		return storage.ErrExceededWriteBatchLimit
+	}
+
+	now := time.Now().UTC()
+
+	return sqlcommon.WriteWithExpirations(ctx, sqlcommon.NewDBInfo(p.db, p.stbl, "NOW()"), store, deletes, writes, expirations, now)
+}
+
+func (p *Postgres) WriteWithPreconditions(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, preconditions []storage.Precondition) error {
+	ctx, span := tracer.Start(ctx, "postgres.WriteWithPreconditions")
+	defer span.End()
+
+	if len(deletes)+len(writes) > p.MaxTuplesPerWrite() {
+		return storage.ErrExceededWriteBatchLimit
+	}
+
+	now := time.Now().UTC()
+
+	return sqlcommon.WriteWithPreconditions(ctx, sqlcommon.NewDBInfo(p.db, p.stbl, "NOW()"), store, deletes, writes, preconditions, now)
+}
+
+// DeleteExpiredTuples deletes the tuples of all the stores that expired at or before the given time, in
+// batches, recording their deletion in the changelog.
+func (p *Postgres) DeleteExpiredTuples(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := tracer.Start(ctx, "postgres.DeleteExpiredTuples")
+	defer span.End()
+
+	return sqlcommon.DeleteExpiredTuples(ctx, sqlcommon.NewDBInfo(p.db, p.stbl, "NOW()"), before.UTC())
+}
+
+func (p *Postgres) ReadTupleCondition(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (*condition.TupleCondition, error) {
+	ctx, span := tracer.Start(ctx, "postgres.ReadTupleCondition")
+	defer span.End()
+
+	return sqlcommon.ReadTupleCondition(ctx, sqlcommon.NewDBInfo(p.db, p.stbl, "NOW()"), store, tupleKey)
+}
+
+func (p *Postgres) ReadTupleConditions(ctx context.Context, store string, tupleKeys []*openfgav1.TupleKey) (map[string]*condition.TupleCondition, error) {
+	ctx, span := tracer.Start(ctx, "postgres.ReadTupleConditions")
+	defer span.End()
+
+	return sqlcommon.ReadTupleConditions(ctx, sqlcommon.NewDBInfo(p.db, p.stbl, "NOW()"), store, tupleKeys)
+}
+
+func (p *Postgres) SampleTuples(ctx context.Context, store string, filter *openfgav1.TupleKey, size int) ([]*openfgav1.TupleKey, error) {
+	ctx, span := tracer.Start(ctx, "postgres.SampleTuples")
+	defer span.End()
+
+	return sqlcommon.SampleTuples(ctx, sqlcommon.NewDBInfo(p.db, p.stbl, "NOW()"), store, filter, size, "RANDOM()")
+}
+
+func (p *Postgres) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, _ storage.ReadOptions) (*openfgav1.Tuple, error) {
 	ctx, span := tracer.Start(ctx, "postgres.ReadUserTuple")
 	defer span.End()
 
@@ -218,8 +329,9 @@ func (p *Postgres) ReadUserTuple(ctx context.Context, store string, tupleKey *op
 
 	var record sqlcommon.TupleRecord
 	err := p.stbl.
-		Select("object_type", "object_id", "relation", "_user").
+		Select("object_type", "object_id", "relation", "_user", "expires_at").
 		From("tuple").
+		Where(sqlcommon.NotExpired(time.Now().UTC())).
 		Where(sq.Eq{
 			"store":       store,
 			"object_type": objectType,
@@ -229,20 +341,23 @@ func (p *Postgres) ReadUserTuple(ctx context.Context, store string, tupleKey *op
 			"user_type":   userType,
 		}).
 		QueryRowContext(ctx).
-		Scan(&record.ObjectType, &record.ObjectID, &record.Relation, &record.User)
+		Scan(&record.ObjectType, &record.ObjectID, &record.Relation, &record.User, &record.ExpiresAt)
 	if err != nil {
 		return nil, sqlcommon.HandleSQLError(err)
 	}
 
+	storage.RecordTupleExpiration(ctx, record.ExpiresAt.Time)
+
 	return record.AsTuple(), nil
 }
 
-func (p *Postgres) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter) (storage.TupleIterator, error) {
+func (p *Postgres) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter, _ storage.ReadOptions) (storage.TupleIterator, error) {
 	ctx, span := tracer.Start(ctx, "postgres.ReadUsersetTuples")
 	defer span.End()
 
-	sb := p.stbl.Select("store", "object_type", "object_id", "relation", "_user", "ulid", "inserted_at").
+	sb := p.stbl.Select("store", "object_type", "object_id", "relation", "_user", "ulid", "inserted_at", "expires_at").
 		From("tuple").
+		Where(sqlcommon.NotExpired(time.Now().UTC())).
 		Where(sq.Eq{"store": store}).
 		Where(sq.Eq{"user_type": tupleUtils.UserSet})
 
@@ -273,10 +388,10 @@ func (p *Postgres) ReadUsersetTuples(ctx context.Context, store string, filter s
 		return nil, sqlcommon.HandleSQLError(err)
 	}
 
-	return sqlcommon.NewSQLTupleIterator(rows), nil
+	return sqlcommon.NewSQLTupleIterator(ctx, rows), nil
 }
 
-func (p *Postgres) ReadStartingWithUser(ctx context.Context, store string, opts storage.ReadStartingWithUserFilter) (storage.TupleIterator, error) {
+func (p *Postgres) ReadStartingWithUser(ctx context.Context, store string, opts storage.ReadStartingWithUserFilter, _ storage.ReadOptions) (storage.TupleIterator, error) {
 	ctx, span := tracer.Start(ctx, "postgres.ReadStartingWithUser")
 	defer span.End()
 
@@ -289,20 +404,26 @@ func (p *Postgres) ReadStartingWithUser(ctx context.Context, store string, opts
 		targetUsersArg = append(targetUsersArg, targetUser)
 	}
 
-	rows, err := p.stbl.
-		Select("store", "object_type", "object_id", "relation", "_user", "ulid", "inserted_at").
+	sb := p.stbl.
+		Select("store", "object_type", "object_id", "relation", "_user", "ulid", "inserted_at", "expires_at").
 		From("tuple").
+		Where(sqlcommon.NotExpired(time.Now().UTC())).
 		Where(sq.Eq{
 			"store":       store,
 			"object_type": opts.ObjectType,
 			"relation":    opts.Relation,
 			"_user":       targetUsersArg,
-		}).QueryContext(ctx)
+		})
+	if len(opts.ObjectIDs) > 0 {
+		sb = sb.Where(sq.Eq{"object_id": opts.ObjectIDs})
+	}
+
+	rows, err := sb.QueryContext(ctx)
 	if err != nil {
 		return nil, sqlcommon.HandleSQLError(err)
 	}
 
-	return sqlcommon.NewSQLTupleIterator(rows), nil
+	return sqlcommon.NewSQLTupleIterator(ctx, rows), nil
 }
 
 func (p *Postgres) MaxTuplesPerWrite() int {
@@ -563,16 +684,34 @@ func (p *Postgres) WriteAssertions(ctx context.Context, store, modelID string, a
 		return err
 	}
 
+	txn, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return sqlcommon.HandleSQLError(err)
+	}
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
 	_, err = p.stbl.
 		Insert("assertion").
 		Columns("store", "authorization_model_id", "assertions").
 		Values(store, modelID, marshalledAssertions).
 		Suffix("ON CONFLICT (store, authorization_model_id) DO UPDATE SET assertions = ?", marshalledAssertions).
+		RunWith(txn). // Part of a txn
 		ExecContext(ctx)
 	if err != nil {
 		return sqlcommon.HandleSQLError(err)
 	}
 
+	err = sqlcommon.WriteAssertionsHistory(ctx, sqlcommon.NewDBInfo(p.db, p.stbl, "NOW()"), txn, store, modelID, marshalledAssertions)
+	if err != nil {
+		return err
+	}
+
+	if err := txn.Commit(); err != nil {
+		return sqlcommon.HandleSQLError(err)
+	}
+
 	return nil
 }
 
@@ -606,11 +745,61 @@ func (p *Postgres) ReadAssertions(ctx context.Context, store, modelID string) ([
 	return assertions.Assertions, nil
 }
 
+func (p *Postgres) ReadAssertionsHistory(ctx context.Context, store, modelID string) ([]*storage.AssertionsVersion, error) {
+	ctx, span := tracer.Start(ctx, "postgres.ReadAssertionsHistory")
+	defer span.End()
+
+	return sqlcommon.ReadAssertionsHistory(ctx, sqlcommon.NewDBInfo(p.db, p.stbl, "NOW()"), store, modelID)
+}
+
+func (p *Postgres) DeleteAssertions(ctx context.Context, store, modelID string) error {
+	ctx, span := tracer.Start(ctx, "postgres.DeleteAssertions")
+	defer span.End()
+
+	return sqlcommon.DeleteAssertions(ctx, sqlcommon.NewDBInfo(p.db, p.stbl, "NOW()"), store, modelID)
+}
+
+func (p *Postgres) WriteAuthorizationModelLabels(ctx context.Context, store, modelID string, labels map[string]string) error {
+	ctx, span := tracer.Start(ctx, "postgres.WriteAuthorizationModelLabels")
+	defer span.End()
+
+	return sqlcommon.WriteAuthorizationModelLabels(ctx, sqlcommon.NewDBInfo(p.db, p.stbl, "NOW()"), store, modelID, labels)
+}
+
+func (p *Postgres) ReadAuthorizationModelLabels(ctx context.Context, store, modelID string) (map[string]string, error) {
+	ctx, span := tracer.Start(ctx, "postgres.ReadAuthorizationModelLabels")
+	defer span.End()
+
+	return sqlcommon.ReadAuthorizationModelLabels(ctx, sqlcommon.NewDBInfo(p.db, p.stbl, "NOW()"), store, modelID)
+}
+
+func (p *Postgres) FindAuthorizationModelIDByLabel(ctx context.Context, store, key, value string) (string, error) {
+	ctx, span := tracer.Start(ctx, "postgres.FindAuthorizationModelIDByLabel")
+	defer span.End()
+
+	return sqlcommon.FindAuthorizationModelIDByLabel(ctx, sqlcommon.NewDBInfo(p.db, p.stbl, "NOW()"), store, key, value)
+}
+
+func (p *Postgres) WriteAuthorizationModelConditions(ctx context.Context, store, modelID string, conditions *condition.ModelConditions) error {
+	ctx, span := tracer.Start(ctx, "postgres.WriteAuthorizationModelConditions")
+	defer span.End()
+
+	return sqlcommon.WriteAuthorizationModelConditions(ctx, sqlcommon.NewDBInfo(p.db, p.stbl, "NOW()"), store, modelID, conditions)
+}
+
+func (p *Postgres) ReadAuthorizationModelConditions(ctx context.Context, store, modelID string) (*condition.ModelConditions, error) {
+	ctx, span := tracer.Start(ctx, "postgres.ReadAuthorizationModelConditions")
+	defer span.End()
+
+	return sqlcommon.ReadAuthorizationModelConditions(ctx, sqlcommon.NewDBInfo(p.db, p.stbl, "NOW()"), store, modelID)
+}
+
 func (p *Postgres) ReadChanges(
 	ctx context.Context,
 	store, objectTypeFilter string,
 	opts storage.PaginationOptions,
 	horizonOffset time.Duration,
+	_ storage.ReadOptions,
 ) ([]*openfgav1.TupleChange, []byte, error) {
 	ctx, span := tracer.Start(ctx, "postgres.ReadChanges")
 	defer span.End()
@@ -685,3 +874,37 @@ func (p *Postgres) ReadChanges(
 func (p *Postgres) IsReady(ctx context.Context) (bool, error) {
 	return sqlcommon.IsReady(ctx, p.db)
 }
+
+// SchemaVersion returns the version of the schema of this Postgres datastore and the latest version
+// known to the server.
+func (p *Postgres) SchemaVersion(ctx context.Context) (int64, int64, error) {
+	return p.migrator.SchemaVersion(ctx)
+}
+
+// Migrate applies the pending migrations to the schema of this Postgres datastore.
+func (p *Postgres) Migrate(ctx context.Context) error {
+	return p.migrator.Migrate(ctx)
+}
+
+// MaintenanceTasks returns the maintenance tasks of this Postgres datastore:
+//
+//   - 'vacuum' reclaims the storage of the deleted tuples and changes.
+//   - 'analyze' refreshes the statistics the query planner relies on.
+func (p *Postgres) MaintenanceTasks() []storage.MaintenanceTask {
+	return []storage.MaintenanceTask{
+		{
+			Name: "vacuum",
+			Run: func(ctx context.Context) error {
+				_, err := p.db.ExecContext(ctx, "VACUUM tuple, changelog")
+				return err
+			},
+		},
+		{
+			Name: "analyze",
+			Run: func(ctx context.Context) error {
+				_, err := p.db.ExecContext(ctx, "ANALYZE tuple, changelog")
+				return err
+			},
+		},
+	}
+}
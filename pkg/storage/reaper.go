@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ExpiredTuplesReaper deletes the expired tuples of a TupleExpirationBackend periodically, in the background.
+type ExpiredTuplesReaper struct {
+	backend  TupleExpirationBackend
+	interval time.Duration
+	onError  func(error)
+
+	cancel   context.CancelFunc
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// StartExpiredTuplesReaper starts deleting the expired tuples of the backend at the interval, until the
+// reaper is stopped. The errors of the deletions, if any, are passed to onError, which may be nil.
+func StartExpiredTuplesReaper(backend TupleExpirationBackend, interval time.Duration, onError func(error)) *ExpiredTuplesReaper {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := &ExpiredTuplesReaper{
+		backend:  backend,
+		interval: interval,
+		onError:  onError,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	go r.run(ctx)
+
+	return r
+}
+
+func (r *ExpiredTuplesReaper) run(ctx context.Context) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.backend.DeleteExpiredTuples(ctx, time.Now()); err != nil && ctx.Err() == nil && r.onError != nil {
+				r.onError(err)
+			}
+		}
+	}
+}
+
+// Stop stops the reaper, and waits for the deletion in progress, if any, to be cancelled. It may be called
+// more than once.
+func (r *ExpiredTuplesReaper) Stop() {
+	r.stopOnce.Do(func() {
+		r.cancel()
+		<-r.done
+	})
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/openfga/openfga/pkg/requestcontext"
+)
+
+type ctxKey string
+
+const requestMetadataCtxKey ctxKey = "request-metadata-context-key"
+
+// RequestMetadata describes the request on behalf of which a datastore operation is performed. Storage
+// decorators and drivers can use it for logging, metrics or row level tagging of the queries they issue.
+//
+// The AuthorizationModelID is only set once the server has resolved the model for the request, so it
+// may be empty for operations that happen before that (or for requests that don't involve a model).
+type RequestMetadata struct {
+	// Method is the name of the API method being served (e.g. "Check").
+	Method string
+
+	StoreID              string
+	AuthorizationModelID string
+	RequestID            string
+
+	// Caller is the subject of the authenticated client that issued the request (if any).
+	Caller string
+}
+
+// ContextWithRequestMetadata attaches the provided RequestMetadata to the parent context.
+func ContextWithRequestMetadata(parent context.Context, md *RequestMetadata) context.Context {
+	return context.WithValue(parent, requestMetadataCtxKey, md)
+}
+
+// RequestMetadataFromContext returns the RequestMetadata from the provided context (if any): the one attached
+// with ContextWithRequestMetadata, or else the requestcontext metadata of the request being served.
+func RequestMetadataFromContext(ctx context.Context) (*RequestMetadata, bool) {
+	if md, ok := ctx.Value(requestMetadataCtxKey).(*RequestMetadata); ok {
+		return md, true
+	}
+
+	if !requestcontext.HasMetadata(ctx) {
+		return nil, false
+	}
+
+	md := &RequestMetadata{}
+	md.Method, _ = requestcontext.Method(ctx)
+	md.StoreID, _ = requestcontext.StoreID(ctx)
+	md.AuthorizationModelID, _ = requestcontext.AuthorizationModelID(ctx)
+	md.RequestID, _ = requestcontext.RequestID(ctx)
+	md.Caller, _ = requestcontext.Caller(ctx)
+
+	return md, true
+}
+
+// ConsistencyFromContext returns the consistency that the reads of the request being served require (see
+// requestcontext.SetConsistency), or ConsistencyPreferenceUnspecified if the request didn't set one.
+func ConsistencyFromContext(ctx context.Context) ConsistencyPreference {
+	name, ok := requestcontext.Consistency(ctx)
+	if !ok {
+		return ConsistencyPreferenceUnspecified
+	}
+
+	c, err := ParseConsistencyPreference(name)
+	if err != nil {
+		return ConsistencyPreferenceUnspecified
+	}
+
+	return c
+}
+
+// ReadOptionsFromContext returns the ReadOptions of the reads of the request being served, e.g. with the
+// consistency they require.
+func ReadOptionsFromContext(ctx context.Context) ReadOptions {
+	return ReadOptions{Consistency: ConsistencyFromContext(ctx)}
+}
@@ -0,0 +1,51 @@
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+
+	"github.com/pressly/goose/v3"
+)
+
+// Migrator applies the schema migrations of a SQL datastore and tracks the version of its schema, which is
+// recorded by goose in the 'goose_db_version' table.
+type Migrator struct {
+	provider *goose.Provider
+}
+
+// NewMigrator constructs a Migrator that applies the migrations found in the provided filesystem.
+func NewMigrator(db *sql.DB, dialect goose.Dialect, migrations fs.FS, opts ...goose.ProviderOption) (*Migrator, error) {
+	provider, err := goose.NewProvider(dialect, db, migrations, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("initialize migrations: %w", err)
+	}
+
+	return &Migrator{provider: provider}, nil
+}
+
+// SchemaVersion returns the version of the last migration applied to the database and the version of the
+// last migration known to the Migrator.
+func (m *Migrator) SchemaVersion(ctx context.Context) (int64, int64, error) {
+	current, err := m.provider.GetDBVersion(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	sources := m.provider.ListSources()
+	if len(sources) == 0 {
+		return current, 0, nil
+	}
+
+	return current, sources[len(sources)-1].Version, nil
+}
+
+// Migrate applies the pending migrations.
+func (m *Migrator) Migrate(ctx context.Context) error {
+	if _, err := m.provider.Up(ctx); err != nil {
+		return fmt.Errorf("apply migrations: %w", err)
+	}
+
+	return nil
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/go-sql-driver/mysql"
 	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/condition"
 	"github.com/openfga/openfga/pkg/logger"
 	"github.com/openfga/openfga/pkg/storage"
 	tupleUtils "github.com/openfga/openfga/pkg/tuple"
@@ -34,6 +35,10 @@ type Config struct {
 	ConnMaxLifetime time.Duration
 
 	ExportMetrics bool
+
+	// ExpiredTuplesReapInterval is the interval at which the expired tuples are deleted in the background. They're
+	// never deleted in the background if it's 0.
+	ExpiredTuplesReapInterval time.Duration
 }
 
 type DatastoreOption func(*Config)
@@ -98,8 +103,19 @@ func WithMetrics() DatastoreOption {
 	}
 }
 
+// WithExpiredTuplesReapInterval sets the interval at which the expired tuples are deleted in the background,
+// which is storage.DefaultExpiredTuplesReapInterval by default. They're never deleted in the background if
+// it's 0.
+func WithExpiredTuplesReapInterval(d time.Duration) DatastoreOption {
+	return func(cfg *Config) {
+		cfg.ExpiredTuplesReapInterval = d
+	}
+}
+
 func NewConfig(opts ...DatastoreOption) *Config {
-	cfg := &Config{}
+	cfg := &Config{
+		ExpiredTuplesReapInterval: storage.DefaultExpiredTuplesReapInterval,
+	}
 
 	for _, opt := range opts {
 		opt(cfg)
@@ -128,6 +144,7 @@ type TupleRecord struct {
 	User       string
 	Ulid       string
 	InsertedAt time.Time
+	ExpiresAt  sql.NullTime
 }
 
 func (t *TupleRecord) AsTuple() *openfgav1.Tuple {
@@ -162,6 +179,7 @@ func UnmarshallContToken(from string) (*ContToken, error) {
 }
 
 type SQLTupleIterator struct {
+	ctx      context.Context
 	rows     *sql.Rows
 	resultCh chan *TupleRecord
 	errCh    chan error
@@ -169,9 +187,12 @@ type SQLTupleIterator struct {
 
 var _ storage.TupleIterator = (*SQLTupleIterator)(nil)
 
-// NewSQLTupleIterator returns a SQL tuple iterator
-func NewSQLTupleIterator(rows *sql.Rows) *SQLTupleIterator {
+// NewSQLTupleIterator returns a SQL tuple iterator of the rows of a query made with the context, to whose
+// storage.ExpirationTracker the expirations of the tuples iterated are recorded. The rows have the columns
+// of a TupleRecord, in order.
+func NewSQLTupleIterator(ctx context.Context, rows *sql.Rows) *SQLTupleIterator {
 	return &SQLTupleIterator{
+		ctx:      ctx,
 		rows:     rows,
 		resultCh: make(chan *TupleRecord, 1),
 		errCh:    make(chan error, 1),
@@ -187,7 +208,7 @@ func (t *SQLTupleIterator) next() (*TupleRecord, error) {
 	}
 
 	var record TupleRecord
-	err := t.rows.Scan(&record.Store, &record.ObjectType, &record.ObjectID, &record.Relation, &record.User, &record.Ulid, &record.InsertedAt)
+	err := t.rows.Scan(&record.Store, &record.ObjectType, &record.ObjectID, &record.Relation, &record.User, &record.Ulid, &record.InsertedAt, &record.ExpiresAt)
 	if err != nil {
 		return nil, err
 	}
@@ -207,6 +228,7 @@ func (t *SQLTupleIterator) ToArray(opts storage.PaginationOptions) ([]*openfgav1
 			}
 			return nil, nil, err
 		}
+		storage.RecordTupleExpiration(t.ctx, tupleRecord.ExpiresAt.Time)
 		res = append(res, tupleRecord.AsTuple())
 	}
 
@@ -234,6 +256,8 @@ func (t *SQLTupleIterator) Next() (*openfgav1.Tuple, error) {
 		return nil, err
 	}
 
+	storage.RecordTupleExpiration(t.ctx, record.ExpiresAt.Time)
+
 	return record.AsTuple(), nil
 }
 
@@ -246,7 +270,8 @@ func HandleSQLError(err error, args ...interface{}) error {
 		return storage.ErrNotFound
 	} else if errors.Is(err, storage.ErrIteratorDone) {
 		return err
-	} else if strings.Contains(err.Error(), "duplicate key value") { // Postgres
+	} else if strings.Contains(err.Error(), "duplicate key value") || // Postgres
+		strings.Contains(err.Error(), "UNIQUE constraint failed") { // SQLite
 		if len(args) > 0 {
 			if tk, ok := args[0].(*openfgav1.TupleKey); ok {
 				return storage.InvalidWriteInputError(tk, openfgav1.TupleOperation_TUPLE_OPERATION_WRITE)
@@ -260,6 +285,10 @@ func HandleSQLError(err error, args ...interface{}) error {
 			}
 		}
 		return storage.ErrCollision
+	} else if strings.Contains(err.Error(), "SQLSTATE 40001") || // Postgres
+		(ok && me.Number == 1213) { // MySQL
+		// the serializable transaction conflicted with a concurrent one
+		return fmt.Errorf("%w: %s", storage.ErrTransactionalWriteFailed, err)
 	}
 
 	return fmt.Errorf("sql error: %w", err)
@@ -281,9 +310,44 @@ func NewDBInfo(db *sql.DB, stbl sq.StatementBuilderType, sqlTime interface{}) *D
 	}
 }
 
+// NotExpired filters the tuples that haven't expired as of now.
+func NotExpired(now time.Time) sq.Sqlizer {
+	return sq.Or{sq.Eq{"expires_at": nil}, sq.Gt{"expires_at": now}}
+}
+
 // Write provides the common method for writing to database across sql storage
 func Write(ctx context.Context, dbInfo *DBInfo, store string, deletes storage.Deletes, writes storage.Writes, now time.Time) error {
-	txn, err := dbInfo.db.BeginTx(ctx, nil)
+	return write(ctx, dbInfo, store, deletes, writes, nil, nil, nil, now)
+}
+
+// WriteWithConditions provides the common method for writing to database across sql storage, the tuples
+// written carrying the conditions keyed by their tuple.TupleKeyToString, if any
+func WriteWithConditions(ctx context.Context, dbInfo *DBInfo, store string, deletes storage.Deletes, writes storage.Writes, conditions map[string]*condition.TupleCondition, now time.Time) error {
+	return write(ctx, dbInfo, store, deletes, writes, conditions, nil, nil, now)
+}
+
+// WriteWithExpirations provides the common method for writing to database across sql storage, the tuples
+// written expiring at the times keyed by their tuple.TupleKeyToString, if any
+func WriteWithExpirations(ctx context.Context, dbInfo *DBInfo, store string, deletes storage.Deletes, writes storage.Writes, expirations map[string]time.Time, now time.Time) error {
+	return write(ctx, dbInfo, store, deletes, writes, nil, expirations, nil, now)
+}
+
+// WriteWithPreconditions provides the common method for writing to database across sql storage, only if the
+// preconditions hold
+func WriteWithPreconditions(ctx context.Context, dbInfo *DBInfo, store string, deletes storage.Deletes, writes storage.Writes, preconditions []storage.Precondition, now time.Time) error {
+	return write(ctx, dbInfo, store, deletes, writes, nil, nil, preconditions, now)
+}
+
+// write deletes and then writes the tuples if the preconditions hold, as of now: the expired tuples can't be
+// deleted, and the expired tuples written again are deleted first.
+func write(ctx context.Context, dbInfo *DBInfo, store string, deletes storage.Deletes, writes storage.Writes, conditions map[string]*condition.TupleCondition, expirations map[string]time.Time, preconditions []storage.Precondition, now time.Time) error {
+	var opts *sql.TxOptions
+	if len(preconditions) > 0 {
+		// the transaction fails to commit if a concurrent write changes the tuples of the preconditions
+		opts = &sql.TxOptions{Isolation: sql.LevelSerializable}
+	}
+
+	txn, err := dbInfo.db.BeginTx(ctx, opts)
 	if err != nil {
 		return HandleSQLError(err)
 	}
@@ -291,10 +355,37 @@ func Write(ctx context.Context, dbInfo *DBInfo, store string, deletes storage.De
 		_ = txn.Rollback()
 	}()
 
+	if err := checkPreconditions(ctx, dbInfo, txn, store, preconditions, now); err != nil {
+		return err
+	}
+
 	changelogBuilder := dbInfo.stbl.
 		Insert("changelog").
 		Columns("store", "object_type", "object_id", "relation", "_user", "operation", "ulid", "inserted_at")
 
+	if len(writes) > 0 {
+		keys := make(sq.Or, 0, len(writes))
+		for _, tk := range writes {
+			objectType, objectID := tupleUtils.SplitObject(tk.GetObject())
+			keys = append(keys, sq.Eq{
+				"object_type": objectType,
+				"object_id":   objectID,
+				"relation":    tk.GetRelation(),
+				"_user":       tk.GetUser(),
+			})
+		}
+
+		expired, err := deleteExpiredTuples(ctx, dbInfo, txn, sq.And{sq.Eq{"store": store}, keys}, now, 0)
+		if err != nil {
+			return err
+		}
+
+		for _, record := range expired {
+			id := ulid.MustNew(ulid.Timestamp(now), ulid.DefaultEntropy()).String()
+			changelogBuilder = changelogBuilder.Values(store, record.ObjectType, record.ObjectID, record.Relation, record.User, openfgav1.TupleOperation_TUPLE_OPERATION_DELETE, id, dbInfo.sqlTime)
+		}
+	}
+
 	deleteBuilder := dbInfo.stbl.Delete("tuple")
 
 	for _, tk := range deletes {
@@ -310,6 +401,7 @@ func Write(ctx context.Context, dbInfo *DBInfo, store string, deletes storage.De
 				"_user":       tk.GetUser(),
 				"user_type":   tupleUtils.GetUserTypeFromUser(tk.GetUser()),
 			}).
+			Where(NotExpired(now)).
 			RunWith(txn). // Part of a txn
 			ExecContext(ctx)
 		if err != nil {
@@ -330,14 +422,28 @@ func Write(ctx context.Context, dbInfo *DBInfo, store string, deletes storage.De
 
 	insertBuilder := dbInfo.stbl.
 		Insert("tuple").
-		Columns("store", "object_type", "object_id", "relation", "_user", "user_type", "ulid", "inserted_at")
+		Columns("store", "object_type", "object_id", "relation", "_user", "user_type", "ulid", "inserted_at", "condition_name", "condition_context", "expires_at")
 
 	for _, tk := range writes {
 		id := ulid.MustNew(ulid.Timestamp(now), ulid.DefaultEntropy()).String()
 		objectType, objectID := tupleUtils.SplitObject(tk.GetObject())
 
+		var conditionName, conditionContext interface{}
+		if c := conditions[tupleUtils.TupleKeyToString(tk)]; c != nil {
+			marshalledContext, err := json.Marshal(c.Context)
+			if err != nil {
+				return err
+			}
+			conditionName, conditionContext = c.Name, marshalledContext
+		}
+
+		var expiresAt interface{}
+		if t, ok := expirations[tupleUtils.TupleKeyToString(tk)]; ok && !t.IsZero() {
+			expiresAt = t.UTC()
+		}
+
 		_, err = insertBuilder.
-			Values(store, objectType, objectID, tk.GetRelation(), tk.GetUser(), tupleUtils.GetUserTypeFromUser(tk.GetUser()), id, dbInfo.sqlTime).
+			Values(store, objectType, objectID, tk.GetRelation(), tk.GetUser(), tupleUtils.GetUserTypeFromUser(tk.GetUser()), id, dbInfo.sqlTime, conditionName, conditionContext, expiresAt).
 			RunWith(txn). // Part of a txn
 			ExecContext(ctx)
 		if err != nil {
@@ -361,6 +467,154 @@ func Write(ctx context.Context, dbInfo *DBInfo, store string, deletes storage.De
 	return nil
 }
 
+// checkPreconditions ensures that the preconditions hold for the tuples of the store, as of now, within the
+// transaction.
+func checkPreconditions(ctx context.Context, dbInfo *DBInfo, txn *sql.Tx, store string, preconditions []storage.Precondition, now time.Time) error {
+	for _, precondition := range preconditions {
+		objectType, objectID := tupleUtils.SplitObject(precondition.TupleKey.GetObject())
+
+		var found int
+		err := dbInfo.stbl.
+			Select("1").
+			From("tuple").
+			Where(sq.Eq{
+				"store":       store,
+				"object_type": objectType,
+				"object_id":   objectID,
+				"relation":    precondition.TupleKey.GetRelation(),
+				"_user":       precondition.TupleKey.GetUser(),
+			}).
+			Where(NotExpired(now)).
+			RunWith(txn). // Part of a txn
+			QueryRowContext(ctx).
+			Scan(&found)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return HandleSQLError(err)
+		}
+
+		if exists := err == nil; exists != precondition.Exists {
+			return storage.PreconditionFailedError(precondition)
+		}
+	}
+
+	return nil
+}
+
+// expiredTuplesBatchSize is the maximum number of expired tuples DeleteExpiredTuples deletes in a transaction.
+const expiredTuplesBatchSize = 100
+
+// DeleteExpiredTuples provides the common method for deleting the tuples that expired at or before the given
+// time across sql storage, in batches, and returns the number of tuples deleted
+func DeleteExpiredTuples(ctx context.Context, dbInfo *DBInfo, before time.Time) (int, error) {
+	var deleted int
+	for {
+		n, err := deleteExpiredTuplesBatch(ctx, dbInfo, before)
+		deleted += n
+		if err != nil || n < expiredTuplesBatchSize {
+			return deleted, err
+		}
+	}
+}
+
+func deleteExpiredTuplesBatch(ctx context.Context, dbInfo *DBInfo, before time.Time) (int, error) {
+	txn, err := dbInfo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, HandleSQLError(err)
+	}
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	expired, err := deleteExpiredTuples(ctx, dbInfo, txn, nil, before, expiredTuplesBatchSize)
+	if err != nil || len(expired) == 0 {
+		return 0, err
+	}
+
+	changelogBuilder := dbInfo.stbl.
+		Insert("changelog").
+		Columns("store", "object_type", "object_id", "relation", "_user", "operation", "ulid", "inserted_at")
+
+	now := time.Now().UTC()
+	for _, record := range expired {
+		id := ulid.MustNew(ulid.Timestamp(now), ulid.DefaultEntropy()).String()
+		changelogBuilder = changelogBuilder.Values(record.Store, record.ObjectType, record.ObjectID, record.Relation, record.User, openfgav1.TupleOperation_TUPLE_OPERATION_DELETE, id, dbInfo.sqlTime)
+	}
+
+	if _, err := changelogBuilder.RunWith(txn).ExecContext(ctx); err != nil {
+		return 0, HandleSQLError(err)
+	}
+
+	if err := txn.Commit(); err != nil {
+		return 0, HandleSQLError(err)
+	}
+
+	return len(expired), nil
+}
+
+// deleteExpiredTuples deletes the tuples matching the filter, if any, that expired at or before the given time,
+// at most limit of them if it's not 0, as part of the transaction, and returns them.
+func deleteExpiredTuples(ctx context.Context, dbInfo *DBInfo, txn *sql.Tx, filter sq.Sqlizer, before time.Time, limit uint64) ([]*TupleRecord, error) {
+	sb := dbInfo.stbl.
+		Select("store", "object_type", "object_id", "relation", "_user").
+		From("tuple").
+		Where(sq.LtOrEq{"expires_at": before})
+	if filter != nil {
+		sb = sb.Where(filter)
+	}
+	if limit != 0 {
+		sb = sb.Limit(limit)
+	}
+
+	rows, err := sb.RunWith(txn).QueryContext(ctx)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	var records []*TupleRecord
+	for rows.Next() {
+		var record TupleRecord
+		if err := rows.Scan(&record.Store, &record.ObjectType, &record.ObjectID, &record.Relation, &record.User); err != nil {
+			rows.Close()
+			return nil, HandleSQLError(err)
+		}
+		records = append(records, &record)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, HandleSQLError(err)
+	}
+	rows.Close()
+
+	deleted := make([]*TupleRecord, 0, len(records))
+	for _, record := range records {
+		res, err := dbInfo.stbl.
+			Delete("tuple").
+			Where(sq.Eq{
+				"store":       record.Store,
+				"object_type": record.ObjectType,
+				"object_id":   record.ObjectID,
+				"relation":    record.Relation,
+				"_user":       record.User,
+				"user_type":   tupleUtils.GetUserTypeFromUser(record.User),
+			}).
+			Where(sq.LtOrEq{"expires_at": before}).
+			RunWith(txn). // Part of a txn
+			ExecContext(ctx)
+		if err != nil {
+			return nil, HandleSQLError(err)
+		}
+
+		// the tuple may have been deleted by a concurrent transaction in the meantime
+		if rowsAffected, err := res.RowsAffected(); err != nil {
+			return nil, HandleSQLError(err)
+		} else if rowsAffected == 1 {
+			deleted = append(deleted, record)
+		}
+	}
+
+	return deleted, nil
+}
+
 func WriteAuthorizationModel(ctx context.Context, dbInfo *DBInfo, store string, model *openfgav1.AuthorizationModel) error {
 	schemaVersion := model.GetSchemaVersion()
 	typeDefinitions := model.GetTypeDefinitions()
@@ -444,6 +698,477 @@ func ReadAuthorizationModel(ctx context.Context, dbInfo *DBInfo, store, modelID
 	}, nil
 }
 
+// WriteAssertionsHistory records the marshalled assertions as the next version of the assertions of the
+// given model. It is meant to be called as part of the transaction that writes the latest assertions.
+func WriteAssertionsHistory(ctx context.Context, dbInfo *DBInfo, txn *sql.Tx, store, modelID string, marshalledAssertions []byte) error {
+	var latestVersion uint32
+	err := dbInfo.stbl.
+		Select("COALESCE(MAX(version), 0)").
+		From("assertion_history").
+		Where(sq.Eq{
+			"store":                  store,
+			"authorization_model_id": modelID,
+		}).
+		RunWith(txn). // Part of a txn
+		QueryRowContext(ctx).
+		Scan(&latestVersion)
+	if err != nil {
+		return HandleSQLError(err)
+	}
+
+	_, err = dbInfo.stbl.
+		Insert("assertion_history").
+		Columns("store", "authorization_model_id", "version", "assertions", "inserted_at").
+		Values(store, modelID, latestVersion+1, marshalledAssertions, dbInfo.sqlTime).
+		RunWith(txn). // Part of a txn
+		ExecContext(ctx)
+	if err != nil {
+		return HandleSQLError(err)
+	}
+
+	return nil
+}
+
+// ReadAssertionsHistory provides the common method for reading the history of the assertions of a model
+// across sql storage
+func ReadAssertionsHistory(ctx context.Context, dbInfo *DBInfo, store, modelID string) ([]*storage.AssertionsVersion, error) {
+	rows, err := dbInfo.stbl.
+		Select("version", "assertions", "inserted_at").
+		From("assertion_history").
+		Where(sq.Eq{
+			"store":                  store,
+			"authorization_model_id": modelID,
+		}).
+		OrderBy("version DESC").
+		QueryContext(ctx)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+	defer rows.Close()
+
+	var versions []*storage.AssertionsVersion
+	for rows.Next() {
+		var version uint32
+		var marshalledAssertions []byte
+		var insertedAt time.Time
+		if err := rows.Scan(&version, &marshalledAssertions, &insertedAt); err != nil {
+			return nil, HandleSQLError(err)
+		}
+
+		var assertions openfgav1.Assertions
+		if err := proto.Unmarshal(marshalledAssertions, &assertions); err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, &storage.AssertionsVersion{
+			Version:    version,
+			Assertions: assertions.GetAssertions(),
+			CreatedAt:  insertedAt,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	return versions, nil
+}
+
+// DeleteAssertions provides the common method for deleting the assertions of a model, along with their
+// history, across sql storage
+func DeleteAssertions(ctx context.Context, dbInfo *DBInfo, store, modelID string) error {
+	txn, err := dbInfo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return HandleSQLError(err)
+	}
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	for _, table := range []string{"assertion", "assertion_history"} {
+		_, err := dbInfo.stbl.
+			Delete(table).
+			Where(sq.Eq{
+				"store":                  store,
+				"authorization_model_id": modelID,
+			}).
+			RunWith(txn). // Part of a txn
+			ExecContext(ctx)
+		if err != nil {
+			return HandleSQLError(err)
+		}
+	}
+
+	if err := txn.Commit(); err != nil {
+		return HandleSQLError(err)
+	}
+
+	return nil
+}
+
+// WriteAuthorizationModelLabels provides the common method for adding labels to an authorization model across
+// sql storage
+func WriteAuthorizationModelLabels(ctx context.Context, dbInfo *DBInfo, store, modelID string, labels map[string]string) error {
+	var exists int
+	err := dbInfo.stbl.
+		Select("1").
+		From("authorization_model").
+		Where(sq.Eq{
+			"store":                  store,
+			"authorization_model_id": modelID,
+		}).
+		Limit(1).
+		QueryRowContext(ctx).
+		Scan(&exists)
+	if err != nil {
+		return HandleSQLError(err)
+	}
+
+	if len(labels) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+
+	txn, err := dbInfo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return HandleSQLError(err)
+	}
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	_, err = dbInfo.stbl.
+		Delete("authorization_model_label").
+		Where(sq.Eq{
+			"store":                  store,
+			"authorization_model_id": modelID,
+			"label_key":              keys,
+		}).
+		RunWith(txn). // Part of a txn
+		ExecContext(ctx)
+	if err != nil {
+		return HandleSQLError(err)
+	}
+
+	insert := dbInfo.stbl.
+		Insert("authorization_model_label").
+		Columns("store", "authorization_model_id", "label_key", "label_value")
+	for key, value := range labels {
+		insert = insert.Values(store, modelID, key, value)
+	}
+
+	if _, err := insert.RunWith(txn).ExecContext(ctx); err != nil {
+		return HandleSQLError(err)
+	}
+
+	if err := txn.Commit(); err != nil {
+		return HandleSQLError(err)
+	}
+
+	return nil
+}
+
+// ReadAuthorizationModelLabels provides the common method for reading the labels of an authorization model
+// across sql storage
+func ReadAuthorizationModelLabels(ctx context.Context, dbInfo *DBInfo, store, modelID string) (map[string]string, error) {
+	rows, err := dbInfo.stbl.
+		Select("label_key", "label_value").
+		From("authorization_model_label").
+		Where(sq.Eq{
+			"store":                  store,
+			"authorization_model_id": modelID,
+		}).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+	defer rows.Close()
+
+	labels := map[string]string{}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, HandleSQLError(err)
+		}
+
+		labels[key] = value
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	return labels, nil
+}
+
+// FindAuthorizationModelIDByLabel provides the common method for finding the latest authorization model with
+// a label across sql storage
+func FindAuthorizationModelIDByLabel(ctx context.Context, dbInfo *DBInfo, store, key, value string) (string, error) {
+	var modelID string
+	err := dbInfo.stbl.
+		Select("authorization_model_id").
+		From("authorization_model_label").
+		Where(sq.Eq{
+			"store":       store,
+			"label_key":   key,
+			"label_value": value,
+		}).
+		OrderBy("authorization_model_id desc").
+		Limit(1).
+		QueryRowContext(ctx).
+		Scan(&modelID)
+	if err != nil {
+		return "", HandleSQLError(err)
+	}
+
+	return modelID, nil
+}
+
+// ReadTupleCondition provides the common method for reading the condition of a tuple across sql storage
+func ReadTupleCondition(ctx context.Context, dbInfo *DBInfo, store string, tk *openfgav1.TupleKey) (*condition.TupleCondition, error) {
+	objectType, objectID := tupleUtils.SplitObject(tk.GetObject())
+
+	var conditionName sql.NullString
+	var conditionContext []byte
+	err := dbInfo.stbl.
+		Select("condition_name", "condition_context").
+		From("tuple").
+		Where(sq.Eq{
+			"store":       store,
+			"object_type": objectType,
+			"object_id":   objectID,
+			"relation":    tk.GetRelation(),
+			"_user":       tk.GetUser(),
+		}).
+		Where(NotExpired(time.Now().UTC())).
+		QueryRowContext(ctx).
+		Scan(&conditionName, &conditionContext)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	return tupleCondition(conditionName, conditionContext)
+}
+
+// readTupleConditionsBatchSize is the maximum number of tuples whose conditions are read by a query.
+const readTupleConditionsBatchSize = 100
+
+// ReadTupleConditions provides the common method for reading the conditions of tuples across sql storage
+func ReadTupleConditions(ctx context.Context, dbInfo *DBInfo, store string, tks []*openfgav1.TupleKey) (map[string]*condition.TupleCondition, error) {
+	conditions := map[string]*condition.TupleCondition{}
+	now := time.Now().UTC()
+
+	for start := 0; start < len(tks); start += readTupleConditionsBatchSize {
+		batch := tks[start:min(start+readTupleConditionsBatchSize, len(tks))]
+
+		keys := make(sq.Or, 0, len(batch))
+		for _, tk := range batch {
+			objectType, objectID := tupleUtils.SplitObject(tk.GetObject())
+			keys = append(keys, sq.Eq{
+				"object_type": objectType,
+				"object_id":   objectID,
+				"relation":    tk.GetRelation(),
+				"_user":       tk.GetUser(),
+			})
+		}
+
+		rows, err := dbInfo.stbl.
+			Select("object_type", "object_id", "relation", "_user", "condition_name", "condition_context").
+			From("tuple").
+			Where(sq.Eq{"store": store}).
+			Where(keys).
+			Where(NotExpired(now)).
+			QueryContext(ctx)
+		if err != nil {
+			return nil, HandleSQLError(err)
+		}
+
+		if err := scanTupleConditions(rows, conditions); err != nil {
+			return nil, err
+		}
+	}
+
+	return conditions, nil
+}
+
+// SampleTuples provides the common method for sampling the tuples of a store at random across sql storage,
+// with the random function of the database (e.g. 'RANDOM()'). The database reads every tuple matching the
+// filter to sample them.
+func SampleTuples(ctx context.Context, dbInfo *DBInfo, store string, filter *openfgav1.TupleKey, size int, random string) ([]*openfgav1.TupleKey, error) {
+	sb := dbInfo.stbl.
+		Select("object_type", "object_id", "relation", "_user").
+		From("tuple").
+		Where(sq.Eq{"store": store}).
+		Where(NotExpired(time.Now().UTC())).
+		OrderBy(random).
+		Limit(uint64(size))
+	if objectType := tupleUtils.GetType(filter.GetObject()); objectType != "" {
+		sb = sb.Where(sq.Eq{"object_type": objectType})
+	}
+	if filter.GetRelation() != "" {
+		sb = sb.Where(sq.Eq{"relation": filter.GetRelation()})
+	}
+
+	rows, err := sb.QueryContext(ctx)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+	defer rows.Close()
+
+	var sample []*openfgav1.TupleKey
+	for rows.Next() {
+		var objectType, objectID, relation, user string
+		if err := rows.Scan(&objectType, &objectID, &relation, &user); err != nil {
+			return nil, HandleSQLError(err)
+		}
+
+		sample = append(sample, tupleUtils.NewTupleKey(tupleUtils.BuildObject(objectType, objectID), relation, user))
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	return sample, nil
+}
+
+func scanTupleConditions(rows *sql.Rows, conditions map[string]*condition.TupleCondition) error {
+	defer rows.Close()
+
+	for rows.Next() {
+		var objectType, objectID, relation, user string
+		var conditionName sql.NullString
+		var conditionContext []byte
+		if err := rows.Scan(&objectType, &objectID, &relation, &user, &conditionName, &conditionContext); err != nil {
+			return HandleSQLError(err)
+		}
+
+		c, err := tupleCondition(conditionName, conditionContext)
+		if err != nil {
+			return err
+		}
+
+		conditions[tupleUtils.TupleKeyToString(tupleUtils.NewTupleKey(tupleUtils.BuildObject(objectType, objectID), relation, user))] = c
+	}
+
+	if err := rows.Err(); err != nil {
+		return HandleSQLError(err)
+	}
+
+	return nil
+}
+
+// tupleCondition returns the condition of the condition_name and condition_context columns of a tuple, or nil if
+// it has none.
+func tupleCondition(conditionName sql.NullString, conditionContext []byte) (*condition.TupleCondition, error) {
+	if !conditionName.Valid {
+		return nil, nil
+	}
+
+	c := &condition.TupleCondition{Name: conditionName.String}
+	if len(conditionContext) > 0 {
+		if err := json.Unmarshal(conditionContext, &c.Context); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// WriteAuthorizationModelConditions provides the common method for replacing the conditions of an
+// authorization model across sql storage
+func WriteAuthorizationModelConditions(ctx context.Context, dbInfo *DBInfo, store, modelID string, conditions *condition.ModelConditions) error {
+	var exists int
+	err := dbInfo.stbl.
+		Select("1").
+		From("authorization_model").
+		Where(sq.Eq{
+			"store":                  store,
+			"authorization_model_id": modelID,
+		}).
+		Limit(1).
+		QueryRowContext(ctx).
+		Scan(&exists)
+	if err != nil {
+		return HandleSQLError(err)
+	}
+
+	marshalledConditions, err := json.Marshal(conditions)
+	if err != nil {
+		return err
+	}
+
+	txn, err := dbInfo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return HandleSQLError(err)
+	}
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	_, err = dbInfo.stbl.
+		Delete("authorization_model_conditions").
+		Where(sq.Eq{
+			"store":                  store,
+			"authorization_model_id": modelID,
+		}).
+		RunWith(txn). // Part of a txn
+		ExecContext(ctx)
+	if err != nil {
+		return HandleSQLError(err)
+	}
+
+	if !conditions.IsEmpty() {
+		_, err = dbInfo.stbl.
+			Insert("authorization_model_conditions").
+			Columns("store", "authorization_model_id", "conditions").
+			Values(store, modelID, marshalledConditions).
+			RunWith(txn). // Part of a txn
+			ExecContext(ctx)
+		if err != nil {
+			return HandleSQLError(err)
+		}
+	}
+
+	if err := txn.Commit(); err != nil {
+		return HandleSQLError(err)
+	}
+
+	return nil
+}
+
+// ReadAuthorizationModelConditions provides the common method for reading the conditions of an authorization
+// model across sql storage
+func ReadAuthorizationModelConditions(ctx context.Context, dbInfo *DBInfo, store, modelID string) (*condition.ModelConditions, error) {
+	var marshalledConditions []byte
+	err := dbInfo.stbl.
+		Select("conditions").
+		From("authorization_model_conditions").
+		Where(sq.Eq{
+			"store":                  store,
+			"authorization_model_id": modelID,
+		}).
+		QueryRowContext(ctx).
+		Scan(&marshalledConditions)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, HandleSQLError(err)
+	}
+
+	conditions := &condition.ModelConditions{}
+	if err := json.Unmarshal(marshalledConditions, conditions); err != nil {
+		return nil, err
+	}
+
+	return conditions, nil
+}
+
 // IsReady returns true if the connection to the datastore is successful
 func IsReady(ctx context.Context, db *sql.DB) (bool, error) {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
@@ -0,0 +1,935 @@
+// Package sqlite contains an implementation of the storage interface that works with SQLite, which persists
+// the data to a single file, e.g. for local development and small single-node deployments.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/assets"
+	"github.com/openfga/openfga/pkg/condition"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/sqlcommon"
+	tupleUtils "github.com/openfga/openfga/pkg/tuple"
+	"github.com/pressly/goose/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	_ "modernc.org/sqlite" // registers the 'sqlite' driver
+)
+
+var tracer = otel.Tracer("openfga/pkg/storage/sqlite")
+
+// defaultPragmas are the pragmas of the connections to the database, unless the uri sets them: the
+// write-ahead log lets the reads run concurrently with a write, the writes wait for the write lock rather than
+// failing right away, and LIKE is case sensitive, like the comparisons of the tuples.
+var defaultPragmas = [][2]string{
+	{"journal_mode", "WAL"},
+	{"busy_timeout", "5000"},
+	{"case_sensitive_like", "1"},
+}
+
+// defaultConnectionParams are the other parameters of the connections to the database, unless the uri sets
+// them: the transactions take the write lock when they begin rather than failing to upgrade their read lock,
+// which also serializes the writes under preconditions, and the times are written in a format whose strings
+// sort like the times, since the expirations of the tuples are compared by the queries.
+var defaultConnectionParams = [][2]string{
+	{"_txlock", "immediate"},
+	{"_time_format", "sqlite"},
+}
+
+type SQLite struct {
+	stbl                   sq.StatementBuilderType
+	db                     *sql.DB
+	logger                 logger.Logger
+	dbStatsCollector       prometheus.Collector
+	migrator               *sqlcommon.Migrator
+	maxTuplesPerWriteField int
+	maxTypesPerModelField  int
+	reaper                 *storage.ExpiredTuplesReaper
+}
+
+var _ storage.OpenFGADatastore = (*SQLite)(nil)
+var _ storage.SchemaMigrator = (*SQLite)(nil)
+var _ storage.Maintainer = (*SQLite)(nil)
+var _ storage.ConditionsBackend = (*SQLite)(nil)
+var _ storage.TupleExpirationBackend = (*SQLite)(nil)
+var _ storage.PreconditionsBackend = (*SQLite)(nil)
+var _ storage.TupleSampler = (*SQLite)(nil)
+
+// New opens the SQLite database of the uri, which is the path of its file (e.g. '/var/lib/openfga/openfga.db'),
+// optionally with the parameters of the connections (e.g. 'openfga.db?_pragma=busy_timeout(10000)'). The file is
+// created if it doesn't exist. The username and password of the config are ignored, since SQLite has no
+// users, and ':memory:' databases aren't supported, since every connection would have its own database.
+func New(uri string, cfg *sqlcommon.Config) (*SQLite, error) {
+	dsn, err := prepareDSN(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("initialize sqlite connection: %w", err)
+	}
+
+	if cfg.MaxOpenConns != 0 {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+
+	if cfg.MaxIdleConns != 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+
+	if cfg.ConnMaxIdleTime != 0 {
+		db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+
+	if cfg.ConnMaxLifetime != 0 {
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+
+	if err := db.PingContext(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ping db: %w", err)
+	}
+
+	migrations, err := fs.Sub(assets.EmbedMigrations, assets.SQLiteMigrationDir)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initialize migrations: %w", err)
+	}
+
+	migrator, err := sqlcommon.NewMigrator(db, goose.DialectSQLite3, migrations)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	var collector prometheus.Collector
+	if cfg.ExportMetrics {
+		collector = collectors.NewDBStatsCollector(db, "openfga")
+		if err := prometheus.Register(collector); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("initialize metrics: %w", err)
+		}
+	}
+
+	ds := &SQLite{
+		stbl:                   sq.StatementBuilder.RunWith(db),
+		db:                     db,
+		logger:                 cfg.Logger,
+		dbStatsCollector:       collector,
+		migrator:               migrator,
+		maxTuplesPerWriteField: cfg.MaxTuplesPerWriteField,
+		maxTypesPerModelField:  cfg.MaxTypesPerModelField,
+	}
+
+	if cfg.ExpiredTuplesReapInterval > 0 {
+		ds.reaper = storage.StartExpiredTuplesReaper(ds, cfg.ExpiredTuplesReapInterval, func(err error) {
+			cfg.Logger.Warn("failed to delete the expired tuples", zap.Error(err))
+		})
+	}
+
+	return ds, nil
+}
+
+// prepareDSN adds the default parameters of the connections to the uri, unless it sets them.
+func prepareDSN(uri string) (string, error) {
+	path, rawParams, _ := strings.Cut(uri, "?")
+	if path == "" || strings.Contains(path, ":memory:") || strings.Contains(rawParams, "mode=memory") {
+		return "", fmt.Errorf("invalid sqlite uri '%s': must be the path of the database file", uri)
+	}
+
+	params, err := url.ParseQuery(rawParams)
+	if err != nil {
+		return "", fmt.Errorf("invalid sqlite uri '%s': %w", uri, err)
+	}
+
+	pragmas := map[string]struct{}{}
+	for _, pragma := range params["_pragma"] {
+		name, _, _ := strings.Cut(pragma, "(")
+		pragmas[strings.ToLower(strings.TrimSpace(name))] = struct{}{}
+	}
+
+	for _, pragma := range defaultPragmas {
+		if _, ok := pragmas[pragma[0]]; !ok {
+			params.Add("_pragma", fmt.Sprintf("%s(%s)", pragma[0], pragma[1]))
+		}
+	}
+
+	for _, param := range defaultConnectionParams {
+		if !params.Has(param[0]) {
+			params.Set(param[0], param[1])
+		}
+	}
+
+	return path + "?" + params.Encode(), nil
+}
+
+// currentTime returns the time of the rows written, which are stored as text in UTC, so that they compare in
+// the order of the times.
+func currentTime() time.Time {
+	return time.Now().UTC()
+}
+
+// Close closes the datastore and cleans up any residual resources.
+func (s *SQLite) Close() {
+	if s.reaper != nil {
+		s.reaper.Stop()
+	}
+	if s.dbStatsCollector != nil {
+		prometheus.Unregister(s.dbStatsCollector)
+	}
+	s.db.Close()
+}
+
+func (s *SQLite) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, _ storage.ReadOptions) (storage.TupleIterator, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.Read")
+	defer span.End()
+
+	return s.read(ctx, store, tupleKey, nil)
+}
+
+func (s *SQLite) ReadPage(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, opts storage.PaginationOptions, _ storage.ReadOptions) ([]*openfgav1.Tuple, []byte, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.ReadPage")
+	defer span.End()
+
+	iter, err := s.read(ctx, store, tupleKey, &opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer iter.Stop()
+
+	return iter.ToArray(opts)
+}
+
+func (s *SQLite) read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, opts *storage.PaginationOptions) (*sqlcommon.SQLTupleIterator, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.read")
+	defer span.End()
+
+	sb := s.stbl.
+		Select("store", "object_type", "object_id", "relation", "_user", "ulid", "inserted_at", "expires_at").
+		From("tuple").
+		Where(sqlcommon.NotExpired(currentTime())).
+		Where(sq.Eq{"store": store})
+	if opts != nil {
+		sb = sb.OrderBy("ulid")
+	}
+	objectType, objectID := tupleUtils.SplitObject(tupleKey.GetObject())
+	if objectType != "" {
+		sb = sb.Where(sq.Eq{"object_type": objectType})
+	}
+	if objectID != "" {
+		sb = sb.Where(sq.Eq{"object_id": objectID})
+	}
+	if tupleKey.GetRelation() != "" {
+		sb = sb.Where(sq.Eq{"relation": tupleKey.GetRelation()})
+	}
+	if tupleKey.GetUser() != "" {
+		sb = sb.Where(sq.Eq{"_user": tupleKey.GetUser()})
+	}
+	if opts != nil && opts.From != "" {
+		token, err := sqlcommon.UnmarshallContToken(opts.From)
+		if err != nil {
+			return nil, err
+		}
+		sb = sb.Where(sq.GtOrEq{"ulid": token.Ulid})
+	}
+	if opts != nil && opts.PageSize != 0 {
+		sb = sb.Limit(uint64(opts.PageSize + 1)) // + 1 is used to determine whether to return a continuation token.
+	}
+
+	rows, err := sb.QueryContext(ctx)
+	if err != nil {
+		return nil, sqlcommon.HandleSQLError(err)
+	}
+
+	return sqlcommon.NewSQLTupleIterator(ctx, rows), nil
+}
+
+func (s *SQLite) Write(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes) error {
+	ctx, span := tracer.Start(ctx, "sqlite.Write")
+	defer span.End()
+
+	if len(deletes)+len(writes) > s.MaxTuplesPerWrite() {
+		return storage.ErrExceededWriteBatchLimit
+	}
+
+	now := currentTime()
+
+	return sqlcommon.Write(ctx, sqlcommon.NewDBInfo(s.db, s.stbl, now), store, deletes, writes, now)
+}
+
+func (s *SQLite) WriteWithConditions(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, conditions map[string]*condition.TupleCondition) error {
+	ctx, span := tracer.Start(ctx, "sqlite.WriteWithConditions")
+	defer span.End()
+
+	if len(deletes)+len(writes) > s.MaxTuplesPerWrite() {
+		return storage.ErrExceededWriteBatchLimit
+	}
+
+	now := currentTime()
+
+	return sqlcommon.WriteWithConditions(ctx, sqlcommon.NewDBInfo(s.db, s.stbl, now), store, deletes, writes, conditions, now)
+}
+
+func (s *SQLite) WriteWithExpirations(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, expirations map[string]time.Time) error {
+	ctx, span := tracer.Start(ctx, "sqlite.WriteWithExpirations")
+	defer span.End()
+
+	if len(deletes)+len(writes) > s.MaxTuplesPerWrite() {
+		return storage.ErrExceededWriteBatchLimit
+	}
+
+	now := currentTime()
+
+	return sqlcommon.WriteWithExpirations(ctx, sqlcommon.NewDBInfo(s.db, s.stbl, now), store, deletes, writes, expirations, now)
+}
+
+func (s *SQLite) WriteWithPreconditions(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, preconditions []storage.Precondition) error {
+	ctx, span := tracer.Start(ctx, "sqlite.WriteWithPreconditions")
+	defer span.End()
+
+	if len(deletes)+len(writes) > s.MaxTuplesPerWrite() {
+		return storage.ErrExceededWriteBatchLimit
+	}
+
+	now := currentTime()
+
+	return sqlcommon.WriteWithPreconditions(ctx, sqlcommon.NewDBInfo(s.db, s.stbl, now), store, deletes, writes, preconditions, now)
+}
+
+// DeleteExpiredTuples deletes the tuples of all the stores that expired at or before the given time, in
+// batches, recording their deletion in the changelog.
+func (s *SQLite) DeleteExpiredTuples(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.DeleteExpiredTuples")
+	defer span.End()
+
+	return sqlcommon.DeleteExpiredTuples(ctx, sqlcommon.NewDBInfo(s.db, s.stbl, currentTime()), before.UTC())
+}
+
+func (s *SQLite) ReadTupleCondition(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (*condition.TupleCondition, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.ReadTupleCondition")
+	defer span.End()
+
+	return sqlcommon.ReadTupleCondition(ctx, sqlcommon.NewDBInfo(s.db, s.stbl, currentTime()), store, tupleKey)
+}
+
+func (s *SQLite) ReadTupleConditions(ctx context.Context, store string, tupleKeys []*openfgav1.TupleKey) (map[string]*condition.TupleCondition, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.ReadTupleConditions")
+	defer span.End()
+
+	return sqlcommon.ReadTupleConditions(ctx, sqlcommon.NewDBInfo(s.db, s.stbl, currentTime()), store, tupleKeys)
+}
+
+func (s *SQLite) SampleTuples(ctx context.Context, store string, filter *openfgav1.TupleKey, size int) ([]*openfgav1.TupleKey, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.SampleTuples")
+	defer span.End()
+
+	return sqlcommon.SampleTuples(ctx, sqlcommon.NewDBInfo(s.db, s.stbl, currentTime()), store, filter, size, "RANDOM()")
+}
+
+func (s *SQLite) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, _ storage.ReadOptions) (*openfgav1.Tuple, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.ReadUserTuple")
+	defer span.End()
+
+	objectType, objectID := tupleUtils.SplitObject(tupleKey.GetObject())
+	userType := tupleUtils.GetUserTypeFromUser(tupleKey.GetUser())
+
+	var record sqlcommon.TupleRecord
+	err := s.stbl.
+		Select("object_type", "object_id", "relation", "_user", "expires_at").
+		From("tuple").
+		Where(sqlcommon.NotExpired(currentTime())).
+		Where(sq.Eq{
+			"store":       store,
+			"object_type": objectType,
+			"object_id":   objectID,
+			"relation":    tupleKey.GetRelation(),
+			"_user":       tupleKey.GetUser(),
+			"user_type":   userType,
+		}).
+		QueryRowContext(ctx).
+		Scan(&record.ObjectType, &record.ObjectID, &record.Relation, &record.User, &record.ExpiresAt)
+	if err != nil {
+		return nil, sqlcommon.HandleSQLError(err)
+	}
+
+	storage.RecordTupleExpiration(ctx, record.ExpiresAt.Time)
+
+	return record.AsTuple(), nil
+}
+
+func (s *SQLite) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter, _ storage.ReadOptions) (storage.TupleIterator, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.ReadUsersetTuples")
+	defer span.End()
+
+	sb := s.stbl.Select("store", "object_type", "object_id", "relation", "_user", "ulid", "inserted_at", "expires_at").
+		From("tuple").
+		Where(sqlcommon.NotExpired(currentTime())).
+		Where(sq.Eq{"store": store}).
+		Where(sq.Eq{"user_type": tupleUtils.UserSet})
+
+	objectType, objectID := tupleUtils.SplitObject(filter.Object)
+	if objectType != "" {
+		sb = sb.Where(sq.Eq{"object_type": objectType})
+	}
+	if objectID != "" {
+		sb = sb.Where(sq.Eq{"object_id": objectID})
+	}
+	if filter.Relation != "" {
+		sb = sb.Where(sq.Eq{"relation": filter.Relation})
+	}
+	if len(filter.AllowedUserTypeRestrictions) > 0 {
+		orConditions := sq.Or{}
+		for _, userset := range filter.AllowedUserTypeRestrictions {
+			if _, ok := userset.RelationOrWildcard.(*openfgav1.RelationReference_Relation); ok {
+				orConditions = append(orConditions, sq.Like{"_user": userset.Type + ":%#" + userset.GetRelation()})
+			}
+			if _, ok := userset.RelationOrWildcard.(*openfgav1.RelationReference_Wildcard); ok {
+				orConditions = append(orConditions, sq.Eq{"_user": userset.Type + ":*"})
+			}
+		}
+		sb = sb.Where(orConditions)
+	}
+	rows, err := sb.QueryContext(ctx)
+	if err != nil {
+		return nil, sqlcommon.HandleSQLError(err)
+	}
+
+	return sqlcommon.NewSQLTupleIterator(ctx, rows), nil
+}
+
+func (s *SQLite) ReadStartingWithUser(ctx context.Context, store string, opts storage.ReadStartingWithUserFilter, _ storage.ReadOptions) (storage.TupleIterator, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.ReadStartingWithUser")
+	defer span.End()
+
+	var targetUsersArg []string
+	for _, u := range opts.UserFilter {
+		targetUser := u.GetObject()
+		if u.GetRelation() != "" {
+			targetUser = strings.Join([]string{u.GetObject(), u.GetRelation()}, "#")
+		}
+		targetUsersArg = append(targetUsersArg, targetUser)
+	}
+
+	sb := s.stbl.
+		Select("store", "object_type", "object_id", "relation", "_user", "ulid", "inserted_at", "expires_at").
+		From("tuple").
+		Where(sqlcommon.NotExpired(currentTime())).
+		Where(sq.Eq{
+			"store":       store,
+			"object_type": opts.ObjectType,
+			"relation":    opts.Relation,
+			"_user":       targetUsersArg,
+		})
+	if len(opts.ObjectIDs) > 0 {
+		sb = sb.Where(sq.Eq{"object_id": opts.ObjectIDs})
+	}
+
+	rows, err := sb.QueryContext(ctx)
+	if err != nil {
+		return nil, sqlcommon.HandleSQLError(err)
+	}
+
+	return sqlcommon.NewSQLTupleIterator(ctx, rows), nil
+}
+
+func (s *SQLite) MaxTuplesPerWrite() int {
+	return s.maxTuplesPerWriteField
+}
+
+func (s *SQLite) ReadAuthorizationModel(ctx context.Context, store string, modelID string) (*openfgav1.AuthorizationModel, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.ReadAuthorizationModel")
+	defer span.End()
+
+	return sqlcommon.ReadAuthorizationModel(ctx, sqlcommon.NewDBInfo(s.db, s.stbl, currentTime()), store, modelID)
+}
+
+func (s *SQLite) ReadAuthorizationModels(ctx context.Context, store string, opts storage.PaginationOptions) ([]*openfgav1.AuthorizationModel, []byte, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.ReadAuthorizationModels")
+	defer span.End()
+
+	sb := s.stbl.Select("authorization_model_id").
+		Distinct().
+		From("authorization_model").
+		Where(sq.Eq{"store": store}).
+		OrderBy("authorization_model_id desc")
+
+	if opts.From != "" {
+		token, err := sqlcommon.UnmarshallContToken(opts.From)
+		if err != nil {
+			return nil, nil, err
+		}
+		sb = sb.Where(sq.LtOrEq{"authorization_model_id": token.Ulid})
+	}
+	if opts.PageSize > 0 {
+		sb = sb.Limit(uint64(opts.PageSize + 1)) // + 1 is used to determine whether to return a continuation token.
+	}
+
+	rows, err := sb.QueryContext(ctx)
+	if err != nil {
+		return nil, nil, sqlcommon.HandleSQLError(err)
+	}
+	defer rows.Close()
+
+	var modelIDs []string
+	var modelID string
+
+	for rows.Next() {
+		err = rows.Scan(&modelID)
+		if err != nil {
+			return nil, nil, sqlcommon.HandleSQLError(err)
+		}
+
+		modelIDs = append(modelIDs, modelID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, sqlcommon.HandleSQLError(err)
+	}
+
+	// the rows are closed before the models are read, so that the reads don't need another connection
+	rows.Close()
+
+	var token []byte
+	numModelIDs := len(modelIDs)
+	if len(modelIDs) > opts.PageSize {
+		numModelIDs = opts.PageSize
+		token, err = json.Marshal(sqlcommon.NewContToken(modelID, ""))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	models := make([]*openfgav1.AuthorizationModel, 0, numModelIDs)
+	// We use numModelIDs here to avoid retrieving possibly one extra model.
+	for i := 0; i < numModelIDs; i++ {
+		model, err := s.ReadAuthorizationModel(ctx, store, modelIDs[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		models = append(models, model)
+	}
+
+	return models, token, nil
+}
+
+func (s *SQLite) FindLatestAuthorizationModelID(ctx context.Context, store string) (string, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.FindLatestAuthorizationModelID")
+	defer span.End()
+
+	var modelID string
+	err := s.stbl.
+		Select("authorization_model_id").
+		From("authorization_model").
+		Where(sq.Eq{"store": store}).
+		OrderBy("authorization_model_id desc").
+		Limit(1).
+		QueryRowContext(ctx).
+		Scan(&modelID)
+	if err != nil {
+		return "", sqlcommon.HandleSQLError(err)
+	}
+
+	return modelID, nil
+}
+
+func (s *SQLite) MaxTypesPerAuthorizationModel() int {
+	return s.maxTypesPerModelField
+}
+
+func (s *SQLite) WriteAuthorizationModel(ctx context.Context, store string, model *openfgav1.AuthorizationModel) error {
+	ctx, span := tracer.Start(ctx, "sqlite.WriteAuthorizationModel")
+	defer span.End()
+
+	typeDefinitions := model.GetTypeDefinitions()
+
+	if len(typeDefinitions) > s.MaxTypesPerAuthorizationModel() {
+		return storage.ExceededMaxTypeDefinitionsLimitError(s.maxTypesPerModelField)
+	}
+
+	return sqlcommon.WriteAuthorizationModel(ctx, sqlcommon.NewDBInfo(s.db, s.stbl, currentTime()), store, model)
+}
+
+func (s *SQLite) CreateStore(ctx context.Context, store *openfgav1.Store) (*openfgav1.Store, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.CreateStore")
+	defer span.End()
+
+	var id, name string
+	var createdAt time.Time
+	err := s.stbl.
+		Insert("store").
+		Columns("id", "name", "created_at", "updated_at").
+		Values(store.Id, store.Name, currentTime(), currentTime()).
+		Suffix("RETURNING id, name, created_at").
+		QueryRowContext(ctx).
+		Scan(&id, &name, &createdAt)
+	if err != nil {
+		return nil, sqlcommon.HandleSQLError(err)
+	}
+
+	return &openfgav1.Store{
+		Id:        id,
+		Name:      name,
+		CreatedAt: timestamppb.New(createdAt),
+		UpdatedAt: timestamppb.New(createdAt),
+	}, nil
+}
+
+func (s *SQLite) GetStore(ctx context.Context, id string) (*openfgav1.Store, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.GetStore")
+	defer span.End()
+
+	row := s.stbl.
+		Select("id", "name", "created_at", "updated_at").
+		From("store").
+		Where(sq.Eq{
+			"id":         id,
+			"deleted_at": nil,
+		}).
+		QueryRowContext(ctx)
+
+	var storeID, name string
+	var createdAt, updatedAt time.Time
+	err := row.Scan(&storeID, &name, &createdAt, &updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, sqlcommon.HandleSQLError(err)
+	}
+
+	return &openfgav1.Store{
+		Id:        storeID,
+		Name:      name,
+		CreatedAt: timestamppb.New(createdAt),
+		UpdatedAt: timestamppb.New(updatedAt),
+	}, nil
+}
+
+func (s *SQLite) ListStores(ctx context.Context, opts storage.PaginationOptions) ([]*openfgav1.Store, []byte, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.ListStores")
+	defer span.End()
+
+	sb := s.stbl.Select("id", "name", "created_at", "updated_at").
+		From("store").
+		Where(sq.Eq{"deleted_at": nil}).
+		OrderBy("id")
+
+	if opts.From != "" {
+		token, err := sqlcommon.UnmarshallContToken(opts.From)
+		if err != nil {
+			return nil, nil, err
+		}
+		sb = sb.Where(sq.GtOrEq{"id": token.Ulid})
+	}
+	if opts.PageSize > 0 {
+		sb = sb.Limit(uint64(opts.PageSize + 1)) // + 1 is used to determine whether to return a continuation token.
+	}
+
+	rows, err := sb.QueryContext(ctx)
+	if err != nil {
+		return nil, nil, sqlcommon.HandleSQLError(err)
+	}
+	defer rows.Close()
+
+	var stores []*openfgav1.Store
+	var id string
+	for rows.Next() {
+		var name string
+		var createdAt, updatedAt time.Time
+		err := rows.Scan(&id, &name, &createdAt, &updatedAt)
+		if err != nil {
+			return nil, nil, sqlcommon.HandleSQLError(err)
+		}
+
+		stores = append(stores, &openfgav1.Store{
+			Id:        id,
+			Name:      name,
+			CreatedAt: timestamppb.New(createdAt),
+			UpdatedAt: timestamppb.New(updatedAt),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, sqlcommon.HandleSQLError(err)
+	}
+
+	if len(stores) > opts.PageSize {
+		contToken, err := json.Marshal(sqlcommon.NewContToken(id, ""))
+		if err != nil {
+			return nil, nil, err
+		}
+		return stores[:opts.PageSize], contToken, nil
+	}
+
+	return stores, nil, nil
+}
+
+func (s *SQLite) DeleteStore(ctx context.Context, id string) error {
+	ctx, span := tracer.Start(ctx, "sqlite.DeleteStore")
+	defer span.End()
+
+	_, err := s.stbl.
+		Update("store").
+		Set("deleted_at", currentTime()).
+		Where(sq.Eq{"id": id}).
+		ExecContext(ctx)
+	if err != nil {
+		return sqlcommon.HandleSQLError(err)
+	}
+
+	return nil
+}
+
+func (s *SQLite) WriteAssertions(ctx context.Context, store, modelID string, assertions []*openfgav1.Assertion) error {
+	ctx, span := tracer.Start(ctx, "sqlite.WriteAssertions")
+	defer span.End()
+
+	marshalledAssertions, err := proto.Marshal(&openfgav1.Assertions{Assertions: assertions})
+	if err != nil {
+		return err
+	}
+
+	txn, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return sqlcommon.HandleSQLError(err)
+	}
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	_, err = s.stbl.
+		Insert("assertion").
+		Columns("store", "authorization_model_id", "assertions").
+		Values(store, modelID, marshalledAssertions).
+		Suffix("ON CONFLICT (store, authorization_model_id) DO UPDATE SET assertions = ?", marshalledAssertions).
+		RunWith(txn). // Part of a txn
+		ExecContext(ctx)
+	if err != nil {
+		return sqlcommon.HandleSQLError(err)
+	}
+
+	err = sqlcommon.WriteAssertionsHistory(ctx, sqlcommon.NewDBInfo(s.db, s.stbl, currentTime()), txn, store, modelID, marshalledAssertions)
+	if err != nil {
+		return err
+	}
+
+	if err := txn.Commit(); err != nil {
+		return sqlcommon.HandleSQLError(err)
+	}
+
+	return nil
+}
+
+func (s *SQLite) ReadAssertions(ctx context.Context, store, modelID string) ([]*openfgav1.Assertion, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.ReadAssertions")
+	defer span.End()
+
+	var marshalledAssertions []byte
+	err := s.stbl.
+		Select("assertions").
+		From("assertion").
+		Where(sq.Eq{
+			"store":                  store,
+			"authorization_model_id": modelID,
+		}).
+		QueryRowContext(ctx).
+		Scan(&marshalledAssertions)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return []*openfgav1.Assertion{}, nil
+		}
+		return nil, sqlcommon.HandleSQLError(err)
+	}
+
+	var assertions openfgav1.Assertions
+	err = proto.Unmarshal(marshalledAssertions, &assertions)
+	if err != nil {
+		return nil, err
+	}
+
+	return assertions.Assertions, nil
+}
+
+func (s *SQLite) ReadAssertionsHistory(ctx context.Context, store, modelID string) ([]*storage.AssertionsVersion, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.ReadAssertionsHistory")
+	defer span.End()
+
+	return sqlcommon.ReadAssertionsHistory(ctx, sqlcommon.NewDBInfo(s.db, s.stbl, currentTime()), store, modelID)
+}
+
+func (s *SQLite) DeleteAssertions(ctx context.Context, store, modelID string) error {
+	ctx, span := tracer.Start(ctx, "sqlite.DeleteAssertions")
+	defer span.End()
+
+	return sqlcommon.DeleteAssertions(ctx, sqlcommon.NewDBInfo(s.db, s.stbl, currentTime()), store, modelID)
+}
+
+func (s *SQLite) WriteAuthorizationModelLabels(ctx context.Context, store, modelID string, labels map[string]string) error {
+	ctx, span := tracer.Start(ctx, "sqlite.WriteAuthorizationModelLabels")
+	defer span.End()
+
+	return sqlcommon.WriteAuthorizationModelLabels(ctx, sqlcommon.NewDBInfo(s.db, s.stbl, currentTime()), store, modelID, labels)
+}
+
+func (s *SQLite) ReadAuthorizationModelLabels(ctx context.Context, store, modelID string) (map[string]string, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.ReadAuthorizationModelLabels")
+	defer span.End()
+
+	return sqlcommon.ReadAuthorizationModelLabels(ctx, sqlcommon.NewDBInfo(s.db, s.stbl, currentTime()), store, modelID)
+}
+
+func (s *SQLite) FindAuthorizationModelIDByLabel(ctx context.Context, store, key, value string) (string, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.FindAuthorizationModelIDByLabel")
+	defer span.End()
+
+	return sqlcommon.FindAuthorizationModelIDByLabel(ctx, sqlcommon.NewDBInfo(s.db, s.stbl, currentTime()), store, key, value)
+}
+
+func (s *SQLite) WriteAuthorizationModelConditions(ctx context.Context, store, modelID string, conditions *condition.ModelConditions) error {
+	ctx, span := tracer.Start(ctx, "sqlite.WriteAuthorizationModelConditions")
+	defer span.End()
+
+	return sqlcommon.WriteAuthorizationModelConditions(ctx, sqlcommon.NewDBInfo(s.db, s.stbl, currentTime()), store, modelID, conditions)
+}
+
+func (s *SQLite) ReadAuthorizationModelConditions(ctx context.Context, store, modelID string) (*condition.ModelConditions, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.ReadAuthorizationModelConditions")
+	defer span.End()
+
+	return sqlcommon.ReadAuthorizationModelConditions(ctx, sqlcommon.NewDBInfo(s.db, s.stbl, currentTime()), store, modelID)
+}
+
+func (s *SQLite) ReadChanges(
+	ctx context.Context,
+	store, objectTypeFilter string,
+	opts storage.PaginationOptions,
+	horizonOffset time.Duration,
+	_ storage.ReadOptions,
+) ([]*openfgav1.TupleChange, []byte, error) {
+	ctx, span := tracer.Start(ctx, "sqlite.ReadChanges")
+	defer span.End()
+
+	sb := s.stbl.Select("ulid", "object_type", "object_id", "relation", "_user", "operation", "inserted_at").
+		From("changelog").
+		Where(sq.Eq{"store": store}).
+		Where(sq.LtOrEq{"inserted_at": currentTime().Add(-horizonOffset)}).
+		OrderBy("ulid asc")
+
+	if objectTypeFilter != "" {
+		sb = sb.Where(sq.Eq{"object_type": objectTypeFilter})
+	}
+	if opts.From != "" {
+		token, err := sqlcommon.UnmarshallContToken(opts.From)
+		if err != nil {
+			return nil, nil, err
+		}
+		if token.ObjectType != objectTypeFilter {
+			return nil, nil, storage.ErrMismatchObjectType
+		}
+
+		sb = sb.Where(sq.Gt{"ulid": token.Ulid}) // > as we always return a continuation token
+	}
+	if opts.PageSize > 0 {
+		sb = sb.Limit(uint64(opts.PageSize)) // + 1 is NOT used here as we always return a continuation token
+	}
+
+	rows, err := sb.QueryContext(ctx)
+	if err != nil {
+		return nil, nil, sqlcommon.HandleSQLError(err)
+	}
+	defer rows.Close()
+
+	var changes []*openfgav1.TupleChange
+	var ulid string
+	for rows.Next() {
+		var objectType, objectID, relation, user string
+		var operation int
+		var insertedAt time.Time
+
+		err = rows.Scan(&ulid, &objectType, &objectID, &relation, &user, &operation, &insertedAt)
+		if err != nil {
+			return nil, nil, sqlcommon.HandleSQLError(err)
+		}
+
+		changes = append(changes, &openfgav1.TupleChange{
+			TupleKey: &openfgav1.TupleKey{
+				Object:   tupleUtils.BuildObject(objectType, objectID),
+				Relation: relation,
+				User:     user,
+			},
+			Operation: openfgav1.TupleOperation(operation),
+			Timestamp: timestamppb.New(insertedAt.UTC()),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, sqlcommon.HandleSQLError(err)
+	}
+
+	if len(changes) == 0 {
+		return nil, nil, storage.ErrNotFound
+	}
+
+	contToken, err := json.Marshal(sqlcommon.NewContToken(ulid, objectTypeFilter))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return changes, contToken, nil
+}
+
+// IsReady reports whether this SQLite datastore instance is ready
+// to accept connections.
+func (s *SQLite) IsReady(ctx context.Context) (bool, error) {
+	return sqlcommon.IsReady(ctx, s.db)
+}
+
+// SchemaVersion returns the version of the schema of this SQLite datastore and the latest version
+// known to the server.
+func (s *SQLite) SchemaVersion(ctx context.Context) (int64, int64, error) {
+	return s.migrator.SchemaVersion(ctx)
+}
+
+// Migrate applies the pending migrations to the schema of this SQLite datastore.
+func (s *SQLite) Migrate(ctx context.Context) error {
+	return s.migrator.Migrate(ctx)
+}
+
+// MaintenanceTasks returns the maintenance tasks of this SQLite datastore:
+//
+//   - 'vacuum' rebuilds the database file, reclaiming the storage of the deleted tuples and changes.
+//   - 'analyze' refreshes the statistics of the indexes the query planner relies on.
+func (s *SQLite) MaintenanceTasks() []storage.MaintenanceTask {
+	return []storage.MaintenanceTask{
+		{
+			Name: "vacuum",
+			Run: func(ctx context.Context) error {
+				_, err := s.db.ExecContext(ctx, "VACUUM")
+				return err
+			},
+		},
+		{
+			Name: "analyze",
+			Run: func(ctx context.Context) error {
+				_, err := s.db.ExecContext(ctx, "ANALYZE")
+				return err
+			},
+		},
+	}
+}
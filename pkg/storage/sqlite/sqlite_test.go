@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/sqlcommon"
+	"github.com/openfga/openfga/pkg/storage/test"
+	"github.com/stretchr/testify/require"
+)
+
+func newSQLite(t *testing.T) *SQLite {
+	ds, err := New(filepath.Join(t.TempDir(), "openfga.db"), sqlcommon.NewConfig())
+	require.NoError(t, err)
+	t.Cleanup(ds.Close)
+
+	require.NoError(t, ds.Migrate(context.Background()))
+
+	return ds
+}
+
+func TestSQLiteDatastore(t *testing.T) {
+	ds := newSQLite(t)
+	test.RunAllTests(t, ds)
+}
+
+func TestSQLiteSchemaVersion(t *testing.T) {
+	ds, err := New(filepath.Join(t.TempDir(), "openfga.db"), sqlcommon.NewConfig())
+	require.NoError(t, err)
+	defer ds.Close()
+
+	ctx := context.Background()
+
+	// a new database has no schema until it's migrated
+	require.Error(t, storage.CheckSchemaVersion(ctx, ds))
+
+	require.NoError(t, ds.Migrate(ctx))
+	current, latest, err := ds.SchemaVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, latest, current)
+	require.NoError(t, storage.CheckSchemaVersion(ctx, ds))
+
+	// migrating an up-to-date schema is a no-op
+	require.NoError(t, ds.Migrate(ctx))
+	current, _, err = ds.SchemaVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, latest, current)
+}
+
+func TestSQLiteDatastoreAfterCloseIsNotReady(t *testing.T) {
+	ds, err := New(filepath.Join(t.TempDir(), "openfga.db"), sqlcommon.NewConfig())
+	require.NoError(t, err)
+	ds.Close()
+
+	ready, err := ds.IsReady(context.Background())
+	require.Error(t, err)
+	require.False(t, ready)
+}
+
+func TestSQLiteMaintenanceTasks(t *testing.T) {
+	ds := newSQLite(t)
+
+	for _, task := range ds.MaintenanceTasks() {
+		require.NoError(t, task.Run(context.Background()), task.Name)
+	}
+}
+
+func TestPrepareDSN(t *testing.T) {
+	dsn, err := prepareDSN("/var/lib/openfga/openfga.db?_pragma=BUSY_TIMEOUT(10000)")
+	require.NoError(t, err)
+	require.Equal(t, "/var/lib/openfga/openfga.db?_pragma=BUSY_TIMEOUT%2810000%29&_pragma=journal_mode%28WAL%29&_pragma=case_sensitive_like%281%29&_time_format=sqlite&_txlock=immediate", dsn)
+
+	for _, uri := range []string{"", ":memory:", "file:openfga.db?mode=memory"} {
+		_, err := prepareDSN(uri)
+		require.Error(t, err, uri)
+	}
+}
@@ -5,15 +5,22 @@ package storage
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/condition"
 )
 
 const (
 	DefaultMaxTuplesPerWrite             = 100
 	DefaultMaxTypesPerAuthorizationModel = 100
 	DefaultPageSize                      = 50
+
+	// DefaultExpiredTuplesReapInterval is the default interval at which the datastores delete the expired
+	// tuples (see TupleExpirationBackend).
+	DefaultExpiredTuplesReapInterval = time.Minute
 )
 
 type PaginationOptions struct {
@@ -33,6 +40,53 @@ func NewPaginationOptions(ps int32, contToken string) PaginationOptions {
 	}
 }
 
+// ConsistencyPreference expresses the consistency that a read from the datastore requires.
+type ConsistencyPreference int
+
+const (
+	// ConsistencyPreferenceUnspecified lets the datastore pick the consistency it reads with.
+	ConsistencyPreferenceUnspecified ConsistencyPreference = iota
+
+	// ConsistencyPreferenceMinimizeLatency prefers the fastest read, even if it may be stale (e.g. served from
+	// a cache or a replica).
+	ConsistencyPreferenceMinimizeLatency
+
+	// ConsistencyPreferenceHigherConsistency prefers a read that reflects the most recent writes.
+	ConsistencyPreferenceHigherConsistency
+)
+
+var consistencyPreferenceNames = map[ConsistencyPreference]string{
+	ConsistencyPreferenceUnspecified:       "UNSPECIFIED",
+	ConsistencyPreferenceMinimizeLatency:   "MINIMIZE_LATENCY",
+	ConsistencyPreferenceHigherConsistency: "HIGHER_CONSISTENCY",
+}
+
+func (c ConsistencyPreference) String() string {
+	if name, ok := consistencyPreferenceNames[c]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("ConsistencyPreference(%d)", int(c))
+}
+
+// ParseConsistencyPreference returns the ConsistencyPreference of the name, e.g. 'HIGHER_CONSISTENCY', which
+// is case insensitive.
+func ParseConsistencyPreference(name string) (ConsistencyPreference, error) {
+	for c, n := range consistencyPreferenceNames {
+		if strings.EqualFold(n, name) {
+			return c, nil
+		}
+	}
+
+	return ConsistencyPreferenceUnspecified, fmt.Errorf("unknown consistency preference '%s'", name)
+}
+
+// ReadOptions specifies options that apply to reads of relationship tuples and changes. New read options
+// should be added here instead of to the datastore method signatures.
+type ReadOptions struct {
+	Consistency ConsistencyPreference
+}
+
 // Writes and Deletes are typesafe aliases for Write arguments.
 type Writes = []*openfgav1.TupleKey
 type Deletes = []*openfgav1.TupleKey
@@ -51,7 +105,7 @@ type RelationshipTupleReader interface {
 	//
 	// The caller must be careful to close the TupleIterator, either by consuming the entire iterator or by closing it.
 	// There is NO guarantee on the order returned on the iterator.
-	Read(context.Context, string, *openfgav1.TupleKey) (TupleIterator, error)
+	Read(context.Context, string, *openfgav1.TupleKey, ReadOptions) (TupleIterator, error)
 
 	// ReadPage is similar to Read, but with PaginationOptions. Instead of returning a TupleIterator, ReadPage
 	// returns a page of tuples and a possibly non-empty continuation token.
@@ -60,7 +114,8 @@ type RelationshipTupleReader interface {
 		ctx context.Context,
 		store string,
 		tk *openfgav1.TupleKey,
-		opts PaginationOptions,
+		paginationOptions PaginationOptions,
+		options ReadOptions,
 	) ([]*openfgav1.Tuple, []byte, error)
 
 	// ReadUserTuple tries to return one tuple that matches the provided key exactly.
@@ -68,6 +123,7 @@ type RelationshipTupleReader interface {
 		ctx context.Context,
 		store string,
 		tk *openfgav1.TupleKey,
+		options ReadOptions,
 	) (*openfgav1.Tuple, error)
 
 	// ReadUsersetTuples returns all userset tuples for a specified object and relation.
@@ -83,6 +139,7 @@ type RelationshipTupleReader interface {
 		ctx context.Context,
 		store string,
 		filter ReadUsersetTuplesFilter,
+		options ReadOptions,
 	) (TupleIterator, error)
 
 	// ReadStartingWithUser performs a reverse read of relationship tuples starting at one or
@@ -100,6 +157,7 @@ type RelationshipTupleReader interface {
 		ctx context.Context,
 		store string,
 		filter ReadStartingWithUserFilter,
+		options ReadOptions,
 	) (TupleIterator, error)
 }
 
@@ -122,6 +180,9 @@ type ReadStartingWithUserFilter struct {
 	ObjectType string
 	Relation   string
 	UserFilter []*openfgav1.ObjectRelation
+
+	// ObjectIDs optionally restricts the tuples to the ones of the objects of ObjectType with these IDs.
+	ObjectIDs []string
 }
 
 type ReadUsersetTuplesFilter struct {
@@ -163,9 +224,46 @@ type StoresBackend interface {
 	ListStores(ctx context.Context, paginationOptions PaginationOptions) ([]*openfgav1.Store, []byte, error)
 }
 
+// AssertionsVersion is one version of the assertions of an authorization model.
+type AssertionsVersion struct {
+	Version    uint32
+	Assertions []*openfgav1.Assertion
+	CreatedAt  time.Time
+}
+
+// AssertionsBackend provides an R/W interface for managing the assertions of authorization models. Assertions
+// are versioned per (store, model ID): every write creates a new version, and the previous versions are kept
+// as the history of the assertions of the model.
 type AssertionsBackend interface {
+	// WriteAssertions replaces the assertions of the given model with a new version.
 	WriteAssertions(ctx context.Context, store, modelID string, assertions []*openfgav1.Assertion) error
+
+	// ReadAssertions returns the latest version of the assertions of the given model, or an empty list if
+	// the model has no assertions.
 	ReadAssertions(ctx context.Context, store, modelID string) ([]*openfgav1.Assertion, error)
+
+	// ReadAssertionsHistory returns every version of the assertions of the given model, from the newest
+	// to the oldest.
+	ReadAssertionsHistory(ctx context.Context, store, modelID string) ([]*AssertionsVersion, error)
+
+	// DeleteAssertions deletes the assertions of the given model, along with their history.
+	DeleteAssertions(ctx context.Context, store, modelID string) error
+}
+
+// AuthorizationModelLabelsBackend provides an R/W interface for managing the labels of authorization models,
+// e.g. 'release=42' or 'git_sha=3f2a9c1', which identify the model of a deployment without copying its ID
+// around.
+type AuthorizationModelLabelsBackend interface {
+	// WriteAuthorizationModelLabels adds the labels to the given model, replacing the values of the labels it
+	// already has with the same keys. It returns ErrNotFound if the model doesn't exist.
+	WriteAuthorizationModelLabels(ctx context.Context, store, modelID string, labels map[string]string) error
+
+	// ReadAuthorizationModelLabels returns the labels of the given model, which are empty if it has none.
+	ReadAuthorizationModelLabels(ctx context.Context, store, modelID string) (map[string]string, error)
+
+	// FindAuthorizationModelIDByLabel returns the ID of the latest model of the store that has the label, or
+	// ErrNotFound if none has.
+	FindAuthorizationModelIDByLabel(ctx context.Context, store, key, value string) (string, error)
 }
 
 type ChangelogBackend interface {
@@ -173,7 +271,7 @@ type ChangelogBackend interface {
 	// ReadChanges returns the writes and deletes that have occurred for tuples of a given object type within a store.
 	// The horizonOffset should be specified using a unit no more granular than a millisecond and should be interpreted
 	// as a millisecond duration.
-	ReadChanges(ctx context.Context, store, objectType string, paginationOptions PaginationOptions, horizonOffset time.Duration) ([]*openfgav1.TupleChange, []byte, error)
+	ReadChanges(ctx context.Context, store, objectType string, paginationOptions PaginationOptions, horizonOffset time.Duration, options ReadOptions) ([]*openfgav1.TupleChange, []byte, error)
 }
 
 type OpenFGADatastore interface {
@@ -181,6 +279,7 @@ type OpenFGADatastore interface {
 	AuthorizationModelBackend
 	StoresBackend
 	AssertionsBackend
+	AuthorizationModelLabelsBackend
 	ChangelogBackend
 
 	// IsReady reports whether the datastore is ready to accept traffic.
@@ -189,3 +288,160 @@ type OpenFGADatastore interface {
 	// Close closes the datastore and cleans up any residual resources.
 	Close()
 }
+
+// TupleStats are statistics of a set of tuples. They're approximate: datastores may estimate them, and may
+// lag behind the latest writes.
+type TupleStats struct {
+	// TupleCount is the number of tuples.
+	TupleCount int64
+
+	// ObjectCount is the number of distinct objects of the tuples.
+	ObjectCount int64
+
+	// UserCount is the number of distinct users of the tuples.
+	UserCount int64
+}
+
+// StatsProvider is implemented by the datastores that maintain statistics of the tuples of each store as
+// they're written and deleted. The statistics help estimate the cost of evaluating a relation, e.g. to
+// evaluate the operands of an intersection from the cheapest to the most expensive.
+type StatsProvider interface {
+	// RelationStats returns the statistics of the tuples of the relation of the object type in the store.
+	RelationStats(ctx context.Context, store, objectType, relation string) (TupleStats, error)
+
+	// StoreStats returns the statistics of all the tuples of the store.
+	StoreStats(ctx context.Context, store string) (TupleStats, error)
+}
+
+// ConditionsBackend is implemented by the datastores that store the conditions of the authorization models,
+// and the conditions the tuples carry (see the condition package).
+type ConditionsBackend interface {
+	// WriteAuthorizationModelConditions replaces the conditions of the given model. It returns ErrNotFound if
+	// the model doesn't exist.
+	WriteAuthorizationModelConditions(ctx context.Context, store, modelID string, conditions *condition.ModelConditions) error
+
+	// ReadAuthorizationModelConditions returns the conditions of the given model, or nil if it has none.
+	ReadAuthorizationModelConditions(ctx context.Context, store, modelID string) (*condition.ModelConditions, error)
+
+	// WriteWithConditions is Write, and the tuples written carry the conditions keyed by their
+	// tuple.TupleKeyToString, if any. The condition of a tuple is deleted along with the tuple.
+	WriteWithConditions(ctx context.Context, store string, d Deletes, w Writes, conditions map[string]*condition.TupleCondition) error
+
+	// ReadTupleCondition returns the condition the tuple with the key carries, or nil if it has none. It
+	// returns ErrNotFound if the tuple doesn't exist.
+	ReadTupleCondition(ctx context.Context, store string, tk *openfgav1.TupleKey) (*condition.TupleCondition, error)
+
+	// ReadTupleConditions returns the conditions the tuples with the keys carry, keyed by their
+	// tuple.TupleKeyToString, so that the conditions of the tuples read together are read at once. The tuples
+	// that don't carry a condition have a nil entry, and the tuples that don't exist have none.
+	ReadTupleConditions(ctx context.Context, store string, tks []*openfgav1.TupleKey) (map[string]*condition.TupleCondition, error)
+}
+
+// TupleExpirationBackend is implemented by the datastores whose tuples may expire, e.g. to grant a temporary
+// access. The reads treat the expired tuples as deleted, and the datastores delete them in the background,
+// recording their deletion in the changelog.
+type TupleExpirationBackend interface {
+	// WriteWithExpirations is Write, and the tuples written expire at the times keyed by their
+	// tuple.TupleKeyToString, if any. An expired tuple can't be deleted, but it can be written again.
+	WriteWithExpirations(ctx context.Context, store string, d Deletes, w Writes, expirations map[string]time.Time) error
+
+	// DeleteExpiredTuples deletes the tuples of all the stores that expired at or before the given time,
+	// recording their deletion in the changelog, and returns the number of tuples deleted.
+	DeleteExpiredTuples(ctx context.Context, before time.Time) (int, error)
+}
+
+// Precondition is a condition on a tuple of a store that a write only applies under.
+type Precondition struct {
+	TupleKey *openfgav1.TupleKey
+
+	// Exists is whether the tuple must exist, rather than not exist.
+	Exists bool
+}
+
+// PreconditionsBackend is implemented by the datastores that can write the tuples under preconditions, so that
+// concurrent writers can implement optimistic concurrency without racing between their reads and their writes.
+type PreconditionsBackend interface {
+	// WriteWithPreconditions is Write, only if the preconditions all hold as the write applies, atomically with
+	// it. It returns an error wrapping ErrPreconditionFailed otherwise, and the write isn't applied. It may
+	// return ErrTransactionalWriteFailed if a concurrent write changed the tuples of the preconditions.
+	WriteWithPreconditions(ctx context.Context, store string, d Deletes, w Writes, preconditions []Precondition) error
+}
+
+// TupleSampler is implemented by the datastores that can sample the tuples of a store at random, across all
+// of them rather than the ones of the first pages read.
+type TupleSampler interface {
+	// SampleTuples returns up to size tuples of the store matching the filter, picked at random with the
+	// same probability. The filter is either empty, or has the object type of the tuples (as 'document:'),
+	// and optionally their relation.
+	SampleTuples(ctx context.Context, store string, filter *openfgav1.TupleKey, size int) ([]*openfgav1.TupleKey, error)
+}
+
+// MaintenanceTask is a maintenance task of a datastore, e.g. the refresh of the statistics of its tables.
+type MaintenanceTask struct {
+	// Name identifies the task, e.g. 'vacuum'.
+	Name string
+
+	Run func(ctx context.Context) error
+}
+
+// Maintainer is implemented by the datastores that have maintenance tasks to run periodically, which the
+// server schedules.
+type Maintainer interface {
+	// MaintenanceTasks returns the maintenance tasks of the datastore, in the order they should run.
+	MaintenanceTasks() []MaintenanceTask
+}
+
+// DatastoreWrapper is implemented by the datastores that wrap another datastore, e.g. to cache or to route its
+// operations. The wrappers implement the optional backends of the datastores (e.g. ConditionsBackend) whether
+// or not the datastore they wrap does, so that the operations of the backends go through them too: use As to
+// find out whether a wrapped datastore supports a backend.
+type DatastoreWrapper interface {
+	// Unwrap returns the datastore that is wrapped.
+	Unwrap() OpenFGADatastore
+}
+
+// As returns the datastore as the optional backend T, e.g. ConditionsBackend, if the datastore and all the
+// datastores it wraps (see DatastoreWrapper) implement it.
+func As[T any](datastore interface{}) (T, bool) {
+	var zero T
+
+	backend, ok := datastore.(T)
+	if !ok {
+		return zero, false
+	}
+
+	for wrapper, ok := datastore.(DatastoreWrapper); ok; wrapper, ok = datastore.(DatastoreWrapper) {
+		datastore = wrapper.Unwrap()
+		if _, ok := datastore.(T); !ok {
+			return zero, false
+		}
+	}
+
+	return backend, true
+}
+
+// SchemaMigrator is implemented by the datastores whose schema is versioned and migrated by the server,
+// e.g. the SQL datastores.
+type SchemaMigrator interface {
+	// SchemaVersion returns the version of the schema of the datastore, i.e. the version of the last
+	// migration applied to it, and the latest version known to the server.
+	SchemaVersion(ctx context.Context) (current int64, latest int64, err error)
+
+	// Migrate applies the pending migrations to the schema of the datastore.
+	Migrate(ctx context.Context) error
+}
+
+// CheckSchemaVersion returns an error wrapping ErrIncompatibleSchema if the schema of the datastore isn't
+// at the latest version known to the server.
+func CheckSchemaVersion(ctx context.Context, migrator SchemaMigrator) error {
+	current, latest, err := migrator.SchemaVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("read the datastore schema version: %w", err)
+	}
+
+	if current != latest {
+		return IncompatibleSchemaError(current, latest)
+	}
+
+	return nil
+}
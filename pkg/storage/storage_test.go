@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -46,3 +48,106 @@ func TestNewPaginationOptions(t *testing.T) {
 		})
 	}
 }
+
+type fakeSchemaMigrator struct {
+	current, latest int64
+	err             error
+}
+
+func (m *fakeSchemaMigrator) SchemaVersion(context.Context) (int64, int64, error) {
+	return m.current, m.latest, m.err
+}
+
+func (m *fakeSchemaMigrator) Migrate(context.Context) error {
+	return nil
+}
+
+func TestCheckSchemaVersion(t *testing.T) {
+	tests := []struct {
+		name          string
+		migrator      *fakeSchemaMigrator
+		expectedError string
+	}{
+		{
+			name:     "latest_version",
+			migrator: &fakeSchemaMigrator{current: 5, latest: 5},
+		},
+		{
+			name:          "pending_migrations",
+			migrator:      &fakeSchemaMigrator{current: 3, latest: 5},
+			expectedError: "the datastore schema is at version 3 but the server requires version 5",
+		},
+		{
+			name:          "newer_schema",
+			migrator:      &fakeSchemaMigrator{current: 6, latest: 5},
+			expectedError: "the datastore schema is at version 6, which is newer than the version 5 known to the server",
+		},
+		{
+			name:          "unreadable_version",
+			migrator:      &fakeSchemaMigrator{err: errors.New("connection refused")},
+			expectedError: "read the datastore schema version: connection refused",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckSchemaVersion(context.Background(), test.migrator)
+			if test.expectedError == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorContains(t, err, test.expectedError)
+			if test.migrator.err == nil {
+				require.ErrorIs(t, err, ErrIncompatibleSchema)
+			}
+		})
+	}
+}
+
+type fakeWrapper struct {
+	OpenFGADatastore
+	wrapped OpenFGADatastore
+}
+
+func (w *fakeWrapper) Unwrap() OpenFGADatastore {
+	return w.wrapped
+}
+
+func (w *fakeWrapper) MaintenanceTasks() []MaintenanceTask {
+	return nil
+}
+
+type fakeMaintainer struct {
+	OpenFGADatastore
+}
+
+func (m *fakeMaintainer) MaintenanceTasks() []MaintenanceTask {
+	return []MaintenanceTask{{Name: "vacuum"}}
+}
+
+func TestAs(t *testing.T) {
+	t.Run("datastore", func(t *testing.T) {
+		maintainer, ok := As[Maintainer](&fakeMaintainer{})
+		require.True(t, ok)
+		require.Len(t, maintainer.MaintenanceTasks(), 1)
+	})
+
+	t.Run("wrapper_of_a_datastore_that_implements_the_backend", func(t *testing.T) {
+		wrapper := &fakeWrapper{wrapped: &fakeWrapper{wrapped: &fakeMaintainer{}}}
+
+		maintainer, ok := As[Maintainer](wrapper)
+		require.True(t, ok)
+		require.Same(t, wrapper, maintainer)
+	})
+
+	t.Run("wrapper_of_a_datastore_that_does_not_implement_the_backend", func(t *testing.T) {
+		_, ok := As[Maintainer](&fakeWrapper{wrapped: &fakeWrapper{}})
+		require.False(t, ok)
+	})
+
+	t.Run("datastore_that_does_not_implement_the_backend", func(t *testing.T) {
+		_, ok := As[StatsProvider](&fakeMaintainer{})
+		require.False(t, ok)
+	})
+}
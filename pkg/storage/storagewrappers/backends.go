@@ -0,0 +1,137 @@
+package storagewrappers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/condition"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+// backendOf returns the datastore as the optional backend T, or an error wrapping storage.ErrUnsupported
+// naming the operation if it doesn't implement it. storage.As doesn't return the backends the wrapped
+// datastores don't implement, so the error is only returned to the callers of the wrappers that don't check.
+func backendOf[T any](ds storage.OpenFGADatastore, operation string) (T, error) {
+	backend, ok := ds.(T)
+	if !ok {
+		return backend, fmt.Errorf("%w: %s", storage.ErrUnsupported, operation)
+	}
+
+	return backend, nil
+}
+
+// forwardedBackends forwards the operations of the optional backends of the datastore (see
+// storage.DatastoreWrapper) to it, for the wrappers that serve them as they are.
+type forwardedBackends struct {
+	ds storage.OpenFGADatastore
+}
+
+func (f forwardedBackends) WriteAuthorizationModelConditions(ctx context.Context, store, modelID string, conditions *condition.ModelConditions) error {
+	backend, err := backendOf[storage.ConditionsBackend](f.ds, "WriteAuthorizationModelConditions")
+	if err != nil {
+		return err
+	}
+
+	return backend.WriteAuthorizationModelConditions(ctx, store, modelID, conditions)
+}
+
+func (f forwardedBackends) ReadAuthorizationModelConditions(ctx context.Context, store, modelID string) (*condition.ModelConditions, error) {
+	backend, err := backendOf[storage.ConditionsBackend](f.ds, "ReadAuthorizationModelConditions")
+	if err != nil {
+		return nil, err
+	}
+
+	return backend.ReadAuthorizationModelConditions(ctx, store, modelID)
+}
+
+func (f forwardedBackends) WriteWithConditions(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, conditions map[string]*condition.TupleCondition) error {
+	backend, err := backendOf[storage.ConditionsBackend](f.ds, "WriteWithConditions")
+	if err != nil {
+		return err
+	}
+
+	return backend.WriteWithConditions(ctx, store, deletes, writes, conditions)
+}
+
+func (f forwardedBackends) ReadTupleCondition(ctx context.Context, store string, tk *openfgav1.TupleKey) (*condition.TupleCondition, error) {
+	backend, err := backendOf[storage.ConditionsBackend](f.ds, "ReadTupleCondition")
+	if err != nil {
+		return nil, err
+	}
+
+	return backend.ReadTupleCondition(ctx, store, tk)
+}
+
+func (f forwardedBackends) ReadTupleConditions(ctx context.Context, store string, tks []*openfgav1.TupleKey) (map[string]*condition.TupleCondition, error) {
+	backend, err := backendOf[storage.ConditionsBackend](f.ds, "ReadTupleConditions")
+	if err != nil {
+		return nil, err
+	}
+
+	return backend.ReadTupleConditions(ctx, store, tks)
+}
+
+func (f forwardedBackends) WriteWithExpirations(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, expirations map[string]time.Time) error {
+	backend, err := backendOf[storage.TupleExpirationBackend](f.ds, "WriteWithExpirations")
+	if err != nil {
+		return err
+	}
+
+	return backend.WriteWithExpirations(ctx, store, deletes, writes, expirations)
+}
+
+func (f forwardedBackends) DeleteExpiredTuples(ctx context.Context, before time.Time) (int, error) {
+	backend, err := backendOf[storage.TupleExpirationBackend](f.ds, "DeleteExpiredTuples")
+	if err != nil {
+		return 0, err
+	}
+
+	return backend.DeleteExpiredTuples(ctx, before)
+}
+
+func (f forwardedBackends) WriteWithPreconditions(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, preconditions []storage.Precondition) error {
+	backend, err := backendOf[storage.PreconditionsBackend](f.ds, "WriteWithPreconditions")
+	if err != nil {
+		return err
+	}
+
+	return backend.WriteWithPreconditions(ctx, store, deletes, writes, preconditions)
+}
+
+func (f forwardedBackends) RelationStats(ctx context.Context, store, objectType, relation string) (storage.TupleStats, error) {
+	backend, err := backendOf[storage.StatsProvider](f.ds, "RelationStats")
+	if err != nil {
+		return storage.TupleStats{}, err
+	}
+
+	return backend.RelationStats(ctx, store, objectType, relation)
+}
+
+func (f forwardedBackends) StoreStats(ctx context.Context, store string) (storage.TupleStats, error) {
+	backend, err := backendOf[storage.StatsProvider](f.ds, "StoreStats")
+	if err != nil {
+		return storage.TupleStats{}, err
+	}
+
+	return backend.StoreStats(ctx, store)
+}
+
+func (f forwardedBackends) SampleTuples(ctx context.Context, store string, filter *openfgav1.TupleKey, size int) ([]*openfgav1.TupleKey, error) {
+	backend, err := backendOf[storage.TupleSampler](f.ds, "SampleTuples")
+	if err != nil {
+		return nil, err
+	}
+
+	return backend.SampleTuples(ctx, store, filter, size)
+}
+
+// MaintenanceTasks returns the maintenance tasks of the datastore, if it has any.
+func (f forwardedBackends) MaintenanceTasks() []storage.MaintenanceTask {
+	if maintainer, ok := f.ds.(storage.Maintainer); ok {
+		return maintainer.MaintenanceTasks()
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package storagewrappers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/condition"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/stretchr/testify/require"
+)
+
+// hiddenBackendsDatastore hides the optional backends of the datastore it embeds.
+type hiddenBackendsDatastore struct {
+	storage.OpenFGADatastore
+}
+
+func TestBackendsAreForwarded(t *testing.T) {
+	ctx := context.Background()
+
+	primary := memory.New()
+	shadow := memory.New()
+
+	ds := NewCachedOpenFGADatastore(
+		NewContextWrapper(
+			NewCircuitBreakerDatastore(
+				NewResidencyDatastore("local",
+					NewRoutingDatastore(
+						NewReplicaRoutingDatastore(
+							NewShadowDatastore(primary, shadow),
+							[]storage.OpenFGADatastore{memory.New()},
+						),
+					),
+				),
+			),
+		), 10)
+	defer ds.Close()
+
+	conditions, ok := storage.As[storage.ConditionsBackend](ds)
+	require.True(t, ok)
+
+	expirations, ok := storage.As[storage.TupleExpirationBackend](ds)
+	require.True(t, ok)
+
+	preconditions, ok := storage.As[storage.PreconditionsBackend](ds)
+	require.True(t, ok)
+
+	_, ok = storage.As[storage.StatsProvider](ds)
+	require.True(t, ok)
+
+	_, ok = storage.As[storage.TupleSampler](ds)
+	require.True(t, ok)
+
+	maintainer, ok := storage.As[storage.Maintainer](ds)
+	require.True(t, ok)
+	require.Len(t, maintainer.MaintenanceTasks(), len(primary.(storage.Maintainer).MaintenanceTasks()))
+
+	store := ulid.Make().String()
+	tk := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+	expected := &condition.TupleCondition{Name: "ip_allowed"}
+
+	err := conditions.WriteWithConditions(ctx, store, nil, []*openfgav1.TupleKey{tk}, map[string]*condition.TupleCondition{
+		tuple.TupleKeyToString(tk): expected,
+	})
+	require.NoError(t, err)
+
+	tupleCondition, err := conditions.ReadTupleCondition(ctx, store, tk)
+	require.NoError(t, err)
+	require.Equal(t, expected, tupleCondition)
+
+	// the write is mirrored to the shadow
+	tupleCondition, err = shadow.(storage.ConditionsBackend).ReadTupleCondition(ctx, store, tk)
+	require.NoError(t, err)
+	require.Equal(t, expected, tupleCondition)
+
+	expiring := tuple.NewTupleKey("document:1", "viewer", "user:bob")
+	err = expirations.WriteWithExpirations(ctx, store, nil, []*openfgav1.TupleKey{expiring}, map[string]time.Time{
+		tuple.TupleKeyToString(expiring): time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	_, err = shadow.ReadUserTuple(ctx, store, expiring, storage.ReadOptions{})
+	require.NoError(t, err)
+
+	deleted, err := expirations.DeleteExpiredTuples(ctx, time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 1, deleted)
+
+	err = preconditions.WriteWithPreconditions(ctx, store, nil, []*openfgav1.TupleKey{expiring}, []storage.Precondition{{TupleKey: tk, Exists: false}})
+	require.ErrorIs(t, err, storage.ErrPreconditionFailed)
+
+	err = preconditions.WriteWithPreconditions(ctx, store, nil, []*openfgav1.TupleKey{expiring}, []storage.Precondition{{TupleKey: tk, Exists: true}})
+	require.NoError(t, err)
+
+	_, err = shadow.ReadUserTuple(ctx, store, expiring, storage.ReadOptions{})
+	require.NoError(t, err)
+}
+
+func TestBackendsOfHiddenDatastore(t *testing.T) {
+	ds := NewContextWrapper(&hiddenBackendsDatastore{memory.New()})
+	defer ds.Close()
+
+	_, ok := storage.As[storage.ConditionsBackend](ds)
+	require.False(t, ok)
+
+	_, err := ds.ReadTupleCondition(context.Background(), ulid.Make().String(), tuple.NewTupleKey("document:1", "viewer", "user:anne"))
+	require.ErrorIs(t, err, storage.ErrUnsupported)
+	require.Empty(t, ds.MaintenanceTasks())
+}
@@ -43,40 +43,41 @@ func NewBoundedConcurrencyTupleReader(wrapped storage.RelationshipTupleReader, c
 	}
 }
 
-func (b *boundedConcurrencyTupleReader) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+func (b *boundedConcurrencyTupleReader) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (*openfgav1.Tuple, error) {
 	b.waitForLimiter(ctx)
 
 	defer func() {
 		<-b.limiter
 	}()
 
-	return b.RelationshipTupleReader.ReadUserTuple(ctx, store, tupleKey)
+	return b.RelationshipTupleReader.ReadUserTuple(ctx, store, tupleKey, options)
 }
 
-func (b *boundedConcurrencyTupleReader) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (storage.TupleIterator, error) {
+func (b *boundedConcurrencyTupleReader) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (storage.TupleIterator, error) {
 	b.waitForLimiter(ctx)
 
 	defer func() {
 		<-b.limiter
 	}()
 
-	return b.RelationshipTupleReader.Read(ctx, store, tupleKey)
+	return b.RelationshipTupleReader.Read(ctx, store, tupleKey, options)
 }
 
-func (b *boundedConcurrencyTupleReader) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter) (storage.TupleIterator, error) {
+func (b *boundedConcurrencyTupleReader) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter, options storage.ReadOptions) (storage.TupleIterator, error) {
 	b.waitForLimiter(ctx)
 
 	defer func() {
 		<-b.limiter
 	}()
 
-	return b.RelationshipTupleReader.ReadUsersetTuples(ctx, store, filter)
+	return b.RelationshipTupleReader.ReadUsersetTuples(ctx, store, filter, options)
 }
 
 func (b *boundedConcurrencyTupleReader) ReadStartingWithUser(
 	ctx context.Context,
 	store string,
 	filter storage.ReadStartingWithUserFilter,
+	options storage.ReadOptions,
 ) (storage.TupleIterator, error) {
 	b.waitForLimiter(ctx)
 
@@ -84,7 +85,7 @@ func (b *boundedConcurrencyTupleReader) ReadStartingWithUser(
 		<-b.limiter
 	}()
 
-	return b.RelationshipTupleReader.ReadStartingWithUser(ctx, store, filter)
+	return b.RelationshipTupleReader.ReadStartingWithUser(ctx, store, filter, options)
 }
 
 func (b *boundedConcurrencyTupleReader) waitForLimiter(ctx context.Context) {
@@ -36,7 +36,7 @@ func TestBoundedConcurrencyWrapper(t *testing.T) {
 	start := time.Now()
 
 	go func() {
-		_, err := limitedTupleReader.ReadUserTuple(context.Background(), store, tuple.NewTupleKey("obj:1", "viewer", "user:anne"))
+		_, err := limitedTupleReader.ReadUserTuple(context.Background(), store, tuple.NewTupleKey("obj:1", "viewer", "user:anne"), storage.ReadOptions{})
 		require.NoError(t, err)
 		wg.Done()
 	}()
@@ -45,13 +45,13 @@ func TestBoundedConcurrencyWrapper(t *testing.T) {
 		_, err := limitedTupleReader.ReadUsersetTuples(context.Background(), store, storage.ReadUsersetTuplesFilter{
 			Object:   "obj:1",
 			Relation: "viewer",
-		})
+		}, storage.ReadOptions{})
 		require.NoError(t, err)
 		wg.Done()
 	}()
 
 	go func() {
-		_, err := limitedTupleReader.Read(context.Background(), store, nil)
+		_, err := limitedTupleReader.Read(context.Background(), store, nil, storage.ReadOptions{})
 		require.NoError(t, err)
 		wg.Done()
 	}()
@@ -66,7 +66,7 @@ func TestBoundedConcurrencyWrapper(t *testing.T) {
 						Object:   "obj",
 						Relation: "viewer",
 					},
-				}})
+				}}, storage.ReadOptions{})
 		require.NoError(t, err)
 		wg.Done()
 	}()
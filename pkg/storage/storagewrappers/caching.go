@@ -3,63 +3,129 @@ package storagewrappers
 
 import (
 	"context"
-	"fmt"
+	"strconv"
 	"time"
 
-	"github.com/karlseguin/ccache/v3"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/cachestats"
+	"github.com/openfga/openfga/pkg/events"
 	"github.com/openfga/openfga/pkg/storage"
-	"golang.org/x/sync/singleflight"
+	"github.com/openfga/openfga/pkg/storage/cached"
 )
 
-const ttl = time.Hour * 168
-
-var _ storage.OpenFGADatastore = (*cachedOpenFGADatastore)(nil)
+var (
+	_ storage.OpenFGADatastore       = (*cachedOpenFGADatastore)(nil)
+	_ storage.DatastoreWrapper       = (*cachedOpenFGADatastore)(nil)
+	_ storage.ConditionsBackend      = (*cachedOpenFGADatastore)(nil)
+	_ storage.TupleExpirationBackend = (*cachedOpenFGADatastore)(nil)
+	_ storage.PreconditionsBackend   = (*cachedOpenFGADatastore)(nil)
+	_ storage.TupleSampler           = (*cachedOpenFGADatastore)(nil)
+	_ storage.StatsProvider          = (*cachedOpenFGADatastore)(nil)
+	_ storage.Maintainer             = (*cachedOpenFGADatastore)(nil)
+)
 
 type cachedOpenFGADatastore struct {
 	storage.OpenFGADatastore
-	lookupGroup singleflight.Group
-	cache       *ccache.Cache[*openfgav1.AuthorizationModel]
+	forwardedBackends
+	models *cached.AuthorizationModelBackend
+
+	// latestModelIDTTL is the TTL of the IDs of the latest models of the stores, 0 not caching them
+	latestModelIDTTL time.Duration
+
+	bus         *events.Bus
+	unsubscribe func()
 }
 
-// NewCachedOpenFGADatastore returns a wrapper over a datastore that caches up to maxSize *openfgav1.AuthorizationModel
-// on every call to storage.ReadAuthorizationModel.
-func NewCachedOpenFGADatastore(inner storage.OpenFGADatastore, maxSize int) *cachedOpenFGADatastore {
-	return &cachedOpenFGADatastore{
-		OpenFGADatastore: inner,
-		cache:            ccache.New(ccache.Configure[*openfgav1.AuthorizationModel]().MaxSize(int64(maxSize))),
+type CachedOpenFGADatastoreOpt func(*cachedOpenFGADatastore)
+
+// WithCacheEvents subscribes the cache to the events of the bus, to invalidate the cached authorization models
+// of the deleted stores. Every invalidation is published on the bus as an events.CacheInvalidated event.
+func WithCacheEvents(bus *events.Bus) CachedOpenFGADatastoreOpt {
+	return func(c *cachedOpenFGADatastore) {
+		c.bus = bus
 	}
 }
 
-func (c *cachedOpenFGADatastore) ReadAuthorizationModel(ctx context.Context, storeID, modelID string) (*openfgav1.AuthorizationModel, error) {
-	cacheKey := fmt.Sprintf("%s:%s", storeID, modelID)
-	cachedEntry := c.cache.Get(cacheKey)
+// WithLatestModelIDCacheTTL caches the ID of the latest authorization model of the stores for the TTL. The ID
+// cached for a store is invalidated when a model is written to, or the store is deleted through, the
+// datastore, but the models written by other servers are only seen once the TTL expires. 0 disables the
+// cache.
+func WithLatestModelIDCacheTTL(ttl time.Duration) CachedOpenFGADatastoreOpt {
+	return func(c *cachedOpenFGADatastore) {
+		c.latestModelIDTTL = ttl
+	}
+}
 
-	if cachedEntry != nil {
-		return cachedEntry.Value(), nil
+// NewCachedOpenFGADatastore returns a wrapper over a datastore that caches up to maxSize *openfgav1.AuthorizationModel
+// on every call to storage.ReadAuthorizationModel (see cached.AuthorizationModelBackend).
+func NewCachedOpenFGADatastore(inner storage.OpenFGADatastore, maxSize int, opts ...CachedOpenFGADatastoreOpt) *cachedOpenFGADatastore {
+	c := &cachedOpenFGADatastore{
+		OpenFGADatastore:  inner,
+		forwardedBackends: forwardedBackends{inner},
 	}
 
-	model, err := c.OpenFGADatastore.ReadAuthorizationModel(ctx, storeID, modelID)
-	if err != nil {
-		return nil, err
+	for _, opt := range opts {
+		opt(c)
 	}
 
-	c.cache.Set(cacheKey, model, ttl) // these are immutable, once created, there cannot be edits, therefore they can be cached without ttl
+	c.models = cached.NewAuthorizationModelBackend(inner,
+		cached.WithMaxSize(maxSize),
+		cached.WithLatestModelIDTTL(c.latestModelIDTTL),
+	)
+
+	if c.bus != nil {
+		c.unsubscribe = c.bus.Subscribe(c.handleStoreDeleted, events.StoreDeleted)
+	}
 
-	return model, nil
+	return c
 }
 
-func (c *cachedOpenFGADatastore) FindLatestAuthorizationModelID(ctx context.Context, storeID string) (string, error) {
-	v, err, _ := c.lookupGroup.Do(fmt.Sprintf("FindLatestAuthorizationModelID:%s", storeID), func() (interface{}, error) {
-		return c.OpenFGADatastore.FindLatestAuthorizationModelID(ctx, storeID)
+func (c *cachedOpenFGADatastore) handleStoreDeleted(ctx context.Context, event events.Event) {
+	invalidated := c.models.InvalidateStore(event.StoreID)
+
+	c.bus.Publish(ctx, events.Event{
+		Type:    events.CacheInvalidated,
+		StoreID: event.StoreID,
+		Attributes: map[string]string{
+			"cache":       cached.ModelCacheName,
+			"invalidated": strconv.Itoa(invalidated),
+		},
 	})
-	if err != nil {
-		return "", err
-	}
-	return v.(string), nil
+}
+
+func (c *cachedOpenFGADatastore) ReadAuthorizationModel(ctx context.Context, storeID, modelID string) (*openfgav1.AuthorizationModel, error) {
+	return c.models.ReadAuthorizationModel(ctx, storeID, modelID)
+}
+
+func (c *cachedOpenFGADatastore) FindLatestAuthorizationModelID(ctx context.Context, storeID string) (string, error) {
+	return c.models.FindLatestAuthorizationModelID(ctx, storeID)
+}
+
+func (c *cachedOpenFGADatastore) WriteAuthorizationModel(ctx context.Context, storeID string, model *openfgav1.AuthorizationModel) error {
+	return c.models.WriteAuthorizationModel(ctx, storeID, model)
+}
+
+func (c *cachedOpenFGADatastore) DeleteStore(ctx context.Context, storeID string) error {
+	defer c.models.InvalidateLatestModelID(storeID)
+
+	return c.OpenFGADatastore.DeleteStore(ctx, storeID)
+}
+
+// Unwrap See storage.DatastoreWrapper.Unwrap
+func (c *cachedOpenFGADatastore) Unwrap() storage.OpenFGADatastore {
+	return c.OpenFGADatastore
+}
+
+// Caches returns the caches of the authorization models, to report their statistics and to flush them.
+func (c *cachedOpenFGADatastore) Caches() []cachestats.Cache {
+	return c.models.Caches()
 }
 
 func (c *cachedOpenFGADatastore) Close() {
-	c.cache.Stop()
+	if c.unsubscribe != nil {
+		c.unsubscribe()
+	}
+
+	c.models.Close()
 	c.OpenFGADatastore.Close()
 }
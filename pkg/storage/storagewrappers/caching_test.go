@@ -2,8 +2,8 @@ package storagewrappers
 
 import (
 	"context"
-	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -11,14 +11,27 @@ import (
 	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	mockstorage "github.com/openfga/openfga/internal/mocks"
+	"github.com/openfga/openfga/pkg/events"
+	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/storage/memory"
 	"github.com/openfga/openfga/pkg/typesystem"
 	"github.com/stretchr/testify/require"
 )
 
+// modelReadsCountingDatastore counts the reads of the authorization models of the datastore it wraps.
+type modelReadsCountingDatastore struct {
+	storage.OpenFGADatastore
+	reads atomic.Int32
+}
+
+func (d *modelReadsCountingDatastore) ReadAuthorizationModel(ctx context.Context, storeID, modelID string) (*openfgav1.AuthorizationModel, error) {
+	d.reads.Add(1)
+	return d.OpenFGADatastore.ReadAuthorizationModel(ctx, storeID, modelID)
+}
+
 func TestReadAuthorizationModel(t *testing.T) {
 	ctx := context.Background()
-	memoryBackend := memory.New()
+	memoryBackend := &modelReadsCountingDatastore{OpenFGADatastore: memory.New()}
 	cachingBackend := NewCachedOpenFGADatastore(memoryBackend, 5)
 	defer cachingBackend.Close()
 
@@ -44,15 +57,13 @@ func TestReadAuthorizationModel(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, model, gotModel)
 
-	// check what's stored inside the cache
-	modelKey := fmt.Sprintf("%s:%s", storeID, model.Id)
-	cachedModel := cachingBackend.cache.Get(modelKey).Value()
-	require.Equal(t, model, cachedModel)
+	require.Equal(t, int32(1), memoryBackend.reads.Load())
 
 	// check that second hit to cache -> hit
 	gotModel, err = cachingBackend.ReadAuthorizationModel(ctx, storeID, model.Id)
 	require.NoError(t, err)
 	require.Equal(t, model, gotModel)
+	require.Equal(t, int32(1), memoryBackend.reads.Load())
 }
 
 func TestSingleFlightFindLatestAuthorizationModelID(t *testing.T) {
@@ -83,3 +94,97 @@ func TestSingleFlightFindLatestAuthorizationModelID(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestCacheInvalidationOnStoreDeleted(t *testing.T) {
+	ctx := context.Background()
+	bus := events.NewBus()
+	defer bus.Close()
+
+	memoryBackend := &modelReadsCountingDatastore{OpenFGADatastore: memory.New()}
+	cachingBackend := NewCachedOpenFGADatastore(memoryBackend, 5, WithCacheEvents(bus))
+	defer cachingBackend.Close()
+
+	var mu sync.Mutex
+	var invalidations []events.Event
+	unsubscribe := bus.Subscribe(func(ctx context.Context, event events.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		invalidations = append(invalidations, event)
+	}, events.CacheInvalidated)
+
+	model := &openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: []*openfgav1.TypeDefinition{
+			{Type: "user"},
+		},
+	}
+	deletedStoreID := ulid.Make().String()
+	otherStoreID := ulid.Make().String()
+
+	for _, storeID := range []string{deletedStoreID, otherStoreID} {
+		err := memoryBackend.WriteAuthorizationModel(ctx, storeID, model)
+		require.NoError(t, err)
+
+		_, err = cachingBackend.ReadAuthorizationModel(ctx, storeID, model.Id)
+		require.NoError(t, err)
+	}
+
+	bus.Publish(ctx, events.Event{Type: events.StoreDeleted, StoreID: deletedStoreID})
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(invalidations) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	unsubscribe()
+
+	require.Equal(t, deletedStoreID, invalidations[0].StoreID)
+	require.Equal(t, map[string]string{"cache": "authorization_models", "invalidated": "1"}, invalidations[0].Attributes)
+
+	// only the model of the deleted store is read from the datastore again
+	reads := memoryBackend.reads.Load()
+	for _, storeID := range []string{deletedStoreID, otherStoreID} {
+		_, err := cachingBackend.ReadAuthorizationModel(ctx, storeID, model.Id)
+		require.NoError(t, err)
+	}
+	require.Equal(t, reads+1, memoryBackend.reads.Load())
+}
+
+func TestLatestModelIDCache(t *testing.T) {
+	ctx := context.Background()
+	memoryBackend := memory.New()
+	cachingBackend := NewCachedOpenFGADatastore(memoryBackend, 5, WithLatestModelIDCacheTTL(time.Hour))
+	defer cachingBackend.Close()
+
+	newModel := func() *openfgav1.AuthorizationModel {
+		return &openfgav1.AuthorizationModel{
+			Id:              ulid.Make().String(),
+			SchemaVersion:   typesystem.SchemaVersion1_1,
+			TypeDefinitions: []*openfgav1.TypeDefinition{{Type: "user"}},
+		}
+	}
+	storeID := ulid.Make().String()
+
+	first := newModel()
+	require.NoError(t, cachingBackend.WriteAuthorizationModel(ctx, storeID, first))
+
+	latestID, err := cachingBackend.FindLatestAuthorizationModelID(ctx, storeID)
+	require.NoError(t, err)
+	require.Equal(t, first.GetId(), latestID)
+
+	// the models written through another server are only seen once the TTL expires
+	require.NoError(t, memoryBackend.WriteAuthorizationModel(ctx, storeID, newModel()))
+
+	latestID, err = cachingBackend.FindLatestAuthorizationModelID(ctx, storeID)
+	require.NoError(t, err)
+	require.Equal(t, first.GetId(), latestID)
+
+	// while the models written through the datastore invalidate it
+	third := newModel()
+	require.NoError(t, cachingBackend.WriteAuthorizationModel(ctx, storeID, third))
+
+	latestID, err = cachingBackend.FindLatestAuthorizationModelID(ctx, storeID)
+	require.NoError(t, err)
+	require.Equal(t, third.GetId(), latestID)
+}
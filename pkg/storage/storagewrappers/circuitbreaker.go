@@ -0,0 +1,558 @@
+package storagewrappers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/condition"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultCircuitBreakerErrorRateThreshold = 0.5
+	defaultCircuitBreakerMinRequests        = 20
+	defaultCircuitBreakerWindow             = 10 * time.Second
+	defaultCircuitBreakerOpenDuration       = 30 * time.Second
+)
+
+var circuitBreakerOpenedCounter = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "datastore_circuit_breaker_opened_total",
+	Help: "The total number of times the circuit breaker of a store opened because of the error rate of its datastore operations.",
+})
+
+var (
+	_ storage.OpenFGADatastore       = (*circuitBreakerOpenFGADatastore)(nil)
+	_ storage.DatastoreWrapper       = (*circuitBreakerOpenFGADatastore)(nil)
+	_ storage.ConditionsBackend      = (*circuitBreakerOpenFGADatastore)(nil)
+	_ storage.TupleExpirationBackend = (*circuitBreakerOpenFGADatastore)(nil)
+	_ storage.PreconditionsBackend   = (*circuitBreakerOpenFGADatastore)(nil)
+	_ storage.TupleSampler           = (*circuitBreakerOpenFGADatastore)(nil)
+	_ storage.StatsProvider          = (*circuitBreakerOpenFGADatastore)(nil)
+	_ storage.Maintainer             = (*circuitBreakerOpenFGADatastore)(nil)
+)
+
+// storeCircuit is the state of the circuit breaker of a store. The circuit is closed while openUntil is zero.
+type storeCircuit struct {
+	windowStart time.Time
+	requests    int
+	failures    int
+
+	openUntil time.Time
+
+	// probing tells whether the operation that probes the datastore after the circuit was open is in flight
+	probing bool
+}
+
+// circuitBreakerOpenFGADatastore is a datastore that fails fast the operations of the stores whose datastore
+// operations fail too often, e.g. because the shard of their data is broken, so that they don't tie up the
+// server while the other stores keep being served.
+type circuitBreakerOpenFGADatastore struct {
+	storage.OpenFGADatastore
+	backends forwardedBackends
+	logger   logger.Logger
+
+	errorRateThreshold float64
+	minRequests        int
+	window             time.Duration
+	openDuration       time.Duration
+	now                func() time.Time
+
+	mu        sync.Mutex
+	circuits  map[string]*storeCircuit
+	lastSweep time.Time
+}
+
+type CircuitBreakerDatastoreOption func(c *circuitBreakerOpenFGADatastore)
+
+// WithCircuitBreakerErrorRateThreshold sets the rate (between 0 and 1) of the datastore operations of a store
+// that fail over a window above which the circuit of the store opens.
+func WithCircuitBreakerErrorRateThreshold(threshold float64) CircuitBreakerDatastoreOption {
+	return func(c *circuitBreakerOpenFGADatastore) {
+		c.errorRateThreshold = threshold
+	}
+}
+
+// WithCircuitBreakerMinRequests sets the minimum number of datastore operations of a store over a window
+// before its error rate can open its circuit.
+func WithCircuitBreakerMinRequests(minRequests int) CircuitBreakerDatastoreOption {
+	return func(c *circuitBreakerOpenFGADatastore) {
+		c.minRequests = minRequests
+	}
+}
+
+// WithCircuitBreakerWindow sets the duration of the windows the error rates are measured over.
+func WithCircuitBreakerWindow(window time.Duration) CircuitBreakerDatastoreOption {
+	return func(c *circuitBreakerOpenFGADatastore) {
+		c.window = window
+	}
+}
+
+// WithCircuitBreakerOpenDuration sets the duration a circuit stays open before the datastore is probed.
+func WithCircuitBreakerOpenDuration(duration time.Duration) CircuitBreakerDatastoreOption {
+	return func(c *circuitBreakerOpenFGADatastore) {
+		c.openDuration = duration
+	}
+}
+
+// WithCircuitBreakerLogger sets the logger the circuits opening and closing are logged with.
+func WithCircuitBreakerLogger(logger logger.Logger) CircuitBreakerDatastoreOption {
+	return func(c *circuitBreakerOpenFGADatastore) {
+		c.logger = logger
+	}
+}
+
+// NewCircuitBreakerDatastore returns a datastore with a circuit breaker per store. Once the rate of the
+// datastore operations of a store that fail over a window exceeds the threshold, the circuit of the store
+// opens: its operations fail with storage.ErrStoreUnavailable, without reaching the datastore, for the open
+// duration. The next operation then probes the datastore, while the others keep failing, and the circuit
+// closes if the probe succeeds, or opens again if it fails.
+//
+// The operations that fail because of the request (e.g. storage.ErrInvalidWriteInput, or any error once the
+// context of the request is done) aren't failures of the datastore. A probe that fails because of the request
+// doesn't close the circuit, and the next operation probes the datastore again. The operations that aren't of a
+// store, e.g. ListStores or the maintenance tasks, are never failed fast.
+func NewCircuitBreakerDatastore(wrapped storage.OpenFGADatastore, opts ...CircuitBreakerDatastoreOption) storage.OpenFGADatastore {
+	c := &circuitBreakerOpenFGADatastore{
+		OpenFGADatastore:   wrapped,
+		backends:           forwardedBackends{wrapped},
+		logger:             logger.NewNoopLogger(),
+		errorRateThreshold: defaultCircuitBreakerErrorRateThreshold,
+		minRequests:        defaultCircuitBreakerMinRequests,
+		window:             defaultCircuitBreakerWindow,
+		openDuration:       defaultCircuitBreakerOpenDuration,
+		now:                time.Now,
+		circuits:           map[string]*storeCircuit{},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// operationOutcome is the outcome of a datastore operation, as far as the health of the datastore goes.
+type operationOutcome int
+
+const (
+	// operationServed is an operation the datastore served, which shows it's healthy.
+	operationServed operationOutcome = iota
+
+	// operationFailed is an operation the datastore failed.
+	operationFailed
+
+	// operationRequestError is an operation that failed because of the request, which doesn't show whether the
+	// datastore is healthy.
+	operationRequestError
+)
+
+// outcome returns the outcome of an operation made with the context from its error. The errors of the operations
+// whose context is done are caused by the request, e.g. by its deadline, rather than by the datastore.
+func outcome(ctx context.Context, err error) operationOutcome {
+	if err == nil || errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrIteratorDone) {
+		return operationServed
+	}
+
+	if ctx.Err() != nil {
+		return operationRequestError
+	}
+
+	for _, requestErr := range []error{
+		storage.ErrCollision,
+		storage.ErrInvalidContinuationToken,
+		storage.ErrInvalidWriteInput,
+		storage.ErrTransactionalWriteFailed,
+		storage.ErrMismatchObjectType,
+		storage.ErrExceededWriteBatchLimit,
+		storage.ErrCancelled,
+		storage.ErrUnknownResidency,
+		storage.ErrCrossRegionRead,
+		storage.ErrStoreUnavailable,
+		storage.ErrPreconditionFailed,
+		storage.ErrUnsupported,
+		context.Canceled,
+	} {
+		if errors.Is(err, requestErr) {
+			return operationRequestError
+		}
+	}
+
+	return operationFailed
+}
+
+// allow returns a storage.StoreUnavailableError if the circuit of the store is open, with the time left until
+// it's probed. Otherwise, it reports whether the operation probes the datastore after the circuit was open.
+func (c *circuitBreakerOpenFGADatastore) allow(store string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	circuit, ok := c.circuits[store]
+	if !ok || circuit.openUntil.IsZero() {
+		return false, nil
+	}
+
+	if now := c.now(); now.Before(circuit.openUntil) || circuit.probing {
+		// the time left is 0 while the probe is in flight, which the clients are hinted to retry shortly after
+		return false, &storage.StoreUnavailableError{Store: store, RetryAfter: max(circuit.openUntil.Sub(now), 0)}
+	}
+
+	circuit.probing = true
+	return true, nil
+}
+
+// record records the outcome of an operation of the store, and opens or closes its circuit accordingly.
+func (c *circuitBreakerOpenFGADatastore) record(store string, probe bool, outcome operationOutcome, err error) {
+	failure := outcome == operationFailed
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.sweep(now)
+
+	circuit, ok := c.circuits[store]
+	if !ok {
+		if !failure {
+			// the healthy stores aren't tracked until they fail
+			return
+		}
+
+		circuit = &storeCircuit{windowStart: now}
+		c.circuits[store] = circuit
+	}
+
+	if probe {
+		circuit.probing = false
+		switch outcome {
+		case operationFailed:
+			circuit.openUntil = now.Add(c.openDuration)
+			return
+		case operationRequestError:
+			// the circuit stays open, and the next operation probes the datastore again
+			return
+		}
+
+		delete(c.circuits, store)
+		c.logger.Info("datastore circuit breaker closed", zap.String("store_id", store))
+		return
+	}
+
+	if !circuit.openUntil.IsZero() {
+		// the operation was in flight when the circuit opened
+		return
+	}
+
+	if now.Sub(circuit.windowStart) >= c.window {
+		circuit.windowStart = now
+		circuit.requests = 0
+		circuit.failures = 0
+	}
+
+	circuit.requests++
+	if failure {
+		circuit.failures++
+	}
+
+	if circuit.requests >= c.minRequests && float64(circuit.failures) >= c.errorRateThreshold*float64(circuit.requests) {
+		circuit.openUntil = now.Add(c.openDuration)
+		circuitBreakerOpenedCounter.Inc()
+		c.logger.Warn("datastore circuit breaker opened",
+			zap.String("store_id", store),
+			zap.Int("failures", circuit.failures),
+			zap.Int("requests", circuit.requests),
+			zap.Error(err))
+	}
+}
+
+// sweep forgets the closed circuits whose window is over, once per window. The caller must hold the lock.
+func (c *circuitBreakerOpenFGADatastore) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < c.window {
+		return
+	}
+	c.lastSweep = now
+
+	for store, circuit := range c.circuits {
+		if circuit.openUntil.IsZero() && now.Sub(circuit.windowStart) >= c.window {
+			delete(c.circuits, store)
+		}
+	}
+}
+
+// guard runs the operation of the store, made with the context, through its circuit breaker.
+func guard[T any](ctx context.Context, c *circuitBreakerOpenFGADatastore, store string, operation func() (T, error)) (T, error) {
+	probe, err := c.allow(store)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	result, err := operation()
+	c.record(store, probe, outcome(ctx, err), err)
+
+	return result, err
+}
+
+// guardIterator runs the operation of the store that returns an iterator through its circuit breaker, and
+// records the failures of the iterator too.
+func (c *circuitBreakerOpenFGADatastore) guardIterator(ctx context.Context, store string, operation func() (storage.TupleIterator, error)) (storage.TupleIterator, error) {
+	iter, err := guard(ctx, c, store, operation)
+	if err != nil {
+		return nil, err
+	}
+
+	return &circuitBreakerTupleIterator{TupleIterator: iter, breaker: c, ctx: ctx, store: store}, nil
+}
+
+type circuitBreakerTupleIterator struct {
+	storage.TupleIterator
+	breaker *circuitBreakerOpenFGADatastore
+	ctx     context.Context
+	store   string
+}
+
+func (i *circuitBreakerTupleIterator) Next() (*openfgav1.Tuple, error) {
+	t, err := i.TupleIterator.Next()
+	if outcome(i.ctx, err) == operationFailed {
+		i.breaker.record(i.store, false, operationFailed, err)
+	}
+
+	return t, err
+}
+
+func (c *circuitBreakerOpenFGADatastore) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (storage.TupleIterator, error) {
+	return c.guardIterator(ctx, store, func() (storage.TupleIterator, error) {
+		return c.OpenFGADatastore.Read(ctx, store, tupleKey, options)
+	})
+}
+
+func (c *circuitBreakerOpenFGADatastore) ReadPage(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, opts storage.PaginationOptions, options storage.ReadOptions) ([]*openfgav1.Tuple, []byte, error) {
+	var token []byte
+	tuples, err := guard(ctx, c, store, func() ([]*openfgav1.Tuple, error) {
+		var tuples []*openfgav1.Tuple
+		var err error
+		tuples, token, err = c.OpenFGADatastore.ReadPage(ctx, store, tupleKey, opts, options)
+		return tuples, err
+	})
+
+	return tuples, token, err
+}
+
+func (c *circuitBreakerOpenFGADatastore) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (*openfgav1.Tuple, error) {
+	return guard(ctx, c, store, func() (*openfgav1.Tuple, error) {
+		return c.OpenFGADatastore.ReadUserTuple(ctx, store, tupleKey, options)
+	})
+}
+
+func (c *circuitBreakerOpenFGADatastore) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter, options storage.ReadOptions) (storage.TupleIterator, error) {
+	return c.guardIterator(ctx, store, func() (storage.TupleIterator, error) {
+		return c.OpenFGADatastore.ReadUsersetTuples(ctx, store, filter, options)
+	})
+}
+
+func (c *circuitBreakerOpenFGADatastore) ReadStartingWithUser(ctx context.Context, store string, filter storage.ReadStartingWithUserFilter, options storage.ReadOptions) (storage.TupleIterator, error) {
+	return c.guardIterator(ctx, store, func() (storage.TupleIterator, error) {
+		return c.OpenFGADatastore.ReadStartingWithUser(ctx, store, filter, options)
+	})
+}
+
+func (c *circuitBreakerOpenFGADatastore) Write(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes) error {
+	_, err := guard(ctx, c, store, func() (struct{}, error) {
+		return struct{}{}, c.OpenFGADatastore.Write(ctx, store, deletes, writes)
+	})
+
+	return err
+}
+
+func (c *circuitBreakerOpenFGADatastore) ReadAuthorizationModel(ctx context.Context, store string, id string) (*openfgav1.AuthorizationModel, error) {
+	return guard(ctx, c, store, func() (*openfgav1.AuthorizationModel, error) {
+		return c.OpenFGADatastore.ReadAuthorizationModel(ctx, store, id)
+	})
+}
+
+func (c *circuitBreakerOpenFGADatastore) ReadAuthorizationModels(ctx context.Context, store string, options storage.PaginationOptions) ([]*openfgav1.AuthorizationModel, []byte, error) {
+	var token []byte
+	models, err := guard(ctx, c, store, func() ([]*openfgav1.AuthorizationModel, error) {
+		var models []*openfgav1.AuthorizationModel
+		var err error
+		models, token, err = c.OpenFGADatastore.ReadAuthorizationModels(ctx, store, options)
+		return models, err
+	})
+
+	return models, token, err
+}
+
+func (c *circuitBreakerOpenFGADatastore) FindLatestAuthorizationModelID(ctx context.Context, store string) (string, error) {
+	return guard(ctx, c, store, func() (string, error) {
+		return c.OpenFGADatastore.FindLatestAuthorizationModelID(ctx, store)
+	})
+}
+
+func (c *circuitBreakerOpenFGADatastore) WriteAuthorizationModel(ctx context.Context, store string, model *openfgav1.AuthorizationModel) error {
+	_, err := guard(ctx, c, store, func() (struct{}, error) {
+		return struct{}{}, c.OpenFGADatastore.WriteAuthorizationModel(ctx, store, model)
+	})
+
+	return err
+}
+
+func (c *circuitBreakerOpenFGADatastore) GetStore(ctx context.Context, id string) (*openfgav1.Store, error) {
+	return guard(ctx, c, id, func() (*openfgav1.Store, error) {
+		return c.OpenFGADatastore.GetStore(ctx, id)
+	})
+}
+
+func (c *circuitBreakerOpenFGADatastore) DeleteStore(ctx context.Context, id string) error {
+	_, err := guard(ctx, c, id, func() (struct{}, error) {
+		return struct{}{}, c.OpenFGADatastore.DeleteStore(ctx, id)
+	})
+
+	return err
+}
+
+func (c *circuitBreakerOpenFGADatastore) WriteAssertions(ctx context.Context, store, modelID string, assertions []*openfgav1.Assertion) error {
+	_, err := guard(ctx, c, store, func() (struct{}, error) {
+		return struct{}{}, c.OpenFGADatastore.WriteAssertions(ctx, store, modelID, assertions)
+	})
+
+	return err
+}
+
+func (c *circuitBreakerOpenFGADatastore) ReadAssertions(ctx context.Context, store, modelID string) ([]*openfgav1.Assertion, error) {
+	return guard(ctx, c, store, func() ([]*openfgav1.Assertion, error) {
+		return c.OpenFGADatastore.ReadAssertions(ctx, store, modelID)
+	})
+}
+
+func (c *circuitBreakerOpenFGADatastore) ReadAssertionsHistory(ctx context.Context, store, modelID string) ([]*storage.AssertionsVersion, error) {
+	return guard(ctx, c, store, func() ([]*storage.AssertionsVersion, error) {
+		return c.OpenFGADatastore.ReadAssertionsHistory(ctx, store, modelID)
+	})
+}
+
+func (c *circuitBreakerOpenFGADatastore) DeleteAssertions(ctx context.Context, store, modelID string) error {
+	_, err := guard(ctx, c, store, func() (struct{}, error) {
+		return struct{}{}, c.OpenFGADatastore.DeleteAssertions(ctx, store, modelID)
+	})
+
+	return err
+}
+
+func (c *circuitBreakerOpenFGADatastore) WriteAuthorizationModelLabels(ctx context.Context, store, modelID string, labels map[string]string) error {
+	_, err := guard(ctx, c, store, func() (struct{}, error) {
+		return struct{}{}, c.OpenFGADatastore.WriteAuthorizationModelLabels(ctx, store, modelID, labels)
+	})
+
+	return err
+}
+
+func (c *circuitBreakerOpenFGADatastore) ReadAuthorizationModelLabels(ctx context.Context, store, modelID string) (map[string]string, error) {
+	return guard(ctx, c, store, func() (map[string]string, error) {
+		return c.OpenFGADatastore.ReadAuthorizationModelLabels(ctx, store, modelID)
+	})
+}
+
+func (c *circuitBreakerOpenFGADatastore) FindAuthorizationModelIDByLabel(ctx context.Context, store, key, value string) (string, error) {
+	return guard(ctx, c, store, func() (string, error) {
+		return c.OpenFGADatastore.FindAuthorizationModelIDByLabel(ctx, store, key, value)
+	})
+}
+
+func (c *circuitBreakerOpenFGADatastore) ReadChanges(ctx context.Context, store, objectType string, paginationOptions storage.PaginationOptions, horizonOffset time.Duration, options storage.ReadOptions) ([]*openfgav1.TupleChange, []byte, error) {
+	var token []byte
+	changes, err := guard(ctx, c, store, func() ([]*openfgav1.TupleChange, error) {
+		var changes []*openfgav1.TupleChange
+		var err error
+		changes, token, err = c.OpenFGADatastore.ReadChanges(ctx, store, objectType, paginationOptions, horizonOffset, options)
+		return changes, err
+	})
+
+	return changes, token, err
+}
+
+func (c *circuitBreakerOpenFGADatastore) WriteAuthorizationModelConditions(ctx context.Context, store, modelID string, conditions *condition.ModelConditions) error {
+	_, err := guard(ctx, c, store, func() (struct{}, error) {
+		return struct{}{}, c.backends.WriteAuthorizationModelConditions(ctx, store, modelID, conditions)
+	})
+
+	return err
+}
+
+func (c *circuitBreakerOpenFGADatastore) ReadAuthorizationModelConditions(ctx context.Context, store, modelID string) (*condition.ModelConditions, error) {
+	return guard(ctx, c, store, func() (*condition.ModelConditions, error) {
+		return c.backends.ReadAuthorizationModelConditions(ctx, store, modelID)
+	})
+}
+
+func (c *circuitBreakerOpenFGADatastore) WriteWithConditions(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, conditions map[string]*condition.TupleCondition) error {
+	_, err := guard(ctx, c, store, func() (struct{}, error) {
+		return struct{}{}, c.backends.WriteWithConditions(ctx, store, deletes, writes, conditions)
+	})
+
+	return err
+}
+
+func (c *circuitBreakerOpenFGADatastore) ReadTupleCondition(ctx context.Context, store string, tk *openfgav1.TupleKey) (*condition.TupleCondition, error) {
+	return guard(ctx, c, store, func() (*condition.TupleCondition, error) {
+		return c.backends.ReadTupleCondition(ctx, store, tk)
+	})
+}
+
+func (c *circuitBreakerOpenFGADatastore) ReadTupleConditions(ctx context.Context, store string, tks []*openfgav1.TupleKey) (map[string]*condition.TupleCondition, error) {
+	return guard(ctx, c, store, func() (map[string]*condition.TupleCondition, error) {
+		return c.backends.ReadTupleConditions(ctx, store, tks)
+	})
+}
+
+func (c *circuitBreakerOpenFGADatastore) WriteWithExpirations(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, expirations map[string]time.Time) error {
+	_, err := guard(ctx, c, store, func() (struct{}, error) {
+		return struct{}{}, c.backends.WriteWithExpirations(ctx, store, deletes, writes, expirations)
+	})
+
+	return err
+}
+
+func (c *circuitBreakerOpenFGADatastore) DeleteExpiredTuples(ctx context.Context, before time.Time) (int, error) {
+	return c.backends.DeleteExpiredTuples(ctx, before)
+}
+
+func (c *circuitBreakerOpenFGADatastore) WriteWithPreconditions(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, preconditions []storage.Precondition) error {
+	_, err := guard(ctx, c, store, func() (struct{}, error) {
+		return struct{}{}, c.backends.WriteWithPreconditions(ctx, store, deletes, writes, preconditions)
+	})
+
+	return err
+}
+
+func (c *circuitBreakerOpenFGADatastore) SampleTuples(ctx context.Context, store string, filter *openfgav1.TupleKey, size int) ([]*openfgav1.TupleKey, error) {
+	return guard(ctx, c, store, func() ([]*openfgav1.TupleKey, error) {
+		return c.backends.SampleTuples(ctx, store, filter, size)
+	})
+}
+
+func (c *circuitBreakerOpenFGADatastore) RelationStats(ctx context.Context, store, objectType, relation string) (storage.TupleStats, error) {
+	return guard(ctx, c, store, func() (storage.TupleStats, error) {
+		return c.backends.RelationStats(ctx, store, objectType, relation)
+	})
+}
+
+func (c *circuitBreakerOpenFGADatastore) StoreStats(ctx context.Context, store string) (storage.TupleStats, error) {
+	return guard(ctx, c, store, func() (storage.TupleStats, error) {
+		return c.backends.StoreStats(ctx, store)
+	})
+}
+
+func (c *circuitBreakerOpenFGADatastore) MaintenanceTasks() []storage.MaintenanceTask {
+	return c.backends.MaintenanceTasks()
+}
+
+// Unwrap See storage.DatastoreWrapper.Unwrap
+func (c *circuitBreakerOpenFGADatastore) Unwrap() storage.OpenFGADatastore {
+	return c.OpenFGADatastore
+}
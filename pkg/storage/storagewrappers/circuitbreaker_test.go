@@ -0,0 +1,186 @@
+package storagewrappers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/stretchr/testify/require"
+)
+
+// brokenStoresDatastore fails the reads of the broken stores, with the error if any.
+type brokenStoresDatastore struct {
+	storage.OpenFGADatastore
+	broken map[string]bool
+	err    error
+	reads  int
+}
+
+func (b *brokenStoresDatastore) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (*openfgav1.Tuple, error) {
+	b.reads++
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if b.broken[store] {
+		if b.err != nil {
+			return nil, b.err
+		}
+		return nil, errors.New("connection refused")
+	}
+
+	return b.OpenFGADatastore.ReadUserTuple(ctx, store, tupleKey, options)
+}
+
+func TestCircuitBreakerDatastore(t *testing.T) {
+	ctx := context.Background()
+	tk := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+
+	setup := func(t *testing.T) (*brokenStoresDatastore, *circuitBreakerOpenFGADatastore, *time.Time) {
+		mem := memory.New()
+		t.Cleanup(mem.Close)
+
+		broken := &brokenStoresDatastore{OpenFGADatastore: mem, broken: map[string]bool{"broken": true}}
+
+		now := time.Now()
+		ds := NewCircuitBreakerDatastore(broken,
+			WithCircuitBreakerMinRequests(4),
+			WithCircuitBreakerErrorRateThreshold(0.5),
+			WithCircuitBreakerWindow(10*time.Second),
+			WithCircuitBreakerOpenDuration(30*time.Second),
+		).(*circuitBreakerOpenFGADatastore)
+		ds.now = func() time.Time { return now }
+
+		return broken, ds, &now
+	}
+
+	read := func(ds storage.OpenFGADatastore, store string) error {
+		_, err := ds.ReadUserTuple(ctx, store, tk, storage.ReadOptions{})
+		return err
+	}
+
+	t.Run("opens_the_circuit_of_the_failing_store_only", func(t *testing.T) {
+		broken, ds, _ := setup(t)
+
+		for i := 0; i < 4; i++ {
+			require.NotErrorIs(t, read(ds, "broken"), storage.ErrStoreUnavailable)
+			require.ErrorIs(t, read(ds, "healthy"), storage.ErrNotFound)
+		}
+
+		reads := broken.reads
+		require.ErrorIs(t, read(ds, "broken"), storage.ErrStoreUnavailable)
+		require.Equal(t, reads, broken.reads)
+
+		require.ErrorIs(t, read(ds, "healthy"), storage.ErrNotFound)
+	})
+
+	t.Run("needs_the_minimum_number_of_requests", func(t *testing.T) {
+		_, ds, now := setup(t)
+
+		for i := 0; i < 3; i++ {
+			require.Error(t, read(ds, "broken"))
+		}
+
+		// the window is over, so that the failures are forgotten
+		*now = now.Add(10 * time.Second)
+		require.NotErrorIs(t, read(ds, "broken"), storage.ErrStoreUnavailable)
+		require.NotErrorIs(t, read(ds, "broken"), storage.ErrStoreUnavailable)
+	})
+
+	t.Run("probes_the_datastore_once_open", func(t *testing.T) {
+		broken, ds, now := setup(t)
+
+		for i := 0; i < 4; i++ {
+			require.Error(t, read(ds, "broken"))
+		}
+		require.ErrorIs(t, read(ds, "broken"), storage.ErrStoreUnavailable)
+
+		// the probe fails, which opens the circuit again
+		*now = now.Add(30 * time.Second)
+		require.NotErrorIs(t, read(ds, "broken"), storage.ErrStoreUnavailable)
+		require.ErrorIs(t, read(ds, "broken"), storage.ErrStoreUnavailable)
+
+		// the probe succeeds, which closes the circuit
+		*now = now.Add(30 * time.Second)
+		broken.broken["broken"] = false
+		require.ErrorIs(t, read(ds, "broken"), storage.ErrNotFound)
+		require.ErrorIs(t, read(ds, "broken"), storage.ErrNotFound)
+	})
+
+	t.Run("carries_the_time_left_until_the_probe", func(t *testing.T) {
+		_, ds, now := setup(t)
+
+		for i := 0; i < 4; i++ {
+			require.Error(t, read(ds, "broken"))
+		}
+
+		*now = now.Add(20 * time.Second)
+
+		var unavailableErr *storage.StoreUnavailableError
+		require.ErrorAs(t, read(ds, "broken"), &unavailableErr)
+		require.Equal(t, "broken", unavailableErr.Store)
+		require.Equal(t, 10*time.Second, unavailableErr.RetryAfter)
+	})
+
+	t.Run("ignores_the_errors_of_the_requests", func(t *testing.T) {
+		_, ds, _ := setup(t)
+
+		for i := 0; i < 10; i++ {
+			require.ErrorIs(t, read(ds, "healthy"), storage.ErrNotFound)
+		}
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		for i := 0; i < 10; i++ {
+			_, _ = ds.ReadUserTuple(canceled, "healthy", tk, storage.ReadOptions{})
+		}
+
+		require.ErrorIs(t, read(ds, "healthy"), storage.ErrNotFound)
+	})
+
+	t.Run("ignores_the_deadlines_of_the_requests", func(t *testing.T) {
+		_, ds, _ := setup(t)
+
+		expired, cancel := context.WithDeadline(ctx, time.Now().Add(-time.Second))
+		defer cancel()
+		for i := 0; i < 10; i++ {
+			_, err := ds.ReadUserTuple(expired, "healthy", tk, storage.ReadOptions{})
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+		}
+
+		require.ErrorIs(t, read(ds, "healthy"), storage.ErrNotFound)
+	})
+
+	t.Run("counts_the_deadlines_of_the_datastore", func(t *testing.T) {
+		broken, ds, _ := setup(t)
+		broken.err = context.DeadlineExceeded
+
+		for i := 0; i < 4; i++ {
+			require.ErrorIs(t, read(ds, "broken"), context.DeadlineExceeded)
+		}
+		require.ErrorIs(t, read(ds, "broken"), storage.ErrStoreUnavailable)
+	})
+
+	t.Run("keeps_the_circuit_open_when_the_probe_is_canceled", func(t *testing.T) {
+		_, ds, now := setup(t)
+
+		for i := 0; i < 4; i++ {
+			require.Error(t, read(ds, "broken"))
+		}
+
+		*now = now.Add(30 * time.Second)
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := ds.ReadUserTuple(canceled, "broken", tk, storage.ReadOptions{})
+		require.ErrorIs(t, err, context.Canceled)
+
+		// the next operation probes the datastore again, and its failure opens the circuit again
+		require.NotErrorIs(t, read(ds, "broken"), storage.ErrStoreUnavailable)
+		require.ErrorIs(t, read(ds, "broken"), storage.ErrStoreUnavailable)
+	})
+}
@@ -2,6 +2,7 @@ package storagewrappers
 
 import (
 	"context"
+	"slices"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"github.com/openfga/openfga/pkg/storage"
@@ -40,10 +41,11 @@ func (c *combinedTupleReader) Read(
 	ctx context.Context,
 	storeID string,
 	tk *openfgav1.TupleKey,
+	options storage.ReadOptions,
 ) (storage.TupleIterator, error) {
 	iter1 := storage.NewStaticTupleIterator(filterTuples(c.contextualTuples, tk.Object, tk.Relation))
 
-	iter2, err := c.RelationshipTupleReader.Read(ctx, storeID, tk)
+	iter2, err := c.RelationshipTupleReader.Read(ctx, storeID, tk, options)
 	if err != nil {
 		return nil, err
 	}
@@ -56,16 +58,18 @@ func (c *combinedTupleReader) ReadPage(
 	store string,
 	tk *openfgav1.TupleKey,
 	opts storage.PaginationOptions,
+	options storage.ReadOptions,
 ) ([]*openfgav1.Tuple, []byte, error) {
 	// no reading from contextual tuples
 
-	return c.RelationshipTupleReader.ReadPage(ctx, store, tk, opts)
+	return c.RelationshipTupleReader.ReadPage(ctx, store, tk, opts, options)
 }
 
 func (c *combinedTupleReader) ReadUserTuple(
 	ctx context.Context,
 	store string,
 	tk *openfgav1.TupleKey,
+	options storage.ReadOptions,
 ) (*openfgav1.Tuple, error) {
 	filteredContextualTuples := filterTuples(c.contextualTuples, tk.Object, tk.Relation)
 
@@ -75,13 +79,14 @@ func (c *combinedTupleReader) ReadUserTuple(
 		}
 	}
 
-	return c.RelationshipTupleReader.ReadUserTuple(ctx, store, tk)
+	return c.RelationshipTupleReader.ReadUserTuple(ctx, store, tk, options)
 }
 
 func (c *combinedTupleReader) ReadUsersetTuples(
 	ctx context.Context,
 	store string,
 	filter storage.ReadUsersetTuplesFilter,
+	options storage.ReadOptions,
 ) (storage.TupleIterator, error) {
 	var usersetTuples []*openfgav1.Tuple
 
@@ -93,7 +98,7 @@ func (c *combinedTupleReader) ReadUsersetTuples(
 
 	iter1 := storage.NewStaticTupleIterator(usersetTuples)
 
-	iter2, err := c.RelationshipTupleReader.ReadUsersetTuples(ctx, store, filter)
+	iter2, err := c.RelationshipTupleReader.ReadUsersetTuples(ctx, store, filter, options)
 	if err != nil {
 		return nil, err
 	}
@@ -105,6 +110,7 @@ func (c *combinedTupleReader) ReadStartingWithUser(
 	ctx context.Context,
 	store string,
 	filter storage.ReadStartingWithUserFilter,
+	options storage.ReadOptions,
 ) (storage.TupleIterator, error) {
 	var filteredTuples []*openfgav1.Tuple
 	for _, t := range c.contextualTuples {
@@ -116,6 +122,12 @@ func (c *combinedTupleReader) ReadStartingWithUser(
 			continue
 		}
 
+		if len(filter.ObjectIDs) > 0 {
+			if _, objectID := tuple.SplitObject(t.GetObject()); !slices.Contains(filter.ObjectIDs, objectID) {
+				continue
+			}
+		}
+
 		for _, u := range filter.UserFilter {
 			targetUser := u.GetObject()
 			if u.GetRelation() != "" {
@@ -132,7 +144,7 @@ func (c *combinedTupleReader) ReadStartingWithUser(
 
 	iter1 := storage.NewStaticTupleIterator(filteredTuples)
 
-	iter2, err := c.RelationshipTupleReader.ReadStartingWithUser(ctx, store, filter)
+	iter2, err := c.RelationshipTupleReader.ReadStartingWithUser(ctx, store, filter, options)
 	if err != nil {
 		return nil, err
 	}
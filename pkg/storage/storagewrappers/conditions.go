@@ -0,0 +1,264 @@
+package storagewrappers
+
+import (
+	"context"
+	"fmt"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/condition"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// NewConditionEvaluatingTupleReader returns a RelationshipTupleReader that hides the tuples whose condition
+// isn't met. The conditions are only read, from the conditions backend, for the tuples the model of the typesys
+// allows to carry one, by batches of the tuples read together, and they're evaluated with the context of the
+// tuple, which takes precedence over the context of the request. The tuples which may carry a condition, but
+// have been deleted since they were read, are hidden. The reads fail with an error wrapping a
+// condition.EvaluationError if a condition can't be evaluated, e.g. if the contexts don't bind all its parameters.
+//
+// The pages read with ReadPage may have fewer tuples than requested.
+func NewConditionEvaluatingTupleReader(
+	ds storage.RelationshipTupleReader,
+	conditions storage.ConditionsBackend,
+	typesys *typesystem.TypeSystem,
+	requestContext map[string]interface{},
+) storage.RelationshipTupleReader {
+	return &conditionEvaluatingTupleReader{
+		RelationshipTupleReader: ds,
+		conditions:              conditions,
+		typesys:                 typesys,
+		requestContext:          requestContext,
+	}
+}
+
+type conditionEvaluatingTupleReader struct {
+	storage.RelationshipTupleReader
+	conditions     storage.ConditionsBackend
+	typesys        *typesystem.TypeSystem
+	requestContext map[string]interface{}
+}
+
+var _ storage.RelationshipTupleReader = (*conditionEvaluatingTupleReader)(nil)
+
+// conditionsBatchSize is the maximum number of tuples of an iterator whose conditions are read at once. The
+// first batches are smaller, so that the reads which stop at the first tuples don't read many more.
+const conditionsBatchSize = 100
+
+// filterMet returns the tuples that don't carry a condition, or carry a condition that is met. The conditions
+// of the tuples are read at once, and the tuples which may carry one but don't exist anymore are left out.
+func (c *conditionEvaluatingTupleReader) filterMet(ctx context.Context, store string, tuples []*openfgav1.Tuple) ([]*openfgav1.Tuple, error) {
+	var keys []*openfgav1.TupleKey
+	for _, t := range tuples {
+		if c.typesys.AllowsConditions(t.GetKey()) {
+			keys = append(keys, t.GetKey())
+		}
+	}
+
+	if len(keys) == 0 {
+		return tuples, nil
+	}
+
+	conditions, err := c.conditions.ReadTupleConditions(ctx, store, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]*openfgav1.Tuple, 0, len(tuples))
+	for _, t := range tuples {
+		tupleCondition, found := conditions[tuple.TupleKeyToString(t.GetKey())]
+		if !found && c.typesys.AllowsConditions(t.GetKey()) {
+			// the tuple has been deleted since it was read, so the condition it carried is unknown
+			continue
+		}
+
+		ok, err := c.met(ctx, tupleCondition)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			filtered = append(filtered, t)
+		}
+	}
+
+	return filtered, nil
+}
+
+// met reports whether the condition of a tuple, if it carries one, is met.
+func (c *conditionEvaluatingTupleReader) met(ctx context.Context, tupleCondition *condition.TupleCondition) (bool, error) {
+	if tupleCondition == nil {
+		return true, nil
+	}
+
+	evaluable, ok := c.typesys.GetCondition(tupleCondition.Name)
+	if !ok {
+		return false, &condition.EvaluationError{
+			Condition: tupleCondition.Name,
+			Err:       fmt.Errorf("the condition isn't defined by the model '%s'", c.typesys.GetAuthorizationModelID()),
+		}
+	}
+
+	return evaluable.Evaluate(ctx, tupleCondition.Context, c.requestContext)
+}
+
+func (c *conditionEvaluatingTupleReader) filter(ctx context.Context, store string, iter storage.TupleIterator) storage.TupleIterator {
+	return &conditionEvaluatingIterator{
+		iter: iter,
+		filter: func(tuples []*openfgav1.Tuple) ([]*openfgav1.Tuple, error) {
+			return c.filterMet(ctx, store, tuples)
+		},
+		allowsConditions: func(t *openfgav1.Tuple) bool {
+			return c.typesys.AllowsConditions(t.GetKey())
+		},
+		batchSize: 1,
+	}
+}
+
+func (c *conditionEvaluatingTupleReader) Read(
+	ctx context.Context,
+	store string,
+	tk *openfgav1.TupleKey,
+	options storage.ReadOptions,
+) (storage.TupleIterator, error) {
+	iter, err := c.RelationshipTupleReader.Read(ctx, store, tk, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.filter(ctx, store, iter), nil
+}
+
+func (c *conditionEvaluatingTupleReader) ReadPage(
+	ctx context.Context,
+	store string,
+	tk *openfgav1.TupleKey,
+	opts storage.PaginationOptions,
+	options storage.ReadOptions,
+) ([]*openfgav1.Tuple, []byte, error) {
+	tuples, continuationToken, err := c.RelationshipTupleReader.ReadPage(ctx, store, tk, opts, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	filtered, err := c.filterMet(ctx, store, tuples)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return filtered, continuationToken, nil
+}
+
+func (c *conditionEvaluatingTupleReader) ReadUserTuple(
+	ctx context.Context,
+	store string,
+	tk *openfgav1.TupleKey,
+	options storage.ReadOptions,
+) (*openfgav1.Tuple, error) {
+	t, err := c.RelationshipTupleReader.ReadUserTuple(ctx, store, tk, options)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered, err := c.filterMet(ctx, store, []*openfgav1.Tuple{t})
+	if err != nil {
+		return nil, err
+	}
+	if len(filtered) == 0 {
+		return nil, storage.ErrNotFound
+	}
+
+	return t, nil
+}
+
+func (c *conditionEvaluatingTupleReader) ReadUsersetTuples(
+	ctx context.Context,
+	store string,
+	filter storage.ReadUsersetTuplesFilter,
+	options storage.ReadOptions,
+) (storage.TupleIterator, error) {
+	iter, err := c.RelationshipTupleReader.ReadUsersetTuples(ctx, store, filter, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.filter(ctx, store, iter), nil
+}
+
+func (c *conditionEvaluatingTupleReader) ReadStartingWithUser(
+	ctx context.Context,
+	store string,
+	filter storage.ReadStartingWithUserFilter,
+	options storage.ReadOptions,
+) (storage.TupleIterator, error) {
+	iter, err := c.RelationshipTupleReader.ReadStartingWithUser(ctx, store, filter, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.filter(ctx, store, iter), nil
+}
+
+// conditionEvaluatingIterator skips the tuples whose condition isn't met. It reads the tuples which may carry a
+// condition by batches, doubling in size up to conditionsBatchSize, so that their conditions are read at once.
+// The other tuples are returned as soon as they're read.
+type conditionEvaluatingIterator struct {
+	iter             storage.TupleIterator
+	filter           func(tuples []*openfgav1.Tuple) ([]*openfgav1.Tuple, error)
+	allowsConditions func(t *openfgav1.Tuple) bool
+
+	// batchSize is the number of tuples of the next batch
+	batchSize int
+
+	// buffer holds the tuples of the batch read whose condition is met, which haven't been returned yet
+	buffer []*openfgav1.Tuple
+
+	// err is returned once the buffer is empty, e.g. storage.ErrIteratorDone
+	err error
+}
+
+func (c *conditionEvaluatingIterator) Next() (*openfgav1.Tuple, error) {
+	for len(c.buffer) == 0 {
+		if c.err != nil {
+			return nil, c.err
+		}
+
+		c.read()
+	}
+
+	t := c.buffer[0]
+	c.buffer = c.buffer[1:]
+
+	return t, nil
+}
+
+// read reads the next batch of tuples into the buffer, and records the error that ends it, if any. A batch
+// ends early at a tuple which can't carry a condition.
+func (c *conditionEvaluatingIterator) read() {
+	batch := make([]*openfgav1.Tuple, 0, c.batchSize)
+	for len(batch) < c.batchSize {
+		t, err := c.iter.Next()
+		if err != nil {
+			c.err = err
+			break
+		}
+		batch = append(batch, t)
+
+		if !c.allowsConditions(t) {
+			break
+		}
+	}
+	c.batchSize = min(2*c.batchSize, conditionsBatchSize)
+
+	filtered, err := c.filter(batch)
+	if err != nil {
+		c.err = err
+		return
+	}
+
+	c.buffer = filtered
+}
+
+func (c *conditionEvaluatingIterator) Stop() {
+	c.iter.Stop()
+}
@@ -0,0 +1,165 @@
+package storagewrappers
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/condition"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+// countingConditionsBackend counts the reads of the conditions of the tuples.
+type countingConditionsBackend struct {
+	storage.ConditionsBackend
+	reads atomic.Int32
+}
+
+func (c *countingConditionsBackend) ReadTupleCondition(ctx context.Context, store string, tk *openfgav1.TupleKey) (*condition.TupleCondition, error) {
+	c.reads.Add(1)
+	return c.ConditionsBackend.ReadTupleCondition(ctx, store, tk)
+}
+
+func (c *countingConditionsBackend) ReadTupleConditions(ctx context.Context, store string, tks []*openfgav1.TupleKey) (map[string]*condition.TupleCondition, error) {
+	c.reads.Add(1)
+	return c.ConditionsBackend.ReadTupleConditions(ctx, store, tks)
+}
+
+func TestConditionEvaluatingTupleReader(t *testing.T) {
+	ctx := context.Background()
+
+	ds := memory.New()
+	defer ds.Close()
+
+	modelConditions := &condition.ModelConditions{
+		Conditions: []*condition.Condition{{
+			Name:       "ip_allowed",
+			Expression: "ip in allowed_ips",
+			Parameters: map[string]condition.ParameterType{
+				"ip":          condition.ParameterTypeString,
+				"allowed_ips": condition.ParameterTypeList,
+			},
+		}},
+		TypeRestrictions: []*condition.TypeRestriction{
+			{Type: "document", Relation: "viewer", UserType: "user", Condition: "ip_allowed"},
+		},
+	}
+	typesys := typesystem.New(&openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+		type document
+		  relations
+		    define viewer: [user] as self
+		    define owner: [user] as self
+		`),
+	}, typesystem.WithConditions(modelConditions))
+
+	// every other user is only allowed from 10.0.0.1
+	store := ulid.Make().String()
+	tupleCount := 2*conditionsBatchSize + 10
+	tupleConditions := map[string]*condition.TupleCondition{}
+	var tks []*openfgav1.TupleKey
+	for i := 0; i < tupleCount; i++ {
+		tk := tuple.NewTupleKey("document:1", "viewer", fmt.Sprintf("user:%d", i))
+		tks = append(tks, tk)
+		if i%2 == 1 {
+			tupleConditions[tuple.TupleKeyToString(tk)] = &condition.TupleCondition{
+				Name:    "ip_allowed",
+				Context: map[string]interface{}{"allowed_ips": []interface{}{"10.0.0.1"}},
+			}
+		}
+	}
+	for start := 0; start < len(tks); start += ds.MaxTuplesPerWrite() {
+		batch := tks[start:min(start+ds.MaxTuplesPerWrite(), len(tks))]
+		require.NoError(t, ds.(storage.ConditionsBackend).WriteWithConditions(ctx, store, nil, batch, tupleConditions))
+	}
+
+	t.Run("reads_the_conditions_by_batches", func(t *testing.T) {
+		conditions := &countingConditionsBackend{ConditionsBackend: ds.(storage.ConditionsBackend)}
+		reader := NewConditionEvaluatingTupleReader(ds, conditions, typesys, map[string]interface{}{"ip": "10.0.0.2"})
+
+		iter, err := reader.Read(ctx, store, tuple.NewTupleKey("document:1", "viewer", ""), storage.ReadOptions{})
+		require.NoError(t, err)
+		require.Len(t, readAllTuples(t, iter), tupleCount/2)
+
+		// by batches of 1, 2, 4, ..., 64 and then 100 tuples
+		require.EqualValues(t, 8, conditions.reads.Load())
+	})
+
+	t.Run("reads_ahead_as_the_tuples_are_read", func(t *testing.T) {
+		require.NoError(t, ds.Write(ctx, store, nil, []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:1", "owner", "user:0"),
+			tuple.NewTupleKey("document:1", "owner", "user:1"),
+		}))
+
+		for _, relation := range []string{"viewer", "owner"} {
+			counting := &countingTupleReader{RelationshipTupleReader: ds}
+			reader := NewConditionEvaluatingTupleReader(counting, ds.(storage.ConditionsBackend), typesys, nil)
+
+			iter, err := reader.Read(ctx, store, tuple.NewTupleKey("document:1", relation, ""), storage.ReadOptions{})
+			require.NoError(t, err)
+
+			_, err = iter.Next()
+			require.NoError(t, err)
+			require.EqualValues(t, 1, counting.tuples.Load())
+			iter.Stop()
+		}
+	})
+
+	t.Run("tuples_deleted_since_they_were_read_are_hidden", func(t *testing.T) {
+		// the conditions of another store, where the tuples don't exist, as if they've been deleted
+		reader := NewConditionEvaluatingTupleReader(ds, &storeConditionsBackend{ds.(storage.ConditionsBackend), ulid.Make().String()}, typesys, nil)
+
+		_, err := reader.ReadUserTuple(ctx, store, tuple.NewTupleKey("document:1", "viewer", "user:0"), storage.ReadOptions{})
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+}
+
+// countingTupleReader counts the tuples read through the iterators of Read.
+type countingTupleReader struct {
+	storage.RelationshipTupleReader
+	tuples atomic.Int32
+}
+
+func (c *countingTupleReader) Read(ctx context.Context, store string, tk *openfgav1.TupleKey, options storage.ReadOptions) (storage.TupleIterator, error) {
+	iter, err := c.RelationshipTupleReader.Read(ctx, store, tk, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &countingTupleIterator{TupleIterator: iter, tuples: &c.tuples}, nil
+}
+
+type countingTupleIterator struct {
+	storage.TupleIterator
+	tuples *atomic.Int32
+}
+
+func (c *countingTupleIterator) Next() (*openfgav1.Tuple, error) {
+	t, err := c.TupleIterator.Next()
+	if err == nil {
+		c.tuples.Add(1)
+	}
+
+	return t, err
+}
+
+// storeConditionsBackend reads the conditions of the tuples of another store.
+type storeConditionsBackend struct {
+	storage.ConditionsBackend
+	store string
+}
+
+func (s *storeConditionsBackend) ReadTupleConditions(ctx context.Context, _ string, tks []*openfgav1.TupleKey) (map[string]*condition.TupleCondition, error) {
+	return s.ConditionsBackend.ReadTupleConditions(ctx, s.store, tks)
+}
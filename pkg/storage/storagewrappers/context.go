@@ -4,6 +4,8 @@ import (
 	"context"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/condition"
+	"github.com/openfga/openfga/pkg/requestcontext"
 	"github.com/openfga/openfga/pkg/storage"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -16,51 +18,94 @@ import (
 // ContextTracerWrapper must be the first wrapper around the datastore if traces are to work properly.
 type ContextTracerWrapper struct {
 	storage.OpenFGADatastore
+	forwardedBackends
 }
 
-var _ storage.OpenFGADatastore = (*ContextTracerWrapper)(nil)
+var (
+	_ storage.OpenFGADatastore       = (*ContextTracerWrapper)(nil)
+	_ storage.DatastoreWrapper       = (*ContextTracerWrapper)(nil)
+	_ storage.ConditionsBackend      = (*ContextTracerWrapper)(nil)
+	_ storage.TupleExpirationBackend = (*ContextTracerWrapper)(nil)
+	_ storage.PreconditionsBackend   = (*ContextTracerWrapper)(nil)
+	_ storage.TupleSampler           = (*ContextTracerWrapper)(nil)
+	_ storage.StatsProvider          = (*ContextTracerWrapper)(nil)
+	_ storage.Maintainer             = (*ContextTracerWrapper)(nil)
+)
 
 func NewContextWrapper(inner storage.OpenFGADatastore) *ContextTracerWrapper {
-	return &ContextTracerWrapper{inner}
+	return &ContextTracerWrapper{
+		OpenFGADatastore:  inner,
+		forwardedBackends: forwardedBackends{inner},
+	}
 }
 
 // queryContext returns a new context (not a child context) with a timeout and
-// the same span data as the supplied context.
+// the same span data, requestcontext metadata and storage.RequestMetadata as the supplied context.
 func queryContext(ctx context.Context) context.Context {
 	span := trace.SpanFromContext(ctx)
-	return trace.ContextWithSpan(context.Background(), span)
+	queryCtx := trace.ContextWithSpan(context.Background(), span)
+
+	queryCtx = requestcontext.CopyTo(queryCtx, ctx)
+	if md, ok := storage.RequestMetadataFromContext(ctx); ok {
+		queryCtx = storage.ContextWithRequestMetadata(queryCtx, md)
+	}
+
+	return queryCtx
+}
+
+// Unwrap See storage.DatastoreWrapper.Unwrap
+func (c *ContextTracerWrapper) Unwrap() storage.OpenFGADatastore {
+	return c.OpenFGADatastore
 }
 
 func (c *ContextTracerWrapper) Close() {
 	c.OpenFGADatastore.Close()
 }
 
-func (c *ContextTracerWrapper) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (storage.TupleIterator, error) {
+func (c *ContextTracerWrapper) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (storage.TupleIterator, error) {
+	queryCtx := queryContext(ctx)
+
+	return c.OpenFGADatastore.Read(queryCtx, store, tupleKey, options)
+}
+
+func (c *ContextTracerWrapper) ReadPage(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, opts storage.PaginationOptions, options storage.ReadOptions) ([]*openfgav1.Tuple, []byte, error) {
+	queryCtx := queryContext(ctx)
+
+	return c.OpenFGADatastore.ReadPage(queryCtx, store, tupleKey, opts, options)
+}
+
+func (c *ContextTracerWrapper) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (*openfgav1.Tuple, error) {
+	queryCtx := queryContext(ctx)
+
+	return c.OpenFGADatastore.ReadUserTuple(queryCtx, store, tupleKey, options)
+}
+
+func (c *ContextTracerWrapper) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter, options storage.ReadOptions) (storage.TupleIterator, error) {
 	queryCtx := queryContext(ctx)
 
-	return c.OpenFGADatastore.Read(queryCtx, store, tupleKey)
+	return c.OpenFGADatastore.ReadUsersetTuples(queryCtx, store, filter, options)
 }
 
-func (c *ContextTracerWrapper) ReadPage(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, opts storage.PaginationOptions) ([]*openfgav1.Tuple, []byte, error) {
+func (c *ContextTracerWrapper) ReadStartingWithUser(ctx context.Context, store string, opts storage.ReadStartingWithUserFilter, options storage.ReadOptions) (storage.TupleIterator, error) {
 	queryCtx := queryContext(ctx)
 
-	return c.OpenFGADatastore.ReadPage(queryCtx, store, tupleKey, opts)
+	return c.OpenFGADatastore.ReadStartingWithUser(queryCtx, store, opts, options)
 }
 
-func (c *ContextTracerWrapper) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+func (c *ContextTracerWrapper) ReadTupleCondition(ctx context.Context, store string, tk *openfgav1.TupleKey) (*condition.TupleCondition, error) {
 	queryCtx := queryContext(ctx)
 
-	return c.OpenFGADatastore.ReadUserTuple(queryCtx, store, tupleKey)
+	return c.forwardedBackends.ReadTupleCondition(queryCtx, store, tk)
 }
 
-func (c *ContextTracerWrapper) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter) (storage.TupleIterator, error) {
+func (c *ContextTracerWrapper) ReadTupleConditions(ctx context.Context, store string, tks []*openfgav1.TupleKey) (map[string]*condition.TupleCondition, error) {
 	queryCtx := queryContext(ctx)
 
-	return c.OpenFGADatastore.ReadUsersetTuples(queryCtx, store, filter)
+	return c.forwardedBackends.ReadTupleConditions(queryCtx, store, tks)
 }
 
-func (c *ContextTracerWrapper) ReadStartingWithUser(ctx context.Context, store string, opts storage.ReadStartingWithUserFilter) (storage.TupleIterator, error) {
+func (c *ContextTracerWrapper) SampleTuples(ctx context.Context, store string, filter *openfgav1.TupleKey, size int) ([]*openfgav1.TupleKey, error) {
 	queryCtx := queryContext(ctx)
 
-	return c.OpenFGADatastore.ReadStartingWithUser(queryCtx, store, opts)
+	return c.forwardedBackends.SampleTuples(queryCtx, store, filter, size)
 }
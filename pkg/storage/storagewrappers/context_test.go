@@ -0,0 +1,32 @@
+package storagewrappers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryContextPreservesRequestMetadata(t *testing.T) {
+	md := &storage.RequestMetadata{
+		Method:               "Check",
+		StoreID:              "01HCSBNPGRMSRYJRJRZ0KCZP1C",
+		AuthorizationModelID: "01HCSBNWV4YGBQW0Y8HP1Q4NKJ",
+		RequestID:            "0ac0a2a3-93e0-4d55-9451-ba8c4d52ac5b",
+		Caller:               "client-1",
+	}
+
+	ctx, cancel := context.WithCancel(storage.ContextWithRequestMetadata(context.Background(), md))
+	cancel()
+
+	queryCtx := queryContext(ctx)
+	require.NoError(t, queryCtx.Err())
+
+	got, ok := storage.RequestMetadataFromContext(queryCtx)
+	require.True(t, ok)
+	require.Equal(t, md, got)
+
+	_, ok = storage.RequestMetadataFromContext(queryContext(context.Background()))
+	require.False(t, ok)
+}
@@ -0,0 +1,277 @@
+package storagewrappers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	defaultHedgingBudget = 0.1
+
+	// hedgingBudgetBurst is the maximum number of reads that can be hedged in a row, e.g. after a quiet
+	// period, regardless of the budget.
+	hedgingBudgetBurst = 10
+
+	hedgedReadWinnerFirst = "first"
+	hedgedReadWinnerHedge = "hedge"
+)
+
+var (
+	hedgedReadsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "datastore_hedged_reads_total",
+		Help: "The total number of reads of the replicas that were hedged with a second read, by operation and by the read that won (first or hedge).",
+	}, []string{"operation", "winner"})
+
+	hedgedReadsOverBudgetCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "datastore_hedged_reads_over_budget_total",
+		Help: "The total number of reads of the replicas that exceeded the hedging threshold but weren't hedged because the hedging budget was exhausted.",
+	}, []string{"operation"})
+)
+
+var (
+	_ storage.OpenFGADatastore       = (*replicaRoutingOpenFGADatastore)(nil)
+	_ storage.DatastoreWrapper       = (*replicaRoutingOpenFGADatastore)(nil)
+	_ storage.ConditionsBackend      = (*replicaRoutingOpenFGADatastore)(nil)
+	_ storage.TupleExpirationBackend = (*replicaRoutingOpenFGADatastore)(nil)
+	_ storage.PreconditionsBackend   = (*replicaRoutingOpenFGADatastore)(nil)
+	_ storage.TupleSampler           = (*replicaRoutingOpenFGADatastore)(nil)
+	_ storage.StatsProvider          = (*replicaRoutingOpenFGADatastore)(nil)
+	_ storage.Maintainer             = (*replicaRoutingOpenFGADatastore)(nil)
+)
+
+// replicaRoutingOpenFGADatastore is a datastore that serves the reads of tuples that tolerate stale data
+// from read replicas of the primary datastore, in turn, and every other operation from the primary.
+//
+// The reads on the paths of Check (and ListObjects) can be hedged: if a replica hasn't answered after a
+// threshold, the read is also sent to another replica (or to the primary, if there's a single replica),
+// and the first answer wins. The hedges are limited by a global budget, so that a slow datastore isn't
+// overloaded by them.
+type replicaRoutingOpenFGADatastore struct {
+	storage.OpenFGADatastore
+	forwardedBackends
+	replicas []storage.OpenFGADatastore
+	next     atomic.Uint64
+
+	hedgeAfter time.Duration
+	budget     *hedgingBudget
+}
+
+type ReplicaRoutingDatastoreOption func(r *replicaRoutingOpenFGADatastore)
+
+// WithHedging hedges the reads that a replica hasn't answered after the provided threshold. The budget is
+// the maximum fraction of the reads that can be hedged (e.g. 0.1 for 10%). A threshold of 0 disables
+// hedging.
+func WithHedging(after time.Duration, budget float64) ReplicaRoutingDatastoreOption {
+	return func(r *replicaRoutingOpenFGADatastore) {
+		r.hedgeAfter = after
+		r.budget = newHedgingBudget(budget)
+	}
+}
+
+// NewReplicaRoutingDatastore returns a datastore that serves the reads of tuples from the replicas of the
+// primary datastore, unless they require ConsistencyPreferenceHigherConsistency, and every other operation
+// (the writes, the reads of the stores, the models, the assertions and the conditions, and the optional
+// backends of the datastore) from the primary.
+func NewReplicaRoutingDatastore(primary storage.OpenFGADatastore, replicas []storage.OpenFGADatastore, opts ...ReplicaRoutingDatastoreOption) storage.OpenFGADatastore {
+	r := &replicaRoutingOpenFGADatastore{
+		OpenFGADatastore:  primary,
+		forwardedBackends: forwardedBackends{primary},
+		replicas:          replicas,
+		budget:            newHedgingBudget(defaultHedgingBudget),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// route returns the datastore to read from with the provided options, and the one to hedge the read with,
+// if any.
+func (r *replicaRoutingOpenFGADatastore) route(options storage.ReadOptions) (storage.OpenFGADatastore, storage.OpenFGADatastore) {
+	if len(r.replicas) == 0 || options.Consistency == storage.ConsistencyPreferenceHigherConsistency {
+		return r.OpenFGADatastore, nil
+	}
+
+	i := r.next.Add(1) - 1
+	first := r.replicas[i%uint64(len(r.replicas))]
+	if len(r.replicas) == 1 {
+		return first, r.OpenFGADatastore
+	}
+
+	return first, r.replicas[(i+1)%uint64(len(r.replicas))]
+}
+
+type hedgedResult[T any] struct {
+	value T
+	err   error
+	hedge bool
+}
+
+// hedgedRead reads from the first datastore and, if it hasn't answered after the hedging threshold and the
+// budget allows it, from the second one too. The first successful answer wins, and the other one is
+// discarded once it arrives.
+func hedgedRead[T any](ctx context.Context, r *replicaRoutingOpenFGADatastore, operation string, first, second storage.OpenFGADatastore, read func(context.Context, storage.OpenFGADatastore) (T, error), discard func(T)) (T, error) {
+	if r.hedgeAfter <= 0 || second == nil {
+		return read(ctx, first)
+	}
+
+	r.budget.deposit()
+
+	results := make(chan hedgedResult[T], 2)
+	attempt := func(ds storage.OpenFGADatastore, hedge bool) {
+		v, err := read(ctx, ds)
+		results <- hedgedResult[T]{value: v, err: err, hedge: hedge}
+	}
+
+	go attempt(first, false)
+
+	timer := time.NewTimer(r.hedgeAfter)
+	defer timer.Stop()
+
+	select {
+	case res := <-results:
+		return res.value, res.err
+	case <-timer.C:
+	}
+
+	if !r.budget.withdraw() {
+		hedgedReadsOverBudgetCounter.WithLabelValues(operation).Inc()
+		res := <-results
+		return res.value, res.err
+	}
+
+	go attempt(second, true)
+
+	res := <-results
+	if res.err != nil && !errors.Is(res.err, storage.ErrNotFound) {
+		// the other read may still succeed
+		res = <-results
+	} else {
+		go func() {
+			if other := <-results; other.err == nil && discard != nil {
+				discard(other.value)
+			}
+		}()
+	}
+
+	winner := hedgedReadWinnerFirst
+	if res.hedge {
+		winner = hedgedReadWinnerHedge
+	}
+	hedgedReadsCounter.WithLabelValues(operation, winner).Inc()
+
+	return res.value, res.err
+}
+
+func stopIterator(iter storage.TupleIterator) {
+	iter.Stop()
+}
+
+func (r *replicaRoutingOpenFGADatastore) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (storage.TupleIterator, error) {
+	first, second := r.route(options)
+	return hedgedRead(ctx, r, "Read", first, second, func(ctx context.Context, ds storage.OpenFGADatastore) (storage.TupleIterator, error) {
+		return ds.Read(ctx, store, tupleKey, options)
+	}, stopIterator)
+}
+
+func (r *replicaRoutingOpenFGADatastore) ReadPage(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, opts storage.PaginationOptions, options storage.ReadOptions) ([]*openfgav1.Tuple, []byte, error) {
+	first, _ := r.route(options)
+	return first.ReadPage(ctx, store, tupleKey, opts, options)
+}
+
+func (r *replicaRoutingOpenFGADatastore) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (*openfgav1.Tuple, error) {
+	first, second := r.route(options)
+	return hedgedRead(ctx, r, "ReadUserTuple", first, second, func(ctx context.Context, ds storage.OpenFGADatastore) (*openfgav1.Tuple, error) {
+		return ds.ReadUserTuple(ctx, store, tupleKey, options)
+	}, nil)
+}
+
+func (r *replicaRoutingOpenFGADatastore) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter, options storage.ReadOptions) (storage.TupleIterator, error) {
+	first, second := r.route(options)
+	return hedgedRead(ctx, r, "ReadUsersetTuples", first, second, func(ctx context.Context, ds storage.OpenFGADatastore) (storage.TupleIterator, error) {
+		return ds.ReadUsersetTuples(ctx, store, filter, options)
+	}, stopIterator)
+}
+
+func (r *replicaRoutingOpenFGADatastore) ReadStartingWithUser(ctx context.Context, store string, filter storage.ReadStartingWithUserFilter, options storage.ReadOptions) (storage.TupleIterator, error) {
+	first, second := r.route(options)
+	return hedgedRead(ctx, r, "ReadStartingWithUser", first, second, func(ctx context.Context, ds storage.OpenFGADatastore) (storage.TupleIterator, error) {
+		return ds.ReadStartingWithUser(ctx, store, filter, options)
+	}, stopIterator)
+}
+
+func (r *replicaRoutingOpenFGADatastore) ReadChanges(ctx context.Context, store, objectType string, paginationOptions storage.PaginationOptions, horizonOffset time.Duration, options storage.ReadOptions) ([]*openfgav1.TupleChange, []byte, error) {
+	first, _ := r.route(options)
+	return first.ReadChanges(ctx, store, objectType, paginationOptions, horizonOffset, options)
+}
+
+// Unwrap returns the primary datastore.
+func (r *replicaRoutingOpenFGADatastore) Unwrap() storage.OpenFGADatastore {
+	return r.OpenFGADatastore
+}
+
+// IsReady reports whether the primary and all the replicas are ready.
+func (r *replicaRoutingOpenFGADatastore) IsReady(ctx context.Context) (bool, error) {
+	for _, ds := range append([]storage.OpenFGADatastore{r.OpenFGADatastore}, r.replicas...) {
+		ready, err := ds.IsReady(ctx)
+		if err != nil || !ready {
+			return ready, err
+		}
+	}
+
+	return true, nil
+}
+
+// Close closes the primary and all the replicas.
+func (r *replicaRoutingOpenFGADatastore) Close() {
+	r.OpenFGADatastore.Close()
+	for _, ds := range r.replicas {
+		ds.Close()
+	}
+}
+
+// hedgingBudget is a token bucket that limits the fraction of the reads that are hedged: every read deposits
+// the budget (e.g. 0.1 token), and every hedge withdraws a token.
+type hedgingBudget struct {
+	mu     sync.Mutex
+	ratio  float64
+	tokens float64
+}
+
+func newHedgingBudget(ratio float64) *hedgingBudget {
+	b := &hedgingBudget{ratio: ratio}
+	if ratio > 0 {
+		b.tokens = hedgingBudgetBurst
+	}
+
+	return b
+}
+
+func (b *hedgingBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(b.tokens+b.ratio, hedgingBudgetBurst)
+}
+
+func (b *hedgingBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+
+	return true
+}
@@ -0,0 +1,117 @@
+package storagewrappers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// slowDatastore delays the reads of tuples of the datastore it wraps.
+type slowDatastore struct {
+	storage.OpenFGADatastore
+	delay time.Duration
+}
+
+func (s *slowDatastore) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (*openfgav1.Tuple, error) {
+	time.Sleep(s.delay)
+	return s.OpenFGADatastore.ReadUserTuple(ctx, store, tupleKey, options)
+}
+
+func (s *slowDatastore) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (storage.TupleIterator, error) {
+	time.Sleep(s.delay)
+	return s.OpenFGADatastore.Read(ctx, store, tupleKey, options)
+}
+
+func TestReplicaRoutingDatastore(t *testing.T) {
+	ctx := context.Background()
+
+	primary := memory.New()
+	replica := memory.New()
+	defer primary.Close()
+	defer replica.Close()
+
+	// the replica lags behind the primary: it only has the tuple of anne
+	anne := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+	bob := tuple.NewTupleKey("document:1", "viewer", "user:bob")
+	require.NoError(t, primary.Write(ctx, "store", nil, []*openfgav1.TupleKey{anne, bob}))
+	require.NoError(t, replica.Write(ctx, "store", nil, []*openfgav1.TupleKey{anne}))
+
+	t.Run("routes_the_reads_by_consistency", func(t *testing.T) {
+		ds := NewReplicaRoutingDatastore(primary, []storage.OpenFGADatastore{replica})
+
+		_, err := ds.ReadUserTuple(ctx, "store", bob, storage.ReadOptions{})
+		require.ErrorIs(t, err, storage.ErrNotFound)
+
+		_, err = ds.ReadUserTuple(ctx, "store", bob, storage.ReadOptions{Consistency: storage.ConsistencyPreferenceHigherConsistency})
+		require.NoError(t, err)
+
+		iter, err := ds.Read(ctx, "store", tuple.NewTupleKey("document:1", "", ""), storage.ReadOptions{})
+		require.NoError(t, err)
+		require.Len(t, readAllTuples(t, iter), 1)
+	})
+
+	t.Run("hedges_the_slow_reads", func(t *testing.T) {
+		ds := NewReplicaRoutingDatastore(primary, []storage.OpenFGADatastore{&slowDatastore{OpenFGADatastore: replica, delay: time.Second}},
+			WithHedging(10*time.Millisecond, 1),
+		)
+
+		before := testutil.ToFloat64(hedgedReadsCounter.WithLabelValues("ReadUserTuple", hedgedReadWinnerHedge))
+
+		start := time.Now()
+		_, err := ds.ReadUserTuple(ctx, "store", bob, storage.ReadOptions{})
+		require.NoError(t, err) // answered by the primary
+		require.Less(t, time.Since(start), time.Second)
+		require.Equal(t, before+1, testutil.ToFloat64(hedgedReadsCounter.WithLabelValues("ReadUserTuple", hedgedReadWinnerHedge)))
+
+		iter, err := ds.Read(ctx, "store", tuple.NewTupleKey("document:1", "", ""), storage.ReadOptions{})
+		require.NoError(t, err)
+		require.Len(t, readAllTuples(t, iter), 2)
+	})
+
+	t.Run("fast_reads_are_not_hedged", func(t *testing.T) {
+		ds := NewReplicaRoutingDatastore(primary, []storage.OpenFGADatastore{replica}, WithHedging(time.Second, 1))
+
+		before := testutil.ToFloat64(hedgedReadsCounter.WithLabelValues("ReadUserTuple", hedgedReadWinnerFirst)) +
+			testutil.ToFloat64(hedgedReadsCounter.WithLabelValues("ReadUserTuple", hedgedReadWinnerHedge))
+
+		_, err := ds.ReadUserTuple(ctx, "store", anne, storage.ReadOptions{})
+		require.NoError(t, err)
+
+		after := testutil.ToFloat64(hedgedReadsCounter.WithLabelValues("ReadUserTuple", hedgedReadWinnerFirst)) +
+			testutil.ToFloat64(hedgedReadsCounter.WithLabelValues("ReadUserTuple", hedgedReadWinnerHedge))
+		require.Equal(t, before, after)
+	})
+
+	t.Run("hedges_are_limited_by_the_budget", func(t *testing.T) {
+		ds := NewReplicaRoutingDatastore(primary, []storage.OpenFGADatastore{&slowDatastore{OpenFGADatastore: replica, delay: 50 * time.Millisecond}},
+			WithHedging(time.Millisecond, 0),
+		)
+
+		before := testutil.ToFloat64(hedgedReadsOverBudgetCounter.WithLabelValues("ReadUserTuple"))
+
+		_, err := ds.ReadUserTuple(ctx, "store", anne, storage.ReadOptions{})
+		require.NoError(t, err)
+		require.Equal(t, before+1, testutil.ToFloat64(hedgedReadsOverBudgetCounter.WithLabelValues("ReadUserTuple")))
+	})
+}
+
+func TestHedgingBudget(t *testing.T) {
+	b := newHedgingBudget(0.5)
+
+	for i := 0; i < hedgingBudgetBurst; i++ {
+		require.True(t, b.withdraw())
+	}
+	require.False(t, b.withdraw())
+
+	b.deposit()
+	require.False(t, b.withdraw())
+	b.deposit()
+	require.True(t, b.withdraw())
+}
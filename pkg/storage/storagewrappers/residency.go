@@ -0,0 +1,560 @@
+package storagewrappers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/condition"
+	"github.com/openfga/openfga/pkg/requestcontext"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+// defaultResidencyUnknownStoreTTL is how long a store found in no region is remembered as unknown.
+const defaultResidencyUnknownStoreTTL = 10 * time.Second
+
+var (
+	_ storage.OpenFGADatastore       = (*residencyOpenFGADatastore)(nil)
+	_ storage.DatastoreWrapper       = (*residencyOpenFGADatastore)(nil)
+	_ storage.ConditionsBackend      = (*residencyOpenFGADatastore)(nil)
+	_ storage.TupleExpirationBackend = (*residencyOpenFGADatastore)(nil)
+	_ storage.PreconditionsBackend   = (*residencyOpenFGADatastore)(nil)
+	_ storage.TupleSampler           = (*residencyOpenFGADatastore)(nil)
+	_ storage.StatsProvider          = (*residencyOpenFGADatastore)(nil)
+	_ storage.Maintainer             = (*residencyOpenFGADatastore)(nil)
+)
+
+// residencyOpenFGADatastore is a datastore that pins the data of every store to the datastore of the region it
+// resides in, for the deployments with strict data locality requirements. The server runs in one region (the
+// local one) and refuses to read or write the data of the stores of the other regions, so that it never leaves
+// them.
+type residencyOpenFGADatastore struct {
+	localRegion string
+
+	// [region] => datastore
+	regions map[string]storage.OpenFGADatastore
+
+	// regionNames is sorted, starting with the local region
+	regionNames []string
+
+	unknownStoreTTL time.Duration
+	now             func() time.Time
+
+	mu sync.RWMutex
+	// [storeID] => region
+	storeRegions map[string]string
+	// [storeID] => the time until which the store is remembered as found in no region
+	unknownStores map[string]time.Time
+	lastSweep     time.Time
+}
+
+type ResidencyDatastoreOption func(r *residencyOpenFGADatastore)
+
+// WithRegion makes the stores residing in the named region be served by the provided datastore.
+func WithRegion(region string, ds storage.OpenFGADatastore) ResidencyDatastoreOption {
+	return func(r *residencyOpenFGADatastore) {
+		r.regions[region] = ds
+	}
+}
+
+// WithResidencyUnknownStoreTTL sets how long a store found in no region is remembered as unknown, so that the
+// lookups of the stores that don't exist don't reach every region.
+func WithResidencyUnknownStoreTTL(ttl time.Duration) ResidencyDatastoreOption {
+	return func(r *residencyOpenFGADatastore) {
+		r.unknownStoreTTL = ttl
+	}
+}
+
+// NewResidencyDatastore returns a datastore that pins the data of every store to the datastore of its region.
+// The stores are created in the region requested in the request context (see
+// requestcontext.SetStoreResidency), or in the local one, and an unknown region is refused with
+// storage.ErrUnknownResidency. The reads and the writes of the stores of other regions than the local one are
+// refused with storage.ErrCrossRegionRead.
+//
+// The region of a store is found by looking it up in every region, the local one first, and is then
+// remembered. The stores found in no region are served by the local region, and are remembered as unknown for a
+// short while (see WithResidencyUnknownStoreTTL).
+//
+// The operations of the optional backends of the datastores (see storage.DatastoreWrapper) are refused like
+// the others, and the maintenance tasks are the ones of the local region.
+//
+// ListStores lists the stores of the local region only, IsReady reports whether the local region is ready,
+// and Close closes the datastores of every region.
+func NewResidencyDatastore(localRegion string, local storage.OpenFGADatastore, opts ...ResidencyDatastoreOption) storage.OpenFGADatastore {
+	r := &residencyOpenFGADatastore{
+		localRegion:     localRegion,
+		regions:         map[string]storage.OpenFGADatastore{},
+		unknownStoreTTL: defaultResidencyUnknownStoreTTL,
+		now:             time.Now,
+		storeRegions:    map[string]string{},
+		unknownStores:   map[string]time.Time{},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	r.regions[localRegion] = local
+
+	for region := range r.regions {
+		if region != localRegion {
+			r.regionNames = append(r.regionNames, region)
+		}
+	}
+	sort.Strings(r.regionNames)
+	r.regionNames = append([]string{localRegion}, r.regionNames...)
+
+	return r
+}
+
+// region returns the region the data of the store resides in.
+func (r *residencyOpenFGADatastore) region(ctx context.Context, store string) (string, error) {
+	r.mu.RLock()
+	region, ok := r.storeRegions[store]
+	unknownUntil := r.unknownStores[store]
+	r.mu.RUnlock()
+	if ok {
+		return region, nil
+	}
+	if r.now().Before(unknownUntil) {
+		return r.localRegion, nil
+	}
+
+	for _, region := range r.regionNames {
+		_, err := r.regions[region].GetStore(ctx, store)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		r.setRegion(store, region)
+		return region, nil
+	}
+
+	r.setUnknown(store)
+	return r.localRegion, nil
+}
+
+func (r *residencyOpenFGADatastore) setRegion(store, region string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.storeRegions[store] = region
+	delete(r.unknownStores, store)
+}
+
+// setUnknown remembers the store as found in no region, and forgets the unknown stores that have expired, once
+// per TTL.
+func (r *residencyOpenFGADatastore) setUnknown(store string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if now.Sub(r.lastSweep) >= r.unknownStoreTTL {
+		r.lastSweep = now
+		for unknown, until := range r.unknownStores {
+			if !now.Before(until) {
+				delete(r.unknownStores, unknown)
+			}
+		}
+	}
+
+	r.unknownStores[store] = now.Add(r.unknownStoreTTL)
+}
+
+// datastore returns the datastore of the local region, if the store resides in it. The data of the stores of
+// the other regions is neither read nor written, since the authorization models they're written against can't be
+// read either.
+func (r *residencyOpenFGADatastore) datastore(ctx context.Context, store string) (storage.OpenFGADatastore, error) {
+	region, err := r.region(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	if region != r.localRegion {
+		return nil, fmt.Errorf("%w: the store '%s' resides in the region '%s', not in '%s'", storage.ErrCrossRegionRead, store, region, r.localRegion)
+	}
+
+	return r.regions[region], nil
+}
+
+func (r *residencyOpenFGADatastore) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (storage.TupleIterator, error) {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	return ds.Read(ctx, store, tupleKey, options)
+}
+
+func (r *residencyOpenFGADatastore) ReadPage(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, opts storage.PaginationOptions, options storage.ReadOptions) ([]*openfgav1.Tuple, []byte, error) {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ds.ReadPage(ctx, store, tupleKey, opts, options)
+}
+
+func (r *residencyOpenFGADatastore) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (*openfgav1.Tuple, error) {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	return ds.ReadUserTuple(ctx, store, tupleKey, options)
+}
+
+func (r *residencyOpenFGADatastore) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter, options storage.ReadOptions) (storage.TupleIterator, error) {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	return ds.ReadUsersetTuples(ctx, store, filter, options)
+}
+
+func (r *residencyOpenFGADatastore) ReadStartingWithUser(ctx context.Context, store string, filter storage.ReadStartingWithUserFilter, options storage.ReadOptions) (storage.TupleIterator, error) {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	return ds.ReadStartingWithUser(ctx, store, filter, options)
+}
+
+func (r *residencyOpenFGADatastore) Write(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes) error {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return err
+	}
+
+	return ds.Write(ctx, store, deletes, writes)
+}
+
+// MaxTuplesPerWrite returns the smallest limit among the datastores of all the regions.
+func (r *residencyOpenFGADatastore) MaxTuplesPerWrite() int {
+	limit := r.regions[r.localRegion].MaxTuplesPerWrite()
+	for _, ds := range r.regions {
+		limit = min(limit, ds.MaxTuplesPerWrite())
+	}
+
+	return limit
+}
+
+func (r *residencyOpenFGADatastore) ReadAuthorizationModel(ctx context.Context, store string, id string) (*openfgav1.AuthorizationModel, error) {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	return ds.ReadAuthorizationModel(ctx, store, id)
+}
+
+func (r *residencyOpenFGADatastore) ReadAuthorizationModels(ctx context.Context, store string, options storage.PaginationOptions) ([]*openfgav1.AuthorizationModel, []byte, error) {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ds.ReadAuthorizationModels(ctx, store, options)
+}
+
+func (r *residencyOpenFGADatastore) FindLatestAuthorizationModelID(ctx context.Context, store string) (string, error) {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return "", err
+	}
+
+	return ds.FindLatestAuthorizationModelID(ctx, store)
+}
+
+// MaxTypesPerAuthorizationModel returns the smallest limit among the datastores of all the regions.
+func (r *residencyOpenFGADatastore) MaxTypesPerAuthorizationModel() int {
+	limit := r.regions[r.localRegion].MaxTypesPerAuthorizationModel()
+	for _, ds := range r.regions {
+		limit = min(limit, ds.MaxTypesPerAuthorizationModel())
+	}
+
+	return limit
+}
+
+func (r *residencyOpenFGADatastore) WriteAuthorizationModel(ctx context.Context, store string, model *openfgav1.AuthorizationModel) error {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return err
+	}
+
+	return ds.WriteAuthorizationModel(ctx, store, model)
+}
+
+// CreateStore creates the store in the region requested in the request context, or in the local region if
+// none is.
+func (r *residencyOpenFGADatastore) CreateStore(ctx context.Context, store *openfgav1.Store) (*openfgav1.Store, error) {
+	region, ok := requestcontext.StoreResidency(ctx)
+	if !ok {
+		region = r.localRegion
+	}
+
+	ds, ok := r.regions[region]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s', the known regions are %v", storage.ErrUnknownResidency, region, r.regionNames)
+	}
+
+	created, err := ds.CreateStore(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	r.setRegion(created.GetId(), region)
+	requestcontext.SetStoreResidency(ctx, region)
+
+	return created, nil
+}
+
+func (r *residencyOpenFGADatastore) DeleteStore(ctx context.Context, id string) error {
+	ds, err := r.datastore(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := ds.DeleteStore(ctx, id); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	delete(r.storeRegions, id)
+	r.mu.Unlock()
+
+	return nil
+}
+
+// GetStore returns the store if it resides in the local region, and records the region in the request context.
+func (r *residencyOpenFGADatastore) GetStore(ctx context.Context, id string) (*openfgav1.Store, error) {
+	ds, err := r.datastore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := ds.GetStore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	requestcontext.SetStoreResidency(ctx, r.localRegion)
+
+	return store, nil
+}
+
+// ListStores lists the stores of the local region only, since the stores of the other regions can't be
+// read from it.
+func (r *residencyOpenFGADatastore) ListStores(ctx context.Context, paginationOptions storage.PaginationOptions) ([]*openfgav1.Store, []byte, error) {
+	return r.regions[r.localRegion].ListStores(ctx, paginationOptions)
+}
+
+func (r *residencyOpenFGADatastore) WriteAssertions(ctx context.Context, store, modelID string, assertions []*openfgav1.Assertion) error {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return err
+	}
+
+	return ds.WriteAssertions(ctx, store, modelID, assertions)
+}
+
+func (r *residencyOpenFGADatastore) ReadAssertions(ctx context.Context, store, modelID string) ([]*openfgav1.Assertion, error) {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	return ds.ReadAssertions(ctx, store, modelID)
+}
+
+func (r *residencyOpenFGADatastore) ReadAssertionsHistory(ctx context.Context, store, modelID string) ([]*storage.AssertionsVersion, error) {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	return ds.ReadAssertionsHistory(ctx, store, modelID)
+}
+
+func (r *residencyOpenFGADatastore) DeleteAssertions(ctx context.Context, store, modelID string) error {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return err
+	}
+
+	return ds.DeleteAssertions(ctx, store, modelID)
+}
+
+func (r *residencyOpenFGADatastore) WriteAuthorizationModelLabels(ctx context.Context, store, modelID string, labels map[string]string) error {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return err
+	}
+
+	return ds.WriteAuthorizationModelLabels(ctx, store, modelID, labels)
+}
+
+func (r *residencyOpenFGADatastore) ReadAuthorizationModelLabels(ctx context.Context, store, modelID string) (map[string]string, error) {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	return ds.ReadAuthorizationModelLabels(ctx, store, modelID)
+}
+
+func (r *residencyOpenFGADatastore) FindAuthorizationModelIDByLabel(ctx context.Context, store, key, value string) (string, error) {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return "", err
+	}
+
+	return ds.FindAuthorizationModelIDByLabel(ctx, store, key, value)
+}
+
+func (r *residencyOpenFGADatastore) ReadChanges(ctx context.Context, store, objectType string, paginationOptions storage.PaginationOptions, horizonOffset time.Duration, options storage.ReadOptions) ([]*openfgav1.TupleChange, []byte, error) {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ds.ReadChanges(ctx, store, objectType, paginationOptions, horizonOffset, options)
+}
+
+func (r *residencyOpenFGADatastore) WriteAuthorizationModelConditions(ctx context.Context, store, modelID string, conditions *condition.ModelConditions) error {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return err
+	}
+
+	return forwardedBackends{ds}.WriteAuthorizationModelConditions(ctx, store, modelID, conditions)
+}
+
+func (r *residencyOpenFGADatastore) ReadAuthorizationModelConditions(ctx context.Context, store, modelID string) (*condition.ModelConditions, error) {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	return forwardedBackends{ds}.ReadAuthorizationModelConditions(ctx, store, modelID)
+}
+
+func (r *residencyOpenFGADatastore) WriteWithConditions(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, conditions map[string]*condition.TupleCondition) error {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return err
+	}
+
+	return forwardedBackends{ds}.WriteWithConditions(ctx, store, deletes, writes, conditions)
+}
+
+func (r *residencyOpenFGADatastore) ReadTupleCondition(ctx context.Context, store string, tk *openfgav1.TupleKey) (*condition.TupleCondition, error) {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	return forwardedBackends{ds}.ReadTupleCondition(ctx, store, tk)
+}
+
+func (r *residencyOpenFGADatastore) ReadTupleConditions(ctx context.Context, store string, tks []*openfgav1.TupleKey) (map[string]*condition.TupleCondition, error) {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	return forwardedBackends{ds}.ReadTupleConditions(ctx, store, tks)
+}
+
+func (r *residencyOpenFGADatastore) WriteWithExpirations(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, expirations map[string]time.Time) error {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return err
+	}
+
+	return forwardedBackends{ds}.WriteWithExpirations(ctx, store, deletes, writes, expirations)
+}
+
+// DeleteExpiredTuples deletes the expired tuples of the datastores of all the regions, and returns the number
+// of tuples deleted from all of them.
+func (r *residencyOpenFGADatastore) DeleteExpiredTuples(ctx context.Context, before time.Time) (int, error) {
+	var deleted int
+	for _, region := range r.regionNames {
+		n, err := forwardedBackends{r.regions[region]}.DeleteExpiredTuples(ctx, before)
+		deleted += n
+		if err != nil {
+			return deleted, fmt.Errorf("delete the expired tuples of the region '%s': %w", region, err)
+		}
+	}
+
+	return deleted, nil
+}
+
+func (r *residencyOpenFGADatastore) WriteWithPreconditions(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, preconditions []storage.Precondition) error {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return err
+	}
+
+	return forwardedBackends{ds}.WriteWithPreconditions(ctx, store, deletes, writes, preconditions)
+}
+
+func (r *residencyOpenFGADatastore) SampleTuples(ctx context.Context, store string, filter *openfgav1.TupleKey, size int) ([]*openfgav1.TupleKey, error) {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	return forwardedBackends{ds}.SampleTuples(ctx, store, filter, size)
+}
+
+func (r *residencyOpenFGADatastore) RelationStats(ctx context.Context, store, objectType, relation string) (storage.TupleStats, error) {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return storage.TupleStats{}, err
+	}
+
+	return forwardedBackends{ds}.RelationStats(ctx, store, objectType, relation)
+}
+
+func (r *residencyOpenFGADatastore) StoreStats(ctx context.Context, store string) (storage.TupleStats, error) {
+	ds, err := r.datastore(ctx, store)
+	if err != nil {
+		return storage.TupleStats{}, err
+	}
+
+	return forwardedBackends{ds}.StoreStats(ctx, store)
+}
+
+// MaintenanceTasks returns the maintenance tasks of the datastore of the local region. The datastores of the
+// other regions are maintained by their servers.
+func (r *residencyOpenFGADatastore) MaintenanceTasks() []storage.MaintenanceTask {
+	return forwardedBackends{r.regions[r.localRegion]}.MaintenanceTasks()
+}
+
+// Unwrap returns the datastore of the local region.
+func (r *residencyOpenFGADatastore) Unwrap() storage.OpenFGADatastore {
+	return r.regions[r.localRegion]
+}
+
+// IsReady reports whether the datastore of the local region is ready, since the server can't serve the reads
+// of the other regions anyway.
+func (r *residencyOpenFGADatastore) IsReady(ctx context.Context) (bool, error) {
+	return r.regions[r.localRegion].IsReady(ctx)
+}
+
+// Close closes the datastores of all the regions.
+func (r *residencyOpenFGADatastore) Close() {
+	for _, region := range r.regionNames {
+		r.regions[region].Close()
+	}
+}
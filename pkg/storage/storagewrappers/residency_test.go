@@ -0,0 +1,173 @@
+package storagewrappers
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/requestcontext"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResidencyDatastore(t *testing.T) {
+	eu := memory.New()
+	us := memory.New()
+
+	ds := NewResidencyDatastore("eu", eu, WithRegion("us", us))
+	defer ds.Close()
+
+	createStore := func(id, region string) error {
+		ctx := requestcontext.NewContext(context.Background())
+		if region != "" {
+			requestcontext.SetStoreResidency(ctx, region)
+		}
+
+		_, err := ds.CreateStore(ctx, &openfgav1.Store{Id: id, Name: id})
+		return err
+	}
+
+	require.NoError(t, createStore("local", ""))
+	require.NoError(t, createStore("eu-store", "eu"))
+	require.NoError(t, createStore("us-store", "us"))
+
+	ctx := context.Background()
+	tk := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+
+	t.Run("creates_the_stores_in_their_region", func(t *testing.T) {
+		for storeID, expected := range map[string]storage.OpenFGADatastore{
+			"local":    eu,
+			"eu-store": eu,
+			"us-store": us,
+		} {
+			_, err := expected.GetStore(ctx, storeID)
+			require.NoError(t, err, storeID)
+		}
+
+		_, err := eu.GetStore(ctx, "us-store")
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("refuses_an_unknown_region", func(t *testing.T) {
+		err := createStore("ap-store", "ap")
+		require.ErrorIs(t, err, storage.ErrUnknownResidency)
+	})
+
+	t.Run("reads_the_local_stores", func(t *testing.T) {
+		require.NoError(t, ds.Write(ctx, "eu-store", nil, []*openfgav1.TupleKey{tk}))
+
+		_, err := ds.ReadUserTuple(ctx, "eu-store", tk, storage.ReadOptions{})
+		require.NoError(t, err)
+
+		rctx := requestcontext.NewContext(context.Background())
+		_, err = ds.GetStore(rctx, "eu-store")
+		require.NoError(t, err)
+
+		region, ok := requestcontext.StoreResidency(rctx)
+		require.True(t, ok)
+		require.Equal(t, "eu", region)
+	})
+
+	t.Run("refuses_cross_region_writes", func(t *testing.T) {
+		err := ds.Write(ctx, "us-store", nil, []*openfgav1.TupleKey{tk})
+		require.ErrorIs(t, err, storage.ErrCrossRegionRead)
+
+		_, err = us.ReadUserTuple(ctx, "us-store", tk, storage.ReadOptions{})
+		require.ErrorIs(t, err, storage.ErrNotFound)
+
+		err = ds.DeleteStore(ctx, "us-store")
+		require.ErrorIs(t, err, storage.ErrCrossRegionRead)
+	})
+
+	t.Run("refuses_cross_region_reads", func(t *testing.T) {
+		_, err := ds.ReadUserTuple(ctx, "us-store", tk, storage.ReadOptions{})
+		require.ErrorIs(t, err, storage.ErrCrossRegionRead)
+
+		_, err = ds.GetStore(ctx, "us-store")
+		require.ErrorIs(t, err, storage.ErrCrossRegionRead)
+
+		_, _, err = ds.ReadChanges(ctx, "us-store", "", storage.PaginationOptions{PageSize: 10}, 0, storage.ReadOptions{})
+		require.ErrorIs(t, err, storage.ErrCrossRegionRead)
+
+		_, err = ds.FindLatestAuthorizationModelID(ctx, "us-store")
+		require.ErrorIs(t, err, storage.ErrCrossRegionRead)
+	})
+
+	t.Run("lists_the_local_stores_only", func(t *testing.T) {
+		stores, _, err := ds.ListStores(ctx, storage.PaginationOptions{PageSize: 10})
+		require.NoError(t, err)
+
+		var storeIDs []string
+		for _, store := range stores {
+			storeIDs = append(storeIDs, store.GetId())
+		}
+		require.ElementsMatch(t, []string{"local", "eu-store"}, storeIDs)
+	})
+
+	t.Run("unknown_stores_are_local", func(t *testing.T) {
+		_, err := ds.GetStore(ctx, "missing")
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("deletes_the_local_stores", func(t *testing.T) {
+		require.NoError(t, ds.DeleteStore(ctx, "eu-store"))
+
+		_, err := eu.GetStore(ctx, "eu-store")
+		require.ErrorIs(t, err, storage.ErrNotFound)
+
+		// once deleted, the store is unknown and looked up again
+		_, err = ds.GetStore(ctx, "eu-store")
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+}
+
+// storeLookupsCountingDatastore counts the lookups of the stores.
+type storeLookupsCountingDatastore struct {
+	storage.OpenFGADatastore
+	lookups atomic.Int32
+}
+
+func (s *storeLookupsCountingDatastore) GetStore(ctx context.Context, id string) (*openfgav1.Store, error) {
+	s.lookups.Add(1)
+	return s.OpenFGADatastore.GetStore(ctx, id)
+}
+
+func TestResidencyDatastoreUnknownStores(t *testing.T) {
+	ctx := context.Background()
+
+	eu := &storeLookupsCountingDatastore{OpenFGADatastore: memory.New()}
+	us := &storeLookupsCountingDatastore{OpenFGADatastore: memory.New()}
+
+	ds := NewResidencyDatastore("eu", eu, WithRegion("us", us), WithResidencyUnknownStoreTTL(10*time.Second)).(*residencyOpenFGADatastore)
+	defer ds.Close()
+
+	now := time.Now()
+	ds.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		_, err := ds.GetStore(ctx, "missing")
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	}
+
+	// the store is looked up in every region once, and then only read from the local one
+	require.EqualValues(t, 4, eu.lookups.Load())
+	require.EqualValues(t, 1, us.lookups.Load())
+
+	now = now.Add(10 * time.Second)
+	_, err := ds.GetStore(ctx, "missing")
+	require.ErrorIs(t, err, storage.ErrNotFound)
+	require.EqualValues(t, 2, us.lookups.Load())
+
+	// a store created since it was remembered as unknown is found in its region
+	rctx := requestcontext.NewContext(ctx)
+	requestcontext.SetStoreResidency(rctx, "us")
+	_, err = ds.CreateStore(rctx, &openfgav1.Store{Id: "missing", Name: "missing"})
+	require.NoError(t, err)
+
+	_, err = ds.GetStore(ctx, "missing")
+	require.ErrorIs(t, err, storage.ErrCrossRegionRead)
+}
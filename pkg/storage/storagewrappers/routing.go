@@ -0,0 +1,403 @@
+package storagewrappers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/condition"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+var (
+	_ storage.OpenFGADatastore       = (*routingOpenFGADatastore)(nil)
+	_ storage.DatastoreWrapper       = (*routingOpenFGADatastore)(nil)
+	_ storage.ConditionsBackend      = (*routingOpenFGADatastore)(nil)
+	_ storage.TupleExpirationBackend = (*routingOpenFGADatastore)(nil)
+	_ storage.PreconditionsBackend   = (*routingOpenFGADatastore)(nil)
+	_ storage.TupleSampler           = (*routingOpenFGADatastore)(nil)
+	_ storage.StatsProvider          = (*routingOpenFGADatastore)(nil)
+	_ storage.Maintainer             = (*routingOpenFGADatastore)(nil)
+)
+
+// routingOpenFGADatastore is a datastore that routes every store-scoped operation to one of several
+// underlying datastores based on the store ID. This allows tenants (stores) to be partitioned across
+// multiple databases without any changes to the rest of the server.
+type routingOpenFGADatastore struct {
+	// fallback serves all the stores that don't match any route
+	fallback storage.OpenFGADatastore
+
+	// [storeID] => datastore
+	storeRoutes map[string]storage.OpenFGADatastore
+
+	// prefixRoutes is sorted by descending prefix length so that the longest prefix wins
+	prefixRoutes []prefixRoute
+}
+
+type prefixRoute struct {
+	prefix    string
+	datastore storage.OpenFGADatastore
+}
+
+type RoutingDatastoreOption func(r *routingOpenFGADatastore)
+
+// WithStoreRoute routes all the operations for the given store to the provided datastore. Store routes
+// take precedence over prefix routes.
+func WithStoreRoute(storeID string, ds storage.OpenFGADatastore) RoutingDatastoreOption {
+	return func(r *routingOpenFGADatastore) {
+		r.storeRoutes[storeID] = ds
+	}
+}
+
+// WithStorePrefixRoute routes the operations of all the stores whose ID starts with the given prefix to
+// the provided datastore. If more than one prefix matches a store ID, the longest one wins.
+func WithStorePrefixRoute(prefix string, ds storage.OpenFGADatastore) RoutingDatastoreOption {
+	return func(r *routingOpenFGADatastore) {
+		r.prefixRoutes = append(r.prefixRoutes, prefixRoute{prefix: prefix, datastore: ds})
+	}
+}
+
+// NewRoutingDatastore returns a datastore that routes the operations of each store to a datastore
+// determined by the provided options. Stores that don't match any route are served by the fallback.
+//
+// Operations that are not scoped to a single store are fanned out: ListStores lists the stores of every
+// datastore (one datastore after the other), IsReady reports ready only if all the datastores are ready,
+// and Close closes all of them.
+//
+// The operations of the optional backends of the datastores (see storage.DatastoreWrapper) are routed like
+// the others, and fail with an error wrapping storage.ErrUnsupported if the datastore of the store doesn't
+// implement them. The expired tuples are deleted from every datastore, and the maintenance tasks run on every
+// datastore that has them. Unwrap returns the fallback, so the datastores should all be of the same engine.
+func NewRoutingDatastore(fallback storage.OpenFGADatastore, opts ...RoutingDatastoreOption) storage.OpenFGADatastore {
+	r := &routingOpenFGADatastore{
+		fallback:    fallback,
+		storeRoutes: map[string]storage.OpenFGADatastore{},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	sort.SliceStable(r.prefixRoutes, func(i, j int) bool {
+		return len(r.prefixRoutes[i].prefix) > len(r.prefixRoutes[j].prefix)
+	})
+
+	return r
+}
+
+// route returns the datastore that holds the data of the provided store.
+func (r *routingOpenFGADatastore) route(store string) storage.OpenFGADatastore {
+	if ds, ok := r.storeRoutes[store]; ok {
+		return ds
+	}
+
+	for _, route := range r.prefixRoutes {
+		if strings.HasPrefix(store, route.prefix) {
+			return route.datastore
+		}
+	}
+
+	return r.fallback
+}
+
+// datastores returns every distinct underlying datastore in a deterministic order, starting with the fallback.
+func (r *routingOpenFGADatastore) datastores() []storage.OpenFGADatastore {
+	seen := map[storage.OpenFGADatastore]struct{}{r.fallback: {}}
+	datastores := []storage.OpenFGADatastore{r.fallback}
+
+	add := func(ds storage.OpenFGADatastore) {
+		if _, ok := seen[ds]; ok {
+			return
+		}
+		seen[ds] = struct{}{}
+		datastores = append(datastores, ds)
+	}
+
+	storeIDs := make([]string, 0, len(r.storeRoutes))
+	for storeID := range r.storeRoutes {
+		storeIDs = append(storeIDs, storeID)
+	}
+	sort.Strings(storeIDs)
+
+	for _, storeID := range storeIDs {
+		add(r.storeRoutes[storeID])
+	}
+
+	for _, route := range r.prefixRoutes {
+		add(route.datastore)
+	}
+
+	return datastores
+}
+
+func (r *routingOpenFGADatastore) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (storage.TupleIterator, error) {
+	return r.route(store).Read(ctx, store, tupleKey, options)
+}
+
+func (r *routingOpenFGADatastore) ReadPage(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, opts storage.PaginationOptions, options storage.ReadOptions) ([]*openfgav1.Tuple, []byte, error) {
+	return r.route(store).ReadPage(ctx, store, tupleKey, opts, options)
+}
+
+func (r *routingOpenFGADatastore) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (*openfgav1.Tuple, error) {
+	return r.route(store).ReadUserTuple(ctx, store, tupleKey, options)
+}
+
+func (r *routingOpenFGADatastore) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter, options storage.ReadOptions) (storage.TupleIterator, error) {
+	return r.route(store).ReadUsersetTuples(ctx, store, filter, options)
+}
+
+func (r *routingOpenFGADatastore) ReadStartingWithUser(ctx context.Context, store string, filter storage.ReadStartingWithUserFilter, options storage.ReadOptions) (storage.TupleIterator, error) {
+	return r.route(store).ReadStartingWithUser(ctx, store, filter, options)
+}
+
+func (r *routingOpenFGADatastore) Write(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes) error {
+	return r.route(store).Write(ctx, store, deletes, writes)
+}
+
+// MaxTuplesPerWrite returns the smallest limit among all the underlying datastores.
+func (r *routingOpenFGADatastore) MaxTuplesPerWrite() int {
+	limit := r.fallback.MaxTuplesPerWrite()
+	for _, ds := range r.datastores() {
+		limit = min(limit, ds.MaxTuplesPerWrite())
+	}
+
+	return limit
+}
+
+func (r *routingOpenFGADatastore) ReadAuthorizationModel(ctx context.Context, store string, id string) (*openfgav1.AuthorizationModel, error) {
+	return r.route(store).ReadAuthorizationModel(ctx, store, id)
+}
+
+func (r *routingOpenFGADatastore) ReadAuthorizationModels(ctx context.Context, store string, options storage.PaginationOptions) ([]*openfgav1.AuthorizationModel, []byte, error) {
+	return r.route(store).ReadAuthorizationModels(ctx, store, options)
+}
+
+func (r *routingOpenFGADatastore) FindLatestAuthorizationModelID(ctx context.Context, store string) (string, error) {
+	return r.route(store).FindLatestAuthorizationModelID(ctx, store)
+}
+
+// MaxTypesPerAuthorizationModel returns the smallest limit among all the underlying datastores.
+func (r *routingOpenFGADatastore) MaxTypesPerAuthorizationModel() int {
+	limit := r.fallback.MaxTypesPerAuthorizationModel()
+	for _, ds := range r.datastores() {
+		limit = min(limit, ds.MaxTypesPerAuthorizationModel())
+	}
+
+	return limit
+}
+
+func (r *routingOpenFGADatastore) WriteAuthorizationModel(ctx context.Context, store string, model *openfgav1.AuthorizationModel) error {
+	return r.route(store).WriteAuthorizationModel(ctx, store, model)
+}
+
+func (r *routingOpenFGADatastore) CreateStore(ctx context.Context, store *openfgav1.Store) (*openfgav1.Store, error) {
+	return r.route(store.GetId()).CreateStore(ctx, store)
+}
+
+func (r *routingOpenFGADatastore) DeleteStore(ctx context.Context, id string) error {
+	return r.route(id).DeleteStore(ctx, id)
+}
+
+func (r *routingOpenFGADatastore) GetStore(ctx context.Context, id string) (*openfgav1.Store, error) {
+	return r.route(id).GetStore(ctx, id)
+}
+
+// ListStores lists the stores of each underlying datastore, one datastore after the other. The
+// continuation token returned is of the form 'index|token', where index identifies the datastore
+// that is being listed and token is the continuation token returned by that datastore.
+//
+// A page never spans more than one datastore, so a page may have fewer stores than the requested
+// page size even though more stores remain to be listed.
+func (r *routingOpenFGADatastore) ListStores(ctx context.Context, paginationOptions storage.PaginationOptions) ([]*openfgav1.Store, []byte, error) {
+	datastores := r.datastores()
+
+	index := 0
+	innerOptions := paginationOptions
+	if paginationOptions.From != "" {
+		indexStr, token, found := strings.Cut(paginationOptions.From, "|")
+		if !found {
+			return nil, nil, storage.ErrInvalidContinuationToken
+		}
+
+		var err error
+		index, err = strconv.Atoi(indexStr)
+		if err != nil || index < 0 || index >= len(datastores) {
+			return nil, nil, storage.ErrInvalidContinuationToken
+		}
+
+		innerOptions.From = token
+	}
+
+	for ; index < len(datastores); index++ {
+		stores, token, err := datastores[index].ListStores(ctx, innerOptions)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(token) > 0 {
+			return stores, []byte(fmt.Sprintf("%d|%s", index, token)), nil
+		}
+
+		if index+1 < len(datastores) {
+			if len(stores) == 0 {
+				innerOptions.From = ""
+				continue
+			}
+
+			return stores, []byte(fmt.Sprintf("%d|", index+1)), nil
+		}
+
+		return stores, nil, nil
+	}
+
+	return nil, nil, nil
+}
+
+func (r *routingOpenFGADatastore) WriteAssertions(ctx context.Context, store, modelID string, assertions []*openfgav1.Assertion) error {
+	return r.route(store).WriteAssertions(ctx, store, modelID, assertions)
+}
+
+func (r *routingOpenFGADatastore) ReadAssertions(ctx context.Context, store, modelID string) ([]*openfgav1.Assertion, error) {
+	return r.route(store).ReadAssertions(ctx, store, modelID)
+}
+
+func (r *routingOpenFGADatastore) ReadAssertionsHistory(ctx context.Context, store, modelID string) ([]*storage.AssertionsVersion, error) {
+	return r.route(store).ReadAssertionsHistory(ctx, store, modelID)
+}
+
+func (r *routingOpenFGADatastore) DeleteAssertions(ctx context.Context, store, modelID string) error {
+	return r.route(store).DeleteAssertions(ctx, store, modelID)
+}
+
+func (r *routingOpenFGADatastore) WriteAuthorizationModelLabels(ctx context.Context, store, modelID string, labels map[string]string) error {
+	return r.route(store).WriteAuthorizationModelLabels(ctx, store, modelID, labels)
+}
+
+func (r *routingOpenFGADatastore) ReadAuthorizationModelLabels(ctx context.Context, store, modelID string) (map[string]string, error) {
+	return r.route(store).ReadAuthorizationModelLabels(ctx, store, modelID)
+}
+
+func (r *routingOpenFGADatastore) FindAuthorizationModelIDByLabel(ctx context.Context, store, key, value string) (string, error) {
+	return r.route(store).FindAuthorizationModelIDByLabel(ctx, store, key, value)
+}
+
+func (r *routingOpenFGADatastore) ReadChanges(ctx context.Context, store, objectType string, paginationOptions storage.PaginationOptions, horizonOffset time.Duration, options storage.ReadOptions) ([]*openfgav1.TupleChange, []byte, error) {
+	return r.route(store).ReadChanges(ctx, store, objectType, paginationOptions, horizonOffset, options)
+}
+
+func (r *routingOpenFGADatastore) WriteAuthorizationModelConditions(ctx context.Context, store, modelID string, conditions *condition.ModelConditions) error {
+	return forwardedBackends{r.route(store)}.WriteAuthorizationModelConditions(ctx, store, modelID, conditions)
+}
+
+func (r *routingOpenFGADatastore) ReadAuthorizationModelConditions(ctx context.Context, store, modelID string) (*condition.ModelConditions, error) {
+	return forwardedBackends{r.route(store)}.ReadAuthorizationModelConditions(ctx, store, modelID)
+}
+
+func (r *routingOpenFGADatastore) WriteWithConditions(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, conditions map[string]*condition.TupleCondition) error {
+	return forwardedBackends{r.route(store)}.WriteWithConditions(ctx, store, deletes, writes, conditions)
+}
+
+func (r *routingOpenFGADatastore) ReadTupleCondition(ctx context.Context, store string, tk *openfgav1.TupleKey) (*condition.TupleCondition, error) {
+	return forwardedBackends{r.route(store)}.ReadTupleCondition(ctx, store, tk)
+}
+
+func (r *routingOpenFGADatastore) ReadTupleConditions(ctx context.Context, store string, tks []*openfgav1.TupleKey) (map[string]*condition.TupleCondition, error) {
+	return forwardedBackends{r.route(store)}.ReadTupleConditions(ctx, store, tks)
+}
+
+func (r *routingOpenFGADatastore) WriteWithExpirations(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, expirations map[string]time.Time) error {
+	return forwardedBackends{r.route(store)}.WriteWithExpirations(ctx, store, deletes, writes, expirations)
+}
+
+// DeleteExpiredTuples deletes the expired tuples of all the underlying datastores, and returns the number of
+// tuples deleted from all of them.
+func (r *routingOpenFGADatastore) DeleteExpiredTuples(ctx context.Context, before time.Time) (int, error) {
+	var deleted int
+	for _, ds := range r.datastores() {
+		n, err := forwardedBackends{ds}.DeleteExpiredTuples(ctx, before)
+		deleted += n
+		if err != nil {
+			return deleted, err
+		}
+	}
+
+	return deleted, nil
+}
+
+func (r *routingOpenFGADatastore) WriteWithPreconditions(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, preconditions []storage.Precondition) error {
+	return forwardedBackends{r.route(store)}.WriteWithPreconditions(ctx, store, deletes, writes, preconditions)
+}
+
+func (r *routingOpenFGADatastore) SampleTuples(ctx context.Context, store string, filter *openfgav1.TupleKey, size int) ([]*openfgav1.TupleKey, error) {
+	return forwardedBackends{r.route(store)}.SampleTuples(ctx, store, filter, size)
+}
+
+func (r *routingOpenFGADatastore) RelationStats(ctx context.Context, store, objectType, relation string) (storage.TupleStats, error) {
+	return forwardedBackends{r.route(store)}.RelationStats(ctx, store, objectType, relation)
+}
+
+func (r *routingOpenFGADatastore) StoreStats(ctx context.Context, store string) (storage.TupleStats, error) {
+	return forwardedBackends{r.route(store)}.StoreStats(ctx, store)
+}
+
+// MaintenanceTasks returns the maintenance tasks of all the underlying datastores, in the order of the first
+// datastore having each. A task runs the tasks of the same name of every datastore, one after the other.
+func (r *routingOpenFGADatastore) MaintenanceTasks() []storage.MaintenanceTask {
+	var names []string
+	runs := map[string][]func(ctx context.Context) error{}
+	for _, ds := range r.datastores() {
+		for _, task := range (forwardedBackends{ds}).MaintenanceTasks() {
+			if _, ok := runs[task.Name]; !ok {
+				names = append(names, task.Name)
+			}
+			runs[task.Name] = append(runs[task.Name], task.Run)
+		}
+	}
+
+	tasks := make([]storage.MaintenanceTask, 0, len(names))
+	for _, name := range names {
+		name := name
+		tasks = append(tasks, storage.MaintenanceTask{
+			Name: name,
+			Run: func(ctx context.Context) error {
+				var errs []error
+				for _, run := range runs[name] {
+					errs = append(errs, run(ctx))
+				}
+
+				return errors.Join(errs...)
+			},
+		})
+	}
+
+	return tasks
+}
+
+// Unwrap returns the fallback datastore.
+func (r *routingOpenFGADatastore) Unwrap() storage.OpenFGADatastore {
+	return r.fallback
+}
+
+// IsReady reports whether all the underlying datastores are ready.
+func (r *routingOpenFGADatastore) IsReady(ctx context.Context) (bool, error) {
+	for _, ds := range r.datastores() {
+		ready, err := ds.IsReady(ctx)
+		if err != nil || !ready {
+			return ready, err
+		}
+	}
+
+	return true, nil
+}
+
+// Close closes all the underlying datastores.
+func (r *routingOpenFGADatastore) Close() {
+	for _, ds := range r.datastores() {
+		ds.Close()
+	}
+}
@@ -0,0 +1,131 @@
+package storagewrappers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/condition"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutingDatastore(t *testing.T) {
+	ctx := context.Background()
+
+	fallback := memory.New()
+	tenantA := memory.New()
+	tenantB := memory.New()
+
+	ds := NewRoutingDatastore(
+		fallback,
+		WithStorePrefixRoute("a", tenantA),
+		WithStorePrefixRoute("ab", tenantB),
+		WithStoreRoute("store-b", tenantB),
+	)
+	defer ds.Close()
+
+	for _, storeID := range []string{"a1", "ab1", "store-b", "other"} {
+		_, err := ds.CreateStore(ctx, &openfgav1.Store{Id: storeID, Name: storeID})
+		require.NoError(t, err)
+
+		err = ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:"+storeID)})
+		require.NoError(t, err)
+	}
+
+	t.Run("routes_by_longest_prefix_then_lookup_table", func(t *testing.T) {
+		for storeID, expected := range map[string]storage.OpenFGADatastore{
+			"a1":      tenantA,
+			"ab1":     tenantB,
+			"store-b": tenantB,
+			"other":   fallback,
+		} {
+			_, err := expected.GetStore(ctx, storeID)
+			require.NoError(t, err, storeID)
+
+			tk := tuple.NewTupleKey("document:1", "viewer", "user:"+storeID)
+			_, err = expected.ReadUserTuple(ctx, storeID, tk, storage.ReadOptions{})
+			require.NoError(t, err, storeID)
+
+			_, err = ds.ReadUserTuple(ctx, storeID, tk, storage.ReadOptions{})
+			require.NoError(t, err, storeID)
+		}
+
+		_, err := fallback.GetStore(ctx, "a1")
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("list_stores_spans_all_datastores", func(t *testing.T) {
+		var storeIDs []string
+		var token []byte
+		for {
+			stores, contToken, err := ds.ListStores(ctx, storage.PaginationOptions{PageSize: 1, From: string(token)})
+			require.NoError(t, err)
+
+			for _, store := range stores {
+				storeIDs = append(storeIDs, store.GetId())
+			}
+
+			if len(contToken) == 0 {
+				break
+			}
+			token = contToken
+		}
+
+		require.ElementsMatch(t, []string{"a1", "ab1", "store-b", "other"}, storeIDs)
+	})
+
+	t.Run("list_stores_with_invalid_token", func(t *testing.T) {
+		_, _, err := ds.ListStores(ctx, storage.PaginationOptions{PageSize: 1, From: "7|"})
+		require.ErrorIs(t, err, storage.ErrInvalidContinuationToken)
+	})
+
+	t.Run("routes_the_optional_backends", func(t *testing.T) {
+		tk := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+		expected := &condition.TupleCondition{Name: "ip_allowed"}
+
+		err := ds.(storage.ConditionsBackend).WriteWithConditions(ctx, "a1", nil, []*openfgav1.TupleKey{tk}, map[string]*condition.TupleCondition{
+			tuple.TupleKeyToString(tk): expected,
+		})
+		require.NoError(t, err)
+
+		tupleCondition, err := tenantA.(storage.ConditionsBackend).ReadTupleCondition(ctx, "a1", tk)
+		require.NoError(t, err)
+		require.Equal(t, expected, tupleCondition)
+
+		_, err = fallback.(storage.ConditionsBackend).ReadTupleCondition(ctx, "a1", tk)
+		require.ErrorIs(t, err, storage.ErrNotFound)
+
+		_, ok := storage.As[storage.PreconditionsBackend](ds)
+		require.True(t, ok)
+	})
+
+	t.Run("deletes_the_expired_tuples_of_every_datastore", func(t *testing.T) {
+		for storeID, expected := range map[string]storage.OpenFGADatastore{"ab1": tenantB, "other": fallback} {
+			tk := tuple.NewTupleKey("document:2", "viewer", "user:"+storeID)
+			err := ds.(storage.TupleExpirationBackend).WriteWithExpirations(ctx, storeID, nil, []*openfgav1.TupleKey{tk}, map[string]time.Time{
+				tuple.TupleKeyToString(tk): time.Now().Add(-time.Second),
+			})
+			require.NoError(t, err)
+
+			_, err = expected.ReadUserTuple(ctx, storeID, tk, storage.ReadOptions{})
+			require.ErrorIs(t, err, storage.ErrNotFound)
+		}
+
+		deleted, err := ds.(storage.TupleExpirationBackend).DeleteExpiredTuples(ctx, time.Now())
+		require.NoError(t, err)
+		require.Equal(t, 2, deleted)
+	})
+
+	t.Run("runs_the_maintenance_tasks_of_every_datastore", func(t *testing.T) {
+		tasks := ds.(storage.Maintainer).MaintenanceTasks()
+		require.Len(t, tasks, len(fallback.(storage.Maintainer).MaintenanceTasks()))
+
+		for _, task := range tasks {
+			require.NoError(t, task.Run(ctx), task.Name)
+		}
+	})
+}
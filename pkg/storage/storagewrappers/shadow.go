@@ -0,0 +1,651 @@
+package storagewrappers
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/condition"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultShadowTimeout        = 5 * time.Second
+	defaultShadowMaxConcurrency = 100
+
+	shadowComparisonMatch    = "match"
+	shadowComparisonMismatch = "mismatch"
+	shadowComparisonError    = "error"
+	shadowComparisonDropped  = "dropped"
+)
+
+var (
+	shadowComparisonCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "shadow_datastore_comparisons_total",
+		Help: "The total number of reads compared between the primary and the shadow datastore, by result (match, mismatch, error or dropped).",
+	}, []string{"operation", "result"})
+
+	shadowWriteErrorCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "shadow_datastore_write_errors_total",
+		Help: "The total number of writes that succeeded on the primary datastore but failed to be mirrored to the shadow datastore.",
+	}, []string{"operation"})
+)
+
+var (
+	_ storage.OpenFGADatastore       = (*shadowOpenFGADatastore)(nil)
+	_ storage.DatastoreWrapper       = (*shadowOpenFGADatastore)(nil)
+	_ storage.ConditionsBackend      = (*shadowOpenFGADatastore)(nil)
+	_ storage.TupleExpirationBackend = (*shadowOpenFGADatastore)(nil)
+	_ storage.PreconditionsBackend   = (*shadowOpenFGADatastore)(nil)
+	_ storage.TupleSampler           = (*shadowOpenFGADatastore)(nil)
+	_ storage.StatsProvider          = (*shadowOpenFGADatastore)(nil)
+	_ storage.Maintainer             = (*shadowOpenFGADatastore)(nil)
+)
+
+// shadowOpenFGADatastore is a datastore that serves every operation from a primary datastore and mirrors it to
+// a shadow datastore. Writes that succeed on the primary are applied to the shadow, and reads are replayed
+// against the shadow in the background and their results compared with the ones of the primary. This allows
+// a new datastore to be validated with production traffic before it becomes the primary.
+type shadowOpenFGADatastore struct {
+	// the optional backends are served by the primary
+	forwardedBackends
+
+	primary storage.OpenFGADatastore
+	shadow  storage.OpenFGADatastore
+
+	logger  logger.Logger
+	timeout time.Duration
+	limiter chan struct{}
+	wg      sync.WaitGroup
+}
+
+type ShadowDatastoreOption func(s *shadowOpenFGADatastore)
+
+// WithShadowLogger sets the logger used to report the mismatches and the errors of the shadow datastore.
+func WithShadowLogger(logger logger.Logger) ShadowDatastoreOption {
+	return func(s *shadowOpenFGADatastore) {
+		s.logger = logger
+	}
+}
+
+// WithShadowTimeout sets the maximum duration of a read replayed against the shadow datastore.
+func WithShadowTimeout(timeout time.Duration) ShadowDatastoreOption {
+	return func(s *shadowOpenFGADatastore) {
+		s.timeout = timeout
+	}
+}
+
+// WithShadowMaxConcurrency sets the maximum number of reads being compared at any time. Reads that would
+// exceed it aren't compared.
+func WithShadowMaxConcurrency(concurrency uint32) ShadowDatastoreOption {
+	return func(s *shadowOpenFGADatastore) {
+		s.limiter = make(chan struct{}, concurrency)
+	}
+}
+
+// NewShadowDatastore returns a datastore that serves every operation from the primary datastore and mirrors
+// it to the shadow datastore.
+//
+// Writes are applied to the shadow only once they succeed on the primary, and a failure to apply them is
+// logged but not returned. Reads are compared asynchronously and never affect the response: the tuples
+// are compared by key regardless of their order, and the reads that are paginated with a continuation
+// token, as well as ReadChanges and ListStores, aren't compared since their results are specific to each
+// datastore.
+//
+// The optional backends of the primary (see storage.DatastoreWrapper) are served by it, and their writes are
+// mirrored to the shadow with its own backends, or as plain writes if it doesn't implement them. Their reads
+// aren't compared.
+func NewShadowDatastore(primary, shadow storage.OpenFGADatastore, opts ...ShadowDatastoreOption) storage.OpenFGADatastore {
+	s := &shadowOpenFGADatastore{
+		forwardedBackends: forwardedBackends{primary},
+		primary:           primary,
+		shadow:            shadow,
+		logger:            logger.NewNoopLogger(),
+		timeout:           defaultShadowTimeout,
+		limiter:           make(chan struct{}, defaultShadowMaxConcurrency),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// compare runs the provided comparison against the shadow datastore in the background, unless too many
+// comparisons are already running.
+func (s *shadowOpenFGADatastore) compare(operation, store string, fn func(ctx context.Context) (bool, error)) {
+	select {
+	case s.limiter <- struct{}{}:
+	default:
+		shadowComparisonCounter.WithLabelValues(operation, shadowComparisonDropped).Inc()
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer func() {
+			<-s.limiter
+			s.wg.Done()
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		defer cancel()
+
+		match, err := fn(ctx)
+		switch {
+		case err != nil:
+			shadowComparisonCounter.WithLabelValues(operation, shadowComparisonError).Inc()
+			s.logger.Warn("shadow datastore read failed",
+				zap.String("operation", operation),
+				zap.String("store_id", store),
+				zap.Error(err),
+			)
+		case !match:
+			shadowComparisonCounter.WithLabelValues(operation, shadowComparisonMismatch).Inc()
+			s.logger.Warn("shadow datastore read mismatch",
+				zap.String("operation", operation),
+				zap.String("store_id", store),
+			)
+		default:
+			shadowComparisonCounter.WithLabelValues(operation, shadowComparisonMatch).Inc()
+		}
+	}()
+}
+
+// mirror applies a write that succeeded on the primary datastore to the shadow datastore.
+func (s *shadowOpenFGADatastore) mirror(ctx context.Context, operation, store string, fn func(ctx context.Context) error) {
+	if err := fn(ctx); err != nil {
+		shadowWriteErrorCounter.WithLabelValues(operation).Inc()
+		s.logger.WarnWithContext(ctx, "failed to mirror the write to the shadow datastore",
+			zap.String("operation", operation),
+			zap.String("store_id", store),
+			zap.Error(err),
+		)
+	}
+}
+
+// tupleKeys returns the sorted string representations of the keys of the provided tuples.
+func tupleKeys(tuples []*openfgav1.Tuple) []string {
+	keys := make([]string, 0, len(tuples))
+	for _, t := range tuples {
+		keys = append(keys, tuple.TupleKeyToString(t.GetKey()))
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func equalTuples(a, b []*openfgav1.Tuple) bool {
+	keysA, keysB := tupleKeys(a), tupleKeys(b)
+	if len(keysA) != len(keysB) {
+		return false
+	}
+
+	for i := range keysA {
+		if keysA[i] != keysB[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// equalErrors reports whether both datastores returned the same error, e.g. storage.ErrNotFound. The
+// errors that aren't shared (e.g. a timeout of the shadow) are reported as errors of the comparison.
+func equalErrors(primaryErr, shadowErr error) (bool, error) {
+	if primaryErr == nil && shadowErr == nil {
+		return true, nil
+	}
+
+	if primaryErr != nil && errors.Is(shadowErr, primaryErr) {
+		return true, nil
+	}
+
+	if shadowErr != nil && !errors.Is(shadowErr, storage.ErrNotFound) {
+		return false, shadowErr
+	}
+
+	return false, nil
+}
+
+func readAll(iter storage.TupleIterator) ([]*openfgav1.Tuple, error) {
+	defer iter.Stop()
+
+	var tuples []*openfgav1.Tuple
+	for {
+		t, err := iter.Next()
+		if err != nil {
+			if errors.Is(err, storage.ErrIteratorDone) {
+				return tuples, nil
+			}
+			return nil, err
+		}
+		tuples = append(tuples, t)
+	}
+}
+
+// shadowComparingIterator records the tuples returned by the primary datastore and, once they've all been
+// returned, compares them with the ones returned by the shadow datastore. The tuples of an iterator that is
+// stopped before it's exhausted aren't compared.
+type shadowComparingIterator struct {
+	storage.TupleIterator
+	tuples []*openfgav1.Tuple
+	done   bool
+	onDone func(tuples []*openfgav1.Tuple)
+}
+
+func (i *shadowComparingIterator) Next() (*openfgav1.Tuple, error) {
+	t, err := i.TupleIterator.Next()
+	if err != nil {
+		if errors.Is(err, storage.ErrIteratorDone) && !i.done {
+			i.done = true
+			i.onDone(i.tuples)
+		}
+		return nil, err
+	}
+
+	i.tuples = append(i.tuples, t)
+
+	return t, nil
+}
+
+func (s *shadowOpenFGADatastore) compareIterator(operation, store string, iter storage.TupleIterator, read func(ctx context.Context) (storage.TupleIterator, error)) storage.TupleIterator {
+	return &shadowComparingIterator{
+		TupleIterator: iter,
+		onDone: func(tuples []*openfgav1.Tuple) {
+			s.compare(operation, store, func(ctx context.Context) (bool, error) {
+				shadowIter, err := read(ctx)
+				if err != nil {
+					return false, err
+				}
+
+				shadowTuples, err := readAll(shadowIter)
+				if err != nil {
+					return false, err
+				}
+
+				return equalTuples(tuples, shadowTuples), nil
+			})
+		},
+	}
+}
+
+func (s *shadowOpenFGADatastore) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (storage.TupleIterator, error) {
+	iter, err := s.primary.Read(ctx, store, tupleKey, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.compareIterator("Read", store, iter, func(ctx context.Context) (storage.TupleIterator, error) {
+		return s.shadow.Read(ctx, store, tupleKey, options)
+	}), nil
+}
+
+func (s *shadowOpenFGADatastore) ReadPage(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, opts storage.PaginationOptions, options storage.ReadOptions) ([]*openfgav1.Tuple, []byte, error) {
+	tuples, token, err := s.primary.ReadPage(ctx, store, tupleKey, opts, options)
+	if err != nil || opts.From != "" {
+		return tuples, token, err
+	}
+
+	s.compare("ReadPage", store, func(ctx context.Context) (bool, error) {
+		shadowTuples, _, err := s.shadow.ReadPage(ctx, store, tupleKey, opts, options)
+		if err != nil {
+			return false, err
+		}
+
+		return equalTuples(tuples, shadowTuples), nil
+	})
+
+	return tuples, token, nil
+}
+
+func (s *shadowOpenFGADatastore) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (*openfgav1.Tuple, error) {
+	t, err := s.primary.ReadUserTuple(ctx, store, tupleKey, options)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+
+	s.compare("ReadUserTuple", store, func(ctx context.Context) (bool, error) {
+		shadowTuple, shadowErr := s.shadow.ReadUserTuple(ctx, store, tupleKey, options)
+		if err != nil || shadowErr != nil {
+			return equalErrors(err, shadowErr)
+		}
+
+		return proto.Equal(t.GetKey(), shadowTuple.GetKey()), nil
+	})
+
+	return t, err
+}
+
+func (s *shadowOpenFGADatastore) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter, options storage.ReadOptions) (storage.TupleIterator, error) {
+	iter, err := s.primary.ReadUsersetTuples(ctx, store, filter, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.compareIterator("ReadUsersetTuples", store, iter, func(ctx context.Context) (storage.TupleIterator, error) {
+		return s.shadow.ReadUsersetTuples(ctx, store, filter, options)
+	}), nil
+}
+
+func (s *shadowOpenFGADatastore) ReadStartingWithUser(ctx context.Context, store string, filter storage.ReadStartingWithUserFilter, options storage.ReadOptions) (storage.TupleIterator, error) {
+	iter, err := s.primary.ReadStartingWithUser(ctx, store, filter, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.compareIterator("ReadStartingWithUser", store, iter, func(ctx context.Context) (storage.TupleIterator, error) {
+		return s.shadow.ReadStartingWithUser(ctx, store, filter, options)
+	}), nil
+}
+
+func (s *shadowOpenFGADatastore) Write(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes) error {
+	if err := s.primary.Write(ctx, store, deletes, writes); err != nil {
+		return err
+	}
+
+	s.mirror(ctx, "Write", store, func(ctx context.Context) error {
+		return s.shadow.Write(ctx, store, deletes, writes)
+	})
+
+	return nil
+}
+
+// MaxTuplesPerWrite returns the smallest limit of the two datastores, so that every write can be mirrored.
+func (s *shadowOpenFGADatastore) MaxTuplesPerWrite() int {
+	return min(s.primary.MaxTuplesPerWrite(), s.shadow.MaxTuplesPerWrite())
+}
+
+func (s *shadowOpenFGADatastore) ReadAuthorizationModel(ctx context.Context, store string, id string) (*openfgav1.AuthorizationModel, error) {
+	model, err := s.primary.ReadAuthorizationModel(ctx, store, id)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+
+	s.compare("ReadAuthorizationModel", store, func(ctx context.Context) (bool, error) {
+		shadowModel, shadowErr := s.shadow.ReadAuthorizationModel(ctx, store, id)
+		if err != nil || shadowErr != nil {
+			return equalErrors(err, shadowErr)
+		}
+
+		return proto.Equal(model, shadowModel), nil
+	})
+
+	return model, err
+}
+
+func (s *shadowOpenFGADatastore) ReadAuthorizationModels(ctx context.Context, store string, options storage.PaginationOptions) ([]*openfgav1.AuthorizationModel, []byte, error) {
+	return s.primary.ReadAuthorizationModels(ctx, store, options)
+}
+
+func (s *shadowOpenFGADatastore) FindLatestAuthorizationModelID(ctx context.Context, store string) (string, error) {
+	id, err := s.primary.FindLatestAuthorizationModelID(ctx, store)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return "", err
+	}
+
+	s.compare("FindLatestAuthorizationModelID", store, func(ctx context.Context) (bool, error) {
+		shadowID, shadowErr := s.shadow.FindLatestAuthorizationModelID(ctx, store)
+		if err != nil || shadowErr != nil {
+			return equalErrors(err, shadowErr)
+		}
+
+		return id == shadowID, nil
+	})
+
+	return id, err
+}
+
+// MaxTypesPerAuthorizationModel returns the smallest limit of the two datastores, so that every model can
+// be mirrored.
+func (s *shadowOpenFGADatastore) MaxTypesPerAuthorizationModel() int {
+	return min(s.primary.MaxTypesPerAuthorizationModel(), s.shadow.MaxTypesPerAuthorizationModel())
+}
+
+func (s *shadowOpenFGADatastore) WriteAuthorizationModel(ctx context.Context, store string, model *openfgav1.AuthorizationModel) error {
+	if err := s.primary.WriteAuthorizationModel(ctx, store, model); err != nil {
+		return err
+	}
+
+	s.mirror(ctx, "WriteAuthorizationModel", store, func(ctx context.Context) error {
+		return s.shadow.WriteAuthorizationModel(ctx, store, model)
+	})
+
+	return nil
+}
+
+// CreateStore creates the store in the primary datastore and then creates it, with the same ID and name,
+// in the shadow datastore.
+func (s *shadowOpenFGADatastore) CreateStore(ctx context.Context, store *openfgav1.Store) (*openfgav1.Store, error) {
+	created, err := s.primary.CreateStore(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mirror(ctx, "CreateStore", created.GetId(), func(ctx context.Context) error {
+		_, err := s.shadow.CreateStore(ctx, &openfgav1.Store{Id: created.GetId(), Name: created.GetName()})
+		return err
+	})
+
+	return created, nil
+}
+
+func (s *shadowOpenFGADatastore) DeleteStore(ctx context.Context, id string) error {
+	if err := s.primary.DeleteStore(ctx, id); err != nil {
+		return err
+	}
+
+	s.mirror(ctx, "DeleteStore", id, func(ctx context.Context) error {
+		return s.shadow.DeleteStore(ctx, id)
+	})
+
+	return nil
+}
+
+func (s *shadowOpenFGADatastore) GetStore(ctx context.Context, id string) (*openfgav1.Store, error) {
+	store, err := s.primary.GetStore(ctx, id)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+
+	s.compare("GetStore", id, func(ctx context.Context) (bool, error) {
+		shadowStore, shadowErr := s.shadow.GetStore(ctx, id)
+		if err != nil || shadowErr != nil {
+			return equalErrors(err, shadowErr)
+		}
+
+		// the timestamps are set by each datastore
+		return store.GetId() == shadowStore.GetId() && store.GetName() == shadowStore.GetName(), nil
+	})
+
+	return store, err
+}
+
+func (s *shadowOpenFGADatastore) ListStores(ctx context.Context, paginationOptions storage.PaginationOptions) ([]*openfgav1.Store, []byte, error) {
+	return s.primary.ListStores(ctx, paginationOptions)
+}
+
+func (s *shadowOpenFGADatastore) WriteAssertions(ctx context.Context, store, modelID string, assertions []*openfgav1.Assertion) error {
+	if err := s.primary.WriteAssertions(ctx, store, modelID, assertions); err != nil {
+		return err
+	}
+
+	s.mirror(ctx, "WriteAssertions", store, func(ctx context.Context) error {
+		return s.shadow.WriteAssertions(ctx, store, modelID, assertions)
+	})
+
+	return nil
+}
+
+func (s *shadowOpenFGADatastore) ReadAssertions(ctx context.Context, store, modelID string) ([]*openfgav1.Assertion, error) {
+	assertions, err := s.primary.ReadAssertions(ctx, store, modelID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.compare("ReadAssertions", store, func(ctx context.Context) (bool, error) {
+		shadowAssertions, err := s.shadow.ReadAssertions(ctx, store, modelID)
+		if err != nil {
+			return false, err
+		}
+
+		if len(assertions) != len(shadowAssertions) {
+			return false, nil
+		}
+
+		for i := range assertions {
+			if !proto.Equal(assertions[i], shadowAssertions[i]) {
+				return false, nil
+			}
+		}
+
+		return true, nil
+	})
+
+	return assertions, nil
+}
+
+func (s *shadowOpenFGADatastore) ReadAssertionsHistory(ctx context.Context, store, modelID string) ([]*storage.AssertionsVersion, error) {
+	return s.primary.ReadAssertionsHistory(ctx, store, modelID)
+}
+
+func (s *shadowOpenFGADatastore) DeleteAssertions(ctx context.Context, store, modelID string) error {
+	if err := s.primary.DeleteAssertions(ctx, store, modelID); err != nil {
+		return err
+	}
+
+	s.mirror(ctx, "DeleteAssertions", store, func(ctx context.Context) error {
+		return s.shadow.DeleteAssertions(ctx, store, modelID)
+	})
+
+	return nil
+}
+
+func (s *shadowOpenFGADatastore) WriteAuthorizationModelLabels(ctx context.Context, store, modelID string, labels map[string]string) error {
+	if err := s.primary.WriteAuthorizationModelLabels(ctx, store, modelID, labels); err != nil {
+		return err
+	}
+
+	s.mirror(ctx, "WriteAuthorizationModelLabels", store, func(ctx context.Context) error {
+		return s.shadow.WriteAuthorizationModelLabels(ctx, store, modelID, labels)
+	})
+
+	return nil
+}
+
+func (s *shadowOpenFGADatastore) ReadAuthorizationModelLabels(ctx context.Context, store, modelID string) (map[string]string, error) {
+	return s.primary.ReadAuthorizationModelLabels(ctx, store, modelID)
+}
+
+func (s *shadowOpenFGADatastore) FindAuthorizationModelIDByLabel(ctx context.Context, store, key, value string) (string, error) {
+	return s.primary.FindAuthorizationModelIDByLabel(ctx, store, key, value)
+}
+
+func (s *shadowOpenFGADatastore) ReadChanges(ctx context.Context, store, objectType string, paginationOptions storage.PaginationOptions, horizonOffset time.Duration, options storage.ReadOptions) ([]*openfgav1.TupleChange, []byte, error) {
+	return s.primary.ReadChanges(ctx, store, objectType, paginationOptions, horizonOffset, options)
+}
+
+func (s *shadowOpenFGADatastore) WriteAuthorizationModelConditions(ctx context.Context, store, modelID string, conditions *condition.ModelConditions) error {
+	if err := s.forwardedBackends.WriteAuthorizationModelConditions(ctx, store, modelID, conditions); err != nil {
+		return err
+	}
+
+	if shadow, ok := s.shadow.(storage.ConditionsBackend); ok {
+		s.mirror(ctx, "WriteAuthorizationModelConditions", store, func(ctx context.Context) error {
+			return shadow.WriteAuthorizationModelConditions(ctx, store, modelID, conditions)
+		})
+	}
+
+	return nil
+}
+
+func (s *shadowOpenFGADatastore) WriteWithConditions(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, conditions map[string]*condition.TupleCondition) error {
+	if err := s.forwardedBackends.WriteWithConditions(ctx, store, deletes, writes, conditions); err != nil {
+		return err
+	}
+
+	s.mirror(ctx, "WriteWithConditions", store, func(ctx context.Context) error {
+		if shadow, ok := s.shadow.(storage.ConditionsBackend); ok {
+			return shadow.WriteWithConditions(ctx, store, deletes, writes, conditions)
+		}
+
+		return s.shadow.Write(ctx, store, deletes, writes)
+	})
+
+	return nil
+}
+
+func (s *shadowOpenFGADatastore) WriteWithExpirations(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, expirations map[string]time.Time) error {
+	if err := s.forwardedBackends.WriteWithExpirations(ctx, store, deletes, writes, expirations); err != nil {
+		return err
+	}
+
+	s.mirror(ctx, "WriteWithExpirations", store, func(ctx context.Context) error {
+		if shadow, ok := s.shadow.(storage.TupleExpirationBackend); ok {
+			return shadow.WriteWithExpirations(ctx, store, deletes, writes, expirations)
+		}
+
+		return s.shadow.Write(ctx, store, deletes, writes)
+	})
+
+	return nil
+}
+
+// DeleteExpiredTuples deletes the expired tuples of the primary, and then of the shadow if its tuples may expire.
+// It returns the number of tuples deleted from the primary.
+func (s *shadowOpenFGADatastore) DeleteExpiredTuples(ctx context.Context, before time.Time) (int, error) {
+	deleted, err := s.forwardedBackends.DeleteExpiredTuples(ctx, before)
+	if err != nil {
+		return deleted, err
+	}
+
+	if shadow, ok := s.shadow.(storage.TupleExpirationBackend); ok {
+		s.mirror(ctx, "DeleteExpiredTuples", "", func(ctx context.Context) error {
+			_, err := shadow.DeleteExpiredTuples(ctx, before)
+			return err
+		})
+	}
+
+	return deleted, nil
+}
+
+// WriteWithPreconditions applies the write to the shadow without the preconditions once they held on the
+// primary, so that the shadow follows the primary even if it has diverged from it.
+func (s *shadowOpenFGADatastore) WriteWithPreconditions(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, preconditions []storage.Precondition) error {
+	if err := s.forwardedBackends.WriteWithPreconditions(ctx, store, deletes, writes, preconditions); err != nil {
+		return err
+	}
+
+	s.mirror(ctx, "WriteWithPreconditions", store, func(ctx context.Context) error {
+		return s.shadow.Write(ctx, store, deletes, writes)
+	})
+
+	return nil
+}
+
+// Unwrap returns the primary datastore.
+func (s *shadowOpenFGADatastore) Unwrap() storage.OpenFGADatastore {
+	return s.primary
+}
+
+// IsReady reports whether the primary datastore is ready. The shadow datastore doesn't serve any traffic.
+func (s *shadowOpenFGADatastore) IsReady(ctx context.Context) (bool, error) {
+	return s.primary.IsReady(ctx)
+}
+
+// Close waits for the comparisons that are running and closes both datastores.
+func (s *shadowOpenFGADatastore) Close() {
+	s.wg.Wait()
+	s.primary.Close()
+	s.shadow.Close()
+}
@@ -0,0 +1,128 @@
+package storagewrappers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func readAllTuples(t *testing.T, iter storage.TupleIterator) []*openfgav1.Tuple {
+	tuples, err := readAll(iter)
+	require.NoError(t, err)
+	return tuples
+}
+
+func TestShadowDatastore(t *testing.T) {
+	ctx := context.Background()
+
+	primary := memory.New()
+	shadow := memory.New()
+
+	ds := NewShadowDatastore(primary, shadow).(*shadowOpenFGADatastore)
+	defer ds.Close()
+
+	store, err := ds.CreateStore(ctx, &openfgav1.Store{Id: "store", Name: "store"})
+	require.NoError(t, err)
+
+	tk := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+	require.NoError(t, ds.Write(ctx, store.GetId(), nil, []*openfgav1.TupleKey{tk}))
+
+	t.Run("mirrors_writes", func(t *testing.T) {
+		shadowStore, err := shadow.GetStore(ctx, store.GetId())
+		require.NoError(t, err)
+		require.Equal(t, store.GetName(), shadowStore.GetName())
+
+		_, err = shadow.ReadUserTuple(ctx, store.GetId(), tk, storage.ReadOptions{})
+		require.NoError(t, err)
+	})
+
+	counter := func(operation, result string) float64 {
+		return testutil.ToFloat64(shadowComparisonCounter.WithLabelValues(operation, result))
+	}
+
+	t.Run("compares_reads", func(t *testing.T) {
+		before := counter("Read", shadowComparisonMatch)
+
+		iter, err := ds.Read(ctx, store.GetId(), tuple.NewTupleKey("document:1", "", ""), storage.ReadOptions{})
+		require.NoError(t, err)
+		require.Len(t, readAllTuples(t, iter), 1)
+
+		ds.wg.Wait()
+		require.Equal(t, before+1, counter("Read", shadowComparisonMatch))
+	})
+
+	t.Run("detects_mismatches", func(t *testing.T) {
+		// a tuple that only the primary has
+		other := tuple.NewTupleKey("document:1", "viewer", "user:bob")
+		require.NoError(t, primary.Write(ctx, store.GetId(), nil, []*openfgav1.TupleKey{other}))
+
+		beforeRead := counter("Read", shadowComparisonMismatch)
+		beforeReadUserTuple := counter("ReadUserTuple", shadowComparisonMismatch)
+
+		iter, err := ds.Read(ctx, store.GetId(), tuple.NewTupleKey("document:1", "", ""), storage.ReadOptions{})
+		require.NoError(t, err)
+		require.Len(t, readAllTuples(t, iter), 2)
+
+		_, err = ds.ReadUserTuple(ctx, store.GetId(), other, storage.ReadOptions{})
+		require.NoError(t, err)
+
+		ds.wg.Wait()
+		require.Equal(t, beforeRead+1, counter("Read", shadowComparisonMismatch))
+		require.Equal(t, beforeReadUserTuple+1, counter("ReadUserTuple", shadowComparisonMismatch))
+	})
+
+	t.Run("shared_not_found_errors_match", func(t *testing.T) {
+		before := counter("ReadAuthorizationModel", shadowComparisonMatch)
+
+		_, err := ds.ReadAuthorizationModel(ctx, store.GetId(), "missing")
+		require.ErrorIs(t, err, storage.ErrNotFound)
+
+		ds.wg.Wait()
+		require.Equal(t, before+1, counter("ReadAuthorizationModel", shadowComparisonMatch))
+	})
+
+	t.Run("iterators_stopped_early_are_not_compared", func(t *testing.T) {
+		before := counter("Read", shadowComparisonMatch) + counter("Read", shadowComparisonMismatch)
+
+		iter, err := ds.Read(ctx, store.GetId(), tuple.NewTupleKey("document:1", "", ""), storage.ReadOptions{})
+		require.NoError(t, err)
+		_, err = iter.Next()
+		require.NoError(t, err)
+		iter.Stop()
+
+		ds.wg.Wait()
+		require.Equal(t, before, counter("Read", shadowComparisonMatch)+counter("Read", shadowComparisonMismatch))
+	})
+}
+
+type failingWriteDatastore struct {
+	storage.OpenFGADatastore
+}
+
+func (f *failingWriteDatastore) Write(context.Context, string, storage.Deletes, storage.Writes) error {
+	return errors.New("shadow unavailable")
+}
+
+func TestShadowDatastoreWriteErrorsAreNotReturned(t *testing.T) {
+	ctx := context.Background()
+
+	primary := memory.New()
+	ds := NewShadowDatastore(primary, &failingWriteDatastore{OpenFGADatastore: memory.New()})
+	defer ds.Close()
+
+	before := testutil.ToFloat64(shadowWriteErrorCounter.WithLabelValues("Write"))
+
+	tk := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+	require.NoError(t, ds.Write(ctx, "store", nil, []*openfgav1.TupleKey{tk}))
+
+	_, err := primary.ReadUserTuple(ctx, "store", tk, storage.ReadOptions{})
+	require.NoError(t, err)
+	require.Equal(t, before+1, testutil.ToFloat64(shadowWriteErrorCounter.WithLabelValues("Write")))
+}
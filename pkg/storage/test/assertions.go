@@ -82,4 +82,84 @@ func AssertionsTest(t *testing.T, datastore storage.OpenFGADatastore) {
 
 		require.Empty(t, gotAssertions)
 	})
+
+	t.Run("every_write_creates_a_new_version", func(t *testing.T) {
+		store := ulid.Make().String()
+		modelID := ulid.Make().String()
+		first := []*openfgav1.Assertion{
+			{
+				TupleKey:    &openfgav1.TupleKey{Object: "doc:readme", Relation: "owner", User: "10"},
+				Expectation: false,
+			},
+		}
+		second := []*openfgav1.Assertion{
+			{
+				TupleKey:    &openfgav1.TupleKey{Object: "doc:readme", Relation: "viewer", User: "11"},
+				Expectation: true,
+			},
+		}
+
+		history, err := datastore.ReadAssertionsHistory(ctx, store, modelID)
+		require.NoError(t, err)
+		require.Empty(t, history)
+
+		err = datastore.WriteAssertions(ctx, store, modelID, first)
+		require.NoError(t, err)
+
+		err = datastore.WriteAssertions(ctx, store, modelID, second)
+		require.NoError(t, err)
+
+		history, err = datastore.ReadAssertionsHistory(ctx, store, modelID)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+
+		require.Equal(t, uint32(2), history[0].Version)
+		require.False(t, history[0].CreatedAt.IsZero())
+		if diff := cmp.Diff(second, history[0].Assertions, cmpOpts...); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+
+		require.Equal(t, uint32(1), history[1].Version)
+		if diff := cmp.Diff(first, history[1].Assertions, cmpOpts...); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("deleting_assertions_deletes_their_history", func(t *testing.T) {
+		store := ulid.Make().String()
+		modelID := ulid.Make().String()
+		otherModelID := ulid.Make().String()
+		assertions := []*openfgav1.Assertion{
+			{
+				TupleKey:    &openfgav1.TupleKey{Object: "doc:readme", Relation: "owner", User: "10"},
+				Expectation: false,
+			},
+		}
+
+		err := datastore.WriteAssertions(ctx, store, modelID, assertions)
+		require.NoError(t, err)
+
+		err = datastore.WriteAssertions(ctx, store, otherModelID, assertions)
+		require.NoError(t, err)
+
+		err = datastore.DeleteAssertions(ctx, store, modelID)
+		require.NoError(t, err)
+
+		gotAssertions, err := datastore.ReadAssertions(ctx, store, modelID)
+		require.NoError(t, err)
+		require.Empty(t, gotAssertions)
+
+		history, err := datastore.ReadAssertionsHistory(ctx, store, modelID)
+		require.NoError(t, err)
+		require.Empty(t, history)
+
+		// the assertions of other models are left untouched
+		gotAssertions, err = datastore.ReadAssertions(ctx, store, otherModelID)
+		require.NoError(t, err)
+		require.Len(t, gotAssertions, 1)
+
+		// deleting assertions that don't exist succeeds
+		err = datastore.DeleteAssertions(ctx, store, modelID)
+		require.NoError(t, err)
+	})
 }
@@ -160,3 +160,72 @@ func FindLatestAuthorizationModelIDTest(t *testing.T, datastore storage.OpenFGAD
 		require.Equal(t, newModel.Id, latestID)
 	})
 }
+
+func AuthorizationModelLabelsTest(t *testing.T, datastore storage.OpenFGADatastore) {
+	ctx := context.Background()
+
+	writeModel := func(t *testing.T, store string) string {
+		model := &openfgav1.AuthorizationModel{
+			Id:            ulid.Make().String(),
+			SchemaVersion: typesystem.SchemaVersion1_1,
+			TypeDefinitions: []*openfgav1.TypeDefinition{
+				{
+					Type: "user",
+				},
+			},
+		}
+		err := datastore.WriteAuthorizationModel(ctx, store, model)
+		require.NoError(t, err)
+
+		return model.GetId()
+	}
+
+	t.Run("labels_of_a_missing_model_cannot_be_written", func(t *testing.T) {
+		err := datastore.WriteAuthorizationModelLabels(ctx, ulid.Make().String(), ulid.Make().String(), map[string]string{"release": "42"})
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("writing_and_reading_labels_succeeds", func(t *testing.T) {
+		store := ulid.Make().String()
+		modelID := writeModel(t, store)
+
+		labels, err := datastore.ReadAuthorizationModelLabels(ctx, store, modelID)
+		require.NoError(t, err)
+		require.Empty(t, labels)
+
+		err = datastore.WriteAuthorizationModelLabels(ctx, store, modelID, map[string]string{"release": "41", "git_sha": "3f2a9c1"})
+		require.NoError(t, err)
+
+		// the labels with the same keys are replaced, and the others are kept
+		err = datastore.WriteAuthorizationModelLabels(ctx, store, modelID, map[string]string{"release": "42"})
+		require.NoError(t, err)
+
+		labels, err = datastore.ReadAuthorizationModelLabels(ctx, store, modelID)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"release": "42", "git_sha": "3f2a9c1"}, labels)
+	})
+
+	t.Run("find_by_label_returns_the_latest_model_with_the_label", func(t *testing.T) {
+		store := ulid.Make().String()
+
+		_, err := datastore.FindAuthorizationModelIDByLabel(ctx, store, "release", "42")
+		require.ErrorIs(t, err, storage.ErrNotFound)
+
+		oldModelID := writeModel(t, store)
+		err = datastore.WriteAuthorizationModelLabels(ctx, store, oldModelID, map[string]string{"release": "42"})
+		require.NoError(t, err)
+
+		newModelID := writeModel(t, store)
+		err = datastore.WriteAuthorizationModelLabels(ctx, store, newModelID, map[string]string{"release": "42"})
+		require.NoError(t, err)
+
+		writeModel(t, store)
+
+		modelID, err := datastore.FindAuthorizationModelIDByLabel(ctx, store, "release", "42")
+		require.NoError(t, err)
+		require.Equal(t, newModelID, modelID)
+
+		_, err = datastore.FindAuthorizationModelIDByLabel(ctx, store, "release", "43")
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+}
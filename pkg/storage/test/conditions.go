@@ -0,0 +1,108 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/condition"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+func ConditionsTest(t *testing.T, datastore storage.OpenFGADatastore, conditions storage.ConditionsBackend) {
+	ctx := context.Background()
+
+	t.Run("conditions_of_a_missing_model_cannot_be_written", func(t *testing.T) {
+		err := conditions.WriteAuthorizationModelConditions(ctx, ulid.Make().String(), ulid.Make().String(), &condition.ModelConditions{})
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("writing_and_reading_model_conditions_succeeds", func(t *testing.T) {
+		store := ulid.Make().String()
+		model := &openfgav1.AuthorizationModel{
+			Id:            ulid.Make().String(),
+			SchemaVersion: typesystem.SchemaVersion1_1,
+			TypeDefinitions: []*openfgav1.TypeDefinition{
+				{
+					Type: "user",
+				},
+			},
+		}
+		err := datastore.WriteAuthorizationModel(ctx, store, model)
+		require.NoError(t, err)
+
+		modelConditions, err := conditions.ReadAuthorizationModelConditions(ctx, store, model.GetId())
+		require.NoError(t, err)
+		require.Nil(t, modelConditions)
+
+		expected := &condition.ModelConditions{
+			Conditions: []*condition.Condition{
+				{
+					Name:       "ip_allowed",
+					Expression: "ip in allowed_ips",
+					Parameters: map[string]condition.ParameterType{
+						"ip":          condition.ParameterTypeString,
+						"allowed_ips": condition.ParameterTypeList,
+					},
+				},
+			},
+			TypeRestrictions: []*condition.TypeRestriction{
+				{Type: "document", Relation: "viewer", UserType: "user", Condition: "ip_allowed"},
+			},
+		}
+		err = conditions.WriteAuthorizationModelConditions(ctx, store, model.GetId(), expected)
+		require.NoError(t, err)
+
+		modelConditions, err = conditions.ReadAuthorizationModelConditions(ctx, store, model.GetId())
+		require.NoError(t, err)
+		require.Equal(t, expected, modelConditions)
+	})
+
+	t.Run("tuples_carry_their_conditions_until_deleted", func(t *testing.T) {
+		store := ulid.Make().String()
+		conditioned := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+		unconditioned := tuple.NewTupleKey("document:1", "viewer", "user:bob")
+		expected := &condition.TupleCondition{
+			Name:    "ip_allowed",
+			Context: map[string]interface{}{"allowed_ips": []interface{}{"10.0.0.1"}},
+		}
+
+		_, err := conditions.ReadTupleCondition(ctx, store, conditioned)
+		require.ErrorIs(t, err, storage.ErrNotFound)
+
+		err = conditions.WriteWithConditions(ctx, store, nil, []*openfgav1.TupleKey{conditioned, unconditioned}, map[string]*condition.TupleCondition{
+			tuple.TupleKeyToString(conditioned): expected,
+		})
+		require.NoError(t, err)
+
+		tupleCondition, err := conditions.ReadTupleCondition(ctx, store, conditioned)
+		require.NoError(t, err)
+		require.Equal(t, expected, tupleCondition)
+
+		tupleCondition, err = conditions.ReadTupleCondition(ctx, store, unconditioned)
+		require.NoError(t, err)
+		require.Nil(t, tupleCondition)
+
+		missing := tuple.NewTupleKey("document:1", "viewer", "user:carl")
+		tupleConditions, err := conditions.ReadTupleConditions(ctx, store, []*openfgav1.TupleKey{conditioned, unconditioned, missing})
+		require.NoError(t, err)
+		require.Equal(t, map[string]*condition.TupleCondition{
+			tuple.TupleKeyToString(conditioned):   expected,
+			tuple.TupleKeyToString(unconditioned): nil,
+		}, tupleConditions)
+
+		// the tuple written again without a condition doesn't carry the condition of the tuple deleted
+		err = datastore.Write(ctx, store, []*openfgav1.TupleKey{conditioned}, nil)
+		require.NoError(t, err)
+		err = datastore.Write(ctx, store, nil, []*openfgav1.TupleKey{conditioned})
+		require.NoError(t, err)
+
+		tupleCondition, err = conditions.ReadTupleCondition(ctx, store, conditioned)
+		require.NoError(t, err)
+		require.Nil(t, tupleCondition)
+	})
+}
@@ -0,0 +1,179 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/stretchr/testify/require"
+)
+
+func ExpirationsTest(t *testing.T, datastore storage.OpenFGADatastore, expirations storage.TupleExpirationBackend) {
+	ctx := context.Background()
+
+	expired := tuple.NewTupleKey("document:1", "viewer", "user:expired")
+	live := tuple.NewTupleKey("document:1", "viewer", "user:live")
+	permanent := tuple.NewTupleKey("document:1", "viewer", "user:permanent")
+	expiredUserset := tuple.NewTupleKey("document:1", "viewer", "group:eng#member")
+
+	// writeTuples writes the tuples to a new store, the expired ones an hour ago and the live one in an hour
+	writeTuples := func(t *testing.T) string {
+		store := ulid.Make().String()
+
+		err := expirations.WriteWithExpirations(ctx, store, nil, []*openfgav1.TupleKey{expired, live, permanent, expiredUserset}, map[string]time.Time{
+			tuple.TupleKeyToString(expired):        time.Now().Add(-time.Hour),
+			tuple.TupleKeyToString(live):           time.Now().Add(time.Hour),
+			tuple.TupleKeyToString(expiredUserset): time.Now().Add(-time.Hour),
+		})
+		require.NoError(t, err)
+
+		return store
+	}
+
+	t.Run("expired_tuples_are_not_read", func(t *testing.T) {
+		store := writeTuples(t)
+
+		iter, err := datastore.Read(ctx, store, tuple.NewTupleKey("document:1", "", ""), storage.ReadOptions{})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{tuple.TupleKeyToString(live), tuple.TupleKeyToString(permanent)}, readTupleKeys(t, iter))
+
+		tuples, _, err := datastore.ReadPage(ctx, store, tuple.NewTupleKey("document:", "", ""), storage.PaginationOptions{PageSize: 10}, storage.ReadOptions{})
+		require.NoError(t, err)
+		require.Len(t, tuples, 2)
+
+		_, err = datastore.ReadUserTuple(ctx, store, expired, storage.ReadOptions{})
+		require.ErrorIs(t, err, storage.ErrNotFound)
+
+		_, err = datastore.ReadUserTuple(ctx, store, live, storage.ReadOptions{})
+		require.NoError(t, err)
+
+		iter, err = datastore.ReadUsersetTuples(ctx, store, storage.ReadUsersetTuplesFilter{Object: "document:1", Relation: "viewer"}, storage.ReadOptions{})
+		require.NoError(t, err)
+		require.Empty(t, readTupleKeys(t, iter))
+
+		iter, err = datastore.ReadStartingWithUser(ctx, store, storage.ReadStartingWithUserFilter{
+			ObjectType: "document",
+			Relation:   "viewer",
+			UserFilter: []*openfgav1.ObjectRelation{{Object: "user:expired"}, {Object: "user:live"}},
+		}, storage.ReadOptions{})
+		require.NoError(t, err)
+		require.Equal(t, []string{tuple.TupleKeyToString(live)}, readTupleKeys(t, iter))
+	})
+
+	t.Run("expirations_of_the_tuples_read_are_tracked", func(t *testing.T) {
+		store := writeTuples(t)
+
+		// the expiration of the live tuple, up to the precision of the datastores
+		assertTracked := func(t *testing.T, tracker *storage.ExpirationTracker) {
+			expiresAt, ok := tracker.Earliest()
+			require.True(t, ok)
+			require.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+		}
+
+		trackedCtx, tracker := storage.ContextWithExpirationTracker(ctx)
+		iter, err := datastore.Read(trackedCtx, store, tuple.NewTupleKey("document:1", "", ""), storage.ReadOptions{})
+		require.NoError(t, err)
+		readTupleKeys(t, iter)
+		assertTracked(t, tracker)
+
+		trackedCtx, tracker = storage.ContextWithExpirationTracker(ctx)
+		_, _, err = datastore.ReadPage(trackedCtx, store, tuple.NewTupleKey("document:", "", ""), storage.PaginationOptions{PageSize: 10}, storage.ReadOptions{})
+		require.NoError(t, err)
+		assertTracked(t, tracker)
+
+		trackedCtx, tracker = storage.ContextWithExpirationTracker(ctx)
+		_, err = datastore.ReadUserTuple(trackedCtx, store, live, storage.ReadOptions{})
+		require.NoError(t, err)
+		assertTracked(t, tracker)
+
+		trackedCtx, tracker = storage.ContextWithExpirationTracker(ctx)
+		iter, err = datastore.ReadStartingWithUser(trackedCtx, store, storage.ReadStartingWithUserFilter{
+			ObjectType: "document",
+			Relation:   "viewer",
+			UserFilter: []*openfgav1.ObjectRelation{{Object: "user:live"}},
+		}, storage.ReadOptions{})
+		require.NoError(t, err)
+		readTupleKeys(t, iter)
+		assertTracked(t, tracker)
+
+		// the tuples which don't expire aren't tracked
+		trackedCtx, tracker = storage.ContextWithExpirationTracker(ctx)
+		_, err = datastore.ReadUserTuple(trackedCtx, store, permanent, storage.ReadOptions{})
+		require.NoError(t, err)
+		_, ok := tracker.Earliest()
+		require.False(t, ok)
+	})
+
+	t.Run("expired_tuples_cannot_be_deleted", func(t *testing.T) {
+		store := writeTuples(t)
+
+		err := datastore.Write(ctx, store, []*openfgav1.TupleKey{expired}, nil)
+		require.ErrorIs(t, err, storage.ErrInvalidWriteInput)
+
+		err = datastore.Write(ctx, store, []*openfgav1.TupleKey{live}, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("expired_tuples_can_be_written_again", func(t *testing.T) {
+		store := writeTuples(t)
+
+		err := datastore.Write(ctx, store, nil, []*openfgav1.TupleKey{expired})
+		require.NoError(t, err)
+
+		_, err = datastore.ReadUserTuple(ctx, store, expired, storage.ReadOptions{})
+		require.NoError(t, err)
+
+		// the tuple written again doesn't expire
+		_, err = expirations.DeleteExpiredTuples(ctx, time.Now())
+		require.NoError(t, err)
+
+		_, err = datastore.ReadUserTuple(ctx, store, expired, storage.ReadOptions{})
+		require.NoError(t, err)
+
+		// the expired tuple is deleted before it's written again
+		changes, _, err := datastore.ReadChanges(ctx, store, "", storage.PaginationOptions{PageSize: 10}, 0, storage.ReadOptions{})
+		require.NoError(t, err)
+
+		var operations []openfgav1.TupleOperation
+		for _, change := range changes[4:] {
+			if tuple.TupleKeyToString(change.GetTupleKey()) == tuple.TupleKeyToString(expired) {
+				operations = append(operations, change.GetOperation())
+			}
+		}
+		require.Equal(t, []openfgav1.TupleOperation{
+			openfgav1.TupleOperation_TUPLE_OPERATION_DELETE,
+			openfgav1.TupleOperation_TUPLE_OPERATION_WRITE,
+		}, operations)
+	})
+
+	t.Run("expired_tuples_are_deleted", func(t *testing.T) {
+		store := writeTuples(t)
+
+		deleted, err := expirations.DeleteExpiredTuples(ctx, time.Now())
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, deleted, 2)
+
+		changes, _, err := datastore.ReadChanges(ctx, store, "", storage.PaginationOptions{PageSize: 10}, 0, storage.ReadOptions{})
+		require.NoError(t, err)
+		require.Len(t, changes, 6)
+
+		var deletes []string
+		for _, change := range changes[4:] {
+			require.Equal(t, openfgav1.TupleOperation_TUPLE_OPERATION_DELETE, change.GetOperation())
+			deletes = append(deletes, tuple.TupleKeyToString(change.GetTupleKey()))
+		}
+		require.ElementsMatch(t, []string{tuple.TupleKeyToString(expired), tuple.TupleKeyToString(expiredUserset)}, deletes)
+
+		// the live tuple is deleted once it expires
+		_, err = expirations.DeleteExpiredTuples(ctx, time.Now().Add(2*time.Hour))
+		require.NoError(t, err)
+
+		iter, err := datastore.Read(ctx, store, tuple.NewTupleKey("document:1", "", ""), storage.ReadOptions{})
+		require.NoError(t, err)
+		require.Equal(t, []string{tuple.TupleKeyToString(permanent)}, readTupleKeys(t, iter))
+	})
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/testfixtures/generator"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/stretchr/testify/require"
+)
+
+// FixturesTest writes the generated fixtures of every shape (see the generator package), whose skewed tuples
+// are closer to the ones of the production stores than the handwritten ones, and reads them back.
+func FixturesTest(t *testing.T, datastore storage.OpenFGADatastore) {
+	ctx := context.Background()
+
+	config := generator.Config{
+		Seed:              1,
+		Users:             50,
+		Objects:           20,
+		TuplesPerRelation: 40,
+		Skew:              1.5,
+	}
+
+	for _, shape := range generator.Shapes() {
+		t.Run(string(shape), func(t *testing.T) {
+			fixture := generator.MustGenerate(shape, config)
+
+			store := ulid.Make().String()
+			_, err := fixture.Write(ctx, datastore, store)
+			require.NoError(t, err)
+
+			t.Run("read_by_object_type", func(t *testing.T) {
+				expected := map[string][]string{}
+				for _, tk := range fixture.Tuples {
+					objectType := tuple.GetType(tk.GetObject())
+					expected[objectType] = append(expected[objectType], tuple.TupleKeyToString(tk))
+				}
+
+				for objectType, keys := range expected {
+					iter, err := datastore.Read(ctx, store, tuple.NewTupleKey(objectType+":", "", ""), storage.ReadOptions{})
+					require.NoError(t, err)
+
+					require.ElementsMatch(t, keys, readTupleKeys(t, iter))
+				}
+			})
+
+			t.Run("read_user_tuples", func(t *testing.T) {
+				for _, tk := range fixture.Tuples {
+					_, err := datastore.ReadUserTuple(ctx, store, tk, storage.ReadOptions{})
+					require.NoError(t, err)
+				}
+			})
+
+			t.Run("read_starting_with_user", func(t *testing.T) {
+				// the tuples of each relation of the users, which the skew concentrates on a few users
+				expected := map[string]map[string][]string{}
+				for _, tk := range fixture.Tuples {
+					objectRelation := tuple.ToObjectRelationString(tuple.GetType(tk.GetObject()), tk.GetRelation())
+					if _, ok := expected[objectRelation]; !ok {
+						expected[objectRelation] = map[string][]string{}
+					}
+					expected[objectRelation][tk.GetUser()] = append(expected[objectRelation][tk.GetUser()], tuple.TupleKeyToString(tk))
+				}
+
+				for objectRelation, users := range expected {
+					objectType, relation := tuple.SplitObjectRelation(objectRelation)
+					for user, keys := range users {
+						userObject, userRelation := tuple.SplitObjectRelation(user)
+
+						iter, err := datastore.ReadStartingWithUser(ctx, store, storage.ReadStartingWithUserFilter{
+							ObjectType: objectType,
+							Relation:   relation,
+							UserFilter: []*openfgav1.ObjectRelation{{Object: userObject, Relation: userRelation}},
+						}, storage.ReadOptions{})
+						require.NoError(t, err)
+
+						require.ElementsMatch(t, keys, readTupleKeys(t, iter))
+					}
+				}
+			})
+		})
+	}
+}
+
+// readTupleKeys reads the tuples of the iterator, and returns their tuple.TupleKeyToString.
+func readTupleKeys(t *testing.T, iter storage.TupleIterator) []string {
+	defer iter.Stop()
+
+	var keys []string
+	for _, tk := range getTupleKeys(iter, t) {
+		keys = append(keys, tuple.TupleKeyToString(tk))
+	}
+
+	return keys
+}
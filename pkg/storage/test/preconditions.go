@@ -0,0 +1,118 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/stretchr/testify/require"
+)
+
+func PreconditionsTest(t *testing.T, datastore storage.OpenFGADatastore, preconditions storage.PreconditionsBackend) {
+	ctx := context.Background()
+
+	owner := tuple.NewTupleKey("document:1", "owner", "user:anne")
+	viewer := tuple.NewTupleKey("document:1", "viewer", "user:beth")
+	editor := tuple.NewTupleKey("document:2", "editor", "user:beth")
+
+	// newStore writes the owner tuple to a new store
+	newStore := func(t *testing.T) string {
+		store := ulid.Make().String()
+
+		err := datastore.Write(ctx, store, nil, []*openfgav1.TupleKey{owner})
+		require.NoError(t, err)
+
+		return store
+	}
+
+	t.Run("writes_if_the_preconditions_hold", func(t *testing.T) {
+		store := newStore(t)
+
+		err := preconditions.WriteWithPreconditions(ctx, store, nil, []*openfgav1.TupleKey{viewer, editor}, []storage.Precondition{
+			{TupleKey: owner, Exists: true},
+			{TupleKey: tuple.NewTupleKey("document:3", "owner", "user:anne"), Exists: false},
+		})
+		require.NoError(t, err)
+
+		_, err = datastore.ReadUserTuple(ctx, store, viewer, storage.ReadOptions{})
+		require.NoError(t, err)
+		_, err = datastore.ReadUserTuple(ctx, store, editor, storage.ReadOptions{})
+		require.NoError(t, err)
+	})
+
+	t.Run("fails_if_a_tuple_required_does_not_exist", func(t *testing.T) {
+		store := newStore(t)
+
+		err := preconditions.WriteWithPreconditions(ctx, store, nil, []*openfgav1.TupleKey{viewer}, []storage.Precondition{
+			{TupleKey: tuple.NewTupleKey("document:1", "owner", "user:beth"), Exists: true},
+		})
+		require.ErrorIs(t, err, storage.ErrPreconditionFailed)
+
+		_, err = datastore.ReadUserTuple(ctx, store, viewer, storage.ReadOptions{})
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("fails_if_a_tuple_forbidden_exists", func(t *testing.T) {
+		store := newStore(t)
+
+		err := preconditions.WriteWithPreconditions(ctx, store, []*openfgav1.TupleKey{owner}, []*openfgav1.TupleKey{editor}, []storage.Precondition{
+			{TupleKey: owner, Exists: false},
+		})
+		require.ErrorIs(t, err, storage.ErrPreconditionFailed)
+
+		// neither the delete nor the write is applied
+		_, err = datastore.ReadUserTuple(ctx, store, owner, storage.ReadOptions{})
+		require.NoError(t, err)
+		_, err = datastore.ReadUserTuple(ctx, store, editor, storage.ReadOptions{})
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("concurrent_writes_under_conflicting_preconditions", func(t *testing.T) {
+		store := newStore(t)
+
+		// each writer claims the document, only if no other writer did
+		const writers = 5
+		claims := make([]*openfgav1.TupleKey, writers)
+		for i := range claims {
+			claims[i] = tuple.NewTupleKey("document:1", "claimed_by", fmt.Sprintf("user:%d", i))
+		}
+
+		errs := make([]error, writers)
+		var wg sync.WaitGroup
+		for i := range claims {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				var unclaimed []storage.Precondition
+				for j, claim := range claims {
+					if j != i {
+						unclaimed = append(unclaimed, storage.Precondition{TupleKey: claim, Exists: false})
+					}
+				}
+
+				errs[i] = preconditions.WriteWithPreconditions(ctx, store, nil, []*openfgav1.TupleKey{claims[i]}, unclaimed)
+			}(i)
+		}
+		wg.Wait()
+
+		var succeeded int
+		for _, err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+
+			if !errors.Is(err, storage.ErrPreconditionFailed) {
+				require.ErrorIs(t, err, storage.ErrTransactionalWriteFailed)
+			}
+		}
+		require.Equal(t, 1, succeeded)
+	})
+}
@@ -0,0 +1,76 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/stretchr/testify/require"
+)
+
+func TupleSamplingTest(t *testing.T, datastore storage.OpenFGADatastore, sampler storage.TupleSampler) {
+	ctx := context.Background()
+
+	store := ulid.Make().String()
+	var tks []*openfgav1.TupleKey
+	for i := 0; i < 20; i++ {
+		tks = append(tks,
+			tuple.NewTupleKey(fmt.Sprintf("document:%d", i), "viewer", "user:anne"),
+			tuple.NewTupleKey(fmt.Sprintf("document:%d", i), "editor", "user:anne"),
+			tuple.NewTupleKey(fmt.Sprintf("folder:%d", i), "viewer", "user:anne"),
+		)
+	}
+	err := datastore.Write(ctx, store, nil, tks)
+	require.NoError(t, err)
+
+	t.Run("samples_the_tuples_of_the_store", func(t *testing.T) {
+		sample, err := sampler.SampleTuples(ctx, store, &openfgav1.TupleKey{}, 10)
+		require.NoError(t, err)
+		require.Len(t, sample, 10)
+
+		seen := map[string]struct{}{}
+		for _, tk := range sample {
+			require.Contains(t, tks, tk)
+			seen[tuple.TupleKeyToString(tk)] = struct{}{}
+		}
+		require.Len(t, seen, 10)
+	})
+
+	t.Run("samples_the_tuples_of_the_relation", func(t *testing.T) {
+		sample, err := sampler.SampleTuples(ctx, store, tuple.NewTupleKey("document:", "editor", ""), 5)
+		require.NoError(t, err)
+		require.Len(t, sample, 5)
+
+		for _, tk := range sample {
+			require.Equal(t, "document", tuple.GetType(tk.GetObject()))
+			require.Equal(t, "editor", tk.GetRelation())
+		}
+	})
+
+	t.Run("samples_every_tuple_of_a_small_store", func(t *testing.T) {
+		sample, err := sampler.SampleTuples(ctx, store, tuple.NewTupleKey("folder:", "", ""), 100)
+		require.NoError(t, err)
+		require.Len(t, sample, 20)
+	})
+
+	t.Run("samples_the_whole_store", func(t *testing.T) {
+		// the sample isn't bound to the first tuples read
+		seen := map[string]struct{}{}
+		for i := 0; i < 50; i++ {
+			sample, err := sampler.SampleTuples(ctx, store, &openfgav1.TupleKey{}, 1)
+			require.NoError(t, err)
+			seen[tuple.TupleKeyToString(sample[0])] = struct{}{}
+		}
+		require.Greater(t, len(seen), 10)
+	})
+
+	t.Run("empty_store", func(t *testing.T) {
+		sample, err := sampler.SampleTuples(ctx, ulid.Make().String(), &openfgav1.TupleKey{}, 10)
+		require.NoError(t, err)
+		require.Empty(t, sample)
+	})
+}
@@ -39,10 +39,34 @@ func RunAllTests(t *testing.T, ds storage.OpenFGADatastore) {
 	t.Run("TestWriteAndReadAuthorizationModel", func(t *testing.T) { WriteAndReadAuthorizationModelTest(t, ds) })
 	t.Run("TestReadAuthorizationModels", func(t *testing.T) { ReadAuthorizationModelsTest(t, ds) })
 	t.Run("TestFindLatestAuthorizationModelID", func(t *testing.T) { FindLatestAuthorizationModelIDTest(t, ds) })
+	t.Run("TestAuthorizationModelLabels", func(t *testing.T) { AuthorizationModelLabelsTest(t, ds) })
 
 	// assertions
 	t.Run("TestWriteAndReadAssertions", func(t *testing.T) { AssertionsTest(t, ds) })
 
+	// conditions
+	if conditions, ok := ds.(storage.ConditionsBackend); ok {
+		t.Run("TestConditions", func(t *testing.T) { ConditionsTest(t, ds, conditions) })
+	}
+
+	// expirations
+	if expirations, ok := ds.(storage.TupleExpirationBackend); ok {
+		t.Run("TestExpirations", func(t *testing.T) { ExpirationsTest(t, ds, expirations) })
+	}
+
+	// preconditions
+	if preconditions, ok := ds.(storage.PreconditionsBackend); ok {
+		t.Run("TestPreconditions", func(t *testing.T) { PreconditionsTest(t, ds, preconditions) })
+	}
+
+	// sampling
+	if sampler, ok := ds.(storage.TupleSampler); ok {
+		t.Run("TestTupleSampling", func(t *testing.T) { TupleSamplingTest(t, ds, sampler) })
+	}
+
+	// generated fixtures
+	t.Run("TestFixtures", func(t *testing.T) { FixturesTest(t, ds) })
+
 	// stores
 	t.Run("TestStore", func(t *testing.T) { StoreTest(t, ds) })
 }
@@ -36,7 +36,7 @@ func ReadChangesTest(t *testing.T, datastore storage.OpenFGADatastore) {
 		err := datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tk1, tk2})
 		require.NoError(t, err)
 
-		changes, continuationToken, err := datastore.ReadChanges(ctx, storeID, "", storage.PaginationOptions{PageSize: 1}, 0)
+		changes, continuationToken, err := datastore.ReadChanges(ctx, storeID, "", storage.PaginationOptions{PageSize: 1}, 0, storage.ReadOptions{})
 		require.NoError(t, err)
 		require.NotEmpty(t, continuationToken)
 
@@ -56,6 +56,7 @@ func ReadChangesTest(t *testing.T, datastore storage.OpenFGADatastore) {
 			From:     string(continuationToken),
 		},
 			0,
+			storage.ReadOptions{},
 		)
 		require.NoError(t, err)
 		require.NotEmpty(t, continuationToken)
@@ -74,7 +75,7 @@ func ReadChangesTest(t *testing.T, datastore storage.OpenFGADatastore) {
 	t.Run("read_changes_with_no_changes_should_return_not_found", func(t *testing.T) {
 		storeID := ulid.Make().String()
 
-		_, _, err := datastore.ReadChanges(ctx, storeID, "", storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 0)
+		_, _, err := datastore.ReadChanges(ctx, storeID, "", storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 0, storage.ReadOptions{})
 		require.ErrorIs(t, err, storage.ErrNotFound)
 	})
 
@@ -95,7 +96,7 @@ func ReadChangesTest(t *testing.T, datastore storage.OpenFGADatastore) {
 		err := datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tk1, tk2})
 		require.NoError(t, err)
 
-		_, _, err = datastore.ReadChanges(ctx, storeID, "", storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 1*time.Minute)
+		_, _, err = datastore.ReadChanges(ctx, storeID, "", storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 1*time.Minute, storage.ReadOptions{})
 		require.ErrorIs(t, err, storage.ErrNotFound)
 	})
 
@@ -116,7 +117,7 @@ func ReadChangesTest(t *testing.T, datastore storage.OpenFGADatastore) {
 		err := datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tk1, tk2})
 		require.NoError(t, err)
 
-		changes, continuationToken, err := datastore.ReadChanges(ctx, storeID, "folder", storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 0)
+		changes, continuationToken, err := datastore.ReadChanges(ctx, storeID, "folder", storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 0, storage.ReadOptions{})
 		require.NoError(t, err)
 		require.NotEmpty(t, continuationToken)
 
@@ -150,7 +151,7 @@ func ReadChangesTest(t *testing.T, datastore storage.OpenFGADatastore) {
 			changes, continuationToken, err = datastore.ReadChanges(context.Background(), storeID, "", storage.PaginationOptions{
 				PageSize: 10,
 				From:     string(continuationToken),
-			}, 1*time.Millisecond)
+			}, 1*time.Millisecond, storage.ReadOptions{})
 			if err != nil {
 				if errors.Is(err, storage.ErrNotFound) {
 					break
@@ -206,7 +207,7 @@ func TupleWritingAndReadingTest(t *testing.T, datastore storage.OpenFGADatastore
 		err = datastore.Write(ctx, storeID, []*openfgav1.TupleKey{tks[0], tks[1]}, []*openfgav1.TupleKey{tks[2]})
 		require.EqualError(t, err, expectedError.Error())
 
-		tuples, _, err := datastore.ReadPage(ctx, storeID, nil, storage.PaginationOptions{PageSize: 50})
+		tuples, _, err := datastore.ReadPage(ctx, storeID, nil, storage.PaginationOptions{PageSize: 50}, storage.ReadOptions{})
 		require.NoError(t, err)
 		require.Equal(t, len(tks), len(tuples))
 	})
@@ -232,7 +233,7 @@ func TupleWritingAndReadingTest(t *testing.T, datastore storage.OpenFGADatastore
 		require.NoError(t, err)
 
 		// Ensure it is not there
-		_, err = datastore.ReadUserTuple(ctx, storeID, tk)
+		_, err = datastore.ReadUserTuple(ctx, storeID, tk, storage.ReadOptions{})
 		require.ErrorIs(t, err, storage.ErrNotFound)
 	})
 
@@ -259,21 +260,21 @@ func TupleWritingAndReadingTest(t *testing.T, datastore storage.OpenFGADatastore
 		err := datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple1, tuple2, tuple3})
 		require.NoError(t, err)
 
-		gotTuple, err := datastore.ReadUserTuple(ctx, storeID, tuple1)
+		gotTuple, err := datastore.ReadUserTuple(ctx, storeID, tuple1, storage.ReadOptions{})
 		require.NoError(t, err)
 
 		if diff := cmp.Diff(tuple1, gotTuple.Key, cmpOpts...); diff != "" {
 			require.FailNowf(t, "mismatch (-want +got):\n%s", diff)
 		}
 
-		gotTuple, err = datastore.ReadUserTuple(ctx, storeID, tuple2)
+		gotTuple, err = datastore.ReadUserTuple(ctx, storeID, tuple2, storage.ReadOptions{})
 		require.NoError(t, err)
 
 		if diff := cmp.Diff(tuple2, gotTuple.Key, cmpOpts...); diff != "" {
 			require.FailNowf(t, "mismatch (-want +got):\n%s", diff)
 		}
 
-		gotTuple, err = datastore.ReadUserTuple(ctx, storeID, tuple3)
+		gotTuple, err = datastore.ReadUserTuple(ctx, storeID, tuple3, storage.ReadOptions{})
 		require.NoError(t, err)
 
 		if diff := cmp.Diff(tuple3, gotTuple.Key, cmpOpts...); diff != "" {
@@ -285,7 +286,7 @@ func TupleWritingAndReadingTest(t *testing.T, datastore storage.OpenFGADatastore
 		storeID := ulid.Make().String()
 		tk := &openfgav1.TupleKey{Object: "doc:readme", Relation: "owner", User: "10"}
 
-		_, err := datastore.ReadUserTuple(ctx, storeID, tk)
+		_, err := datastore.ReadUserTuple(ctx, storeID, tk, storage.ReadOptions{})
 		require.ErrorIs(t, err, storage.ErrNotFound)
 	})
 
@@ -320,7 +321,7 @@ func TupleWritingAndReadingTest(t *testing.T, datastore storage.OpenFGADatastore
 		gotTuples, err := datastore.ReadUsersetTuples(ctx, storeID, storage.ReadUsersetTuplesFilter{
 			Object:   "doc:readme",
 			Relation: "owner",
-		})
+		}, storage.ReadOptions{})
 		require.NoError(t, err)
 
 		iter := storage.NewTupleKeyIteratorFromTupleIterator(gotTuples)
@@ -354,7 +355,7 @@ func TupleWritingAndReadingTest(t *testing.T, datastore storage.OpenFGADatastore
 	t.Run("reading_userset_tuples_that_don't_exist_should_an_empty_iterator", func(t *testing.T) {
 		storeID := ulid.Make().String()
 
-		gotTuples, err := datastore.ReadUsersetTuples(ctx, storeID, storage.ReadUsersetTuplesFilter{Object: "doc:readme", Relation: "owner"})
+		gotTuples, err := datastore.ReadUsersetTuples(ctx, storeID, storage.ReadUsersetTuplesFilter{Object: "doc:readme", Relation: "owner"}, storage.ReadOptions{})
 		require.NoError(t, err)
 		defer gotTuples.Stop()
 
@@ -380,7 +381,7 @@ func TupleWritingAndReadingTest(t *testing.T, datastore storage.OpenFGADatastore
 			AllowedUserTypeRestrictions: []*openfgav1.RelationReference{
 				typesystem.DirectRelationReference("group", "member"),
 			},
-		})
+		}, storage.ReadOptions{})
 		require.NoError(t, err)
 
 		iter := storage.NewTupleKeyIteratorFromTupleIterator(gotTuples)
@@ -417,7 +418,7 @@ func TupleWritingAndReadingTest(t *testing.T, datastore storage.OpenFGADatastore
 				typesystem.DirectRelationReference("group", "member"),
 				typesystem.DirectRelationReference("grouping", "member"),
 			},
-		})
+		}, storage.ReadOptions{})
 		require.NoError(t, err)
 
 		iter := storage.NewTupleKeyIteratorFromTupleIterator(gotTuples)
@@ -458,7 +459,7 @@ func TupleWritingAndReadingTest(t *testing.T, datastore storage.OpenFGADatastore
 			AllowedUserTypeRestrictions: []*openfgav1.RelationReference{
 				typesystem.WildcardRelationReference("user"),
 			},
-		})
+		}, storage.ReadOptions{})
 		require.NoError(t, err)
 
 		iter := storage.NewTupleKeyIteratorFromTupleIterator(gotTuples)
@@ -495,7 +496,7 @@ func TupleWritingAndReadingTest(t *testing.T, datastore storage.OpenFGADatastore
 				typesystem.DirectRelationReference("group", "member"),
 				typesystem.WildcardRelationReference("user"),
 			},
-		})
+		}, storage.ReadOptions{})
 		require.NoError(t, err)
 
 		iter := storage.NewTupleKeyIteratorFromTupleIterator(gotTuples)
@@ -529,7 +530,7 @@ func TuplePaginationOptionsTest(t *testing.T, datastore storage.OpenFGADatastore
 	require.NoError(t, err)
 
 	t.Run("readPage_pagination_works_properly", func(t *testing.T) {
-		tuples0, contToken0, err := datastore.ReadPage(ctx, storeID, &openfgav1.TupleKey{Object: "doc:readme"}, storage.PaginationOptions{PageSize: 1})
+		tuples0, contToken0, err := datastore.ReadPage(ctx, storeID, &openfgav1.TupleKey{Object: "doc:readme"}, storage.PaginationOptions{PageSize: 1}, storage.ReadOptions{})
 		require.NoError(t, err)
 		require.Len(t, tuples0, 1)
 		require.NotEmpty(t, contToken0)
@@ -538,7 +539,7 @@ func TuplePaginationOptionsTest(t *testing.T, datastore storage.OpenFGADatastore
 			t.Fatalf("mismatch (-want +got):\n%s", diff)
 		}
 
-		tuples1, contToken1, err := datastore.ReadPage(ctx, storeID, &openfgav1.TupleKey{Object: "doc:readme"}, storage.PaginationOptions{PageSize: 1, From: string(contToken0)})
+		tuples1, contToken1, err := datastore.ReadPage(ctx, storeID, &openfgav1.TupleKey{Object: "doc:readme"}, storage.PaginationOptions{PageSize: 1, From: string(contToken0)}, storage.ReadOptions{})
 		require.NoError(t, err)
 		require.Len(t, tuples1, 1)
 		require.Empty(t, contToken1)
@@ -549,21 +550,21 @@ func TuplePaginationOptionsTest(t *testing.T, datastore storage.OpenFGADatastore
 	})
 
 	t.Run("reading_a_page_completely_does_not_return_a_continuation_token", func(t *testing.T) {
-		tuples, contToken, err := datastore.ReadPage(ctx, storeID, &openfgav1.TupleKey{Object: "doc:readme"}, storage.PaginationOptions{PageSize: 2})
+		tuples, contToken, err := datastore.ReadPage(ctx, storeID, &openfgav1.TupleKey{Object: "doc:readme"}, storage.PaginationOptions{PageSize: 2}, storage.ReadOptions{})
 		require.NoError(t, err)
 		require.Len(t, tuples, 2)
 		require.Empty(t, contToken)
 	})
 
 	t.Run("reading_a_page_partially_returns_a_continuation_token", func(t *testing.T) {
-		tuples, contToken, err := datastore.ReadPage(ctx, storeID, &openfgav1.TupleKey{Object: "doc:readme"}, storage.PaginationOptions{PageSize: 1})
+		tuples, contToken, err := datastore.ReadPage(ctx, storeID, &openfgav1.TupleKey{Object: "doc:readme"}, storage.PaginationOptions{PageSize: 1}, storage.ReadOptions{})
 		require.NoError(t, err)
 		require.Len(t, tuples, 1)
 		require.NotEmpty(t, contToken)
 	})
 
 	t.Run("ReadPaginationWorks", func(t *testing.T) {
-		tuple0, contToken0, err := datastore.ReadPage(ctx, storeID, nil, storage.PaginationOptions{PageSize: 1})
+		tuple0, contToken0, err := datastore.ReadPage(ctx, storeID, nil, storage.PaginationOptions{PageSize: 1}, storage.ReadOptions{})
 		require.NoError(t, err)
 		require.Len(t, tuple0, 1)
 		require.NotEmpty(t, contToken0)
@@ -572,7 +573,7 @@ func TuplePaginationOptionsTest(t *testing.T, datastore storage.OpenFGADatastore
 			t.Fatalf("mismatch (-want +got):\n%s", diff)
 		}
 
-		tuple1, contToken1, err := datastore.ReadPage(ctx, storeID, nil, storage.PaginationOptions{PageSize: 1, From: string(contToken0)})
+		tuple1, contToken1, err := datastore.ReadPage(ctx, storeID, nil, storage.PaginationOptions{PageSize: 1, From: string(contToken0)}, storage.ReadOptions{})
 		require.NoError(t, err)
 		require.Len(t, tuple1, 1)
 		require.Empty(t, contToken1)
@@ -583,14 +584,14 @@ func TuplePaginationOptionsTest(t *testing.T, datastore storage.OpenFGADatastore
 	})
 
 	t.Run("reading_by_storeID_completely_does_not_return_a_continuation_token", func(t *testing.T) {
-		tuples, contToken, err := datastore.ReadPage(ctx, storeID, nil, storage.PaginationOptions{PageSize: 2})
+		tuples, contToken, err := datastore.ReadPage(ctx, storeID, nil, storage.PaginationOptions{PageSize: 2}, storage.ReadOptions{})
 		require.NoError(t, err)
 		require.Len(t, tuples, 2)
 		require.Empty(t, contToken)
 	})
 
 	t.Run("reading_by_storeID_partially_returns_a_continuation_token", func(t *testing.T) {
-		tuples, contToken, err := datastore.ReadPage(ctx, storeID, nil, storage.PaginationOptions{PageSize: 1})
+		tuples, contToken, err := datastore.ReadPage(ctx, storeID, nil, storage.PaginationOptions{PageSize: 1}, storage.ReadOptions{})
 		require.NoError(t, err)
 		require.Len(t, tuples, 1)
 		require.NotEmpty(t, contToken)
@@ -630,6 +631,7 @@ func ReadStartingWithUserTest(t *testing.T, datastore storage.OpenFGADatastore)
 					},
 				},
 			},
+			storage.ReadOptions{},
 		)
 		require.NoError(err)
 
@@ -638,6 +640,34 @@ func ReadStartingWithUserTest(t *testing.T, datastore storage.OpenFGADatastore)
 		require.ElementsMatch([]string{"document:doc1", "document:doc2"}, objects)
 	})
 
+	t.Run("returns_results_of_the_object_ids", func(t *testing.T) {
+		storeID := ulid.Make().String()
+
+		err := datastore.Write(ctx, storeID, nil, append(tuples, tuple.NewTupleKey("document:doc4", "viewer", "user:jon")))
+		require.NoError(err)
+
+		tupleIterator, err := datastore.ReadStartingWithUser(
+			ctx,
+			storeID,
+			storage.ReadStartingWithUserFilter{
+				ObjectType: "document",
+				Relation:   "viewer",
+				UserFilter: []*openfgav1.ObjectRelation{
+					{
+						Object: "user:jon",
+					},
+				},
+				ObjectIDs: []string{"doc2", "doc4", "doc5"},
+			},
+			storage.ReadOptions{},
+		)
+		require.NoError(err)
+
+		objects := getObjects(tupleIterator, require)
+
+		require.ElementsMatch([]string{"document:doc4"}, objects)
+	})
+
 	t.Run("returns_no_results_if_the_input_users_do_not_match_the_tuples", func(t *testing.T) {
 		storeID := ulid.Make().String()
 
@@ -656,6 +686,7 @@ func ReadStartingWithUserTest(t *testing.T, datastore storage.OpenFGADatastore)
 					},
 				},
 			},
+			storage.ReadOptions{},
 		)
 		require.NoError(err)
 
@@ -682,6 +713,7 @@ func ReadStartingWithUserTest(t *testing.T, datastore storage.OpenFGADatastore)
 					},
 				},
 			},
+			storage.ReadOptions{},
 		)
 		require.NoError(err)
 
@@ -708,6 +740,7 @@ func ReadStartingWithUserTest(t *testing.T, datastore storage.OpenFGADatastore)
 					},
 				},
 			},
+			storage.ReadOptions{},
 		)
 		require.NoError(err)
 
@@ -736,6 +769,7 @@ func ReadTest(t *testing.T, datastore storage.OpenFGADatastore) {
 			ctx,
 			storeID,
 			tuple.NewTupleKey("", "", ""),
+			storage.ReadOptions{},
 		)
 		require.NoError(t, err)
 		defer tupleIterator.Stop()
@@ -754,6 +788,7 @@ func ReadTest(t *testing.T, datastore storage.OpenFGADatastore) {
 			ctx,
 			storeID,
 			tuple.NewTupleKey("document:1", "reader", "user:bob"),
+			storage.ReadOptions{},
 		)
 		require.NoError(t, err)
 		defer tupleIterator.Stop()
@@ -770,6 +805,7 @@ func ReadTest(t *testing.T, datastore storage.OpenFGADatastore) {
 			ctx,
 			storeID,
 			tuple.NewTupleKey("document:", "reader", "user:bob"),
+			storage.ReadOptions{},
 		)
 		require.NoError(t, err)
 		defer tupleIterator.Stop()
@@ -786,6 +822,7 @@ func ReadTest(t *testing.T, datastore storage.OpenFGADatastore) {
 			ctx,
 			storeID,
 			tuple.NewTupleKey("document:1", "reader", ""),
+			storage.ReadOptions{},
 		)
 		require.NoError(t, err)
 		defer tupleIterator.Stop()
@@ -803,6 +840,7 @@ func ReadTest(t *testing.T, datastore storage.OpenFGADatastore) {
 			ctx,
 			storeID,
 			tuple.NewTupleKey("document:1", "", ""),
+			storage.ReadOptions{},
 		)
 		require.NoError(t, err)
 		defer tupleIterator.Stop()
@@ -821,6 +859,7 @@ func ReadTest(t *testing.T, datastore storage.OpenFGADatastore) {
 			ctx,
 			storeID,
 			tuple.NewTupleKey("document:1", "", "user:bob"),
+			storage.ReadOptions{},
 		)
 		require.NoError(t, err)
 		defer tupleIterator.Stop()
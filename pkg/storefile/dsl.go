@@ -0,0 +1,213 @@
+package storefile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// ParseModel parses an authorization model written in the OpenFGA DSL. Both the current syntax, e.g.
+//
+//	model
+//	  schema 1.1
+//	type document
+//	  relations
+//	    define viewer: [user] or editor
+//
+// and the earlier one, e.g. 'define viewer: [user] as self or editor', are supported. The model returned
+// has no ID.
+func ParseModel(dsl string) (*openfgav1.AuthorizationModel, error) {
+	schemaVersion := typesystem.SchemaVersion1_1
+
+	var lines []string
+	for _, line := range strings.Split(dsl, "\n") {
+		line = stripComment(line)
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "model":
+			continue
+		case "schema":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("invalid schema declaration '%s'", strings.TrimSpace(line))
+			}
+			schemaVersion = fields[1]
+			continue
+		case "define":
+			line = normalizeDefine(line)
+		}
+
+		lines = append(lines, line)
+	}
+
+	typeDefinitions, err := parse(strings.Join(lines, "\n"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the model: %w", err)
+	}
+
+	return &openfgav1.AuthorizationModel{
+		SchemaVersion:   schemaVersion,
+		TypeDefinitions: typeDefinitions,
+	}, nil
+}
+
+// parse parses the earlier syntax of the DSL. The parser panics on some malformed input, e.g. a relation
+// without a rewrite, so the panics are turned into errors.
+func parse(dsl string) (typeDefinitions []*openfgav1.TypeDefinition, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed model: %v", r)
+		}
+	}()
+
+	return parser.Parse(dsl)
+}
+
+// stripComment removes the comment, if any, at the end of the line. A comment starts with a '#' at the
+// start of the line or after a whitespace, so that usersets (e.g. 'group#member') are preserved.
+func stripComment(line string) string {
+	for i, r := range line {
+		if r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t') {
+			return line[:i]
+		}
+	}
+
+	return line
+}
+
+// normalizeDefine rewrites a relation definition of the current syntax into the earlier one, which is the
+// one understood by the parser: the type restrictions move before the rewrite, where they're replaced by
+// 'self'. For example, 'define viewer: ([user] or editor) and allowed' becomes 'define viewer: [user] as
+// (self or editor) and allowed' and 'define viewer: editor' becomes 'define viewer as editor'.
+func normalizeDefine(line string) string {
+	for _, field := range strings.Fields(line) {
+		if field == "as" {
+			// the earlier syntax
+			return line
+		}
+	}
+
+	name, rewrite, found := strings.Cut(line, ":")
+	if !found {
+		return line
+	}
+
+	start := strings.Index(rewrite, "[")
+	if start < 0 {
+		return fmt.Sprintf("%s as %s", name, rewrite)
+	}
+
+	end := strings.Index(rewrite, "]")
+	if end < start {
+		return line
+	}
+
+	return fmt.Sprintf("%s: %s as %sself%s", name, rewrite[start:end+1], rewrite[:start], rewrite[end+1:])
+}
+
+// FormatDSL formats an authorization model written in the OpenFGA DSL, in either syntax, into the canonical
+// form of the model (see typesystem.CanonicalizeModel) written in the current syntax, so that the models that
+// only differ by their layout, their comments or the order of their types and relations format the same.
+func FormatDSL(dsl string) (string, error) {
+	model, err := ParseModel(dsl)
+	if err != nil {
+		return "", err
+	}
+
+	return TransformModelToDSL(typesystem.CanonicalizeModel(model)), nil
+}
+
+// TransformModelToDSL writes the provided authorization model in the current syntax of the OpenFGA DSL.
+// The relations of every type are written in alphabetical order.
+func TransformModelToDSL(model *openfgav1.AuthorizationModel) string {
+	var sb strings.Builder
+
+	sb.WriteString("model\n")
+	sb.WriteString(fmt.Sprintf("  schema %s\n", model.GetSchemaVersion()))
+
+	for _, typeDefinition := range model.GetTypeDefinitions() {
+		sb.WriteString(fmt.Sprintf("\ntype %s\n", typeDefinition.GetType()))
+
+		relations := typeDefinition.GetRelations()
+		if len(relations) == 0 {
+			continue
+		}
+
+		names := make([]string, 0, len(relations))
+		for name := range relations {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		sb.WriteString("  relations\n")
+		for _, name := range names {
+			directlyRelatedTypes := typeDefinition.GetMetadata().GetRelations()[name].GetDirectlyRelatedUserTypes()
+			rewrite := writeRewrite(relations[name], directlyRelatedTypes, false)
+			sb.WriteString(fmt.Sprintf("    define %s: %s\n", name, rewrite))
+		}
+	}
+
+	return sb.String()
+}
+
+// writeRewrite writes a rewrite of a relation. Nested operations are grouped in parentheses.
+func writeRewrite(rewrite *openfgav1.Userset, directlyRelatedTypes []*openfgav1.RelationReference, nested bool) string {
+	var operator string
+	var children []*openfgav1.Userset
+
+	switch rw := rewrite.GetUserset().(type) {
+	case *openfgav1.Userset_This:
+		if len(directlyRelatedTypes) == 0 {
+			return "self"
+		}
+		return writeDirectlyRelatedTypes(directlyRelatedTypes)
+	case *openfgav1.Userset_ComputedUserset:
+		return rw.ComputedUserset.GetRelation()
+	case *openfgav1.Userset_TupleToUserset:
+		return fmt.Sprintf("%s from %s", rw.TupleToUserset.GetComputedUserset().GetRelation(), rw.TupleToUserset.GetTupleset().GetRelation())
+	case *openfgav1.Userset_Union:
+		operator, children = "or", rw.Union.GetChild()
+	case *openfgav1.Userset_Intersection:
+		operator, children = "and", rw.Intersection.GetChild()
+	case *openfgav1.Userset_Difference:
+		operator, children = "but not", []*openfgav1.Userset{rw.Difference.GetBase(), rw.Difference.GetSubtract()}
+	default:
+		return ""
+	}
+
+	operands := make([]string, 0, len(children))
+	for _, child := range children {
+		operands = append(operands, writeRewrite(child, directlyRelatedTypes, true))
+	}
+
+	s := strings.Join(operands, fmt.Sprintf(" %s ", operator))
+	if nested {
+		return fmt.Sprintf("(%s)", s)
+	}
+
+	return s
+}
+
+func writeDirectlyRelatedTypes(references []*openfgav1.RelationReference) string {
+	types := make([]string, 0, len(references))
+	for _, reference := range references {
+		switch ref := reference.GetRelationOrWildcard().(type) {
+		case *openfgav1.RelationReference_Relation:
+			types = append(types, fmt.Sprintf("%s#%s", reference.GetType(), ref.Relation))
+		case *openfgav1.RelationReference_Wildcard:
+			types = append(types, fmt.Sprintf("%s:*", reference.GetType()))
+		default:
+			types = append(types, reference.GetType())
+		}
+	}
+
+	return fmt.Sprintf("[%s]", strings.Join(types, ", "))
+}
@@ -0,0 +1,183 @@
+package storefile
+
+import (
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParseModel(t *testing.T) {
+	expected := parser.MustParse(`
+	type user
+
+	type group
+	  relations
+	    define member: [user, group#member] as self
+
+	type folder
+	  relations
+	    define viewer: [user, user:*] as self
+
+	type document
+	  relations
+	    define blocked: [user] as self
+	    define editor: [user] as self
+	    define parent: [folder] as self
+	    define owner: [user] as self
+	    define viewer: [user, group#member] as self or editor or viewer from parent
+	    define can_delete as owner and editor
+	    define can_view as viewer but not blocked
+	`)
+
+	tests := []struct {
+		name string
+		dsl  string
+	}{
+		{
+			name: "current_syntax",
+			dsl: `model
+  schema 1.1
+
+# the users
+type user
+
+type group
+  relations
+    define member: [user, group#member]
+
+type folder
+  relations
+    define viewer: [user, user:*] # public folders
+
+type document
+  relations
+    define blocked: [user]
+    define editor: [user]
+    define parent: [folder]
+    define owner: [user]
+    define viewer: [user, group#member] or editor or viewer from parent
+    define can_delete: owner and editor
+    define can_view: viewer but not blocked
+`,
+		},
+		{
+			name: "earlier_syntax",
+			dsl: `type user
+
+type group
+  relations
+    define member: [user, group#member] as self
+
+type folder
+  relations
+    define viewer: [user, user:*] as self
+
+type document
+  relations
+    define blocked: [user] as self
+    define editor: [user] as self
+    define parent: [folder] as self
+    define owner: [user] as self
+    define viewer: [user, group#member] as self or editor or viewer from parent
+    define can_delete as owner and editor
+    define can_view as viewer but not blocked
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			model, err := ParseModel(test.dsl)
+			require.NoError(t, err)
+			require.Equal(t, typesystem.SchemaVersion1_1, model.GetSchemaVersion())
+			require.Len(t, model.GetTypeDefinitions(), len(expected))
+
+			for i := range expected {
+				require.True(t, proto.Equal(expected[i], model.GetTypeDefinitions()[i]), expected[i].GetType())
+			}
+		})
+	}
+
+	t.Run("invalid_model", func(t *testing.T) {
+		_, err := ParseModel("type document\n  relations\n    define viewer: [user] or or editor")
+		require.Error(t, err)
+
+		_, err = ParseModel("type document relations define")
+		require.Error(t, err)
+	})
+}
+
+func TestTransformModelToDSL(t *testing.T) {
+	model, err := ParseModel(`model
+  schema 1.1
+
+type user
+
+type document
+  relations
+    define allowed: [user]
+    define blocked: [user]
+    define editor: [user]
+    define viewer: ([user] or editor) and allowed
+    define can_view: viewer but not blocked
+`)
+	require.NoError(t, err)
+
+	dsl := TransformModelToDSL(model)
+	require.Equal(t, `model
+  schema 1.1
+
+type user
+
+type document
+  relations
+    define allowed: [user]
+    define blocked: [user]
+    define can_view: viewer but not blocked
+    define editor: [user]
+    define viewer: ([user] or editor) and allowed
+`, dsl)
+
+	roundTripped, err := ParseModel(dsl)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(model, roundTripped))
+}
+
+func TestFormatDSL(t *testing.T) {
+	formatted, err := FormatDSL(`type document
+	relations
+		define viewer: [user] as self or (editor or owner)   # the earlier syntax
+		define owner: [user] as self
+		define editor: [user, group#member] as self
+type group
+  relations
+    define member: [user] as self
+type user
+`)
+	require.NoError(t, err)
+	require.Equal(t, `model
+  schema 1.1
+
+type document
+  relations
+    define editor: [group#member, user]
+    define owner: [user]
+    define viewer: [user] or editor or owner
+
+type group
+  relations
+    define member: [user]
+
+type user
+`, formatted)
+
+	reformatted, err := FormatDSL(formatted)
+	require.NoError(t, err)
+	require.Equal(t, formatted, reformatted)
+
+	_, err = FormatDSL(`type document relations define viewer`)
+	require.Error(t, err)
+}
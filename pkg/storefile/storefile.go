@@ -0,0 +1,157 @@
+// Package storefile contains the OpenFGA store file format ('.fga.yaml'), which bundles the authorization
+// model of a store, written in the DSL, along with its relationship tuples and tests. It's the format used
+// by the OpenFGA tooling (e.g. the CLI and the VS Code extension) to share stores.
+package storefile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/tuple"
+	"gopkg.in/yaml.v3"
+)
+
+// TupleKey is a relationship tuple of a store file.
+type TupleKey struct {
+	User     string `yaml:"user"`
+	Relation string `yaml:"relation"`
+	Object   string `yaml:"object"`
+}
+
+// CheckTest asserts the relations that a user has, or doesn't have, with an object.
+type CheckTest struct {
+	User       string          `yaml:"user"`
+	Object     string          `yaml:"object"`
+	Assertions map[string]bool `yaml:"assertions"`
+}
+
+// ListObjectsTest asserts the objects of a type that a user has each relation with.
+type ListObjectsTest struct {
+	User       string              `yaml:"user"`
+	Type       string              `yaml:"type"`
+	Assertions map[string][]string `yaml:"assertions"`
+}
+
+// Test is a set of assertions, evaluated against the tuples of the store along with the tuples of the test.
+type Test struct {
+	Name        string             `yaml:"name"`
+	Description string             `yaml:"description,omitempty"`
+	TupleFile   string             `yaml:"tuple_file,omitempty"`
+	Tuples      []*TupleKey        `yaml:"tuples,omitempty"`
+	Check       []*CheckTest       `yaml:"check,omitempty"`
+	ListObjects []*ListObjectsTest `yaml:"list_objects,omitempty"`
+}
+
+// StoreFile is the content of a store file. The model and the tuples are either inlined or stored in the
+// files referenced by ModelFile and TupleFile, whose paths are relative to the store file.
+type StoreFile struct {
+	Name      string      `yaml:"name"`
+	ModelFile string      `yaml:"model_file,omitempty"`
+	Model     string      `yaml:"model,omitempty"`
+	TupleFile string      `yaml:"tuple_file,omitempty"`
+	Tuples    []*TupleKey `yaml:"tuples,omitempty"`
+	Tests     []*Test     `yaml:"tests,omitempty"`
+}
+
+// Parse parses the content of a store file. The files it references, if any, aren't read.
+func Parse(data []byte) (*StoreFile, error) {
+	var file StoreFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse the store file: %w", err)
+	}
+
+	return &file, nil
+}
+
+// Load reads and parses the store file at the provided path, and inlines the model and the tuples of the
+// files it references.
+func Load(path string) (*StoreFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the store file: %w", err)
+	}
+
+	file, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(path)
+
+	if file.ModelFile != "" {
+		model, err := os.ReadFile(filepath.Join(dir, file.ModelFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the model file: %w", err)
+		}
+		file.Model = string(model)
+		file.ModelFile = ""
+	}
+
+	if file.TupleFile != "" {
+		tuples, err := loadTuples(filepath.Join(dir, file.TupleFile))
+		if err != nil {
+			return nil, err
+		}
+		file.Tuples = append(file.Tuples, tuples...)
+		file.TupleFile = ""
+	}
+
+	for _, test := range file.Tests {
+		if test.TupleFile != "" {
+			tuples, err := loadTuples(filepath.Join(dir, test.TupleFile))
+			if err != nil {
+				return nil, err
+			}
+			test.Tuples = append(test.Tuples, tuples...)
+			test.TupleFile = ""
+		}
+	}
+
+	return file, nil
+}
+
+// loadTuples reads a tuple file, whose format is given by its extension (see TupleFormatOf).
+func loadTuples(path string) ([]*TupleKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the tuple file: %w", err)
+	}
+
+	tuples, err := ParseTuples(data, TupleFormatOf(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the tuple file '%s': %w", path, err)
+	}
+
+	return tuples, nil
+}
+
+// Marshal encodes the store file in YAML.
+func (f *StoreFile) Marshal() ([]byte, error) {
+	return yaml.Marshal(f)
+}
+
+// AuthorizationModel parses the model of the store file.
+func (f *StoreFile) AuthorizationModel() (*openfgav1.AuthorizationModel, error) {
+	if f.ModelFile != "" && f.Model == "" {
+		return nil, errors.New("the model file of the store file must be loaded")
+	}
+
+	if f.Model == "" {
+		return nil, errors.New("the store file has no model")
+	}
+
+	return ParseModel(f.Model)
+}
+
+// TupleKeys returns the tuples of the store file.
+func (f *StoreFile) TupleKeys() []*openfgav1.TupleKey {
+	tupleKeys := make([]*openfgav1.TupleKey, 0, len(f.Tuples))
+	for _, t := range f.Tuples {
+		tupleKeys = append(tupleKeys, tuple.NewTupleKey(t.Object, t.Relation, t.User))
+	}
+
+	return tupleKeys
+}
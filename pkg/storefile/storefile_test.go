@@ -0,0 +1,98 @@
+package storefile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile := func(t *testing.T, name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	writeFile(t, "model.fga", `model
+  schema 1.1
+type user
+type document
+  relations
+    define viewer: [user]
+`)
+	writeFile(t, "tuples.yaml", `
+- user: user:anne
+  relation: viewer
+  object: document:1
+`)
+	writeFile(t, "test-tuples.csv", `user_type,user_id,relation,object_type,object_id
+user,bob,viewer,document,2
+`)
+	writeFile(t, "store.fga.yaml", `
+name: documents
+model_file: ./model.fga
+tuple_file: ./tuples.yaml
+tuples:
+  - user: user:charlie
+    relation: viewer
+    object: document:3
+tests:
+  - name: viewers
+    tuple_file: ./test-tuples.csv
+    check:
+      - user: user:bob
+        object: document:2
+        assertions:
+          viewer: true
+`)
+
+	file, err := Load(filepath.Join(dir, "store.fga.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, "documents", file.Name)
+	require.Empty(t, file.ModelFile)
+	require.Empty(t, file.TupleFile)
+
+	model, err := file.AuthorizationModel()
+	require.NoError(t, err)
+	require.Len(t, model.GetTypeDefinitions(), 2)
+
+	require.Equal(t, []string{"document:3#viewer@user:charlie", "document:1#viewer@user:anne"}, tupleStrings(file))
+
+	require.Len(t, file.Tests, 1)
+	require.Equal(t, []*TupleKey{{User: "user:bob", Relation: "viewer", Object: "document:2"}}, file.Tests[0].Tuples)
+	require.Equal(t, map[string]bool{"viewer": true}, file.Tests[0].Check[0].Assertions)
+
+	t.Run("missing_model_file", func(t *testing.T) {
+		writeFile(t, "missing.fga.yaml", "name: missing\nmodel_file: ./missing.fga\n")
+
+		_, err := Load(filepath.Join(dir, "missing.fga.yaml"))
+		require.ErrorContains(t, err, "failed to read the model file")
+	})
+}
+
+func TestMarshal(t *testing.T) {
+	file := &StoreFile{
+		Name:   "documents",
+		Model:  "model\n  schema 1.1\n\ntype user\n",
+		Tuples: []*TupleKey{{User: "user:anne", Relation: "viewer", Object: "document:1"}},
+	}
+
+	data, err := file.Marshal()
+	require.NoError(t, err)
+
+	parsed, err := Parse(data)
+	require.NoError(t, err)
+	require.Equal(t, file, parsed)
+}
+
+func tupleStrings(file *StoreFile) []string {
+	var tuples []string
+	for _, tk := range file.TupleKeys() {
+		tuples = append(tuples, tuple.TupleKeyToString(tk))
+	}
+
+	return tuples
+}
@@ -0,0 +1,342 @@
+package storefile
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/openfga/openfga/pkg/tuple"
+	"gopkg.in/yaml.v3"
+)
+
+// TupleFormat is the format of a tuple file.
+type TupleFormat string
+
+const (
+	// TupleFormatYAML is a YAML (or JSON) list of tuples.
+	TupleFormatYAML TupleFormat = "yaml"
+
+	// TupleFormatCSV is a CSV file whose header names the columns of the tuples. The columns are either
+	// 'user', 'relation' and 'object', or 'user_type', 'user_id', 'user_relation' (optional), 'relation',
+	// 'object_type' and 'object_id', in any order.
+	TupleFormatCSV TupleFormat = "csv"
+
+	// TupleFormatNDJSON is a tuple per line, as a JSON object with the 'user', 'relation' and 'object' keys.
+	TupleFormatNDJSON TupleFormat = "ndjson"
+)
+
+// The columns of the CSV tuple files.
+const (
+	csvUser         = "user"
+	csvUserType     = "user_type"
+	csvUserID       = "user_id"
+	csvUserRelation = "user_relation"
+	csvRelation     = "relation"
+	csvObject       = "object"
+	csvObjectType   = "object_type"
+	csvObjectID     = "object_id"
+)
+
+var (
+	csvColumns = map[string]struct{}{
+		csvUser: {}, csvUserType: {}, csvUserID: {}, csvUserRelation: {},
+		csvRelation: {}, csvObject: {}, csvObjectType: {}, csvObjectID: {},
+	}
+
+	// csvHeader is the header of the CSV tuple files written by MarshalTuples.
+	csvHeader = []string{csvUser, csvRelation, csvObject}
+)
+
+// ParseTupleFormat parses the name of a tuple format.
+func ParseTupleFormat(s string) (TupleFormat, error) {
+	switch format := TupleFormat(strings.ToLower(s)); format {
+	case TupleFormatYAML, TupleFormatCSV, TupleFormatNDJSON:
+		return format, nil
+	case "yml", "json":
+		return TupleFormatYAML, nil
+	case "jsonl":
+		return TupleFormatNDJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported tuple format '%s': must be one of '%s', '%s' or '%s'", s, TupleFormatYAML, TupleFormatCSV, TupleFormatNDJSON)
+	}
+}
+
+// TupleFormatOf returns the format of a tuple file given its path: '.csv' files are CSV, '.ndjson' and
+// '.jsonl' files are NDJSON, and the other ones are YAML.
+func TupleFormatOf(path string) TupleFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return TupleFormatCSV
+	case ".ndjson", ".jsonl":
+		return TupleFormatNDJSON
+	default:
+		return TupleFormatYAML
+	}
+}
+
+// ParseTuples parses a tuple file of the given format. The tuples of the CSV and NDJSON files are validated
+// as they're parsed, and the errors locate the invalid line (e.g. "line 3: the tuple has no relation").
+func ParseTuples(data []byte, format TupleFormat) ([]*TupleKey, error) {
+	switch format {
+	case TupleFormatYAML:
+		var tuples []*TupleKey
+		if err := yaml.Unmarshal(data, &tuples); err != nil {
+			return nil, err
+		}
+		return tuples, nil
+	case TupleFormatCSV:
+		return parseCSVTuples(data)
+	case TupleFormatNDJSON:
+		return parseNDJSONTuples(data)
+	default:
+		return nil, fmt.Errorf("unsupported tuple format '%s'", format)
+	}
+}
+
+func parseCSVTuples(data []byte) ([]*TupleKey, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1 // checked below, to report the line
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("line 1: the CSV file has no header")
+		}
+		return nil, csvError(err)
+	}
+
+	columns, err := parseCSVHeader(header)
+	if err != nil {
+		return nil, fmt.Errorf("line 1: %w", err)
+	}
+
+	var tuples []*TupleKey
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, csvError(err)
+		}
+
+		line, _ := r.FieldPos(0)
+		if len(record) != len(header) {
+			return nil, fmt.Errorf("line %d: expected %d fields but got %d", line, len(header), len(record))
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		tk := &TupleKey{Relation: field(csvRelation)}
+		if _, ok := columns[csvUser]; ok {
+			tk.User = field(csvUser)
+			tk.Object = field(csvObject)
+		} else {
+			tk.User, err = csvUserOf(field(csvUserType), field(csvUserID), field(csvUserRelation))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			tk.Object, err = csvObjectOf(field(csvObjectType), field(csvObjectID))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+
+		if err := validateTupleKey(tk); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		tuples = append(tuples, tk)
+	}
+
+	return tuples, nil
+}
+
+// parseCSVHeader returns the index of each column of the header, which must name either the 'user',
+// 'relation' and 'object' columns, or the typed ones.
+func parseCSVHeader(header []string) (map[string]int, error) {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+
+		if _, ok := csvColumns[name]; !ok {
+			return nil, fmt.Errorf("unknown column '%s'", name)
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("duplicate column '%s'", name)
+		}
+		columns[name] = i
+	}
+
+	_, untyped := columns[csvUser]
+	_, typed := columns[csvUserType]
+
+	var required []string
+	switch {
+	case untyped && typed:
+		return nil, fmt.Errorf("the header can't have both the '%s' and the '%s' columns", csvUser, csvUserType)
+	case untyped:
+		required = []string{csvUser, csvRelation, csvObject}
+		for _, name := range []string{csvUserID, csvUserRelation, csvObjectType, csvObjectID} {
+			if _, ok := columns[name]; ok {
+				return nil, fmt.Errorf("the column '%s' can't be used with the '%s' column", name, csvUser)
+			}
+		}
+	default:
+		required = []string{csvUserType, csvUserID, csvRelation, csvObjectType, csvObjectID}
+		if _, ok := columns[csvObject]; ok {
+			return nil, fmt.Errorf("the column '%s' can't be used with the '%s' column", csvObject, csvUserType)
+		}
+	}
+
+	for _, name := range required {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column '%s'", name)
+		}
+	}
+
+	return columns, nil
+}
+
+func csvUserOf(userType, userID, userRelation string) (string, error) {
+	if userType == "" {
+		return "", fmt.Errorf("the tuple has no %s", csvUserType)
+	}
+	if userID == "" {
+		return "", fmt.Errorf("the tuple has no %s", csvUserID)
+	}
+
+	user := tuple.BuildObject(userType, userID)
+	if userRelation != "" {
+		user = tuple.ToObjectRelationString(user, userRelation)
+	}
+
+	return user, nil
+}
+
+func csvObjectOf(objectType, objectID string) (string, error) {
+	if objectType == "" {
+		return "", fmt.Errorf("the tuple has no %s", csvObjectType)
+	}
+	if objectID == "" {
+		return "", fmt.Errorf("the tuple has no %s", csvObjectID)
+	}
+
+	return tuple.BuildObject(objectType, objectID), nil
+}
+
+// csvError reports the parse errors of the CSV reader with the line they occurred on.
+func csvError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Errorf("line %d: %w", parseErr.Line, parseErr.Err)
+	}
+
+	return err
+}
+
+// ndjsonTupleKey is a tuple of an NDJSON tuple file.
+type ndjsonTupleKey struct {
+	User     string `json:"user"`
+	Relation string `json:"relation"`
+	Object   string `json:"object"`
+}
+
+func parseNDJSONTuples(data []byte) ([]*TupleKey, error) {
+	var tuples []*TupleKey
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.DisallowUnknownFields()
+
+		var tk ndjsonTupleKey
+		if err := dec.Decode(&tk); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		if dec.More() {
+			return nil, fmt.Errorf("line %d: a line must hold a single tuple", i+1)
+		}
+
+		t := &TupleKey{User: tk.User, Relation: tk.Relation, Object: tk.Object}
+		if err := validateTupleKey(t); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		tuples = append(tuples, t)
+	}
+
+	return tuples, nil
+}
+
+// validateTupleKey checks the syntax of a tuple. Whether it's valid for the model is checked when it's
+// written.
+func validateTupleKey(tk *TupleKey) error {
+	switch {
+	case tk.User == "":
+		return errors.New("the tuple has no user")
+	case tk.Relation == "":
+		return errors.New("the tuple has no relation")
+	case tk.Object == "":
+		return errors.New("the tuple has no object")
+	case !tuple.IsValidUser(tk.User):
+		return fmt.Errorf("invalid user '%s'", tk.User)
+	case !tuple.IsValidRelation(tk.Relation):
+		return fmt.Errorf("invalid relation '%s'", tk.Relation)
+	case !tuple.IsValidObject(tk.Object):
+		return fmt.Errorf("invalid object '%s'", tk.Object)
+	}
+
+	return nil
+}
+
+// MarshalTuples encodes tuples in the given format. The CSV files have the 'user', 'relation' and 'object'
+// columns.
+func MarshalTuples(tuples []*TupleKey, format TupleFormat) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch format {
+	case TupleFormatYAML:
+		return yaml.Marshal(tuples)
+	case TupleFormatCSV:
+		w := csv.NewWriter(&buf)
+		if err := w.Write(csvHeader); err != nil {
+			return nil, err
+		}
+		for _, tk := range tuples {
+			if err := w.Write([]string{tk.User, tk.Relation, tk.Object}); err != nil {
+				return nil, err
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, err
+		}
+	case TupleFormatNDJSON:
+		enc := json.NewEncoder(&buf)
+		for _, tk := range tuples {
+			if err := enc.Encode(ndjsonTupleKey{User: tk.User, Relation: tk.Relation, Object: tk.Object}); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported tuple format '%s'", format)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,163 @@
+package storefile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTuples(t *testing.T) {
+	anne := &TupleKey{User: "user:anne", Relation: "viewer", Object: "document:1"}
+	members := &TupleKey{User: "group:eng#member", Relation: "editor", Object: "document:2"}
+
+	tests := []struct {
+		name     string
+		format   TupleFormat
+		data     string
+		expected []*TupleKey
+		err      string
+	}{
+		{
+			name:     "yaml",
+			format:   TupleFormatYAML,
+			data:     "- user: user:anne\n  relation: viewer\n  object: document:1\n",
+			expected: []*TupleKey{anne},
+		},
+		{
+			name:     "csv",
+			format:   TupleFormatCSV,
+			data:     "object,relation,user\ndocument:1,viewer,user:anne\ndocument:2,editor,group:eng#member\n",
+			expected: []*TupleKey{anne, members},
+		},
+		{
+			name:     "csv_typed_columns",
+			format:   TupleFormatCSV,
+			data:     "\ufeffuser_type,user_id,user_relation,relation,object_type,object_id\nuser,anne,,viewer,document,1\ngroup,eng,member,editor,document,2\n",
+			expected: []*TupleKey{anne, members},
+		},
+		{
+			name:   "csv_without_header",
+			format: TupleFormatCSV,
+			data:   "",
+			err:    "line 1: the CSV file has no header",
+		},
+		{
+			name:   "csv_unknown_column",
+			format: TupleFormatCSV,
+			data:   "user,relation,object,condition\n",
+			err:    "line 1: unknown column 'condition'",
+		},
+		{
+			name:   "csv_missing_column",
+			format: TupleFormatCSV,
+			data:   "user_type,user_id,relation,object_type\n",
+			err:    "line 1: missing column 'object_id'",
+		},
+		{
+			name:   "csv_mixed_columns",
+			format: TupleFormatCSV,
+			data:   "user,relation,object_type,object_id\n",
+			err:    "line 1: the column 'object_type' can't be used with the 'user' column",
+		},
+		{
+			name:   "csv_wrong_number_of_fields",
+			format: TupleFormatCSV,
+			data:   "user,relation,object\nuser:anne,viewer,document:1\nuser:bob,viewer\n",
+			err:    "line 3: expected 3 fields but got 2",
+		},
+		{
+			name:   "csv_empty_field",
+			format: TupleFormatCSV,
+			data:   "user,relation,object\nuser:anne,,document:1\n",
+			err:    "line 2: the tuple has no relation",
+		},
+		{
+			name:   "csv_invalid_object",
+			format: TupleFormatCSV,
+			data:   "user,relation,object\nuser:anne,viewer,document\n",
+			err:    "line 2: invalid object 'document'",
+		},
+		{
+			name:   "csv_syntax_error",
+			format: TupleFormatCSV,
+			data:   "user,relation,object\nuser:anne,viewer,document:1\n\"user:bob,viewer,document:1\n",
+			err:    "line 3",
+		},
+		{
+			name:     "ndjson",
+			format:   TupleFormatNDJSON,
+			data:     "{\"user\":\"user:anne\",\"relation\":\"viewer\",\"object\":\"document:1\"}\n\n{\"object\":\"document:2\",\"relation\":\"editor\",\"user\":\"group:eng#member\"}",
+			expected: []*TupleKey{anne, members},
+		},
+		{
+			name:   "ndjson_unknown_field",
+			format: TupleFormatNDJSON,
+			data:   "{\"user\":\"user:anne\",\"relation\":\"viewer\",\"object\":\"document:1\"}\n{\"user\":\"user:anne\",\"relation\":\"viewer\",\"object\":\"document:1\",\"condition\":\"x\"}\n",
+			err:    "line 2: json: unknown field \"condition\"",
+		},
+		{
+			name:   "ndjson_several_tuples_per_line",
+			format: TupleFormatNDJSON,
+			data:   "{\"user\":\"user:anne\",\"relation\":\"viewer\",\"object\":\"document:1\"} {}\n",
+			err:    "line 1: a line must hold a single tuple",
+		},
+		{
+			name:   "ndjson_invalid_user",
+			format: TupleFormatNDJSON,
+			data:   "\n\n{\"user\":\"user:anne smith\",\"relation\":\"viewer\",\"object\":\"document:1\"}\n",
+			err:    "line 3: invalid user 'user:anne smith'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tuples, err := ParseTuples([]byte(test.data), test.format)
+			if test.err != "" {
+				require.ErrorContains(t, err, test.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expected, tuples)
+		})
+	}
+}
+
+func TestMarshalTuples(t *testing.T) {
+	tuples := []*TupleKey{
+		{User: "user:anne", Relation: "viewer", Object: "document:1"},
+		{User: "group:eng#member", Relation: "editor", Object: "document:2"},
+	}
+
+	for _, format := range []TupleFormat{TupleFormatYAML, TupleFormatCSV, TupleFormatNDJSON} {
+		t.Run(string(format), func(t *testing.T) {
+			data, err := MarshalTuples(tuples, format)
+			require.NoError(t, err)
+
+			parsed, err := ParseTuples(data, format)
+			require.NoError(t, err)
+			require.Equal(t, tuples, parsed)
+		})
+	}
+
+	data, err := MarshalTuples(tuples, TupleFormatCSV)
+	require.NoError(t, err)
+	require.Equal(t, "user,relation,object\nuser:anne,viewer,document:1\ngroup:eng#member,editor,document:2\n", string(data))
+}
+
+func TestParseTupleFormat(t *testing.T) {
+	format, err := ParseTupleFormat("CSV")
+	require.NoError(t, err)
+	require.Equal(t, TupleFormatCSV, format)
+
+	format, err = ParseTupleFormat("jsonl")
+	require.NoError(t, err)
+	require.Equal(t, TupleFormatNDJSON, format)
+
+	_, err = ParseTupleFormat("xml")
+	require.ErrorContains(t, err, "unsupported tuple format 'xml'")
+
+	require.Equal(t, TupleFormatCSV, TupleFormatOf("tuples.CSV"))
+	require.Equal(t, TupleFormatNDJSON, TupleFormatOf("tuples.jsonl"))
+	require.Equal(t, TupleFormatYAML, TupleFormatOf("tuples.json"))
+}
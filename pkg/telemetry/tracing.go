@@ -4,6 +4,7 @@ package telemetry
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"go.opentelemetry.io/otel"
@@ -93,13 +94,42 @@ func MustNewTracerProvider(opts ...TracerOption) *sdktrace.TracerProvider {
 		sdktrace.WithSpanProcessor(sdktrace.NewBatchSpanProcessor(exp)),
 	)
 
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	SetTextMapPropagator()
 
 	otel.SetTracerProvider(tp)
 
 	return tp
 }
 
+// SetTextMapPropagator sets the global propagator to the W3C Trace Context (traceparent and tracestate
+// headers) and Baggage propagators. It is independent of the tracer provider so that the trace context of
+// the callers is propagated even when tracing is disabled.
+func SetTextMapPropagator() {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+// NewPropagatingTransport wraps the provided http.RoundTripper (http.DefaultTransport if nil) so that the
+// trace context of every outgoing request is injected in its headers.
+func NewPropagatingTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &propagatingTransport{base: base}
+}
+
+type propagatingTransport struct {
+	base http.RoundTripper
+}
+
+func (t *propagatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a RoundTripper must not modify the request
+	req = req.Clone(req.Context())
+	otel.GetTextMapPropagator().Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+
+	return t.base.RoundTrip(req)
+}
+
 func TraceError(span trace.Span, err error) {
 	span.RecordError(err)
 	span.SetStatus(codes.Error, err.Error())
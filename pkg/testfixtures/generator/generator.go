@@ -0,0 +1,484 @@
+// Package generator generates synthetic fixtures for the benchmarks and the tests: realistic authorization
+// models, e.g. organizations of folders and documents, and tuples whose users and objects are drawn with a
+// configurable skew, so that a few of them are much more popular than the others, like in production stores.
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+var (
+	ErrUnknownShape  = errors.New("unknown fixture shape")
+	ErrInvalidConfig = errors.New("invalid fixture config")
+)
+
+// Shape is the shape of the model of a fixture.
+type Shape string
+
+const (
+	// ShapeOrgFolderDocument is the model of the organizations whose members own and share documents in a
+	// hierarchy of folders, whose permissions are inherited from the parent folders.
+	ShapeOrgFolderDocument Shape = "org_folder_document"
+
+	// ShapeGitHub is the model of the organizations whose repositories are shared with users and nested teams,
+	// whose permissions are inherited from the base permissions of the organization.
+	ShapeGitHub Shape = "github"
+
+	// ShapeFlat is the canonical model of the documents shared directly with users, without any rewrite.
+	ShapeFlat Shape = "flat"
+
+	// ShapeDeepHierarchy is the canonical model of the folders whose viewers are inherited from long chains of
+	// parent folders.
+	ShapeDeepHierarchy Shape = "deep_hierarchy"
+
+	// ShapeWideGroups is the canonical model of the documents shared with groups of many members, which may be
+	// nested.
+	ShapeWideGroups Shape = "wide_groups"
+
+	// ShapeIntersection is the canonical model of the documents whose permissions intersect and exclude several
+	// relations.
+	ShapeIntersection Shape = "intersection"
+)
+
+// deepHierarchyDepth is the number of folders of the chains of parent folders of ShapeDeepHierarchy, within
+// the default resolution depth of the server.
+const deepHierarchyDepth = 10
+
+// Shapes returns the shapes of the models the generator supports.
+func Shapes() []Shape {
+	return []Shape{ShapeOrgFolderDocument, ShapeGitHub, ShapeFlat, ShapeDeepHierarchy, ShapeWideGroups, ShapeIntersection}
+}
+
+// CanonicalShapes returns the shapes of the canonical models, each of which stresses a single kind of
+// resolution, e.g. the benchmarks that detect the regressions of the latency of Check and ListObjects.
+func CanonicalShapes() []Shape {
+	return []Shape{ShapeFlat, ShapeDeepHierarchy, ShapeWideGroups, ShapeIntersection}
+}
+
+// ParseShape returns the shape with the name, e.g. 'github'.
+func ParseShape(name string) (Shape, error) {
+	for _, s := range Shapes() {
+		if strings.EqualFold(name, string(s)) {
+			return s, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: '%s'", ErrUnknownShape, name)
+}
+
+// Config configures the generation of a fixture.
+type Config struct {
+	// Seed seeds the random source of the generator. The same config always generates the same fixture.
+	Seed int64
+
+	// Users is the number of users.
+	Users int
+
+	// Objects is the number of objects of each type of the model, other than the users.
+	Objects int
+
+	// TuplesPerRelation is the number of tuples that relate users to the objects of each relation with
+	// directly related user types. The duplicates are dropped, so there may be fewer of them.
+	TuplesPerRelation int
+
+	// Skew is the exponent of the Zipf distribution the users and the objects of the tuples are drawn from:
+	// the greater it is, the more the tuples concentrate on a few users and objects. The users and the
+	// objects are drawn uniformly if it's 0, otherwise it must be greater than 1.
+	Skew float64
+
+	// Checks is the number of tuple keys to check against the fixture to sample.
+	Checks int
+}
+
+// DefaultConfig returns the config of a fixture of a few thousand tuples, with a moderate skew.
+func DefaultConfig() Config {
+	return Config{
+		Seed:              1,
+		Users:             1000,
+		Objects:           200,
+		TuplesPerRelation: 500,
+		Skew:              1.1,
+		Checks:            100,
+	}
+}
+
+func (c Config) validate() error {
+	if c.Users < 1 || c.Objects < 1 {
+		return fmt.Errorf("%w: there must be at least one user and one object of each type", ErrInvalidConfig)
+	}
+
+	if c.TuplesPerRelation < 0 || c.Checks < 0 {
+		return fmt.Errorf("%w: the number of tuples and of checks can't be negative", ErrInvalidConfig)
+	}
+
+	if c.Skew != 0 && c.Skew <= 1 {
+		return fmt.Errorf("%w: the skew must be 0 or greater than 1", ErrInvalidConfig)
+	}
+
+	return nil
+}
+
+// Fixture is a generated model, with the tuples of a store and the tuple keys to check against them.
+type Fixture struct {
+	Shape Shape
+
+	// Model is the model of the fixture, without an ID.
+	Model *openfgav1.AuthorizationModel
+
+	Tuples []*openfgav1.TupleKey
+
+	// Checks are the tuple keys to check against the model and the tuples, whose users are drawn with the
+	// skew of the tuples.
+	Checks []*openfgav1.TupleKey
+}
+
+// Write writes the model of the fixture, with a new ID, and its tuples, in batches of the size of the writes of
+// the datastore, to a store. It returns the ID of the model.
+func (f *Fixture) Write(ctx context.Context, ds storage.OpenFGADatastore, store string) (string, error) {
+	model := &openfgav1.AuthorizationModel{
+		Id:              ulid.Make().String(),
+		SchemaVersion:   f.Model.GetSchemaVersion(),
+		TypeDefinitions: f.Model.GetTypeDefinitions(),
+	}
+	if err := ds.WriteAuthorizationModel(ctx, store, model); err != nil {
+		return "", err
+	}
+
+	batchSize := ds.MaxTuplesPerWrite()
+	for start := 0; start < len(f.Tuples); start += batchSize {
+		end := start + batchSize
+		if end > len(f.Tuples) {
+			end = len(f.Tuples)
+		}
+
+		if err := ds.Write(ctx, store, nil, f.Tuples[start:end]); err != nil {
+			return "", err
+		}
+	}
+
+	return model.GetId(), nil
+}
+
+// shape is the model of a shape, and the way the tuples of its relations are generated.
+type shape struct {
+	model string
+
+	// populate generates the tuples of the fixture
+	populate func(g *generator)
+
+	// checks are the 'type#relation' the tuple keys checked against the fixture are drawn from
+	checks []string
+}
+
+var shapes = map[Shape]shape{
+	ShapeOrgFolderDocument: {
+		model: `
+		type user
+		type organization
+		  relations
+		    define admin: [user] as self
+		    define member: [user] as self or admin
+		type folder
+		  relations
+		    define org: [organization] as self
+		    define parent: [folder] as self
+		    define owner: [user] as self or admin from org
+		    define editor: [user, organization#member] as self or owner or editor from parent
+		    define viewer: [user, organization#member] as self or editor or viewer from parent
+		type document
+		  relations
+		    define parent: [folder] as self
+		    define owner: [user] as self
+		    define editor: [user, organization#member] as self or owner or editor from parent
+		    define viewer: [user, organization#member] as self or editor or viewer from parent
+		`,
+		populate: func(g *generator) {
+			g.related("organization", "admin", "user")
+			g.related("organization", "member", "user")
+
+			g.parents("folder", "org", "organization")
+			g.tree("folder", "parent", "")
+			g.related("folder", "owner", "user")
+			g.related("folder", "editor", "user", "organization#member")
+			g.related("folder", "viewer", "user", "organization#member")
+
+			g.parents("document", "parent", "folder")
+			g.related("document", "owner", "user")
+			g.related("document", "editor", "user", "organization#member")
+			g.related("document", "viewer", "user", "organization#member")
+		},
+		checks: []string{"document#viewer", "document#editor", "folder#viewer"},
+	},
+	ShapeGitHub: {
+		model: `
+		type user
+		type team
+		  relations
+		    define member: [user, team#member] as self
+		type organization
+		  relations
+		    define owner: [user] as self
+		    define member: [user] as self or owner
+		    define repo_admin: [user, organization#member] as self
+		    define repo_writer: [user, organization#member] as self
+		    define repo_reader: [user, organization#member] as self
+		type repo
+		  relations
+		    define owner: [organization] as self
+		    define admin: [user, team#member] as self or repo_admin from owner
+		    define maintainer: [user, team#member] as self or admin
+		    define writer: [user, team#member] as self or maintainer or repo_writer from owner
+		    define triager: [user, team#member] as self or writer
+		    define reader: [user, team#member] as self or triager or repo_reader from owner
+		`,
+		populate: func(g *generator) {
+			g.related("team", "member", "user")
+			g.tree("team", "member", "member")
+
+			g.related("organization", "owner", "user")
+			g.related("organization", "member", "user")
+			g.related("organization", "repo_admin", "user", "organization#member")
+			g.related("organization", "repo_writer", "user", "organization#member")
+			g.related("organization", "repo_reader", "user", "organization#member")
+
+			g.parents("repo", "owner", "organization")
+			g.related("repo", "admin", "user", "team#member")
+			g.related("repo", "maintainer", "user", "team#member")
+			g.related("repo", "writer", "user", "team#member")
+			g.related("repo", "triager", "user", "team#member")
+			g.related("repo", "reader", "user", "team#member")
+		},
+		checks: []string{"repo#reader", "repo#writer", "repo#admin"},
+	},
+	ShapeFlat: {
+		model: `
+		type user
+		type document
+		  relations
+		    define viewer: [user] as self
+		`,
+		populate: func(g *generator) {
+			g.related("document", "viewer", "user")
+		},
+		checks: []string{"document#viewer"},
+	},
+	ShapeDeepHierarchy: {
+		model: `
+		type user
+		type folder
+		  relations
+		    define parent: [folder] as self
+		    define viewer: [user] as self or viewer from parent
+		`,
+		populate: func(g *generator) {
+			g.chains("folder", "parent", deepHierarchyDepth)
+			g.related("folder", "viewer", "user")
+		},
+		checks: []string{"folder#viewer"},
+	},
+	ShapeWideGroups: {
+		model: `
+		type user
+		type group
+		  relations
+		    define member: [user, group#member] as self
+		type document
+		  relations
+		    define viewer: [user, group#member] as self
+		`,
+		populate: func(g *generator) {
+			g.members("group", "member", "user", g.config.Users/40)
+			g.tree("group", "member", "member")
+			g.related("document", "viewer", "user", "group#member")
+		},
+		checks: []string{"document#viewer"},
+	},
+	ShapeIntersection: {
+		model: `
+		type user
+		type organization
+		  relations
+		    define member: [user] as self
+		type document
+		  relations
+		    define org: [organization] as self
+		    define viewer: [user] as self
+		    define approved: [user] as self
+		    define blocked: [user] as self
+		    define can_view as viewer and approved and member from org
+		    define can_view_unblocked as can_view but not blocked
+		`,
+		populate: func(g *generator) {
+			g.related("organization", "member", "user")
+			g.parents("document", "org", "organization")
+			g.related("document", "viewer", "user")
+			g.related("document", "approved", "user")
+			g.related("document", "blocked", "user")
+		},
+		checks: []string{"document#can_view", "document#can_view_unblocked"},
+	},
+}
+
+// Generate generates a fixture of the shape with the config.
+func Generate(s Shape, config Config) (*Fixture, error) {
+	spec, ok := shapes[s]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownShape, s)
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		config: config,
+		rand:   rand.New(rand.NewSource(config.Seed)),
+		zipfs:  map[int]*rand.Zipf{},
+		seen:   map[string]struct{}{},
+	}
+	spec.populate(g)
+
+	fixture := &Fixture{
+		Shape: s,
+		Model: &openfgav1.AuthorizationModel{
+			SchemaVersion:   typesystem.SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(spec.model),
+		},
+		Tuples: g.tuples,
+		Checks: make([]*openfgav1.TupleKey, 0, config.Checks),
+	}
+
+	for i := 0; i < config.Checks; i++ {
+		objectType, relation := tuple.SplitObjectRelation(spec.checks[g.rand.Intn(len(spec.checks))])
+		fixture.Checks = append(fixture.Checks, tuple.NewTupleKey(g.object(objectType), relation, g.user()))
+	}
+
+	return fixture, nil
+}
+
+// MustGenerate is Generate, which panics if the shape or the config is invalid.
+func MustGenerate(s Shape, config Config) *Fixture {
+	fixture, err := Generate(s, config)
+	if err != nil {
+		panic(err)
+	}
+
+	return fixture
+}
+
+type generator struct {
+	config Config
+	rand   *rand.Rand
+
+	// [n] => the Zipf distribution of the indexes in [0, n)
+	zipfs map[int]*rand.Zipf
+
+	// [tupleKey] => the tuple has been generated
+	seen   map[string]struct{}
+	tuples []*openfgav1.TupleKey
+}
+
+// index draws an index in [0, n) with the skew of the config.
+func (g *generator) index(n int) int {
+	if g.config.Skew == 0 || n == 1 {
+		return g.rand.Intn(n)
+	}
+
+	zipf, ok := g.zipfs[n]
+	if !ok {
+		zipf = rand.NewZipf(g.rand, g.config.Skew, 1, uint64(n-1))
+		g.zipfs[n] = zipf
+	}
+
+	return int(zipf.Uint64())
+}
+
+func (g *generator) user() string {
+	return fmt.Sprintf("user:%d", g.index(g.config.Users))
+}
+
+func (g *generator) object(objectType string) string {
+	return fmt.Sprintf("%s:%d", objectType, g.index(g.config.Objects))
+}
+
+func (g *generator) add(object, relation, user string) {
+	tk := tuple.NewTupleKey(object, relation, user)
+
+	key := tuple.TupleKeyToString(tk)
+	if _, ok := g.seen[key]; ok {
+		return
+	}
+
+	g.seen[key] = struct{}{}
+	g.tuples = append(g.tuples, tk)
+}
+
+// related generates the tuples of a relation, whose users are drawn from the user types, e.g. 'user' or
+// 'organization#member'.
+func (g *generator) related(objectType, relation string, userTypes ...string) {
+	for i := 0; i < g.config.TuplesPerRelation; i++ {
+		userType := userTypes[g.rand.Intn(len(userTypes))]
+
+		var user string
+		if userType == "user" {
+			user = g.user()
+		} else {
+			userObjectType, userRelation := tuple.SplitObjectRelation(userType)
+			user = tuple.ToObjectRelationString(g.object(userObjectType), userRelation)
+		}
+
+		g.add(g.object(objectType), relation, user)
+	}
+}
+
+// parents relates every object of the type to a parent of the parent type, e.g. every document to its folder.
+func (g *generator) parents(objectType, relation, parentType string) {
+	for i := 0; i < g.config.Objects; i++ {
+		g.add(fmt.Sprintf("%s:%d", objectType, i), relation, g.object(parentType))
+	}
+}
+
+// members relates every object of the type to a number of users of the user type, e.g. every group to its
+// members, drawn with the skew.
+func (g *generator) members(objectType, relation, userType string, perObject int) {
+	for i := 0; i < g.config.Objects; i++ {
+		for j := 0; j < perObject; j++ {
+			g.add(fmt.Sprintf("%s:%d", objectType, i), relation, fmt.Sprintf("%s:%d", userType, g.index(g.config.Users)))
+		}
+	}
+}
+
+// chains relates the objects of the type into chains of the length through the relation, e.g. every folder to
+// its parent folder: every object is related to the one before it, but the first object of each chain.
+func (g *generator) chains(objectType, relation string, length int) {
+	for i := 1; i < g.config.Objects; i++ {
+		if i%length != 0 {
+			g.add(fmt.Sprintf("%s:%d", objectType, i), relation, fmt.Sprintf("%s:%d", objectType, i-1))
+		}
+	}
+}
+
+// tree relates the objects of the type into a tree, through the relation: every object but the first is related
+// to an object before it, or to its userset with the userset relation if it's set, e.g. 'team:0#member' for a
+// nested team. The objects before an object are drawn with the skew, so that the tree is deeper without it.
+func (g *generator) tree(objectType, relation, usersetRelation string) {
+	for i := 1; i < g.config.Objects; i++ {
+		user := fmt.Sprintf("%s:%d", objectType, g.index(i))
+		if usersetRelation != "" {
+			user = tuple.ToObjectRelationString(user, usersetRelation)
+		}
+
+		g.add(fmt.Sprintf("%s:%d", objectType, i), relation, user)
+	}
+}
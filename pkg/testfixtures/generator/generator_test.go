@@ -0,0 +1,140 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/internal/validation"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	for _, s := range Shapes() {
+		t.Run(string(s), func(t *testing.T) {
+			config := DefaultConfig()
+
+			fixture, err := Generate(s, config)
+			require.NoError(t, err)
+			require.NotEmpty(t, fixture.Tuples)
+			require.Len(t, fixture.Checks, config.Checks)
+
+			typesys, err := typesystem.NewAndValidate(context.Background(), fixture.Model)
+			require.NoError(t, err)
+
+			seen := map[string]struct{}{}
+			for _, tk := range fixture.Tuples {
+				require.NoError(t, validation.ValidateTuple(typesys, tk))
+
+				key := tuple.TupleKeyToString(tk)
+				require.NotContains(t, seen, key)
+				seen[key] = struct{}{}
+			}
+
+			for _, tk := range fixture.Checks {
+				require.NoError(t, validation.ValidateUserObjectRelation(typesys, tk))
+			}
+
+			// the same config always generates the same fixture
+			again, err := Generate(s, config)
+			require.NoError(t, err)
+			require.Equal(t, fixture.Tuples, again.Tuples)
+			require.Equal(t, fixture.Checks, again.Checks)
+		})
+	}
+}
+
+func TestGenerateSkew(t *testing.T) {
+	// usersOfHottest returns the share of the tuples of the most popular user of the fixture
+	usersOfHottest := func(fixture *Fixture) float64 {
+		counts := map[string]int{}
+		var total, hottest int
+		for _, tk := range fixture.Tuples {
+			if tuple.GetType(tk.GetUser()) != "user" {
+				continue
+			}
+
+			total++
+			counts[tk.GetUser()]++
+			if counts[tk.GetUser()] > hottest {
+				hottest = counts[tk.GetUser()]
+			}
+		}
+
+		return float64(hottest) / float64(total)
+	}
+
+	config := DefaultConfig()
+	config.Skew = 0
+	uniform := MustGenerate(ShapeOrgFolderDocument, config)
+
+	config.Skew = 2
+	skewed := MustGenerate(ShapeOrgFolderDocument, config)
+
+	require.Less(t, usersOfHottest(uniform), 0.05)
+	require.Greater(t, usersOfHottest(skewed), 0.2)
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	_, err := Generate("unknown", DefaultConfig())
+	require.ErrorIs(t, err, ErrUnknownShape)
+
+	for name, config := range map[string]Config{
+		"no_users":        {Objects: 1},
+		"no_objects":      {Users: 1},
+		"negative_tuples": {Users: 1, Objects: 1, TuplesPerRelation: -1},
+		"skew_too_low":    {Users: 1, Objects: 1, Skew: 0.5},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Generate(ShapeGitHub, config)
+			require.ErrorIs(t, err, ErrInvalidConfig)
+		})
+	}
+}
+
+func TestParseShape(t *testing.T) {
+	s, err := ParseShape("GitHub")
+	require.NoError(t, err)
+	require.Equal(t, ShapeGitHub, s)
+
+	_, err = ParseShape("drive")
+	require.ErrorIs(t, err, ErrUnknownShape)
+}
+
+func TestWrite(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New(memory.WithMaxTuplesPerWrite(10))
+	defer ds.Close()
+
+	store := ulid.Make().String()
+
+	config := DefaultConfig()
+	config.TuplesPerRelation = 20
+	fixture := MustGenerate(ShapeGitHub, config)
+
+	modelID, err := fixture.Write(ctx, ds, store)
+	require.NoError(t, err)
+
+	model, err := ds.ReadAuthorizationModel(ctx, store, modelID)
+	require.NoError(t, err)
+	require.Equal(t, fixture.Model.GetTypeDefinitions(), model.GetTypeDefinitions())
+
+	iter, err := ds.Read(ctx, store, &openfgav1.TupleKey{}, storage.ReadOptions{})
+	require.NoError(t, err)
+	defer iter.Stop()
+
+	var read int
+	for {
+		if _, err := iter.Next(); err != nil {
+			require.ErrorIs(t, err, storage.ErrIteratorDone)
+			break
+		}
+		read++
+	}
+	require.Equal(t, len(fixture.Tuples), read)
+}
@@ -0,0 +1,74 @@
+package tuple
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Crashing inputs found by the fuzzers below are persisted by the Go toolchain under testdata/fuzz
+// and are replayed as regression tests by a regular `go test` run.
+
+func FuzzSplitObject(f *testing.F) {
+	for _, seed := range []string{"", ":", "document:1", "document:", ":1", "document:1:2", "document#viewer"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, object string) {
+		objectType, objectID := SplitObject(object)
+
+		if strings.Contains(object, ":") {
+			require.Equal(t, object, BuildObject(objectType, objectID))
+		} else {
+			require.Empty(t, objectType)
+			require.Equal(t, object, objectID)
+		}
+
+		_ = IsValidObject(object)
+	})
+}
+
+func FuzzSplitObjectRelation(f *testing.F) {
+	for _, seed := range []string{"", "#", "document:1#viewer", "document:1#", "#viewer", "document:1#viewer#editor", "group:*#member"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, objectRelation string) {
+		object, relation := SplitObjectRelation(objectRelation)
+
+		if strings.Contains(objectRelation, "#") {
+			require.Equal(t, objectRelation, ToObjectRelationString(object, relation))
+		} else {
+			require.Equal(t, objectRelation, object)
+			require.Empty(t, relation)
+		}
+
+		require.Equal(t, relation, GetRelation(objectRelation))
+		_ = IsObjectRelation(objectRelation)
+		_ = IsValidRelation(relation)
+	})
+}
+
+func FuzzTupleKey(f *testing.F) {
+	f.Add("document:1", "viewer", "user:jon")
+	f.Add("document:1", "viewer", "group:eng#member")
+	f.Add("document:1", "viewer", "user:*")
+	f.Add("document:1", "viewer", "*")
+	f.Add("", "", "")
+	f.Add("document:1:2", "view#er", "a:b:c#d#e")
+
+	f.Fuzz(func(t *testing.T, object, relation, user string) {
+		tk := NewTupleKey(object, relation, user)
+
+		_ = TupleKeyToString(tk)
+		_ = GetUserTypeFromUser(user)
+		_ = IsWildcard(user)
+		_ = IsTypedWildcard(user)
+
+		if IsValidUser(user) {
+			require.LessOrEqual(t, strings.Count(user, ":"), 1)
+			require.LessOrEqual(t, strings.Count(user, "#"), 1)
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package tuple
+
+import (
+	"errors"
+	"fmt"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+)
+
+var (
+	ErrInvalidObject   = errors.New("invalid object")
+	ErrInvalidRelation = errors.New("invalid relation")
+	ErrInvalidUser     = errors.New("invalid user")
+)
+
+// UserKind is the kind of the user of a tuple.
+type UserKind string
+
+const (
+	// UserKindUser is the kind of the users that are objects, e.g. 'user:anne'.
+	UserKindUser UserKind = "user"
+
+	// UserKindUserset is the kind of the users that are the usersets of objects, e.g. 'group:eng#member'.
+	UserKindUserset UserKind = "userset"
+
+	// UserKindWildcard is the kind of the users that are typed wildcards, e.g. 'user:*'.
+	UserKindWildcard UserKind = "wildcard"
+)
+
+// ObjectRef is an object of the form 'type:id'.
+type ObjectRef struct {
+	Type string
+	ID   string
+}
+
+// NewObjectRef returns the ObjectRef of the provided type and ID.
+func NewObjectRef(objectType, objectID string) ObjectRef {
+	return ObjectRef{Type: objectType, ID: objectID}
+}
+
+// String returns the object as it's written in the tuples, i.e. 'type:id'.
+func (o ObjectRef) String() string {
+	return BuildObject(o.Type, o.ID)
+}
+
+// ParseObject parses an object of the form 'type:id'. It returns an error wrapping ErrInvalidObject if the
+// object is malformed.
+func ParseObject(object string) (ObjectRef, error) {
+	if !IsValidObject(object) {
+		return ObjectRef{}, fmt.Errorf("%w '%s': it must be of the form 'type:id'", ErrInvalidObject, object)
+	}
+
+	objectType, objectID := SplitObject(object)
+	return NewObjectRef(objectType, objectID), nil
+}
+
+// UserRef is a typed user: an object (e.g. 'user:anne'), the userset of an object (e.g. 'group:eng#member')
+// or a typed wildcard (e.g. 'user:*'). The ID of a wildcard is Wildcard, and the Relation is only set for
+// the usersets.
+type UserRef struct {
+	Kind     UserKind
+	Type     string
+	ID       string
+	Relation string
+}
+
+// NewUserRef returns the UserRef of the object of the provided type and ID.
+func NewUserRef(objectType, objectID string) UserRef {
+	return UserRef{Kind: UserKindUser, Type: objectType, ID: objectID}
+}
+
+// NewUsersetRef returns the UserRef of the userset of the provided relation of the object of the provided
+// type and ID.
+func NewUsersetRef(objectType, objectID, relation string) UserRef {
+	return UserRef{Kind: UserKindUserset, Type: objectType, ID: objectID, Relation: relation}
+}
+
+// NewWildcardRef returns the UserRef of the typed wildcard of the provided type.
+func NewWildcardRef(objectType string) UserRef {
+	return UserRef{Kind: UserKindWildcard, Type: objectType, ID: Wildcard}
+}
+
+// Object returns the object of the user, i.e. 'type:id' for the objects and the usersets, and 'type:*' for
+// the wildcards.
+func (u UserRef) Object() ObjectRef {
+	return NewObjectRef(u.Type, u.ID)
+}
+
+// String returns the user as it's written in the tuples.
+func (u UserRef) String() string {
+	if u.Kind == UserKindUserset {
+		return ToObjectRelationString(BuildObject(u.Type, u.ID), u.Relation)
+	}
+
+	return BuildObject(u.Type, u.ID)
+}
+
+// ParseUser parses a typed user of the form 'type:id', 'type:id#relation' or 'type:*'. It returns an error
+// wrapping ErrInvalidUser if the user is malformed or untyped (e.g. 'anne' or '*').
+func ParseUser(user string) (UserRef, error) {
+	if !IsValidUser(user) {
+		return UserRef{}, fmt.Errorf("%w '%s'", ErrInvalidUser, user)
+	}
+
+	object, relation := SplitObjectRelation(user)
+	objectType, objectID := SplitObject(object)
+	if objectType == "" || objectID == "" {
+		return UserRef{}, fmt.Errorf("%w '%s': it must be of the form 'type:id', 'type:id#relation' or 'type:*'", ErrInvalidUser, user)
+	}
+
+	switch {
+	case relation != "":
+		if objectID == Wildcard {
+			return UserRef{}, fmt.Errorf("%w '%s': a wildcard can't have a relation", ErrInvalidUser, user)
+		}
+		return NewUsersetRef(objectType, objectID, relation), nil
+	case objectID == Wildcard:
+		return NewWildcardRef(objectType), nil
+	default:
+		return NewUserRef(objectType, objectID), nil
+	}
+}
+
+// TupleKeyBuilder builds valid tuple keys, e.g.
+//
+//	tk, err := tuple.NewTupleKeyBuilder().
+//		Object("document", "roadmap").
+//		Relation("viewer").
+//		Userset("group", "eng", "member").
+//		Build()
+type TupleKeyBuilder struct {
+	object   ObjectRef
+	relation string
+	user     UserRef
+}
+
+// NewTupleKeyBuilder returns an empty TupleKeyBuilder.
+func NewTupleKeyBuilder() *TupleKeyBuilder {
+	return &TupleKeyBuilder{}
+}
+
+// Object sets the object of the tuple key.
+func (b *TupleKeyBuilder) Object(objectType, objectID string) *TupleKeyBuilder {
+	b.object = NewObjectRef(objectType, objectID)
+	return b
+}
+
+// Relation sets the relation of the tuple key.
+func (b *TupleKeyBuilder) Relation(relation string) *TupleKeyBuilder {
+	b.relation = relation
+	return b
+}
+
+// User sets the user of the tuple key to the object of the provided type and ID.
+func (b *TupleKeyBuilder) User(objectType, objectID string) *TupleKeyBuilder {
+	b.user = NewUserRef(objectType, objectID)
+	return b
+}
+
+// Userset sets the user of the tuple key to the userset of the provided relation of the object of the
+// provided type and ID.
+func (b *TupleKeyBuilder) Userset(objectType, objectID, relation string) *TupleKeyBuilder {
+	b.user = NewUsersetRef(objectType, objectID, relation)
+	return b
+}
+
+// Wildcard sets the user of the tuple key to the typed wildcard of the provided type.
+func (b *TupleKeyBuilder) Wildcard(objectType string) *TupleKeyBuilder {
+	b.user = NewWildcardRef(objectType)
+	return b
+}
+
+// Build returns the tuple key, or an error wrapping ErrInvalidObject, ErrInvalidRelation or ErrInvalidUser
+// if any part of it is missing or malformed.
+func (b *TupleKeyBuilder) Build() (*openfgav1.TupleKey, error) {
+	object := b.object.String()
+	if _, err := ParseObject(object); err != nil || b.object.ID == Wildcard {
+		return nil, fmt.Errorf("%w '%s': it must be of the form 'type:id'", ErrInvalidObject, object)
+	}
+
+	if !IsValidRelation(b.relation) {
+		return nil, fmt.Errorf("%w '%s'", ErrInvalidRelation, b.relation)
+	}
+
+	user := b.user.String()
+	parsed, err := ParseUser(user)
+	if err != nil {
+		return nil, err
+	}
+	if parsed != b.user {
+		return nil, fmt.Errorf("%w '%s'", ErrInvalidUser, user)
+	}
+
+	return NewTupleKey(object, b.relation, user), nil
+}
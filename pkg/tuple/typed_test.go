@@ -0,0 +1,163 @@
+package tuple
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseObject(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		object   string
+		expected ObjectRef
+		valid    bool
+	}{
+		{
+			name:     "valid",
+			object:   "document:roadmap",
+			expected: ObjectRef{Type: "document", ID: "roadmap"},
+			valid:    true,
+		},
+		{
+			name:   "empty",
+			object: "",
+		},
+		{
+			name:   "untyped",
+			object: "roadmap",
+		},
+		{
+			name:   "with_relation",
+			object: "document:roadmap#viewer",
+		},
+		{
+			name:   "missing_id",
+			object: "document:",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			object, err := ParseObject(tc.object)
+			if !tc.valid {
+				require.ErrorIs(t, err, ErrInvalidObject)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, object)
+			require.Equal(t, tc.object, object.String())
+		})
+	}
+}
+
+func TestParseUser(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		user     string
+		expected UserRef
+		valid    bool
+	}{
+		{
+			name:     "user",
+			user:     "user:anne",
+			expected: UserRef{Kind: UserKindUser, Type: "user", ID: "anne"},
+			valid:    true,
+		},
+		{
+			name:     "userset",
+			user:     "group:eng#member",
+			expected: UserRef{Kind: UserKindUserset, Type: "group", ID: "eng", Relation: "member"},
+			valid:    true,
+		},
+		{
+			name:     "wildcard",
+			user:     "user:*",
+			expected: UserRef{Kind: UserKindWildcard, Type: "user", ID: Wildcard},
+			valid:    true,
+		},
+		{
+			name: "untyped_user",
+			user: "anne",
+		},
+		{
+			name: "untyped_wildcard",
+			user: "*",
+		},
+		{
+			name: "wildcard_with_relation",
+			user: "user:*#member",
+		},
+		{
+			name: "missing_id",
+			user: "group:#member",
+		},
+		{
+			name: "too_many_separators",
+			user: "user:anne:bob",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			user, err := ParseUser(tc.user)
+			if !tc.valid {
+				require.ErrorIs(t, err, ErrInvalidUser)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, user)
+			require.Equal(t, tc.user, user.String())
+		})
+	}
+}
+
+func TestTupleKeyBuilder(t *testing.T) {
+	t.Run("user", func(t *testing.T) {
+		tk, err := NewTupleKeyBuilder().Object("document", "roadmap").Relation("viewer").User("user", "anne").Build()
+		require.NoError(t, err)
+		require.Equal(t, NewTupleKey("document:roadmap", "viewer", "user:anne"), tk)
+	})
+
+	t.Run("userset", func(t *testing.T) {
+		tk, err := NewTupleKeyBuilder().Object("document", "roadmap").Relation("viewer").Userset("group", "eng", "member").Build()
+		require.NoError(t, err)
+		require.Equal(t, NewTupleKey("document:roadmap", "viewer", "group:eng#member"), tk)
+	})
+
+	t.Run("wildcard", func(t *testing.T) {
+		tk, err := NewTupleKeyBuilder().Object("document", "roadmap").Relation("viewer").Wildcard("user").Build()
+		require.NoError(t, err)
+		require.Equal(t, NewTupleKey("document:roadmap", "viewer", "user:*"), tk)
+	})
+
+	t.Run("missing_object", func(t *testing.T) {
+		_, err := NewTupleKeyBuilder().Relation("viewer").User("user", "anne").Build()
+		require.ErrorIs(t, err, ErrInvalidObject)
+	})
+
+	t.Run("wildcard_object", func(t *testing.T) {
+		_, err := NewTupleKeyBuilder().Object("document", Wildcard).Relation("viewer").User("user", "anne").Build()
+		require.ErrorIs(t, err, ErrInvalidObject)
+	})
+
+	t.Run("invalid_relation", func(t *testing.T) {
+		_, err := NewTupleKeyBuilder().Object("document", "roadmap").Relation("can view").User("user", "anne").Build()
+		require.ErrorIs(t, err, ErrInvalidRelation)
+	})
+
+	t.Run("missing_user", func(t *testing.T) {
+		_, err := NewTupleKeyBuilder().Object("document", "roadmap").Relation("viewer").Build()
+		require.ErrorIs(t, err, ErrInvalidUser)
+	})
+
+	t.Run("userset_without_relation", func(t *testing.T) {
+		_, err := NewTupleKeyBuilder().Object("document", "roadmap").Relation("viewer").Userset("group", "eng", "").Build()
+		require.ErrorIs(t, err, ErrInvalidUser)
+	})
+
+	t.Run("wildcard_id_as_user", func(t *testing.T) {
+		_, err := NewTupleKeyBuilder().Object("document", "roadmap").Relation("viewer").User("user", Wildcard).Build()
+		require.ErrorIs(t, err, ErrInvalidUser)
+	})
+}
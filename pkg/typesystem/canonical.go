@@ -0,0 +1,165 @@
+package typesystem
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// CanonicalizeModel returns a copy of the model in its canonical form, without its ID: the types are sorted
+// by name, as are the directly related user types of every relation, the nested unions and intersections
+// are flattened, and their operands, which commute, are sorted. Two models that only differ by these
+// orders and groupings, which don't change what they resolve, have the same canonical form.
+func CanonicalizeModel(model *openfgav1.AuthorizationModel) *openfgav1.AuthorizationModel {
+	canonical := proto.Clone(model).(*openfgav1.AuthorizationModel)
+	canonical.Id = ""
+
+	for _, typeDefinition := range canonical.GetTypeDefinitions() {
+		for name, rewrite := range typeDefinition.GetRelations() {
+			typeDefinition.Relations[name] = canonicalizeRewrite(rewrite)
+		}
+
+		for _, metadata := range typeDefinition.GetMetadata().GetRelations() {
+			references := metadata.GetDirectlyRelatedUserTypes()
+			sort.SliceStable(references, func(i, j int) bool {
+				return relationReferenceKey(references[i]) < relationReferenceKey(references[j])
+			})
+		}
+	}
+
+	sort.SliceStable(canonical.TypeDefinitions, func(i, j int) bool {
+		return canonical.TypeDefinitions[i].GetType() < canonical.TypeDefinitions[j].GetType()
+	})
+
+	return canonical
+}
+
+// canonicalizeRewrite flattens the nested unions and intersections of the rewrite, and sorts their operands.
+func canonicalizeRewrite(rewrite *openfgav1.Userset) *openfgav1.Userset {
+	switch rw := rewrite.GetUserset().(type) {
+	case *openfgav1.Userset_Union:
+		rw.Union.Child = canonicalizeOperands(rw.Union.GetChild(), func(u *openfgav1.Userset) []*openfgav1.Userset {
+			return u.GetUnion().GetChild()
+		})
+	case *openfgav1.Userset_Intersection:
+		rw.Intersection.Child = canonicalizeOperands(rw.Intersection.GetChild(), func(u *openfgav1.Userset) []*openfgav1.Userset {
+			return u.GetIntersection().GetChild()
+		})
+	case *openfgav1.Userset_Difference:
+		rw.Difference.Base = canonicalizeRewrite(rw.Difference.GetBase())
+		rw.Difference.Subtract = canonicalizeRewrite(rw.Difference.GetSubtract())
+	}
+
+	return rewrite
+}
+
+// canonicalizeOperands canonicalizes the operands of a union or of an intersection, replacing the operands
+// that are operations of the same kind (i.e. whose children returns some operands) by their own operands.
+func canonicalizeOperands(operands []*openfgav1.Userset, children func(*openfgav1.Userset) []*openfgav1.Userset) []*openfgav1.Userset {
+	flattened := make([]*openfgav1.Userset, 0, len(operands))
+	for _, operand := range operands {
+		operand = canonicalizeRewrite(operand)
+		if nested := children(operand); len(nested) > 0 {
+			flattened = append(flattened, nested...)
+			continue
+		}
+
+		flattened = append(flattened, operand)
+	}
+
+	sort.SliceStable(flattened, func(i, j int) bool {
+		return rewriteKey(flattened[i]) < rewriteKey(flattened[j])
+	})
+
+	return flattened
+}
+
+// rewriteKey returns a textual representation of the rewrite, which orders the operands of the canonical
+// rewrites: 'self' comes first, then the computed usersets and the tuple to usersets, then the operations.
+func rewriteKey(rewrite *openfgav1.Userset) string {
+	var operator string
+	var operands []*openfgav1.Userset
+
+	switch rw := rewrite.GetUserset().(type) {
+	case *openfgav1.Userset_This:
+		return "0 self"
+	case *openfgav1.Userset_ComputedUserset:
+		return fmt.Sprintf("1 %s", rw.ComputedUserset.GetRelation())
+	case *openfgav1.Userset_TupleToUserset:
+		return fmt.Sprintf("1 %s from %s", rw.TupleToUserset.GetComputedUserset().GetRelation(), rw.TupleToUserset.GetTupleset().GetRelation())
+	case *openfgav1.Userset_Union:
+		operator, operands = "or", rw.Union.GetChild()
+	case *openfgav1.Userset_Intersection:
+		operator, operands = "and", rw.Intersection.GetChild()
+	case *openfgav1.Userset_Difference:
+		operator, operands = "but not", []*openfgav1.Userset{rw.Difference.GetBase(), rw.Difference.GetSubtract()}
+	default:
+		return ""
+	}
+
+	keys := make([]string, 0, len(operands))
+	for _, operand := range operands {
+		keys = append(keys, rewriteKey(operand))
+	}
+
+	return fmt.Sprintf("2 (%s)", strings.Join(keys, fmt.Sprintf(" %s ", operator)))
+}
+
+// relationReferenceKey returns a textual representation of the reference, e.g. 'group#member'.
+func relationReferenceKey(reference *openfgav1.RelationReference) string {
+	switch ref := reference.GetRelationOrWildcard().(type) {
+	case *openfgav1.RelationReference_Relation:
+		return fmt.Sprintf("%s#%s", reference.GetType(), ref.Relation)
+	case *openfgav1.RelationReference_Wildcard:
+		return fmt.Sprintf("%s:*", reference.GetType())
+	default:
+		return reference.GetType()
+	}
+}
+
+// ModelHash returns the hex encoded SHA-256 hash of the canonical form of the model (see CanonicalizeModel),
+// which tells whether two models are identical regardless of their IDs.
+func ModelHash(model *openfgav1.AuthorizationModel) string {
+	canonical := CanonicalizeModel(model)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "schema %s\n", canonical.GetSchemaVersion())
+
+	for _, typeDefinition := range canonical.GetTypeDefinitions() {
+		fmt.Fprintf(h, "type %s\n", typeDefinition.GetType())
+
+		// the relations of the metadata without a rewrite are hashed too, since they're part of the model
+		metadata := typeDefinition.GetMetadata().GetRelations()
+		names := make([]string, 0, len(typeDefinition.GetRelations())+len(metadata))
+		for name := range typeDefinition.GetRelations() {
+			names = append(names, name)
+		}
+		for name := range metadata {
+			if _, ok := typeDefinition.GetRelations()[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			references := make([]string, 0, len(metadata[name].GetDirectlyRelatedUserTypes()))
+			for _, reference := range metadata[name].GetDirectlyRelatedUserTypes() {
+				references = append(references, relationReferenceKey(reference))
+			}
+
+			rewrite := ""
+			if r, ok := typeDefinition.GetRelations()[name]; ok {
+				rewrite = rewriteKey(r)
+			}
+
+			fmt.Fprintf(h, "define %s [%s] %s\n", name, strings.Join(references, ", "), rewrite)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,119 @@
+package typesystem
+
+import (
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelHash(t *testing.T) {
+	model := func(id, dsl string) *openfgav1.AuthorizationModel {
+		return &openfgav1.AuthorizationModel{
+			Id:              id,
+			SchemaVersion:   SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(dsl),
+		}
+	}
+
+	original := model("01HCSBNPGRMSRYJRJRZ0KCZP1C", `
+	type user
+
+	type group
+	  relations
+	    define member: [user, group#member] as self
+
+	type document
+	  relations
+	    define blocked: [user] as self
+	    define editor: [user] as self
+	    define owner: [user] as self
+	    define viewer: [user, group#member] as self or editor or owner
+	    define can_view as viewer but not blocked
+	`)
+
+	// the same model, with another ID, and its types, user types and commuting operands in other orders
+	reordered := model("01HCSBNWV4YGBQW0Y8HP1Q4NKJ", `
+	type document
+	  relations
+	    define can_view as viewer but not blocked
+	    define viewer: [group#member, user] as owner or (editor or self)
+	    define owner: [user] as self
+	    define editor: [user] as self
+	    define blocked: [user] as self
+
+	type group
+	  relations
+	    define member: [group#member, user] as self
+
+	type user
+	`)
+
+	require.Equal(t, ModelHash(original), ModelHash(reordered))
+	require.Len(t, ModelHash(original), 64)
+
+	// the operands of a difference don't commute
+	swapped := model("", `
+	type user
+
+	type group
+	  relations
+	    define member: [user, group#member] as self
+
+	type document
+	  relations
+	    define blocked: [user] as self
+	    define editor: [user] as self
+	    define owner: [user] as self
+	    define viewer: [user, group#member] as self or editor or owner
+	    define can_view as blocked but not viewer
+	`)
+	require.NotEqual(t, ModelHash(original), ModelHash(swapped))
+
+	// a user type more
+	wildcard := model("", `
+	type user
+
+	type group
+	  relations
+	    define member: [user, user:*, group#member] as self
+
+	type document
+	  relations
+	    define blocked: [user] as self
+	    define editor: [user] as self
+	    define owner: [user] as self
+	    define viewer: [user, group#member] as self or editor or owner
+	    define can_view as viewer but not blocked
+	`)
+	require.NotEqual(t, ModelHash(original), ModelHash(wildcard))
+
+	schema10 := model("", `type user`)
+	schema10.SchemaVersion = SchemaVersion1_0
+	require.NotEqual(t, ModelHash(model("", `type user`)), ModelHash(schema10))
+}
+
+func TestCanonicalizeModelDoesNotChangeTheModel(t *testing.T) {
+	model := &openfgav1.AuthorizationModel{
+		Id:            "01HCSBNPGRMSRYJRJRZ0KCZP1C",
+		SchemaVersion: SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+
+		type document
+		  relations
+		    define owner: [user] as self
+		    define viewer: [user] as owner or self
+		`),
+	}
+
+	canonical := CanonicalizeModel(model)
+	require.Empty(t, canonical.GetId())
+	require.Equal(t, "document", canonical.GetTypeDefinitions()[0].GetType())
+	require.IsType(t, &openfgav1.Userset_This{}, canonical.GetTypeDefinitions()[0].GetRelations()["viewer"].GetUnion().GetChild()[0].GetUserset())
+
+	require.Equal(t, "01HCSBNPGRMSRYJRJRZ0KCZP1C", model.GetId())
+	require.Equal(t, "user", model.GetTypeDefinitions()[0].GetType())
+	require.IsType(t, &openfgav1.Userset_ComputedUserset{}, model.GetTypeDefinitions()[1].GetRelations()["viewer"].GetUnion().GetChild()[0].GetUserset())
+}
@@ -0,0 +1,78 @@
+package typesystem
+
+// buildComputedRelationClosure flattens every chain of relations whose rewrites are purely computed
+// usersets (no tuples involved) into a direct mapping from each relation in the chain to the last
+// relation of the chain. For example, given
+//
+//	type document
+//	  relations
+//	    define viewer: editor
+//	    define editor: owner
+//	    define owner: [user]
+//
+// both viewer and editor map to owner.
+func (t *TypeSystem) buildComputedRelationClosure() map[string]map[string]string {
+	closure := make(map[string]map[string]string, len(t.relations))
+
+	for objectType, relations := range t.relations {
+		for relationName := range relations {
+			terminal, ok := t.followComputedRelationChain(objectType, relationName)
+			if !ok {
+				continue
+			}
+
+			if _, ok := closure[objectType]; !ok {
+				closure[objectType] = make(map[string]string)
+			}
+			closure[objectType][relationName] = terminal
+		}
+	}
+
+	return closure
+}
+
+// followComputedRelationChain follows the chain of purely computed userset rewrites starting at the
+// provided relation and returns the last relation in it. It returns false if the relation is not
+// itself defined as a purely computed userset or if the chain contains a cycle.
+func (t *TypeSystem) followComputedRelationChain(objectType, relation string) (string, bool) {
+	visited := map[string]struct{}{relation: {}}
+
+	current := relation
+	for {
+		rel, ok := t.relations[objectType][current]
+		if !ok {
+			return "", false
+		}
+
+		computed := rel.GetRewrite().GetComputedUserset()
+		if computed == nil {
+			break
+		}
+
+		next := computed.GetRelation()
+		if _, ok := visited[next]; ok {
+			return "", false
+		}
+		visited[next] = struct{}{}
+
+		current = next
+	}
+
+	if current == relation {
+		return "", false
+	}
+
+	return current, true
+}
+
+// ResolveComputedRelation returns the relation that ultimately needs to be evaluated in order to
+// resolve the provided relation on the objectType. If the TypeSystem was constructed with
+// WithComputedRelationClosure and the relation is the start of a purely computed userset chain,
+// then the last relation of that chain is returned. Otherwise the provided relation is returned as is.
+func (t *TypeSystem) ResolveComputedRelation(objectType, relation string) string {
+	if terminal, ok := t.computedRelationClosure[objectType][relation]; ok {
+		return terminal
+	}
+
+	return relation
+}
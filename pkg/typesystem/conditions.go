@@ -0,0 +1,142 @@
+package typesystem
+
+import (
+	"errors"
+	"fmt"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/condition"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+var ErrInvalidCondition = errors.New("invalid condition")
+
+// WithConditions adds the conditions of the model, and the type restrictions that allow the tuples to carry
+// them, to the TypeSystem (see the condition package). They're validated against the model when the
+// TypeSystem is validated.
+func WithConditions(conditions *condition.ModelConditions) TypeSystemOption {
+	return func(t *TypeSystem) {
+		t.modelConditions = conditions
+	}
+}
+
+// buildConditions indexes the conditions of the model by name, and the conditions allowed by the type
+// restrictions by 'type#relation' and user type.
+func (t *TypeSystem) buildConditions() {
+	t.conditions = make(map[string]*condition.EvaluableCondition, len(t.modelConditions.Conditions))
+	for _, c := range t.modelConditions.Conditions {
+		t.conditions[c.Name] = condition.NewEvaluableCondition(c)
+	}
+
+	t.conditionedRestrictions = map[string]map[string]map[string]struct{}{}
+	for _, r := range t.modelConditions.TypeRestrictions {
+		key := tuple.ToObjectRelationString(r.Type, r.Relation)
+		if _, ok := t.conditionedRestrictions[key]; !ok {
+			t.conditionedRestrictions[key] = map[string]map[string]struct{}{}
+		}
+		if _, ok := t.conditionedRestrictions[key][r.UserType]; !ok {
+			t.conditionedRestrictions[key][r.UserType] = map[string]struct{}{}
+		}
+		t.conditionedRestrictions[key][r.UserType][r.Condition] = struct{}{}
+	}
+}
+
+// GetModelConditions returns the conditions of the model, or nil if it has none.
+func (t *TypeSystem) GetModelConditions() *condition.ModelConditions {
+	return t.modelConditions
+}
+
+// GetCondition returns the condition of the model with the name, if any.
+func (t *TypeSystem) GetCondition(name string) (*condition.EvaluableCondition, bool) {
+	c, ok := t.conditions[name]
+	return c, ok
+}
+
+// AllowsConditions reports whether the tuples with the object type, the relation and the user type of the
+// tuple key can carry a condition, i.e. whether a type restriction of the model allows them to.
+func (t *TypeSystem) AllowsConditions(tk *openfgav1.TupleKey) bool {
+	if len(t.conditionedRestrictions) == 0 {
+		return false
+	}
+
+	key := tuple.ToObjectRelationString(tuple.GetType(tk.GetObject()), tk.GetRelation())
+	_, ok := t.conditionedRestrictions[key][userType(tk.GetUser())]
+	return ok
+}
+
+// IsConditionAllowed reports whether the tuple key can carry the condition with the name.
+func (t *TypeSystem) IsConditionAllowed(tk *openfgav1.TupleKey, name string) bool {
+	key := tuple.ToObjectRelationString(tuple.GetType(tk.GetObject()), tk.GetRelation())
+	_, ok := t.conditionedRestrictions[key][userType(tk.GetUser())][name]
+	return ok
+}
+
+// validateConditions ensures that every condition compiles, and that every conditioned type restriction
+// applies to a directly related user type of a relation of the model, with a condition of the model.
+func (t *TypeSystem) validateConditions() error {
+	if t.modelConditions.IsEmpty() {
+		return nil
+	}
+
+	for _, c := range t.modelConditions.Conditions {
+		if t.conditions[c.Name] != nil && t.conditions[c.Name].Condition != c {
+			return fmt.Errorf("%w: the condition '%s' is defined more than once", ErrInvalidCondition, c.Name)
+		}
+
+		if err := t.conditions[c.Name].Compile(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidCondition, err)
+		}
+	}
+
+	for _, r := range t.modelConditions.TypeRestrictions {
+		if _, ok := t.conditions[r.Condition]; !ok {
+			return fmt.Errorf("%w: the type restriction '%s' of '%s#%s' uses the undefined condition '%s'", ErrInvalidCondition, r.UserType, r.Type, r.Relation, r.Condition)
+		}
+
+		directlyRelatedTypes, err := t.GetDirectlyRelatedUserTypes(r.Type, r.Relation)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidCondition, err)
+		}
+
+		found := false
+		for _, ref := range directlyRelatedTypes {
+			if relationReferenceString(ref) == r.UserType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: '%s' isn't a directly related user type of '%s#%s'", ErrInvalidCondition, r.UserType, r.Type, r.Relation)
+		}
+	}
+
+	return nil
+}
+
+// userType returns the user type of a user as it's written in the type restrictions, e.g. 'user' for
+// 'user:anne', 'user:*' for the wildcard and 'group#member' for 'group:eng#member'.
+func userType(user string) string {
+	if tuple.IsWildcard(user) {
+		return user
+	}
+
+	object, relation := tuple.SplitObjectRelation(user)
+	if relation != "" {
+		return tuple.GetType(object) + "#" + relation
+	}
+
+	return tuple.GetType(user)
+}
+
+// relationReferenceString returns the user type of a relation reference as it's written in the type
+// restrictions.
+func relationReferenceString(ref *openfgav1.RelationReference) string {
+	switch {
+	case ref.GetWildcard() != nil:
+		return ref.GetType() + ":*"
+	case ref.GetRelation() != "":
+		return ref.GetType() + "#" + ref.GetRelation()
+	default:
+		return ref.GetType()
+	}
+}
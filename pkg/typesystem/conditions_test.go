@@ -0,0 +1,117 @@
+package typesystem
+
+import (
+	"context"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/condition"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditions(t *testing.T) {
+	model := &openfgav1.AuthorizationModel{
+		Id:            "01GXSA8YR785C4FYS3C0RTG7B1",
+		SchemaVersion: SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+		type group
+		  relations
+		    define member: [user] as self
+		type document
+		  relations
+		    define viewer: [user, user:*, group#member] as self
+		`),
+	}
+
+	ipAllowed := &condition.Condition{
+		Name:       "ip_allowed",
+		Expression: "ip == '10.0.0.1'",
+		Parameters: map[string]condition.ParameterType{"ip": condition.ParameterTypeString},
+	}
+
+	tests := []struct {
+		name       string
+		conditions *condition.ModelConditions
+		valid      bool
+	}{
+		{
+			name: "valid",
+			conditions: &condition.ModelConditions{
+				Conditions: []*condition.Condition{ipAllowed},
+				TypeRestrictions: []*condition.TypeRestriction{
+					{Type: "document", Relation: "viewer", UserType: "user", Condition: "ip_allowed"},
+					{Type: "document", Relation: "viewer", UserType: "group#member", Condition: "ip_allowed"},
+				},
+			},
+			valid: true,
+		},
+		{
+			name: "duplicate_condition",
+			conditions: &condition.ModelConditions{
+				Conditions: []*condition.Condition{ipAllowed, {Name: "ip_allowed", Expression: "true"}},
+			},
+		},
+		{
+			name: "invalid_expression",
+			conditions: &condition.ModelConditions{
+				Conditions: []*condition.Condition{{Name: "invalid", Expression: "ip =="}},
+			},
+		},
+		{
+			name: "undefined_condition",
+			conditions: &condition.ModelConditions{
+				TypeRestrictions: []*condition.TypeRestriction{
+					{Type: "document", Relation: "viewer", UserType: "user", Condition: "ip_allowed"},
+				},
+			},
+		},
+		{
+			name: "undefined_relation",
+			conditions: &condition.ModelConditions{
+				Conditions: []*condition.Condition{ipAllowed},
+				TypeRestrictions: []*condition.TypeRestriction{
+					{Type: "document", Relation: "editor", UserType: "user", Condition: "ip_allowed"},
+				},
+			},
+		},
+		{
+			name: "not_a_directly_related_user_type",
+			conditions: &condition.ModelConditions{
+				Conditions: []*condition.Condition{ipAllowed},
+				TypeRestrictions: []*condition.TypeRestriction{
+					{Type: "document", Relation: "viewer", UserType: "group", Condition: "ip_allowed"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewAndValidate(context.Background(), model, WithConditions(test.conditions))
+			if test.valid {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, ErrInvalidCondition)
+			}
+		})
+	}
+
+	t.Run("allowed_conditions", func(t *testing.T) {
+		typesys, err := NewAndValidate(context.Background(), model, WithConditions(tests[0].conditions))
+		require.NoError(t, err)
+
+		require.True(t, typesys.AllowsConditions(tuple.NewTupleKey("document:1", "viewer", "user:anne")))
+		require.True(t, typesys.AllowsConditions(tuple.NewTupleKey("document:1", "viewer", "group:eng#member")))
+		require.False(t, typesys.AllowsConditions(tuple.NewTupleKey("document:1", "viewer", "user:*")))
+		require.False(t, typesys.AllowsConditions(tuple.NewTupleKey("group:eng", "member", "user:anne")))
+
+		require.True(t, typesys.IsConditionAllowed(tuple.NewTupleKey("document:1", "viewer", "user:anne"), "ip_allowed"))
+		require.False(t, typesys.IsConditionAllowed(tuple.NewTupleKey("document:1", "viewer", "user:anne"), "business_hours"))
+
+		_, ok := typesys.GetCondition("ip_allowed")
+		require.True(t, ok)
+	})
+}
@@ -0,0 +1,176 @@
+package typesystem
+
+import (
+	"sort"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/tuple"
+	"google.golang.org/protobuf/proto"
+)
+
+// ChangeKind is the kind of a change between two authorization models.
+type ChangeKind string
+
+const (
+	TypeAdded              ChangeKind = "type_added"
+	TypeRemoved            ChangeKind = "type_removed"
+	RelationAdded          ChangeKind = "relation_added"
+	RelationRemoved        ChangeKind = "relation_removed"
+	RelationRewriteChanged ChangeKind = "relation_rewrite_changed"
+	TypeRestrictionAdded   ChangeKind = "type_restriction_added"
+	TypeRestrictionRemoved ChangeKind = "type_restriction_removed"
+)
+
+// Change is a change between two authorization models (see Diff).
+type Change struct {
+	Kind       ChangeKind `json:"kind"`
+	ObjectType string     `json:"object_type"`
+	Relation   string     `json:"relation,omitempty"`
+
+	// TypeRestriction is the type restriction added or removed, e.g. 'user', 'user:*' or 'group#member'.
+	TypeRestriction string `json:"type_restriction,omitempty"`
+}
+
+// Breaking reports whether the change may invalidate the tuples written with the old model, i.e. whether it
+// removes a type, a relation or a type restriction.
+func (c *Change) Breaking() bool {
+	switch c.Kind {
+	case TypeRemoved, RelationRemoved, TypeRestrictionRemoved:
+		return true
+	default:
+		return false
+	}
+}
+
+// Invalidates reports whether the tuple, valid under the old model, is invalidated by the change.
+func (c *Change) Invalidates(tk *openfgav1.TupleKey) bool {
+	objectType := tuple.GetType(tk.GetObject())
+	userObject, userRelation := tuple.SplitObjectRelation(tk.GetUser())
+	userType := tuple.GetType(userObject)
+
+	switch c.Kind {
+	case TypeRemoved:
+		return objectType == c.ObjectType || userType == c.ObjectType
+	case RelationRemoved:
+		return (objectType == c.ObjectType && tk.GetRelation() == c.Relation) ||
+			(userType == c.ObjectType && userRelation == c.Relation)
+	case TypeRestrictionRemoved:
+		if objectType != c.ObjectType || tk.GetRelation() != c.Relation {
+			return false
+		}
+
+		switch {
+		case tuple.IsTypedWildcard(tk.GetUser()):
+			return c.TypeRestriction == tk.GetUser()
+		case userRelation != "":
+			return c.TypeRestriction == tuple.ToObjectRelationString(userType, userRelation)
+		default:
+			return c.TypeRestriction == userType
+		}
+	default:
+		return false
+	}
+}
+
+// Diff returns the changes from the old authorization model to the new one: the types and the relations added
+// and removed, the relations whose rewrite changed and the type restrictions added to and removed from the
+// relations. The changes are sorted by type, by relation and by kind. The changes within the relations of a
+// type added or removed aren't reported.
+func Diff(oldModel, newModel *openfgav1.AuthorizationModel) []*Change {
+	oldTypes, newTypes := typeDefinitionsByType(oldModel), typeDefinitionsByType(newModel)
+
+	var changes []*Change
+	for objectType, oldTypedef := range oldTypes {
+		newTypedef, ok := newTypes[objectType]
+		if !ok {
+			changes = append(changes, &Change{Kind: TypeRemoved, ObjectType: objectType})
+			continue
+		}
+
+		changes = append(changes, diffRelations(objectType, oldTypedef, newTypedef)...)
+	}
+
+	for objectType := range newTypes {
+		if _, ok := oldTypes[objectType]; !ok {
+			changes = append(changes, &Change{Kind: TypeAdded, ObjectType: objectType})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].ObjectType != changes[j].ObjectType {
+			return changes[i].ObjectType < changes[j].ObjectType
+		}
+		if changes[i].Relation != changes[j].Relation {
+			return changes[i].Relation < changes[j].Relation
+		}
+		if changes[i].Kind != changes[j].Kind {
+			return changes[i].Kind < changes[j].Kind
+		}
+		return changes[i].TypeRestriction < changes[j].TypeRestriction
+	})
+
+	return changes
+}
+
+func typeDefinitionsByType(model *openfgav1.AuthorizationModel) map[string]*openfgav1.TypeDefinition {
+	typedefs := make(map[string]*openfgav1.TypeDefinition, len(model.GetTypeDefinitions()))
+	for _, typedef := range model.GetTypeDefinitions() {
+		typedefs[typedef.GetType()] = typedef
+	}
+
+	return typedefs
+}
+
+// diffRelations returns the changes of the relations of a type in both models.
+func diffRelations(objectType string, oldTypedef, newTypedef *openfgav1.TypeDefinition) []*Change {
+	var changes []*Change
+	for relation, oldRewrite := range oldTypedef.GetRelations() {
+		newRewrite, ok := newTypedef.GetRelations()[relation]
+		if !ok {
+			changes = append(changes, &Change{Kind: RelationRemoved, ObjectType: objectType, Relation: relation})
+			continue
+		}
+
+		if !proto.Equal(oldRewrite, newRewrite) {
+			changes = append(changes, &Change{Kind: RelationRewriteChanged, ObjectType: objectType, Relation: relation})
+		}
+
+		oldRestrictions := typeRestrictions(oldTypedef, relation)
+		newRestrictions := typeRestrictions(newTypedef, relation)
+		for restriction := range oldRestrictions {
+			if _, ok := newRestrictions[restriction]; !ok {
+				changes = append(changes, &Change{Kind: TypeRestrictionRemoved, ObjectType: objectType, Relation: relation, TypeRestriction: restriction})
+			}
+		}
+		for restriction := range newRestrictions {
+			if _, ok := oldRestrictions[restriction]; !ok {
+				changes = append(changes, &Change{Kind: TypeRestrictionAdded, ObjectType: objectType, Relation: relation, TypeRestriction: restriction})
+			}
+		}
+	}
+
+	for relation := range newTypedef.GetRelations() {
+		if _, ok := oldTypedef.GetRelations()[relation]; !ok {
+			changes = append(changes, &Change{Kind: RelationAdded, ObjectType: objectType, Relation: relation})
+		}
+	}
+
+	return changes
+}
+
+// typeRestrictions returns the type restrictions of a relation of a type definition, e.g. 'user:*'.
+func typeRestrictions(typedef *openfgav1.TypeDefinition, relation string) map[string]struct{} {
+	refs := typedef.GetMetadata().GetRelations()[relation].GetDirectlyRelatedUserTypes()
+
+	restrictions := make(map[string]struct{}, len(refs))
+	for _, ref := range refs {
+		restriction := ref.GetType()
+		if ref.GetRelation() != "" || ref.GetWildcard() != nil {
+			restriction = GetRelationReferenceAsString(ref)
+		}
+
+		restrictions[restriction] = struct{}{}
+	}
+
+	return restrictions
+}
@@ -0,0 +1,84 @@
+package typesystem
+
+import (
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	oldModel := &openfgav1.AuthorizationModel{
+		SchemaVersion: SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+		type team
+		  relations
+		    define member: [user] as self
+		type group
+		  relations
+		    define member: [user] as self
+		type document
+		  relations
+		    define editor: [user] as self
+		    define viewer: [user, user:*, group#member] as self or editor
+		`),
+	}
+
+	newModel := &openfgav1.AuthorizationModel{
+		SchemaVersion: SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+		type group
+		  relations
+		    define member: [user] as self
+		    define owner: [user] as self
+		type folder
+		  relations
+		    define viewer: [user] as self
+		type document
+		  relations
+		    define viewer: [user, group#member, group#owner] as self
+		`),
+	}
+
+	require.Equal(t, []*Change{
+		{Kind: RelationRemoved, ObjectType: "document", Relation: "editor"},
+		{Kind: RelationRewriteChanged, ObjectType: "document", Relation: "viewer"},
+		{Kind: TypeRestrictionAdded, ObjectType: "document", Relation: "viewer", TypeRestriction: "group#owner"},
+		{Kind: TypeRestrictionRemoved, ObjectType: "document", Relation: "viewer", TypeRestriction: "user:*"},
+		{Kind: TypeAdded, ObjectType: "folder"},
+		{Kind: RelationAdded, ObjectType: "group", Relation: "owner"},
+		{Kind: TypeRemoved, ObjectType: "team"},
+	}, Diff(oldModel, newModel))
+
+	require.Empty(t, Diff(oldModel, oldModel))
+}
+
+func TestChangeInvalidates(t *testing.T) {
+	tests := []struct {
+		change      *Change
+		tuple       *openfgav1.TupleKey
+		invalidates bool
+	}{
+		{&Change{Kind: TypeRemoved, ObjectType: "team"}, tuple.NewTupleKey("team:1", "member", "user:anne"), true},
+		{&Change{Kind: TypeRemoved, ObjectType: "team"}, tuple.NewTupleKey("document:1", "viewer", "team:1#member"), true},
+		{&Change{Kind: TypeRemoved, ObjectType: "team"}, tuple.NewTupleKey("document:1", "viewer", "user:anne"), false},
+		{&Change{Kind: RelationRemoved, ObjectType: "group", Relation: "member"}, tuple.NewTupleKey("group:1", "member", "user:anne"), true},
+		{&Change{Kind: RelationRemoved, ObjectType: "group", Relation: "member"}, tuple.NewTupleKey("document:1", "viewer", "group:1#member"), true},
+		{&Change{Kind: RelationRemoved, ObjectType: "group", Relation: "member"}, tuple.NewTupleKey("group:1", "owner", "user:anne"), false},
+		{&Change{Kind: TypeRestrictionRemoved, ObjectType: "document", Relation: "viewer", TypeRestriction: "user:*"}, tuple.NewTupleKey("document:1", "viewer", "user:*"), true},
+		{&Change{Kind: TypeRestrictionRemoved, ObjectType: "document", Relation: "viewer", TypeRestriction: "user:*"}, tuple.NewTupleKey("document:1", "viewer", "user:anne"), false},
+		{&Change{Kind: TypeRestrictionRemoved, ObjectType: "document", Relation: "viewer", TypeRestriction: "user"}, tuple.NewTupleKey("document:1", "viewer", "user:anne"), true},
+		{&Change{Kind: TypeRestrictionRemoved, ObjectType: "document", Relation: "viewer", TypeRestriction: "group#member"}, tuple.NewTupleKey("document:1", "viewer", "group:1#member"), true},
+		{&Change{Kind: TypeRestrictionRemoved, ObjectType: "document", Relation: "viewer", TypeRestriction: "group#member"}, tuple.NewTupleKey("document:1", "editor", "group:1#member"), false},
+		{&Change{Kind: TypeAdded, ObjectType: "folder"}, tuple.NewTupleKey("folder:1", "viewer", "user:anne"), false},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.invalidates, test.change.Invalidates(test.tuple), "%+v %s", test.change, tuple.TupleKeyToString(test.tuple))
+		require.Equal(t, test.change.Kind != TypeAdded, test.change.Breaking())
+	}
+}
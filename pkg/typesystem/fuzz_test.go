@@ -0,0 +1,115 @@
+package typesystem
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Crashing inputs found by the fuzzers below are persisted by the Go toolchain under testdata/fuzz
+// and are replayed as regression tests by a regular `go test` run.
+
+var fuzzSeedModels = []string{
+	`type user`,
+	`type user
+
+	type document
+	  relations
+	    define owner: [user] as self
+	    define viewer: [user, user:*, group#member] as self or owner
+
+	type group
+	  relations
+	    define member: [user] as self`,
+	`type user
+
+	type folder
+	  relations
+	    define viewer: [user] as self
+
+	type document
+	  relations
+	    define parent: [folder] as self
+	    define blocked: [user] as self
+	    define editor: [user] as self
+	    define viewer as (viewer from parent or editor) but not blocked
+	    define auditor as editor and viewer`,
+	`type resource
+	  relations
+	    define x as y
+	    define y as x`,
+}
+
+// FuzzNewAndValidate fuzzes the model validation with arbitrary, binary encoded authorization models. Any
+// model (including malformed ones, e.g. with empty usersets or relation references) must either be
+// accepted or rejected with an error, but never cause a panic.
+func FuzzNewAndValidate(f *testing.F) {
+	for _, model := range fuzzSeedModels {
+		bytes, err := proto.Marshal(&openfgav1.AuthorizationModel{
+			SchemaVersion:   SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(model),
+		})
+		if err != nil {
+			f.Fatal(err)
+		}
+
+		f.Add(bytes)
+	}
+
+	f.Fuzz(func(t *testing.T, bytes []byte) {
+		var model openfgav1.AuthorizationModel
+		if err := proto.Unmarshal(bytes, &model); err != nil {
+			t.Skip()
+		}
+
+		_, _ = NewAndValidate(context.Background(), &model)
+	})
+}
+
+// FuzzNewAndValidateDSL fuzzes the relation and type restriction (relation reference) parsing of models
+// expressed in the DSL, and the validation of whatever model results from it.
+func FuzzNewAndValidateDSL(f *testing.F) {
+	for _, model := range fuzzSeedModels {
+		f.Add(model)
+	}
+
+	f.Fuzz(func(t *testing.T, dsl string) {
+		typedefs, err := parseDSL(dsl)
+		if err != nil {
+			t.Skip()
+		}
+
+		typesys, err := NewAndValidate(context.Background(), &openfgav1.AuthorizationModel{
+			SchemaVersion:   SchemaVersion1_1,
+			TypeDefinitions: typedefs,
+		})
+		if err != nil {
+			return
+		}
+
+		for objectType, relations := range typesys.relations {
+			for relation := range relations {
+				_, _ = typesys.GetDirectlyRelatedUserTypes(objectType, relation)
+				_, _ = typesys.RelationInvolvesIntersection(objectType, relation)
+				_, _ = typesys.RelationInvolvesExclusion(objectType, relation)
+				_, _ = typesys.IsTuplesetRelation(objectType, relation)
+			}
+		}
+	})
+}
+
+// parseDSL parses the DSL, turning panics of the parser into errors so that the fuzzer only reports
+// crashes of the model validation.
+func parseDSL(dsl string) (typedefs []*openfgav1.TypeDefinition, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("parser panic: %v", r)
+		}
+	}()
+
+	return parser.Parse(dsl)
+}
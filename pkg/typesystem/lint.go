@@ -0,0 +1,92 @@
+package typesystem
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	// WarningUnusedType is the code of the warnings of the types without relations that no relation can be
+	// related to, which can't be used in any tuple.
+	WarningUnusedType = "unused_type"
+
+	// WarningPublicWildcard is the code of the warnings of the relations that can be related to every object of
+	// a type with a public wildcard (e.g. '[user:*]').
+	WarningPublicWildcard = "public_wildcard"
+
+	// WarningIntersectionOrExclusion is the code of the warnings of the relations defined with an intersection
+	// ('and') or an exclusion ('but not'), which are more expensive to evaluate, in particular with ListObjects.
+	WarningIntersectionOrExclusion = "intersection_or_exclusion"
+)
+
+// Warning is a finding of Lint on a valid model, which doesn't prevent the model from being written.
+type Warning struct {
+	Code       string `json:"code"`
+	ObjectType string `json:"object_type"`
+	Relation   string `json:"relation,omitempty"`
+	Message    string `json:"message"`
+}
+
+// Lint returns the warnings of the model of the TypeSystem, sorted by type and relation. The model must be
+// valid (see NewAndValidate).
+func (t *TypeSystem) Lint() []*Warning {
+	var warnings []*Warning
+
+	referencedTypes := map[string]struct{}{}
+	for _, typedef := range t.GetAllTypeDefinitions() {
+		objectType := typedef.GetType()
+
+		relations := make([]string, 0, len(t.relations[objectType]))
+		for relation := range t.relations[objectType] {
+			relations = append(relations, relation)
+		}
+		sort.Strings(relations)
+
+		for _, relation := range relations {
+			for _, ref := range t.relations[objectType][relation].GetTypeInfo().GetDirectlyRelatedUserTypes() {
+				referencedTypes[ref.GetType()] = struct{}{}
+
+				if ref.GetWildcard() != nil {
+					warnings = append(warnings, &Warning{
+						Code:       WarningPublicWildcard,
+						ObjectType: objectType,
+						Relation:   relation,
+						Message:    fmt.Sprintf("'%s#%s' can be related to every object of type '%s' with '%s'", objectType, relation, ref.GetType(), GetRelationReferenceAsString(ref)),
+					})
+				}
+			}
+
+			if RewriteContainsIntersection(typedef.GetRelations()[relation]) || RewriteContainsExclusion(typedef.GetRelations()[relation]) {
+				warnings = append(warnings, &Warning{
+					Code:       WarningIntersectionOrExclusion,
+					ObjectType: objectType,
+					Relation:   relation,
+					Message:    fmt.Sprintf("'%s#%s' is defined with an intersection or an exclusion, which is more expensive to evaluate", objectType, relation),
+				})
+			}
+		}
+	}
+
+	for _, typedef := range t.GetAllTypeDefinitions() {
+		if len(typedef.GetRelations()) > 0 {
+			continue
+		}
+
+		if _, ok := referencedTypes[typedef.GetType()]; !ok {
+			warnings = append(warnings, &Warning{
+				Code:       WarningUnusedType,
+				ObjectType: typedef.GetType(),
+				Message:    fmt.Sprintf("the type '%s' has no relations and no relation can be related to it", typedef.GetType()),
+			})
+		}
+	}
+
+	sort.SliceStable(warnings, func(i, j int) bool {
+		if warnings[i].ObjectType != warnings[j].ObjectType {
+			return warnings[i].ObjectType < warnings[j].ObjectType
+		}
+		return warnings[i].Relation < warnings[j].Relation
+	})
+
+	return warnings
+}
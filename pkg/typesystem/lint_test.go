@@ -0,0 +1,46 @@
+package typesystem
+
+import (
+	"context"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	typesys, err := NewAndValidate(context.Background(), &openfgav1.AuthorizationModel{
+		SchemaVersion: SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`
+		type user
+		type service
+		type group
+		  relations
+		    define member: [user] as self
+		type document
+		  relations
+		    define blocked: [user] as self
+		    define viewer: [user, user:*, group#member] as self but not blocked
+		`),
+	})
+	require.NoError(t, err)
+
+	warnings := typesys.Lint()
+	require.Len(t, warnings, 3)
+
+	require.Equal(t, &Warning{
+		Code:       WarningPublicWildcard,
+		ObjectType: "document",
+		Relation:   "viewer",
+		Message:    "'document#viewer' can be related to every object of type 'user' with 'user:*'",
+	}, warnings[0])
+	require.Equal(t, WarningIntersectionOrExclusion, warnings[1].Code)
+	require.Equal(t, "document", warnings[1].ObjectType)
+	require.Equal(t, "viewer", warnings[1].Relation)
+	require.Equal(t, &Warning{
+		Code:       WarningUnusedType,
+		ObjectType: "service",
+		Message:    "the type 'service' has no relations and no relation can be related to it",
+	}, warnings[2])
+}
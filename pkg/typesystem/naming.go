@@ -0,0 +1,76 @@
+package typesystem
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var (
+	ErrReservedName = errors.New("the name is reserved by the naming policy")
+	ErrNameTooLong  = errors.New("the name exceeds the maximum length of the naming policy")
+)
+
+// NamingPolicy restricts the names of the types and of the relations of the models, beyond the reserved
+// keywords, e.g. to enforce organization-wide naming rules. It's only enforced when the models are
+// validated, so that the models written before the policy was set can still be used.
+type NamingPolicy struct {
+	// ReservedTypeNames are the patterns that the type names must not match.
+	ReservedTypeNames []*regexp.Regexp
+
+	// ReservedRelationNames are the patterns that the relation names must not match.
+	ReservedRelationNames []*regexp.Regexp
+
+	// MaxTypeNameLength is the maximum length of the type names. 0 means no limit.
+	MaxTypeNameLength int
+
+	// MaxRelationNameLength is the maximum length of the relation names. 0 means no limit.
+	MaxRelationNameLength int
+}
+
+// WithNamingPolicy enforces the provided naming policy when the TypeSystem is validated.
+func WithNamingPolicy(policy *NamingPolicy) TypeSystemOption {
+	return func(t *TypeSystem) {
+		t.namingPolicy = policy
+	}
+}
+
+// validateTypeName returns an error wrapping ErrReservedName or ErrNameTooLong if the type name violates
+// the policy.
+func (p *NamingPolicy) validateTypeName(objectType string) error {
+	if p == nil {
+		return nil
+	}
+
+	if p.MaxTypeNameLength > 0 && len(objectType) > p.MaxTypeNameLength {
+		return fmt.Errorf("%w: the type name '%s' is longer than %d characters", ErrNameTooLong, objectType, p.MaxTypeNameLength)
+	}
+
+	for _, re := range p.ReservedTypeNames {
+		if re.MatchString(objectType) {
+			return fmt.Errorf("%w: the type name '%s' matches the reserved pattern '%s'", ErrReservedName, objectType, re)
+		}
+	}
+
+	return nil
+}
+
+// validateRelationName returns an error wrapping ErrReservedName or ErrNameTooLong if the relation name
+// violates the policy.
+func (p *NamingPolicy) validateRelationName(relation string) error {
+	if p == nil {
+		return nil
+	}
+
+	if p.MaxRelationNameLength > 0 && len(relation) > p.MaxRelationNameLength {
+		return fmt.Errorf("%w: the relation name '%s' is longer than %d characters", ErrNameTooLong, relation, p.MaxRelationNameLength)
+	}
+
+	for _, re := range p.ReservedRelationNames {
+		if re.MatchString(relation) {
+			return fmt.Errorf("%w: the relation name '%s' matches the reserved pattern '%s'", ErrReservedName, relation, re)
+		}
+	}
+
+	return nil
+}
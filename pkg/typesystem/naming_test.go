@@ -0,0 +1,99 @@
+package typesystem
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamingPolicy(t *testing.T) {
+	policy := &NamingPolicy{
+		ReservedTypeNames:     []*regexp.Regexp{regexp.MustCompile(`^internal_`)},
+		ReservedRelationNames: []*regexp.Regexp{regexp.MustCompile(`^can_`), regexp.MustCompile(`[A-Z]`)},
+		MaxTypeNameLength:     12,
+		MaxRelationNameLength: 8,
+	}
+
+	tests := []struct {
+		name          string
+		model         string
+		expectedError error
+		expectedMsg   string
+	}{
+		{
+			name: "valid",
+			model: `
+			type user
+			type document
+			  relations
+			    define viewer: [user] as self
+			`,
+		},
+		{
+			name: "reserved_type_name",
+			model: `
+			type user
+			type internal_doc
+			`,
+			expectedError: ErrReservedName,
+			expectedMsg:   "the name is reserved by the naming policy: the type name 'internal_doc' matches the reserved pattern '^internal_'",
+		},
+		{
+			name: "type_name_too_long",
+			model: `
+			type user
+			type organization_unit
+			`,
+			expectedError: ErrNameTooLong,
+			expectedMsg:   "the name exceeds the maximum length of the naming policy: the type name 'organization_unit' is longer than 12 characters",
+		},
+		{
+			name: "reserved_relation_name",
+			model: `
+			type user
+			type document
+			  relations
+			    define can_view: [user] as self
+			`,
+			expectedError: ErrReservedName,
+			expectedMsg:   "the definition of relation 'can_view' in object type 'document' is invalid: the name is reserved by the naming policy: the relation name 'can_view' matches the reserved pattern '^can_'",
+		},
+		{
+			name: "relation_name_too_long",
+			model: `
+			type user
+			type document
+			  relations
+			    define commenter: [user] as self
+			`,
+			expectedError: ErrNameTooLong,
+			expectedMsg:   "the definition of relation 'commenter' in object type 'document' is invalid: the name exceeds the maximum length of the naming policy: the relation name 'commenter' is longer than 8 characters",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			model := &openfgav1.AuthorizationModel{
+				SchemaVersion:   SchemaVersion1_1,
+				TypeDefinitions: parser.MustParse(test.model),
+			}
+
+			_, err := NewAndValidate(context.Background(), model, WithNamingPolicy(policy))
+			if test.expectedError == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, test.expectedError)
+			require.EqualError(t, err, test.expectedMsg)
+
+			// the policy is only enforced when it's provided
+			_, err = NewAndValidate(context.Background(), model)
+			require.NoError(t, err)
+		})
+	}
+}
@@ -9,12 +9,16 @@ import (
 	"github.com/karlseguin/ccache/v3"
 	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/requestcontext"
 	"github.com/openfga/openfga/pkg/storage"
 	"golang.org/x/sync/singleflight"
 )
 
 const (
 	typesystemCacheTTL = 168 * time.Hour // 7 days
+
+	// typesystemCacheName is the name of the cache reported to the requests that bypass it
+	typesystemCacheName = "typesystem"
 )
 
 // TypesystemResolverFunc is a function that implementations can implement to provide lookup and
@@ -26,8 +30,14 @@ type TypesystemResolverFunc func(ctx context.Context, storeID, modelID string) (
 // the resolved model. The type-system resolution is memoized so if another lookup of the same model occurs,
 // then the earlier TypeSystem that was constructed will be used.
 //
+// The provided TypeSystemOption(s) are applied to every TypeSystem that is constructed. If the datastore is a
+// storage.ConditionsBackend (see storage.As), the conditions of the model are added to its TypeSystem.
+//
+// The memoized TypeSystem isn't used for the requests that bypass the caches (see
+// requestcontext.SetCacheBypassed), and the TypeSystem they construct isn't memoized.
+//
 // The memoized resolver function is safe for concurrent use.
-func MemoizedTypesystemResolverFunc(datastore storage.AuthorizationModelReadBackend) TypesystemResolverFunc {
+func MemoizedTypesystemResolverFunc(datastore storage.AuthorizationModelReadBackend, opts ...TypeSystemOption) TypesystemResolverFunc {
 	lookupGroup := singleflight.Group{}
 
 	cache := ccache.New(ccache.Configure[*TypeSystem]())
@@ -61,9 +71,15 @@ func MemoizedTypesystemResolverFunc(datastore storage.AuthorizationModelReadBack
 
 		key := fmt.Sprintf("%s/%s", storeID, modelID)
 
+		bypassed := requestcontext.CacheBypassed(ctx)
+
 		item := cache.Get(key)
 		if item != nil {
-			return item.Value(), nil
+			if !bypassed {
+				return item.Value(), nil
+			}
+
+			requestcontext.AddBypassedCache(ctx, typesystemCacheName)
 		}
 
 		v, err, _ := lookupGroup.Do(fmt.Sprintf("ReadAuthorizationModel:%s/%s", storeID, modelID), func() (interface{}, error) {
@@ -79,12 +95,26 @@ func MemoizedTypesystemResolverFunc(datastore storage.AuthorizationModelReadBack
 
 		model := v.(*openfgav1.AuthorizationModel)
 
-		typesys, err := NewAndValidate(ctx, model)
+		modelOpts := opts
+		if conditionsBackend, ok := storage.As[storage.ConditionsBackend](datastore); ok {
+			conditions, err := conditionsBackend.ReadAuthorizationModelConditions(ctx, storeID, modelID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to ReadAuthorizationModelConditions: %w", err)
+			}
+
+			if !conditions.IsEmpty() {
+				modelOpts = append(append([]TypeSystemOption{}, opts...), WithConditions(conditions))
+			}
+		}
+
+		typesys, err := NewAndValidate(ctx, model, modelOpts...)
 		if err != nil {
 			return nil, fmt.Errorf("%w: %v", ErrInvalidModel, err)
 		}
 
-		cache.Set(key, typesys, typesystemCacheTTL)
+		if !bypassed {
+			cache.Set(key, typesys, typesystemCacheTTL)
+		}
 
 		return typesys, nil
 	}
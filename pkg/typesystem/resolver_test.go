@@ -11,6 +11,7 @@ import (
 	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	mockstorage "github.com/openfga/openfga/internal/mocks"
+	"github.com/openfga/openfga/pkg/requestcontext"
 	"github.com/stretchr/testify/require"
 )
 
@@ -121,3 +122,44 @@ func TestSingleFlightMemoizedTypesystemResolverFunc(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestMemoizedTypesystemResolverFuncBypassingCache(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+
+	storeID := ulid.Make().String()
+	modelID := ulid.Make().String()
+
+	mockDatastore.EXPECT().
+		ReadAuthorizationModel(gomock.Any(), storeID, modelID).
+		Times(3).
+		Return(&openfgav1.AuthorizationModel{
+			Id:            modelID,
+			SchemaVersion: SchemaVersion1_1,
+			TypeDefinitions: parser.MustParse(`
+			type user
+			`),
+		}, nil)
+
+	resolver := MemoizedTypesystemResolverFunc(mockDatastore)
+
+	// the TypeSystem constructed for a bypassing request isn't memoized
+	ctx := requestcontext.NewContext(context.Background())
+	requestcontext.SetCacheBypassed(ctx)
+
+	_, err := resolver(ctx, storeID, modelID)
+	require.NoError(t, err)
+	require.Empty(t, requestcontext.BypassedCaches(ctx))
+
+	_, err = resolver(context.Background(), storeID, modelID)
+	require.NoError(t, err)
+
+	ctx = requestcontext.NewContext(context.Background())
+	requestcontext.SetCacheBypassed(ctx)
+
+	_, err = resolver(ctx, storeID, modelID)
+	require.NoError(t, err)
+	require.Equal(t, []string{typesystemCacheName}, requestcontext.BypassedCaches(ctx))
+}
@@ -0,0 +1,65 @@
+package typesystem
+
+import (
+	"errors"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+)
+
+var (
+	ErrWildcardDisallowed                = errors.New("public wildcards are disallowed in this deployment")
+	ErrIntersectionOrExclusionDisallowed = errors.New("intersection and exclusion are disallowed in this deployment")
+)
+
+// WithWildcardsDisallowed rejects the models whose relations can be directly related to a public wildcard
+// (e.g. '[user:*]') when the TypeSystem is validated.
+func WithWildcardsDisallowed() TypeSystemOption {
+	return func(t *TypeSystem) {
+		t.wildcardsDisallowed = true
+	}
+}
+
+// WithIntersectionAndExclusionDisallowed rejects the models whose relations are defined with an intersection
+// ('and') or an exclusion ('but not') when the TypeSystem is validated.
+func WithIntersectionAndExclusionDisallowed() TypeSystemOption {
+	return func(t *TypeSystem) {
+		t.intersectionAndExclusionDisallowed = true
+	}
+}
+
+// validateRestrictions ensures that the definition of a relation only uses the features allowed by the
+// options of the TypeSystem. The rewrite must be valid.
+func (t *TypeSystem) validateRestrictions(objectType, relation string, rewrite *openfgav1.Userset) error {
+	if t.wildcardsDisallowed {
+		directlyRelatedTypes, err := t.GetDirectlyRelatedUserTypes(objectType, relation)
+		if err != nil {
+			return err
+		}
+
+		for _, ref := range directlyRelatedTypes {
+			if ref.GetWildcard() != nil {
+				return &InvalidRelationError{ObjectType: objectType, Relation: relation, Cause: ErrWildcardDisallowed}
+			}
+		}
+	}
+
+	if t.intersectionAndExclusionDisallowed {
+		result, err := WalkUsersetRewrite(rewrite, func(r *openfgav1.Userset) interface{} {
+			switch r.GetUserset().(type) {
+			case *openfgav1.Userset_Intersection, *openfgav1.Userset_Difference:
+				return true
+			default:
+				return nil
+			}
+		})
+		if err != nil {
+			return err
+		}
+
+		if result != nil {
+			return &InvalidRelationError{ObjectType: objectType, Relation: relation, Cause: ErrIntersectionOrExclusionDisallowed}
+		}
+	}
+
+	return nil
+}
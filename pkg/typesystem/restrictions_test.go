@@ -0,0 +1,104 @@
+package typesystem
+
+import (
+	"context"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestrictions(t *testing.T) {
+	tests := []struct {
+		name          string
+		model         string
+		opts          []TypeSystemOption
+		expectedError error
+	}{
+		{
+			name: "wildcard_allowed",
+			model: `
+			type user
+			type document
+			  relations
+			    define viewer: [user, user:*] as self
+			`,
+			opts: []TypeSystemOption{WithIntersectionAndExclusionDisallowed()},
+		},
+		{
+			name: "wildcard_disallowed",
+			model: `
+			type user
+			type document
+			  relations
+			    define viewer: [user, user:*] as self
+			`,
+			opts:          []TypeSystemOption{WithWildcardsDisallowed()},
+			expectedError: ErrWildcardDisallowed,
+		},
+		{
+			name: "intersection_allowed",
+			model: `
+			type user
+			type document
+			  relations
+			    define allowed: [user] as self
+			    define viewer: [user] as self and allowed
+			`,
+			opts: []TypeSystemOption{WithWildcardsDisallowed()},
+		},
+		{
+			name: "intersection_disallowed",
+			model: `
+			type user
+			type document
+			  relations
+			    define allowed: [user] as self
+			    define viewer: [user] as self and allowed
+			`,
+			opts:          []TypeSystemOption{WithIntersectionAndExclusionDisallowed()},
+			expectedError: ErrIntersectionOrExclusionDisallowed,
+		},
+		{
+			name: "exclusion_disallowed",
+			model: `
+			type user
+			type document
+			  relations
+			    define blocked: [user] as self
+			    define viewer: [user] as self but not blocked
+			`,
+			opts:          []TypeSystemOption{WithIntersectionAndExclusionDisallowed()},
+			expectedError: ErrIntersectionOrExclusionDisallowed,
+		},
+		{
+			name: "union_allowed",
+			model: `
+			type user
+			type document
+			  relations
+			    define owner: [user] as self
+			    define viewer: [user] as self or owner
+			`,
+			opts: []TypeSystemOption{WithWildcardsDisallowed(), WithIntersectionAndExclusionDisallowed()},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			model := &openfgav1.AuthorizationModel{
+				SchemaVersion:   SchemaVersion1_1,
+				TypeDefinitions: parser.MustParse(test.model),
+			}
+
+			_, err := NewAndValidate(context.Background(), model, test.opts...)
+			if test.expectedError == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, test.expectedError)
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"sort"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/condition"
 	"github.com/openfga/openfga/pkg/tuple"
 	"go.opentelemetry.io/otel"
 )
@@ -151,11 +152,38 @@ type TypeSystem struct {
 	relations     map[string]map[string]*openfgav1.Relation
 	modelID       string
 	schemaVersion string
+
+	materializeComputedRelations bool
+	// [objectType] => [relationName] => terminal relation of a pure computed userset chain
+	computedRelationClosure map[string]map[string]string
+
+	namingPolicy                       *NamingPolicy
+	wildcardsDisallowed                bool
+	intersectionAndExclusionDisallowed bool
+
+	modelConditions *condition.ModelConditions
+	// [conditionName] => condition
+	conditions map[string]*condition.EvaluableCondition
+	// [objectType#relation] => [userType] => names of the conditions allowed
+	conditionedRestrictions map[string]map[string]map[string]struct{}
+}
+
+// TypeSystemOption defines an option that can be used to change the behavior of a TypeSystem.
+type TypeSystemOption func(t *TypeSystem)
+
+// WithComputedRelationClosure enables the precomputation of the closure of purely computed userset
+// chains (e.g. `define a: b`, `define b: c`) when the TypeSystem is constructed. When enabled, the
+// resolution of such a chain jumps straight to the last relation in it instead of resolving each
+// intermediate relation. See ResolveComputedRelation.
+func WithComputedRelationClosure() TypeSystemOption {
+	return func(t *TypeSystem) {
+		t.materializeComputedRelations = true
+	}
 }
 
 // New creates a *TypeSystem from an *openfgav1.AuthorizationModel.
 // It assumes that the input model is valid. If you need to run validations, use NewAndValidate.
-func New(model *openfgav1.AuthorizationModel) *TypeSystem {
+func New(model *openfgav1.AuthorizationModel, opts ...TypeSystemOption) *TypeSystem {
 	tds := make(map[string]*openfgav1.TypeDefinition, len(model.GetTypeDefinitions()))
 	relations := make(map[string]map[string]*openfgav1.Relation, len(model.GetTypeDefinitions()))
 
@@ -181,12 +209,26 @@ func New(model *openfgav1.AuthorizationModel) *TypeSystem {
 		relations[typeName] = tdRelations
 	}
 
-	return &TypeSystem{
+	t := &TypeSystem{
 		modelID:         model.GetId(),
 		schemaVersion:   model.GetSchemaVersion(),
 		typeDefinitions: tds,
 		relations:       relations,
 	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	if t.materializeComputedRelations {
+		t.computedRelationClosure = t.buildComputedRelationClosure()
+	}
+
+	if !t.modelConditions.IsEmpty() {
+		t.buildConditions()
+	}
+
+	return t
 }
 
 // GetAuthorizationModelID returns the id for the authorization model this
@@ -206,6 +248,20 @@ func (t *TypeSystem) GetTypeDefinition(objectType string) (*openfgav1.TypeDefini
 	return nil, false
 }
 
+// GetAllTypeDefinitions returns all the type definitions in the TypeSystem, sorted by type name.
+func (t *TypeSystem) GetAllTypeDefinitions() []*openfgav1.TypeDefinition {
+	typedefs := make([]*openfgav1.TypeDefinition, 0, len(t.typeDefinitions))
+	for _, typedef := range t.typeDefinitions {
+		typedefs = append(typedefs, typedef)
+	}
+
+	sort.Slice(typedefs, func(i, j int) bool {
+		return typedefs[i].GetType() < typedefs[j].GetType()
+	})
+
+	return typedefs
+}
+
 // GetRelations returns all relations in the TypeSystem for a given type
 func (t *TypeSystem) GetRelations(objectType string) (map[string]*openfgav1.Relation, error) {
 	_, ok := t.GetTypeDefinition(objectType)
@@ -614,6 +670,17 @@ func (t *TypeSystem) relationInvolvesExclusion(objectType, relation string, visi
 	return false, nil
 }
 
+// cloneVisitedRelations returns a deep copy of the visited relations, so that the relations visited while
+// walking one branch of a rewrite are not seen as visited by its sibling branches.
+func cloneVisitedRelations(visitedRelations map[string]map[string]struct{}) map[string]map[string]struct{} {
+	v := make(map[string]map[string]struct{}, len(visitedRelations))
+	for typeName, relations := range visitedRelations {
+		v[typeName] = maps.Clone(relations)
+	}
+
+	return v
+}
+
 // hasEntrypoints recursively walks the rewrite definition for the given relation to determine if there is at least
 // one path in the rewrite rule that could relate to at least one concrete object type. If there is no such path that
 // could lead to at least one relationship with some object type, then false is returned along with an error indicating
@@ -625,7 +692,7 @@ func hasEntrypoints(
 	rewrite *openfgav1.Userset,
 	visitedRelations map[string]map[string]struct{},
 ) (bool, bool, error) {
-	v := maps.Clone(visitedRelations)
+	v := cloneVisitedRelations(visitedRelations)
 
 	if val, ok := v[typeName]; ok {
 		val[relationName] = struct{}{}
@@ -640,7 +707,7 @@ func hasEntrypoints(
 		return false, false, fmt.Errorf("undefined type definition for '%s#%s'", typeName, relationName)
 	}
 
-	switch rw := rewrite.Userset.(type) {
+	switch rw := rewrite.GetUserset().(type) {
 	case *openfgav1.Userset_This:
 		for _, assignableType := range relation.GetTypeInfo().GetDirectlyRelatedUserTypes() {
 			if assignableType.GetRelationOrWildcard() == nil || assignableType.GetWildcard() != nil {
@@ -791,11 +858,11 @@ func hasEntrypoints(
 //     a. For a type (e.g. user) this means checking that this type is in the *TypeSystem
 //     b. For a type#relation this means checking that this type with this relation is in the *TypeSystem
 //  4. Check that a relation is assignable if and only if it has a non-zero list of types
-func NewAndValidate(ctx context.Context, model *openfgav1.AuthorizationModel) (*TypeSystem, error) {
+func NewAndValidate(ctx context.Context, model *openfgav1.AuthorizationModel, opts ...TypeSystemOption) (*TypeSystem, error) {
 	_, span := tracer.Start(ctx, "typesystem.NewAndValidate")
 	defer span.End()
 
-	t := New(model)
+	t := New(model, opts...)
 	schemaVersion := t.GetSchemaVersion()
 
 	if !IsSchemaVersionSupported(schemaVersion) {
@@ -840,6 +907,10 @@ func NewAndValidate(ctx context.Context, model *openfgav1.AuthorizationModel) (*
 		}
 	}
 
+	if err := t.validateConditions(); err != nil {
+		return nil, err
+	}
+
 	return t, nil
 }
 
@@ -859,6 +930,11 @@ func (t *TypeSystem) validateRelation(typeName, relationName string, relationMap
 		return err
 	}
 
+	err = t.validateRestrictions(typeName, relationName, rewrite)
+	if err != nil {
+		return err
+	}
+
 	visitedRelations := map[string]map[string]struct{}{}
 
 	hasEntrypoints, loop, err := hasEntrypoints(t.relations, typeName, relationName, rewrite, visitedRelations)
@@ -907,7 +983,7 @@ func containsDuplicateType(model *openfgav1.AuthorizationModel) bool {
 }
 
 // validateNames ensures that a model doesn't have object types or relations
-// called "self" or "this"
+// called "self" or "this", nor ones that violate the naming policy (if any)
 func (t *TypeSystem) validateNames() error {
 	for _, td := range t.typeDefinitions {
 		objectType := td.GetType()
@@ -920,6 +996,10 @@ func (t *TypeSystem) validateNames() error {
 			return &InvalidTypeError{ObjectType: objectType, Cause: ErrReservedKeywords}
 		}
 
+		if err := t.namingPolicy.validateTypeName(objectType); err != nil {
+			return err
+		}
+
 		for relation := range td.GetRelations() {
 			if relation == "" {
 				return fmt.Errorf("type '%s' defines a relation with an empty string for a name", objectType)
@@ -928,6 +1008,10 @@ func (t *TypeSystem) validateNames() error {
 			if relation == "self" || relation == "this" {
 				return &InvalidRelationError{ObjectType: objectType, Relation: relation, Cause: ErrReservedKeywords}
 			}
+
+			if err := t.namingPolicy.validateRelationName(relation); err != nil {
+				return &InvalidRelationError{ObjectType: objectType, Relation: relation, Cause: err}
+			}
 		}
 	}
 
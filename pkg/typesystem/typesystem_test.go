@@ -368,6 +368,20 @@ func TestSuccessfulRewriteValidations(t *testing.T) {
 				SchemaVersion: SchemaVersion1_1,
 			},
 		},
+		{
+			name: "computed_relation_to_intersection_with_repeated_relations",
+			model: &openfgav1.AuthorizationModel{
+				TypeDefinitions: parser.MustParse(`
+				type user
+				type document
+				  relations
+					define editor: [user] as self
+					define viewer as editor and editor
+					define reader as viewer
+				`),
+				SchemaVersion: SchemaVersion1_1,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -2393,3 +2407,89 @@ func TestHasTypeInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveComputedRelation(t *testing.T) {
+	model := `type user
+
+	type document
+	  relations
+	    define owner: [user] as self
+	    define editor as owner
+	    define viewer as editor
+	    define reader as viewer or owner
+	    define commenter as reader`
+
+	tests := []struct {
+		name       string
+		opts       []TypeSystemOption
+		objectType string
+		relation   string
+		expected   string
+	}{
+		{
+			name:       "closure_disabled",
+			objectType: "document",
+			relation:   "viewer",
+			expected:   "viewer",
+		},
+		{
+			name:       "chain_of_two",
+			opts:       []TypeSystemOption{WithComputedRelationClosure()},
+			objectType: "document",
+			relation:   "viewer",
+			expected:   "owner",
+		},
+		{
+			name:       "chain_of_one",
+			opts:       []TypeSystemOption{WithComputedRelationClosure()},
+			objectType: "document",
+			relation:   "editor",
+			expected:   "owner",
+		},
+		{
+			name:       "chain_stops_at_union",
+			opts:       []TypeSystemOption{WithComputedRelationClosure()},
+			objectType: "document",
+			relation:   "commenter",
+			expected:   "reader",
+		},
+		{
+			name:       "not_a_computed_relation",
+			opts:       []TypeSystemOption{WithComputedRelationClosure()},
+			objectType: "document",
+			relation:   "owner",
+			expected:   "owner",
+		},
+		{
+			name:       "undefined_type",
+			opts:       []TypeSystemOption{WithComputedRelationClosure()},
+			objectType: "folder",
+			relation:   "viewer",
+			expected:   "viewer",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			typesys := New(&openfgav1.AuthorizationModel{
+				SchemaVersion:   SchemaVersion1_1,
+				TypeDefinitions: parser.MustParse(model),
+			}, test.opts...)
+
+			require.Equal(t, test.expected, typesys.ResolveComputedRelation(test.objectType, test.relation))
+		})
+	}
+}
+
+func TestComputedRelationClosureIgnoresCycles(t *testing.T) {
+	typesys := New(&openfgav1.AuthorizationModel{
+		SchemaVersion: SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`type resource
+		  relations
+		    define x as y
+		    define y as x`),
+	}, WithComputedRelationClosure())
+
+	require.Equal(t, "x", typesys.ResolveComputedRelation("resource", "x"))
+	require.Equal(t, "y", typesys.ResolveComputedRelation("resource", "y"))
+}
@@ -0,0 +1,398 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const documentsModel = `
+type user
+type group
+  relations
+    define member: [user] as self
+type document
+  relations
+    define owner: [user] as self
+    define editor: [user, group#member] as self or owner
+    define viewer: [user, user:*, group#member] as self or editor
+`
+
+// newStore creates a store with the documents model, and returns the IDs of the store and of the model.
+func newStore(t *testing.T, target *Target) (string, string) {
+	ctx := context.Background()
+
+	createStoreResp, err := target.Client.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "e2e-" + t.Name()})
+	require.NoError(t, err)
+
+	writeModelResp, err := target.Client.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         createStoreResp.GetId(),
+		SchemaVersion:   typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(documentsModel),
+	})
+	require.NoError(t, err)
+
+	return createStoreResp.GetId(), writeModelResp.GetAuthorizationModelId()
+}
+
+func write(t *testing.T, target *Target, store string, tuples ...*openfgav1.TupleKey) {
+	_, err := target.Client.Write(context.Background(), &openfgav1.WriteRequest{
+		StoreId: store,
+		Writes:  &openfgav1.TupleKeys{TupleKeys: tuples},
+	})
+	require.NoError(t, err)
+}
+
+func testStores(t *testing.T, target *Target) {
+	ctx := context.Background()
+
+	createResp, err := target.Client.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "e2e-stores"})
+	require.NoError(t, err)
+	require.Equal(t, "e2e-stores", createResp.GetName())
+
+	getResp, err := target.Client.GetStore(ctx, &openfgav1.GetStoreRequest{StoreId: createResp.GetId()})
+	require.NoError(t, err)
+	require.Equal(t, createResp.GetId(), getResp.GetId())
+	require.Equal(t, createResp.GetName(), getResp.GetName())
+
+	var found bool
+	var continuationToken string
+	for {
+		listResp, err := target.Client.ListStores(ctx, &openfgav1.ListStoresRequest{
+			PageSize:          wrapperspb.Int32(50),
+			ContinuationToken: continuationToken,
+		})
+		require.NoError(t, err)
+
+		for _, store := range listResp.GetStores() {
+			found = found || store.GetId() == createResp.GetId()
+		}
+
+		continuationToken = listResp.GetContinuationToken()
+		if found || continuationToken == "" {
+			break
+		}
+	}
+	require.True(t, found)
+
+	_, err = target.Client.DeleteStore(ctx, &openfgav1.DeleteStoreRequest{StoreId: createResp.GetId()})
+	require.NoError(t, err)
+
+	_, err = target.Client.GetStore(ctx, &openfgav1.GetStoreRequest{StoreId: createResp.GetId()})
+	require.Equal(t, codes.Code(openfgav1.NotFoundErrorCode_store_id_not_found), status.Code(err))
+}
+
+func testModels(t *testing.T, target *Target) {
+	ctx := context.Background()
+	store, modelID := newStore(t, target)
+
+	readResp, err := target.Client.ReadAuthorizationModel(ctx, &openfgav1.ReadAuthorizationModelRequest{
+		StoreId: store,
+		Id:      modelID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, modelID, readResp.GetAuthorizationModel().GetId())
+	require.Len(t, readResp.GetAuthorizationModel().GetTypeDefinitions(), 3)
+
+	secondResp, err := target.Client.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         store,
+		SchemaVersion:   typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(`type user`),
+	})
+	require.NoError(t, err)
+
+	// the models are listed from the latest
+	readModelsResp, err := target.Client.ReadAuthorizationModels(ctx, &openfgav1.ReadAuthorizationModelsRequest{StoreId: store})
+	require.NoError(t, err)
+	require.Len(t, readModelsResp.GetAuthorizationModels(), 2)
+	require.Equal(t, secondResp.GetAuthorizationModelId(), readModelsResp.GetAuthorizationModels()[0].GetId())
+	require.Equal(t, modelID, readModelsResp.GetAuthorizationModels()[1].GetId())
+
+	_, err = target.Client.ReadAuthorizationModel(ctx, &openfgav1.ReadAuthorizationModelRequest{
+		StoreId: store,
+		Id:      ulid.Make().String(),
+	})
+	require.Equal(t, codes.Code(openfgav1.ErrorCode_authorization_model_not_found), status.Code(err))
+}
+
+func testWrites(t *testing.T, target *Target) {
+	ctx := context.Background()
+	store, _ := newStore(t, target)
+
+	write(t, target, store,
+		tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:roadmap", "editor", "group:eng#member"),
+	)
+
+	readResp, err := target.Client.Read(ctx, &openfgav1.ReadRequest{
+		StoreId:  store,
+		TupleKey: tuple.NewTupleKey("document:roadmap", "", ""),
+	})
+	require.NoError(t, err)
+	require.Len(t, readResp.GetTuples(), 2)
+
+	_, err = target.Client.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: store,
+		Deletes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+		}},
+	})
+	require.NoError(t, err)
+
+	readResp, err = target.Client.Read(ctx, &openfgav1.ReadRequest{
+		StoreId:  store,
+		TupleKey: tuple.NewTupleKey("document:roadmap", "", ""),
+	})
+	require.NoError(t, err)
+	require.Len(t, readResp.GetTuples(), 1)
+
+	changesResp, err := target.Client.ReadChanges(ctx, &openfgav1.ReadChangesRequest{StoreId: store})
+	require.NoError(t, err)
+	require.Len(t, changesResp.GetChanges(), 3)
+	require.Equal(t, openfgav1.TupleOperation_TUPLE_OPERATION_DELETE, changesResp.GetChanges()[2].GetOperation())
+
+	// a tuple can't be written twice
+	_, err = target.Client.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: store,
+		Writes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:roadmap", "editor", "group:eng#member"),
+		}},
+	})
+	require.Equal(t, codes.Code(openfgav1.ErrorCode_write_failed_due_to_invalid_input), status.Code(err))
+}
+
+func testChecks(t *testing.T, target *Target) {
+	ctx := context.Background()
+	store, modelID := newStore(t, target)
+
+	write(t, target, store,
+		tuple.NewTupleKey("group:eng", "member", "user:anne"),
+		tuple.NewTupleKey("document:roadmap", "editor", "group:eng#member"),
+		tuple.NewTupleKey("document:handbook", "viewer", "user:*"),
+	)
+
+	tests := []struct {
+		name             string
+		tupleKey         *openfgav1.TupleKey
+		contextualTuples []*openfgav1.TupleKey
+		allowed          bool
+	}{
+		{
+			name:     "through_a_group",
+			tupleKey: tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+			allowed:  true,
+		},
+		{
+			name:     "not_related",
+			tupleKey: tuple.NewTupleKey("document:roadmap", "viewer", "user:bob"),
+		},
+		{
+			name:     "through_a_wildcard",
+			tupleKey: tuple.NewTupleKey("document:handbook", "viewer", "user:bob"),
+			allowed:  true,
+		},
+		{
+			name:             "through_a_contextual_tuple",
+			tupleKey:         tuple.NewTupleKey("document:roadmap", "viewer", "user:bob"),
+			contextualTuples: []*openfgav1.TupleKey{tuple.NewTupleKey("group:eng", "member", "user:bob")},
+			allowed:          true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := target.Client.Check(ctx, &openfgav1.CheckRequest{
+				StoreId:              store,
+				AuthorizationModelId: modelID,
+				TupleKey:             test.tupleKey,
+				ContextualTuples:     &openfgav1.ContextualTupleKeys{TupleKeys: test.contextualTuples},
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.allowed, resp.GetAllowed())
+		})
+	}
+
+	listObjectsResp, err := target.Client.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+		StoreId:  store,
+		Type:     "document",
+		Relation: "viewer",
+		User:     "user:anne",
+	})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"document:roadmap", "document:handbook"}, listObjectsResp.GetObjects())
+}
+
+func testPagination(t *testing.T, target *Target) {
+	ctx := context.Background()
+	store, _ := newStore(t, target)
+
+	const tuples = 7
+
+	var written []string
+	for i := 0; i < tuples; i++ {
+		tk := tuple.NewTupleKey("document:roadmap", "viewer", "user:"+ulid.Make().String())
+		write(t, target, store, tk)
+		written = append(written, tuple.TupleKeyToString(tk))
+	}
+
+	var read []string
+	var continuationToken string
+	for pages := 1; ; pages++ {
+		require.LessOrEqual(t, pages, tuples, "too many pages")
+
+		resp, err := target.Client.Read(ctx, &openfgav1.ReadRequest{
+			StoreId:           store,
+			PageSize:          wrapperspb.Int32(2),
+			ContinuationToken: continuationToken,
+		})
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(resp.GetTuples()), 2)
+
+		for _, tp := range resp.GetTuples() {
+			read = append(read, tuple.TupleKeyToString(tp.GetKey()))
+		}
+
+		continuationToken = resp.GetContinuationToken()
+		if continuationToken == "" {
+			break
+		}
+	}
+	require.ElementsMatch(t, written, read)
+
+	var changes int
+	continuationToken = ""
+	for pages := 1; ; pages++ {
+		require.LessOrEqual(t, pages, tuples+1, "too many pages")
+
+		resp, err := target.Client.ReadChanges(ctx, &openfgav1.ReadChangesRequest{
+			StoreId:           store,
+			PageSize:          wrapperspb.Int32(3),
+			ContinuationToken: continuationToken,
+		})
+		require.NoError(t, err)
+
+		if len(resp.GetChanges()) == 0 {
+			// the token of the last page is returned once there are no more changes, to poll for the next ones
+			break
+		}
+		changes += len(resp.GetChanges())
+		continuationToken = resp.GetContinuationToken()
+	}
+	require.Equal(t, tuples, changes)
+
+	_, err := target.Client.Read(ctx, &openfgav1.ReadRequest{StoreId: store, ContinuationToken: "invalid"})
+	require.Equal(t, codes.Code(openfgav1.ErrorCode_invalid_continuation_token), status.Code(err))
+}
+
+func testErrors(t *testing.T, target *Target) {
+	ctx := context.Background()
+	store, modelID := newStore(t, target)
+
+	tests := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{
+			name: "invalid_store_id",
+			call: func() error {
+				_, err := target.Client.GetStore(ctx, &openfgav1.GetStoreRequest{StoreId: "invalid"})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "undefined_type",
+			call: func() error {
+				_, err := target.Client.Write(ctx, &openfgav1.WriteRequest{
+					StoreId: store,
+					Writes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{
+						tuple.NewTupleKey("folder:1", "viewer", "user:anne"),
+					}},
+				})
+				return err
+			},
+			code: codes.Code(openfgav1.ErrorCode_validation_error),
+		},
+		{
+			name: "undefined_relation",
+			call: func() error {
+				_, err := target.Client.Check(ctx, &openfgav1.CheckRequest{
+					StoreId:              store,
+					AuthorizationModelId: modelID,
+					TupleKey:             tuple.NewTupleKey("document:1", "admin", "user:anne"),
+				})
+				return err
+			},
+			code: codes.Code(openfgav1.ErrorCode_validation_error),
+		},
+		{
+			name: "no_model",
+			call: func() error {
+				createResp, err := target.Client.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "e2e-no-model"})
+				require.NoError(t, err)
+
+				_, err = target.Client.Check(ctx, &openfgav1.CheckRequest{
+					StoreId:  createResp.GetId(),
+					TupleKey: tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+				})
+				return err
+			},
+			code: codes.Code(openfgav1.ErrorCode_latest_authorization_model_not_found),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.code, status.Code(test.call()))
+		})
+	}
+}
+
+func testHTTP(t *testing.T, target *Target) {
+	createStoreResp := &openfgav1.CreateStoreResponse{}
+	require.Equal(t, http.StatusCreated, target.Do(t, http.MethodPost, "/stores", &openfgav1.CreateStoreRequest{Name: "e2e-http"}, createStoreResp))
+	store := createStoreResp.GetId()
+
+	writeModelResp := &openfgav1.WriteAuthorizationModelResponse{}
+	require.Equal(t, http.StatusCreated, target.Do(t, http.MethodPost, "/stores/"+store+"/authorization-models", &openfgav1.WriteAuthorizationModelRequest{
+		SchemaVersion:   typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(documentsModel),
+	}, writeModelResp))
+
+	require.Equal(t, http.StatusOK, target.Do(t, http.MethodPost, "/stores/"+store+"/write", &openfgav1.WriteRequest{
+		Writes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+		}},
+	}, &openfgav1.WriteResponse{}))
+
+	checkResp := &openfgav1.CheckResponse{}
+	require.Equal(t, http.StatusOK, target.Do(t, http.MethodPost, "/stores/"+store+"/check", &openfgav1.CheckRequest{
+		AuthorizationModelId: writeModelResp.GetAuthorizationModelId(),
+		TupleKey:             tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+	}, checkResp))
+	require.True(t, checkResp.GetAllowed())
+
+	errResp := &serverErrors.ErrorResponse{}
+	require.Equal(t, http.StatusBadRequest, target.Do(t, http.MethodPost, "/stores/"+store+"/check", &openfgav1.CheckRequest{
+		TupleKey: tuple.NewTupleKey("document:roadmap", "admin", "user:anne"),
+	}, errResp))
+	require.Equal(t, openfgav1.ErrorCode_validation_error.String(), errResp.Code)
+
+	require.Equal(t, http.StatusNoContent, target.Do(t, http.MethodDelete, "/stores/"+store, nil, nil))
+
+	errResp = &serverErrors.ErrorResponse{}
+	require.Equal(t, http.StatusNotFound, target.Do(t, http.MethodGet, "/stores/"+store, nil, errResp))
+	require.Equal(t, openfgav1.NotFoundErrorCode_store_id_not_found.String(), errResp.Code)
+}
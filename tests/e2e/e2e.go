@@ -0,0 +1,206 @@
+// Package e2e contains the end-to-end tests of the API. They run against a full server, with its gRPC and HTTP
+// servers, started by the tests with a datastore provisioned in a container, or against an external deployment
+// (see ExternalTarget) to validate a release.
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/cmd/run"
+	"github.com/openfga/openfga/tests"
+	"github.com/openfga/openfga/tests/check"
+	"github.com/openfga/openfga/tests/listobjects"
+	"github.com/openfga/openfga/tests/writemodel"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// GRPCAddrEnv is the environment variable of the gRPC address (e.g. 'openfga.example.com:8081') of the
+	// external deployment the tests run against.
+	GRPCAddrEnv = "OPENFGA_E2E_GRPC_ADDR"
+
+	// HTTPAddrEnv is the environment variable of the HTTP URL (e.g. 'http://openfga.example.com:8080') of the
+	// external deployment the tests run against.
+	HTTPAddrEnv = "OPENFGA_E2E_HTTP_URL"
+
+	// APITokenEnv is the environment variable of the preshared key the requests to the external deployment are
+	// authenticated with, if any.
+	APITokenEnv = "OPENFGA_E2E_API_TOKEN"
+)
+
+// Target is a deployment the tests run against, through its gRPC and HTTP APIs.
+type Target struct {
+	Client openfgav1.OpenFGAServiceClient
+
+	// HTTPURL is the base URL of the HTTP API, e.g. 'http://localhost:8080'.
+	HTTPURL string
+
+	// apiToken authenticates the requests, if it's set
+	apiToken string
+}
+
+// StartTarget starts a server with the datastore engine, which is provisioned in a container unless it's
+// 'memory', and returns it as a Target. The server is stopped at the end of the test.
+func StartTarget(t *testing.T, engine string) *Target {
+	cfg := run.MustDefaultConfigWithRandomPorts()
+	cfg.Log.Level = "none"
+	cfg.Datastore.Engine = engine
+
+	cancel := tests.StartServer(t, cfg)
+	t.Cleanup(cancel)
+
+	target := &Target{HTTPURL: "http://" + cfg.HTTP.Addr}
+	target.dial(t, cfg.GRPC.Addr)
+	target.waitForHTTP(t)
+
+	return target
+}
+
+// ExternalTarget returns the external deployment of the GRPCAddrEnv and HTTPAddrEnv environment variables as a
+// Target, or skips the test if they aren't set.
+func ExternalTarget(t *testing.T) *Target {
+	grpcAddr, httpURL := os.Getenv(GRPCAddrEnv), os.Getenv(HTTPAddrEnv)
+	if grpcAddr == "" || httpURL == "" {
+		t.Skipf("the %s and %s environment variables aren't set", GRPCAddrEnv, HTTPAddrEnv)
+	}
+
+	target := &Target{HTTPURL: httpURL, apiToken: os.Getenv(APITokenEnv)}
+	target.dial(t, grpcAddr)
+	target.waitForHTTP(t)
+
+	return target
+}
+
+func (target *Target) dial(t *testing.T, addr string) {
+	opts := []grpc.DialOption{
+		grpc.WithBlock(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+	if target.apiToken != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(tokenCredentials(target.apiToken)))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, addr, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	target.Client = openfgav1.NewOpenFGAServiceClient(conn)
+}
+
+// waitForHTTP waits for the HTTP API to be served, which it may be after the gRPC API.
+func (target *Target) waitForHTTP(t *testing.T) {
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(target.HTTPURL + "/healthz")
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+
+		return resp.StatusCode == http.StatusOK
+	}, 30*time.Second, 100*time.Millisecond)
+}
+
+// Do sends a request with the JSON encoding of the body, if it's not nil, to the path of the HTTP API, and
+// decodes the JSON body of the response into out, if it's not nil. The messages are encoded and decoded with
+// protojson. It returns the status code of the response.
+func (target *Target) Do(t *testing.T, method, path string, body, out interface{}) int {
+	resp, encoded := target.send(t, method, path, nil, body)
+
+	if out != nil {
+		decode(t, method, path, encoded, out)
+	}
+
+	return resp.StatusCode
+}
+
+// send sends a request with the headers and the JSON encoding of the body, if it's not nil, to the path of the
+// HTTP API, and returns the response along with its body, which is already read.
+func (target *Target) send(t *testing.T, method, path string, header http.Header, body interface{}) (*http.Response, []byte) {
+	var reader io.Reader
+	if body != nil {
+		var encoded []byte
+		var err error
+		if message, ok := body.(proto.Message); ok {
+			encoded, err = protojson.Marshal(message)
+		} else {
+			encoded, err = json.Marshal(body)
+		}
+		require.NoError(t, err)
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, target.HTTPURL+path, reader)
+	require.NoError(t, err)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if target.apiToken != "" {
+		req.Header.Set("Authorization", "Bearer "+target.apiToken)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	encoded, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp, encoded
+}
+
+// decode decodes the JSON body of the response to the request into out, with protojson if it's a message.
+func decode(t *testing.T, method, path string, encoded []byte, out interface{}) {
+	var err error
+	if message, ok := out.(proto.Message); ok {
+		err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(encoded, message)
+	} else {
+		err = json.Unmarshal(encoded, out)
+	}
+	require.NoError(t, err, fmt.Sprintf("%s %s: %s", method, path, encoded))
+}
+
+// RunAllTests runs the API test matrix against the target: the stores, the models, the writes, the checks, the
+// pagination and the errors of the API, through gRPC and HTTP, the parity of the two interfaces, along with the Check, ListObjects and
+// WriteAuthorizationModel test suites. Every test uses its own stores, so the target may serve other traffic.
+func RunAllTests(t *testing.T, target *Target) {
+	t.Run("Stores", func(t *testing.T) { testStores(t, target) })
+	t.Run("Models", func(t *testing.T) { testModels(t, target) })
+	t.Run("Writes", func(t *testing.T) { testWrites(t, target) })
+	t.Run("Checks", func(t *testing.T) { testChecks(t, target) })
+	t.Run("Pagination", func(t *testing.T) { testPagination(t, target) })
+	t.Run("Errors", func(t *testing.T) { testErrors(t, target) })
+	t.Run("HTTP", func(t *testing.T) { testHTTP(t, target) })
+	t.Run("Parity", func(t *testing.T) { testParity(t, target) })
+
+	check.RunAllTests(t, target.Client)
+	listobjects.RunAllTests(t, target.Client)
+	writemodel.RunAllTests(t, target.Client)
+}
+
+// tokenCredentials authenticates the gRPC requests with a preshared key.
+type tokenCredentials string
+
+func (c tokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(c)}, nil
+}
+
+func (c tokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
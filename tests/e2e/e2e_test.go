@@ -0,0 +1,25 @@
+package e2e
+
+import (
+	"testing"
+)
+
+func TestE2EMemory(t *testing.T) {
+	RunAllTests(t, StartTarget(t, "memory"))
+}
+
+func TestE2EPostgres(t *testing.T) {
+	RunAllTests(t, StartTarget(t, "postgres"))
+}
+
+func TestE2EMySQL(t *testing.T) {
+	RunAllTests(t, StartTarget(t, "mysql"))
+}
+
+// TestE2EExternal runs the tests against the external deployment of the environment variables, e.g. to validate
+// a release:
+//
+//	OPENFGA_E2E_GRPC_ADDR=localhost:8081 OPENFGA_E2E_HTTP_URL=http://localhost:8080 go test ./tests/e2e -run TestE2EExternal
+func TestE2EExternal(t *testing.T) {
+	RunAllTests(t, ExternalTarget(t))
+}
@@ -0,0 +1,560 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	"github.com/google/go-cmp/cmp"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/middleware/consistency"
+	"github.com/openfga/openfga/pkg/server"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// parityFixture is the store the RPCs of a parityCase are sent to.
+type parityFixture struct {
+	store   string
+	modelID string
+}
+
+// parityCase is an RPC sent through the gRPC interface and through the HTTP gateway, which must return the same
+// response, or fail with the same error.
+type parityCase struct {
+	name string
+
+	// mutates is whether the RPC changes the store, in which case it's sent to a new store through each interface.
+	mutates bool
+
+	// fails is whether the RPC fails, with the same error through both interfaces.
+	fails bool
+
+	// header is the header of the request, which is sent as the gRPC metadata or as the HTTP header.
+	header map[string]string
+
+	// grpc sends the RPC through gRPC.
+	grpc func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error)
+
+	// http returns the request of the RPC through the HTTP gateway, and the message its response decodes into.
+	http func(f parityFixture) (method, path string, body, response proto.Message)
+
+	// status is the HTTP status of the successful response, http.StatusOK if it's 0.
+	status int
+
+	// ignore are the fields of the response that differ between two calls, e.g. the ID of the store created.
+	ignore cmp.Option
+}
+
+// testParity asserts that the RPCs return the same responses, the same errors and the same headers through the
+// gRPC interface and through the HTTP gateway, so that the translation bugs of the gateway are caught.
+func testParity(t *testing.T, target *Target) {
+	const model = `
+type user
+type document
+  relations
+    define viewer: [user] as self`
+
+	contextualTuples, err := protojson.Marshal(&openfgav1.ContextualTupleKeys{TupleKeys: []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:budget", "viewer", "user:beth"),
+	}})
+	require.NoError(t, err)
+
+	setup := func(t *testing.T) parityFixture {
+		store, modelID := newStore(t, target)
+		write(t, target, store,
+			tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+			tuple.NewTupleKey("document:roadmap", "editor", "group:eng#member"),
+			tuple.NewTupleKey("document:handbook", "viewer", "user:*"),
+			tuple.NewTupleKey("group:eng", "member", "user:beth"),
+		)
+
+		_, err := target.Client.WriteAssertions(context.Background(), &openfgav1.WriteAssertionsRequest{
+			StoreId:              store,
+			AuthorizationModelId: modelID,
+			Assertions: []*openfgav1.Assertion{
+				{TupleKey: tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"), Expectation: true},
+			},
+		})
+		require.NoError(t, err)
+
+		return parityFixture{store: store, modelID: modelID}
+	}
+
+	tests := []parityCase{
+		{
+			name:    "create_store",
+			mutates: true,
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "e2e-parity"}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodPost, "/stores", &openfgav1.CreateStoreRequest{Name: "e2e-parity"}, &openfgav1.CreateStoreResponse{}
+			},
+			status: http.StatusCreated,
+			ignore: protocmp.IgnoreFields(&openfgav1.CreateStoreResponse{}, "id", "created_at", "updated_at"),
+		},
+		{
+			name: "get_store",
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.GetStore(ctx, &openfgav1.GetStoreRequest{StoreId: f.store}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodGet, "/stores/" + f.store, nil, &openfgav1.GetStoreResponse{}
+			},
+		},
+		{
+			name:  "get_missing_store",
+			fails: true,
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.GetStore(ctx, &openfgav1.GetStoreRequest{StoreId: missingStoreID}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodGet, "/stores/" + missingStoreID, nil, &openfgav1.GetStoreResponse{}
+			},
+		},
+		{
+			name:  "list_stores_with_invalid_continuation_token",
+			fails: true,
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.ListStores(ctx, &openfgav1.ListStoresRequest{ContinuationToken: "invalid"}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodGet, "/stores?continuation_token=invalid", nil, &openfgav1.ListStoresResponse{}
+			},
+		},
+		{
+			name:    "delete_store",
+			mutates: true,
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.DeleteStore(ctx, &openfgav1.DeleteStoreRequest{StoreId: f.store}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodDelete, "/stores/" + f.store, nil, &openfgav1.DeleteStoreResponse{}
+			},
+			status: http.StatusNoContent,
+		},
+		{
+			name:    "write_authorization_model",
+			mutates: true,
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+					StoreId:         f.store,
+					SchemaVersion:   typesystem.SchemaVersion1_1,
+					TypeDefinitions: parser.MustParse(model),
+				}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodPost, "/stores/" + f.store + "/authorization-models", &openfgav1.WriteAuthorizationModelRequest{
+					SchemaVersion:   typesystem.SchemaVersion1_1,
+					TypeDefinitions: parser.MustParse(model),
+				}, &openfgav1.WriteAuthorizationModelResponse{}
+			},
+			status: http.StatusCreated,
+			ignore: protocmp.IgnoreFields(&openfgav1.WriteAuthorizationModelResponse{}, "authorization_model_id"),
+		},
+		{
+			name:    "write_invalid_authorization_model",
+			mutates: true,
+			fails:   true,
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+					StoreId:         f.store,
+					SchemaVersion:   typesystem.SchemaVersion1_1,
+					TypeDefinitions: parser.MustParse(model + " or owner"),
+				}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodPost, "/stores/" + f.store + "/authorization-models", &openfgav1.WriteAuthorizationModelRequest{
+					SchemaVersion:   typesystem.SchemaVersion1_1,
+					TypeDefinitions: parser.MustParse(model + " or owner"),
+				}, &openfgav1.WriteAuthorizationModelResponse{}
+			},
+		},
+		{
+			name: "read_authorization_model",
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.ReadAuthorizationModel(ctx, &openfgav1.ReadAuthorizationModelRequest{StoreId: f.store, Id: f.modelID}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodGet, "/stores/" + f.store + "/authorization-models/" + f.modelID, nil, &openfgav1.ReadAuthorizationModelResponse{}
+			},
+		},
+		{
+			name:  "read_missing_authorization_model",
+			fails: true,
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.ReadAuthorizationModel(ctx, &openfgav1.ReadAuthorizationModelRequest{StoreId: f.store, Id: missingModelID}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodGet, "/stores/" + f.store + "/authorization-models/" + missingModelID, nil, &openfgav1.ReadAuthorizationModelResponse{}
+			},
+		},
+		{
+			name: "read_authorization_models",
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.ReadAuthorizationModels(ctx, &openfgav1.ReadAuthorizationModelsRequest{StoreId: f.store, PageSize: wrapperspb.Int32(1)}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodGet, "/stores/" + f.store + "/authorization-models?page_size=1", nil, &openfgav1.ReadAuthorizationModelsResponse{}
+			},
+		},
+		{
+			name:    "write",
+			mutates: true,
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.Write(ctx, &openfgav1.WriteRequest{
+					StoreId: f.store,
+					Writes:  &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:budget", "owner", "user:anne")}},
+					Deletes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:roadmap", "viewer", "user:anne")}},
+				}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodPost, "/stores/" + f.store + "/write", &openfgav1.WriteRequest{
+					Writes:  &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:budget", "owner", "user:anne")}},
+					Deletes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:roadmap", "viewer", "user:anne")}},
+				}, &openfgav1.WriteResponse{}
+			},
+		},
+		{
+			name:    "write_existing_tuple",
+			mutates: true,
+			fails:   true,
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.Write(ctx, &openfgav1.WriteRequest{
+					StoreId: f.store,
+					Writes:  &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:roadmap", "viewer", "user:anne")}},
+				}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodPost, "/stores/" + f.store + "/write", &openfgav1.WriteRequest{
+					Writes: &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:roadmap", "viewer", "user:anne")}},
+				}, &openfgav1.WriteResponse{}
+			},
+		},
+		{
+			name: "read",
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.Read(ctx, &openfgav1.ReadRequest{StoreId: f.store, TupleKey: tuple.NewTupleKey("document:roadmap", "", "")}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodPost, "/stores/" + f.store + "/read", &openfgav1.ReadRequest{
+					TupleKey: tuple.NewTupleKey("document:roadmap", "", ""),
+				}, &openfgav1.ReadResponse{}
+			},
+		},
+		{
+			name:   "read_with_contextual_tuples_header",
+			header: map[string]string{server.ContextualTuplesHeader: string(contextualTuples)},
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.Read(ctx, &openfgav1.ReadRequest{StoreId: f.store, TupleKey: tuple.NewTupleKey("document:budget", "", "")}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodPost, "/stores/" + f.store + "/read", &openfgav1.ReadRequest{
+					TupleKey: tuple.NewTupleKey("document:budget", "", ""),
+				}, &openfgav1.ReadResponse{}
+			},
+		},
+		{
+			name:   "read_with_invalid_consistency_header",
+			header: map[string]string{consistency.ConsistencyHeader: "invalid"},
+			fails:  true,
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.Read(ctx, &openfgav1.ReadRequest{StoreId: f.store}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodPost, "/stores/" + f.store + "/read", &openfgav1.ReadRequest{}, &openfgav1.ReadResponse{}
+			},
+		},
+		{
+			name: "read_changes",
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.ReadChanges(ctx, &openfgav1.ReadChangesRequest{StoreId: f.store, Type: "document"}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodGet, "/stores/" + f.store + "/changes?type=document", nil, &openfgav1.ReadChangesResponse{}
+			},
+		},
+		{
+			name:  "read_changes_with_invalid_continuation_token",
+			fails: true,
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.ReadChanges(ctx, &openfgav1.ReadChangesRequest{StoreId: f.store, ContinuationToken: "invalid"}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodGet, "/stores/" + f.store + "/changes?continuation_token=invalid", nil, &openfgav1.ReadChangesResponse{}
+			},
+		},
+		{
+			name: "check",
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.Check(ctx, &openfgav1.CheckRequest{
+					StoreId:              f.store,
+					AuthorizationModelId: f.modelID,
+					TupleKey:             tuple.NewTupleKey("document:roadmap", "viewer", "user:beth"),
+				}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodPost, "/stores/" + f.store + "/check", &openfgav1.CheckRequest{
+					AuthorizationModelId: f.modelID,
+					TupleKey:             tuple.NewTupleKey("document:roadmap", "viewer", "user:beth"),
+				}, &openfgav1.CheckResponse{}
+			},
+		},
+		{
+			name: "check_with_contextual_tuples",
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.Check(ctx, &openfgav1.CheckRequest{
+					StoreId:  f.store,
+					TupleKey: tuple.NewTupleKey("document:budget", "viewer", "user:beth"),
+					ContextualTuples: &openfgav1.ContextualTupleKeys{TupleKeys: []*openfgav1.TupleKey{
+						tuple.NewTupleKey("document:budget", "owner", "user:beth"),
+					}},
+				}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodPost, "/stores/" + f.store + "/check", &openfgav1.CheckRequest{
+					TupleKey: tuple.NewTupleKey("document:budget", "viewer", "user:beth"),
+					ContextualTuples: &openfgav1.ContextualTupleKeys{TupleKeys: []*openfgav1.TupleKey{
+						tuple.NewTupleKey("document:budget", "owner", "user:beth"),
+					}},
+				}, &openfgav1.CheckResponse{}
+			},
+		},
+		{
+			name:  "check_with_undefined_relation",
+			fails: true,
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.Check(ctx, &openfgav1.CheckRequest{
+					StoreId:  f.store,
+					TupleKey: tuple.NewTupleKey("document:roadmap", "admin", "user:anne"),
+				}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodPost, "/stores/" + f.store + "/check", &openfgav1.CheckRequest{
+					TupleKey: tuple.NewTupleKey("document:roadmap", "admin", "user:anne"),
+				}, &openfgav1.CheckResponse{}
+			},
+		},
+		{
+			name:  "check_of_missing_store",
+			fails: true,
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.Check(ctx, &openfgav1.CheckRequest{
+					StoreId:  missingStoreID,
+					TupleKey: tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+				}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodPost, "/stores/" + missingStoreID + "/check", &openfgav1.CheckRequest{
+					TupleKey: tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+				}, &openfgav1.CheckResponse{}
+			},
+		},
+		{
+			name: "expand",
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.Expand(ctx, &openfgav1.ExpandRequest{
+					StoreId:              f.store,
+					AuthorizationModelId: f.modelID,
+					TupleKey:             tuple.NewTupleKey("document:roadmap", "viewer", ""),
+				}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodPost, "/stores/" + f.store + "/expand", &openfgav1.ExpandRequest{
+					AuthorizationModelId: f.modelID,
+					TupleKey:             tuple.NewTupleKey("document:roadmap", "viewer", ""),
+				}, &openfgav1.ExpandResponse{}
+			},
+		},
+		{
+			name: "list_objects",
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+					StoreId:  f.store,
+					Type:     "document",
+					Relation: "viewer",
+					User:     "user:beth",
+				}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodPost, "/stores/" + f.store + "/list-objects", &openfgav1.ListObjectsRequest{
+					Type:     "document",
+					Relation: "viewer",
+					User:     "user:beth",
+				}, &openfgav1.ListObjectsResponse{}
+			},
+			ignore: protocmp.SortRepeatedFields(&openfgav1.ListObjectsResponse{}, "objects"),
+		},
+		{
+			name:    "write_assertions",
+			mutates: true,
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.WriteAssertions(ctx, &openfgav1.WriteAssertionsRequest{
+					StoreId:              f.store,
+					AuthorizationModelId: f.modelID,
+					Assertions: []*openfgav1.Assertion{
+						{TupleKey: tuple.NewTupleKey("document:roadmap", "viewer", "user:beth"), Expectation: true},
+					},
+				}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodPut, "/stores/" + f.store + "/assertions/" + f.modelID, &openfgav1.WriteAssertionsRequest{
+					Assertions: []*openfgav1.Assertion{
+						{TupleKey: tuple.NewTupleKey("document:roadmap", "viewer", "user:beth"), Expectation: true},
+					},
+				}, &openfgav1.WriteAssertionsResponse{}
+			},
+			status: http.StatusNoContent,
+		},
+		{
+			name: "read_assertions",
+			grpc: func(ctx context.Context, client openfgav1.OpenFGAServiceClient, f parityFixture, opts ...grpc.CallOption) (proto.Message, error) {
+				return client.ReadAssertions(ctx, &openfgav1.ReadAssertionsRequest{StoreId: f.store, AuthorizationModelId: f.modelID}, opts...)
+			},
+			http: func(f parityFixture) (string, string, proto.Message, proto.Message) {
+				return http.MethodGet, "/stores/" + f.store + "/assertions/" + f.modelID, nil, &openfgav1.ReadAssertionsResponse{}
+			},
+		},
+	}
+
+	shared := setup(t)
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			grpcFixture, httpFixture := shared, shared
+			if test.mutates {
+				grpcFixture, httpFixture = setup(t), setup(t)
+			}
+
+			ctx := context.Background()
+			for key, value := range test.header {
+				ctx = metadata.AppendToOutgoingContext(ctx, key, value)
+			}
+			grpcResp, grpcErr := test.grpc(ctx, target.Client, grpcFixture)
+
+			method, path, body, httpResp := test.http(httpFixture)
+			header := http.Header{}
+			for key, value := range test.header {
+				header.Set(key, value)
+			}
+			resp, encoded := target.send(t, method, path, header, body)
+
+			require.Equal(t, test.fails, grpcErr != nil, "unexpected gRPC error: %v", grpcErr)
+			if grpcErr != nil {
+				requireSameError(t, grpcErr, resp.StatusCode, encoded)
+				return
+			}
+
+			expectedStatus := test.status
+			if expectedStatus == 0 {
+				expectedStatus = http.StatusOK
+			}
+			require.Equal(t, expectedStatus, resp.StatusCode, string(encoded))
+
+			if len(encoded) > 0 {
+				decode(t, method, path, encoded, httpResp)
+			}
+
+			opts := []cmp.Option{protocmp.Transform()}
+			if test.ignore != nil {
+				opts = append(opts, test.ignore)
+			}
+			if diff := cmp.Diff(grpcResp, httpResp, opts...); diff != "" {
+				require.FailNow(t, fmt.Sprintf("the gRPC and HTTP responses differ (-grpc +http):\n%s", diff))
+			}
+		})
+	}
+
+	t.Run("response_headers", func(t *testing.T) {
+		var md metadata.MD
+		_, err := target.Client.Check(context.Background(), &openfgav1.CheckRequest{
+			StoreId:  shared.store,
+			TupleKey: tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+		}, grpc.Header(&md))
+		require.NoError(t, err)
+		require.Equal(t, []string{shared.modelID}, md.Get(server.AuthorizationModelIDHeader))
+
+		resp, _ := target.send(t, http.MethodPost, "/stores/"+shared.store+"/check", nil, &openfgav1.CheckRequest{
+			TupleKey: tuple.NewTupleKey("document:roadmap", "viewer", "user:anne"),
+		})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, shared.modelID, resp.Header.Get(server.AuthorizationModelIDHeader))
+	})
+
+	t.Run("pagination_tokens", func(t *testing.T) {
+		// the pages read through gRPC only, and through both interfaces in turn with the tokens of the other
+		grpcPages := readPages(t, target, shared.store, func(int) bool { return true })
+		mixedPages := readPages(t, target, shared.store, func(page int) bool { return page%2 == 0 })
+
+		require.Equal(t, grpcPages, mixedPages)
+		require.Greater(t, len(grpcPages), 1)
+	})
+}
+
+const (
+	missingStoreID = "01HAZTKW3M3QTYNMW79TVEAY6J"
+	missingModelID = "01HAZTM3N6XSBXWJ7VAXZ3Q9QH"
+)
+
+// requireSameError asserts that the HTTP response is the error the gateway maps the gRPC error to.
+func requireSameError(t *testing.T, grpcErr error, httpStatus int, encoded []byte) {
+	expected := serverErrors.NewEncodedError(serverErrors.ConvertToEncodedErrorCode(status.Convert(grpcErr)), grpcErr.Error())
+	require.Equal(t, expected.HTTPStatus(), httpStatus, string(encoded))
+
+	errResp := &serverErrors.ErrorResponse{}
+	require.NoError(t, json.Unmarshal(encoded, errResp), string(encoded))
+	require.Equal(t, expected.Code(), errResp.Code)
+	require.Equal(t, status.Convert(grpcErr).Message(), errResp.Message)
+}
+
+// readPages reads the tuples of the store two at a time, each page through gRPC if viaGRPC returns true for its
+// number and through HTTP otherwise, and returns the tuples and the continuation token of every page.
+func readPages(t *testing.T, target *Target, store string, viaGRPC func(page int) bool) []string {
+	var pages []string
+	var continuationToken string
+	for page := 0; ; page++ {
+		require.Less(t, page, 10, "too many pages")
+
+		resp := &openfgav1.ReadResponse{}
+		if viaGRPC(page) {
+			var err error
+			resp, err = target.Client.Read(context.Background(), &openfgav1.ReadRequest{
+				StoreId:           store,
+				PageSize:          wrapperspb.Int32(2),
+				ContinuationToken: continuationToken,
+			})
+			require.NoError(t, err)
+		} else {
+			path := "/stores/" + store + "/read"
+			require.Equal(t, http.StatusOK, target.Do(t, http.MethodPost, path, &openfgav1.ReadRequest{
+				PageSize:          wrapperspb.Int32(2),
+				ContinuationToken: continuationToken,
+			}, resp))
+		}
+
+		var keys []string
+		for _, tp := range resp.GetTuples() {
+			keys = append(keys, tuple.TupleKeyToString(tp.GetKey()))
+		}
+		pages = append(pages, fmt.Sprintf("%v %s", keys, resp.GetContinuationToken()))
+
+		continuationToken = resp.GetContinuationToken()
+		if continuationToken == "" {
+			return pages
+		}
+	}
+}
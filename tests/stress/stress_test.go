@@ -0,0 +1,257 @@
+//go:build stress
+// +build stress
+
+// Package stress contains a stress-test harness that drives concurrent Check, Write and ReadChanges
+// calls against a server backed by the memory datastore. It is intended to be run with the race
+// detector enabled (see `make stress-test`) in order to flush out data races at the query layer.
+package stress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	parser "github.com/craigpastro/openfga-dsl-parser/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/openfga/openfga/pkg/server"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	numModels      = 5
+	numWorkers     = 8
+	numObjects     = 20
+	numUsers       = 20
+	stressDuration = 5 * time.Second
+)
+
+func TestConcurrentCheckWriteReadChanges(t *testing.T) {
+	seed := time.Now().UnixNano()
+	t.Logf("random seed: %d", seed)
+
+	rng := rand.New(rand.NewSource(seed))
+
+	for i := 0; i < numModels; i++ {
+		model, relations := randomModel(rng)
+		experimentals := []server.ExperimentalFeatureFlag{}
+		if rng.Intn(2) == 0 {
+			experimentals = append(experimentals, server.ExperimentalComputedRelationClosure)
+		}
+
+		t.Run(fmt.Sprintf("model_%d", i), func(t *testing.T) {
+			runStress(t, rng.Int63(), model, relations, experimentals)
+		})
+	}
+}
+
+func runStress(t *testing.T, seed int64, model string, relations []string, experimentals []server.ExperimentalFeatureFlag) {
+	t.Logf("model:\n%s", model)
+
+	ds := memory.New()
+	defer ds.Close()
+
+	s := server.MustNewServerWithOpts(
+		server.WithDatastore(ds),
+		server.WithExperimentals(experimentals...),
+		server.WithCheckQueryCacheEnabled(true),
+	)
+
+	ctx := context.Background()
+
+	createStoreResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "stress"})
+	require.NoError(t, err)
+	storeID := createStoreResp.GetId()
+
+	writeModelResp, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   typesystem.SchemaVersion1_1,
+		TypeDefinitions: parser.MustParse(model),
+	})
+	require.NoError(t, err)
+	modelID := writeModelResp.GetAuthorizationModelId()
+
+	ctx, cancel := context.WithTimeout(ctx, stressDuration)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, numWorkers*3)
+
+	for i := 0; i < numWorkers; i++ {
+		workerRng := rand.New(rand.NewSource(seed + int64(i)))
+
+		wg.Add(3)
+		go func(rng *rand.Rand) {
+			defer wg.Done()
+			errCh <- checkWorker(ctx, rng, s, storeID, modelID, relations)
+		}(rand.New(rand.NewSource(workerRng.Int63())))
+
+		go func(rng *rand.Rand) {
+			defer wg.Done()
+			errCh <- writeWorker(ctx, rng, s, storeID, modelID)
+		}(rand.New(rand.NewSource(workerRng.Int63())))
+
+		go func() {
+			defer wg.Done()
+			errCh <- readChangesWorker(ctx, s, storeID)
+		}()
+	}
+
+	wg.Wait()
+	close(errCh)
+
+	for err := range errCh {
+		require.NoError(t, err)
+	}
+}
+
+func checkWorker(ctx context.Context, rng *rand.Rand, s *server.Server, storeID, modelID string, relations []string) error {
+	for ctx.Err() == nil {
+		_, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:              storeID,
+			AuthorizationModelId: modelID,
+			TupleKey: tuple.NewTupleKey(
+				randomObject(rng, "document"),
+				relations[rng.Intn(len(relations))],
+				randomUser(rng),
+			),
+		})
+		if err != nil && !isExpectedError(ctx, err) {
+			return fmt.Errorf("check: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func writeWorker(ctx context.Context, rng *rand.Rand, s *server.Server, storeID, modelID string) error {
+	for ctx.Err() == nil {
+		var tk *openfgav1.TupleKey
+		if rng.Intn(2) == 0 {
+			tk = tuple.NewTupleKey(randomObject(rng, "document"), "owner", randomUser(rng))
+		} else {
+			tk = tuple.NewTupleKey(randomObject(rng, "document"), "parent", randomObject(rng, "folder"))
+		}
+
+		req := &openfgav1.WriteRequest{
+			StoreId:              storeID,
+			AuthorizationModelId: modelID,
+		}
+		if rng.Intn(3) == 0 {
+			req.Deletes = &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{tk}}
+		} else {
+			req.Writes = &openfgav1.TupleKeys{TupleKeys: []*openfgav1.TupleKey{tk}}
+		}
+
+		_, err := s.Write(ctx, req)
+		if err != nil && !isExpectedError(ctx, err) {
+			return fmt.Errorf("write: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func readChangesWorker(ctx context.Context, s *server.Server, storeID string) error {
+	var continuationToken string
+	for ctx.Err() == nil {
+		resp, err := s.ReadChanges(ctx, &openfgav1.ReadChangesRequest{
+			StoreId:           storeID,
+			PageSize:          wrapperspb.Int32(10),
+			ContinuationToken: continuationToken,
+		})
+		if err != nil {
+			if isExpectedError(ctx, err) {
+				continue
+			}
+			return fmt.Errorf("read changes: %w", err)
+		}
+
+		continuationToken = resp.GetContinuationToken()
+	}
+
+	return nil
+}
+
+// isExpectedError returns true for errors that are a legitimate outcome of the randomized workload,
+// such as writing a tuple that already exists or deleting one that doesn't.
+func isExpectedError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+
+	if errors.Is(err, serverErrors.RequestCancelled) {
+		return true
+	}
+
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.Code(openfgav1.ErrorCode_write_failed_due_to_invalid_input),
+			codes.Code(openfgav1.ErrorCode_authorization_model_resolution_too_complex),
+			codes.DeadlineExceeded,
+			codes.Canceled:
+			return true
+		}
+	}
+
+	return false
+}
+
+func randomObject(rng *rand.Rand, objectType string) string {
+	return fmt.Sprintf("%s:%d", objectType, rng.Intn(numObjects))
+}
+
+func randomUser(rng *rand.Rand) string {
+	return fmt.Sprintf("user:%d", rng.Intn(numUsers))
+}
+
+// randomModel returns a random, valid model along with the relations defined on the 'document' type.
+// Every model has a directly assignable 'owner' relation and a 'parent' tupleset relation, and then a
+// random number of relations that are rewritten in terms of the relations defined before them.
+func randomModel(rng *rand.Rand) (string, []string) {
+	var sb strings.Builder
+	sb.WriteString("type user\n\n")
+	sb.WriteString("type folder\n  relations\n    define viewer: [user] as self\n\n")
+	sb.WriteString("type document\n  relations\n")
+	sb.WriteString("    define owner: [user] as self\n")
+	sb.WriteString("    define parent: [folder] as self\n")
+
+	relations := []string{"owner"}
+
+	numRelations := 2 + rng.Intn(6)
+	for i := 0; i < numRelations; i++ {
+		name := fmt.Sprintf("r%d", i)
+		a := relations[rng.Intn(len(relations))]
+		b := relations[rng.Intn(len(relations))]
+
+		var rewrite string
+		switch rng.Intn(5) {
+		case 0:
+			rewrite = a
+		case 1:
+			rewrite = fmt.Sprintf("%s or %s", a, b)
+		case 2:
+			rewrite = fmt.Sprintf("%s and %s", a, b)
+		case 3:
+			rewrite = fmt.Sprintf("%s but not %s", a, b)
+		default:
+			rewrite = fmt.Sprintf("%s or viewer from parent", a)
+		}
+
+		sb.WriteString(fmt.Sprintf("    define %s as %s\n", name, rewrite))
+		relations = append(relations, name)
+	}
+
+	return sb.String(), relations
+}